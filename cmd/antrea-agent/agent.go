@@ -17,8 +17,11 @@ package main
 import (
 	"fmt"
 	"net"
+	"strconv"
+	"sync"
 	"time"
 
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/informers"
 	"k8s.io/klog/v2"
 
@@ -27,38 +30,55 @@ import (
 	"antrea.io/antrea/pkg/agent/cniserver"
 	_ "antrea.io/antrea/pkg/agent/cniserver/ipam"
 	"antrea.io/antrea/pkg/agent/config"
+	"antrea.io/antrea/pkg/agent/configwatcher"
+	"antrea.io/antrea/pkg/agent/controller/dhcp"
 	"antrea.io/antrea/pkg/agent/controller/egress"
 	"antrea.io/antrea/pkg/agent/controller/networkpolicy"
 	"antrea.io/antrea/pkg/agent/controller/noderoute"
+	"antrea.io/antrea/pkg/agent/controller/packetcapture"
 	"antrea.io/antrea/pkg/agent/controller/traceflow"
 	"antrea.io/antrea/pkg/agent/flowexporter/connections"
 	"antrea.io/antrea/pkg/agent/flowexporter/exporter"
 	"antrea.io/antrea/pkg/agent/flowexporter/flowrecords"
 	"antrea.io/antrea/pkg/agent/interfacestore"
+	"antrea.io/antrea/pkg/agent/memoryguard"
 	"antrea.io/antrea/pkg/agent/metrics"
+	"antrea.io/antrea/pkg/agent/nodelatency"
 	npl "antrea.io/antrea/pkg/agent/nodeportlocal"
 	"antrea.io/antrea/pkg/agent/openflow"
+	"antrea.io/antrea/pkg/agent/otelexporter"
 	"antrea.io/antrea/pkg/agent/proxy"
 	"antrea.io/antrea/pkg/agent/querier"
 	"antrea.io/antrea/pkg/agent/route"
+	"antrea.io/antrea/pkg/agent/servicecidr"
 	"antrea.io/antrea/pkg/agent/stats"
 	"antrea.io/antrea/pkg/agent/types"
+	"antrea.io/antrea/pkg/apis/controlplane/v1beta2"
 	crdinformers "antrea.io/antrea/pkg/client/informers/externalversions"
+	agentconfig "antrea.io/antrea/pkg/config/agent"
 	"antrea.io/antrea/pkg/features"
 	"antrea.io/antrea/pkg/log"
 	"antrea.io/antrea/pkg/monitor"
+	"antrea.io/antrea/pkg/monitor/condition"
 	ofconfig "antrea.io/antrea/pkg/ovs/openflow"
 	"antrea.io/antrea/pkg/ovs/ovsconfig"
+	antreaquerier "antrea.io/antrea/pkg/querier"
 	"antrea.io/antrea/pkg/signals"
 	"antrea.io/antrea/pkg/util/cipher"
 	"antrea.io/antrea/pkg/util/k8s"
 	"antrea.io/antrea/pkg/version"
 )
 
-// informerDefaultResync is the default resync period if a handler doesn't specify one.
-// Use the same default value as kube-controller-manager:
-// https://github.com/kubernetes/kubernetes/blob/release-1.17/pkg/controller/apis/config/v1alpha1/defaults.go#L120
-const informerDefaultResync = 12 * time.Hour
+const (
+	// informerDefaultResync is the default resync period if a handler doesn't specify one.
+	// Use the same default value as kube-controller-manager:
+	// https://github.com/kubernetes/kubernetes/blob/release-1.17/pkg/controller/apis/config/v1alpha1/defaults.go#L120
+	informerDefaultResync = 12 * time.Hour
+
+	// shutdownTimeout is the maximum time to wait for the components to stop after receiving
+	// the termination signal.
+	shutdownTimeout = 10 * time.Second
+)
 
 // run starts Antrea agent with the given options and waits for termination signal.
 func run(o *Options) error {
@@ -76,12 +96,13 @@ func run(o *Options) error {
 	informerFactory := informers.NewSharedInformerFactory(k8sClient, informerDefaultResync)
 	crdInformerFactory := crdinformers.NewSharedInformerFactory(crdClient, informerDefaultResync)
 	traceflowInformer := crdInformerFactory.Crd().V1alpha1().Traceflows()
+	packetCaptureInformer := crdInformerFactory.Crd().V1alpha1().PacketCaptures()
 	egressInformer := crdInformerFactory.Crd().V1alpha2().Egresses()
 	nodeInformer := informerFactory.Core().V1().Nodes()
 	externalIPPoolInformer := crdInformerFactory.Crd().V1alpha2().ExternalIPPools()
 
 	// Create Antrea Clientset for the given config.
-	antreaClientProvider := agent.NewAntreaClientProvider(o.config.AntreaClientConnection, k8sClient)
+	antreaClientProvider := agent.NewAntreaClientProvider(o.config.AntreaClientConnection, o.config.EnableControlplaneCompression, k8sClient)
 
 	// Register Antrea Agent metrics if EnablePrometheusMetrics is set
 	if o.config.EnablePrometheusMetrics {
@@ -105,6 +126,14 @@ func run(o *Options) error {
 		features.DefaultFeatureGate.Enabled(features.AntreaPolicy),
 		features.DefaultFeatureGate.Enabled(features.Egress),
 		features.DefaultFeatureGate.Enabled(features.FlowExporter))
+	if o.flowChangeTrackingSize > 0 {
+		ofClient.EnableFlowChangeTracking(o.flowChangeTrackingSize)
+	}
+	k8sIsolationLogging := o.config.K8sIsolationLogging
+	ofClient.EnableK8sIsolationLogging(k8sIsolationLogging.Ingress, k8sIsolationLogging.Egress)
+	ofClient.ConfigureNDGuard(o.config.NDGuard.Enable, o.config.NDGuard.EnableLogging)
+	ofClient.ConfigureServiceLoopGuard(o.config.ServiceLoopGuard.EnableLogging)
+	ofClient.ConfigureConnectionProbe(o.openflowProbeInterval, o.config.OVSConnectionProbe.FailureThreshold)
 
 	_, serviceCIDRNet, _ := net.ParseCIDR(o.config.ServiceCIDR)
 	var serviceCIDRNetv6 *net.IPNet
@@ -115,9 +144,39 @@ func run(o *Options) error {
 
 	_, encapMode := config.GetTrafficEncapModeFromStr(o.config.TrafficEncapMode)
 	networkConfig := &config.NetworkConfig{
-		TunnelType:        ovsconfig.TunnelType(o.config.TunnelType),
-		TrafficEncapMode:  encapMode,
-		EnableIPSecTunnel: o.config.EnableIPSecTunnel}
+		TunnelType:           ovsconfig.TunnelType(o.config.TunnelType),
+		TunnelPort:           int32(o.config.TunnelPort),
+		TrafficEncapMode:     encapMode,
+		EnableIPSecTunnel:    o.config.EnableIPSecTunnel,
+		AdvertiseServiceCIDR: o.config.AdvertiseServiceCIDR,
+		EnableTCPMSSClamping: o.config.TCPMSSClamping,
+		TCPMSS:               o.config.TCPMSS}
+	// The tunnel options have been validated by Options.validate. The DSCP value is the 6 most
+	// significant bits of the TOS byte.
+	if o.config.TunnelDSCP == agentconfig.TunnelDSCPInherit {
+		networkConfig.TunnelTOS = agentconfig.TunnelDSCPInherit
+	} else if o.config.TunnelDSCP != "" {
+		dscp, _ := strconv.Atoi(o.config.TunnelDSCP)
+		networkConfig.TunnelTOS = strconv.Itoa(dscp << 2)
+	}
+	// The gateway options have been validated by Options.validate.
+	if o.config.GatewayMAC != "" {
+		networkConfig.GatewayMAC, _ = net.ParseMAC(o.config.GatewayMAC)
+	}
+	if o.config.GatewayIPv4 != "" {
+		networkConfig.GatewayIPv4 = net.ParseIP(o.config.GatewayIPv4)
+	}
+	if o.config.GatewayIPv6 != "" {
+		networkConfig.GatewayIPv6 = net.ParseIP(o.config.GatewayIPv6)
+	}
+	// The tunnel profiles have been validated by Options.validate.
+	for _, profile := range o.config.TunnelProfiles {
+		networkConfig.TunnelProfiles = append(networkConfig.TunnelProfiles, config.TunnelProfile{
+			Name:         profile.Name,
+			NodeSelector: labels.SelectorFromSet(profile.NodeSelector),
+			Options:      profile.Options,
+		})
+	}
 
 	routeClient, err := route.NewClient(serviceCIDRNet, networkConfig, o.config.NoSNAT)
 	if err != nil {
@@ -134,6 +193,10 @@ func run(o *Options) error {
 	// cause the stopCh channel to be closed; if another signal is received before the program
 	// exits, we will force exit.
 	stopCh := signals.RegisterSignalHandlers()
+	if o.ovsdbProbeInterval > 0 {
+		ovsdbKeepalive := ovsconfig.NewOVSDBKeepalive(ovsdbConnection, o.ovsdbProbeInterval, o.config.OVSConnectionProbe.FailureThreshold)
+		go ovsdbKeepalive.Run(stopCh)
+	}
 	// Initialize agent and node network.
 	agentInitializer := agent.NewInitializer(
 		k8sClient,
@@ -155,6 +218,36 @@ func run(o *Options) error {
 		return fmt.Errorf("error initializing agent: %v", err)
 	}
 	nodeConfig := agentInitializer.GetNodeConfig()
+	// Check the rules depending on the Node, e.g. the Service CIDRs must not overlap with the PodCIDRs.
+	nodeInfo := &agentconfig.NodeInfo{}
+	for _, podCIDR := range []*net.IPNet{nodeConfig.PodIPv4CIDR, nodeConfig.PodIPv6CIDR} {
+		if podCIDR != nil {
+			nodeInfo.PodCIDRs = append(nodeInfo.PodCIDRs, podCIDR)
+		}
+	}
+	if err := agentconfig.Validate(o.config, nodeInfo); err != nil {
+		return fmt.Errorf("invalid configuration for Node %s: %v", nodeConfig.Name, err)
+	}
+
+	policyBootstrapFailClosed := o.config.PolicyBootstrapMode == policyBootstrapModeFailClosed
+	if policyBootstrapFailClosed {
+		// The flows must be installed before the flows of the Pods are restored by the CNIServer, and are
+		// uninstalled by the NetworkPolicyController once the NetworkPolicies are enforced.
+		// The allowlist has been validated by Options.validate.
+		var allowlist []types.PolicyBootstrapPeer
+		for _, peer := range o.config.PolicyBootstrapAllowlist {
+			_, ipNet, _ := net.ParseCIDR(peer.CIDR)
+			allowlist = append(allowlist, types.PolicyBootstrapPeer{
+				IPNet:    *ipNet,
+				Protocol: v1beta2.Protocol(peer.Protocol),
+				Port:     uint16(peer.Port),
+			})
+		}
+		if err := ofClient.InstallPolicyBootstrapFlows(allowlist); err != nil {
+			return fmt.Errorf("error installing policy bootstrap flows: %v", err)
+		}
+		klog.Info("Installed the policy bootstrap flows, new connections of local Pods are dropped until NetworkPolicies are enforced")
+	}
 
 	nodeRouteController := noderoute.NewNodeRouteController(
 		k8sClient,
@@ -193,16 +286,41 @@ func run(o *Options) error {
 	asyncRuleDeleteInterval := o.pollInterval
 	antreaPolicyEnabled := features.DefaultFeatureGate.Enabled(features.AntreaPolicy)
 	// In Antrea agent, status manager and audit logging will automatically be enabled
-	// if AntreaPolicy feature is enabled.
+	// if AntreaPolicy feature is enabled. Audit logging is enabled as well if the packets
+	// dropped by K8s NetworkPolicy isolation are logged.
 	statusManagerEnabled := antreaPolicyEnabled
-	loggingEnabled := antreaPolicyEnabled
+	loggingEnabled := antreaPolicyEnabled || k8sIsolationLogging.Ingress || k8sIsolationLogging.Egress
 
 	var denyConnStore *connections.DenyConnectionStore
 	if features.DefaultFeatureGate.Enabled(features.FlowExporter) {
 		denyConnStore = connections.NewDenyConnectionStore(ifaceStore, proxier)
 	}
+	// otelExporter exports the audit logs and the flow records to an OpenTelemetry collector.
+	var otelExporter *otelexporter.Exporter
+	var auditLogExporter networkpolicy.AuditLogExporter
+	if features.DefaultFeatureGate.Enabled(features.OTelExporter) {
+		exporterConfig := o.config.OTelExporter
+		otelExporter, err = otelexporter.NewExporter(otelexporter.Options{
+			Endpoint:      exporterConfig.Endpoint,
+			Insecure:      exporterConfig.Insecure,
+			CACertPath:    exporterConfig.CACertPath,
+			ServerName:    exporterConfig.ServerName,
+			Headers:       exporterConfig.Headers,
+			QueueSize:     exporterConfig.QueueSize,
+			BatchSize:     exporterConfig.BatchSize,
+			FlushInterval: o.otelExporterFlushInterval,
+			NodeName:      nodeConfig.Name,
+		})
+		if err != nil {
+			return fmt.Errorf("error creating OpenTelemetry exporter: %v", err)
+		}
+		if exporterConfig.ExportAuditLogs {
+			auditLogExporter = otelExporter
+		}
+	}
 	networkPolicyController, err := networkpolicy.NewNetworkPolicyController(
 		antreaClientProvider,
+		k8sClient,
 		ofClient,
 		ifaceStore,
 		nodeConfig.Name,
@@ -210,12 +328,30 @@ func run(o *Options) error {
 		antreaPolicyEnabled,
 		statusManagerEnabled,
 		loggingEnabled,
+		o.config.AuditLogging.Destination == agentconfig.AuditLogDestinationEventLog,
+		o.config.AuditLogging.LogDir,
+		o.config.AuditLogging.Format == agentconfig.AuditLogFormatJSON,
+		o.auditLogAggregationWindow,
+		auditLogExporter,
 		denyConnStore,
-		asyncRuleDeleteInterval)
+		o.config.UDPRejectMode == udpRejectModePortUnreachable,
+		asyncRuleDeleteInterval,
+		policyBootstrapFailClosed,
+		o.config.NetworkPolicyWorkers)
 	if err != nil {
 		return fmt.Errorf("error creating new NetworkPolicy controller: %v", err)
 	}
 
+	// configWatcher reloads the configuration file when it changes, and applies the changes of the
+	// options which can be changed without restarting the agent with the hooks of the components
+	// using them.
+	configWatcher := configwatcher.NewWatcher(o.configFile, o.config, o.configHash, o.reloadConfig)
+	if loggingEnabled {
+		configWatcher.AddHook("auditLogging", []string{"auditLogging.destination", "auditLogging.logDir"}, func(c *agentconfig.AgentConfig) error {
+			return networkPolicyController.ReconfigureAuditLogging(c.AuditLogging.Destination == agentconfig.AuditLogDestinationEventLog, c.AuditLogging.LogDir)
+		})
+	}
+
 	// statsCollector collects stats and reports to the antrea-controller periodically. For now it's only used for
 	// NetworkPolicy stats.
 	var statsCollector *stats.Collector
@@ -244,7 +380,12 @@ func run(o *Options) error {
 		nodeConfig,
 		k8sClient,
 		isChaining,
+		cniserver.InterfaceDiscovery{
+			Strategy: cniserver.InterfaceDiscoveryStrategy(o.config.PolicyOnlyInterfaceDiscovery.Strategy),
+			Prefix:   o.config.PolicyOnlyInterfaceDiscovery.Prefix,
+		},
 		routeClient,
+		uint32(o.config.PodConnectionLimit.DefaultLimit),
 		networkReadyCh)
 	err = cniServer.Initialize(ovsBridgeClient, ofClient, ifaceStore, entityUpdates)
 	if err != nil {
@@ -267,6 +408,48 @@ func run(o *Options) error {
 			serviceCIDRNet)
 	}
 
+	var packetCaptureController *packetcapture.Controller
+	// packetCaptureQuerier is left nil when PacketCapture is disabled, so that the agent API
+	// can report it.
+	var packetCaptureQuerier antreaquerier.AgentPacketCaptureQuerier
+	if features.DefaultFeatureGate.Enabled(features.PacketCapture) {
+		packetCaptureController = packetcapture.NewPacketCaptureController(
+			k8sClient,
+			crdClient,
+			packetCaptureInformer,
+			ofClient,
+			ifaceStore,
+			nodeConfig)
+		packetCaptureQuerier = packetCaptureController
+	}
+
+	// The DHCP controller binds the addresses leased with DHCP to the Pods annotated with
+	// pod.antrea.io/dhcp.
+	dhcpController := dhcp.NewController(ofClient, ifaceStore)
+
+	var nodeLatencyMonitor *nodelatency.NodeLatencyMonitor
+	// nodeLatencyQuerier is left nil when NodeLatencyMonitor is disabled, so that the agent API
+	// can report it.
+	var nodeLatencyQuerier antreaquerier.AgentNodeLatencyQuerier
+	if features.DefaultFeatureGate.Enabled(features.NodeLatencyMonitor) {
+		nodeLatencyMonitor = nodelatency.NewNodeLatencyMonitor(
+			nodeConfig.Name,
+			informerFactory,
+			config.IsIPv4Enabled(nodeConfig, networkConfig.TrafficEncapMode),
+			config.IsIPv6Enabled(nodeConfig, networkConfig.TrafficEncapMode))
+		nodeLatencyQuerier = nodeLatencyMonitor
+	}
+
+	// The Service CIDRs which are not set explicitly in the configuration are replaced with the
+	// ones discovered from the ClusterIPs of the Services when they disagree.
+	serviceCIDRDiscoverer := servicecidr.NewDiscoverer(
+		informerFactory,
+		serviceCIDRNet,
+		serviceCIDRNetv6,
+		!o.serviceCIDRDefaulted,
+		o.config.ServiceCIDRv6 != "",
+		agentInitializer.UpdateServiceCIDRs)
+
 	// TODO: we should call this after installing flows for initial node routes
 	//  and initial NetworkPolicies so that no packets will be mishandled.
 	if err := agentInitializer.FlowRestoreComplete(); err != nil {
@@ -303,7 +486,13 @@ func run(o *Options) error {
 
 	go nodeRouteController.Run(stopCh)
 
-	go networkPolicyController.Run(stopCh)
+	// componentsWG tracks the components which have work to finish before exiting.
+	var componentsWG sync.WaitGroup
+	componentsWG.Add(1)
+	go func() {
+		defer componentsWG.Done()
+		networkPolicyController.Run(stopCh)
+	}()
 
 	if features.DefaultFeatureGate.Enabled(features.Egress) {
 		go egressController.Run(stopCh)
@@ -317,6 +506,18 @@ func run(o *Options) error {
 		go traceflowController.Run(stopCh)
 	}
 
+	if features.DefaultFeatureGate.Enabled(features.PacketCapture) {
+		go packetCaptureController.Run(stopCh)
+	}
+
+	go dhcpController.Run(stopCh)
+
+	go serviceCIDRDiscoverer.Run(stopCh)
+
+	if features.DefaultFeatureGate.Enabled(features.NodeLatencyMonitor) {
+		go nodeLatencyMonitor.Run(stopCh)
+	}
+
 	if features.DefaultFeatureGate.Enabled(features.AntreaProxy) {
 		go proxier.GetProxyProvider().Run(stopCh)
 	}
@@ -330,47 +531,74 @@ func run(o *Options) error {
 		ovsBridgeClient,
 		proxier,
 		networkPolicyController,
+		nodeLatencyQuerier,
+		condition.DefaultManager(),
 		o.config.APIPort)
 
 	agentMonitor := monitor.NewAgentMonitor(crdClient, legacyCRDClient, agentQuerier)
 
 	go agentMonitor.Run(stopCh)
 
-	cipherSuites, err := cipher.GenerateCipherSuitesList(o.config.TLSCipherSuites)
-	if err != nil {
-		return fmt.Errorf("error generating Cipher Suite list: %v", err)
-	}
-	apiServer, err := apiserver.New(
-		agentQuerier,
-		networkPolicyController,
-		o.config.APIPort,
-		o.config.EnablePrometheusMetrics,
-		o.config.ClientConnection.Kubeconfig,
-		cipherSuites,
-		cipher.TLSVersionMap[o.config.TLSMinVersion])
-	if err != nil {
-		return fmt.Errorf("error when creating agent API server: %v", err)
-	}
-	go apiServer.Run(stopCh)
-
 	// Start PacketIn for features and specify their own reason.
 	var packetInReasons []uint8
 	if features.DefaultFeatureGate.Enabled(features.Traceflow) {
 		packetInReasons = append(packetInReasons, uint8(openflow.PacketInReasonTF))
 	}
-	if features.DefaultFeatureGate.Enabled(features.AntreaPolicy) {
+	// The packets dropped by K8s NetworkPolicy isolation are logged with the same packet-in reason
+	// as the packets matching Antrea-native policy rules.
+	if loggingEnabled {
 		packetInReasons = append(packetInReasons, uint8(openflow.PacketInReasonNP))
 	}
+	if networkConfig.TrafficEncapMode.IsNetworkPolicyOnly() && config.IsIPv6Enabled(nodeConfig, networkConfig.TrafficEncapMode) {
+		packetInReasons = append(packetInReasons, uint8(openflow.PacketInReasonND))
+	}
+	if networkConfig.TrafficEncapMode.SupportsEncap() {
+		packetInReasons = append(packetInReasons, uint8(openflow.PacketInReasonPMTU))
+	}
+	if features.DefaultFeatureGate.Enabled(features.PacketCapture) {
+		packetInReasons = append(packetInReasons, uint8(openflow.PacketInReasonPC))
+	}
+	if o.config.NDGuard.Enable && config.IsIPv6Enabled(nodeConfig, networkConfig.TrafficEncapMode) {
+		packetInReasons = append(packetInReasons, uint8(openflow.PacketInReasonNDGuard))
+	}
+	packetInReasons = append(packetInReasons, uint8(openflow.PacketInReasonDHCP))
+	if features.DefaultFeatureGate.Enabled(features.AntreaProxy) {
+		packetInReasons = append(packetInReasons, uint8(openflow.PacketInReasonServiceLoop), uint8(openflow.PacketInReasonServiceSourceRange))
+	}
 	if len(packetInReasons) > 0 {
 		go ofClient.StartPacketInHandler(packetInReasons, stopCh)
 	}
+	go ofClient.MonitorPodConnectionLimits(o.config.PodConnectionLimit.EnableLogging, stopCh)
+
+	// The memory guard reduces the buffers of the agent when its memory usage gets close to its
+	// limit. The components register with it when they are created.
+	var memoryGuard *memoryguard.Guard
+	if o.config.MemoryGuard.Enable {
+		memoryGuard = memoryguard.NewGuard(memoryguard.NewCgroupUsageProvider(), o.config.MemoryGuard.Watermark)
+		memoryGuard.Register("packetin", func() int {
+			return ofClient.ShrinkPacketInQueues(true)
+		}, func() {
+			ofClient.ShrinkPacketInQueues(false)
+		})
+		configWatcher.AddHook("memoryGuard", []string{"memoryGuard.watermark"}, func(c *agentconfig.AgentConfig) error {
+			memoryGuard.SetWatermark(c.MemoryGuard.Watermark)
+			return nil
+		})
+	}
 
+	// flowExporterQuerier is left nil when FlowExporter is disabled, so that the agent API can
+	// report it.
+	var flowExporterQuerier antreaquerier.AgentFlowExporterQuerier
 	// Initialize flow exporter to start go routines to poll conntrack flows and export IPFIX flow records
 	if features.DefaultFeatureGate.Enabled(features.FlowExporter) {
 		v4Enabled := config.IsIPv4Enabled(nodeConfig, networkConfig.TrafficEncapMode)
 		v6Enabled := config.IsIPv6Enabled(nodeConfig, networkConfig.TrafficEncapMode)
 		isNetworkPolicyOnly := networkConfig.TrafficEncapMode.IsNetworkPolicyOnly()
 
+		var flowRecordExporter exporter.FlowRecordExporter
+		if otelExporter != nil && o.config.OTelExporter.ExportFlowRecords {
+			flowRecordExporter = otelExporter
+		}
 		flowRecords := flowrecords.NewFlowRecords()
 		conntrackConnStore := connections.NewConntrackConnectionStore(
 			connections.InitializeConnTrackDumper(nodeConfig, serviceCIDRNet, serviceCIDRNetv6, ovsDatapathType, features.DefaultFeatureGate.Enabled(features.AntreaProxy)),
@@ -387,22 +615,109 @@ func run(o *Options) error {
 			conntrackConnStore,
 			flowRecords,
 			denyConnStore,
-			o.flowCollectorAddr,
-			o.flowCollectorProto,
+			o.flowCollectors,
 			o.activeFlowTimeout,
 			o.idleFlowTimeout,
 			v4Enabled,
 			v6Enabled,
 			k8sClient,
 			nodeRouteController,
-			isNetworkPolicyOnly)
+			isNetworkPolicyOnly,
+			flowRecordExporter)
 		if err != nil {
 			return fmt.Errorf("error when creating IPFIX flow exporter: %v", err)
 		}
-		go flowExporter.Run(stopCh)
+		flowExporterQuerier = flowExporter
+		if memoryGuard != nil {
+			memoryGuard.Register("flowexporter", flowExporter.ReduceMemory, nil)
+		}
+		configWatcher.AddHook("flowExporter", []string{"flowPollInterval", "activeFlowExportTimeout", "idleFlowExportTimeout"}, func(c *agentconfig.AgentConfig) error {
+			pollInterval, activeFlowTimeout, idleFlowTimeout, err := agentconfig.FlowExportIntervals(c)
+			if err != nil {
+				return err
+			}
+			conntrackConnStore.SetPollInterval(pollInterval)
+			flowExporter.SetFlowTimeouts(activeFlowTimeout, idleFlowTimeout)
+			return nil
+		})
+		componentsWG.Add(1)
+		go func() {
+			defer componentsWG.Done()
+			flowExporter.Run(stopCh)
+		}()
+	}
+
+	// The agent API server is created once the flow exporter is, as it reports the status of the
+	// flow collectors.
+	cipherSuites, err := cipher.GenerateCipherSuitesList(o.config.TLSCipherSuites)
+	if err != nil {
+		return fmt.Errorf("error generating Cipher Suite list: %v", err)
+	}
+	apiServer, err := apiserver.New(
+		agentQuerier,
+		networkPolicyController,
+		networkPolicyController,
+		networkPolicyController,
+		packetCaptureQuerier,
+		nodeLatencyQuerier,
+		flowExporterQuerier,
+		networkPolicyController,
+		configWatcher,
+		o.config.APIPort,
+		o.config.EnablePrometheusMetrics,
+		o.config.ClientConnection.Kubeconfig,
+		cipherSuites,
+		cipher.TLSVersionMap[o.config.TLSMinVersion])
+	if err != nil {
+		return fmt.Errorf("error when creating agent API server: %v", err)
+	}
+	go apiServer.Run(stopCh)
+
+	if memoryGuard != nil {
+		go memoryGuard.Run(stopCh)
+	}
+
+	if o.configFile != "" {
+		go configWatcher.Run(stopCh)
+	}
+
+	// The OpenTelemetry exporter is stopped after the other components, so that it exports the
+	// final flow records sent by the flow exporter when it stops.
+	otelExporterStopCh := make(chan struct{})
+	var otelExporterWG sync.WaitGroup
+	if otelExporter != nil {
+		otelExporterWG.Add(1)
+		go func() {
+			defer otelExporterWG.Done()
+			otelExporter.Run(otelExporterStopCh)
+		}()
 	}
 
 	<-stopCh
 	klog.Info("Stopping Antrea agent")
+	waitForComponents(&componentsWG, shutdownTimeout)
+	close(otelExporterStopCh)
+	waitForComponents(&otelExporterWG, shutdownTimeout)
+	// Disconnecting from OVS cancels the flow operations which are still in progress, e.g. the
+	// bundles which have not been committed are discarded by OVS.
+	if err := ofClient.Disconnect(); err != nil {
+		klog.Errorf("Failed to disconnect from OVS: %v", err)
+	}
 	return nil
 }
+
+// waitForComponents waits for the components tracked by componentsWG to stop, giving up after
+// timeout.
+func waitForComponents(componentsWG *sync.WaitGroup, timeout time.Duration) {
+	done := make(chan struct{})
+	go func() {
+		componentsWG.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		klog.Info("All components have been stopped")
+	case <-time.After(timeout):
+		klog.Warningf("Timed out waiting for components to stop after %v", timeout)
+	}
+}
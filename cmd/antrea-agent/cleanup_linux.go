@@ -0,0 +1,117 @@
+// +build linux
+
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"k8s.io/klog/v2"
+
+	"antrea.io/antrea/pkg/agent/route"
+	"antrea.io/antrea/pkg/ovs/ovsconfig"
+)
+
+const (
+	// antreaRunDir is the directory in which antrea-agent keeps its runtime state.
+	antreaRunDir = "/var/run/antrea"
+	// ovsRunSubDir is the sub-directory of antreaRunDir used by the antrea-ovs container. It is
+	// left untouched as it's still used by OVS while the cleanup runs.
+	ovsRunSubDir = "openvswitch"
+)
+
+type cleanupStep struct {
+	name string
+	run  func() error
+}
+
+// runCleanup removes the Antrea state from the Node: the OVS bridge and its ports, the iptables
+// chains and ipsets, the routes on the host gateway interface and the interface itself, and the
+// runtime state. All steps are idempotent so that they can be run on a Node from which Antrea has
+// been partially removed. A step is run even if the previous ones failed, and an error is
+// returned if any of them failed.
+func runCleanup(o *Options) error {
+	steps := []cleanupStep{
+		// Routes must be deleted before the bridge, as the gateway interface is deleted with it.
+		{"routes on the host gateway interface", func() error { return route.CleanupGatewayRoutes(o.config.HostGateway) }},
+		{"OVS bridge", func() error { return cleanupOVSBridge(o) }},
+		{"host gateway interface", func() error { return route.CleanupGatewayLink(o.config.HostGateway) }},
+		// iptables rules must be deleted before the ipsets they reference.
+		{"iptables chains", func() error { return route.CleanupIPTables(true, isIP6TablesAvailable()) }},
+		{"ipsets", route.CleanupIPSets},
+		{"runtime state", func() error { return cleanupRunDir(antreaRunDir) }},
+	}
+	var failedSteps []string
+	for _, step := range steps {
+		if err := step.run(); err != nil {
+			klog.Errorf("Failed to clean up %s: %v", step.name, err)
+			failedSteps = append(failedSteps, step.name)
+			continue
+		}
+		klog.Infof("Cleaned up %s", step.name)
+	}
+	if len(failedSteps) > 0 {
+		return fmt.Errorf("failed to clean up %s", strings.Join(failedSteps, ", "))
+	}
+	return nil
+}
+
+func cleanupOVSBridge(o *Options) error {
+	ovsdbAddress := ovsconfig.GetConnAddress(o.config.OVSRunDir)
+	ovsdbConnection, err := ovsconfig.NewOVSDBConnectionUDS(ovsdbAddress)
+	if err != nil {
+		return fmt.Errorf("error connecting OVSDB: %v", err)
+	}
+	defer ovsdbConnection.Close()
+	ovsBridgeClient := ovsconfig.NewOVSBridge(o.config.OVSBridge, ovsconfig.OVSDatapathType(o.config.OVSDatapathType), ovsdbConnection)
+	if err := ovsBridgeClient.Delete(); err != nil {
+		return fmt.Errorf("error deleting OVS bridge %s: %v", o.config.OVSBridge, err)
+	}
+	return nil
+}
+
+// isIP6TablesAvailable returns whether ip6tables is installed, in which case the IPv6 chains
+// must be cleaned up as well.
+func isIP6TablesAvailable() bool {
+	_, err := exec.LookPath("ip6tables")
+	return err == nil
+}
+
+// cleanupRunDir removes all the files and directories in runDir, except the OVS sub-directory.
+func cleanupRunDir(runDir string) error {
+	files, err := ioutil.ReadDir(runDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("error reading directory %s: %v", runDir, err)
+	}
+	for _, file := range files {
+		if file.Name() == ovsRunSubDir {
+			continue
+		}
+		path := filepath.Join(runDir, file.Name())
+		if err := os.RemoveAll(path); err != nil {
+			return fmt.Errorf("error removing %s: %v", path, err)
+		}
+	}
+	return nil
+}
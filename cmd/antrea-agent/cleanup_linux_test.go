@@ -0,0 +1,49 @@
+// +build linux
+
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCleanupRunDir(t *testing.T) {
+	runDir, err := ioutil.TempDir("", "antrea-run")
+	require.NoError(t, err)
+	defer os.RemoveAll(runDir)
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(runDir, "cni.sock"), nil, 0600))
+	require.NoError(t, os.MkdirAll(filepath.Join(runDir, "cni", "networks"), 0700))
+	require.NoError(t, os.MkdirAll(filepath.Join(runDir, ovsRunSubDir), 0700))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(runDir, ovsRunSubDir, "conf.db"), nil, 0600))
+
+	require.NoError(t, cleanupRunDir(runDir))
+	files, err := ioutil.ReadDir(runDir)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, ovsRunSubDir, files[0].Name())
+	assert.FileExists(t, filepath.Join(runDir, ovsRunSubDir, "conf.db"))
+
+	// Cleaning up again or a missing directory must not fail.
+	assert.NoError(t, cleanupRunDir(runDir))
+	assert.NoError(t, cleanupRunDir(filepath.Join(runDir, "missing")))
+}
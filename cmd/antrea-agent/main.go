@@ -19,12 +19,15 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"os"
 
 	"github.com/spf13/cobra"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/component-base/logs"
 	"k8s.io/klog/v2"
 
+	"antrea.io/antrea/pkg/agent/crashjournal"
 	"antrea.io/antrea/pkg/log"
 	"antrea.io/antrea/pkg/version"
 )
@@ -40,22 +43,48 @@ func main() {
 	}
 }
 
+// fatalf records the fatal error in the crash journal, then logs it and exits like klog.Fatalf.
+func fatalf(journal *crashjournal.Journal, format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	if err := journal.RecordFatal(msg); err != nil {
+		klog.Errorf("Failed to record fatal error in the crash journal: %v", err)
+	}
+	klog.Fatal(msg)
+}
+
 func newAgentCommand() *cobra.Command {
 	opts := newOptions()
+	// The crash journal keeps the panics and fatal errors of the previous runs of the agent, for
+	// the cases in which their logs are lost, e.g. when the agent is crash-looping.
+	journal := crashjournal.New(crashjournal.DefaultPath, crashjournal.DefaultMaxEntries)
 
 	cmd := &cobra.Command{
 		Use:  "antrea-agent",
 		Long: "The Antrea agent runs on each node.",
 		Run: func(cmd *cobra.Command, args []string) {
+			// Panics in the main goroutine, and in the goroutines using HandleCrash, are
+			// recorded before the agent crashes.
+			defer journal.HandlePanic()
+			utilruntime.PanicHandlers = append(utilruntime.PanicHandlers, journal.RecordPanic)
 			log.InitLogFileLimits(cmd.Flags())
-			if err := opts.complete(args); err != nil {
-				klog.Fatalf("Failed to complete: %v", err)
+			err := opts.complete(args)
+			// The hash is set as soon as the configuration file is read, so that it is
+			// recorded with the errors caused by an invalid configuration.
+			journal.SetConfigHash(opts.configHash)
+			if err != nil {
+				fatalf(journal, "Failed to complete: %v", err)
 			}
 			if err := opts.validate(args); err != nil {
-				klog.Fatalf("Failed to validate: %v", err)
+				fatalf(journal, "Failed to validate: %v", err)
+			}
+			if opts.cleanup {
+				if err := runCleanup(opts); err != nil {
+					fatalf(journal, "Error cleaning up Antrea state: %v", err)
+				}
+				return
 			}
 			if err := run(opts); err != nil {
-				klog.Fatalf("Error running agent: %v", err)
+				fatalf(journal, "Error running agent: %v", err)
 			}
 		},
 		Version: version.GetFullVersionWithRuntimeInfo(),
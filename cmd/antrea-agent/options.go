@@ -15,6 +15,7 @@
 package main
 
 import (
+	"crypto/sha256"
 	"fmt"
 	"io/ioutil"
 	"net"
@@ -22,11 +23,15 @@ import (
 
 	"github.com/spf13/pflag"
 	"gopkg.in/yaml.v2"
-	"k8s.io/klog/v2"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
 
 	"antrea.io/antrea/pkg/agent/config"
+	"antrea.io/antrea/pkg/agent/flowexporter/exporter"
 	"antrea.io/antrea/pkg/apis"
+	"antrea.io/antrea/pkg/apis/controlplane/v1beta2"
 	"antrea.io/antrea/pkg/cni"
+	agentconfig "antrea.io/antrea/pkg/config/agent"
 	"antrea.io/antrea/pkg/features"
 	"antrea.io/antrea/pkg/ovs/ovsconfig"
 	"antrea.io/antrea/pkg/util/flowexport"
@@ -41,33 +46,58 @@ const (
 	defaultFlowCollectorAddress    = "flow-aggregator.flow-aggregator.svc:4739:tls"
 	defaultFlowCollectorTransport  = "tls"
 	defaultFlowCollectorPort       = "4739"
-	defaultFlowPollInterval        = 5 * time.Second
-	defaultActiveFlowExportTimeout = 30 * time.Second
-	defaultIdleFlowExportTimeout   = 15 * time.Second
-	defaultNPLPortRange            = "40000-41000"
+	defaultFlowCollectorName       = "default"
+	defaultTemplateRefreshInterval = 30 * time.Minute
+	defaultFlowPollInterval        = agentconfig.DefaultFlowPollInterval
+	defaultActiveFlowExportTimeout = agentconfig.DefaultActiveFlowExportTimeout
+	defaultIdleFlowExportTimeout   = agentconfig.DefaultIdleFlowExportTimeout
+	defaultNPLPortRange            = agentconfig.DefaultNPLPortRange
+
+	policyBootstrapModeFailOpen   = "failOpen"
+	policyBootstrapModeFailClosed = "failClosed"
+
+	udpRejectModeAdminProhibited = "adminProhibited"
+	udpRejectModePortUnreachable = "portUnreachable"
 )
 
 type Options struct {
 	// The path of configuration file.
 	configFile string
 	// The configuration object
-	config *AgentConfig
-	// IPFIX flow collector address
-	flowCollectorAddr string
-	// IPFIX flow collector protocol
-	flowCollectorProto string
+	config *agentconfig.AgentConfig
+	// The SHA-256 hash of the configuration file, recorded in the crash journal
+	configHash string
+	// IPFIX flow collectors
+	flowCollectors []exporter.CollectorConfig
 	// Flow exporter poll interval
 	pollInterval time.Duration
 	// Active flow timeout to export records of active flows
 	activeFlowTimeout time.Duration
 	// Idle flow timeout to export records of inactive flows
 	idleFlowTimeout time.Duration
+	// Whether to clean up the Antrea state on the Node and exit
+	cleanup bool
+	// Number of the latest OVS flow changes recorded for debugging, 0 disables the recording
+	flowChangeTrackingSize int
+	// Whether serviceCIDR is not set in the configuration file and defaults to defaultServiceCIDR,
+	// in which case it is replaced with the discovered Service CIDR if they disagree
+	serviceCIDRDefaulted bool
+	// Maximum time a log record is buffered by the OpenTelemetry exporter before it is sent
+	otelExporterFlushInterval time.Duration
+	// Window during which the audit log entries of identical packets are aggregated
+	auditLogAggregationWindow time.Duration
+	// Intervals between the keepalive probes of the OpenFlow and OVSDB connections, 0 disables the probes
+	openflowProbeInterval time.Duration
+	ovsdbProbeInterval    time.Duration
 }
 
 func newOptions() *Options {
 	return &Options{
-		config: &AgentConfig{
+		config: &agentconfig.AgentConfig{
 			EnablePrometheusMetrics: true,
+			NDGuard:                 agentconfig.NDGuardConfig{Enable: true},
+			MemoryGuard:             agentconfig.MemoryGuardConfig{Enable: true},
+			OTelExporter:            agentconfig.OTelExporterConfig{ExportAuditLogs: true, ExportFlowRecords: true},
 		},
 	}
 }
@@ -75,6 +105,8 @@ func newOptions() *Options {
 // addFlags adds flags to fs and binds them to options.
 func (o *Options) addFlags(fs *pflag.FlagSet) {
 	fs.StringVar(&o.configFile, "config", o.configFile, "The path to the configuration file")
+	fs.BoolVar(&o.cleanup, "cleanup", o.cleanup, "Clean up the Antrea state (OVS bridge, iptables chains, routes, etc.) on the Node and exit")
+	fs.IntVar(&o.flowChangeTrackingSize, "flow-change-tracking-size", o.flowChangeTrackingSize, "Number of the latest OVS flow changes to record with the objects which triggered them, for debugging flow churn with 'antctl get flowchanges'. 0 disables the recording")
 }
 
 // complete completes all the required options.
@@ -97,18 +129,15 @@ func (o *Options) validate(args []string) error {
 	if len(args) != 0 {
 		return fmt.Errorf("no positional arguments are supported")
 	}
-
-	// Validate service CIDR configuration
-	_, _, err := net.ParseCIDR(o.config.ServiceCIDR)
-	if err != nil {
-		return fmt.Errorf("Service CIDR %s is invalid", o.config.ServiceCIDR)
+	if o.flowChangeTrackingSize < 0 {
+		return fmt.Errorf("flow-change-tracking-size must not be negative")
 	}
-	if o.config.ServiceCIDRv6 != "" {
-		_, _, err := net.ParseCIDR(o.config.ServiceCIDRv6)
-		if err != nil {
-			return fmt.Errorf("Service CIDR v6 %s is invalid", o.config.ServiceCIDRv6)
-		}
+	// Check the ranges of the options and their consistency first, so that all the violations of
+	// these rules are reported at once.
+	if err := agentconfig.Validate(o.config, nil); err != nil {
+		return fmt.Errorf("invalid configuration: %v", err)
 	}
+
 	if o.config.TunnelType != ovsconfig.VXLANTunnel && o.config.TunnelType != ovsconfig.GeneveTunnel &&
 		o.config.TunnelType != ovsconfig.GRETunnel && o.config.TunnelType != ovsconfig.STTTunnel {
 		return fmt.Errorf("tunnel type %s is invalid", o.config.TunnelType)
@@ -125,8 +154,7 @@ func (o *Options) validate(args []string) error {
 	}
 
 	// Check if the enabled features are supported on the OS.
-	err = o.checkUnsupportedFeatures()
-	if err != nil {
+	if err := o.checkUnsupportedFeatures(); err != nil {
 		return err
 	}
 
@@ -138,9 +166,20 @@ func (o *Options) validate(args []string) error {
 			return fmt.Errorf("IPsec tunnel may only be enabled in %s mode", config.TrafficEncapModeEncap)
 		}
 	}
+	if !encapMode.SupportsEncap() && (o.config.TunnelPort != 0 || o.config.TunnelDSCP != "") {
+		return fmt.Errorf("tunnelPort and tunnelDSCP are not applicable to the %s mode", encapMode)
+	}
 	if o.config.NoSNAT && !(encapMode == config.TrafficEncapModeNoEncap || encapMode == config.TrafficEncapModeNetworkPolicyOnly) {
 		return fmt.Errorf("noSNAT is only applicable to the %s mode", config.TrafficEncapModeNoEncap)
 	}
+	if o.config.AdvertiseServiceCIDR {
+		if encapMode != config.TrafficEncapModeNoEncap {
+			return fmt.Errorf("advertiseServiceCIDR is only applicable to the %s mode", config.TrafficEncapModeNoEncap)
+		}
+		if ip, _, _ := net.ParseCIDR(o.config.ServiceCIDR); ip.To4() == nil {
+			return fmt.Errorf("advertiseServiceCIDR requires serviceCIDR to be an IPv4 CIDR")
+		}
+	}
 	if encapMode == config.TrafficEncapModeNetworkPolicyOnly {
 		// In the NetworkPolicyOnly mode, Antrea will not perform SNAT
 		// (but SNAT can be done by the primary CNI).
@@ -149,6 +188,38 @@ func (o *Options) validate(args []string) error {
 	if err := o.validateFlowExporterConfig(); err != nil {
 		return fmt.Errorf("failed to validate flow exporter config: %v", err)
 	}
+	if err := o.validateOTelExporterConfig(); err != nil {
+		return fmt.Errorf("failed to validate OpenTelemetry exporter config: %v", err)
+	}
+	auditLogAggregationWindow, err := time.ParseDuration(o.config.AuditLogging.AggregationWindow)
+	if err != nil {
+		return fmt.Errorf("auditLogging.aggregationWindow is not provided in right format")
+	}
+	o.auditLogAggregationWindow = auditLogAggregationWindow
+	openflowProbeInterval, err := time.ParseDuration(o.config.OVSConnectionProbe.OpenFlowInterval)
+	if err != nil {
+		return fmt.Errorf("ovsConnectionProbe.openflowInterval is not provided in right format")
+	}
+	o.openflowProbeInterval = openflowProbeInterval
+	ovsdbProbeInterval, err := time.ParseDuration(o.config.OVSConnectionProbe.OVSDBInterval)
+	if err != nil {
+		return fmt.Errorf("ovsConnectionProbe.ovsdbInterval is not provided in right format")
+	}
+	o.ovsdbProbeInterval = ovsdbProbeInterval
+	if err := o.validateGatewayConfig(); err != nil {
+		return fmt.Errorf("failed to validate gateway config: %v", err)
+	}
+	if err := o.validateTunnelProfiles(encapMode); err != nil {
+		return fmt.Errorf("failed to validate tunnel profiles: %v", err)
+	}
+	if err := o.validatePolicyBootstrapConfig(); err != nil {
+		return fmt.Errorf("failed to validate policy bootstrap config: %v", err)
+	}
+	switch o.config.UDPRejectMode {
+	case udpRejectModeAdminProhibited, udpRejectModePortUnreachable:
+	default:
+		return fmt.Errorf("udpRejectMode %s is unknown", o.config.UDPRejectMode)
+	}
 	return nil
 }
 
@@ -157,10 +228,27 @@ func (o *Options) loadConfigFromFile() error {
 	if err != nil {
 		return err
 	}
+	o.configHash = fmt.Sprintf("%x", sha256.Sum256(data))
 
 	return yaml.UnmarshalStrict(data, &o.config)
 }
 
+// reloadConfig loads and validates the configuration file again, for the agent configuration
+// watcher. The feature gates are not applied, as they cannot be changed without restarting the
+// agent.
+func (o *Options) reloadConfig() (*agentconfig.AgentConfig, error) {
+	newOpts := newOptions()
+	newOpts.configFile = o.configFile
+	if err := newOpts.loadConfigFromFile(); err != nil {
+		return nil, err
+	}
+	newOpts.setDefaults()
+	if err := newOpts.validate(nil); err != nil {
+		return nil, err
+	}
+	return newOpts.config, nil
+}
+
 func (o *Options) setDefaults() {
 	if o.config.CNISocket == "" {
 		o.config.CNISocket = cni.AntreaCNISocketAddr
@@ -188,10 +276,66 @@ func (o *Options) setDefaults() {
 	}
 	if o.config.ServiceCIDR == "" {
 		o.config.ServiceCIDR = defaultServiceCIDR
+		o.serviceCIDRDefaulted = true
 	}
 	if o.config.APIPort == 0 {
 		o.config.APIPort = apis.AntreaAgentAPIPort
 	}
+	if o.config.MemoryGuard.Watermark == 0 {
+		o.config.MemoryGuard.Watermark = agentconfig.DefaultMemoryGuardWatermark
+	}
+
+	if o.config.AuditLogging.Destination == "" {
+		o.config.AuditLogging.Destination = agentconfig.AuditLogDestinationFile
+	}
+	if o.config.AuditLogging.Format == "" {
+		o.config.AuditLogging.Format = agentconfig.AuditLogFormatText
+	}
+	if o.config.AuditLogging.AggregationWindow == "" {
+		o.config.AuditLogging.AggregationWindow = agentconfig.DefaultAuditLogAggregationWindow.String()
+	}
+
+	if o.config.OVSConnectionProbe.OpenFlowInterval == "" {
+		o.config.OVSConnectionProbe.OpenFlowInterval = agentconfig.DefaultOVSConnectionProbeInterval.String()
+	}
+	if o.config.OVSConnectionProbe.OVSDBInterval == "" {
+		o.config.OVSConnectionProbe.OVSDBInterval = agentconfig.DefaultOVSConnectionProbeInterval.String()
+	}
+	if o.config.OVSConnectionProbe.FailureThreshold == 0 {
+		o.config.OVSConnectionProbe.FailureThreshold = agentconfig.DefaultOVSConnectionProbeFailureThreshold
+	}
+
+	if o.config.PolicyOnlyInterfaceDiscovery.Strategy == "" {
+		o.config.PolicyOnlyInterfaceDiscovery.Strategy = agentconfig.PolicyOnlyInterfaceDiscoveryVethPeer
+	}
+
+	if o.config.NetworkPolicyWorkers == 0 {
+		o.config.NetworkPolicyWorkers = agentconfig.DefaultNetworkPolicyWorkers
+	}
+
+	if o.config.OTelExporter.QueueSize == 0 {
+		o.config.OTelExporter.QueueSize = agentconfig.DefaultOTelExporterQueueSize
+	}
+	if o.config.OTelExporter.BatchSize == 0 {
+		o.config.OTelExporter.BatchSize = agentconfig.DefaultOTelExporterBatchSize
+	}
+	if o.config.OTelExporter.FlushInterval == "" {
+		o.config.OTelExporter.FlushInterval = agentconfig.DefaultOTelExporterFlushPeriod.String()
+	}
+
+	if o.config.PolicyBootstrapMode == "" {
+		o.config.PolicyBootstrapMode = policyBootstrapModeFailOpen
+	}
+	for i := range o.config.PolicyBootstrapAllowlist {
+		peer := &o.config.PolicyBootstrapAllowlist[i]
+		if peer.Port != 0 && peer.Protocol == "" {
+			peer.Protocol = string(v1beta2.ProtocolTCP)
+		}
+	}
+
+	if o.config.UDPRejectMode == "" {
+		o.config.UDPRejectMode = udpRejectModeAdminProhibited
+	}
 
 	if o.config.ClusterMembershipPort == 0 {
 		o.config.ClusterMembershipPort = apis.AntreaAgentClusterMembershipPort
@@ -221,12 +365,28 @@ func (o *Options) setDefaults() {
 
 func (o *Options) validateFlowExporterConfig() error {
 	if features.DefaultFeatureGate.Enabled(features.FlowExporter) {
-		host, port, proto, err := flowexport.ParseFlowCollectorAddr(o.config.FlowCollectorAddr, defaultFlowCollectorPort, defaultFlowCollectorTransport)
-		if err != nil {
-			return err
+		collectors := o.config.FlowCollectors
+		if len(collectors) == 0 {
+			// The flow records are exported to the single collector configured with flowCollectorAddr.
+			collectors = []agentconfig.FlowCollectorConfig{{Name: defaultFlowCollectorName, Address: o.config.FlowCollectorAddr}}
+		}
+		names := sets.NewString()
+		o.flowCollectors = nil
+		for i := range collectors {
+			collector := &collectors[i]
+			if collector.Name == "" {
+				return fmt.Errorf("the name of flow collector %d must be provided", i)
+			}
+			if names.Has(collector.Name) {
+				return fmt.Errorf("the name of flow collector %s is not unique", collector.Name)
+			}
+			names.Insert(collector.Name)
+			collectorConfig, err := parseFlowCollectorConfig(collector)
+			if err != nil {
+				return fmt.Errorf("invalid flow collector %s: %v", collector.Name, err)
+			}
+			o.flowCollectors = append(o.flowCollectors, collectorConfig)
 		}
-		o.flowCollectorAddr = net.JoinHostPort(host, port)
-		o.flowCollectorProto = proto
 
 		// Parse the given flowPollInterval config
 		if o.config.FlowPollInterval != "" {
@@ -242,10 +402,6 @@ func (o *Options) validateFlowExporterConfig() error {
 			if err != nil {
 				return fmt.Errorf("ActiveFlowExportTimeout is not provided in right format")
 			}
-			if o.activeFlowTimeout < o.pollInterval {
-				o.activeFlowTimeout = o.pollInterval
-				klog.Warningf("ActiveFlowExportTimeout must be greater than or equal to FlowPollInterval")
-			}
 		}
 		// Parse the given inactiveFlowExportTimeout config
 		if o.config.IdleFlowExportTimeout != "" {
@@ -253,11 +409,148 @@ func (o *Options) validateFlowExporterConfig() error {
 			if err != nil {
 				return fmt.Errorf("IdleFlowExportTimeout is not provided in right format")
 			}
-			if o.idleFlowTimeout < o.pollInterval {
-				o.activeFlowTimeout = o.pollInterval
-				klog.Warningf("IdleFlowExportTimeout must be greater than or equal to FlowPollInterval")
+		}
+	}
+	return nil
+}
+
+// parseFlowCollectorConfig parses the configuration of a flow collector.
+func parseFlowCollectorConfig(collector *agentconfig.FlowCollectorConfig) (exporter.CollectorConfig, error) {
+	if collector.Address == "" {
+		return exporter.CollectorConfig{}, fmt.Errorf("the address must be provided")
+	}
+	host, port, proto, err := flowexport.ParseFlowCollectorAddr(collector.Address, defaultFlowCollectorPort, defaultFlowCollectorTransport)
+	if err != nil {
+		return exporter.CollectorConfig{}, err
+	}
+	if collector.TLS.ClientCertPath != "" && (collector.TLS.CACertPath == "" || collector.TLS.ClientKeyPath == "") {
+		return exporter.CollectorConfig{}, fmt.Errorf("caCertPath and clientKeyPath must be provided with clientCertPath")
+	}
+	templateRefreshInterval := defaultTemplateRefreshInterval
+	if collector.TemplateRefreshInterval != "" {
+		templateRefreshInterval, err = time.ParseDuration(collector.TemplateRefreshInterval)
+		if err != nil || templateRefreshInterval < time.Second {
+			return exporter.CollectorConfig{}, fmt.Errorf("templateRefreshInterval must be a duration of at least one second")
+		}
+	}
+	filter := exporter.RecordFilter{ExcludeDenyConnections: collector.RecordFilter.ExcludeDenyConnections}
+	for _, flowType := range collector.RecordFilter.FlowTypes {
+		value, err := flowexport.ParseFlowType(flowType)
+		if err != nil {
+			return exporter.CollectorConfig{}, err
+		}
+		filter.FlowTypes = append(filter.FlowTypes, value)
+	}
+	if len(collector.RecordFilter.Namespaces) > 0 {
+		filter.Namespaces = sets.NewString(collector.RecordFilter.Namespaces...)
+	}
+	return exporter.CollectorConfig{
+		Name:                    collector.Name,
+		Address:                 net.JoinHostPort(host, port),
+		Protocol:                proto,
+		CACertPath:              collector.TLS.CACertPath,
+		ClientCertPath:          collector.TLS.ClientCertPath,
+		ClientKeyPath:           collector.TLS.ClientKeyPath,
+		TemplateRefreshInterval: templateRefreshInterval,
+		Filter:                  filter,
+	}, nil
+}
+
+func (o *Options) validateOTelExporterConfig() error {
+	if !features.DefaultFeatureGate.Enabled(features.OTelExporter) {
+		return nil
+	}
+	flushInterval, err := time.ParseDuration(o.config.OTelExporter.FlushInterval)
+	if err != nil {
+		return fmt.Errorf("flushInterval is not provided in right format")
+	}
+	o.otelExporterFlushInterval = flushInterval
+	return nil
+}
+
+// validateGatewayConfig validates the user-provided gateway MAC and IP addresses. Whether the IP
+// addresses belong to the PodCIDRs of the Node can only be checked once the Node is retrieved,
+// which is done by the agent Initializer.
+func (o *Options) validateTunnelProfiles(encapMode config.TrafficEncapModeType) error {
+	if len(o.config.TunnelProfiles) == 0 {
+		return nil
+	}
+	if !encapMode.SupportsEncap() {
+		return fmt.Errorf("tunnel profiles are not applicable to the %s mode", encapMode)
+	}
+	if o.config.EnableIPSecTunnel {
+		return fmt.Errorf("tunnel profiles cannot be used with IPsec tunnel")
+	}
+	names := sets.NewString()
+	for _, profile := range o.config.TunnelProfiles {
+		if profile.Name == "" {
+			return fmt.Errorf("tunnel profile name must not be empty")
+		}
+		if names.Has(profile.Name) {
+			return fmt.Errorf("tunnel profile name %s is duplicate", profile.Name)
+		}
+		names.Insert(profile.Name)
+		if _, err := labels.ValidatedSelectorFromSet(profile.NodeSelector); err != nil {
+			return fmt.Errorf("nodeSelector of tunnel profile %s is invalid: %v", profile.Name, err)
+		}
+		for _, option := range config.ReservedTunnelOptions {
+			if _, ok := profile.Options[option]; ok {
+				return fmt.Errorf("option %s of tunnel profile %s is managed by Antrea and cannot be set", option, profile.Name)
+			}
+		}
+	}
+	return nil
+}
+
+func (o *Options) validatePolicyBootstrapConfig() error {
+	switch o.config.PolicyBootstrapMode {
+	case policyBootstrapModeFailOpen:
+		if len(o.config.PolicyBootstrapAllowlist) > 0 {
+			return fmt.Errorf("policyBootstrapAllowlist is only applicable to the %s mode", policyBootstrapModeFailClosed)
+		}
+	case policyBootstrapModeFailClosed:
+	default:
+		return fmt.Errorf("policyBootstrapMode %s is unknown", o.config.PolicyBootstrapMode)
+	}
+	for _, peer := range o.config.PolicyBootstrapAllowlist {
+		if _, _, err := net.ParseCIDR(peer.CIDR); err != nil {
+			return fmt.Errorf("CIDR %s is invalid", peer.CIDR)
+		}
+		if peer.Port < 0 || peer.Port > 65535 {
+			return fmt.Errorf("port %d is invalid", peer.Port)
+		}
+		switch v1beta2.Protocol(peer.Protocol) {
+		case v1beta2.ProtocolTCP, v1beta2.ProtocolUDP, v1beta2.ProtocolSCTP:
+		default:
+			if peer.Protocol != "" {
+				return fmt.Errorf("protocol %s is invalid", peer.Protocol)
 			}
 		}
 	}
 	return nil
 }
+
+func (o *Options) validateGatewayConfig() error {
+	if o.config.GatewayMAC != "" {
+		mac, err := net.ParseMAC(o.config.GatewayMAC)
+		if err != nil {
+			return fmt.Errorf("gatewayMAC %s is invalid: %v", o.config.GatewayMAC, err)
+		}
+		if len(mac) != 6 || mac[0]&0x01 != 0 {
+			return fmt.Errorf("gatewayMAC %s must be a 48-bit unicast MAC address", o.config.GatewayMAC)
+		}
+	}
+	if o.config.GatewayIPv4 != "" {
+		ip := net.ParseIP(o.config.GatewayIPv4)
+		if ip == nil || ip.To4() == nil {
+			return fmt.Errorf("gatewayIPv4 %s is not a valid IPv4 address", o.config.GatewayIPv4)
+		}
+	}
+	if o.config.GatewayIPv6 != "" {
+		ip := net.ParseIP(o.config.GatewayIPv6)
+		if ip == nil || ip.To4() != nil {
+			return fmt.Errorf("gatewayIPv6 %s is not a valid IPv6 address", o.config.GatewayIPv6)
+		}
+	}
+	return nil
+}
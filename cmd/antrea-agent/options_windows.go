@@ -51,6 +51,12 @@ func (o *Options) checkUnsupportedFeatures() error {
 	if o.config.EnableIPSecTunnel {
 		unsupported = append(unsupported, "IPsecTunnel")
 	}
+	if o.config.AdvertiseServiceCIDR {
+		unsupported = append(unsupported, "AdvertiseServiceCIDR")
+	}
+	if o.config.TCPMSSClamping {
+		unsupported = append(unsupported, "TCPMSSClamping")
+	}
 
 	if unsupported != nil {
 		return fmt.Errorf("unsupported features on Windows: {%s}", strings.Join(unsupported, ", "))
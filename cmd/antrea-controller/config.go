@@ -45,4 +45,16 @@ type ControllerConfig struct {
 	TLSMinVersion string `yaml:"tlsMinVersion,omitempty"`
 	// Legacy CRD mirroring.
 	LegacyCRDMirroring bool `yaml:"legacyCRDMirroring,omitempty"`
+	// The number of workers computing each kind of NetworkPolicy object (AppliedToGroups, AddressGroups, internal
+	// NetworkPolicies and ClusterGroups) in parallel. Increasing it can reduce the start-up time of large clusters.
+	// Defaults to 4.
+	NetworkPolicyControllerWorkers int `yaml:"networkPolicyControllerWorkers,omitempty"`
+	// The maximum number of OpenFlow flows the NetworkPolicies of a Namespace may cost, as estimated by the
+	// antrea-controller. The policies exceeding the quota are not enforced. 0 means no quota.
+	// Defaults to 0.
+	NetworkPolicyFlowQuotaPerNamespace int `yaml:"networkPolicyFlowQuotaPerNamespace,omitempty"`
+	// Reject the controlplane API requests of the antrea-agents whose minor version differs from the minor version
+	// of the antrea-controller by more than one. A warning is logged for these antrea-agents in any case.
+	// Defaults to false.
+	RejectUnsupportedAgentVersionSkew bool `yaml:"rejectUnsupportedAgentVersionSkew,omitempty"`
 }
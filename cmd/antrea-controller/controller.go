@@ -15,11 +15,14 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
 	"net"
+	"net/http"
 	"os"
 	"path"
+	"sync"
 	"time"
 
 	apiextensionclientset "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
@@ -33,14 +36,17 @@ import (
 
 	"antrea.io/antrea/pkg/apiserver"
 	"antrea.io/antrea/pkg/apiserver/certificate"
+	"antrea.io/antrea/pkg/apiserver/handlers/policyflows"
 	"antrea.io/antrea/pkg/apiserver/openapi"
 	"antrea.io/antrea/pkg/apiserver/storage"
 	crdinformers "antrea.io/antrea/pkg/client/informers/externalversions"
 	"antrea.io/antrea/pkg/clusteridentity"
+	"antrea.io/antrea/pkg/controller/agentversion"
 	"antrea.io/antrea/pkg/controller/crdmirroring"
 	"antrea.io/antrea/pkg/controller/crdmirroring/crdhandler"
 	"antrea.io/antrea/pkg/controller/egress"
 	egressstore "antrea.io/antrea/pkg/controller/egress/store"
+	"antrea.io/antrea/pkg/controller/groupevents"
 	"antrea.io/antrea/pkg/controller/grouping"
 	"antrea.io/antrea/pkg/controller/metrics"
 	"antrea.io/antrea/pkg/controller/networkpolicy"
@@ -76,6 +82,15 @@ const (
 	// able to handle watch timeouts gracefully but recommends using a large value in
 	// production.
 	serverMinWatchTimeout = 2 * time.Hour
+
+	// shutdownTimeout is the maximum time to wait for the watches to be drained and the components to stop after
+	// receiving the termination signal.
+	shutdownTimeout = 10 * time.Second
+
+	// delegatedAuthCacheTTL is how long the answers of the kube-apiserver to the authentication and authorization
+	// requests delegated by the Antrea apiserver are cached. It's larger than the default (10s), so that the Antrea
+	// clients which reconnect while the kube-apiserver is briefly unavailable can still be authenticated.
+	delegatedAuthCacheTTL = 2 * time.Minute
 )
 
 var allowedPaths = []string{
@@ -92,6 +107,8 @@ var allowedPaths = []string{
 	"/validate/externalippool",
 	"/validate/egress",
 	"/convert/clustergroup",
+	"/convert/networkpolicy",
+	"/convert/clusternetworkpolicy",
 }
 
 // run starts Antrea Controller with the given options and waits for termination signal.
@@ -111,9 +128,11 @@ func run(o *Options) error {
 	serviceInformer := informerFactory.Core().V1().Services()
 	networkPolicyInformer := informerFactory.Networking().V1().NetworkPolicies()
 	nodeInformer := informerFactory.Core().V1().Nodes()
-	cnpInformer := crdInformerFactory.Crd().V1alpha1().ClusterNetworkPolicies()
+	cnpInformer := crdInformerFactory.Crd().V1beta1().ClusterNetworkPolicies()
+	cnpv1a1Informer := crdInformerFactory.Crd().V1alpha1().ClusterNetworkPolicies()
 	eeInformer := crdInformerFactory.Crd().V1alpha2().ExternalEntities()
-	anpInformer := crdInformerFactory.Crd().V1alpha1().NetworkPolicies()
+	anpInformer := crdInformerFactory.Crd().V1beta1().NetworkPolicies()
+	anpv1a1Informer := crdInformerFactory.Crd().V1alpha1().NetworkPolicies()
 	tierInformer := crdInformerFactory.Crd().V1alpha1().Tiers()
 	tfInformer := crdInformerFactory.Crd().V1alpha1().Traceflows()
 	cgv1a2Informer := crdInformerFactory.Crd().V1alpha2().ClusterGroups()
@@ -154,6 +173,7 @@ func run(o *Options) error {
 		groupEntityIndex,
 		namespaceInformer,
 		serviceInformer,
+		nodeInformer,
 		networkPolicyInformer,
 		cnpInformer,
 		anpInformer,
@@ -162,7 +182,8 @@ func run(o *Options) error {
 		addressGroupStore,
 		appliedToGroupStore,
 		networkPolicyStore,
-		groupStore)
+		groupStore,
+		o.config.NetworkPolicyFlowQuotaPerNamespace)
 
 	var networkPolicyStatusController *networkpolicy.StatusController
 	if features.DefaultFeatureGate.Enabled(features.AntreaPolicy) {
@@ -175,20 +196,20 @@ func run(o *Options) error {
 	var cgMirroringController *crdmirroring.Controller
 	var eeMirroringController *crdmirroring.Controller
 	if features.DefaultFeatureGate.Enabled(features.AntreaPolicy) && o.config.LegacyCRDMirroring {
-		anpMirroringHandler := crdhandler.NewNetworkPolicyHandler(anpInformer.Lister(),
+		anpMirroringHandler := crdhandler.NewNetworkPolicyHandler(anpv1a1Informer.Lister(),
 			legacyANPInformer.Lister(),
 			crdClient,
 			legacyCRDClient)
-		anpMirroringController = crdmirroring.NewController(anpInformer.Informer(),
+		anpMirroringController = crdmirroring.NewController(anpv1a1Informer.Informer(),
 			legacyANPInformer.Informer(),
 			anpMirroringHandler,
 			"NetworkPolicy")
 
-		cnpMirroringHandler := crdhandler.NewClusterNetworkPolicyHandler(cnpInformer.Lister(),
+		cnpMirroringHandler := crdhandler.NewClusterNetworkPolicyHandler(cnpv1a1Informer.Lister(),
 			legacyCNPInformer.Lister(),
 			crdClient.CrdV1alpha1().ClusterNetworkPolicies(),
 			legacyCRDClient.SecurityV1alpha1().ClusterNetworkPolicies())
-		cnpMirroringController = crdmirroring.NewController(cnpInformer.Informer(),
+		cnpMirroringController = crdmirroring.NewController(cnpv1a1Informer.Informer(),
 			legacyCNPInformer.Informer(),
 			cnpMirroringHandler,
 			"ClusterNetworkPolicy")
@@ -223,7 +244,22 @@ func run(o *Options) error {
 
 	endpointQuerier := networkpolicy.NewEndpointQuerier(networkPolicyController)
 
-	controllerQuerier := querier.NewControllerQuerier(networkPolicyController, o.config.APIPort)
+	groupEventRecorder := groupevents.NewRecorder(addressGroupStore, networkPolicyStore, groupevents.DefaultRetention)
+
+	// The controlplane API is served to antrea-agents directly on the antrea-controller endpoint, so
+	// antrea-controller can still run in a degraded mode when the APIServices cannot be registered.
+	apiAggregationAvailable, err := apiserver.CheckAPIAggregation(aggregatorClient)
+	if err != nil {
+		return fmt.Errorf("error checking K8s API aggregation layer: %v", err)
+	}
+	if !apiAggregationAvailable {
+		klog.Warning("Running without the K8s API aggregation layer, the stats API is disabled")
+		aggregatorClient = nil
+	}
+
+	agentVersionTracker := agentversion.NewTracker(version.GetFullVersion(), nodeInformer)
+
+	controllerQuerier := querier.NewControllerQuerier(networkPolicyController, agentVersionTracker, o.config.APIPort, apiAggregationAvailable)
 
 	controllerMonitor := monitor.NewControllerMonitor(crdClient, legacyCRDClient, nodeInformer, controllerQuerier)
 
@@ -256,6 +292,14 @@ func run(o *Options) error {
 		statsAggregator = stats.NewAggregator(networkPolicyInformer, cnpInformer, anpInformer)
 	}
 
+	// The antrea-controller authenticates to the antrea-agents with the same credentials as to the
+	// K8s API, to retrieve the flows of a policy from all the Nodes it spans.
+	agentClientConfig, err := k8s.CreateRestConfig(o.config.ClientConnection, "")
+	if err != nil {
+		return fmt.Errorf("error creating antrea-agent client config: %v", err)
+	}
+	policyFlowGetter := policyflows.NewAgentFlowGetter(nodeInformer.Lister(), crdClient, agentClientConfig)
+
 	cipherSuites, err := cipher.GenerateCipherSuitesList(o.config.TLSCipherSuites)
 	if err != nil {
 		return fmt.Errorf("error generating Cipher Suite list: %v", err)
@@ -273,14 +317,19 @@ func run(o *Options) error {
 		groupStore,
 		egressGroupStore,
 		controllerQuerier,
+		agentVersionTracker,
+		o.config.RejectUnsupportedAgentVersionSkew,
 		endpointQuerier,
+		groupEventRecorder,
 		networkPolicyController,
 		networkPolicyStatusController,
 		egressController,
 		statsAggregator,
+		policyFlowGetter,
 		o.config.EnablePrometheusMetrics,
 		cipherSuites,
-		cipher.TLSVersionMap[o.config.TLSMinVersion])
+		cipher.TLSVersionMap[o.config.TLSMinVersion],
+		apiAggregationAvailable)
 	if err != nil {
 		return fmt.Errorf("error creating API server config: %v", err)
 	}
@@ -289,9 +338,11 @@ func run(o *Options) error {
 		return fmt.Errorf("error creating API server: %v", err)
 	}
 
-	err = apiserver.CleanupDeprecatedAPIServices(aggregatorClient)
-	if err != nil {
-		return fmt.Errorf("failed to clean up the deprecated APIServices: %v", err)
+	if apiAggregationAvailable {
+		err = apiserver.CleanupDeprecatedAPIServices(aggregatorClient)
+		if err != nil {
+			return fmt.Errorf("failed to clean up the deprecated APIServices: %v", err)
+		}
 	}
 
 	// Set up signal capture: the first SIGTERM / SIGINT signal is handled gracefully and will
@@ -311,12 +362,20 @@ func run(o *Options) error {
 
 	go groupEntityController.Run(stopCh)
 
-	go networkPolicyController.Run(stopCh)
+	go networkPolicyController.Run(o.config.NetworkPolicyControllerWorkers, stopCh)
+
+	go groupEventRecorder.Run(stopCh)
 
 	go apiServer.Run(stopCh)
 
+	// componentsWG tracks the components which have work to finish before exiting.
+	var componentsWG sync.WaitGroup
 	if features.DefaultFeatureGate.Enabled(features.NetworkPolicyStats) {
-		go statsAggregator.Run(stopCh)
+		componentsWG.Add(1)
+		go func() {
+			defer componentsWG.Done()
+			statsAggregator.Run(stopCh)
+		}()
 	}
 
 	if o.config.EnablePrometheusMetrics {
@@ -329,6 +388,9 @@ func run(o *Options) error {
 
 	if features.DefaultFeatureGate.Enabled(features.AntreaPolicy) {
 		go networkPolicyStatusController.Run(stopCh)
+		// Antrea-native policies created before their CRDs graduated to v1beta1 are still stored
+		// as v1alpha1 objects until they are rewritten.
+		go networkpolicy.NewStorageVersionMigrator(crdClient, apiExtensionClient).Run(stopCh)
 	}
 
 	if o.config.LegacyCRDMirroring {
@@ -349,9 +411,39 @@ func run(o *Options) error {
 
 	<-stopCh
 	klog.Info("Stopping Antrea controller")
+	shutdown(&componentsWG, addressGroupStore, appliedToGroupStore, networkPolicyStore, egressGroupStore, groupStore)
 	return nil
 }
 
+// shutdown drains the watches served by the provided stores and waits for the components tracked by componentsWG to
+// stop, giving up after shutdownTimeout.
+func shutdown(componentsWG *sync.WaitGroup, stores ...storage.Interface) {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	var storesWG sync.WaitGroup
+	for _, s := range stores {
+		storesWG.Add(1)
+		go func(s storage.Interface) {
+			defer storesWG.Done()
+			s.Shutdown(ctx)
+		}(s)
+	}
+	storesWG.Wait()
+
+	done := make(chan struct{})
+	go func() {
+		componentsWG.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		klog.Info("All components have been stopped")
+	case <-ctx.Done():
+		klog.Warningf("Timed out waiting for components to stop after %v", shutdownTimeout)
+	}
+}
+
 func createAPIServerConfig(kubeconfig string,
 	client clientset.Interface,
 	aggregatorClient aggregatorclientset.Interface,
@@ -364,17 +456,24 @@ func createAPIServerConfig(kubeconfig string,
 	groupStore storage.Interface,
 	egressGroupStore storage.Interface,
 	controllerQuerier querier.ControllerQuerier,
+	agentVersionTracker *agentversion.Tracker,
+	rejectUnsupportedAgentVersionSkew bool,
 	endpointQuerier networkpolicy.EndpointQuerier,
+	groupEventRecorder *groupevents.Recorder,
 	npController *networkpolicy.NetworkPolicyController,
 	networkPolicyStatusController *networkpolicy.StatusController,
 	egressController *egress.EgressController,
 	statsAggregator *stats.Aggregator,
+	policyFlowGetter policyflows.AgentFlowGetter,
 	enableMetrics bool,
 	cipherSuites []uint16,
-	tlsMinVersion uint16) (*apiserver.Config, error) {
+	tlsMinVersion uint16,
+	apiAggregationAvailable bool) (*apiserver.Config, error) {
 	secureServing := genericoptions.NewSecureServingOptions().WithLoopback()
 	authentication := genericoptions.NewDelegatingAuthenticationOptions()
 	authorization := genericoptions.NewDelegatingAuthorizationOptions().WithAlwaysAllowPaths(allowedPaths...)
+	authentication.CacheTTL = delegatedAuthCacheTTL
+	authorization.AllowCacheTTL = delegatedAuthCacheTTL
 
 	caCertController, err := certificate.ApplyServerCert(selfSignedCert, client, aggregatorClient, apiExtensionClient, secureServing)
 	if err != nil {
@@ -412,6 +511,10 @@ func createAPIServerConfig(kubeconfig string,
 	serverConfig.OpenAPIConfig.Info.Title = "Antrea"
 	serverConfig.EnableMetrics = enableMetrics
 	serverConfig.MinRequestTimeout = int(serverMinWatchTimeout.Seconds())
+	serverConfig.BuildHandlerChainFunc = func(apiHandler http.Handler, c *genericapiserver.Config) http.Handler {
+		handler := apiserver.WithAgentVersionCheck(apiHandler, agentVersionTracker, rejectUnsupportedAgentVersionSkew)
+		return genericapiserver.DefaultBuildHandlerChain(apiserver.WithControlplaneCompression(handler), c)
+	}
 	serverConfig.SecureServing.CipherSuites = cipherSuites
 	serverConfig.SecureServing.MinTLSVersion = tlsMinVersion
 
@@ -428,6 +531,9 @@ func createAPIServerConfig(kubeconfig string,
 		controllerQuerier,
 		networkPolicyStatusController,
 		endpointQuerier,
+		groupEventRecorder,
 		npController,
-		egressController), nil
+		egressController,
+		policyFlowGetter,
+		apiAggregationAvailable), nil
 }
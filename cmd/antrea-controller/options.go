@@ -16,12 +16,14 @@ package main
 
 import (
 	"errors"
+	"fmt"
 	"io/ioutil"
 
 	"github.com/spf13/pflag"
 	"gopkg.in/yaml.v2"
 
 	"antrea.io/antrea/pkg/apis"
+	"antrea.io/antrea/pkg/controller/networkpolicy"
 	"antrea.io/antrea/pkg/features"
 )
 
@@ -63,6 +65,12 @@ func (o *Options) validate(args []string) error {
 	if len(args) != 0 {
 		return errors.New("no positional arguments are supported")
 	}
+	if o.config.NetworkPolicyControllerWorkers < 0 {
+		return fmt.Errorf("networkPolicyControllerWorkers must be positive, got %d", o.config.NetworkPolicyControllerWorkers)
+	}
+	if o.config.NetworkPolicyFlowQuotaPerNamespace < 0 {
+		return fmt.Errorf("networkPolicyFlowQuotaPerNamespace must not be negative, got %d", o.config.NetworkPolicyFlowQuotaPerNamespace)
+	}
 	return nil
 }
 
@@ -79,4 +87,7 @@ func (o *Options) setDefaults() {
 	if o.config.APIPort == 0 {
 		o.config.APIPort = apis.AntreaControllerAPIPort
 	}
+	if o.config.NetworkPolicyControllerWorkers == 0 {
+		o.config.NetworkPolicyControllerWorkers = networkpolicy.DefaultWorkers
+	}
 }
@@ -64,7 +64,11 @@ $GOPATH/bin/lister-gen \
   --input-dirs "${ANTREA_PKG}/pkg/apis/crd/v1alpha2" \
   --input-dirs "${ANTREA_PKG}/pkg/apis/crd/v1alpha3" \
   --input-dirs "${ANTREA_PKG}/pkg/apis/crd/v1beta1" \
+  --input-dirs "${ANTREA_PKG}/pkg/apis/stats/v1alpha1" \
   --output-package "${ANTREA_PKG}/pkg/client/listers" \
+  --plural-exceptions "NetworkPolicyStats:NetworkPolicyStats" \
+  --plural-exceptions "AntreaNetworkPolicyStats:AntreaNetworkPolicyStats" \
+  --plural-exceptions "AntreaClusterNetworkPolicyStats:AntreaClusterNetworkPolicyStats" \
   --go-header-file hack/boilerplate/license_header.go.txt
 
 # Generate informers with K8s codegen tools.
@@ -163,7 +167,7 @@ MOCKGEN_TARGETS=(
   "pkg/agent/flowexporter/connections ConnTrackDumper,NetFilterConnTrack testing"
   "pkg/agent/interfacestore InterfaceStore testing"
   "pkg/agent/nodeportlocal/rules PodPortRules testing"
-  "pkg/agent/openflow Client,OFEntryOperations testing"
+  "pkg/agent/openflow Client,OFEntryOperations,PacketInClient,PacketOutClient,PodConnectivityClient,PolicyClient,ServiceClient,TraceflowClient testing"
   "pkg/agent/proxy Proxier testing"
   "pkg/agent/querier AgentQuerier testing"
   "pkg/agent/route Interface testing"
@@ -30,12 +30,14 @@ import (
 	"k8s.io/apimachinery/pkg/util/wait"
 	clientset "k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/util/retry"
+	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog/v2"
 
 	"antrea.io/antrea/pkg/agent/cniserver"
 	"antrea.io/antrea/pkg/agent/config"
 	"antrea.io/antrea/pkg/agent/controller/noderoute"
 	"antrea.io/antrea/pkg/agent/interfacestore"
+	"antrea.io/antrea/pkg/agent/metrics"
 	"antrea.io/antrea/pkg/agent/openflow"
 	"antrea.io/antrea/pkg/agent/openflow/cookie"
 	"antrea.io/antrea/pkg/agent/route"
@@ -51,10 +53,19 @@ const (
 	defaultTunInterfaceName = "antrea-tun0"
 	maxRetryForHostLink     = 5
 	// ipsecPSKEnvKey is environment variable.
-	ipsecPSKEnvKey          = "ANTREA_IPSEC_PSK"
-	roundNumKey             = "roundNum" // round number key in externalIDs.
+	ipsecPSKEnvKey = "ANTREA_IPSEC_PSK"
+	roundNumKey    = "roundNum" // round number key in externalIDs.
+	// roundGenerationKey is the key in externalIDs of the generation of the round number, which
+	// is incremented every time the round number wraps around.
+	roundGenerationKey = "roundGeneration"
+	// trafficEncapModeKey is the key in externalIDs of the traffic encap mode which was used
+	// by the last agent run which successfully initialized the Node network.
+	trafficEncapModeKey     = "trafficEncapMode"
 	initialRoundNum         = 1
 	maxRetryForRoundNumSave = 5
+	// interfaceStoreInitWorkers is the number of workers parsing the OVS ports when initializing
+	// the interface store.
+	interfaceStoreInitWorkers = 8
 )
 
 // getIPNetDeviceFromIP is meant to be overridden for testing.
@@ -119,6 +130,21 @@ func (i *Initializer) GetNodeConfig() *config.NodeConfig {
 	return i.nodeConfig
 }
 
+// UpdateServiceCIDRs updates the Service CIDRs after Initialize was called, and reprograms the
+// flows for the Service CIDRs. When AntreaProxy is enabled, the flows for the advertised Service
+// CIDR do not depend on its value and are left unchanged.
+func (i *Initializer) UpdateServiceCIDRs(serviceCIDR, serviceCIDRv6 *net.IPNet) error {
+	i.serviceCIDR = serviceCIDR
+	i.serviceCIDRv6 = serviceCIDRv6
+	if i.enableProxy {
+		return nil
+	}
+	if err := i.ofClient.InstallClusterServiceCIDRFlows([]*net.IPNet{serviceCIDR, serviceCIDRv6}); err != nil {
+		return fmt.Errorf("failed to update OpenFlow entries for Service CIDRs: %v", err)
+	}
+	return nil
+}
+
 // setupOVSBridge sets up the OVS bridge and create host gateway interface and tunnel port
 func (i *Initializer) setupOVSBridge() error {
 	if err := i.ovsBridgeClient.Create(); err != nil {
@@ -148,7 +174,10 @@ func (i *Initializer) setupOVSBridge() error {
 }
 
 // initInterfaceStore initializes InterfaceStore with all OVS ports retrieved
-// from the OVS bridge.
+// from the OVS bridge. The ports are retrieved with a single OVSDB transaction,
+// and their external IDs are parsed by parallel workers. The container ports
+// with malformed external IDs are not adopted but quarantined in InterfaceStore,
+// so that they can be found and cleaned up manually.
 func (i *Initializer) initInterfaceStore() error {
 	ovsPorts, err := i.ovsBridgeClient.GetPortList()
 	if err != nil {
@@ -156,28 +185,22 @@ func (i *Initializer) initInterfaceStore() error {
 		return err
 	}
 
-	ifaceList := make([]*interfacestore.InterfaceConfig, 0, len(ovsPorts))
 	uplinkIfName := i.nodeConfig.UplinkNetConfig.Name
-	for index := range ovsPorts {
+	intfs := make([]*interfacestore.InterfaceConfig, len(ovsPorts))
+	quarantineErrs := make([]error, len(ovsPorts))
+	parsePort := func(index int) {
 		port := &ovsPorts[index]
 		ovsPort := &interfacestore.OVSPortConfig{
 			PortUUID: port.UUID,
 			OFPort:   port.OFPort}
-		var intf *interfacestore.InterfaceConfig
 		switch {
 		case port.OFPort == config.HostGatewayOFPort:
-			intf = &interfacestore.InterfaceConfig{
+			intfs[index] = &interfacestore.InterfaceConfig{
 				Type:          interfacestore.GatewayInterface,
 				InterfaceName: port.Name,
 				OVSPortConfig: ovsPort}
-			if intf.InterfaceName != i.hostGateway {
-				klog.Warningf("The discovered gateway interface name %s is different from the configured value: %s",
-					intf.InterfaceName, i.hostGateway)
-				// Set the gateway interface name to the discovered name.
-				i.hostGateway = intf.InterfaceName
-			}
 		case port.Name == uplinkIfName:
-			intf = &interfacestore.InterfaceConfig{
+			intfs[index] = &interfacestore.InterfaceConfig{
 				Type:          interfacestore.UplinkInterface,
 				InterfaceName: port.Name,
 				OVSPortConfig: ovsPort,
@@ -189,24 +212,59 @@ func (i *Initializer) initInterfaceStore() error {
 		case port.IFType == ovsconfig.GRETunnel:
 			fallthrough
 		case port.IFType == ovsconfig.STTTunnel:
-			intf = noderoute.ParseTunnelInterfaceConfig(port, ovsPort)
-			if intf != nil && port.OFPort == config.DefaultTunOFPort &&
-				intf.InterfaceName != i.nodeConfig.DefaultTunName {
-				klog.Infof("The discovered default tunnel interface name %s is different from the default value: %s",
-					intf.InterfaceName, i.nodeConfig.DefaultTunName)
-				// Set the default tunnel interface name to the discovered name.
-				i.nodeConfig.DefaultTunName = intf.InterfaceName
-			}
+			intfs[index] = noderoute.ParseTunnelInterfaceConfig(port, ovsPort)
 		default:
 			// The port should be for a container interface.
-			intf = cniserver.ParseOVSPortInterfaceConfig(port, ovsPort, true)
+			if err := cniserver.ValidateOVSPortExternalIDs(port, true); err != nil {
+				quarantineErrs[index] = err
+				return
+			}
+			intfs[index] = cniserver.ParseOVSPortInterfaceConfig(port, ovsPort, true)
 		}
-		if intf != nil {
-			ifaceList = append(ifaceList, intf)
+	}
+	workqueue.ParallelizeUntil(context.TODO(), interfaceStoreInitWorkers, len(ovsPorts), parsePort)
+
+	ifaceList := make([]*interfacestore.InterfaceConfig, 0, len(ovsPorts))
+	var quarantinedPorts []*interfacestore.QuarantinedPort
+	for index := range ovsPorts {
+		port := &ovsPorts[index]
+		if quarantineErrs[index] != nil {
+			klog.Errorf("Quarantined OVS port %s (UUID %s) with malformed external IDs %v: %v",
+				port.Name, port.UUID, port.ExternalIDs, quarantineErrs[index])
+			quarantinedPorts = append(quarantinedPorts, &interfacestore.QuarantinedPort{
+				Name:        port.Name,
+				PortUUID:    port.UUID,
+				OFPort:      port.OFPort,
+				ExternalIDs: port.ExternalIDs,
+				Reason:      quarantineErrs[index].Error(),
+			})
+			continue
+		}
+		intf := intfs[index]
+		if intf == nil {
+			continue
+		}
+		switch {
+		case intf.Type == interfacestore.GatewayInterface && intf.InterfaceName != i.hostGateway:
+			klog.Warningf("The discovered gateway interface name %s is different from the configured value: %s",
+				intf.InterfaceName, i.hostGateway)
+			// Set the gateway interface name to the discovered name.
+			i.hostGateway = intf.InterfaceName
+		case intf.Type == interfacestore.TunnelInterface && port.OFPort == config.DefaultTunOFPort &&
+			intf.InterfaceName != i.nodeConfig.DefaultTunName:
+			klog.Infof("The discovered default tunnel interface name %s is different from the default value: %s",
+				intf.InterfaceName, i.nodeConfig.DefaultTunName)
+			// Set the default tunnel interface name to the discovered name.
+			i.nodeConfig.DefaultTunName = intf.InterfaceName
 		}
+		ifaceList = append(ifaceList, intf)
 	}
 
 	i.ifaceStore.Initialize(ifaceList)
+	i.ifaceStore.SetQuarantinedPorts(quarantinedPorts)
+	metrics.OVSRestoredPortCount.WithLabelValues("adopted").Set(float64(len(ifaceList)))
+	metrics.OVSRestoredPortCount.WithLabelValues("quarantined").Set(float64(len(quarantinedPorts)))
+	klog.Infof("Restored %d interfaces from %d OVS ports, quarantined %d ports", len(ifaceList), len(ovsPorts), len(quarantinedPorts))
 	return nil
 }
 
@@ -232,6 +290,13 @@ func (i *Initializer) Initialize() error {
 		return err
 	}
 
+	lastEncapMode, modeChanged := i.checkTrafficEncapModeChange()
+	if modeChanged {
+		if err := i.migrateOVSBridgeFromTrafficEncapMode(lastEncapMode); err != nil {
+			return err
+		}
+	}
+
 	wg.Add(1)
 	// routeClient.Initialize() should be after i.setupOVSBridge() which
 	// creates the host gateway interface.
@@ -239,11 +304,23 @@ func (i *Initializer) Initialize() error {
 		return err
 	}
 
+	if modeChanged {
+		if err := i.migrateHostNetworkFromTrafficEncapMode(lastEncapMode); err != nil {
+			return err
+		}
+	}
+
 	// Install OpenFlow entries on OVS bridge.
 	if err := i.initOpenFlowPipeline(); err != nil {
 		return err
 	}
 
+	if err := saveTrafficEncapMode(i.networkConfig.TrafficEncapMode, i.ovsBridgeClient); err != nil {
+		// Not fatal: the migration will be attempted again on the next restart, and all
+		// migration steps are idempotent.
+		klog.Errorf("Failed to persist traffic encap mode %s to OVSDB: %v", i.networkConfig.TrafficEncapMode, err)
+	}
+
 	// The Node's network is ready only when both synchronous and asynchronous initialization are done.
 	go func() {
 		wg.Wait()
@@ -253,13 +330,14 @@ func (i *Initializer) Initialize() error {
 	return nil
 }
 
-// persistRoundNum will save the provided round number to OVSDB as an external ID. To account for
-// transient failures, this (synchronous) function includes a retry mechanism.
-func persistRoundNum(num uint64, bridgeClient ovsconfig.OVSBridgeClient, interval time.Duration, maxRetries int) {
-	klog.Infof("Persisting round number %d to OVSDB", num)
+// persistRoundNum will save the provided round number and generation to OVSDB as external IDs. To
+// account for transient failures, this (synchronous) function includes a retry mechanism.
+func persistRoundNum(roundInfo types.RoundInfo, bridgeClient ovsconfig.OVSBridgeClient, interval time.Duration, maxRetries int) {
+	num := roundInfo.RoundNum
+	klog.Infof("Persisting round number %d (generation %d) to OVSDB", num, roundInfo.Generation)
 	retry := 0
 	for {
-		err := saveRoundNum(num, bridgeClient)
+		err := saveRoundNum(num, roundInfo.Generation, bridgeClient)
 		if err == nil {
 			klog.Infof("Round number %d was persisted to OVSDB", num)
 			return // success
@@ -276,13 +354,14 @@ func persistRoundNum(num uint64, bridgeClient ovsconfig.OVSBridgeClient, interva
 // initOpenFlowPipeline sets up necessary Openflow entries, including pipeline, classifiers, conn_track, and gateway flows
 // Every time the agent is (re)started, we go through the following sequence:
 //   1. agent determines the new round number (this is done by incrementing the round number
-//   persisted in OVSDB, or if it's not available by picking round 1).
-//   2. any existing flow for which the round number matches the round number obtained from step 1
-//   is deleted.
-//   3. all required flows are installed, using the round number obtained from step 1.
-//   4. after convergence, all existing flows for which the round number matches the previous round
-//   number (i.e. the round number which was persisted in OVSDB, if any) are deleted.
-//   5. the new round number obtained from step 1 is persisted to OVSDB.
+//   persisted in OVSDB, or if it's not available by picking round 1). The generation persisted in
+//   OVSDB is incremented when the round number wraps around.
+//   2. any existing flow for which the round number and generation match the ones obtained from
+//   step 1 is deleted.
+//   3. all required flows are installed, using the round number and generation obtained from step 1.
+//   4. after convergence, all existing flows for which the round number and generation match the
+//   previous ones (i.e. the ones which were persisted in OVSDB, if any) are deleted.
+//   5. the new round number and generation obtained from step 1 are persisted to OVSDB.
 // The rationale for not persisting the new round number until after all previous flows have been
 // deleted is to avoid a situation in which some stale flows are never deleted because of successive
 // agent restarts (with the agent crashing before step 4 can be completed). With the sequence
@@ -350,6 +429,14 @@ func (i *Initializer) initOpenFlowPipeline() error {
 			klog.Errorf("Failed to setup default OpenFlow entries for ClusterIP Services: %v", err)
 			return err
 		}
+		if i.networkConfig.AdvertiseServiceCIDR {
+			// Set up flow entries for the traffic to ClusterIPs which is received from the Node
+			// network and routed to the host gateway interface by the route client.
+			if err := i.ofClient.InstallClusterServiceCIDRFlows([]*net.IPNet{i.serviceCIDR}); err != nil {
+				klog.Errorf("Failed to setup OpenFlow entries for advertised Service CIDR: %v", err)
+				return err
+			}
+		}
 	}
 
 	go func() {
@@ -370,7 +457,7 @@ func (i *Initializer) initOpenFlowPipeline() error {
 			klog.Errorf("Error when deleting stale flows from previous round: %v", err)
 			return
 		}
-		persistRoundNum(roundInfo.RoundNum, i.ovsBridgeClient, 1*time.Second, maxRetryForRoundNumSave)
+		persistRoundNum(roundInfo, i.ovsBridgeClient, 1*time.Second, maxRetryForRoundNumSave)
 	}()
 
 	go func() {
@@ -507,6 +594,15 @@ func (i *Initializer) configureGatewayInterface(gatewayIface *interfacestore.Int
 	var gwMAC net.HardwareAddr
 	var gwLinkIdx int
 	var err error
+	// Idempotent operation to set the gateway's MAC: like the MTU, the desired MAC may change
+	// across restarts, in which case the interface is reprogrammed and the gateway flows are
+	// installed with the new MAC by initOpenFlowPipeline.
+	if i.networkConfig.GatewayMAC != nil {
+		klog.V(2).Infof("Setting gateway interface %s MAC to %s", i.hostGateway, i.networkConfig.GatewayMAC)
+		if err := i.ovsBridgeClient.SetInterfaceMAC(i.hostGateway, i.networkConfig.GatewayMAC); err != nil {
+			return fmt.Errorf("failed to set MAC of gateway interface %s to %s: %v", i.hostGateway, i.networkConfig.GatewayMAC, err)
+		}
+	}
 	// Host link might not be queried at once after creating OVS internal port; retry max 5 times with 1s
 	// delay each time to ensure the link is ready.
 	for retry := 0; retry < maxRetryForHostLink; retry++ {
@@ -527,6 +623,10 @@ func (i *Initializer) configureGatewayInterface(gatewayIface *interfacestore.Int
 		klog.Errorf("Failed to find host link for gateway %s: %v", i.hostGateway, err)
 		return err
 	}
+	if i.networkConfig.GatewayMAC != nil && gwMAC.String() != i.networkConfig.GatewayMAC.String() {
+		return fmt.Errorf("gateway interface %s has MAC %s which conflicts with the configured gatewayMAC %s, "+
+			"make sure the MAC is not managed by another component", i.hostGateway, gwMAC, i.networkConfig.GatewayMAC)
+	}
 
 	i.nodeConfig.GatewayConfig = &config.GatewayConfig{Name: i.hostGateway, MAC: gwMAC}
 	gatewayIface.MAC = gwMAC
@@ -571,7 +671,9 @@ func (i *Initializer) setupDefaultTunnelInterface() error {
 	if portExists {
 		if i.networkConfig.TrafficEncapMode.SupportsEncap() &&
 			tunnelIface.TunnelInterfaceConfig.Type == i.networkConfig.TunnelType &&
-			tunnelIface.TunnelInterfaceConfig.LocalIP.Equal(localIP) {
+			tunnelIface.TunnelInterfaceConfig.LocalIP.Equal(localIP) &&
+			tunnelIface.TunnelInterfaceConfig.DstPort == i.networkConfig.TunnelPort &&
+			tunnelIface.TunnelInterfaceConfig.TOS == i.networkConfig.TunnelTOS {
 			klog.V(2).Infof("Tunnel port %s already exists on OVS bridge", tunnelPortName)
 			// This could happen when upgrading from previous versions that didn't set it.
 			if shouldEnableCsum && !tunnelIface.TunnelInterfaceConfig.Csum {
@@ -585,7 +687,7 @@ func (i *Initializer) setupDefaultTunnelInterface() error {
 
 		if err := i.ovsBridgeClient.DeletePort(tunnelIface.PortUUID); err != nil {
 			if i.networkConfig.TrafficEncapMode.SupportsEncap() {
-				return fmt.Errorf("failed to remove tunnel port %s with wrong tunnel type: %s", tunnelPortName, err)
+				return fmt.Errorf("failed to remove tunnel port %s with wrong tunnel configuration: %s", tunnelPortName, err)
 			} else {
 				klog.Errorf("Failed to remove tunnel port %s in NoEncapMode: %v", tunnelPortName, err)
 			}
@@ -603,12 +705,12 @@ func (i *Initializer) setupDefaultTunnelInterface() error {
 			tunnelPortName = defaultTunInterfaceName
 			i.nodeConfig.DefaultTunName = tunnelPortName
 		}
-		tunnelPortUUID, err := i.ovsBridgeClient.CreateTunnelPortExt(tunnelPortName, i.networkConfig.TunnelType, config.DefaultTunOFPort, shouldEnableCsum, localIPStr, "", "", nil)
+		tunnelPortUUID, err := i.ovsBridgeClient.CreateTunnelPortExt(tunnelPortName, i.networkConfig.TunnelType, config.DefaultTunOFPort, shouldEnableCsum, i.networkConfig.TunnelPort, i.networkConfig.TunnelTOS, localIPStr, "", "", nil)
 		if err != nil {
 			klog.Errorf("Failed to create tunnel port %s type %s on OVS bridge: %v", tunnelPortName, i.networkConfig.TunnelType, err)
 			return err
 		}
-		tunnelIface = interfacestore.NewTunnelInterface(tunnelPortName, i.networkConfig.TunnelType, localIP, shouldEnableCsum)
+		tunnelIface = interfacestore.NewTunnelInterface(tunnelPortName, i.networkConfig.TunnelType, localIP, shouldEnableCsum, i.networkConfig.TunnelPort, i.networkConfig.TunnelTOS)
 		tunnelIface.OVSPortConfig = &interfacestore.OVSPortConfig{PortUUID: tunnelPortUUID, OFPort: config.DefaultTunOFPort}
 		i.ifaceStore.AddInterface(tunnelIface)
 	}
@@ -651,16 +753,23 @@ func (i *Initializer) initNodeLocalConfig() error {
 		return fmt.Errorf("failed to get local IPNet device with IP %v: %v", ipAddr, err)
 	}
 
+	annotations := map[string]string{}
 	// Update the Node's MAC address in the annotations of the Node. The MAC address will be used for direct routing by
 	// OVS in noencap case on Windows Nodes. As a mixture of Linux and Windows nodes is possible, Linux Nodes' MAC
 	// addresses should be reported too to make them discoverable for Windows Nodes.
 	if i.networkConfig.TrafficEncapMode.SupportsNoEncap() {
-		klog.Infof("Updating Node MAC annotation")
+		annotations[types.NodeMACAddressAnnotationKey] = localIntf.HardwareAddr.String()
+	}
+	// Publish the tunnel configuration of the Node, so that the peer Nodes can detect that it is not consistent with
+	// theirs, as the tunnel traffic would be dropped.
+	if i.networkConfig.TrafficEncapMode.SupportsEncap() {
+		annotations[types.NodeTunnelConfigAnnotationKey] = i.networkConfig.TunnelConfig()
+	}
+	if len(annotations) > 0 {
+		klog.Infof("Updating Node annotations %v", annotations)
 		patch, _ := json.Marshal(map[string]interface{}{
 			"metadata": map[string]interface{}{
-				"annotations": map[string]string{
-					types.NodeMACAddressAnnotationKey: localIntf.HardwareAddr.String(),
-				},
+				"annotations": annotations,
 			},
 		})
 		if err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
@@ -781,23 +890,118 @@ func (i *Initializer) readIPSecPSK() error {
 	return nil
 }
 
-func getLastRoundNum(bridgeClient ovsconfig.OVSBridgeClient) (uint64, error) {
+// checkTrafficEncapModeChange compares the traffic encap mode persisted in OVSDB by the last agent
+// run with the configured one. It returns the last mode, and true if it is known and differs from
+// the configured mode.
+func (i *Initializer) checkTrafficEncapModeChange() (config.TrafficEncapModeType, bool) {
+	lastEncapMode, err := getLastTrafficEncapMode(i.ovsBridgeClient)
+	if err != nil {
+		klog.Infof("No valid traffic encap mode found in OVSDB, skipping traffic encap mode migration: %v", err)
+		return config.TrafficEncapModeInvalid, false
+	}
+	if lastEncapMode == i.networkConfig.TrafficEncapMode {
+		return lastEncapMode, false
+	}
+	klog.Infof("Traffic encap mode changed from %s to %s, migrating Node network configuration", lastEncapMode, i.networkConfig.TrafficEncapMode)
+	return lastEncapMode, true
+}
+
+// migrateOVSBridgeFromTrafficEncapMode removes the OVS ports which were created for the last
+// traffic encap mode and which no longer apply. It must be called after setupOVSBridge, which
+// already reconciles the default tunnel port with the configured mode.
+func (i *Initializer) migrateOVSBridgeFromTrafficEncapMode(lastEncapMode config.TrafficEncapModeType) error {
+	if !lastEncapMode.SupportsEncap() || i.networkConfig.TrafficEncapMode.SupportsEncap() {
+		return nil
+	}
+	// Tunnel ports dedicated to peer Nodes, e.g. for IPsec, are no longer needed when the new
+	// mode doesn't support encap.
+	for _, tunnelIface := range i.ifaceStore.GetInterfacesByType(interfacestore.TunnelInterface) {
+		if err := i.ovsBridgeClient.DeletePort(tunnelIface.PortUUID); err != nil {
+			return fmt.Errorf("failed to delete tunnel port %s when migrating from traffic encap mode %s: %v", tunnelIface.InterfaceName, lastEncapMode, err)
+		}
+		i.ifaceStore.DeleteInterface(tunnelIface)
+		klog.Infof("Traffic encap mode migration: deleted tunnel port %s", tunnelIface.InterfaceName)
+	}
+	return nil
+}
+
+// migrateHostNetworkFromTrafficEncapMode removes the host network configuration which was created
+// for the last traffic encap mode and which no longer applies. The iptables rules, including the
+// SNAT rules, are fully re-rendered for the configured mode by the route client, so only the routes
+// to peer Nodes need to be removed: they will be installed again for the configured mode by the
+// NodeRouteController.
+func (i *Initializer) migrateHostNetworkFromTrafficEncapMode(lastEncapMode config.TrafficEncapModeType) error {
+	klog.Infof("Traffic encap mode migration: iptables rules are synced for traffic encap mode %s", i.networkConfig.TrafficEncapMode)
+	if err := i.routeClient.Reconcile(nil); err != nil {
+		return fmt.Errorf("failed to delete routes to peer Nodes when migrating from traffic encap mode %s: %v", lastEncapMode, err)
+	}
+	klog.Info("Traffic encap mode migration: deleted routes to peer Nodes")
+	return nil
+}
+
+func getLastTrafficEncapMode(bridgeClient ovsconfig.OVSBridgeClient) (config.TrafficEncapModeType, error) {
 	extIDs, ovsCfgErr := bridgeClient.GetExternalIDs()
 	if ovsCfgErr != nil {
-		return 0, fmt.Errorf("error getting external IDs: %w", ovsCfgErr)
+		return config.TrafficEncapModeInvalid, fmt.Errorf("error getting external IDs: %w", ovsCfgErr)
+	}
+	modeValue, exists := extIDs[trafficEncapModeKey]
+	if !exists {
+		return config.TrafficEncapModeInvalid, fmt.Errorf("no traffic encap mode found in OVSDB")
+	}
+	ok, mode := config.GetTrafficEncapModeFromStr(modeValue)
+	if !ok {
+		return config.TrafficEncapModeInvalid, fmt.Errorf("unknown traffic encap mode %s", modeValue)
+	}
+	return mode, nil
+}
+
+func saveTrafficEncapMode(mode config.TrafficEncapModeType, bridgeClient ovsconfig.OVSBridgeClient) error {
+	return saveExternalID(trafficEncapModeKey, mode.String(), bridgeClient)
+}
+
+// getLastRoundNum returns the round number and generation persisted in OVSDB. The generation
+// defaults to 0 if it was not persisted, which is the case for flows installed by agents which
+// predate the introduction of generations.
+func getLastRoundNum(bridgeClient ovsconfig.OVSBridgeClient) (uint64, uint64, error) {
+	extIDs, ovsCfgErr := bridgeClient.GetExternalIDs()
+	if ovsCfgErr != nil {
+		return 0, 0, fmt.Errorf("error getting external IDs: %w", ovsCfgErr)
 	}
 	roundNumValue, exists := extIDs[roundNumKey]
 	if !exists {
-		return 0, fmt.Errorf("no round number found in OVSDB")
+		return 0, 0, fmt.Errorf("no round number found in OVSDB")
 	}
 	num, err := strconv.ParseUint(roundNumValue, 10, 64)
 	if err != nil {
-		return 0, fmt.Errorf("error parsing last round number %v: %w", num, err)
+		return 0, 0, fmt.Errorf("error parsing last round number %v: %w", roundNumValue, err)
+	}
+	generationValue, exists := extIDs[roundGenerationKey]
+	if !exists {
+		return num, 0, nil
+	}
+	generation, err := strconv.ParseUint(generationValue, 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("error parsing last round generation %v: %w", generationValue, err)
 	}
-	return num, nil
+	return num, generation, nil
+}
+
+func saveRoundNum(num, generation uint64, bridgeClient ovsconfig.OVSBridgeClient) error {
+	return saveExternalIDs(map[string]string{
+		roundNumKey:        fmt.Sprint(num),
+		roundGenerationKey: fmt.Sprint(generation),
+	}, bridgeClient)
+}
+
+// saveExternalID sets the provided key to the provided value in the external IDs of the bridge,
+// preserving the other external IDs.
+func saveExternalID(key, value string, bridgeClient ovsconfig.OVSBridgeClient) error {
+	return saveExternalIDs(map[string]string{key: value}, bridgeClient)
 }
 
-func saveRoundNum(num uint64, bridgeClient ovsconfig.OVSBridgeClient) error {
+// saveExternalIDs sets the provided keys to the provided values in the external IDs of the bridge
+// in a single transaction, preserving the other external IDs.
+func saveExternalIDs(values map[string]string, bridgeClient ovsconfig.OVSBridgeClient) error {
 	extIDs, ovsCfgErr := bridgeClient.GetExternalIDs()
 	if ovsCfgErr != nil {
 		return fmt.Errorf("error getting external IDs: %w", ovsCfgErr)
@@ -806,13 +1010,15 @@ func saveRoundNum(num uint64, bridgeClient ovsconfig.OVSBridgeClient) error {
 	for k, v := range extIDs {
 		updatedExtIDs[k] = v
 	}
-	updatedExtIDs[roundNumKey] = fmt.Sprint(num)
+	for k, v := range values {
+		updatedExtIDs[k] = v
+	}
 	return bridgeClient.SetExternalIDs(updatedExtIDs)
 }
 
 func getRoundInfo(bridgeClient ovsconfig.OVSBridgeClient) types.RoundInfo {
 	roundInfo := types.RoundInfo{}
-	num, err := getLastRoundNum(bridgeClient)
+	num, generation, err := getLastRoundNum(bridgeClient)
 	if err != nil {
 		klog.Infof("No round number found in OVSDB, using %v", initialRoundNum)
 		// We use a fixed value instead of a randomly-generated value to ensure that stale
@@ -822,18 +1028,32 @@ func getRoundInfo(bridgeClient ovsconfig.OVSBridgeClient) types.RoundInfo {
 	} else {
 		roundInfo.PrevRoundNum = new(uint64)
 		*roundInfo.PrevRoundNum = num
+		roundInfo.PrevGeneration = generation
 		num++
+		// The generation is incremented when the round number wraps around, so that flows
+		// from the previous rounds using the same round number are never confused with
+		// the ones installed by this round.
+		if num >= 1<<cookie.BitwidthRound {
+			generation++
+		}
 	}
 
 	num %= 1 << cookie.BitwidthRound
-	klog.Infof("Using round number %d", num)
+	generation %= 1 << cookie.BitwidthGeneration
+	klog.Infof("Using round number %d (generation %d)", num, generation)
 	roundInfo.RoundNum = num
+	roundInfo.Generation = generation
 
 	return roundInfo
 }
 
 func (i *Initializer) getNodeMTU(localIntf *net.Interface) (int, error) {
 	if i.mtu != 0 {
+		// Pod traffic cannot be larger than the MTU of the transport interface, even when it is not
+		// encapsulated.
+		if localIntf.MTU > 0 && i.mtu > localIntf.MTU {
+			return 0, fmt.Errorf("defaultMTU %d is larger than the MTU %d of the transport interface %s", i.mtu, localIntf.MTU, localIntf.Name)
+		}
 		return i.mtu, nil
 	}
 	mtu := localIntf.MTU
@@ -859,14 +1079,46 @@ func (i *Initializer) getNodeMTU(localIntf *net.Interface) (int, error) {
 	return mtu, nil
 }
 
+// getGatewayIP returns the gateway IP address to use for the provided local subnet. It is the
+// user-provided address of the subnet's IP family if any, otherwise the first address in the
+// subnet. An error is returned if the user-provided address is not a host address of the subnet.
+func (i *Initializer) getGatewayIP(localSubnet *net.IPNet) (net.IP, error) {
+	subnetID := localSubnet.IP.Mask(localSubnet.Mask)
+	configuredIP := i.networkConfig.GatewayIPv6
+	if localSubnet.IP.To4() != nil {
+		configuredIP = i.networkConfig.GatewayIPv4
+	}
+	if configuredIP == nil {
+		return ip.NextIP(subnetID), nil
+	}
+	if !localSubnet.Contains(configuredIP) {
+		return nil, fmt.Errorf("configured gateway IP %s is not in the PodCIDR %s of the Node", configuredIP, localSubnet)
+	}
+	ones, bits := localSubnet.Mask.Size()
+	if bits-ones > 1 {
+		// The subnet ID can't be assigned to the gateway, neither can the broadcast address for IPv4.
+		broadcastIP := make(net.IP, len(subnetID))
+		for idx := range subnetID {
+			broadcastIP[idx] = subnetID[idx] | ^localSubnet.Mask[idx]
+		}
+		if configuredIP.Equal(subnetID) || (configuredIP.To4() != nil && configuredIP.Equal(broadcastIP)) {
+			return nil, fmt.Errorf("configured gateway IP %s is not a host address of the PodCIDR %s of the Node", configuredIP, localSubnet)
+		}
+	}
+	return configuredIP, nil
+}
+
 func (i *Initializer) allocateGatewayAddresses(localSubnets []*net.IPNet, gatewayIface *interfacestore.InterfaceConfig) error {
 	var gwIPs []*net.IPNet
 	for _, localSubnet := range localSubnets {
 		if localSubnet == nil {
 			continue
 		}
-		subnetID := localSubnet.IP.Mask(localSubnet.Mask)
-		gwIP := &net.IPNet{IP: ip.NextIP(subnetID), Mask: localSubnet.Mask}
+		gatewayIP, err := i.getGatewayIP(localSubnet)
+		if err != nil {
+			return err
+		}
+		gwIP := &net.IPNet{IP: gatewayIP, Mask: localSubnet.Mask}
 		gwIPs = append(gwIPs, gwIP)
 	}
 	if len(gwIPs) == 0 {
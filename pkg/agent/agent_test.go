@@ -32,6 +32,7 @@ import (
 	"antrea.io/antrea/pkg/agent/cniserver"
 	"antrea.io/antrea/pkg/agent/config"
 	"antrea.io/antrea/pkg/agent/interfacestore"
+	"antrea.io/antrea/pkg/agent/openflow/cookie"
 	"antrea.io/antrea/pkg/agent/types"
 	"antrea.io/antrea/pkg/ovs/ovsconfig"
 	ovsconfigtest "antrea.io/antrea/pkg/ovs/ovsconfig/testing"
@@ -107,9 +108,59 @@ func TestInitstore(t *testing.T) {
 	}
 }
 
+func TestInitInterfaceStoreQuarantine(t *testing.T) {
+	controller := mock.NewController(t)
+	defer controller.Finish()
+	mockOVSBridgeClient := ovsconfigtest.NewMockOVSBridgeClient(controller)
+
+	store := interfacestore.NewInterfaceStore()
+	initializer := newAgentInitializer(mockOVSBridgeClient, store)
+	uplinkNetConfig := config.AdapterNetConfig{Name: "eth-antrea-test-1"}
+	initializer.nodeConfig = &config.NodeConfig{UplinkNetConfig: &uplinkNetConfig}
+
+	uuid1 := uuid.New().String()
+	p1MAC, _ := net.ParseMAC("11:22:33:44:55:66")
+	validIDs := convertExternalIDMap(cniserver.BuildOVSPortExternalIDs(
+		interfacestore.NewContainerInterface("p1", uuid1, "pod1", "ns1", p1MAC, []net.IP{net.ParseIP("1.1.1.1")})))
+	truncatedIDs := map[string]string{}
+	invalidIPIDs := map[string]string{}
+	for k, v := range validIDs {
+		invalidIPIDs[k] = v
+		if k != "ip-address" && k != "attached-mac" {
+			truncatedIDs[k] = v
+		}
+	}
+	invalidIPIDs["ip-address"] = "1.1.1"
+
+	ovsPorts := []ovsconfig.OVSPortData{
+		{UUID: uuid.New().String(), Name: "antrea-gw0", IFName: "antrea-gw0", OFPort: config.HostGatewayOFPort},
+		{UUID: uuid.New().String(), Name: "br-int", IFName: "br-int"},
+		{UUID: uuid1, Name: "p1", IFName: "p1", OFPort: 11, ExternalIDs: validIDs},
+		{UUID: uuid.New().String(), Name: "p2", IFName: "p2", OFPort: 12, ExternalIDs: truncatedIDs},
+		{UUID: uuid.New().String(), Name: "p3", IFName: "p3", OFPort: 13, ExternalIDs: invalidIPIDs},
+	}
+	mockOVSBridgeClient.EXPECT().GetPortList().Return(ovsPorts, nil)
+	require.NoError(t, initializer.initInterfaceStore())
+
+	assert.Equal(t, 2, store.Len())
+	_, found := store.GetContainerInterface(uuid1)
+	assert.True(t, found, "Valid container port should be adopted")
+	_, found = store.GetInterfaceByName("antrea-gw0")
+	assert.True(t, found, "Gateway port should be adopted")
+
+	quarantined := store.GetQuarantinedPorts()
+	require.Len(t, quarantined, 2)
+	assert.Equal(t, "p2", quarantined[0].Name)
+	assert.Equal(t, int32(12), quarantined[0].OFPort)
+	assert.Equal(t, "missing external IDs ip-address, attached-mac", quarantined[0].Reason)
+	assert.Equal(t, "p3", quarantined[1].Name)
+	assert.Contains(t, quarantined[1].Reason, "invalid IP")
+}
+
 func TestPersistRoundNum(t *testing.T) {
 	const maxRetries = 3
 	const roundNum uint64 = 5555
+	const generation uint64 = 3
 
 	controller := mock.NewController(t)
 	defer controller.Finish()
@@ -121,12 +172,13 @@ func TestPersistRoundNum(t *testing.T) {
 	mockOVSBridgeClient.EXPECT().GetExternalIDs().Return(externalIDs, nil).After(firstCall)
 	newExternalIDs := make(map[string]interface{})
 	newExternalIDs[roundNumKey] = fmt.Sprint(roundNum)
+	newExternalIDs[roundGenerationKey] = fmt.Sprint(generation)
 	mockOVSBridgeClient.EXPECT().SetExternalIDs(mock.Eq(newExternalIDs)).Times(1)
 
 	// The first call to saveRoundNum will fail. Because we set the retry interval to 0,
 	// persistRoundNum should retry immediately and the second call will succeed (as per the
 	// expectations above).
-	persistRoundNum(roundNum, mockOVSBridgeClient, 0, maxRetries)
+	persistRoundNum(types.RoundInfo{RoundNum: roundNum, Generation: generation}, mockOVSBridgeClient, 0, maxRetries)
 }
 
 func TestGetRoundInfo(t *testing.T) {
@@ -141,6 +193,92 @@ func TestGetRoundInfo(t *testing.T) {
 	mockOVSBridgeClient.EXPECT().GetExternalIDs().Return(externalIDs, nil)
 	roundInfo = getRoundInfo(mockOVSBridgeClient)
 	assert.Equal(t, uint64(initialRoundNum), roundInfo.RoundNum, "Unexpected round number")
+	// The generation defaults to 0 when it was not persisted by the previous round.
+	mockOVSBridgeClient.EXPECT().GetExternalIDs().Return(map[string]string{roundNumKey: "5"}, nil)
+	roundInfo = getRoundInfo(mockOVSBridgeClient)
+	prevRoundNum := uint64(5)
+	assert.Equal(t, types.RoundInfo{RoundNum: 6, Generation: 0, PrevRoundNum: &prevRoundNum, PrevGeneration: 0}, roundInfo)
+}
+
+// TestGetRoundInfoRestartCycles simulates several agent restarts, including one which wraps around
+// the round number and one in which the agent crashes before persisting its round number, and
+// verifies that the cookie of the current round never matches the cookie mask of the previous one.
+func TestGetRoundInfoRestartCycles(t *testing.T) {
+	controller := mock.NewController(t)
+	defer controller.Finish()
+	mockOVSBridgeClient := ovsconfigtest.NewMockOVSBridgeClient(controller)
+
+	const maxRoundNum = 1<<cookie.BitwidthRound - 1
+	externalIDs := map[string]string{roundNumKey: fmt.Sprint(maxRoundNum - 1)}
+	mockOVSBridgeClient.EXPECT().GetExternalIDs().DoAndReturn(func() (map[string]string, ovsconfig.Error) {
+		return externalIDs, nil
+	}).AnyTimes()
+	mockOVSBridgeClient.EXPECT().SetExternalIDs(mock.Any()).DoAndReturn(func(extIDs map[string]interface{}) ovsconfig.Error {
+		externalIDs = convertExternalIDMap(extIDs)
+		return nil
+	}).AnyTimes()
+
+	tests := []struct {
+		expectedRoundNum       uint64
+		expectedGeneration     uint64
+		expectedPrevRoundNum   uint64
+		expectedPrevGeneration uint64
+		crash                  bool
+	}{
+		{maxRoundNum, 0, maxRoundNum - 1, 0, false},
+		{0, 1, maxRoundNum, 0, true},
+		{0, 1, maxRoundNum, 0, false},
+		{1, 1, 0, 1, false},
+	}
+	for i, tt := range tests {
+		roundInfo := getRoundInfo(mockOVSBridgeClient)
+		require.NotNil(t, roundInfo.PrevRoundNum)
+		assert.Equal(t, tt.expectedRoundNum, roundInfo.RoundNum, "Unexpected round number for restart %d", i)
+		assert.Equal(t, tt.expectedGeneration, roundInfo.Generation, "Unexpected generation for restart %d", i)
+		assert.Equal(t, tt.expectedPrevRoundNum, *roundInfo.PrevRoundNum, "Unexpected previous round number for restart %d", i)
+		assert.Equal(t, tt.expectedPrevGeneration, roundInfo.PrevGeneration, "Unexpected previous generation for restart %d", i)
+
+		currentCookie := cookie.NewAllocator(roundInfo.RoundNum, roundInfo.Generation).Request(cookie.Pod).Raw()
+		prevCookie, prevMask := cookie.CookieMaskForRoundAndGeneration(*roundInfo.PrevRoundNum, roundInfo.PrevGeneration)
+		assert.NotEqual(t, prevCookie, currentCookie&prevMask, "Flows of the current round would be deleted as stale flows for restart %d", i)
+
+		if !tt.crash {
+			persistRoundNum(roundInfo, mockOVSBridgeClient, 0, 0)
+		}
+	}
+}
+
+func TestGetLastTrafficEncapMode(t *testing.T) {
+	controller := mock.NewController(t)
+	defer controller.Finish()
+	mockOVSBridgeClient := ovsconfigtest.NewMockOVSBridgeClient(controller)
+
+	mockOVSBridgeClient.EXPECT().GetExternalIDs().Return(nil, ovsconfig.NewTransactionError(fmt.Errorf("Failed to get external IDs"), true))
+	_, err := getLastTrafficEncapMode(mockOVSBridgeClient)
+	assert.Error(t, err)
+	mockOVSBridgeClient.EXPECT().GetExternalIDs().Return(map[string]string{}, nil)
+	_, err = getLastTrafficEncapMode(mockOVSBridgeClient)
+	assert.Error(t, err)
+	mockOVSBridgeClient.EXPECT().GetExternalIDs().Return(map[string]string{trafficEncapModeKey: "foo"}, nil)
+	_, err = getLastTrafficEncapMode(mockOVSBridgeClient)
+	assert.Error(t, err)
+	mockOVSBridgeClient.EXPECT().GetExternalIDs().Return(map[string]string{trafficEncapModeKey: "noEncap"}, nil)
+	mode, err := getLastTrafficEncapMode(mockOVSBridgeClient)
+	require.NoError(t, err)
+	assert.Equal(t, config.TrafficEncapModeNoEncap, mode)
+}
+
+func TestSaveTrafficEncapMode(t *testing.T) {
+	controller := mock.NewController(t)
+	defer controller.Finish()
+	mockOVSBridgeClient := ovsconfigtest.NewMockOVSBridgeClient(controller)
+
+	mockOVSBridgeClient.EXPECT().GetExternalIDs().Return(map[string]string{roundNumKey: "5"}, nil)
+	mockOVSBridgeClient.EXPECT().SetExternalIDs(map[string]interface{}{
+		roundNumKey:         "5",
+		trafficEncapModeKey: config.TrafficEncapModeHybrid.String(),
+	}).Return(nil)
+	assert.NoError(t, saveTrafficEncapMode(config.TrafficEncapModeHybrid, mockOVSBridgeClient))
 }
 
 func TestInitNodeLocalConfig(t *testing.T) {
@@ -161,9 +299,12 @@ func TestInitNodeLocalConfig(t *testing.T) {
 		name                   string
 		trafficEncapMode       config.TrafficEncapModeType
 		tunnelType             ovsconfig.TunnelType
+		tunnelPort             int32
+		tunnelTOS              string
 		mtu                    int
 		expectedMTU            int
 		expectedNodeAnnotation map[string]string
+		expectedErr            bool
 	}{
 		{
 			name:                   "noencap mode",
@@ -173,11 +314,15 @@ func TestInitNodeLocalConfig(t *testing.T) {
 			expectedNodeAnnotation: map[string]string{types.NodeMACAddressAnnotationKey: macAddr.String()},
 		},
 		{
-			name:                   "hybrid mode",
-			trafficEncapMode:       config.TrafficEncapModeHybrid,
-			mtu:                    0,
-			expectedMTU:            1500,
-			expectedNodeAnnotation: map[string]string{types.NodeMACAddressAnnotationKey: macAddr.String()},
+			name:             "hybrid mode",
+			trafficEncapMode: config.TrafficEncapModeHybrid,
+			tunnelType:       ovsconfig.GeneveTunnel,
+			mtu:              0,
+			expectedMTU:      1500,
+			expectedNodeAnnotation: map[string]string{
+				types.NodeMACAddressAnnotationKey:   macAddr.String(),
+				types.NodeTunnelConfigAnnotationKey: "geneve",
+			},
 		},
 		{
 			name:                   "encap mode, geneve tunnel",
@@ -185,7 +330,7 @@ func TestInitNodeLocalConfig(t *testing.T) {
 			tunnelType:             ovsconfig.GeneveTunnel,
 			mtu:                    0,
 			expectedMTU:            1450,
-			expectedNodeAnnotation: nil,
+			expectedNodeAnnotation: map[string]string{types.NodeTunnelConfigAnnotationKey: "geneve"},
 		},
 		{
 			name:                   "encap mode, mtu specified",
@@ -193,7 +338,24 @@ func TestInitNodeLocalConfig(t *testing.T) {
 			tunnelType:             ovsconfig.GeneveTunnel,
 			mtu:                    1400,
 			expectedMTU:            1400,
-			expectedNodeAnnotation: nil,
+			expectedNodeAnnotation: map[string]string{types.NodeTunnelConfigAnnotationKey: "geneve"},
+		},
+		{
+			name:                   "encap mode, tunnel options specified",
+			trafficEncapMode:       config.TrafficEncapModeEncap,
+			tunnelType:             ovsconfig.GeneveTunnel,
+			tunnelPort:             6082,
+			tunnelTOS:              "inherit",
+			mtu:                    0,
+			expectedMTU:            1450,
+			expectedNodeAnnotation: map[string]string{types.NodeTunnelConfigAnnotationKey: "geneve,dst_port=6082,tos=inherit"},
+		},
+		{
+			name:             "encap mode, mtu larger than transport interface",
+			trafficEncapMode: config.TrafficEncapModeEncap,
+			tunnelType:       ovsconfig.GeneveTunnel,
+			mtu:              9000,
+			expectedErr:      true,
 		},
 	}
 	for _, tt := range tests {
@@ -227,9 +389,16 @@ func TestInitNodeLocalConfig(t *testing.T) {
 				networkConfig: &config.NetworkConfig{
 					TrafficEncapMode: tt.trafficEncapMode,
 					TunnelType:       tt.tunnelType,
+					TunnelPort:       tt.tunnelPort,
+					TunnelTOS:        tt.tunnelTOS,
 				},
 			}
-			require.NoError(t, initializer.initNodeLocalConfig())
+			err := initializer.initNodeLocalConfig()
+			if tt.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
 			expectedNodeConfig := config.NodeConfig{
 				Name:            nodeName,
 				OVSBridge:       ovsBridge,
@@ -240,7 +409,7 @@ func TestInitNodeLocalConfig(t *testing.T) {
 				UplinkNetConfig: new(config.AdapterNetConfig),
 			}
 			assert.Equal(t, expectedNodeConfig, *initializer.nodeConfig)
-			node, err := client.CoreV1().Nodes().Get(context.TODO(), nodeName, metav1.GetOptions{})
+			node, err = client.CoreV1().Nodes().Get(context.TODO(), nodeName, metav1.GetOptions{})
 			require.NoError(t, err)
 			assert.Equal(t, tt.expectedNodeAnnotation, node.Annotations)
 		})
@@ -259,3 +428,70 @@ func mockNodeNameEnv(name string) func() {
 	_ = os.Setenv(env.NodeNameEnvKey, name)
 	return func() { os.Unsetenv(env.NodeNameEnvKey) }
 }
+
+func TestGetGatewayIP(t *testing.T) {
+	_, podCIDRv4, _ := net.ParseCIDR("10.10.1.0/24")
+	_, podCIDRv6, _ := net.ParseCIDR("fd00:10:10:1::/64")
+	tests := []struct {
+		name          string
+		networkConfig *config.NetworkConfig
+		podCIDR       *net.IPNet
+		expectedIP    net.IP
+		expectedErr   bool
+	}{
+		{
+			name:          "default IPv4",
+			networkConfig: &config.NetworkConfig{},
+			podCIDR:       podCIDRv4,
+			expectedIP:    net.ParseIP("10.10.1.1"),
+		},
+		{
+			name:          "default IPv6",
+			networkConfig: &config.NetworkConfig{GatewayIPv4: net.ParseIP("10.10.1.254")},
+			podCIDR:       podCIDRv6,
+			expectedIP:    net.ParseIP("fd00:10:10:1::1"),
+		},
+		{
+			name:          "configured IPv4",
+			networkConfig: &config.NetworkConfig{GatewayIPv4: net.ParseIP("10.10.1.254")},
+			podCIDR:       podCIDRv4,
+			expectedIP:    net.ParseIP("10.10.1.254"),
+		},
+		{
+			name:          "configured IPv6",
+			networkConfig: &config.NetworkConfig{GatewayIPv6: net.ParseIP("fd00:10:10:1::fe")},
+			podCIDR:       podCIDRv6,
+			expectedIP:    net.ParseIP("fd00:10:10:1::fe"),
+		},
+		{
+			name:          "configured IPv4 out of PodCIDR",
+			networkConfig: &config.NetworkConfig{GatewayIPv4: net.ParseIP("10.10.2.1")},
+			podCIDR:       podCIDRv4,
+			expectedErr:   true,
+		},
+		{
+			name:          "configured IPv4 subnet ID",
+			networkConfig: &config.NetworkConfig{GatewayIPv4: net.ParseIP("10.10.1.0")},
+			podCIDR:       podCIDRv4,
+			expectedErr:   true,
+		},
+		{
+			name:          "configured IPv4 broadcast address",
+			networkConfig: &config.NetworkConfig{GatewayIPv4: net.ParseIP("10.10.1.255")},
+			podCIDR:       podCIDRv4,
+			expectedErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			initializer := &Initializer{networkConfig: tt.networkConfig}
+			gatewayIP, err := initializer.getGatewayIP(tt.podCIDR)
+			if tt.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, tt.expectedIP.Equal(gatewayIP), "Expected gateway IP %s, got %s", tt.expectedIP, gatewayIP)
+		})
+	}
+}
@@ -27,19 +27,33 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
 	k8sversion "k8s.io/apimachinery/pkg/version"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
 	"k8s.io/apiserver/pkg/registry/rest"
 	genericapiserver "k8s.io/apiserver/pkg/server"
 	"k8s.io/apiserver/pkg/server/healthz"
 	genericoptions "k8s.io/apiserver/pkg/server/options"
 
+	"antrea.io/antrea/pkg/agent/apiserver/handlers"
 	"antrea.io/antrea/pkg/agent/apiserver/handlers/addressgroup"
+	"antrea.io/antrea/pkg/agent/apiserver/handlers/agentconfig"
 	"antrea.io/antrea/pkg/agent/apiserver/handlers/agentinfo"
 	"antrea.io/antrea/pkg/agent/apiserver/handlers/appliedtogroup"
+	"antrea.io/antrea/pkg/agent/apiserver/handlers/auditlogs"
+	"antrea.io/antrea/pkg/agent/apiserver/handlers/crashjournal"
 	"antrea.io/antrea/pkg/agent/apiserver/handlers/featuregates"
+	"antrea.io/antrea/pkg/agent/apiserver/handlers/flowchanges"
+	"antrea.io/antrea/pkg/agent/apiserver/handlers/flowexporter"
 	"antrea.io/antrea/pkg/agent/apiserver/handlers/networkpolicy"
+	"antrea.io/antrea/pkg/agent/apiserver/handlers/nodelatency"
 	"antrea.io/antrea/pkg/agent/apiserver/handlers/ovsflows"
 	"antrea.io/antrea/pkg/agent/apiserver/handlers/ovstracing"
+	"antrea.io/antrea/pkg/agent/apiserver/handlers/packetcapture"
+	"antrea.io/antrea/pkg/agent/apiserver/handlers/pipeline"
 	"antrea.io/antrea/pkg/agent/apiserver/handlers/podinterface"
+	"antrea.io/antrea/pkg/agent/apiserver/handlers/realizationerrors"
+	"antrea.io/antrea/pkg/agent/apiserver/handlers/resync"
+	"antrea.io/antrea/pkg/agent/apiserver/handlers/serviceendpoints"
+	agentcrashjournal "antrea.io/antrea/pkg/agent/crashjournal"
 	agentquerier "antrea.io/antrea/pkg/agent/querier"
 	systeminstall "antrea.io/antrea/pkg/apis/system/install"
 	systemv1beta1 "antrea.io/antrea/pkg/apis/system/v1beta1"
@@ -72,16 +86,28 @@ func (s *agentAPIServer) Run(stopCh <-chan struct{}) error {
 	return s.GenericAPIServer.PrepareRun().Run(stopCh)
 }
 
-func installHandlers(aq agentquerier.AgentQuerier, npq querier.AgentNetworkPolicyInfoQuerier, s *genericapiserver.GenericAPIServer) {
+func installHandlers(aq agentquerier.AgentQuerier, npq querier.AgentNetworkPolicyInfoQuerier, npr querier.AgentNetworkPolicyResyncer, neq querier.AgentNetworkPolicyRealizationErrorQuerier, pcq querier.AgentPacketCaptureQuerier, nlq querier.AgentNodeLatencyQuerier, feq querier.AgentFlowExporterQuerier, alq querier.AgentAuditLogQuerier, acq querier.AgentConfigQuerier, s *genericapiserver.GenericAPIServer) {
 	s.Handler.NonGoRestfulMux.HandleFunc("/loglevel", loglevel.HandleFunc())
 	s.Handler.NonGoRestfulMux.HandleFunc("/featuregates", featuregates.HandleFunc())
+	s.Handler.NonGoRestfulMux.HandleFunc("/config", agentconfig.HandleFunc(acq))
 	s.Handler.NonGoRestfulMux.HandleFunc("/agentinfo", agentinfo.HandleFunc(aq))
 	s.Handler.NonGoRestfulMux.HandleFunc("/podinterfaces", podinterface.HandleFunc(aq))
 	s.Handler.NonGoRestfulMux.HandleFunc("/networkpolicies", networkpolicy.HandleFunc(aq))
+	s.Handler.NonGoRestfulMux.HandleFunc("/networkpolicy/errors", realizationerrors.HandleFunc(neq))
 	s.Handler.NonGoRestfulMux.HandleFunc("/appliedtogroups", appliedtogroup.HandleFunc(npq))
 	s.Handler.NonGoRestfulMux.HandleFunc("/addressgroups", addressgroup.HandleFunc(npq))
 	s.Handler.NonGoRestfulMux.HandleFunc("/ovsflows", ovsflows.HandleFunc(aq))
 	s.Handler.NonGoRestfulMux.HandleFunc("/ovstracing", ovstracing.HandleFunc(aq))
+	s.Handler.NonGoRestfulMux.HandleFunc("/pipeline", pipeline.HandleFunc(aq))
+	s.Handler.NonGoRestfulMux.HandleFunc("/auditlogs", auditlogs.HandleFunc(auditlogs.GetLogFile(), aq.GetNodeConfig().Name))
+	s.Handler.NonGoRestfulMux.HandleFunc("/auditlogs/recent", auditlogs.HandleRecentFunc(alq, aq.GetNodeConfig().Name))
+	s.Handler.NonGoRestfulMux.HandleFunc("/packetcaptures", packetcapture.HandleFunc(pcq))
+	s.Handler.NonGoRestfulMux.HandleFunc("/debug/flowchanges", flowchanges.HandleFunc(aq))
+	s.Handler.NonGoRestfulMux.HandleFunc("/proxy/endpoints", serviceendpoints.HandleFunc(aq))
+	s.Handler.NonGoRestfulMux.HandleFunc("/nodelatency", nodelatency.HandleFunc(nlq))
+	s.Handler.NonGoRestfulMux.HandleFunc("/flowexporter", flowexporter.HandleFunc(feq))
+	s.Handler.NonGoRestfulMux.HandleFunc("/crashjournal", crashjournal.HandleFunc(agentcrashjournal.DefaultPath))
+	s.Handler.NonGoRestfulMux.HandleFunc("/resync", resync.HandleFunc(npr))
 }
 
 func installAPIGroup(s *genericapiserver.GenericAPIServer, aq agentquerier.AgentQuerier, npq querier.AgentNetworkPolicyInfoQuerier) error {
@@ -95,7 +121,7 @@ func installAPIGroup(s *genericapiserver.GenericAPIServer, aq agentquerier.Agent
 }
 
 // New creates an APIServer for running in antrea agent.
-func New(aq agentquerier.AgentQuerier, npq querier.AgentNetworkPolicyInfoQuerier, bindPort int,
+func New(aq agentquerier.AgentQuerier, npq querier.AgentNetworkPolicyInfoQuerier, npr querier.AgentNetworkPolicyResyncer, neq querier.AgentNetworkPolicyRealizationErrorQuerier, pcq querier.AgentPacketCaptureQuerier, nlq querier.AgentNodeLatencyQuerier, feq querier.AgentFlowExporterQuerier, alq querier.AgentAuditLogQuerier, acq querier.AgentConfigQuerier, bindPort int,
 	enableMetrics bool, kubeconfig string, cipherSuites []uint16, tlsMinVersion uint16) (*agentAPIServer, error) {
 	cfg, err := newConfig(npq, bindPort, enableMetrics, kubeconfig)
 	if err != nil {
@@ -110,7 +136,7 @@ func New(aq agentquerier.AgentQuerier, npq querier.AgentNetworkPolicyInfoQuerier
 	if err := installAPIGroup(s, aq, npq); err != nil {
 		return nil, err
 	}
-	installHandlers(aq, npq, s)
+	installHandlers(aq, npq, npr, neq, pcq, nlq, feq, alq, acq, s)
 	return &agentAPIServer{GenericAPIServer: s}, nil
 }
 
@@ -159,6 +185,15 @@ func newConfig(npq querier.AgentNetworkPolicyInfoQuerier, bindPort int, enableMe
 		GitCommit:    antreaversion.GetGitSHA(),
 	}
 	serverConfig.EnableMetrics = enableMetrics
+	// Streaming responses of the API handlers in watch mode must not be subject to the request
+	// timeout applied to the non long-running requests.
+	defaultLongRunningFunc := serverConfig.LongRunningFunc
+	serverConfig.LongRunningFunc = func(r *http.Request, requestInfo *genericapirequest.RequestInfo) bool {
+		if !requestInfo.IsResourceRequest && handlers.IsWatchRequest(r) {
+			return true
+		}
+		return defaultLongRunningFunc(r, requestInfo)
+	}
 	// Add readiness probe to check the status of watchers.
 	check := healthz.NamedCheck("watcher", func(_ *http.Request) error {
 		if npq.GetControllerConnectionStatus() {
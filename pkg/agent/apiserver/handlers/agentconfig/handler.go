@@ -0,0 +1,52 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agentconfig
+
+import (
+	"net/http"
+
+	"gopkg.in/yaml.v2"
+	"k8s.io/klog/v2"
+
+	"antrea.io/antrea/pkg/querier"
+)
+
+// redactedValue replaces the values of the options which may hold credentials.
+const redactedValue = "<redacted>"
+
+// HandleFunc returns the function which handles the API requests to "/config". The handler
+// function populates the live configuration of the agent, in the format of the configuration file,
+// to the response. The headers sent to the OpenTelemetry collector are redacted, as they may hold
+// credentials.
+func HandleFunc(acq querier.AgentConfigQuerier) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		config := *acq.GetAgentConfig()
+		if len(config.OTelExporter.Headers) > 0 {
+			headers := make(map[string]string, len(config.OTelExporter.Headers))
+			for name := range config.OTelExporter.Headers {
+				headers[name] = redactedValue
+			}
+			config.OTelExporter.Headers = headers
+		}
+		data, err := yaml.Marshal(&config)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			klog.Errorf("Error when encoding agent configuration to yaml: %v", err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/yaml")
+		w.Write(data)
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agentconfig
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+
+	agentconfig "antrea.io/antrea/pkg/config/agent"
+)
+
+type fakeAgentConfigQuerier struct {
+	config *agentconfig.AgentConfig
+}
+
+func (q *fakeAgentConfigQuerier) GetAgentConfig() *agentconfig.AgentConfig {
+	return q.config
+}
+
+func TestHandleFunc(t *testing.T) {
+	config := &agentconfig.AgentConfig{
+		FlowPollInterval: "10s",
+		AuditLogging:     agentconfig.AuditLoggingConfig{Destination: agentconfig.AuditLogDestinationFile, LogDir: "/var/log/audit"},
+		OTelExporter:     agentconfig.OTelExporterConfig{Endpoint: "otel-collector:4317", Headers: map[string]string{"Authorization": "Bearer token"}},
+	}
+	handler := HandleFunc(&fakeAgentConfigQuerier{config: config})
+	recorder := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "", nil)
+	require.NoError(t, err)
+	handler.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	var received agentconfig.AgentConfig
+	require.NoError(t, yaml.UnmarshalStrict(recorder.Body.Bytes(), &received))
+	assert.Equal(t, "10s", received.FlowPollInterval)
+	assert.Equal(t, "/var/log/audit", received.AuditLogging.LogDir)
+	assert.Equal(t, map[string]string{"Authorization": redactedValue}, received.OTelExporter.Headers)
+	// The live configuration is not modified.
+	assert.Equal(t, "Bearer token", config.OTelExporter.Headers["Authorization"])
+}
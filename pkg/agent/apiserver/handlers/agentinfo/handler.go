@@ -21,23 +21,15 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/klog/v2"
 
+	"antrea.io/antrea/pkg/agent/apiserver/handlers/agentinfo/types"
 	"antrea.io/antrea/pkg/agent/querier"
 	"antrea.io/antrea/pkg/antctl/transform/common"
 	"antrea.io/antrea/pkg/apis/crd/v1beta1"
 )
 
-// AntreaAgentInfoResponse is the struct for the response of agentinfo command.
-// It includes all fields except meta info from v1beta1.AntreaAgentInfo struct.
-type AntreaAgentInfoResponse struct {
-	Version                     string                              `json:"version,omitempty"`                     // Antrea binary version
-	PodRef                      corev1.ObjectReference              `json:"podRef,omitempty"`                      // The Pod that Antrea Agent is running in
-	NodeRef                     corev1.ObjectReference              `json:"nodeRef,omitempty"`                     // The Node that Antrea Agent is running in
-	NodeSubnets                 []string                            `json:"nodeSubnets,omitempty"`                 // Node subnets
-	OVSInfo                     v1beta1.OVSInfo                     `json:"ovsInfo,omitempty"`                     // OVS Information
-	NetworkPolicyControllerInfo v1beta1.NetworkPolicyControllerInfo `json:"networkPolicyControllerInfo,omitempty"` // Antrea Agent NetworkPolicy information
-	LocalPodNum                 int32                               `json:"localPodNum,omitempty"`                 // The number of Pods which the agent is in charge of
-	AgentConditions             []v1beta1.AgentCondition            `json:"agentConditions,omitempty"`             // Agent condition contains types like AgentHealthy
-}
+// AntreaAgentInfoResponse is the struct for the response of agentinfo command. Its schema is defined
+// by types.AgentInfo.
+type AntreaAgentInfoResponse types.AgentInfo
 
 // HandleFunc returns the function which can handle queries issued by agentinfo commands.
 // The handler function populates Antrea agent information to the response.
@@ -54,6 +46,7 @@ func HandleFunc(aq querier.AgentQuerier) http.HandlerFunc {
 			LocalPodNum:                 agentInfo.LocalPodNum,
 			AgentConditions:             agentInfo.AgentConditions,
 			NodeSubnets:                 agentInfo.NodeSubnets,
+			LogLevel:                    agentInfo.LogLevel,
 		}
 		err := json.NewEncoder(w).Encode(info)
 		if err != nil {
@@ -66,7 +59,7 @@ func HandleFunc(aq querier.AgentQuerier) http.HandlerFunc {
 var _ common.TableOutput = new(AntreaAgentInfoResponse)
 
 func (r AntreaAgentInfoResponse) GetTableHeader() []string {
-	return []string{"POD", "NODE", "STATUS", "NODE-SUBNET", "NETWORK-POLICIES", "ADDRESS-GROUPS", "APPLIED-TO-GROUPS", "LOCAL-PODS"}
+	return []string{"POD", "NODE", "STATUS", "VERSION", "NODE-SUBNET", "NETWORK-POLICIES", "ADDRESS-GROUPS", "APPLIED-TO-GROUPS", "LOCAL-PODS"}
 }
 
 func (r AntreaAgentInfoResponse) GetAgentConditionStr() string {
@@ -89,6 +82,7 @@ func (r AntreaAgentInfoResponse) GetTableRow(maxColumnLength int) []string {
 	return []string{r.PodRef.Namespace + "/" + r.PodRef.Name,
 		r.NodeRef.Name,
 		r.GetAgentConditionStr(),
+		r.Version,
 		common.GenerateTableElementWithSummary(r.NodeSubnets, maxColumnLength),
 		common.Int32ToString(r.NetworkPolicyControllerInfo.NetworkPolicyNum),
 		common.Int32ToString(r.NetworkPolicyControllerInfo.AddressGroupNum),
@@ -0,0 +1,420 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auditlogs
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"antrea.io/antrea/pkg/querier"
+	"antrea.io/antrea/pkg/util/logdir"
+)
+
+const (
+	// logTimeFormat is the format of the timestamp of the audit log entries, as written by
+	// log.Logger with the Ldate and Lmicroseconds flags.
+	logTimeFormat = "2006/01/02 15:04:05.000000"
+	// backupTimeFormat is the format of the timestamp added by lumberjack to the name of the
+	// rotated log files.
+	backupTimeFormat = "2006-01-02T15-04-05.000"
+	compressSuffix   = ".gz"
+	// flushInterval is the number of entries after which the response is flushed.
+	flushInterval = 100
+	// defaultRecentLimit is the number of recent entries returned when no limit is requested.
+	defaultRecentLimit = 100
+)
+
+// GetLogFile returns the path of the audit log file written by the NetworkPolicy controller. Its
+// rotated backups are kept in the same directory.
+func GetLogFile() string {
+	return filepath.Join(logdir.GetLogDir(), "networkpolicy", "np.log")
+}
+
+// Entry is an audit log entry, i.e. the first packet of a connection matching an Antrea-native
+// policy rule with logging enabled. It is the object streamed by the handler, one JSON document
+// per entry.
+type Entry struct {
+	Timestamp   time.Time `json:"timestamp"`
+	Node        string    `json:"node,omitempty"`
+	Table       string    `json:"table"`
+	Policy      string    `json:"policy"`
+	Rule        string    `json:"rule,omitempty"`
+	Disposition string    `json:"disposition"`
+	Priority    string    `json:"priority"`
+	SrcIP       string    `json:"srcIP"`
+	SrcPort     uint16    `json:"srcPort,omitempty"`
+	DestIP      string    `json:"destIP"`
+	DestPort    uint16    `json:"destPort,omitempty"`
+	Length      uint16    `json:"length"`
+	Protocol    string    `json:"protocol"`
+	ICMPType    *uint8    `json:"icmpType,omitempty"`
+	ICMPCode    *uint8    `json:"icmpCode,omitempty"`
+	// Packets is the number of identical packets aggregated in the entry. It is not set for the
+	// entry of a single packet.
+	Packets int `json:"packets,omitempty"`
+}
+
+// Filter selects the audit log entries returned by the handler. Zero fields match all entries.
+type Filter struct {
+	Since       time.Time
+	Until       time.Time
+	Policy      string
+	Disposition string
+}
+
+// Match returns whether the entry is selected by the filter. A policy matches either the full
+// reference of the entry's policy (e.g. "AntreaNetworkPolicy:default/test-anp") or the reference
+// without its type (e.g. "default/test-anp").
+func (f *Filter) Match(e *Entry) bool {
+	if !f.Since.IsZero() && e.Timestamp.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && e.Timestamp.After(f.Until) {
+		return false
+	}
+	if f.Policy != "" && f.Policy != e.Policy && !strings.HasSuffix(e.Policy, ":"+f.Policy) {
+		return false
+	}
+	if f.Disposition != "" && !strings.EqualFold(f.Disposition, e.Disposition) {
+		return false
+	}
+	return true
+}
+
+// parseEntry parses an audit log line, either in JSON or in the text format:
+// <yyyy/mm/dd> <time> <table> <policy> <disposition> <priority> SRC: <srcIP> DEST: <destIP> <length> <protocol> <srcPort> <destPort> [<rule>]
+// The rule name is missing for the traffic dropped by K8s isolation. The ports are replaced with
+// the type and the code for ICMP, and with "-" when the packet-in didn't include the transport
+// header. The ports are missing from the entries written by older agents.
+func parseEntry(line string) (*Entry, error) {
+	if strings.HasPrefix(line, "{") {
+		// The agent writes the priority, the length and the number of packets with different
+		// keys than the ones of Entry.
+		var jsonEntry struct {
+			Entry
+			OFPriority  string `json:"ofPriority"`
+			PktLength   uint16 `json:"pktLength"`
+			PacketCount int    `json:"packetCount"`
+		}
+		if err := json.Unmarshal([]byte(line), &jsonEntry); err != nil {
+			return nil, err
+		}
+		e := jsonEntry.Entry
+		e.Priority = jsonEntry.OFPriority
+		e.Length = jsonEntry.PktLength
+		e.Packets = jsonEntry.PacketCount
+		return &e, nil
+	}
+	fields := strings.Fields(line)
+	// The entries of identical packets aggregated by the agent end with the number of packets,
+	// e.g. "[10 packets]".
+	packets := 0
+	if n := len(fields); n > 2 && fields[n-1] == "packets]" && strings.HasPrefix(fields[n-2], "[") {
+		count, err := strconv.Atoi(strings.TrimPrefix(fields[n-2], "["))
+		if err != nil {
+			return nil, err
+		}
+		packets = count
+		fields = fields[:n-2]
+	}
+	if len(fields) < 12 || len(fields) > 15 || fields[6] != "SRC:" || fields[8] != "DEST:" {
+		return nil, fmt.Errorf("unexpected audit log format")
+	}
+	timestamp, err := time.ParseInLocation(logTimeFormat, fields[0]+" "+fields[1], time.Local)
+	if err != nil {
+		return nil, err
+	}
+	length, err := strconv.ParseUint(fields[10], 10, 16)
+	if err != nil {
+		return nil, err
+	}
+	e := &Entry{
+		Timestamp:   timestamp,
+		Table:       fields[2],
+		Policy:      fields[3],
+		Disposition: fields[4],
+		Priority:    fields[5],
+		SrcIP:       fields[7],
+		DestIP:      fields[9],
+		Length:      uint16(length),
+		Protocol:    fields[11],
+		Packets:     packets,
+	}
+	switch len(fields) {
+	case 13:
+		e.Rule = fields[12]
+	case 14, 15:
+		if err := parseTransportFields(e, fields[12], fields[13]); err != nil {
+			return nil, err
+		}
+		if len(fields) == 15 {
+			e.Rule = fields[14]
+		}
+	}
+	return e, nil
+}
+
+// parseTransportFields sets the ports of the entry, or its ICMP type and code, from the two fields
+// following the protocol in the text format.
+func parseTransportFields(e *Entry, src, dest string) error {
+	if src == "-" && dest == "-" {
+		return nil
+	}
+	if e.Protocol == "ICMP" || e.Protocol == "IPv6-ICMP" {
+		icmpType, err := strconv.ParseUint(src, 10, 8)
+		if err != nil {
+			return err
+		}
+		icmpCode, err := strconv.ParseUint(dest, 10, 8)
+		if err != nil {
+			return err
+		}
+		t, c := uint8(icmpType), uint8(icmpCode)
+		e.ICMPType, e.ICMPCode = &t, &c
+		return nil
+	}
+	srcPort, err := strconv.ParseUint(src, 10, 16)
+	if err != nil {
+		return err
+	}
+	destPort, err := strconv.ParseUint(dest, 10, 16)
+	if err != nil {
+		return err
+	}
+	e.SrcPort, e.DestPort = uint16(srcPort), uint16(destPort)
+	return nil
+}
+
+// getLogFiles returns the audit log file and its rotated backups, from the oldest to the most
+// recent. Backups rotated before since only contain older entries and are skipped.
+func getLogFiles(logFile string, since time.Time) ([]string, error) {
+	dir := filepath.Dir(logFile)
+	ext := filepath.Ext(logFile)
+	prefix := strings.TrimSuffix(filepath.Base(logFile), ext) + "-"
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	type backup struct {
+		path      string
+		rotatedAt time.Time
+	}
+	var backups []backup
+	for _, f := range files {
+		name := strings.TrimSuffix(f.Name(), compressSuffix)
+		if f.IsDir() || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ext) {
+			continue
+		}
+		rotatedAt, err := time.Parse(backupTimeFormat, name[len(prefix):len(name)-len(ext)])
+		if err != nil {
+			continue
+		}
+		if !since.IsZero() && rotatedAt.Before(since) {
+			continue
+		}
+		backups = append(backups, backup{path: filepath.Join(dir, f.Name()), rotatedAt: rotatedAt})
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].rotatedAt.Before(backups[j].rotatedAt)
+	})
+	paths := make([]string, 0, len(backups)+1)
+	for _, b := range backups {
+		paths = append(paths, b.path)
+	}
+	if _, err := os.Stat(logFile); err == nil {
+		paths = append(paths, logFile)
+	}
+	return paths, nil
+}
+
+// readLogFile calls fn with each entry of the log file matching the filter, reading the file line
+// by line. It stops at the first error returned by fn.
+func readLogFile(path string, filter *Filter, fn func(e *Entry) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	var reader io.Reader = f
+	if strings.HasSuffix(path, compressSuffix) {
+		gzReader, err := gzip.NewReader(f)
+		if err != nil {
+			return err
+		}
+		defer gzReader.Close()
+		reader = gzReader
+	}
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		e, err := parseEntry(scanner.Text())
+		if err != nil {
+			klog.V(4).Infof("Skipping invalid audit log line in %s: %v", path, err)
+			continue
+		}
+		if !filter.Match(e) {
+			continue
+		}
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+func parseTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// parseFilter returns the filter of the entries selected by the query parameters.
+func parseFilter(query url.Values) (*Filter, error) {
+	var filter Filter
+	var err error
+	if filter.Since, err = parseTime(query.Get("since")); err != nil {
+		return nil, fmt.Errorf("invalid since time, it must be in RFC3339 format")
+	}
+	if filter.Until, err = parseTime(query.Get("until")); err != nil {
+		return nil, fmt.Errorf("invalid until time, it must be in RFC3339 format")
+	}
+	filter.Policy = query.Get("policy")
+	filter.Disposition = query.Get("disposition")
+	return &filter, nil
+}
+
+// newEntry returns the Entry of an audit log entry kept in memory by the agent of the Node.
+func newEntry(e *querier.AuditLogEntry, nodeName string) *Entry {
+	return &Entry{
+		Timestamp:   e.Timestamp,
+		Node:        nodeName,
+		Table:       e.Table,
+		Policy:      e.Policy,
+		Rule:        e.Rule,
+		Disposition: e.Disposition,
+		Priority:    e.Priority,
+		SrcIP:       e.SrcIP,
+		SrcPort:     e.SrcPort,
+		DestIP:      e.DestIP,
+		DestPort:    e.DestPort,
+		Length:      e.Length,
+		Protocol:    e.Protocol,
+		ICMPType:    e.ICMPType,
+		ICMPCode:    e.ICMPCode,
+		Packets:     e.Packets,
+	}
+}
+
+// HandleFunc returns the function which can handle API requests to "/auditlogs". The entries of
+// the audit log file and its rotated backups matching the query are streamed in chronological
+// order, one JSON document per entry.
+func HandleFunc(logFile, nodeName string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		filter, err := parseFilter(r.URL.Query())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		paths, err := getLogFiles(logFile, filter.Since)
+		if err != nil && !os.IsNotExist(err) {
+			klog.Errorf("Failed to list audit log files: %v", err)
+			http.Error(w, "failed to list audit log files", http.StatusInternalServerError)
+			return
+		}
+		flusher, _ := w.(http.Flusher)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		encoder := json.NewEncoder(w)
+		count := 0
+		for _, path := range paths {
+			err := readLogFile(path, filter, func(e *Entry) error {
+				e.Node = nodeName
+				if err := encoder.Encode(e); err != nil {
+					return err
+				}
+				if count++; count%flushInterval == 0 && flusher != nil {
+					flusher.Flush()
+				}
+				return nil
+			})
+			if err != nil {
+				// The status has been sent already, the client sees a truncated stream.
+				klog.Errorf("Failed to stream audit log file %s: %v", path, err)
+				return
+			}
+		}
+	}
+}
+
+// HandleRecentFunc returns the function which can handle API requests to "/auditlogs/recent". The
+// last entries written by the agent which match the query, at most the limit (100 by default), are
+// returned in chronological order, one JSON document per entry. They are read from the entries kept
+// in memory by the agent, instead of the audit log files.
+func HandleRecentFunc(alq querier.AgentAuditLogQuerier, nodeName string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		filter, err := parseFilter(r.URL.Query())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		limit := defaultRecentLimit
+		if value := r.URL.Query().Get("limit"); value != "" {
+			if limit, err = strconv.Atoi(value); err != nil || limit <= 0 {
+				http.Error(w, "invalid limit, it must be a positive integer", http.StatusBadRequest)
+				return
+			}
+		}
+		entries, enabled := alq.GetRecentAuditLogs()
+		if !enabled {
+			http.Error(w, "audit logging is not enabled", http.StatusServiceUnavailable)
+			return
+		}
+		var matched []*Entry
+		for i := range entries {
+			if e := newEntry(&entries[i], nodeName); filter.Match(e) {
+				matched = append(matched, e)
+			}
+		}
+		if len(matched) > limit {
+			matched = matched[len(matched)-limit:]
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		encoder := json.NewEncoder(w)
+		for _, e := range matched {
+			if err := encoder.Encode(e); err != nil {
+				klog.Errorf("Failed to encode recent audit log entries: %v", err)
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,286 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auditlogs
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"antrea.io/antrea/pkg/querier"
+)
+
+func writeLogFile(t *testing.T, path string, lines []string, compress bool) {
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	defer f.Close()
+	var w io.Writer = f
+	if compress {
+		gzWriter := gzip.NewWriter(f)
+		defer gzWriter.Close()
+		w = gzWriter
+	}
+	_, err = io.WriteString(w, strings.Join(lines, "\n")+"\n")
+	require.NoError(t, err)
+}
+
+func TestHandleFunc(t *testing.T) {
+	dir, err := ioutil.TempDir("", "auditlogs")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	logFile := filepath.Join(dir, "np.log")
+
+	// The oldest backup, rotated before the time range of most test cases.
+	writeLogFile(t, filepath.Join(dir, "np-2021-05-01T09-00-00.000.log.gz"), []string{
+		"2021/05/01 08:59:00.000000 AntreaPolicyIngressRule AntreaNetworkPolicy:default/anp1 Drop 44900 SRC: 10.0.0.1 DEST: 10.0.0.2 60 TCP",
+	}, true)
+	writeLogFile(t, filepath.Join(dir, "np-2021-05-01T11-00-00.000.log.gz"), []string{
+		"2021/05/01 10:00:00.000000 AntreaPolicyIngressRule AntreaNetworkPolicy:default/anp1 Drop 44900 SRC: 10.0.0.1 DEST: 10.0.0.2 60 TCP",
+		"2021/05/01 10:30:00.000000 AntreaPolicyEgressRule AntreaClusterNetworkPolicy:acnp1 Allow 44800 SRC: 10.0.0.3 DEST: 10.0.0.4 84 ICMP allow-ping",
+	}, true)
+	writeLogFile(t, filepath.Join(dir, "np-2021-05-01T12-00-00.000.log"), []string{
+		"invalid line",
+		"2021/05/01 11:30:00.000000 AntreaPolicyIngressRule AntreaNetworkPolicy:default/anp1 Allow 44900 SRC: 10.0.0.1 DEST: 10.0.0.2 60 TCP",
+	}, false)
+	writeLogFile(t, logFile, []string{
+		`{"timestamp":"2021-05-01T12:30:00Z","table":"AntreaPolicyIngressRule","policy":"AntreaNetworkPolicy:default/anp2","disposition":"Drop","priority":"44900","srcIP":"10.0.0.5","destIP":"10.0.0.6","length":60,"protocol":"UDP"}`,
+	}, false)
+
+	for _, tc := range []struct {
+		name             string
+		query            string
+		expectedStatus   int
+		expectedPolicies []string
+	}{
+		{
+			name:             "all entries",
+			query:            "",
+			expectedStatus:   http.StatusOK,
+			expectedPolicies: []string{"AntreaNetworkPolicy:default/anp1", "AntreaNetworkPolicy:default/anp1", "AntreaClusterNetworkPolicy:acnp1", "AntreaNetworkPolicy:default/anp1", "AntreaNetworkPolicy:default/anp2"},
+		},
+		{
+			name:             "time range",
+			query:            "?since=2021-05-01T10:15:00Z&until=2021-05-01T12:00:00Z",
+			expectedStatus:   http.StatusOK,
+			expectedPolicies: []string{"AntreaClusterNetworkPolicy:acnp1", "AntreaNetworkPolicy:default/anp1"},
+		},
+		{
+			name:             "policy and disposition",
+			query:            "?policy=default/anp1&disposition=drop",
+			expectedStatus:   http.StatusOK,
+			expectedPolicies: []string{"AntreaNetworkPolicy:default/anp1", "AntreaNetworkPolicy:default/anp1"},
+		},
+		{
+			name:             "full policy reference",
+			query:            "?policy=AntreaNetworkPolicy:default/anp2",
+			expectedStatus:   http.StatusOK,
+			expectedPolicies: []string{"AntreaNetworkPolicy:default/anp2"},
+		},
+		{
+			name:           "invalid time",
+			query:          "?since=10m",
+			expectedStatus: http.StatusBadRequest,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			// The text format has no time zone, use UTC to make the expectations deterministic.
+			local := time.Local
+			time.Local = time.UTC
+			defer func() { time.Local = local }()
+
+			req, err := http.NewRequest(http.MethodGet, tc.query, nil)
+			require.NoError(t, err)
+			recorder := httptest.NewRecorder()
+			HandleFunc(logFile, "node1").ServeHTTP(recorder, req)
+			require.Equal(t, tc.expectedStatus, recorder.Code)
+			if tc.expectedStatus != http.StatusOK {
+				return
+			}
+			var policies []string
+			var last time.Time
+			decoder := json.NewDecoder(recorder.Body)
+			for decoder.More() {
+				var e Entry
+				require.NoError(t, decoder.Decode(&e))
+				assert.Equal(t, "node1", e.Node)
+				assert.False(t, e.Timestamp.Before(last), "Entries should be in chronological order")
+				last = e.Timestamp
+				policies = append(policies, e.Policy)
+			}
+			assert.Equal(t, tc.expectedPolicies, policies)
+		})
+	}
+}
+
+type fakeAuditLogQuerier struct {
+	entries []querier.AuditLogEntry
+	enabled bool
+}
+
+func (q *fakeAuditLogQuerier) GetRecentAuditLogs() ([]querier.AuditLogEntry, bool) {
+	return q.entries, q.enabled
+}
+
+func TestHandleRecentFunc(t *testing.T) {
+	start := time.Date(2021, 5, 1, 10, 0, 0, 0, time.UTC)
+	var entries []querier.AuditLogEntry
+	for i := 0; i < 150; i++ {
+		disposition := "Allow"
+		if i%2 == 0 {
+			disposition = "Drop"
+		}
+		policy := "AntreaNetworkPolicy:default/anp1"
+		if i%3 == 0 {
+			policy = "AntreaClusterNetworkPolicy:acnp1"
+		}
+		entries = append(entries, querier.AuditLogEntry{Timestamp: start.Add(time.Duration(i) * time.Second), Policy: policy, Disposition: disposition})
+	}
+	alq := &fakeAuditLogQuerier{entries: entries, enabled: true}
+
+	for _, tc := range []struct {
+		name            string
+		query           string
+		disabled        bool
+		expectedStatus  int
+		expectedSeconds []int
+	}{
+		{
+			name:            "default limit",
+			query:           "",
+			expectedStatus:  http.StatusOK,
+			expectedSeconds: []int{50, 149},
+		},
+		{
+			name:            "limit",
+			query:           "?limit=3",
+			expectedStatus:  http.StatusOK,
+			expectedSeconds: []int{147, 149},
+		},
+		{
+			name:            "policy and disposition",
+			query:           "?policy=acnp1&disposition=drop&limit=2",
+			expectedStatus:  http.StatusOK,
+			expectedSeconds: []int{138, 144},
+		},
+		{
+			name:           "invalid limit",
+			query:          "?limit=0",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "audit logging disabled",
+			query:          "",
+			disabled:       true,
+			expectedStatus: http.StatusServiceUnavailable,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			q := alq
+			if tc.disabled {
+				q = &fakeAuditLogQuerier{}
+			}
+			req, err := http.NewRequest(http.MethodGet, tc.query, nil)
+			require.NoError(t, err)
+			recorder := httptest.NewRecorder()
+			HandleRecentFunc(q, "node1").ServeHTTP(recorder, req)
+			require.Equal(t, tc.expectedStatus, recorder.Code)
+			if tc.expectedStatus != http.StatusOK {
+				return
+			}
+			var seconds []int
+			decoder := json.NewDecoder(recorder.Body)
+			for decoder.More() {
+				var e Entry
+				require.NoError(t, decoder.Decode(&e))
+				assert.Equal(t, "node1", e.Node)
+				seconds = append(seconds, int(e.Timestamp.Sub(start)/time.Second))
+			}
+			// Only the first and the last entries are checked.
+			require.NotEmpty(t, seconds)
+			assert.Equal(t, tc.expectedSeconds, []int{seconds[0], seconds[len(seconds)-1]})
+		})
+	}
+}
+
+func TestParseEntry(t *testing.T) {
+	local := time.Local
+	time.Local = time.UTC
+	defer func() { time.Local = local }()
+
+	timestamp := time.Date(2021, 5, 1, 10, 30, 0, 0, time.UTC)
+	icmpType, icmpCode := uint8(8), uint8(0)
+	for _, tc := range []struct {
+		line          string
+		expectedEntry *Entry
+	}{
+		{
+			line:          "2021/05/01 10:30:00.000000 IngressDefaultRule K8sNetworkPolicy Drop 200 SRC: 10.0.0.1 DEST: 10.0.0.2 60 TCP",
+			expectedEntry: &Entry{Timestamp: timestamp, Table: "IngressDefaultRule", Policy: "K8sNetworkPolicy", Disposition: "Drop", Priority: "200", SrcIP: "10.0.0.1", DestIP: "10.0.0.2", Length: 60, Protocol: "TCP"},
+		},
+		{
+			line:          "2021/05/01 10:30:00.000000 AntreaPolicyEgressRule AntreaClusterNetworkPolicy:acnp1 Allow 44800 SRC: 10.0.0.3 DEST: 10.0.0.4 84 ICMP egress-1",
+			expectedEntry: &Entry{Timestamp: timestamp, Table: "AntreaPolicyEgressRule", Policy: "AntreaClusterNetworkPolicy:acnp1", Rule: "egress-1", Disposition: "Allow", Priority: "44800", SrcIP: "10.0.0.3", DestIP: "10.0.0.4", Length: 84, Protocol: "ICMP"},
+		},
+		{
+			line:          "2021/05/01 10:30:00.000000 AntreaPolicyEgressRule AntreaClusterNetworkPolicy:acnp1 Drop 44800 SRC: 10.0.0.3 DEST: 10.0.0.4 84 TCP egress-1 [25 packets]",
+			expectedEntry: &Entry{Timestamp: timestamp, Table: "AntreaPolicyEgressRule", Policy: "AntreaClusterNetworkPolicy:acnp1", Rule: "egress-1", Disposition: "Drop", Priority: "44800", SrcIP: "10.0.0.3", DestIP: "10.0.0.4", Length: 84, Protocol: "TCP", Packets: 25},
+		},
+		{
+			line:          `{"timestamp":"2021-05-01T10:30:00Z","table":"AntreaPolicyEgressRule","policy":"AntreaClusterNetworkPolicy:acnp 1","rule":"egress-1","disposition":"Drop","ofPriority":"44800","srcIP":"10.0.0.3","srcPort":34567,"destIP":"10.0.0.4","destPort":80,"pktLength":60,"protocol":"TCP","packetCount":25}`,
+			expectedEntry: &Entry{Timestamp: timestamp, Table: "AntreaPolicyEgressRule", Policy: "AntreaClusterNetworkPolicy:acnp 1", Rule: "egress-1", Disposition: "Drop", Priority: "44800", SrcIP: "10.0.0.3", SrcPort: 34567, DestIP: "10.0.0.4", DestPort: 80, Length: 60, Protocol: "TCP", Packets: 25},
+		},
+		{
+			line:          "2021/05/01 10:30:00.000000 AntreaPolicyEgressRule AntreaClusterNetworkPolicy:acnp1 Drop 44800 SRC: 10.0.0.3 DEST: 10.0.0.4 60 TCP 34567 80 egress-1 [25 packets]",
+			expectedEntry: &Entry{Timestamp: timestamp, Table: "AntreaPolicyEgressRule", Policy: "AntreaClusterNetworkPolicy:acnp1", Rule: "egress-1", Disposition: "Drop", Priority: "44800", SrcIP: "10.0.0.3", SrcPort: 34567, DestIP: "10.0.0.4", DestPort: 80, Length: 60, Protocol: "TCP", Packets: 25},
+		},
+		{
+			line:          "2021/05/01 10:30:00.000000 AntreaPolicyEgressRule AntreaClusterNetworkPolicy:acnp1 Allow 44800 SRC: 10.0.0.3 DEST: 10.0.0.4 84 ICMP 8 0 egress-1",
+			expectedEntry: &Entry{Timestamp: timestamp, Table: "AntreaPolicyEgressRule", Policy: "AntreaClusterNetworkPolicy:acnp1", Rule: "egress-1", Disposition: "Allow", Priority: "44800", SrcIP: "10.0.0.3", DestIP: "10.0.0.4", Length: 84, Protocol: "ICMP", ICMPType: &icmpType, ICMPCode: &icmpCode},
+		},
+		{
+			line:          "2021/05/01 10:30:00.000000 IngressDefaultRule K8sNetworkPolicy Drop 200 SRC: 10.0.0.1 DEST: 10.0.0.2 60 UDP - -",
+			expectedEntry: &Entry{Timestamp: timestamp, Table: "IngressDefaultRule", Policy: "K8sNetworkPolicy", Disposition: "Drop", Priority: "200", SrcIP: "10.0.0.1", DestIP: "10.0.0.2", Length: 60, Protocol: "UDP"},
+		},
+		{
+			line:          `{"timestamp":"2021-05-01T10:30:00Z","table":"AntreaPolicyEgressRule","policy":"AntreaClusterNetworkPolicy:acnp1","rule":"egress-1","disposition":"Allow","ofPriority":"44800","srcIP":"10.0.0.3","destIP":"10.0.0.4","icmpType":8,"icmpCode":0,"pktLength":84,"protocol":"ICMP"}`,
+			expectedEntry: &Entry{Timestamp: timestamp, Table: "AntreaPolicyEgressRule", Policy: "AntreaClusterNetworkPolicy:acnp1", Rule: "egress-1", Disposition: "Allow", Priority: "44800", SrcIP: "10.0.0.3", DestIP: "10.0.0.4", Length: 84, Protocol: "ICMP", ICMPType: &icmpType, ICMPCode: &icmpCode},
+		},
+		{
+			line: "2021/05/01 10:30:00.000000 AntreaPolicyEgressRule AntreaClusterNetworkPolicy:acnp1 Allow 44800 SRC: 10.0.0.3 DEST: 10.0.0.4 84 ICMP 8 0 egress-1 extra",
+		},
+		{
+			line: "2021/05/01 10:30:00.000000 AntreaPolicyEgressRule AntreaClusterNetworkPolicy:acnp1 Allow 44800 SRC: 10.0.0.3 DEST: 10.0.0.4 60 TCP http 80 egress-1",
+		},
+	} {
+		e, err := parseEntry(tc.line)
+		if tc.expectedEntry == nil {
+			assert.Error(t, err)
+			continue
+		}
+		require.NoError(t, err)
+		assert.Equal(t, tc.expectedEntry, e)
+	}
+}
@@ -0,0 +1,45 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package crashjournal
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"k8s.io/klog/v2"
+
+	"antrea.io/antrea/pkg/agent/crashjournal"
+)
+
+// HandleFunc returns the function which handles the queries of the crash journal at path. The
+// handler function populates the panics and fatal errors recorded by the previous runs of the
+// agent, from the oldest to the latest, to the response.
+func HandleFunc(path string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		entries, err := crashjournal.Read(path)
+		if err != nil {
+			klog.Errorf("Failed to read the crash journal: %v", err)
+			http.Error(w, "failed to read the crash journal", http.StatusInternalServerError)
+			return
+		}
+		if entries == nil {
+			entries = []crashjournal.Entry{}
+		}
+		if err := json.NewEncoder(w).Encode(entries); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			klog.Errorf("Error when encoding crash journal to json: %v", err)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package crashjournal
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"antrea.io/antrea/pkg/agent/crashjournal"
+)
+
+func TestHandleFunc(t *testing.T) {
+	dir, err := ioutil.TempDir("", "crashjournal")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "crash-journal.json")
+	handler := HandleFunc(path)
+
+	query := func() (int, []crashjournal.Entry) {
+		recorder := httptest.NewRecorder()
+		req, err := http.NewRequest(http.MethodGet, "", nil)
+		require.NoError(t, err)
+		handler.ServeHTTP(recorder, req)
+		var entries []crashjournal.Entry
+		if recorder.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &entries))
+		}
+		return recorder.Code, entries
+	}
+
+	// The agent has not crashed yet.
+	code, entries := query()
+	assert.Equal(t, http.StatusOK, code)
+	assert.Empty(t, entries)
+
+	journal := crashjournal.New(path, crashjournal.DefaultMaxEntries)
+	journal.SetConfigHash("abcd")
+	require.NoError(t, journal.RecordFatal("Error running agent: failed to connect to OVS"))
+	code, entries = query()
+	assert.Equal(t, http.StatusOK, code)
+	require.Len(t, entries, 1)
+	assert.Equal(t, crashjournal.KindFatal, entries[0].Kind)
+	assert.Equal(t, "Error running agent: failed to connect to OVS", entries[0].Message)
+	assert.Equal(t, "abcd", entries[0].ConfigHash)
+
+	require.NoError(t, ioutil.WriteFile(path, []byte("{"), 0644))
+	code, _ = query()
+	assert.Equal(t, http.StatusInternalServerError, code)
+}
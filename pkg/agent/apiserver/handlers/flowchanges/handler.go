@@ -0,0 +1,94 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flowchanges
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"antrea.io/antrea/pkg/agent/openflow"
+	"antrea.io/antrea/pkg/agent/querier"
+	"antrea.io/antrea/pkg/agent/types"
+	"antrea.io/antrea/pkg/antctl/transform/common"
+	binding "antrea.io/antrea/pkg/ovs/openflow"
+)
+
+// Response is the response struct of flowchanges command.
+type Response struct {
+	Time        string `json:"time"`
+	Operation   string `json:"operation"`
+	Category    string `json:"category"`
+	Cookie      string `json:"cookie"`
+	TableID     uint8  `json:"tableID"`
+	Table       string `json:"table"`
+	TriggerKind string `json:"triggerKind"`
+	TriggerName string `json:"triggerName,omitempty"`
+}
+
+func newResponse(change types.FlowChange) Response {
+	return Response{
+		Time:        change.Time.Format(time.RFC3339Nano),
+		Operation:   change.Operation,
+		Category:    change.Category,
+		Cookie:      fmt.Sprintf("%#x", change.Cookie),
+		TableID:     change.TableID,
+		Table:       openflow.GetFlowTableName(binding.TableIDType(change.TableID)),
+		TriggerKind: change.Trigger.Kind,
+		TriggerName: change.Trigger.Name,
+	}
+}
+
+// HandleFunc returns the function which can handle queries issued by the flowchanges command. The
+// handler function populates the recorded flow changes, from the oldest to the latest, to the
+// response. It returns 404 if flow change tracking is not enabled in the agent.
+func HandleFunc(aq querier.AgentQuerier) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		changes := aq.GetOpenflowClient().GetFlowChanges()
+		if changes == nil {
+			http.Error(w, "flow change tracking is not enabled, it can be enabled with the --flow-change-tracking-size flag of antrea-agent", http.StatusNotFound)
+			return
+		}
+		resps := make([]Response, 0, len(changes))
+		for _, change := range changes {
+			resps = append(resps, newResponse(change))
+		}
+		if err := json.NewEncoder(w).Encode(resps); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			klog.Errorf("Error when encoding flow changes to json: %v", err)
+		}
+	}
+}
+
+var _ common.TableOutput = new(Response)
+
+func (r Response) GetTableHeader() []string {
+	return []string{"TIME", "OPERATION", "CATEGORY", "COOKIE", "TABLE", "TRIGGER"}
+}
+
+func (r Response) GetTableRow(maxColumnLength int) []string {
+	trigger := r.TriggerKind
+	if r.TriggerName != "" {
+		trigger = fmt.Sprintf("%s %s", r.TriggerKind, r.TriggerName)
+	}
+	return []string{r.Time, r.Operation, r.Category, r.Cookie, fmt.Sprintf("%s(%d)", r.Table, r.TableID), trigger}
+}
+
+func (r Response) SortRows() bool {
+	return false
+}
@@ -0,0 +1,101 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flowexporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"antrea.io/antrea/pkg/antctl/transform/common"
+	"antrea.io/antrea/pkg/querier"
+)
+
+// Response is the response struct of flowexporter command.
+type Response struct {
+	Collector       string `json:"collector"`
+	Address         string `json:"address"`
+	Protocol        string `json:"protocol"`
+	Connected       bool   `json:"connected"`
+	RecordsSent     uint64 `json:"recordsSent"`
+	RecordsFiltered uint64 `json:"recordsFiltered"`
+	Failures        uint64 `json:"failures"`
+	LastError       string `json:"lastError,omitempty"`
+	// LastExportTime is not set if no flow record has been sent to the collector.
+	LastExportTime string `json:"lastExportTime,omitempty"`
+}
+
+func newResponse(status querier.FlowCollectorStatus) Response {
+	resp := Response{
+		Collector:       status.Name,
+		Address:         status.Address,
+		Protocol:        status.Protocol,
+		Connected:       status.Connected,
+		RecordsSent:     status.RecordsSent,
+		RecordsFiltered: status.RecordsFiltered,
+		Failures:        status.Failures,
+		LastError:       status.LastError,
+	}
+	if !status.LastExportTime.IsZero() {
+		resp.LastExportTime = status.LastExportTime.UTC().Format(time.RFC3339)
+	}
+	return resp
+}
+
+// HandleFunc returns the function which can handle API requests to "/flowexporter". The status of
+// a single collector is returned if the "collector" query parameter is provided.
+func HandleFunc(feq querier.AgentFlowExporterQuerier) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if feq == nil {
+			http.Error(w, "FlowExporter is not enabled", http.StatusServiceUnavailable)
+			return
+		}
+		collector := r.URL.Query().Get("collector")
+		resps := []Response{}
+		for _, status := range feq.GetFlowCollectorStatuses() {
+			if collector == "" || status.Name == collector {
+				resps = append(resps, newResponse(status))
+			}
+		}
+		if collector != "" && len(resps) == 0 {
+			http.Error(w, fmt.Sprintf("flow collector %s not found", collector), http.StatusNotFound)
+			return
+		}
+
+		if err := json.NewEncoder(w).Encode(resps); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			klog.Errorf("Error when encoding flow collector statuses to json: %v", err)
+		}
+	}
+}
+
+var _ common.TableOutput = new(Response)
+
+func (r Response) GetTableHeader() []string {
+	return []string{"COLLECTOR", "ADDRESS", "PROTOCOL", "CONNECTED", "RECORDS-SENT", "RECORDS-FILTERED", "FAILURES", "LAST-ERROR"}
+}
+
+func (r Response) GetTableRow(maxColumnLength int) []string {
+	return []string{r.Collector, r.Address, r.Protocol, strconv.FormatBool(r.Connected), strconv.FormatUint(r.RecordsSent, 10),
+		strconv.FormatUint(r.RecordsFiltered, 10), strconv.FormatUint(r.Failures, 10), r.LastError}
+}
+
+func (r Response) SortRows() bool {
+	return false
+}
@@ -0,0 +1,107 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flowexporter
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"antrea.io/antrea/pkg/querier"
+)
+
+type fakeQuerier struct {
+	statuses []querier.FlowCollectorStatus
+}
+
+func (q *fakeQuerier) GetFlowCollectorStatuses() []querier.FlowCollectorStatus {
+	return q.statuses
+}
+
+func TestHandleFunc(t *testing.T) {
+	exportTime := time.Date(2021, 6, 1, 10, 0, 0, 0, time.UTC)
+	feq := &fakeQuerier{statuses: []querier.FlowCollectorStatus{
+		{
+			Name:            "security",
+			Address:         "10.10.0.1:4739",
+			Protocol:        "tls",
+			Connected:       true,
+			RecordsSent:     100,
+			RecordsFiltered: 20,
+			LastExportTime:  exportTime,
+		},
+		// A collector which has been down since the agent started.
+		{
+			Name:      "debug",
+			Address:   "10.10.0.2:4739",
+			Protocol:  "udp",
+			Failures:  3,
+			LastError: "error when starting exporter: connection refused",
+		},
+	}}
+	security := Response{Collector: "security", Address: "10.10.0.1:4739", Protocol: "tls", Connected: true, RecordsSent: 100, RecordsFiltered: 20, LastExportTime: "2021-06-01T10:00:00Z"}
+	debug := Response{Collector: "debug", Address: "10.10.0.2:4739", Protocol: "udp", Failures: 3, LastError: "error when starting exporter: connection refused"}
+
+	tests := []struct {
+		name          string
+		querier       querier.AgentFlowExporterQuerier
+		query         string
+		expectedCode  int
+		expectedResps []Response
+	}{
+		{
+			name:          "all collectors",
+			querier:       feq,
+			expectedCode:  http.StatusOK,
+			expectedResps: []Response{security, debug},
+		},
+		{
+			name:          "single collector",
+			querier:       feq,
+			query:         "?collector=debug",
+			expectedCode:  http.StatusOK,
+			expectedResps: []Response{debug},
+		},
+		{
+			name:         "unknown collector",
+			querier:      feq,
+			query:        "?collector=other",
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "feature disabled",
+			expectedCode: http.StatusServiceUnavailable,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, "/flowexporter"+tt.query, nil)
+			require.NoError(t, err)
+			recorder := httptest.NewRecorder()
+			HandleFunc(tt.querier)(recorder, req)
+			assert.Equal(t, tt.expectedCode, recorder.Code)
+			if tt.expectedCode == http.StatusOK {
+				var received []Response
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &received))
+				assert.Equal(t, tt.expectedResps, received)
+			}
+		})
+	}
+}
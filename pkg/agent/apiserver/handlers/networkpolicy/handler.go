@@ -19,13 +19,26 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"reflect"
+	"sort"
 	"strings"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/klog/v2"
+
+	"antrea.io/antrea/pkg/agent/apiserver/handlers"
+	nptypes "antrea.io/antrea/pkg/agent/apiserver/handlers/networkpolicy/types"
 	agentquerier "antrea.io/antrea/pkg/agent/querier"
 	cpv1beta "antrea.io/antrea/pkg/apis/controlplane/v1beta2"
 	"antrea.io/antrea/pkg/querier"
 )
 
+// WatchEvent is the object streamed by the handler in watch mode, for each NetworkPolicy
+// which is added, updated or deleted in the agent.
+type WatchEvent = nptypes.WatchEvent
+
 // HandleFunc creates a http.HandlerFunc which uses an AgentNetworkPolicyInfoQuerier
 // to query network policy rules in current agent.
 func HandleFunc(aq agentquerier.AgentQuerier) http.HandlerFunc {
@@ -36,19 +49,22 @@ func HandleFunc(aq agentquerier.AgentQuerier) http.HandlerFunc {
 			return
 		}
 
-		var obj interface{}
-		npq := aq.GetNetworkPolicyInfoQuerier()
-		var nps []cpv1beta.NetworkPolicy
+		if handlers.IsWatchRequest(r) {
+			known := map[types.UID]cpv1beta.NetworkPolicy{}
+			handlers.Stream(w, r, handlers.DefaultWatchInterval, func() ([]interface{}, error) {
+				return diffNetworkPolicies(known, getNetworkPolicies(aq, npFilter)), nil
+			})
+			return
+		}
 
-		if npFilter.Pod != "" {
-			interfaces := aq.GetInterfaceStore().GetContainerInterfacesByPod(npFilter.Pod, npFilter.Namespace)
-			if len(interfaces) > 0 {
-				nps = npq.GetAppliedNetworkPolicies(npFilter.Pod, npFilter.Namespace, npFilter)
-			}
-		} else {
-			nps = npq.GetNetworkPolicies(npFilter)
+		// A Pod unknown to this agent, e.g. because its CNI ADD has not completed yet, must not
+		// be reported as a Pod to which no NetworkPolicy applies.
+		if npFilter.Pod != "" && len(aq.GetInterfaceStore().GetContainerInterfacesByPod(npFilter.Pod, npFilter.Namespace)) == 0 {
+			writeNotFound(w, fmt.Sprintf("Pod %s/%s is unknown to this agent, its network may not be set up yet", npFilter.Namespace, npFilter.Pod))
+			return
 		}
-		obj = cpv1beta.NetworkPolicyList{Items: nps}
+
+		obj := cpv1beta.NetworkPolicyList{Items: getNetworkPolicies(aq, npFilter)}
 
 		if err := json.NewEncoder(w).Encode(obj); err != nil {
 			http.Error(w, "Failed to encode response: "+err.Error(), http.StatusInternalServerError)
@@ -56,6 +72,69 @@ func HandleFunc(aq agentquerier.AgentQuerier) http.HandlerFunc {
 	}
 }
 
+// writeNotFound writes a NotFound Status, which API clients decode into an error with the
+// provided message.
+func writeNotFound(w http.ResponseWriter, message string) {
+	status := metav1.Status{
+		TypeMeta: metav1.TypeMeta{Kind: "Status", APIVersion: "v1"},
+		Status:   metav1.StatusFailure,
+		Message:  message,
+		Reason:   metav1.StatusReasonNotFound,
+		Code:     http.StatusNotFound,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusNotFound)
+	if err := json.NewEncoder(w).Encode(status); err != nil {
+		klog.Errorf("Failed to encode response: %v", err)
+	}
+}
+
+func getNetworkPolicies(aq agentquerier.AgentQuerier, npFilter *querier.NetworkPolicyQueryFilter) []cpv1beta.NetworkPolicy {
+	npq := aq.GetNetworkPolicyInfoQuerier()
+	if npFilter.Pod != "" {
+		interfaces := aq.GetInterfaceStore().GetContainerInterfacesByPod(npFilter.Pod, npFilter.Namespace)
+		if len(interfaces) > 0 {
+			return npq.GetAppliedNetworkPolicies(npFilter.Pod, npFilter.Namespace, npFilter)
+		}
+		return nil
+	}
+	return npq.GetNetworkPolicies(npFilter)
+}
+
+// diffNetworkPolicies generates the WatchEvents which turn the known NetworkPolicies into
+// the current ones, and updates the known NetworkPolicies accordingly. Events are sorted by
+// NetworkPolicy name.
+func diffNetworkPolicies(known map[types.UID]cpv1beta.NetworkPolicy, current []cpv1beta.NetworkPolicy) []interface{} {
+	var events []WatchEvent
+	currentUIDs := make(map[types.UID]bool, len(current))
+	for _, np := range current {
+		currentUIDs[np.UID] = true
+		knownNP, exists := known[np.UID]
+		if !exists {
+			events = append(events, WatchEvent{Type: watch.Added, Object: np})
+		} else if !reflect.DeepEqual(knownNP, np) {
+			events = append(events, WatchEvent{Type: watch.Modified, Object: np})
+		} else {
+			continue
+		}
+		known[np.UID] = np
+	}
+	for uid, np := range known {
+		if !currentUIDs[uid] {
+			events = append(events, WatchEvent{Type: watch.Deleted, Object: np})
+			delete(known, uid)
+		}
+	}
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].Object.Name < events[j].Object.Name
+	})
+	objs := make([]interface{}, 0, len(events))
+	for _, event := range events {
+		objs = append(objs, event)
+	}
+	return objs
+}
+
 // From user shorthand input to cpv1beta1.NetworkPolicyType
 var mapToNetworkPolicyType = map[string]cpv1beta.NetworkPolicyType{
 	"NP":    cpv1beta.K8sNetworkPolicy,
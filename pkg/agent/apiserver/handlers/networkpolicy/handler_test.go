@@ -0,0 +1,116 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package networkpolicy
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"antrea.io/antrea/pkg/agent/interfacestore"
+	interfacestoretest "antrea.io/antrea/pkg/agent/interfacestore/testing"
+	queriertest "antrea.io/antrea/pkg/agent/querier/testing"
+	cpv1beta "antrea.io/antrea/pkg/apis/controlplane/v1beta2"
+	"antrea.io/antrea/pkg/querier"
+	npqueriertest "antrea.io/antrea/pkg/querier/testing"
+)
+
+func TestPodNetworkPolicyQuery(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	podInterface := &interfacestore.InterfaceConfig{
+		InterfaceName: "interface0",
+		ContainerInterfaceConfig: &interfacestore.ContainerInterfaceConfig{
+			ContainerID:  "containerid0",
+			PodName:      "pod0",
+			PodNamespace: "ns0",
+		},
+	}
+	np := cpv1beta.NetworkPolicy{ObjectMeta: metav1.ObjectMeta{Name: "np0", UID: "uid0"}}
+
+	testcases := map[string]struct {
+		query            string
+		interfaces       []*interfacestore.InterfaceConfig
+		appliedPolicies  []cpv1beta.NetworkPolicy
+		expectedStatus   int
+		expectedPolicies []cpv1beta.NetworkPolicy
+	}{
+		"Pod without namespace": {
+			query:          "?pod=pod0",
+			expectedStatus: http.StatusBadRequest,
+		},
+		"Pod unknown to the agent": {
+			query:          "?pod=pod0&namespace=ns0",
+			expectedStatus: http.StatusNotFound,
+		},
+		"Pod known with no policy": {
+			query:            "?pod=pod0&namespace=ns0",
+			interfaces:       []*interfacestore.InterfaceConfig{podInterface},
+			appliedPolicies:  []cpv1beta.NetworkPolicy{},
+			expectedStatus:   http.StatusOK,
+			expectedPolicies: []cpv1beta.NetworkPolicy{},
+		},
+		"Pod known with policies": {
+			query:            "?pod=pod0&namespace=ns0",
+			interfaces:       []*interfacestore.InterfaceConfig{podInterface},
+			appliedPolicies:  []cpv1beta.NetworkPolicy{np},
+			expectedStatus:   http.StatusOK,
+			expectedPolicies: []cpv1beta.NetworkPolicy{np},
+		},
+	}
+
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			i := interfacestoretest.NewMockInterfaceStore(ctrl)
+			i.EXPECT().GetContainerInterfacesByPod("pod0", "ns0").Return(tc.interfaces).AnyTimes()
+			npq := npqueriertest.NewMockAgentNetworkPolicyInfoQuerier(ctrl)
+			if tc.appliedPolicies != nil {
+				npq.EXPECT().GetAppliedNetworkPolicies("pod0", "ns0", gomock.Any()).DoAndReturn(
+					func(pod, namespace string, _ *querier.NetworkPolicyQueryFilter) []cpv1beta.NetworkPolicy {
+						return tc.appliedPolicies
+					})
+			}
+			q := queriertest.NewMockAgentQuerier(ctrl)
+			q.EXPECT().GetInterfaceStore().Return(i).AnyTimes()
+			q.EXPECT().GetNetworkPolicyInfoQuerier().Return(npq).AnyTimes()
+			handler := HandleFunc(q)
+
+			req, err := http.NewRequest(http.MethodGet, tc.query, nil)
+			require.NoError(t, err)
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, req)
+			assert.Equal(t, tc.expectedStatus, recorder.Code)
+
+			switch tc.expectedStatus {
+			case http.StatusOK:
+				var received cpv1beta.NetworkPolicyList
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &received))
+				assert.Equal(t, tc.expectedPolicies, received.Items)
+			case http.StatusNotFound:
+				var status metav1.Status
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &status))
+				assert.Equal(t, metav1.StatusReasonNotFound, status.Reason)
+				assert.Contains(t, status.Message, "ns0/pod0")
+			}
+		})
+	}
+}
@@ -0,0 +1,30 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// Package types defines the schema of the /networkpolicies API of the Antrea Agent, which returns the control plane
+// NetworkPolicies received by the Agent. It has no dependency on the Agent implementation, so that it can be imported
+// by external consumers of the API.
+package types
+
+import (
+	"k8s.io/apimachinery/pkg/watch"
+
+	cpv1beta "antrea.io/antrea/pkg/apis/controlplane/v1beta2"
+)
+
+// WatchEvent is streamed when the API is queried in watch mode, i.e. with the "watch=true" query parameter, for
+// each NetworkPolicy which is added, updated or deleted in the Agent.
+type WatchEvent struct {
+	Type   watch.EventType        `json:"type"`
+	Object cpv1beta.NetworkPolicy `json:"object"`
+}
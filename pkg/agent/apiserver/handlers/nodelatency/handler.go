@@ -0,0 +1,104 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nodelatency
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"antrea.io/antrea/pkg/antctl/transform/common"
+	"antrea.io/antrea/pkg/querier"
+)
+
+// Response is the response struct of nodelatency command.
+type Response struct {
+	PeerNode  string `json:"peerNode"`
+	GatewayIP string `json:"gatewayIP"`
+	// LastRTTMilliseconds is not set if no probe of the peer Node has succeeded.
+	LastRTTMilliseconds *float64 `json:"lastRTTMilliseconds,omitempty"`
+	// LastProbeTime is not set if the peer Node has not been probed yet.
+	LastProbeTime       string  `json:"lastProbeTime,omitempty"`
+	PacketLoss          float64 `json:"packetLoss"`
+	ConsecutiveFailures int     `json:"consecutiveFailures"`
+	Reachable           bool    `json:"reachable"`
+}
+
+func newResponse(latency querier.PeerNodeLatency) Response {
+	resp := Response{
+		PeerNode:            latency.NodeName,
+		GatewayIP:           latency.GatewayIP.String(),
+		PacketLoss:          latency.PacketLoss,
+		ConsecutiveFailures: latency.ConsecutiveFailures,
+		Reachable:           latency.Reachable,
+	}
+	if latency.LastRTT > 0 {
+		rtt := float64(latency.LastRTT) / float64(time.Millisecond)
+		resp.LastRTTMilliseconds = &rtt
+	}
+	if !latency.LastProbeTime.IsZero() {
+		resp.LastProbeTime = latency.LastProbeTime.UTC().Format(time.RFC3339)
+	}
+	return resp
+}
+
+// HandleFunc returns the function which can handle API requests to "/nodelatency". The latency
+// of a single peer Node is returned if the "node" query parameter is provided.
+func HandleFunc(nlq querier.AgentNodeLatencyQuerier) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if nlq == nil {
+			http.Error(w, "NodeLatencyMonitor is not enabled", http.StatusServiceUnavailable)
+			return
+		}
+		node := r.URL.Query().Get("node")
+		resps := []Response{}
+		for _, latency := range nlq.GetPeerNodeLatencies() {
+			if node == "" || latency.NodeName == node {
+				resps = append(resps, newResponse(latency))
+			}
+		}
+		if node != "" && len(resps) == 0 {
+			http.Error(w, fmt.Sprintf("peer Node %s not found", node), http.StatusNotFound)
+			return
+		}
+
+		if err := json.NewEncoder(w).Encode(resps); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			klog.Errorf("Error when encoding Node latency to json: %v", err)
+		}
+	}
+}
+
+var _ common.TableOutput = new(Response)
+
+func (r Response) GetTableHeader() []string {
+	return []string{"PEER-NODE", "GATEWAY-IP", "LAST-RTT", "PACKET-LOSS", "REACHABLE"}
+}
+
+func (r Response) GetTableRow(maxColumnLength int) []string {
+	var rtt string
+	if r.LastRTTMilliseconds != nil {
+		rtt = fmt.Sprintf("%.3fms", *r.LastRTTMilliseconds)
+	}
+	return []string{r.PeerNode, r.GatewayIP, rtt, fmt.Sprintf("%.0f%%", r.PacketLoss*100), strconv.FormatBool(r.Reachable)}
+}
+
+func (r Response) SortRows() bool {
+	return true
+}
@@ -0,0 +1,121 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nodelatency
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"antrea.io/antrea/pkg/querier"
+)
+
+type fakeQuerier struct {
+	latencies []querier.PeerNodeLatency
+}
+
+func (q *fakeQuerier) GetPeerNodeLatencies() []querier.PeerNodeLatency {
+	return q.latencies
+}
+
+func (q *fakeQuerier) GetUnreachablePeerNodes() []string {
+	return nil
+}
+
+func floatPtr(f float64) *float64 {
+	return &f
+}
+
+func TestHandleFunc(t *testing.T) {
+	probeTime := time.Date(2021, 6, 1, 10, 0, 0, 0, time.UTC)
+	nlq := &fakeQuerier{latencies: []querier.PeerNodeLatency{
+		{
+			NodeName:      "node1",
+			GatewayIP:     net.ParseIP("10.10.1.1"),
+			LastRTT:       1500 * time.Microsecond,
+			LastProbeTime: probeTime,
+			PacketLoss:    0.1,
+			Reachable:     true,
+		},
+		{
+			NodeName:            "node2",
+			GatewayIP:           net.ParseIP("10.10.2.1"),
+			LastProbeTime:       probeTime,
+			PacketLoss:          1,
+			ConsecutiveFailures: 3,
+		},
+		// A peer Node which has not been probed yet.
+		{
+			NodeName:  "node3",
+			GatewayIP: net.ParseIP("10.10.3.1"),
+			Reachable: true,
+		},
+	}}
+	node1 := Response{PeerNode: "node1", GatewayIP: "10.10.1.1", LastRTTMilliseconds: floatPtr(1.5), LastProbeTime: "2021-06-01T10:00:00Z", PacketLoss: 0.1, Reachable: true}
+	node2 := Response{PeerNode: "node2", GatewayIP: "10.10.2.1", LastProbeTime: "2021-06-01T10:00:00Z", PacketLoss: 1, ConsecutiveFailures: 3}
+	node3 := Response{PeerNode: "node3", GatewayIP: "10.10.3.1", Reachable: true}
+
+	tests := []struct {
+		name          string
+		querier       querier.AgentNodeLatencyQuerier
+		query         string
+		expectedCode  int
+		expectedResps []Response
+	}{
+		{
+			name:          "all peer Nodes",
+			querier:       nlq,
+			expectedCode:  http.StatusOK,
+			expectedResps: []Response{node1, node2, node3},
+		},
+		{
+			name:          "single peer Node",
+			querier:       nlq,
+			query:         "?node=node2",
+			expectedCode:  http.StatusOK,
+			expectedResps: []Response{node2},
+		},
+		{
+			name:         "unknown peer Node",
+			querier:      nlq,
+			query:        "?node=node4",
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "feature disabled",
+			expectedCode: http.StatusServiceUnavailable,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, "/nodelatency"+tt.query, nil)
+			require.NoError(t, err)
+			recorder := httptest.NewRecorder()
+			HandleFunc(tt.querier)(recorder, req)
+			assert.Equal(t, tt.expectedCode, recorder.Code)
+			if tt.expectedCode == http.StatusOK {
+				var received []Response
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &received))
+				assert.Equal(t, tt.expectedResps, received)
+			}
+		})
+	}
+}
@@ -16,12 +16,17 @@ package ovsflows
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 
 	"k8s.io/klog/v2"
 
+	"antrea.io/antrea/pkg/agent/apiserver/handlers"
+	"antrea.io/antrea/pkg/agent/apiserver/handlers/ovsflows/types"
 	"antrea.io/antrea/pkg/agent/openflow"
 	agentquerier "antrea.io/antrea/pkg/agent/querier"
 	"antrea.io/antrea/pkg/antctl/transform/common"
@@ -30,10 +35,14 @@ import (
 	"antrea.io/antrea/pkg/querier"
 )
 
-// Response is the response struct of ovsflows command.
-type Response struct {
-	Flow string `json:"flow,omitempty"`
-}
+// Response is the response struct of ovsflows command. Its schema is defined by types.Flow.
+type Response types.Flow
+
+// TableFlowCount is the object streamed by the handler in watch mode. Its schema is defined by
+// types.TableFlowCount.
+type TableFlowCount types.TableFlowCount
+
+var flowTableRegexp = regexp.MustCompile(`(?:^|[\s,])table=([^,\s]+)`)
 
 func dumpMatchedFlows(aq agentquerier.AgentQuerier, flowKeys []string) ([]Response, error) {
 	resps := []Response{}
@@ -86,24 +95,13 @@ func dumpMatchedGroups(aq agentquerier.AgentQuerier, groupIDs []binding.GroupIDT
 // nil is returned if the flow table can not be found (the passed table name or
 // number is invalid).
 func getTableFlows(aq agentquerier.AgentQuerier, tables string) ([]Response, error) {
+	tableIDs := parseTables(tables)
+	if tableIDs == nil {
+		return nil, nil
+	}
 	var resps []Response
-	for _, tableSeg := range strings.Split(tables, ",") {
-		tableSeg = strings.TrimSpace(tableSeg)
-		var tableNumber binding.TableIDType
-		// Table nubmer is a 8-bit unsigned integer.
-		n, err := strconv.ParseUint(tableSeg, 10, 8)
-		if err == nil {
-			tableNumber = binding.TableIDType(n)
-			if openflow.GetFlowTableName(tableNumber) == "" {
-				return nil, nil
-			}
-		} else {
-			tableNumber = openflow.GetFlowTableNumber(tableSeg)
-			if tableNumber == binding.TableIDAll {
-				return nil, nil
-			}
-		}
-		resp, err := dumpFlows(aq, tableNumber)
+	for _, tableID := range tableIDs {
+		resp, err := dumpFlows(aq, tableID)
 		if err != nil {
 			return nil, err
 		}
@@ -179,9 +177,138 @@ func getNetworkPolicyFlows(aq agentquerier.AgentQuerier, npName, namespace strin
 	return dumpMatchedFlows(aq, flowKeys)
 }
 
+// getCapabilities returns the OpenFlow version, the OVS version and the optional OVS capabilities
+// detected by the agent, one per line.
+func getCapabilities(aq agentquerier.AgentQuerier) []Response {
+	capabilities := aq.GetOpenflowClient().GetOVSCapabilities()
+	resps := []Response{
+		{fmt.Sprintf("openflow_version=%s", capabilities.OpenFlowVersion)},
+		{fmt.Sprintf("ovs_version=%s", capabilities.OVSVersion)},
+	}
+	for _, capability := range capabilities.Available {
+		resps = append(resps, Response{fmt.Sprintf("capability=%s", capability)})
+	}
+	return resps
+}
+
+// parseTables returns the IDs of the comma separated flow table names or numbers. nil is
+// returned if any flow table can not be found.
+func parseTables(tables string) []binding.TableIDType {
+	var tableIDs []binding.TableIDType
+	for _, tableSeg := range strings.Split(tables, ",") {
+		tableSeg = strings.TrimSpace(tableSeg)
+		// Table nubmer is a 8-bit unsigned integer.
+		n, err := strconv.ParseUint(tableSeg, 10, 8)
+		if err == nil {
+			if openflow.GetFlowTableName(binding.TableIDType(n)) == "" {
+				return nil
+			}
+			tableIDs = append(tableIDs, binding.TableIDType(n))
+		} else {
+			tableNumber := openflow.GetFlowTableNumber(tableSeg)
+			if tableNumber == binding.TableIDAll {
+				return nil
+			}
+			tableIDs = append(tableIDs, tableNumber)
+		}
+	}
+	return tableIDs
+}
+
+// getTableFlowCounts returns the number of flows of each table in tableIDs, or of all tables
+// having flows if tableIDs is empty. The delta of each count is computed from the counts of
+// the previous call, which are updated in lastCounts.
+func getTableFlowCounts(aq agentquerier.AgentQuerier, tableIDs []binding.TableIDType, lastCounts map[uint8]int) ([]TableFlowCount, error) {
+	flowStrs, err := aq.GetOVSCtlClient().DumpFlows()
+	if err != nil {
+		return nil, err
+	}
+	counts := map[uint8]int{}
+	for _, tableID := range tableIDs {
+		counts[uint8(tableID)] = 0
+	}
+	for _, flowStr := range flowStrs {
+		match := flowTableRegexp.FindStringSubmatch(flowStr)
+		if match == nil {
+			continue
+		}
+		// Flow tables are printed with their names if they are known by OVS.
+		var tableID uint8
+		if n, err := strconv.ParseUint(match[1], 10, 8); err == nil {
+			tableID = uint8(n)
+		} else if tableNumber := openflow.GetFlowTableNumber(match[1]); tableNumber != binding.TableIDAll {
+			tableID = uint8(tableNumber)
+		} else {
+			continue
+		}
+		if _, ok := counts[tableID]; ok || len(tableIDs) == 0 {
+			counts[tableID]++
+		}
+	}
+	// Tables which had flows during the previous call and no longer have any must be reported.
+	for tableID := range lastCounts {
+		if _, ok := counts[tableID]; !ok {
+			counts[tableID] = 0
+		}
+	}
+	resps := make([]TableFlowCount, 0, len(counts))
+	for tableID, count := range counts {
+		tableName := openflow.GetFlowTableName(binding.TableIDType(tableID))
+		if tableName == "" {
+			tableName = fmt.Sprint(tableID)
+		}
+		resps = append(resps, TableFlowCount{
+			TableID: tableID,
+			Table:   tableName,
+			Count:   count,
+			Delta:   count - lastCounts[tableID],
+		})
+		if count == 0 && len(tableIDs) == 0 {
+			delete(lastCounts, tableID)
+		} else {
+			lastCounts[tableID] = count
+		}
+	}
+	sort.Slice(resps, func(i, j int) bool {
+		return resps[i].TableID < resps[j].TableID
+	})
+	return resps, nil
+}
+
+// watchTableFlowCounts streams the flow counts of the tables every DefaultWatchInterval.
+func watchTableFlowCounts(aq agentquerier.AgentQuerier, w http.ResponseWriter, r *http.Request) {
+	table := r.URL.Query().Get("table")
+	for _, param := range []string{"pod", "service", "networkpolicy", "namespace", "groups", "capabilities"} {
+		if r.URL.Query().Get(param) != "" {
+			http.Error(w, "only the table parameter is supported in watch mode", http.StatusBadRequest)
+			return
+		}
+	}
+	var tableIDs []binding.TableIDType
+	if table != "" {
+		if tableIDs = parseTables(table); tableIDs == nil {
+			http.Error(w, "invalid table name or number", http.StatusBadRequest)
+			return
+		}
+	}
+	lastCounts := map[uint8]int{}
+	handlers.Stream(w, r, handlers.DefaultWatchInterval, func() ([]interface{}, error) {
+		counts, err := getTableFlowCounts(aq, tableIDs, lastCounts)
+		if err != nil {
+			return nil, err
+		}
+		return []interface{}{counts}, nil
+	})
+}
+
 // HandleFunc returns the function which can handle API requests to "/ovsflows".
 func HandleFunc(aq agentquerier.AgentQuerier) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		if handlers.IsWatchRequest(r) {
+			watchTableFlowCounts(aq, w, r)
+			return
+		}
+
 		var err error
 		var resps []Response
 		pod := r.URL.Query().Get("pod")
@@ -190,14 +317,18 @@ func HandleFunc(aq agentquerier.AgentQuerier) http.HandlerFunc {
 		namespace := r.URL.Query().Get("namespace")
 		table := r.URL.Query().Get("table")
 		groups := r.URL.Query().Get("groups")
+		capabilities := r.URL.Query().Get("capabilities")
 
-		if (pod != "" || service != "" || networkPolicy != "") && namespace == "" {
+		// The flows of an Antrea ClusterNetworkPolicy are requested without namespace.
+		if (pod != "" || service != "") && namespace == "" {
 			http.Error(w, "namespace must be provided", http.StatusBadRequest)
 			return
 		}
 
-		if pod == "" && service == "" && networkPolicy == "" && namespace == "" && table == "" && groups == "" {
+		if pod == "" && service == "" && networkPolicy == "" && namespace == "" && table == "" && groups == "" && capabilities == "" {
 			resps, err = dumpFlows(aq, binding.TableIDAll)
+		} else if capabilities != "" {
+			resps = getCapabilities(aq)
 		} else if pod != "" {
 			// Pod Namespace must be provided to dump flows of a Pod.
 			resps, err = getPodFlows(aq, pod, namespace)
@@ -256,3 +387,21 @@ func (r Response) GetTableRow(maxColumnLength int) []string {
 func (r Response) SortRows() bool {
 	return false
 }
+
+var _ common.TableOutput = new(TableFlowCount)
+
+func (c TableFlowCount) GetTableHeader() []string {
+	return []string{"TABLE", "FLOWS", "DELTA"}
+}
+
+func (c TableFlowCount) GetTableRow(maxColumnLength int) []string {
+	delta := strconv.Itoa(c.Delta)
+	if c.Delta > 0 {
+		delta = "+" + delta
+	}
+	return []string{fmt.Sprintf("%s(%d)", c.Table, c.TableID), strconv.Itoa(c.Count), delta}
+}
+
+func (c TableFlowCount) SortRows() bool {
+	return false
+}
@@ -58,7 +58,6 @@ func TestBadRequests(t *testing.T) {
 	badRequests := map[string]string{
 		"Pod only":                  "?pod=pod1",
 		"Service only":              "?service=svc1",
-		"NetworkPolicy only":        "?networkpolicy=np1",
 		"Namespace only":            "?namespace=ns1",
 		"Pod and NetworkPolicy":     "?pod=pod1&&networkpolicy=np1",
 		"Pod and table":             "?pod=pod1&&table=0",
@@ -185,6 +184,12 @@ func TestNetworkPolicyFlows(t *testing.T) {
 			query:          "?networkpolicy=np1&&namespace=ns1",
 			expectedStatus: http.StatusOK,
 		},
+		{
+			test:           "Existing ClusterNetworkPolicy",
+			name:           "acnp1",
+			query:          "?networkpolicy=acnp1",
+			expectedStatus: http.StatusOK,
+		},
 		{
 			test:           "Non-existing NetworkPolicy",
 			name:           "np2",
@@ -246,6 +251,33 @@ func TestTableFlows(t *testing.T) {
 
 }
 
+func TestCapabilities(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tc := testCase{
+		test:           "Capabilities",
+		query:          "?capabilities=true",
+		expectedStatus: http.StatusOK,
+		resps: []Response{
+			{"openflow_version=OpenFlow13"},
+			{"ovs_version=2.14.0"},
+			{"capability=LearnDeleteLearned"},
+			{"capability=Meter"},
+		},
+	}
+	ofc := oftest.NewMockClient(ctrl)
+	q := aqtest.NewMockAgentQuerier(ctrl)
+	q.EXPECT().GetOpenflowClient().Return(ofc).Times(1)
+	ofc.EXPECT().GetOVSCapabilities().Return(binding.Capabilities{
+		OpenFlowVersion: binding.OpenFlow13,
+		OVSVersion:      "2.14.0",
+		Available:       []binding.Capability{binding.CapabilityLearnDeleteLearned, binding.CapabilityMeter},
+	}).Times(1)
+
+	runHTTPTest(t, &tc, q)
+}
+
 func TestGroups(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -334,3 +366,48 @@ func runHTTPTest(t *testing.T, tc *testCase, aq agentquerier.AgentQuerier) {
 		}
 	}
 }
+
+func TestTableFlowCounts(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ovsctl := ovsctltest.NewMockOVSCtlClient(ctrl)
+	q := aqtest.NewMockAgentQuerier(ctrl)
+	q.EXPECT().GetOVSCtlClient().Return(ovsctl).AnyTimes()
+	gomock.InOrder(
+		ovsctl.EXPECT().DumpFlows().Return([]string{
+			"cookie=0x0, table=0, n_packets=0, n_bytes=0, priority=0 actions=resubmit(,10)",
+			"cookie=0x0, table=IngressRule, n_packets=0, n_bytes=0, priority=200,ip actions=conjunction(1,1/2)",
+			"cookie=0x0, table=90, n_packets=0, n_bytes=0, priority=0 actions=resubmit(,100)",
+		}, nil),
+		ovsctl.EXPECT().DumpFlows().Return([]string{
+			"cookie=0x0, table=90, n_packets=0, n_bytes=0, priority=0 actions=resubmit(,100)",
+		}, nil),
+		ovsctl.EXPECT().DumpFlows().Return([]string{
+			"cookie=0x0, table=90, n_packets=0, n_bytes=0, priority=0 actions=resubmit(,100)",
+		}, nil),
+	)
+
+	lastCounts := map[uint8]int{}
+	counts, err := getTableFlowCounts(q, nil, lastCounts)
+	assert.NoError(t, err)
+	assert.Equal(t, []TableFlowCount{
+		{TableID: 0, Table: "Classification", Count: 1, Delta: 1},
+		{TableID: 90, Table: "IngressRule", Count: 2, Delta: 2},
+	}, counts)
+
+	// A table which no longer has any flow is reported once with a zero count.
+	counts, err = getTableFlowCounts(q, nil, lastCounts)
+	assert.NoError(t, err)
+	assert.Equal(t, []TableFlowCount{
+		{TableID: 0, Table: "Classification", Count: 0, Delta: -1},
+		{TableID: 90, Table: "IngressRule", Count: 1, Delta: -1},
+	}, counts)
+
+	counts, err = getTableFlowCounts(q, []binding.TableIDType{0, 90}, map[uint8]int{})
+	assert.NoError(t, err)
+	assert.Equal(t, []TableFlowCount{
+		{TableID: 0, Table: "Classification", Count: 0, Delta: 0},
+		{TableID: 90, Table: "IngressRule", Count: 1, Delta: 1},
+	}, counts)
+}
@@ -0,0 +1,31 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// Package types defines the schema of the /ovsflows API of the Antrea Agent, which dumps the OVS flows and groups
+// installed by the Agent. It has no dependency on the Agent implementation, so that it can be imported by external
+// consumers of the API.
+package types
+
+// Flow is an OVS flow or group, in the format of "ovs-ofctl dump-flows" and "ovs-ofctl dump-groups".
+type Flow struct {
+	Flow string `json:"flow,omitempty"`
+}
+
+// TableFlowCount is streamed when the API is queried in watch mode, i.e. with the "watch=true" query parameter, for
+// each OVS flow table: its number of flows and the change since the previous update.
+type TableFlowCount struct {
+	TableID uint8  `json:"tableID"`
+	Table   string `json:"table"`
+	Count   int    `json:"count"`
+	Delta   int    `json:"delta"`
+}
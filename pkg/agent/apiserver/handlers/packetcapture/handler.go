@@ -0,0 +1,62 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package packetcapture
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"k8s.io/klog/v2"
+
+	"antrea.io/antrea/pkg/querier"
+)
+
+// HandleFunc returns the function which can handle API requests to "/packetcaptures". The pcap
+// file of the PacketCapture specified by the "name" query parameter is returned, if the capture
+// has been completed on the Node.
+func HandleFunc(pcq querier.AgentPacketCaptureQuerier) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := r.URL.Query().Get("name")
+		if name == "" {
+			http.Error(w, "name must be provided", http.StatusBadRequest)
+			return
+		}
+		if pcq == nil {
+			http.Error(w, "PacketCapture is not enabled", http.StatusServiceUnavailable)
+			return
+		}
+		path, ok := pcq.GetPcapFile(name)
+		if !ok {
+			http.Error(w, fmt.Sprintf("PacketCapture %s has not been completed on this Node", name), http.StatusNotFound)
+			return
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			klog.Errorf("Failed to open pcap file of PacketCapture %s: %v", name, err)
+			http.Error(w, "failed to open pcap file", http.StatusInternalServerError)
+			return
+		}
+		defer f.Close()
+		w.Header().Set("Content-Type", "application/vnd.tcpdump.pcap")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s.pcap", name))
+		w.WriteHeader(http.StatusOK)
+		if _, err := io.Copy(w, f); err != nil {
+			// The status has been sent already, the client sees a truncated file.
+			klog.Errorf("Failed to send pcap file of PacketCapture %s: %v", name, err)
+		}
+	}
+}
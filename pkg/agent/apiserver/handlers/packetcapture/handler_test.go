@@ -0,0 +1,92 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package packetcapture
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"antrea.io/antrea/pkg/querier"
+)
+
+type fakeQuerier struct {
+	files map[string]string
+}
+
+func (q *fakeQuerier) GetPcapFile(name string) (string, bool) {
+	path, ok := q.files[name]
+	return path, ok
+}
+
+func TestHandleFunc(t *testing.T) {
+	dir, err := ioutil.TempDir("", "packetcapture")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "pc1.pcap")
+	require.NoError(t, ioutil.WriteFile(path, []byte("pcap content"), 0600))
+	pcq := &fakeQuerier{files: map[string]string{"pc1": path}}
+
+	tests := []struct {
+		name         string
+		querier      querier.AgentPacketCaptureQuerier
+		query        string
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:         "completed capture",
+			querier:      pcq,
+			query:        "?name=pc1",
+			expectedCode: http.StatusOK,
+			expectedBody: "pcap content",
+		},
+		{
+			name:         "unknown capture",
+			querier:      pcq,
+			query:        "?name=pc2",
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "no name",
+			querier:      pcq,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "feature disabled",
+			query:        "?name=pc1",
+			expectedCode: http.StatusServiceUnavailable,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, "/packetcaptures"+tt.query, nil)
+			require.NoError(t, err)
+			recorder := httptest.NewRecorder()
+			HandleFunc(tt.querier)(recorder, req)
+			assert.Equal(t, tt.expectedCode, recorder.Code)
+			if tt.expectedCode == http.StatusOK {
+				assert.Equal(t, tt.expectedBody, recorder.Body.String())
+				assert.Equal(t, "application/vnd.tcpdump.pcap", recorder.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pipeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"k8s.io/klog/v2"
+
+	"antrea.io/antrea/pkg/agent/openflow"
+	"antrea.io/antrea/pkg/agent/querier"
+	"antrea.io/antrea/pkg/antctl/transform/common"
+	binding "antrea.io/antrea/pkg/ovs/openflow"
+)
+
+// SchemaVersion is the version of the Response schema. It must be bumped when
+// a field is removed from Response or Table, or when the meaning of a field
+// changes, so that external tools can detect incompatible changes.
+const SchemaVersion = "v1"
+
+// Response is the response struct of pipeline command.
+type Response struct {
+	SchemaVersion string  `json:"schemaVersion"`
+	Tables        []Table `json:"tables"`
+}
+
+// Table describes a table of the OVS pipeline.
+type Table struct {
+	ID      uint8  `json:"id"`
+	Name    string `json:"name"`
+	Purpose string `json:"purpose,omitempty"`
+	Feature string `json:"feature,omitempty"`
+	// Next is the ID of the table to which packets are resubmitted when they
+	// go to the next table. It is nil for the last table of a chain.
+	Next       *uint8 `json:"next,omitempty"`
+	MissAction string `json:"missAction"`
+	FlowCount  uint   `json:"flowCount"`
+}
+
+func newTable(table binding.Table, flowCounts map[uint]uint) Table {
+	t := Table{
+		ID:         uint8(table.GetID()),
+		MissAction: table.GetMissAction().String(),
+		FlowCount:  flowCounts[uint(table.GetID())],
+	}
+	if flowTable, ok := openflow.GetFlowTable(table.GetID()); ok {
+		t.Name = flowTable.Name
+		t.Purpose = flowTable.Purpose
+		t.Feature = flowTable.Feature
+	}
+	if next := table.GetNext(); next != binding.LastTableID {
+		nextID := uint8(next)
+		t.Next = &nextID
+	}
+	return t
+}
+
+// HandleFunc returns the function which can handle queries issued by the
+// pipeline command. The handler function populates the tables of the OVS
+// pipeline, ordered by table ID, to the response.
+func HandleFunc(aq querier.AgentQuerier) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ofClient := aq.GetOpenflowClient()
+		flowCounts := make(map[uint]uint)
+		for _, status := range ofClient.GetFlowTableStatus() {
+			flowCounts[status.ID] = status.FlowCount
+		}
+		resp := Response{SchemaVersion: SchemaVersion, Tables: []Table{}}
+		for _, table := range ofClient.GetPipeline() {
+			resp.Tables = append(resp.Tables, newTable(table, flowCounts))
+		}
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			klog.Errorf("Error when encoding pipeline to json: %v", err)
+		}
+	}
+}
+
+var _ common.TableOutput = new(Table)
+
+func (t Table) GetTableHeader() []string {
+	return []string{"TABLE", "NEXT", "MISS-ACTION", "FEATURE", "FLOWS"}
+}
+
+func (t Table) GetTableRow(maxColumnLength int) []string {
+	next := "-"
+	if t.Next != nil {
+		next = tableString(*t.Next, openflow.GetFlowTableName(binding.TableIDType(*t.Next)))
+	}
+	return []string{tableString(t.ID, t.Name), next, t.MissAction, t.Feature, strconv.Itoa(int(t.FlowCount))}
+}
+
+func (t Table) SortRows() bool {
+	return false
+}
+
+func tableString(id uint8, name string) string {
+	return fmt.Sprintf("%s(%d)", name, id)
+}
@@ -0,0 +1,104 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pipeline
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"antrea.io/antrea/pkg/agent/openflow"
+	oftest "antrea.io/antrea/pkg/agent/openflow/testing"
+	aqtest "antrea.io/antrea/pkg/agent/querier/testing"
+	binding "antrea.io/antrea/pkg/ovs/openflow"
+	ovsoftest "antrea.io/antrea/pkg/ovs/openflow/testing"
+)
+
+func newMockTable(ctrl *gomock.Controller, id, next binding.TableIDType, missAction binding.MissActionType) binding.Table {
+	table := ovsoftest.NewMockTable(ctrl)
+	table.EXPECT().GetID().Return(id).AnyTimes()
+	table.EXPECT().GetNext().Return(next).AnyTimes()
+	table.EXPECT().GetMissAction().Return(missAction).AnyTimes()
+	return table
+}
+
+func TestPipelineQuery(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ofc := oftest.NewMockClient(ctrl)
+	ofc.EXPECT().GetPipeline().Return([]binding.Table{
+		newMockTable(ctrl, openflow.ClassifierTable, 10, binding.TableMissActionDrop),
+		newMockTable(ctrl, openflow.EgressRuleTable, openflow.EgressDefaultTable, binding.TableMissActionNext),
+		newMockTable(ctrl, openflow.L2ForwardingOutTable, binding.LastTableID, binding.TableMissActionDrop),
+	})
+	ofc.EXPECT().GetFlowTableStatus().Return([]binding.TableStatus{
+		{ID: uint(openflow.ClassifierTable), FlowCount: 5},
+		{ID: uint(openflow.L2ForwardingOutTable), FlowCount: 3},
+	})
+	q := aqtest.NewMockAgentQuerier(ctrl)
+	q.EXPECT().GetOpenflowClient().Return(ofc)
+
+	req, err := http.NewRequest(http.MethodGet, "", nil)
+	require.NoError(t, err)
+	recorder := httptest.NewRecorder()
+	HandleFunc(q).ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var resp Response
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &resp))
+	next := func(id binding.TableIDType) *uint8 {
+		n := uint8(id)
+		return &n
+	}
+	expected := Response{
+		SchemaVersion: SchemaVersion,
+		Tables: []Table{
+			{
+				ID:         uint8(openflow.ClassifierTable),
+				Name:       "Classification",
+				Purpose:    "Classify packets by their input port",
+				Feature:    "Core",
+				Next:       next(10),
+				MissAction: "drop",
+				FlowCount:  5,
+			},
+			{
+				ID:         uint8(openflow.EgressRuleTable),
+				Name:       "EgressRule",
+				Purpose:    "Enforce egress rules of K8s NetworkPolicies",
+				Feature:    "NetworkPolicy",
+				Next:       next(openflow.EgressDefaultTable),
+				MissAction: "next",
+			},
+			{
+				ID:         uint8(openflow.L2ForwardingOutTable),
+				Name:       "Output",
+				Purpose:    "Output packets to the selected port",
+				Feature:    "Core",
+				MissAction: "drop",
+				FlowCount:  3,
+			},
+		},
+	}
+	assert.Equal(t, expected, resp)
+	assert.Equal(t, []string{"Classification(0)", "SpoofGuard(10)", "drop", "Core", "5"}, resp.Tables[0].GetTableRow(0))
+	assert.Equal(t, []string{"Output(110)", "-", "drop", "Core", "3"}, resp.Tables[2].GetTableRow(0))
+}
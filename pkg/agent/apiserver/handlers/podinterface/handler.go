@@ -15,27 +15,41 @@
 package podinterface
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"net"
 	"net/http"
+	"reflect"
+	"sort"
 	"strings"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/watch"
+
+	"antrea.io/antrea/pkg/agent/apiserver/handlers"
+	"antrea.io/antrea/pkg/agent/apiserver/handlers/podinterface/types"
 	"antrea.io/antrea/pkg/agent/interfacestore"
 	"antrea.io/antrea/pkg/agent/querier"
 	"antrea.io/antrea/pkg/antctl/transform/common"
+	"antrea.io/antrea/pkg/util/k8s"
 )
 
-// Response describes the response struct of pod-interface command.
-type Response struct {
-	PodName       string   `json:"name,omitempty" antctl:"name,Name of the Pod"`
-	PodNamespace  string   `json:"podNamespace,omitempty"`
-	InterfaceName string   `json:"interfaceName,omitempty"`
-	IPs           []string `json:"ips,omitempty"`
-	MAC           string   `json:"mac,omitempty"`
-	PortUUID      string   `json:"portUUID,omitempty"`
-	OFPort        int32    `json:"ofPort,omitempty"`
-	ContainerID   string   `json:"containerID,omitempty"`
-}
+const (
+	// versionParam is the query parameter used to require a version of the API schema.
+	versionParam = "version"
+	// labelSelectorParam is the query parameter used to filter the Pod interfaces by Pod labels.
+	labelSelectorParam = "labelSelector"
+	// quarantinedParam is the query parameter used to get the quarantined OVS ports instead of the Pod interfaces.
+	quarantinedParam = "quarantined"
+)
+
+// Response describes the response struct of pod-interface command. Its schema is defined by
+// types.PodInterface.
+type Response types.PodInterface
 
 func generateResponse(i *interfacestore.InterfaceConfig) Response {
 	return Response{
@@ -58,21 +72,136 @@ func getPodIPs(ips []net.IP) []string {
 	return ipStrs
 }
 
+// getPodInterfaces returns the Pod interfaces matching the provided Pod name, Namespace and label selector, which
+// are ignored when empty. The labels of the Pods are retrieved from the K8s apiserver, only when a label selector
+// is provided.
+func getPodInterfaces(ctx context.Context, aq querier.AgentQuerier, name, ns string, selector labels.Selector) ([]Response, error) {
+	var selectedPods sets.String
+	if selector != nil {
+		podList, err := aq.GetK8sClient().CoreV1().Pods(ns).List(ctx, metav1.ListOptions{
+			LabelSelector: selector.String(),
+			FieldSelector: fields.OneTermEqualSelector("spec.nodeName", aq.GetNodeConfig().Name).String(),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error when listing Pods matching label selector %q: %w", selector.String(), err)
+		}
+		selectedPods = sets.NewString()
+		for i := range podList.Items {
+			selectedPods.Insert(k8s.NamespacedName(podList.Items[i].Namespace, podList.Items[i].Name))
+		}
+	}
+
+	var pods []Response
+	for _, v := range aq.GetInterfaceStore().GetInterfacesByType(interfacestore.ContainerInterface) {
+		podName := (*v.ContainerInterfaceConfig).PodName
+		podNS := (*v.ContainerInterfaceConfig).PodNamespace
+		if (len(name) == 0 || name == podName) && (len(ns) == 0 || ns == podNS) &&
+			(selectedPods == nil || selectedPods.Has(k8s.NamespacedName(podNS, podName))) {
+			pods = append(pods, generateResponse(v))
+		}
+	}
+	return pods, nil
+}
+
+// getQuarantinedPorts returns the OVS ports with malformed external IDs which were not adopted as Pod interfaces.
+func getQuarantinedPorts(aq querier.AgentQuerier) []types.QuarantinedPort {
+	ports := []types.QuarantinedPort{}
+	for _, p := range aq.GetInterfaceStore().GetQuarantinedPorts() {
+		ports = append(ports, types.QuarantinedPort{
+			InterfaceName: p.Name,
+			PortUUID:      p.PortUUID,
+			OFPort:        p.OFPort,
+			ExternalIDs:   p.ExternalIDs,
+			Reason:        p.Reason,
+		})
+	}
+	return ports
+}
+
+// diffPodInterfaces returns the watch events for the changes between the known Pod interfaces and the current
+// ones, and updates the known Pod interfaces.
+func diffPodInterfaces(known map[string]types.PodInterface, current []Response) []interface{} {
+	var events []types.WatchEvent
+	currentKeys := sets.NewString()
+	for _, pod := range current {
+		podInterface := types.PodInterface(pod)
+		key := podInterface.ContainerID + "/" + podInterface.InterfaceName
+		currentKeys.Insert(key)
+		knownPodInterface, exists := known[key]
+		if !exists {
+			events = append(events, types.WatchEvent{Type: watch.Added, Object: podInterface})
+		} else if !reflect.DeepEqual(knownPodInterface, podInterface) {
+			events = append(events, types.WatchEvent{Type: watch.Modified, Object: podInterface})
+		} else {
+			continue
+		}
+		known[key] = podInterface
+	}
+	for key, podInterface := range known {
+		if !currentKeys.Has(key) {
+			events = append(events, types.WatchEvent{Type: watch.Deleted, Object: podInterface})
+			delete(known, key)
+		}
+	}
+	sort.Slice(events, func(i, j int) bool {
+		if events[i].Object.PodNamespace != events[j].Object.PodNamespace {
+			return events[i].Object.PodNamespace < events[j].Object.PodNamespace
+		}
+		if events[i].Object.PodName != events[j].Object.PodName {
+			return events[i].Object.PodName < events[j].Object.PodName
+		}
+		return events[i].Object.InterfaceName < events[j].Object.InterfaceName
+	})
+	objs := make([]interface{}, 0, len(events))
+	for _, event := range events {
+		objs = append(objs, event)
+	}
+	return objs
+}
+
 // HandleFunc returns the function which can handle queries issued by the pod-interface command,
+// as well as by external consumers of the API. See the types package for the schema of the API.
 func HandleFunc(aq querier.AgentQuerier) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		name := r.URL.Query().Get("name")
-		ns := r.URL.Query().Get("namespace")
-
-		var pods []Response
-		for _, v := range aq.GetInterfaceStore().GetInterfacesByType(interfacestore.ContainerInterface) {
-			podName := (*v.ContainerInterfaceConfig).PodName
-			podNS := (*v.ContainerInterfaceConfig).PodNamespace
-			if (len(name) == 0 || name == podName) && (len(ns) == 0 || ns == podNS) {
-				pods = append(pods, generateResponse(v))
+		query := r.URL.Query()
+		if version := query.Get(versionParam); version != "" && version != types.Version {
+			http.Error(w, fmt.Sprintf("unsupported version %q, supported version is %q", version, types.Version), http.StatusBadRequest)
+			return
+		}
+		if query.Get(quarantinedParam) == "true" {
+			if err := json.NewEncoder(w).Encode(getQuarantinedPorts(aq)); err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+			return
+		}
+		name := query.Get("name")
+		ns := query.Get("namespace")
+		var selector labels.Selector
+		if labelSelector := query.Get(labelSelectorParam); labelSelector != "" {
+			var err error
+			if selector, err = labels.Parse(labelSelector); err != nil {
+				http.Error(w, fmt.Sprintf("invalid label selector %q: %v", labelSelector, err), http.StatusBadRequest)
+				return
 			}
 		}
 
+		if handlers.IsWatchRequest(r) {
+			known := map[string]types.PodInterface{}
+			handlers.Stream(w, r, handlers.DefaultWatchInterval, func() ([]interface{}, error) {
+				pods, err := getPodInterfaces(r.Context(), aq, name, ns, selector)
+				if err != nil {
+					return nil, err
+				}
+				return diffPodInterfaces(known, pods), nil
+			})
+			return
+		}
+
+		pods, err := getPodInterfaces(r.Context(), aq, name, ns, selector)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
 		if len(name) > 0 && len(pods) == 0 {
 			w.WriteHeader(http.StatusNotFound)
 			return
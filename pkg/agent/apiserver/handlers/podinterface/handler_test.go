@@ -23,7 +23,13 @@ import (
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/fake"
 
+	"antrea.io/antrea/pkg/agent/apiserver/handlers/podinterface/types"
+	"antrea.io/antrea/pkg/agent/config"
 	"antrea.io/antrea/pkg/agent/interfacestore"
 	interfacestoretest "antrea.io/antrea/pkg/agent/interfacestore/testing"
 	queriertest "antrea.io/antrea/pkg/agent/querier/testing"
@@ -242,3 +248,181 @@ func TestPodInterfaceListQuery(t *testing.T) {
 		}
 	}
 }
+
+func TestPodInterfaceVersionQuery(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testcases := map[string]struct {
+		query          string
+		expectedStatus int
+	}{
+		"Supported version": {
+			query:          "?version=v1",
+			expectedStatus: http.StatusOK,
+		},
+		"Unsupported version": {
+			query:          "?version=v2",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for k, tc := range testcases {
+		i := interfacestoretest.NewMockInterfaceStore(ctrl)
+		i.EXPECT().GetInterfacesByType(interfacestore.ContainerInterface).Return(testInterfaceConfigs).AnyTimes()
+
+		q := queriertest.NewMockAgentQuerier(ctrl)
+		q.EXPECT().GetInterfaceStore().Return(i).AnyTimes()
+		handler := HandleFunc(q)
+
+		req, err := http.NewRequest(http.MethodGet, tc.query, nil)
+		assert.Nil(t, err)
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, req)
+		assert.Equal(t, tc.expectedStatus, recorder.Code, k)
+	}
+}
+
+func TestQuarantinedPortQuery(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testcases := map[string]struct {
+		quarantinedPorts []*interfacestore.QuarantinedPort
+		expectedResponse []types.QuarantinedPort
+	}{
+		"No quarantined port": {
+			expectedResponse: []types.QuarantinedPort{},
+		},
+		"Quarantined port": {
+			quarantinedPorts: []*interfacestore.QuarantinedPort{{
+				Name:        "pod0-abc",
+				PortUUID:    "portUUID0",
+				OFPort:      10,
+				ExternalIDs: map[string]string{"pod-name": "pod0"},
+				Reason:      "missing external IDs container-id",
+			}},
+			expectedResponse: []types.QuarantinedPort{{
+				InterfaceName: "pod0-abc",
+				PortUUID:      "portUUID0",
+				OFPort:        10,
+				ExternalIDs:   map[string]string{"pod-name": "pod0"},
+				Reason:        "missing external IDs container-id",
+			}},
+		},
+	}
+
+	for k, tc := range testcases {
+		i := interfacestoretest.NewMockInterfaceStore(ctrl)
+		i.EXPECT().GetQuarantinedPorts().Return(tc.quarantinedPorts)
+
+		q := queriertest.NewMockAgentQuerier(ctrl)
+		q.EXPECT().GetInterfaceStore().Return(i)
+		handler := HandleFunc(q)
+
+		req, err := http.NewRequest(http.MethodGet, "?quarantined=true", nil)
+		assert.Nil(t, err)
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, req)
+		assert.Equal(t, http.StatusOK, recorder.Code, k)
+		var received []types.QuarantinedPort
+		assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &received), k)
+		assert.Equal(t, tc.expectedResponse, received, k)
+	}
+}
+
+func TestPodInterfaceLabelSelectorQuery(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	k8sClient := fake.NewSimpleClientset(
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: podNames[0], Namespace: "namespaceA", Labels: map[string]string{"app": "foo"}},
+			Spec:       corev1.PodSpec{NodeName: "node1"},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: podNames[1], Namespace: "namespaceA", Labels: map[string]string{"app": "bar"}},
+			Spec:       corev1.PodSpec{NodeName: "node1"},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: podNames[0], Namespace: "namespaceB", Labels: map[string]string{"app": "foo"}},
+			Spec:       corev1.PodSpec{NodeName: "node1"},
+		},
+	)
+
+	testcases := map[string]struct {
+		query           string
+		expectedStatus  int
+		expectedContent []Response
+	}{
+		"Label selector in all namespaces": {
+			query:           "?labelSelector=app%3Dfoo",
+			expectedStatus:  http.StatusOK,
+			expectedContent: []Response{responses[0], responses[2]},
+		},
+		"Label selector in a namespace": {
+			query:           "?labelSelector=app%3Dfoo&namespace=namespaceB",
+			expectedStatus:  http.StatusOK,
+			expectedContent: []Response{responses[2]},
+		},
+		"Label selector with set-based requirement": {
+			query:           "?labelSelector=app+in+%28bar%29",
+			expectedStatus:  http.StatusOK,
+			expectedContent: []Response{responses[1]},
+		},
+		"Invalid label selector": {
+			query:          "?labelSelector=app+in+foo",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for k, tc := range testcases {
+		i := interfacestoretest.NewMockInterfaceStore(ctrl)
+		i.EXPECT().GetInterfacesByType(interfacestore.ContainerInterface).Return(testInterfaceConfigs).AnyTimes()
+
+		q := queriertest.NewMockAgentQuerier(ctrl)
+		q.EXPECT().GetInterfaceStore().Return(i).AnyTimes()
+		q.EXPECT().GetK8sClient().Return(k8sClient).AnyTimes()
+		q.EXPECT().GetNodeConfig().Return(&config.NodeConfig{Name: "node1"}).AnyTimes()
+		handler := HandleFunc(q)
+
+		req, err := http.NewRequest(http.MethodGet, tc.query, nil)
+		assert.Nil(t, err)
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, req)
+		assert.Equal(t, tc.expectedStatus, recorder.Code, k)
+
+		if tc.expectedStatus == http.StatusOK {
+			var received []Response
+			err = json.Unmarshal(recorder.Body.Bytes(), &received)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expectedContent, received, k)
+		}
+	}
+}
+
+func TestDiffPodInterfaces(t *testing.T) {
+	known := map[string]types.PodInterface{}
+
+	events := diffPodInterfaces(known, []Response{responses[1], responses[0]})
+	assert.Equal(t, []interface{}{
+		types.WatchEvent{Type: watch.Added, Object: types.PodInterface(responses[0])},
+		types.WatchEvent{Type: watch.Added, Object: types.PodInterface(responses[1])},
+	}, events)
+
+	events = diffPodInterfaces(known, []Response{responses[0], responses[1]})
+	assert.Empty(t, events)
+
+	updatedResponse := responses[1]
+	updatedResponse.OFPort = 10
+	events = diffPodInterfaces(known, []Response{updatedResponse, responses[2]})
+	assert.Equal(t, []interface{}{
+		types.WatchEvent{Type: watch.Deleted, Object: types.PodInterface(responses[0])},
+		types.WatchEvent{Type: watch.Modified, Object: types.PodInterface(updatedResponse)},
+		types.WatchEvent{Type: watch.Added, Object: types.PodInterface(responses[2])},
+	}, events)
+	assert.Len(t, known, 2)
+}
@@ -0,0 +1,67 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package types defines the schema of the /podinterfaces API of the Antrea Agent, which maps the Pods running on
+// the Node to their network interfaces. It has no dependency on the Agent implementation, so that it can be
+// imported by external consumers of the API, e.g. monitoring agents running on the Nodes.
+//
+// The schema is versioned and Version is its current version. Within a version, the following guarantees are made:
+//   - fields are never removed or renamed, and their meaning never changes;
+//   - new optional fields may be added, consumers must ignore the fields they don't know.
+// Any other change requires a new version. A client can require a version with the "version" query parameter, in
+// which case the request fails with status 400 (Bad Request) if the Agent doesn't serve this version.
+package types
+
+import (
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// Version is the current version of the /podinterfaces API schema.
+const Version = "v1"
+
+// PodInterface describes a network interface created by the Antrea Agent for a Pod.
+type PodInterface struct {
+	PodName      string `json:"name,omitempty" antctl:"name,Name of the Pod"`
+	PodNamespace string `json:"podNamespace,omitempty"`
+	// InterfaceName is the name of the host-side veth interface, which is also the name of the OVS port.
+	InterfaceName string   `json:"interfaceName,omitempty"`
+	IPs           []string `json:"ips,omitempty"`
+	MAC           string   `json:"mac,omitempty"`
+	PortUUID      string   `json:"portUUID,omitempty"`
+	OFPort        int32    `json:"ofPort,omitempty"`
+	ContainerID   string   `json:"containerID,omitempty"`
+}
+
+// QuarantinedPort describes an OVS port found by the Antrea Agent at startup, whose external IDs identify it as the
+// port of a Pod interface but are malformed, e.g. truncated. The port is not adopted as a Pod interface, and should be
+// inspected and cleaned up manually. The quarantined ports are returned instead of the Pod interfaces when the API is
+// queried with the "quarantined=true" query parameter.
+type QuarantinedPort struct {
+	InterfaceName string            `json:"interfaceName,omitempty"`
+	PortUUID      string            `json:"portUUID,omitempty"`
+	OFPort        int32             `json:"ofPort,omitempty"`
+	ExternalIDs   map[string]string `json:"externalIDs,omitempty"`
+	// Reason describes why the external IDs are malformed.
+	Reason string `json:"reason,omitempty"`
+}
+
+// WatchEvent is streamed for each change of the Pod interfaces when the API is queried in watch mode, i.e. with
+// the "watch=true" query parameter. The watch starts with an ADDED event for each existing Pod interface, followed
+// by ADDED, MODIFIED and DELETED events. A Pod interface is identified by its ContainerID and InterfaceName.
+type WatchEvent struct {
+	Type watch.EventType `json:"type"`
+	// Object is the Pod interface after the change, or the last known state of the Pod interface for DELETED
+	// events.
+	Object PodInterface `json:"object"`
+}
@@ -0,0 +1,67 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package realizationerrors
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"antrea.io/antrea/pkg/querier"
+)
+
+// Response is the response struct of the "/networkpolicy/errors" API.
+type Response struct {
+	// Policy is the reference of the NetworkPolicy, e.g. AntreaNetworkPolicy:ns1/policy1.
+	Policy      string `json:"policy"`
+	RuleID      string `json:"ruleID"`
+	RuleName    string `json:"ruleName,omitempty"`
+	ErrorClass  string `json:"errorClass"`
+	Error       string `json:"error"`
+	RetryCount  int    `json:"retryCount"`
+	LastAttempt string `json:"lastAttempt"`
+}
+
+func newResponse(realizationErr querier.NetworkPolicyRealizationError) Response {
+	resp := Response{
+		RuleID:      realizationErr.RuleID,
+		RuleName:    realizationErr.RuleName,
+		ErrorClass:  realizationErr.ErrorClass,
+		Error:       realizationErr.Error,
+		RetryCount:  realizationErr.RetryCount,
+		LastAttempt: realizationErr.LastAttempt.UTC().Format(time.RFC3339),
+	}
+	if realizationErr.PolicyRef != nil {
+		resp.Policy = realizationErr.PolicyRef.ToString()
+	}
+	return resp
+}
+
+// HandleFunc returns the function which can handle API requests to "/networkpolicy/errors". The
+// NetworkPolicy rules which the agent failed to realize, and keeps retrying, are returned.
+func HandleFunc(neq querier.AgentNetworkPolicyRealizationErrorQuerier) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		resps := []Response{}
+		for _, realizationErr := range neq.GetNetworkPolicyRealizationErrors() {
+			resps = append(resps, newResponse(realizationErr))
+		}
+		if err := json.NewEncoder(w).Encode(resps); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			klog.Errorf("Error when encoding NetworkPolicy realization errors to json: %v", err)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package realizationerrors
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"antrea.io/antrea/pkg/apis/controlplane/v1beta2"
+	"antrea.io/antrea/pkg/querier"
+)
+
+type fakeQuerier struct {
+	errors []querier.NetworkPolicyRealizationError
+}
+
+func (q *fakeQuerier) GetNetworkPolicyRealizationErrors() []querier.NetworkPolicyRealizationError {
+	return q.errors
+}
+
+func TestHandleFunc(t *testing.T) {
+	lastAttempt := time.Date(2021, 6, 1, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name          string
+		errors        []querier.NetworkPolicyRealizationError
+		expectedResps []Response
+	}{
+		{
+			name:          "no error",
+			expectedResps: []Response{},
+		},
+		{
+			name: "failed rules",
+			errors: []querier.NetworkPolicyRealizationError{
+				{
+					PolicyRef:   &v1beta2.NetworkPolicyReference{Type: v1beta2.AntreaClusterNetworkPolicy, Name: "cnp1"},
+					RuleID:      "rule1",
+					RuleName:    "allow-dns",
+					ErrorClass:  "FlowInstall",
+					Error:       "error installing ofRule 1: broken pipe",
+					RetryCount:  2,
+					LastAttempt: lastAttempt,
+				},
+				{
+					PolicyRef:   &v1beta2.NetworkPolicyReference{Type: v1beta2.AntreaNetworkPolicy, Namespace: "ns1", Name: "anp1"},
+					RuleID:      "rule2",
+					RuleName:    "drop-all",
+					ErrorClass:  "IDAllocation",
+					Error:       "error allocating Openflow ID",
+					LastAttempt: lastAttempt,
+				},
+			},
+			expectedResps: []Response{
+				{
+					Policy:      "AntreaClusterNetworkPolicy:cnp1",
+					RuleID:      "rule1",
+					RuleName:    "allow-dns",
+					ErrorClass:  "FlowInstall",
+					Error:       "error installing ofRule 1: broken pipe",
+					RetryCount:  2,
+					LastAttempt: "2021-06-01T10:00:00Z",
+				},
+				{
+					Policy:      "AntreaNetworkPolicy:ns1/anp1",
+					RuleID:      "rule2",
+					RuleName:    "drop-all",
+					ErrorClass:  "IDAllocation",
+					Error:       "error allocating Openflow ID",
+					LastAttempt: "2021-06-01T10:00:00Z",
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, "/networkpolicy/errors", nil)
+			require.NoError(t, err)
+			recorder := httptest.NewRecorder()
+			HandleFunc(&fakeQuerier{errors: tt.errors})(recorder, req)
+			assert.Equal(t, http.StatusOK, recorder.Code)
+			var received []Response
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &received))
+			assert.Equal(t, tt.expectedResps, received)
+		})
+	}
+}
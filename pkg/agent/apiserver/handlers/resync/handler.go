@@ -0,0 +1,48 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resync
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"k8s.io/klog/v2"
+
+	"antrea.io/antrea/pkg/querier"
+)
+
+// HandleFunc returns the function which handles the POST requests to "/resync". The handler
+// function resyncs the NetworkPolicies, AddressGroups and AppliedToGroups of the agent with the
+// Antrea Controller, and populates the corrections made to the response. Concurrent requests share
+// the same resync.
+func HandleFunc(npr querier.AgentNetworkPolicyResyncer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "only POST is supported", http.StatusMethodNotAllowed)
+			return
+		}
+		summary, err := npr.ResyncNetworkPolicies(r.Context())
+		if err != nil {
+			klog.Errorf("Failed to resync NetworkPolicies: %v", err)
+			http.Error(w, "failed to resync NetworkPolicies: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if err := json.NewEncoder(w).Encode(summary); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			klog.Errorf("Error when encoding resync summary to json: %v", err)
+		}
+	}
+}
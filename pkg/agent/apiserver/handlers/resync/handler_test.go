@@ -0,0 +1,89 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resync
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"antrea.io/antrea/pkg/querier"
+)
+
+type fakeResyncer struct {
+	summary *querier.NetworkPolicyResyncSummary
+	err     error
+	calls   int
+}
+
+func (r *fakeResyncer) ResyncNetworkPolicies(_ context.Context) (*querier.NetworkPolicyResyncSummary, error) {
+	r.calls++
+	return r.summary, r.err
+}
+
+func TestHandleFunc(t *testing.T) {
+	summary := &querier.NetworkPolicyResyncSummary{PoliciesAdded: 1, AddressGroupsUpdated: 2, GroupMembersAdded: 3}
+	tests := []struct {
+		name            string
+		method          string
+		resyncer        *fakeResyncer
+		expectedCode    int
+		expectedCalls   int
+		expectedSummary *querier.NetworkPolicyResyncSummary
+	}{
+		{
+			name:            "resync",
+			method:          http.MethodPost,
+			resyncer:        &fakeResyncer{summary: summary},
+			expectedCode:    http.StatusOK,
+			expectedCalls:   1,
+			expectedSummary: summary,
+		},
+		{
+			name:          "resync error",
+			method:        http.MethodPost,
+			resyncer:      &fakeResyncer{err: fmt.Errorf("timeout")},
+			expectedCode:  http.StatusInternalServerError,
+			expectedCalls: 1,
+		},
+		{
+			name:         "GET not allowed",
+			method:       http.MethodGet,
+			resyncer:     &fakeResyncer{summary: summary},
+			expectedCode: http.StatusMethodNotAllowed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, "/resync", nil)
+			require.NoError(t, err)
+			recorder := httptest.NewRecorder()
+			HandleFunc(tt.resyncer).ServeHTTP(recorder, req)
+			assert.Equal(t, tt.expectedCode, recorder.Code)
+			assert.Equal(t, tt.expectedCalls, tt.resyncer.calls)
+			if tt.expectedSummary != nil {
+				var received querier.NetworkPolicyResyncSummary
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &received))
+				assert.Equal(t, *tt.expectedSummary, received)
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package serviceendpoints
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"k8s.io/klog/v2"
+
+	"antrea.io/antrea/pkg/agent/openflow"
+	"antrea.io/antrea/pkg/agent/proxy/types"
+	agentquerier "antrea.io/antrea/pkg/agent/querier"
+	"antrea.io/antrea/pkg/antctl/transform/common"
+	"antrea.io/antrea/pkg/features"
+)
+
+// Response is the response struct of serviceendpoints command. There is one Response for each
+// port of the Service.
+type Response struct {
+	Service   string     `json:"service"`
+	PortName  string     `json:"portName,omitempty"`
+	Protocol  string     `json:"protocol"`
+	ClusterIP string     `json:"clusterIP"`
+	Port      int        `json:"port"`
+	GroupID   uint32     `json:"groupID"`
+	Endpoints []Endpoint `json:"endpoints"`
+}
+
+// Endpoint describes an Endpoint of a Service port, and the bucket which selects it in the OVS
+// group of the Service port. BucketIndex and Weight are not set if no bucket selects the Endpoint.
+type Endpoint struct {
+	IP                string `json:"ip"`
+	Port              int    `json:"port"`
+	BucketIndex       *int   `json:"bucketIndex,omitempty"`
+	Weight            *int   `json:"weight,omitempty"`
+	Local             bool   `json:"local"`
+	DNATFlowInstalled bool   `json:"dnatFlowInstalled"`
+}
+
+func bucketKey(ip net.IP, port int) string {
+	return net.JoinHostPort(ip.String(), strconv.Itoa(port))
+}
+
+func newResponse(aq agentquerier.AgentQuerier, svcPort types.ServicePortEndpoints) (Response, error) {
+	groupStr, err := aq.GetOVSCtlClient().DumpGroup(uint32(svcPort.GroupID))
+	if err != nil {
+		return Response{}, fmt.Errorf("error when dumping group %d: %w", svcPort.GroupID, err)
+	}
+	buckets := map[string]openflow.ServiceGroupBucket{}
+	for _, bucket := range openflow.ParseServiceGroupBuckets(groupStr) {
+		buckets[bucketKey(bucket.EndpointIP, int(bucket.EndpointPort))] = bucket
+	}
+
+	resp := Response{
+		Service:   svcPort.ServicePortName.NamespacedName.String(),
+		PortName:  svcPort.ServicePortName.Port,
+		Protocol:  string(svcPort.Protocol),
+		ClusterIP: svcPort.ClusterIP.String(),
+		Port:      svcPort.Port,
+		GroupID:   uint32(svcPort.GroupID),
+		Endpoints: make([]Endpoint, 0, len(svcPort.Endpoints)),
+	}
+	for _, ep := range svcPort.Endpoints {
+		port, _ := ep.Port()
+		endpoint := Endpoint{
+			IP:    ep.IP(),
+			Port:  port,
+			Local: ep.GetIsLocal(),
+		}
+		if bucket, ok := buckets[bucketKey(net.ParseIP(ep.IP()), port)]; ok {
+			endpoint.BucketIndex = &bucket.Index
+			endpoint.Weight = &bucket.Weight
+		}
+		if ep.DNATFlowKey != "" {
+			flowStr, err := aq.GetOVSCtlClient().DumpMatchedFlow(ep.DNATFlowKey)
+			if err != nil {
+				return Response{}, fmt.Errorf("error when dumping flow %s: %w", ep.DNATFlowKey, err)
+			}
+			endpoint.DNATFlowInstalled = flowStr != ""
+		}
+		resp.Endpoints = append(resp.Endpoints, endpoint)
+	}
+	return resp, nil
+}
+
+// HandleFunc returns the function which can handle API requests to "/proxy/endpoints". The
+// Service must be provided with the "service" parameter, in the <namespace>/<name> format.
+// It returns 404 if the Service is not known by AntreaProxy.
+func HandleFunc(aq agentquerier.AgentQuerier) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		service := r.URL.Query().Get("service")
+		parts := strings.Split(service, "/")
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			http.Error(w, "service must be provided in the <namespace>/<name> format", http.StatusBadRequest)
+			return
+		}
+		if !features.DefaultFeatureGate.Enabled(features.AntreaProxy) {
+			http.Error(w, "AntreaProxy is not enabled", http.StatusServiceUnavailable)
+			return
+		}
+
+		svcPorts, found := aq.GetProxier().GetServiceEndpoints(parts[1], parts[0])
+		if !found {
+			http.Error(w, fmt.Sprintf("Service %s not found", service), http.StatusNotFound)
+			return
+		}
+		resps := make([]Response, 0, len(svcPorts))
+		for _, svcPort := range svcPorts {
+			resp, err := newResponse(aq, svcPort)
+			if err != nil {
+				klog.Errorf("Failed to get Endpoints of Service %s: %v", service, err)
+				http.Error(w, "OVS group dumping failed", http.StatusInternalServerError)
+				return
+			}
+			resps = append(resps, resp)
+		}
+
+		if err := json.NewEncoder(w).Encode(resps); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			klog.Errorf("Error when encoding Service Endpoints to json: %v", err)
+		}
+	}
+}
+
+var _ common.TableOutput = new(Response)
+
+func (r Response) GetTableHeader() []string {
+	return []string{"SERVICE", "PORT", "CLUSTER-IP", "GROUP", "ENDPOINTS"}
+}
+
+// GetTableRow renders each Endpoint as <ip:port>(<bucket>/<weight>[,local][,no-dnat]), where
+// <bucket> and <weight> are "-" if no bucket of the group selects the Endpoint.
+func (r Response) GetTableRow(maxColumnLength int) []string {
+	port := fmt.Sprintf("%d/%s", r.Port, r.Protocol)
+	if r.PortName != "" {
+		port = fmt.Sprintf("%s:%s", r.PortName, port)
+	}
+	endpoints := make([]string, 0, len(r.Endpoints))
+	for _, ep := range r.Endpoints {
+		bucket, weight := "-", "-"
+		if ep.BucketIndex != nil {
+			bucket = strconv.Itoa(*ep.BucketIndex)
+		}
+		if ep.Weight != nil {
+			weight = strconv.Itoa(*ep.Weight)
+		}
+		attrs := []string{fmt.Sprintf("%s/%s", bucket, weight)}
+		if ep.Local {
+			attrs = append(attrs, "local")
+		}
+		if !ep.DNATFlowInstalled {
+			attrs = append(attrs, "no-dnat")
+		}
+		endpoints = append(endpoints, fmt.Sprintf("%s(%s)", net.JoinHostPort(ep.IP, strconv.Itoa(ep.Port)), strings.Join(attrs, ",")))
+	}
+	return []string{r.Service, port, r.ClusterIP, strconv.Itoa(int(r.GroupID)), common.GenerateTableElementWithSummary(endpoints, maxColumnLength)}
+}
+
+func (r Response) SortRows() bool {
+	return true
+}
@@ -0,0 +1,158 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package serviceendpoints
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	k8sapitypes "k8s.io/apimachinery/pkg/types"
+
+	proxytest "antrea.io/antrea/pkg/agent/proxy/testing"
+	"antrea.io/antrea/pkg/agent/proxy/types"
+	aqtest "antrea.io/antrea/pkg/agent/querier/testing"
+	ovsctltest "antrea.io/antrea/pkg/ovs/ovsctl/testing"
+	k8sproxy "antrea.io/antrea/third_party/proxy"
+)
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func TestBadRequests(t *testing.T) {
+	badRequests := map[string]string{
+		"No Service":        "",
+		"No Namespace":      "?service=svc1",
+		"Empty Namespace":   "?service=/svc1",
+		"Empty name":        "?service=ns1/",
+		"Too many segments": "?service=ns1/svc1/http",
+	}
+
+	handler := HandleFunc(nil)
+	for k, r := range badRequests {
+		req, err := http.NewRequest(http.MethodGet, r, nil)
+		require.NoError(t, err)
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, req)
+		assert.Equal(t, http.StatusBadRequest, recorder.Code, k)
+	}
+}
+
+func TestServiceEndpoints(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svcPortName := k8sproxy.ServicePortName{
+		NamespacedName: k8sapitypes.NamespacedName{Namespace: "ns1", Name: "svc1"},
+		Port:           "http",
+		Protocol:       corev1.ProtocolTCP,
+	}
+	svcPortNameV6 := svcPortName
+	svcPortNameV6.Port = "http-v6"
+	svcPorts := []types.ServicePortEndpoints{
+		{
+			ServicePortName: svcPortName,
+			ClusterIP:       net.ParseIP("10.96.0.10"),
+			Port:            80,
+			Protocol:        corev1.ProtocolTCP,
+			GroupID:         3,
+			Endpoints: []types.ServiceEndpoint{
+				{Endpoint: k8sproxy.NewBaseEndpointInfo("10.10.0.2", 8080, true, nil), DNATFlowKey: "dnatFlowKey1"},
+				{Endpoint: k8sproxy.NewBaseEndpointInfo("10.10.1.2", 8080, false, nil), DNATFlowKey: "dnatFlowKey2"},
+				// An Endpoint whose flows are not installed yet.
+				{Endpoint: k8sproxy.NewBaseEndpointInfo("10.10.1.3", 8080, false, nil)},
+			},
+		},
+		{
+			ServicePortName: svcPortNameV6,
+			ClusterIP:       net.ParseIP("fd00:10:96::a"),
+			Port:            80,
+			Protocol:        corev1.ProtocolTCP,
+			GroupID:         4,
+			Endpoints: []types.ServiceEndpoint{
+				{Endpoint: k8sproxy.NewBaseEndpointInfo("fd00:10:10::2", 8080, true, nil), DNATFlowKey: "dnatFlowKey3"},
+			},
+		},
+	}
+	// The buckets are not in the order of the Endpoints.
+	group3 := "group_id=3,type=select," +
+		"bucket=weight:100,actions=load:0xa0a0102->NXM_NX_REG3[],load:0x1f90->NXM_NX_REG4[0..15],resubmit(,42)," +
+		"bucket=weight:100,actions=load:0xa0a0002->NXM_NX_REG3[],load:0x1f90->NXM_NX_REG4[0..15],resubmit(,42)"
+	group4 := "group_id=4,type=select," +
+		"bucket=weight:100,actions=set_field:0xfd000010001000000000000000000002->xxreg3,load:0x1f90->NXM_NX_REG4[0..15],resubmit(,42)"
+
+	p := proxytest.NewMockProxier(ctrl)
+	ovsctl := ovsctltest.NewMockOVSCtlClient(ctrl)
+	q := aqtest.NewMockAgentQuerier(ctrl)
+	q.EXPECT().GetProxier().Return(p).Times(2)
+	q.EXPECT().GetOVSCtlClient().Return(ovsctl).AnyTimes()
+	p.EXPECT().GetServiceEndpoints("svc1", "ns1").Return(svcPorts, true)
+	p.EXPECT().GetServiceEndpoints("svc2", "ns1").Return(nil, false)
+	ovsctl.EXPECT().DumpGroup(uint32(3)).Return(group3, nil)
+	ovsctl.EXPECT().DumpGroup(uint32(4)).Return(group4, nil)
+	ovsctl.EXPECT().DumpMatchedFlow("dnatFlowKey1").Return("flow1", nil)
+	ovsctl.EXPECT().DumpMatchedFlow("dnatFlowKey2").Return("", nil)
+	ovsctl.EXPECT().DumpMatchedFlow("dnatFlowKey3").Return("flow3", nil)
+
+	handler := HandleFunc(q)
+
+	req, err := http.NewRequest(http.MethodGet, "?service=ns1/svc2", nil)
+	require.NoError(t, err)
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+
+	req, err = http.NewRequest(http.MethodGet, "?service=ns1/svc1", nil)
+	require.NoError(t, err)
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var received []Response
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &received))
+	expected := []Response{
+		{
+			Service:   "ns1/svc1",
+			PortName:  "http",
+			Protocol:  "TCP",
+			ClusterIP: "10.96.0.10",
+			Port:      80,
+			GroupID:   3,
+			Endpoints: []Endpoint{
+				{IP: "10.10.0.2", Port: 8080, BucketIndex: intPtr(1), Weight: intPtr(100), Local: true, DNATFlowInstalled: true},
+				{IP: "10.10.1.2", Port: 8080, BucketIndex: intPtr(0), Weight: intPtr(100)},
+				{IP: "10.10.1.3", Port: 8080},
+			},
+		},
+		{
+			Service:   "ns1/svc1",
+			PortName:  "http-v6",
+			Protocol:  "TCP",
+			ClusterIP: "fd00:10:96::a",
+			Port:      80,
+			GroupID:   4,
+			Endpoints: []Endpoint{
+				{IP: "fd00:10:10::2", Port: 8080, BucketIndex: intPtr(0), Weight: intPtr(100), Local: true, DNATFlowInstalled: true},
+			},
+		},
+	}
+	assert.Equal(t, expected, received)
+}
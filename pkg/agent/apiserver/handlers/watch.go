@@ -0,0 +1,77 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+const (
+	// WatchParam is the query parameter used to request a watch from the API handlers which support it.
+	WatchParam = "watch"
+	// DefaultWatchInterval is the interval at which the API handlers poll the agent state in watch mode.
+	DefaultWatchInterval = 2 * time.Second
+)
+
+// IsWatchRequest returns whether the request asks for a watch instead of a single response.
+func IsWatchRequest(r *http.Request) bool {
+	return r.URL.Query().Get(WatchParam) == "true"
+}
+
+// Stream calls poll immediately and then every interval, until the request is cancelled or poll fails. Each object
+// returned by poll is written to the response as a JSON document and flushed right away, so that the client can
+// decode the stream one object at a time as it is received (the response uses chunked transfer encoding).
+func Stream(w http.ResponseWriter, r *http.Request, interval time.Duration, poll func() ([]interface{}, error)) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+	objs, err := poll()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	encoder := json.NewEncoder(w)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		for _, obj := range objs {
+			if err := encoder.Encode(obj); err != nil {
+				klog.V(2).Infof("Stopped streaming %s to client: %v", r.URL.Path, err)
+				return
+			}
+		}
+		flusher.Flush()
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+		}
+		if objs, err = poll(); err != nil {
+			// The response status has already been sent, the client is expected to reconnect.
+			klog.Errorf("Failed to poll %s for watch: %v", r.URL.Path, err)
+			return
+		}
+	}
+}
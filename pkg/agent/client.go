@@ -30,6 +30,8 @@ import (
 
 	cert "antrea.io/antrea/pkg/apiserver/certificate"
 	"antrea.io/antrea/pkg/client/clientset/versioned"
+	"antrea.io/antrea/pkg/util/env"
+	"antrea.io/antrea/pkg/version"
 )
 
 // AntreaClientProvider provides a method to get Antrea client.
@@ -40,6 +42,9 @@ type AntreaClientProvider interface {
 // antreaClientProvider provides an AntreaClientProvider that can dynamically react to ConfigMap changes.
 type antreaClientProvider struct {
 	config config.ClientConnectionConfiguration
+	// enableCompression indicates whether to request the responses of the Antrea Controller to
+	// be compressed with gzip.
+	enableCompression bool
 	// mutex protects client.
 	mutex sync.RWMutex
 	// client is the Antrea client that will be returned. It will be updated when caBundle is updated.
@@ -50,7 +55,7 @@ type antreaClientProvider struct {
 
 var _ dynamiccertificates.Listener = &antreaClientProvider{}
 
-func NewAntreaClientProvider(config config.ClientConnectionConfiguration, kubeClient kubernetes.Interface) *antreaClientProvider {
+func NewAntreaClientProvider(config config.ClientConnectionConfiguration, enableCompression bool, kubeClient kubernetes.Interface) *antreaClientProvider {
 	// The key "ca.crt" may not exist at the beginning, no need to fail as the CA provider will watch the ConfigMap
 	// and notify antreaClientProvider of any update. The consumers of antreaClientProvider are supposed to always
 	// call GetAntreaClient() to get a client and not cache it.
@@ -62,6 +67,7 @@ func NewAntreaClientProvider(config config.ClientConnectionConfiguration, kubeCl
 		kubeClient)
 	antreaClientProvider := &antreaClientProvider{
 		config:            config,
+		enableCompression: enableCompression,
 		caContentProvider: antreaCAProvider,
 	}
 
@@ -122,6 +128,18 @@ func (p *antreaClientProvider) updateAntreaClient() error {
 	kubeConfig.ContentType = "application/vnd.kubernetes.protobuf"
 	kubeConfig.QPS = p.config.QPS
 	kubeConfig.Burst = int(p.config.Burst)
+	// The transparent compression of http.Transport is always disabled: either compression is
+	// disabled, or gzipRoundTripper handles it to report the compressed and raw sizes. Compression
+	// is independent of ContentType, so it applies to protobuf responses as well.
+	kubeConfig.DisableCompression = true
+	if p.enableCompression {
+		kubeConfig.Wrap(newGzipRoundTripper)
+	}
+	nodeName, err := env.GetNodeName()
+	if err != nil {
+		return err
+	}
+	kubeConfig.Wrap(newVersionRoundTripper(version.GetFullVersion(), nodeName))
 	client, err := versioned.NewForConfig(kubeConfig)
 	if err != nil {
 		return err
@@ -0,0 +1,306 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	agentinfotypes "antrea.io/antrea/pkg/agent/apiserver/handlers/agentinfo/types"
+	ovsflowstypes "antrea.io/antrea/pkg/agent/apiserver/handlers/ovsflows/types"
+	podinterfacetypes "antrea.io/antrea/pkg/agent/apiserver/handlers/podinterface/types"
+	cpv1beta "antrea.io/antrea/pkg/apis/controlplane/v1beta2"
+	antrea "antrea.io/antrea/pkg/client/clientset/versioned"
+	"antrea.io/antrea/pkg/client/clientset/versioned/scheme"
+	"antrea.io/antrea/pkg/util/k8s"
+)
+
+// The paths of the Agent API endpoints.
+const (
+	AgentInfoPath       = "/agentinfo"
+	NetworkPoliciesPath = "/networkpolicies"
+	OVSFlowsPath        = "/ovsflows"
+	PodInterfacesPath   = "/podinterfaces"
+)
+
+const defaultRetryInterval = 1 * time.Second
+
+// Options configures the requests issued by a Client.
+type Options struct {
+	// Timeout is the time limit of each attempt of a request, including the connection setup and
+	// the reading of the response body. There is no time limit if it is 0.
+	Timeout time.Duration
+	// MaxRetries is the number of times a request is retried after it failed because the Agent
+	// could not be reached, or because of a server-side error. Requests are not retried if it is 0.
+	MaxRetries int
+	// RetryInterval is the time to wait before retrying a request. Defaults to 1 second.
+	RetryInterval time.Duration
+}
+
+// Client is a typed client of the Agent API. It is safe for concurrent use.
+type Client struct {
+	restClient rest.Interface
+	options    Options
+}
+
+// New returns a Client for the Agent API served at the address of config, e.g.
+// "https://127.0.0.1:10350" in the antrea-agent Pod.
+func New(config *rest.Config, options Options) (*Client, error) {
+	config = rest.CopyConfig(config)
+	if config.NegotiatedSerializer == nil {
+		config.NegotiatedSerializer = scheme.Codecs.WithoutConversion()
+	}
+	restClient, err := rest.UnversionedRESTClientFor(config)
+	if err != nil {
+		return nil, fmt.Errorf("error when creating REST client: %w", err)
+	}
+	if options.RetryInterval == 0 {
+		options.RetryInterval = defaultRetryInterval
+	}
+	return &Client{restClient: restClient, options: options}, nil
+}
+
+// NewForNode returns a Client for the Agent running on the provided Node. config, e.g. loaded from
+// a kubeconfig file, is used to look up the address of the Agent with the K8s API, and its
+// credentials are used to authenticate to the Agent. The Agent serves a self-signed certificate,
+// which is not verified.
+func NewForNode(ctx context.Context, config *rest.Config, nodeName string, options Options) (*Client, error) {
+	k8sClient, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("error when creating K8s clientset: %w", err)
+	}
+	antreaClient, err := antrea.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("error when creating Antrea clientset: %w", err)
+	}
+	agentConfig := rest.CopyConfig(config)
+	agentConfig.Insecure = true
+	agentConfig.CAFile = ""
+	agentConfig.CAData = nil
+	agentConfig, err = ConfigForNode(ctx, k8sClient, antreaClient, agentConfig, nodeName)
+	if err != nil {
+		return nil, err
+	}
+	return New(agentConfig, options)
+}
+
+// NewInClusterForNode returns a Client for the Agent running on the provided Node, which
+// authenticates to the Agent with the ServiceAccount token of the Pod it runs in. See NewForNode.
+func NewInClusterForNode(ctx context.Context, nodeName string, options Options) (*Client, error) {
+	config, err := rest.InClusterConfig()
+	if err != nil {
+		return nil, fmt.Errorf("error when loading in-cluster config: %w", err)
+	}
+	return NewForNode(ctx, config, nodeName, options)
+}
+
+// ConfigForNode returns a copy of cfgTmpl whose Host is the address of the API of the Agent running
+// on the provided Node.
+func ConfigForNode(ctx context.Context, k8sClient kubernetes.Interface, antreaClient antrea.Interface, cfgTmpl *rest.Config, nodeName string) (*rest.Config, error) {
+	node, err := k8sClient.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error when looking up Node %s: %w", nodeName, err)
+	}
+	// TODO: filter by Node name, but that would require API support
+	agentInfoList, err := antreaClient.CrdV1beta1().AntreaAgentInfos().List(ctx, metav1.ListOptions{ResourceVersion: "0"})
+	if err != nil {
+		return nil, err
+	}
+	apiPort := 0
+	for i := range agentInfoList.Items {
+		if agentInfoList.Items[i].NodeRef.Name == nodeName {
+			apiPort = agentInfoList.Items[i].APIPort
+			break
+		}
+	}
+	if apiPort == 0 {
+		return nil, fmt.Errorf("no Antrea Agent found for Node name %s", nodeName)
+	}
+	nodeIP, err := k8s.GetNodeAddr(node)
+	if err != nil {
+		return nil, fmt.Errorf("error when parsing IP of Node %s", nodeName)
+	}
+	cfg := rest.CopyConfig(cfgTmpl)
+	cfg.Host = fmt.Sprintf("https://%s", net.JoinHostPort(nodeIP.String(), fmt.Sprint(apiPort)))
+	return cfg, nil
+}
+
+// Get issues a GET request to the provided path of the Agent API, with the provided query
+// parameters, and returns the raw response body. Failed requests are retried according to the
+// Options of the Client. The returned error is a *errors.StatusError if the Agent responded with an
+// error status.
+func (c *Client) Get(ctx context.Context, path string, params url.Values) ([]byte, error) {
+	return c.do(ctx, http.MethodGet, path, params)
+}
+
+// Post issues a POST request to the provided path of the Agent API, with the provided query
+// parameters, and returns the raw response body. It is used by the endpoints which change the
+// state of the Agent, e.g. "/loglevel", which must be idempotent as failed requests are retried
+// like with Get.
+func (c *Client) Post(ctx context.Context, path string, params url.Values) ([]byte, error) {
+	return c.do(ctx, http.MethodPost, path, params)
+}
+
+func (c *Client) do(ctx context.Context, verb string, path string, params url.Values) ([]byte, error) {
+	u := url.URL{Path: path, RawQuery: params.Encode()}
+	for attempt := 0; ; attempt++ {
+		data, err := c.restClient.Verb(verb).RequestURI(u.RequestURI()).Timeout(c.options.Timeout).DoRaw(ctx)
+		if err == nil || attempt >= c.options.MaxRetries || !isRetriable(ctx, err) {
+			return data, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(c.options.RetryInterval):
+		}
+	}
+}
+
+// isRetriable returns whether a request which failed with err may succeed if retried.
+func isRetriable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if statusErr, ok := err.(*errors.StatusError); ok {
+		code := statusErr.Status().Code
+		return code >= 500 || code == 429
+	}
+	// The Agent could not be reached.
+	return true
+}
+
+func (c *Client) getInto(ctx context.Context, path string, params url.Values, obj interface{}) error {
+	data, err := c.Get(ctx, path, params)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, obj); err != nil {
+		return fmt.Errorf("error when decoding response of %s: %w", path, err)
+	}
+	return nil
+}
+
+// AgentInfo returns the information of the Agent, e.g. its version and its health.
+func (c *Client) AgentInfo(ctx context.Context) (*agentinfotypes.AgentInfo, error) {
+	info := &agentinfotypes.AgentInfo{}
+	if err := c.getInto(ctx, AgentInfoPath, url.Values{}, info); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// NetworkPolicyFilter selects the NetworkPolicies returned by Client.NetworkPolicies. The empty
+// fields are ignored.
+type NetworkPolicyFilter struct {
+	// Name is the name of the control plane NetworkPolicy. No other field can be set with it.
+	Name string
+	// Source is the name of the original policy resource, K8s NetworkPolicy or Antrea-native policy.
+	Source string
+	// Namespace is the Namespace of the original policy resource, or of the Pod.
+	Namespace string
+	// Pod selects the NetworkPolicies applied to a Pod. Namespace must be set with it.
+	Pod string
+	// Type is the type of the original policy resource: K8sNP, ACNP or ANP.
+	Type string
+}
+
+// NetworkPolicies returns the control plane NetworkPolicies received by the Agent, which match the
+// filter.
+func (c *Client) NetworkPolicies(ctx context.Context, filter NetworkPolicyFilter) ([]cpv1beta.NetworkPolicy, error) {
+	params := url.Values{}
+	setIfNotEmpty(params, "name", filter.Name)
+	setIfNotEmpty(params, "source", filter.Source)
+	setIfNotEmpty(params, "namespace", filter.Namespace)
+	setIfNotEmpty(params, "pod", filter.Pod)
+	setIfNotEmpty(params, "type", filter.Type)
+	list := &cpv1beta.NetworkPolicyList{}
+	if err := c.getInto(ctx, NetworkPoliciesPath, params, list); err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+// OVSFlowFilter selects the OVS flows or groups returned by Client.OVSFlows. At most one of Pod,
+// Service, NetworkPolicy, Tables and Groups can be set. All the flows are returned if none is set.
+type OVSFlowFilter struct {
+	// Namespace is the Namespace of the Pod, Service or NetworkPolicy. It is empty for an Antrea
+	// ClusterNetworkPolicy.
+	Namespace     string
+	Pod           string
+	Service       string
+	NetworkPolicy string
+	// Tables are comma separated flow table names or numbers.
+	Tables string
+	// Groups are comma separated group IDs, or "all".
+	Groups string
+}
+
+// OVSFlows returns the OVS flows or groups which match the filter.
+func (c *Client) OVSFlows(ctx context.Context, filter OVSFlowFilter) ([]ovsflowstypes.Flow, error) {
+	params := url.Values{}
+	setIfNotEmpty(params, "namespace", filter.Namespace)
+	setIfNotEmpty(params, "pod", filter.Pod)
+	setIfNotEmpty(params, "service", filter.Service)
+	setIfNotEmpty(params, "networkpolicy", filter.NetworkPolicy)
+	setIfNotEmpty(params, "table", filter.Tables)
+	setIfNotEmpty(params, "groups", filter.Groups)
+	var flows []ovsflowstypes.Flow
+	if err := c.getInto(ctx, OVSFlowsPath, params, &flows); err != nil {
+		return nil, err
+	}
+	return flows, nil
+}
+
+// PodInterfaceFilter selects the Pod interfaces returned by Client.PodInterfaces. The empty fields
+// are ignored.
+type PodInterfaceFilter struct {
+	// Name is the name of the Pod.
+	Name      string
+	Namespace string
+	// LabelSelector selects the Pods by labels, e.g. "app=web".
+	LabelSelector string
+}
+
+// PodInterfaces returns the network interfaces of the Pods running on the Node, which match the
+// filter. The request fails if the Agent doesn't serve the version of the schema the Client is
+// built with.
+func (c *Client) PodInterfaces(ctx context.Context, filter PodInterfaceFilter) ([]podinterfacetypes.PodInterface, error) {
+	params := url.Values{}
+	params.Set("version", podinterfacetypes.Version)
+	setIfNotEmpty(params, "name", filter.Name)
+	setIfNotEmpty(params, "namespace", filter.Namespace)
+	setIfNotEmpty(params, "labelSelector", filter.LabelSelector)
+	var interfaces []podinterfacetypes.PodInterface
+	if err := c.getInto(ctx, PodInterfacesPath, params, &interfaces); err != nil {
+		return nil, err
+	}
+	return interfaces, nil
+}
+
+func setIfNotEmpty(params url.Values, key, value string) {
+	if value != "" {
+		params.Set(key, value)
+	}
+}
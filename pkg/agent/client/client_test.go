@@ -0,0 +1,178 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakeclientset "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+
+	agentinfotypes "antrea.io/antrea/pkg/agent/apiserver/handlers/agentinfo/types"
+	ovsflowstypes "antrea.io/antrea/pkg/agent/apiserver/handlers/ovsflows/types"
+	podinterfacetypes "antrea.io/antrea/pkg/agent/apiserver/handlers/podinterface/types"
+	cpv1beta "antrea.io/antrea/pkg/apis/controlplane/v1beta2"
+	crdv1beta1 "antrea.io/antrea/pkg/apis/crd/v1beta1"
+	fakeversioned "antrea.io/antrea/pkg/client/clientset/versioned/fake"
+)
+
+// newAgentServer returns a server which checks that requests are sent to path with the expected
+// query, and responds with resp.
+func newAgentServer(t *testing.T, path string, expectedQuery url.Values, resp interface{}) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, path, r.URL.Path)
+		assert.Equal(t, expectedQuery, r.URL.Query())
+		json.NewEncoder(w).Encode(resp)
+	}))
+}
+
+func newTestClient(t *testing.T, server *httptest.Server, options Options) *Client {
+	c, err := New(&rest.Config{Host: server.URL}, options)
+	require.NoError(t, err)
+	return c
+}
+
+func TestAgentInfo(t *testing.T) {
+	expected := &agentinfotypes.AgentInfo{
+		Version:     "v1.3.0",
+		NodeRef:     corev1.ObjectReference{Kind: "Node", Name: "node1"},
+		NodeSubnets: []string{"10.10.0.0/24"},
+		LocalPodNum: 3,
+	}
+	server := newAgentServer(t, "/agentinfo", url.Values{}, expected)
+	defer server.Close()
+
+	info, err := newTestClient(t, server, Options{}).AgentInfo(context.TODO())
+	require.NoError(t, err)
+	assert.Equal(t, expected, info)
+}
+
+func TestNetworkPolicies(t *testing.T) {
+	nps := []cpv1beta.NetworkPolicy{{ObjectMeta: metav1.ObjectMeta{Name: "uid1", UID: "uid1"}}}
+	server := newAgentServer(t, "/networkpolicies", url.Values{"namespace": {"ns1"}, "pod": {"pod1"}}, cpv1beta.NetworkPolicyList{Items: nps})
+	defer server.Close()
+
+	got, err := newTestClient(t, server, Options{}).NetworkPolicies(context.TODO(), NetworkPolicyFilter{Namespace: "ns1", Pod: "pod1"})
+	require.NoError(t, err)
+	assert.Equal(t, nps, got)
+}
+
+func TestOVSFlows(t *testing.T) {
+	flows := []ovsflowstypes.Flow{{Flow: "table=0, priority=200,in_port=2 actions=goto_table:10"}}
+	server := newAgentServer(t, "/ovsflows", url.Values{"table": {"Classification"}}, flows)
+	defer server.Close()
+
+	got, err := newTestClient(t, server, Options{}).OVSFlows(context.TODO(), OVSFlowFilter{Tables: "Classification"})
+	require.NoError(t, err)
+	assert.Equal(t, flows, got)
+}
+
+func TestPodInterfaces(t *testing.T) {
+	interfaces := []podinterfacetypes.PodInterface{{PodName: "pod1", PodNamespace: "ns1", InterfaceName: "pod1-6631b7", OFPort: 3}}
+	server := newAgentServer(t, "/podinterfaces", url.Values{"version": {podinterfacetypes.Version}, "labelSelector": {"app=web"}}, interfaces)
+	defer server.Close()
+
+	got, err := newTestClient(t, server, Options{}).PodInterfaces(context.TODO(), PodInterfaceFilter{LabelSelector: "app=web"})
+	require.NoError(t, err)
+	assert.Equal(t, interfaces, got)
+}
+
+func TestGetRetries(t *testing.T) {
+	tests := []struct {
+		name             string
+		statusCodes      []int
+		maxRetries       int
+		expectedAttempts int
+		expectedErr      bool
+	}{
+		{name: "no retry", statusCodes: []int{http.StatusServiceUnavailable}, expectedAttempts: 1, expectedErr: true},
+		{name: "retry server error", statusCodes: []int{http.StatusServiceUnavailable, http.StatusInternalServerError, http.StatusOK}, maxRetries: 3, expectedAttempts: 3},
+		{name: "retries exhausted", statusCodes: []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusOK}, maxRetries: 1, expectedAttempts: 2, expectedErr: true},
+		{name: "no retry of client error", statusCodes: []int{http.StatusBadRequest, http.StatusOK}, maxRetries: 3, expectedAttempts: 1, expectedErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				code := tt.statusCodes[attempts]
+				attempts++
+				if code != http.StatusOK {
+					http.Error(w, "error", code)
+					return
+				}
+				w.Write([]byte("[]"))
+			}))
+			defer server.Close()
+
+			c := newTestClient(t, server, Options{MaxRetries: tt.maxRetries, RetryInterval: time.Millisecond})
+			_, err := c.Get(context.TODO(), OVSFlowsPath, url.Values{})
+			assert.Equal(t, tt.expectedAttempts, attempts)
+			if tt.expectedErr {
+				require.Error(t, err)
+				assert.Equal(t, int32(tt.statusCodes[attempts-1]), err.(*errors.StatusError).Status().Code)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestGetTimeout(t *testing.T) {
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-done
+	}))
+	defer server.Close()
+	defer close(done)
+
+	c := newTestClient(t, server, Options{Timeout: 100 * time.Millisecond})
+	_, err := c.Get(context.TODO(), AgentInfoPath, url.Values{})
+	assert.Error(t, err)
+}
+
+func TestConfigForNode(t *testing.T) {
+	k8sClient := fakeclientset.NewSimpleClientset(&corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node1"},
+		Status: corev1.NodeStatus{
+			Addresses: []corev1.NodeAddress{{Type: corev1.NodeInternalIP, Address: "192.168.1.10"}},
+		},
+	})
+	antreaClient := fakeversioned.NewSimpleClientset(&crdv1beta1.AntreaAgentInfo{
+		ObjectMeta: metav1.ObjectMeta{Name: "node1"},
+		NodeRef:    corev1.ObjectReference{Kind: "Node", Name: "node1"},
+		APIPort:    10350,
+	})
+	cfgTmpl := &rest.Config{Host: "https://10.96.0.1:443", BearerToken: "token"}
+
+	cfg, err := ConfigForNode(context.TODO(), k8sClient, antreaClient, cfgTmpl, "node1")
+	require.NoError(t, err)
+	assert.Equal(t, "https://192.168.1.10:10350", cfg.Host)
+	assert.Equal(t, "token", cfg.BearerToken)
+	assert.Equal(t, "https://10.96.0.1:443", cfgTmpl.Host)
+
+	_, err = ConfigForNode(context.TODO(), k8sClient, antreaClient, cfgTmpl, "node2")
+	assert.Error(t, err)
+}
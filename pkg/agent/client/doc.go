@@ -0,0 +1,20 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package client provides a typed client of the HTTP API served by the Antrea Agent on each Node,
+// e.g. to query the NetworkPolicies, the OVS flows or the Pod interfaces of a Node. The responses
+// are decoded into the types defined in the "types" package of each API handler, which external
+// consumers can import as well. antctl queries the Agent with this client, so that it never drifts
+// from the API.
+package client
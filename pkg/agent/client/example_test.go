@@ -0,0 +1,44 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"antrea.io/antrea/pkg/agent/client"
+)
+
+// A monitoring agent running as a DaemonSet lists the NetworkPolicies applied to a Pod of its Node.
+// NODE_NAME is set from the spec.nodeName field of the Pod with the downward API.
+func ExampleNewInClusterForNode() {
+	ctx := context.Background()
+	c, err := client.NewInClusterForNode(ctx, os.Getenv("NODE_NAME"), client.Options{
+		Timeout:    10 * time.Second,
+		MaxRetries: 3,
+	})
+	if err != nil {
+		panic(err)
+	}
+	nps, err := c.NetworkPolicies(ctx, client.NetworkPolicyFilter{Namespace: "default", Pod: "web-0"})
+	if err != nil {
+		panic(err)
+	}
+	for _, np := range nps {
+		fmt.Println(np.SourceRef.ToString())
+	}
+}
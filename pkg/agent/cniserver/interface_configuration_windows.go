@@ -495,6 +495,8 @@ func (ic *ifConfigurator) getInterceptedInterfaces(
 	sandbox string,
 	containerNetNS string,
 	containerIFDev string,
+	discovery InterfaceDiscovery,
+	prevResult *current.Result,
 ) (*current.Interface, *current.Interface, error) {
 	return nil, nil, errors.New("getInterceptedInterfaces is unsupported on Windows")
 }
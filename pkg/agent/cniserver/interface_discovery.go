@@ -0,0 +1,39 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cniserver
+
+// InterfaceDiscoveryStrategy is how the interfaces of a Pod, created by the primary CNI, are
+// discovered in the networkPolicyOnly mode.
+type InterfaceDiscoveryStrategy string
+
+const (
+	// InterfaceDiscoveryVethPeer looks up the host peer of the veth in the network namespace of the
+	// Pod.
+	InterfaceDiscoveryVethPeer InterfaceDiscoveryStrategy = "vethPeer"
+	// InterfaceDiscoveryCNIResult uses the host interface in the result of the previous plugin in
+	// the CNI chain.
+	InterfaceDiscoveryCNIResult InterfaceDiscoveryStrategy = "cniResult"
+	// InterfaceDiscoveryPrefix looks up the host veth whose name starts with a prefix, and whose
+	// peer is the interface of the Pod.
+	InterfaceDiscoveryPrefix InterfaceDiscoveryStrategy = "prefix"
+)
+
+// InterfaceDiscovery configures the discovery of the interfaces of the Pods in the
+// networkPolicyOnly mode. The zero value uses InterfaceDiscoveryVethPeer.
+type InterfaceDiscovery struct {
+	Strategy InterfaceDiscoveryStrategy
+	// Prefix is the prefix of the names of the host interfaces, with InterfaceDiscoveryPrefix.
+	Prefix string
+}
@@ -0,0 +1,193 @@
+// +build linux
+
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cniserver
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/containernetworking/cni/pkg/types/current"
+	"github.com/containernetworking/plugins/pkg/ns"
+	"github.com/vishvananda/netlink"
+)
+
+// linkGetter gets the links used to discover the interfaces of the Pods in the networkPolicyOnly
+// mode, so that the discovery can be tested with fake links.
+type linkGetter interface {
+	LinkByName(name string) (netlink.Link, error)
+	LinkByIndex(index int) (netlink.Link, error)
+	LinkList() ([]netlink.Link, error)
+	// NSLinkByName returns the link with the name in the network namespace at nsPath, and the ID
+	// of the network namespace in the host network namespace, or -1 if it has no ID.
+	NSLinkByName(nsPath, name string) (netlink.Link, int, error)
+}
+
+type netlinkLinkGetter struct{}
+
+func (netlinkLinkGetter) LinkByName(name string) (netlink.Link, error) {
+	return netlink.LinkByName(name)
+}
+
+func (netlinkLinkGetter) LinkByIndex(index int) (netlink.Link, error) {
+	return netlink.LinkByIndex(index)
+}
+
+func (netlinkLinkGetter) LinkList() ([]netlink.Link, error) {
+	return netlink.LinkList()
+}
+
+func (netlinkLinkGetter) NSLinkByName(nsPath, name string) (netlink.Link, int, error) {
+	netNS, err := ns.GetNS(nsPath)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get NS for path %s: %w", nsPath, err)
+	}
+	defer netNS.Close()
+	nsID, err := netlink.GetNetNsIdByFd(int(netNS.Fd()))
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get ID of NS %s: %w", nsPath, err)
+	}
+	var link netlink.Link
+	if err := netNS.Do(func(_ ns.NetNS) error {
+		link, err = netlink.LinkByName(name)
+		return err
+	}); err != nil {
+		return nil, 0, fmt.Errorf("failed to get link %s in container %s: %w", name, nsPath, err)
+	}
+	return link, nsID, nil
+}
+
+// getInterceptedInterfaces discovers the interface of the Pod, created by the primary CNI in the
+// networkPolicyOnly mode, and its host interface, which is attached to OVS.
+func (ic *ifConfigurator) getInterceptedInterfaces(
+	sandbox string,
+	containerNetNS string,
+	containerIFDev string,
+	discovery InterfaceDiscovery,
+	prevResult *current.Result,
+) (*current.Interface, *current.Interface, error) {
+	containerLink, nsID, err := ic.links.NSLinkByName(containerNetNS, containerIFDev)
+	if err != nil {
+		return nil, nil, fmt.Errorf("connectInterceptedInterface failed to get veth info: %w", err)
+	}
+	containerIface := &current.Interface{
+		Name:    containerIFDev,
+		Sandbox: sandbox,
+		Mac:     containerLink.Attrs().HardwareAddr.String(),
+	}
+
+	var hostLink netlink.Link
+	switch discovery.Strategy {
+	case InterfaceDiscoveryCNIResult:
+		hostLink, err = ic.getHostLinkFromCNIResult(containerLink, prevResult)
+	case InterfaceDiscoveryPrefix:
+		hostLink, err = ic.getHostLinkByPrefix(containerLink, nsID, discovery.Prefix)
+	default:
+		hostLink, err = ic.getHostLinkByVethPeer(containerLink)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("connectInterceptedInterface failed to get veth peer info: %w", err)
+	}
+	if masterIndex := hostLink.Attrs().MasterIndex; masterIndex > 0 {
+		masterLink, err := ic.links.LinkByIndex(masterIndex)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get master link for dev %s: %w", hostLink.Attrs().Name, err)
+		}
+		if _, isBridge := masterLink.(*netlink.Bridge); isBridge {
+			return nil, nil, fmt.Errorf("connectInterceptedInterface: does not expect device %s attached to bridge", hostLink.Attrs().Name)
+		}
+	}
+	hostIface := &current.Interface{
+		Name: hostLink.Attrs().Name,
+		Mac:  hostLink.Attrs().HardwareAddr.String(),
+	}
+	return containerIface, hostIface, nil
+}
+
+// getHostLinkByVethPeer returns the peer of the veth of the Pod, whose index is the link index of
+// the veth.
+func (ic *ifConfigurator) getHostLinkByVethPeer(containerLink netlink.Link) (netlink.Link, error) {
+	if !isVeth(containerLink) {
+		return nil, fmt.Errorf("container interface %s is not of type veth", containerLink.Attrs().Name)
+	}
+	peerIndex := containerLink.Attrs().ParentIndex
+	hostLink, err := ic.links.LinkByIndex(peerIndex)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get link for idx %d: %w", peerIndex, err)
+	}
+	return hostLink, nil
+}
+
+// getHostLinkFromCNIResult returns the host interface in the result of the previous CNI plugin.
+// When the result has several host interfaces, e.g. a bridge and a veth, the peer of the interface
+// of the Pod is returned.
+func (ic *ifConfigurator) getHostLinkFromCNIResult(containerLink netlink.Link, prevResult *current.Result) (netlink.Link, error) {
+	if prevResult == nil {
+		return nil, fmt.Errorf("no result of the previous CNI plugin")
+	}
+	var hostLinks []netlink.Link
+	for _, intf := range prevResult.Interfaces {
+		if intf.Sandbox != "" {
+			continue
+		}
+		link, err := ic.links.LinkByName(intf.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get host interface %s of the CNI result: %w", intf.Name, err)
+		}
+		if intf.Mac != "" && intf.Mac != link.Attrs().HardwareAddr.String() {
+			return nil, fmt.Errorf("host interface %s MAC %s doesn't match link address %s",
+				intf.Name, intf.Mac, link.Attrs().HardwareAddr.String())
+		}
+		hostLinks = append(hostLinks, link)
+	}
+	switch len(hostLinks) {
+	case 0:
+		return nil, fmt.Errorf("no host interface in the result of the previous CNI plugin")
+	case 1:
+		return hostLinks[0], nil
+	}
+	for _, link := range hostLinks {
+		if link.Attrs().Index == containerLink.Attrs().ParentIndex {
+			return link, nil
+		}
+	}
+	return nil, fmt.Errorf("none of the %d host interfaces of the CNI result is the peer of container interface %s",
+		len(hostLinks), containerLink.Attrs().Name)
+}
+
+// getHostLinkByPrefix returns the host veth whose name starts with prefix, and whose peer is the
+// interface of the Pod. As the index of the interface is only unique in the network namespace of the
+// Pod, the network namespace of the peer must be the one of the Pod when it has an ID.
+func (ic *ifConfigurator) getHostLinkByPrefix(containerLink netlink.Link, nsID int, prefix string) (netlink.Link, error) {
+	links, err := ic.links.LinkList()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list host interfaces: %w", err)
+	}
+	for _, link := range links {
+		if !strings.HasPrefix(link.Attrs().Name, prefix) || !isVeth(link) {
+			continue
+		}
+		if link.Attrs().ParentIndex != containerLink.Attrs().Index {
+			continue
+		}
+		if nsID >= 0 && link.Attrs().NetNsID != nsID {
+			continue
+		}
+		return link, nil
+	}
+	return nil, fmt.Errorf("no veth interface with prefix %s is the peer of container interface %s",
+		prefix, containerLink.Attrs().Name)
+}
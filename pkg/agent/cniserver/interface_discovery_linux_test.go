@@ -0,0 +1,256 @@
+// +build linux
+
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cniserver
+
+import (
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/containernetworking/cni/pkg/types/current"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vishvananda/netlink"
+
+	"antrea.io/antrea/pkg/agent/interfacestore"
+	openflowtest "antrea.io/antrea/pkg/agent/openflow/testing"
+	routetest "antrea.io/antrea/pkg/agent/route/testing"
+	antreatypes "antrea.io/antrea/pkg/agent/types"
+	ovsconfigtest "antrea.io/antrea/pkg/ovs/ovsconfig/testing"
+)
+
+const (
+	testContainerNetNS = "/var/run/netns/test"
+	testNetNSID        = 5
+)
+
+// fakeLinkGetter returns the links of a single Pod network namespace, and of the host network
+// namespace.
+type fakeLinkGetter struct {
+	containerLinks map[string]netlink.Link
+	nsID           int
+	hostLinks      []netlink.Link
+}
+
+func (g *fakeLinkGetter) LinkByName(name string) (netlink.Link, error) {
+	for _, link := range g.hostLinks {
+		if link.Attrs().Name == name {
+			return link, nil
+		}
+	}
+	return nil, fmt.Errorf("link %s not found", name)
+}
+
+func (g *fakeLinkGetter) LinkByIndex(index int) (netlink.Link, error) {
+	for _, link := range g.hostLinks {
+		if link.Attrs().Index == index {
+			return link, nil
+		}
+	}
+	return nil, fmt.Errorf("link %s not found", name)
+}
+
+func (g *fakeLinkGetter) LinkList() ([]netlink.Link, error) {
+	return g.hostLinks, nil
+}
+
+func (g *fakeLinkGetter) NSLinkByName(nsPath, name string) (netlink.Link, int, error) {
+	if nsPath != testContainerNetNS {
+		return nil, 0, fmt.Errorf("failed to get NS for path %s", nsPath)
+	}
+	link, ok := g.containerLinks[name]
+	if !ok {
+		return nil, 0, fmt.Errorf("link %s not found in container %s", name, nsPath)
+	}
+	return link, g.nsID, nil
+}
+
+func newTestVeth(index int, name string, mac string, peerIndex int, peerNSID int) *netlink.Veth {
+	hwAddr, _ := net.ParseMAC(mac)
+	return &netlink.Veth{LinkAttrs: netlink.LinkAttrs{
+		Index:        index,
+		Name:         name,
+		HardwareAddr: hwAddr,
+		ParentIndex:  peerIndex,
+		NetNsID:      peerNSID,
+	}}
+}
+
+// newTestLinkGetter returns the links of a Pod connected by a Cilium-like CNI: the Pod interface
+// eth0, with index 3, is the peer of lxc1234 in the host network namespace. The host network
+// namespace also has the veth of another Pod with the same eth0 index in another network namespace,
+// a veth attached to a bridge, and the uplink.
+func newTestLinkGetter() *fakeLinkGetter {
+	bridgedVeth := newTestVeth(13, "veth5678", "aa:bb:cc:00:00:13", 3, 7)
+	bridgedVeth.MasterIndex = 20
+	return &fakeLinkGetter{
+		containerLinks: map[string]netlink.Link{
+			"eth0": newTestVeth(3, "eth0", "aa:bb:cc:00:00:03", 12, 0),
+		},
+		nsID: testNetNSID,
+		hostLinks: []netlink.Link{
+			&netlink.Device{LinkAttrs: netlink.LinkAttrs{Index: 2, Name: "ens3"}},
+			newTestVeth(11, "lxc9999", "aa:bb:cc:00:00:11", 3, 6),
+			newTestVeth(12, "lxc1234", "aa:bb:cc:00:00:12", 3, testNetNSID),
+			bridgedVeth,
+			&netlink.Bridge{LinkAttrs: netlink.LinkAttrs{Index: 20, Name: "cni0"}},
+		},
+	}
+}
+
+func TestGetInterceptedInterfaces(t *testing.T) {
+	cniResult := func(interfaces ...*current.Interface) *current.Result {
+		return &current.Result{Interfaces: interfaces}
+	}
+	containerInterface := &current.Interface{Name: "eth0", Sandbox: testContainerNetNS}
+	tests := []struct {
+		name              string
+		discovery         InterfaceDiscovery
+		prevResult        *current.Result
+		updateLinks       func(g *fakeLinkGetter)
+		expectedHostIface string
+		expectedErr       bool
+	}{
+		{
+			name:              "veth peer",
+			expectedHostIface: "lxc1234",
+		},
+		{
+			name:      "veth peer attached to bridge",
+			discovery: InterfaceDiscovery{Strategy: InterfaceDiscoveryVethPeer},
+			updateLinks: func(g *fakeLinkGetter) {
+				g.containerLinks["eth0"].Attrs().ParentIndex = 13
+			},
+			expectedErr: true,
+		},
+		{
+			name:      "veth peer of non-veth interface",
+			discovery: InterfaceDiscovery{Strategy: InterfaceDiscoveryVethPeer},
+			updateLinks: func(g *fakeLinkGetter) {
+				g.containerLinks["eth0"] = &netlink.Device{LinkAttrs: netlink.LinkAttrs{Index: 3, Name: "eth0"}}
+			},
+			expectedErr: true,
+		},
+		{
+			name:              "CNI result",
+			discovery:         InterfaceDiscovery{Strategy: InterfaceDiscoveryCNIResult},
+			prevResult:        cniResult(&current.Interface{Name: "lxc1234", Mac: "aa:bb:cc:00:00:12"}, containerInterface),
+			expectedHostIface: "lxc1234",
+		},
+		{
+			name:       "CNI result with several host interfaces",
+			discovery:  InterfaceDiscovery{Strategy: InterfaceDiscoveryCNIResult},
+			prevResult: cniResult(&current.Interface{Name: "lxc9999"}, &current.Interface{Name: "lxc1234"}, containerInterface),
+			// lxc1234 is the peer of eth0.
+			expectedHostIface: "lxc1234",
+		},
+		{
+			name:        "CNI result without host interface",
+			discovery:   InterfaceDiscovery{Strategy: InterfaceDiscoveryCNIResult},
+			prevResult:  cniResult(containerInterface),
+			expectedErr: true,
+		},
+		{
+			name:        "CNI result with unknown host interface",
+			discovery:   InterfaceDiscovery{Strategy: InterfaceDiscoveryCNIResult},
+			prevResult:  cniResult(&current.Interface{Name: "tap0"}, containerInterface),
+			expectedErr: true,
+		},
+		{
+			name:        "CNI result with MAC mismatch",
+			discovery:   InterfaceDiscovery{Strategy: InterfaceDiscoveryCNIResult},
+			prevResult:  cniResult(&current.Interface{Name: "lxc1234", Mac: "aa:bb:cc:00:00:11"}, containerInterface),
+			expectedErr: true,
+		},
+		{
+			name:              "prefix",
+			discovery:         InterfaceDiscovery{Strategy: InterfaceDiscoveryPrefix, Prefix: "lxc"},
+			expectedHostIface: "lxc1234",
+		},
+		{
+			name:      "prefix without netns ID",
+			discovery: InterfaceDiscovery{Strategy: InterfaceDiscoveryPrefix, Prefix: "lxc"},
+			updateLinks: func(g *fakeLinkGetter) {
+				g.nsID = -1
+			},
+			// Without the ID of the netns, the first veth whose peer has the index of eth0 is
+			// selected.
+			expectedHostIface: "lxc9999",
+		},
+		{
+			name:        "prefix without match",
+			discovery:   InterfaceDiscovery{Strategy: InterfaceDiscoveryPrefix, Prefix: "cali"},
+			expectedErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			links := newTestLinkGetter()
+			if tt.updateLinks != nil {
+				tt.updateLinks(links)
+			}
+			ic := &ifConfigurator{links: links}
+			containerIface, hostIface, err := ic.getInterceptedInterfaces(testContainerNetNS, testContainerNetNS, "eth0", tt.discovery, tt.prevResult)
+			if tt.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, &current.Interface{Name: "eth0", Mac: "aa:bb:cc:00:00:03", Sandbox: testContainerNetNS}, containerIface)
+			assert.Equal(t, tt.expectedHostIface, hostIface.Name)
+		})
+	}
+}
+
+func TestConnectInterceptedInterface(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+	mockOVSBridgeClient := ovsconfigtest.NewMockOVSBridgeClient(controller)
+	mockOFClient := openflowtest.NewMockPodConnectivityClient(controller)
+	mockRoute := routetest.NewMockInterface(controller)
+	ifaceStore := interfacestore.NewInterfaceStore()
+	gwMAC, _ := net.ParseMAC("00:00:11:11:11:11")
+	podConfigurator, err := newPodConfigurator(mockOVSBridgeClient, mockOFClient, mockRoute, ifaceStore, gwMAC, "system", false, make(chan antreatypes.EntityReference, 100))
+	require.NoError(t, err)
+	podConfigurator.ifConfigurator.links = newTestLinkGetter()
+	podConfigurator.interfaceDiscovery = InterfaceDiscovery{Strategy: InterfaceDiscoveryPrefix, Prefix: "lxc"}
+
+	defer func(f func(string) (string, error)) { getNSPath = f }(getNSPath)
+	getNSPath = func(netnsName string) (string, error) {
+		return netnsName, nil
+	}
+
+	containerID := "container1"
+	_, ipNet, _ := net.ParseCIDR("10.1.2.100/24")
+	ipNet.IP = net.ParseIP("10.1.2.100")
+	prevResult := &current.Result{IPs: []*current.IPConfig{{Version: "4", Address: *ipNet}}}
+	containerMAC, _ := net.ParseMAC("aa:bb:cc:00:00:03")
+	// The OVS port and the Pod flows use the discovered host interface, and its OF port.
+	mockRoute.EXPECT().MigrateRoutesToGw("lxc1234").Return(nil)
+	mockOVSBridgeClient.EXPECT().CreatePort("lxc1234", "lxc1234", gomock.Any()).Return("port-uuid", nil)
+	mockOVSBridgeClient.EXPECT().GetOFPort("lxc1234").Return(int32(7), nil)
+	mockOFClient.EXPECT().InstallPodFlows("lxc1234", []net.IP{ipNet.IP}, containerMAC, uint32(7)).Return(nil)
+
+	err = podConfigurator.connectInterceptedInterface(testPodName, testPodNamespace, containerID, testContainerNetNS, "eth0", prevResult, newContainerAccessArbitrator())
+	require.NoError(t, err)
+	containerConfig, found := ifaceStore.GetContainerInterface(containerID)
+	require.True(t, found)
+	assert.Equal(t, "lxc1234", containerConfig.InterfaceName)
+	assert.Equal(t, int32(7), containerConfig.OFPort)
+	assert.Equal(t, "port-uuid", containerConfig.PortUUID)
+}
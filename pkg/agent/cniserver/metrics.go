@@ -0,0 +1,94 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cniserver
+
+import (
+	"errors"
+	"time"
+
+	"antrea.io/antrea/pkg/agent/metrics"
+)
+
+// cniCommand is the CNI command used to label the CNI metrics.
+type cniCommand string
+
+const (
+	cniCommandAdd cniCommand = "add"
+	cniCommandDel cniCommand = "del"
+)
+
+// cniPhase is a phase of the processing of a CNI command.
+type cniPhase string
+
+const (
+	phaseIPAM cniPhase = "ipam"
+	// phaseInterface covers the container interface and its routes.
+	phaseInterface cniPhase = "interface"
+	phaseOVSPort   cniPhase = "ovs_port"
+	phaseFlows     cniPhase = "flows"
+	// phaseTotal covers the whole command.
+	phaseTotal cniPhase = "total"
+)
+
+// failureReason is the reason why a CNI command failed. A failure in a phase has the name of the
+// phase as reason.
+type failureReason string
+
+const (
+	reasonInvalidRequest  failureReason = "invalid_request"
+	reasonNetworkNotReady failureReason = "network_not_ready"
+	reasonIPAM            failureReason = failureReason(phaseIPAM)
+	reasonInterface       failureReason = failureReason(phaseInterface)
+	reasonOVSPort         failureReason = failureReason(phaseOVSPort)
+	reasonFlows           failureReason = failureReason(phaseFlows)
+)
+
+// phaseError is an error which occurred in a given phase of a CNI command.
+type phaseError struct {
+	phase cniPhase
+	err   error
+}
+
+func newPhaseError(phase cniPhase, err error) error {
+	return &phaseError{phase: phase, err: err}
+}
+
+func (e *phaseError) Error() string {
+	return e.err.Error()
+}
+
+func (e *phaseError) Unwrap() error {
+	return e.err
+}
+
+// failureReasonFromError returns the phase in which err occurred as failure reason, or
+// defaultReason if err does not wrap a phaseError.
+func failureReasonFromError(err error, defaultReason failureReason) failureReason {
+	var pErr *phaseError
+	if errors.As(err, &pErr) {
+		return failureReason(pErr.phase)
+	}
+	return defaultReason
+}
+
+// observePhase records the time elapsed since start as the latency of a phase of a CNI command.
+func observePhase(command cniCommand, phase cniPhase, start time.Time) {
+	metrics.CNICmdLatency.WithLabelValues(string(command), string(phase)).Observe(float64(time.Since(start).Milliseconds()))
+}
+
+// recordFailure records the failure of a CNI command.
+func recordFailure(command cniCommand, reason failureReason) {
+	metrics.CNICmdFailureCount.WithLabelValues(string(command), string(reason)).Inc()
+}
@@ -18,7 +18,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"net"
+	"strconv"
 	"strings"
+	"time"
 
 	cnitypes "github.com/containernetworking/cni/pkg/types"
 	"github.com/containernetworking/cni/pkg/types/current"
@@ -56,6 +58,8 @@ const (
 	ovsExternalIDContainerID  = "container-id"
 	ovsExternalIDPodName      = "pod-name"
 	ovsExternalIDPodNamespace = "pod-namespace"
+	ovsExternalIDDHCP         = "dhcp"
+	ovsExternalIDConnLimit    = "connection-limit"
 )
 
 const (
@@ -65,7 +69,7 @@ const (
 
 type podConfigurator struct {
 	ovsBridgeClient ovsconfig.OVSBridgeClient
-	ofClient        openflow.Client
+	ofClient        openflow.PodConnectivityClient
 	routeClient     route.Interface
 	ifaceStore      interfacestore.InterfaceStore
 	gatewayMAC      net.HardwareAddr
@@ -73,11 +77,23 @@ type podConfigurator struct {
 	// entityUpdates is a channel for notifying updates of local endpoints / entities (most notably Pod)
 	// to other components which may benefit from this information, i.e NetworkPolicyController.
 	entityUpdates chan<- types.EntityReference
+	// isDHCPPod returns whether a Pod obtains its addresses using DHCP. It's set by the CNI server
+	// once the Pod monitor is created, and nil before that.
+	isDHCPPod func(podNamespace, podName string) bool
+	// podConnectionLimit returns the connection limit of a Pod, 0 if its connections are not
+	// limited. It's set by the CNI server once the Pod monitor is created, and nil before that.
+	podConnectionLimit func(podNamespace, podName string) uint32
+	// interfaceDiscovery is how the interfaces of the Pods are discovered in the networkPolicyOnly
+	// mode. It's set by the CNI server.
+	interfaceDiscovery InterfaceDiscovery
 }
 
+// getNSPath is overridden in tests, which don't run the Pods in network namespaces.
+var getNSPath = util.GetNSPath
+
 func newPodConfigurator(
 	ovsBridgeClient ovsconfig.OVSBridgeClient,
-	ofClient openflow.Client,
+	ofClient openflow.PodConnectivityClient,
 	routeClient route.Interface,
 	ifaceStore interfacestore.InterfaceStore,
 	gatewayMAC net.HardwareAddr,
@@ -122,13 +138,15 @@ func buildContainerConfig(
 	}
 	// containerIface.Mac should be a valid MAC string, otherwise it should throw error before
 	containerMAC, _ := net.ParseMAC(containerIface.Mac)
-	return interfacestore.NewContainerInterface(
+	containerConfig := interfacestore.NewContainerInterface(
 		interfaceName,
 		containerID,
 		podName,
 		podNamespace,
 		containerMAC,
 		containerIPs)
+	containerConfig.CreationTimestamp = time.Now()
+	return containerConfig
 }
 
 // BuildOVSPortExternalIDs parses OVS port external_ids from InterfaceConfig.
@@ -140,6 +158,12 @@ func BuildOVSPortExternalIDs(containerConfig *interfacestore.InterfaceConfig) ma
 	externalIDs[ovsExternalIDIP] = getContainerIPsString(containerConfig.IPs)
 	externalIDs[ovsExternalIDPodName] = containerConfig.PodName
 	externalIDs[ovsExternalIDPodNamespace] = containerConfig.PodNamespace
+	if containerConfig.DHCP {
+		externalIDs[ovsExternalIDDHCP] = "true"
+	}
+	if containerConfig.ConnectionLimit > 0 {
+		externalIDs[ovsExternalIDConnLimit] = strconv.FormatUint(uint64(containerConfig.ConnectionLimit), 10)
+	}
 	return externalIDs
 }
 
@@ -151,6 +175,41 @@ func getContainerIPsString(ips []net.IP) string {
 	return strings.Join(containerIPs, ",")
 }
 
+// ValidateOVSPortExternalIDs checks the Pod properties saved in the OVS port external_ids. It
+// returns an error describing the problem if the port has some of the external IDs set for Pod
+// interfaces, but they are incomplete or invalid, e.g. because they were truncated. nil is
+// returned for a valid Pod interface port, and for a port which has none of these external IDs.
+// If "checkMac" param is set as true the ovsExternalIDMAC of portData must be a valid MAC string.
+func ValidateOVSPortExternalIDs(portData *ovsconfig.OVSPortData, checkMac bool) error {
+	keys := []string{ovsExternalIDContainerID, ovsExternalIDPodName, ovsExternalIDPodNamespace, ovsExternalIDIP, ovsExternalIDMAC}
+	var missing []string
+	for _, key := range keys {
+		if portData.ExternalIDs[key] == "" {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) == len(keys) {
+		return nil
+	}
+	if !checkMac && len(missing) > 0 && missing[len(missing)-1] == ovsExternalIDMAC {
+		missing = missing[:len(missing)-1]
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("missing external IDs %s", strings.Join(missing, ", "))
+	}
+	for _, ipStr := range strings.Split(portData.ExternalIDs[ovsExternalIDIP], ",") {
+		if net.ParseIP(ipStr) == nil {
+			return fmt.Errorf("invalid IP %q in external ID %s", ipStr, ovsExternalIDIP)
+		}
+	}
+	if checkMac {
+		if _, err := net.ParseMAC(portData.ExternalIDs[ovsExternalIDMAC]); err != nil {
+			return fmt.Errorf("invalid MAC in external ID %s: %v", ovsExternalIDMAC, err)
+		}
+	}
+	return nil
+}
+
 // ParseOVSPortInterfaceConfig reads the Pod properties saved in the OVS port
 // external_ids, initializes and returns an InterfaceConfig struct.
 // nill will be returned, if the OVS port does not have external IDs or it is
@@ -189,6 +248,14 @@ func ParseOVSPortInterfaceConfig(portData *ovsconfig.OVSPortData, portConfig *in
 		podNamespace,
 		containerMAC,
 		containerIPs)
+	interfaceConfig.DHCP = portData.ExternalIDs[ovsExternalIDDHCP] == "true"
+	if connLimit, ok := portData.ExternalIDs[ovsExternalIDConnLimit]; ok {
+		if limit, err := strconv.ParseUint(connLimit, 10, 32); err != nil {
+			klog.Errorf("Failed to parse connection limit from OVS external config %s: %v", connLimit, err)
+		} else {
+			interfaceConfig.ConnectionLimit = uint32(limit)
+		}
+	}
 	interfaceConfig.OVSPortConfig = portConfig
 	return interfaceConfig
 }
@@ -205,9 +272,11 @@ func (pc *podConfigurator) configureInterfaces(
 	createOVSPort bool,
 	containerAccess *containerAccessArbitrator,
 ) error {
+	interfaceStart := time.Now()
 	err := pc.ifConfigurator.configureContainerLink(podName, podNameSpace, containerID, containerNetNS, containerIFDev, mtu, sriovVFDeviceID, "", result)
+	observePhase(cniCommandAdd, phaseInterface, interfaceStart)
 	if err != nil {
-		return err
+		return newPhaseError(phaseInterface, err)
 	}
 	hostIface := result.Interfaces[0]
 	containerIface := result.Interfaces[1]
@@ -239,7 +308,7 @@ func (pc *podConfigurator) configureInterfaces(
 
 	var containerConfig *interfacestore.InterfaceConfig
 	if containerConfig, err = pc.connectInterfaceToOVS(podName, podNameSpace, containerID, hostIface, containerIface, result.IPs, containerAccess); err != nil {
-		return fmt.Errorf("failed to connect to ovs for container %s: %v", containerID, err)
+		return fmt.Errorf("failed to connect to ovs for container %s: %w", containerID, err)
 	} else {
 		success = true
 	}
@@ -298,8 +367,11 @@ func (pc *podConfigurator) removeInterfaces(containerID string) error {
 		return err
 	}
 
-	if err := pc.ifConfigurator.removeContainerLink(containerID, containerConfig.InterfaceName); err != nil {
-		return err
+	interfaceStart := time.Now()
+	err := pc.ifConfigurator.removeContainerLink(containerID, containerConfig.InterfaceName)
+	observePhase(cniCommandDel, phaseInterface, interfaceStart)
+	if err != nil {
+		return newPhaseError(phaseInterface, err)
 	}
 	return nil
 }
@@ -437,6 +509,16 @@ func (pc *podConfigurator) reconcile(pods []corev1.Pod, containerAccess *contain
 			); err != nil {
 				klog.Errorf("Error when re-installing flows for Pod %s", namespacedName)
 			}
+			if containerConfig.DHCP {
+				if err := pc.ofClient.InstallPodDHCPFlows(containerConfig.InterfaceName, containerConfig.MAC, uint32(containerConfig.OFPort)); err != nil {
+					klog.Errorf("Error when re-installing DHCP flows for Pod %s", namespacedName)
+				}
+			}
+			if containerConfig.ConnectionLimit > 0 {
+				if err := pc.ofClient.InstallPodConnectionLimitFlows(containerConfig.InterfaceName, containerConfig.PodNamespace, containerConfig.PodName, uint32(containerConfig.OFPort), containerConfig.ConnectionLimit); err != nil {
+					klog.Errorf("Error when re-installing connection limit flows for Pod %s", namespacedName)
+				}
+			}
 		} else {
 			// clean-up and delete interface
 			klog.V(4).Infof("Deleting interface %s", containerConfig.InterfaceName)
@@ -455,11 +537,18 @@ func (pc *podConfigurator) reconcile(pods []corev1.Pod, containerAccess *contain
 func (pc *podConfigurator) connectInterfaceToOVSCommon(ovsPortName string, containerConfig *interfacestore.InterfaceConfig) error {
 	// create OVS Port and add attach container configuration into external_ids
 	containerID := containerConfig.ContainerID
+	if pc.isDHCPPod != nil && pc.isDHCPPod(containerConfig.PodNamespace, containerConfig.PodName) {
+		containerConfig.DHCP = true
+	}
+	if pc.podConnectionLimit != nil {
+		containerConfig.ConnectionLimit = pc.podConnectionLimit(containerConfig.PodNamespace, containerConfig.PodName)
+	}
 	klog.V(2).Infof("Adding OVS port %s for container %s", ovsPortName, containerID)
 	ovsAttachInfo := BuildOVSPortExternalIDs(containerConfig)
+	ovsPortStart := time.Now()
 	portUUID, err := pc.createOVSPort(ovsPortName, ovsAttachInfo)
 	if err != nil {
-		return fmt.Errorf("failed to add OVS port for container %s: %v", containerID, err)
+		return newPhaseError(phaseOVSPort, fmt.Errorf("failed to add OVS port for container %s: %v", containerID, err))
 	}
 	// Remove OVS port if any failure occurs in later manipulation.
 	defer func() {
@@ -470,14 +559,27 @@ func (pc *podConfigurator) connectInterfaceToOVSCommon(ovsPortName string, conta
 
 	// GetOFPort will wait for up to 1 second for OVSDB to report the OFPort number.
 	ofPort, err := pc.ovsBridgeClient.GetOFPort(ovsPortName)
+	observePhase(cniCommandAdd, phaseOVSPort, ovsPortStart)
 	if err != nil {
-		return fmt.Errorf("failed to get of_port of OVS port %s: %v", ovsPortName, err)
+		return newPhaseError(phaseOVSPort, fmt.Errorf("failed to get of_port of OVS port %s: %v", ovsPortName, err))
 	}
 
 	klog.V(2).Infof("Setting up Openflow entries for container %s", containerID)
+	flowsStart := time.Now()
 	err = pc.ofClient.InstallPodFlows(ovsPortName, containerConfig.IPs, containerConfig.MAC, uint32(ofPort))
+	if err == nil && containerConfig.DHCP {
+		if err = pc.ofClient.InstallPodDHCPFlows(ovsPortName, containerConfig.MAC, uint32(ofPort)); err != nil {
+			_ = pc.ofClient.UninstallPodFlows(ovsPortName)
+		}
+	}
+	if err == nil && containerConfig.ConnectionLimit > 0 {
+		if err = pc.ofClient.InstallPodConnectionLimitFlows(ovsPortName, containerConfig.PodNamespace, containerConfig.PodName, uint32(ofPort), containerConfig.ConnectionLimit); err != nil {
+			_ = pc.ofClient.UninstallPodFlows(ovsPortName)
+		}
+	}
+	observePhase(cniCommandAdd, phaseFlows, flowsStart)
 	if err != nil {
-		return fmt.Errorf("failed to add Openflow entries for container %s: %v", containerID, err)
+		return newPhaseError(phaseFlows, fmt.Errorf("failed to add Openflow entries for container %s: %v", containerID, err))
 	}
 	containerConfig.OVSPortConfig = &interfacestore.OVSPortConfig{PortUUID: portUUID, OFPort: ofPort}
 	// Add containerConfig into local cache
@@ -493,8 +595,11 @@ func (pc *podConfigurator) connectInterfaceToOVSCommon(ovsPortName string, conta
 func (pc *podConfigurator) disconnectInterfaceFromOVS(containerConfig *interfacestore.InterfaceConfig) error {
 	containerID := containerConfig.ContainerID
 	klog.V(2).Infof("Deleting Openflow entries for container %s", containerID)
-	if err := pc.ofClient.UninstallPodFlows(containerConfig.InterfaceName); err != nil {
-		return fmt.Errorf("failed to delete Openflow entries for container %s: %v", containerID, err)
+	flowsStart := time.Now()
+	err := pc.ofClient.UninstallPodFlows(containerConfig.InterfaceName)
+	observePhase(cniCommandDel, phaseFlows, flowsStart)
+	if err != nil {
+		return newPhaseError(phaseFlows, fmt.Errorf("failed to delete Openflow entries for container %s: %v", containerID, err))
 		// We should not delete OVS port if Pod flows deletion fails, otherwise
 		// it is possible a new Pod will reuse the reclaimed ofport number, and
 		// the OVS flows added for the new Pod can conflict with the stale
@@ -503,8 +608,11 @@ func (pc *podConfigurator) disconnectInterfaceFromOVS(containerConfig *interface
 
 	klog.V(2).Infof("Deleting OVS port %s for container %s", containerConfig.PortUUID, containerID)
 	// TODO: handle error and introduce garbage collection for failure on deletion
-	if err := pc.ovsBridgeClient.DeletePort(containerConfig.PortUUID); err != nil {
-		return fmt.Errorf("failed to delete OVS port for container %s: %v", containerID, err)
+	ovsPortStart := time.Now()
+	err = pc.ovsBridgeClient.DeletePort(containerConfig.PortUUID)
+	observePhase(cniCommandDel, phaseOVSPort, ovsPortStart)
+	if err != nil {
+		return newPhaseError(phaseOVSPort, fmt.Errorf("failed to delete OVS port for container %s: %v", containerID, err))
 	}
 	// Remove container configuration from cache.
 	pc.ifaceStore.DeleteInterface(containerConfig)
@@ -519,22 +627,25 @@ func (pc *podConfigurator) connectInterceptedInterface(
 	containerID string,
 	containerNetNS string,
 	containerIFDev string,
-	containerIPs []*current.IPConfig,
+	prevResult *current.Result,
 	containerAccess *containerAccessArbitrator,
 ) error {
-	sandbox, err := util.GetNSPath(containerNetNS)
+	sandbox, err := getNSPath(containerNetNS)
 	if err != nil {
 		return err
 	}
-	containerIface, hostIface, err := pc.ifConfigurator.getInterceptedInterfaces(sandbox, containerNetNS, containerIFDev)
+	containerIface, hostIface, err := pc.ifConfigurator.getInterceptedInterfaces(sandbox, containerNetNS, containerIFDev, pc.interfaceDiscovery, prevResult)
 	if err != nil {
 		return err
 	}
-	if err = pc.routeClient.MigrateRoutesToGw(hostIface.Name); err != nil {
-		return fmt.Errorf("connectInterceptedInterface failed to migrate: %w", err)
+	interfaceStart := time.Now()
+	err = pc.routeClient.MigrateRoutesToGw(hostIface.Name)
+	observePhase(cniCommandAdd, phaseInterface, interfaceStart)
+	if err != nil {
+		return newPhaseError(phaseInterface, fmt.Errorf("connectInterceptedInterface failed to migrate: %w", err))
 	}
 	_, err = pc.connectInterfaceToOVS(podName, podNameSpace, containerID, hostIface,
-		containerIface, containerIPs, containerAccess)
+		containerIface, prevResult.IPs, containerAccess)
 	return err
 }
 
@@ -550,7 +661,7 @@ func (pc *podConfigurator) disconnectInterceptedInterface(podName, podNamespace,
 			IP:   ip,
 			Mask: net.CIDRMask(32, 32),
 		}, ""); err != nil {
-			return fmt.Errorf("connectInterceptedInterface failed to migrate: %w", err)
+			return newPhaseError(phaseInterface, fmt.Errorf("connectInterceptedInterface failed to migrate: %w", err))
 		}
 	}
 	return pc.disconnectInterfaceFromOVS(containerConfig)
@@ -0,0 +1,218 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cniserver
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/util/wait"
+	coreinformers "k8s.io/client-go/informers/core/v1"
+	clientset "k8s.io/client-go/kubernetes"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	"antrea.io/antrea/pkg/agent/interfacestore"
+	"antrea.io/antrea/pkg/agent/types"
+	"antrea.io/antrea/pkg/util/k8s"
+)
+
+const (
+	// staleInterfaceGracePeriod is how long the interface of a container can exist without its Pod
+	// before it is considered stale. It leaves time for the Pod informer to catch up with a Pod
+	// which has just been created, and for the runtime to send CNI DEL for a Pod which has just
+	// been deleted.
+	staleInterfaceGracePeriod = 2 * time.Minute
+	// staleInterfaceCheckInterval is the interval between two checks for stale interfaces.
+	staleInterfaceCheckInterval = 30 * time.Second
+	// podResyncPeriod is the resync period of the Pod informer. Resync is not needed as the
+	// interfaces are checked periodically.
+	podResyncPeriod = 0
+)
+
+// podMonitor watches the Pods running on the Node and removes the interfaces of the containers
+// whose Pod no longer exists or has terminated. CNI DEL is normally in charge of it, but it is
+// never received if kubelet crashes or the container runtime loses its state, in which case the
+// stale Pod flows would be inherited by a new Pod reusing the OF port.
+type podMonitor struct {
+	kubeClient      clientset.Interface
+	podInformer     cache.SharedIndexInformer
+	podLister       corelisters.PodLister
+	podListerSynced cache.InformerSynced
+	podConfigurator *podConfigurator
+	ifaceStore      interfacestore.InterfaceStore
+	containerAccess *containerAccessArbitrator
+	isChaining      bool
+	// defaultConnectionLimit is the connection limit of the Pods which don't override it with
+	// the PodConnectionLimitAnnotationKey annotation. 0 means that their connections are not limited.
+	defaultConnectionLimit uint32
+	gracePeriod            time.Duration
+	// staleSince records, for each container ID, when its interface was first found without a
+	// running Pod. It is only accessed by the reconciling goroutine.
+	staleSince map[string]time.Time
+}
+
+func newPodMonitor(
+	kubeClient clientset.Interface,
+	nodeName string,
+	podConfigurator *podConfigurator,
+	ifaceStore interfacestore.InterfaceStore,
+	containerAccess *containerAccessArbitrator,
+	isChaining bool,
+	defaultConnectionLimit uint32,
+) *podMonitor {
+	// Watch only the Pods which belong to the Node where the agent is running.
+	podInformer := coreinformers.NewFilteredPodInformer(
+		kubeClient,
+		metav1.NamespaceAll,
+		podResyncPeriod,
+		cache.Indexers{},
+		func(options *metav1.ListOptions) {
+			options.FieldSelector = fields.OneTermEqualSelector("spec.nodeName", nodeName).String()
+		},
+	)
+	return &podMonitor{
+		kubeClient:             kubeClient,
+		podInformer:            podInformer,
+		podLister:              corelisters.NewPodLister(podInformer.GetIndexer()),
+		podListerSynced:        podInformer.HasSynced,
+		podConfigurator:        podConfigurator,
+		ifaceStore:             ifaceStore,
+		containerAccess:        containerAccess,
+		isChaining:             isChaining,
+		defaultConnectionLimit: defaultConnectionLimit,
+		gracePeriod:            staleInterfaceGracePeriod,
+		staleSince:             make(map[string]time.Time),
+	}
+}
+
+func (m *podMonitor) Run(stopCh <-chan struct{}) {
+	klog.Info("Starting Pod monitor")
+	defer klog.Info("Shutting down Pod monitor")
+
+	go m.podInformer.Run(stopCh)
+	if !cache.WaitForNamedCacheSync("Pod monitor", stopCh, m.podListerSynced) {
+		return
+	}
+	wait.Until(m.removeStaleInterfaces, staleInterfaceCheckInterval, stopCh)
+}
+
+// isPodRunning returns whether the Pod with the provided Namespace and name exists and has not
+// terminated.
+func (m *podMonitor) isPodRunning(namespace, name string) bool {
+	pod, err := m.podLister.Pods(namespace).Get(name)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			klog.Errorf("Failed to get Pod %s: %v", k8s.NamespacedName(namespace, name), err)
+			// Consider the Pod as running to be safe.
+			return true
+		}
+		return false
+	}
+	return pod.Status.Phase != corev1.PodSucceeded && pod.Status.Phase != corev1.PodFailed
+}
+
+// isDHCPPod returns whether the Pod with the provided Namespace and name obtains its addresses
+// using DHCP. The Pod is retrieved from the API if it is not in the informer cache yet, as the
+// network of a Pod can be set up before the informer is notified of the Pod.
+func (m *podMonitor) isDHCPPod(namespace, name string) bool {
+	pod, err := m.podLister.Pods(namespace).Get(name)
+	if err != nil {
+		pod, err = m.kubeClient.CoreV1().Pods(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+		if err != nil {
+			klog.Errorf("Failed to get Pod %s, assuming it does not use DHCP: %v", k8s.NamespacedName(namespace, name), err)
+			return false
+		}
+	}
+	return pod.Annotations[types.PodDHCPAnnotationKey] == "true"
+}
+
+// podConnectionLimit returns the connection limit of the Pod with the provided Namespace and name,
+// which is read from its PodConnectionLimitAnnotationKey annotation if it's set, or the default
+// limit otherwise. Like isDHCPPod, the Pod is retrieved from the API if it is not in the informer
+// cache yet.
+func (m *podMonitor) podConnectionLimit(namespace, name string) uint32 {
+	pod, err := m.podLister.Pods(namespace).Get(name)
+	if err != nil {
+		pod, err = m.kubeClient.CoreV1().Pods(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+		if err != nil {
+			klog.Errorf("Failed to get Pod %s, using the default connection limit: %v", k8s.NamespacedName(namespace, name), err)
+			return m.defaultConnectionLimit
+		}
+	}
+	value, ok := pod.Annotations[types.PodConnectionLimitAnnotationKey]
+	if !ok {
+		return m.defaultConnectionLimit
+	}
+	limit, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		klog.Errorf("Invalid connection limit %q for Pod %s, using the default connection limit: %v", value, k8s.NamespacedName(namespace, name), err)
+		return m.defaultConnectionLimit
+	}
+	return uint32(limit)
+}
+
+// removeStaleInterfaces removes the interfaces whose Pod has not been running for more than the
+// grace period.
+func (m *podMonitor) removeStaleInterfaces() {
+	now := time.Now()
+	staleSince := make(map[string]time.Time)
+	for _, containerConfig := range m.ifaceStore.GetInterfacesByType(interfacestore.ContainerInterface) {
+		containerID := containerConfig.ContainerID
+		if m.isPodRunning(containerConfig.PodNamespace, containerConfig.PodName) {
+			continue
+		}
+		since, ok := m.staleSince[containerID]
+		if !ok {
+			since = now
+		}
+		if now.Sub(since) < m.gracePeriod {
+			staleSince[containerID] = since
+			continue
+		}
+		if err := m.removeStaleInterface(containerID); err != nil {
+			klog.Errorf("Failed to remove stale interface %s of container %s: %v", containerConfig.InterfaceName, containerID, err)
+			// Retry in the next check.
+			staleSince[containerID] = since
+		}
+	}
+	// Forget the containers which have been removed or whose Pod is running again.
+	m.staleSince = staleSince
+}
+
+// removeStaleInterface removes the interface of a container while holding the container's lock,
+// so that it can not race with an in-flight CNI request for the same container. The interface and
+// the Pod are checked again after getting the lock, as they may have changed in the meantime.
+func (m *podMonitor) removeStaleInterface(containerID string) error {
+	m.containerAccess.lockContainer(containerID)
+	defer m.containerAccess.unlockContainer(containerID)
+
+	containerConfig, found := m.ifaceStore.GetContainerInterface(containerID)
+	if !found || m.isPodRunning(containerConfig.PodNamespace, containerConfig.PodName) {
+		return nil
+	}
+	klog.Infof("Removing stale interface %s of container %s for Pod %s which is no longer running", containerConfig.InterfaceName,
+		containerID, k8s.NamespacedName(containerConfig.PodNamespace, containerConfig.PodName))
+	if m.isChaining {
+		return m.podConfigurator.disconnectInterceptedInterface(containerConfig.PodName, containerConfig.PodNamespace, containerID)
+	}
+	return m.podConfigurator.removeInterfaces(containerID)
+}
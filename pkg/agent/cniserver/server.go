@@ -106,8 +106,14 @@ type CNIServer struct {
 	kubeClient           clientset.Interface
 	containerAccess      *containerAccessArbitrator
 	podConfigurator      *podConfigurator
+	podMonitor           *podMonitor
 	isChaining           bool
-	routeClient          route.Interface
+	// interfaceDiscovery is how the interfaces of the Pods are discovered when isChaining is true.
+	interfaceDiscovery InterfaceDiscovery
+	routeClient        route.Interface
+	// defaultPodConnectionLimit is the connection limit of the Pods which don't override it with
+	// an annotation. 0 means that their connections are not limited.
+	defaultPodConnectionLimit uint32
 	// networkReadyCh notifies that the network is ready so new Pods can be created. Therefore, CmdAdd waits for it.
 	networkReadyCh <-chan struct{}
 }
@@ -373,14 +379,17 @@ func (s *CNIServer) validatePrevResult(cfgArgs *cnipb.CniCmdArgs, k8sCNIArgs *k8
 
 func (s *CNIServer) CmdAdd(ctx context.Context, request *cnipb.CniCmdRequest) (*cnipb.CniCmdResponse, error) {
 	klog.Infof("Received CmdAdd request %v", request)
+	defer observePhase(cniCommandAdd, phaseTotal, time.Now())
 	cniConfig, response := s.checkRequestMessage(request)
 	if response != nil {
+		recordFailure(cniCommandAdd, reasonInvalidRequest)
 		return response, nil
 	}
 
 	select {
 	case <-time.After(networkReadyTimeout):
 		klog.Errorf("Cannot process CmdAdd request for container %v because network is not ready", cniConfig.ContainerId)
+		recordFailure(cniCommandAdd, reasonNetworkNotReady)
 		return s.tryAgainLaterResponse(), nil
 	case <-s.networkReadyCh:
 	}
@@ -414,6 +423,11 @@ func (s *CNIServer) CmdAdd(ctx context.Context, request *cnipb.CniCmdRequest) (*
 		if err == nil {
 			success = true
 		}
+		if err != nil {
+			recordFailure(cniCommandAdd, failureReasonFromError(err, reasonInterface))
+		} else if resp.Error != nil {
+			recordFailure(cniCommandAdd, reasonInvalidRequest)
+		}
 		return resp, err
 	}
 
@@ -423,13 +437,17 @@ func (s *CNIServer) CmdAdd(ctx context.Context, request *cnipb.CniCmdRequest) (*
 	// On windows platform, CNI plugin is called for all containers in a Pod.
 	if !isInfraContainer {
 		if ipamResult, _ = ipam.GetIPFromCache(infraContainer); ipamResult == nil {
+			recordFailure(cniCommandAdd, reasonIPAM)
 			return nil, fmt.Errorf("allocated IP address not found")
 		}
 	} else {
 		// Request IP Address from IPAM driver.
+		ipamStart := time.Now()
 		ipamResult, err = ipam.ExecIPAMAdd(cniConfig.CniCmdArgs, cniConfig.IPAM.Type, infraContainer)
+		observePhase(cniCommandAdd, phaseIPAM, ipamStart)
 		if err != nil {
 			klog.Errorf("Failed to request IP addresses for container %v: %v", cniConfig.ContainerId, err)
+			recordFailure(cniCommandAdd, reasonIPAM)
 			return s.ipamFailureResponse(err), nil
 		}
 	}
@@ -455,6 +473,7 @@ func (s *CNIServer) CmdAdd(ctx context.Context, request *cnipb.CniCmdRequest) (*
 		s.containerAccess,
 	); err != nil {
 		klog.Errorf("Failed to configure interfaces for container %s: %v", cniConfig.ContainerId, err)
+		recordFailure(cniCommandAdd, failureReasonFromError(err, reasonInterface))
 		return s.configInterfaceFailureResponse(err), nil
 	}
 
@@ -467,9 +486,11 @@ func (s *CNIServer) CmdAdd(ctx context.Context, request *cnipb.CniCmdRequest) (*
 func (s *CNIServer) CmdDel(_ context.Context, request *cnipb.CniCmdRequest) (
 	*cnipb.CniCmdResponse, error) {
 	klog.Infof("Received CmdDel request %v", request)
+	defer observePhase(cniCommandDel, phaseTotal, time.Now())
 
 	cniConfig, response := s.checkRequestMessage(request)
 	if response != nil {
+		recordFailure(cniCommandDel, reasonInvalidRequest)
 		return response, nil
 	}
 
@@ -478,17 +499,26 @@ func (s *CNIServer) CmdDel(_ context.Context, request *cnipb.CniCmdRequest) (
 	defer s.containerAccess.unlockContainer(infraContainer)
 
 	if s.isChaining {
-		return s.interceptDel(cniConfig)
+		resp, err := s.interceptDel(cniConfig)
+		if err != nil {
+			recordFailure(cniCommandDel, failureReasonFromError(err, reasonInterface))
+		}
+		return resp, err
 	}
 	// Release IP to IPAM driver
-	if err := ipam.ExecIPAMDelete(cniConfig.CniCmdArgs, cniConfig.IPAM.Type, infraContainer); err != nil {
+	ipamStart := time.Now()
+	err := ipam.ExecIPAMDelete(cniConfig.CniCmdArgs, cniConfig.IPAM.Type, infraContainer)
+	observePhase(cniCommandDel, phaseIPAM, ipamStart)
+	if err != nil {
 		klog.Errorf("Failed to delete IP addresses for container %v: %v", cniConfig.ContainerId, err)
+		recordFailure(cniCommandDel, reasonIPAM)
 		return s.ipamFailureResponse(err), nil
 	}
 	klog.Infof("Deleted IP addresses for container %v", cniConfig.ContainerId)
 	// Remove host interface and OVS configuration
 	if err := s.podConfigurator.removeInterfaces(cniConfig.ContainerId); err != nil {
 		klog.Errorf("Failed to remove interfaces for container %s: %v", cniConfig.ContainerId, err)
+		recordFailure(cniCommandDel, failureReasonFromError(err, reasonInterface))
 		return s.configInterfaceFailureResponse(err), nil
 	}
 	klog.Infof("CmdDel for container %v succeeded", cniConfig.ContainerId)
@@ -534,26 +564,30 @@ func New(
 	nodeConfig *config.NodeConfig,
 	kubeClient clientset.Interface,
 	isChaining bool,
+	interfaceDiscovery InterfaceDiscovery,
 	routeClient route.Interface,
+	defaultPodConnectionLimit uint32,
 	networkReadyCh <-chan struct{},
 ) *CNIServer {
 	return &CNIServer{
-		cniSocket:            cniSocket,
-		supportedCNIVersions: supportedCNIVersionSet,
-		serverVersion:        cni.AntreaCNIVersion,
-		nodeConfig:           nodeConfig,
-		hostProcPathPrefix:   hostProcPathPrefix,
-		kubeClient:           kubeClient,
-		containerAccess:      newContainerAccessArbitrator(),
-		isChaining:           isChaining,
-		routeClient:          routeClient,
-		networkReadyCh:       networkReadyCh,
+		cniSocket:                 cniSocket,
+		supportedCNIVersions:      supportedCNIVersionSet,
+		serverVersion:             cni.AntreaCNIVersion,
+		nodeConfig:                nodeConfig,
+		hostProcPathPrefix:        hostProcPathPrefix,
+		kubeClient:                kubeClient,
+		containerAccess:           newContainerAccessArbitrator(),
+		isChaining:                isChaining,
+		interfaceDiscovery:        interfaceDiscovery,
+		routeClient:               routeClient,
+		defaultPodConnectionLimit: defaultPodConnectionLimit,
+		networkReadyCh:            networkReadyCh,
 	}
 }
 
 func (s *CNIServer) Initialize(
 	ovsBridgeClient ovsconfig.OVSBridgeClient,
-	ofClient openflow.Client,
+	ofClient openflow.PodConnectivityClient,
 	ifaceStore interfacestore.InterfaceStore,
 	entityUpdates chan<- types.EntityReference,
 ) error {
@@ -568,6 +602,10 @@ func (s *CNIServer) Initialize(
 	if err := s.reconcile(); err != nil {
 		return fmt.Errorf("error during initial reconciliation for CNI server: %v", err)
 	}
+	s.podMonitor = newPodMonitor(s.kubeClient, s.nodeConfig.Name, s.podConfigurator, ifaceStore, s.containerAccess, s.isChaining, s.defaultPodConnectionLimit)
+	s.podConfigurator.isDHCPPod = s.podMonitor.isDHCPPod
+	s.podConfigurator.podConnectionLimit = s.podMonitor.podConnectionLimit
+	s.podConfigurator.interfaceDiscovery = s.interfaceDiscovery
 	return nil
 }
 
@@ -588,6 +626,8 @@ func (s *CNIServer) Run(stopCh <-chan struct{}) {
 			klog.Errorf("Failed to serve connections: %v", err)
 		}
 	}()
+	// Remove the interfaces of the Pods for which CNI DEL is never received.
+	go s.podMonitor.Run(stopCh)
 	<-stopCh
 }
 
@@ -609,7 +649,7 @@ func (s *CNIServer) interceptAdd(cniConfig *CNIConfig) (*cnipb.CniCmdResponse, e
 		cniConfig.ContainerId,
 		s.hostNetNsPath(cniConfig.Netns),
 		cniConfig.Ifname,
-		prevResult.IPs,
+		prevResult,
 		s.containerAccess); err != nil {
 		return &cnipb.CniCmdResponse{CniResult: []byte("")}, fmt.Errorf("failed to connect container %s to ovs: %w", cniConfig.ContainerId, err)
 	}
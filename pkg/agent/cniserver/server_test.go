@@ -31,12 +31,17 @@ import (
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/component-base/metrics/testutil"
 
 	"antrea.io/antrea/pkg/agent/cniserver/ipam"
 	ipamtest "antrea.io/antrea/pkg/agent/cniserver/ipam/testing"
 	cniservertest "antrea.io/antrea/pkg/agent/cniserver/testing"
 	"antrea.io/antrea/pkg/agent/config"
 	"antrea.io/antrea/pkg/agent/interfacestore"
+	"antrea.io/antrea/pkg/agent/metrics"
 	openflowtest "antrea.io/antrea/pkg/agent/openflow/testing"
 	antreatypes "antrea.io/antrea/pkg/agent/types"
 	"antrea.io/antrea/pkg/agent/util"
@@ -416,7 +421,7 @@ func TestRemoveInterface(t *testing.T) {
 	controller := gomock.NewController(t)
 	defer controller.Finish()
 	mockOVSBridgeClient := ovsconfigtest.NewMockOVSBridgeClient(controller)
-	mockOFClient := openflowtest.NewMockClient(controller)
+	mockOFClient := openflowtest.NewMockPodConnectivityClient(controller)
 	ifaceStore := interfacestore.NewInterfaceStore()
 	gwMAC, _ := net.ParseMAC("00:00:11:11:11:11")
 	podConfigurator, err := newPodConfigurator(mockOVSBridgeClient, mockOFClient, nil, ifaceStore, gwMAC, "system", false, make(chan antreatypes.EntityReference, 100))
@@ -493,6 +498,244 @@ func TestRemoveInterface(t *testing.T) {
 	})
 }
 
+func TestRemoveStaleInterfaces(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+	mockOVSBridgeClient := ovsconfigtest.NewMockOVSBridgeClient(controller)
+	mockOFClient := openflowtest.NewMockPodConnectivityClient(controller)
+	ifaceStore := interfacestore.NewInterfaceStore()
+	gwMAC, _ := net.ParseMAC("00:00:11:11:11:11")
+	podConfigurator, err := newPodConfigurator(mockOVSBridgeClient, mockOFClient, nil, ifaceStore, gwMAC, "system", false, make(chan antreatypes.EntityReference, 100))
+	require.Nil(t, err, "No error expected in podConfigurator constructor")
+	monitor := newPodMonitor(fake.NewSimpleClientset(), "node1", podConfigurator, ifaceStore, newContainerAccessArbitrator(), false, 0)
+
+	containerMAC, _ := net.ParseMAC("aa:bb:cc:dd:ee:ff")
+	addInterface := func(podName string, ip string) *interfacestore.InterfaceConfig {
+		containerID := uuid.New().String()
+		containerConfig := interfacestore.NewContainerInterface(
+			util.GenerateContainerInterfaceName(podName, testPodNamespace, containerID),
+			containerID,
+			podName,
+			testPodNamespace,
+			containerMAC,
+			[]net.IP{net.ParseIP(ip)})
+		containerConfig.OVSPortConfig = &interfacestore.OVSPortConfig{PortUUID: uuid.New().String(), OFPort: 0}
+		ifaceStore.AddInterface(containerConfig)
+		return containerConfig
+	}
+	addPod := func(name string, phase corev1.PodPhase) {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testPodNamespace},
+			Status:     corev1.PodStatus{Phase: phase},
+		}
+		monitor.podInformer.GetIndexer().Add(pod)
+	}
+
+	runningConfig := addInterface("running", "1.1.1.1")
+	addPod("running", corev1.PodRunning)
+	succeededConfig := addInterface("succeeded", "1.1.1.2")
+	addPod("succeeded", corev1.PodSucceeded)
+	deletedConfig := addInterface("deleted", "1.1.1.3")
+
+	// The interfaces are not removed before the grace period expires.
+	monitor.removeStaleInterfaces()
+	assert.Len(t, monitor.staleSince, 2)
+	assert.Len(t, ifaceStore.GetContainerInterfacesByPod("deleted", testPodNamespace), 1)
+
+	// The interface is kept if the Pod shows up before the grace period expires.
+	addPod("deleted", corev1.PodRunning)
+	monitor.removeStaleInterfaces()
+	assert.Len(t, monitor.staleSince, 1)
+	monitor.podInformer.GetIndexer().Delete(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "deleted", Namespace: testPodNamespace}})
+
+	monitor.gracePeriod = 0
+	for _, staleConfig := range []*interfacestore.InterfaceConfig{succeededConfig, deletedConfig} {
+		mockOFClient.EXPECT().UninstallPodFlows(staleConfig.InterfaceName).Return(nil)
+		mockOVSBridgeClient.EXPECT().DeletePort(staleConfig.PortUUID).Return(nil)
+	}
+	monitor.removeStaleInterfaces()
+	assert.Empty(t, monitor.staleSince)
+	_, found := ifaceStore.GetContainerInterface(runningConfig.ContainerID)
+	assert.True(t, found, "Interface of running Pod should be kept")
+	_, found = ifaceStore.GetContainerInterface(succeededConfig.ContainerID)
+	assert.False(t, found, "Interface of terminated Pod should be removed")
+	_, found = ifaceStore.GetContainerInterface(deletedConfig.ContainerID)
+	assert.False(t, found, "Interface of deleted Pod should be removed")
+}
+
+func getFailureCount(t *testing.T, command cniCommand, reason failureReason) float64 {
+	count, err := testutil.GetCounterMetricValue(metrics.CNICmdFailureCount.WithLabelValues(string(command), string(reason)))
+	require.NoError(t, err)
+	return count
+}
+
+func TestCNIFailureMetrics(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+	ipamType := "metrics-test"
+	ipamMock := ipamtest.NewMockIPAMDriver(controller)
+	require.NoError(t, ipam.RegisterIPAMDriver(ipamType, ipamMock))
+	mockOVSBridgeClient := ovsconfigtest.NewMockOVSBridgeClient(controller)
+	mockOFClient := openflowtest.NewMockPodConnectivityClient(controller)
+	ifaceStore := interfacestore.NewInterfaceStore()
+	gwMAC, _ := net.ParseMAC("00:00:11:11:11:11")
+	podConfigurator, err := newPodConfigurator(mockOVSBridgeClient, mockOFClient, nil, ifaceStore, gwMAC, "system", false, make(chan antreatypes.EntityReference, 100))
+	require.Nil(t, err, "No error expected in podConfigurator constructor")
+	cniServer := newCNIServer(t)
+	cniServer.podConfigurator = podConfigurator
+
+	ctx := context.Background()
+	networkCfg := generateNetworkConfiguration("testCfg", supportedCNIVersion)
+	networkCfg.IPAM.Type = ipamType
+	containerMAC, _ := net.ParseMAC("aa:bb:cc:dd:ee:ff")
+	addInterface := func(containerID string) *interfacestore.InterfaceConfig {
+		containerConfig := interfacestore.NewContainerInterface(
+			util.GenerateContainerInterfaceName(testPodName, testPodNamespace, containerID),
+			containerID,
+			testPodName,
+			testPodNamespace,
+			containerMAC,
+			[]net.IP{net.ParseIP("1.1.1.1")})
+		containerConfig.OVSPortConfig = &interfacestore.OVSPortConfig{PortUUID: uuid.New().String(), OFPort: 0}
+		ifaceStore.AddInterface(containerConfig)
+		return containerConfig
+	}
+
+	t.Run("Invalid request on ADD", func(t *testing.T) {
+		requestMsg, _ := newRequest(args, generateNetworkConfiguration("testCfg", unsupportedCNIVersion), "", t)
+		before := getFailureCount(t, cniCommandAdd, reasonInvalidRequest)
+		_, err := cniServer.CmdAdd(ctx, &requestMsg)
+		require.NoError(t, err)
+		assert.Equal(t, before+1, getFailureCount(t, cniCommandAdd, reasonInvalidRequest))
+	})
+
+	t.Run("IPAM failure on ADD", func(t *testing.T) {
+		requestMsg, _ := newRequest(args, networkCfg, "", t)
+		ipamMock.EXPECT().Add(gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("IPAM add error"))
+		ipamMock.EXPECT().Del(gomock.Any(), gomock.Any()).Return(nil)
+		before := getFailureCount(t, cniCommandAdd, reasonIPAM)
+		_, err := cniServer.CmdAdd(ctx, &requestMsg)
+		require.NoError(t, err)
+		assert.Equal(t, before+1, getFailureCount(t, cniCommandAdd, reasonIPAM))
+	})
+
+	t.Run("IPAM failure on DEL", func(t *testing.T) {
+		requestMsg, _ := newRequest(args, networkCfg, "", t)
+		ipamMock.EXPECT().Del(gomock.Any(), gomock.Any()).Return(fmt.Errorf("IPAM delete error"))
+		before := getFailureCount(t, cniCommandDel, reasonIPAM)
+		_, err := cniServer.CmdDel(ctx, &requestMsg)
+		require.NoError(t, err)
+		assert.Equal(t, before+1, getFailureCount(t, cniCommandDel, reasonIPAM))
+	})
+
+	t.Run("Flows failure on DEL", func(t *testing.T) {
+		requestMsg, containerID := newRequest(args, networkCfg, "", t)
+		containerConfig := addInterface(containerID)
+		ipamMock.EXPECT().Del(gomock.Any(), gomock.Any()).Return(nil)
+		mockOFClient.EXPECT().UninstallPodFlows(containerConfig.InterfaceName).Return(fmt.Errorf("failed to delete openflow entry"))
+		before := getFailureCount(t, cniCommandDel, reasonFlows)
+		_, err := cniServer.CmdDel(ctx, &requestMsg)
+		require.NoError(t, err)
+		assert.Equal(t, before+1, getFailureCount(t, cniCommandDel, reasonFlows))
+	})
+
+	t.Run("OVS port failure on DEL", func(t *testing.T) {
+		requestMsg, containerID := newRequest(args, networkCfg, "", t)
+		containerConfig := addInterface(containerID)
+		ipamMock.EXPECT().Del(gomock.Any(), gomock.Any()).Return(nil)
+		mockOFClient.EXPECT().UninstallPodFlows(containerConfig.InterfaceName).Return(nil)
+		mockOVSBridgeClient.EXPECT().DeletePort(containerConfig.PortUUID).Return(ovsconfig.NewTransactionError(fmt.Errorf("error while deleting OVS port"), true))
+		before := getFailureCount(t, cniCommandDel, reasonOVSPort)
+		_, err := cniServer.CmdDel(ctx, &requestMsg)
+		require.NoError(t, err)
+		assert.Equal(t, before+1, getFailureCount(t, cniCommandDel, reasonOVSPort))
+	})
+
+	newContainerConfig := func() *interfacestore.InterfaceConfig {
+		containerID := uuid.New().String()
+		return interfacestore.NewContainerInterface(
+			util.GenerateContainerInterfaceName(testPodName, testPodNamespace, containerID),
+			containerID,
+			testPodName,
+			testPodNamespace,
+			containerMAC,
+			[]net.IP{net.ParseIP("1.1.1.1")})
+	}
+
+	t.Run("OVS port failure on ADD", func(t *testing.T) {
+		containerConfig := newContainerConfig()
+		mockOVSBridgeClient.EXPECT().CreatePort(containerConfig.InterfaceName, containerConfig.InterfaceName, gomock.Any()).Return("", ovsconfig.NewTransactionError(fmt.Errorf("error while creating OVS port"), true))
+		err := podConfigurator.connectInterfaceToOVSCommon(containerConfig.InterfaceName, containerConfig)
+		require.Error(t, err)
+		assert.Equal(t, reasonOVSPort, failureReasonFromError(err, reasonInterface))
+	})
+
+	t.Run("Flows failure on ADD", func(t *testing.T) {
+		containerConfig := newContainerConfig()
+		portUUID := uuid.New().String()
+		mockOVSBridgeClient.EXPECT().CreatePort(containerConfig.InterfaceName, containerConfig.InterfaceName, gomock.Any()).Return(portUUID, nil)
+		mockOVSBridgeClient.EXPECT().GetOFPort(containerConfig.InterfaceName).Return(int32(10), nil)
+		mockOFClient.EXPECT().InstallPodFlows(containerConfig.InterfaceName, containerConfig.IPs, containerConfig.MAC, uint32(10)).Return(fmt.Errorf("failed to add openflow entry"))
+		mockOVSBridgeClient.EXPECT().DeletePort(portUUID).Return(nil)
+		err := podConfigurator.connectInterfaceToOVSCommon(containerConfig.InterfaceName, containerConfig)
+		require.Error(t, err)
+		assert.Equal(t, reasonFlows, failureReasonFromError(err, reasonInterface))
+	})
+
+	t.Run("DHCP flows failure on ADD", func(t *testing.T) {
+		podConfigurator.isDHCPPod = func(podNamespace, podName string) bool { return true }
+		defer func() { podConfigurator.isDHCPPod = nil }()
+		containerConfig := newContainerConfig()
+		portUUID := uuid.New().String()
+		mockOVSBridgeClient.EXPECT().CreatePort(containerConfig.InterfaceName, containerConfig.InterfaceName, gomock.Any()).Return(portUUID, nil)
+		mockOVSBridgeClient.EXPECT().GetOFPort(containerConfig.InterfaceName).Return(int32(10), nil)
+		mockOFClient.EXPECT().InstallPodFlows(containerConfig.InterfaceName, containerConfig.IPs, containerConfig.MAC, uint32(10)).Return(nil)
+		mockOFClient.EXPECT().InstallPodDHCPFlows(containerConfig.InterfaceName, containerConfig.MAC, uint32(10)).Return(fmt.Errorf("failed to add openflow entry"))
+		mockOFClient.EXPECT().UninstallPodFlows(containerConfig.InterfaceName).Return(nil)
+		mockOVSBridgeClient.EXPECT().DeletePort(portUUID).Return(nil)
+		err := podConfigurator.connectInterfaceToOVSCommon(containerConfig.InterfaceName, containerConfig)
+		require.Error(t, err)
+		assert.Equal(t, reasonFlows, failureReasonFromError(err, reasonInterface))
+		assert.True(t, containerConfig.DHCP)
+	})
+
+	t.Run("Connection limit flows failure on ADD", func(t *testing.T) {
+		podConfigurator.podConnectionLimit = func(podNamespace, podName string) uint32 { return 1000 }
+		defer func() { podConfigurator.podConnectionLimit = nil }()
+		containerConfig := newContainerConfig()
+		portUUID := uuid.New().String()
+		mockOVSBridgeClient.EXPECT().CreatePort(containerConfig.InterfaceName, containerConfig.InterfaceName, gomock.Any()).Return(portUUID, nil)
+		mockOVSBridgeClient.EXPECT().GetOFPort(containerConfig.InterfaceName).Return(int32(10), nil)
+		mockOFClient.EXPECT().InstallPodFlows(containerConfig.InterfaceName, containerConfig.IPs, containerConfig.MAC, uint32(10)).Return(nil)
+		mockOFClient.EXPECT().InstallPodConnectionLimitFlows(containerConfig.InterfaceName, testPodNamespace, containerConfig.PodName, uint32(10), uint32(1000)).Return(fmt.Errorf("failed to set conntrack limit"))
+		mockOFClient.EXPECT().UninstallPodFlows(containerConfig.InterfaceName).Return(nil)
+		mockOVSBridgeClient.EXPECT().DeletePort(portUUID).Return(nil)
+		err := podConfigurator.connectInterfaceToOVSCommon(containerConfig.InterfaceName, containerConfig)
+		require.Error(t, err)
+		assert.Equal(t, reasonFlows, failureReasonFromError(err, reasonInterface))
+		assert.Equal(t, uint32(1000), containerConfig.ConnectionLimit)
+	})
+}
+
+func TestPodConnectionLimit(t *testing.T) {
+	monitor := newPodMonitor(fake.NewSimpleClientset(), "node1", nil, nil, newContainerAccessArbitrator(), false, 1000)
+	for name, annotation := range map[string]string{"custom": "50", "unlimited": "0", "invalid": "-1"} {
+		monitor.podInformer.GetIndexer().Add(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   testPodNamespace,
+			Annotations: map[string]string{antreatypes.PodConnectionLimitAnnotationKey: annotation},
+		}})
+	}
+	monitor.podInformer.GetIndexer().Add(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "default", Namespace: testPodNamespace}})
+
+	assert.Equal(t, uint32(50), monitor.podConnectionLimit(testPodNamespace, "custom"))
+	assert.Equal(t, uint32(0), monitor.podConnectionLimit(testPodNamespace, "unlimited"))
+	assert.Equal(t, uint32(1000), monitor.podConnectionLimit(testPodNamespace, "invalid"))
+	assert.Equal(t, uint32(1000), monitor.podConnectionLimit(testPodNamespace, "default"))
+	// The default limit is used for a Pod which cannot be retrieved.
+	assert.Equal(t, uint32(1000), monitor.podConnectionLimit(testPodNamespace, "missing"))
+}
+
 func TestBuildOVSPortExternalIDs(t *testing.T) {
 	containerID := uuid.New().String()
 	containerMAC, _ := net.ParseMAC("aa:bb:cc:dd:ee:ff")
@@ -500,6 +743,8 @@ func TestBuildOVSPortExternalIDs(t *testing.T) {
 	containerIP2 := net.ParseIP("2001:fd1a::2")
 	containerIPs := []net.IP{containerIP1, containerIP2}
 	containerConfig := interfacestore.NewContainerInterface("pod1-abcd", containerID, "test-1", "t1", containerMAC, containerIPs)
+	containerConfig.DHCP = true
+	containerConfig.ConnectionLimit = 1000
 	externalIds := BuildOVSPortExternalIDs(containerConfig)
 	parsedIP, existed := externalIds[ovsExternalIDIP]
 	parsedIPStr := parsedIP.(string)
@@ -539,6 +784,8 @@ func TestBuildOVSPortExternalIDs(t *testing.T) {
 		}
 		assert.True(t, existed, fmt.Sprintf("IP %s should exist in the restored InterfaceConfig", ip1.String()))
 	}
+	assert.True(t, ifaceConfig.DHCP)
+	assert.Equal(t, uint32(1000), ifaceConfig.ConnectionLimit)
 }
 
 func translateRawPrevResult(prevResult *current.Result, cniVersion string) (map[string]interface{}, error) {
@@ -619,4 +866,5 @@ func init() {
 	gwMAC, _ := net.ParseMAC("00:00:00:00:00:01")
 	gateway := &config.GatewayConfig{Name: "", IPv4: gwIPv4, IPv6: gwIPv6, MAC: gwMAC}
 	testNodeConfig = &config.NodeConfig{Name: nodeName, PodIPv4CIDR: nodePodCIDRv4, PodIPv6CIDR: nodePodCIDRv6, GatewayConfig: gateway}
+	metrics.InitializeCNIMetrics()
 }
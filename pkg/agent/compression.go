@@ -0,0 +1,96 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+
+	"antrea.io/antrea/pkg/agent/metrics"
+)
+
+// gzipRoundTripper requests the responses of the Antrea Controller to be compressed with gzip and
+// decompresses them. It is used instead of the transparent compression of http.Transport to
+// report the compressed and raw sizes of the responses.
+type gzipRoundTripper struct {
+	rt http.RoundTripper
+}
+
+func newGzipRoundTripper(rt http.RoundTripper) http.RoundTripper {
+	return &gzipRoundTripper{rt: rt}
+}
+
+func (t *gzipRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = utilnet.CloneRequest(req)
+	req.Header.Set("Accept-Encoding", "gzip")
+	resp, err := t.rt.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Header.Get("Content-Encoding") != "gzip" {
+		return resp, nil
+	}
+	resp.Body = &gzipReader{body: resp.Body}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+	return resp, nil
+}
+
+func (t *gzipRoundTripper) WrappedRoundTripper() http.RoundTripper {
+	return t.rt
+}
+
+// countingReader counts the bytes read from the wrapped Reader.
+type countingReader struct {
+	io.Reader
+}
+
+func (r countingReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	metrics.ControlplaneCompressedResponseBytes.Add(float64(n))
+	return n, err
+}
+
+// gzipReader decompresses the response body. The gzip.Reader is created lazily on the first read
+// as it reads the gzip header, which would block the caller until the first watch event is sent.
+type gzipReader struct {
+	body io.ReadCloser
+	zr   *gzip.Reader
+	err  error
+}
+
+func (r *gzipReader) Read(p []byte) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+	if r.zr == nil {
+		r.zr, r.err = gzip.NewReader(countingReader{r.body})
+		if r.err != nil {
+			return 0, r.err
+		}
+	}
+	n, err := r.zr.Read(p)
+	metrics.ControlplaneRawResponseBytes.Add(float64(n))
+	return n, err
+}
+
+func (r *gzipReader) Close() error {
+	return r.body.Close()
+}
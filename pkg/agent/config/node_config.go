@@ -18,6 +18,8 @@ import (
 	"fmt"
 	"net"
 
+	"k8s.io/apimachinery/pkg/labels"
+
 	"antrea.io/antrea/pkg/ovs/ovsconfig"
 )
 
@@ -43,6 +45,11 @@ const (
 	IPv6ExtraOverhead = 20
 )
 
+// VirtualServiceIPv4 is the next hop of the route which is installed for the Service CIDR when it is
+// advertised through the host gateway interface. It is a link-local address which is resolved by the
+// OVS pipeline to the global virtual MAC.
+var VirtualServiceIPv4 = net.ParseIP("169.254.169.253").To4()
+
 type GatewayConfig struct {
 	// Name is the name of host gateway, e.g. antrea-gw0.
 	Name string
@@ -102,10 +109,72 @@ func (n *NodeConfig) String() string {
 
 // User provided network configuration parameters.
 type NetworkConfig struct {
-	TrafficEncapMode  TrafficEncapModeType
-	TunnelType        ovsconfig.TunnelType
+	TrafficEncapMode TrafficEncapModeType
+	TunnelType       ovsconfig.TunnelType
+	// TunnelPort is the destination port of the tunnel traffic. 0 means the default port of the
+	// tunnel type.
+	TunnelPort int32
+	// TunnelTOS is the "tos" option of the tunnel ports: the TOS byte of the outer IP header as a
+	// decimal number, or "inherit". It's empty if the TOS of the tunnel traffic is not set.
+	TunnelTOS         string
 	EnableIPSecTunnel bool
 	IPSecPSK          string
+	// GatewayMAC is the MAC address to assign to the host gateway interface. It's nil if
+	// the MAC address should be allocated automatically.
+	GatewayMAC net.HardwareAddr
+	// GatewayIPv4 and GatewayIPv6 are the IP addresses to assign to the host gateway
+	// interface. They are nil if the first address of the PodCIDR should be used.
+	GatewayIPv4 net.IP
+	GatewayIPv6 net.IP
+	// TunnelProfiles are the tunnel profiles for peer Nodes which require specific tunnel
+	// options. A peer Node is selected by the first profile whose NodeSelector matches it.
+	TunnelProfiles []TunnelProfile
+	// AdvertiseServiceCIDR indicates whether the Service CIDR should be routed to the host
+	// gateway interface, so that ClusterIPs can be reached from the Node network through
+	// AntreaProxy. It is only supported in the noEncap mode.
+	AdvertiseServiceCIDR bool
+	// EnableTCPMSSClamping indicates whether the TCP MSS of the connections between local Pods and
+	// the external network should be clamped. TCPMSS is the MSS to set, 0 means it is derived from
+	// the Node MTU.
+	EnableTCPMSSClamping bool
+	TCPMSS               int
+}
+
+// ReservedTunnelOptions are the OVS interface options which are set by Antrea on tunnel ports and
+// cannot be provided by tunnel profiles.
+var ReservedTunnelOptions = []string{"remote_ip", "local_ip", "key", "psk"}
+
+// TunnelConfig returns the description of the tunnel options which must be consistent on all the
+// Nodes. It is published in the NodeTunnelConfigAnnotationKey annotation of the Node, so that the
+// agents can detect the peer Nodes with a different configuration.
+func (nc *NetworkConfig) TunnelConfig() string {
+	tunnelConfig := string(nc.TunnelType)
+	if nc.TunnelPort != 0 {
+		tunnelConfig += fmt.Sprintf(",dst_port=%d", nc.TunnelPort)
+	}
+	if nc.TunnelTOS != "" {
+		tunnelConfig += ",tos=" + nc.TunnelTOS
+	}
+	return tunnelConfig
+}
+
+// TunnelProfile defines the OVS interface options of the tunnel ports dedicated to the peer Nodes
+// selected by NodeSelector.
+type TunnelProfile struct {
+	Name         string
+	NodeSelector labels.Selector
+	Options      map[string]string
+}
+
+// GetTunnelProfile returns the first tunnel profile which selects the Node with the provided
+// labels, and nil if there is none.
+func (nc *NetworkConfig) GetTunnelProfile(nodeLabels map[string]string) *TunnelProfile {
+	for i := range nc.TunnelProfiles {
+		if nc.TunnelProfiles[i].NodeSelector.Matches(labels.Set(nodeLabels)) {
+			return &nc.TunnelProfiles[i]
+		}
+	}
+	return nil
 }
 
 // IsIPv4Enabled returns true if the cluster network supports IPv4.
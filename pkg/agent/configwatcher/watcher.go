@@ -0,0 +1,181 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package configwatcher reloads the configuration of antrea-agent when its configuration file
+// changes, and applies the changes of the options which are safe to change at runtime without
+// restarting the agent.
+package configwatcher
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+
+	agentconfig "antrea.io/antrea/pkg/config/agent"
+)
+
+const (
+	// checkInterval is the interval between two checks of the content of the configuration file.
+	checkInterval = 10 * time.Second
+)
+
+// LoadFunc reads the configuration file, sets the defaults of the options and validates them.
+type LoadFunc func() (*agentconfig.AgentConfig, error)
+
+// ReconfigureFunc applies the values of the options of config to a module of the agent.
+type ReconfigureFunc func(config *agentconfig.AgentConfig) error
+
+type hook struct {
+	name        string
+	options     sets.String
+	reconfigure ReconfigureFunc
+}
+
+// Watcher reloads the configuration file of the agent when its content changes, or when the agent
+// receives SIGHUP. The changes of the dynamic options, listed in agentconfig.DynamicOptions, are
+// applied by the hooks of the modules using them, while the changes of the other options are
+// ignored with a warning until the agent is restarted.
+type Watcher struct {
+	configFile string
+	load       LoadFunc
+	hooks      []hook
+
+	// mutex protects config and configHash.
+	mutex sync.RWMutex
+	// config is the live configuration of the agent: the configuration it was started with, and
+	// the changes of the dynamic options applied since then.
+	config     *agentconfig.AgentConfig
+	configHash string
+}
+
+// NewWatcher creates a Watcher of configFile, from which config was loaded. configHash is the
+// SHA-256 hash of the file config was loaded from.
+func NewWatcher(configFile string, config *agentconfig.AgentConfig, configHash string, load LoadFunc) *Watcher {
+	return &Watcher{
+		configFile: configFile,
+		load:       load,
+		config:     config,
+		configHash: configHash,
+	}
+}
+
+// AddHook registers the function applying the changes of the given dynamic options to a module.
+// It must be called before Run.
+func (w *Watcher) AddHook(name string, options []string, reconfigure ReconfigureFunc) {
+	w.hooks = append(w.hooks, hook{name: name, options: sets.NewString(options...), reconfigure: reconfigure})
+}
+
+// GetAgentConfig returns the live configuration of the agent. It must not be modified.
+func (w *Watcher) GetAgentConfig() *agentconfig.AgentConfig {
+	w.mutex.RLock()
+	defer w.mutex.RUnlock()
+	return w.config
+}
+
+// Run checks the configuration file periodically, and on SIGHUP, until stopCh is closed.
+func (w *Watcher) Run(stopCh <-chan struct{}) {
+	klog.Infof("Starting watching the agent configuration file %s", w.configFile)
+	signalCh := make(chan os.Signal, 1)
+	signal.Notify(signalCh, syscall.SIGHUP)
+	defer signal.Stop(signalCh)
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-signalCh:
+			klog.Info("Received SIGHUP, reloading the agent configuration")
+			hash, err := w.fileHash()
+			if err != nil {
+				klog.Errorf("Failed to read the agent configuration file: %v", err)
+				continue
+			}
+			w.reload(hash)
+		case <-ticker.C:
+			hash, err := w.fileHash()
+			if err != nil {
+				klog.Errorf("Failed to read the agent configuration file: %v", err)
+				continue
+			}
+			w.mutex.RLock()
+			changed := hash != w.configHash
+			w.mutex.RUnlock()
+			if changed {
+				klog.Info("The agent configuration file has changed, reloading it")
+				w.reload(hash)
+			}
+		}
+	}
+}
+
+func (w *Watcher) fileHash() (string, error) {
+	data, err := ioutil.ReadFile(w.configFile)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(data)), nil
+}
+
+// reload loads the configuration file, whose content has the given hash, and applies the changes of
+// its dynamic options with the hooks. The options whose hook fails keep their current values. The
+// current configuration is kept if the file is invalid.
+func (w *Watcher) reload(hash string) {
+	newConfig, err := w.load()
+	if err != nil {
+		klog.Errorf("Failed to reload the agent configuration, keeping the current configuration: %v", err)
+		// Don't reload the same invalid file at every check.
+		w.mutex.Lock()
+		w.configHash = hash
+		w.mutex.Unlock()
+		return
+	}
+	oldConfig := w.GetAgentConfig()
+	var dynamicOptions []string
+	for _, option := range agentconfig.ChangedOptions(oldConfig, newConfig) {
+		if agentconfig.DynamicOptions.Has(option) {
+			dynamicOptions = append(dynamicOptions, option)
+		} else {
+			klog.Warningf("Ignoring the change of option %s, which requires restarting the agent", option)
+		}
+	}
+	config := *oldConfig
+	agentconfig.CopyOptions(&config, newConfig, dynamicOptions)
+	changedOptions := sets.NewString(dynamicOptions...)
+	for _, h := range w.hooks {
+		options := h.options.Intersection(changedOptions).List()
+		if len(options) == 0 {
+			continue
+		}
+		if err := h.reconfigure(&config); err != nil {
+			klog.ErrorS(err, "Failed to apply the changes of the agent configuration", "module", h.name, "options", options)
+			agentconfig.CopyOptions(&config, oldConfig, options)
+			continue
+		}
+		klog.InfoS("Applied the changes of the agent configuration", "module", h.name, "options", options)
+	}
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.config = &config
+	w.configHash = hash
+}
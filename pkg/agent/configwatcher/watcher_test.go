@@ -0,0 +1,91 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package configwatcher
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	agentconfig "antrea.io/antrea/pkg/config/agent"
+)
+
+func TestReload(t *testing.T) {
+	oldConfig := &agentconfig.AgentConfig{
+		OVSBridge:        "br-int",
+		FlowPollInterval: "5s",
+		AuditLogging:     agentconfig.AuditLoggingConfig{Destination: agentconfig.AuditLogDestinationFile},
+		MemoryGuard:      agentconfig.MemoryGuardConfig{Enable: true, Watermark: 90},
+	}
+	newConfig := *oldConfig
+	var loadErr error
+	w := NewWatcher("antrea-agent.conf", oldConfig, "hash1", func() (*agentconfig.AgentConfig, error) {
+		if loadErr != nil {
+			return nil, loadErr
+		}
+		config := newConfig
+		return &config, nil
+	})
+	var flowExporterConfigs, memoryGuardConfigs []*agentconfig.AgentConfig
+	var memoryGuardErr error
+	w.AddHook("flowExporter", []string{"flowPollInterval", "activeFlowExportTimeout"}, func(c *agentconfig.AgentConfig) error {
+		flowExporterConfigs = append(flowExporterConfigs, c)
+		return nil
+	})
+	w.AddHook("memoryGuard", []string{"memoryGuard.watermark"}, func(c *agentconfig.AgentConfig) error {
+		memoryGuardConfigs = append(memoryGuardConfigs, c)
+		return memoryGuardErr
+	})
+
+	// The hooks are not called when their options don't change, and the immutable options keep
+	// their values.
+	newConfig.OVSBridge = "br-test"
+	newConfig.MemoryGuard.Enable = false
+	newConfig.FlowPollInterval = "10s"
+	w.reload("hash2")
+	require.Len(t, flowExporterConfigs, 1)
+	assert.Equal(t, "10s", flowExporterConfigs[0].FlowPollInterval)
+	assert.Empty(t, memoryGuardConfigs)
+	liveConfig := w.GetAgentConfig()
+	assert.Equal(t, "br-int", liveConfig.OVSBridge)
+	assert.True(t, liveConfig.MemoryGuard.Enable)
+	assert.Equal(t, "10s", liveConfig.FlowPollInterval)
+	assert.Equal(t, "hash2", w.configHash)
+	// The previous configuration is not modified.
+	assert.Equal(t, "5s", oldConfig.FlowPollInterval)
+
+	// The options keep their values when their hook fails.
+	memoryGuardErr = errors.New("failed")
+	newConfig.MemoryGuard.Watermark = 80
+	newConfig.AuditLogging.LogDir = "/var/log/audit"
+	w.reload("hash3")
+	assert.Len(t, flowExporterConfigs, 1)
+	require.Len(t, memoryGuardConfigs, 1)
+	assert.Equal(t, 80, memoryGuardConfigs[0].MemoryGuard.Watermark)
+	liveConfig = w.GetAgentConfig()
+	assert.Equal(t, 90, liveConfig.MemoryGuard.Watermark)
+	// The dynamic options without hook are applied.
+	assert.Equal(t, "/var/log/audit", liveConfig.AuditLogging.LogDir)
+
+	// The current configuration is kept when the file is invalid.
+	loadErr = errors.New("invalid configuration")
+	newConfig.FlowPollInterval = "1s"
+	w.reload("hash4")
+	assert.Len(t, flowExporterConfigs, 1)
+	assert.Same(t, liveConfig, w.GetAgentConfig())
+	assert.Equal(t, "hash4", w.configHash)
+}
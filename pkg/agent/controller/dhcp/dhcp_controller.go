@@ -0,0 +1,212 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dhcp
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+
+	"antrea.io/antrea/pkg/agent/interfacestore"
+	"antrea.io/antrea/pkg/agent/openflow"
+	"antrea.io/antrea/pkg/agent/util"
+	"antrea.io/antrea/pkg/util/k8s"
+)
+
+const (
+	controllerName = "AntreaAgentDHCPController"
+	// leaseCheckInterval is the interval between two checks for expired leases.
+	leaseCheckInterval = 10 * time.Second
+)
+
+// lease is an address leased by a DHCP server to a Pod.
+type lease struct {
+	ip net.IP
+	// expiry is the time at which the lease expires if it is not renewed. It is zero for
+	// infinite leases.
+	expiry time.Time
+}
+
+// openflowClient is the part of openflow.Client used by the Controller: it receives the DHCP replies
+// as packet-in messages, and updates the Pod flows.
+type openflowClient interface {
+	openflow.PodConnectivityClient
+	openflow.PacketInClient
+}
+
+// Controller binds the addresses leased with DHCP to the local Pods which obtain their addresses
+// using DHCP. It learns the leases from the DHCP replies sent to these Pods, which are sent to the
+// agent by the flows installed with InstallPodDHCPFlows. When a lease is acknowledged, its address
+// replaces the address of the same family of the Pod in the interface store, and the Pod flows are
+// updated so that the SpoofGuardTable only allows the leased address. When a lease expires without
+// being renewed, or is revoked by the DHCP server, its address is removed from the Pod.
+type Controller struct {
+	ofClient   openflowClient
+	ifaceStore interfacestore.InterfaceStore
+	// leasesMutex protects leases, and serializes the updates of the interfaces of the Pods.
+	leasesMutex sync.Mutex
+	// leases are the leases bound to Pods, keyed by interface name and then by IP family.
+	leases map[string]map[uint8]*lease
+}
+
+// NewController creates a DHCP Controller and registers it as the handler of the DHCP replies sent
+// to the agent.
+func NewController(ofClient openflowClient, ifaceStore interfacestore.InterfaceStore) *Controller {
+	c := &Controller{
+		ofClient:   ofClient,
+		ifaceStore: ifaceStore,
+		leases:     make(map[string]map[uint8]*lease),
+	}
+	c.ofClient.RegisterPacketInHandler(uint8(openflow.PacketInReasonDHCP), "dhcp", c)
+	return c
+}
+
+// Run checks for expired leases periodically until stopCh is closed.
+func (c *Controller) Run(stopCh <-chan struct{}) {
+	klog.Infof("Starting %s", controllerName)
+	defer klog.Infof("Shutting down %s", controllerName)
+
+	wait.Until(func() {
+		c.expireLeases(time.Now())
+	}, leaseCheckInterval, stopCh)
+}
+
+// getDHCPInterface returns the interface of the local Pod using DHCP which has the provided MAC.
+func (c *Controller) getDHCPInterface(mac net.HardwareAddr) (*interfacestore.InterfaceConfig, bool) {
+	for _, iface := range c.ifaceStore.GetInterfacesByType(interfacestore.ContainerInterface) {
+		if iface.DHCP && bytes.Equal(iface.MAC, mac) {
+			return iface, true
+		}
+	}
+	return nil, false
+}
+
+// handleReply updates the leases of the Pod the DHCP reply is sent to. now is the time at which the
+// reply was received.
+func (c *Controller) handleReply(reply *dhcpReply, now time.Time) error {
+	c.leasesMutex.Lock()
+	defer c.leasesMutex.Unlock()
+
+	iface, ok := c.getDHCPInterface(reply.clientMAC)
+	if !ok {
+		klog.V(2).Infof("Ignoring DHCP reply sent to %s, which is not the MAC of a Pod using DHCP", reply.clientMAC)
+		return nil
+	}
+	podName := k8s.NamespacedName(iface.PodNamespace, iface.PodName)
+	podLeases, ok := c.leases[iface.InterfaceName]
+	if !ok {
+		podLeases = make(map[uint8]*lease)
+		c.leases[iface.InterfaceName] = podLeases
+	}
+	current, hasLease := podLeases[reply.family]
+
+	if reply.release {
+		if !hasLease || (reply.ip != nil && !reply.ip.Equal(current.ip)) {
+			return nil
+		}
+		klog.Infof("DHCP lease of IP %s was revoked for Pod %s", current.ip, podName)
+		if err := c.updateIPs(iface, reply.family, nil, now); err != nil {
+			return err
+		}
+		delete(podLeases, reply.family)
+		return nil
+	}
+
+	var expiry time.Time
+	if reply.leaseTime != infiniteLeaseTime {
+		expiry = now.Add(reply.leaseTime)
+	}
+	if hasLease && current.ip.Equal(reply.ip) {
+		klog.V(2).Infof("DHCP lease of IP %s was renewed for Pod %s", current.ip, podName)
+		current.expiry = expiry
+		return nil
+	}
+	klog.Infof("Binding IP %s leased with DHCP to Pod %s", reply.ip, podName)
+	if err := c.updateIPs(iface, reply.family, reply.ip, now); err != nil {
+		return err
+	}
+	podLeases[reply.family] = &lease{ip: reply.ip, expiry: expiry}
+	return nil
+}
+
+// expireLeases removes the addresses of the leases which have expired at the provided time from
+// their Pod, and forgets the leases of the Pods which have been removed.
+func (c *Controller) expireLeases(now time.Time) {
+	c.leasesMutex.Lock()
+	defer c.leasesMutex.Unlock()
+
+	interfaces := make(map[string]*interfacestore.InterfaceConfig)
+	for _, iface := range c.ifaceStore.GetInterfacesByType(interfacestore.ContainerInterface) {
+		interfaces[iface.InterfaceName] = iface
+	}
+	for name, podLeases := range c.leases {
+		iface, ok := interfaces[name]
+		if !ok {
+			delete(c.leases, name)
+			continue
+		}
+		for family, l := range podLeases {
+			if l.expiry.IsZero() || now.Before(l.expiry) {
+				continue
+			}
+			klog.Infof("DHCP lease of IP %s expired for Pod %s", l.ip, k8s.NamespacedName(iface.PodNamespace, iface.PodName))
+			if err := c.updateIPs(iface, family, nil, now); err != nil {
+				// Retry in the next check.
+				klog.Errorf("Failed to remove expired IP %s: %v", l.ip, err)
+				continue
+			}
+			delete(podLeases, family)
+			// The interface has been replaced in the interface store.
+			iface, _ = c.ifaceStore.GetInterfaceByName(name)
+		}
+	}
+}
+
+// updateIPs replaces the IP of the provided family of the interface with ip, or removes it if ip is
+// nil, in the Pod flows and in the interface store.
+func (c *Controller) updateIPs(iface *interfacestore.InterfaceConfig, family uint8, ip net.IP, now time.Time) error {
+	var ips []net.IP
+	for _, ifaceIP := range iface.IPs {
+		if ipFamily(ifaceIP) != family {
+			ips = append(ips, ifaceIP)
+		}
+	}
+	if ip != nil {
+		ips = append(ips, ip)
+	}
+	if err := c.ofClient.UpdatePodFlows(iface.InterfaceName, ips, iface.MAC, uint32(iface.OFPort)); err != nil {
+		return fmt.Errorf("failed to update flows of interface %s: %v", iface.InterfaceName, err)
+	}
+	// The InterfaceConfig in the interface store must not be modified, so it is replaced with a copy.
+	newIface := *iface
+	newIface.IPs = ips
+	newIface.IPUpdateTimestamp = now
+	if !c.ifaceStore.UpdateInterface(&newIface) {
+		return fmt.Errorf("interface %s was removed", iface.InterfaceName)
+	}
+	return nil
+}
+
+func ipFamily(ip net.IP) uint8 {
+	if ip.To4() != nil {
+		return util.FamilyIPv4
+	}
+	return util.FamilyIPv6
+}
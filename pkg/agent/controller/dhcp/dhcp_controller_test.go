@@ -0,0 +1,242 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dhcp
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"antrea.io/antrea/pkg/agent/interfacestore"
+	openflowtest "antrea.io/antrea/pkg/agent/openflow/testing"
+	"antrea.io/antrea/pkg/agent/util"
+)
+
+var (
+	podMAC, _   = net.ParseMAC("aa:bb:cc:dd:ee:01")
+	otherMAC, _ = net.ParseMAC("aa:bb:cc:dd:ee:02")
+	initialIP   = net.ParseIP("10.10.0.2").To4()
+	leasedIP    = net.ParseIP("192.168.1.10").To4()
+	leasedIP2   = net.ParseIP("192.168.1.11").To4()
+	leasedIPv6  = net.ParseIP("fd00:1::10")
+)
+
+// dhcpv4Message builds a DHCP reply with the provided message type and lease time options.
+func dhcpv4Message(messageType byte, yiaddr net.IP, chaddr net.HardwareAddr, leaseTime uint32) []byte {
+	data := make([]byte, bootpOptionsOffset)
+	data[0] = bootpOpReply
+	data[1] = 1
+	data[bootpHLenOffset] = byte(len(chaddr))
+	copy(data[bootpYIAddrOffset:], yiaddr.To4())
+	copy(data[bootpCHAddrOffset:], chaddr)
+	binary.BigEndian.PutUint32(data[bootpMagicOffset:], dhcpMagicCookie)
+	data = append(data, dhcpOptionMessageType, 1, messageType)
+	if leaseTime != 0 {
+		data = append(data, dhcpOptionLeaseTime, 4, 0, 0, 0, 0)
+		binary.BigEndian.PutUint32(data[len(data)-4:], leaseTime)
+	}
+	return append(data, dhcpOptionEnd)
+}
+
+// dhcpv6Reply builds a DHCPv6 Reply with an IA_NA address.
+func dhcpv6Reply(ip net.IP, validLifetime uint32) []byte {
+	iaAddr := make([]byte, dhcpv6IAAddrHeaderLen)
+	copy(iaAddr, ip.To16())
+	binary.BigEndian.PutUint32(iaAddr[net.IPv6len+4:], validLifetime)
+	iana := append(make([]byte, dhcpv6IANAHeaderLen), dhcpv6Option(dhcpv6OptionIAAddr, iaAddr)...)
+	return append([]byte{dhcpv6MessageTypeReply, 0, 0, 1}, dhcpv6Option(dhcpv6OptionIANA, iana)...)
+}
+
+func dhcpv6Option(code uint16, value []byte) []byte {
+	option := make([]byte, 4, 4+len(value))
+	binary.BigEndian.PutUint16(option, code)
+	binary.BigEndian.PutUint16(option[2:], uint16(len(value)))
+	return append(option, value...)
+}
+
+func TestParseDHCPv4(t *testing.T) {
+	tests := []struct {
+		name          string
+		data          []byte
+		expectedReply *dhcpReply
+		expectedErr   bool
+	}{
+		{
+			name:          "ACK",
+			data:          dhcpv4Message(dhcpMessageTypeACK, leasedIP, podMAC, 3600),
+			expectedReply: &dhcpReply{clientMAC: podMAC, family: util.FamilyIPv4, ip: leasedIP, leaseTime: time.Hour},
+		},
+		{
+			name:          "ACK without lease time",
+			data:          dhcpv4Message(dhcpMessageTypeACK, leasedIP, podMAC, 0),
+			expectedReply: &dhcpReply{clientMAC: podMAC, family: util.FamilyIPv4, ip: leasedIP, leaseTime: infiniteLeaseTime},
+		},
+		{
+			name:          "NAK",
+			data:          dhcpv4Message(dhcpMessageTypeNAK, net.IPv4zero, podMAC, 0),
+			expectedReply: &dhcpReply{clientMAC: podMAC, family: util.FamilyIPv4, leaseTime: infiniteLeaseTime, release: true},
+		},
+		{
+			name: "OFFER",
+			data: dhcpv4Message(2, leasedIP, podMAC, 3600),
+		},
+		{
+			name: "ACK to INFORM",
+			data: dhcpv4Message(dhcpMessageTypeACK, net.IPv4zero, podMAC, 0),
+		},
+		{
+			name:        "truncated message",
+			data:        dhcpv4Message(dhcpMessageTypeACK, leasedIP, podMAC, 0)[:100],
+			expectedErr: true,
+		},
+		{
+			name:        "truncated option",
+			data:        dhcpv4Message(dhcpMessageTypeACK, leasedIP, podMAC, 3600)[:bootpOptionsOffset+6],
+			expectedErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reply, err := parseDHCPv4(tt.data)
+			if tt.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedReply, reply)
+		})
+	}
+}
+
+func TestParseDHCPv6(t *testing.T) {
+	reply, err := parseDHCPv6(dhcpv6Reply(leasedIPv6, 7200), podMAC)
+	require.NoError(t, err)
+	assert.Equal(t, &dhcpReply{clientMAC: podMAC, family: util.FamilyIPv6, ip: leasedIPv6, leaseTime: 2 * time.Hour}, reply)
+
+	reply, err = parseDHCPv6(dhcpv6Reply(leasedIPv6, 0), podMAC)
+	require.NoError(t, err)
+	assert.True(t, reply.release)
+
+	// Advertise.
+	data := dhcpv6Reply(leasedIPv6, 7200)
+	data[0] = 2
+	reply, err = parseDHCPv6(data, podMAC)
+	assert.NoError(t, err)
+	assert.Nil(t, reply)
+
+	data = dhcpv6Reply(leasedIPv6, 7200)
+	_, err = parseDHCPv6(data[:len(data)-4], podMAC)
+	assert.Error(t, err)
+}
+
+func newTestController(t *testing.T) (*Controller, *openflowtest.MockClient, interfacestore.InterfaceStore) {
+	mockOFClient := openflowtest.NewMockClient(gomock.NewController(t))
+	mockOFClient.EXPECT().RegisterPacketInHandler(gomock.Any(), gomock.Any(), gomock.Any())
+	ifaceStore := interfacestore.NewInterfaceStore()
+	dhcpIface := interfacestore.NewContainerInterface("pod1-eth0", "c1", "pod1", "default", podMAC, []net.IP{initialIP})
+	dhcpIface.DHCP = true
+	dhcpIface.OFPort = 3
+	ifaceStore.AddInterface(dhcpIface)
+	ifaceStore.AddInterface(interfacestore.NewContainerInterface("pod2-eth0", "c2", "pod2", "default", otherMAC, []net.IP{net.ParseIP("10.10.0.3")}))
+	return NewController(mockOFClient, ifaceStore), mockOFClient, ifaceStore
+}
+
+func TestLeaseLifecycle(t *testing.T) {
+	c, mockOFClient, ifaceStore := newTestController(t)
+	now := time.Now()
+
+	mockOFClient.EXPECT().UpdatePodFlows("pod1-eth0", []net.IP{leasedIP}, podMAC, uint32(3))
+	reply, _ := parseDHCPv4(dhcpv4Message(dhcpMessageTypeACK, leasedIP, podMAC, 60))
+	require.NoError(t, c.handleReply(reply, now))
+	iface, ok := ifaceStore.GetInterfaceByIP(leasedIP.String())
+	require.True(t, ok)
+	assert.Equal(t, "pod1", iface.PodName)
+	assert.Equal(t, now, iface.IPUpdateTimestamp)
+	_, ok = ifaceStore.GetInterfaceByIP(initialIP.String())
+	assert.False(t, ok)
+
+	// Renewing the lease doesn't update the flows.
+	reply, _ = parseDHCPv4(dhcpv4Message(dhcpMessageTypeACK, leasedIP, podMAC, 60))
+	require.NoError(t, c.handleReply(reply, now.Add(50*time.Second)))
+	c.expireLeases(now.Add(70 * time.Second))
+	_, ok = ifaceStore.GetInterfaceByIP(leasedIP.String())
+	assert.True(t, ok)
+
+	// A DHCPv6 lease is added to the IPv4 lease.
+	mockOFClient.EXPECT().UpdatePodFlows("pod1-eth0", []net.IP{leasedIP, leasedIPv6}, podMAC, uint32(3))
+	reply, _ = parseDHCPv6(dhcpv6Reply(leasedIPv6, 0xffffffff), podMAC)
+	require.NoError(t, c.handleReply(reply, now))
+
+	// A new IPv4 address replaces the leased one.
+	mockOFClient.EXPECT().UpdatePodFlows("pod1-eth0", []net.IP{leasedIPv6, leasedIP2}, podMAC, uint32(3))
+	reply, _ = parseDHCPv4(dhcpv4Message(dhcpMessageTypeACK, leasedIP2, podMAC, 60))
+	require.NoError(t, c.handleReply(reply, now.Add(100*time.Second)))
+	_, ok = ifaceStore.GetInterfaceByIP(leasedIP.String())
+	assert.False(t, ok)
+
+	// The IPv4 lease expires, the infinite IPv6 lease doesn't.
+	mockOFClient.EXPECT().UpdatePodFlows("pod1-eth0", []net.IP{leasedIPv6}, podMAC, uint32(3))
+	c.expireLeases(now.Add(200 * time.Second))
+	_, ok = ifaceStore.GetInterfaceByIP(leasedIP2.String())
+	assert.False(t, ok)
+	_, ok = ifaceStore.GetInterfaceByIP(leasedIPv6.String())
+	assert.True(t, ok)
+	c.expireLeases(now.Add(24 * time.Hour))
+}
+
+func TestLeaseRevoked(t *testing.T) {
+	c, mockOFClient, ifaceStore := newTestController(t)
+	now := time.Now()
+
+	mockOFClient.EXPECT().UpdatePodFlows("pod1-eth0", []net.IP{leasedIP}, podMAC, uint32(3))
+	reply, _ := parseDHCPv4(dhcpv4Message(dhcpMessageTypeACK, leasedIP, podMAC, 60))
+	require.NoError(t, c.handleReply(reply, now))
+
+	mockOFClient.EXPECT().UpdatePodFlows("pod1-eth0", gomock.Nil(), podMAC, uint32(3))
+	reply, _ = parseDHCPv4(dhcpv4Message(dhcpMessageTypeNAK, net.IPv4zero, podMAC, 0))
+	require.NoError(t, c.handleReply(reply, now))
+	_, ok := ifaceStore.GetInterfaceByIP(leasedIP.String())
+	assert.False(t, ok)
+	assert.Empty(t, c.leases["pod1-eth0"])
+}
+
+func TestIgnoreNonDHCPPods(t *testing.T) {
+	c, _, ifaceStore := newTestController(t)
+
+	reply, _ := parseDHCPv4(dhcpv4Message(dhcpMessageTypeACK, leasedIP, otherMAC, 60))
+	require.NoError(t, c.handleReply(reply, time.Now()))
+	_, ok := ifaceStore.GetInterfaceByIP(leasedIP.String())
+	assert.False(t, ok)
+	assert.Empty(t, c.leases)
+}
+
+func TestForgetLeasesOfRemovedPods(t *testing.T) {
+	c, mockOFClient, ifaceStore := newTestController(t)
+	now := time.Now()
+
+	mockOFClient.EXPECT().UpdatePodFlows("pod1-eth0", []net.IP{leasedIP}, podMAC, uint32(3))
+	reply, _ := parseDHCPv4(dhcpv4Message(dhcpMessageTypeACK, leasedIP, podMAC, 60))
+	require.NoError(t, c.handleReply(reply, now))
+
+	iface, _ := ifaceStore.GetInterfaceByName("pod1-eth0")
+	ifaceStore.DeleteInterface(iface)
+	c.expireLeases(now.Add(time.Hour))
+	assert.Empty(t, c.leases)
+}
@@ -0,0 +1,217 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dhcp
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/contiv/libOpenflow/protocol"
+	"github.com/contiv/ofnet/ofctrl"
+
+	"antrea.io/antrea/pkg/agent/util"
+)
+
+const (
+	// BOOTP message fields (RFC 2131).
+	bootpOpReply       = 2
+	bootpHLenOffset    = 2
+	bootpYIAddrOffset  = 16
+	bootpCHAddrOffset  = 28
+	bootpMagicOffset   = 236
+	bootpOptionsOffset = 240
+	dhcpMagicCookie    = 0x63825363
+
+	// DHCP options (RFC 2132).
+	dhcpOptionPad         = 0
+	dhcpOptionLeaseTime   = 51
+	dhcpOptionMessageType = 53
+	dhcpOptionEnd         = 255
+	dhcpMessageTypeACK    = 5
+	dhcpMessageTypeNAK    = 6
+
+	// DHCPv6 message fields and options (RFC 8415).
+	dhcpv6MessageTypeReply = 7
+	dhcpv6OptionsOffset    = 4
+	dhcpv6OptionIANA       = 3
+	dhcpv6OptionIAAddr     = 5
+	// IA_NA options start with the IAID, T1 and T2, and IAADDR options start with the address and
+	// its preferred and valid lifetimes.
+	dhcpv6IANAHeaderLen   = 12
+	dhcpv6IAAddrHeaderLen = 24
+
+	// infiniteLeaseTime is the lease time of the leases which never expire.
+	infiniteLeaseTime = time.Duration(0xffffffff) * time.Second
+)
+
+// dhcpReply is a DHCP reply granting or revoking a lease.
+type dhcpReply struct {
+	clientMAC net.HardwareAddr
+	family    uint8
+	// ip is the leased address. It may be nil when the lease is revoked.
+	ip        net.IP
+	leaseTime time.Duration
+	// release is true when the lease is revoked, by a DHCPNAK or a DHCPv6 Reply with a valid
+	// lifetime of 0.
+	release bool
+}
+
+// HandlePacketIn implements openflow.PacketInHandler. The packets are the DHCP replies sent to the
+// local Pods which use DHCP.
+func (c *Controller) HandlePacketIn(pktIn *ofctrl.PacketIn) error {
+	var udpPkt *protocol.UDP
+	var ok bool
+	switch ipPkt := pktIn.Data.Data.(type) {
+	case *protocol.IPv4:
+		udpPkt, ok = ipPkt.Data.(*protocol.UDP)
+	case *protocol.IPv6:
+		udpPkt, ok = ipPkt.Data.(*protocol.UDP)
+	default:
+		return fmt.Errorf("unexpected Ethertype %#x for DHCP message", pktIn.Data.Ethertype)
+	}
+	if !ok {
+		return errors.New("packet is not a UDP datagram")
+	}
+	var reply *dhcpReply
+	var err error
+	if pktIn.Data.Ethertype == protocol.IPv4_MSG {
+		reply, err = parseDHCPv4(udpPkt.Data)
+	} else {
+		reply, err = parseDHCPv6(udpPkt.Data, pktIn.Data.HWDst)
+	}
+	if err != nil {
+		return err
+	}
+	// Messages which don't grant or revoke a lease are ignored.
+	if reply == nil {
+		return nil
+	}
+	return c.handleReply(reply, time.Now())
+}
+
+// parseDHCPv4 parses a DHCPACK or a DHCPNAK. It returns nil for the other messages.
+func parseDHCPv4(data []byte) (*dhcpReply, error) {
+	if len(data) < bootpOptionsOffset {
+		return nil, fmt.Errorf("DHCP message is too short: %d bytes", len(data))
+	}
+	if data[0] != bootpOpReply {
+		return nil, nil
+	}
+	if binary.BigEndian.Uint32(data[bootpMagicOffset:]) != dhcpMagicCookie {
+		return nil, errors.New("DHCP message has an invalid magic cookie")
+	}
+	hlen := int(data[bootpHLenOffset])
+	if hlen != 6 {
+		return nil, fmt.Errorf("DHCP message has an unexpected hardware address length %d", hlen)
+	}
+	reply := &dhcpReply{
+		clientMAC: net.HardwareAddr(append([]byte(nil), data[bootpCHAddrOffset:bootpCHAddrOffset+hlen]...)),
+		family:    util.FamilyIPv4,
+		leaseTime: infiniteLeaseTime,
+	}
+	var messageType byte
+	for i := bootpOptionsOffset; i < len(data); {
+		code := data[i]
+		if code == dhcpOptionEnd {
+			break
+		}
+		if code == dhcpOptionPad {
+			i++
+			continue
+		}
+		if i+2 > len(data) || i+2+int(data[i+1]) > len(data) {
+			return nil, fmt.Errorf("DHCP option %d is truncated", code)
+		}
+		value := data[i+2 : i+2+int(data[i+1])]
+		switch code {
+		case dhcpOptionMessageType:
+			if len(value) == 1 {
+				messageType = value[0]
+			}
+		case dhcpOptionLeaseTime:
+			if len(value) == 4 {
+				reply.leaseTime = time.Duration(binary.BigEndian.Uint32(value)) * time.Second
+			}
+		}
+		i += 2 + len(value)
+	}
+	switch messageType {
+	case dhcpMessageTypeACK:
+		reply.ip = net.IP(append([]byte(nil), data[bootpYIAddrOffset:bootpYIAddrOffset+net.IPv4len]...))
+		if reply.ip.IsUnspecified() {
+			// DHCPACK replying to a DHCPINFORM, which doesn't grant a lease.
+			return nil, nil
+		}
+	case dhcpMessageTypeNAK:
+		reply.release = true
+	default:
+		return nil, nil
+	}
+	return reply, nil
+}
+
+// parseDHCPv6 parses a DHCPv6 Reply with an IA_NA address. It returns nil for the other messages.
+// DHCPv6 messages don't carry the MAC of the client, so clientMAC is the destination MAC of the
+// reply.
+func parseDHCPv6(data []byte, clientMAC net.HardwareAddr) (*dhcpReply, error) {
+	if len(data) < dhcpv6OptionsOffset {
+		return nil, fmt.Errorf("DHCPv6 message is too short: %d bytes", len(data))
+	}
+	if data[0] != dhcpv6MessageTypeReply {
+		return nil, nil
+	}
+	iana, err := findDHCPv6Option(data[dhcpv6OptionsOffset:], dhcpv6OptionIANA)
+	if err != nil || iana == nil {
+		return nil, err
+	}
+	if len(iana) < dhcpv6IANAHeaderLen {
+		return nil, errors.New("DHCPv6 IA_NA option is truncated")
+	}
+	iaAddr, err := findDHCPv6Option(iana[dhcpv6IANAHeaderLen:], dhcpv6OptionIAAddr)
+	if err != nil || iaAddr == nil {
+		return nil, err
+	}
+	if len(iaAddr) < dhcpv6IAAddrHeaderLen {
+		return nil, errors.New("DHCPv6 IAADDR option is truncated")
+	}
+	validLifetime := binary.BigEndian.Uint32(iaAddr[net.IPv6len+4:])
+	return &dhcpReply{
+		clientMAC: clientMAC,
+		family:    util.FamilyIPv6,
+		ip:        net.IP(append([]byte(nil), iaAddr[:net.IPv6len]...)),
+		leaseTime: time.Duration(validLifetime) * time.Second,
+		release:   validLifetime == 0,
+	}, nil
+}
+
+// findDHCPv6Option returns the value of the first DHCPv6 option with the provided code, or nil if
+// there is none.
+func findDHCPv6Option(data []byte, code uint16) ([]byte, error) {
+	for i := 0; i+4 <= len(data); {
+		optionCode := binary.BigEndian.Uint16(data[i:])
+		optionLen := int(binary.BigEndian.Uint16(data[i+2:]))
+		if i+4+optionLen > len(data) {
+			return nil, fmt.Errorf("DHCPv6 option %d is truncated", optionCode)
+		}
+		if optionCode == code {
+			return data[i+4 : i+4+optionLen], nil
+		}
+		i += 4 + optionLen
+	}
+	return nil, nil
+}
@@ -109,7 +109,7 @@ type egressBinding struct {
 }
 
 type EgressController struct {
-	ofClient             openflow.Client
+	ofClient             openflow.PodConnectivityClient
 	routeClient          route.Interface
 	crdClient            clientsetversioned.Interface
 	antreaClientProvider agent.AntreaClientProvider
@@ -144,7 +144,7 @@ type EgressController struct {
 }
 
 func NewEgressController(
-	ofClient openflow.Client,
+	ofClient openflow.PodConnectivityClient,
 	antreaClientGetter agent.AntreaClientProvider,
 	crdClient clientsetversioned.Interface,
 	ifaceStore interfacestore.InterfaceStore,
@@ -16,6 +16,7 @@ package networkpolicy
 
 import (
 	"fmt"
+	"sync"
 	"testing"
 	"time"
 
@@ -250,3 +251,34 @@ func TestIdAllocatorWorker(t *testing.T) {
 		})
 	}
 }
+
+func TestAllocateForRuleConcurrently(t *testing.T) {
+	const workers = 16
+	const rulesPerWorker = 100
+	a := newIDAllocator(testAsyncDeleteInterval, 1, 3, 5)
+	ids := make(chan uint32, workers*rulesPerWorker)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < rulesPerWorker; i++ {
+				rule := &types.PolicyRule{Direction: v1beta2.DirectionIn}
+				assert.NoError(t, a.allocateForRule(rule))
+				ids <- rule.FlowID
+			}
+		}()
+	}
+	wg.Wait()
+	close(ids)
+
+	// Each rule reconciled by a worker gets its own ID, and released IDs are reused.
+	allocated := sets.NewInt()
+	for id := range ids {
+		assert.False(t, allocated.Has(int(id)), "ID %d allocated twice", id)
+		allocated.Insert(int(id))
+	}
+	assert.Equal(t, workers*rulesPerWorker, allocated.Len())
+	assert.True(t, allocated.HasAll(2, 4))
+	assert.False(t, allocated.HasAny(1, 3, 5))
+}
@@ -0,0 +1,102 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package networkpolicy
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+const (
+	// maxAggregatedAuditLogEntries bounds the number of distinct entries aggregated in a window.
+	// Once it is reached, the entries of new packets are written right away until the window ends.
+	maxAggregatedAuditLogEntries = 10000
+)
+
+// auditLogKey identifies the audit log entries of identical packets. The source port is not part of
+// the key as it usually differs between the connections of a client, the aggregated entry has the
+// source port of the first packet.
+type auditLogKey struct {
+	srcIP       string
+	destIP      string
+	destPort    uint16
+	icmpType    uint8
+	protocolStr string
+	npRef       string
+	disposition string
+}
+
+// auditLogAggregator aggregates the audit log entries of identical packets logged within a window
+// into a single entry with the number of packets, so that a client hitting a logged rule repeatedly
+// doesn't flood the audit logs. The aggregated entries are written when flush is called at the end
+// of every window.
+type auditLogAggregator struct {
+	window     time.Duration
+	maxEntries int
+	// write writes an entry to the audit log sink.
+	write func(ob *logInfo) error
+
+	mutex sync.Mutex
+	// entries are the first entries of the distinct packets logged in the current window, in the
+	// order they were logged, and indexes maps their keys to their positions in entries.
+	entries []*logInfo
+	indexes map[auditLogKey]int
+}
+
+func newAuditLogAggregator(window time.Duration, maxEntries int, write func(ob *logInfo) error) *auditLogAggregator {
+	return &auditLogAggregator{
+		window:     window,
+		maxEntries: maxEntries,
+		write:      write,
+		indexes:    map[auditLogKey]int{},
+	}
+}
+
+// add aggregates ob with the identical entries logged in the current window. ob is written right
+// away if the maximum number of aggregated entries is reached.
+func (a *auditLogAggregator) add(ob *logInfo) error {
+	key := auditLogKey{srcIP: ob.srcIP, destIP: ob.destIP, destPort: ob.destPort, icmpType: ob.icmpType, protocolStr: ob.protocolStr, npRef: ob.npRef, disposition: ob.disposition}
+	a.mutex.Lock()
+	if i, ok := a.indexes[key]; ok {
+		a.entries[i].packetCount++
+		a.mutex.Unlock()
+		return nil
+	}
+	if len(a.entries) >= a.maxEntries {
+		a.mutex.Unlock()
+		return a.write(ob)
+	}
+	ob.packetCount = 1
+	a.indexes[key] = len(a.entries)
+	a.entries = append(a.entries, ob)
+	a.mutex.Unlock()
+	return nil
+}
+
+// flush writes the entries aggregated in the current window, and starts a new window.
+func (a *auditLogAggregator) flush() {
+	a.mutex.Lock()
+	entries := a.entries
+	a.entries = nil
+	a.indexes = map[auditLogKey]int{}
+	a.mutex.Unlock()
+	for _, ob := range entries {
+		if err := a.write(ob); err != nil {
+			klog.Errorf("Failed to write audit log entry: %v", err)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package networkpolicy
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestAuditLogAggregator(maxEntries int) (*auditLogAggregator, *bytes.Buffer) {
+	var buf bytes.Buffer
+	sink := &fileAuditLogSink{logger: log.New(&buf, "", 0)}
+	return newAuditLogAggregator(time.Second, maxEntries, sink.write), &buf
+}
+
+func logLines(buf *bytes.Buffer) []string {
+	if buf.Len() == 0 {
+		return nil
+	}
+	return strings.Split(strings.TrimSpace(buf.String()), "\n")
+}
+
+func TestAuditLogAggregator(t *testing.T) {
+	a, buf := newTestAuditLogAggregator(maxAggregatedAuditLogEntries)
+	newLogInfo := func(srcIP string, pktLength uint16) *logInfo {
+		return &logInfo{tableName: "AntreaPolicyIngressRule", npRef: "AntreaNetworkPolicy:default/test-anp", ruleName: "drop-all", disposition: "Drop", ofPriority: "44900", srcIP: srcIP, destIP: "10.10.0.5", pktLength: pktLength, protocolStr: "TCP"}
+	}
+
+	// The identical packets are aggregated into the entry of the first packet, while the packets of
+	// another source are not merged.
+	const identicalPackets = 100
+	for i := 0; i < identicalPackets; i++ {
+		require.NoError(t, a.add(newLogInfo("10.10.0.4", uint16(60+i))))
+	}
+	require.NoError(t, a.add(newLogInfo("10.10.0.6", 60)))
+	// Nothing is written before the window ends.
+	assert.Empty(t, logLines(buf))
+	a.flush()
+	assert.Equal(t, []string{
+		"AntreaPolicyIngressRule AntreaNetworkPolicy:default/test-anp Drop 44900 SRC: 10.10.0.4 DEST: 10.10.0.5 60 TCP - - drop-all [100 packets]",
+		"AntreaPolicyIngressRule AntreaNetworkPolicy:default/test-anp Drop 44900 SRC: 10.10.0.6 DEST: 10.10.0.5 60 TCP - - drop-all",
+	}, logLines(buf))
+
+	// A new window starts after the flush.
+	buf.Reset()
+	require.NoError(t, a.add(newLogInfo("10.10.0.4", 60)))
+	a.flush()
+	assert.Equal(t, []string{
+		"AntreaPolicyIngressRule AntreaNetworkPolicy:default/test-anp Drop 44900 SRC: 10.10.0.4 DEST: 10.10.0.5 60 TCP - - drop-all",
+	}, logLines(buf))
+}
+
+func TestAuditLogAggregatorDistinctTuples(t *testing.T) {
+	a, buf := newTestAuditLogAggregator(maxAggregatedAuditLogEntries)
+	ob := logInfo{tableName: "AntreaPolicyIngressRule", npRef: "AntreaNetworkPolicy:default/test-anp", disposition: "Drop", ofPriority: "44900", srcIP: "10.10.0.4", destIP: "10.10.0.5", pktLength: 60, protocolStr: "TCP"}
+	variants := []func(ob *logInfo){
+		func(ob *logInfo) {},
+		func(ob *logInfo) { ob.srcIP = "10.10.0.6" },
+		func(ob *logInfo) { ob.destIP = "10.10.0.6" },
+		func(ob *logInfo) { ob.protocolStr = "UDP" },
+		func(ob *logInfo) { ob.npRef = "AntreaNetworkPolicy:default/other-anp" },
+		func(ob *logInfo) { ob.disposition = "Allow" },
+	}
+	for _, variant := range variants {
+		entry := ob
+		variant(&entry)
+		require.NoError(t, a.add(&entry))
+	}
+	a.flush()
+	lines := logLines(buf)
+	require.Len(t, lines, len(variants))
+	for _, line := range lines {
+		assert.NotContains(t, line, "packets]")
+	}
+}
+
+func TestAuditLogAggregatorMaxEntries(t *testing.T) {
+	a, buf := newTestAuditLogAggregator(1)
+	require.NoError(t, a.add(&logInfo{srcIP: "10.10.0.4", destIP: "10.10.0.5", protocolStr: "TCP"}))
+	require.NoError(t, a.add(&logInfo{srcIP: "10.10.0.4", destIP: "10.10.0.5", protocolStr: "TCP"}))
+	// The entries of new packets are written right away once the maximum number of entries is
+	// reached.
+	require.NoError(t, a.add(&logInfo{srcIP: "10.10.0.6", destIP: "10.10.0.5", protocolStr: "TCP"}))
+	lines := logLines(buf)
+	require.Len(t, lines, 1)
+	assert.Contains(t, lines[0], "SRC: 10.10.0.6")
+	a.flush()
+	lines = logLines(buf)
+	require.Len(t, lines, 2)
+	assert.Contains(t, lines[1], "SRC: 10.10.0.4")
+	assert.Contains(t, lines[1], "[2 packets]")
+}
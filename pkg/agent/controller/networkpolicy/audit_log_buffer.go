@@ -0,0 +1,90 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package networkpolicy
+
+import (
+	"sync"
+	"time"
+
+	"antrea.io/antrea/pkg/querier"
+)
+
+// recentAuditLogEntries is the number of audit log entries kept in memory to be queried through the
+// agent API.
+const recentAuditLogEntries = 1000
+
+// auditLogBuffer is a ring buffer of the most recent audit log entries, so that they can be queried
+// without reading the audit log files, wherever the entries are written.
+type auditLogBuffer struct {
+	mutex sync.Mutex
+	// entries holds at most size entries. Once it is full, next is the position of the oldest entry,
+	// which is overwritten by the next entry added.
+	entries []querier.AuditLogEntry
+	size    int
+	next    int
+}
+
+func newAuditLogBuffer(size int) *auditLogBuffer {
+	return &auditLogBuffer{
+		entries: make([]querier.AuditLogEntry, 0, size),
+		size:    size,
+	}
+}
+
+// add adds the entry of ob written at time t, evicting the oldest entry if the buffer is full.
+func (b *auditLogBuffer) add(ob *logInfo, t time.Time) {
+	entry := ob.auditLogEntry(t)
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if len(b.entries) < b.size {
+		b.entries = append(b.entries, entry)
+		return
+	}
+	b.entries[b.next] = entry
+	b.next = (b.next + 1) % b.size
+}
+
+// list returns a copy of the entries, from the oldest to the most recent.
+func (b *auditLogBuffer) list() []querier.AuditLogEntry {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	entries := make([]querier.AuditLogEntry, 0, len(b.entries))
+	entries = append(entries, b.entries[b.next:]...)
+	return append(entries, b.entries[:b.next]...)
+}
+
+// auditLogEntry returns the entry of ob written at time t, as returned by the agent API.
+func (ob *logInfo) auditLogEntry(t time.Time) querier.AuditLogEntry {
+	entry := querier.AuditLogEntry{
+		Timestamp:   t,
+		Table:       ob.tableName,
+		Policy:      ob.npRef,
+		Rule:        ob.ruleName,
+		Disposition: ob.disposition,
+		Priority:    ob.ofPriority,
+		SrcIP:       ob.srcIP,
+		DestIP:      ob.destIP,
+		Length:      ob.pktLength,
+		Protocol:    ob.protocolStr,
+		Packets:     ob.packetCount,
+	}
+	if ob.hasTransportHeader && ob.isICMP() {
+		icmpType, icmpCode := ob.icmpType, ob.icmpCode
+		entry.ICMPType, entry.ICMPCode = &icmpType, &icmpCode
+	} else if ob.hasTransportHeader {
+		entry.SrcPort, entry.DestPort = ob.srcPort, ob.destPort
+	}
+	return entry
+}
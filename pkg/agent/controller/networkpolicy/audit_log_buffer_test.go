@@ -0,0 +1,110 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package networkpolicy
+
+import (
+	"bytes"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+
+	"antrea.io/antrea/pkg/agent/openflow"
+	"antrea.io/antrea/pkg/querier"
+)
+
+func TestAuditLogBuffer(t *testing.T) {
+	b := newAuditLogBuffer(3)
+	start := time.Date(2021, 5, 1, 10, 0, 0, 0, time.UTC)
+	minutes := func(entries []querier.AuditLogEntry) []int {
+		var result []int
+		for _, e := range entries {
+			result = append(result, e.Timestamp.Minute())
+		}
+		return result
+	}
+	ob := &logInfo{npRef: "AntreaNetworkPolicy:default/test-anp", disposition: "Drop"}
+
+	assert.Empty(t, b.list())
+	for i := 0; i < 2; i++ {
+		b.add(ob, start.Add(time.Duration(i)*time.Minute))
+	}
+	assert.Equal(t, []int{0, 1}, minutes(b.list()))
+	// The oldest entries are evicted once the buffer is full.
+	for i := 2; i < 7; i++ {
+		b.add(ob, start.Add(time.Duration(i)*time.Minute))
+	}
+	assert.Equal(t, []int{4, 5, 6}, minutes(b.list()))
+}
+
+func TestAuditLogEntry(t *testing.T) {
+	timestamp := time.Date(2021, 5, 1, 10, 0, 0, 0, time.UTC)
+	icmpType, icmpCode := uint8(8), uint8(0)
+	for _, tc := range []struct {
+		name     string
+		ob       *logInfo
+		expected querier.AuditLogEntry
+	}{
+		{
+			name:     "TCP",
+			ob:       &logInfo{tableName: "AntreaPolicyIngressRule", npRef: "AntreaNetworkPolicy:default/test-anp", ruleName: "drop-all", disposition: "Drop", ofPriority: "44900", srcIP: "10.10.0.4", srcPort: 34567, destIP: "10.10.0.5", destPort: 80, pktLength: 60, protocolStr: "TCP", packetCount: 3, hasTransportHeader: true},
+			expected: querier.AuditLogEntry{Timestamp: timestamp, Table: "AntreaPolicyIngressRule", Policy: "AntreaNetworkPolicy:default/test-anp", Rule: "drop-all", Disposition: "Drop", Priority: "44900", SrcIP: "10.10.0.4", SrcPort: 34567, DestIP: "10.10.0.5", DestPort: 80, Length: 60, Protocol: "TCP", Packets: 3},
+		},
+		{
+			name:     "ICMP",
+			ob:       &logInfo{tableName: "AntreaPolicyIngressRule", npRef: "AntreaNetworkPolicy:default/test-anp", disposition: "Allow", ofPriority: "44900", srcIP: "10.10.0.4", destIP: "10.10.0.5", icmpType: 8, pktLength: 84, protocolStr: "ICMP", hasTransportHeader: true},
+			expected: querier.AuditLogEntry{Timestamp: timestamp, Table: "AntreaPolicyIngressRule", Policy: "AntreaNetworkPolicy:default/test-anp", Disposition: "Allow", Priority: "44900", SrcIP: "10.10.0.4", DestIP: "10.10.0.5", ICMPType: &icmpType, ICMPCode: &icmpCode, Length: 84, Protocol: "ICMP"},
+		},
+		{
+			name:     "truncated packet",
+			ob:       &logInfo{tableName: "IngressDefaultRule", npRef: "K8sDefaultDrop", disposition: "Drop", ofPriority: "200", srcIP: "10.10.0.4", destIP: "10.10.0.5", pktLength: 60, protocolStr: "UDP"},
+			expected: querier.AuditLogEntry{Timestamp: timestamp, Table: "IngressDefaultRule", Policy: "K8sDefaultDrop", Disposition: "Drop", Priority: "200", SrcIP: "10.10.0.4", DestIP: "10.10.0.5", Length: 60, Protocol: "UDP"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.ob.auditLogEntry(timestamp))
+		})
+	}
+}
+
+func TestGetRecentAuditLogs(t *testing.T) {
+	var buf bytes.Buffer
+	defer func(sink auditLogSink) { antreaPolicyLogSink = sink }(antreaPolicyLogSink)
+	antreaPolicyLogSink = &fileAuditLogSink{logger: log.New(&buf, "", 0)}
+
+	c := &Controller{k8sIsolationLogLimiter: rate.NewLimiter(rate.Inf, 1)}
+	_, enabled := c.GetRecentAuditLogs()
+	assert.False(t, enabled)
+
+	c.recentAuditLogs = newAuditLogBuffer(recentAuditLogEntries)
+	c.auditLogAggregator = newAuditLogAggregator(time.Second, maxAggregatedAuditLogEntries, c.writeAuditLog)
+	pktIn := newK8sIsolationDropPacketIn(uint8(openflow.IngressDefaultTable))
+	for i := 0; i < 5; i++ {
+		require.NoError(t, c.logPacket(pktIn))
+	}
+	// The aggregated entries are kept once they are written.
+	entries, enabled := c.GetRecentAuditLogs()
+	assert.True(t, enabled)
+	assert.Empty(t, entries)
+	c.auditLogAggregator.flush()
+	entries, _ = c.GetRecentAuditLogs()
+	require.Len(t, entries, 1)
+	assert.Equal(t, "K8sDefaultDrop", entries[0].Policy)
+	assert.Equal(t, 5, entries[0].Packets)
+	assert.NotEmpty(t, buf.String())
+}
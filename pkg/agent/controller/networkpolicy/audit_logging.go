@@ -0,0 +1,497 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package networkpolicy
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	"antrea.io/antrea/pkg/agent/otelexporter"
+	crdv1beta1 "antrea.io/antrea/pkg/apis/crd/v1beta1"
+	"antrea.io/antrea/pkg/monitor/condition"
+	"antrea.io/antrea/pkg/util/logdir"
+)
+
+const (
+	logfileSubdir string = "networkpolicy"
+	logfileName   string = "np.log"
+
+	// auditLogEventSource is the source of the audit log entries in the Application log of the
+	// Windows Event Log.
+	auditLogEventSource = "AntreaPolicyAudit"
+
+	// auditLogBufferSize is the number of audit log entries which can be waiting to be written.
+	auditLogBufferSize = 1024
+)
+
+// The event IDs of the audit log entries in the Windows Event Log, one per disposition, so that
+// the entries can be filtered by disposition. EventCreate.exe, which is used as the message file of
+// the event source, only accepts IDs between 1 and 1000.
+const (
+	auditLogEventIDAllow   uint32 = 1
+	auditLogEventIDDrop    uint32 = 2
+	auditLogEventIDReject  uint32 = 3
+	auditLogEventIDPass    uint32 = 4
+	auditLogEventIDUnknown uint32 = 5
+)
+
+// antreaPolicyLogSink is where logPacket writes the audit log entries. It is set by initLogger,
+// and replaced by reconfigureLogger while packet-in handlers may be writing to it.
+var (
+	antreaPolicyLogSink      auditLogSink
+	antreaPolicyLogSinkMutex sync.RWMutex
+)
+
+// auditLogSink is a destination of the audit log entries.
+type auditLogSink interface {
+	write(ob *logInfo) error
+	close() error
+}
+
+// AuditLogExporter exports the audit log entries in addition to the audit log sink, e.g. to an
+// OpenTelemetry collector. ExportAuditLog must not block.
+type AuditLogExporter interface {
+	ExportAuditLog(record *otelexporter.AuditLogRecord)
+}
+
+// isICMP returns whether the traffic logged is ICMP or ICMPv6, whose transport fields are the ICMP
+// type and code instead of the ports.
+func (ob *logInfo) isICMP() bool {
+	return ob.protocolStr == "ICMP" || ob.protocolStr == "IPv6-ICMP"
+}
+
+// transportFields returns the source and destination ports of ob, or its ICMP type and code, as
+// written to np.log. They are "-" when the transport header is unknown, e.g. for a truncated
+// packet.
+func (ob *logInfo) transportFields() (string, string) {
+	switch {
+	case !ob.hasTransportHeader:
+		return "-", "-"
+	case ob.isICMP():
+		return strconv.Itoa(int(ob.icmpType)), strconv.Itoa(int(ob.icmpCode))
+	default:
+		return strconv.Itoa(int(ob.srcPort)), strconv.Itoa(int(ob.destPort))
+	}
+}
+
+// String returns the audit log entry of ob, as written to np.log. The date and time are added by the
+// logger. The rule name is appended only when known, so that the entries of the traffic dropped by K8s
+// isolation keep the same format, and the number of packets only when several identical packets are
+// aggregated in the entry.
+func (ob *logInfo) String() string {
+	srcField, destField := ob.transportFields()
+	entry := fmt.Sprintf("%s %s %s %s SRC: %s DEST: %s %d %s %s %s", ob.tableName, ob.npRef, ob.disposition, ob.ofPriority, ob.srcIP, ob.destIP, ob.pktLength, ob.protocolStr, srcField, destField)
+	if ob.ruleName != "" {
+		entry += " " + ob.ruleName
+	}
+	if ob.packetCount > 1 {
+		entry += fmt.Sprintf(" [%d packets]", ob.packetCount)
+	}
+	return entry
+}
+
+// eventMessage returns the audit log entry of ob with one field per line, as written to the Windows
+// Event Log, in which the entries are read one at a time.
+func (ob *logInfo) eventMessage() string {
+	msg := fmt.Sprintf("Table: %s\nPolicy: %s\nRule: %s\nAction: %s\nPriority: %s\nSource: %s\nDestination: %s\nLength: %d\nProtocol: %s",
+		ob.tableName, ob.npRef, ob.ruleName, ob.disposition, ob.ofPriority, ob.srcIP, ob.destIP, ob.pktLength, ob.protocolStr)
+	srcField, destField := ob.transportFields()
+	if ob.isICMP() {
+		msg += fmt.Sprintf("\nICMP type: %s\nICMP code: %s", srcField, destField)
+	} else {
+		msg += fmt.Sprintf("\nSource port: %s\nDestination port: %s", srcField, destField)
+	}
+	if ob.packetCount > 1 {
+		msg += fmt.Sprintf("\nPackets: %d", ob.packetCount)
+	}
+	return msg
+}
+
+// auditLogEncoder formats the audit log entries written to np.log, with the time at which they are
+// written.
+type auditLogEncoder interface {
+	encode(ob *logInfo, t time.Time) (string, error)
+}
+
+// textAuditLogEncoder formats the entries as the space-separated fields returned by
+// logInfo.String, prefixed with the date and time.
+type textAuditLogEncoder struct{}
+
+func (textAuditLogEncoder) encode(ob *logInfo, t time.Time) (string, error) {
+	// Same date and time format as a log.Logger with the log.Ldate|log.Lmicroseconds flags.
+	return t.Format("2006/01/02 15:04:05.000000") + " " + ob.String(), nil
+}
+
+// jsonAuditLogEntry is an audit log entry formatted by jsonAuditLogEncoder.
+type jsonAuditLogEntry struct {
+	Timestamp   string `json:"timestamp"`
+	Table       string `json:"table"`
+	Policy      string `json:"policy"`
+	Rule        string `json:"rule,omitempty"`
+	Disposition string `json:"disposition"`
+	OFPriority  string `json:"ofPriority"`
+	SrcIP       string `json:"srcIP"`
+	SrcPort     uint16 `json:"srcPort,omitempty"`
+	DestIP      string `json:"destIP"`
+	DestPort    uint16 `json:"destPort,omitempty"`
+	ICMPType    *uint8 `json:"icmpType,omitempty"`
+	ICMPCode    *uint8 `json:"icmpCode,omitempty"`
+	PktLength   uint16 `json:"pktLength"`
+	Protocol    string `json:"protocol"`
+	PacketCount int    `json:"packetCount,omitempty"`
+}
+
+// jsonAuditLogEncoder formats the entries as JSON objects with explicit keys, one per line, so that
+// they can be parsed reliably by log collectors.
+type jsonAuditLogEncoder struct{}
+
+func (jsonAuditLogEncoder) encode(ob *logInfo, t time.Time) (string, error) {
+	entry := jsonAuditLogEntry{
+		Timestamp:   t.Format(time.RFC3339Nano),
+		Table:       ob.tableName,
+		Policy:      ob.npRef,
+		Rule:        ob.ruleName,
+		Disposition: ob.disposition,
+		OFPriority:  ob.ofPriority,
+		SrcIP:       ob.srcIP,
+		DestIP:      ob.destIP,
+		PktLength:   ob.pktLength,
+		Protocol:    ob.protocolStr,
+	}
+	// The ICMP type and code can be 0, hence they are only omitted when unknown.
+	if ob.hasTransportHeader && ob.isICMP() {
+		entry.ICMPType = &ob.icmpType
+		entry.ICMPCode = &ob.icmpCode
+	} else if ob.hasTransportHeader {
+		entry.SrcPort = ob.srcPort
+		entry.DestPort = ob.destPort
+	}
+	if ob.packetCount > 1 {
+		entry.PacketCount = ob.packetCount
+	}
+	data, err := json.Marshal(&entry)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// newAuditLogEncoder returns the encoder of the JSON format if jsonFormat is true, and of the text
+// format otherwise.
+func newAuditLogEncoder(jsonFormat bool) auditLogEncoder {
+	if jsonFormat {
+		return jsonAuditLogEncoder{}
+	}
+	return textAuditLogEncoder{}
+}
+
+// fileAuditLogSink writes the audit log entries to a log file.
+type fileAuditLogSink struct {
+	logger  *log.Logger
+	encoder auditLogEncoder
+	output  io.Closer
+}
+
+func (s *fileAuditLogSink) write(ob *logInfo) error {
+	entry, err := s.encoder.encode(ob, time.Now())
+	if err != nil {
+		return err
+	}
+	s.logger.Print(entry)
+	return nil
+}
+
+func (s *fileAuditLogSink) close() error {
+	if s.output == nil {
+		return nil
+	}
+	return s.output.Close()
+}
+
+// newFileAuditLogSink returns a sink writing to np.log in logDir, which is created if it doesn't
+// exist, the entries formatted by encoder. The log file is rotated.
+func newFileAuditLogSink(logDir string, encoder auditLogEncoder) (*fileAuditLogSink, error) {
+	if err := os.MkdirAll(logDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create the audit log directory %s: %v", logDir, err)
+	}
+	logFile := filepath.Join(logDir, logfileName)
+	// Use lumberjack log file rot
+	logOutput := &lumberjack.Logger{
+		Filename:   logFile,
+		MaxSize:    500,  // allow max 500 megabytes for one log file
+		MaxBackups: 3,    // allow max 3 old log file backups
+		MaxAge:     28,   // allow max 28 days maintenance of old log files
+		Compress:   true, // compress the old log files for backup
+	}
+	klog.V(2).Infof("Initialized Antrea-native Policy Logger for audit logging with log file '%s'", logFile)
+	return &fileAuditLogSink{
+		// The date and time are added by the encoder.
+		logger:  log.New(logOutput, "", 0),
+		encoder: encoder,
+		output:  logOutput,
+	}, nil
+}
+
+// exporterAuditLogSink writes the audit log entries to another sink, and exports them with an
+// AuditLogExporter.
+type exporterAuditLogSink struct {
+	auditLogSink
+	exporter AuditLogExporter
+}
+
+func (s *exporterAuditLogSink) write(ob *logInfo) error {
+	s.exporter.ExportAuditLog(ob.auditLogRecord())
+	return s.auditLogSink.write(ob)
+}
+
+// auditLogRecord returns the record of ob exported by an AuditLogExporter.
+func (ob *logInfo) auditLogRecord() *otelexporter.AuditLogRecord {
+	return &otelexporter.AuditLogRecord{
+		Timestamp:     time.Now(),
+		TableName:     ob.tableName,
+		NetworkPolicy: ob.npRef,
+		RuleName:      ob.ruleName,
+		Disposition:   ob.disposition,
+		OFPriority:    ob.ofPriority,
+		SourceIP:      ob.srcIP,
+		DestinationIP: ob.destIP,
+		PacketLength:  ob.pktLength,
+		Protocol:      ob.protocolStr,
+		Message:       ob.String(),
+	}
+}
+
+// asyncAuditLogSink writes the audit log entries to another sink from a single goroutine, so that
+// the entries logged concurrently by the packet-in handlers are written one at a time and in the
+// order they are logged. The entries are dropped when the buffer is full, so that logging never
+// blocks the packet-in handlers.
+type asyncAuditLogSink struct {
+	auditLogSink
+	entries chan *logInfo
+	// done is closed when all the entries have been written after the sink is closed.
+	done chan struct{}
+
+	// mutex protects closed, and prevents entries from being closed while an entry is sent.
+	mutex  sync.RWMutex
+	closed bool
+
+	// dropped is the number of entries dropped since the last time it was reported, and
+	// droppedTotal the number of entries dropped since the sink was created.
+	dropped      uint64
+	droppedTotal uint64
+}
+
+func newAsyncAuditLogSink(sink auditLogSink, bufferSize int) *asyncAuditLogSink {
+	s := &asyncAuditLogSink{
+		auditLogSink: sink,
+		entries:      make(chan *logInfo, bufferSize),
+		done:         make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+// run writes the queued entries until the sink is closed. The AuditLoggingReady condition of the
+// agent is set to False when an entry fails to be written, and back to True once an entry is
+// written again.
+func (s *asyncAuditLogSink) run() {
+	defer close(s.done)
+	failing := false
+	for ob := range s.entries {
+		if err := s.auditLogSink.write(ob); err != nil {
+			klog.Errorf("Failed to write audit log entry: %v", err)
+			if !failing {
+				condition.Set(crdv1beta1.AuditLoggingReady, corev1.ConditionFalse, "WriteFailed", err.Error())
+				failing = true
+			}
+		} else if failing {
+			condition.Set(crdv1beta1.AuditLoggingReady, corev1.ConditionTrue, "", "")
+			failing = false
+		}
+		if dropped := atomic.SwapUint64(&s.dropped, 0); dropped > 0 {
+			klog.Warningf("Dropped %d audit log entries as the audit log buffer was full", dropped)
+		}
+	}
+}
+
+// write queues the entry to be written. It never blocks: the entry is dropped if the buffer is full
+// or the sink is closed.
+func (s *asyncAuditLogSink) write(ob *logInfo) error {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	if !s.closed {
+		select {
+		case s.entries <- ob:
+			return nil
+		default:
+		}
+	}
+	atomic.AddUint64(&s.dropped, 1)
+	atomic.AddUint64(&s.droppedTotal, 1)
+	return nil
+}
+
+// close waits for the queued entries to be written before closing the wrapped sink.
+func (s *asyncAuditLogSink) close() error {
+	s.mutex.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.entries)
+	}
+	s.mutex.Unlock()
+	<-s.done
+	return s.auditLogSink.close()
+}
+
+// droppedCount returns the number of entries dropped since the sink was created.
+func (s *asyncAuditLogSink) droppedCount() uint64 {
+	return atomic.LoadUint64(&s.droppedTotal)
+}
+
+// eventLogWriter writes events to the Windows Event Log. It is implemented by *eventlog.Log.
+type eventLogWriter interface {
+	Info(eid uint32, msg string) error
+	Warning(eid uint32, msg string) error
+	Close() error
+}
+
+// eventLogAuditLogSink writes the audit log entries to the Windows Event Log. The entries of the
+// allowed packets are informational events, and the ones of the dropped and rejected packets are
+// warning events.
+type eventLogAuditLogSink struct {
+	writer eventLogWriter
+}
+
+func (s *eventLogAuditLogSink) write(ob *logInfo) error {
+	msg := ob.eventMessage()
+	switch ob.disposition {
+	case "Allow":
+		return s.writer.Info(auditLogEventIDAllow, msg)
+	case "Pass":
+		return s.writer.Info(auditLogEventIDPass, msg)
+	case "Drop":
+		return s.writer.Warning(auditLogEventIDDrop, msg)
+	case "Reject":
+		return s.writer.Warning(auditLogEventIDReject, msg)
+	default:
+		return s.writer.Warning(auditLogEventIDUnknown, msg)
+	}
+}
+
+func (s *eventLogAuditLogSink) close() error {
+	return s.writer.Close()
+}
+
+// initLogger is called while newing Antrea network policy agent controller.
+// It initializes antreaPolicyLogSink specifically for Antrea Policies audit
+// logging: the audit logs are written to the Windows Event Log if toEventLog
+// is true, and to np.log in logDir otherwise, formatted by encoder. logDir
+// defaults to the "networkpolicy" subdirectory of the agent log directory. The
+// audit logs are also exported with exporter if it's not nil. The audit logs
+// are written by a single goroutine, as they are logged by concurrent
+// packet-in handlers.
+func initLogger(toEventLog bool, logDir string, encoder auditLogEncoder, exporter AuditLogExporter) error {
+	sink, err := newAuditLogSink(toEventLog, logDir, encoder)
+	if err != nil {
+		condition.Set(crdv1beta1.AuditLoggingReady, corev1.ConditionFalse, "InitializationFailed", err.Error())
+		return err
+	}
+	if exporter != nil {
+		sink = &exporterAuditLogSink{auditLogSink: sink, exporter: exporter}
+	}
+	antreaPolicyLogSinkMutex.Lock()
+	antreaPolicyLogSink = newAsyncAuditLogSink(sink, auditLogBufferSize)
+	antreaPolicyLogSinkMutex.Unlock()
+	condition.Set(crdv1beta1.AuditLoggingReady, corev1.ConditionTrue, "", "")
+	return nil
+}
+
+// reconfigureLogger is called when the audit logging configuration of the agent is reloaded. It
+// replaces antreaPolicyLogSink with a sink writing to the new destination, and closes the previous
+// sink once the entries it has queued are written. antreaPolicyLogSink is kept if the new sink
+// cannot be created.
+func reconfigureLogger(toEventLog bool, logDir string, encoder auditLogEncoder, exporter AuditLogExporter) error {
+	sink, err := newAuditLogSink(toEventLog, logDir, encoder)
+	if err != nil {
+		return err
+	}
+	if exporter != nil {
+		sink = &exporterAuditLogSink{auditLogSink: sink, exporter: exporter}
+	}
+	antreaPolicyLogSinkMutex.Lock()
+	oldSink := antreaPolicyLogSink
+	antreaPolicyLogSink = newAsyncAuditLogSink(sink, auditLogBufferSize)
+	antreaPolicyLogSinkMutex.Unlock()
+	condition.Set(crdv1beta1.AuditLoggingReady, corev1.ConditionTrue, "", "")
+	if oldSink != nil {
+		if err := oldSink.close(); err != nil {
+			klog.Errorf("Failed to close the previous output of Antrea-native Policy Logger: %v", err)
+		}
+	}
+	return nil
+}
+
+// getLogSink returns the current antreaPolicyLogSink.
+func getLogSink() auditLogSink {
+	antreaPolicyLogSinkMutex.RLock()
+	defer antreaPolicyLogSinkMutex.RUnlock()
+	return antreaPolicyLogSink
+}
+
+// newAuditLogSink returns the sink writing the audit logs to the Windows Event Log if toEventLog is
+// true, and to np.log in logDir formatted by encoder otherwise.
+func newAuditLogSink(toEventLog bool, logDir string, encoder auditLogEncoder) (auditLogSink, error) {
+	if toEventLog {
+		writer, err := openEventLog()
+		if err != nil {
+			return nil, fmt.Errorf("failed to open the Windows Event Log for audit logging: %v", err)
+		}
+		klog.V(2).Infof("Initialized Antrea-native Policy Logger for audit logging with event source '%s'", auditLogEventSource)
+		return &eventLogAuditLogSink{writer: writer}, nil
+	}
+	if logDir == "" {
+		logDir = filepath.Join(logdir.GetLogDir(), logfileSubdir)
+	}
+	fileSink, err := newFileAuditLogSink(logDir, encoder)
+	if err != nil {
+		return nil, err
+	}
+	return fileSink, nil
+}
+
+// closeLogger is called when Antrea network policy agent controller stops.
+// It closes antreaPolicyLogSink so that the audit logs are persisted before
+// the agent exits.
+func closeLogger() {
+	sink := getLogSink()
+	if sink == nil {
+		return
+	}
+	if err := sink.close(); err != nil {
+		klog.Errorf("Failed to close the output of Antrea-native Policy Logger: %v", err)
+	}
+}
@@ -0,0 +1,322 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package networkpolicy
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"antrea.io/antrea/pkg/agent/otelexporter"
+)
+
+type fakeEvent struct {
+	warning bool
+	eid     uint32
+	msg     string
+}
+
+// fakeEventLogWriter records the events written to it instead of writing them to the Windows Event
+// Log.
+type fakeEventLogWriter struct {
+	events []fakeEvent
+	closed bool
+}
+
+func (w *fakeEventLogWriter) Info(eid uint32, msg string) error {
+	w.events = append(w.events, fakeEvent{eid: eid, msg: msg})
+	return nil
+}
+
+func (w *fakeEventLogWriter) Warning(eid uint32, msg string) error {
+	w.events = append(w.events, fakeEvent{warning: true, eid: eid, msg: msg})
+	return nil
+}
+
+func (w *fakeEventLogWriter) Close() error {
+	w.closed = true
+	return nil
+}
+
+// fakeAuditLogExporter records the audit log entries exported to it.
+type fakeAuditLogExporter struct {
+	records []*otelexporter.AuditLogRecord
+}
+
+func (e *fakeAuditLogExporter) ExportAuditLog(record *otelexporter.AuditLogRecord) {
+	e.records = append(e.records, record)
+}
+
+func TestEventLogAuditLogSink(t *testing.T) {
+	newLogInfo := func(disposition string) *logInfo {
+		return &logInfo{
+			tableName:   "AntreaPolicyIngressRule",
+			npRef:       "AntreaNetworkPolicy:default/test-anp",
+			ruleName:    "allow-web",
+			disposition: disposition,
+			ofPriority:  "44900",
+			srcIP:       "10.10.0.4",
+			destIP:      "10.10.0.5",
+			pktLength:   60,
+			protocolStr: "TCP",
+			srcPort:     34567,
+			destPort:    80,
+
+			hasTransportHeader: true,
+		}
+	}
+	tests := []struct {
+		disposition   string
+		expectedEvent fakeEvent
+	}{
+		{disposition: "Allow", expectedEvent: fakeEvent{eid: auditLogEventIDAllow}},
+		{disposition: "Pass", expectedEvent: fakeEvent{eid: auditLogEventIDPass}},
+		{disposition: "Drop", expectedEvent: fakeEvent{warning: true, eid: auditLogEventIDDrop}},
+		{disposition: "Reject", expectedEvent: fakeEvent{warning: true, eid: auditLogEventIDReject}},
+		{disposition: "Unknown(7)", expectedEvent: fakeEvent{warning: true, eid: auditLogEventIDUnknown}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.disposition, func(t *testing.T) {
+			writer := &fakeEventLogWriter{}
+			sink := &eventLogAuditLogSink{writer: writer}
+			require.NoError(t, sink.write(newLogInfo(tt.disposition)))
+			expectedEvent := tt.expectedEvent
+			expectedEvent.msg = "Table: AntreaPolicyIngressRule\n" +
+				"Policy: AntreaNetworkPolicy:default/test-anp\n" +
+				"Rule: allow-web\n" +
+				"Action: " + tt.disposition + "\n" +
+				"Priority: 44900\n" +
+				"Source: 10.10.0.4\n" +
+				"Destination: 10.10.0.5\n" +
+				"Length: 60\n" +
+				"Protocol: TCP\n" +
+				"Source port: 34567\n" +
+				"Destination port: 80"
+			assert.Equal(t, []fakeEvent{expectedEvent}, writer.events)
+			require.NoError(t, sink.close())
+			assert.True(t, writer.closed)
+		})
+	}
+}
+
+func TestLogInfoString(t *testing.T) {
+	ob := &logInfo{tableName: "AntreaPolicyIngressRule", npRef: "AntreaNetworkPolicy:default/test-anp", disposition: "Allow", ofPriority: "44900", srcIP: "10.10.0.4", destIP: "10.10.0.5", pktLength: 60, protocolStr: "TCP"}
+	// The ports are unknown without transport header.
+	assert.Equal(t, "AntreaPolicyIngressRule AntreaNetworkPolicy:default/test-anp Allow 44900 SRC: 10.10.0.4 DEST: 10.10.0.5 60 TCP - -", ob.String())
+	ob.ruleName = "allow-web"
+	assert.Equal(t, "AntreaPolicyIngressRule AntreaNetworkPolicy:default/test-anp Allow 44900 SRC: 10.10.0.4 DEST: 10.10.0.5 60 TCP - - allow-web", ob.String())
+	ob.srcPort, ob.destPort, ob.hasTransportHeader = 34567, 80, true
+	assert.Equal(t, "AntreaPolicyIngressRule AntreaNetworkPolicy:default/test-anp Allow 44900 SRC: 10.10.0.4 DEST: 10.10.0.5 60 TCP 34567 80 allow-web", ob.String())
+	// The ICMP type and code are logged instead of the ports.
+	ob = &logInfo{tableName: "AntreaPolicyEgressRule", npRef: "AntreaClusterNetworkPolicy:acnp1", ruleName: "allow-ping", disposition: "Allow", ofPriority: "44800", srcIP: "10.10.0.4", destIP: "10.10.0.5", pktLength: 84, protocolStr: "ICMP", icmpType: 8, hasTransportHeader: true}
+	assert.Equal(t, "AntreaPolicyEgressRule AntreaClusterNetworkPolicy:acnp1 Allow 44800 SRC: 10.10.0.4 DEST: 10.10.0.5 84 ICMP 8 0 allow-ping", ob.String())
+}
+
+func TestAuditLogEncoders(t *testing.T) {
+	ob := &logInfo{
+		tableName:   "AntreaPolicyIngressRule",
+		npRef:       "AntreaNetworkPolicy:default/test anp",
+		ruleName:    "allow-web",
+		disposition: "Allow",
+		ofPriority:  "44900",
+		srcIP:       "10.10.0.4",
+		srcPort:     34567,
+		destIP:      "10.10.0.5",
+		destPort:    80,
+		pktLength:   60,
+		protocolStr: "TCP",
+
+		hasTransportHeader: true,
+	}
+	ts := time.Date(2021, 5, 1, 10, 30, 0, 123456000, time.UTC)
+	tests := []struct {
+		name          string
+		encoder       auditLogEncoder
+		packetCount   int
+		expectedEntry string
+	}{
+		{
+			name:          "text",
+			encoder:       textAuditLogEncoder{},
+			expectedEntry: "2021/05/01 10:30:00.123456 AntreaPolicyIngressRule AntreaNetworkPolicy:default/test anp Allow 44900 SRC: 10.10.0.4 DEST: 10.10.0.5 60 TCP 34567 80 allow-web",
+		},
+		{
+			name:          "text aggregated",
+			encoder:       textAuditLogEncoder{},
+			packetCount:   10,
+			expectedEntry: "2021/05/01 10:30:00.123456 AntreaPolicyIngressRule AntreaNetworkPolicy:default/test anp Allow 44900 SRC: 10.10.0.4 DEST: 10.10.0.5 60 TCP 34567 80 allow-web [10 packets]",
+		},
+		{
+			// The policy name containing a space is a single JSON value.
+			name:          "json",
+			encoder:       jsonAuditLogEncoder{},
+			expectedEntry: `{"timestamp":"2021-05-01T10:30:00.123456Z","table":"AntreaPolicyIngressRule","policy":"AntreaNetworkPolicy:default/test anp","rule":"allow-web","disposition":"Allow","ofPriority":"44900","srcIP":"10.10.0.4","srcPort":34567,"destIP":"10.10.0.5","destPort":80,"pktLength":60,"protocol":"TCP"}`,
+		},
+		{
+			name:          "json aggregated",
+			encoder:       jsonAuditLogEncoder{},
+			packetCount:   10,
+			expectedEntry: `{"timestamp":"2021-05-01T10:30:00.123456Z","table":"AntreaPolicyIngressRule","policy":"AntreaNetworkPolicy:default/test anp","rule":"allow-web","disposition":"Allow","ofPriority":"44900","srcIP":"10.10.0.4","srcPort":34567,"destIP":"10.10.0.5","destPort":80,"pktLength":60,"protocol":"TCP","packetCount":10}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entryOb := *ob
+			entryOb.packetCount = tt.packetCount
+			entry, err := tt.encoder.encode(&entryOb, ts)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedEntry, entry)
+		})
+	}
+}
+
+func TestJSONAuditLogEncoderEscaping(t *testing.T) {
+	ob := &logInfo{tableName: "AntreaPolicyIngressRule", npRef: `AntreaClusterNetworkPolicy:"quoted" policy`, disposition: "Drop", ofPriority: "44900", srcIP: "10.10.0.4", destIP: "10.10.0.5", pktLength: 60, protocolStr: "UDP"}
+	entry, err := jsonAuditLogEncoder{}.encode(ob, time.Now())
+	require.NoError(t, err)
+	var decoded map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(entry), &decoded))
+	assert.Equal(t, `AntreaClusterNetworkPolicy:"quoted" policy`, decoded["policy"])
+	// The rule name and the ports are omitted when unknown.
+	assert.NotContains(t, decoded, "rule")
+	assert.NotContains(t, decoded, "srcPort")
+	assert.NotContains(t, decoded, "destPort")
+}
+
+func TestJSONAuditLogEncoderICMP(t *testing.T) {
+	// An echo reply has type 0 and code 0, which are still written.
+	ob := &logInfo{tableName: "AntreaPolicyEgressRule", npRef: "AntreaClusterNetworkPolicy:acnp1", disposition: "Allow", ofPriority: "44800", srcIP: "10.10.0.5", destIP: "10.10.0.4", pktLength: 84, protocolStr: "ICMP", hasTransportHeader: true}
+	entry, err := jsonAuditLogEncoder{}.encode(ob, time.Now())
+	require.NoError(t, err)
+	assert.Contains(t, entry, `"icmpType":0,"icmpCode":0,`)
+	assert.NotContains(t, entry, "Port")
+}
+
+func TestInitLoggerFileJSON(t *testing.T) {
+	defer func(sink auditLogSink) { antreaPolicyLogSink = sink }(antreaPolicyLogSink)
+	root, err := ioutil.TempDir("", "antrea-audit")
+	require.NoError(t, err)
+	defer os.RemoveAll(root)
+
+	require.NoError(t, initLogger(false, root, newAuditLogEncoder(true), nil))
+	require.NoError(t, antreaPolicyLogSink.write(&logInfo{tableName: "IngressDefaultRule", npRef: "K8sDefaultDrop", disposition: "Drop", ofPriority: "200", srcIP: "1.1.1.1", destIP: "2.2.2.2", pktLength: 1, protocolStr: "TCP"}))
+	closeLogger()
+	data, err := ioutil.ReadFile(filepath.Join(root, logfileName))
+	require.NoError(t, err)
+	// Each entry is a JSON object on its own line, without the prefix of the text format.
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 1)
+	var decoded map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &decoded))
+	assert.Equal(t, "K8sDefaultDrop", decoded["policy"])
+	assert.Equal(t, "IngressDefaultRule", decoded["table"])
+}
+
+func TestInitLoggerFile(t *testing.T) {
+	defer func(sink auditLogSink) { antreaPolicyLogSink = sink }(antreaPolicyLogSink)
+	root, err := ioutil.TempDir("", "antrea-audit")
+	require.NoError(t, err)
+	defer os.RemoveAll(root)
+
+	// The missing parent directories of the log directory are created.
+	logDir := filepath.Join(root, "logs", "networkpolicy")
+	require.NoError(t, initLogger(false, logDir, textAuditLogEncoder{}, nil))
+	require.NoError(t, antreaPolicyLogSink.write(&logInfo{tableName: "IngressDefaultRule", npRef: "K8sDefaultDrop", disposition: "Drop", ofPriority: "200", srcIP: "1.1.1.1", destIP: "2.2.2.2", pktLength: 1, protocolStr: "TCP"}))
+	closeLogger()
+	data, err := ioutil.ReadFile(filepath.Join(logDir, logfileName))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "IngressDefaultRule K8sDefaultDrop Drop 200 SRC: 1.1.1.1 DEST: 2.2.2.2 1 TCP")
+}
+
+func TestReconfigureLogger(t *testing.T) {
+	defer func(sink auditLogSink) { antreaPolicyLogSink = sink }(antreaPolicyLogSink)
+	root, err := ioutil.TempDir("", "antrea-audit")
+	require.NoError(t, err)
+	defer os.RemoveAll(root)
+
+	ob := &logInfo{tableName: "IngressDefaultRule", npRef: "K8sDefaultDrop", disposition: "Drop", ofPriority: "200", srcIP: "1.1.1.1", destIP: "2.2.2.2", pktLength: 1, protocolStr: "TCP"}
+	oldLogDir := filepath.Join(root, "old")
+	newLogDir := filepath.Join(root, "new")
+	require.NoError(t, initLogger(false, oldLogDir, textAuditLogEncoder{}, nil))
+	require.NoError(t, getLogSink().write(ob))
+	oldSink := getLogSink()
+	require.NoError(t, reconfigureLogger(false, newLogDir, textAuditLogEncoder{}, nil))
+	assert.NotSame(t, oldSink, getLogSink())
+	// The entries queued before the reconfiguration are written to the previous log file.
+	data, err := ioutil.ReadFile(filepath.Join(oldLogDir, logfileName))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "IngressDefaultRule K8sDefaultDrop Drop 200 SRC: 1.1.1.1 DEST: 2.2.2.2 1 TCP")
+
+	ob.srcIP = "3.3.3.3"
+	require.NoError(t, getLogSink().write(ob))
+	closeLogger()
+	data, err = ioutil.ReadFile(filepath.Join(newLogDir, logfileName))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "SRC: 3.3.3.3")
+	data, err = ioutil.ReadFile(filepath.Join(oldLogDir, logfileName))
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "SRC: 3.3.3.3")
+}
+
+func TestReconfigureLoggerFailure(t *testing.T) {
+	defer func(sink auditLogSink) { antreaPolicyLogSink = sink }(antreaPolicyLogSink)
+	root, err := ioutil.TempDir("", "antrea-audit")
+	require.NoError(t, err)
+	defer os.RemoveAll(root)
+
+	require.NoError(t, initLogger(false, root, textAuditLogEncoder{}, nil))
+	defer closeLogger()
+	sink := getLogSink()
+	// The log directory cannot be created under a file.
+	file := filepath.Join(root, "file")
+	require.NoError(t, ioutil.WriteFile(file, nil, 0644))
+	require.Error(t, reconfigureLogger(false, filepath.Join(file, "networkpolicy"), textAuditLogEncoder{}, nil))
+	assert.Same(t, sink, getLogSink())
+}
+
+func TestExporterAuditLogSink(t *testing.T) {
+	writer := &fakeEventLogWriter{}
+	exporter := &fakeAuditLogExporter{}
+	sink := &exporterAuditLogSink{auditLogSink: &eventLogAuditLogSink{writer: writer}, exporter: exporter}
+	ob := &logInfo{tableName: "AntreaPolicyIngressRule", npRef: "AntreaNetworkPolicy:default/test-anp", ruleName: "allow-web", disposition: "Allow", ofPriority: "44900", srcIP: "10.10.0.4", destIP: "10.10.0.5", pktLength: 60, protocolStr: "TCP"}
+	require.NoError(t, sink.write(ob))
+	// The entry is both written to the wrapped sink and exported.
+	assert.Len(t, writer.events, 1)
+	require.Len(t, exporter.records, 1)
+	record := exporter.records[0]
+	assert.False(t, record.Timestamp.IsZero())
+	record.Timestamp = time.Time{}
+	assert.Equal(t, &otelexporter.AuditLogRecord{
+		TableName:     "AntreaPolicyIngressRule",
+		NetworkPolicy: "AntreaNetworkPolicy:default/test-anp",
+		RuleName:      "allow-web",
+		Disposition:   "Allow",
+		OFPriority:    "44900",
+		SourceIP:      "10.10.0.4",
+		DestinationIP: "10.10.0.5",
+		PacketLength:  60,
+		Protocol:      "TCP",
+		Message:       ob.String(),
+	}, record)
+	require.NoError(t, sink.close())
+	assert.True(t, writer.closed)
+}
@@ -0,0 +1,41 @@
+// +build windows
+
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package networkpolicy
+
+import (
+	"golang.org/x/sys/windows/registry"
+	"golang.org/x/sys/windows/svc/eventlog"
+)
+
+// eventSourceKeyName is the registry key of the event sources of the Application log.
+const eventSourceKeyName = `SYSTEM\CurrentControlSet\Services\EventLog\Application`
+
+// openEventLog registers the audit log event source if it isn't registered yet, e.g. by a previous
+// run of the agent, and opens it.
+func openEventLog() (eventLogWriter, error) {
+	key, err := registry.OpenKey(registry.LOCAL_MACHINE, eventSourceKeyName+`\`+auditLogEventSource, registry.QUERY_VALUE)
+	if err == nil {
+		key.Close()
+	} else if err == registry.ErrNotExist {
+		if err := eventlog.InstallAsEventCreate(auditLogEventSource, eventlog.Info|eventlog.Warning); err != nil {
+			return nil, err
+		}
+	} else {
+		return nil, err
+	}
+	return eventlog.Open(auditLogEventSource)
+}
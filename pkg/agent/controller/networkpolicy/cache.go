@@ -19,12 +19,11 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"sync"
+	"time"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/sets"
-	"k8s.io/client-go/tools/cache"
 	"k8s.io/klog/v2"
 
 	"antrea.io/antrea/pkg/agent/metrics"
@@ -61,7 +60,8 @@ type rule struct {
 	To v1beta.NetworkPolicyPeer
 	// Protocols and Ports of this rule.
 	Services []v1beta.Service
-	// Name of this rule. Empty for k8s NetworkPolicy.
+	// Name of this rule. For the rules without name, e.g. the ones of K8s NetworkPolicies, it is
+	// the index-based identifier generated by indexRuleName.
 	Name string
 	// Action of this rule. nil for k8s NetworkPolicy.
 	Action *crdv1alpha1.RuleAction
@@ -130,42 +130,53 @@ func (r *CompletedRule) isAntreaNetworkPolicyRule() bool {
 	return r.SourceRef.Type != v1beta.K8sNetworkPolicy
 }
 
+// isLayer2Rule returns whether the rule is a layer 2 rule of a ClusterNetworkPolicy, which matches
+// the ARP or Neighbor Discovery messages sent by its target members.
+func (r *CompletedRule) isLayer2Rule() bool {
+	if len(r.Services) == 0 || r.Services[0].Protocol == nil {
+		return false
+	}
+	protocol := *r.Services[0].Protocol
+	return protocol == v1beta.ProtocolARP || protocol == v1beta.ProtocolICMPv6
+}
+
 // ruleCache caches Antrea AddressGroups, AppliedToGroups and NetworkPolicies,
 // can construct complete rules that can be used by reconciler to enforce.
+// NetworkPolicies and their rules are sharded by NetworkPolicy UID, groups are
+// sharded by group name, so that concurrent accesses to different policies or
+// groups are not serialized by a single lock.
 type ruleCache struct {
-	appliedToSetLock sync.RWMutex
 	// appliedToSetByGroup stores the AppliedToGroup members.
-	// It is a mapping from group name to a set of GroupMembers.
-	appliedToSetByGroup map[string]v1beta.GroupMemberSet
+	appliedToSetByGroup *groupStore
 
-	addressSetLock sync.RWMutex
 	// addressSetByGroup stores the AddressGroup members.
-	// It is a mapping from group name to a set of GroupMembers.
-	addressSetByGroup map[string]v1beta.GroupMemberSet
+	addressSetByGroup *groupStore
 
-	policyMapLock sync.RWMutex
-	// policyMap is a map using NetworkPolicy UID as the key.
-	// TODO: reduce its storage redundancy with rules.
-	policyMap map[string]*v1beta.NetworkPolicy
+	// policyShards stores the NetworkPolicies and their rules.
+	policyShards []*policyShard
 
-	// rules is a storage that supports listing rules using multiple indexing functions.
-	// rules is thread-safe.
-	rules cache.Indexer
-	// dirtyRuleHandler is a callback that is run upon finding a rule out-of-sync.
-	dirtyRuleHandler func(string)
+	// dirtyRuleHandler is a callback that is run upon finding a rule out-of-sync. It's given the ID
+	// of the rule and the UID of its NetworkPolicy.
+	dirtyRuleHandler func(ruleID, policyUID string)
 
 	// entityUpdates is a channel for receiving entity (e.g. Pod) updates from CNIServer.
 	entityUpdates <-chan antreatypes.EntityReference
 }
 
+func (c *ruleCache) policyShard(uid string) *policyShard {
+	return c.policyShards[shardIndex(uid, len(c.policyShards))]
+}
+
 func (c *ruleCache) getNetworkPolicies(npFilter *querier.NetworkPolicyQueryFilter) []v1beta.NetworkPolicy {
 	var ret []v1beta.NetworkPolicy
-	c.policyMapLock.RLock()
-	defer c.policyMapLock.RUnlock()
-	for _, np := range c.policyMap {
-		if c.networkPolicyMatchFilter(npFilter, np) {
-			ret = append(ret, *np)
+	for _, shard := range c.policyShards {
+		shard.lock.RLock()
+		for uid, np := range shard.policyMap {
+			if c.networkPolicyMatchFilter(npFilter, np) {
+				ret = append(ret, withLastUpdateTime(np, shard.updateTimeByPolicy[uid]))
+			}
 		}
+		shard.lock.RUnlock()
 	}
 	return ret
 }
@@ -181,36 +192,72 @@ func (c *ruleCache) networkPolicyMatchFilter(npFilter *querier.NetworkPolicyQuer
 }
 
 func (c *ruleCache) getNetworkPolicy(uid string) *v1beta.NetworkPolicy {
-	c.policyMapLock.RLock()
-	defer c.policyMapLock.RUnlock()
-	policy, exists := c.policyMap[uid]
+	shard := c.policyShard(uid)
+	shard.lock.RLock()
+	defer shard.lock.RUnlock()
+	policy, exists := shard.policyMap[uid]
 	if !exists {
 		return nil
 	}
 	return policy
 }
 
+// getNetworkPolicyWithLastUpdateTime returns a copy of the NetworkPolicy with the provided UID,
+// annotated with the time it was last received. It returns nil if the NetworkPolicy doesn't exist.
+func (c *ruleCache) getNetworkPolicyWithLastUpdateTime(uid string) *v1beta.NetworkPolicy {
+	shard := c.policyShard(uid)
+	shard.lock.RLock()
+	defer shard.lock.RUnlock()
+	policy, exists := shard.policyMap[uid]
+	if !exists {
+		return nil
+	}
+	ret := withLastUpdateTime(policy, shard.updateTimeByPolicy[uid])
+	return &ret
+}
+
+// withLastUpdateTime returns a shallow copy of the provided NetworkPolicy with the
+// LastUpdateTimeAnnotation set. The annotations of the cached NetworkPolicy are not modified.
+func withLastUpdateTime(policy *v1beta.NetworkPolicy, updateTime time.Time) v1beta.NetworkPolicy {
+	ret := *policy
+	ret.Annotations = lastUpdateTimeAnnotations(policy.Annotations, updateTime)
+	return ret
+}
+
+// lastUpdateTimeAnnotations returns a copy of the provided annotations with the
+// LastUpdateTimeAnnotation set to updateTime. The annotations are returned as they are if
+// updateTime is unknown.
+func lastUpdateTimeAnnotations(annotations map[string]string, updateTime time.Time) map[string]string {
+	if updateTime.IsZero() {
+		return annotations
+	}
+	ret := make(map[string]string, len(annotations)+1)
+	for k, v := range annotations {
+		ret[k] = v
+	}
+	ret[querier.LastUpdateTimeAnnotation] = updateTime.UTC().Format(time.RFC3339)
+	return ret
+}
+
 func (c *ruleCache) getAppliedNetworkPolicies(pod, namespace string, npFilter *querier.NetworkPolicyQueryFilter) []v1beta.NetworkPolicy {
 	var groups []string
 	memberPod := &v1beta.GroupMember{Pod: &v1beta.PodReference{Name: pod, Namespace: namespace}}
-	c.appliedToSetLock.RLock()
-	for group, memberSet := range c.appliedToSetByGroup {
+	c.appliedToSetByGroup.forEach(func(group string, memberSet v1beta.GroupMemberSet) {
 		if memberSet.Has(memberPod) {
 			groups = append(groups, group)
 		}
-	}
-	c.appliedToSetLock.RUnlock()
+	})
 
 	var policies []v1beta.NetworkPolicy
 	policyKeys := sets.NewString()
 	for _, group := range groups {
-		rules, _ := c.rules.ByIndex(appliedToGroupIndex, group)
+		rules := c.rulesByIndex(appliedToGroupIndex, group)
 		for _, ruleObj := range rules {
 			rule := ruleObj.(*rule)
 			if policyKeys.Has(string(rule.PolicyUID)) {
 				continue
 			}
-			np := c.getNetworkPolicy(string(rule.PolicyUID))
+			np := c.getNetworkPolicyWithLastUpdateTime(string(rule.PolicyUID))
 			// The Policy might be removed during the query.
 			if np == nil {
 				continue
@@ -223,8 +270,27 @@ func (c *ruleCache) getAppliedNetworkPolicies(pod, namespace string, npFilter *q
 	return policies
 }
 
+// getAppliedRuleIDs returns the IDs of the rules applied to the provided Pod.
+func (c *ruleCache) getAppliedRuleIDs(pod, namespace string) sets.String {
+	var groups []string
+	memberPod := &v1beta.GroupMember{Pod: &v1beta.PodReference{Name: pod, Namespace: namespace}}
+	c.appliedToSetByGroup.forEach(func(group string, memberSet v1beta.GroupMemberSet) {
+		if memberSet.Has(memberPod) {
+			groups = append(groups, group)
+		}
+	})
+
+	ruleIDs := sets.NewString()
+	for _, group := range groups {
+		for _, ruleObj := range c.rulesByIndex(appliedToGroupIndex, group) {
+			ruleIDs.Insert(ruleObj.(*rule).ID)
+		}
+	}
+	return ruleIDs
+}
+
 func (c *ruleCache) getEffectiveRulesByNetworkPolicy(uid string) []*rule {
-	objs, _ := c.rules.ByIndex(policyIndex, uid)
+	objs, _ := c.policyShard(uid).rules.ByIndex(policyIndex, uid)
 	if len(objs) == 0 {
 		return nil
 	}
@@ -233,17 +299,13 @@ func (c *ruleCache) getEffectiveRulesByNetworkPolicy(uid string) []*rule {
 	// A rule is considered effective when any of its AppliedToGroups can be populated.
 	isEffective := func(r *rule) bool {
 		for _, g := range r.AppliedToGroups {
-			_, exists := c.appliedToSetByGroup[g]
-			if exists {
+			if c.appliedToSetByGroup.has(g) {
 				return true
 			}
 		}
 		return false
 	}
 
-	c.appliedToSetLock.RLock()
-	defer c.appliedToSetLock.RUnlock()
-
 	for _, obj := range objs {
 		rule := obj.(*rule)
 		if isEffective(rule) {
@@ -253,38 +315,43 @@ func (c *ruleCache) getEffectiveRulesByNetworkPolicy(uid string) []*rule {
 	return rules
 }
 
+// rulesByIndex returns the rules of all NetworkPolicies matching the provided index.
+func (c *ruleCache) rulesByIndex(indexName, indexedValue string) []interface{} {
+	var objs []interface{}
+	for _, shard := range c.policyShards {
+		shardObjs, _ := shard.rules.ByIndex(indexName, indexedValue)
+		objs = append(objs, shardObjs...)
+	}
+	return objs
+}
+
 func (c *ruleCache) GetAddressGroups() []v1beta.AddressGroup {
 	var ret []v1beta.AddressGroup
-	c.addressSetLock.RLock()
-	defer c.addressSetLock.RUnlock()
-
-	for k, v := range c.addressSetByGroup {
+	c.addressSetByGroup.forEachWithUpdateTime(func(k string, v v1beta.GroupMemberSet, updateTime time.Time) {
 		var groupMembers []v1beta.GroupMember
 		for _, member := range v {
 			groupMembers = append(groupMembers, *member)
 		}
 		ret = append(ret, v1beta.AddressGroup{
-			ObjectMeta:   metav1.ObjectMeta{Name: k},
+			ObjectMeta:   metav1.ObjectMeta{Name: k, Annotations: lastUpdateTimeAnnotations(nil, updateTime)},
 			GroupMembers: groupMembers,
 		})
-	}
+	})
 	return ret
 }
 
 func (c *ruleCache) GetAppliedToGroups() []v1beta.AppliedToGroup {
 	var ret []v1beta.AppliedToGroup
-	c.appliedToSetLock.RLock()
-	defer c.appliedToSetLock.RUnlock()
-	for k, v := range c.appliedToSetByGroup {
+	c.appliedToSetByGroup.forEachWithUpdateTime(func(k string, v v1beta.GroupMemberSet, updateTime time.Time) {
 		var groupMembers []v1beta.GroupMember
 		for _, member := range v.Items() {
 			groupMembers = append(groupMembers, *member)
 		}
 		ret = append(ret, v1beta.AppliedToGroup{
-			ObjectMeta:   metav1.ObjectMeta{Name: k},
+			ObjectMeta:   metav1.ObjectMeta{Name: k, Annotations: lastUpdateTimeAnnotations(nil, updateTime)},
 			GroupMembers: groupMembers,
 		})
-	}
+	})
 	return ret
 }
 
@@ -319,19 +386,22 @@ func policyIndexFunc(obj interface{}) ([]string, error) {
 }
 
 // newRuleCache returns a new *ruleCache.
-func newRuleCache(dirtyRuleHandler func(string), podUpdate <-chan antreatypes.EntityReference) *ruleCache {
-	rules := cache.NewIndexer(
-		ruleKeyFunc,
-		cache.Indexers{addressGroupIndex: addressGroupIndexFunc, appliedToGroupIndex: appliedToGroupIndexFunc, policyIndex: policyIndexFunc},
-	)
+func newRuleCache(dirtyRuleHandler func(ruleID, policyUID string), podUpdate <-chan antreatypes.EntityReference) *ruleCache {
+	return newRuleCacheWithShards(dirtyRuleHandler, podUpdate, ruleCacheShardNum)
+}
+
+// newRuleCacheWithShards returns a new *ruleCache with the provided number of shards.
+func newRuleCacheWithShards(dirtyRuleHandler func(ruleID, policyUID string), podUpdate <-chan antreatypes.EntityReference, shardNum int) *ruleCache {
 	cache := &ruleCache{
-		appliedToSetByGroup: make(map[string]v1beta.GroupMemberSet),
-		addressSetByGroup:   make(map[string]v1beta.GroupMemberSet),
-		policyMap:           make(map[string]*v1beta.NetworkPolicy),
-		rules:               rules,
+		appliedToSetByGroup: newGroupStore(appliedToGroupCacheName, shardNum),
+		addressSetByGroup:   newGroupStore(addressGroupCacheName, shardNum),
+		policyShards:        make([]*policyShard, shardNum),
 		dirtyRuleHandler:    dirtyRuleHandler,
 		entityUpdates:       podUpdate,
 	}
+	for i := range cache.policyShards {
+		cache.policyShards[i] = newPolicyShard()
+	}
 	go cache.processEntityUpdates()
 	return cache
 }
@@ -347,52 +417,33 @@ func (c *ruleCache) processEntityUpdates() {
 	for {
 		select {
 		case entity := <-c.entityUpdates:
-			func() {
-				member := &v1beta.GroupMember{
-					Pod:            entity.Pod,
-					ExternalEntity: entity.ExternalEntity,
-				}
-				c.appliedToSetLock.RLock()
-				defer c.appliedToSetLock.RUnlock()
-				for group, memberSet := range c.appliedToSetByGroup {
-					if memberSet.Has(member) {
-						c.onAppliedToGroupUpdate(group)
-					}
+			member := &v1beta.GroupMember{
+				Pod:            entity.Pod,
+				ExternalEntity: entity.ExternalEntity,
+			}
+			c.appliedToSetByGroup.forEach(func(group string, memberSet v1beta.GroupMemberSet) {
+				if memberSet.Has(member) {
+					c.onAppliedToGroupUpdate(group)
 				}
-			}()
+			})
 		}
 	}
 }
 
 // GetAddressGroupNum gets the number of AddressGroup.
 func (c *ruleCache) GetAddressGroupNum() int {
-	c.addressSetLock.RLock()
-	defer c.addressSetLock.RUnlock()
-
-	return len(c.addressSetByGroup)
+	return c.addressSetByGroup.len()
 }
 
 // ReplaceAddressGroups atomically adds the given groups to the cache and deletes
 // the pre-existing groups that are not in the given groups from the cache.
 // It makes the cache in sync with the apiserver when restarting a watch.
 func (c *ruleCache) ReplaceAddressGroups(groups []*v1beta.AddressGroup) {
-	c.addressSetLock.Lock()
-	defer c.addressSetLock.Unlock()
-
-	oldGroupKeys := make(sets.String, len(c.addressSetByGroup))
-	for key := range c.addressSetByGroup {
-		oldGroupKeys.Insert(key)
-	}
-
+	memberSets := make(map[string]v1beta.GroupMemberSet, len(groups))
 	for _, group := range groups {
-		oldGroupKeys.Delete(group.Name)
-		c.addAddressGroupLocked(group)
-	}
-
-	for key := range oldGroupKeys {
-		delete(c.addressSetByGroup, key)
+		memberSets[group.Name] = newGroupMemberSet(group.GroupMembers)
 	}
-	return
+	c.addressSetByGroup.replace(memberSets, c.onAddressGroupUpdate)
 }
 
 // AddAddressGroup adds a new *v1beta.AddressGroup to the cache. The rules
@@ -400,49 +451,62 @@ func (c *ruleCache) ReplaceAddressGroups(groups []*v1beta.AddressGroup) {
 // It's safe to add an AddressGroup multiple times as it only overrides the
 // map, this could happen when the watcher reconnects to the Apiserver.
 func (c *ruleCache) AddAddressGroup(group *v1beta.AddressGroup) error {
-	c.addressSetLock.Lock()
-	defer c.addressSetLock.Unlock()
+	c.addressSetByGroup.set(group.Name, newGroupMemberSet(group.GroupMembers), c.onAddressGroupUpdate)
+	return nil
+}
 
-	return c.addAddressGroupLocked(group)
+// normalizeGroupMember returns a copy of the provided GroupMember which only
+// holds the fields the agent needs to realize rules. The copy is allocated
+// individually so that the cache never retains the slices of the watch events
+// it was built from: storing the address of an element would keep the whole
+// backing array of the event alive, including members that were removed later
+// via patches.
+func normalizeGroupMember(member *v1beta.GroupMember) *v1beta.GroupMember {
+	normalized := &v1beta.GroupMember{}
+	if member.Pod != nil {
+		normalized.Pod = &v1beta.PodReference{Name: member.Pod.Name, Namespace: member.Pod.Namespace}
+	} else if member.ExternalEntity != nil {
+		normalized.ExternalEntity = &v1beta.ExternalEntityReference{Name: member.ExternalEntity.Name, Namespace: member.ExternalEntity.Namespace}
+	}
+	if len(member.IPs) > 0 {
+		normalized.IPs = make([]v1beta.IPAddress, len(member.IPs))
+		for i := range member.IPs {
+			normalized.IPs[i] = append(v1beta.IPAddress(nil), member.IPs[i]...)
+		}
+	}
+	if len(member.Ports) > 0 {
+		normalized.Ports = append([]v1beta.NamedPort(nil), member.Ports...)
+	}
+	return normalized
 }
 
-func (c *ruleCache) addAddressGroupLocked(group *v1beta.AddressGroup) error {
-	groupMemberSet := v1beta.GroupMemberSet{}
-	for i := range group.GroupMembers {
-		// Must not store address of loop iterator variable as it's the same
-		// address taking different values in each loop iteration, otherwise
-		// groupMemberSet would eventually contain only the last value.
-		// https://github.com/golang/go/wiki/CommonMistakes#using-reference-to-loop-iterator-variable
-		groupMemberSet.Insert(&group.GroupMembers[i])
+// newGroupMemberSet builds a GroupMemberSet holding normalized copies of the
+// provided GroupMembers.
+func newGroupMemberSet(members []v1beta.GroupMember) v1beta.GroupMemberSet {
+	memberSet := make(v1beta.GroupMemberSet, len(members))
+	for i := range members {
+		memberSet.Insert(normalizeGroupMember(&members[i]))
 	}
+	return memberSet
+}
 
-	oldGroupMemberSet, exists := c.addressSetByGroup[group.Name]
-	if exists && oldGroupMemberSet.Equal(groupMemberSet) {
-		return nil
+// patchGroupMemberSet applies the added and removed GroupMembers of a patch to
+// the provided GroupMemberSet. Added members are normalized before insertion.
+func patchGroupMemberSet(memberSet v1beta.GroupMemberSet, added, removed []v1beta.GroupMember) {
+	for i := range added {
+		memberSet.Insert(normalizeGroupMember(&added[i]))
+	}
+	for i := range removed {
+		memberSet.Delete(&removed[i])
 	}
-	c.addressSetByGroup[group.Name] = groupMemberSet
-	c.onAddressGroupUpdate(group.Name)
-	return nil
 }
 
 // PatchAddressGroup updates a cached *v1beta.AddressGroup.
 // The rules referencing it will be regarded as dirty.
 func (c *ruleCache) PatchAddressGroup(patch *v1beta.AddressGroupPatch) error {
-	c.addressSetLock.Lock()
-	defer c.addressSetLock.Unlock()
-
-	groupMemberSet, exists := c.addressSetByGroup[patch.Name]
-	if !exists {
+	if !c.addressSetByGroup.patch(patch.Name, patch.AddedGroupMembers, patch.RemovedGroupMembers, c.onAddressGroupUpdate) {
 		return fmt.Errorf("AddressGroup %v doesn't exist in cache, can't be patched", patch.Name)
 	}
-	for i := range patch.AddedGroupMembers {
-		groupMemberSet.Insert(&patch.AddedGroupMembers[i])
-	}
-	for i := range patch.RemovedGroupMembers {
-		groupMemberSet.Delete(&patch.RemovedGroupMembers[i])
-	}
-
-	c.onAddressGroupUpdate(patch.Name)
 	return nil
 }
 
@@ -450,42 +514,24 @@ func (c *ruleCache) PatchAddressGroup(patch *v1beta.AddressGroupPatch) error {
 // It should only happen when a group is no longer referenced by any rule, so
 // no need to mark dirty rules.
 func (c *ruleCache) DeleteAddressGroup(group *v1beta.AddressGroup) error {
-	c.addressSetLock.Lock()
-	defer c.addressSetLock.Unlock()
-
-	delete(c.addressSetByGroup, group.Name)
+	c.addressSetByGroup.delete(group.Name)
 	return nil
 }
 
 // GetAppliedToGroupNum gets the number of AppliedToGroup.
 func (c *ruleCache) GetAppliedToGroupNum() int {
-	c.appliedToSetLock.RLock()
-	defer c.appliedToSetLock.RUnlock()
-
-	return len(c.appliedToSetByGroup)
+	return c.appliedToSetByGroup.len()
 }
 
 // ReplaceAppliedToGroups atomically adds the given groups to the cache and deletes
 // the pre-existing groups that are not in the given groups from the cache.
 // It makes the cache in sync with the apiserver when restarting a watch.
 func (c *ruleCache) ReplaceAppliedToGroups(groups []*v1beta.AppliedToGroup) {
-	c.appliedToSetLock.Lock()
-	defer c.appliedToSetLock.Unlock()
-
-	oldGroupKeys := make(sets.String, len(c.appliedToSetByGroup))
-	for key := range c.appliedToSetByGroup {
-		oldGroupKeys.Insert(key)
-	}
-
+	memberSets := make(map[string]v1beta.GroupMemberSet, len(groups))
 	for _, group := range groups {
-		oldGroupKeys.Delete(group.Name)
-		c.addAppliedToGroupLocked(group)
-	}
-
-	for key := range oldGroupKeys {
-		delete(c.appliedToSetByGroup, key)
+		memberSets[group.Name] = newGroupMemberSet(group.GroupMembers)
 	}
-	return
+	c.appliedToSetByGroup.replace(memberSets, c.onAppliedToGroupUpdate)
 }
 
 // AddAppliedToGroup adds a new *v1beta.AppliedToGroup to the cache. The rules
@@ -493,59 +539,40 @@ func (c *ruleCache) ReplaceAppliedToGroups(groups []*v1beta.AppliedToGroup) {
 // It's safe to add an AppliedToGroup multiple times as it only overrides the
 // map, this could happen when the watcher reconnects to the Apiserver.
 func (c *ruleCache) AddAppliedToGroup(group *v1beta.AppliedToGroup) error {
-	c.appliedToSetLock.Lock()
-	defer c.appliedToSetLock.Unlock()
-
-	return c.addAppliedToGroupLocked(group)
-}
-
-func (c *ruleCache) addAppliedToGroupLocked(group *v1beta.AppliedToGroup) error {
-	memberSet := v1beta.GroupMemberSet{}
-	for i := range group.GroupMembers {
-		memberSet.Insert(&group.GroupMembers[i])
-	}
-	oldMemberSet, exists := c.appliedToSetByGroup[group.Name]
-	if exists && oldMemberSet.Equal(memberSet) {
-		return nil
-	}
-	c.appliedToSetByGroup[group.Name] = memberSet
-	c.onAppliedToGroupUpdate(group.Name)
+	c.appliedToSetByGroup.set(group.Name, newGroupMemberSet(group.GroupMembers), c.onAppliedToGroupUpdate)
 	return nil
 }
 
 // PatchAppliedToGroup updates a cached *v1beta.AppliedToGroupPatch.
 // The rules referencing it will be regarded as dirty.
 func (c *ruleCache) PatchAppliedToGroup(patch *v1beta.AppliedToGroupPatch) error {
-	c.appliedToSetLock.Lock()
-	defer c.appliedToSetLock.Unlock()
-
-	memberSet, exists := c.appliedToSetByGroup[patch.Name]
-	if !exists {
+	if !c.appliedToSetByGroup.patch(patch.Name, patch.AddedGroupMembers, patch.RemovedGroupMembers, c.onAppliedToGroupUpdate) {
 		return fmt.Errorf("AppliedToGroup %v doesn't exist in cache, can't be patched", patch.Name)
 	}
-	for i := range patch.AddedGroupMembers {
-		memberSet.Insert(&patch.AddedGroupMembers[i])
-	}
-	for i := range patch.RemovedGroupMembers {
-		memberSet.Delete(&patch.RemovedGroupMembers[i])
-	}
-	c.onAppliedToGroupUpdate(patch.Name)
 	return nil
 }
 
 // DeleteAppliedToGroup deletes a cached *v1beta.AppliedToGroup.
 // It may be called when a rule becomes ineffective, so it needs to mark dirty rules.
 func (c *ruleCache) DeleteAppliedToGroup(group *v1beta.AppliedToGroup) error {
-	c.appliedToSetLock.Lock()
-	defer c.appliedToSetLock.Unlock()
-
-	delete(c.appliedToSetByGroup, group.Name)
+	c.appliedToSetByGroup.delete(group.Name)
 	c.onAppliedToGroupUpdate(group.Name)
 	return nil
 }
 
-// toRule converts v1beta.NetworkPolicyRule to *rule.
-func toRule(r *v1beta.NetworkPolicyRule, policy *v1beta.NetworkPolicy, maxPriority int32) *rule {
+// indexRuleName returns the identifier of the rules without name, e.g. the rules of K8s
+// NetworkPolicies: the direction of the rule and its index among the rules of the policy with the
+// same direction.
+func indexRuleName(direction v1beta.Direction, index int) string {
+	if direction == v1beta.DirectionIn {
+		return fmt.Sprintf("ingress-%d", index)
+	}
+	return fmt.Sprintf("egress-%d", index)
+}
+
+// toRule converts v1beta.NetworkPolicyRule to *rule. index is the index of r among the rules of
+// policy with the same direction, which identifies r if it has no name.
+func toRule(r *v1beta.NetworkPolicyRule, policy *v1beta.NetworkPolicy, maxPriority int32, index int) *rule {
 	appliedToGroups := policy.AppliedToGroups
 	if len(r.AppliedToGroups) != 0 {
 		appliedToGroups = r.AppliedToGroups
@@ -566,6 +593,11 @@ func toRule(r *v1beta.NetworkPolicyRule, policy *v1beta.NetworkPolicy, maxPriori
 		EnableLogging:   r.EnableLogging,
 	}
 	rule.ID = hashRule(rule)
+	// The identifier is set after the ID is calculated, so that the rules are still identified
+	// by their content, and reordering them doesn't trigger any reconciliation.
+	if rule.Name == "" {
+		rule.Name = indexRuleName(r.Direction, index)
+	}
 	rule.PolicyName = policy.Name
 	rule.MaxPriority = maxPriority
 	return rule
@@ -588,22 +620,31 @@ func getMaxPriority(policy *v1beta.NetworkPolicy) int32 {
 
 // GetNetworkPolicyNum gets the number of NetworkPolicy.
 func (c *ruleCache) GetNetworkPolicyNum() int {
-	c.policyMapLock.RLock()
-	defer c.policyMapLock.RUnlock()
-
-	return len(c.policyMap)
+	n := 0
+	for _, shard := range c.policyShards {
+		shard.lock.RLock()
+		n += len(shard.policyMap)
+		shard.lock.RUnlock()
+	}
+	return n
 }
 
 // ReplaceNetworkPolicies atomically adds the given policies to the cache and deletes
 // the pre-existing policies that are not in the given policies from the cache.
 // It makes the cache in sync with the apiserver when restarting a watch.
 func (c *ruleCache) ReplaceNetworkPolicies(policies []*v1beta.NetworkPolicy) {
-	c.policyMapLock.Lock()
-	defer c.policyMapLock.Unlock()
+	// The shards are always locked in the same order, no other code path holds more than one
+	// shard lock at a time.
+	for _, shard := range c.policyShards {
+		shard.lock.Lock()
+		defer shard.lock.Unlock()
+	}
 
-	oldKeys := make(sets.String, len(c.policyMap))
-	for key := range c.policyMap {
-		oldKeys.Insert(key)
+	oldKeys := make(sets.String)
+	for _, shard := range c.policyShards {
+		for key := range shard.policyMap {
+			oldKeys.Insert(key)
+		}
 	}
 
 	for i := range policies {
@@ -612,11 +653,11 @@ func (c *ruleCache) ReplaceNetworkPolicies(policies []*v1beta.NetworkPolicy) {
 		} else {
 			metrics.NetworkPolicyCount.Inc()
 		}
-		c.updateNetworkPolicyLocked(policies[i])
+		c.updateNetworkPolicyLocked(c.policyShard(string(policies[i].UID)), policies[i])
 	}
 
 	for key := range oldKeys {
-		c.deleteNetworkPolicyLocked(key)
+		c.deleteNetworkPolicyLocked(c.policyShard(key), key)
 	}
 	return
 }
@@ -627,57 +668,65 @@ func (c *ruleCache) ReplaceNetworkPolicies(policies []*v1beta.NetworkPolicy) {
 // UpdateNetworkPolicy to ensure orphan rules are removed.
 func (c *ruleCache) AddNetworkPolicy(policy *v1beta.NetworkPolicy) error {
 	metrics.NetworkPolicyCount.Inc()
-	c.policyMapLock.Lock()
-	defer c.policyMapLock.Unlock()
-	return c.updateNetworkPolicyLocked(policy)
+	shard := c.policyShard(string(policy.UID))
+	shard.lock.Lock()
+	defer shard.lock.Unlock()
+	return c.updateNetworkPolicyLocked(shard, policy)
 }
 
 // UpdateNetworkPolicy updates a cached *v1beta.NetworkPolicy.
 // The added rules and removed rules will be regarded as dirty.
 func (c *ruleCache) UpdateNetworkPolicy(policy *v1beta.NetworkPolicy) error {
-	c.policyMapLock.Lock()
-	defer c.policyMapLock.Unlock()
-	return c.updateNetworkPolicyLocked(policy)
-}
-
-func (c *ruleCache) updateNetworkPolicyLocked(policy *v1beta.NetworkPolicy) error {
-	c.policyMap[string(policy.UID)] = policy
-	existingRules, _ := c.rules.ByIndex(policyIndex, string(policy.UID))
+	shard := c.policyShard(string(policy.UID))
+	shard.lock.Lock()
+	defer shard.lock.Unlock()
+	return c.updateNetworkPolicyLocked(shard, policy)
+}
+
+// updateNetworkPolicyLocked updates a NetworkPolicy and its rules in the provided shard, which
+// must be the one of the NetworkPolicy and must be locked.
+func (c *ruleCache) updateNetworkPolicyLocked(shard *policyShard, policy *v1beta.NetworkPolicy) error {
+	shard.policyMap[string(policy.UID)] = policy
+	shard.updateTimeByPolicy[string(policy.UID)] = time.Now()
+	existingRules, _ := shard.rules.ByIndex(policyIndex, string(policy.UID))
 	ruleByID := map[string]interface{}{}
 	for _, r := range existingRules {
 		ruleByID[r.(*rule).ID] = r
 	}
 
 	maxPriority := getMaxPriority(policy)
+	ruleIndexes := map[v1beta.Direction]int{}
 	for i := range policy.Rules {
-		r := toRule(&policy.Rules[i], policy, maxPriority)
+		direction := policy.Rules[i].Direction
+		r := toRule(&policy.Rules[i], policy, maxPriority, ruleIndexes[direction])
+		ruleIndexes[direction]++
 		if _, exists := ruleByID[r.ID]; exists {
 			// If rule already exists, remove it from the map so the ones left finally are orphaned.
 			klog.V(2).Infof("Rule %v was not changed", r.ID)
 			delete(ruleByID, r.ID)
 		} else {
 			// If rule doesn't exist, add it to cache, mark it as dirty.
-			c.rules.Add(r)
+			shard.rules.Add(r)
 			// Count up antrea_agent_ingress_networkpolicy_rule_count or antrea_agent_egress_networkpolicy_rule_count
 			if r.Direction == v1beta.DirectionIn {
 				metrics.IngressNetworkPolicyRuleCount.Inc()
 			} else {
 				metrics.EgressNetworkPolicyRuleCount.Inc()
 			}
-			c.dirtyRuleHandler(r.ID)
+			c.dirtyRuleHandler(r.ID, string(policy.UID))
 		}
 	}
 
 	// At this moment, the remaining rules are orphaned, remove them from store and mark them as dirty.
 	for ruleID, r := range ruleByID {
-		c.rules.Delete(r)
+		shard.rules.Delete(r)
 		// Count down antrea_agent_ingress_networkpolicy_rule_count or antrea_agent_egress_networkpolicy_rule_count
 		if r.(*rule).Direction == v1beta.DirectionIn {
 			metrics.IngressNetworkPolicyRuleCount.Dec()
 		} else {
 			metrics.EgressNetworkPolicyRuleCount.Dec()
 		}
-		c.dirtyRuleHandler(ruleID)
+		c.dirtyRuleHandler(ruleID, string(policy.UID))
 	}
 	return nil
 }
@@ -685,15 +734,19 @@ func (c *ruleCache) updateNetworkPolicyLocked(policy *v1beta.NetworkPolicy) erro
 // DeleteNetworkPolicy deletes a cached *v1beta.NetworkPolicy.
 // All its rules will be regarded as dirty.
 func (c *ruleCache) DeleteNetworkPolicy(policy *v1beta.NetworkPolicy) error {
-	c.policyMapLock.Lock()
-	defer c.policyMapLock.Unlock()
+	shard := c.policyShard(string(policy.UID))
+	shard.lock.Lock()
+	defer shard.lock.Unlock()
 
-	return c.deleteNetworkPolicyLocked(string(policy.UID))
+	return c.deleteNetworkPolicyLocked(shard, string(policy.UID))
 }
 
-func (c *ruleCache) deleteNetworkPolicyLocked(uid string) error {
-	delete(c.policyMap, uid)
-	existingRules, _ := c.rules.ByIndex(policyIndex, uid)
+// deleteNetworkPolicyLocked deletes a NetworkPolicy and its rules from the provided shard, which
+// must be the one of the NetworkPolicy and must be locked.
+func (c *ruleCache) deleteNetworkPolicyLocked(shard *policyShard, uid string) error {
+	delete(shard.policyMap, uid)
+	delete(shard.updateTimeByPolicy, uid)
+	existingRules, _ := shard.rules.ByIndex(policyIndex, uid)
 	for _, r := range existingRules {
 		ruleID := r.(*rule).ID
 		// Count down antrea_agent_ingress_networkpolicy_rule_count or antrea_agent_egress_networkpolicy_rule_count
@@ -702,13 +755,25 @@ func (c *ruleCache) deleteNetworkPolicyLocked(uid string) error {
 		} else {
 			metrics.EgressNetworkPolicyRuleCount.Dec()
 		}
-		c.rules.Delete(r)
-		c.dirtyRuleHandler(ruleID)
+		shard.rules.Delete(r)
+		c.dirtyRuleHandler(ruleID, uid)
 	}
 	metrics.NetworkPolicyCount.Dec()
 	return nil
 }
 
+// getRule returns the rule with the provided ID. As rule IDs don't reveal the
+// NetworkPolicies they belong to, all shards are looked up.
+func (c *ruleCache) getRule(ruleID string) (*rule, bool) {
+	for _, shard := range c.policyShards {
+		obj, exists, _ := shard.rules.GetByKey(ruleID)
+		if exists {
+			return obj.(*rule), true
+		}
+	}
+	return nil, false
+}
+
 // GetCompletedRule constructs a *CompletedRule for the provided ruleID.
 // If the rule is not effective or not realizable due to missing group data, the return value will indicate it.
 // A rule is considered effective when any of its AppliedToGroups can be populated.
@@ -723,13 +788,11 @@ func (c *ruleCache) deleteNetworkPolicyLocked(uid string) error {
 // In these cases, it is not guaranteed that all AppliedToGroups in the rule will eventually be present in the cache.
 // Only the AppliedToGroups whose span includes this Node will eventually be received.
 func (c *ruleCache) GetCompletedRule(ruleID string) (completedRule *CompletedRule, effective bool, realizable bool) {
-	obj, exists, _ := c.rules.GetByKey(ruleID)
+	r, exists := c.getRule(ruleID)
 	if !exists {
 		return nil, false, false
 	}
 
-	r := obj.(*rule)
-
 	groupMembers, anyExists := c.unionAppliedToGroups(r.AppliedToGroups)
 	if !anyExists {
 		return nil, false, false
@@ -758,18 +821,24 @@ func (c *ruleCache) GetCompletedRule(ruleID string) (completedRule *CompletedRul
 // onAppliedToGroupUpdate gets rules referencing to the provided AppliedToGroup
 // and mark them as dirty.
 func (c *ruleCache) onAppliedToGroupUpdate(groupName string) {
-	ruleIDs, _ := c.rules.IndexKeys(appliedToGroupIndex, groupName)
-	for _, ruleID := range ruleIDs {
-		c.dirtyRuleHandler(ruleID)
+	for _, shard := range c.policyShards {
+		rules, _ := shard.rules.ByIndex(appliedToGroupIndex, groupName)
+		for _, obj := range rules {
+			r := obj.(*rule)
+			c.dirtyRuleHandler(r.ID, string(r.PolicyUID))
+		}
 	}
 }
 
 // onAddressGroupUpdate gets rules referencing to the provided AddressGroup
 // and mark them as dirty.
 func (c *ruleCache) onAddressGroupUpdate(groupName string) {
-	ruleIDs, _ := c.rules.IndexKeys(addressGroupIndex, groupName)
-	for _, ruleID := range ruleIDs {
-		c.dirtyRuleHandler(ruleID)
+	for _, shard := range c.policyShards {
+		rules, _ := shard.rules.ByIndex(addressGroupIndex, groupName)
+		for _, obj := range rules {
+			r := obj.(*rule)
+			c.dirtyRuleHandler(r.ID, string(r.PolicyUID))
+		}
 	}
 }
 
@@ -777,17 +846,10 @@ func (c *ruleCache) onAddressGroupUpdate(groupName string) {
 // If any group is not found, nil and false will be returned to indicate the
 // set is not complete yet.
 func (c *ruleCache) unionAddressGroups(groupNames []string) (v1beta.GroupMemberSet, bool) {
-	c.addressSetLock.RLock()
-	defer c.addressSetLock.RUnlock()
-
-	set := v1beta.NewGroupMemberSet()
-	for _, groupName := range groupNames {
-		curSet, exists := c.addressSetByGroup[groupName]
-		if !exists {
-			klog.V(2).Infof("AddressGroup %v was not found", groupName)
-			return nil, false
-		}
-		set = set.Union(curSet)
+	set, missingGroups := c.addressSetByGroup.union(groupNames)
+	if len(missingGroups) > 0 {
+		klog.V(2).Infof("AddressGroups %v were not found", missingGroups)
+		return nil, false
 	}
 	return set, true
 }
@@ -795,19 +857,9 @@ func (c *ruleCache) unionAddressGroups(groupNames []string) (v1beta.GroupMemberS
 // unionAppliedToGroups gets the union of pods of the provided appliedTo groups.
 // If any group is found, the union and true will be returned. Otherwise an empty set and false will be returned.
 func (c *ruleCache) unionAppliedToGroups(groupNames []string) (v1beta.GroupMemberSet, bool) {
-	c.appliedToSetLock.RLock()
-	defer c.appliedToSetLock.RUnlock()
-
-	anyExists := false
-	set := v1beta.NewGroupMemberSet()
-	for _, groupName := range groupNames {
-		curSet, exists := c.appliedToSetByGroup[groupName]
-		if !exists {
-			klog.V(2).Infof("AppliedToGroup %v was not found", groupName)
-			continue
-		}
-		anyExists = true
-		set = set.Union(curSet)
+	set, missingGroups := c.appliedToSetByGroup.union(groupNames)
+	if len(missingGroups) > 0 {
+		klog.V(2).Infof("AppliedToGroups %v were not found", missingGroups)
 	}
-	return set, anyExists
+	return set, len(missingGroups) < len(groupNames)
 }
@@ -0,0 +1,255 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package networkpolicy
+
+import (
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"k8s.io/client-go/tools/cache"
+
+	"antrea.io/antrea/pkg/agent/metrics"
+	v1beta "antrea.io/antrea/pkg/apis/controlplane/v1beta2"
+)
+
+const (
+	// ruleCacheShardNum is the default number of shards the NetworkPolicies and groups of
+	// ruleCache are distributed to. Reconciliation workers and API queriers accessing different
+	// shards don't contend for the same lock.
+	ruleCacheShardNum = 16
+
+	// The values of the "cache" label of the lock wait metric.
+	policyCacheName         = "policy"
+	appliedToGroupCacheName = "appliedtogroup"
+	addressGroupCacheName   = "addressgroup"
+)
+
+// shardIndex returns the index of the shard the provided key belongs to.
+func shardIndex(key string, shardNum int) int {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return int(h.Sum32() % uint32(shardNum))
+}
+
+// cacheLock is a sync.RWMutex which reports the time spent waiting for it.
+type cacheLock struct {
+	sync.RWMutex
+	// cacheName is the value of the "cache" label of the wait metric.
+	cacheName string
+}
+
+func (l *cacheLock) Lock() {
+	start := time.Now()
+	l.RWMutex.Lock()
+	l.observeWait(start)
+}
+
+func (l *cacheLock) RLock() {
+	start := time.Now()
+	l.RWMutex.RLock()
+	l.observeWait(start)
+}
+
+func (l *cacheLock) observeWait(start time.Time) {
+	metrics.NetworkPolicyCacheLockWaitDuration.WithLabelValues(l.cacheName).Observe(float64(time.Since(start).Microseconds()))
+}
+
+// policyShard holds the NetworkPolicies whose UIDs belong to it, and their rules.
+type policyShard struct {
+	lock cacheLock
+	// policyMap is a map using NetworkPolicy UID as the key.
+	// TODO: reduce its storage redundancy with rules.
+	policyMap map[string]*v1beta.NetworkPolicy
+	// updateTimeByPolicy is a map from NetworkPolicy UID to the time it was last received.
+	updateTimeByPolicy map[string]time.Time
+	// rules is a storage that supports listing rules using multiple indexing functions.
+	// rules is thread-safe, it can be accessed without holding lock.
+	rules cache.Indexer
+}
+
+func newPolicyShard() *policyShard {
+	return &policyShard{
+		lock:               cacheLock{cacheName: policyCacheName},
+		policyMap:          make(map[string]*v1beta.NetworkPolicy),
+		updateTimeByPolicy: make(map[string]time.Time),
+		rules: cache.NewIndexer(
+			ruleKeyFunc,
+			cache.Indexers{addressGroupIndex: addressGroupIndexFunc, appliedToGroupIndex: appliedToGroupIndexFunc, policyIndex: policyIndexFunc},
+		),
+	}
+}
+
+// groupShard holds the members of the groups whose names belong to it.
+type groupShard struct {
+	lock cacheLock
+	// memberSetByGroup is a mapping from group name to a set of GroupMembers.
+	memberSetByGroup map[string]v1beta.GroupMemberSet
+	// updateTimeByGroup is a mapping from group name to the time it was last received, even if
+	// its members didn't change.
+	updateTimeByGroup map[string]time.Time
+}
+
+// setLocked sets the members of a group and calls onUpdate if they changed.
+func (s *groupShard) setLocked(groupName string, memberSet v1beta.GroupMemberSet, onUpdate func(string)) {
+	s.updateTimeByGroup[groupName] = time.Now()
+	oldMemberSet, exists := s.memberSetByGroup[groupName]
+	if exists && oldMemberSet.Equal(memberSet) {
+		return
+	}
+	s.memberSetByGroup[groupName] = memberSet
+	onUpdate(groupName)
+}
+
+// deleteLocked deletes a group.
+func (s *groupShard) deleteLocked(groupName string) {
+	delete(s.memberSetByGroup, groupName)
+	delete(s.updateTimeByGroup, groupName)
+}
+
+// groupStore stores the members of AddressGroups or AppliedToGroups, sharded by group name.
+type groupStore struct {
+	shards []*groupShard
+}
+
+func newGroupStore(cacheName string, shardNum int) *groupStore {
+	s := &groupStore{shards: make([]*groupShard, shardNum)}
+	for i := range s.shards {
+		s.shards[i] = &groupShard{
+			lock:              cacheLock{cacheName: cacheName},
+			memberSetByGroup:  make(map[string]v1beta.GroupMemberSet),
+			updateTimeByGroup: make(map[string]time.Time),
+		}
+	}
+	return s
+}
+
+func (s *groupStore) shard(groupName string) *groupShard {
+	return s.shards[shardIndex(groupName, len(s.shards))]
+}
+
+// has returns whether the provided group exists.
+func (s *groupStore) has(groupName string) bool {
+	shard := s.shard(groupName)
+	shard.lock.RLock()
+	defer shard.lock.RUnlock()
+	_, exists := shard.memberSetByGroup[groupName]
+	return exists
+}
+
+// len returns the number of groups.
+func (s *groupStore) len() int {
+	n := 0
+	for _, shard := range s.shards {
+		shard.lock.RLock()
+		n += len(shard.memberSetByGroup)
+		shard.lock.RUnlock()
+	}
+	return n
+}
+
+// forEach calls fn for each group, holding the read lock of the group's shard. fn must not modify
+// the GroupMemberSet.
+func (s *groupStore) forEach(fn func(groupName string, memberSet v1beta.GroupMemberSet)) {
+	for _, shard := range s.shards {
+		shard.lock.RLock()
+		for groupName, memberSet := range shard.memberSetByGroup {
+			fn(groupName, memberSet)
+		}
+		shard.lock.RUnlock()
+	}
+}
+
+// forEachWithUpdateTime is like forEach, but also provides the time each group was last received.
+func (s *groupStore) forEachWithUpdateTime(fn func(groupName string, memberSet v1beta.GroupMemberSet, updateTime time.Time)) {
+	for _, shard := range s.shards {
+		shard.lock.RLock()
+		for groupName, memberSet := range shard.memberSetByGroup {
+			fn(groupName, memberSet, shard.updateTimeByGroup[groupName])
+		}
+		shard.lock.RUnlock()
+	}
+}
+
+// union gets the union of the members of the provided groups. The groups which don't exist are
+// returned as well.
+func (s *groupStore) union(groupNames []string) (v1beta.GroupMemberSet, []string) {
+	set := v1beta.NewGroupMemberSet()
+	var missingGroups []string
+	for _, groupName := range groupNames {
+		shard := s.shard(groupName)
+		shard.lock.RLock()
+		if curSet, exists := shard.memberSetByGroup[groupName]; exists {
+			set = set.Union(curSet)
+		} else {
+			missingGroups = append(missingGroups, groupName)
+		}
+		shard.lock.RUnlock()
+	}
+	return set, missingGroups
+}
+
+// set sets the members of a group and calls onUpdate if they changed.
+func (s *groupStore) set(groupName string, memberSet v1beta.GroupMemberSet, onUpdate func(string)) {
+	shard := s.shard(groupName)
+	shard.lock.Lock()
+	defer shard.lock.Unlock()
+	shard.setLocked(groupName, memberSet, onUpdate)
+}
+
+// replace atomically sets the members of the provided groups and deletes the other groups.
+// onUpdate is called for each provided group whose members changed.
+func (s *groupStore) replace(memberSets map[string]v1beta.GroupMemberSet, onUpdate func(string)) {
+	// The shards are always locked in the same order, no other code path holds more than one
+	// shard lock at a time.
+	for _, shard := range s.shards {
+		shard.lock.Lock()
+		defer shard.lock.Unlock()
+	}
+	for _, shard := range s.shards {
+		for groupName := range shard.memberSetByGroup {
+			if _, exists := memberSets[groupName]; !exists {
+				shard.deleteLocked(groupName)
+			}
+		}
+	}
+	for groupName, memberSet := range memberSets {
+		s.shard(groupName).setLocked(groupName, memberSet, onUpdate)
+	}
+}
+
+// patch applies the added and removed GroupMembers to a group and calls onUpdate. It returns
+// false if the group doesn't exist.
+func (s *groupStore) patch(groupName string, added, removed []v1beta.GroupMember, onUpdate func(string)) bool {
+	shard := s.shard(groupName)
+	shard.lock.Lock()
+	defer shard.lock.Unlock()
+	memberSet, exists := shard.memberSetByGroup[groupName]
+	if !exists {
+		return false
+	}
+	shard.updateTimeByGroup[groupName] = time.Now()
+	patchGroupMemberSet(memberSet, added, removed)
+	onUpdate(groupName)
+	return true
+}
+
+// delete deletes a group.
+func (s *groupStore) delete(groupName string) {
+	shard := s.shard(groupName)
+	shard.lock.Lock()
+	defer shard.lock.Unlock()
+	shard.deleteLocked(groupName)
+}
@@ -15,17 +15,23 @@
 package networkpolicy
 
 import (
+	"fmt"
 	"net"
 	"reflect"
+	"runtime"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/sets"
 
 	"antrea.io/antrea/pkg/agent/types"
 	"antrea.io/antrea/pkg/apis/controlplane/v1beta2"
+	"antrea.io/antrea/pkg/querier"
 )
 
 var (
@@ -175,7 +181,7 @@ func newDirtyRuleRecorder() *dirtyRuleRecorder {
 	return &dirtyRuleRecorder{sets.NewString(), make(chan string, 100)}
 }
 
-func (r *dirtyRuleRecorder) Record(ruleID string) {
+func (r *dirtyRuleRecorder) Record(ruleID, _ string) {
 	r.rules.Insert(ruleID)
 	r.eventCh <- ruleID
 }
@@ -192,6 +198,37 @@ func newAddressGroupMember(ips ...string) *v1beta2.GroupMember {
 	return &v1beta2.GroupMember{IPs: ipAddrs}
 }
 
+func TestToRuleName(t *testing.T) {
+	policy := &v1beta2.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{UID: "policy1", Namespace: "ns1", Name: "name1"},
+		SourceRef: &v1beta2.NetworkPolicyReference{
+			Type:      v1beta2.AntreaNetworkPolicy,
+			Namespace: "ns1",
+			Name:      "name1",
+			UID:       "policy1",
+		},
+	}
+	namedRule := &v1beta2.NetworkPolicyRule{
+		Direction: v1beta2.DirectionIn,
+		From:      v1beta2.NetworkPolicyPeer{AddressGroups: []string{"addressGroup1"}},
+		Name:      "allow-web",
+	}
+	unnamedIngressRule := &v1beta2.NetworkPolicyRule{
+		Direction: v1beta2.DirectionIn,
+		From:      v1beta2.NetworkPolicyPeer{AddressGroups: []string{"addressGroup2"}},
+	}
+	unnamedEgressRule := &v1beta2.NetworkPolicyRule{
+		Direction: v1beta2.DirectionOut,
+		To:        v1beta2.NetworkPolicyPeer{AddressGroups: []string{"addressGroup2"}},
+	}
+	assert.Equal(t, "allow-web", toRule(namedRule, policy, 0, 0).Name)
+	assert.Equal(t, "ingress-1", toRule(unnamedIngressRule, policy, 0, 1).Name)
+	assert.Equal(t, "egress-0", toRule(unnamedEgressRule, policy, 0, 0).Name)
+	// The index-based name must not change the ID of the rule, so that reordering the rules of a
+	// policy doesn't trigger any reconciliation.
+	assert.Equal(t, toRule(unnamedIngressRule, policy, 0, 0).ID, toRule(unnamedIngressRule, policy, 0, 1).ID)
+}
+
 func TestRuleCacheAddAddressGroup(t *testing.T) {
 	rule1 := &rule{
 		ID:   "rule1",
@@ -243,14 +280,14 @@ func TestRuleCacheAddAddressGroup(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			c, recorder, _ := newFakeRuleCache()
 			for _, rule := range tt.rules {
-				c.rules.Add(rule)
+				c.addRules(rule)
 			}
 			c.AddAddressGroup(tt.args)
 
 			if !recorder.rules.Equal(tt.expectedDirtyRules) {
 				t.Errorf("Got dirty rules %v, expected %v", recorder.rules, tt.expectedDirtyRules)
 			}
-			actualAddresses, exists := c.addressSetByGroup[tt.args.Name]
+			actualAddresses, exists := c.addressSetByGroup.get(tt.args.Name)
 			if !exists {
 				t.Fatalf("AddressGroup %s not found", tt.args.Name)
 			}
@@ -266,6 +303,43 @@ func newFakeRuleCache() (*ruleCache, *dirtyRuleRecorder, chan types.EntityRefere
 	return c, recorder, ch
 }
 
+// addRules adds the provided rules to the shards of their NetworkPolicies.
+func (c *ruleCache) addRules(rules ...*rule) {
+	for _, r := range rules {
+		c.policyShard(string(r.PolicyUID)).rules.Add(r)
+	}
+}
+
+// listRules lists the rules of all shards.
+func (c *ruleCache) listRules() []interface{} {
+	var rules []interface{}
+	for _, shard := range c.policyShards {
+		rules = append(rules, shard.rules.List()...)
+	}
+	return rules
+}
+
+// setMemberSets sets the members of the provided groups without marking any rule as dirty.
+func (s *groupStore) setMemberSets(memberSets map[string]v1beta2.GroupMemberSet) {
+	for groupName, memberSet := range memberSets {
+		s.shard(groupName).memberSetByGroup[groupName] = memberSet
+	}
+}
+
+// memberSets returns the members of all groups, keyed by group name.
+func (s *groupStore) memberSets() map[string]v1beta2.GroupMemberSet {
+	memberSets := map[string]v1beta2.GroupMemberSet{}
+	s.forEach(func(groupName string, memberSet v1beta2.GroupMemberSet) {
+		memberSets[groupName] = memberSet
+	})
+	return memberSets
+}
+
+func (s *groupStore) get(groupName string) (v1beta2.GroupMemberSet, bool) {
+	memberSet, exists := s.memberSets()[groupName]
+	return memberSet, exists
+}
+
 func TestRuleCacheReplaceAppliedToGroups(t *testing.T) {
 	rule1 := &rule{
 		ID:              "rule1",
@@ -335,16 +409,16 @@ func TestRuleCacheReplaceAppliedToGroups(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			c, recorder, _ := newFakeRuleCache()
 			for _, rule := range tt.rules {
-				c.rules.Add(rule)
+				c.addRules(rule)
 			}
-			c.appliedToSetByGroup = tt.preExistingGroups
+			c.appliedToSetByGroup.setMemberSets(tt.preExistingGroups)
 			c.ReplaceAppliedToGroups(tt.args)
 
 			if !recorder.rules.Equal(tt.expectedDirtyRules) {
 				t.Errorf("Got dirty rules %v, expected %v", recorder.rules, tt.expectedDirtyRules)
 			}
-			if !reflect.DeepEqual(c.appliedToSetByGroup, tt.expectedGroups) {
-				t.Errorf("Got appliedToSetByGroup %#v, expected %#v", c.appliedToSetByGroup, tt.expectedGroups)
+			if !reflect.DeepEqual(c.appliedToSetByGroup.memberSets(), tt.expectedGroups) {
+				t.Errorf("Got appliedToSetByGroup %#v, expected %#v", c.appliedToSetByGroup.memberSets(), tt.expectedGroups)
 			}
 		})
 	}
@@ -419,16 +493,16 @@ func TestRuleCacheReplaceAddressGroups(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			c, recorder, _ := newFakeRuleCache()
 			for _, rule := range tt.rules {
-				c.rules.Add(rule)
+				c.addRules(rule)
 			}
-			c.addressSetByGroup = tt.preExistingGroups
+			c.addressSetByGroup.setMemberSets(tt.preExistingGroups)
 			c.ReplaceAddressGroups(tt.args)
 
 			if !recorder.rules.Equal(tt.expectedDirtyRules) {
 				t.Errorf("Got dirty rules %v, expected %v", recorder.rules, tt.expectedDirtyRules)
 			}
-			if !reflect.DeepEqual(c.addressSetByGroup, tt.expectedGroups) {
-				t.Errorf("Got addressSetByGroup %#v, expected %#v", c.addressSetByGroup, tt.expectedGroups)
+			if !reflect.DeepEqual(c.addressSetByGroup.memberSets(), tt.expectedGroups) {
+				t.Errorf("Got addressSetByGroup %#v, expected %#v", c.addressSetByGroup.memberSets(), tt.expectedGroups)
 			}
 		})
 	}
@@ -463,8 +537,8 @@ func TestRuleCacheReplaceNetworkPolicies(t *testing.T) {
 			UID:       "policy1",
 		},
 	}
-	rule1 := toRule(networkPolicyRule1, networkPolicy1, k8sNPMaxPriority)
-	rule2 := toRule(networkPolicyRule1, networkPolicy2, k8sNPMaxPriority)
+	rule1 := toRule(networkPolicyRule1, networkPolicy1, k8sNPMaxPriority, 0)
+	rule2 := toRule(networkPolicyRule1, networkPolicy2, k8sNPMaxPriority, 0)
 	tests := []struct {
 		name               string
 		rules              []*rule
@@ -505,15 +579,15 @@ func TestRuleCacheReplaceNetworkPolicies(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			c, recorder, _ := newFakeRuleCache()
 			for _, rule := range tt.rules {
-				c.rules.Add(rule)
-				c.policyMap[string(rule.PolicyUID)] = &v1beta2.NetworkPolicy{}
+				c.addRules(rule)
+				c.policyShard(string(rule.PolicyUID)).policyMap[string(rule.PolicyUID)] = &v1beta2.NetworkPolicy{}
 			}
 			c.ReplaceNetworkPolicies(tt.args)
 
 			if !recorder.rules.Equal(tt.expectedDirtyRules) {
 				t.Errorf("Got dirty rules %v, expected %v", recorder.rules, tt.expectedDirtyRules)
 			}
-			assert.ElementsMatch(t, tt.expectedRules, c.rules.List(), "rules not match")
+			assert.ElementsMatch(t, tt.expectedRules, c.listRules(), "rules not match")
 		})
 	}
 }
@@ -569,14 +643,14 @@ func TestRuleCacheAddAppliedToGroup(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			c, recorder, _ := newFakeRuleCache()
 			for _, rule := range tt.rules {
-				c.rules.Add(rule)
+				c.addRules(rule)
 			}
 			c.AddAppliedToGroup(tt.args)
 
 			if !recorder.rules.Equal(tt.expectedDirtyRules) {
 				t.Errorf("Got dirty rules %v, expected %v", recorder.rules, tt.expectedDirtyRules)
 			}
-			actualPods, exists := c.appliedToSetByGroup[tt.args.Name]
+			actualPods, exists := c.appliedToSetByGroup.get(tt.args.Name)
 			if !exists {
 				t.Fatalf("AppliedToGroup %s not found", tt.args.Name)
 			}
@@ -638,9 +712,9 @@ func TestRuleCacheAddNetworkPolicy(t *testing.T) {
 			UID:       "policy3",
 		},
 	}
-	rule1 := toRule(networkPolicyRule1, networkPolicy2, k8sNPMaxPriority)
-	rule2 := toRule(networkPolicyRule2, networkPolicy2, k8sNPMaxPriority)
-	rule3 := toRule(networkPolicyRule3, networkPolicy3, 0)
+	rule1 := toRule(networkPolicyRule1, networkPolicy2, k8sNPMaxPriority, 0)
+	rule2 := toRule(networkPolicyRule2, networkPolicy2, k8sNPMaxPriority, 1)
+	rule3 := toRule(networkPolicyRule3, networkPolicy3, 0, 0)
 	tests := []struct {
 		name               string
 		args               *v1beta2.NetworkPolicy
@@ -670,7 +744,7 @@ func TestRuleCacheAddNetworkPolicy(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			c, recorder, _ := newFakeRuleCache()
 			c.AddNetworkPolicy(tt.args)
-			actualRules := c.rules.List()
+			actualRules := c.listRules()
 			if !assert.ElementsMatch(t, tt.expectedRules, actualRules) {
 				t.Errorf("Got rules %v, expected %v", actualRules, tt.expectedRules)
 			}
@@ -733,11 +807,11 @@ func TestRuleCacheDeleteNetworkPolicy(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			c, recorder, _ := newFakeRuleCache()
 			for _, rule := range tt.rules {
-				c.rules.Add(rule)
+				c.addRules(rule)
 			}
 			c.DeleteNetworkPolicy(tt.args)
 
-			actualRules := c.rules.List()
+			actualRules := c.listRules()
 			if !assert.ElementsMatch(t, tt.expectedRules, actualRules) {
 				t.Errorf("Got rules %v, expected %v", actualRules, tt.expectedRules)
 			}
@@ -851,15 +925,13 @@ func TestRuleCacheGetCompletedRule(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			c, _, _ := newFakeRuleCache()
-			c.addressSetByGroup["addressGroup1"] = addressGroup1
-			c.addressSetByGroup["addressGroup2"] = addressGroup2
-			c.appliedToSetByGroup["appliedToGroup1"] = appliedToGroup1
-			c.appliedToSetByGroup["appliedToGroup2"] = appliedToGroup2
-			c.rules.Add(rule1)
-			c.rules.Add(rule2)
-			c.rules.Add(rule3)
-			c.rules.Add(rule4)
-			c.rules.Add(rule5)
+			c.addressSetByGroup.setMemberSets(map[string]v1beta2.GroupMemberSet{"addressGroup1": addressGroup1, "addressGroup2": addressGroup2})
+			c.appliedToSetByGroup.setMemberSets(map[string]v1beta2.GroupMemberSet{"appliedToGroup1": appliedToGroup1, "appliedToGroup2": appliedToGroup2})
+			c.addRules(rule1)
+			c.addRules(rule2)
+			c.addRules(rule3)
+			c.addRules(rule4)
+			c.addRules(rule5)
 
 			gotCompletedRule, gotEffective, gotRealizable := c.GetCompletedRule(tt.args)
 			if !reflect.DeepEqual(gotCompletedRule, tt.wantCompletedRule) {
@@ -935,9 +1007,9 @@ func TestRuleCachePatchAppliedToGroup(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			c, recorder, _ := newFakeRuleCache()
-			c.appliedToSetByGroup = tt.podSetByGroup
+			c.appliedToSetByGroup.setMemberSets(tt.podSetByGroup)
 			for _, rule := range tt.rules {
-				c.rules.Add(rule)
+				c.addRules(rule)
 			}
 			err := c.PatchAppliedToGroup(tt.args)
 			if (err == nil) == tt.expectedErr {
@@ -946,7 +1018,7 @@ func TestRuleCachePatchAppliedToGroup(t *testing.T) {
 			if !recorder.rules.Equal(tt.expectedDirtyRules) {
 				t.Errorf("Got dirty rules %v, expected %v", recorder.rules, tt.expectedDirtyRules)
 			}
-			actualPods, _ := c.appliedToSetByGroup[tt.args.Name]
+			actualPods, _ := c.appliedToSetByGroup.get(tt.args.Name)
 			assert.ElementsMatch(t, tt.expectedPods, actualPods.Items(), "stored Pods not equal")
 		})
 	}
@@ -1012,9 +1084,9 @@ func TestRuleCachePatchAddressGroup(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			c, recorder, _ := newFakeRuleCache()
-			c.addressSetByGroup = tt.addressSetByGroup
+			c.addressSetByGroup.setMemberSets(tt.addressSetByGroup)
 			for _, rule := range tt.rules {
-				c.rules.Add(rule)
+				c.addRules(rule)
 			}
 			err := c.PatchAddressGroup(tt.args)
 			if (err == nil) == tt.expectedErr {
@@ -1023,7 +1095,7 @@ func TestRuleCachePatchAddressGroup(t *testing.T) {
 			if !recorder.rules.Equal(tt.expectedDirtyRules) {
 				t.Errorf("Got dirty rules %v, expected %v", recorder.rules, tt.expectedDirtyRules)
 			}
-			actualAddresses, _ := c.addressSetByGroup[tt.args.Name]
+			actualAddresses, _ := c.addressSetByGroup.get(tt.args.Name)
 			assert.ElementsMatch(t, tt.expectedAddresses, actualAddresses.Items(), "stored addresses not equal")
 		})
 	}
@@ -1075,9 +1147,11 @@ func TestRuleCacheUpdateNetworkPolicy(t *testing.T) {
 			UID:       "policy1",
 		},
 	}
-	rule1 := toRule(networkPolicyRule1, networkPolicy1, k8sNPMaxPriority)
-	rule2 := toRule(networkPolicyRule1, networkPolicy2, k8sNPMaxPriority)
-	rule3 := toRule(networkPolicyRule2, networkPolicy3, k8sNPMaxPriority)
+	networkPolicy4 := networkPolicy3.DeepCopy()
+	networkPolicy4.Rules = []v1beta2.NetworkPolicyRule{*networkPolicyRule2, *networkPolicyRule1}
+	rule1 := toRule(networkPolicyRule1, networkPolicy1, k8sNPMaxPriority, 0)
+	rule2 := toRule(networkPolicyRule1, networkPolicy2, k8sNPMaxPriority, 0)
+	rule3 := toRule(networkPolicyRule2, networkPolicy3, k8sNPMaxPriority, 1)
 	tests := []struct {
 		name               string
 		rules              []*rule
@@ -1099,16 +1173,24 @@ func TestRuleCacheUpdateNetworkPolicy(t *testing.T) {
 			[]*rule{rule1, rule3},
 			sets.NewString(rule3.ID),
 		},
+		{
+			// Rules are identified by their content, reordering them must not trigger any reconciliation.
+			"reordering-rules",
+			[]*rule{rule1, rule3},
+			networkPolicy4,
+			[]*rule{rule1, rule3},
+			sets.NewString(),
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			c, recorder, _ := newFakeRuleCache()
 			for _, rule := range tt.rules {
-				c.rules.Add(rule)
+				c.addRules(rule)
 			}
 			c.UpdateNetworkPolicy(tt.args)
 
-			actualRules := c.rules.List()
+			actualRules := c.listRules()
 			if !assert.ElementsMatch(t, tt.expectedRules, actualRules) {
 				t.Errorf("Got rules %v, expected %v", actualRules, tt.expectedRules)
 			}
@@ -1163,9 +1245,9 @@ func TestRuleCacheProcessPodUpdates(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			c, recorder, ch := newFakeRuleCache()
-			c.appliedToSetByGroup = tt.podSetByGroup
+			c.appliedToSetByGroup.setMemberSets(tt.podSetByGroup)
 			for _, rule := range tt.rules {
-				c.rules.Add(rule)
+				c.addRules(rule)
 			}
 			ch <- tt.podUpdate
 
@@ -1186,3 +1268,300 @@ func TestRuleCacheProcessPodUpdates(t *testing.T) {
 		})
 	}
 }
+
+func TestRuleCachePatchNormalizedGroups(t *testing.T) {
+	rule1 := &rule{
+		ID:              "rule1",
+		From:            v1beta2.NetworkPolicyPeer{AddressGroups: []string{"addressGroup1"}},
+		AppliedToGroups: []string{"appliedToGroup1"},
+	}
+	c, _, _ := newFakeRuleCache()
+	c.addRules(rule1)
+
+	addressGroup := &v1beta2.AddressGroup{
+		ObjectMeta:   metav1.ObjectMeta{Name: "addressGroup1"},
+		GroupMembers: []v1beta2.GroupMember{*newAddressGroupMember("1.1.1.1"), *newAddressGroupMember("2.2.2.2")},
+	}
+	appliedToGroup := &v1beta2.AppliedToGroup{
+		ObjectMeta:   metav1.ObjectMeta{Name: "appliedToGroup1"},
+		GroupMembers: []v1beta2.GroupMember{*newAppliedToGroupMember("pod1", "ns1"), *newAppliedToGroupMember("pod2", "ns1")},
+	}
+	c.AddAddressGroup(addressGroup)
+	c.AddAppliedToGroup(appliedToGroup)
+
+	// The cache must not reference the received objects, mutating them must
+	// not affect the cached members.
+	addressGroup.GroupMembers[0].IPs[0][15] = 100
+	appliedToGroup.GroupMembers[0].Pod.Name = "mutated"
+
+	require.NoError(t, c.PatchAddressGroup(&v1beta2.AddressGroupPatch{
+		ObjectMeta:          metav1.ObjectMeta{Name: "addressGroup1"},
+		AddedGroupMembers:   []v1beta2.GroupMember{*newAddressGroupMember("3.3.3.3")},
+		RemovedGroupMembers: []v1beta2.GroupMember{*newAddressGroupMember("1.1.1.1")},
+	}))
+	require.NoError(t, c.PatchAppliedToGroup(&v1beta2.AppliedToGroupPatch{
+		ObjectMeta:          metav1.ObjectMeta{Name: "appliedToGroup1"},
+		AddedGroupMembers:   []v1beta2.GroupMember{*newAppliedToGroupMember("pod3", "ns1")},
+		RemovedGroupMembers: []v1beta2.GroupMember{*newAppliedToGroupMember("pod1", "ns1")},
+	}))
+
+	assert.ElementsMatch(t, []*v1beta2.GroupMember{newAddressGroupMember("2.2.2.2"), newAddressGroupMember("3.3.3.3")}, c.addressSetByGroup.memberSets()["addressGroup1"].Items())
+	assert.ElementsMatch(t, []*v1beta2.GroupMember{newAppliedToGroupMember("pod2", "ns1"), newAppliedToGroupMember("pod3", "ns1")}, c.appliedToSetByGroup.memberSets()["appliedToGroup1"].Items())
+
+	completedRule, effective, realizable := c.GetCompletedRule("rule1")
+	require.True(t, effective)
+	require.True(t, realizable)
+	assert.ElementsMatch(t, []*v1beta2.GroupMember{newAddressGroupMember("2.2.2.2"), newAddressGroupMember("3.3.3.3")}, completedRule.FromAddresses.Items())
+	assert.ElementsMatch(t, []*v1beta2.GroupMember{newAppliedToGroupMember("pod2", "ns1"), newAppliedToGroupMember("pod3", "ns1")}, completedRule.TargetMembers.Items())
+}
+
+func TestRuleCacheLastUpdateTime(t *testing.T) {
+	c, _, _ := newFakeRuleCache()
+	lastUpdateTime := func(annotations map[string]string) time.Time {
+		updateTime, err := time.Parse(time.RFC3339, annotations[querier.LastUpdateTimeAnnotation])
+		require.NoError(t, err)
+		return updateTime
+	}
+	// The annotation has a precision of one second.
+	start := time.Now().Truncate(time.Second)
+
+	addressGroup := &v1beta2.AddressGroup{
+		ObjectMeta:   metav1.ObjectMeta{Name: "addressGroup1"},
+		GroupMembers: []v1beta2.GroupMember{*newAddressGroupMember("1.1.1.1")},
+	}
+	c.AddAddressGroup(addressGroup)
+	c.AddAppliedToGroup(&v1beta2.AppliedToGroup{
+		ObjectMeta:   metav1.ObjectMeta{Name: "appliedToGroup1"},
+		GroupMembers: []v1beta2.GroupMember{*newAppliedToGroupMember("pod1", "ns1")},
+	})
+	policy := newConcurrencyTestPolicy(1, 1)
+	c.AddNetworkPolicy(policy)
+
+	addressGroups := c.GetAddressGroups()
+	require.Len(t, addressGroups, 1)
+	assert.False(t, lastUpdateTime(addressGroups[0].Annotations).Before(start))
+	appliedToGroups := c.GetAppliedToGroups()
+	require.Len(t, appliedToGroups, 1)
+	assert.False(t, lastUpdateTime(appliedToGroups[0].Annotations).Before(start))
+	policies := c.getNetworkPolicies(&querier.NetworkPolicyQueryFilter{})
+	require.Len(t, policies, 1)
+	assert.False(t, lastUpdateTime(policies[0].Annotations).Before(start))
+	// The cached NetworkPolicy must not be annotated.
+	assert.Nil(t, c.getNetworkPolicy(string(policy.UID)).Annotations)
+
+	// Receiving a group again updates its time even if its members didn't change.
+	oldTime := start.Add(-time.Hour)
+	c.addressSetByGroup.shard("addressGroup1").updateTimeByGroup["addressGroup1"] = oldTime
+	c.AddAddressGroup(addressGroup)
+	assert.False(t, lastUpdateTime(c.GetAddressGroups()[0].Annotations).Before(start))
+
+	c.addressSetByGroup.shard("addressGroup1").updateTimeByGroup["addressGroup1"] = oldTime
+	require.NoError(t, c.PatchAddressGroup(&v1beta2.AddressGroupPatch{
+		ObjectMeta:        metav1.ObjectMeta{Name: "addressGroup1"},
+		AddedGroupMembers: []v1beta2.GroupMember{*newAddressGroupMember("2.2.2.2")},
+	}))
+	assert.False(t, lastUpdateTime(c.GetAddressGroups()[0].Annotations).Before(start))
+
+	c.DeleteAddressGroup(addressGroup)
+	assert.NotContains(t, c.addressSetByGroup.shard("addressGroup1").updateTimeByGroup, "addressGroup1")
+	c.DeleteNetworkPolicy(policy)
+	assert.NotContains(t, c.policyShard(string(policy.UID)).updateTimeByPolicy, string(policy.UID))
+}
+
+// BenchmarkRuleCacheAddAddressGroup measures the heap retained by the cache
+// after receiving an AddressGroup with 20k members and a patch removing half
+// of them, with the received objects dropped as the watcher would do.
+func BenchmarkRuleCacheAddAddressGroup(b *testing.B) {
+	const memberNum = 20000
+	newAddressGroup := func() *v1beta2.AddressGroup {
+		members := make([]v1beta2.GroupMember, memberNum)
+		for i := range members {
+			members[i] = v1beta2.GroupMember{
+				Pod: &v1beta2.PodReference{Name: fmt.Sprintf("pod-%d", i), Namespace: "ns"},
+				IPs: []v1beta2.IPAddress{v1beta2.IPAddress(net.IPv4(10, byte(i>>16), byte(i>>8), byte(i)))},
+			}
+		}
+		return &v1beta2.AddressGroup{ObjectMeta: metav1.ObjectMeta{Name: "group1"}, GroupMembers: members}
+	}
+	heapAlloc := func() int64 {
+		var m runtime.MemStats
+		runtime.GC()
+		runtime.ReadMemStats(&m)
+		return int64(m.HeapAlloc)
+	}
+
+	b.ReportAllocs()
+	var totalRetained int64
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		before := heapAlloc()
+		c, _, _ := newFakeRuleCache()
+		b.StartTimer()
+		c.AddAddressGroup(newAddressGroup())
+		c.PatchAddressGroup(&v1beta2.AddressGroupPatch{ObjectMeta: metav1.ObjectMeta{Name: "group1"}, RemovedGroupMembers: newAddressGroup().GroupMembers[:memberNum/2]})
+		b.StopTimer()
+		totalRetained += heapAlloc() - before
+		runtime.KeepAlive(c)
+		b.StartTimer()
+	}
+	b.ReportMetric(float64(totalRetained)/float64(b.N), "retained-B/op")
+}
+
+func newConcurrencyTestPolicy(i, generation int) *v1beta2.NetworkPolicy {
+	return &v1beta2.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{UID: k8stypes.UID(fmt.Sprintf("policy%d", i)), Name: fmt.Sprintf("policy%d", i)},
+		Rules: []v1beta2.NetworkPolicyRule{{
+			Direction: v1beta2.DirectionIn,
+			From:      v1beta2.NetworkPolicyPeer{AddressGroups: []string{fmt.Sprintf("addressGroup%d", i)}},
+			// The priority makes each generation a new rule.
+			Priority: int32(generation),
+		}},
+		AppliedToGroups: []string{fmt.Sprintf("appliedToGroup%d", i)},
+		SourceRef:       &v1beta2.NetworkPolicyReference{Type: v1beta2.AntreaNetworkPolicy, Namespace: "ns1", Name: fmt.Sprintf("policy%d", i)},
+	}
+}
+
+// TestRuleCacheConcurrentAccess verifies that the cache remains consistent when
+// NetworkPolicies and groups are added, updated and deleted concurrently while
+// being queried. It's meant to be run with the race detector enabled.
+func TestRuleCacheConcurrentAccess(t *testing.T) {
+	const policyNum = 64
+	const iterations = 20
+	c := newRuleCache(func(string, string) {}, make(chan types.EntityReference))
+
+	stopCh := make(chan struct{})
+	var readers sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			for {
+				select {
+				case <-stopCh:
+					return
+				default:
+				}
+				c.getNetworkPolicies(&querier.NetworkPolicyQueryFilter{Namespace: "ns1"})
+				c.getAppliedNetworkPolicies("pod1", "ns1", &querier.NetworkPolicyQueryFilter{})
+				c.GetAddressGroups()
+				c.GetAppliedToGroups()
+				c.GetNetworkPolicyNum()
+				for _, obj := range c.listRules() {
+					c.GetCompletedRule(obj.(*rule).ID)
+				}
+			}
+		}()
+	}
+
+	var writers sync.WaitGroup
+	for i := 0; i < policyNum; i++ {
+		writers.Add(1)
+		go func(i int) {
+			defer writers.Done()
+			addressGroupName := fmt.Sprintf("addressGroup%d", i)
+			appliedToGroupName := fmt.Sprintf("appliedToGroup%d", i)
+			for j := 0; j < iterations; j++ {
+				c.AddAppliedToGroup(&v1beta2.AppliedToGroup{
+					ObjectMeta:   metav1.ObjectMeta{Name: appliedToGroupName},
+					GroupMembers: []v1beta2.GroupMember{*newAppliedToGroupMember("pod1", "ns1")},
+				})
+				c.AddAddressGroup(&v1beta2.AddressGroup{
+					ObjectMeta:   metav1.ObjectMeta{Name: addressGroupName},
+					GroupMembers: []v1beta2.GroupMember{*newAddressGroupMember("1.1.1.1")},
+				})
+				c.AddNetworkPolicy(newConcurrencyTestPolicy(i, j))
+				c.UpdateNetworkPolicy(newConcurrencyTestPolicy(i, j+1))
+				c.PatchAddressGroup(&v1beta2.AddressGroupPatch{
+					ObjectMeta:        metav1.ObjectMeta{Name: addressGroupName},
+					AddedGroupMembers: []v1beta2.GroupMember{*newAddressGroupMember(fmt.Sprintf("2.2.2.%d", j))},
+				})
+				c.DeleteNetworkPolicy(newConcurrencyTestPolicy(i, j+1))
+				c.DeleteAddressGroup(&v1beta2.AddressGroup{ObjectMeta: metav1.ObjectMeta{Name: addressGroupName}})
+				c.DeleteAppliedToGroup(&v1beta2.AppliedToGroup{ObjectMeta: metav1.ObjectMeta{Name: appliedToGroupName}})
+			}
+			c.AddAppliedToGroup(&v1beta2.AppliedToGroup{
+				ObjectMeta:   metav1.ObjectMeta{Name: appliedToGroupName},
+				GroupMembers: []v1beta2.GroupMember{*newAppliedToGroupMember("pod1", "ns1")},
+			})
+			c.AddAddressGroup(&v1beta2.AddressGroup{
+				ObjectMeta:   metav1.ObjectMeta{Name: addressGroupName},
+				GroupMembers: []v1beta2.GroupMember{*newAddressGroupMember("1.1.1.1")},
+			})
+			c.AddNetworkPolicy(newConcurrencyTestPolicy(i, iterations))
+		}(i)
+	}
+	writers.Wait()
+	close(stopCh)
+	readers.Wait()
+
+	assert.Equal(t, policyNum, c.GetNetworkPolicyNum())
+	assert.Equal(t, policyNum, c.GetAddressGroupNum())
+	assert.Equal(t, policyNum, c.GetAppliedToGroupNum())
+	assert.Len(t, c.listRules(), policyNum)
+	assert.Len(t, c.getAppliedNetworkPolicies("pod1", "ns1", &querier.NetworkPolicyQueryFilter{}), policyNum)
+	for i := 0; i < policyNum; i++ {
+		rules := c.getEffectiveRulesByNetworkPolicy(fmt.Sprintf("policy%d", i))
+		require.Len(t, rules, 1)
+		assert.Equal(t, int32(iterations), rules[0].Priority)
+		completedRule, effective, realizable := c.GetCompletedRule(rules[0].ID)
+		require.True(t, effective)
+		require.True(t, realizable)
+		assert.ElementsMatch(t, []*v1beta2.GroupMember{newAddressGroupMember("1.1.1.1")}, completedRule.FromAddresses.Items())
+		assert.ElementsMatch(t, []*v1beta2.GroupMember{newAppliedToGroupMember("pod1", "ns1")}, completedRule.TargetMembers.Items())
+	}
+}
+
+// BenchmarkRuleCacheQueryWithConcurrentWorkers measures the latency of querying
+// all NetworkPolicies, as "antctl get networkpolicy" does, while 64 workers keep
+// updating NetworkPolicies and computing their rules as the reconciliation
+// workers do during a burst of policy changes. The "shards-1" case corresponds
+// to a cache guarded by a single lock.
+func BenchmarkRuleCacheQueryWithConcurrentWorkers(b *testing.B) {
+	const policyNum = 1000
+	const workerNum = 64
+	for _, shardNum := range []int{1, ruleCacheShardNum} {
+		b.Run(fmt.Sprintf("shards-%d", shardNum), func(b *testing.B) {
+			c := newRuleCacheWithShards(func(string, string) {}, make(chan types.EntityReference), shardNum)
+			for i := 0; i < policyNum; i++ {
+				c.AddAppliedToGroup(&v1beta2.AppliedToGroup{
+					ObjectMeta:   metav1.ObjectMeta{Name: fmt.Sprintf("appliedToGroup%d", i)},
+					GroupMembers: []v1beta2.GroupMember{*newAppliedToGroupMember(fmt.Sprintf("pod%d", i), "ns1")},
+				})
+				c.AddAddressGroup(&v1beta2.AddressGroup{
+					ObjectMeta:   metav1.ObjectMeta{Name: fmt.Sprintf("addressGroup%d", i)},
+					GroupMembers: []v1beta2.GroupMember{*newAddressGroupMember("1.1.1.1")},
+				})
+				c.AddNetworkPolicy(newConcurrencyTestPolicy(i, 0))
+			}
+
+			stopCh := make(chan struct{})
+			var workers sync.WaitGroup
+			for w := 0; w < workerNum; w++ {
+				workers.Add(1)
+				go func(w int) {
+					defer workers.Done()
+					for generation := 1; ; generation++ {
+						select {
+						case <-stopCh:
+							return
+						default:
+						}
+						i := (w + generation*workerNum) % policyNum
+						c.UpdateNetworkPolicy(newConcurrencyTestPolicy(i, generation))
+						for _, r := range c.getEffectiveRulesByNetworkPolicy(fmt.Sprintf("policy%d", i)) {
+							c.GetCompletedRule(r.ID)
+						}
+					}
+				}(w)
+			}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				c.getNetworkPolicies(&querier.NetworkPolicyQueryFilter{})
+			}
+			b.StopTimer()
+			close(stopCh)
+			workers.Wait()
+		})
+	}
+}
@@ -16,25 +16,36 @@ package networkpolicy
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"reflect"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
+	"golang.org/x/time/rate"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/apimachinery/pkg/watch"
+	clientset "k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog/v2"
 
 	"antrea.io/antrea/pkg/agent"
 	"antrea.io/antrea/pkg/agent/flowexporter/connections"
 	"antrea.io/antrea/pkg/agent/interfacestore"
+	"antrea.io/antrea/pkg/agent/metrics"
 	"antrea.io/antrea/pkg/agent/openflow"
 	"antrea.io/antrea/pkg/agent/types"
 	"antrea.io/antrea/pkg/apis/controlplane/v1beta2"
+	crdv1beta1 "antrea.io/antrea/pkg/apis/crd/v1beta1"
+	"antrea.io/antrea/pkg/monitor/condition"
 	"antrea.io/antrea/pkg/querier"
 )
 
@@ -44,21 +55,34 @@ const (
 	maxRetryDelay = 300 * time.Second
 	// Default number of workers processing a rule change.
 	defaultWorkers = 4
+	// Maximum number of queued rules a worker reconciles together.
+	maxRulesPerSync = 100
+	// How often the number of seconds since the last event of each watcher, and the number of
+	// rules queued for each worker, are reported.
+	metricsInterval = 10 * time.Second
 )
 
 var emptyWatch = watch.NewEmptyWatch()
 
+// openflowClient is the part of openflow.Client used by the Controller: the flows of the rules are
+// programmed by the reconciler, and the packet-in and packet-out messages are used for audit logging
+// and for rejecting connections.
+type openflowClient interface {
+	openflow.PolicyClient
+	openflow.PacketInClient
+	openflow.PacketOutClient
+}
+
 // Controller is responsible for watching Antrea AddressGroups, AppliedToGroups,
 // and NetworkPolicies, feeding them to ruleCache, getting dirty rules from
 // ruleCache, invoking reconciler to reconcile them.
 //
-//          a.Feed AddressGroups,AppliedToGroups
-//               and NetworkPolicies
-//  |-----------|    <--------    |----------- |  c. Reconcile dirty rules |----------- |
-//  | ruleCache |                 | Controller |     ------------>         | reconciler |
-//  | ----------|    -------->    |----------- |                           |----------- |
-//              b. Notify dirty rules
-//
+//	        a.Feed AddressGroups,AppliedToGroups
+//	             and NetworkPolicies
+//	|-----------|    <--------    |----------- |  c. Reconcile dirty rules |----------- |
+//	| ruleCache |                 | Controller |     ------------>         | reconciler |
+//	| ----------|    -------->    |----------- |                           |----------- |
+//	            b. Notify dirty rules
 type Controller struct {
 	// antreaPolicyEnabled indicates whether Antrea NetworkPolicy and
 	// ClusterNetworkPolicy are enabled.
@@ -67,6 +91,21 @@ type Controller struct {
 	statusManagerEnabled bool
 	// loggingEnabled indicates where Antrea policy audit logging is enabled.
 	loggingEnabled bool
+	// auditLogEncoder formats the audit logs written to np.log. The format is chosen when the
+	// controller is created, and kept when the audit logging configuration is reloaded.
+	auditLogEncoder auditLogEncoder
+	// auditLogExporter exports the audit logs in addition to the audit log sink. It is kept to
+	// create a new sink when the audit logging configuration is reloaded.
+	auditLogExporter AuditLogExporter
+	// auditLogAggregator aggregates the audit log entries of identical packets. It is nil if audit
+	// logging or the aggregation is disabled.
+	auditLogAggregator *auditLogAggregator
+	// recentAuditLogs keeps the most recent audit log entries to be queried through the agent API.
+	// It is nil if audit logging is disabled.
+	recentAuditLogs *auditLogBuffer
+	// k8sIsolationLogLimiter rate-limits the audit logging of the packets dropped by K8s
+	// NetworkPolicy isolation.
+	k8sIsolationLogLimiter *rate.Limiter
 	// antreaClientProvider provides interfaces to get antreaClient, which can be
 	// used to watch Antrea AddressGroups, AppliedToGroups, and NetworkPolicies.
 	// We need to get antreaClient dynamically because the apiserver cert can be
@@ -75,18 +114,26 @@ type Controller struct {
 	// watches won't be interrupted by rotating cert. The new client will be used
 	// after the existing watches expire.
 	antreaClientProvider agent.AntreaClientProvider
-	// queue maintains the NetworkPolicy ruleIDs that need to be synced.
-	queue workqueue.RateLimitingInterface
+	// queues maintain the NetworkPolicy ruleIDs that need to be synced, one queue per worker.
+	// The rules of a NetworkPolicy are always added to the same queue, so that the changes of a
+	// NetworkPolicy are reconciled one batch at a time, while the rules of different
+	// NetworkPolicies are reconciled in parallel by different workers.
+	queues []workqueue.RateLimitingInterface
 	// ruleCache maintains the desired state of NetworkPolicy rules.
 	ruleCache *ruleCache
 	// reconciler provides interfaces to reconcile the desired state of
 	// NetworkPolicy rules with the actual state of Openflow entries.
 	reconciler Reconciler
 	// ofClient registers packetin for Antrea Policy logging.
-	ofClient openflow.Client
+	ofClient openflowClient
 	// statusManager syncs NetworkPolicy statuses with the antrea-controller.
 	// It's only for Antrea NetworkPolicies.
-	statusManager         StatusManager
+	statusManager StatusManager
+	// realizationErrors keeps track of the rules which failed to be realized.
+	realizationErrors *realizationErrorRegistry
+	// podReadiness sets the readiness gate condition of the local Pods which declare it. It's nil
+	// if no K8s client is provided.
+	podReadiness          *podReadinessController
 	networkPolicyWatcher  *watcher
 	appliedToGroupWatcher *watcher
 	addressGroupWatcher   *watcher
@@ -94,32 +141,65 @@ type Controller struct {
 	ifaceStore            interfacestore.InterfaceStore
 	// denyConnStore is for storing deny connections for flow exporter.
 	denyConnStore *connections.DenyConnectionStore
+	// rejectUDPWithPortUnreachable indicates whether the UDP datagrams rejected by Antrea-native
+	// policy rules are answered with ICMP port unreachable instead of administratively prohibited.
+	rejectUDPWithPortUnreachable bool
+	// bootstrapper removes the flows installed to fail closed at startup after the first sync. It's nil if the agent
+	// fails open.
+	bootstrapper *policyBootstrapper
+	// resyncInProgress is the on-demand resync in progress, nil if there is none.
+	resyncInProgress *resyncRequest
+	// resyncLock protects resyncInProgress.
+	resyncLock sync.Mutex
 }
 
 // NewNetworkPolicyController returns a new *Controller.
 func NewNetworkPolicyController(antreaClientGetter agent.AntreaClientProvider,
-	ofClient openflow.Client,
+	kubeClient clientset.Interface,
+	ofClient openflowClient,
 	ifaceStore interfacestore.InterfaceStore,
 	nodeName string,
 	entityUpdates <-chan types.EntityReference,
 	antreaPolicyEnabled bool,
 	statusManagerEnabled bool,
 	loggingEnabled bool,
+	auditLogToEventLog bool,
+	auditLogDir string,
+	auditLogJSONFormat bool,
+	auditLogAggregationWindow time.Duration,
+	auditLogExporter AuditLogExporter,
 	denyConnStore *connections.DenyConnectionStore,
-	asyncRuleDeleteInterval time.Duration) (*Controller, error) {
+	rejectUDPWithPortUnreachable bool,
+	asyncRuleDeleteInterval time.Duration,
+	policyBootstrapFailClosed bool,
+	workers int) (*Controller, error) {
 	c := &Controller{
 		antreaClientProvider: antreaClientGetter,
-		queue:                workqueue.NewNamedRateLimitingQueue(workqueue.NewItemExponentialFailureRateLimiter(minRetryDelay, maxRetryDelay), "networkpolicyrule"),
+		queues:               make([]workqueue.RateLimitingInterface, workers),
 		reconciler:           newReconciler(ofClient, ifaceStore, asyncRuleDeleteInterval),
 		ofClient:             ofClient,
 		antreaPolicyEnabled:  antreaPolicyEnabled,
 		statusManagerEnabled: statusManagerEnabled,
 		loggingEnabled:       loggingEnabled,
+		auditLogEncoder:      newAuditLogEncoder(auditLogJSONFormat),
+		auditLogExporter:     auditLogExporter,
 		denyConnStore:        denyConnStore,
+		realizationErrors:    newRealizationErrorRegistry(),
+
+		rejectUDPWithPortUnreachable: rejectUDPWithPortUnreachable,
+	}
+	for i := range c.queues {
+		c.queues[i] = workqueue.NewNamedRateLimitingQueue(workqueue.NewItemExponentialFailureRateLimiter(minRetryDelay, maxRetryDelay), fmt.Sprintf("networkpolicyrule-%d", i))
 	}
 	c.ruleCache = newRuleCache(c.enqueueRule, entityUpdates)
+	if policyBootstrapFailClosed {
+		c.bootstrapper = newPolicyBootstrapper(ofClient)
+	}
 	if statusManagerEnabled {
-		c.statusManager = newStatusController(antreaClientGetter, nodeName, c.ruleCache)
+		c.statusManager = newStatusController(antreaClientGetter, nodeName, c.ruleCache, c.realizationErrors)
+	}
+	if kubeClient != nil {
+		c.podReadiness = newPodReadinessController(kubeClient, nodeName, c.ruleCache)
 	}
 
 	// Create a WaitGroup that is used to block network policy workers from asynchronously processing
@@ -131,11 +211,16 @@ func NewNetworkPolicyController(antreaClientGetter agent.AntreaClientProvider,
 	if c.ofClient != nil && loggingEnabled {
 		// Register packetInHandler
 		c.ofClient.RegisterPacketInHandler(uint8(openflow.PacketInReasonNP), "networkpolicy", c)
+		c.k8sIsolationLogLimiter = rate.NewLimiter(k8sIsolationLogRate, k8sIsolationLogBurst)
+		c.recentAuditLogs = newAuditLogBuffer(recentAuditLogEntries)
 		// Initiate logger for Antrea Policy audit logging
-		err := initLogger()
+		err := initLogger(auditLogToEventLog, auditLogDir, c.auditLogEncoder, auditLogExporter)
 		if err != nil {
 			return nil, err
 		}
+		if auditLogAggregationWindow > 0 {
+			c.auditLogAggregator = newAuditLogAggregator(auditLogAggregationWindow, maxAggregatedAuditLogEntries, c.writeAuditLog)
+		}
 	}
 
 	// Use nodeName to filter resources when watching resources.
@@ -144,7 +229,8 @@ func NewNetworkPolicyController(antreaClientGetter agent.AntreaClientProvider,
 	}
 
 	c.networkPolicyWatcher = &watcher{
-		objectType: "NetworkPolicy",
+		objectType:    "NetworkPolicy",
+		syncedChanged: c.updateControlplaneSyncedCondition,
 		watchFunc: func() (watch.Interface, error) {
 			antreaClient, err := c.antreaClientProvider.GetAntreaClient()
 			if err != nil {
@@ -220,10 +306,12 @@ func NewNetworkPolicyController(antreaClientGetter agent.AntreaClientProvider,
 		},
 		fullSyncWaitGroup: &c.fullSyncGroup,
 		fullSynced:        false,
+		restartCh:         make(chan struct{}, 1),
 	}
 
 	c.appliedToGroupWatcher = &watcher{
-		objectType: "AppliedToGroup",
+		objectType:    "AppliedToGroup",
+		syncedChanged: c.updateControlplaneSyncedCondition,
 		watchFunc: func() (watch.Interface, error) {
 			antreaClient, err := c.antreaClientProvider.GetAntreaClient()
 			if err != nil {
@@ -269,10 +357,12 @@ func NewNetworkPolicyController(antreaClientGetter agent.AntreaClientProvider,
 		},
 		fullSyncWaitGroup: &c.fullSyncGroup,
 		fullSynced:        false,
+		restartCh:         make(chan struct{}, 1),
 	}
 
 	c.addressGroupWatcher = &watcher{
-		objectType: "AddressGroup",
+		objectType:    "AddressGroup",
+		syncedChanged: c.updateControlplaneSyncedCondition,
 		watchFunc: func() (watch.Interface, error) {
 			antreaClient, err := c.antreaClientProvider.GetAntreaClient()
 			if err != nil {
@@ -318,6 +408,7 @@ func NewNetworkPolicyController(antreaClientGetter agent.AntreaClientProvider,
 		},
 		fullSyncWaitGroup: &c.fullSyncGroup,
 		fullSynced:        false,
+		restartCh:         make(chan struct{}, 1),
 	}
 	c.ifaceStore = ifaceStore
 	return c, nil
@@ -375,15 +466,59 @@ func (c *Controller) GetRuleByFlowID(ruleFlowID uint32) *types.PolicyRule {
 	return rule
 }
 
+// ReconfigureAuditLogging changes the destination of the audit logs of Antrea-native policies and
+// K8s NetworkPolicy isolation without restarting the agent. It's a no-op if audit logging is not
+// enabled.
+func (c *Controller) ReconfigureAuditLogging(toEventLog bool, logDir string) error {
+	if c.ofClient == nil || !c.loggingEnabled {
+		return nil
+	}
+	return reconfigureLogger(toEventLog, logDir, c.auditLogEncoder, c.auditLogExporter)
+}
+
+// GetRecentAuditLogs returns the most recent audit log entries, from the oldest to the most recent,
+// and false if audit logging is not enabled.
+func (c *Controller) GetRecentAuditLogs() ([]querier.AuditLogEntry, bool) {
+	if c.recentAuditLogs == nil {
+		return nil, false
+	}
+	return c.recentAuditLogs.list(), true
+}
+
 func (c *Controller) GetControllerConnectionStatus() bool {
 	// When the watchers are connected, controller connection status is true. Otherwise, it is false.
 	return c.addressGroupWatcher.isConnected() && c.appliedToGroupWatcher.isConnected() && c.networkPolicyWatcher.isConnected()
 }
 
+// updateControlplaneSyncedCondition sets the ControlplaneSynced condition of the agent, which is
+// True when all the watchers have handled the init events of their current watch.
+func (c *Controller) updateControlplaneSyncedCondition() {
+	var unsynced []string
+	for _, w := range []*watcher{c.networkPolicyWatcher, c.appliedToGroupWatcher, c.addressGroupWatcher} {
+		if !w.isSynced() {
+			unsynced = append(unsynced, w.objectType)
+		}
+	}
+	if len(unsynced) == 0 {
+		condition.Set(crdv1beta1.ControlplaneSynced, corev1.ConditionTrue, "", "")
+		return
+	}
+	condition.Set(crdv1beta1.ControlplaneSynced, corev1.ConditionFalse, "NotSynced", fmt.Sprintf("Not synced with the Antrea Controller: %s", strings.Join(unsynced, ", ")))
+}
+
 // Run begins watching and processing Antrea AddressGroups, AppliedToGroups
 // and NetworkPolicies, and spawns workers that reconciles NetworkPolicy rules.
-// Run will not return until stopCh is closed.
+// Run will not return until stopCh is closed. The audit logger is closed when
+// Run returns.
 func (c *Controller) Run(stopCh <-chan struct{}) {
+	defer closeLogger()
+	if c.auditLogAggregator != nil {
+		go wait.Until(c.auditLogAggregator.flush, c.auditLogAggregator.window, stopCh)
+		// The entries aggregated in the last window are written before the audit logger is closed.
+		defer c.auditLogAggregator.flush()
+	}
+	// The watchers haven't been synced yet.
+	c.updateControlplaneSyncedCondition()
 	attempts := 0
 	if err := wait.PollImmediateUntil(200*time.Millisecond, func() (bool, error) {
 		if attempts%10 == 0 {
@@ -406,17 +541,26 @@ func (c *Controller) Run(stopCh <-chan struct{}) {
 	go wait.NonSlidingUntil(c.appliedToGroupWatcher.watch, 5*time.Second, stopCh)
 	go wait.NonSlidingUntil(c.addressGroupWatcher.watch, 5*time.Second, stopCh)
 	go wait.NonSlidingUntil(c.networkPolicyWatcher.watch, 5*time.Second, stopCh)
+	go wait.Until(c.updateMetrics, metricsInterval, stopCh)
 
 	klog.Infof("Waiting for all watchers to complete full sync")
 	c.fullSyncGroup.Wait()
 	klog.Infof("All watchers have completed full sync, installing flows for init events")
 	// Batch install all rules in queue after fullSync is finished.
-	c.processAllItemsInQueue()
+	failedRuleKeys := c.processAllItemsInQueue()
+	if c.bootstrapper != nil {
+		c.bootstrapper.firstSyncProcessed(failedRuleKeys, stopCh)
+	}
+	// The readiness gates are only synced once the rules known at startup have been processed.
+	if c.podReadiness != nil {
+		go c.podReadiness.Run(stopCh)
+	}
 
-	klog.Infof("Starting NetworkPolicy workers now")
-	defer c.queue.ShutDown()
-	for i := 0; i < defaultWorkers; i++ {
-		go wait.Until(c.worker, time.Second, stopCh)
+	klog.Infof("Starting %d NetworkPolicy workers now", len(c.queues))
+	for _, queue := range c.queues {
+		defer queue.ShutDown()
+		queue := queue
+		go wait.Until(func() { c.worker(queue) }, time.Second, stopCh)
 	}
 
 	klog.Infof("Starting IDAllocator worker to maintain the async rule cache")
@@ -429,70 +573,121 @@ func (c *Controller) Run(stopCh <-chan struct{}) {
 	<-stopCh
 }
 
-func (c *Controller) enqueueRule(ruleID string) {
-	c.queue.Add(ruleID)
+// updateMetrics reports the number of seconds since each watcher received its last event, and
+// the number of rules queued for each worker. A watcher which hasn't received any event yet is not
+// reported.
+func (c *Controller) updateMetrics() {
+	for _, w := range []*watcher{c.networkPolicyWatcher, c.appliedToGroupWatcher, c.addressGroupWatcher} {
+		if seconds, ok := w.secondsSinceLastEvent(); ok {
+			metrics.NetworkPolicyWatchSecondsSinceLastEvent.WithLabelValues(w.objectType).Set(seconds)
+		}
+	}
+	for i, queue := range c.queues {
+		metrics.NetworkPolicyRuleQueueDepth.WithLabelValues(strconv.Itoa(i)).Set(float64(queue.Len()))
+	}
+}
+
+// queueForPolicy returns the queue of the rules of the provided NetworkPolicy.
+func (c *Controller) queueForPolicy(policyUID string) workqueue.RateLimitingInterface {
+	return c.queues[shardIndex(policyUID, len(c.queues))]
+}
+
+func (c *Controller) enqueueRule(ruleID, policyUID string) {
+	c.queueForPolicy(policyUID).Add(ruleID)
 }
 
-// worker runs a worker thread that just dequeues items, processes them, and
-// marks them done. You may run as many of these in parallel as you wish; the
-// workqueue guarantees that they will not end up processing the same rule at
-// the same time.
-func (c *Controller) worker() {
-	for c.processNextWorkItem() {
+// worker runs a worker thread that just dequeues items from the provided queue,
+// processes them, and marks them done. Each queue is processed by a single
+// worker, hence a rule is never processed by two workers at the same time.
+func (c *Controller) worker(queue workqueue.RateLimitingInterface) {
+	for c.processNextWorkItem(queue) {
 	}
 }
 
-func (c *Controller) processNextWorkItem() bool {
-	key, quit := c.queue.Get()
+func (c *Controller) processNextWorkItem(queue workqueue.RateLimitingInterface) bool {
+	keys, quit := getNextWorkItems(queue)
 	if quit {
 		return false
 	}
-	defer c.queue.Done(key)
+	for _, key := range keys {
+		defer queue.Done(key)
+	}
 
-	err := c.syncRule(key.(string))
-	c.handleErr(err, key)
+	if len(keys) == 1 {
+		err := c.syncRule(keys[0])
+		c.handleErr(queue, err, keys[0])
+	} else {
+		c.syncRuleBatch(queue, keys)
+	}
 
 	return true
 }
 
+// getNextWorkItems waits for a rule key to be queued, then pops it with the rule keys
+// queued at the moment, up to maxRulesPerSync, so that the changes of rules caused by
+// the same event, e.g. an address moving from an AddressGroup to another, can be
+// reconciled together. The caller must be the only worker of the queue.
+func getNextWorkItems(queue workqueue.RateLimitingInterface) ([]string, bool) {
+	key, quit := queue.Get()
+	if quit {
+		return nil, true
+	}
+	keys := []string{key.(string)}
+	// No other worker can get keys from the queue, hence Get doesn't block.
+	for len(keys) < maxRulesPerSync && queue.Len() > 0 {
+		key, quit := queue.Get()
+		if quit {
+			break
+		}
+		keys = append(keys, key.(string))
+	}
+	return keys, false
+}
+
 // processAllItemsInQueue pops all rule keys queued at the moment and calls syncRules to
-// reconcile those rules in batch.
-func (c *Controller) processAllItemsInQueue() {
-	numRules := c.queue.Len()
-	batchSyncRuleKeys := make([]string, numRules)
-	for i := 0; i < numRules; i++ {
-		ruleKey, _ := c.queue.Get()
-		batchSyncRuleKeys[i] = ruleKey.(string)
-		// set key to done to prevent missing watched updates between here and fullSync finish.
-		c.queue.Done(ruleKey)
+// reconcile those rules in batch. It returns the keys of the rules which failed to be
+// reconciled and have been requeued.
+func (c *Controller) processAllItemsInQueue() []string {
+	var batchSyncRuleKeys []string
+	queueByRuleKey := map[string]workqueue.RateLimitingInterface{}
+	for _, queue := range c.queues {
+		numRules := queue.Len()
+		for i := 0; i < numRules; i++ {
+			ruleKey, _ := queue.Get()
+			batchSyncRuleKeys = append(batchSyncRuleKeys, ruleKey.(string))
+			queueByRuleKey[ruleKey.(string)] = queue
+			// set key to done to prevent missing watched updates between here and fullSync finish.
+			queue.Done(ruleKey)
+		}
 	}
 	// Reconcile all rule keys at once.
 	if err := c.syncRules(batchSyncRuleKeys); err != nil {
-		klog.Errorf("Error occurred when reconciling all rules for init events: %v", err)
-		for _, k := range batchSyncRuleKeys {
-			c.queue.AddRateLimited(k)
+		failedRuleKeys := batchSyncRuleKeys
+		// Only requeue the rules which failed if the others have been realized.
+		var batchErr *batchReconcileError
+		if errors.As(err, &batchErr) {
+			failedRuleKeys = batchErr.failedRuleIDs()
 		}
+		klog.Errorf("Error occurred when reconciling rules for init events, retrying %d of %d rules: %v", len(failedRuleKeys), len(batchSyncRuleKeys), err)
+		for _, k := range failedRuleKeys {
+			queueByRuleKey[k].AddRateLimited(k)
+		}
+		return failedRuleKeys
 	}
+	return nil
 }
 
 func (c *Controller) syncRule(key string) error {
 	startTime := time.Now()
 	defer func() {
-		klog.V(4).Infof("Finished syncing rule %q. (%v)", key, time.Since(startTime))
+		syncDuration := time.Since(startTime)
+		metrics.NetworkPolicySyncDuration.Observe(float64(syncDuration.Milliseconds()))
+		klog.V(4).Infof("Finished syncing rule %q. (%v)", key, syncDuration)
 	}()
 
 	rule, effective, realizable := c.ruleCache.GetCompletedRule(key)
 	if !effective {
-		klog.V(2).Infof("Rule %v was not effective, removing its flows", key)
-		if err := c.reconciler.Forget(key); err != nil {
-			return err
-		}
-		if c.statusManagerEnabled {
-			// We don't know whether this is a rule owned by Antrea Policy, but
-			// harmless to delete it.
-			c.statusManager.DeleteRuleRealization(key)
-		}
-		return nil
+		return c.forgetRule(key)
 	}
 	// If the rule is not realizable, we can simply skip it as it will be marked as dirty
 	// and queued again when we receive the missing group it missed.
@@ -500,7 +695,9 @@ func (c *Controller) syncRule(key string) error {
 		klog.V(2).Infof("Rule %v was not realizable, skipping", key)
 		return nil
 	}
-	if err := c.reconciler.Reconcile(rule); err != nil {
+	err := c.reconciler.Reconcile(rule)
+	c.recordRealization(rule, err)
+	if err != nil {
 		return err
 	}
 	if c.statusManagerEnabled && rule.SourceRef.Type != v1beta2.K8sNetworkPolicy {
@@ -509,6 +706,71 @@ func (c *Controller) syncRule(key string) error {
 	return nil
 }
 
+// forgetRule removes the flows of a rule which is not effective anymore.
+func (c *Controller) forgetRule(key string) error {
+	klog.V(2).Infof("Rule %v was not effective, removing its flows", key)
+	c.forgetRealizationError(key)
+	if err := c.reconciler.Forget(key); err != nil {
+		return err
+	}
+	if c.podReadiness != nil {
+		c.podReadiness.forgetRule(key)
+	}
+	if c.statusManagerEnabled {
+		// We don't know whether this is a rule owned by Antrea Policy, but
+		// harmless to delete it.
+		c.statusManager.DeleteRuleRealization(key)
+	}
+	return nil
+}
+
+// syncRuleBatch syncs the rules popped together from the queue. The effective and
+// realizable rules are reconciled together, so that the reconciler can order the
+// changes of their addresses by priority. The result of each rule is handled
+// separately.
+func (c *Controller) syncRuleBatch(queue workqueue.RateLimitingInterface, keys []string) {
+	startTime := time.Now()
+	defer func() {
+		klog.V(4).Infof("Finished syncing %d rules. (%v)", len(keys), time.Since(startTime))
+	}()
+
+	var rules []*CompletedRule
+	for _, key := range keys {
+		rule, effective, realizable := c.ruleCache.GetCompletedRule(key)
+		if !effective {
+			c.handleErr(queue, c.forgetRule(key), key)
+		} else if !realizable {
+			klog.V(2).Infof("Rule %v was not realizable, skipping", key)
+			c.handleErr(queue, nil, key)
+		} else {
+			rules = append(rules, rule)
+		}
+	}
+	if len(rules) == 0 {
+		return
+	}
+	err := c.reconciler.ReconcileRules(rules)
+	syncDuration := time.Since(startTime)
+	var batchErr *batchReconcileError
+	isBatchErr := errors.As(err, &batchErr)
+	syncedPolicies := sets.NewString()
+	for _, rule := range rules {
+		ruleErr := err
+		if isBatchErr {
+			ruleErr = batchErr.failedRules[rule.ID]
+		}
+		c.recordRealization(rule, ruleErr)
+		if ruleErr == nil && c.statusManagerEnabled && rule.SourceRef.Type != v1beta2.K8sNetworkPolicy {
+			c.statusManager.SetRuleRealization(rule.ID, rule.PolicyUID)
+		}
+		c.handleErr(queue, ruleErr, rule.ID)
+		syncedPolicies.Insert(string(rule.PolicyUID))
+	}
+	for range syncedPolicies {
+		metrics.NetworkPolicySyncDuration.Observe(float64(syncDuration.Milliseconds()))
+	}
+}
+
 // syncRules calls the reconciler to sync all the rules after watchers complete full sync.
 // After flows for those init events are installed, subsequent rules will be handled asynchronously
 // by the syncRule() function.
@@ -531,27 +793,46 @@ func (c *Controller) syncRules(keys []string) error {
 			allRules = append(allRules, rule)
 		}
 	}
-	if err := c.reconciler.BatchReconcile(allRules); err != nil {
+	err := c.reconciler.BatchReconcile(allRules)
+	var batchErr *batchReconcileError
+	isBatchErr := errors.As(err, &batchErr)
+	for _, rule := range allRules {
+		ruleErr := err
+		if isBatchErr {
+			ruleErr = batchErr.failedRules[rule.ID]
+		}
+		c.recordRealization(rule, ruleErr)
+	}
+	if err != nil && !isBatchErr {
 		return err
 	}
 	if c.statusManagerEnabled {
 		for _, rule := range allRules {
+			// Rules which failed to be realized are reported once they are reconciled again.
+			if batchErr != nil {
+				if _, failed := batchErr.failedRules[rule.ID]; failed {
+					continue
+				}
+			}
 			if rule.SourceRef.Type != v1beta2.K8sNetworkPolicy {
 				c.statusManager.SetRuleRealization(rule.ID, rule.PolicyUID)
 			}
 		}
 	}
-	return nil
+	return err
 }
 
-func (c *Controller) handleErr(err error, key interface{}) {
+func (c *Controller) handleErr(queue workqueue.RateLimitingInterface, err error, key interface{}) {
 	if err == nil {
-		c.queue.Forget(key)
+		queue.Forget(key)
+		if c.bootstrapper != nil {
+			c.bootstrapper.ruleRealized(key.(string))
+		}
 		return
 	}
 
 	klog.Errorf("Error syncing rule %q, retrying. Error: %v", key, err)
-	c.queue.AddRateLimited(key)
+	queue.AddRateLimited(key)
 }
 
 // watcher is responsible for watching a given resource with the provided watchFunc
@@ -571,12 +852,23 @@ type watcher struct {
 	ReplaceFunc func(objs []runtime.Object) error
 	// connected represents whether the watch has connected to apiserver successfully.
 	connected bool
-	// lock protects connected.
+	// synced represents whether the init events of the current watch have been handled.
+	synced bool
+	// syncedChanged is called when synced changes, if it's not nil.
+	syncedChanged func()
+	// lastEventTime is the time the last event was received, init events included.
+	lastEventTime time.Time
+	// lock protects connected and lastEventTime.
 	lock sync.RWMutex
 	// group to be notified when each watcher receives bookmark event
 	fullSyncWaitGroup *sync.WaitGroup
 	// fullSynced indicates if the resource has been synced at least once since agent started.
 	fullSynced bool
+	// restartCh is used to stop the current watch so that a new one relists the objects.
+	restartCh chan struct{}
+	// resyncWaiters are the channels to close once the objects have been relisted, after a resync
+	// was requested. They are protected by lock.
+	resyncWaiters []chan struct{}
 }
 
 func (w *watcher) isConnected() bool {
@@ -591,18 +883,103 @@ func (w *watcher) setConnected(connected bool) {
 	w.connected = connected
 }
 
+func (w *watcher) isSynced() bool {
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+	return w.synced
+}
+
+func (w *watcher) setSynced(synced bool) {
+	w.lock.Lock()
+	changed := w.synced != synced
+	w.synced = synced
+	w.lock.Unlock()
+	if changed && w.syncedChanged != nil {
+		w.syncedChanged()
+	}
+}
+
+// setLastEventTime records that an event was received.
+func (w *watcher) setLastEventTime(t time.Time) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.lastEventTime = t
+}
+
+// secondsSinceLastEvent returns the number of seconds since the last event was received. It
+// returns false if no event has been received yet.
+func (w *watcher) secondsSinceLastEvent() (float64, bool) {
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+	if w.lastEventTime.IsZero() {
+		return 0, false
+	}
+	return time.Since(w.lastEventTime).Seconds(), true
+}
+
+// resync stops the current watch, if any, so that the objects are relisted by the next one. It
+// returns a channel which is closed once the relisted objects have been handled.
+func (w *watcher) resync() <-chan struct{} {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	done := make(chan struct{})
+	w.resyncWaiters = append(w.resyncWaiters, done)
+	select {
+	case w.restartCh <- struct{}{}:
+	default:
+	}
+	return done
+}
+
+// takeResyncWaiters returns the pending resync waiters, which are satisfied by the watch starting
+// now, and clears the pending restart request.
+func (w *watcher) takeResyncWaiters() []chan struct{} {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	waiters := w.resyncWaiters
+	w.resyncWaiters = nil
+	select {
+	case <-w.restartCh:
+	default:
+	}
+	return waiters
+}
+
+// requeueResyncWaiters adds back resync waiters which were not satisfied because the watch
+// failed before handling the relisted objects.
+func (w *watcher) requeueResyncWaiters(waiters []chan struct{}) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.resyncWaiters = append(waiters, w.resyncWaiters...)
+}
+
+// watch watches the objects until the watch fails or is stopped by the server, and restarts it
+// immediately when a resync is requested.
 func (w *watcher) watch() {
+	for w.watchOnce() {
+	}
+}
+
+// watchOnce runs a single watch, and returns true if it was stopped because a resync was
+// requested.
+func (w *watcher) watchOnce() bool {
+	resyncWaiters := w.takeResyncWaiters()
+	defer func() {
+		if len(resyncWaiters) > 0 {
+			w.requeueResyncWaiters(resyncWaiters)
+		}
+	}()
 	klog.Infof("Starting watch for %s", w.objectType)
 	watcher, err := w.watchFunc()
 	if err != nil {
 		klog.Warningf("Failed to start watch for %s: %v", w.objectType, err)
-		return
+		return false
 	}
 	// Watch method doesn't return error but "emptyWatch" in case of some partial data errors,
 	// e.g. timeout error. Make sure that watcher is not empty and log warning otherwise.
 	if reflect.TypeOf(watcher) == reflect.TypeOf(emptyWatch) {
 		klog.Warningf("Failed to start watch for %s, please ensure antrea service is reachable for the agent", w.objectType)
-		return
+		return false
 	}
 
 	klog.Infof("Started watch for %s", w.objectType)
@@ -611,6 +988,7 @@ func (w *watcher) watch() {
 	defer func() {
 		klog.Infof("Stopped watch for %s, total items received: %d", w.objectType, eventCount)
 		w.setConnected(false)
+		w.setSynced(false)
 		watcher.Stop()
 	}()
 
@@ -624,15 +1002,19 @@ loop:
 		case event, ok := <-watcher.ResultChan():
 			if !ok {
 				klog.Warningf("Result channel for %s was closed", w.objectType)
-				return
+				return false
 			}
 			switch event.Type {
 			case watch.Added:
 				klog.V(2).Infof("Added %s (%#v)", w.objectType, event.Object)
 				initObjects = append(initObjects, event.Object)
 			case watch.Bookmark:
+				w.setLastEventTime(time.Now())
 				break loop
 			}
+		case <-w.restartCh:
+			klog.Infof("Restarting watch for %s to resync", w.objectType)
+			return true
 		}
 	}
 	klog.Infof("Received %d init events for %s", len(initObjects), w.objectType)
@@ -640,44 +1022,57 @@ loop:
 	eventCount += len(initObjects)
 	if err := w.ReplaceFunc(initObjects); err != nil {
 		klog.Errorf("Failed to handle init events: %v", err)
-		return
+		return false
 	}
+	w.setSynced(true)
 	if !w.fullSynced {
 		w.fullSynced = true
 		// Notify fullSyncWaitGroup that all events before bookmark is handled
 		w.fullSyncWaitGroup.Done()
 	}
+	for _, done := range resyncWaiters {
+		close(done)
+	}
+	resyncWaiters = nil
 
 	for {
 		select {
 		case event, ok := <-watcher.ResultChan():
 			if !ok {
-				return
+				return false
 			}
 			switch event.Type {
 			case watch.Added:
 				if err := w.AddFunc(event.Object); err != nil {
 					klog.Errorf("Failed to handle added event: %v", err)
-					return
+					return false
 				}
 				klog.V(2).Infof("Added %s (%#v)", w.objectType, event.Object)
 			case watch.Modified:
 				if err := w.UpdateFunc(event.Object); err != nil {
 					klog.Errorf("Failed to handle modified event: %v", err)
-					return
+					return false
 				}
 				klog.V(2).Infof("Updated %s (%#v)", w.objectType, event.Object)
 			case watch.Deleted:
 				if err := w.DeleteFunc(event.Object); err != nil {
 					klog.Errorf("Failed to handle deleted event: %v", err)
-					return
+					return false
 				}
 				klog.V(2).Infof("Removed %s (%#v)", w.objectType, event.Object)
+			case watch.Error:
+				// antrea-controller sends an Error event when it's shutting down, the watch will be restarted.
+				klog.Infof("Watch for %s was terminated by the server: %v", w.objectType, apierrors.FromObject(event.Object))
+				return false
 			default:
 				klog.Errorf("Unknown event: %v", event)
-				return
+				return false
 			}
+			w.setLastEventTime(time.Now())
 			eventCount++
+		case <-w.restartCh:
+			klog.Infof("Restarting watch for %s to resync", w.objectType)
+			return true
 		}
 	}
 }
@@ -52,8 +52,8 @@ func (g *antreaClientGetter) GetAntreaClient() (versioned.Interface, error) {
 func newTestController() (*Controller, *fake.Clientset, *mockReconciler) {
 	clientset := &fake.Clientset{}
 	ch := make(chan agenttypes.EntityReference, 100)
-	controller, _ := NewNetworkPolicyController(&antreaClientGetter{clientset}, nil, nil, "node1", ch,
-		true, true, true, nil, testAsyncDeleteInterval)
+	controller, _ := NewNetworkPolicyController(&antreaClientGetter{clientset}, nil, nil, nil, "node1", ch,
+		true, true, true, false, "", false, 0, nil, nil, false, testAsyncDeleteInterval, false, defaultWorkers)
 	reconciler := newMockReconciler()
 	controller.reconciler = reconciler
 	return controller, clientset, reconciler
@@ -95,6 +95,16 @@ func (r *mockReconciler) BatchReconcile(rules []*CompletedRule) error {
 	return nil
 }
 
+func (r *mockReconciler) ReconcileRules(rules []*CompletedRule) error {
+	r.Lock()
+	defer r.Unlock()
+	for _, rule := range rules {
+		r.lastRealized[rule.ID] = rule
+		r.updated <- rule.ID
+	}
+	return nil
+}
+
 func (r *mockReconciler) Forget(ruleID string) error {
 	r.Lock()
 	defer r.Unlock()
@@ -217,6 +227,9 @@ func TestAddSingleGroupRule(t *testing.T) {
 	}
 	networkPolicies := controller.GetNetworkPolicies(&querier.NetworkPolicyQueryFilter{SourceName: policy1.SourceRef.Name, Namespace: policy1.SourceRef.Namespace})
 	require.Equal(t, 1, len(networkPolicies))
+	// The returned NetworkPolicy is annotated with the time it was received.
+	assert.Contains(t, networkPolicies[0].Annotations, querier.LastUpdateTimeAnnotation)
+	networkPolicies[0].Annotations = nil
 	assert.Equal(t, policy1, &networkPolicies[0])
 	assert.Equal(t, 1, controller.GetNetworkPolicyNum())
 	assert.Equal(t, 0, controller.GetAddressGroupNum())
@@ -302,6 +315,9 @@ func TestAddMultipleGroupsRule(t *testing.T) {
 	}
 	networkPolicies := controller.GetNetworkPolicies(&querier.NetworkPolicyQueryFilter{SourceName: policy1.SourceRef.Name, Namespace: policy1.SourceRef.Namespace})
 	require.Equal(t, 1, len(networkPolicies))
+	// The returned NetworkPolicy is annotated with the time it was received.
+	assert.Contains(t, networkPolicies[0].Annotations, querier.LastUpdateTimeAnnotation)
+	networkPolicies[0].Annotations = nil
 	assert.Equal(t, policy1, &networkPolicies[0])
 	assert.Equal(t, 1, controller.GetNetworkPolicyNum())
 	assert.Equal(t, 1, controller.GetAddressGroupNum())
@@ -587,3 +603,92 @@ func TestNetworkPolicyMetrics(t *testing.T) {
 	waitForReconcilerDeleted()
 	checkNetworkPolicyMetrics()
 }
+
+func TestEnqueueRuleByPolicy(t *testing.T) {
+	controller, _, _ := newTestController()
+	queueOf := func(ruleID string) int {
+		for i, queue := range controller.queues {
+			for j := queue.Len(); j > 0; j-- {
+				key, _ := queue.Get()
+				queue.Done(key)
+				queue.Add(key)
+				if key.(string) == ruleID {
+					return i
+				}
+			}
+		}
+		return -1
+	}
+
+	queuesUsed := map[int]bool{}
+	for i := 0; i < 64; i++ {
+		policyUID := fmt.Sprintf("uid%d", i)
+		controller.enqueueRule(fmt.Sprintf("rule%d-1", i), policyUID)
+		controller.enqueueRule(fmt.Sprintf("rule%d-2", i), policyUID)
+		queue := queueOf(fmt.Sprintf("rule%d-1", i))
+		require.NotEqual(t, -1, queue)
+		// The rules of a NetworkPolicy are always synced by the same worker.
+		assert.Equal(t, queue, queueOf(fmt.Sprintf("rule%d-2", i)))
+		queuesUsed[queue] = true
+	}
+	// The NetworkPolicies are distributed to all the workers.
+	assert.Len(t, queuesUsed, defaultWorkers)
+}
+
+// latencyReconciler implements Reconciler. It takes a fixed time to reconcile each rule,
+// like the round trips to OVS do, and signals each reconciled rule to a WaitGroup.
+type latencyReconciler struct {
+	mockReconciler
+	latency    time.Duration
+	reconciled sync.WaitGroup
+}
+
+func (r *latencyReconciler) Reconcile(_ *CompletedRule) error {
+	time.Sleep(r.latency)
+	r.reconciled.Done()
+	return nil
+}
+
+func (r *latencyReconciler) ReconcileRules(rules []*CompletedRule) error {
+	for _, rule := range rules {
+		r.Reconcile(rule)
+	}
+	return nil
+}
+
+// BenchmarkSyncRulesWithWorkers measures the time taken to realize the rules of 5000
+// NetworkPolicies, as after the agent reconnects to the antrea-controller, with different
+// numbers of workers. As the reconciliation of each rule takes a fixed time, the throughput
+// is expected to grow almost linearly with the number of workers.
+func BenchmarkSyncRulesWithWorkers(b *testing.B) {
+	const policyNum = 5000
+	for _, workers := range []int{1, 4, 16} {
+		b.Run(fmt.Sprintf("workers-%d", workers), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				controller, _ := NewNetworkPolicyController(&antreaClientGetter{&fake.Clientset{}}, nil, nil, nil, "node1", make(chan agenttypes.EntityReference),
+					true, false, false, false, "", false, 0, nil, nil, false, testAsyncDeleteInterval, false, workers)
+				reconciler := &latencyReconciler{latency: 100 * time.Microsecond}
+				reconciler.reconciled.Add(policyNum)
+				controller.reconciler = reconciler
+				controller.ruleCache.AddAppliedToGroup(newAppliedToGroup("appliedToGroup1", []v1beta2.GroupMember{*newAppliedToGroupMember("pod1", "ns1")}))
+				controller.ruleCache.AddAddressGroup(newAddressGroup("addressGroup1", []v1beta2.GroupMember{*newAddressGroupMember("1.1.1.1")}))
+				for j := 0; j < policyNum; j++ {
+					policy := newNetworkPolicy(fmt.Sprintf("policy%d", j), types.UID(fmt.Sprintf("uid%d", j)), []string{"addressGroup1"}, nil, []string{"appliedToGroup1"}, nil)
+					controller.ruleCache.AddNetworkPolicy(policy)
+				}
+				b.StartTimer()
+
+				for _, queue := range controller.queues {
+					go controller.worker(queue)
+				}
+				reconciler.reconciled.Wait()
+
+				b.StopTimer()
+				for _, queue := range controller.queues {
+					queue.ShutDown()
+				}
+			}
+		})
+	}
+}
@@ -18,16 +18,15 @@ import (
 	"encoding/binary"
 	"errors"
 	"fmt"
-	"log"
-	"os"
-	"path/filepath"
+	"strconv"
 	"time"
 
 	"github.com/contiv/libOpenflow/openflow13"
 	"github.com/contiv/libOpenflow/protocol"
+	"github.com/contiv/libOpenflow/util"
 	"github.com/contiv/ofnet/ofctrl"
 	"github.com/vmware/go-ipfix/pkg/registry"
-	"gopkg.in/natefinch/lumberjack.v2"
+	"golang.org/x/time/rate"
 	"k8s.io/klog/v2"
 
 	"antrea.io/antrea/pkg/agent/config"
@@ -35,13 +34,9 @@ import (
 	"antrea.io/antrea/pkg/agent/openflow"
 	binding "antrea.io/antrea/pkg/ovs/openflow"
 	"antrea.io/antrea/pkg/util/ip"
-	"antrea.io/antrea/pkg/util/logdir"
 )
 
 const (
-	logfileSubdir string = "networkpolicy"
-	logfileName   string = "np.log"
-
 	IPv4HdrLen uint16 = 20
 	IPv6HdrLen uint16 = 40
 
@@ -51,14 +46,24 @@ const (
 	TCPRst uint8 = 0b000100
 
 	ICMPDstUnreachableType         uint8 = 3
+	ICMPDstPortUnreachableCode     uint8 = 3
 	ICMPDstHostAdminProhibitedCode uint8 = 10
 
 	ICMPv6DstUnreachableType     uint8 = 1
 	ICMPv6DstAdminProhibitedCode uint8 = 1
-)
-
-var (
-	AntreaPolicyLogger *log.Logger
+	ICMPv6DstPortUnreachableCode uint8 = 4
+
+	// ICMPQuotedPayloadLen is the number of bytes of the payload of the original packet quoted
+	// after its IP header in the ICMP reject responses, which include the ports of UDP datagrams.
+	ICMPQuotedPayloadLen = 8
+
+	// k8sDefaultDropPolicyRef is the policy reference logged for the packets dropped by K8s
+	// NetworkPolicy isolation, which don't match any NetworkPolicy rule.
+	k8sDefaultDropPolicyRef = "K8sDefaultDrop"
+	// The packets dropped by K8s NetworkPolicy isolation can be triggered by any client, e.g. a port
+	// scanner, so they are logged at a limited rate.
+	k8sIsolationLogRate  = rate.Limit(10)
+	k8sIsolationLogBurst = 50
 )
 
 // logInfo will be set by retrieving info from packetin and register
@@ -67,32 +72,19 @@ type logInfo struct {
 	npRef       string // Network Policy name reference for Antrea NetworkPolicy
 	disposition string // Allow/Drop of the rule sending packetin
 	ofPriority  string // openflow priority of the flow sending packetin
+	ruleName    string // name of the Network Policy rule sending packetin, empty for K8s isolation drops
 	srcIP       string // source IP of the traffic logged
+	srcPort     uint16 // source port of the traffic logged, for TCP, UDP and SCTP
 	destIP      string // destination IP of the traffic logged
+	destPort    uint16 // destination port of the traffic logged, for TCP, UDP and SCTP
+	icmpType    uint8  // ICMP type of the traffic logged, for ICMP and ICMPv6
+	icmpCode    uint8  // ICMP code of the traffic logged, for ICMP and ICMPv6
 	pktLength   uint16 // packet length of packetin
 	protocolStr string // protocol of the traffic logged
-}
-
-// initLogger is called while newing Antrea network policy agent controller.
-// Customize AntreaPolicyLogger specifically for Antrea Policies audit logging.
-func initLogger() error {
-	logDir := filepath.Join(logdir.GetLogDir(), logfileSubdir)
-	logFile := filepath.Join(logDir, logfileName)
-	if _, err := os.Stat(logDir); os.IsNotExist(err) {
-		os.Mkdir(logDir, 0755)
-	}
-
-	// Use lumberjack log file rot
-	logOutput := &lumberjack.Logger{
-		Filename:   logFile,
-		MaxSize:    500,  // allow max 500 megabytes for one log file
-		MaxBackups: 3,    // allow max 3 old log file backups
-		MaxAge:     28,   // allow max 28 days maintenance of old log files
-		Compress:   true, // compress the old log files for backup
-	}
-	AntreaPolicyLogger = log.New(logOutput, "", log.Ldate|log.Lmicroseconds)
-	klog.V(2).Infof("Initialized Antrea-native Policy Logger for audit logging with log file '%s'", logFile)
-	return nil
+	packetCount int    // number of identical packets aggregated in the entry, 0 or 1 for a single packet
+	// hasTransportHeader is true if the ports, or the ICMP type and code, were parsed from the transport
+	// header, which is not included in the packet-in of a truncated packet.
+	hasTransportHeader bool
 }
 
 // HandlePacketIn is the packetin handler registered to openflow by Antrea network
@@ -133,6 +125,10 @@ func (c *Controller) HandlePacketIn(pktIn *ofctrl.PacketIn) error {
 // logPacket retrieves information from openflow reg, controller cache, packet-in
 // packet to log.
 func (c *Controller) logPacket(pktIn *ofctrl.PacketIn) error {
+	if isK8sIsolationDropTable(binding.TableIDType(pktIn.TableId)) && !c.k8sIsolationLogLimiter.Allow() {
+		klog.V(4).Info("Skipped logging a packet dropped by K8s NetworkPolicy isolation because of rate limiting")
+		return nil
+	}
 	ob := new(logInfo)
 
 	// Get Network Policy log info
@@ -147,9 +143,20 @@ func (c *Controller) logPacket(pktIn *ofctrl.PacketIn) error {
 		return fmt.Errorf("received error while handling packetin for NetworkPolicy: %v", err)
 	}
 
-	// Store log file
-	AntreaPolicyLogger.Printf("%s %s %s %s SRC: %s DEST: %s %d %s", ob.tableName, ob.npRef, ob.disposition, ob.ofPriority, ob.srcIP, ob.destIP, ob.pktLength, ob.protocolStr)
-	return nil
+	// Store log entry
+	if c.auditLogAggregator != nil {
+		return c.auditLogAggregator.add(ob)
+	}
+	return c.writeAuditLog(ob)
+}
+
+// writeAuditLog writes the audit log entry of ob to the audit log sink, and keeps it among the recent
+// entries which can be queried through the agent API.
+func (c *Controller) writeAuditLog(ob *logInfo) error {
+	if c.recentAuditLogs != nil {
+		c.recentAuditLogs.add(ob, time.Now())
+	}
+	return getLogSink().write(ob)
 }
 
 // getMatchRegField returns match to the regNum register.
@@ -160,12 +167,13 @@ func getMatchRegField(matchers *ofctrl.Matchers, regNum uint32) *ofctrl.MatchFie
 // getMatch receives ofctrl matchers and table id, match field.
 // Modifies match field to Ingress/Egress register based on tableID.
 func getMatch(matchers *ofctrl.Matchers, tableID binding.TableIDType, disposition uint32) *ofctrl.MatchField {
-	// Get match from CNPDenyConjIDReg if disposition is not allow.
-	if disposition != openflow.DispositionAllow {
+	// Get match from CNPDenyConjIDReg if disposition is not allow or pass.
+	if disposition != openflow.DispositionAllow && disposition != openflow.DispositionPass {
 		return getMatchRegField(matchers, uint32(openflow.CNPDenyConjIDReg))
 	}
-	// Get match from ingress/egress reg if disposition is allow
-	for _, table := range append(openflow.GetAntreaPolicyEgressTables(), openflow.EgressRuleTable) {
+	// Get match from ingress/egress reg if disposition is allow or pass
+	egressTables := append(openflow.GetAntreaPolicyEgressTables(), openflow.GetAntreaPolicyLayer2Tables()...)
+	for _, table := range append(egressTables, openflow.EgressRuleTable) {
 		if tableID == table {
 			return getMatchRegField(matchers, uint32(openflow.EgressReg))
 		}
@@ -204,44 +212,105 @@ func getNetworkPolicyInfo(pktIn *ofctrl.PacketIn, c *Controller, ob *logInfo) er
 	if err != nil {
 		return fmt.Errorf("received error while unloading disposition from reg: %v", err)
 	}
-	ob.disposition = openflow.DispositionToString[info]
+	ob.disposition = openflow.DispositionToString(info)
+
+	// The packets dropped by K8s NetworkPolicy isolation don't match any rule, there is no
+	// conjunction to get the policy from.
+	if isK8sIsolationDropTable(tableID) {
+		ob.npRef = k8sDefaultDropPolicyRef
+		ob.ofPriority = strconv.Itoa(int(openflow.K8sIsolationDropPriority))
+		return nil
+	}
 
 	// Set match to corresponding ingress/egress reg according to disposition
 	match = getMatch(matchers, tableID, info)
 
-	// Get Network Policy full name and OF priority of the conjunction
+	// Get Network Policy full name, OF priority and rule name of the conjunction
 	info, err = getInfoInReg(match, nil)
 	if err != nil {
 		return fmt.Errorf("received error while unloading conjunction id from reg: %v", err)
 	}
-	ob.npRef, ob.ofPriority = c.ofClient.GetPolicyInfoFromConjunction(info)
+	ob.npRef, ob.ofPriority, ob.ruleName = c.ofClient.GetPolicyInfoFromConjunction(info)
 
 	return nil
 }
 
-// getPacketInfo fills in srcIP, destIP, pktLength, protocol of logInfo ob.
+// isK8sIsolationDropTable returns whether tableID is the ID of a table dropping the packets isolated
+// by K8s NetworkPolicies.
+func isK8sIsolationDropTable(tableID binding.TableIDType) bool {
+	return tableID == openflow.IngressDefaultTable || tableID == openflow.EgressDefaultTable
+}
+
+// getPacketInfo fills in srcIP, srcPort, destIP, destPort, pktLength, protocol of logInfo ob.
 func getPacketInfo(pktIn *ofctrl.PacketIn, ob *logInfo) error {
 	var prot uint8
+	var payload util.Message
 	switch ipPkt := pktIn.Data.Data.(type) {
 	case *protocol.IPv4:
 		ob.srcIP = ipPkt.NWSrc.String()
 		ob.destIP = ipPkt.NWDst.String()
 		ob.pktLength = ipPkt.Length
 		prot = ipPkt.Protocol
+		payload = ipPkt.Data
 	case *protocol.IPv6:
 		ob.srcIP = ipPkt.NWSrc.String()
 		ob.destIP = ipPkt.NWDst.String()
 		ob.pktLength = ipPkt.Length
 		prot = ipPkt.NextHeader
+		payload = ipPkt.Data
+	case *protocol.ARP:
+		// ARP packets are sent to the controller by the layer 2 rules of Antrea-native policies.
+		ob.srcIP = ipPkt.IPSrc.String()
+		ob.destIP = ipPkt.IPDst.String()
+		ob.pktLength = ipPkt.Len()
+		ob.protocolStr = "ARP"
+		return nil
 	default:
-		return errors.New("unsupported packet-in: should be a valid IPv4 or IPv6 packet")
+		return errors.New("unsupported packet-in: should be a valid IPv4, IPv6 or ARP packet")
 	}
 
 	ob.protocolStr = ip.IPProtocolNumberToString(prot, "UnknownProtocol")
-
+	getTransportInfo(prot, payload, ob)
 	return nil
 }
 
+// getTransportInfo fills in srcPort and destPort for TCP, UDP and SCTP, or icmpType and icmpCode for
+// ICMP and ICMPv6, from the transport header in payload. The packet-in only includes the first bytes
+// of a truncated packet, in which case the entry is still logged without them.
+func getTransportInfo(prot uint8, payload util.Message, ob *logInfo) {
+	switch l4 := payload.(type) {
+	case *protocol.UDP:
+		// libOpenflow leaves the UDP header empty when it is truncated, while the length of a
+		// parsed header is at least the length of the header itself.
+		if l4.Length < 8 {
+			return
+		}
+		ob.srcPort, ob.destPort = l4.PortSrc, l4.PortDst
+	case *protocol.ICMP:
+		// libOpenflow leaves the ICMP header empty when it is truncated, so the type and code are
+		// only trusted when the whole 8-byte header is included.
+		if len(l4.Data) < 4 {
+			return
+		}
+		ob.icmpType, ob.icmpCode = l4.Type, l4.Code
+	case *util.Buffer:
+		// libOpenflow doesn't parse the TCP and SCTP headers, which both start with the source and
+		// destination ports.
+		if prot != ip.TCPProtocol && prot != ip.SCTPProtocol {
+			return
+		}
+		data, _ := l4.MarshalBinary()
+		if len(data) < 4 {
+			return
+		}
+		ob.srcPort = binary.BigEndian.Uint16(data[:2])
+		ob.destPort = binary.BigEndian.Uint16(data[2:4])
+	default:
+		return
+	}
+	ob.hasTransportHeader = true
+}
+
 // rejectRequest sends reject response to the requesting client, based on the
 // packet-in message.
 func (c *Controller) rejectRequest(pktIn *ofctrl.PacketIn) error {
@@ -306,20 +375,11 @@ func (c *Controller) rejectRequest(pktIn *ofctrl.PacketIn) error {
 			TCPAck|TCPRst,
 			true)
 	} else {
-		// Use ICMP host administratively prohibited for ICMP, UDP, SCTP reject.
-		icmpType := ICMPDstUnreachableType
-		icmpCode := ICMPDstHostAdminProhibitedCode
-		ipHdrLen := IPv4HdrLen
-		if isIPv6 {
-			icmpType = ICMPv6DstUnreachableType
-			icmpCode = ICMPv6DstAdminProhibitedCode
-			ipHdrLen = IPv6HdrLen
+		icmpType, icmpCode := getICMPRejectTypeCode(prot, isIPv6, c.rejectUDPWithPortUnreachable)
+		icmpData, err := getICMPRejectData(pktIn.Data.Data)
+		if err != nil {
+			return err
 		}
-		ipHdr, _ := pktIn.Data.Data.MarshalBinary()
-		icmpData := make([]byte, int(ICMPUnusedHdrLen+ipHdrLen+8))
-		// Put ICMP unused header in Data prop and set it to zero.
-		binary.BigEndian.PutUint32(icmpData[:ICMPUnusedHdrLen], 0)
-		copy(icmpData[ICMPUnusedHdrLen:], ipHdr[:ipHdrLen+8])
 		return c.ofClient.SendICMPPacketOut(
 			srcMAC.String(),
 			dstMAC.String(),
@@ -335,6 +395,81 @@ func (c *Controller) rejectRequest(pktIn *ofctrl.PacketIn) error {
 	}
 }
 
+// getICMPRejectTypeCode returns the ICMP or ICMPv6 type and code of the reject response to a
+// packet of protocol prot. Host administratively prohibited is used for ICMP, SCTP, and UDP unless
+// udpPortUnreachable is true, in which case UDP datagrams are rejected with port unreachable, which
+// DNS resolvers handle by failing over to the next server immediately.
+func getICMPRejectTypeCode(prot uint8, isIPv6 bool, udpPortUnreachable bool) (uint8, uint8) {
+	portUnreachable := udpPortUnreachable && prot == protocol.Type_UDP
+	if isIPv6 {
+		if portUnreachable {
+			return ICMPv6DstUnreachableType, ICMPv6DstPortUnreachableCode
+		}
+		return ICMPv6DstUnreachableType, ICMPv6DstAdminProhibitedCode
+	}
+	if portUnreachable {
+		return ICMPDstUnreachableType, ICMPDstPortUnreachableCode
+	}
+	return ICMPDstUnreachableType, ICMPDstHostAdminProhibitedCode
+}
+
+// getICMPRejectData returns the data of the ICMP reject response to the IP packet ipPkt: the unused
+// 4 bytes of the ICMP header, followed by the IP header of ipPkt, including the IPv4 options or the
+// IPv6 extension headers, and the first 8 bytes of its payload. An error is returned if the
+// packet-in is truncated before the end of the quoted bytes.
+func getICMPRejectData(ipPkt util.Message) ([]byte, error) {
+	var ipHdr, payload []byte
+	var l4 util.Message
+	switch pkt := ipPkt.(type) {
+	case *protocol.IPv4:
+		if pkt.Data == nil {
+			return nil, errors.New("IPv4 packet has no payload")
+		}
+		// The header is encoded here, as IPv4.MarshalBinary drops the options.
+		options, _ := pkt.Options.MarshalBinary()
+		ipHdr = make([]byte, int(IPv4HdrLen)+len(options))
+		ipHdr[0] = pkt.Version<<4 | uint8(len(ipHdr)/4)
+		ipHdr[1] = pkt.DSCP<<2 | pkt.ECN
+		binary.BigEndian.PutUint16(ipHdr[2:], pkt.Length)
+		binary.BigEndian.PutUint16(ipHdr[4:], pkt.Id)
+		binary.BigEndian.PutUint16(ipHdr[6:], pkt.Flags<<13|pkt.FragmentOffset)
+		ipHdr[8] = pkt.TTL
+		ipHdr[9] = pkt.Protocol
+		binary.BigEndian.PutUint16(ipHdr[10:], pkt.Checksum)
+		copy(ipHdr[12:], pkt.NWSrc.To4())
+		copy(ipHdr[16:], pkt.NWDst.To4())
+		copy(ipHdr[IPv4HdrLen:], options)
+		payload, _ = pkt.Data.MarshalBinary()
+		l4 = pkt.Data
+	case *protocol.IPv6:
+		if pkt.Data == nil {
+			return nil, errors.New("IPv6 packet has no payload")
+		}
+		data, err := pkt.MarshalBinary()
+		if err != nil {
+			return nil, fmt.Errorf("error when encoding IPv6 packet: %v", err)
+		}
+		hdrLen := len(data) - int(pkt.Data.Len())
+		ipHdr, payload = data[:hdrLen], data[hdrLen:]
+		l4 = pkt.Data
+	default:
+		return nil, fmt.Errorf("unexpected packet type %T", ipPkt)
+	}
+	// libOpenflow leaves the UDP header empty when it is truncated, while its encoding still
+	// takes 8 bytes.
+	if udp, ok := l4.(*protocol.UDP); ok && udp.Length < 8 {
+		return nil, errors.New("UDP header is truncated")
+	}
+	if len(payload) < ICMPQuotedPayloadLen {
+		return nil, fmt.Errorf("payload is truncated to %d bytes", len(payload))
+	}
+	icmpData := make([]byte, int(ICMPUnusedHdrLen)+len(ipHdr)+ICMPQuotedPayloadLen)
+	// The unused 4 bytes of the ICMP header are left to zero.
+	copy(icmpData[ICMPUnusedHdrLen:], ipHdr)
+	copy(icmpData[int(ICMPUnusedHdrLen)+len(ipHdr):], payload[:ICMPQuotedPayloadLen])
+	return icmpData, nil
+}
+
 func (c *Controller) storeDenyConnection(pktIn *ofctrl.PacketIn) error {
 	packet, err := binding.ParsePacketIn(pktIn)
 	if err != nil {
@@ -377,7 +512,7 @@ func (c *Controller) storeDenyConnection(pktIn *ofctrl.PacketIn) error {
 	if err != nil {
 		return fmt.Errorf("error when getting disposition from reg: %v", err)
 	}
-	disposition := openflow.DispositionToString[id]
+	disposition := openflow.DispositionToString(id)
 
 	// For K8s NetworkPolicy implicit drop action, we cannot get name/namespace.
 	if tableID == openflow.IngressDefaultTable {
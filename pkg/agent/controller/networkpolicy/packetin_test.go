@@ -15,13 +15,26 @@
 package networkpolicy
 
 import (
+	"bytes"
+	"log"
 	"net"
+	"regexp"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/contiv/libOpenflow/openflow13"
 	"github.com/contiv/libOpenflow/protocol"
 	"github.com/contiv/libOpenflow/util"
 	"github.com/contiv/ofnet/ofctrl"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+
+	"antrea.io/antrea/pkg/agent/openflow"
+	binding "antrea.io/antrea/pkg/ovs/openflow"
+	"antrea.io/antrea/pkg/util/ip"
 )
 
 func TestGetPacketInfo(t *testing.T) {
@@ -52,6 +65,26 @@ func TestGetPacketInfo(t *testing.T) {
 			logInfo{srcIP: "1.1.1.1", destIP: "2.2.2.2", pktLength: 1, protocolStr: "TCP"},
 			false,
 		},
+		{
+			"arp",
+			&ofctrl.PacketIn{
+				Reason: 1,
+				Data: protocol.Ethernet{
+					Ethertype: 0x0806,
+					Data: util.Message(&protocol.ARP{
+						HWType:      1,
+						ProtoType:   0x0800,
+						HWLength:    6,
+						ProtoLength: 4,
+						Operation:   protocol.Type_Request,
+						IPSrc:       net.IPv4(1, 1, 1, 1),
+						IPDst:       net.IPv4(2, 2, 2, 2),
+					}),
+				},
+			},
+			logInfo{srcIP: "1.1.1.1", destIP: "2.2.2.2", pktLength: 28, protocolStr: "ARP"},
+			false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -63,3 +96,261 @@ func TestGetPacketInfo(t *testing.T) {
 		})
 	}
 }
+
+// newIPv4PacketIn returns the packet-in of an IPv4 packet with the given transport header, parsed by
+// libOpenflow from the first capturedLen bytes of the packet, like a packet-in of a truncated packet.
+func newIPv4PacketIn(t *testing.T, ipProtocol uint8, transportHeader []byte, capturedLen int) *ofctrl.PacketIn {
+	pkt := &protocol.IPv4{
+		Version:  4,
+		IHL:      5,
+		Length:   uint16(20 + len(transportHeader)),
+		TTL:      64,
+		Protocol: ipProtocol,
+		NWSrc:    net.IPv4(10, 10, 0, 4),
+		NWDst:    net.IPv4(10, 10, 0, 5),
+		Data:     util.NewBuffer(transportHeader),
+	}
+	data, err := pkt.MarshalBinary()
+	require.NoError(t, err)
+	if capturedLen < len(data) {
+		data = data[:capturedLen]
+	}
+	// Like ofnet, ignore the error returned when the transport header cannot be parsed.
+	ipPkt := new(protocol.IPv4)
+	ipPkt.UnmarshalBinary(data)
+	return &ofctrl.PacketIn{Data: protocol.Ethernet{Ethertype: 0x0800, Data: ipPkt}}
+}
+
+func TestGetPacketInfoTransportHeader(t *testing.T) {
+	tcpHeader, err := (&protocol.TCP{PortSrc: 34567, PortDst: 80, HdrLen: 5}).MarshalBinary()
+	require.NoError(t, err)
+	udpHeader, err := (&protocol.UDP{PortSrc: 34567, PortDst: 53, Length: 8}).MarshalBinary()
+	require.NoError(t, err)
+	sctpHeader := []byte{0x87, 0x07, 0x0b, 0x59, 0, 0, 0, 0, 0, 0, 0, 0}
+	icmpHeader := []byte{8, 0, 0, 0, 0, 1, 0, 1}
+	tests := []struct {
+		name          string
+		ipProtocol    uint8
+		header        []byte
+		capturedLen   int
+		expectedEntry string
+	}{
+		{"tcp", protocol.Type_TCP, tcpHeader, 128, "SRC: 10.10.0.4 DEST: 10.10.0.5 40 TCP 34567 80 allow-web"},
+		// The ports are the first 4 bytes of the TCP header.
+		{"truncated tcp", protocol.Type_TCP, tcpHeader, 24, "SRC: 10.10.0.4 DEST: 10.10.0.5 40 TCP 34567 80 allow-web"},
+		{"tcp without ports", protocol.Type_TCP, tcpHeader, 22, "SRC: 10.10.0.4 DEST: 10.10.0.5 40 TCP - - allow-web"},
+		{"udp", protocol.Type_UDP, udpHeader, 128, "SRC: 10.10.0.4 DEST: 10.10.0.5 28 UDP 34567 53 allow-web"},
+		{"truncated udp", protocol.Type_UDP, udpHeader, 26, "SRC: 10.10.0.4 DEST: 10.10.0.5 28 UDP - - allow-web"},
+		{"sctp", ip.SCTPProtocol, sctpHeader, 128, "SRC: 10.10.0.4 DEST: 10.10.0.5 32 SCTP 34567 2905 allow-web"},
+		{"icmp", protocol.Type_ICMP, icmpHeader, 128, "SRC: 10.10.0.4 DEST: 10.10.0.5 28 ICMP 8 0 allow-web"},
+		{"truncated icmp", protocol.Type_ICMP, icmpHeader, 22, "SRC: 10.10.0.4 DEST: 10.10.0.5 28 ICMP - - allow-web"},
+		{"ip header only", protocol.Type_TCP, tcpHeader, 20, "SRC: 10.10.0.4 DEST: 10.10.0.5 40 TCP - - allow-web"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ob := &logInfo{tableName: "AntreaPolicyIngressRule", npRef: "AntreaNetworkPolicy:default/test-anp", disposition: "Drop", ofPriority: "44900", ruleName: "allow-web"}
+			require.NoError(t, getPacketInfo(newIPv4PacketIn(t, tt.ipProtocol, tt.header, tt.capturedLen), ob))
+			assert.Equal(t, "AntreaPolicyIngressRule AntreaNetworkPolicy:default/test-anp Drop 44900 "+tt.expectedEntry, ob.String())
+		})
+	}
+}
+
+func newK8sIsolationDropPacketIn(tableID uint8) *ofctrl.PacketIn {
+	dispositionData := uint32(openflow.DispositionDrop) << openflow.APDispositionMarkRange[0]
+	return &ofctrl.PacketIn{
+		TableId: tableID,
+		Match: openflow13.Match{Fields: []openflow13.MatchField{
+			*openflow13.NewRegMatchField(int(openflow.DispositionMarkReg), dispositionData, nil),
+		}},
+		Data: protocol.Ethernet{
+			Ethertype: 0x0800,
+			Data: util.Message(&protocol.IPv4{
+				NWSrc:    net.IPv4(1, 1, 1, 1),
+				NWDst:    net.IPv4(2, 2, 2, 2),
+				Length:   1,
+				Protocol: 6,
+			}),
+		},
+	}
+}
+
+func TestGetNetworkPolicyInfoK8sIsolationDrop(t *testing.T) {
+	for _, tableID := range []uint8{uint8(openflow.IngressDefaultTable), uint8(openflow.EgressDefaultTable)} {
+		// The packet doesn't match any conjunction, so ofClient must not be used.
+		c := &Controller{}
+		actualOb := logInfo{}
+		require.NoError(t, getNetworkPolicyInfo(newK8sIsolationDropPacketIn(tableID), c, &actualOb))
+		assert.Equal(t, logInfo{
+			tableName:   openflow.GetFlowTableName(binding.TableIDType(tableID)),
+			npRef:       "K8sDefaultDrop",
+			disposition: "Drop",
+			ofPriority:  "200",
+		}, actualOb)
+	}
+}
+
+func TestLogPacketK8sIsolationDropRateLimited(t *testing.T) {
+	var buf bytes.Buffer
+	defer func(sink auditLogSink) { antreaPolicyLogSink = sink }(antreaPolicyLogSink)
+	antreaPolicyLogSink = &fileAuditLogSink{logger: log.New(&buf, "", 0)}
+	c := &Controller{k8sIsolationLogLimiter: rate.NewLimiter(rate.Every(time.Hour), 2)}
+
+	pktIn := newK8sIsolationDropPacketIn(uint8(openflow.IngressDefaultTable))
+	for i := 0; i < 5; i++ {
+		require.NoError(t, c.logPacket(pktIn))
+	}
+	// Only the burst of the limiter is logged.
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	assert.Contains(t, lines[0], "K8sDefaultDrop Drop 200 SRC: 1.1.1.1 DEST: 2.2.2.2 1 TCP")
+}
+
+func TestLogPacketAggregated(t *testing.T) {
+	var buf bytes.Buffer
+	defer func(sink auditLogSink) { antreaPolicyLogSink = sink }(antreaPolicyLogSink)
+	antreaPolicyLogSink = &fileAuditLogSink{logger: log.New(&buf, "", 0)}
+	c := &Controller{k8sIsolationLogLimiter: rate.NewLimiter(rate.Inf, 1)}
+	c.auditLogAggregator = newAuditLogAggregator(time.Second, maxAggregatedAuditLogEntries, func(ob *logInfo) error {
+		return getLogSink().write(ob)
+	})
+
+	pktIn := newK8sIsolationDropPacketIn(uint8(openflow.IngressDefaultTable))
+	for i := 0; i < 5; i++ {
+		require.NoError(t, c.logPacket(pktIn))
+	}
+	assert.Empty(t, buf.String())
+	c.auditLogAggregator.flush()
+	assert.Equal(t, "IngressDefaultRule K8sDefaultDrop Drop 200 SRC: 1.1.1.1 DEST: 2.2.2.2 1 TCP - - [5 packets]\n", buf.String())
+}
+
+func TestLogPacketConcurrent(t *testing.T) {
+	var buf bytes.Buffer
+	defer func(sink auditLogSink) { antreaPolicyLogSink = sink }(antreaPolicyLogSink)
+	sink := newAsyncAuditLogSink(&fileAuditLogSink{logger: log.New(&buf, "", log.Ldate|log.Lmicroseconds)}, 64)
+	antreaPolicyLogSink = sink
+	c := &Controller{k8sIsolationLogLimiter: rate.NewLimiter(rate.Inf, 1)}
+
+	const workers, packetsPerWorker = 20, 200
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			pktIn := newK8sIsolationDropPacketIn(uint8(openflow.IngressDefaultTable))
+			for j := 0; j < packetsPerWorker; j++ {
+				assert.NoError(t, c.logPacket(pktIn))
+			}
+		}()
+	}
+	wg.Wait()
+	require.NoError(t, sink.close())
+
+	// Every entry is either written as a whole line or accounted as dropped.
+	linePattern := regexp.MustCompile(`^\d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2}\.\d{6} IngressDefaultRule K8sDefaultDrop Drop 200 SRC: 1\.1\.1\.1 DEST: 2\.2\.2\.2 1 TCP - -$`)
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	for _, line := range lines {
+		assert.Regexp(t, linePattern, line)
+	}
+	assert.Equal(t, uint64(workers*packetsPerWorker), uint64(len(lines))+sink.droppedCount())
+
+	// The entries logged after the sink is closed are dropped.
+	require.NoError(t, c.logPacket(newK8sIsolationDropPacketIn(uint8(openflow.IngressDefaultTable))))
+	assert.Equal(t, uint64(workers*packetsPerWorker+1), uint64(len(lines))+sink.droppedCount())
+}
+
+func TestGetICMPRejectTypeCode(t *testing.T) {
+	tests := []struct {
+		name               string
+		prot               uint8
+		isIPv6             bool
+		udpPortUnreachable bool
+		expectedType       uint8
+		expectedCode       uint8
+	}{
+		{"udp", protocol.Type_UDP, false, false, 3, 10},
+		{"udp port unreachable", protocol.Type_UDP, false, true, 3, 3},
+		{"sctp", ip.SCTPProtocol, false, true, 3, 10},
+		{"icmp", protocol.Type_ICMP, false, true, 3, 10},
+		{"udp ipv6", protocol.Type_UDP, true, false, 1, 1},
+		{"udp ipv6 port unreachable", protocol.Type_UDP, true, true, 1, 4},
+		{"icmpv6", protocol.Type_IPv6ICMP, true, true, 1, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			icmpType, icmpCode := getICMPRejectTypeCode(tt.prot, tt.isIPv6, tt.udpPortUnreachable)
+			assert.Equal(t, tt.expectedType, icmpType)
+			assert.Equal(t, tt.expectedCode, icmpCode)
+		})
+	}
+}
+
+func TestGetICMPRejectData(t *testing.T) {
+	udpDatagram := []byte{
+		0x87, 0x07, 0x00, 0x35, 0x00, 0x10, 0xde, 0xad, // Source port 34567, destination port 53, length 16, checksum.
+		0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, // Payload.
+	}
+	ipv4Header := []byte{
+		0x46, 0x00, 0x00, 0x28, 0x12, 0x34, 0x40, 0x00, // Version 4, IHL 6, length 40, ID, DF.
+		0x40, 0x11, 0xab, 0xcd, 0x0a, 0x0a, 0x00, 0x04, // TTL 64, UDP, checksum, source 10.10.0.4.
+		0x0a, 0x0a, 0x00, 0x05, 0x94, 0x04, 0x00, 0x00, // Destination 10.10.0.5, router alert option.
+	}
+	ipv6Header := []byte{
+		0x6a, 0xb1, 0x23, 0x45, 0x00, 0x10, 0x11, 0x40, // Version 6, traffic class, flow label, length 16, UDP, hop limit 64.
+		0xfd, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x04, // Source fd00::4.
+		0xfd, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x05, // Destination fd00::5.
+	}
+	unusedHeader := []byte{0x00, 0x00, 0x00, 0x00}
+	concat := func(parts ...[]byte) []byte {
+		var data []byte
+		for _, part := range parts {
+			data = append(data, part...)
+		}
+		return data
+	}
+	tests := []struct {
+		name         string
+		ipPkt        util.Message
+		data         []byte
+		expectedData []byte
+		expectedErr  bool
+	}{
+		{
+			name:  "ipv4 with options",
+			ipPkt: new(protocol.IPv4),
+			data:  concat(ipv4Header, udpDatagram),
+			// The options are quoted with the IP header, followed by the UDP header only.
+			expectedData: concat(unusedHeader, ipv4Header, udpDatagram[:8]),
+		},
+		{
+			name:         "ipv6",
+			ipPkt:        new(protocol.IPv6),
+			data:         concat(ipv6Header, udpDatagram),
+			expectedData: concat(unusedHeader, ipv6Header, udpDatagram[:8]),
+		},
+		{
+			name:        "truncated ipv4",
+			ipPkt:       new(protocol.IPv4),
+			data:        concat(ipv4Header, udpDatagram)[:30],
+			expectedErr: true,
+		},
+		{
+			name:        "truncated ipv6",
+			ipPkt:       new(protocol.IPv6),
+			data:        concat(ipv6Header, udpDatagram)[:44],
+			expectedErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Like ofnet, ignore the error returned when the transport header cannot be parsed.
+			tt.ipPkt.UnmarshalBinary(tt.data)
+			icmpData, err := getICMPRejectData(tt.ipPkt)
+			if tt.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedData, icmpData)
+		})
+	}
+}
@@ -0,0 +1,315 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package networkpolicy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	coreinformers "k8s.io/client-go/informers/core/v1"
+	clientset "k8s.io/client-go/kubernetes"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	"antrea.io/antrea/pkg/util/k8s"
+)
+
+// PodReadinessGatePolicyRealized is the condition type of the Pod readiness gate which keeps a Pod
+// NotReady until the NetworkPolicies applied to it are realized on its Node. Pods opt in by
+// declaring it in their readinessGates.
+const PodReadinessGatePolicyRealized corev1.PodConditionType = "antrea.io/network-policy-realized"
+
+const (
+	podReadinessControllerName = "PodReadinessController"
+	// Set resyncPeriod to 0 to disable resyncing.
+	podReadinessResyncPeriod  = 0
+	podReadinessMinRetryDelay = 5 * time.Second
+	podReadinessMaxRetryDelay = 300 * time.Second
+
+	podReadinessReasonRealized    = "NetworkPoliciesRealized"
+	podReadinessReasonNotRealized = "NetworkPoliciesNotRealized"
+)
+
+// podReadinessController sets the condition of the PodReadinessGatePolicyRealized readiness gate
+// for the local Pods which declare it. The condition is True when all the rules applied to the Pod
+// have been realized, and is set back to False when any of them fails to be realized. A rule which
+// has been realized once is still considered as realized when it is updated, so that a Pod doesn't
+// flap between Ready and NotReady on every change of the addresses of its rules.
+type podReadinessController struct {
+	kubeClient      clientset.Interface
+	podInformer     cache.SharedIndexInformer
+	podLister       corelisters.PodLister
+	podListerSynced cache.InformerSynced
+	ruleCache       *ruleCache
+	queue           workqueue.RateLimitingInterface
+
+	rulesMutex sync.RWMutex
+	// realizedRules is the set of the IDs of the rules which have been realized.
+	realizedRules sets.String
+
+	podsMutex sync.RWMutex
+	// trackedPods is keyed by the namespaced name of the local Pods which declare the readiness
+	// gate. The value is the status of the condition last set by the controller, or an empty
+	// string if it hasn't been set yet.
+	trackedPods map[string]corev1.ConditionStatus
+}
+
+func newPodReadinessController(kubeClient clientset.Interface, nodeName string, ruleCache *ruleCache) *podReadinessController {
+	// Watch only the Pods which belong to the Node where the agent is running.
+	podInformer := coreinformers.NewFilteredPodInformer(
+		kubeClient,
+		metav1.NamespaceAll,
+		podReadinessResyncPeriod,
+		cache.Indexers{},
+		func(options *metav1.ListOptions) {
+			options.FieldSelector = fields.OneTermEqualSelector("spec.nodeName", nodeName).String()
+		},
+	)
+	c := &podReadinessController{
+		kubeClient:      kubeClient,
+		podInformer:     podInformer,
+		podLister:       corelisters.NewPodLister(podInformer.GetIndexer()),
+		podListerSynced: podInformer.HasSynced,
+		ruleCache:       ruleCache,
+		queue:           workqueue.NewNamedRateLimitingQueue(workqueue.NewItemExponentialFailureRateLimiter(podReadinessMinRetryDelay, podReadinessMaxRetryDelay), "podReadiness"),
+		realizedRules:   sets.NewString(),
+		trackedPods:     map[string]corev1.ConditionStatus{},
+	}
+	podInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    c.enqueuePod,
+		UpdateFunc: func(_, obj interface{}) { c.enqueuePod(obj) },
+		DeleteFunc: c.enqueuePod,
+	})
+	return c
+}
+
+// hasPolicyReadinessGate returns whether the Pod declares the PodReadinessGatePolicyRealized
+// readiness gate.
+func hasPolicyReadinessGate(pod *corev1.Pod) bool {
+	for _, gate := range pod.Spec.ReadinessGates {
+		if gate.ConditionType == PodReadinessGatePolicyRealized {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *podReadinessController) enqueuePod(obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		klog.Errorf("Failed to get key of Pod %v: %v", obj, err)
+		return
+	}
+	pod, isPod := obj.(*corev1.Pod)
+	// Ignore the Pods which don't declare the readiness gate, unless they used to, in which
+	// case they must be untracked.
+	if isPod && !hasPolicyReadinessGate(pod) && !c.isTracked(key) {
+		return
+	}
+	c.queue.Add(key)
+}
+
+func (c *podReadinessController) isTracked(key string) bool {
+	c.podsMutex.RLock()
+	defer c.podsMutex.RUnlock()
+	_, tracked := c.trackedPods[key]
+	return tracked
+}
+
+// enqueueTrackedPods enqueues all the Pods which declare the readiness gate. It's called when the
+// realization of any rule changes, as finding out the Pods which the rule is applied to would be
+// as expensive as checking the Pods.
+func (c *podReadinessController) enqueueTrackedPods() {
+	c.podsMutex.RLock()
+	defer c.podsMutex.RUnlock()
+	for key := range c.trackedPods {
+		c.queue.Add(key)
+	}
+}
+
+// setRuleRealized records whether the last attempt to realize the rule succeeded. The Pods are
+// checked again on every failure, even if the rule has never been realized, as the Pods it applies
+// to may have been ready before the rule was added.
+func (c *podReadinessController) setRuleRealized(ruleID string, realized bool) {
+	c.rulesMutex.Lock()
+	changed := c.realizedRules.Has(ruleID) != realized
+	if realized {
+		c.realizedRules.Insert(ruleID)
+	} else {
+		c.realizedRules.Delete(ruleID)
+	}
+	c.rulesMutex.Unlock()
+	if changed || !realized {
+		c.enqueueTrackedPods()
+	}
+}
+
+// forgetRule removes the rule which is no longer effective on this Node. The Pods are checked again
+// as they may have been waiting for the rule to be realized.
+func (c *podReadinessController) forgetRule(ruleID string) {
+	c.rulesMutex.Lock()
+	c.realizedRules.Delete(ruleID)
+	c.rulesMutex.Unlock()
+	c.enqueueTrackedPods()
+}
+
+// unrealizedRuleNum returns the number of rules applied to the Pod which haven't been realized.
+func (c *podReadinessController) unrealizedRuleNum(pod *corev1.Pod) int {
+	ruleIDs := c.ruleCache.getAppliedRuleIDs(pod.Name, pod.Namespace)
+	c.rulesMutex.RLock()
+	defer c.rulesMutex.RUnlock()
+	num := 0
+	for ruleID := range ruleIDs {
+		if !c.realizedRules.Has(ruleID) {
+			num++
+		}
+	}
+	return num
+}
+
+// Run starts watching the local Pods and syncing their readiness gate conditions. It must be called
+// after the rules received before the first full sync of the NetworkPolicy controller have been
+// processed, otherwise the Pods would be considered as ready before their rules are known.
+func (c *podReadinessController) Run(stopCh <-chan struct{}) {
+	klog.Infof("Starting %s", podReadinessControllerName)
+	defer klog.Infof("Shutting down %s", podReadinessControllerName)
+	defer c.queue.ShutDown()
+
+	go c.podInformer.Run(stopCh)
+	if !cache.WaitForNamedCacheSync(podReadinessControllerName, stopCh, c.podListerSynced) {
+		return
+	}
+	go wait.Until(c.worker, time.Second, stopCh)
+	<-stopCh
+}
+
+func (c *podReadinessController) worker() {
+	for c.processNextWorkItem() {
+	}
+}
+
+func (c *podReadinessController) processNextWorkItem() bool {
+	key, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	defer c.queue.Done(key)
+
+	if err := c.syncPod(key.(string)); err != nil {
+		klog.Errorf("Error syncing readiness gate of Pod %s, requeuing: %v", key, err)
+		c.queue.AddRateLimited(key)
+	} else {
+		c.queue.Forget(key)
+	}
+	return true
+}
+
+func (c *podReadinessController) syncPod(key string) error {
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return err
+	}
+	pod, err := c.podLister.Pods(namespace).Get(name)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			c.untrackPod(key)
+			return nil
+		}
+		return err
+	}
+	if !hasPolicyReadinessGate(pod) {
+		c.untrackPod(key)
+		return nil
+	}
+	c.podsMutex.Lock()
+	if _, tracked := c.trackedPods[key]; !tracked {
+		c.trackedPods[key] = ""
+	}
+	c.podsMutex.Unlock()
+
+	condition := corev1.PodCondition{
+		Type:   PodReadinessGatePolicyRealized,
+		Status: corev1.ConditionTrue,
+		Reason: podReadinessReasonRealized,
+	}
+	if num := c.unrealizedRuleNum(pod); num > 0 {
+		condition.Status = corev1.ConditionFalse
+		condition.Reason = podReadinessReasonNotRealized
+		condition.Message = fmt.Sprintf("%d NetworkPolicy rule(s) not realized", num)
+	}
+	if current := getPodCondition(pod, PodReadinessGatePolicyRealized); current != nil &&
+		current.Status == condition.Status && current.Reason == condition.Reason && current.Message == condition.Message {
+		c.setTrackedStatus(key, condition.Status)
+		return nil
+	}
+	condition.LastTransitionTime = metav1.Now()
+	if err := c.patchPodCondition(pod, &condition); err != nil {
+		return err
+	}
+	klog.V(2).Infof("Set readiness gate condition of Pod %s to %s", k8s.NamespacedName(namespace, name), condition.Status)
+	c.setTrackedStatus(key, condition.Status)
+	return nil
+}
+
+func (c *podReadinessController) untrackPod(key string) {
+	c.podsMutex.Lock()
+	defer c.podsMutex.Unlock()
+	delete(c.trackedPods, key)
+}
+
+func (c *podReadinessController) setTrackedStatus(key string, status corev1.ConditionStatus) {
+	c.podsMutex.Lock()
+	defer c.podsMutex.Unlock()
+	// The Pod may have been untracked in the meantime.
+	if _, tracked := c.trackedPods[key]; tracked {
+		c.trackedPods[key] = status
+	}
+}
+
+func getPodCondition(pod *corev1.Pod, conditionType corev1.PodConditionType) *corev1.PodCondition {
+	for i := range pod.Status.Conditions {
+		if pod.Status.Conditions[i].Type == conditionType {
+			return &pod.Status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// patchPodCondition sets the condition in the status of the Pod. The conditions of a Pod are
+// merged by type, hence the other conditions are left untouched.
+func (c *podReadinessController) patchPodCondition(pod *corev1.Pod, condition *corev1.PodCondition) error {
+	type podStatus struct {
+		Conditions []corev1.PodCondition `json:"conditions"`
+	}
+	type podPatch struct {
+		Status podStatus `json:"status"`
+	}
+	patchData := podPatch{Status: podStatus{Conditions: []corev1.PodCondition{*condition}}}
+	payloads, _ := json.Marshal(patchData)
+	_, err := c.kubeClient.CoreV1().Pods(pod.Namespace).Patch(context.TODO(), pod.Name, types.StrategicMergePatchType, payloads, metav1.PatchOptions{}, "status")
+	return err
+}
@@ -0,0 +1,101 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package networkpolicy
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+
+	agenttypes "antrea.io/antrea/pkg/agent/types"
+	"antrea.io/antrea/pkg/apis/controlplane/v1beta2"
+)
+
+func newTestPod(name string, withReadinessGate bool) *corev1.Pod {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testNamespace},
+		Spec:       corev1.PodSpec{NodeName: "node1"},
+	}
+	if withReadinessGate {
+		pod.Spec.ReadinessGates = []corev1.PodReadinessGate{{ConditionType: PodReadinessGatePolicyRealized}}
+	}
+	return pod
+}
+
+func TestPodReadinessController(t *testing.T) {
+	pod1 := newTestPod("pod1", true)
+	pod2 := newTestPod("pod2", false)
+	kubeClient := k8sfake.NewSimpleClientset(pod1, pod2)
+	ruleCache := newRuleCache(func(ruleID, policyUID string) {}, make(chan agenttypes.EntityReference, 10))
+	c := newPodReadinessController(kubeClient, "node1", ruleCache)
+	require.NoError(t, c.podInformer.GetIndexer().Add(pod1))
+	require.NoError(t, c.podInformer.GetIndexer().Add(pod2))
+
+	ruleCache.AddAppliedToGroup(newAppliedToGroup("appliedToGroup1", []v1beta2.GroupMember{*newAppliedToGroupMember("pod1", testNamespace), *newAppliedToGroupMember("pod2", testNamespace)}))
+	policy := newNetworkPolicy("policy1", "uid1", []string{"addressGroup1"}, nil, []string{"appliedToGroup1"}, nil)
+	ruleCache.AddNetworkPolicy(policy)
+	ruleIDs := ruleCache.getAppliedRuleIDs("pod1", testNamespace)
+	require.Equal(t, 1, ruleIDs.Len())
+	ruleID := ruleIDs.List()[0]
+
+	checkCondition := func(expectedStatus corev1.ConditionStatus, expectedReason string) {
+		require.NoError(t, c.syncPod("ns1/pod1"))
+		pod, err := kubeClient.CoreV1().Pods(testNamespace).Get(context.TODO(), "pod1", metav1.GetOptions{})
+		require.NoError(t, err)
+		condition := getPodCondition(pod, PodReadinessGatePolicyRealized)
+		require.NotNil(t, condition)
+		assert.Equal(t, expectedStatus, condition.Status)
+		assert.Equal(t, expectedReason, condition.Reason)
+		assert.Equal(t, map[string]corev1.ConditionStatus{"ns1/pod1": expectedStatus}, c.trackedPods)
+		// Update the informer cache as the informer would.
+		require.NoError(t, c.podInformer.GetIndexer().Update(pod))
+	}
+
+	// The rule applied to the Pod has not been realized yet.
+	checkCondition(corev1.ConditionFalse, podReadinessReasonNotRealized)
+	// The condition is not patched again if it doesn't change.
+	actionNum := len(kubeClient.Actions())
+	require.NoError(t, c.syncPod("ns1/pod1"))
+	assert.Len(t, kubeClient.Actions(), actionNum)
+
+	c.setRuleRealized(ruleID, true)
+	assert.Equal(t, 1, c.queue.Len())
+	checkCondition(corev1.ConditionTrue, podReadinessReasonRealized)
+
+	// The rule fails to be realized after it's updated.
+	c.setRuleRealized(ruleID, false)
+	checkCondition(corev1.ConditionFalse, podReadinessReasonNotRealized)
+
+	// The rule is no longer applied to the Pod.
+	ruleCache.DeleteNetworkPolicy(policy)
+	c.forgetRule(ruleID)
+	checkCondition(corev1.ConditionTrue, podReadinessReasonRealized)
+
+	// The Pods which don't declare the readiness gate are ignored.
+	actionNum = len(kubeClient.Actions())
+	require.NoError(t, c.syncPod("ns1/pod2"))
+	assert.Len(t, kubeClient.Actions(), actionNum)
+	assert.NotContains(t, c.trackedPods, "ns1/pod2")
+
+	// The Pod is untracked when it's deleted.
+	require.NoError(t, c.podInformer.GetIndexer().Delete(pod1))
+	require.NoError(t, c.syncPod("ns1/pod1"))
+	assert.Empty(t, c.trackedPods)
+}
@@ -0,0 +1,85 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package networkpolicy
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+
+	"antrea.io/antrea/pkg/agent/openflow"
+)
+
+const uninstallBootstrapFlowsRetryInterval = time.Second
+
+// policyBootstrapper removes the flows installed by the agent to fail closed at startup, once all the rules received
+// during the first sync with the antrea-controller have been realized. The rules are installed before the flows are
+// removed, so that no connection allowed by the rules is dropped during the switch.
+type policyBootstrapper struct {
+	ofClient openflow.PolicyClient
+	mutex    sync.Mutex
+	// pendingRules are the keys of the rules of the first sync which have not been realized yet. It's nil until the
+	// first sync has been processed.
+	pendingRules sets.String
+	// done indicates whether the removal of the flows has been triggered.
+	done   bool
+	stopCh <-chan struct{}
+}
+
+func newPolicyBootstrapper(ofClient openflow.PolicyClient) *policyBootstrapper {
+	return &policyBootstrapper{ofClient: ofClient}
+}
+
+// firstSyncProcessed must be called once the rules of the first sync have been reconciled in batch, with the keys of
+// the rules which have failed to be reconciled and will be retried.
+func (b *policyBootstrapper) firstSyncProcessed(failedRuleKeys []string, stopCh <-chan struct{}) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.stopCh = stopCh
+	b.pendingRules = sets.NewString(failedRuleKeys...)
+	if len(b.pendingRules) > 0 {
+		klog.Infof("Keeping the policy bootstrap flows until %d rules are realized", len(b.pendingRules))
+	}
+	b.checkPendingRules()
+}
+
+// ruleRealized must be called when a rule has been reconciled successfully.
+func (b *policyBootstrapper) ruleRealized(ruleKey string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.pendingRules == nil || b.done {
+		return
+	}
+	b.pendingRules.Delete(ruleKey)
+	b.checkPendingRules()
+}
+
+func (b *policyBootstrapper) checkPendingRules() {
+	if len(b.pendingRules) > 0 || b.done {
+		return
+	}
+	b.done = true
+	go wait.PollImmediateUntil(uninstallBootstrapFlowsRetryInterval, func() (bool, error) {
+		if err := b.ofClient.UninstallPolicyBootstrapFlows(); err != nil {
+			klog.Errorf("Failed to uninstall the policy bootstrap flows, will retry: %v", err)
+			return false, nil
+		}
+		klog.Info("NetworkPolicies are realized, uninstalled the policy bootstrap flows")
+		return true, nil
+	}, b.stopCh)
+}
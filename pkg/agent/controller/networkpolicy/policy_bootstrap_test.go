@@ -0,0 +1,84 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package networkpolicy
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+
+	openflowtest "antrea.io/antrea/pkg/agent/openflow/testing"
+)
+
+func TestPolicyBootstrapper(t *testing.T) {
+	tests := []struct {
+		name           string
+		failedRuleKeys []string
+		realizedRules  []string
+		// Number of failures before the flows are uninstalled.
+		uninstallFailures int
+	}{
+		{
+			name: "no failed rule",
+		},
+		{
+			name:           "failed rules realized later",
+			failedRuleKeys: []string{"rule1", "rule2"},
+			realizedRules:  []string{"rule1", "rule3", "rule2"},
+		},
+		{
+			name:              "uninstall retried",
+			uninstallFailures: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+			mockOFClient := openflowtest.NewMockPolicyClient(controller)
+			stopCh := make(chan struct{})
+			defer close(stopCh)
+			uninstalled := make(chan struct{})
+			if tt.uninstallFailures > 0 {
+				mockOFClient.EXPECT().UninstallPolicyBootstrapFlows().Return(fmt.Errorf("error")).Times(tt.uninstallFailures)
+			}
+			mockOFClient.EXPECT().UninstallPolicyBootstrapFlows().Do(func() {
+				close(uninstalled)
+			}).Return(nil).Times(1)
+
+			b := newPolicyBootstrapper(mockOFClient)
+			// Rules realized before the first sync is processed must be ignored.
+			b.ruleRealized("rule1")
+			b.firstSyncProcessed(tt.failedRuleKeys, stopCh)
+			for _, key := range tt.realizedRules {
+				select {
+				case <-uninstalled:
+					t.Fatalf("Bootstrap flows uninstalled before all failed rules were realized")
+				case <-time.After(100 * time.Millisecond):
+				}
+				b.ruleRealized(key)
+			}
+			select {
+			case <-uninstalled:
+			case <-time.After(2*uninstallBootstrapFlowsRetryInterval + time.Second):
+				t.Fatalf("Bootstrap flows were not uninstalled")
+			}
+			// Rules realized afterwards must not trigger another uninstallation.
+			b.ruleRealized("rule4")
+		})
+	}
+}
@@ -0,0 +1,202 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package networkpolicy
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	"antrea.io/antrea/pkg/agent/metrics"
+	"antrea.io/antrea/pkg/apis/controlplane/v1beta2"
+	"antrea.io/antrea/pkg/querier"
+)
+
+// realizationErrorClass is the stage of the realization of a rule which failed.
+type realizationErrorClass string
+
+const (
+	realizationErrorIDAllocation       realizationErrorClass = "IDAllocation"
+	realizationErrorPriorityAssignment realizationErrorClass = "PriorityAssignment"
+	realizationErrorFlowInstall        realizationErrorClass = "FlowInstall"
+	realizationErrorFlowUpdate         realizationErrorClass = "FlowUpdate"
+	realizationErrorFlowUninstall      realizationErrorClass = "FlowUninstall"
+	realizationErrorUnknown            realizationErrorClass = "Unknown"
+)
+
+// realizationError is an error returned by the reconciler, annotated with the stage of the
+// realization which failed.
+type realizationError struct {
+	class realizationErrorClass
+	err   error
+}
+
+func newRealizationError(class realizationErrorClass, err error) error {
+	return &realizationError{class: class, err: err}
+}
+
+func (e *realizationError) Error() string {
+	return e.err.Error()
+}
+
+func (e *realizationError) Unwrap() error {
+	return e.err
+}
+
+// classifyRealizationError returns the class of an error returned by the reconciler.
+func classifyRealizationError(err error) realizationErrorClass {
+	var realizationErr *realizationError
+	if errors.As(err, &realizationErr) {
+		return realizationErr.class
+	}
+	return realizationErrorUnknown
+}
+
+// realizationErrorEntry is the struct kept by realizationErrorRegistry for a rule which failed to
+// be realized.
+type realizationErrorEntry struct {
+	querier.NetworkPolicyRealizationError
+	policyUID types.UID
+}
+
+// realizationErrorRegistry keeps track of the rules which failed to be realized, so that the
+// failures are not only logged while the rules are retried. The entry of a rule is removed once it
+// is realized, or once it is no longer effective on the Node, e.g. because its NetworkPolicy is
+// deleted.
+type realizationErrorRegistry struct {
+	mutex sync.RWMutex
+	// entries is keyed by rule ID.
+	entries map[string]*realizationErrorEntry
+}
+
+func newRealizationErrorRegistry() *realizationErrorRegistry {
+	return &realizationErrorRegistry{entries: map[string]*realizationErrorEntry{}}
+}
+
+// recordFailure records a failure to realize the rule. It returns true if the rule was not
+// failing before, or was failing at another stage.
+func (r *realizationErrorRegistry) recordFailure(rule *CompletedRule, err error) bool {
+	class := classifyRealizationError(err)
+	metrics.NetworkPolicyRealizationErrors.WithLabelValues(string(class)).Inc()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	entry, exists := r.entries[rule.ID]
+	if !exists {
+		entry = &realizationErrorEntry{
+			NetworkPolicyRealizationError: querier.NetworkPolicyRealizationError{
+				PolicyRef: rule.SourceRef,
+				RuleID:    rule.ID,
+				RuleName:  rule.Name,
+			},
+			policyUID: rule.PolicyUID,
+		}
+		r.entries[rule.ID] = entry
+	} else {
+		entry.RetryCount++
+	}
+	changed := entry.ErrorClass != string(class)
+	entry.ErrorClass = string(class)
+	entry.Error = err.Error()
+	entry.LastAttempt = time.Now()
+	return changed
+}
+
+// clear removes the entry of the rule, if any, and returns it.
+func (r *realizationErrorRegistry) clear(ruleID string) *realizationErrorEntry {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	entry, exists := r.entries[ruleID]
+	if !exists {
+		return nil
+	}
+	delete(r.entries, ruleID)
+	return entry
+}
+
+// list returns the rules which failed to be realized, sorted by NetworkPolicy and rule ID.
+func (r *realizationErrorRegistry) list() []querier.NetworkPolicyRealizationError {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	errs := make([]querier.NetworkPolicyRealizationError, 0, len(r.entries))
+	for _, entry := range r.entries {
+		errs = append(errs, entry.NetworkPolicyRealizationError)
+	}
+	sort.Slice(errs, func(i, j int) bool {
+		refI, refJ := errs[i].PolicyRef.ToString(), errs[j].PolicyRef.ToString()
+		if refI != refJ {
+			return refI < refJ
+		}
+		return errs[i].RuleID < errs[j].RuleID
+	})
+	return errs
+}
+
+// policyErrorMessage returns a summary of the rules of the NetworkPolicy which failed to be
+// realized, or an empty string if there is none. The retry count and the time of the last attempt
+// are not included, so that the message only changes when the failures change.
+func (r *realizationErrorRegistry) policyErrorMessage(policyUID types.UID) string {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	var msgs []string
+	for _, entry := range r.entries {
+		if entry.policyUID == policyUID {
+			msgs = append(msgs, fmt.Sprintf("rule %s (%s): %s", entry.RuleName, entry.ErrorClass, entry.Error))
+		}
+	}
+	if len(msgs) == 0 {
+		return ""
+	}
+	sort.Strings(msgs)
+	return fmt.Sprintf("failed to realize %d rule(s): %s", len(msgs), strings.Join(msgs, "; "))
+}
+
+// GetNetworkPolicyRealizationErrors returns the rules which failed to be realized, sorted by
+// NetworkPolicy and rule ID.
+func (c *Controller) GetNetworkPolicyRealizationErrors() []querier.NetworkPolicyRealizationError {
+	return c.realizationErrors.list()
+}
+
+// recordRealization records the result of the realization of a rule. The status of its
+// NetworkPolicy is synced again when the rule starts or stops failing, so that the failure is
+// reported to the antrea-controller, and so are the readiness gates of the local Pods.
+func (c *Controller) recordRealization(rule *CompletedRule, err error) {
+	if c.podReadiness != nil {
+		c.podReadiness.setRuleRealized(rule.ID, err == nil)
+	}
+	var changed bool
+	if err != nil {
+		changed = c.realizationErrors.recordFailure(rule, err)
+	} else {
+		changed = c.realizationErrors.clear(rule.ID) != nil
+	}
+	if changed && c.statusManagerEnabled && rule.SourceRef.Type != v1beta2.K8sNetworkPolicy {
+		c.statusManager.Resync(rule.PolicyUID)
+	}
+}
+
+// forgetRealizationError removes the failure of a rule which is no longer effective, e.g. because
+// its NetworkPolicy has been deleted.
+func (c *Controller) forgetRealizationError(ruleID string) {
+	entry := c.realizationErrors.clear(ruleID)
+	if entry != nil && c.statusManagerEnabled && entry.PolicyRef.Type != v1beta2.K8sNetworkPolicy {
+		c.statusManager.Resync(entry.policyUID)
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package networkpolicy
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/types"
+
+	"antrea.io/antrea/pkg/apis/controlplane/v1beta2"
+)
+
+// fakeStatusManager implements StatusManager. It records the NetworkPolicies resynced.
+type fakeStatusManager struct {
+	resynced []types.UID
+}
+
+func (m *fakeStatusManager) SetRuleRealization(ruleID string, policyID types.UID) {}
+
+func (m *fakeStatusManager) DeleteRuleRealization(ruleID string) {}
+
+func (m *fakeStatusManager) Resync(policyID types.UID) {
+	m.resynced = append(m.resynced, policyID)
+}
+
+func (m *fakeStatusManager) Run(stopCh <-chan struct{}) {}
+
+func newFailingRule(id, name string, policyUID types.UID, sourceRef *v1beta2.NetworkPolicyReference) *CompletedRule {
+	return &CompletedRule{rule: &rule{ID: id, Name: name, PolicyUID: policyUID, SourceRef: sourceRef}}
+}
+
+func TestClassifyRealizationError(t *testing.T) {
+	err := newRealizationError(realizationErrorFlowUpdate, fmt.Errorf("broken pipe"))
+	assert.Equal(t, realizationErrorFlowUpdate, classifyRealizationError(err))
+	assert.Equal(t, realizationErrorFlowUpdate, classifyRealizationError(fmt.Errorf("error reconciling rule: %w", err)))
+	assert.Equal(t, realizationErrorUnknown, classifyRealizationError(fmt.Errorf("broken pipe")))
+	assert.Equal(t, "broken pipe", err.Error())
+}
+
+func TestRealizationErrorRegistry(t *testing.T) {
+	anp := &v1beta2.NetworkPolicyReference{Type: v1beta2.AntreaNetworkPolicy, Namespace: "ns1", Name: "anp1", UID: "uid1"}
+	cnp := &v1beta2.NetworkPolicyReference{Type: v1beta2.AntreaClusterNetworkPolicy, Name: "cnp1", UID: "uid2"}
+	rule1 := newFailingRule("rule1", "allow-dns", "uid1", anp)
+	rule2 := newFailingRule("rule2", "drop-all", "uid1", anp)
+	rule3 := newFailingRule("rule3", "allow-web", "uid2", cnp)
+	r := newRealizationErrorRegistry()
+
+	assert.True(t, r.recordFailure(rule2, newRealizationError(realizationErrorIDAllocation, fmt.Errorf("error allocating Openflow ID"))))
+	assert.True(t, r.recordFailure(rule1, newRealizationError(realizationErrorFlowInstall, fmt.Errorf("broken pipe"))))
+	assert.True(t, r.recordFailure(rule3, fmt.Errorf("unexpected error")))
+	// Failing again at the same stage is a retry.
+	assert.False(t, r.recordFailure(rule1, newRealizationError(realizationErrorFlowInstall, fmt.Errorf("connection refused"))))
+	// Failing at another stage changes the failure.
+	assert.True(t, r.recordFailure(rule2, newRealizationError(realizationErrorFlowInstall, fmt.Errorf("broken pipe"))))
+
+	errs := r.list()
+	require.Len(t, errs, 3)
+	// The errors are sorted by NetworkPolicy and rule ID.
+	assert.Equal(t, []string{"rule3", "rule1", "rule2"}, []string{errs[0].RuleID, errs[1].RuleID, errs[2].RuleID})
+	assert.Equal(t, cnp, errs[0].PolicyRef)
+	assert.Equal(t, "Unknown", errs[0].ErrorClass)
+	assert.Equal(t, 0, errs[0].RetryCount)
+	assert.Equal(t, "allow-dns", errs[1].RuleName)
+	assert.Equal(t, "FlowInstall", errs[1].ErrorClass)
+	assert.Equal(t, "connection refused", errs[1].Error)
+	assert.Equal(t, 1, errs[1].RetryCount)
+	assert.False(t, errs[1].LastAttempt.IsZero())
+
+	assert.Equal(t, "failed to realize 2 rule(s): rule allow-dns (FlowInstall): connection refused; rule drop-all (FlowInstall): broken pipe", r.policyErrorMessage("uid1"))
+	assert.Equal(t, "failed to realize 1 rule(s): rule allow-web (Unknown): unexpected error", r.policyErrorMessage("uid2"))
+	assert.Empty(t, r.policyErrorMessage("uid3"))
+
+	// The entries are cleared when the rules are realized or removed.
+	entry := r.clear("rule1")
+	require.NotNil(t, entry)
+	assert.Equal(t, types.UID("uid1"), entry.policyUID)
+	assert.Nil(t, r.clear("rule1"))
+	r.clear("rule2")
+	assert.Empty(t, r.policyErrorMessage("uid1"))
+	assert.Len(t, r.list(), 1)
+}
+
+func TestRecordRealization(t *testing.T) {
+	anp := &v1beta2.NetworkPolicyReference{Type: v1beta2.AntreaNetworkPolicy, Namespace: "ns1", Name: "anp1", UID: "uid1"}
+	knp := &v1beta2.NetworkPolicyReference{Type: v1beta2.K8sNetworkPolicy, Namespace: "ns1", Name: "knp1", UID: "uid2"}
+	antreaRule := newFailingRule("rule1", "allow-dns", "uid1", anp)
+	k8sRule := newFailingRule("rule2", "ingress-0", "uid2", knp)
+	flowErr := newRealizationError(realizationErrorFlowInstall, fmt.Errorf("broken pipe"))
+
+	statusManager := &fakeStatusManager{}
+	c := &Controller{statusManagerEnabled: true, statusManager: statusManager, realizationErrors: newRealizationErrorRegistry()}
+
+	// The status is resynced when the rule starts failing, not when it's retried.
+	c.recordRealization(antreaRule, flowErr)
+	c.recordRealization(antreaRule, flowErr)
+	assert.Equal(t, []types.UID{"uid1"}, statusManager.resynced)
+	assert.Len(t, c.GetNetworkPolicyRealizationErrors(), 1)
+
+	// The status is resynced when the rule stops failing.
+	c.recordRealization(antreaRule, nil)
+	assert.Equal(t, []types.UID{"uid1", "uid1"}, statusManager.resynced)
+	assert.Empty(t, c.GetNetworkPolicyRealizationErrors())
+	c.recordRealization(antreaRule, nil)
+	assert.Len(t, statusManager.resynced, 2)
+
+	// The status is resynced when the failing rule is no longer effective.
+	c.recordRealization(antreaRule, flowErr)
+	c.forgetRealizationError(antreaRule.ID)
+	assert.Equal(t, []types.UID{"uid1", "uid1", "uid1", "uid1"}, statusManager.resynced)
+	assert.Empty(t, c.GetNetworkPolicyRealizationErrors())
+
+	// The failures of K8s NetworkPolicies are recorded, but their statuses are not synced.
+	statusManager.resynced = nil
+	c.recordRealization(k8sRule, flowErr)
+	assert.Len(t, c.GetNetworkPolicyRealizationErrors(), 1)
+	c.forgetRealizationError(k8sRule.ID)
+	assert.Empty(t, c.GetNetworkPolicyRealizationErrors())
+	assert.Empty(t, statusManager.resynced)
+}
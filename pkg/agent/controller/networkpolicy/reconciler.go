@@ -16,8 +16,10 @@ package networkpolicy
 
 import (
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"net"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -40,6 +42,32 @@ var (
 	baselineTierPriority int32 = 253
 )
 
+// batchReconcileError is returned by BatchReconcile when some of the rules could not be
+// realized. All the other rules of the batch have been realized.
+type batchReconcileError struct {
+	// failedRules maps the ID of each rule which could not be realized to the cause.
+	failedRules map[string]error
+}
+
+func (e *batchReconcileError) Error() string {
+	ruleIDs := e.failedRuleIDs()
+	msgs := make([]string, 0, len(ruleIDs))
+	for _, ruleID := range ruleIDs {
+		msgs = append(msgs, fmt.Sprintf("rule %s: %v", ruleID, e.failedRules[ruleID]))
+	}
+	return fmt.Sprintf("failed to realize %d rule(s): %s", len(ruleIDs), strings.Join(msgs, "; "))
+}
+
+// failedRuleIDs returns the sorted IDs of the rules which could not be realized.
+func (e *batchReconcileError) failedRuleIDs() []string {
+	ruleIDs := make([]string, 0, len(e.failedRules))
+	for ruleID := range e.failedRules {
+		ruleIDs = append(ruleIDs, ruleID)
+	}
+	sort.Strings(ruleIDs)
+	return ruleIDs
+}
+
 // Reconciler is an interface that knows how to reconcile the desired state of
 // CompletedRule with the actual state of Openflow entries.
 type Reconciler interface {
@@ -49,9 +77,17 @@ type Reconciler interface {
 
 	// BatchReconcile reconciles the desired state of the provided CompletedRules
 	// with the actual state of Openflow entries in batch. It should only be invoked
-	// if all rules are newly added without last realized status.
+	// if all rules are newly added without last realized status. If only some of
+	// the rules fail to be realized, a *batchReconcileError is returned.
 	BatchReconcile(rules []*CompletedRule) error
 
+	// ReconcileRules reconciles the desired state of the provided CompletedRules
+	// with the actual state of Openflow entries, ordering the address changes by
+	// rule priority so that no intermediate state is more permissive than both
+	// the initial and the final states. If only some of the rules fail to be
+	// realized, a *batchReconcileError is returned.
+	ReconcileRules(rules []*CompletedRule) error
+
 	// Forget cleanups the actual state of Openflow entries of the specified ruleID.
 	Forget(ruleID string) error
 
@@ -164,6 +200,26 @@ func newLastRealized(rule *CompletedRule) *lastRealized {
 type tablePriorityAssigner struct {
 	assigner *priorityAssigner
 	mutex    sync.RWMutex
+	// pendingUninstalls is the number of rules of the table being uninstalled asynchronously, whose
+	// stale priorities are not released yet. It's protected by mutex.
+	pendingUninstalls int
+	// uninstallsDone is broadcast when pendingUninstalls drops to 0. Its Locker is mutex.
+	uninstallsDone *sync.Cond
+}
+
+func newTablePriorityAssigner(isBaselineTier bool) *tablePriorityAssigner {
+	pa := &tablePriorityAssigner{assigner: newPriorityAssigner(isBaselineTier)}
+	pa.uninstallsDone = sync.NewCond(&pa.mutex)
+	return pa
+}
+
+// waitForUninstallsLocked blocks until the stale priorities of all the rules of the table being
+// uninstalled are released, so that priorities are never assigned while they may be released
+// concurrently. The caller must hold mutex.
+func (pa *tablePriorityAssigner) waitForUninstallsLocked() {
+	for pa.pendingUninstalls > 0 {
+		pa.uninstallsDone.Wait()
+	}
 }
 
 // reconciler implements Reconciler.
@@ -172,7 +228,7 @@ type tablePriorityAssigner struct {
 // time. Different rules can be processed in parallel.
 type reconciler struct {
 	// ofClient is the Openflow interface.
-	ofClient openflow.Client
+	ofClient openflow.PolicyClient
 
 	// ifaceStore provides container interface OFPort and IP information.
 	ifaceStore interfacestore.InterfaceStore
@@ -193,17 +249,16 @@ type reconciler struct {
 }
 
 // newReconciler returns a new *reconciler.
-func newReconciler(ofClient openflow.Client, ifaceStore interfacestore.InterfaceStore, asyncRuleDeleteInterval time.Duration) *reconciler {
+func newReconciler(ofClient openflow.PolicyClient, ifaceStore interfacestore.InterfaceStore, asyncRuleDeleteInterval time.Duration) *reconciler {
 	priorityAssigners := map[binding.TableIDType]*tablePriorityAssigner{}
 	for _, table := range openflow.GetAntreaPolicyBaselineTierTables() {
-		priorityAssigners[table] = &tablePriorityAssigner{
-			assigner: newPriorityAssigner(true),
-		}
+		priorityAssigners[table] = newTablePriorityAssigner(true)
 	}
 	for _, table := range openflow.GetAntreaPolicyMultiTierTables() {
-		priorityAssigners[table] = &tablePriorityAssigner{
-			assigner: newPriorityAssigner(false),
-		}
+		priorityAssigners[table] = newTablePriorityAssigner(false)
+	}
+	for _, table := range openflow.GetAntreaPolicyLayer2Tables() {
+		priorityAssigners[table] = newTablePriorityAssigner(false)
 	}
 	reconciler := &reconciler{
 		ofClient:          ofClient,
@@ -244,10 +299,11 @@ func (r *reconciler) Reconcile(rule *CompletedRule) error {
 		// not yet installed on OVS will be missed.
 		priorityAssigner.mutex.Lock()
 		defer priorityAssigner.mutex.Unlock()
+		priorityAssigner.waitForUninstallsLocked()
 	}
 	ofPriority, registeredBefore, err := r.getOFPriority(rule, ruleTable, priorityAssigner)
 	if err != nil {
-		return err
+		return newRealizationError(realizationErrorPriorityAssignment, err)
 	}
 	var ofRuleInstallErr error
 	if !exists {
@@ -272,6 +328,13 @@ func (r *reconciler) getOFRuleTable(rule *CompletedRule) binding.TableIDType {
 			return openflow.EgressRuleTable
 		}
 	}
+	// The layer 2 rules of all the Tiers are enforced in the tables dedicated to their protocol.
+	if rule.isLayer2Rule() {
+		if *rule.Services[0].Protocol == v1beta2.ProtocolARP {
+			return openflow.AntreaPolicyARPRuleTable
+		}
+		return openflow.AntreaPolicyNDRuleTable
+	}
 	var ruleTables []binding.TableIDType
 	if rule.Direction == v1beta2.DirectionIn {
 		ruleTables = openflow.GetAntreaPolicyIngressTables()
@@ -339,7 +402,7 @@ func (r *reconciler) BatchReconcile(rules []*CompletedRule) error {
 		}
 	}
 	if err := r.registerOFPriorities(rulesToInstall); err != nil {
-		return err
+		return newRealizationError(realizationErrorPriorityAssignment, err)
 	}
 	for _, rule := range rulesToInstall {
 		ruleTable := r.getOFRuleTable(rule)
@@ -352,7 +415,10 @@ func (r *reconciler) BatchReconcile(rules []*CompletedRule) error {
 		}
 	}
 	ofRuleInstallErr := r.batchAdd(rulesToInstall, priorities)
-	if ofRuleInstallErr != nil {
+	var batchErr *batchReconcileError
+	// If only some rules fail, the priorities are kept as they may be shared with
+	// realized rules, and they are reused when the failed rules are reconciled again.
+	if ofRuleInstallErr != nil && !errors.As(ofRuleInstallErr, &batchErr) {
 		// If batch reconcile fails, all priorities should be released and the
 		// priorityAssigners should return to the initial state.
 		for tableID, ofPriorities := range prioritiesByTable {
@@ -365,6 +431,137 @@ func (r *reconciler) BatchReconcile(rules []*CompletedRule) error {
 	return ofRuleInstallErr
 }
 
+// ReconcileRules reconciles the provided CompletedRules in two passes. When an
+// address moves from the AddressGroup of a rule to the AddressGroup of another
+// rule, adding it to the lower-precedence rule before deleting it from the
+// higher-precedence one would let the latter match the traffic for a while,
+// e.g. an allow rule could still win over the drop rule the address was moved
+// to. Hence the first pass only removes the addresses which are no longer
+// selected, from the highest-precedence rule to the lowest one, and the second
+// pass reconciles the rules to their desired states, from the lowest-precedence
+// rule to the highest one.
+func (r *reconciler) ReconcileRules(rules []*CompletedRule) error {
+	sortedRules := make([]*CompletedRule, len(rules))
+	copy(sortedRules, rules)
+	sort.SliceStable(sortedRules, func(i, j int) bool {
+		return rulePrecedes(sortedRules[i], sortedRules[j])
+	})
+	failedRules := map[string]error{}
+	for _, rule := range sortedRules {
+		value, exists := r.lastRealizeds.Load(rule.ID)
+		if !exists {
+			continue
+		}
+		intermediate := intermediateRule(value.(*lastRealized), rule)
+		if intermediate == nil {
+			continue
+		}
+		klog.V(2).Infof("Removing the unselected addresses of rule %s before reconciling it", rule.ID)
+		if err := r.Reconcile(intermediate); err != nil {
+			failedRules[rule.ID] = err
+		}
+	}
+	for i := len(sortedRules) - 1; i >= 0; i-- {
+		rule := sortedRules[i]
+		if err := r.Reconcile(rule); err != nil {
+			if _, failed := failedRules[rule.ID]; !failed {
+				failedRules[rule.ID] = err
+			}
+		} else {
+			delete(failedRules, rule.ID)
+		}
+	}
+	if len(failedRules) > 0 {
+		return &batchReconcileError{failedRules: failedRules}
+	}
+	return nil
+}
+
+// rulePrecedes returns whether rule a takes precedence over rule b in the
+// OpenFlow pipeline. The rules of K8s NetworkPolicies are enforced after the
+// rules of all Antrea-native policies, except the ones in the baseline Tier.
+func rulePrecedes(a, b *CompletedRule) bool {
+	effectiveTierPriority := func(r *CompletedRule) float64 {
+		if !r.isAntreaNetworkPolicyRule() {
+			return float64(baselineTierPriority) - 0.5
+		}
+		return float64(*r.TierPriority)
+	}
+	tierA, tierB := effectiveTierPriority(a), effectiveTierPriority(b)
+	if tierA != tierB {
+		return tierA < tierB
+	}
+	if !a.isAntreaNetworkPolicyRule() {
+		return false
+	}
+	if *a.PolicyPriority != *b.PolicyPriority {
+		return *a.PolicyPriority < *b.PolicyPriority
+	}
+	return a.Priority < b.Priority
+}
+
+// intermediateRule returns the state between the last realized state of a rule
+// and its desired state in which the addresses no longer selected by the rule
+// have been removed, but no address has been added yet. It returns nil if no
+// address needs to be removed.
+func intermediateRule(lastRealized *lastRealized, newRule *CompletedRule) *CompletedRule {
+	intermediate := &CompletedRule{
+		rule:          newRule.rule,
+		FromAddresses: newRule.FromAddresses,
+		ToAddresses:   newRule.ToAddresses,
+		TargetMembers: lastRealized.TargetMembers,
+	}
+	removed := false
+	if newRule.Direction == v1beta2.DirectionIn {
+		intermediate.FromAddresses = retainedMembers(lastRealized.FromAddresses, newRule.FromAddresses)
+		removed = len(intermediate.FromAddresses) < len(lastRealized.FromAddresses)
+	} else {
+		// The addresses are grouped by the results of resolving named ports,
+		// each group being realized by its own Openflow rule.
+		prevMembersByServicesMap, _ := groupMembersByServices(lastRealized.Services, lastRealized.ToAddresses)
+		newMembersByServicesMap, _ := groupMembersByServices(newRule.Services, newRule.ToAddresses)
+		intermediate.ToAddresses = v1beta2.NewGroupMemberSet()
+		for svcKey, prevMembers := range prevMembersByServicesMap {
+			newMembers := newMembersByServicesMap[svcKey]
+			members := retainedMembers(prevMembers, newMembers)
+			// Emptying a group which is still needed would uninstall its Openflow
+			// rule only to install it again, keep the group unchanged instead.
+			if len(members) == 0 && len(newMembers) > 0 {
+				members = prevMembers
+			}
+			if len(members) < len(prevMembers) {
+				removed = true
+			}
+			intermediate.ToAddresses = intermediate.ToAddresses.Union(members)
+		}
+	}
+	if !removed {
+		return nil
+	}
+	return intermediate
+}
+
+// retainedMembers returns the members of prevMembers which are in newMembers or
+// share at least one IP with newMembers. The IPs of the other members are no
+// longer selected.
+func retainedMembers(prevMembers, newMembers v1beta2.GroupMemberSet) v1beta2.GroupMemberSet {
+	removedIPs := prevMembers.IPDifference(newMembers)
+	retained := v1beta2.NewGroupMemberSet()
+	for _, member := range prevMembers {
+		if newMembers.Has(member) {
+			retained.Insert(member)
+			continue
+		}
+		for _, ip := range member.IPs {
+			if !removedIPs.Has(net.IP(ip).String()) {
+				retained.Insert(member)
+				break
+			}
+		}
+	}
+	return retained
+}
+
 // registerOFPriorities constructs a Priority type for each CompletedRule in the input list,
 // and registers those Priorities with appropriate tablePriorityAssigner based on Tier.
 func (r *reconciler) registerOFPriorities(rules []*CompletedRule) error {
@@ -396,7 +593,7 @@ func (r *reconciler) add(rule *CompletedRule, ofPriority *uint16, table binding.
 		// Each pod group gets an Openflow ID.
 		err := r.idAllocator.allocateForRule(ofRule)
 		if err != nil {
-			return fmt.Errorf("error allocating Openflow ID")
+			return newRealizationError(realizationErrorIDAllocation, fmt.Errorf("error allocating Openflow ID"))
 		}
 		if err = r.installOFRule(ofRule); err != nil {
 			return err
@@ -415,7 +612,12 @@ func (r *reconciler) computeOFRulesForAdd(rule *CompletedRule, ofPriority *uint1
 
 	ofRuleByServicesMap := map[servicesKey]*types.PolicyRule{}
 
-	if rule.Direction == v1beta2.DirectionIn {
+	if rule.isLayer2Rule() {
+		svcKey := normalizeServices(rule.Services)
+		ofPorts := r.getOFPorts(rule.TargetMembers)
+		lastRealized.podOFPorts[svcKey] = ofPorts
+		ofRuleByServicesMap[svcKey] = r.newLayer2OFRule(rule, ofPorts, ofPriority, table)
+	} else if rule.Direction == v1beta2.DirectionIn {
 		// Addresses got from source GroupMembers' IPs.
 		from1 := groupMembersToOFAddresses(rule.FromAddresses)
 		// Get addresses that in From IPBlock but not in Except IPBlocks.
@@ -492,6 +694,26 @@ func (r *reconciler) computeOFRulesForAdd(rule *CompletedRule, ofPriority *uint1
 	return ofRuleByServicesMap, lastRealized
 }
 
+// newLayer2OFRule returns the PolicyRule of a layer 2 rule. It matches the messages sent from the
+// OVS ports of the target members, and its destination addresses are the target addresses of the
+// messages, i.e. the IPs of the members of its peers and its IPBlocks.
+func (r *reconciler) newLayer2OFRule(rule *CompletedRule, ofPorts sets.Int32, ofPriority *uint16, table binding.TableIDType) *types.PolicyRule {
+	to := groupMembersToOFAddresses(rule.ToAddresses)
+	to = append(to, ipBlocksToOFAddresses(rule.To.IPBlocks, r.ipv4Enabled, r.ipv6Enabled)...)
+	return &types.PolicyRule{
+		Direction:     v1beta2.DirectionOut,
+		From:          ofPortsToOFAddresses(ofPorts),
+		To:            to,
+		Service:       rule.Services,
+		Action:        rule.Action,
+		Name:          rule.Name,
+		Priority:      ofPriority,
+		TableID:       table,
+		PolicyRef:     rule.SourceRef,
+		EnableLogging: rule.EnableLogging,
+	}
+}
+
 // batchAdd converts CompletedRules to PolicyRules and invokes BatchInstallPolicyRuleFlows to install them.
 func (r *reconciler) batchAdd(rules []*CompletedRule, ofPriorities []*uint16) error {
 	lastRealizeds := make([]*lastRealized, len(rules))
@@ -506,7 +728,7 @@ func (r *reconciler) batchAdd(rules []*CompletedRule, ofPriorities []*uint16) er
 		for svcKey, ofRule := range ofRuleByServicesMap {
 			err := r.idAllocator.allocateForRule(ofRule)
 			if err != nil {
-				return fmt.Errorf("error allocating Openflow ID")
+				return newRealizationError(realizationErrorIDAllocation, fmt.Errorf("error allocating Openflow ID"))
 			}
 			allOFRules = append(allOFRules, ofRule)
 			if ofIDUpdateMaps[idx] == nil {
@@ -515,18 +737,33 @@ func (r *reconciler) batchAdd(rules []*CompletedRule, ofPriorities []*uint16) er
 			ofIDUpdateMaps[idx][svcKey] = ofRule.FlowID
 		}
 	}
-	if err := r.ofClient.BatchInstallPolicyRuleFlows(allOFRules); err != nil {
+	err := r.ofClient.BatchInstallPolicyRuleFlows(allOFRules)
+	var installErr *openflow.PolicyRuleInstallError
+	if err != nil && !errors.As(err, &installErr) {
 		for _, rule := range allOFRules {
 			r.idAllocator.forgetRule(rule.FlowID)
 		}
-		return err
+		return newRealizationError(realizationErrorFlowInstall, err)
 	}
+	failedRules := map[string]error{}
 	for i, lastRealized := range lastRealizeds {
 		ofIDUpdatesByRule := ofIDUpdateMaps[i]
 		for svcKey, ofID := range ofIDUpdatesByRule {
+			// Record ofID only if its Openflow is installed successfully, the missing ones
+			// are installed when the rule is reconciled again.
+			if installErr != nil {
+				if ruleErr, failed := installErr.FailedRules[ofID]; failed {
+					r.idAllocator.forgetRule(ofID)
+					failedRules[rules[i].ID] = newRealizationError(realizationErrorFlowInstall, ruleErr)
+					continue
+				}
+			}
 			lastRealized.ofIDs[svcKey] = ofID
 		}
 	}
+	if len(failedRules) > 0 {
+		return &batchReconcileError{failedRules: failedRules}
+	}
 	return nil
 }
 
@@ -543,7 +780,31 @@ func (r *reconciler) update(lastRealized *lastRealized, newRule *CompletedRule,
 
 	// As rule identifier is calculated from the rule's content, the update can
 	// only happen to Group members.
-	if newRule.Direction == v1beta2.DirectionIn {
+	if newRule.isLayer2Rule() {
+		svcKey := normalizeServices(newRule.Services)
+		newOFPorts := r.getOFPorts(newRule.TargetMembers)
+		ofID, exists := lastRealized.ofIDs[svcKey]
+		if !exists {
+			ofRule := r.newLayer2OFRule(newRule, newOFPorts, ofPriority, table)
+			if err := r.idAllocator.allocateForRule(ofRule); err != nil {
+				return newRealizationError(realizationErrorIDAllocation, fmt.Errorf("error allocating Openflow ID"))
+			}
+			if err := r.installOFRule(ofRule); err != nil {
+				return err
+			}
+			lastRealized.ofIDs[svcKey] = ofRule.FlowID
+		} else {
+			addedFrom := ofPortsToOFAddresses(newOFPorts.Difference(lastRealized.podOFPorts[svcKey]))
+			deletedFrom := ofPortsToOFAddresses(lastRealized.podOFPorts[svcKey].Difference(newOFPorts))
+			addedTo := groupMembersToOFAddresses(newRule.ToAddresses.Difference(lastRealized.ToAddresses))
+			deletedTo := groupMembersToOFAddresses(lastRealized.ToAddresses.Difference(newRule.ToAddresses))
+			if err := r.updateOFRule(ofID, addedFrom, addedTo, deletedFrom, deletedTo, ofPriority); err != nil {
+				return err
+			}
+			delete(staleOFIDs, svcKey)
+		}
+		lastRealized.podOFPorts[svcKey] = newOFPorts
+	} else if newRule.Direction == v1beta2.DirectionIn {
 		from1 := groupMembersToOFAddresses(newRule.FromAddresses)
 		from2 := ipBlocksToOFAddresses(newRule.From.IPBlocks, r.ipv4Enabled, r.ipv6Enabled)
 		addedFrom := ipsToOFAddresses(newRule.FromAddresses.IPDifference(lastRealized.FromAddresses))
@@ -561,6 +822,7 @@ func (r *reconciler) update(lastRealized *lastRealized, newRule *CompletedRule,
 					To:            ofPortsToOFAddresses(newOFPorts),
 					Service:       filterUnresolvablePort(servicesMap[svcKey]),
 					Action:        newRule.Action,
+					Name:          newRule.Name,
 					Priority:      ofPriority,
 					FlowID:        ofID,
 					TableID:       table,
@@ -569,7 +831,7 @@ func (r *reconciler) update(lastRealized *lastRealized, newRule *CompletedRule,
 				}
 				err := r.idAllocator.allocateForRule(ofRule)
 				if err != nil {
-					return err
+					return newRealizationError(realizationErrorIDAllocation, fmt.Errorf("error allocating Openflow ID"))
 				}
 				if err = r.installOFRule(ofRule); err != nil {
 					return err
@@ -610,6 +872,7 @@ func (r *reconciler) update(lastRealized *lastRealized, newRule *CompletedRule,
 					To:            groupMembersToOFAddresses(members),
 					Service:       filterUnresolvablePort(servicesMap[svcKey]),
 					Action:        newRule.Action,
+					Name:          newRule.Name,
 					Priority:      ofPriority,
 					FlowID:        ofID,
 					TableID:       table,
@@ -624,7 +887,7 @@ func (r *reconciler) update(lastRealized *lastRealized, newRule *CompletedRule,
 				}
 				err := r.idAllocator.allocateForRule(ofRule)
 				if err != nil {
-					return fmt.Errorf("error allocating Openflow ID")
+					return newRealizationError(realizationErrorIDAllocation, fmt.Errorf("error allocating Openflow ID"))
 				}
 				if err = r.installOFRule(ofRule); err != nil {
 					return err
@@ -659,7 +922,7 @@ func (r *reconciler) installOFRule(ofRule *types.PolicyRule) error {
 		ofRule.FlowID, ofRule.Direction, len(ofRule.From), len(ofRule.To), len(ofRule.Service))
 	if err := r.ofClient.InstallPolicyRuleFlows(ofRule); err != nil {
 		r.idAllocator.forgetRule(ofRule.FlowID)
-		return fmt.Errorf("error installing ofRule %v: %v", ofRule.FlowID, err)
+		return newRealizationError(realizationErrorFlowInstall, fmt.Errorf("error installing ofRule %v: %v", ofRule.FlowID, err))
 	}
 	return nil
 }
@@ -670,22 +933,22 @@ func (r *reconciler) updateOFRule(ofID uint32, addedFrom []types.Address, addedT
 	// TODO: This might be unnecessarily complex and hard for error handling, consider revising the Openflow interfaces.
 	if len(addedFrom) > 0 {
 		if err := r.ofClient.AddPolicyRuleAddress(ofID, types.SrcAddress, addedFrom, priority); err != nil {
-			return fmt.Errorf("error adding policy rule source addresses for ofRule %v: %v", ofID, err)
+			return newRealizationError(realizationErrorFlowUpdate, fmt.Errorf("error adding policy rule source addresses for ofRule %v: %v", ofID, err))
 		}
 	}
 	if len(addedTo) > 0 {
 		if err := r.ofClient.AddPolicyRuleAddress(ofID, types.DstAddress, addedTo, priority); err != nil {
-			return fmt.Errorf("error adding policy rule destination addresses for ofRule %v: %v", ofID, err)
+			return newRealizationError(realizationErrorFlowUpdate, fmt.Errorf("error adding policy rule destination addresses for ofRule %v: %v", ofID, err))
 		}
 	}
 	if len(deletedFrom) > 0 {
 		if err := r.ofClient.DeletePolicyRuleAddress(ofID, types.SrcAddress, deletedFrom, priority); err != nil {
-			return fmt.Errorf("error deleting policy rule source addresses for ofRule %v: %v", ofID, err)
+			return newRealizationError(realizationErrorFlowUpdate, fmt.Errorf("error deleting policy rule source addresses for ofRule %v: %v", ofID, err))
 		}
 	}
 	if len(deletedTo) > 0 {
 		if err := r.ofClient.DeletePolicyRuleAddress(ofID, types.DstAddress, deletedTo, priority); err != nil {
-			return fmt.Errorf("error deleting policy rule destination addresses for ofRule %v: %v", ofID, err)
+			return newRealizationError(realizationErrorFlowUpdate, fmt.Errorf("error deleting policy rule destination addresses for ofRule %v: %v", ofID, err))
 		}
 	}
 	return nil
@@ -695,27 +958,70 @@ func (r *reconciler) uninstallOFRule(ofID uint32, table binding.TableIDType) err
 	klog.V(2).Infof("Uninstalling ofRule %d", ofID)
 	stalePriorities, err := r.ofClient.UninstallPolicyRuleFlows(ofID)
 	if err != nil {
-		return fmt.Errorf("error uninstalling ofRule %v: %v", ofID, err)
+		return newRealizationError(realizationErrorFlowUninstall, fmt.Errorf("error uninstalling ofRule %v: %v", ofID, err))
 	}
-	if len(stalePriorities) > 0 {
-		for _, p := range stalePriorities {
-			klog.V(2).Infof("Releasing stale priority %v", p)
-			priorityNum, err := strconv.ParseUint(p, 10, 16)
-			if err != nil {
-				// Cannot parse the priority str. Theoretically this should never happen.
-				return err
+	return r.releaseOFRule(ofID, table, stalePriorities)
+}
+
+// uninstallOFRuleAsync uninstalls the Openflow entries of ofID in the background, so that other rules
+// can be reconciled meanwhile. It's retried until it succeeds. If the table has a priorityAssigner,
+// the caller must hold its mutex, and the rules of the table are not reconciled until the stale
+// priorities are released.
+func (r *reconciler) uninstallOFRuleAsync(ofID uint32, table binding.TableIDType) {
+	klog.V(2).Infof("Uninstalling ofRule %d asynchronously", ofID)
+	priorityAssigner, exists := r.priorityAssigners[table]
+	if exists {
+		priorityAssigner.pendingUninstalls++
+	}
+	resultCh := r.ofClient.UninstallPolicyRuleFlowsAsync(ofID)
+	go func() {
+		retryDelay := minRetryDelay
+		result := <-resultCh
+		for result.Err != nil {
+			klog.Errorf("Error uninstalling ofRule %v, retrying in %v: %v", ofID, retryDelay, result.Err)
+			time.Sleep(retryDelay)
+			if retryDelay *= 2; retryDelay > maxRetryDelay {
+				retryDelay = maxRetryDelay
 			}
-			// If there are stalePriorities, priorityAssigners[table] must not be nil.
-			priorityAssigner, _ := r.priorityAssigners[table]
-			priorityAssigner.assigner.Release(uint16(priorityNum))
+			result = <-r.ofClient.UninstallPolicyRuleFlowsAsync(ofID)
 		}
+		if exists {
+			priorityAssigner.mutex.Lock()
+			defer priorityAssigner.mutex.Unlock()
+			defer func() {
+				priorityAssigner.pendingUninstalls--
+				if priorityAssigner.pendingUninstalls == 0 {
+					priorityAssigner.uninstallsDone.Broadcast()
+				}
+			}()
+		}
+		if err := r.releaseOFRule(ofID, table, result.StalePriorities); err != nil {
+			klog.Errorf("Error releasing ofRule %v: %v", ofID, err)
+		}
+	}()
+}
+
+// releaseOFRule releases the stale priorities returned by the uninstallation of ofID, and ofID itself.
+// If the table has a priorityAssigner, the caller must hold its mutex.
+func (r *reconciler) releaseOFRule(ofID uint32, table binding.TableIDType, stalePriorities []string) error {
+	for _, p := range stalePriorities {
+		klog.V(2).Infof("Releasing stale priority %v", p)
+		priorityNum, err := strconv.ParseUint(p, 10, 16)
+		if err != nil {
+			// Cannot parse the priority str. Theoretically this should never happen.
+			return err
+		}
+		// If there are stalePriorities, priorityAssigners[table] must not be nil.
+		priorityAssigner, _ := r.priorityAssigners[table]
+		priorityAssigner.assigner.Release(uint16(priorityNum))
 	}
 	r.idAllocator.forgetRule(ofID)
 	return nil
 }
 
-// Forget invokes UninstallPolicyRuleFlows to uninstall Openflow entries
-// associated with the provided ruleID if it was enforced before.
+// Forget invokes UninstallPolicyRuleFlowsAsync to uninstall Openflow entries
+// associated with the provided ruleID if it was enforced before. It doesn't
+// wait for the entries to be uninstalled.
 func (r *reconciler) Forget(ruleID string) error {
 	klog.Infof("Forgetting rule %v", ruleID)
 
@@ -733,9 +1039,7 @@ func (r *reconciler) Forget(ruleID string) error {
 		defer priorityAssigner.mutex.Unlock()
 	}
 	for svcKey, ofID := range lastRealized.ofIDs {
-		if err := r.uninstallOFRule(ofID, table); err != nil {
-			return err
-		}
+		r.uninstallOFRuleAsync(ofID, table)
 		delete(lastRealized.ofIDs, svcKey)
 		delete(lastRealized.podOFPorts, svcKey)
 	}
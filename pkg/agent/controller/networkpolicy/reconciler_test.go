@@ -20,9 +20,11 @@ import (
 	"net"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/apimachinery/pkg/util/sets"
 
@@ -32,6 +34,7 @@ import (
 	"antrea.io/antrea/pkg/agent/types"
 	"antrea.io/antrea/pkg/agent/util"
 	"antrea.io/antrea/pkg/apis/controlplane/v1beta2"
+	crdv1alpha1 "antrea.io/antrea/pkg/apis/crd/v1alpha1"
 )
 
 var (
@@ -167,14 +170,14 @@ func TestReconcilerForget(t *testing.T) {
 			controller := gomock.NewController(t)
 			defer controller.Finish()
 			ifaceStore := interfacestore.NewInterfaceStore()
-			mockOFClient := openflowtest.NewMockClient(controller)
+			mockOFClient := openflowtest.NewMockPolicyClient(controller)
 			mockOFClient.EXPECT().IsIPv4Enabled().Return(true).AnyTimes()
 			mockOFClient.EXPECT().IsIPv6Enabled().Return(false).AnyTimes()
 			if len(tt.expectedOFRuleIDs) == 0 {
-				mockOFClient.EXPECT().UninstallPolicyRuleFlows(gomock.Any()).Times(0)
+				mockOFClient.EXPECT().UninstallPolicyRuleFlowsAsync(gomock.Any()).Times(0)
 			} else {
 				for _, ofID := range tt.expectedOFRuleIDs {
-					mockOFClient.EXPECT().UninstallPolicyRuleFlows(ofID)
+					mockOFClient.EXPECT().UninstallPolicyRuleFlowsAsync(ofID).Return(newUninstallResultCh(nil, nil))
 				}
 			}
 			r := newReconciler(mockOFClient, ifaceStore, testAsyncDeleteInterval)
@@ -184,7 +187,69 @@ func TestReconcilerForget(t *testing.T) {
 			if err := r.Forget(tt.args); (err != nil) != tt.wantErr {
 				t.Fatalf("Forget() error = %v, wantErr %v", err, tt.wantErr)
 			}
+			_, exists := r.lastRealizeds.Load(tt.args)
+			assert.False(t, exists)
+			// The stale priorities are released once the uninstallations complete.
+			for _, pa := range r.priorityAssigners {
+				assert.Eventually(t, func() bool {
+					pa.mutex.Lock()
+					defer pa.mutex.Unlock()
+					return pa.pendingUninstalls == 0
+				}, time.Second, 10*time.Millisecond)
+			}
+		})
+	}
+}
+
+// newUninstallResultCh returns a channel from which the provided result of an asynchronous
+// uninstallation can be received.
+func newUninstallResultCh(stalePriorities []string, err error) <-chan types.PolicyRuleUninstallResult {
+	ch := make(chan types.PolicyRuleUninstallResult, 1)
+	ch <- types.PolicyRuleUninstallResult{StalePriorities: stalePriorities, Err: err}
+	return ch
+}
+
+func TestReconcilerReconcileWaitsForUninstall(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+	mockOFClient := openflowtest.NewMockPolicyClient(controller)
+	mockOFClient.EXPECT().IsIPv4Enabled().Return(true).AnyTimes()
+	mockOFClient.EXPECT().IsIPv6Enabled().Return(false).AnyTimes()
+	r := newReconciler(mockOFClient, interfacestore.NewInterfaceStore(), testAsyncDeleteInterval)
+	r.lastRealizeds.Store("foo", &lastRealized{
+		ofIDs: map[servicesKey]uint32{servicesKey1: 8},
+		CompletedRule: &CompletedRule{
+			rule: &rule{ID: "foo", Direction: v1beta2.DirectionIn, PolicyPriority: &policyPriority, TierPriority: &tierPriority, SourceRef: &cnp1},
+		},
+	})
+
+	resultCh := make(chan types.PolicyRuleUninstallResult, 1)
+	mockOFClient.EXPECT().UninstallPolicyRuleFlowsAsync(uint32(8)).Return((<-chan types.PolicyRuleUninstallResult)(resultCh))
+	require.NoError(t, r.Forget("foo"))
+
+	// A rule in the same table must not be reconciled while the stale priorities of the forgotten
+	// rule may still be released.
+	mockOFClient.EXPECT().InstallPolicyRuleFlows(gomock.Any())
+	reconciled := make(chan error)
+	go func() {
+		reconciled <- r.Reconcile(&CompletedRule{
+			rule:          &rule{ID: "bar", Direction: v1beta2.DirectionIn, PolicyPriority: &policyPriority, TierPriority: &tierPriority, SourceRef: &cnp1},
+			FromAddresses: addressGroup1,
+			TargetMembers: appliedToGroup1,
 		})
+	}()
+	select {
+	case <-reconciled:
+		t.Fatal("Rule was reconciled before the uninstallation completed")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	resultCh <- types.PolicyRuleUninstallResult{}
+	select {
+	case err := <-reconciled:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("Rule was not reconciled after the uninstallation completed")
 	}
 }
 
@@ -512,19 +577,12 @@ func TestReconcilerReconcile(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			controller := gomock.NewController(t)
-			defer controller.Finish()
-			mockOFClient := openflowtest.NewMockClient(controller)
-			mockOFClient.EXPECT().IsIPv4Enabled().Return(true).AnyTimes()
-			mockOFClient.EXPECT().IsIPv6Enabled().Return(false).AnyTimes()
-			// TODO: mock idAllocator and priorityAssigner
-			for i := 0; i < len(tt.expectedOFRules); i++ {
-				mockOFClient.EXPECT().InstallPolicyRuleFlows(gomock.Any())
-			}
-			r := newReconciler(mockOFClient, ifaceStore, testAsyncDeleteInterval)
+			ofClient := openflowtest.NewFakeClient(true, false)
+			r := newReconciler(ofClient, ifaceStore, testAsyncDeleteInterval)
 			if err := r.Reconcile(tt.args); (err != nil) != tt.wantErr {
 				t.Fatalf("Reconcile() error = %v, wantErr %v", err, tt.wantErr)
 			}
+			assertPolicyRulesInstalled(t, ofClient, tt.expectedOFRules)
 		})
 	}
 }
@@ -579,7 +637,7 @@ func TestReconcileWithTransientError(t *testing.T) {
 
 	controller := gomock.NewController(t)
 	defer controller.Finish()
-	mockOFClient := openflowtest.NewMockClient(controller)
+	mockOFClient := openflowtest.NewMockPolicyClient(controller)
 	mockOFClient.EXPECT().IsIPv4Enabled().Return(true).AnyTimes()
 	mockOFClient.EXPECT().IsIPv6Enabled().Return(true).AnyTimes()
 	r := newReconciler(mockOFClient, ifaceStore, testAsyncDeleteInterval)
@@ -738,35 +796,198 @@ func TestReconcilerBatchReconcile(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			controller := gomock.NewController(t)
-			defer controller.Finish()
-			mockOFClient := openflowtest.NewMockClient(controller)
-			mockOFClient.EXPECT().IsIPv4Enabled().Return(true).AnyTimes()
-			mockOFClient.EXPECT().IsIPv6Enabled().Return(false).AnyTimes()
-			r := newReconciler(mockOFClient, ifaceStore, testAsyncDeleteInterval)
+			ofClient := openflowtest.NewFakeClient(true, false)
+			r := newReconciler(ofClient, ifaceStore, testAsyncDeleteInterval)
 			if tt.numInstalledRules > 0 {
 				// BatchInstall should skip rules already installed
 				r.lastRealizeds.Store(tt.args[0].ID, newLastRealized(tt.args[0]))
 			}
-			// TODO: mock idAllocator and priorityAssigner
-			mockOFClient.EXPECT().BatchInstallPolicyRuleFlows(gomock.Any()).
-				Do(func(rules []*types.PolicyRule) {
-					if tt.numInstalledRules == 0 {
-						assert.Equalf(t, len(rules), len(tt.expectedOFRules),
-							"Expect to install %v flows while %v flows were installed",
-							len(tt.expectedOFRules), len(rules))
-					} else if tt.numInstalledRules > 0 {
-						assert.Equalf(t, len(rules), len(tt.expectedOFRules)-tt.numInstalledRules,
-							"Expect to install %v flows while %v flows were installed",
-							len(tt.expectedOFRules)-tt.numInstalledRules, len(rules))
-					}
-				})
 			err := r.BatchReconcile(tt.args)
 			assert.Equalf(t, err != nil, tt.wantErr, "BatchReconcile() error = %v, wantErr %v", err, tt.wantErr)
+			assert.Lenf(t, ofClient.PolicyRules(), len(tt.expectedOFRules)-tt.numInstalledRules,
+				"Expect to install %v flows while %v flows were installed",
+				len(tt.expectedOFRules)-tt.numInstalledRules, len(ofClient.PolicyRules()))
 		})
 	}
 }
 
+func TestReconcilerBatchReconcilePartialFailure(t *testing.T) {
+	ifaceStore := interfacestore.NewInterfaceStore()
+	ifaceStore.AddInterface(&interfacestore.InterfaceConfig{
+		InterfaceName:            util.GenerateContainerInterfaceName("pod1", "ns1", "container1"),
+		IPs:                      []net.IP{net.ParseIP("2.2.2.2")},
+		ContainerInterfaceConfig: &interfacestore.ContainerInterfaceConfig{PodName: "pod1", PodNamespace: "ns1", ContainerID: "container1"},
+		OVSPortConfig:            &interfacestore.OVSPortConfig{OFPort: 1},
+	})
+	ingressRule := &CompletedRule{
+		rule:          &rule{ID: "ingress-rule", Direction: v1beta2.DirectionIn, Services: []v1beta2.Service{serviceTCP80}, SourceRef: &np1},
+		FromAddresses: addressGroup1,
+		TargetMembers: appliedToGroup1,
+	}
+	egressRule := &CompletedRule{
+		rule:          &rule{ID: "egress-rule", Direction: v1beta2.DirectionOut, SourceRef: &np1},
+		ToAddresses:   addressGroup1,
+		TargetMembers: appliedToGroup1,
+	}
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+	mockOFClient := openflowtest.NewMockPolicyClient(controller)
+	mockOFClient.EXPECT().IsIPv4Enabled().Return(true).AnyTimes()
+	mockOFClient.EXPECT().IsIPv6Enabled().Return(false).AnyTimes()
+	r := newReconciler(mockOFClient, ifaceStore, testAsyncDeleteInterval)
+	r.idAllocator.deleteInterval = 0
+
+	// The flows of the egress rule fail to be installed.
+	mockOFClient.EXPECT().BatchInstallPolicyRuleFlows(gomock.Any()).DoAndReturn(func(rules []*types.PolicyRule) error {
+		require.Len(t, rules, 2)
+		failedRules := map[uint32]error{}
+		for _, rule := range rules {
+			if rule.Direction == v1beta2.DirectionOut {
+				failedRules[rule.FlowID] = transientError
+			}
+		}
+		return &openflow.PolicyRuleInstallError{FailedRules: failedRules}
+	}).Times(1)
+	err := r.BatchReconcile([]*CompletedRule{ingressRule, egressRule})
+	var batchErr *batchReconcileError
+	require.True(t, errors.As(err, &batchErr))
+	assert.Equal(t, []string{"egress-rule"}, batchErr.failedRuleIDs())
+	assert.Equal(t, realizationErrorFlowInstall, classifyRealizationError(batchErr.failedRules["egress-rule"]))
+	// The ingress rule is realized, the egress rule has no realized ofID and its ID is released.
+	value, exists := r.lastRealizeds.Load(ingressRule.ID)
+	require.True(t, exists)
+	assert.Len(t, value.(*lastRealized).ofIDs, 1)
+	value, exists = r.lastRealizeds.Load(egressRule.ID)
+	require.True(t, exists)
+	assert.Empty(t, value.(*lastRealized).ofIDs)
+	assert.Equal(t, 1, r.idAllocator.deleteQueue.Len())
+
+	// Reconciling the failed rule again only installs its flows.
+	mockOFClient.EXPECT().InstallPolicyRuleFlows(newPolicyRulesMatcher(&types.PolicyRule{
+		Direction: v1beta2.DirectionOut,
+		From:      ipsToOFAddresses(sets.NewString("2.2.2.2")),
+		To:        ipsToOFAddresses(sets.NewString("1.1.1.1")),
+		Service:   nil,
+		PolicyRef: &np1,
+		TableID:   openflow.EgressRuleTable,
+	})).Return(nil).Times(1)
+	err = r.Reconcile(egressRule)
+	require.NoError(t, err)
+	value, _ = r.lastRealizeds.Load(egressRule.ID)
+	assert.Len(t, value.(*lastRealized).ofIDs, 1)
+}
+
+func TestReconcilerReconcileRules(t *testing.T) {
+	ifaceStore := interfacestore.NewInterfaceStore()
+	ifaceStore.AddInterface(&interfacestore.InterfaceConfig{
+		InterfaceName:            util.GenerateContainerInterfaceName("pod1", "ns1", "container1"),
+		IPs:                      []net.IP{net.ParseIP("2.2.2.2")},
+		ContainerInterfaceConfig: &interfacestore.ContainerInterfaceConfig{PodName: "pod1", PodNamespace: "ns1", ContainerID: "container1"},
+		OVSPortConfig:            &interfacestore.OVSPortConfig{OFPort: 1},
+	})
+	allowAction := crdv1alpha1.RuleActionAllow
+	dropAction := crdv1alpha1.RuleActionDrop
+	// The allow rule takes precedence over the drop rule.
+	allowRule := &rule{ID: "allow-rule", Direction: v1beta2.DirectionIn, Priority: 0, PolicyPriority: &policyPriority, TierPriority: &tierPriority, Action: &allowAction, SourceRef: &cnp1}
+	dropRule := &rule{ID: "drop-rule", Direction: v1beta2.DirectionIn, Priority: 1, PolicyPriority: &policyPriority, TierPriority: &tierPriority, Action: &dropAction, SourceRef: &cnp1}
+	memberX := newAddressGroupMember("1.1.1.1")
+	memberY := newAddressGroupMember("1.1.1.2")
+	memberZ := newAddressGroupMember("1.1.1.3")
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+	mockOFClient := openflowtest.NewMockPolicyClient(controller)
+	mockOFClient.EXPECT().IsIPv4Enabled().Return(true).AnyTimes()
+	mockOFClient.EXPECT().IsIPv6Enabled().Return(false).AnyTimes()
+	mockOFClient.EXPECT().ReassignFlowPriorities(gomock.Any(), gomock.Any()).AnyTimes()
+	r := newReconciler(mockOFClient, ifaceStore, testAsyncDeleteInterval)
+
+	// The source addresses realized by each Openflow rule, used to verify every intermediate state.
+	actions := map[uint32]crdv1alpha1.RuleAction{}
+	srcIPs := map[uint32]sets.String{}
+	var calls []string
+	checkState := func() {
+		matchedBy := map[string][]crdv1alpha1.RuleAction{}
+		for ofID, ips := range srcIPs {
+			for ip := range ips {
+				matchedBy[ip] = append(matchedBy[ip], actions[ofID])
+			}
+		}
+		// 1.1.1.1 must never be matched by the allow rule and the drop rule at the same time, as
+		// the allow rule would win. 1.1.1.2 and 1.1.1.3 must keep being matched by their rules.
+		assert.LessOrEqual(t, len(matchedBy["1.1.1.1"]), 1, "1.1.1.1 is matched by both rules")
+		assert.Equal(t, []crdv1alpha1.RuleAction{allowAction}, matchedBy["1.1.1.2"])
+		assert.Equal(t, []crdv1alpha1.RuleAction{dropAction}, matchedBy["1.1.1.3"])
+	}
+	mockOFClient.EXPECT().InstallPolicyRuleFlows(gomock.Any()).DoAndReturn(func(ofRule *types.PolicyRule) error {
+		actions[ofRule.FlowID] = *ofRule.Action
+		srcIPs[ofRule.FlowID] = sets.NewString()
+		for _, addr := range ofRule.From {
+			srcIPs[ofRule.FlowID].Insert(addr.GetMatchValue())
+		}
+		return nil
+	}).Times(2)
+	mockOFClient.EXPECT().AddPolicyRuleAddress(gomock.Any(), types.SrcAddress, gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ofID uint32, addrType types.AddressType, addresses []types.Address, priority *uint16) error {
+			for _, addr := range addresses {
+				srcIPs[ofID].Insert(addr.GetMatchValue())
+				calls = append(calls, fmt.Sprintf("add %s to %s rule", addr.GetMatchValue(), actions[ofID]))
+			}
+			checkState()
+			return nil
+		}).AnyTimes()
+	mockOFClient.EXPECT().DeletePolicyRuleAddress(gomock.Any(), types.SrcAddress, gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ofID uint32, addrType types.AddressType, addresses []types.Address, priority *uint16) error {
+			for _, addr := range addresses {
+				srcIPs[ofID].Delete(addr.GetMatchValue())
+				calls = append(calls, fmt.Sprintf("delete %s from %s rule", addr.GetMatchValue(), actions[ofID]))
+			}
+			checkState()
+			return nil
+		}).AnyTimes()
+
+	require.NoError(t, r.ReconcileRules([]*CompletedRule{
+		{rule: allowRule, FromAddresses: v1beta2.NewGroupMemberSet(memberX, memberY), TargetMembers: appliedToGroup1},
+		{rule: dropRule, FromAddresses: v1beta2.NewGroupMemberSet(memberZ), TargetMembers: appliedToGroup1},
+	}))
+	checkState()
+
+	// 1.1.1.1 moves from the AddressGroup of the allow rule to the one of the drop rule. The rules
+	// are provided in the reverse order of their precedence.
+	require.NoError(t, r.ReconcileRules([]*CompletedRule{
+		{rule: dropRule, FromAddresses: v1beta2.NewGroupMemberSet(memberX, memberZ), TargetMembers: appliedToGroup1},
+		{rule: allowRule, FromAddresses: v1beta2.NewGroupMemberSet(memberY), TargetMembers: appliedToGroup1},
+	}))
+	assert.Equal(t, []string{"delete 1.1.1.1 from Allow rule", "add 1.1.1.1 to Drop rule"}, calls)
+
+	// Reconciling the same rules again is idempotent.
+	calls = nil
+	require.NoError(t, r.ReconcileRules([]*CompletedRule{
+		{rule: allowRule, FromAddresses: v1beta2.NewGroupMemberSet(memberY), TargetMembers: appliedToGroup1},
+		{rule: dropRule, FromAddresses: v1beta2.NewGroupMemberSet(memberX, memberZ), TargetMembers: appliedToGroup1},
+	}))
+	assert.Empty(t, calls)
+}
+
+func TestRulePrecedes(t *testing.T) {
+	policyPriority2 := float64(2)
+	tierPriority2 := int32(2)
+	applicationRule := &CompletedRule{rule: &rule{Priority: 0, PolicyPriority: &policyPriority, TierPriority: &tierPriority, SourceRef: &cnp1}}
+	lowerRule := &CompletedRule{rule: &rule{Priority: 1, PolicyPriority: &policyPriority, TierPriority: &tierPriority, SourceRef: &cnp1}}
+	lowerPolicyRule := &CompletedRule{rule: &rule{Priority: 0, PolicyPriority: &policyPriority2, TierPriority: &tierPriority, SourceRef: &cnp1}}
+	lowerTierRule := &CompletedRule{rule: &rule{Priority: 0, PolicyPriority: &policyPriority, TierPriority: &tierPriority2, SourceRef: &cnp1}}
+	k8sRule := &CompletedRule{rule: &rule{Priority: -1, SourceRef: &np1}}
+	baselineRule := &CompletedRule{rule: &rule{Priority: 0, PolicyPriority: &policyPriority, TierPriority: &baselineTierPriority, SourceRef: &cnp1}}
+
+	orderedRules := []*CompletedRule{applicationRule, lowerRule, lowerPolicyRule, lowerTierRule, k8sRule, baselineRule}
+	for i, a := range orderedRules {
+		for j, b := range orderedRules {
+			assert.Equal(t, i < j, rulePrecedes(a, b), "rulePrecedes(%d, %d)", i, j)
+		}
+	}
+}
+
 func TestReconcilerUpdate(t *testing.T) {
 	ifaceStore := interfacestore.NewInterfaceStore()
 	ifaceStore.AddInterface(
@@ -936,7 +1157,7 @@ func TestReconcilerUpdate(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			controller := gomock.NewController(t)
 			defer controller.Finish()
-			mockOFClient := openflowtest.NewMockClient(controller)
+			mockOFClient := openflowtest.NewMockPolicyClient(controller)
 			mockOFClient.EXPECT().InstallPolicyRuleFlows(gomock.Any()).MaxTimes(2)
 			mockOFClient.EXPECT().IsIPv4Enabled().Return(true).AnyTimes()
 			mockOFClient.EXPECT().IsIPv6Enabled().Return(true).AnyTimes()
@@ -1458,7 +1679,7 @@ func TestReconcilerReconcileIPv6Only(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			controller := gomock.NewController(t)
 			defer controller.Finish()
-			mockOFClient := openflowtest.NewMockClient(controller)
+			mockOFClient := openflowtest.NewMockPolicyClient(controller)
 			mockOFClient.EXPECT().IsIPv4Enabled().Return(false).AnyTimes()
 			mockOFClient.EXPECT().IsIPv6Enabled().Return(true).AnyTimes()
 			// TODO: mock idAllocator and priorityAssigner
@@ -1861,7 +2082,7 @@ func TestReconcilerReconcileDualStack(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			controller := gomock.NewController(t)
 			defer controller.Finish()
-			mockOFClient := openflowtest.NewMockClient(controller)
+			mockOFClient := openflowtest.NewMockPolicyClient(controller)
 			mockOFClient.EXPECT().IsIPv4Enabled().Return(true).AnyTimes()
 			mockOFClient.EXPECT().IsIPv6Enabled().Return(true).AnyTimes()
 			// TODO: mock idAllocator and priorityAssigner
@@ -1934,6 +2155,27 @@ type policyRuleMatcher struct {
 	ofPolicyRule *types.PolicyRule
 }
 
+// assertPolicyRulesInstalled asserts that the PolicyRules installed in the FakeClient match the
+// expected ones. The TableIDs are not compared as they are not set in the expected PolicyRules.
+func assertPolicyRulesInstalled(t *testing.T, ofClient *openflowtest.FakeClient, expectedOFRules []*types.PolicyRule) {
+	installedRules := ofClient.PolicyRules()
+	require.Len(t, installedRules, len(expectedOFRules))
+	matched := make([]bool, len(installedRules))
+	for _, expected := range expectedOFRules {
+		found := false
+		for i, installed := range installedRules {
+			expectedCopy := *expected
+			expectedCopy.TableID = installed.TableID
+			if !matched[i] && newPolicyRulesMatcher(&expectedCopy).Matches(installed) {
+				matched[i] = true
+				found = true
+				break
+			}
+		}
+		assert.Truef(t, found, "Expected PolicyRule %v was not installed", expected)
+	}
+}
+
 func newPolicyRulesMatcher(ofRule *types.PolicyRule) gomock.Matcher {
 	return policyRuleMatcher{ofPolicyRule: ofRule}
 }
@@ -0,0 +1,183 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package networkpolicy
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+
+	v1beta "antrea.io/antrea/pkg/apis/controlplane/v1beta2"
+	"antrea.io/antrea/pkg/querier"
+)
+
+const (
+	// resyncTimeout bounds the time a resync waits for the watches to relist the objects and for
+	// the queued rules to be reconciled.
+	resyncTimeout = 2 * time.Minute
+	// resyncPollInterval is the interval at which the rule queues are checked during a resync.
+	resyncPollInterval = 100 * time.Millisecond
+)
+
+// resyncRequest is a resync shared by the concurrent callers of ResyncNetworkPolicies.
+type resyncRequest struct {
+	done    chan struct{}
+	summary *querier.NetworkPolicyResyncSummary
+	err     error
+}
+
+// cacheSnapshot is a copy of the NetworkPolicies and group members in the ruleCache, used to
+// compute the corrections made by a resync.
+type cacheSnapshot struct {
+	policyGenerations map[string]int64
+	addressGroups     map[string]v1beta.GroupMemberSet
+	appliedToGroups   map[string]v1beta.GroupMemberSet
+}
+
+var _ querier.AgentNetworkPolicyResyncer = new(Controller)
+
+// ResyncNetworkPolicies restarts the watches of NetworkPolicies, AddressGroups and AppliedToGroups
+// so that they relist all the objects from the Antrea Controller, then waits for the rules marked
+// dirty by the relist to be reconciled by the workers, which reconcile them in batches without
+// removing the existing flows first. It returns the corrections made to the cache. A call which
+// arrives while a resync is in progress waits for the result of the latter instead of starting
+// another one.
+func (c *Controller) ResyncNetworkPolicies(ctx context.Context) (*querier.NetworkPolicyResyncSummary, error) {
+	c.resyncLock.Lock()
+	req := c.resyncInProgress
+	if req == nil {
+		req = &resyncRequest{done: make(chan struct{})}
+		c.resyncInProgress = req
+		go c.resync(req)
+	}
+	c.resyncLock.Unlock()
+
+	select {
+	case <-req.done:
+		return req.summary, req.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (c *Controller) resync(req *resyncRequest) {
+	defer func() {
+		c.resyncLock.Lock()
+		c.resyncInProgress = nil
+		c.resyncLock.Unlock()
+		close(req.done)
+	}()
+	startTime := time.Now()
+	klog.Info("Resyncing NetworkPolicies with the Antrea Controller")
+	before := c.ruleCache.snapshot()
+	watchers := []*watcher{c.networkPolicyWatcher, c.appliedToGroupWatcher, c.addressGroupWatcher}
+	relisted := make([]<-chan struct{}, len(watchers))
+	for i, w := range watchers {
+		relisted[i] = w.resync()
+	}
+	timeout := time.After(resyncTimeout)
+	for i, w := range watchers {
+		select {
+		case <-relisted[i]:
+		case <-timeout:
+			req.err = fmt.Errorf("timeout waiting for the %s watch to relist", w.objectType)
+			return
+		}
+	}
+	if err := wait.PollImmediate(resyncPollInterval, resyncTimeout-time.Since(startTime), c.queuesEmpty); err != nil {
+		req.err = fmt.Errorf("timeout waiting for the rules to be reconciled")
+		return
+	}
+	req.summary = diffSnapshots(before, c.ruleCache.snapshot())
+	klog.Infof("Resynced NetworkPolicies with the Antrea Controller in %v: %+v", time.Since(startTime), *req.summary)
+}
+
+// queuesEmpty returns whether all the rule queues are empty.
+func (c *Controller) queuesEmpty() (bool, error) {
+	for _, queue := range c.queues {
+		if queue.Len() > 0 {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// snapshot copies the NetworkPolicy generations and the group members of the cache.
+func (c *ruleCache) snapshot() *cacheSnapshot {
+	s := &cacheSnapshot{
+		policyGenerations: map[string]int64{},
+		addressGroups:     map[string]v1beta.GroupMemberSet{},
+		appliedToGroups:   map[string]v1beta.GroupMemberSet{},
+	}
+	for _, shard := range c.policyShards {
+		shard.lock.RLock()
+		for uid, policy := range shard.policyMap {
+			s.policyGenerations[uid] = policy.Generation
+		}
+		shard.lock.RUnlock()
+	}
+	copyGroups := func(groups map[string]v1beta.GroupMemberSet) func(string, v1beta.GroupMemberSet) {
+		return func(groupName string, memberSet v1beta.GroupMemberSet) {
+			groups[groupName] = memberSet.Union(nil)
+		}
+	}
+	c.addressSetByGroup.forEach(copyGroups(s.addressGroups))
+	c.appliedToSetByGroup.forEach(copyGroups(s.appliedToGroups))
+	return s
+}
+
+// diffSnapshots computes the changes between two snapshots of the cache.
+func diffSnapshots(before, after *cacheSnapshot) *querier.NetworkPolicyResyncSummary {
+	summary := &querier.NetworkPolicyResyncSummary{}
+	for uid, generation := range after.policyGenerations {
+		if oldGeneration, exists := before.policyGenerations[uid]; !exists {
+			summary.PoliciesAdded++
+		} else if oldGeneration != generation {
+			summary.PoliciesUpdated++
+		}
+	}
+	for uid := range before.policyGenerations {
+		if _, exists := after.policyGenerations[uid]; !exists {
+			summary.PoliciesRemoved++
+		}
+	}
+	diffGroups := func(before, after map[string]v1beta.GroupMemberSet) (added, removed, updated int) {
+		for groupName, memberSet := range after {
+			oldMemberSet, exists := before[groupName]
+			if !exists {
+				added++
+				continue
+			}
+			membersAdded, membersRemoved := len(memberSet.Difference(oldMemberSet)), len(oldMemberSet.Difference(memberSet))
+			if membersAdded > 0 || membersRemoved > 0 {
+				updated++
+				summary.GroupMembersAdded += membersAdded
+				summary.GroupMembersRemoved += membersRemoved
+			}
+		}
+		for groupName := range before {
+			if _, exists := after[groupName]; !exists {
+				removed++
+			}
+		}
+		return
+	}
+	summary.AddressGroupsAdded, summary.AddressGroupsRemoved, summary.AddressGroupsUpdated = diffGroups(before.addressGroups, after.addressGroups)
+	summary.AppliedToGroupsAdded, summary.AppliedToGroupsRemoved, summary.AppliedToGroupsUpdated = diffGroups(before.appliedToGroups, after.appliedToGroups)
+	return summary
+}
@@ -0,0 +1,172 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package networkpolicy
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/watch"
+	k8stesting "k8s.io/client-go/testing"
+
+	"antrea.io/antrea/pkg/apis/controlplane/v1beta2"
+	"antrea.io/antrea/pkg/querier"
+)
+
+func TestResyncNetworkPolicies(t *testing.T) {
+	controller, clientset, reconciler := newTestController()
+	// A new FakeWatcher is created for every watch, so that the test can tell when a watch is
+	// restarted.
+	watchers := map[string]chan *watch.FakeWatcher{}
+	for _, resource := range []string{"addressgroups", "appliedtogroups", "networkpolicies"} {
+		ch := make(chan *watch.FakeWatcher, 2)
+		watchers[resource] = ch
+		clientset.AddWatchReactor(resource, func(_ k8stesting.Action) (bool, watch.Interface, error) {
+			w := watch.NewFakeWithChanSize(10, false)
+			ch <- w
+			return true, w, nil
+		})
+	}
+	nextWatcher := func(resource string) *watch.FakeWatcher {
+		select {
+		case w := <-watchers[resource]:
+			return w
+		case <-time.After(time.Second):
+			t.Fatalf("Expected a watch of %s, got none", resource)
+		}
+		return nil
+	}
+	relist := func(objs map[string][]runtime.Object) {
+		for resource, resourceObjs := range objs {
+			w := nextWatcher(resource)
+			for _, obj := range resourceObjs {
+				w.Add(obj)
+			}
+			w.Action(watch.Bookmark, nil)
+		}
+	}
+	expectUpdate := func() {
+		select {
+		case <-reconciler.updated:
+		case <-time.After(time.Second):
+			t.Fatal("Expected one update, got none")
+		}
+	}
+
+	protocolTCP := v1beta2.ProtocolTCP
+	port := intstr.FromInt(80)
+	services := []v1beta2.Service{{Protocol: &protocolTCP, Port: &port}}
+	policy1 := newNetworkPolicy("policy1", "uid1", []string{"addressGroup1"}, []string{}, []string{"appliedToGroup1"}, services)
+	appliedToGroup1 := newAppliedToGroup("appliedToGroup1", []v1beta2.GroupMember{*newAppliedToGroupMember("pod1", "ns1")})
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go controller.Run(stopCh)
+
+	relist(map[string][]runtime.Object{
+		"networkpolicies": {policy1},
+		"addressgroups":   {newAddressGroup("addressGroup1", []v1beta2.GroupMember{*newAddressGroupMember("1.1.1.1")})},
+		"appliedtogroups": {appliedToGroup1},
+	})
+	expectUpdate()
+
+	// Concurrent resyncs share the same relist.
+	type result struct {
+		summary *querier.NetworkPolicyResyncSummary
+		err     error
+	}
+	results := make(chan result, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			summary, err := controller.ResyncNetworkPolicies(context.Background())
+			results <- result{summary, err}
+		}()
+	}
+	time.Sleep(100 * time.Millisecond)
+	// The address missed by the agent is relisted and reconciled.
+	relist(map[string][]runtime.Object{
+		"networkpolicies": {policy1},
+		"addressgroups":   {newAddressGroup("addressGroup1", []v1beta2.GroupMember{*newAddressGroupMember("1.1.1.1"), *newAddressGroupMember("2.2.2.2")})},
+		"appliedtogroups": {appliedToGroup1},
+	})
+	expectUpdate()
+	expectedSummary := &querier.NetworkPolicyResyncSummary{AddressGroupsUpdated: 1, GroupMembersAdded: 1}
+	for i := 0; i < 2; i++ {
+		select {
+		case r := <-results:
+			require.NoError(t, r.err)
+			assert.Equal(t, expectedSummary, r.summary)
+		case <-time.After(time.Second):
+			t.Fatal("Expected the resync to complete")
+		}
+	}
+	for resource, ch := range watchers {
+		assert.Empty(t, ch, "Expected a single restart of the watch of %s", resource)
+	}
+	rule, _ := reconciler.getLastRealized(controller.ruleCache.getEffectiveRulesByNetworkPolicy("uid1")[0].ID)
+	assert.Equal(t, 2, len(rule.FromAddresses))
+}
+
+func TestResyncNetworkPoliciesCanceled(t *testing.T) {
+	controller, _, _ := newTestController()
+	// The watches are not running, so the resync cannot complete.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := controller.ResyncNetworkPolicies(ctx)
+	assert.Equal(t, context.Canceled, err)
+}
+
+func TestDiffSnapshots(t *testing.T) {
+	pod1 := newAppliedToGroupMember("pod1", "ns1")
+	pod2 := newAppliedToGroupMember("pod2", "ns1")
+	address1 := newAddressGroupMember("1.1.1.1")
+	address2 := newAddressGroupMember("2.2.2.2")
+	address3 := newAddressGroupMember("3.3.3.3")
+	before := &cacheSnapshot{
+		policyGenerations: map[string]int64{"uid1": 1, "uid2": 1, "uid3": 1},
+		addressGroups: map[string]v1beta2.GroupMemberSet{
+			"ag1": v1beta2.NewGroupMemberSet(address1, address2),
+			"ag2": v1beta2.NewGroupMemberSet(address1),
+		},
+		appliedToGroups: map[string]v1beta2.GroupMemberSet{
+			"atg1": v1beta2.NewGroupMemberSet(pod1),
+		},
+	}
+	after := &cacheSnapshot{
+		policyGenerations: map[string]int64{"uid1": 1, "uid2": 2, "uid4": 1},
+		addressGroups: map[string]v1beta2.GroupMemberSet{
+			"ag1": v1beta2.NewGroupMemberSet(address1, address3),
+			"ag2": v1beta2.NewGroupMemberSet(address1),
+			"ag3": v1beta2.NewGroupMemberSet(address2),
+		},
+		appliedToGroups: map[string]v1beta2.GroupMemberSet{
+			"atg1": v1beta2.NewGroupMemberSet(pod1, pod2),
+		},
+	}
+	assert.Equal(t, &querier.NetworkPolicyResyncSummary{
+		PoliciesAdded:          1,
+		PoliciesRemoved:        1,
+		PoliciesUpdated:        1,
+		AddressGroupsAdded:     1,
+		AddressGroupsUpdated:   1,
+		AppliedToGroupsUpdated: 1,
+		GroupMembersAdded:      2,
+		GroupMembersRemoved:    1,
+	}, diffSnapshots(before, after))
+}
@@ -17,6 +17,7 @@ package networkpolicy
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -58,10 +59,24 @@ type StatusController struct {
 	statusControlInterface networkPolicyStatusControlInterface
 	// ruleCache provides the desired state of NetworkPolicy rules.
 	ruleCache *ruleCache
+	// realizationErrors provides the rules which failed to be realized.
+	realizationErrors *realizationErrorRegistry
 	// realizedRules keeps track of the realized NetworkPolicy rules.
 	realizedRules cache.Indexer
 	// queue maintains the UIDs of the NetworkPolicy that need to be processed.
 	queue workqueue.RateLimitingInterface
+	// realizedGenerations keeps the last realized generation of each NetworkPolicy and the time at
+	// which it was realized, so that the same realization time is reported if the status is synced
+	// again.
+	realizedGenerations     map[types.UID]realizedGeneration
+	realizedGenerationsLock sync.Mutex
+}
+
+// realizedGeneration is the struct kept by StatusController for storing the time at which a
+// NetworkPolicy generation was realized.
+type realizedGeneration struct {
+	generation      int64
+	realizationTime metav1.Time
 }
 
 // realizedRule is the struct kept by StatusController for storing a realized rule.
@@ -82,15 +97,17 @@ func realizedRulePolicyIndexFunc(obj interface{}) ([]string, error) {
 	return []string{string(rule.policyID)}, nil
 }
 
-func newStatusController(antreaClientProvider agent.AntreaClientProvider, nodeName string, ruleCache *ruleCache) *StatusController {
+func newStatusController(antreaClientProvider agent.AntreaClientProvider, nodeName string, ruleCache *ruleCache, realizationErrors *realizationErrorRegistry) *StatusController {
 	return &StatusController{
 		statusControlInterface: &networkPolicyStatusControl{antreaClientProvider: antreaClientProvider},
 		nodeName:               nodeName,
 		ruleCache:              ruleCache,
+		realizationErrors:      realizationErrors,
 		realizedRules: cache.NewIndexer(realizedRuleKeyFunc, cache.Indexers{
 			realizedRulePolicyIndex: realizedRulePolicyIndexFunc,
 		}),
-		queue: workqueue.NewNamedRateLimitingQueue(workqueue.NewItemExponentialFailureRateLimiter(minRetryDelay, maxRetryDelay), "networkpolicystatus"),
+		queue:               workqueue.NewNamedRateLimitingQueue(workqueue.NewItemExponentialFailureRateLimiter(minRetryDelay, maxRetryDelay), "networkpolicystatus"),
+		realizedGenerations: map[types.UID]realizedGeneration{},
 	}
 }
 
@@ -158,17 +175,56 @@ func (c *StatusController) processNextWorkItem() bool {
 	return true
 }
 
+// getRealizationTime returns the time at which the provided generation of the NetworkPolicy was
+// realized. If it's the first time the generation is found realized, the current time is recorded.
+func (c *StatusController) getRealizationTime(uid types.UID, generation int64) metav1.Time {
+	c.realizedGenerationsLock.Lock()
+	defer c.realizedGenerationsLock.Unlock()
+	if realized, exists := c.realizedGenerations[uid]; exists && realized.generation == generation {
+		return realized.realizationTime
+	}
+	realizationTime := metav1.Now()
+	c.realizedGenerations[uid] = realizedGeneration{generation: generation, realizationTime: realizationTime}
+	return realizationTime
+}
+
+func (c *StatusController) deleteRealizationTime(uid types.UID) {
+	c.realizedGenerationsLock.Lock()
+	defer c.realizedGenerationsLock.Unlock()
+	delete(c.realizedGenerations, uid)
+}
+
 func (c *StatusController) syncHandler(uid types.UID) error {
 	policy := c.ruleCache.getNetworkPolicy(string(uid))
 	// The policy must have been deleted, no further processing.
 	if policy == nil {
+		c.deleteRealizationTime(uid)
 		return nil
 	}
 	desiredRules := c.ruleCache.getEffectiveRulesByNetworkPolicy(string(uid))
 	// The policy must have been deleted, no further processing.
 	if len(desiredRules) == 0 {
+		c.deleteRealizationTime(uid)
 		return nil
 	}
+	// Report the rules which failed to be realized, so that the policy doesn't silently remain
+	// partially realized while they are retried.
+	if realizationError := c.realizationErrors.policyErrorMessage(uid); realizationError != "" {
+		klog.V(2).Infof("Syncing NetworkPolicyStatus for %s, generation: %v, realization error: %s", uid, policy.Generation, realizationError)
+		status := &v1beta2.NetworkPolicyStatus{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: policy.Name,
+			},
+			Nodes: []v1beta2.NetworkPolicyNodeStatus{
+				{
+					NodeName:         c.nodeName,
+					Generation:       policy.Generation,
+					RealizationError: realizationError,
+				},
+			},
+		}
+		return c.statusControlInterface.UpdateNetworkPolicyStatus(status.Name, status)
+	}
 	actualRules, _ := c.realizedRules.ByIndex(realizedRulePolicyIndex, string(uid))
 	// desiredRules should match actualRules exactly.
 	if len(desiredRules) != len(actualRules) {
@@ -197,8 +253,9 @@ func (c *StatusController) syncHandler(uid types.UID) error {
 		},
 		Nodes: []v1beta2.NetworkPolicyNodeStatus{
 			{
-				NodeName:   c.nodeName,
-				Generation: policy.Generation,
+				NodeName:        c.nodeName,
+				Generation:      policy.Generation,
+				RealizationTime: c.getRealizationTime(uid, policy.Generation),
 			},
 		},
 	}
@@ -21,6 +21,7 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/wait"
 
@@ -51,9 +52,9 @@ func (c *fakeNetworkPolicyControl) getNetworkPolicyStatus() *v1beta2.NetworkPoli
 }
 
 func newTestStatusController() (*StatusController, *ruleCache, *fakeNetworkPolicyControl) {
-	ruleCache := newRuleCache(func(s string) {}, make(<-chan types.EntityReference))
+	ruleCache := newRuleCache(func(string, string) {}, make(<-chan types.EntityReference))
 	statusControl := &fakeNetworkPolicyControl{}
-	statusController := newStatusController(nil, testNode1, ruleCache)
+	statusController := newStatusController(nil, testNode1, ruleCache, newRealizationErrorRegistry())
 	statusController.statusControlInterface = statusControl
 	return statusController, ruleCache, statusControl
 }
@@ -117,11 +118,80 @@ func TestSyncStatusForNewPolicy(t *testing.T) {
 			}
 			// TODO: Use a determinate mechanism.
 			time.Sleep(500 * time.Millisecond)
-			assert.Equal(t, tt.expectedStatus, statusControl.getNetworkPolicyStatus())
+			status := statusControl.getNetworkPolicyStatus()
+			if status != nil {
+				// The realization time is not deterministic, only check it's set.
+				status = status.DeepCopy()
+				for i := range status.Nodes {
+					assert.False(t, status.Nodes[i].RealizationTime.IsZero(), "The realization time should be set")
+					status.Nodes[i].RealizationTime = v1.Time{}
+				}
+			}
+			assert.Equal(t, tt.expectedStatus, status)
 		})
 	}
 }
 
+func TestRealizationTimeForResyncedPolicy(t *testing.T) {
+	statusController, ruleCache, statusControl := newTestStatusController()
+
+	ruleCache.AddAppliedToGroup(newAppliedToGroup("appliedToGroup1", []v1beta2.GroupMember{*newAppliedToGroupMember("pod1", "ns1")}))
+	policy := newNetworkPolicy("policy1", "uid1", []string{"addressGroup1"}, []string{}, []string{"appliedToGroup1"}, nil)
+	policy.Generation = 1
+	ruleCache.AddNetworkPolicy(policy)
+	rule := ruleCache.getEffectiveRulesByNetworkPolicy(string(policy.UID))[0]
+	statusController.SetRuleRealization(rule.ID, policy.UID)
+
+	require.NoError(t, statusController.syncHandler(policy.UID))
+	realizationTime := statusControl.getNetworkPolicyStatus().Nodes[0].RealizationTime
+	assert.False(t, realizationTime.IsZero())
+
+	// Syncing the same generation again must report the same realization time.
+	require.NoError(t, statusController.syncHandler(policy.UID))
+	assert.Equal(t, realizationTime, statusControl.getNetworkPolicyStatus().Nodes[0].RealizationTime)
+
+	// A new generation is realized at a new time.
+	policy.Generation = 2
+	ruleCache.UpdateNetworkPolicy(policy)
+	statusController.realizedGenerations[policy.UID] = realizedGeneration{generation: 1, realizationTime: v1.NewTime(realizationTime.Add(-time.Minute))}
+	require.NoError(t, statusController.syncHandler(policy.UID))
+	status := statusControl.getNetworkPolicyStatus()
+	assert.Equal(t, int64(2), status.Nodes[0].Generation)
+	assert.False(t, status.Nodes[0].RealizationTime.Before(&realizationTime))
+
+	// The realization time is forgotten once the policy is deleted.
+	ruleCache.DeleteNetworkPolicy(policy)
+	require.NoError(t, statusController.syncHandler(policy.UID))
+	assert.NotContains(t, statusController.realizedGenerations, policy.UID)
+}
+
+func TestSyncStatusWithRealizationError(t *testing.T) {
+	statusController, ruleCache, statusControl := newTestStatusController()
+
+	ruleCache.AddAppliedToGroup(newAppliedToGroup("appliedToGroup1", []v1beta2.GroupMember{*newAppliedToGroupMember("pod1", "ns1")}))
+	policy := newNetworkPolicyWithMultipleRules("policy1", "uid1", []string{"addressGroup1"}, []string{"addressGroup2"}, []string{"appliedToGroup1"}, nil)
+	policy.Generation = 1
+	ruleCache.AddNetworkPolicy(policy)
+	rules := ruleCache.getEffectiveRulesByNetworkPolicy(string(policy.UID))
+	require.Len(t, rules, 2)
+	statusController.SetRuleRealization(rules[0].ID, policy.UID)
+	failedRule := &CompletedRule{rule: rules[1]}
+	statusController.realizationErrors.recordFailure(failedRule, newRealizationError(realizationErrorFlowInstall, fmt.Errorf("broken pipe")))
+
+	// The failure is reported while the rule is retried.
+	require.NoError(t, statusController.syncHandler(policy.UID))
+	expectedError := fmt.Sprintf("failed to realize 1 rule(s): rule %s (FlowInstall): broken pipe", failedRule.Name)
+	assert.Equal(t, []v1beta2.NetworkPolicyNodeStatus{{NodeName: testNode1, Generation: 1, RealizationError: expectedError}}, statusControl.getNetworkPolicyStatus().Nodes)
+
+	// The policy is realized once the rule is realized.
+	statusController.realizationErrors.clear(failedRule.ID)
+	statusController.SetRuleRealization(failedRule.ID, policy.UID)
+	require.NoError(t, statusController.syncHandler(policy.UID))
+	nodeStatus := statusControl.getNetworkPolicyStatus().Nodes[0]
+	assert.Empty(t, nodeStatus.RealizationError)
+	assert.False(t, nodeStatus.RealizationTime.IsZero())
+}
+
 func TestSyncStatusUpForUpdatedPolicy(t *testing.T) {
 	statusController, ruleCache, statusControl := newTestStatusController()
 	stopCh := make(chan struct{})
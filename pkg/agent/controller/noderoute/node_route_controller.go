@@ -15,13 +15,16 @@
 package noderoute
 
 import (
+	"errors"
 	"fmt"
 	"net"
+	"sync"
 	"time"
 
 	"github.com/containernetworking/plugins/pkg/ip"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/informers"
 	coreinformers "k8s.io/client-go/informers/core/v1"
@@ -33,6 +36,7 @@ import (
 
 	"antrea.io/antrea/pkg/agent/config"
 	"antrea.io/antrea/pkg/agent/interfacestore"
+	"antrea.io/antrea/pkg/agent/metrics"
 	"antrea.io/antrea/pkg/agent/openflow"
 	"antrea.io/antrea/pkg/agent/route"
 	"antrea.io/antrea/pkg/agent/types"
@@ -52,7 +56,8 @@ const (
 	// Default number of workers processing a node change
 	defaultWorkers = 4
 
-	ovsExternalIDNodeName = "node-name"
+	ovsExternalIDNodeName      = "node-name"
+	ovsExternalIDTunnelProfile = "tunnel-profile"
 
 	nodeRouteInfoPodCIDRIndexName = "podCIDR"
 )
@@ -61,7 +66,7 @@ const (
 type Controller struct {
 	kubeClient       clientset.Interface
 	ovsBridgeClient  ovsconfig.OVSBridgeClient
-	ofClient         openflow.Client
+	ofClient         openflow.PodConnectivityClient
 	routeClient      route.Interface
 	interfaceStore   interfacestore.InterfaceStore
 	networkConfig    *config.NetworkConfig
@@ -74,6 +79,23 @@ type Controller struct {
 	// The key is the host name of the Node, the value is the nodeRouteInfo of the Node.
 	// A node will be in the map after its flows and routes are installed successfully.
 	installedNodes cache.Indexer
+	// pendingNodes are the names of the Nodes whose routes and flows cannot be installed yet,
+	// because their PodCIDRs or transport address are not set, protected by pendingNodesMutex.
+	// They are requeued with backoff until the information is available.
+	pendingNodes      sets.String
+	pendingNodesMutex sync.Mutex
+}
+
+// nodePendingError is returned by addNodeRoute when the routes and flows of a Node cannot be
+// installed yet, because some information is missing from the Node. Some cloud providers assign
+// the PodCIDRs of the Nodes asynchronously, after the Nodes have been created.
+type nodePendingError struct {
+	nodeName string
+	reason   string
+}
+
+func (e *nodePendingError) Error() string {
+	return fmt.Sprintf("routes and flows to Node %s are pending: %s", e.nodeName, e.reason)
 }
 
 // NewNodeRouteController instantiates a new Controller object which will process Node events
@@ -81,7 +103,7 @@ type Controller struct {
 func NewNodeRouteController(
 	kubeClient clientset.Interface,
 	informerFactory informers.SharedInformerFactory,
-	client openflow.Client,
+	client openflow.PodConnectivityClient,
 	ovsBridgeClient ovsconfig.OVSBridgeClient,
 	routeClient route.Interface,
 	interfaceStore interfacestore.InterfaceStore,
@@ -101,6 +123,7 @@ func NewNodeRouteController(
 		nodeListerSynced: nodeInformer.Informer().HasSynced,
 		queue:            workqueue.NewNamedRateLimitingQueue(workqueue.NewItemExponentialFailureRateLimiter(minRetryDelay, maxRetryDelay), "noderoute"),
 		installedNodes:   cache.NewIndexer(nodeRouteInfoKeyFunc, cache.Indexers{nodeRouteInfoPodCIDRIndexName: nodeRouteInfoPodCIDRIndexFunc}),
+		pendingNodes:     sets.NewString(),
 	}
 	nodeInformer.Informer().AddEventHandlerWithResyncPeriod(
 		cache.ResourceEventHandlerFuncs{
@@ -138,6 +161,10 @@ type nodeRouteInfo struct {
 	nodeIP    net.IP
 	gatewayIP []net.IP
 	nodeMAC   net.HardwareAddr
+	// tunnelProfile is the name of the tunnel profile selecting the Node, if any.
+	tunnelProfile string
+	// tunnelConfig is the tunnel configuration published by the Node, if any.
+	tunnelConfig string
 }
 
 // enqueueNode adds an object to the controller work queue
@@ -207,27 +234,30 @@ func (c *Controller) removeStaleTunnelPorts() error {
 	// knownInterfaces is the list of interfaces currently in the local cache.
 	knownInterfaces := c.interfaceStore.GetInterfaceKeysByType(interfacestore.TunnelInterface)
 
-	if c.networkConfig.EnableIPSecTunnel {
-		for _, node := range nodes {
-			interfaceConfig, found := c.interfaceStore.GetNodeTunnelInterface(node.Name)
-			if !found {
-				// Tunnel port not created for this Node, nothing to do.
-				continue
-			}
+	for _, node := range nodes {
+		interfaceConfig, found := c.interfaceStore.GetNodeTunnelInterface(node.Name)
+		if !found {
+			// Tunnel port not created for this Node, nothing to do.
+			continue
+		}
 
-			peerNodeIP, err := k8s.GetNodeAddr(node)
-			if err != nil {
-				klog.Errorf("Failed to retrieve IP address of Node %s: %v", node.Name, err)
-				continue
-			}
+		peerNodeIP, err := k8s.GetNodeAddr(node)
+		if err != nil {
+			klog.Errorf("Failed to retrieve IP address of Node %s: %v", node.Name, err)
+			continue
+		}
 
-			ifaceID := util.GenerateNodeTunnelInterfaceKey(node.Name)
-			validConfiguration := interfaceConfig.PSK == c.networkConfig.IPSecPSK &&
+		ifaceID := util.GenerateNodeTunnelInterfaceKey(node.Name)
+		validConfiguration := false
+		if c.networkConfig.EnableIPSecTunnel {
+			validConfiguration = interfaceConfig.PSK == c.networkConfig.IPSecPSK &&
 				interfaceConfig.RemoteIP.Equal(peerNodeIP) &&
 				interfaceConfig.TunnelInterfaceConfig.Type == c.networkConfig.TunnelType
-			if validConfiguration {
-				desiredInterfaces[ifaceID] = true
-			}
+		} else if profile := c.networkConfig.GetTunnelProfile(node.Labels); profile != nil {
+			validConfiguration = c.isValidTunnelProfilePort(interfaceConfig, peerNodeIP, profile)
+		}
+		if validConfiguration {
+			desiredInterfaces[ifaceID] = true
 		}
 	}
 
@@ -337,7 +367,15 @@ func (c *Controller) processNextWorkItem() bool {
 	} else if err := c.syncNodeRoute(key); err == nil {
 		// If no error occurs we Forget this item so it does not get queued again until
 		// another change happens.
+		c.setNodePending(key, false)
 		c.queue.Forget(key)
+	} else if pendingErr := (*nodePendingError)(nil); errors.As(err, &pendingErr) {
+		// The Node is retried with backoff, in case no update event is received when the
+		// missing information is set, e.g. if the event is lost during a resync. It's an
+		// expected state for new Nodes, hence it's not logged as an error.
+		c.setNodePending(key, true)
+		c.queue.AddRateLimited(key)
+		klog.Infof("Waiting for Node %s, requeuing: %v", key, err)
 	} else {
 		// Put the item back on the workqueue to handle any transient errors.
 		c.queue.AddRateLimited(key)
@@ -346,6 +384,19 @@ func (c *Controller) processNextWorkItem() bool {
 	return true
 }
 
+// setNodePending records whether the routes and flows of a Node are pending, and updates the
+// PendingPeerNodeCount metric.
+func (c *Controller) setNodePending(nodeName string, pending bool) {
+	c.pendingNodesMutex.Lock()
+	defer c.pendingNodesMutex.Unlock()
+	if pending {
+		c.pendingNodes.Insert(nodeName)
+	} else {
+		c.pendingNodes.Delete(nodeName)
+	}
+	metrics.PendingPeerNodeCount.Set(float64(c.pendingNodes.Len()))
+}
+
 // syncNode manages connectivity to "peer" Node with name nodeName
 // If we have not established connectivity to the Node yet:
 //   * we install the appropriate Linux route:
@@ -392,19 +443,23 @@ func (c *Controller) deleteNodeRoute(nodeName string) error {
 	}
 	c.installedNodes.Delete(obj)
 
-	if c.networkConfig.EnableIPSecTunnel {
-		interfaceConfig, ok := c.interfaceStore.GetNodeTunnelInterface(nodeName)
-		if !ok {
-			// Tunnel port not created for this Node.
-			return nil
-		}
-		if err := c.ovsBridgeClient.DeletePort(interfaceConfig.PortUUID); err != nil {
-			klog.Errorf("Failed to delete OVS tunnel port %s for Node %s: %v",
-				interfaceConfig.InterfaceName, nodeName, err)
-			return fmt.Errorf("failed to delete OVS tunnel port for Node %s", nodeName)
-		}
-		c.interfaceStore.DeleteInterface(interfaceConfig)
+	// Delete the IPSec tunnel port or the tunnel profile port created for the Node.
+	return c.deleteNodeTunnelPort(nodeName)
+}
+
+// deleteNodeTunnelPort deletes the tunnel port dedicated to the Node if it exists.
+func (c *Controller) deleteNodeTunnelPort(nodeName string) error {
+	interfaceConfig, ok := c.interfaceStore.GetNodeTunnelInterface(nodeName)
+	if !ok {
+		// Tunnel port not created for this Node.
+		return nil
 	}
+	if err := c.ovsBridgeClient.DeletePort(interfaceConfig.PortUUID); err != nil {
+		klog.Errorf("Failed to delete OVS tunnel port %s for Node %s: %v",
+			interfaceConfig.InterfaceName, nodeName, err)
+		return fmt.Errorf("failed to delete OVS tunnel port for Node %s", nodeName)
+	}
+	c.interfaceStore.DeleteInterface(interfaceConfig)
 	return nil
 }
 
@@ -415,17 +470,33 @@ func (c *Controller) addNodeRoute(nodeName string, node *corev1.Node) error {
 		return fmt.Errorf("error when retrieving MAC of Node %s: %v", nodeName, err)
 	}
 
+	var tunnelProfileName string
+	tunnelProfile := c.networkConfig.GetTunnelProfile(node.Labels)
+	if tunnelProfile != nil {
+		tunnelProfileName = tunnelProfile.Name
+	}
+
+	peerTunnelConfig := node.Annotations[types.NodeTunnelConfigAnnotationKey]
+
 	nrInfo, installed, _ := c.installedNodes.GetByKey(nodeName)
 
-	if installed && nrInfo.(*nodeRouteInfo).nodeMAC.String() == peerNodeMAC.String() {
-		// Route is already added for this Node and Node MAC isn't changed.
+	if installed && nrInfo.(*nodeRouteInfo).nodeMAC.String() == peerNodeMAC.String() &&
+		nrInfo.(*nodeRouteInfo).tunnelProfile == tunnelProfileName &&
+		nrInfo.(*nodeRouteInfo).tunnelConfig == peerTunnelConfig {
+		// Route is already added for this Node and neither Node MAC, tunnel profile nor tunnel
+		// configuration is changed.
 		return nil
 	}
 
 	podCIDRStrs := getPodCIDRsOnNode(node)
 	if len(podCIDRStrs) == 0 {
-		// If no valid PodCIDR is configured in Node.Spec, return immediately.
-		return nil
+		// The PodCIDRs may not be allocated yet, the Node is processed again once they are.
+		return &nodePendingError{nodeName: nodeName, reason: "PodCIDR is not set"}
+	}
+	peerNodeIP, err := k8s.GetNodeAddr(node)
+	if err != nil {
+		// The addresses may not be reported yet, the Node is processed again once they are.
+		return &nodePendingError{nodeName: nodeName, reason: err.Error()}
 	}
 	klog.Infof("Adding routes and flows to Node %s, podCIDRs: %v, addresses: %v",
 		nodeName, podCIDRStrs, node.Status.Addresses)
@@ -437,9 +508,7 @@ func (c *Controller) addNodeRoute(nodeName string, node *corev1.Node) error {
 			nodeName, podCIDR, node.Status.Addresses)
 
 		if podCIDR == "" {
-			klog.Errorf("PodCIDR is empty for Node %s", nodeName)
-			// Does not help to return an error and trigger controller retries.
-			return nil
+			return &nodePendingError{nodeName: nodeName, reason: "PodCIDR is empty"}
 		}
 
 		nodesHaveSamePodCIDR, _ := c.installedNodes.IndexKeys(nodeRouteInfoPodCIDRIndexName, podCIDR)
@@ -470,18 +539,28 @@ func (c *Controller) addNodeRoute(nodeName string, node *corev1.Node) error {
 		podCIDRs = append(podCIDRs, peerPodCIDR)
 	}
 
-	peerNodeIP, err := k8s.GetNodeAddr(node)
-	if err != nil {
-		klog.Errorf("Failed to retrieve IP address of Node %s: %v", nodeName, err)
-		return nil
+	// The tunnel ports created for the tunnel profiles have their own options. Nodes which don't
+	// publish their tunnel configuration run an older version and are not checked.
+	if tunnelProfile == nil && peerTunnelConfig != "" && peerTunnelConfig != c.networkConfig.TunnelConfig() &&
+		c.networkConfig.TrafficEncapMode.NeedsEncapToPeer(peerNodeIP, c.nodeConfig.NodeIPAddr) {
+		// The flows are installed anyway, as the configuration may be in the process of being
+		// rolled out to all the Nodes.
+		klog.Warningf("Tunnel configuration %q of Node %s is not consistent with the local tunnel configuration %q, the tunnel traffic between the Nodes may be dropped",
+			peerTunnelConfig, nodeName, c.networkConfig.TunnelConfig())
 	}
 
-	ipsecTunOFPort := int32(0)
+	tunOFPort := int32(0)
 	if c.networkConfig.EnableIPSecTunnel {
 		// Create a separate tunnel port for the Node, as OVS IPSec monitor needs to
 		// read PSK and remote IP from the Node's tunnel interface to create IPSec
 		// security policies.
-		if ipsecTunOFPort, err = c.createIPSecTunnelPort(nodeName, peerNodeIP); err != nil {
+		if tunOFPort, err = c.createIPSecTunnelPort(nodeName, peerNodeIP); err != nil {
+			return err
+		}
+	} else if tunnelProfile != nil && c.networkConfig.TrafficEncapMode.NeedsEncapToPeer(peerNodeIP, c.nodeConfig.NodeIPAddr) {
+		// Create a separate tunnel port for the Node, with the options of the tunnel
+		// profile selecting it.
+		if tunOFPort, err = c.createTunnelProfilePort(nodeName, peerNodeIP, tunnelProfile); err != nil {
 			return err
 		}
 	}
@@ -490,11 +569,18 @@ func (c *Controller) addNodeRoute(nodeName string, node *corev1.Node) error {
 		nodeName,
 		peerConfig,
 		peerNodeIP,
-		uint32(ipsecTunOFPort),
+		uint32(tunOFPort),
 		peerNodeMAC)
 	if err != nil {
 		return fmt.Errorf("failed to install flows to Node %s: %v", nodeName, err)
 	}
+	if tunOFPort == 0 {
+		// The Node may have been selected by a tunnel profile before. Its tunnel port can
+		// be deleted now that the flows to the Node use the default tunnel port.
+		if err := c.deleteNodeTunnelPort(nodeName); err != nil {
+			return err
+		}
+	}
 
 	var peerGatewayIPs []net.IP
 	for peerPodCIDR, peerGatewayIP := range peerConfig {
@@ -504,11 +590,13 @@ func (c *Controller) addNodeRoute(nodeName string, node *corev1.Node) error {
 		peerGatewayIPs = append(peerGatewayIPs, peerGatewayIP)
 	}
 	c.installedNodes.Add(&nodeRouteInfo{
-		nodeName:  nodeName,
-		podCIDRs:  podCIDRs,
-		nodeIP:    peerNodeIP,
-		gatewayIP: peerGatewayIPs,
-		nodeMAC:   peerNodeMAC,
+		nodeName:      nodeName,
+		podCIDRs:      podCIDRs,
+		nodeIP:        peerNodeIP,
+		gatewayIP:     peerGatewayIPs,
+		nodeMAC:       peerNodeMAC,
+		tunnelProfile: tunnelProfileName,
+		tunnelConfig:  peerTunnelConfig,
 	})
 	return err
 }
@@ -519,8 +607,6 @@ func getPodCIDRsOnNode(node *corev1.Node) []string {
 	}
 
 	if node.Spec.PodCIDR == "" {
-		klog.Errorf("PodCIDR is empty for Node %s", node.Name)
-		// Does not help to return an error and trigger controller retries.
 		return nil
 	}
 	return []string{node.Spec.PodCIDR}
@@ -545,6 +631,8 @@ func (c *Controller) createIPSecTunnelPort(nodeName string, nodeIP net.IP) (int3
 			c.networkConfig.TunnelType,
 			0, // ofPortRequest - let OVS allocate OFPort number.
 			false,
+			0,
+			c.networkConfig.TunnelTOS,
 			"",
 			nodeIP.String(),
 			c.networkConfig.IPSecPSK,
@@ -576,6 +664,97 @@ func (c *Controller) createIPSecTunnelPort(nodeName string, nodeIP net.IP) (int3
 	return ofPort, nil
 }
 
+// createTunnelProfilePort creates a tunnel port for the remote Node with the options of the
+// tunnel profile if the tunnel does not exist, and returns the ofport number. If the tunnel
+// exists but its configuration is out of date, it is re-created.
+func (c *Controller) createTunnelProfilePort(nodeName string, nodeIP net.IP, profile *config.TunnelProfile) (int32, error) {
+	interfaceConfig, ok := c.interfaceStore.GetNodeTunnelInterface(nodeName)
+	if ok && !c.isValidTunnelProfilePort(interfaceConfig, nodeIP, profile) {
+		klog.Infof("Re-creating tunnel port %s for Node %s with tunnel profile %s", interfaceConfig.InterfaceName, nodeName, profile.Name)
+		if err := c.deleteNodeTunnelPort(nodeName); err != nil {
+			return 0, err
+		}
+		ok = false
+	}
+	if ok {
+		if interfaceConfig.OFPort != 0 {
+			return interfaceConfig.OFPort, nil
+		}
+	} else {
+		portName := util.GenerateNodeTunnelInterfaceName(nodeName)
+		ovsExternalIDs := map[string]interface{}{
+			ovsExternalIDNodeName:      nodeName,
+			ovsExternalIDTunnelProfile: profile.Name,
+		}
+		portUUID, err := c.ovsBridgeClient.CreateTunnelPortExt(
+			portName,
+			c.networkConfig.TunnelType,
+			0, // ofPortRequest - let OVS allocate OFPort number.
+			false,
+			0,
+			"",
+			"",
+			nodeIP.String(),
+			"",
+			ovsExternalIDs)
+		if err != nil {
+			return 0, fmt.Errorf("failed to create tunnel port for Node %s", nodeName)
+		}
+
+		ovsPortConfig := &interfacestore.OVSPortConfig{PortUUID: portUUID}
+		interfaceConfig = interfacestore.NewTunnelProfileInterface(
+			portName,
+			c.networkConfig.TunnelType,
+			nodeName,
+			nodeIP,
+			profile.Name,
+			profile.Options)
+		interfaceConfig.OVSPortConfig = ovsPortConfig
+		c.interfaceStore.AddInterface(interfaceConfig)
+
+		if len(profile.Options) > 0 {
+			// The options replace all the existing options of the interface.
+			options := map[string]interface{}{"remote_ip": nodeIP.String()}
+			for k, v := range profile.Options {
+				options[k] = v
+			}
+			if err := c.ovsBridgeClient.SetInterfaceOptions(portName, options); err != nil {
+				// Delete the port so that it is re-created when retrying.
+				c.deleteNodeTunnelPort(nodeName)
+				return 0, fmt.Errorf("failed to set options of tunnel port for Node %s", nodeName)
+			}
+		}
+		klog.Infof("Created tunnel port %s for Node %s with tunnel profile %s", portName, nodeName, profile.Name)
+	}
+
+	// GetOFPort will wait for up to 1 second for OVSDB to report the OFPort number.
+	ofPort, err := c.ovsBridgeClient.GetOFPort(interfaceConfig.InterfaceName)
+	if err != nil {
+		// Could be a temporary OVSDB connection failure or timeout.
+		// Let NodeRouteController retry at errors.
+		return 0, fmt.Errorf("failed to get of_port of tunnel port for Node %s", nodeName)
+	}
+	interfaceConfig.OFPort = ofPort
+	return ofPort, nil
+}
+
+// isValidTunnelProfilePort returns whether the tunnel port has been created for the Node IP with
+// the current configuration of the tunnel profile.
+func (c *Controller) isValidTunnelProfilePort(interfaceConfig *interfacestore.InterfaceConfig, nodeIP net.IP, profile *config.TunnelProfile) bool {
+	if interfaceConfig.Profile != profile.Name ||
+		!interfaceConfig.RemoteIP.Equal(nodeIP) ||
+		interfaceConfig.TunnelInterfaceConfig.Type != c.networkConfig.TunnelType ||
+		len(interfaceConfig.ProfileOptions) != len(profile.Options) {
+		return false
+	}
+	for k, v := range profile.Options {
+		if value, ok := interfaceConfig.ProfileOptions[k]; !ok || value != v {
+			return false
+		}
+	}
+	return true
+}
+
 // ParseTunnelInterfaceConfig initializes and returns an InterfaceConfig struct
 // for a tunnel interface. It reads tunnel type, remote IP, IPSec PSK from the
 // OVS interface options, and NodeName from the OVS port external_ids.
@@ -588,12 +767,13 @@ func ParseTunnelInterfaceConfig(
 		klog.V(2).Infof("OVS port %s has no options", portData.Name)
 		return nil
 	}
-	remoteIP, localIP, psk, csum := ovsconfig.ParseTunnelInterfaceOptions(portData)
+	remoteIP, localIP, psk, csum, dstPort, tos := ovsconfig.ParseTunnelInterfaceOptions(portData)
 
 	var interfaceConfig *interfacestore.InterfaceConfig
-	var nodeName string
+	var nodeName, profile string
 	if portData.ExternalIDs != nil {
 		nodeName = portData.ExternalIDs[ovsExternalIDNodeName]
+		profile = portData.ExternalIDs[ovsExternalIDTunnelProfile]
 	}
 	if psk != "" {
 		interfaceConfig = interfacestore.NewIPSecTunnelInterface(
@@ -602,8 +782,23 @@ func ParseTunnelInterfaceConfig(
 			nodeName,
 			remoteIP,
 			psk)
+	} else if profile != "" {
+		profileOptions := make(map[string]string, len(portData.Options))
+		for k, v := range portData.Options {
+			profileOptions[k] = v
+		}
+		for _, option := range config.ReservedTunnelOptions {
+			delete(profileOptions, option)
+		}
+		interfaceConfig = interfacestore.NewTunnelProfileInterface(
+			portData.Name,
+			ovsconfig.TunnelType(portData.IFType),
+			nodeName,
+			remoteIP,
+			profile,
+			profileOptions)
 	} else {
-		interfaceConfig = interfacestore.NewTunnelInterface(portData.Name, ovsconfig.TunnelType(portData.IFType), localIP, csum)
+		interfaceConfig = interfacestore.NewTunnelInterface(portData.Name, ovsconfig.TunnelType(portData.IFType), localIP, csum, dstPort, tos)
 	}
 	interfaceConfig.OVSPortConfig = portConfig
 	return interfaceConfig
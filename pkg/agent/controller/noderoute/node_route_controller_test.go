@@ -23,15 +23,21 @@ import (
 	"github.com/containernetworking/plugins/pkg/ip"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/component-base/metrics/testutil"
 
 	"antrea.io/antrea/pkg/agent/config"
 	"antrea.io/antrea/pkg/agent/interfacestore"
+	"antrea.io/antrea/pkg/agent/metrics"
 	oftest "antrea.io/antrea/pkg/agent/openflow/testing"
 	routetest "antrea.io/antrea/pkg/agent/route/testing"
+	"antrea.io/antrea/pkg/agent/util"
+	"antrea.io/antrea/pkg/ovs/ovsconfig"
 	ovsconfigtest "antrea.io/antrea/pkg/ovs/ovsconfig/testing"
 )
 
@@ -49,7 +55,7 @@ type fakeController struct {
 	*Controller
 	clientset       *fake.Clientset
 	informerFactory informers.SharedInformerFactory
-	ofClient        *oftest.MockClient
+	ofClient        *oftest.MockPodConnectivityClient
 	ovsClient       *ovsconfigtest.MockOVSBridgeClient
 	routeClient     *routetest.MockInterface
 	interfaceStore  interfacestore.InterfaceStore
@@ -59,7 +65,7 @@ func newController(t *testing.T) (*fakeController, func()) {
 	clientset := fake.NewSimpleClientset()
 	informerFactory := informers.NewSharedInformerFactory(clientset, 12*time.Hour)
 	ctrl := gomock.NewController(t)
-	ofClient := oftest.NewMockClient(ctrl)
+	ofClient := oftest.NewMockPodConnectivityClient(ctrl)
 	ovsClient := ovsconfigtest.NewMockOVSBridgeClient(ctrl)
 	routeClient := routetest.NewMockInterface(ctrl)
 	interfaceStore := interfacestore.NewInterfaceStore()
@@ -162,6 +168,127 @@ func TestControllerWithDuplicatePodCIDR(t *testing.T) {
 	}
 }
 
+func TestNodeWithoutPodCIDR(t *testing.T) {
+	metrics.InitializeNodeRouteMetrics()
+	getPendingCount := func() float64 {
+		count, err := testutil.GetGaugeMetricValue(metrics.PendingPeerNodeCount)
+		require.NoError(t, err)
+		return count
+	}
+
+	c, closeFn := newController(t)
+	defer closeFn()
+	defer c.queue.ShutDown()
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	c.informerFactory.Start(stopCh)
+	c.informerFactory.WaitForCacheSync(stopCh)
+
+	// The Node is created before its PodCIDR is allocated and its addresses are reported.
+	node1 := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "node1",
+		},
+	}
+	c.clientset.CoreV1().Nodes().Create(context.TODO(), node1, metav1.CreateOptions{})
+	c.processNextWorkItem()
+	assert.True(t, c.pendingNodes.Has("node1"))
+	assert.Equal(t, float64(1), getPendingCount())
+	assert.Equal(t, 1, c.queue.NumRequeues("node1"), "Pending Node should be requeued with backoff")
+
+	// The PodCIDR is allocated, but the addresses are still missing.
+	node1.Spec = corev1.NodeSpec{
+		PodCIDR:  podCIDR.String(),
+		PodCIDRs: []string{podCIDR.String()},
+	}
+	c.clientset.CoreV1().Nodes().Update(context.TODO(), node1, metav1.UpdateOptions{})
+	c.processNextWorkItem()
+	assert.True(t, c.pendingNodes.Has("node1"))
+	assert.Equal(t, 2, c.queue.NumRequeues("node1"))
+
+	// The addresses are reported, the routes and flows are installed.
+	node1.Status = corev1.NodeStatus{
+		Addresses: []corev1.NodeAddress{
+			{
+				Type:    corev1.NodeInternalIP,
+				Address: nodeIP1.String(),
+			},
+		},
+	}
+	c.clientset.CoreV1().Nodes().UpdateStatus(context.TODO(), node1, metav1.UpdateOptions{})
+	c.ofClient.EXPECT().InstallNodeFlows("node1", gomock.Any(), nodeIP1, uint32(0), nil).Times(1)
+	c.routeClient.EXPECT().AddRoutes(podCIDR, "node1", nodeIP1, podCIDRGateway).Times(1)
+	c.processNextWorkItem()
+	assert.False(t, c.pendingNodes.Has("node1"))
+	assert.Equal(t, float64(0), getPendingCount())
+	assert.Equal(t, 0, c.queue.NumRequeues("node1"))
+	_, installed, _ := c.installedNodes.GetByKey("node1")
+	assert.True(t, installed)
+}
+
+func TestTunnelProfilePort(t *testing.T) {
+	c, closeFn := newController(t)
+	defer closeFn()
+	defer c.queue.ShutDown()
+
+	c.networkConfig.TunnelType = ovsconfig.GeneveTunnel
+	c.networkConfig.TunnelProfiles = []config.TunnelProfile{
+		{
+			Name:         "zone-b",
+			NodeSelector: labels.SelectorFromSet(map[string]string{"zone": "b"}),
+			Options:      map[string]string{"dst_port": "6082"},
+		},
+	}
+	node1 := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "node1",
+			Labels: map[string]string{"zone": "b"},
+		},
+		Spec: corev1.NodeSpec{
+			PodCIDR:  podCIDR.String(),
+			PodCIDRs: []string{podCIDR.String()},
+		},
+		Status: corev1.NodeStatus{
+			Addresses: []corev1.NodeAddress{
+				{
+					Type:    corev1.NodeInternalIP,
+					Address: nodeIP1.String(),
+				},
+			},
+		},
+	}
+	portName := util.GenerateNodeTunnelInterfaceName("node1")
+
+	// The Node is selected by the tunnel profile, a tunnel port is created for it.
+	c.ovsClient.EXPECT().CreateTunnelPortExt(portName, ovsconfig.TunnelType(ovsconfig.GeneveTunnel), int32(0), false, int32(0), "", "", nodeIP1.String(), "",
+		map[string]interface{}{ovsExternalIDNodeName: "node1", ovsExternalIDTunnelProfile: "zone-b"}).Return("port-uuid", nil)
+	c.ovsClient.EXPECT().SetInterfaceOptions(portName, map[string]interface{}{"remote_ip": nodeIP1.String(), "dst_port": "6082"}).Return(nil)
+	c.ovsClient.EXPECT().GetOFPort(portName).Return(int32(10), nil)
+	c.ofClient.EXPECT().InstallNodeFlows("node1", gomock.Any(), nodeIP1, uint32(10), nil)
+	c.routeClient.EXPECT().AddRoutes(podCIDR, "node1", nodeIP1, podCIDRGateway)
+	require.NoError(t, c.addNodeRoute("node1", node1))
+	interfaceConfig, found := c.interfaceStore.GetNodeTunnelInterface("node1")
+	require.True(t, found)
+	assert.Equal(t, "zone-b", interfaceConfig.Profile)
+	assert.Equal(t, int32(10), interfaceConfig.OFPort)
+
+	// Nothing changes, the Node is skipped.
+	require.NoError(t, c.addNodeRoute("node1", node1))
+
+	// The Node is no longer selected, its flows fall back to the default tunnel port before its
+	// tunnel port is deleted.
+	node1.Labels = nil
+	gomock.InOrder(
+		c.ofClient.EXPECT().InstallNodeFlows("node1", gomock.Any(), nodeIP1, uint32(0), nil),
+		c.ovsClient.EXPECT().DeletePort("port-uuid").Return(nil),
+	)
+	c.routeClient.EXPECT().AddRoutes(podCIDR, "node1", nodeIP1, podCIDRGateway)
+	require.NoError(t, c.addNodeRoute("node1", node1))
+	_, found = c.interfaceStore.GetNodeTunnelInterface("node1")
+	assert.False(t, found)
+}
+
 func TestIPInPodSubnets(t *testing.T) {
 	c, closeFn := newController(t)
 	defer closeFn()
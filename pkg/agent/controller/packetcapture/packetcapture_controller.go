@@ -0,0 +1,518 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package packetcapture
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/contiv/libOpenflow/protocol"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	"antrea.io/antrea/pkg/agent/config"
+	"antrea.io/antrea/pkg/agent/interfacestore"
+	"antrea.io/antrea/pkg/agent/openflow"
+	"antrea.io/antrea/pkg/agent/util"
+	crdv1alpha1 "antrea.io/antrea/pkg/apis/crd/v1alpha1"
+	clientsetversioned "antrea.io/antrea/pkg/client/clientset/versioned"
+	crdinformers "antrea.io/antrea/pkg/client/informers/externalversions/crd/v1alpha1"
+	crdlisters "antrea.io/antrea/pkg/client/listers/crd/v1alpha1"
+	binding "antrea.io/antrea/pkg/ovs/openflow"
+)
+
+const (
+	controllerName = "AntreaAgentPacketCaptureController"
+	// Set resyncPeriod to 0 to disable resyncing.
+	resyncPeriod time.Duration = 0
+	// How long to wait before retrying the processing of a PacketCapture.
+	minRetryDelay = 5 * time.Second
+	maxRetryDelay = 300 * time.Second
+	// Default number of workers processing PacketCapture requests.
+	defaultWorkers = 4
+	// maxConcurrentCaptures is the maximum number of PacketCaptures running at the same time
+	// on a Node. PacketCaptures exceeding the limit fail.
+	maxConcurrentCaptures = 16
+	// maxFileSize is the maximum size of a pcap file in bytes. The capture is stopped before
+	// a packet would make the file exceed it.
+	maxFileSize = 64 * 1024 * 1024
+)
+
+// defaultCaptureDir is the directory storing the pcap files. It is cleaned up when the
+// controller starts, as the PacketCaptures of a previous run cannot be served anymore.
+var defaultCaptureDir = filepath.Join(os.TempDir(), "antrea", "packetcapture")
+
+type captureState struct {
+	name string
+	uid  types.UID
+	// packet holds the fields matched by the capture. Unset fields match all packets.
+	packet     *binding.Packet
+	maxPackets int32
+	numPackets int32
+	file       *os.File
+	writer     *pcapWriter
+	timer      *time.Timer
+}
+
+// Controller is responsible for running the PacketCaptures whose source Pod, or destination Pod
+// if the source is not a Pod, runs on the Node. Packets are captured by OVS flows sending a copy
+// of the matching packets to the agent, which writes them to a pcap file.
+type Controller struct {
+	kubeClient                clientset.Interface
+	crdClient                 clientsetversioned.Interface
+	packetCaptureLister       crdlisters.PacketCaptureLister
+	packetCaptureListerSynced cache.InformerSynced
+	ofClient                  openflow.TraceflowClient
+	interfaceStore            interfacestore.InterfaceStore
+	nodeConfig                *config.NodeConfig
+	captureDir                string
+	queue                     workqueue.RateLimitingInterface
+	capturesMutex             sync.Mutex
+	// runningCaptures stores the state of the PacketCaptures running on the Node, with the
+	// PacketCapture name as the key.
+	runningCaptures map[string]*captureState
+	// completedCaptures stores the state of the PacketCaptures completed on the Node, whose
+	// pcap files can be downloaded, with the PacketCapture name as the key.
+	completedCaptures map[string]*captureState
+}
+
+// NewPacketCaptureController instantiates a new Controller object which will process
+// PacketCapture events.
+func NewPacketCaptureController(
+	kubeClient clientset.Interface,
+	crdClient clientsetversioned.Interface,
+	packetCaptureInformer crdinformers.PacketCaptureInformer,
+	client openflow.TraceflowClient,
+	interfaceStore interfacestore.InterfaceStore,
+	nodeConfig *config.NodeConfig) *Controller {
+	c := &Controller{
+		kubeClient:                kubeClient,
+		crdClient:                 crdClient,
+		packetCaptureLister:       packetCaptureInformer.Lister(),
+		packetCaptureListerSynced: packetCaptureInformer.Informer().HasSynced,
+		ofClient:                  client,
+		interfaceStore:            interfaceStore,
+		nodeConfig:                nodeConfig,
+		captureDir:                defaultCaptureDir,
+		queue:                     workqueue.NewNamedRateLimitingQueue(workqueue.NewItemExponentialFailureRateLimiter(minRetryDelay, maxRetryDelay), "packetcapture"),
+		runningCaptures:           make(map[string]*captureState),
+		completedCaptures:         make(map[string]*captureState),
+	}
+
+	packetCaptureInformer.Informer().AddEventHandlerWithResyncPeriod(
+		cache.ResourceEventHandlerFuncs{
+			AddFunc:    c.addPacketCapture,
+			UpdateFunc: c.updatePacketCapture,
+			DeleteFunc: c.deletePacketCapture,
+		},
+		resyncPeriod,
+	)
+	c.ofClient.RegisterPacketInHandler(uint8(openflow.PacketInReasonPC), "packetcapture", c)
+	return c
+}
+
+func (c *Controller) enqueuePacketCapture(pc *crdv1alpha1.PacketCapture) {
+	c.queue.Add(pc.Name)
+}
+
+// Run will create defaultWorkers workers (go routines) which will process the PacketCapture
+// events from the workqueue.
+func (c *Controller) Run(stopCh <-chan struct{}) {
+	defer c.queue.ShutDown()
+
+	klog.Infof("Starting %s", controllerName)
+	defer klog.Infof("Shutting down %s", controllerName)
+
+	if err := os.RemoveAll(c.captureDir); err != nil {
+		klog.Errorf("Failed to remove pcap files in %s: %v", c.captureDir, err)
+	}
+	if err := os.MkdirAll(c.captureDir, 0700); err != nil {
+		klog.Errorf("Failed to create directory %s for pcap files: %v", c.captureDir, err)
+		return
+	}
+
+	if !cache.WaitForNamedCacheSync(controllerName, stopCh, c.packetCaptureListerSynced) {
+		return
+	}
+
+	for i := 0; i < defaultWorkers; i++ {
+		go wait.Until(c.worker, time.Second, stopCh)
+	}
+	<-stopCh
+}
+
+func (c *Controller) addPacketCapture(obj interface{}) {
+	pc := obj.(*crdv1alpha1.PacketCapture)
+	klog.Infof("Processing PacketCapture %s ADD event", pc.Name)
+	c.enqueuePacketCapture(pc)
+}
+
+func (c *Controller) updatePacketCapture(_, curObj interface{}) {
+	pc := curObj.(*crdv1alpha1.PacketCapture)
+	klog.V(2).Infof("Processing PacketCapture %s UPDATE event", pc.Name)
+	c.enqueuePacketCapture(pc)
+}
+
+func (c *Controller) deletePacketCapture(old interface{}) {
+	pc, ok := old.(*crdv1alpha1.PacketCapture)
+	if !ok {
+		tombstone, ok := old.(cache.DeletedFinalStateUnknown)
+		if !ok {
+			klog.Errorf("Error decoding object when deleting PacketCapture, invalid type: %v", old)
+			return
+		}
+		pc, ok = tombstone.Obj.(*crdv1alpha1.PacketCapture)
+		if !ok {
+			klog.Errorf("Error decoding object tombstone when deleting PacketCapture, invalid type: %v", tombstone.Obj)
+			return
+		}
+	}
+	klog.Infof("Processing PacketCapture %s DELETE event", pc.Name)
+	c.enqueuePacketCapture(pc)
+}
+
+func (c *Controller) worker() {
+	for c.processPacketCaptureItem() {
+	}
+}
+
+func (c *Controller) processPacketCaptureItem() bool {
+	obj, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	defer c.queue.Done(obj)
+
+	if key, ok := obj.(string); !ok {
+		c.queue.Forget(obj)
+		klog.Errorf("Expected string in work queue but got %#v", obj)
+		return true
+	} else if err := c.syncPacketCapture(key); err == nil {
+		c.queue.Forget(key)
+	} else {
+		c.queue.AddRateLimited(key)
+		klog.Errorf("Error syncing PacketCapture %s, requeuing. Error: %v", key, err)
+	}
+	return true
+}
+
+func (c *Controller) syncPacketCapture(name string) error {
+	startTime := time.Now()
+	defer func() {
+		klog.V(4).Infof("Finished syncing PacketCapture for %s. (%v)", name, time.Since(startTime))
+	}()
+
+	pc, err := c.packetCaptureLister.Get(name)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			c.cleanupPacketCapture(name)
+			return nil
+		}
+		return err
+	}
+
+	c.capturesMutex.Lock()
+	state, ok := c.runningCaptures[name]
+	if !ok {
+		state, ok = c.completedCaptures[name]
+	}
+	c.capturesMutex.Unlock()
+	if ok {
+		if state.uid == pc.UID {
+			return nil
+		}
+		// The PacketCapture has been deleted and re-created with the same name.
+		c.cleanupPacketCapture(name)
+	}
+
+	switch pc.Status.Phase {
+	case "":
+		return c.startPacketCapture(pc)
+	case crdv1alpha1.PacketCaptureRunning:
+		if pc.Status.NodeName == c.nodeConfig.Name {
+			// The capture was started before the agent restarted, and its packets are lost.
+			return c.updatePacketCaptureStatus(pc.Name, crdv1alpha1.PacketCaptureStatus{
+				Phase:  crdv1alpha1.PacketCaptureFailed,
+				Reason: "antrea-agent restarted during the capture",
+			})
+		}
+	}
+	return nil
+}
+
+// startPacketCapture starts the capture if the Pod the PacketCapture is run for is on the Node.
+func (c *Controller) startPacketCapture(pc *crdv1alpha1.PacketCapture) error {
+	var pod, ns string
+	if pc.Spec.Source.Pod != "" {
+		pod, ns = pc.Spec.Source.Pod, pc.Spec.Source.Namespace
+	} else if pc.Spec.Destination.Pod != "" {
+		pod, ns = pc.Spec.Destination.Pod, pc.Spec.Destination.Namespace
+	} else {
+		klog.Errorf("PacketCapture %s has neither source nor destination Pod specified", pc.Name)
+		return nil
+	}
+	podInterfaces := c.interfaceStore.GetContainerInterfacesByPod(pod, ns)
+	if len(podInterfaces) == 0 {
+		return nil
+	}
+
+	packet, err := c.preparePacket(pc, podInterfaces[0])
+	if err == nil {
+		err = c.startCapture(pc, packet)
+	}
+	if err != nil {
+		return c.updatePacketCaptureStatus(pc.Name, crdv1alpha1.PacketCaptureStatus{
+			Phase:    crdv1alpha1.PacketCaptureFailed,
+			Reason:   fmt.Sprintf("Node: %s, error: %v", c.nodeConfig.Name, err),
+			NodeName: c.nodeConfig.Name,
+		})
+	}
+	return nil
+}
+
+// startCapture creates the pcap file, reports the PacketCapture as running and installs the
+// flows of the capture. The status is updated before the flows are installed, so that it cannot
+// overwrite the status reported when the capture is stopped.
+func (c *Controller) startCapture(pc *crdv1alpha1.PacketCapture, packet *binding.Packet) error {
+	maxPackets := pc.Spec.MaxPackets
+	if maxPackets == 0 {
+		maxPackets = crdv1alpha1.DefaultPacketCaptureMaxPackets
+	} else if maxPackets < 0 || maxPackets > crdv1alpha1.MaxPacketCaptureMaxPackets {
+		return fmt.Errorf("maxPackets must be between 1 and %d", crdv1alpha1.MaxPacketCaptureMaxPackets)
+	}
+	timeout := pc.Spec.Timeout
+	if timeout == 0 {
+		timeout = crdv1alpha1.DefaultPacketCaptureTimeout
+	} else if timeout > crdv1alpha1.MaxPacketCaptureTimeout {
+		return fmt.Errorf("timeout cannot exceed %d seconds", crdv1alpha1.MaxPacketCaptureTimeout)
+	}
+
+	c.capturesMutex.Lock()
+	defer c.capturesMutex.Unlock()
+	if len(c.runningCaptures) >= maxConcurrentCaptures {
+		return fmt.Errorf("the number of running PacketCaptures reached the limit of %d", maxConcurrentCaptures)
+	}
+	file, err := os.Create(c.pcapFilePath(pc.Name))
+	if err != nil {
+		return fmt.Errorf("failed to create pcap file: %v", err)
+	}
+	success := false
+	defer func() {
+		if !success {
+			file.Close()
+			os.Remove(file.Name())
+		}
+	}()
+	writer, err := newPcapWriter(file)
+	if err != nil {
+		return fmt.Errorf("failed to write pcap file: %v", err)
+	}
+	if err := c.updatePacketCaptureStatus(pc.Name, crdv1alpha1.PacketCaptureStatus{
+		Phase:    crdv1alpha1.PacketCaptureRunning,
+		NodeName: c.nodeConfig.Name,
+	}); err != nil {
+		return fmt.Errorf("failed to update status: %v", err)
+	}
+	klog.V(2).Infof("Installing flow entries for PacketCapture %s", pc.Name)
+	if err := c.ofClient.InstallPacketCaptureFlows(pc.Name, packet, timeout); err != nil {
+		return fmt.Errorf("failed to install flows: %v", err)
+	}
+	state := &captureState{
+		name:       pc.Name,
+		uid:        pc.UID,
+		packet:     packet,
+		maxPackets: maxPackets,
+		file:       file,
+		writer:     writer,
+	}
+	state.timer = time.AfterFunc(time.Duration(timeout)*time.Second, func() {
+		c.stopCapture(state, "Timeout reached")
+	})
+	c.runningCaptures[pc.Name] = state
+	success = true
+	return nil
+}
+
+func (c *Controller) preparePacket(pc *crdv1alpha1.PacketCapture, intf *interfacestore.InterfaceConfig) (*binding.Packet, error) {
+	podIP := intf.GetIPv4Addr()
+	if podIP == nil {
+		return nil, errors.New("Pod does not have an IPv4 address")
+	}
+	packet := new(binding.Packet)
+	var err error
+	if pc.Spec.Source.Pod != "" {
+		packet.SourceIP = podIP
+		packet.DestinationIP, err = c.getPeerIP(&pc.Spec.Destination)
+	} else {
+		packet.DestinationIP = podIP
+		packet.SourceIP, err = c.getPeerIP(&pc.Spec.Source)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch strings.ToUpper(pc.Spec.Protocol) {
+	case "":
+	case "TCP":
+		packet.IPProto = protocol.Type_TCP
+	case "UDP":
+		packet.IPProto = protocol.Type_UDP
+	case "ICMP":
+		packet.IPProto = protocol.Type_ICMP
+	default:
+		return nil, fmt.Errorf("unsupported protocol %s", pc.Spec.Protocol)
+	}
+	if pc.Spec.SrcPort != 0 || pc.Spec.DstPort != 0 {
+		if packet.IPProto != protocol.Type_TCP && packet.IPProto != protocol.Type_UDP {
+			return nil, errors.New("ports can only be specified for TCP or UDP")
+		}
+		if pc.Spec.SrcPort < 0 || pc.Spec.SrcPort > 65535 || pc.Spec.DstPort < 0 || pc.Spec.DstPort > 65535 {
+			return nil, errors.New("ports must be between 1 and 65535")
+		}
+		packet.SourcePort = uint16(pc.Spec.SrcPort)
+		packet.DestinationPort = uint16(pc.Spec.DstPort)
+	}
+	return packet, nil
+}
+
+// getPeerIP returns the IPv4 address of the peer, or nil if the peer is not specified.
+func (c *Controller) getPeerIP(peer *crdv1alpha1.PacketCapturePeer) (net.IP, error) {
+	if peer.IP != "" {
+		if peer.Pod != "" {
+			return nil, errors.New("Pod and IP cannot be both specified for a peer")
+		}
+		ip := net.ParseIP(peer.IP).To4()
+		if ip == nil {
+			return nil, fmt.Errorf("invalid IPv4 address %s", peer.IP)
+		}
+		return ip, nil
+	}
+	if peer.Pod == "" {
+		return nil, nil
+	}
+	var ip net.IP
+	if podInterfaces := c.interfaceStore.GetContainerInterfacesByPod(peer.Pod, peer.Namespace); len(podInterfaces) > 0 {
+		ip = podInterfaces[0].GetIPv4Addr()
+	} else {
+		pod, err := c.kubeClient.CoreV1().Pods(peer.Namespace).Get(context.TODO(), peer.Pod, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get Pod %s/%s: %v", peer.Namespace, peer.Pod, err)
+		}
+		podIPs := make([]net.IP, len(pod.Status.PodIPs))
+		for i, podIP := range pod.Status.PodIPs {
+			podIPs[i] = net.ParseIP(podIP.IP)
+		}
+		ip = util.GetIPv4Addr(podIPs)
+	}
+	if ip == nil {
+		return nil, fmt.Errorf("Pod %s/%s does not have an IPv4 address", peer.Namespace, peer.Pod)
+	}
+	return ip, nil
+}
+
+// stopCapture stops a running capture and reports its result. It does nothing if the capture
+// has already been stopped.
+func (c *Controller) stopCapture(state *captureState, reason string) {
+	c.capturesMutex.Lock()
+	if c.runningCaptures[state.name] != state {
+		c.capturesMutex.Unlock()
+		return
+	}
+	delete(c.runningCaptures, state.name)
+	c.completedCaptures[state.name] = state
+	c.capturesMutex.Unlock()
+
+	c.releaseCapture(state)
+	klog.Infof("Stopped PacketCapture %s after capturing %d packets: %s", state.name, state.numPackets, reason)
+	if err := c.updatePacketCaptureStatus(state.name, crdv1alpha1.PacketCaptureStatus{
+		Phase:              crdv1alpha1.PacketCaptureSucceeded,
+		Reason:             reason,
+		NodeName:           c.nodeConfig.Name,
+		NumCapturedPackets: state.numPackets,
+		FileSize:           state.writer.size,
+	}); err != nil && !apierrors.IsNotFound(err) {
+		klog.Errorf("Failed to update status of PacketCapture %s: %v", state.name, err)
+	}
+}
+
+// releaseCapture uninstalls the flows of a capture and closes its pcap file.
+func (c *Controller) releaseCapture(state *captureState) {
+	state.timer.Stop()
+	if err := c.ofClient.UninstallPacketCaptureFlows(state.name); err != nil {
+		klog.Errorf("Failed to uninstall PacketCapture %s flows: %v", state.name, err)
+	}
+	if err := state.file.Close(); err != nil {
+		klog.Errorf("Failed to close pcap file of PacketCapture %s: %v", state.name, err)
+	}
+}
+
+// cleanupPacketCapture stops the capture if it is running and removes its pcap file.
+func (c *Controller) cleanupPacketCapture(name string) {
+	c.capturesMutex.Lock()
+	state, running := c.runningCaptures[name]
+	if running {
+		delete(c.runningCaptures, name)
+	} else if state = c.completedCaptures[name]; state != nil {
+		delete(c.completedCaptures, name)
+	}
+	c.capturesMutex.Unlock()
+	if state == nil {
+		return
+	}
+	if running {
+		c.releaseCapture(state)
+	}
+	if err := os.Remove(state.file.Name()); err != nil && !os.IsNotExist(err) {
+		klog.Errorf("Failed to remove pcap file of PacketCapture %s: %v", name, err)
+	}
+}
+
+func (c *Controller) updatePacketCaptureStatus(name string, status crdv1alpha1.PacketCaptureStatus) error {
+	type PacketCapture struct {
+		Status crdv1alpha1.PacketCaptureStatus `json:"status,omitempty"`
+	}
+	payloads, _ := json.Marshal(PacketCapture{Status: status})
+	_, err := c.crdClient.CrdV1alpha1().PacketCaptures().Patch(context.TODO(), name, types.MergePatchType, payloads, metav1.PatchOptions{}, "status")
+	return err
+}
+
+func (c *Controller) pcapFilePath(name string) string {
+	return filepath.Join(c.captureDir, name+".pcap")
+}
+
+// GetPcapFile implements querier.AgentPacketCaptureQuerier.
+func (c *Controller) GetPcapFile(name string) (string, bool) {
+	c.capturesMutex.Lock()
+	defer c.capturesMutex.Unlock()
+	state, ok := c.completedCaptures[name]
+	if !ok {
+		return "", false
+	}
+	return state.file.Name(), true
+}
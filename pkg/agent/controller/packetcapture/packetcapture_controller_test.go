@@ -0,0 +1,294 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package packetcapture
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/contiv/libOpenflow/protocol"
+	"github.com/contiv/ofnet/ofctrl"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+
+	"antrea.io/antrea/pkg/agent/config"
+	"antrea.io/antrea/pkg/agent/interfacestore"
+	openflowtest "antrea.io/antrea/pkg/agent/openflow/testing"
+	crdv1alpha1 "antrea.io/antrea/pkg/apis/crd/v1alpha1"
+	fakeversioned "antrea.io/antrea/pkg/client/clientset/versioned/fake"
+	crdinformers "antrea.io/antrea/pkg/client/informers/externalversions"
+	binding "antrea.io/antrea/pkg/ovs/openflow"
+)
+
+const fakeNode = "node1"
+
+var (
+	localPodIP  = net.ParseIP("10.10.0.2").To4()
+	remotePodIP = net.ParseIP("10.10.1.2").To4()
+)
+
+type fakeController struct {
+	*Controller
+	mockOFClient *openflowtest.MockTraceflowClient
+	crdClient    *fakeversioned.Clientset
+	pcIndexer    cache.Indexer
+}
+
+func newFakeController(t *testing.T, initObjects ...runtime.Object) *fakeController {
+	controller := gomock.NewController(t)
+	mockOFClient := openflowtest.NewMockTraceflowClient(controller)
+	mockOFClient.EXPECT().RegisterPacketInHandler(gomock.Any(), gomock.Any(), gomock.Any())
+
+	remotePod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "remote-pod"},
+		Status:     corev1.PodStatus{PodIPs: []corev1.PodIP{{IP: remotePodIP.String()}}},
+	}
+	kubeClient := fake.NewSimpleClientset(remotePod)
+	crdClient := fakeversioned.NewSimpleClientset(initObjects...)
+	crdInformerFactory := crdinformers.NewSharedInformerFactory(crdClient, 0)
+	pcInformer := crdInformerFactory.Crd().V1alpha1().PacketCaptures()
+	for _, obj := range initObjects {
+		pcInformer.Informer().GetIndexer().Add(obj)
+	}
+
+	ifaceStore := interfacestore.NewInterfaceStore()
+	ifaceStore.AddInterface(interfacestore.NewContainerInterface("local-pod-eth0", "c1", "local-pod", "default", nil, []net.IP{localPodIP}))
+
+	c := NewPacketCaptureController(kubeClient, crdClient, pcInformer, mockOFClient, ifaceStore, &config.NodeConfig{Name: fakeNode})
+	captureDir, err := ioutil.TempDir("", "packetcapture")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(captureDir) })
+	c.captureDir = captureDir
+	return &fakeController{Controller: c, mockOFClient: mockOFClient, crdClient: crdClient, pcIndexer: pcInformer.Informer().GetIndexer()}
+}
+
+func newPacketCapture(name string, spec crdv1alpha1.PacketCaptureSpec) *crdv1alpha1.PacketCapture {
+	return &crdv1alpha1.PacketCapture{
+		ObjectMeta: metav1.ObjectMeta{Name: name, UID: "uid-" + name},
+		Spec:       spec,
+	}
+}
+
+func newUDPPacketIn(srcIP, dstIP net.IP, srcPort, dstPort uint16) *ofctrl.PacketIn {
+	return &ofctrl.PacketIn{
+		Data: protocol.Ethernet{
+			HWDst:     net.HardwareAddr{0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0x01},
+			HWSrc:     net.HardwareAddr{0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0x02},
+			Ethertype: protocol.IPv4_MSG,
+			Data: &protocol.IPv4{
+				Version:  4,
+				IHL:      5,
+				Length:   28,
+				TTL:      64,
+				Protocol: protocol.Type_UDP,
+				NWSrc:    srcIP,
+				NWDst:    dstIP,
+				Data:     &protocol.UDP{PortSrc: srcPort, PortDst: dstPort, Length: 8},
+			},
+		},
+	}
+}
+
+func getStatus(t *testing.T, c *fakeController, name string) crdv1alpha1.PacketCaptureStatus {
+	pc, err := c.crdClient.CrdV1alpha1().PacketCaptures().Get(context.TODO(), name, metav1.GetOptions{})
+	require.NoError(t, err)
+	return pc.Status
+}
+
+func TestPacketCapture(t *testing.T) {
+	pc := newPacketCapture("pc1", crdv1alpha1.PacketCaptureSpec{
+		Source:      crdv1alpha1.PacketCapturePeer{Namespace: "default", Pod: "local-pod"},
+		Destination: crdv1alpha1.PacketCapturePeer{Namespace: "default", Pod: "remote-pod"},
+		Protocol:    "UDP",
+		DstPort:     53,
+		MaxPackets:  2,
+	})
+	c := newFakeController(t, pc)
+	expectedPacket := &binding.Packet{SourceIP: localPodIP, DestinationIP: remotePodIP, IPProto: protocol.Type_UDP, DestinationPort: 53}
+	c.mockOFClient.EXPECT().InstallPacketCaptureFlows("pc1", expectedPacket, crdv1alpha1.DefaultPacketCaptureTimeout)
+	require.NoError(t, c.syncPacketCapture("pc1"))
+	assert.Equal(t, crdv1alpha1.PacketCaptureStatus{Phase: crdv1alpha1.PacketCaptureRunning, NodeName: fakeNode}, getStatus(t, c, "pc1"))
+	_, ok := c.GetPcapFile("pc1")
+	assert.False(t, ok, "pcap file should not be served while the capture is running")
+
+	// Packets not matching the capture are ignored.
+	require.NoError(t, c.HandlePacketIn(newUDPPacketIn(localPodIP, remotePodIP, 10000, 80)))
+	require.NoError(t, c.HandlePacketIn(newUDPPacketIn(localPodIP, remotePodIP, 10000, 53)))
+	c.mockOFClient.EXPECT().UninstallPacketCaptureFlows("pc1")
+	require.NoError(t, c.HandlePacketIn(newUDPPacketIn(localPodIP, remotePodIP, 10001, 53)))
+	// Packets received after the capture is stopped are ignored.
+	require.NoError(t, c.HandlePacketIn(newUDPPacketIn(localPodIP, remotePodIP, 10002, 53)))
+
+	// Each record is made of the record header, the Ethernet header, the IP header and the UDP header.
+	expectedSize := int64(pcapGlobalHeaderLen + 2*(pcapRecordHeaderLen+14+20+8))
+	assert.Equal(t, crdv1alpha1.PacketCaptureStatus{
+		Phase:              crdv1alpha1.PacketCaptureSucceeded,
+		Reason:             "Maximum number of packets captured",
+		NodeName:           fakeNode,
+		NumCapturedPackets: 2,
+		FileSize:           expectedSize,
+	}, getStatus(t, c, "pc1"))
+	path, ok := c.GetPcapFile("pc1")
+	require.True(t, ok)
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, expectedSize, info.Size())
+
+	// The pcap file is removed when the PacketCapture is deleted.
+	require.NoError(t, c.pcIndexer.Delete(pc))
+	require.NoError(t, c.syncPacketCapture("pc1"))
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+	_, ok = c.GetPcapFile("pc1")
+	assert.False(t, ok)
+}
+
+func TestPacketCaptureTimeout(t *testing.T) {
+	pc := newPacketCapture("pc1", crdv1alpha1.PacketCaptureSpec{
+		Destination: crdv1alpha1.PacketCapturePeer{Namespace: "default", Pod: "local-pod"},
+		Timeout:     1,
+	})
+	c := newFakeController(t, pc)
+	expectedPacket := &binding.Packet{DestinationIP: localPodIP}
+	c.mockOFClient.EXPECT().InstallPacketCaptureFlows("pc1", expectedPacket, uint16(1))
+	uninstalled := make(chan struct{})
+	c.mockOFClient.EXPECT().UninstallPacketCaptureFlows("pc1").Do(func(string) { close(uninstalled) })
+	require.NoError(t, c.syncPacketCapture("pc1"))
+	require.NoError(t, c.HandlePacketIn(newUDPPacketIn(remotePodIP, localPodIP, 10000, 53)))
+	select {
+	case <-uninstalled:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("PacketCapture was not stopped after its timeout")
+	}
+	assert.Eventually(t, func() bool {
+		return getStatus(t, c, "pc1").Phase == crdv1alpha1.PacketCaptureSucceeded
+	}, 2*time.Second, 50*time.Millisecond)
+	status := getStatus(t, c, "pc1")
+	assert.Equal(t, "Timeout reached", status.Reason)
+	assert.Equal(t, int32(1), status.NumCapturedPackets)
+}
+
+func TestPacketCaptureNotRunOnNode(t *testing.T) {
+	pcs := []runtime.Object{
+		newPacketCapture("remote-source", crdv1alpha1.PacketCaptureSpec{
+			Source:      crdv1alpha1.PacketCapturePeer{Namespace: "default", Pod: "remote-pod"},
+			Destination: crdv1alpha1.PacketCapturePeer{Namespace: "default", Pod: "local-pod"},
+		}),
+		newPacketCapture("no-pod", crdv1alpha1.PacketCaptureSpec{
+			Source: crdv1alpha1.PacketCapturePeer{IP: remotePodIP.String()},
+		}),
+	}
+	c := newFakeController(t, pcs...)
+	for _, name := range []string{"remote-source", "no-pod"} {
+		require.NoError(t, c.syncPacketCapture(name))
+		assert.Equal(t, crdv1alpha1.PacketCaptureStatus{}, getStatus(t, c, name))
+	}
+}
+
+func TestPacketCaptureFailure(t *testing.T) {
+	localSource := crdv1alpha1.PacketCapturePeer{Namespace: "default", Pod: "local-pod"}
+	tests := []struct {
+		name           string
+		spec           crdv1alpha1.PacketCaptureSpec
+		expectedReason string
+	}{
+		{
+			name:           "invalid IP",
+			spec:           crdv1alpha1.PacketCaptureSpec{Source: localSource, Destination: crdv1alpha1.PacketCapturePeer{IP: "fd00::1"}},
+			expectedReason: "invalid IPv4 address fd00::1",
+		},
+		{
+			name:           "unknown Pod",
+			spec:           crdv1alpha1.PacketCaptureSpec{Source: localSource, Destination: crdv1alpha1.PacketCapturePeer{Namespace: "default", Pod: "unknown"}},
+			expectedReason: `failed to get Pod default/unknown: pods "unknown" not found`,
+		},
+		{
+			name:           "unsupported protocol",
+			spec:           crdv1alpha1.PacketCaptureSpec{Source: localSource, Protocol: "SCTP"},
+			expectedReason: "unsupported protocol SCTP",
+		},
+		{
+			name:           "port without protocol",
+			spec:           crdv1alpha1.PacketCaptureSpec{Source: localSource, DstPort: 80},
+			expectedReason: "ports can only be specified for TCP or UDP",
+		},
+		{
+			name:           "too many packets",
+			spec:           crdv1alpha1.PacketCaptureSpec{Source: localSource, MaxPackets: crdv1alpha1.MaxPacketCaptureMaxPackets + 1},
+			expectedReason: "maxPackets must be between 1 and 10000",
+		},
+		{
+			name:           "timeout too long",
+			spec:           crdv1alpha1.PacketCaptureSpec{Source: localSource, Timeout: crdv1alpha1.MaxPacketCaptureTimeout + 1},
+			expectedReason: "timeout cannot exceed 300 seconds",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newFakeController(t, newPacketCapture("pc1", tt.spec))
+			require.NoError(t, c.syncPacketCapture("pc1"))
+			assert.Equal(t, crdv1alpha1.PacketCaptureStatus{
+				Phase:    crdv1alpha1.PacketCaptureFailed,
+				Reason:   fmt.Sprintf("Node: %s, error: %s", fakeNode, tt.expectedReason),
+				NodeName: fakeNode,
+			}, getStatus(t, c, "pc1"))
+		})
+	}
+}
+
+func TestPacketCaptureConcurrencyLimit(t *testing.T) {
+	var pcs []runtime.Object
+	for i := 0; i <= maxConcurrentCaptures; i++ {
+		pcs = append(pcs, newPacketCapture(fmt.Sprintf("pc%d", i), crdv1alpha1.PacketCaptureSpec{
+			Source: crdv1alpha1.PacketCapturePeer{Namespace: "default", Pod: "local-pod"},
+		}))
+	}
+	c := newFakeController(t, pcs...)
+	c.mockOFClient.EXPECT().InstallPacketCaptureFlows(gomock.Any(), gomock.Any(), gomock.Any()).Times(maxConcurrentCaptures)
+	c.mockOFClient.EXPECT().UninstallPacketCaptureFlows(gomock.Any()).AnyTimes()
+	for i := 0; i < maxConcurrentCaptures; i++ {
+		require.NoError(t, c.syncPacketCapture(fmt.Sprintf("pc%d", i)))
+	}
+	name := fmt.Sprintf("pc%d", maxConcurrentCaptures)
+	require.NoError(t, c.syncPacketCapture(name))
+	status := getStatus(t, c, name)
+	assert.Equal(t, crdv1alpha1.PacketCaptureFailed, status.Phase)
+	assert.Contains(t, status.Reason, "the number of running PacketCaptures reached the limit of 16")
+}
+
+func TestPacketCaptureAgentRestart(t *testing.T) {
+	pc := newPacketCapture("pc1", crdv1alpha1.PacketCaptureSpec{
+		Source: crdv1alpha1.PacketCapturePeer{Namespace: "default", Pod: "local-pod"},
+	})
+	pc.Status = crdv1alpha1.PacketCaptureStatus{Phase: crdv1alpha1.PacketCaptureRunning, NodeName: fakeNode}
+	c := newFakeController(t, pc)
+	require.NoError(t, c.syncPacketCapture("pc1"))
+	assert.Equal(t, crdv1alpha1.PacketCaptureStatus{
+		Phase:    crdv1alpha1.PacketCaptureFailed,
+		Reason:   "antrea-agent restarted during the capture",
+		NodeName: fakeNode,
+	}, getStatus(t, c, "pc1"))
+}
@@ -0,0 +1,86 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package packetcapture
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/contiv/ofnet/ofctrl"
+	"k8s.io/klog/v2"
+
+	binding "antrea.io/antrea/pkg/ovs/openflow"
+)
+
+// HandlePacketIn writes the packet to the pcap file of every running capture it matches. The
+// flows of overlapping captures may not all be hit by a packet, so the packet is matched against
+// all captures here.
+func (c *Controller) HandlePacketIn(pktIn *ofctrl.PacketIn) error {
+	packet, err := binding.ParsePacketIn(pktIn)
+	if err != nil {
+		return fmt.Errorf("failed to parse packet: %v", err)
+	}
+	data, err := pktIn.Data.MarshalBinary()
+	if err != nil {
+		return fmt.Errorf("failed to serialize packet: %v", err)
+	}
+	now := time.Now()
+
+	stopped := map[*captureState]string{}
+	c.capturesMutex.Lock()
+	for name, state := range c.runningCaptures {
+		if !matchPacket(state.packet, packet) {
+			continue
+		}
+		if state.writer.size+int64(pcapRecordHeaderLen+len(data)) > maxFileSize {
+			stopped[state] = "Maximum pcap file size reached"
+			continue
+		}
+		if err := state.writer.writePacket(now, data); err != nil {
+			klog.Errorf("Failed to write packet to pcap file of PacketCapture %s: %v", name, err)
+			continue
+		}
+		state.numPackets++
+		if state.numPackets >= state.maxPackets {
+			stopped[state] = "Maximum number of packets captured"
+		}
+	}
+	c.capturesMutex.Unlock()
+
+	for state, reason := range stopped {
+		c.stopCapture(state, reason)
+	}
+	return nil
+}
+
+// matchPacket returns whether the packet has all the fields set in filter.
+func matchPacket(filter, packet *binding.Packet) bool {
+	if filter.SourceIP != nil && !filter.SourceIP.Equal(packet.SourceIP) {
+		return false
+	}
+	if filter.DestinationIP != nil && !filter.DestinationIP.Equal(packet.DestinationIP) {
+		return false
+	}
+	if filter.IPProto != 0 && filter.IPProto != packet.IPProto {
+		return false
+	}
+	if filter.SourcePort != 0 && filter.SourcePort != packet.SourcePort {
+		return false
+	}
+	if filter.DestinationPort != 0 && filter.DestinationPort != packet.DestinationPort {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,74 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package packetcapture
+
+import (
+	"encoding/binary"
+	"io"
+	"time"
+)
+
+// The pcap file format is described in https://wiki.wireshark.org/Development/LibpcapFileFormat.
+const (
+	pcapMagicNumber  uint32 = 0xa1b2c3d4
+	pcapVersionMajor uint16 = 2
+	pcapVersionMinor uint16 = 4
+	// pcapSnapLen is the maximum number of bytes written for each packet.
+	pcapSnapLen uint32 = 65535
+	// pcapLinkTypeEthernet is the link-layer header type of the captured packets.
+	pcapLinkTypeEthernet uint32 = 1
+
+	pcapGlobalHeaderLen = 24
+	pcapRecordHeaderLen = 16
+)
+
+// pcapWriter writes packets to w in the pcap format, and keeps track of the number of bytes
+// written.
+type pcapWriter struct {
+	w    io.Writer
+	size int64
+}
+
+// newPcapWriter returns a pcapWriter after writing the pcap global header to w.
+func newPcapWriter(w io.Writer) (*pcapWriter, error) {
+	header := make([]byte, pcapGlobalHeaderLen)
+	binary.LittleEndian.PutUint32(header[0:4], pcapMagicNumber)
+	binary.LittleEndian.PutUint16(header[4:6], pcapVersionMajor)
+	binary.LittleEndian.PutUint16(header[6:8], pcapVersionMinor)
+	// The timezone offset and the timestamp accuracy are always 0.
+	binary.LittleEndian.PutUint32(header[16:20], pcapSnapLen)
+	binary.LittleEndian.PutUint32(header[20:24], pcapLinkTypeEthernet)
+	if _, err := w.Write(header); err != nil {
+		return nil, err
+	}
+	return &pcapWriter{w: w, size: pcapGlobalHeaderLen}, nil
+}
+
+// writePacket writes a packet record, truncating the packet data to pcapSnapLen bytes.
+func (w *pcapWriter) writePacket(timestamp time.Time, data []byte) error {
+	capturedLen := len(data)
+	if capturedLen > int(pcapSnapLen) {
+		capturedLen = int(pcapSnapLen)
+	}
+	record := make([]byte, pcapRecordHeaderLen+capturedLen)
+	binary.LittleEndian.PutUint32(record[0:4], uint32(timestamp.Unix()))
+	binary.LittleEndian.PutUint32(record[4:8], uint32(timestamp.Nanosecond()/1000))
+	binary.LittleEndian.PutUint32(record[8:12], uint32(capturedLen))
+	binary.LittleEndian.PutUint32(record[12:16], uint32(len(data)))
+	copy(record[pcapRecordHeaderLen:], data[:capturedLen])
+	n, err := w.w.Write(record)
+	w.size += int64(n)
+	return err
+}
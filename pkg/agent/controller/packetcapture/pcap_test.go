@@ -0,0 +1,56 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package packetcapture
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPcapWriter(t *testing.T) {
+	buf := new(bytes.Buffer)
+	w, err := newPcapWriter(buf)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{
+		0xd4, 0xc3, 0xb2, 0xa1, // magic number
+		0x02, 0x00, 0x04, 0x00, // version 2.4
+		0x00, 0x00, 0x00, 0x00, // timezone offset
+		0x00, 0x00, 0x00, 0x00, // timestamp accuracy
+		0xff, 0xff, 0x00, 0x00, // snapshot length
+		0x01, 0x00, 0x00, 0x00, // link-layer header type
+	}, buf.Bytes())
+
+	timestamp := time.Unix(1, 2000)
+	data := []byte{0xaa, 0xbb, 0xcc}
+	require.NoError(t, w.writePacket(timestamp, data))
+	assert.Equal(t, []byte{
+		0x01, 0x00, 0x00, 0x00, // seconds
+		0x02, 0x00, 0x00, 0x00, // microseconds
+		0x03, 0x00, 0x00, 0x00, // captured length
+		0x03, 0x00, 0x00, 0x00, // original length
+		0xaa, 0xbb, 0xcc,
+	}, buf.Bytes()[pcapGlobalHeaderLen:])
+	assert.Equal(t, int64(buf.Len()), w.size)
+
+	// Packets larger than the snapshot length are truncated.
+	buf.Reset()
+	require.NoError(t, w.writePacket(timestamp, make([]byte, pcapSnapLen+10)))
+	assert.Equal(t, pcapRecordHeaderLen+int(pcapSnapLen), buf.Len())
+	assert.Equal(t, []byte{0xff, 0xff, 0x00, 0x00, 0x09, 0x00, 0x01, 0x00}, buf.Bytes()[8:16])
+}
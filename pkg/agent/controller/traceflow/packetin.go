@@ -182,10 +182,7 @@ func (c *Controller) parsePacketIn(pktIn *ofctrl.PacketIn) (*crdv1alpha1.Tracefl
 				return nil, nil, nil, err
 			}
 			ob := getNetworkPolicyObservation(tableID, false)
-			npRef := c.networkPolicyQuerier.GetNetworkPolicyByRuleFlowID(egressInfo)
-			if npRef != nil {
-				ob.NetworkPolicy = npRef.ToString()
-			}
+			c.setNetworkPolicyRule(ob, egressInfo)
 			obs = append(obs, *ob)
 		}
 	}
@@ -197,15 +194,14 @@ func (c *Controller) parsePacketIn(pktIn *ofctrl.PacketIn) (*crdv1alpha1.Tracefl
 			return nil, nil, nil, err
 		}
 		ob := getNetworkPolicyObservation(tableID, true)
-		npRef := c.networkPolicyQuerier.GetNetworkPolicyByRuleFlowID(ingressInfo)
-		if npRef != nil {
-			ob.NetworkPolicy = npRef.ToString()
-		}
+		c.setNetworkPolicyRule(ob, ingressInfo)
 		obs = append(obs, *ob)
 	}
 
-	// Get drop table.
-	if tableID == uint8(openflow.EgressMetricTable) || tableID == uint8(openflow.IngressMetricTable) {
+	// Get drop table. The packets dropped by the layer 2 rules of Antrea-native policies are sent to the
+	// controller from the layer 2 rule tables directly.
+	if tableID == uint8(openflow.EgressMetricTable) || tableID == uint8(openflow.IngressMetricTable) ||
+		tableID == uint8(openflow.AntreaPolicyARPRuleTable) || tableID == uint8(openflow.AntreaPolicyNDRuleTable) {
 		ob := getNetworkPolicyObservation(tableID, tableID == uint8(openflow.IngressMetricTable))
 		if match := getMatchRegField(matchers, uint32(openflow.CNPDenyConjIDReg)); match != nil {
 			notAllowConjInfo, err := getRegValue(match, nil)
@@ -216,6 +212,7 @@ func (c *Controller) parsePacketIn(pktIn *ofctrl.PacketIn) (*crdv1alpha1.Tracefl
 				if npRef := ruleRef.PolicyRef; npRef != nil {
 					ob.NetworkPolicy = npRef.ToString()
 				}
+				ob.NetworkPolicyRule = ruleRef.Name
 				if ruleRef.Action != nil && *ruleRef.Action == crdv1alpha1.RuleActionReject {
 					ob.Action = crdv1alpha1.ActionRejected
 				}
@@ -350,8 +347,9 @@ func getNetworkPolicyObservation(tableID uint8, ingress bool) *crdv1alpha1.Obser
 		}
 	} else {
 		switch tableID {
-		case uint8(openflow.EgressMetricTable), uint8(openflow.EgressDefaultTable):
-			// Packet dropped by ANP/default drop rule
+		case uint8(openflow.EgressMetricTable), uint8(openflow.EgressDefaultTable),
+			uint8(openflow.AntreaPolicyARPRuleTable), uint8(openflow.AntreaPolicyNDRuleTable):
+			// Packet dropped by ANP/default drop rule, or by a layer 2 rule
 			ob.ComponentInfo = openflow.GetFlowTableName(binding.TableIDType(tableID))
 			ob.Action = crdv1alpha1.ActionDropped
 		default:
@@ -362,6 +360,23 @@ func getNetworkPolicyObservation(tableID uint8, ingress bool) *crdv1alpha1.Obser
 	return ob
 }
 
+// setNetworkPolicyRule sets the NetworkPolicy and the name of the rule which the packet matched in the
+// Observation. If the rule has the Pass action, the packet was deferred to the next stages instead
+// of being forwarded by the rule.
+func (c *Controller) setNetworkPolicyRule(ob *crdv1alpha1.Observation, ruleFlowID uint32) {
+	ruleRef := c.networkPolicyQuerier.GetRuleByFlowID(ruleFlowID)
+	if ruleRef == nil {
+		return
+	}
+	if ruleRef.PolicyRef != nil {
+		ob.NetworkPolicy = ruleRef.PolicyRef.ToString()
+	}
+	ob.NetworkPolicyRule = ruleRef.Name
+	if ruleRef.Action != nil && *ruleRef.Action == crdv1alpha1.RuleActionPass {
+		ob.Action = crdv1alpha1.ActionPassed
+	}
+}
+
 func isValidCtNw(ipStr string) bool {
 	ip := net.ParseIP(ipStr)
 	if ip == nil {
@@ -74,6 +74,18 @@ func Test_getNetworkPolicyObservation(t *testing.T) {
 				Action:        crdv1alpha1.ActionDropped,
 			},
 		},
+		{
+			name: "layer 2 rule drop",
+			args: args{
+				tableID: uint8(openflow.AntreaPolicyNDRuleTable),
+				ingress: false,
+			},
+			want: &crdv1alpha1.Observation{
+				Component:     crdv1alpha1.ComponentNetworkPolicy,
+				ComponentInfo: "AntreaPolicyNDRule",
+				Action:        crdv1alpha1.ActionDropped,
+			},
+		},
 		{
 			name: "egress accept",
 			args: args{
@@ -94,7 +94,7 @@ type Controller struct {
 	traceflowLister        crdlisters.TraceflowLister
 	traceflowListerSynced  cache.InformerSynced
 	ovsBridgeClient        ovsconfig.OVSBridgeClient
-	ofClient               openflow.Client
+	ofClient               openflow.TraceflowClient
 	networkPolicyQuerier   querier.AgentNetworkPolicyInfoQuerier
 	interfaceStore         interfacestore.InterfaceStore
 	networkConfig          *config.NetworkConfig
@@ -114,7 +114,7 @@ func NewTraceflowController(
 	informerFactory informers.SharedInformerFactory,
 	traceflowClient clientsetversioned.Interface,
 	traceflowInformer crdinformers.TraceflowInformer,
-	client openflow.Client,
+	client openflow.TraceflowClient,
 	npQuerier querier.AgentNetworkPolicyInfoQuerier,
 	ovsBridgeClient ovsconfig.OVSBridgeClient,
 	interfaceStore interfacestore.InterfaceStore,
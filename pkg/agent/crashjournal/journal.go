@@ -0,0 +1,163 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package crashjournal persists the panics and fatal errors of the agent to a bounded file, so that
+// they can be diagnosed after the agent restarts even when the logs of the previous container are
+// gone. It only depends on the local filesystem, so that it can be used before the agent is
+// initialized.
+package crashjournal
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultPath is the path of the crash journal of the agent.
+	DefaultPath = "/var/run/antrea/crash-journal.json"
+	// DefaultMaxEntries is the number of the latest entries kept in the crash journal.
+	DefaultMaxEntries = 10
+
+	// KindPanic is the kind of the entries recording a panic.
+	KindPanic = "panic"
+	// KindFatal is the kind of the entries recording a fatal error.
+	KindFatal = "fatal"
+
+	// maxStackSize is the maximum size of the stack trace of an entry, so that the goroutine
+	// dumps of large panics don't fill the journal.
+	maxStackSize = 64 << 10
+)
+
+// Entry is a panic or fatal error recorded in the crash journal.
+type Entry struct {
+	Time       string `json:"time"`
+	Kind       string `json:"kind"`
+	Message    string `json:"message"`
+	Stack      string `json:"stack,omitempty"`
+	ConfigHash string `json:"configHash,omitempty"`
+}
+
+// Journal records the panics and fatal errors of the agent in a file, keeping the latest
+// maxEntries entries.
+type Journal struct {
+	path       string
+	maxEntries int
+
+	mutex      sync.Mutex
+	configHash string
+	// panicRecorded is set once a panic is recorded, so that a panic recovered and re-raised by
+	// several handlers is only recorded once.
+	panicRecorded bool
+}
+
+// New returns a Journal writing to the file at path, which is created when the first entry is
+// recorded.
+func New(path string, maxEntries int) *Journal {
+	return &Journal{path: path, maxEntries: maxEntries}
+}
+
+// SetConfigHash sets the hash of the agent configuration recorded with the following entries.
+func (j *Journal) SetConfigHash(configHash string) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	j.configHash = configHash
+}
+
+// RecordFatal records a fatal error with the stack trace of the calling goroutine.
+func (j *Journal) RecordFatal(message string) error {
+	return j.record(KindFatal, message, debug.Stack())
+}
+
+// RecordPanic records a recovered panic with the stack trace of the calling goroutine, which
+// includes the frames of the panic when it is called from a deferred function. Only the first
+// panic is recorded, as the process is crashing. It can be added to the PanicHandlers of
+// k8s.io/apimachinery/pkg/util/runtime.
+func (j *Journal) RecordPanic(r interface{}) {
+	j.mutex.Lock()
+	recorded := j.panicRecorded
+	j.panicRecorded = true
+	j.mutex.Unlock()
+	if recorded {
+		return
+	}
+	// The process is crashing, there is nothing else to do with the error.
+	j.record(KindPanic, fmt.Sprint(r), debug.Stack())
+}
+
+// HandlePanic records the panic being recovered, if any, and panics again with the same value. It
+// must be called directly with defer.
+func (j *Journal) HandlePanic() {
+	if r := recover(); r != nil {
+		j.RecordPanic(r)
+		panic(r)
+	}
+}
+
+func (j *Journal) record(kind, message string, stack []byte) error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	if len(stack) > maxStackSize {
+		stack = stack[:maxStackSize]
+	}
+	// A corrupted journal, e.g. because the agent was killed while writing it, is overwritten.
+	entries, _ := Read(j.path)
+	entries = append(entries, Entry{
+		Time:       time.Now().UTC().Format(time.RFC3339Nano),
+		Kind:       kind,
+		Message:    message,
+		Stack:      string(stack),
+		ConfigHash: j.configHash,
+	})
+	if len(entries) > j.maxEntries {
+		entries = entries[len(entries)-j.maxEntries:]
+	}
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(j.path), 0755); err != nil {
+		return fmt.Errorf("error when creating the directory of the crash journal: %w", err)
+	}
+	// Write to a temporary file first, so that the existing entries are not lost if the agent is
+	// killed while writing.
+	tmpPath := j.path + ".tmp"
+	if err := ioutil.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("error when writing the crash journal: %w", err)
+	}
+	return os.Rename(tmpPath, j.path)
+}
+
+// Read returns the entries of the crash journal at path, from the oldest to the latest. It returns
+// no entries if the journal doesn't exist.
+func Read(path string) ([]Entry, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var entries []Entry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("error when parsing the crash journal: %w", err)
+	}
+	return entries, nil
+}
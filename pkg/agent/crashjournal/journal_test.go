@@ -0,0 +1,97 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package crashjournal
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestJournal(t *testing.T, maxEntries int) (*Journal, string) {
+	dir, err := ioutil.TempDir("", "antrea-crash-journal")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	// The missing parent directories of the journal are created.
+	path := filepath.Join(dir, "run", "antrea", "crash-journal.json")
+	return New(path, maxEntries), path
+}
+
+func TestReadMissingJournal(t *testing.T) {
+	_, path := newTestJournal(t, 3)
+	entries, err := Read(path)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestRecordFatal(t *testing.T) {
+	journal, path := newTestJournal(t, 3)
+	require.NoError(t, journal.RecordFatal("Failed to complete: missing config"))
+	journal.SetConfigHash("abcd")
+	for i := 0; i < 3; i++ {
+		require.NoError(t, journal.RecordFatal(fmt.Sprintf("Error running agent: %d", i)))
+	}
+
+	entries, err := Read(path)
+	require.NoError(t, err)
+	// Only the latest entries are kept.
+	require.Len(t, entries, 3)
+	for i, entry := range entries {
+		assert.Equal(t, KindFatal, entry.Kind)
+		assert.Equal(t, fmt.Sprintf("Error running agent: %d", i), entry.Message)
+		assert.Equal(t, "abcd", entry.ConfigHash)
+		assert.Contains(t, entry.Stack, "TestRecordFatal")
+		assert.NotEmpty(t, entry.Time)
+	}
+}
+
+func TestRecordCorruptedJournal(t *testing.T) {
+	journal, path := newTestJournal(t, 3)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, ioutil.WriteFile(path, []byte("[{\"time\":"), 0644))
+	_, err := Read(path)
+	assert.Error(t, err)
+
+	require.NoError(t, journal.RecordFatal("Error running agent"))
+	entries, err := Read(path)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "Error running agent", entries[0].Message)
+}
+
+func panicWithJournal(journal *Journal) {
+	defer journal.HandlePanic()
+	panic("invalid memory address")
+}
+
+func TestHandlePanic(t *testing.T) {
+	journal, path := newTestJournal(t, 3)
+	assert.PanicsWithValue(t, "invalid memory address", func() { panicWithJournal(journal) })
+	// The panic is only recorded once if it is recovered again.
+	journal.RecordPanic("invalid memory address")
+
+	entries, err := Read(path)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, KindPanic, entries[0].Kind)
+	assert.Equal(t, "invalid memory address", entries[0].Message)
+	// The stack trace includes the frame which panicked.
+	assert.Contains(t, entries[0].Stack, "panicWithJournal")
+}
@@ -17,6 +17,7 @@ package connections
 import (
 	"fmt"
 	"sync"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/klog/v2"
@@ -26,8 +27,18 @@ import (
 	"antrea.io/antrea/pkg/agent/proxy"
 )
 
+// podTombstone holds the identity of a deleted local Pod for one of its IPs, so that the
+// connections which started before its deletion are still attributed to it.
+type podTombstone struct {
+	podName      string
+	podNamespace string
+	deletionTime time.Time
+}
+
 type connectionStore struct {
-	connections   map[flowexporter.ConnectionKey]*flowexporter.Connection
+	connections map[flowexporter.ConnectionKey]*flowexporter.Connection
+	// podTombstones is keyed by the IPs of the deleted local Pods. It is protected by mutex.
+	podTombstones map[string]*podTombstone
 	ifaceStore    interfacestore.InterfaceStore
 	antreaProxier proxy.Proxier
 	mutex         sync.Mutex
@@ -39,6 +50,7 @@ func NewConnectionStore(
 ) connectionStore {
 	return connectionStore{
 		connections:   make(map[flowexporter.ConnectionKey]*flowexporter.Connection),
+		podTombstones: make(map[string]*podTombstone),
 		ifaceStore:    ifaceStore,
 		antreaProxier: proxier,
 	}
@@ -83,19 +95,71 @@ func (cs *connectionStore) fillPodInfo(conn *flowexporter.Connection) {
 	srcIP := conn.FlowKey.SourceAddress.String()
 	dstIP := conn.FlowKey.DestinationAddress.String()
 
-	sIface, srcFound := cs.ifaceStore.GetInterfaceByIP(srcIP)
-	dIface, dstFound := cs.ifaceStore.GetInterfaceByIP(dstIP)
+	var srcFound, dstFound bool
+	conn.SourcePodName, conn.SourcePodNamespace, srcFound = cs.lookupPod(conn, srcIP, "source")
+	conn.DestinationPodName, conn.DestinationPodNamespace, dstFound = cs.lookupPod(conn, dstIP, "destination")
 	if !srcFound && !dstFound {
 		klog.Warningf("Cannot map any of the IP %s or %s to a local Pod", srcIP, dstIP)
 	}
-	if srcFound && sIface.Type == interfacestore.ContainerInterface {
-		conn.SourcePodName = sIface.ContainerInterfaceConfig.PodName
-		conn.SourcePodNamespace = sIface.ContainerInterfaceConfig.PodNamespace
+}
+
+// lookupPod returns the name and Namespace of the local Pod the connection belongs to for the given
+// IP, and whether the IP was mapped to a local interface or to a deleted local Pod. A deleted Pod is
+// used when the connection started before the deletion of the Pod, and either no interface has the
+// IP anymore or the connection predates the interface which reused the IP. The caller must hold
+// the mutex.
+func (cs *connectionStore) lookupPod(conn *flowexporter.Connection, ip, direction string) (string, string, bool) {
+	iface, found := cs.ifaceStore.GetInterfaceByIP(ip)
+	if found && iface.Type != interfacestore.ContainerInterface {
+		return "", "", true
+	}
+	if found && !connPredatesInterface(conn, iface) {
+		return iface.ContainerInterfaceConfig.PodName, iface.ContainerInterfaceConfig.PodNamespace, true
+	}
+	tombstone, tombstoneFound := cs.podTombstones[ip]
+	if tombstoneFound && (conn.StartTime.IsZero() || conn.StartTime.Before(tombstone.deletionTime)) {
+		klog.V(2).Infof("Connection %v started before the deletion of Pod %s/%s, attributing the %s IP %s to it", conn.FlowKey, tombstone.podNamespace, tombstone.podName, direction, ip)
+		return tombstone.podName, tombstone.podNamespace, true
+	}
+	if found {
+		klog.V(2).Infof("Connection %v started before the creation of the interface of Pod %s/%s, the %s IP %s may have been reassigned", conn.FlowKey, iface.PodNamespace, iface.PodName, direction, ip)
+	}
+	return "", "", found
+}
+
+// onInterfaceDelete records a tombstone of the Pod of a deleted container interface for each of its
+// IPs, so that its existing connections are still attributed to it until their final records are
+// exported.
+func (cs *connectionStore) onInterfaceDelete(interfaceConfig *interfacestore.InterfaceConfig) {
+	if interfaceConfig.Type != interfacestore.ContainerInterface {
+		return
+	}
+	cs.mutex.Lock()
+	defer cs.mutex.Unlock()
+	now := time.Now()
+	for _, ip := range interfaceConfig.IPs {
+		cs.podTombstones[ip.String()] = &podTombstone{
+			podName:      interfaceConfig.PodName,
+			podNamespace: interfaceConfig.PodNamespace,
+			deletionTime: now,
+		}
+	}
+	klog.V(2).Infof("Recorded tombstone of deleted Pod %s/%s", interfaceConfig.PodNamespace, interfaceConfig.PodName)
+}
+
+// connPredatesInterface returns whether the connection started before the creation of the
+// interface, or before its IPs were last updated, e.g. when a DHCP lease was bound to the Pod. In
+// that case, the connection belonged to a previous owner of the interface IP and must not be
+// attributed to the Pod of the interface. It returns false if either time is unknown.
+func connPredatesInterface(conn *flowexporter.Connection, iface *interfacestore.InterfaceConfig) bool {
+	ifaceTime := iface.CreationTimestamp
+	if iface.IPUpdateTimestamp.After(ifaceTime) {
+		ifaceTime = iface.IPUpdateTimestamp
 	}
-	if dstFound && dIface.Type == interfacestore.ContainerInterface {
-		conn.DestinationPodName = dIface.ContainerInterfaceConfig.PodName
-		conn.DestinationPodNamespace = dIface.ContainerInterfaceConfig.PodNamespace
+	if conn.StartTime.IsZero() || ifaceTime.IsZero() {
+		return false
 	}
+	return conn.StartTime.Before(ifaceTime)
 }
 
 func (cs *connectionStore) fillServiceInfo(conn *flowexporter.Connection, serviceStr string) {
@@ -23,6 +23,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"antrea.io/antrea/pkg/agent/flowexporter"
+	"antrea.io/antrea/pkg/agent/interfacestore"
 	interfacestoretest "antrea.io/antrea/pkg/agent/interfacestore/testing"
 )
 
@@ -85,3 +86,102 @@ func TestConnectionStore_ForAllConnectionsDo(t *testing.T) {
 		assert.Equal(t, conn.OriginalPackets, uint64(0), "OriginalPackets should be reset")
 	}
 }
+
+func TestConnectionStore_FillPodInfoWithReusedIP(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	refTime := time.Now()
+	// The IP of the local Pod has been reassigned to it after the previous owner of the IP was deleted.
+	podInterface := &interfacestore.InterfaceConfig{
+		InterfaceName: "pod1-abcd",
+		Type:          interfacestore.ContainerInterface,
+		IPs:           []net.IP{{10, 10, 0, 2}},
+		ContainerInterfaceConfig: &interfacestore.ContainerInterfaceConfig{
+			PodName:      "pod1",
+			PodNamespace: "ns1",
+		},
+		CreationTimestamp: refTime.Add(-10 * time.Second),
+	}
+	tuple := flowexporter.Tuple{SourceAddress: net.IP{10, 10, 1, 2}, DestinationAddress: net.IP{10, 10, 0, 2}, Protocol: 6, SourcePort: 65280, DestinationPort: 80}
+
+	tests := []struct {
+		name            string
+		startTime       time.Time
+		expectedPodName string
+		expectedPodNS   string
+	}{
+		{
+			name:            "connection of the previous owner of the IP",
+			startTime:       refTime.Add(-20 * time.Second),
+			expectedPodName: "",
+			expectedPodNS:   "",
+		},
+		{
+			name:            "connection of the current owner of the IP",
+			startTime:       refTime.Add(-5 * time.Second),
+			expectedPodName: "pod1",
+			expectedPodNS:   "ns1",
+		},
+		{
+			name:            "unknown start time",
+			expectedPodName: "pod1",
+			expectedPodNS:   "ns1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockIfaceStore := interfacestoretest.NewMockInterfaceStore(ctrl)
+			connStore := NewConnectionStore(mockIfaceStore, nil)
+			mockIfaceStore.EXPECT().GetInterfaceByIP(tuple.SourceAddress.String()).Return(nil, false)
+			mockIfaceStore.EXPECT().GetInterfaceByIP(tuple.DestinationAddress.String()).Return(podInterface, true)
+			conn := &flowexporter.Connection{
+				StartTime: tt.startTime,
+				FlowKey:   tuple,
+			}
+			connStore.fillPodInfo(conn)
+			assert.Equal(t, "", conn.SourcePodName)
+			assert.Equal(t, "", conn.SourcePodNamespace)
+			assert.Equal(t, tt.expectedPodName, conn.DestinationPodName)
+			assert.Equal(t, tt.expectedPodNS, conn.DestinationPodNamespace)
+		})
+	}
+}
+
+func TestConnectionStore_FillPodInfoWithLeasedIP(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	refTime := time.Now()
+	// The IP was leased with DHCP to the local Pod after the Pod was created.
+	podInterface := &interfacestore.InterfaceConfig{
+		InterfaceName: "pod1-abcd",
+		Type:          interfacestore.ContainerInterface,
+		IPs:           []net.IP{{10, 10, 0, 2}},
+		ContainerInterfaceConfig: &interfacestore.ContainerInterfaceConfig{
+			PodName:      "pod1",
+			PodNamespace: "ns1",
+			DHCP:         true,
+		},
+		CreationTimestamp: refTime.Add(-60 * time.Second),
+		IPUpdateTimestamp: refTime.Add(-10 * time.Second),
+	}
+	tuple := flowexporter.Tuple{SourceAddress: net.IP{10, 10, 1, 2}, DestinationAddress: net.IP{10, 10, 0, 2}, Protocol: 6, SourcePort: 65280, DestinationPort: 80}
+
+	for _, tt := range []struct {
+		startTime       time.Time
+		expectedPodName string
+	}{
+		{startTime: refTime.Add(-20 * time.Second), expectedPodName: ""},
+		{startTime: refTime.Add(-5 * time.Second), expectedPodName: "pod1"},
+	} {
+		mockIfaceStore := interfacestoretest.NewMockInterfaceStore(ctrl)
+		connStore := NewConnectionStore(mockIfaceStore, nil)
+		mockIfaceStore.EXPECT().GetInterfaceByIP(tuple.SourceAddress.String()).Return(nil, false)
+		mockIfaceStore.EXPECT().GetInterfaceByIP(tuple.DestinationAddress.String()).Return(podInterface, true)
+		conn := &flowexporter.Connection{
+			StartTime: tt.startTime,
+			FlowKey:   tuple,
+		}
+		connStore.fillPodInfo(conn)
+		assert.Equal(t, tt.expectedPodName, conn.DestinationPodName)
+	}
+}
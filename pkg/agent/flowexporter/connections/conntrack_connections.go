@@ -32,6 +32,12 @@ import (
 	"antrea.io/antrea/pkg/querier"
 )
 
+const (
+	// defaultPodTombstoneGracePeriod is the minimum duration during which the connections of a
+	// deleted local Pod are still attributed to it, before their final records are exported.
+	defaultPodTombstoneGracePeriod = 30 * time.Second
+)
+
 var serviceProtocolMap = map[uint8]corev1.Protocol{
 	6:   corev1.ProtocolTCP,
 	17:  corev1.ProtocolUDP,
@@ -44,7 +50,15 @@ type ConntrackConnectionStore struct {
 	v4Enabled            bool
 	v6Enabled            bool
 	networkPolicyQuerier querier.AgentNetworkPolicyInfoQuerier
-	pollInterval         time.Duration
+	// pollInterval and podTombstoneGracePeriod are protected by mutex, as they can be changed
+	// by SetPollInterval.
+	pollInterval time.Duration
+	// podTombstoneGracePeriod is the duration during which the tombstone of a deleted local Pod
+	// is kept. It spans at least two poll cycles, so that the connections are polled once more
+	// after the deletion of the Pod.
+	podTombstoneGracePeriod time.Duration
+	// pollIntervalCh notifies Run that the poll interval has changed.
+	pollIntervalCh chan struct{}
 	connectionStore
 }
 
@@ -58,28 +72,65 @@ func NewConntrackConnectionStore(
 	npQuerier querier.AgentNetworkPolicyInfoQuerier,
 	pollInterval time.Duration,
 ) *ConntrackConnectionStore {
-	return &ConntrackConnectionStore{
-		flowRecords:          flowRecords,
-		connDumper:           connTrackDumper,
-		v4Enabled:            v4Enabled,
-		v6Enabled:            v6Enabled,
-		networkPolicyQuerier: npQuerier,
-		pollInterval:         pollInterval,
-		connectionStore:      NewConnectionStore(ifaceStore, proxier),
+	cs := &ConntrackConnectionStore{
+		flowRecords:             flowRecords,
+		connDumper:              connTrackDumper,
+		v4Enabled:               v4Enabled,
+		v6Enabled:               v6Enabled,
+		networkPolicyQuerier:    npQuerier,
+		pollInterval:            pollInterval,
+		podTombstoneGracePeriod: podTombstoneGracePeriodFor(pollInterval),
+		pollIntervalCh:          make(chan struct{}, 1),
+		connectionStore:         NewConnectionStore(ifaceStore, proxier),
 	}
+	if ifaceStore != nil {
+		ifaceStore.AddDeleteHandler(cs.onInterfaceDelete)
+	}
+	return cs
+}
+
+// podTombstoneGracePeriodFor returns the grace period of the Pod tombstones for pollInterval.
+func podTombstoneGracePeriodFor(pollInterval time.Duration) time.Duration {
+	if 2*pollInterval > defaultPodTombstoneGracePeriod {
+		return 2 * pollInterval
+	}
+	return defaultPodTombstoneGracePeriod
+}
+
+// SetPollInterval changes the interval at which the conntrack connections are polled, e.g. when the
+// agent configuration is reloaded. It takes effect from the next poll cycle.
+func (cs *ConntrackConnectionStore) SetPollInterval(pollInterval time.Duration) {
+	cs.mutex.Lock()
+	cs.pollInterval = pollInterval
+	cs.podTombstoneGracePeriod = podTombstoneGracePeriodFor(pollInterval)
+	cs.mutex.Unlock()
+	select {
+	case cs.pollIntervalCh <- struct{}{}:
+	default:
+	}
+}
+
+func (cs *ConntrackConnectionStore) getPollInterval() time.Duration {
+	cs.mutex.Lock()
+	defer cs.mutex.Unlock()
+	return cs.pollInterval
 }
 
 // Run enables the periodical polling of conntrack connections at a given flowPollInterval.
 func (cs *ConntrackConnectionStore) Run(stopCh <-chan struct{}) {
 	klog.Infof("Starting conntrack polling")
 
-	pollTicker := time.NewTicker(cs.pollInterval)
+	pollTicker := time.NewTicker(cs.getPollInterval())
 	defer pollTicker.Stop()
 
 	for {
 		select {
 		case <-stopCh:
-			break
+			return
+		case <-cs.pollIntervalCh:
+			pollInterval := cs.getPollInterval()
+			pollTicker.Reset(pollInterval)
+			klog.Infof("Changed conntrack poll interval to %v", pollInterval)
 		case <-pollTicker.C:
 			_, err := cs.Poll()
 			if err != nil {
@@ -144,6 +195,7 @@ func (cs *ConntrackConnectionStore) Poll() ([]int, error) {
 		return []int{}, err
 	}
 	metrics.MaxConnectionsInConnTrackTable.Set(float64(maxConns))
+	cs.expirePodTombstones(time.Now())
 	klog.V(2).Infof("Conntrack polling successful")
 
 	return connsLens, nil
@@ -189,6 +241,38 @@ func (cs *ConntrackConnectionStore) addNetworkPolicyMetadata(conn *flowexporter.
 	}
 }
 
+// expirePodTombstones deletes the tombstones of the local Pods deleted for longer than the grace
+// period, and marks the connections which are still attributed to these Pods as PodDeleted, so
+// that their final records are exported.
+func (cs *ConntrackConnectionStore) expirePodTombstones(now time.Time) {
+	cs.mutex.Lock()
+	defer cs.mutex.Unlock()
+	expired := make(map[string]*podTombstone)
+	for ip, tombstone := range cs.podTombstones {
+		if now.Sub(tombstone.deletionTime) >= cs.podTombstoneGracePeriod {
+			expired[ip] = tombstone
+			delete(cs.podTombstones, ip)
+		}
+	}
+	if len(expired) == 0 {
+		return
+	}
+	belongsToPod := func(conn *flowexporter.Connection, ip, podName, podNamespace string) bool {
+		tombstone, ok := expired[ip]
+		return ok && tombstone.podName == podName && tombstone.podNamespace == podNamespace && conn.StartTime.Before(tombstone.deletionTime)
+	}
+	for _, conn := range cs.connections {
+		if conn.PodDeleted {
+			continue
+		}
+		if belongsToPod(conn, conn.FlowKey.SourceAddress.String(), conn.SourcePodName, conn.SourcePodNamespace) ||
+			belongsToPod(conn, conn.FlowKey.DestinationAddress.String(), conn.DestinationPodName, conn.DestinationPodNamespace) {
+			klog.V(2).Infof("Pod of connection %v has been deleted, exporting its final record", conn.FlowKey)
+			conn.PodDeleted = true
+		}
+	}
+}
+
 // AddOrUpdateConn updates the connection if it is already present, i.e., update timestamp, counters etc.,
 // or adds a new connection with the resolved K8s metadata.
 func (cs *ConntrackConnectionStore) AddOrUpdateConn(conn *flowexporter.Connection) {
@@ -211,6 +295,7 @@ func (cs *ConntrackConnectionStore) AddOrUpdateConn(conn *flowexporter.Connectio
 		existingConn.ReverseBytes = conn.ReverseBytes
 		existingConn.ReversePackets = conn.ReversePackets
 		existingConn.TCPState = conn.TCPState
+		existingConn.SCTPState = conn.SCTPState
 		klog.V(4).Infof("Antrea flow updated: %v", existingConn)
 	} else {
 		cs.fillPodInfo(conn)
@@ -200,6 +200,7 @@ func TestConntrackConnectionStore_AddOrUpdateConn(t *testing.T) {
 	mockProxier := proxytest.NewMockProxier(ctrl)
 	mockConnDumper := connectionstest.NewMockConnTrackDumper(ctrl)
 	npQuerier := queriertest.NewMockAgentNetworkPolicyInfoQuerier(ctrl)
+	mockIfaceStore.EXPECT().AddDeleteHandler(gomock.Any())
 	conntrackConnStore := NewConntrackConnectionStore(mockConnDumper, flowrecords.NewFlowRecords(), mockIfaceStore, true, false, mockProxier, npQuerier, testPollInterval)
 
 	// Add flow1conn and flow3conn to the Connection map
@@ -320,6 +321,7 @@ func TestConnectionStore_DeleteConnectionByKey(t *testing.T) {
 	metrics.TotalAntreaConnectionsInConnTrackTable.Set(float64(len(testFlows)))
 	// Create connectionStore
 	mockIfaceStore := interfacestoretest.NewMockInterfaceStore(ctrl)
+	mockIfaceStore.EXPECT().AddDeleteHandler(gomock.Any())
 	connStore := NewConntrackConnectionStore(nil, flowrecords.NewFlowRecords(), mockIfaceStore, true, false, nil, nil, testPollInterval)
 	// Add flows to the connection store.
 	for i, flow := range testFlows {
@@ -344,6 +346,7 @@ func TestConnectionStore_MetricSettingInPoll(t *testing.T) {
 	// Create connectionStore
 	mockIfaceStore := interfacestoretest.NewMockInterfaceStore(ctrl)
 	mockConnDumper := connectionstest.NewMockConnTrackDumper(ctrl)
+	mockIfaceStore.EXPECT().AddDeleteHandler(gomock.Any())
 	conntrackConnStore := NewConntrackConnectionStore(mockConnDumper, flowrecords.NewFlowRecords(), mockIfaceStore, true, false, nil, nil, testPollInterval)
 	// Hard-coded conntrack occupancy metrics for test
 	TotalConnections := 0
@@ -358,6 +361,73 @@ func TestConnectionStore_MetricSettingInPoll(t *testing.T) {
 	checkMaxConnectionsMetric(t, MaxConnections)
 }
 
+func TestConntrackConnectionStore_PodTombstone(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	metrics.InitializeConnectionMetrics()
+	refTime := time.Now()
+	podIP := net.IP{10, 10, 0, 2}
+	peerIP := net.IP{10, 10, 1, 2}
+	ifaceStore := interfacestore.NewInterfaceStore()
+	oldInterface := interfacestore.NewContainerInterface("pod1-abcd", "container1", "pod1", "ns1", nil, []net.IP{podIP})
+	oldInterface.CreationTimestamp = refTime.Add(-60 * time.Second)
+	ifaceStore.AddInterface(oldInterface)
+	mockConnDumper := connectionstest.NewMockConnTrackDumper(ctrl)
+	conntrackConnStore := NewConntrackConnectionStore(mockConnDumper, flowrecords.NewFlowRecords(), ifaceStore, true, false, nil, nil, testPollInterval)
+
+	newConn := func(startTime time.Time, srcPort uint16) *flowexporter.Connection {
+		return &flowexporter.Connection{
+			StartTime: startTime,
+			StopTime:  startTime,
+			FlowKey:   flowexporter.Tuple{SourceAddress: podIP, DestinationAddress: peerIP, Protocol: 6, SourcePort: srcPort, DestinationPort: 80},
+			IsPresent: true,
+		}
+	}
+	getConn := func(conn *flowexporter.Connection) *flowexporter.Connection {
+		actualConn, ok := conntrackConnStore.GetConnByKey(flowexporter.NewConnectionKey(conn))
+		require.True(t, ok)
+		return actualConn
+	}
+
+	// Connection of the Pod before its deletion.
+	conn1 := newConn(refTime.Add(-30*time.Second), 60001)
+	conntrackConnStore.AddOrUpdateConn(conn1)
+	assert.Equal(t, "pod1", getConn(conn1).SourcePodName)
+
+	// The Pod is deleted and its IP is reused right away by a new Pod.
+	ifaceStore.DeleteInterface(oldInterface)
+	newInterface := interfacestore.NewContainerInterface("pod2-abcd", "container2", "pod2", "ns1", nil, []net.IP{podIP})
+	newInterface.CreationTimestamp = time.Now()
+	ifaceStore.AddInterface(newInterface)
+
+	// Connection of the deleted Pod which is polled for the first time after the deletion, and
+	// connection of the new Pod.
+	conn2 := newConn(refTime.Add(-20*time.Second), 60002)
+	conntrackConnStore.AddOrUpdateConn(conn2)
+	conn3 := newConn(newInterface.CreationTimestamp.Add(time.Second), 60003)
+	conntrackConnStore.AddOrUpdateConn(conn3)
+	assert.Equal(t, "pod1", getConn(conn2).SourcePodName)
+	assert.Equal(t, "ns1", getConn(conn2).SourcePodNamespace)
+	assert.Equal(t, "pod2", getConn(conn3).SourcePodName)
+	assert.Equal(t, "ns1", getConn(conn3).SourcePodNamespace)
+
+	// The connections are still attributed to the deleted Pod during the grace period.
+	conntrackConnStore.expirePodTombstones(time.Now())
+	assert.Len(t, conntrackConnStore.podTombstones, 1)
+	assert.False(t, getConn(conn1).PodDeleted)
+	assert.False(t, getConn(conn2).PodDeleted)
+	assert.False(t, getConn(conn3).PodDeleted)
+
+	// The connections of the deleted Pod are marked for their final export once the grace
+	// period is over.
+	conntrackConnStore.expirePodTombstones(time.Now().Add(defaultPodTombstoneGracePeriod))
+	assert.Empty(t, conntrackConnStore.podTombstones)
+	assert.True(t, getConn(conn1).PodDeleted)
+	assert.True(t, getConn(conn2).PodDeleted)
+	assert.False(t, getConn(conn3).PodDeleted)
+	assert.True(t, flowexporter.IsConnectionDying(getConn(conn1)))
+}
+
 func checkAntreaConnectionMetrics(t *testing.T, numConns int) {
 	expectedAntreaConnectionCount := `
 	# HELP antrea_agent_conntrack_antrea_connection_count [ALPHA] Number of connections in the Antrea ZoneID of the conntrack table. This metric gets updated at an interval specified by flowPollInterval, a configuration parameter for the Agent.
@@ -387,3 +457,18 @@ func checkMaxConnectionsMetric(t *testing.T, maxConns int) {
 	err := testutil.GatherAndCompare(legacyregistry.DefaultGatherer, strings.NewReader(expectedMaxConnectionsCount), "antrea_agent_conntrack_max_connection_count")
 	assert.NoError(t, err)
 }
+
+func TestConntrackConnectionStore_SetPollInterval(t *testing.T) {
+	conntrackConnStore := NewConntrackConnectionStore(nil, flowrecords.NewFlowRecords(), nil, true, false, nil, nil, 5*time.Second)
+	assert.Equal(t, defaultPodTombstoneGracePeriod, conntrackConnStore.podTombstoneGracePeriod)
+
+	// The grace period of the Pod tombstones still spans two poll cycles with a longer poll interval.
+	conntrackConnStore.SetPollInterval(time.Minute)
+	assert.Equal(t, time.Minute, conntrackConnStore.getPollInterval())
+	assert.Equal(t, 2*time.Minute, conntrackConnStore.podTombstoneGracePeriod)
+	// Successive changes don't block while Run is not running, and Run is notified once.
+	conntrackConnStore.SetPollInterval(time.Second)
+	assert.Equal(t, time.Second, conntrackConnStore.getPollInterval())
+	assert.Equal(t, defaultPodTombstoneGracePeriod, conntrackConnStore.podTombstoneGracePeriod)
+	assert.Len(t, conntrackConnStore.pollIntervalCh, 1)
+}
@@ -151,10 +151,14 @@ func NetlinkFlowToAntreaConnection(conn *conntrack.Flow) *flowexporter.Connectio
 		DestinationPodNamespace:   "",
 		DestinationPodName:        "",
 		TCPState:                  "",
+		SCTPState:                 "",
 	}
 	if conn.ProtoInfo.TCP != nil {
 		newConn.TCPState = stateToString(conn.ProtoInfo.TCP.State)
 	}
+	if conn.ProtoInfo.SCTP != nil {
+		newConn.SCTPState = sctpStateToString(conn.ProtoInfo.SCTP.State)
+	}
 
 	// Get the stop time from dumped connection if the connection is terminated(dying state).
 	if conn.Status.Dying() {
@@ -205,3 +209,23 @@ func stateToString(state uint8) string {
 		return stateList[state]
 	}
 }
+
+// reference: https://github.com/torvalds/linux/blob/v5.9/include/uapi/linux/netfilter/nf_conntrack_sctp.h
+func sctpStateToString(state uint8) string {
+	stateList := []string{
+		"NONE",
+		"CLOSED",
+		"COOKIE_WAIT",
+		"COOKIE_ECHOED",
+		"ESTABLISHED",
+		"SHUTDOWN_SENT",
+		"SHUTDOWN_RECD",
+		"SHUTDOWN_ACK_SENT",
+		"HEARTBEAT_SENT",
+		"HEARTBEAT_ACKED",
+	}
+	if int(state) >= len(stateList) { // invalid state number
+		return ""
+	}
+	return stateList[state]
+}
@@ -281,4 +281,31 @@ func TestNetLinkFlowToAntreaConnection(t *testing.T) {
 
 	antreaFlow = NetlinkFlowToAntreaConnection(netlinkFlow)
 	assert.Equalf(t, expectedAntreaFlow, antreaFlow, "both flows should be equal")
+
+	// Create new conntrack flow of an SCTP association.
+	netlinkFlow = &conntrack.Flow{
+		TupleOrig: conntrackFlowTuple, TupleReply: conntrackFlowTuple, TupleMaster: conntrackFlowTuple,
+		Timeout: 123, Status: conntrack.Status{Value: conntrack.StatusAssured}, Mark: 0x1234, Zone: 2,
+		Timestamp: conntrack.Timestamp{Start: time.Date(2020, 7, 25, 8, 40, 8, 959000000, time.UTC)},
+		ProtoInfo: conntrack.ProtoInfo{SCTP: &conntrack.ProtoInfoSCTP{State: 4}},
+	}
+	antreaFlow = NetlinkFlowToAntreaConnection(netlinkFlow)
+	assert.Equal(t, "ESTABLISHED", antreaFlow.SCTPState)
+	assert.Equal(t, "", antreaFlow.TCPState)
+}
+
+func TestFlowStringToAntreaConnectionSCTP(t *testing.T) {
+	flow := "sctp,orig=(src=10.10.1.2,dst=10.96.0.20,sport=5000,dport=36412,packets=12,bytes=1400),reply=(src=10.10.1.3,dst=10.10.1.2,sport=36412,dport=5000,packets=10,bytes=1100),start=2021-06-01T10:00:00.000,id=1234,zone=65520,status=SEEN_REPLY|ASSURED|CONFIRMED|DST_NAT|DST_NAT_DONE,timeout=431999,mark=33,protoinfo=(state=ESTABLISHED,vtag_orig=3255114587,vtag_reply=1520357810)"
+	conn, err := flowStringToAntreaConnection(flow, uint16(openflow.CtZone))
+	require.NoError(t, err)
+	require.NotNil(t, conn)
+	assert.Equal(t, uint8(132), conn.FlowKey.Protocol)
+	assert.Equal(t, net.ParseIP("10.10.1.2"), conn.FlowKey.SourceAddress)
+	assert.Equal(t, net.ParseIP("10.10.1.3"), conn.FlowKey.DestinationAddress)
+	assert.Equal(t, uint16(5000), conn.FlowKey.SourcePort)
+	assert.Equal(t, uint16(36412), conn.FlowKey.DestinationPort)
+	assert.Equal(t, net.ParseIP("10.96.0.20"), conn.DestinationServiceAddress)
+	assert.Equal(t, uint16(36412), conn.DestinationServicePort)
+	assert.Equal(t, "ESTABLISHED", conn.SCTPState)
+	assert.Equal(t, "", conn.TCPState)
 }
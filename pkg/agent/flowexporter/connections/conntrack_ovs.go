@@ -38,6 +38,7 @@ var (
 		"tcp":       6,
 		"udp":       17,
 		"ipv6-icmp": 58,
+		"sctp":      132,
 	}
 	// Mapping is defined at https://github.com/torvalds/linux/blob/v5.9/include/uapi/linux/netfilter/nf_conntrack_common.h#L42
 	conntrackStatusMap = map[string]uint32{
@@ -267,10 +268,14 @@ func flowStringToAntreaConnection(flow string, zoneFilter uint16) (*flowexporter
 			conn.ID = uint32(val)
 		case strings.Contains(fs, "protoinfo"):
 			fields := strings.Split(fs, "(")
-			// retrieve tcpState from state or state_orig
+			// retrieve tcpState or sctpState from state or state_orig
 			if strings.Contains(fields[1], "state") {
 				items := strings.Split(fields[1], "=")
-				conn.TCPState = items[1]
+				if conn.FlowKey.Protocol == protocols["sctp"] {
+					conn.SCTPState = items[1]
+				} else {
+					conn.TCPState = items[1]
+				}
 			}
 		}
 	}
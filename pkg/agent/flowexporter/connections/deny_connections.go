@@ -16,6 +16,7 @@ package connections
 
 import (
 	"fmt"
+	"sort"
 	"time"
 
 	"k8s.io/klog/v2"
@@ -93,3 +94,27 @@ func (ds *DenyConnectionStore) DeleteConnWithoutLock(connKey flowexporter.Connec
 	metrics.TotalDenyConnections.Dec()
 	return nil
 }
+
+// EvictOldest deletes the given fraction of the deny connections, starting with the connections
+// which were exported the longest time ago, to reduce the memory usage when the connections cannot
+// be exported. It returns the number of deleted connections.
+func (ds *DenyConnectionStore) EvictOldest(fraction float64) int {
+	ds.mutex.Lock()
+	defer ds.mutex.Unlock()
+	n := int(float64(len(ds.connections)) * fraction)
+	if n == 0 {
+		return 0
+	}
+	keys := make([]flowexporter.ConnectionKey, 0, len(ds.connections))
+	for key := range ds.connections {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return ds.connections[keys[i]].LastExportTime.Before(ds.connections[keys[j]].LastExportTime)
+	})
+	for _, key := range keys[:n] {
+		delete(ds.connections, key)
+	}
+	metrics.TotalDenyConnections.Sub(float64(n))
+	return n
+}
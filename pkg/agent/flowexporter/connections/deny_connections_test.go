@@ -135,3 +135,22 @@ func checkDenyConnectionMetrics(t *testing.T, numConns int) {
 	err := testutil.GatherAndCompare(legacyregistry.DefaultGatherer, strings.NewReader(expectedDenyConnectionCount), "antrea_agent_denied_connection_count")
 	assert.NoError(t, err)
 }
+
+func TestDenyConnectionStore_EvictOldest(t *testing.T) {
+	refTime := time.Now()
+	denyConnStore := NewDenyConnectionStore(nil, nil)
+	for i := 0; i < 4; i++ {
+		conn := &flowexporter.Connection{
+			FlowKey:        flowexporter.Tuple{SourceAddress: net.IP{1, 2, 3, 4}, DestinationAddress: net.IP{4, 3, 2, 1}, Protocol: 6, SourcePort: uint16(65280 + i), DestinationPort: 255},
+			LastExportTime: refTime.Add(time.Duration(i) * time.Second),
+		}
+		denyConnStore.connections[flowexporter.NewConnectionKey(conn)] = conn
+	}
+
+	assert.Equal(t, 2, denyConnStore.EvictOldest(0.5))
+	assert.Len(t, denyConnStore.connections, 2)
+	for _, conn := range denyConnStore.connections {
+		assert.False(t, conn.LastExportTime.Before(refTime.Add(2*time.Second)), "the oldest connections should be evicted")
+	}
+	assert.Equal(t, 0, denyConnStore.EvictOldest(0.25))
+}
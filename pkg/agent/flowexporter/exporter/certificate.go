@@ -17,6 +17,7 @@ package exporter
 import (
 	"context"
 	"fmt"
+	"io/ioutil"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
@@ -52,3 +53,24 @@ func getClientCertKey(k8sClient kubernetes.Interface) ([]byte, []byte, error) {
 	}
 	return clientSecret.Data["tls.crt"], clientSecret.Data["tls.key"], nil
 }
+
+// readCredentials reads the CA certificate, and the client certificate and key if their paths are
+// not empty, used to connect to a collector with TLS.
+func readCredentials(caCertPath, clientCertPath, clientKeyPath string) ([]byte, []byte, []byte, error) {
+	caCert, err := ioutil.ReadFile(caCertPath)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("error reading CA certificate: %v", err)
+	}
+	if clientCertPath == "" {
+		return caCert, nil, nil, nil
+	}
+	clientCert, err := ioutil.ReadFile(clientCertPath)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("error reading client certificate: %v", err)
+	}
+	clientKey, err := ioutil.ReadFile(clientKeyPath)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("error reading client key: %v", err)
+	}
+	return caCert, clientCert, clientKey, nil
+}
@@ -0,0 +1,190 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	ipfixentities "github.com/vmware/go-ipfix/pkg/entities"
+	"github.com/vmware/go-ipfix/pkg/exporter"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+
+	"antrea.io/antrea/pkg/agent/flowexporter"
+	"antrea.io/antrea/pkg/ipfix"
+	"antrea.io/antrea/pkg/querier"
+)
+
+const (
+	connectionFailedReason = "ConnectionFailed"
+	exportFailedReason     = "ExportFailed"
+)
+
+// CollectorConfig is the configuration of an IPFIX collector the flow records are exported to.
+type CollectorConfig struct {
+	// Name identifies the collector in the logs and in the status of the flow exporter.
+	Name string
+	// Address of the collector, as "<HOST>:<PORT>".
+	Address string
+	// Protocol is "tcp", "udp" or "tls".
+	Protocol string
+	// Paths of the CA certificate, client certificate and client key used with the "tls" protocol.
+	// The credentials of the Flow Aggregator are used if CACertPath is empty.
+	CACertPath     string
+	ClientCertPath string
+	ClientKeyPath  string
+	// TemplateRefreshInterval is the interval at which the templates are sent again with the "udp"
+	// protocol. The default of the exporting process is used if it is 0.
+	TemplateRefreshInterval time.Duration
+	Filter                  RecordFilter
+}
+
+// RecordFilter selects the flow records exported to a collector. The zero value selects all the
+// flow records.
+type RecordFilter struct {
+	// FlowTypes are the types of the flows whose records are exported, all of them if empty.
+	FlowTypes []uint8
+	// Namespaces are the Namespaces of the Pods whose flow records are exported: a record is
+	// exported if its source or destination Pod is in one of them. All the records are exported if
+	// it is empty.
+	Namespaces sets.String
+	// ExcludeDenyConnections excludes the records of the connections denied by NetworkPolicies.
+	ExcludeDenyConnections bool
+}
+
+func (f *RecordFilter) matches(conn *flowexporter.Connection, flowType uint8, isDenyConn bool) bool {
+	if isDenyConn && f.ExcludeDenyConnections {
+		return false
+	}
+	if len(f.FlowTypes) > 0 {
+		found := false
+		for _, t := range f.FlowTypes {
+			if t == flowType {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(f.Namespaces) > 0 && !f.Namespaces.Has(conn.SourcePodNamespace) && !f.Namespaces.Has(conn.DestinationPodNamespace) {
+		return false
+	}
+	return true
+}
+
+// collectorExporter exports the flow records to a single IPFIX collector. Each collector has its own
+// exporting process, templates and status, so that a collector which is down does not affect the
+// export to the others. It is only used by the goroutine of the flow exporter, except its status.
+type collectorExporter struct {
+	config        CollectorConfig
+	exporterInput exporter.ExporterInput
+	// process is nil until the connection to the collector is established, and after a failure.
+	process      ipfix.IPFIXExportingProcess
+	ipfixSet     ipfixentities.Set
+	templateIDv4 uint16
+	templateIDv6 uint16
+	// statusMutex protects status and failureReason, as the status is read by the agent API.
+	statusMutex   sync.RWMutex
+	status        querier.FlowCollectorStatus
+	failureReason string
+}
+
+func newCollectorExporter(config CollectorConfig, nodeName string) *collectorExporter {
+	return &collectorExporter{
+		config:        config,
+		exporterInput: prepareExporterInputArgs(config.Address, config.Protocol, nodeName),
+		ipfixSet:      ipfixentities.NewSet(false),
+		status: querier.FlowCollectorStatus{
+			Name:     config.Name,
+			Address:  config.Address,
+			Protocol: config.Protocol,
+		},
+	}
+}
+
+// sendDataSet sends a data record made of the given elements to the collector.
+func (c *collectorExporter) sendDataSet(elements []*ipfixentities.InfoElementWithValue, isIPv6 bool) error {
+	templateID := c.templateIDv4
+	if isIPv6 {
+		templateID = c.templateIDv6
+	}
+	c.ipfixSet.ResetSet()
+	if err := c.ipfixSet.PrepareSet(ipfixentities.Data, templateID); err != nil {
+		return err
+	}
+	// TODO: more records per data set will be supported when go-ipfix supports size check when adding records
+	if err := c.ipfixSet.AddRecord(elements, templateID); err != nil {
+		return fmt.Errorf("error in adding record to data set: %v", err)
+	}
+	sentBytes, err := c.process.SendSet(c.ipfixSet)
+	if err != nil {
+		return fmt.Errorf("error when sending data set: %v", err)
+	}
+	klog.V(4).InfoS("Data set sent successfully", "collector", c.config.Name, "bytes", sentBytes)
+	return nil
+}
+
+func (c *collectorExporter) setConnected() {
+	c.statusMutex.Lock()
+	defer c.statusMutex.Unlock()
+	c.status.Connected = true
+	c.status.LastError = ""
+	c.failureReason = ""
+}
+
+// fail records a failure of the connection to the collector or of a send, and closes the
+// connection, which is re-established in the next export cycle.
+func (c *collectorExporter) fail(reason string, err error) {
+	c.close()
+	c.statusMutex.Lock()
+	defer c.statusMutex.Unlock()
+	c.status.Failures++
+	c.status.LastError = err.Error()
+	c.failureReason = reason
+}
+
+// close closes the connection to the collector, if it is established.
+func (c *collectorExporter) close() {
+	if c.process != nil {
+		c.process.CloseConnToCollector()
+		c.process = nil
+	}
+	c.statusMutex.Lock()
+	defer c.statusMutex.Unlock()
+	c.status.Connected = false
+}
+
+func (c *collectorExporter) recordSent() {
+	c.statusMutex.Lock()
+	defer c.statusMutex.Unlock()
+	c.status.RecordsSent++
+	c.status.LastExportTime = time.Now()
+}
+
+func (c *collectorExporter) recordFiltered() {
+	c.statusMutex.Lock()
+	defer c.statusMutex.Unlock()
+	c.status.RecordsFiltered++
+}
+
+func (c *collectorExporter) getStatus() (querier.FlowCollectorStatus, string) {
+	c.statusMutex.RLock()
+	defer c.statusMutex.RUnlock()
+	return c.status, c.failureReason
+}
@@ -18,11 +18,14 @@ import (
 	"fmt"
 	"hash/fnv"
 	"net"
+	"strings"
+	"sync"
 	"time"
 
 	ipfixentities "github.com/vmware/go-ipfix/pkg/entities"
 	"github.com/vmware/go-ipfix/pkg/exporter"
 	ipfixregistry "github.com/vmware/go-ipfix/pkg/registry"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/klog/v2"
@@ -32,7 +35,11 @@ import (
 	"antrea.io/antrea/pkg/agent/flowexporter/connections"
 	"antrea.io/antrea/pkg/agent/flowexporter/flowrecords"
 	"antrea.io/antrea/pkg/agent/openflow"
+	"antrea.io/antrea/pkg/agent/otelexporter"
+	crdv1beta1 "antrea.io/antrea/pkg/apis/crd/v1beta1"
 	"antrea.io/antrea/pkg/ipfix"
+	"antrea.io/antrea/pkg/monitor/condition"
+	"antrea.io/antrea/pkg/querier"
 	"antrea.io/antrea/pkg/util/env"
 )
 
@@ -84,27 +91,40 @@ var (
 	AntreaInfoElementsIPv6 = append(antreaInfoElementsCommon, []string{"destinationClusterIPv6"}...)
 )
 
+// memoryPressureEvictionFraction is the fraction of the flow records and deny connections dropped at
+// every check of the memory guard while the memory usage of the agent is above the watermark.
+const memoryPressureEvictionFraction = 0.25
+
+// FlowRecordExporter exports the flow records instead of the IPFIX exporting process, e.g. to an
+// OpenTelemetry collector. ExportFlowRecord must not block.
+type FlowRecordExporter interface {
+	ExportFlowRecord(record *otelexporter.FlowRecord)
+}
+
 type flowExporter struct {
-	conntrackConnStore  *connections.ConntrackConnectionStore
-	flowRecords         *flowrecords.FlowRecords
-	denyConnStore       *connections.DenyConnectionStore
-	process             ipfix.IPFIXExportingProcess
-	elementsListv4      []*ipfixentities.InfoElementWithValue
-	elementsListv6      []*ipfixentities.InfoElementWithValue
-	ipfixSet            ipfixentities.Set
-	numDataSetsSent     uint64 // used for unit tests.
-	templateIDv4        uint16
-	templateIDv6        uint16
-	registry            ipfix.IPFIXRegistry
-	v4Enabled           bool
-	v6Enabled           bool
-	exporterInput       exporter.ExporterInput
+	conntrackConnStore *connections.ConntrackConnectionStore
+	flowRecords        *flowrecords.FlowRecords
+	denyConnStore      *connections.DenyConnectionStore
+	// collectors are the export pipelines of the IPFIX collectors. The elements of a flow record
+	// are built once in elementsListv4 or elementsListv6, and sent to all the collectors.
+	collectors      []*collectorExporter
+	elementsListv4  []*ipfixentities.InfoElementWithValue
+	elementsListv6  []*ipfixentities.InfoElementWithValue
+	numDataSetsSent uint64 // used for unit tests.
+	registry        ipfix.IPFIXRegistry
+	v4Enabled       bool
+	v6Enabled       bool
+	// timeoutsMutex protects activeFlowTimeout and idleFlowTimeout, as they can be changed by
+	// SetFlowTimeouts.
+	timeoutsMutex       sync.RWMutex
 	activeFlowTimeout   time.Duration
 	idleFlowTimeout     time.Duration
 	k8sClient           kubernetes.Interface
 	nodeRouteController *noderoute.Controller
 	isNetworkPolicyOnly bool
 	nodeName            string
+	// recordExporter exports the flow records instead of the IPFIX exporting process if it's not nil.
+	recordExporter FlowRecordExporter
 }
 
 func genObservationID(nodeName string) uint32 {
@@ -131,172 +151,303 @@ func prepareExporterInputArgs(collectorAddr, collectorProto, nodeName string) ex
 	return expInput
 }
 
+// NewFlowExporter creates a flow exporter which exports the flow records to all the given IPFIX
+// collectors, or with recordExporter if it is not nil.
 func NewFlowExporter(connStore *connections.ConntrackConnectionStore, records *flowrecords.FlowRecords, denyConnStore *connections.DenyConnectionStore,
-	collectorAddr string, collectorProto string, activeFlowTimeout time.Duration, idleFlowTimeout time.Duration,
+	collectorConfigs []CollectorConfig, activeFlowTimeout time.Duration, idleFlowTimeout time.Duration,
 	v4Enabled bool, v6Enabled bool, k8sClient kubernetes.Interface,
-	nodeRouteController *noderoute.Controller, isNetworkPolicyOnly bool) (*flowExporter, error) {
+	nodeRouteController *noderoute.Controller, isNetworkPolicyOnly bool, recordExporter FlowRecordExporter) (*flowExporter, error) {
 	// Initialize IPFIX registry
 	registry := ipfix.NewIPFIXRegistry()
 	registry.LoadRegistry()
 
-	// Prepare input args for IPFIX exporting process.
 	nodeName, err := env.GetNodeName()
 	if err != nil {
 		return nil, err
 	}
-	expInput := prepareExporterInputArgs(collectorAddr, collectorProto, nodeName)
+	// Prepare the export pipelines of the IPFIX collectors, unless the flow records are exported
+	// by recordExporter.
+	var collectors []*collectorExporter
+	if recordExporter == nil {
+		for _, config := range collectorConfigs {
+			collectors = append(collectors, newCollectorExporter(config, nodeName))
+		}
+	}
 
 	return &flowExporter{
 		conntrackConnStore:  connStore,
 		flowRecords:         records,
 		denyConnStore:       denyConnStore,
+		collectors:          collectors,
 		registry:            registry,
 		v4Enabled:           v4Enabled,
 		v6Enabled:           v6Enabled,
-		exporterInput:       expInput,
 		activeFlowTimeout:   activeFlowTimeout,
 		idleFlowTimeout:     idleFlowTimeout,
-		ipfixSet:            ipfixentities.NewSet(false),
 		k8sClient:           k8sClient,
 		nodeRouteController: nodeRouteController,
 		isNetworkPolicyOnly: isNetworkPolicyOnly,
 		nodeName:            nodeName,
+		recordExporter:      recordExporter,
 	}, nil
 }
 
 // Run calls Export function periodically to check if flow records need to be exported
-// based on active flow and idle flow timeouts.
+// based on active flow and idle flow timeouts. When stopCh is closed, the records of all
+// active connections are exported one last time before the connections to the collectors are
+// closed.
 func (exp *flowExporter) Run(stopCh <-chan struct{}) {
-	go wait.Until(exp.Export, time.Second, stopCh)
+	wait.Until(exp.Export, time.Second, stopCh)
+	exp.shutdown()
+}
+
+// SetFlowTimeouts changes the active flow and idle flow timeouts of the flow records, e.g. when the
+// agent configuration is reloaded. They are applied from the next export cycle.
+func (exp *flowExporter) SetFlowTimeouts(activeFlowTimeout, idleFlowTimeout time.Duration) {
+	exp.timeoutsMutex.Lock()
+	defer exp.timeoutsMutex.Unlock()
+	exp.activeFlowTimeout = activeFlowTimeout
+	exp.idleFlowTimeout = idleFlowTimeout
+}
+
+func (exp *flowExporter) getFlowTimeouts() (time.Duration, time.Duration) {
+	exp.timeoutsMutex.RLock()
+	defer exp.timeoutsMutex.RUnlock()
+	return exp.activeFlowTimeout, exp.idleFlowTimeout
+}
+
+// shutdown sends the final flow records to the collectors and closes the connections to them,
+// so that the collectors get the latest stats of the connections which are still active
+// when the agent stops.
+func (exp *flowExporter) shutdown() {
+	if exp.numConnectedCollectors() == 0 && exp.recordExporter == nil {
+		return
+	}
+	if err := exp.sendFlowRecords(true); err != nil {
+		klog.Errorf("Error when sending final flow records: %v", err)
+	} else {
+		klog.Info("Sent final flow records to collectors")
+	}
+	for _, c := range exp.collectors {
+		c.close()
+	}
+}
+
+func (exp *flowExporter) numConnectedCollectors() int {
+	count := 0
+	for _, c := range exp.collectors {
+		if c.process != nil {
+			count++
+		}
+	}
+	return count
+}
 
-	<-stopCh
+// ReduceMemory drops the flow records and the deny connections which were exported the longest time
+// ago, e.g. because the collector is down, to reduce the memory usage of the agent. It is called by
+// the memory guard, and returns the number of dropped items.
+func (exp *flowExporter) ReduceMemory() int {
+	keys := exp.flowRecords.EvictOldest(memoryPressureEvictionFraction)
+	for _, key := range keys {
+		// The connection is deleted from the conntrack connection store once it is dying, as if
+		// its record had been exported.
+		if err := exp.conntrackConnStore.SetExportDone(key); err != nil {
+			klog.V(4).Infof("Failed to mark dropped flow record as exported: %v", err)
+		}
+	}
+	return len(keys) + exp.denyConnStore.EvictOldest(memoryPressureEvictionFraction)
 }
 
 func (exp *flowExporter) Export() {
-	// Retry to connect to IPFIX collector if the exporting process gets reset
-	if exp.process == nil {
-		err := exp.initFlowExporter()
-		if err != nil {
-			klog.Errorf("Error when initializing flow exporter: %v", err)
+	if exp.recordExporter != nil {
+		// The flow records are exported without the IPFIX exporting process, so there is no
+		// connection to the IPFIX collector to initialize.
+		if err := exp.sendFlowRecords(false); err != nil {
+			klog.Errorf("Error when sending flow records: %v", err)
+			condition.Set(crdv1beta1.FlowExporterConnected, corev1.ConditionFalse, "ExportFailed", err.Error())
+			return
+		}
+		condition.Set(crdv1beta1.FlowExporterConnected, corev1.ConditionTrue, "", "")
+		return
+	}
+	// Retry to connect to the IPFIX collectors whose exporting process gets reset.
+	for _, c := range exp.collectors {
+		if c.process != nil {
+			continue
+		}
+		if err := exp.connectCollector(c); err != nil {
+			klog.ErrorS(err, "Error when initializing flow exporter", "collector", c.config.Name)
 			// There could be other errors while initializing flow exporter other than connecting to IPFIX collector,
 			// therefore closing the connection and resetting the process.
-			if exp.process != nil {
-				exp.process.CloseConnToCollector()
-				exp.process = nil
-			}
-			return
+			c.fail(connectionFailedReason, err)
+			continue
 		}
+		c.setConnected()
 	}
-	// Send flow records to IPFIX collector.
-	err := exp.sendFlowRecords()
-	if err != nil {
+	if exp.numConnectedCollectors() == 0 {
+		exp.updateConnectedCondition()
+		return
+	}
+	// Send flow records to the IPFIX collectors. If there is an error when sending flow records to a collector
+	// because of intermittent connectivity, its connection is reset and reinitialized in the next export cycle,
+	// while the flow records are still sent to the other collectors.
+	if err := exp.sendFlowRecords(false); err != nil {
 		klog.Errorf("Error when sending flow records: %v", err)
-		// If there is an error when sending flow records because of intermittent connectivity, we reset the connection
-		// to IPFIX collector and retry in the next export cycle to reinitialize the connection and send flow records.
-		exp.process.CloseConnToCollector()
-		exp.process = nil
+	} else {
+		klog.V(2).Infof("Successfully exported IPFIX flow records")
+	}
+	exp.updateConnectedCondition()
+}
+
+// updateConnectedCondition sets the FlowExporterConnected condition, which is only true if the flow
+// records are exported to all the collectors.
+func (exp *flowExporter) updateConnectedCondition() {
+	var reason string
+	var failures []string
+	for _, c := range exp.collectors {
+		status, failureReason := c.getStatus()
+		if status.Connected {
+			continue
+		}
+		if reason == "" {
+			reason = failureReason
+		}
+		failures = append(failures, fmt.Sprintf("%s: %s", status.Name, status.LastError))
+	}
+	if len(failures) == 0 {
+		condition.Set(crdv1beta1.FlowExporterConnected, corev1.ConditionTrue, "", "")
 		return
 	}
-	klog.V(2).Infof("Successfully exported IPFIX flow records")
+	condition.Set(crdv1beta1.FlowExporterConnected, corev1.ConditionFalse, reason, strings.Join(failures, "; "))
+}
+
+// GetFlowCollectorStatuses returns the status of the export to each IPFIX collector, in the order
+// of the configuration. It is empty when the flow records are exported by the recordExporter.
+func (exp *flowExporter) GetFlowCollectorStatuses() []querier.FlowCollectorStatus {
+	statuses := make([]querier.FlowCollectorStatus, 0, len(exp.collectors))
+	for _, c := range exp.collectors {
+		status, _ := c.getStatus()
+		statuses = append(statuses, status)
+	}
+	return statuses
 }
 
-func (exp *flowExporter) initFlowExporter() error {
+// connectCollector starts the exporting process of a collector and sends the templates to it.
+func (exp *flowExporter) connectCollector(c *collectorExporter) error {
+	if err := exp.initElementsLists(); err != nil {
+		return err
+	}
 	var err error
-	if exp.exporterInput.IsEncrypted {
+	if c.exporterInput.IsEncrypted {
 		// if CA certificate, client certificate and key do not exist during initialization,
 		// it will retry to obtain the credentials in next export cycle
-		exp.exporterInput.CACert, err = getCACert(exp.k8sClient)
-		if err != nil {
-			return fmt.Errorf("cannot retrieve CA cert: %v", err)
-		}
-		exp.exporterInput.ClientCert, exp.exporterInput.ClientKey, err = getClientCertKey(exp.k8sClient)
-		if err != nil {
-			return fmt.Errorf("cannot retrieve client cert and key: %v", err)
+		if c.config.CACertPath != "" {
+			c.exporterInput.CACert, c.exporterInput.ClientCert, c.exporterInput.ClientKey, err = readCredentials(c.config.CACertPath, c.config.ClientCertPath, c.config.ClientKeyPath)
+			if err != nil {
+				return err
+			}
+		} else {
+			c.exporterInput.CACert, err = getCACert(exp.k8sClient)
+			if err != nil {
+				return fmt.Errorf("cannot retrieve CA cert: %v", err)
+			}
+			c.exporterInput.ClientCert, c.exporterInput.ClientKey, err = getClientCertKey(exp.k8sClient)
+			if err != nil {
+				return fmt.Errorf("cannot retrieve client cert and key: %v", err)
+			}
 		}
 		// TLS transport does not need any tempRefTimeout, so sending 0.
-		exp.exporterInput.TempRefTimeout = 0
-	} else if exp.exporterInput.CollectorProtocol == "tcp" {
+		c.exporterInput.TempRefTimeout = 0
+	} else if c.exporterInput.CollectorProtocol == "tcp" {
 		// TCP transport does not need any tempRefTimeout, so sending 0.
 		// tempRefTimeout is the template refresh timeout, which specifies how often
 		// the exporting process should send the template again.
-		exp.exporterInput.TempRefTimeout = 0
+		c.exporterInput.TempRefTimeout = 0
 	} else {
-		// For UDP transport, hardcoding tempRefTimeout value as 1800s.
-		exp.exporterInput.TempRefTimeout = 1800
+		// For UDP transport, the exporting process uses its default if it is 0.
+		c.exporterInput.TempRefTimeout = uint32(c.config.TemplateRefreshInterval / time.Second)
 	}
-	expProcess, err := ipfix.NewIPFIXExportingProcess(exp.exporterInput)
+	expProcess, err := ipfix.NewIPFIXExportingProcess(c.exporterInput)
 	if err != nil {
 		return fmt.Errorf("error when starting exporter: %v", err)
 	}
-	exp.process = expProcess
+	c.process = expProcess
 	if exp.v4Enabled {
-		templateID := exp.process.NewTemplateID()
-		exp.templateIDv4 = templateID
-		sentBytes, err := exp.sendTemplateSet(false)
+		c.templateIDv4 = c.process.NewTemplateID()
+		sentBytes, err := exp.sendTemplateSet(c, false)
 		if err != nil {
 			return err
 		}
-
-		klog.V(2).Infof("Initialized flow exporter for IPv4 flow records and sent %d bytes size of template record", sentBytes)
+		klog.V(2).InfoS("Initialized flow exporter for IPv4 flow records", "collector", c.config.Name, "templateBytes", sentBytes)
 	}
 	if exp.v6Enabled {
-		templateID := exp.process.NewTemplateID()
-		exp.templateIDv6 = templateID
-		sentBytes, err := exp.sendTemplateSet(true)
+		c.templateIDv6 = c.process.NewTemplateID()
+		sentBytes, err := exp.sendTemplateSet(c, true)
 		if err != nil {
 			return err
 		}
-		klog.V(2).Infof("Initialized flow exporter for IPv6 flow records and sent %d bytes size of template record", sentBytes)
+		klog.V(2).InfoS("Initialized flow exporter for IPv6 flow records", "collector", c.config.Name, "templateBytes", sentBytes)
 	}
 	return nil
 }
 
-func (exp *flowExporter) sendFlowRecords() error {
+// initElementsLists builds the lists of the elements of the IPv4 and IPv6 data records, which are
+// shared by all the collectors.
+func (exp *flowExporter) initElementsLists() error {
+	if exp.v4Enabled && exp.elementsListv4 == nil {
+		elements, err := exp.getTemplateElements(false)
+		if err != nil {
+			return err
+		}
+		exp.elementsListv4 = elements
+	}
+	if exp.v6Enabled && exp.elementsListv6 == nil {
+		elements, err := exp.getTemplateElements(true)
+		if err != nil {
+			return err
+		}
+		exp.elementsListv6 = elements
+	}
+	return nil
+}
+
+// sendFlowRecords sends the flow records whose active flow or idle flow timeout has expired.
+// If final is true, the records of all active connections are sent regardless of the active
+// flow timeout.
+func (exp *flowExporter) sendFlowRecords(final bool) error {
+	activeFlowTimeout, idleFlowTimeout := exp.getFlowTimeouts()
+	if final {
+		activeFlowTimeout = 0
+	}
 	updateOrSendFlowRecord := func(key flowexporter.ConnectionKey, record flowexporter.FlowRecord) error {
 		recordNeedsSending := false
 		// We do not check for any timeout as the connection is still idle since
-		// the idleFlowTimeout was triggered.
-		if !record.IsActive {
+		// the idleFlowTimeout was triggered, unless the Pod of the connection was
+		// deleted and its final record must be sent.
+		if !record.IsActive && !record.Conn.PodDeleted {
 			return nil
 		}
 		// Send a flow record if the conditions for either timeout
 		// (activeFlowTimeout or idleFlowTimeout) are met. A flow is considered
 		// to be idle if its packet counts haven't changed since the last export.
-		if time.Since(record.LastExportTime) >= exp.idleFlowTimeout {
+		if time.Since(record.LastExportTime) >= idleFlowTimeout {
 			if ((record.Conn.OriginalPackets <= record.PrevPackets) && (record.Conn.ReversePackets <= record.PrevReversePackets)) || flowexporter.IsConnectionDying(&record.Conn) {
 				// Idle flow timeout
 				record.IsActive = false
 				recordNeedsSending = true
 			}
 		}
-		if time.Since(record.LastExportTime) >= exp.activeFlowTimeout {
+		if time.Since(record.LastExportTime) >= activeFlowTimeout {
 			// Active flow timeout
 			recordNeedsSending = true
 		}
+		if record.Conn.PodDeleted {
+			// The final record of the connections of a deleted Pod is sent right away.
+			recordNeedsSending = true
+		}
 		if recordNeedsSending {
-			exp.ipfixSet.ResetSet()
-			if record.IsIPv6 {
-				if err := exp.ipfixSet.PrepareSet(ipfixentities.Data, exp.templateIDv6); err != nil {
-					return err
-				}
-				// TODO: more records per data set will be supported when go-ipfix supports size check when adding records
-				if err := exp.addRecordToSet(record); err != nil {
-					return err
-				}
-				if _, err := exp.sendDataSet(); err != nil {
-					return err
-				}
-			} else {
-				if err := exp.ipfixSet.PrepareSet(ipfixentities.Data, exp.templateIDv4); err != nil {
-					return err
-				}
-				// TODO: more records per data set will be supported when go-ipfix supports size check when adding records
-				if err := exp.addRecordToSet(record); err != nil {
-					return err
-				}
-				if _, err := exp.sendDataSet(); err != nil {
-					return err
-				}
+			if err := exp.sendFlowRecord(record); err != nil {
+				return err
 			}
 			exp.numDataSetsSent = exp.numDataSetsSent + 1
 
@@ -323,22 +474,16 @@ func (exp *flowExporter) sendFlowRecords() error {
 	}
 
 	exportDenyConn := func(connKey flowexporter.ConnectionKey, conn *flowexporter.Connection) error {
-		if conn.DeltaPackets > 0 && time.Since(conn.LastExportTime) >= exp.activeFlowTimeout {
-			if err := exp.addDenyConnToSet(conn, ipfixregistry.ActiveTimeoutReason); err != nil {
-				return err
-			}
-			if _, err := exp.sendDataSet(); err != nil {
+		if conn.DeltaPackets > 0 && time.Since(conn.LastExportTime) >= activeFlowTimeout {
+			if err := exp.sendDenyConn(conn, ipfixregistry.ActiveTimeoutReason); err != nil {
 				return err
 			}
 			exp.numDataSetsSent = exp.numDataSetsSent + 1
 			klog.V(4).InfoS("Record for deny connection sent successfully", "flowKey", connKey, "connection", conn)
 			exp.denyConnStore.ResetConnStatsWithoutLock(connKey)
 		}
-		if time.Since(conn.LastExportTime) >= exp.idleFlowTimeout {
-			if err := exp.addDenyConnToSet(conn, ipfixregistry.IdleTimeoutReason); err != nil {
-				return err
-			}
-			if _, err := exp.sendDataSet(); err != nil {
+		if time.Since(conn.LastExportTime) >= idleFlowTimeout {
+			if err := exp.sendDenyConn(conn, ipfixregistry.IdleTimeoutReason); err != nil {
 				return err
 			}
 			exp.numDataSetsSent = exp.numDataSetsSent + 1
@@ -354,21 +499,124 @@ func (exp *flowExporter) sendFlowRecords() error {
 	return nil
 }
 
-func (exp *flowExporter) sendTemplateSet(isIPv6 bool) (int, error) {
+// sendFlowRecord sends a flow record with the recordExporter if it's set, and as an IPFIX data set
+// otherwise.
+func (exp *flowExporter) sendFlowRecord(record flowexporter.FlowRecord) error {
+	if exp.recordExporter != nil {
+		otelRecord := exp.newOTelFlowRecord(&record.Conn, getFlowEndReason(&record))
+		otelRecord.PacketDeltaCount = record.Conn.OriginalPackets - record.PrevPackets
+		otelRecord.OctetDeltaCount = record.Conn.OriginalBytes - record.PrevBytes
+		otelRecord.ReversePacketDeltaCount = record.Conn.ReversePackets - record.PrevReversePackets
+		otelRecord.ReverseOctetDeltaCount = record.Conn.ReverseBytes - record.PrevReverseBytes
+		exp.recordExporter.ExportFlowRecord(otelRecord)
+		return nil
+	}
+	flowType := exp.findFlowType(record.Conn)
+	return exp.sendToCollectors(&record.Conn, record.IsIPv6, false, flowType, func() []*ipfixentities.InfoElementWithValue {
+		return exp.getRecordElements(record, flowType)
+	})
+}
+
+// sendDenyConn sends the record of a deny connection with the recordExporter if it's set, and as an
+// IPFIX data set otherwise.
+func (exp *flowExporter) sendDenyConn(conn *flowexporter.Connection, flowEndReason uint8) error {
+	if exp.recordExporter != nil {
+		otelRecord := exp.newOTelFlowRecord(conn, flowEndReason)
+		otelRecord.PacketDeltaCount = conn.DeltaPackets
+		otelRecord.OctetDeltaCount = conn.DeltaBytes
+		exp.recordExporter.ExportFlowRecord(otelRecord)
+		return nil
+	}
+	flowType := exp.findFlowType(*conn)
+	isIPv6 := conn.FlowKey.SourceAddress.To4() == nil
+	return exp.sendToCollectors(conn, isIPv6, true, flowType, func() []*ipfixentities.InfoElementWithValue {
+		return exp.getDenyConnElements(conn, flowEndReason, flowType)
+	})
+}
+
+// sendToCollectors sends a flow record to the connected collectors whose filter matches it. The
+// elements of the record are built with buildElements at most once, and shared by the collectors.
+// A collector which fails to send the record is reset without affecting the others. An error is
+// returned if no collector sent or filtered out the record, so that it is sent again in the next
+// export cycle.
+func (exp *flowExporter) sendToCollectors(conn *flowexporter.Connection, isIPv6 bool, isDenyConn bool, flowType uint8, buildElements func() []*ipfixentities.InfoElementWithValue) error {
+	var elements []*ipfixentities.InfoElementWithValue
+	var lastErr error
+	handled := false
+	for _, c := range exp.collectors {
+		if c.process == nil {
+			continue
+		}
+		if !c.config.Filter.matches(conn, flowType, isDenyConn) {
+			c.recordFiltered()
+			handled = true
+			continue
+		}
+		if elements == nil {
+			elements = buildElements()
+		}
+		if err := c.sendDataSet(elements, isIPv6); err != nil {
+			klog.ErrorS(err, "Error when sending flow record, resetting the connection to the collector", "collector", c.config.Name)
+			c.fail(exportFailedReason, err)
+			lastErr = err
+			continue
+		}
+		c.recordSent()
+		handled = true
+	}
+	if handled {
+		return nil
+	}
+	if lastErr != nil {
+		return lastErr
+	}
+	return fmt.Errorf("no flow collector is connected")
+}
+
+// newOTelFlowRecord returns the record of a connection exported by the recordExporter, with the
+// same values as its IPFIX data record except the delta counts, which are set by the caller.
+func (exp *flowExporter) newOTelFlowRecord(conn *flowexporter.Connection, flowEndReason uint8) *otelexporter.FlowRecord {
+	record := &otelexporter.FlowRecord{
+		Conn:          conn,
+		FlowEndReason: flowEndReason,
+		FlowType:      exp.findFlowType(*conn),
+	}
+	// Add nodeName for only local pods whose pod names are resolved.
+	if conn.SourcePodName != "" {
+		record.SourceNodeName = exp.nodeName
+	}
+	if conn.DestinationPodName != "" {
+		record.DestinationNodeName = exp.nodeName
+	}
+	return record
+}
+
+// getFlowEndReason returns the reason why a flow record is sent.
+func getFlowEndReason(record *flowexporter.FlowRecord) uint8 {
+	if record.Conn.PodDeleted {
+		return flowexporter.PodDeletedReason
+	} else if flowexporter.IsConnectionDying(&record.Conn) {
+		return ipfixregistry.EndOfFlowReason
+	} else if record.IsActive {
+		return ipfixregistry.ActiveTimeoutReason
+	}
+	return ipfixregistry.IdleTimeoutReason
+}
+
+// getTemplateElements returns the elements of the IPv4 or IPv6 template, without values.
+func (exp *flowExporter) getTemplateElements(isIPv6 bool) ([]*ipfixentities.InfoElementWithValue, error) {
 	elements := make([]*ipfixentities.InfoElementWithValue, 0)
 
 	IANAInfoElements := IANAInfoElementsIPv4
 	AntreaInfoElements := AntreaInfoElementsIPv4
-	templateID := exp.templateIDv4
 	if isIPv6 {
 		IANAInfoElements = IANAInfoElementsIPv6
 		AntreaInfoElements = AntreaInfoElementsIPv6
-		templateID = exp.templateIDv6
 	}
 	for _, ie := range IANAInfoElements {
 		element, err := exp.registry.GetInfoElement(ie, ipfixregistry.IANAEnterpriseID)
 		if err != nil {
-			return 0, fmt.Errorf("%s not present. returned error: %v", ie, err)
+			return nil, fmt.Errorf("%s not present. returned error: %v", ie, err)
 		}
 		ieWithValue := ipfixentities.NewInfoElementWithValue(element, nil)
 		elements = append(elements, ieWithValue)
@@ -376,7 +624,7 @@ func (exp *flowExporter) sendTemplateSet(isIPv6 bool) (int, error) {
 	for _, ie := range IANAReverseInfoElements {
 		element, err := exp.registry.GetInfoElement(ie, ipfixregistry.IANAReversedEnterpriseID)
 		if err != nil {
-			return 0, fmt.Errorf("%s not present. returned error: %v", ie, err)
+			return nil, fmt.Errorf("%s not present. returned error: %v", ie, err)
 		}
 		ieWithValue := ipfixentities.NewInfoElementWithValue(element, nil)
 		elements = append(elements, ieWithValue)
@@ -384,35 +632,42 @@ func (exp *flowExporter) sendTemplateSet(isIPv6 bool) (int, error) {
 	for _, ie := range AntreaInfoElements {
 		element, err := exp.registry.GetInfoElement(ie, ipfixregistry.AntreaEnterpriseID)
 		if err != nil {
-			return 0, fmt.Errorf("information element %s is not present in Antrea registry", ie)
+			return nil, fmt.Errorf("information element %s is not present in Antrea registry", ie)
 		}
 		ieWithValue := ipfixentities.NewInfoElementWithValue(element, nil)
 		elements = append(elements, ieWithValue)
 	}
-	exp.ipfixSet.ResetSet()
-	if err := exp.ipfixSet.PrepareSet(ipfixentities.Template, templateID); err != nil {
+	return elements, nil
+}
+
+// sendTemplateSet sends the IPv4 or IPv6 template to a collector. The elements of the template are
+// built again, as the shared elements of the data records have values.
+func (exp *flowExporter) sendTemplateSet(c *collectorExporter, isIPv6 bool) (int, error) {
+	elements, err := exp.getTemplateElements(isIPv6)
+	if err != nil {
+		return 0, err
+	}
+	templateID := c.templateIDv4
+	if isIPv6 {
+		templateID = c.templateIDv6
+	}
+	c.ipfixSet.ResetSet()
+	if err := c.ipfixSet.PrepareSet(ipfixentities.Template, templateID); err != nil {
 		return 0, err
 	}
-	err := exp.ipfixSet.AddRecord(elements, templateID)
+	err = c.ipfixSet.AddRecord(elements, templateID)
 	if err != nil {
 		return 0, fmt.Errorf("error in adding record to template set: %v", err)
 	}
-	sentBytes, err := exp.process.SendSet(exp.ipfixSet)
+	sentBytes, err := c.process.SendSet(c.ipfixSet)
 	if err != nil {
 		return 0, fmt.Errorf("error in IPFIX exporting process when sending template record: %v", err)
 	}
-
-	// Get all elements from template record.
-	if !isIPv6 {
-		exp.elementsListv4 = elements
-	} else {
-		exp.elementsListv6 = elements
-	}
-
 	return sentBytes, nil
 }
 
-func (exp *flowExporter) addRecordToSet(record flowexporter.FlowRecord) error {
+// getRecordElements sets the values of the elements of a flow record, and returns them.
+func (exp *flowExporter) getRecordElements(record flowexporter.FlowRecord, flowType uint8) []*ipfixentities.InfoElementWithValue {
 	// Iterate over all infoElements in the list
 	eL := exp.elementsListv4
 	if record.IsIPv6 {
@@ -425,13 +680,7 @@ func (exp *flowExporter) addRecordToSet(record flowexporter.FlowRecord) error {
 		case "flowEndSeconds":
 			ie.Value = uint32(record.Conn.StopTime.Unix())
 		case "flowEndReason":
-			if flowexporter.IsConnectionDying(&record.Conn) {
-				ie.Value = ipfixregistry.EndOfFlowReason
-			} else if record.IsActive {
-				ie.Value = ipfixregistry.ActiveTimeoutReason
-			} else {
-				ie.Value = ipfixregistry.IdleTimeoutReason
-			}
+			ie.Value = getFlowEndReason(&record)
 		case "sourceIPv4Address":
 			ie.Value = record.Conn.FlowKey.SourceAddress
 		case "destinationIPv4Address":
@@ -551,33 +800,19 @@ func (exp *flowExporter) addRecordToSet(record flowexporter.FlowRecord) error {
 		case "tcpState":
 			ie.Value = record.Conn.TCPState
 		case "flowType":
-			ie.Value = exp.findFlowType(record.Conn)
+			ie.Value = flowType
 		}
 	}
-
-	templateID := exp.templateIDv4
-	if record.IsIPv6 {
-		templateID = exp.templateIDv6
-	}
-	err := exp.ipfixSet.AddRecord(eL, templateID)
-	if err != nil {
-		return fmt.Errorf("error in adding record to data set: %v", err)
-	}
-	return nil
+	return eL
 }
 
-func (exp *flowExporter) addDenyConnToSet(conn *flowexporter.Connection, flowEndReason uint8) error {
-	exp.ipfixSet.ResetSet()
-
+// getDenyConnElements sets the values of the elements of the record of a deny connection, and
+// returns them.
+func (exp *flowExporter) getDenyConnElements(conn *flowexporter.Connection, flowEndReason uint8, flowType uint8) []*ipfixentities.InfoElementWithValue {
 	eL := exp.elementsListv4
-	templateID := exp.templateIDv4
 	if conn.FlowKey.SourceAddress.To4() == nil {
-		templateID = exp.templateIDv6
 		eL = exp.elementsListv6
 	}
-	if err := exp.ipfixSet.PrepareSet(ipfixentities.Data, templateID); err != nil {
-		return err
-	}
 	// Iterate over all infoElements in the list
 	for _, ie := range eL {
 		switch ieName := ie.Element.Name; ieName {
@@ -676,24 +911,10 @@ func (exp *flowExporter) addDenyConnToSet(conn *flowexporter.Connection, flowEnd
 		case "tcpState":
 			ie.Value = ""
 		case "flowType":
-			ie.Value = exp.findFlowType(*conn)
+			ie.Value = flowType
 		}
 	}
-
-	err := exp.ipfixSet.AddRecord(eL, templateID)
-	if err != nil {
-		return fmt.Errorf("error in adding record to data set: %v", err)
-	}
-	return nil
-}
-
-func (exp *flowExporter) sendDataSet() (int, error) {
-	sentBytes, err := exp.process.SendSet(exp.ipfixSet)
-	if err != nil {
-		return 0, fmt.Errorf("error when sending data set: %v", err)
-	}
-	klog.V(4).Infof("Data set sent successfully. Bytes sent: %d", sentBytes)
-	return sentBytes, nil
+	return eL
 }
 
 func (exp *flowExporter) findFlowType(conn flowexporter.Connection) uint8 {
@@ -80,8 +80,8 @@ func BenchmarkExportConntrackConns(b *testing.B) {
 	}
 	b.ResetTimer()
 	for n := 0; n < b.N; n++ {
-		exp.initFlowExporter()
-		exp.sendFlowRecords()
+		exp.connectCollector(exp.collectors[0])
+		exp.sendFlowRecords(false)
 	}
 	b.Logf("\nSummary:\nNumber of conntrack connections: %d\nNumber of dying conntrack connections: %d\nTotal connections received: %d\n", testNumOfConns, testNumOfDyingConns, recordsReceived)
 }
@@ -121,8 +121,8 @@ func BenchmarkExportDenyConns(b *testing.B) {
 	}
 	b.ResetTimer()
 	for n := 0; n < b.N; n++ {
-		exp.initFlowExporter()
-		exp.sendFlowRecords()
+		exp.connectCollector(exp.collectors[0])
+		exp.sendFlowRecords(false)
 	}
 	b.Logf("\nSummary:\nNumber of deny connections: %d\nNumber of idle deny connections: %d\nTotal connections received: %d\n", testNumOfDenyConns, testNumOfIdleDenyConns, recordsReceived)
 
@@ -145,7 +145,8 @@ func setupExporter(isConntrackConn bool) (*flowExporter, error) {
 		addDenyConns(denyConnStore)
 	}
 
-	exp, _ := NewFlowExporter(conntrackConnStore, records, denyConnStore, collectorAddr.String(), collectorAddr.Network(), testActiveFlowTimeout, testIdleFlowTimeout, true, false, nil, nil, false)
+	collectors := []CollectorConfig{{Name: "local", Address: collectorAddr.String(), Protocol: collectorAddr.Network()}}
+	exp, _ := NewFlowExporter(conntrackConnStore, records, denyConnStore, collectors, testActiveFlowTimeout, testIdleFlowTimeout, true, false, nil, nil, false, nil)
 	return exp, err
 }
 
@@ -15,21 +15,26 @@
 package exporter
 
 import (
+	"fmt"
 	"net"
 	"testing"
 	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	ipfixentities "github.com/vmware/go-ipfix/pkg/entities"
 	ipfixentitiestesting "github.com/vmware/go-ipfix/pkg/entities/testing"
 	"github.com/vmware/go-ipfix/pkg/registry"
 	ipfixregistry "github.com/vmware/go-ipfix/pkg/registry"
+	"k8s.io/apimachinery/pkg/util/sets"
 
 	"antrea.io/antrea/pkg/agent/flowexporter"
 	"antrea.io/antrea/pkg/agent/flowexporter/connections"
 	connectionstest "antrea.io/antrea/pkg/agent/flowexporter/connections/testing"
 	"antrea.io/antrea/pkg/agent/flowexporter/flowrecords"
+	"antrea.io/antrea/pkg/agent/otelexporter"
+	"antrea.io/antrea/pkg/ipfix"
 	ipfixtest "antrea.io/antrea/pkg/ipfix/testing"
 )
 
@@ -40,6 +45,16 @@ const (
 	testIdleFlowTimeout   = 1 * time.Second
 )
 
+func newTestCollector(name string, process ipfix.IPFIXExportingProcess, set ipfixentities.Set) *collectorExporter {
+	collector := newCollectorExporter(CollectorConfig{Name: name, Address: "10.0.0.1:4739", Protocol: "tcp"}, "node1")
+	collector.process = process
+	collector.ipfixSet = set
+	collector.templateIDv4 = testTemplateIDv4
+	collector.templateIDv6 = testTemplateIDv6
+	collector.setConnected()
+	return collector
+}
+
 func TestFlowExporter_sendTemplateSet(t *testing.T) {
 	for _, tc := range []struct {
 		v4Enabled bool
@@ -60,26 +75,24 @@ func testSendTemplateSet(t *testing.T, v4Enabled bool, v6Enabled bool) {
 	mockIPFIXExpProc := ipfixtest.NewMockIPFIXExportingProcess(ctrl)
 	mockIPFIXRegistry := ipfixtest.NewMockIPFIXRegistry(ctrl)
 	flowExp := &flowExporter{
-		process:      mockIPFIXExpProc,
-		templateIDv4: testTemplateIDv4,
-		templateIDv6: testTemplateIDv6,
-		registry:     mockIPFIXRegistry,
-		v4Enabled:    v4Enabled,
-		v6Enabled:    v6Enabled,
+		registry:  mockIPFIXRegistry,
+		v4Enabled: v4Enabled,
+		v6Enabled: v6Enabled,
 	}
+	collector := newTestCollector("collector", mockIPFIXExpProc, nil)
 
 	if v4Enabled {
-		sendTemplateSet(t, ctrl, mockIPFIXExpProc, mockIPFIXRegistry, flowExp, false)
+		sendTemplateSet(t, ctrl, mockIPFIXExpProc, mockIPFIXRegistry, flowExp, collector, false)
 	}
 	if v6Enabled {
-		sendTemplateSet(t, ctrl, mockIPFIXExpProc, mockIPFIXRegistry, flowExp, true)
+		sendTemplateSet(t, ctrl, mockIPFIXExpProc, mockIPFIXRegistry, flowExp, collector, true)
 	}
 }
 
-func sendTemplateSet(t *testing.T, ctrl *gomock.Controller, mockIPFIXExpProc *ipfixtest.MockIPFIXExportingProcess, mockIPFIXRegistry *ipfixtest.MockIPFIXRegistry, flowExp *flowExporter, isIPv6 bool) {
+func sendTemplateSet(t *testing.T, ctrl *gomock.Controller, mockIPFIXExpProc *ipfixtest.MockIPFIXExportingProcess, mockIPFIXRegistry *ipfixtest.MockIPFIXRegistry, flowExp *flowExporter, collector *collectorExporter, isIPv6 bool) {
 	var mockTempSet *ipfixentitiestesting.MockSet
 	mockTempSet = ipfixentitiestesting.NewMockSet(ctrl)
-	flowExp.ipfixSet = mockTempSet
+	collector.ipfixSet = mockTempSet
 	// Following consists of all elements that are in IANAInfoElements and AntreaInfoElements (globals)
 	// Only the element name is needed, other arguments have dummy values.
 	elemList := getElementList(isIPv6)
@@ -112,14 +125,8 @@ func sendTemplateSet(t *testing.T, ctrl *gomock.Controller, mockIPFIXExpProc *ip
 		mockTempSet.EXPECT().PrepareSet(ipfixentities.Template, testTemplateIDv6).Return(nil)
 	}
 	mockIPFIXExpProc.EXPECT().SendSet(mockTempSet).Return(0, nil)
-	_, err := flowExp.sendTemplateSet(isIPv6)
+	_, err := flowExp.sendTemplateSet(collector, isIPv6)
 	assert.NoError(t, err, "Error in sending template set")
-
-	eL := flowExp.elementsListv4
-	if isIPv6 {
-		eL = flowExp.elementsListv6
-	}
-	assert.Len(t, eL, len(ianaIE)+len(IANAReverseInfoElements)+len(antreaIE), "flowExp.elementsList and template record should have same number of elements")
 }
 
 func getElementList(isIPv6 bool) []*ipfixentities.InfoElementWithValue {
@@ -176,18 +183,17 @@ func testSendDataSet(t *testing.T, v4Enabled bool, v6Enabled bool) {
 		elemListv6 = getElemList(IANAInfoElementsIPv6, AntreaInfoElementsIPv6)
 	}
 	flowExp := &flowExporter{
-		process:        mockIPFIXExpProc,
 		elementsListv4: elemListv4,
 		elementsListv6: elemListv6,
-		templateIDv4:   testTemplateIDv4,
-		templateIDv6:   testTemplateIDv6,
 		registry:       mockIPFIXRegistry,
 		v4Enabled:      v4Enabled,
 		v6Enabled:      v6Enabled,
-		ipfixSet:       mockDataSet,
 	}
+	collector := newTestCollector("collector", mockIPFIXExpProc, mockDataSet)
 
 	sendDataSet := func(elemList []*ipfixentities.InfoElementWithValue, templateID uint16, record flowexporter.FlowRecord) {
+		mockDataSet.EXPECT().ResetSet()
+		mockDataSet.EXPECT().PrepareSet(ipfixentities.Data, templateID).Return(nil)
 		mockDataSet.EXPECT().AddRecord(gomock.AssignableToTypeOf(elemList), templateID).DoAndReturn(
 			func(elements []*ipfixentities.InfoElementWithValue, templateID uint16) interface{} {
 				for i, ieWithValue := range elements {
@@ -198,9 +204,8 @@ func testSendDataSet(t *testing.T, v4Enabled bool, v6Enabled bool) {
 			},
 		)
 		mockIPFIXExpProc.EXPECT().SendSet(mockDataSet).Return(0, nil)
-		err := flowExp.addRecordToSet(record)
-		assert.NoError(t, err, "Error when adding record to data set")
-		_, err = flowExp.sendDataSet()
+		elements := flowExp.getRecordElements(record, ipfixregistry.FlowTypeIntraNode)
+		err := collector.sendDataSet(elements, record.IsIPv6)
 		assert.NoError(t, err, "Error in sending data set")
 	}
 
@@ -353,8 +358,6 @@ func testSendFlowRecords(t *testing.T, v4Enabled bool, v6Enabled bool) {
 	flowExp := &flowExporter{
 		elementsListv4:    elemListv4,
 		elementsListv6:    elemListv6,
-		templateIDv4:      testTemplateIDv4,
-		templateIDv6:      testTemplateIDv6,
 		v4Enabled:         true,
 		activeFlowTimeout: testActiveFlowTimeout,
 		idleFlowTimeout:   testIdleFlowTimeout,
@@ -374,8 +377,7 @@ func runSendFlowRecordTests(t *testing.T, flowExp *flowExporter, isIPv6 bool) {
 
 	mockIPFIXExpProc := ipfixtest.NewMockIPFIXExportingProcess(ctrl)
 	mockDataSet := ipfixentitiestesting.NewMockSet(ctrl)
-	flowExp.process = mockIPFIXExpProc
-	flowExp.ipfixSet = mockDataSet
+	flowExp.collectors = []*collectorExporter{newTestCollector("collector", mockIPFIXExpProc, mockDataSet)}
 	mockConnDumper := connectionstest.NewMockConnTrackDumper(ctrl)
 	flowExp.conntrackConnStore = connections.NewConntrackConnectionStore(mockConnDumper, flowrecords.NewFlowRecords(), nil, !isIPv6, isIPv6, nil, nil, 1)
 
@@ -477,16 +479,16 @@ func runSendFlowRecordTests(t *testing.T, flowExp *flowExporter, isIPv6 bool) {
 				count += 1
 			}
 			if !isIPv6 {
-				mockDataSet.EXPECT().PrepareSet(ipfixentities.Data, flowExp.templateIDv4).Times(count).Return(nil)
-				mockDataSet.EXPECT().AddRecord(flowExp.elementsListv4, flowExp.templateIDv4).Times(count).Return(nil)
+				mockDataSet.EXPECT().PrepareSet(ipfixentities.Data, testTemplateIDv4).Times(count).Return(nil)
+				mockDataSet.EXPECT().AddRecord(flowExp.elementsListv4, testTemplateIDv4).Times(count).Return(nil)
 			} else {
-				mockDataSet.EXPECT().PrepareSet(ipfixentities.Data, flowExp.templateIDv6).Times(count).Return(nil)
-				mockDataSet.EXPECT().AddRecord(flowExp.elementsListv6, flowExp.templateIDv6).Times(count).Return(nil)
+				mockDataSet.EXPECT().PrepareSet(ipfixentities.Data, testTemplateIDv6).Times(count).Return(nil)
+				mockDataSet.EXPECT().AddRecord(flowExp.elementsListv6, testTemplateIDv6).Times(count).Return(nil)
 			}
 			mockIPFIXExpProc.EXPECT().SendSet(mockDataSet).Times(count).Return(0, nil)
 			mockDataSet.EXPECT().ResetSet().Times(count)
 
-			err = flowExp.sendFlowRecords()
+			err = flowExp.sendFlowRecords(false)
 			assert.NoError(t, err)
 			assert.Equalf(t, uint64(count), flowExp.numDataSetsSent, "%v data sets should have been sent.", count)
 			if tt.isDenyConnActive {
@@ -516,3 +518,354 @@ func getNumOfConnections(connStore *connections.DenyConnectionStore) int {
 	connStore.ForAllConnectionsDo(countNumOfConns)
 	return count
 }
+
+func TestFlowExporter_RunFinalExport(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockIPFIXExpProc := ipfixtest.NewMockIPFIXExportingProcess(ctrl)
+	mockDataSet := ipfixentitiestesting.NewMockSet(ctrl)
+	mockConnDumper := connectionstest.NewMockConnTrackDumper(ctrl)
+	flowExp := &flowExporter{
+		collectors:         []*collectorExporter{newTestCollector("collector", mockIPFIXExpProc, mockDataSet)},
+		elementsListv4:     getElemList(IANAInfoElementsIPv4, AntreaInfoElementsIPv4),
+		v4Enabled:          true,
+		activeFlowTimeout:  testActiveFlowTimeout,
+		idleFlowTimeout:    testIdleFlowTimeout,
+		conntrackConnStore: connections.NewConntrackConnectionStore(mockConnDumper, flowrecords.NewFlowRecords(), nil, true, false, nil, nil, 1),
+		flowRecords:        flowrecords.NewFlowRecords(),
+		denyConnStore:      connections.NewDenyConnectionStore(nil, nil),
+	}
+
+	conn := getConnection(false, true, 0x4, 6, "SYN_SENT")
+	connKey := flowexporter.NewConnectionKey(conn)
+	flowExp.conntrackConnStore.AddOrUpdateConn(conn)
+	require.NoError(t, flowExp.flowRecords.AddOrUpdateFlowRecord(connKey, conn))
+	// The record has just been exported, it would not be sent again before the active flow
+	// timeout expires, but it must be sent when the exporter stops.
+	flowRec, exists := flowExp.flowRecords.GetFlowRecordFromMap(&connKey)
+	require.True(t, exists)
+	flowRec.IsActive = true
+	flowRec.LastExportTime = time.Now()
+	flowExp.flowRecords.AddFlowRecordToMap(&connKey, flowRec)
+
+	mockDataSet.EXPECT().ResetSet()
+	mockDataSet.EXPECT().PrepareSet(ipfixentities.Data, testTemplateIDv4).Return(nil)
+	mockDataSet.EXPECT().AddRecord(flowExp.elementsListv4, testTemplateIDv4).Return(nil)
+	mockIPFIXExpProc.EXPECT().SendSet(mockDataSet).Return(0, nil)
+	mockIPFIXExpProc.EXPECT().CloseConnToCollector()
+
+	stopCh := make(chan struct{})
+	close(stopCh)
+	flowExp.Run(stopCh)
+	assert.Equal(t, uint64(1), flowExp.numDataSetsSent)
+	assert.Nil(t, flowExp.collectors[0].process)
+}
+
+func TestFlowExporter_SetFlowTimeouts(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockIPFIXExpProc := ipfixtest.NewMockIPFIXExportingProcess(ctrl)
+	mockDataSet := ipfixentitiestesting.NewMockSet(ctrl)
+	mockConnDumper := connectionstest.NewMockConnTrackDumper(ctrl)
+	flowExp := &flowExporter{
+		collectors:         []*collectorExporter{newTestCollector("collector", mockIPFIXExpProc, mockDataSet)},
+		elementsListv4:     getElemList(IANAInfoElementsIPv4, AntreaInfoElementsIPv4),
+		v4Enabled:          true,
+		activeFlowTimeout:  time.Hour,
+		idleFlowTimeout:    time.Hour,
+		conntrackConnStore: connections.NewConntrackConnectionStore(mockConnDumper, flowrecords.NewFlowRecords(), nil, true, false, nil, nil, 1),
+		flowRecords:        flowrecords.NewFlowRecords(),
+		denyConnStore:      connections.NewDenyConnectionStore(nil, nil),
+	}
+
+	conn := getConnection(false, true, 0x4, 6, "ESTABLISHED")
+	connKey := flowexporter.NewConnectionKey(conn)
+	flowExp.conntrackConnStore.AddOrUpdateConn(conn)
+	require.NoError(t, flowExp.flowRecords.AddOrUpdateFlowRecord(connKey, conn))
+	flowRec, exists := flowExp.flowRecords.GetFlowRecordFromMap(&connKey)
+	require.True(t, exists)
+	flowRec.IsActive = true
+	flowRec.LastExportTime = time.Now().Add(-time.Minute)
+	flowExp.flowRecords.AddFlowRecordToMap(&connKey, flowRec)
+
+	// The active flow timeout has not expired yet.
+	require.NoError(t, flowExp.sendFlowRecords(false))
+	assert.Equal(t, uint64(0), flowExp.numDataSetsSent)
+
+	// The record is sent once the active flow timeout is reduced.
+	flowExp.SetFlowTimeouts(30*time.Second, time.Hour)
+	activeFlowTimeout, idleFlowTimeout := flowExp.getFlowTimeouts()
+	assert.Equal(t, 30*time.Second, activeFlowTimeout)
+	assert.Equal(t, time.Hour, idleFlowTimeout)
+	mockDataSet.EXPECT().ResetSet()
+	mockDataSet.EXPECT().PrepareSet(ipfixentities.Data, testTemplateIDv4).Return(nil)
+	mockDataSet.EXPECT().AddRecord(flowExp.elementsListv4, testTemplateIDv4).Return(nil)
+	mockIPFIXExpProc.EXPECT().SendSet(mockDataSet).Return(0, nil)
+	require.NoError(t, flowExp.sendFlowRecords(false))
+	assert.Equal(t, uint64(1), flowExp.numDataSetsSent)
+}
+
+func TestFlowExporter_sendFlowRecordsOfDeletedPod(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockIPFIXExpProc := ipfixtest.NewMockIPFIXExportingProcess(ctrl)
+	mockDataSet := ipfixentitiestesting.NewMockSet(ctrl)
+	mockConnDumper := connectionstest.NewMockConnTrackDumper(ctrl)
+	flowExp := &flowExporter{
+		collectors:         []*collectorExporter{newTestCollector("collector", mockIPFIXExpProc, mockDataSet)},
+		elementsListv4:     getElemList(IANAInfoElementsIPv4, AntreaInfoElementsIPv4),
+		v4Enabled:          true,
+		activeFlowTimeout:  testActiveFlowTimeout,
+		idleFlowTimeout:    testIdleFlowTimeout,
+		conntrackConnStore: connections.NewConntrackConnectionStore(mockConnDumper, flowrecords.NewFlowRecords(), nil, true, false, nil, nil, 1),
+		flowRecords:        flowrecords.NewFlowRecords(),
+		denyConnStore:      connections.NewDenyConnectionStore(nil, nil),
+	}
+
+	conn := getConnection(false, true, 0x4, 6, "ESTABLISHED")
+	connKey := flowexporter.NewConnectionKey(conn)
+	flowExp.conntrackConnStore.AddOrUpdateConn(conn)
+	// The Pod of the connection has been deleted: the final record must be sent right away, even
+	// though the record is inactive and none of the timeouts has expired.
+	conn.PodDeleted = true
+	require.NoError(t, flowExp.flowRecords.AddOrUpdateFlowRecord(connKey, conn))
+	flowRec, exists := flowExp.flowRecords.GetFlowRecordFromMap(&connKey)
+	require.True(t, exists)
+	flowRec.IsActive = false
+	flowRec.LastExportTime = time.Now()
+	flowExp.flowRecords.AddFlowRecordToMap(&connKey, flowRec)
+
+	mockDataSet.EXPECT().ResetSet()
+	mockDataSet.EXPECT().PrepareSet(ipfixentities.Data, testTemplateIDv4).Return(nil)
+	mockDataSet.EXPECT().AddRecord(flowExp.elementsListv4, testTemplateIDv4).Return(nil)
+	mockIPFIXExpProc.EXPECT().SendSet(mockDataSet).Return(0, nil)
+
+	require.NoError(t, flowExp.sendFlowRecords(false))
+	assert.Equal(t, uint64(1), flowExp.numDataSetsSent)
+	for _, ie := range flowExp.elementsListv4 {
+		if ie.Element.Name == "flowEndReason" {
+			assert.Equal(t, flowexporter.PodDeletedReason, ie.Value)
+		}
+	}
+	_, recPresent := flowExp.flowRecords.GetFlowRecordFromMap(&connKey)
+	assert.False(t, recPresent)
+	connection, _ := flowExp.conntrackConnStore.GetConnByKey(connKey)
+	assert.True(t, connection.DoneExport)
+}
+
+// fakeFlowRecordExporter records the flow records exported to it.
+type fakeFlowRecordExporter struct {
+	records []*otelexporter.FlowRecord
+}
+
+func (e *fakeFlowRecordExporter) ExportFlowRecord(record *otelexporter.FlowRecord) {
+	e.records = append(e.records, record)
+}
+
+func TestFlowExporter_RunWithRecordExporter(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockConnDumper := connectionstest.NewMockConnTrackDumper(ctrl)
+	recordExporter := &fakeFlowRecordExporter{}
+	// The records are exported without any IPFIX exporting process.
+	flowExp := &flowExporter{
+		v4Enabled:           true,
+		activeFlowTimeout:   testActiveFlowTimeout,
+		idleFlowTimeout:     testIdleFlowTimeout,
+		conntrackConnStore:  connections.NewConntrackConnectionStore(mockConnDumper, flowrecords.NewFlowRecords(), nil, true, false, nil, nil, 1),
+		flowRecords:         flowrecords.NewFlowRecords(),
+		denyConnStore:       connections.NewDenyConnectionStore(nil, nil),
+		isNetworkPolicyOnly: true,
+		nodeName:            "node1",
+		recordExporter:      recordExporter,
+	}
+
+	conn := getConnection(false, true, 0x4, 6, "ESTABLISHED")
+	connKey := flowexporter.NewConnectionKey(conn)
+	flowExp.conntrackConnStore.AddOrUpdateConn(conn)
+	require.NoError(t, flowExp.flowRecords.AddOrUpdateFlowRecord(connKey, conn))
+	flowRec, exists := flowExp.flowRecords.GetFlowRecordFromMap(&connKey)
+	require.True(t, exists)
+	flowRec.IsActive = true
+	flowRec.LastExportTime = time.Now()
+	flowRec.PrevPackets = 0xa0
+	flowRec.PrevBytes = 0xab00
+	flowExp.flowRecords.AddFlowRecordToMap(&connKey, flowRec)
+
+	stopCh := make(chan struct{})
+	close(stopCh)
+	flowExp.Run(stopCh)
+	assert.Equal(t, uint64(1), flowExp.numDataSetsSent)
+	require.Len(t, recordExporter.records, 1)
+	record := recordExporter.records[0]
+	assert.Equal(t, conn.FlowKey, record.Conn.FlowKey)
+	assert.Equal(t, ipfixregistry.ActiveTimeoutReason, record.FlowEndReason)
+	assert.Equal(t, ipfixregistry.FlowTypeInterNode, record.FlowType)
+	assert.Equal(t, uint64(0xb), record.PacketDeltaCount)
+	assert.Equal(t, uint64(0xcd), record.OctetDeltaCount)
+	assert.Equal(t, uint64(0xa), record.ReversePacketDeltaCount)
+	assert.Equal(t, "node1", record.SourceNodeName)
+	assert.Equal(t, "", record.DestinationNodeName)
+
+	denyConn := getDenyConnection(false, true, 6)
+	require.NoError(t, flowExp.sendDenyConn(denyConn, ipfixregistry.IdleTimeoutReason))
+	require.Len(t, recordExporter.records, 2)
+	record = recordExporter.records[1]
+	assert.Equal(t, ipfixregistry.IdleTimeoutReason, record.FlowEndReason)
+	assert.Equal(t, denyConn.DeltaPackets, record.PacketDeltaCount)
+	assert.Equal(t, denyConn.DeltaBytes, record.OctetDeltaCount)
+}
+
+func TestFlowExporter_sendFlowRecordsToMultipleCollectors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockIPFIXExpProc1 := ipfixtest.NewMockIPFIXExportingProcess(ctrl)
+	mockDataSet1 := ipfixentitiestesting.NewMockSet(ctrl)
+	mockIPFIXExpProc2 := ipfixtest.NewMockIPFIXExportingProcess(ctrl)
+	mockDataSet2 := ipfixentitiestesting.NewMockSet(ctrl)
+	mockIPFIXExpProc3 := ipfixtest.NewMockIPFIXExportingProcess(ctrl)
+	mockDataSet3 := ipfixentitiestesting.NewMockSet(ctrl)
+	mockConnDumper := connectionstest.NewMockConnTrackDumper(ctrl)
+	filteredCollector := newTestCollector("filtered", mockIPFIXExpProc3, mockDataSet3)
+	filteredCollector.config.Filter = RecordFilter{Namespaces: sets.NewString("other-ns")}
+	flowExp := &flowExporter{
+		collectors: []*collectorExporter{
+			newTestCollector("security", mockIPFIXExpProc1, mockDataSet1),
+			newTestCollector("debug", mockIPFIXExpProc2, mockDataSet2),
+			filteredCollector,
+		},
+		elementsListv4:     getElemList(IANAInfoElementsIPv4, AntreaInfoElementsIPv4),
+		v4Enabled:          true,
+		activeFlowTimeout:  testActiveFlowTimeout,
+		idleFlowTimeout:    testIdleFlowTimeout,
+		conntrackConnStore: connections.NewConntrackConnectionStore(mockConnDumper, flowrecords.NewFlowRecords(), nil, true, false, nil, nil, 1),
+		flowRecords:        flowrecords.NewFlowRecords(),
+		denyConnStore:      connections.NewDenyConnectionStore(nil, nil),
+	}
+
+	addRecord := func() flowexporter.ConnectionKey {
+		conn := getConnection(false, true, 0x4, 6, "ESTABLISHED")
+		connKey := flowexporter.NewConnectionKey(conn)
+		flowExp.conntrackConnStore.AddOrUpdateConn(conn)
+		require.NoError(t, flowExp.flowRecords.AddOrUpdateFlowRecord(connKey, conn))
+		flowRec, exists := flowExp.flowRecords.GetFlowRecordFromMap(&connKey)
+		require.True(t, exists)
+		flowRec.IsActive = true
+		flowRec.LastExportTime = time.Now().Add(-testActiveFlowTimeout)
+		flowExp.flowRecords.AddFlowRecordToMap(&connKey, flowRec)
+		return connKey
+	}
+	connKey := addRecord()
+
+	// The first collector is down: its connection is reset, and the record is still sent to the
+	// second collector and filtered out by the third one.
+	mockDataSet1.EXPECT().ResetSet()
+	mockDataSet1.EXPECT().PrepareSet(ipfixentities.Data, testTemplateIDv4).Return(nil)
+	mockDataSet1.EXPECT().AddRecord(flowExp.elementsListv4, testTemplateIDv4).Return(nil)
+	mockIPFIXExpProc1.EXPECT().SendSet(mockDataSet1).Return(0, fmt.Errorf("broken pipe"))
+	mockIPFIXExpProc1.EXPECT().CloseConnToCollector()
+	mockDataSet2.EXPECT().ResetSet()
+	mockDataSet2.EXPECT().PrepareSet(ipfixentities.Data, testTemplateIDv4).Return(nil)
+	mockDataSet2.EXPECT().AddRecord(flowExp.elementsListv4, testTemplateIDv4).Return(nil)
+	mockIPFIXExpProc2.EXPECT().SendSet(mockDataSet2).Return(0, nil)
+	require.NoError(t, flowExp.sendFlowRecords(false))
+	assert.Equal(t, uint64(1), flowExp.numDataSetsSent)
+	flowRec, exists := flowExp.flowRecords.GetFlowRecordFromMap(&connKey)
+	require.True(t, exists)
+	assert.WithinDuration(t, time.Now(), flowRec.LastExportTime, time.Second)
+
+	statuses := flowExp.GetFlowCollectorStatuses()
+	require.Len(t, statuses, 3)
+	assert.Equal(t, "security", statuses[0].Name)
+	assert.False(t, statuses[0].Connected)
+	assert.Equal(t, uint64(1), statuses[0].Failures)
+	assert.Equal(t, "error when sending data set: broken pipe", statuses[0].LastError)
+	assert.Equal(t, uint64(0), statuses[0].RecordsSent)
+	assert.Equal(t, "debug", statuses[1].Name)
+	assert.True(t, statuses[1].Connected)
+	assert.Equal(t, uint64(1), statuses[1].RecordsSent)
+	assert.False(t, statuses[1].LastExportTime.IsZero())
+	assert.Equal(t, "filtered", statuses[2].Name)
+	assert.True(t, statuses[2].Connected)
+	assert.Equal(t, uint64(0), statuses[2].RecordsSent)
+	assert.Equal(t, uint64(1), statuses[2].RecordsFiltered)
+
+	// Once no collector can send the record, it is kept to be sent again in the next export cycle.
+	flowExp.collectors = flowExp.collectors[:2]
+	connKey = addRecord()
+	mockDataSet2.EXPECT().ResetSet()
+	mockDataSet2.EXPECT().PrepareSet(ipfixentities.Data, testTemplateIDv4).Return(nil)
+	mockDataSet2.EXPECT().AddRecord(flowExp.elementsListv4, testTemplateIDv4).Return(nil)
+	mockIPFIXExpProc2.EXPECT().SendSet(mockDataSet2).Return(0, fmt.Errorf("connection refused"))
+	mockIPFIXExpProc2.EXPECT().CloseConnToCollector()
+	assert.Error(t, flowExp.sendFlowRecords(false))
+	assert.Equal(t, uint64(1), flowExp.numDataSetsSent)
+	flowRec, exists = flowExp.flowRecords.GetFlowRecordFromMap(&connKey)
+	require.True(t, exists)
+	assert.True(t, time.Since(flowRec.LastExportTime) >= testActiveFlowTimeout)
+	assert.Equal(t, 0, flowExp.numConnectedCollectors())
+}
+
+func TestRecordFilter(t *testing.T) {
+	conn := getConnection(false, true, 0x4, 6, "ESTABLISHED")
+	tests := []struct {
+		name       string
+		filter     RecordFilter
+		flowType   uint8
+		isDenyConn bool
+		expected   bool
+	}{
+		{
+			name:     "empty filter",
+			flowType: ipfixregistry.FlowTypeInterNode,
+			expected: true,
+		},
+		{
+			name:     "matching flow type",
+			filter:   RecordFilter{FlowTypes: []uint8{ipfixregistry.FlowTypeIntraNode, ipfixregistry.FlowTypeInterNode}},
+			flowType: ipfixregistry.FlowTypeInterNode,
+			expected: true,
+		},
+		{
+			name:     "other flow type",
+			filter:   RecordFilter{FlowTypes: []uint8{ipfixregistry.FlowTypeToExternal}},
+			flowType: ipfixregistry.FlowTypeInterNode,
+			expected: false,
+		},
+		{
+			name:     "matching source Pod Namespace",
+			filter:   RecordFilter{Namespaces: sets.NewString("ns")},
+			flowType: ipfixregistry.FlowTypeInterNode,
+			expected: true,
+		},
+		{
+			name:     "other Namespace",
+			filter:   RecordFilter{Namespaces: sets.NewString("other-ns")},
+			flowType: ipfixregistry.FlowTypeInterNode,
+			expected: false,
+		},
+		{
+			name:       "excluded deny connection",
+			filter:     RecordFilter{ExcludeDenyConnections: true},
+			flowType:   ipfixregistry.FlowTypeInterNode,
+			isDenyConn: true,
+			expected:   false,
+		},
+		{
+			name:     "allowed connection with deny connections excluded",
+			filter:   RecordFilter{ExcludeDenyConnections: true},
+			flowType: ipfixregistry.FlowTypeInterNode,
+			expected: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.filter.matches(conn, tt.flowType, tt.isDenyConn))
+		})
+	}
+}
@@ -16,6 +16,7 @@ package flowrecords
 
 import (
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
@@ -65,8 +66,8 @@ func (fr *FlowRecords) AddOrUpdateFlowRecord(key flowexporter.ConnectionKey, con
 			IsActive:           true,
 		}
 	} else {
-		// set IsActive flag to true when there are changes either in stats or TCP state
-		if (conn.OriginalPackets > record.PrevPackets) || (conn.ReversePackets > record.PrevReversePackets) || record.Conn.TCPState != conn.TCPState {
+		// set IsActive flag to true when there are changes either in stats or TCP/SCTP state
+		if (conn.OriginalPackets > record.PrevPackets) || (conn.ReversePackets > record.PrevReversePackets) || record.Conn.TCPState != conn.TCPState || record.Conn.SCTPState != conn.SCTPState {
 			record.IsActive = true
 		}
 		record.Conn = *conn
@@ -130,3 +131,27 @@ func (fr *FlowRecords) ForAllFlowRecordsDo(callback flowexporter.FlowRecordCallB
 	}
 	return nil
 }
+
+// EvictOldest deletes the given fraction of the records, starting with the records which were
+// exported the longest time ago, to reduce the memory usage when the records cannot be exported. It
+// returns the keys of the deleted records.
+func (fr *FlowRecords) EvictOldest(fraction float64) []flowexporter.ConnectionKey {
+	fr.mutex.Lock()
+	defer fr.mutex.Unlock()
+	n := int(float64(len(fr.recordsMap)) * fraction)
+	if n == 0 {
+		return nil
+	}
+	keys := make([]flowexporter.ConnectionKey, 0, len(fr.recordsMap))
+	for key := range fr.recordsMap {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return fr.recordsMap[keys[i]].LastExportTime.Before(fr.recordsMap[keys[j]].LastExportTime)
+	})
+	keys = keys[:n]
+	for _, key := range keys {
+		delete(fr.recordsMap, key)
+	}
+	return keys
+}
@@ -45,7 +45,10 @@ type Connection struct {
 	IsPresent bool
 	// DoneExport marks whether the related flow records are already exported or not so that we can
 	// safely delete the connection from the connection map.
-	DoneExport         bool
+	DoneExport bool
+	// PodDeleted marks the connections of a local Pod which has been deleted for longer than the
+	// grace period of its tombstone, so that their final records are exported right away.
+	PodDeleted         bool
 	Zone               uint16
 	Mark               uint32
 	StatusFlag         uint32
@@ -73,6 +76,7 @@ type Connection struct {
 	EgressNetworkPolicyRuleName    string
 	EgressNetworkPolicyRuleAction  uint8
 	TCPState                       string
+	SCTPState                      string
 	// fields specific to deny connections
 	// DeltaBytes and DeltaPackets are octetDeltaCount and packetDeltaCount over each active
 	// flow timeout duration.
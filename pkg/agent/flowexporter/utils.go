@@ -24,6 +24,10 @@ import (
 
 const (
 	connectionDyingFlag = uint32(1 << 9)
+	// PodDeletedReason is the flowEndReason of the connections ended because their local Pod
+	// was deleted. It is the "forced end" reason defined by IANA, which is not provided by the
+	// go-ipfix registry.
+	PodDeletedReason = uint8(0x04)
 )
 
 // NewConnectionKey creates 5-tuple of flow as connection key
@@ -42,6 +46,10 @@ func IsConnectionDying(conn *Connection) bool {
 	if conn.TCPState == "TIME_WAIT" || conn.TCPState == "CLOSE" {
 		return true
 	}
+	// "CLOSED" state indicates the SCTP association has been shut down or aborted.
+	if conn.SCTPState == "CLOSED" {
+		return true
+	}
 	// connections in other protocol with dying bit set
 	if conn.TCPState == "" && (conn.StatusFlag&connectionDyingFlag != 0) {
 		return true
@@ -50,6 +58,10 @@ func IsConnectionDying(conn *Connection) bool {
 	if !conn.IsPresent {
 		return true
 	}
+	// The local Pod of the connection has been deleted.
+	if conn.PodDeleted {
+		return true
+	}
 	return false
 }
 
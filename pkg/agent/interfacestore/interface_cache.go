@@ -64,6 +64,10 @@ const (
 type interfaceCache struct {
 	sync.RWMutex
 	cache cache.Indexer
+	// quarantinedPorts are the OVS ports with malformed external IDs found at startup.
+	quarantinedPorts []*QuarantinedPort
+	// deleteHandlers are notified of the deleted interfaces.
+	deleteHandlers []InterfaceDeleteHandler
 }
 
 func (c *interfaceCache) Initialize(interfaces []*InterfaceConfig) {
@@ -103,15 +107,41 @@ func (c *interfaceCache) AddInterface(interfaceConfig *InterfaceConfig) {
 	}
 }
 
-// DeleteInterface deletes interface from local cache.
-func (c *interfaceCache) DeleteInterface(interfaceConfig *InterfaceConfig) {
+// UpdateInterface replaces an interface in local cache with interfaceConfig, e.g. after its IPs
+// have changed. It returns false and does nothing if the interface is not in local cache, as it
+// may have been deleted concurrently. The replaced InterfaceConfig must not be modified, as it may
+// still be used by other components.
+func (c *interfaceCache) UpdateInterface(interfaceConfig *InterfaceConfig) bool {
 	c.Lock()
 	defer c.Unlock()
-	c.cache.Delete(interfaceConfig)
+	if _, exists, _ := c.cache.Get(interfaceConfig); !exists {
+		return false
+	}
+	c.cache.Update(interfaceConfig)
+	return true
+}
 
+// DeleteInterface deletes interface from local cache, and notifies the delete handlers.
+func (c *interfaceCache) DeleteInterface(interfaceConfig *InterfaceConfig) {
+	c.Lock()
+	c.cache.Delete(interfaceConfig)
 	if interfaceConfig.Type == ContainerInterface {
 		metrics.PodCount.Dec()
 	}
+	handlers := c.deleteHandlers
+	c.Unlock()
+
+	// The handlers are called without holding the lock, so that they can access the cache.
+	for _, handler := range handlers {
+		handler(interfaceConfig)
+	}
+}
+
+// AddDeleteHandler registers a handler notified of the interfaces deleted from local cache.
+func (c *interfaceCache) AddDeleteHandler(handler InterfaceDeleteHandler) {
+	c.Lock()
+	defer c.Unlock()
+	c.deleteHandlers = append(c.deleteHandlers, handler)
 }
 
 // GetInterface retrieves interface from local cache given the interface key.
@@ -221,6 +251,22 @@ func (c *interfaceCache) GetNodeTunnelInterface(nodeName string) (*InterfaceConf
 	return obj.(*InterfaceConfig), true
 }
 
+// SetQuarantinedPorts sets the OVS ports which could not be adopted because of malformed external
+// IDs.
+func (c *interfaceCache) SetQuarantinedPorts(ports []*QuarantinedPort) {
+	c.Lock()
+	defer c.Unlock()
+	c.quarantinedPorts = ports
+}
+
+// GetQuarantinedPorts returns the OVS ports which could not be adopted because of malformed external
+// IDs.
+func (c *interfaceCache) GetQuarantinedPorts() []*QuarantinedPort {
+	c.RLock()
+	defer c.RUnlock()
+	return c.quarantinedPorts
+}
+
 func interfaceNameIndexFunc(obj interface{}) ([]string, error) {
 	interfaceConfig := obj.(*InterfaceConfig)
 	return []string{interfaceConfig.InterfaceName}, nil
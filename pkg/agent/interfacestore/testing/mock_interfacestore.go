@@ -48,6 +48,18 @@ func (m *MockInterfaceStore) EXPECT() *MockInterfaceStoreMockRecorder {
 	return m.recorder
 }
 
+// AddDeleteHandler mocks base method
+func (m *MockInterfaceStore) AddDeleteHandler(arg0 interfacestore.InterfaceDeleteHandler) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "AddDeleteHandler", arg0)
+}
+
+// AddDeleteHandler indicates an expected call of AddDeleteHandler
+func (mr *MockInterfaceStoreMockRecorder) AddDeleteHandler(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddDeleteHandler", reflect.TypeOf((*MockInterfaceStore)(nil).AddDeleteHandler), arg0)
+}
+
 // AddInterface mocks base method
 func (m *MockInterfaceStore) AddInterface(arg0 *interfacestore.InterfaceConfig) {
 	m.ctrl.T.Helper()
@@ -202,6 +214,20 @@ func (mr *MockInterfaceStoreMockRecorder) GetInterfacesByType(arg0 interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInterfacesByType", reflect.TypeOf((*MockInterfaceStore)(nil).GetInterfacesByType), arg0)
 }
 
+// GetQuarantinedPorts mocks base method
+func (m *MockInterfaceStore) GetQuarantinedPorts() []*interfacestore.QuarantinedPort {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetQuarantinedPorts")
+	ret0, _ := ret[0].([]*interfacestore.QuarantinedPort)
+	return ret0
+}
+
+// GetQuarantinedPorts indicates an expected call of GetQuarantinedPorts
+func (mr *MockInterfaceStoreMockRecorder) GetQuarantinedPorts() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetQuarantinedPorts", reflect.TypeOf((*MockInterfaceStore)(nil).GetQuarantinedPorts))
+}
+
 // GetNodeTunnelInterface mocks base method
 func (m *MockInterfaceStore) GetNodeTunnelInterface(arg0 string) (*interfacestore.InterfaceConfig, bool) {
 	m.ctrl.T.Helper()
@@ -242,3 +268,29 @@ func (mr *MockInterfaceStoreMockRecorder) Len() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Len", reflect.TypeOf((*MockInterfaceStore)(nil).Len))
 }
+
+// SetQuarantinedPorts mocks base method
+func (m *MockInterfaceStore) SetQuarantinedPorts(arg0 []*interfacestore.QuarantinedPort) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetQuarantinedPorts", arg0)
+}
+
+// SetQuarantinedPorts indicates an expected call of SetQuarantinedPorts
+func (mr *MockInterfaceStoreMockRecorder) SetQuarantinedPorts(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetQuarantinedPorts", reflect.TypeOf((*MockInterfaceStore)(nil).SetQuarantinedPorts), arg0)
+}
+
+// UpdateInterface mocks base method
+func (m *MockInterfaceStore) UpdateInterface(arg0 *interfacestore.InterfaceConfig) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateInterface", arg0)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// UpdateInterface indicates an expected call of UpdateInterface
+func (mr *MockInterfaceStoreMockRecorder) UpdateInterface(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateInterface", reflect.TypeOf((*MockInterfaceStore)(nil).UpdateInterface), arg0)
+}
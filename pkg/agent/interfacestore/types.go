@@ -17,6 +17,7 @@ package interfacestore
 import (
 	"net"
 	"strconv"
+	"time"
 
 	"antrea.io/antrea/pkg/agent/util"
 	"antrea.io/antrea/pkg/ovs/ovsconfig"
@@ -48,6 +49,12 @@ type ContainerInterfaceConfig struct {
 	ContainerID  string
 	PodName      string
 	PodNamespace string
+	// Whether the Pod obtains its addresses using DHCP. The IPs of the interface are then
+	// updated when a lease is bound to the Pod or expires.
+	DHCP bool
+	// The maximum number of connections initiated by the Pod which are tracked at a time. 0 means
+	// that the connections of the Pod are not limited.
+	ConnectionLimit uint32
 }
 
 type TunnelInterfaceConfig struct {
@@ -62,6 +69,15 @@ type TunnelInterfaceConfig struct {
 	// Whether options:csum is set for this tunnel interface.
 	// If true, encapsulation header UDP checksums will be computed on outgoing packets.
 	Csum bool
+	// Destination port of the tunnel traffic set in options:dst_port, 0 if the default port of
+	// the tunnel type is used.
+	DstPort int32
+	// TOS of the tunnel traffic set in options:tos, empty if it is not set.
+	TOS string
+	// Name of the tunnel profile the tunnel interface is created for, if any.
+	Profile string
+	// Options set on the tunnel interface for the tunnel profile.
+	ProfileOptions map[string]string
 }
 
 type InterfaceConfig struct {
@@ -70,17 +86,40 @@ type InterfaceConfig struct {
 	InterfaceName string
 	IPs           []net.IP
 	MAC           net.HardwareAddr
+	// Time at which the interface was created. It is zero if the interface was restored from
+	// the OVS bridge and the time is unknown.
+	CreationTimestamp time.Time
+	// Time at which the IPs were last updated after the creation of the interface, e.g. when a
+	// DHCP lease was bound to the Pod. It is zero if the IPs have never been updated.
+	IPUpdateTimestamp time.Time
 	*OVSPortConfig
 	*ContainerInterfaceConfig
 	*TunnelInterfaceConfig
 }
 
+// QuarantinedPort is an OVS port found at startup whose external IDs identify it as a container
+// port, but are malformed (e.g. truncated) so that it cannot be adopted as an interface. It is
+// kept out of the interface store and reported for manual cleanup.
+type QuarantinedPort struct {
+	Name        string
+	PortUUID    string
+	OFPort      int32
+	ExternalIDs map[string]string
+	// Reason describes why the external IDs are malformed.
+	Reason string
+}
+
+// InterfaceDeleteHandler is notified of the interfaces deleted from the InterfaceStore.
+type InterfaceDeleteHandler func(interfaceConfig *InterfaceConfig)
+
 // InterfaceStore is a service interface to create local interfaces for container, host gateway, and tunnel port.
 // Support add/delete/get operations
 type InterfaceStore interface {
 	Initialize(interfaces []*InterfaceConfig)
 	AddInterface(interfaceConfig *InterfaceConfig)
+	UpdateInterface(interfaceConfig *InterfaceConfig) bool
 	DeleteInterface(interfaceConfig *InterfaceConfig)
+	AddDeleteHandler(handler InterfaceDeleteHandler)
 	GetInterface(interfaceKey string) (*InterfaceConfig, bool)
 	GetInterfaceByName(interfaceName string) (*InterfaceConfig, bool)
 	GetContainerInterface(containerID string) (*InterfaceConfig, bool)
@@ -92,6 +131,8 @@ type InterfaceStore interface {
 	GetInterfacesByType(interfaceType InterfaceType) []*InterfaceConfig
 	Len() int
 	GetInterfaceKeysByType(interfaceType InterfaceType) []string
+	SetQuarantinedPorts(ports []*QuarantinedPort)
+	GetQuarantinedPorts() []*QuarantinedPort
 }
 
 // NewContainerInterface creates InterfaceConfig for a Pod.
@@ -122,8 +163,8 @@ func NewGatewayInterface(gatewayName string) *InterfaceConfig {
 
 // NewTunnelInterface creates InterfaceConfig for the default tunnel port
 // interface.
-func NewTunnelInterface(tunnelName string, tunnelType ovsconfig.TunnelType, localIP net.IP, csum bool) *InterfaceConfig {
-	tunnelConfig := &TunnelInterfaceConfig{Type: tunnelType, LocalIP: localIP, Csum: csum}
+func NewTunnelInterface(tunnelName string, tunnelType ovsconfig.TunnelType, localIP net.IP, csum bool, dstPort int32, tos string) *InterfaceConfig {
+	tunnelConfig := &TunnelInterfaceConfig{Type: tunnelType, LocalIP: localIP, Csum: csum, DstPort: dstPort, TOS: tos}
 	return &InterfaceConfig{InterfaceName: tunnelName, Type: TunnelInterface, TunnelInterfaceConfig: tunnelConfig}
 }
 
@@ -134,6 +175,13 @@ func NewIPSecTunnelInterface(interfaceName string, tunnelType ovsconfig.TunnelTy
 	return &InterfaceConfig{InterfaceName: interfaceName, Type: TunnelInterface, TunnelInterfaceConfig: tunnelConfig}
 }
 
+// NewTunnelProfileInterface creates InterfaceConfig for the tunnel to the Node
+// created for a tunnel profile.
+func NewTunnelProfileInterface(interfaceName string, tunnelType ovsconfig.TunnelType, nodeName string, nodeIP net.IP, profile string, options map[string]string) *InterfaceConfig {
+	tunnelConfig := &TunnelInterfaceConfig{Type: tunnelType, NodeName: nodeName, RemoteIP: nodeIP, Profile: profile, ProfileOptions: options}
+	return &InterfaceConfig{InterfaceName: interfaceName, Type: TunnelInterface, TunnelInterfaceConfig: tunnelConfig}
+}
+
 // NewUplinkInterface creates InterfaceConfig for the uplink interface.
 func NewUplinkInterface(uplinkName string) *InterfaceConfig {
 	uplinkConfig := &InterfaceConfig{InterfaceName: uplinkName, Type: UplinkInterface}
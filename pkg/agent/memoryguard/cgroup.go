@@ -0,0 +1,87 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memoryguard
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultCgroupRoot = "/sys/fs/cgroup"
+
+	// cgroup v2 files, in the cgroup of the container.
+	cgroupV2UsageFile = "memory.current"
+	cgroupV2LimitFile = "memory.max"
+	// cgroup v1 files, in the memory controller hierarchy.
+	cgroupV1UsageFile = "memory/memory.usage_in_bytes"
+	cgroupV1LimitFile = "memory/memory.limit_in_bytes"
+
+	// cgroupV1Unlimited is the lowest limit reported by cgroup v1 when the memory is not limited,
+	// which is the largest int64 rounded down to the page size.
+	cgroupV1Unlimited = uint64(1) << 62
+)
+
+// cgroupUsageProvider provides the memory usage and limit of the cgroup of the agent container,
+// with either cgroup v2 or cgroup v1.
+type cgroupUsageProvider struct {
+	root string
+}
+
+// NewCgroupUsageProvider returns a UsageProvider reading the memory usage and limit of the cgroup
+// of the agent container, which must be mounted at /sys/fs/cgroup.
+func NewCgroupUsageProvider() UsageProvider {
+	return &cgroupUsageProvider{root: defaultCgroupRoot}
+}
+
+func (p *cgroupUsageProvider) GetMemoryUsage() (uint64, uint64, error) {
+	usageFile, limitFile := cgroupV2UsageFile, cgroupV2LimitFile
+	if _, err := os.Stat(filepath.Join(p.root, usageFile)); err != nil {
+		usageFile, limitFile = cgroupV1UsageFile, cgroupV1LimitFile
+	}
+	usage, err := readCgroupValue(filepath.Join(p.root, usageFile))
+	if err != nil {
+		return 0, 0, err
+	}
+	limit, err := readCgroupValue(filepath.Join(p.root, limitFile))
+	if err != nil {
+		return 0, 0, err
+	}
+	if limit >= cgroupV1Unlimited {
+		limit = 0
+	}
+	return usage, limit, nil
+}
+
+// readCgroupValue reads a cgroup file holding a number of bytes. "max" is read as 0, i.e. no limit.
+func readCgroupValue(path string) (uint64, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	value := strings.TrimSpace(string(data))
+	if value == "max" {
+		return 0, nil
+	}
+	v, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q in %s: %v", value, path, err)
+	}
+	return v, nil
+}
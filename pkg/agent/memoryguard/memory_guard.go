@@ -0,0 +1,130 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memoryguard
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+
+	"antrea.io/antrea/pkg/agent/metrics"
+)
+
+const (
+	// checkInterval is the interval between two checks of the memory usage.
+	checkInterval = 5 * time.Second
+)
+
+// UsageProvider provides the memory usage of the agent and its memory limit, in bytes. The limit is
+// 0 if the memory of the agent is not limited.
+type UsageProvider interface {
+	GetMemoryUsage() (usage uint64, limit uint64, err error)
+}
+
+// component is a component of the agent whose memory usage can be reduced, e.g. by dropping the
+// oldest items of a buffer.
+type component struct {
+	name    string
+	reduce  func() int
+	restore func()
+}
+
+// Guard prevents the agent from being OOM killed when one of its buffers grows unbounded, e.g.
+// because the flow collector is down. When the memory usage of the agent is above a watermark of
+// its limit, the registered components are asked to reduce their memory usage, at every check until
+// the usage is back below the watermark.
+type Guard struct {
+	usageProvider UsageProvider
+	// watermark is the percentage of the memory limit above which the components are asked to
+	// reduce their memory usage.
+	watermark uint64
+	// componentsMutex protects watermark, components and underPressure.
+	componentsMutex sync.Mutex
+	components      []component
+	underPressure   bool
+}
+
+// NewGuard creates a Guard checking the memory usage provided by usageProvider against the
+// watermark, a percentage of the memory limit.
+func NewGuard(usageProvider UsageProvider, watermark int) *Guard {
+	return &Guard{
+		usageProvider: usageProvider,
+		watermark:     uint64(watermark),
+	}
+}
+
+// Register registers a component whose memory usage can be reduced. reduce is called at every
+// check while the memory usage is above the watermark, and returns the number of items it dropped.
+// restore, if not nil, is called once the memory usage is back below the watermark, e.g. to restore
+// the size of a buffer.
+func (g *Guard) Register(name string, reduce func() int, restore func()) {
+	g.componentsMutex.Lock()
+	defer g.componentsMutex.Unlock()
+	g.components = append(g.components, component{name: name, reduce: reduce, restore: restore})
+}
+
+// SetWatermark changes the watermark, e.g. when the agent configuration is reloaded. It's applied
+// from the next check.
+func (g *Guard) SetWatermark(watermark int) {
+	g.componentsMutex.Lock()
+	defer g.componentsMutex.Unlock()
+	g.watermark = uint64(watermark)
+}
+
+// Run checks the memory usage periodically until stopCh is closed.
+func (g *Guard) Run(stopCh <-chan struct{}) {
+	g.componentsMutex.Lock()
+	klog.Infof("Starting memory guard with a watermark of %d%% of the memory limit", g.watermark)
+	g.componentsMutex.Unlock()
+	wait.Until(g.check, checkInterval, stopCh)
+}
+
+// check compares the memory usage with the watermark and calls the registered components
+// accordingly.
+func (g *Guard) check() {
+	usage, limit, err := g.usageProvider.GetMemoryUsage()
+	if err != nil {
+		klog.V(2).Infof("Failed to get the memory usage of the agent: %v", err)
+		return
+	}
+	g.componentsMutex.Lock()
+	defer g.componentsMutex.Unlock()
+	if limit == 0 || usage*100 < limit*g.watermark {
+		if g.underPressure {
+			klog.Infof("Memory usage %d bytes is back below %d%% of the limit %d bytes", usage, g.watermark, limit)
+			for _, c := range g.components {
+				if c.restore != nil {
+					c.restore()
+				}
+			}
+			g.underPressure = false
+		}
+		return
+	}
+	if !g.underPressure {
+		klog.Warningf("Memory usage %d bytes is above %d%% of the limit %d bytes, reducing buffers", usage, g.watermark, limit)
+		g.underPressure = true
+	}
+	for _, c := range g.components {
+		dropped := c.reduce()
+		if dropped == 0 {
+			continue
+		}
+		metrics.MemoryGuardDroppedItemCount.WithLabelValues(c.name).Add(float64(dropped))
+		klog.Warningf("Memory guard dropped %d items of %s", dropped, c.name)
+	}
+}
@@ -0,0 +1,169 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memoryguard
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeUsageProvider struct {
+	usage uint64
+	limit uint64
+	err   error
+}
+
+func (p *fakeUsageProvider) GetMemoryUsage() (uint64, uint64, error) {
+	return p.usage, p.limit, p.err
+}
+
+type fakeComponent struct {
+	items    int
+	reduced  int
+	restored int
+}
+
+func (c *fakeComponent) reduce() int {
+	c.reduced++
+	dropped := c.items / 2
+	c.items -= dropped
+	return dropped
+}
+
+func (c *fakeComponent) restore() {
+	c.restored++
+}
+
+func TestGuard(t *testing.T) {
+	provider := &fakeUsageProvider{usage: 500, limit: 1000}
+	g := NewGuard(provider, 90)
+	c := &fakeComponent{items: 100}
+	g.Register("fake", c.reduce, c.restore)
+
+	g.check()
+	assert.Equal(t, 0, c.reduced)
+
+	// The components are asked to reduce their memory usage at every check above the watermark.
+	provider.usage = 900
+	g.check()
+	g.check()
+	assert.Equal(t, 2, c.reduced)
+	assert.Equal(t, 25, c.items)
+	assert.Equal(t, 0, c.restored)
+
+	// Errors don't change the state of the guard.
+	provider.err = errors.New("cgroup not found")
+	g.check()
+	assert.Equal(t, 2, c.reduced)
+	assert.Equal(t, 0, c.restored)
+
+	// The components are restored once, when the usage goes back below the watermark.
+	provider.err = nil
+	provider.usage = 800
+	g.check()
+	g.check()
+	assert.Equal(t, 2, c.reduced)
+	assert.Equal(t, 1, c.restored)
+
+	// The guard does nothing when the memory is not limited.
+	provider.usage, provider.limit = 900, 0
+	g.check()
+	assert.Equal(t, 2, c.reduced)
+}
+
+func TestGuardSetWatermark(t *testing.T) {
+	provider := &fakeUsageProvider{usage: 800, limit: 1000}
+	g := NewGuard(provider, 90)
+	c := &fakeComponent{items: 100}
+	g.Register("fake", c.reduce, c.restore)
+
+	g.check()
+	assert.Equal(t, 0, c.reduced)
+
+	// The usage is above the lowered watermark.
+	g.SetWatermark(70)
+	g.check()
+	assert.Equal(t, 1, c.reduced)
+
+	// The components are restored when the watermark is raised above the usage.
+	g.SetWatermark(85)
+	g.check()
+	assert.Equal(t, 1, c.reduced)
+	assert.Equal(t, 1, c.restored)
+}
+
+func TestCgroupUsageProvider(t *testing.T) {
+	tests := []struct {
+		name          string
+		files         map[string]string
+		expectedUsage uint64
+		expectedLimit uint64
+		expectedErr   bool
+	}{
+		{
+			name:          "cgroup v2",
+			files:         map[string]string{cgroupV2UsageFile: "1048576\n", cgroupV2LimitFile: "2097152\n"},
+			expectedUsage: 1048576,
+			expectedLimit: 2097152,
+		},
+		{
+			name:          "cgroup v2 without limit",
+			files:         map[string]string{cgroupV2UsageFile: "1048576\n", cgroupV2LimitFile: "max\n"},
+			expectedUsage: 1048576,
+		},
+		{
+			name:          "cgroup v1",
+			files:         map[string]string{cgroupV1UsageFile: "1048576\n", cgroupV1LimitFile: "2097152\n"},
+			expectedUsage: 1048576,
+			expectedLimit: 2097152,
+		},
+		{
+			name:          "cgroup v1 without limit",
+			files:         map[string]string{cgroupV1UsageFile: "1048576\n", cgroupV1LimitFile: "9223372036854771712\n"},
+			expectedUsage: 1048576,
+		},
+		{
+			name:        "no cgroup",
+			expectedErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, err := ioutil.TempDir("", "cgroup")
+			require.NoError(t, err)
+			defer os.RemoveAll(root)
+			for name, content := range tt.files {
+				path := filepath.Join(root, name)
+				require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+				require.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+			}
+			p := &cgroupUsageProvider{root: root}
+			usage, limit, err := p.GetMemoryUsage()
+			if tt.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedUsage, usage)
+			assert.Equal(t, tt.expectedLimit, limit)
+		})
+	}
+}
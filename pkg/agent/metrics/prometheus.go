@@ -66,6 +66,62 @@ var (
 		},
 	)
 
+	NetworkPolicyCacheLockWaitDuration = metrics.NewHistogramVec(
+		&metrics.HistogramOpts{
+			Namespace:      metricNamespaceAntrea,
+			Subsystem:      metricSubsystemAgent,
+			Name:           "networkpolicy_cache_lock_wait_microseconds",
+			Help:           "Time spent waiting for the locks of the NetworkPolicy rule cache, partitioned by cache (policy, appliedtogroup and addressgroup).",
+			Buckets:        metrics.ExponentialBuckets(1, 4, 10),
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"cache"},
+	)
+
+	NetworkPolicyRealizationErrors = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Namespace:      metricNamespaceAntrea,
+			Subsystem:      metricSubsystemAgent,
+			Name:           "networkpolicy_realization_errors_total",
+			Help:           "Number of failures to realize NetworkPolicy rules, partitioned by error class (IDAllocation, PriorityAssignment, FlowInstall, FlowUpdate, FlowUninstall and Unknown).",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"class"},
+	)
+
+	NetworkPolicyRuleQueueDepth = metrics.NewGaugeVec(
+		&metrics.GaugeOpts{
+			Namespace:      metricNamespaceAntrea,
+			Subsystem:      metricSubsystemAgent,
+			Name:           "networkpolicy_rule_queue_depth",
+			Help:           "Number of NetworkPolicy rules waiting to be reconciled, partitioned by worker.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"worker"},
+	)
+
+	NetworkPolicySyncDuration = metrics.NewHistogram(
+		&metrics.HistogramOpts{
+			Namespace:      metricNamespaceAntrea,
+			Subsystem:      metricSubsystemAgent,
+			Name:           "networkpolicy_sync_duration_milliseconds",
+			Help:           "Time taken by a worker to reconcile the changed rules of a NetworkPolicy, observed once per NetworkPolicy synced.",
+			Buckets:        metrics.ExponentialBuckets(1, 2, 15),
+			StabilityLevel: metrics.ALPHA,
+		},
+	)
+
+	NetworkPolicyWatchSecondsSinceLastEvent = metrics.NewGaugeVec(
+		&metrics.GaugeOpts{
+			Namespace:      metricNamespaceAntrea,
+			Subsystem:      metricSubsystemAgent,
+			Name:           "networkpolicy_watch_seconds_since_last_event",
+			Help:           "Number of seconds since the last event was received from the Antrea Controller, partitioned by watch type (NetworkPolicy, AppliedToGroup and AddressGroup).",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"type"},
+	)
+
 	OVSTotalFlowCount = metrics.NewGauge(&metrics.GaugeOpts{
 		Namespace:      metricNamespaceAntrea,
 		Subsystem:      metricSubsystemAgent,
@@ -83,6 +139,14 @@ var (
 		StabilityLevel: metrics.STABLE,
 	}, []string{"table_id"})
 
+	OVSRestoredPortCount = metrics.NewGaugeVec(&metrics.GaugeOpts{
+		Namespace:      metricNamespaceAntrea,
+		Subsystem:      metricSubsystemAgent,
+		Name:           "ovs_restored_port_count",
+		Help:           "Number of OVS ports found when the agent started, partitioned by result (adopted in the interface store, or quarantined because of malformed external IDs).",
+		StabilityLevel: metrics.ALPHA,
+	}, []string{"result"})
+
 	OVSFlowOpsCount = metrics.NewCounterVec(
 		&metrics.CounterOpts{
 			Namespace:      metricNamespaceAntrea,
@@ -116,6 +180,52 @@ var (
 		[]string{"operation"},
 	)
 
+	OVSConnectionFailureCount = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Namespace:      metricNamespaceAntrea,
+			Subsystem:      metricSubsystemAgent,
+			Name:           "ovs_connection_failure_count",
+			Help:           "Number of connections to OVS re-established because no reply was received to the keepalive probes, partitioned by connection (openflow and ovsdb).",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"connection"},
+	)
+
+	OVSConnectionFailureDetectionLatency = metrics.NewHistogramVec(
+		&metrics.HistogramOpts{
+			Namespace:      metricNamespaceAntrea,
+			Subsystem:      metricSubsystemAgent,
+			Name:           "ovs_connection_failure_detection_latency_seconds",
+			Help:           "The time between the last reply received on a connection to OVS and the detection of its failure by the keepalive probes, partitioned by connection (openflow and ovsdb).",
+			Buckets:        metrics.ExponentialBuckets(0.5, 2, 8),
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"connection"},
+	)
+
+	CNICmdLatency = metrics.NewHistogramVec(
+		&metrics.HistogramOpts{
+			Namespace:      metricNamespaceAntrea,
+			Subsystem:      metricSubsystemAgent,
+			Name:           "cni_cmd_latency_milliseconds",
+			Help:           "The latency of CNI commands, partitioned by command (add and del) and by phase (ipam, interface, ovs_port, flows and total).",
+			Buckets:        metrics.ExponentialBuckets(1, 2, 15),
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"command", "phase"},
+	)
+
+	CNICmdFailureCount = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Namespace:      metricNamespaceAntrea,
+			Subsystem:      metricSubsystemAgent,
+			Name:           "cni_cmd_failure_count",
+			Help:           "Number of failed CNI commands, partitioned by command (add and del) and by failure reason.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"command", "reason"},
+	)
+
 	TotalConnectionsInConnTrackTable = metrics.NewGauge(
 		&metrics.GaugeOpts{
 			Namespace:      metricNamespaceAntrea,
@@ -155,6 +265,154 @@ var (
 			StabilityLevel: metrics.ALPHA,
 		},
 	)
+
+	ControlplaneCompressedResponseBytes = metrics.NewCounter(
+		&metrics.CounterOpts{
+			Namespace:      metricNamespaceAntrea,
+			Subsystem:      metricSubsystemAgent,
+			Name:           "controlplane_response_compressed_bytes",
+			Help:           "Total number of bytes of the gzip-encoded controlplane API responses received from the Antrea Controller, before decompression.",
+			StabilityLevel: metrics.ALPHA,
+		},
+	)
+
+	ControlplaneRawResponseBytes = metrics.NewCounter(
+		&metrics.CounterOpts{
+			Namespace:      metricNamespaceAntrea,
+			Subsystem:      metricSubsystemAgent,
+			Name:           "controlplane_response_raw_bytes",
+			Help:           "Total number of bytes of the gzip-encoded controlplane API responses received from the Antrea Controller, after decompression.",
+			StabilityLevel: metrics.ALPHA,
+		},
+	)
+
+	PeerNodeLatency = metrics.NewGaugeVec(
+		&metrics.GaugeOpts{
+			Namespace:      metricNamespaceAntrea,
+			Subsystem:      metricSubsystemAgent,
+			Name:           "peer_node_latency_milliseconds",
+			Help:           "Round-trip time of the last successful probe of the gateway of each peer Node. The peer Node name is used as a label. This metric is only available when the NodeLatencyMonitor feature is enabled.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"peer_node"},
+	)
+
+	PeerNodePacketLoss = metrics.NewGaugeVec(
+		&metrics.GaugeOpts{
+			Namespace:      metricNamespaceAntrea,
+			Subsystem:      metricSubsystemAgent,
+			Name:           "peer_node_packet_loss_ratio",
+			Help:           "Ratio of failed probes among the most recent probes of the gateway of each peer Node. The peer Node name is used as a label. This metric is only available when the NodeLatencyMonitor feature is enabled.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"peer_node"},
+	)
+
+	PendingPeerNodeCount = metrics.NewGauge(
+		&metrics.GaugeOpts{
+			Namespace:      metricNamespaceAntrea,
+			Subsystem:      metricSubsystemAgent,
+			Name:           "pending_peer_node_count",
+			Help:           "Number of peer Nodes whose routes and flows are not installed yet because their PodCIDR or transport address is not set. They are retried with backoff until the information is available.",
+			StabilityLevel: metrics.ALPHA,
+		},
+	)
+
+	NDGuardDroppedPacketCount = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Namespace:      metricNamespaceAntrea,
+			Subsystem:      metricSubsystemAgent,
+			Name:           "nd_guard_dropped_packet_count",
+			Help:           "Number of IPv6 Neighbor Discovery messages sent by local Pods and dropped by the ND guard. The message type is used as a label. The messages exceeding the packet-in rate limit are dropped without being counted.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"type"},
+	)
+
+	ServiceLoopGuardDroppedPacketCount = metrics.NewCounter(
+		&metrics.CounterOpts{
+			Namespace:      metricNamespaceAntrea,
+			Subsystem:      metricSubsystemAgent,
+			Name:           "service_loop_guard_dropped_packet_count",
+			Help:           "Number of packets accessing a Service which are dropped by the Service loop guard, because their Endpoint was reselected too many times without being found. The packets exceeding the packet-in rate limit are dropped without being counted.",
+			StabilityLevel: metrics.ALPHA,
+		},
+	)
+
+	ServiceSourceRangeDroppedPacketCount = metrics.NewCounter(
+		&metrics.CounterOpts{
+			Namespace:      metricNamespaceAntrea,
+			Subsystem:      metricSubsystemAgent,
+			Name:           "service_source_range_dropped_packet_count",
+			Help:           "Number of packets accessing a LoadBalancer Service IP which are dropped because their source is not in the loadBalancerSourceRanges of the Service. The packets exceeding the packet-in rate limit are dropped without being counted.",
+			StabilityLevel: metrics.ALPHA,
+		},
+	)
+
+	PodConnectionLimitReachedCount = metrics.NewCounter(
+		&metrics.CounterOpts{
+			Namespace:      metricNamespaceAntrea,
+			Subsystem:      metricSubsystemAgent,
+			Name:           "pod_connection_limit_reached_count",
+			Help:           "Number of times a local Pod reached its connection limit, after which its new connections are dropped until some of its connections are closed.",
+			StabilityLevel: metrics.ALPHA,
+		},
+	)
+
+	MemoryGuardDroppedItemCount = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Namespace:      metricNamespaceAntrea,
+			Subsystem:      metricSubsystemAgent,
+			Name:           "memory_guard_dropped_item_count",
+			Help:           "Number of items dropped by the memory guard to reduce the memory usage of the agent when it is above the watermark. The component dropping the items is used as a label.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"component"},
+	)
+
+	ServiceCIDRDiscoveryMismatch = metrics.NewGaugeVec(
+		&metrics.GaugeOpts{
+			Namespace:      metricNamespaceAntrea,
+			Subsystem:      metricSubsystemAgent,
+			Name:           "service_cidr_discovery_mismatch",
+			Help:           "Whether the Service CIDR discovered from the ClusterIPs of the Services is not included in the configured Service CIDR (1) or is (0). The IP family is used as a label.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"ip_family"},
+	)
+
+	AgentConditionStatus = metrics.NewGaugeVec(
+		&metrics.GaugeOpts{
+			Namespace:      metricNamespaceAntrea,
+			Subsystem:      metricSubsystemAgent,
+			Name:           "condition_status",
+			Help:           "Status of the conditions reported in the AntreaAgentInfo of the agent: 1 if True, 0 if False and -1 if Unknown. The condition type is used as a label.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"type"},
+	)
+
+	OTelExporterExportedRecordCount = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Namespace:      metricNamespaceAntrea,
+			Subsystem:      metricSubsystemAgent,
+			Name:           "otel_exporter_exported_record_count",
+			Help:           "Number of log records exported to the OpenTelemetry collector. The type of the records is used as a label.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"type"},
+	)
+
+	OTelExporterDroppedRecordCount = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Namespace:      metricNamespaceAntrea,
+			Subsystem:      metricSubsystemAgent,
+			Name:           "otel_exporter_dropped_record_count",
+			Help:           "Number of log records dropped by the OpenTelemetry exporter, because the queue was full or the export failed. The type of the records and the reason are used as labels.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"type", "reason"},
+	)
 )
 
 func InitializePrometheusMetrics() {
@@ -164,6 +422,18 @@ func InitializePrometheusMetrics() {
 	InitializeNetworkPolicyMetrics()
 	InitializeOVSMetrics()
 	InitializeConnectionMetrics()
+	InitializeCNIMetrics()
+	InitializeControlplaneMetrics()
+	InitializeNodeLatencyMetrics()
+	InitializeNodeRouteMetrics()
+	InitializeNDGuardMetrics()
+	InitializeServiceLoopGuardMetrics()
+	InitializeServiceSourceRangeMetrics()
+	InitializePodConnectionLimitMetrics()
+	InitializeMemoryGuardMetrics()
+	InitializeServiceCIDRMetrics()
+	InitializeOTelExporterMetrics()
+	InitializeAgentConditionMetrics()
 }
 
 func InitializePodMetrics() {
@@ -184,6 +454,26 @@ func InitializeNetworkPolicyMetrics() {
 	if err := legacyregistry.Register(NetworkPolicyCount); err != nil {
 		klog.Error("Failed to register antrea_agent_networkpolicy_count with Prometheus")
 	}
+
+	if err := legacyregistry.Register(NetworkPolicyCacheLockWaitDuration); err != nil {
+		klog.Error("Failed to register antrea_agent_networkpolicy_cache_lock_wait_microseconds with Prometheus")
+	}
+
+	if err := legacyregistry.Register(NetworkPolicyRealizationErrors); err != nil {
+		klog.Error("Failed to register antrea_agent_networkpolicy_realization_errors_total with Prometheus")
+	}
+
+	if err := legacyregistry.Register(NetworkPolicyRuleQueueDepth); err != nil {
+		klog.Error("Failed to register antrea_agent_networkpolicy_rule_queue_depth with Prometheus")
+	}
+
+	if err := legacyregistry.Register(NetworkPolicySyncDuration); err != nil {
+		klog.Error("Failed to register antrea_agent_networkpolicy_sync_duration_milliseconds with Prometheus")
+	}
+
+	if err := legacyregistry.Register(NetworkPolicyWatchSecondsSinceLastEvent); err != nil {
+		klog.Error("Failed to register antrea_agent_networkpolicy_watch_seconds_since_last_event with Prometheus")
+	}
 }
 
 func InitializeOVSMetrics() {
@@ -194,6 +484,10 @@ func InitializeOVSMetrics() {
 		klog.Error("Failed to register antrea_agent_ovs_flow_count with Prometheus")
 	}
 
+	if err := legacyregistry.Register(OVSRestoredPortCount); err != nil {
+		klog.Error("Failed to register antrea_agent_ovs_restored_port_count with Prometheus")
+	}
+
 	if err := legacyregistry.Register(OVSFlowOpsCount); err != nil {
 		klog.Error("Failed to register antrea_agent_ovs_flow_ops_count with Prometheus")
 	}
@@ -203,6 +497,12 @@ func InitializeOVSMetrics() {
 	if err := legacyregistry.Register(OVSFlowOpsLatency); err != nil {
 		klog.Error("Failed to register antrea_agent_ovs_flow_ops_latency_milliseconds with Prometheus")
 	}
+	if err := legacyregistry.Register(OVSConnectionFailureCount); err != nil {
+		klog.Error("Failed to register antrea_agent_ovs_connection_failure_count with Prometheus")
+	}
+	if err := legacyregistry.Register(OVSConnectionFailureDetectionLatency); err != nil {
+		klog.Error("Failed to register antrea_agent_ovs_connection_failure_detection_latency_seconds with Prometheus")
+	}
 	// Initialize OpenFlow operations metrics with label add, modify and delete
 	// since those metrics won't come out until observation.
 	opsArray := [3]string{"add", "modify", "delete"}
@@ -227,3 +527,87 @@ func InitializeConnectionMetrics() {
 		klog.Errorf("Failed to register antrea_agent_conntrack_max_connection_count with error: %v", err)
 	}
 }
+
+func InitializeCNIMetrics() {
+	if err := legacyregistry.Register(CNICmdLatency); err != nil {
+		klog.Errorf("Failed to register antrea_agent_cni_cmd_latency_milliseconds with error: %v", err)
+	}
+	if err := legacyregistry.Register(CNICmdFailureCount); err != nil {
+		klog.Errorf("Failed to register antrea_agent_cni_cmd_failure_count with error: %v", err)
+	}
+}
+
+func InitializeControlplaneMetrics() {
+	if err := legacyregistry.Register(ControlplaneCompressedResponseBytes); err != nil {
+		klog.Errorf("Failed to register antrea_agent_controlplane_response_compressed_bytes with error: %v", err)
+	}
+	if err := legacyregistry.Register(ControlplaneRawResponseBytes); err != nil {
+		klog.Errorf("Failed to register antrea_agent_controlplane_response_raw_bytes with error: %v", err)
+	}
+}
+
+func InitializeNodeLatencyMetrics() {
+	if err := legacyregistry.Register(PeerNodeLatency); err != nil {
+		klog.Errorf("Failed to register antrea_agent_peer_node_latency_milliseconds with error: %v", err)
+	}
+	if err := legacyregistry.Register(PeerNodePacketLoss); err != nil {
+		klog.Errorf("Failed to register antrea_agent_peer_node_packet_loss_ratio with error: %v", err)
+	}
+}
+
+func InitializeNodeRouteMetrics() {
+	if err := legacyregistry.Register(PendingPeerNodeCount); err != nil {
+		klog.Errorf("Failed to register antrea_agent_pending_peer_node_count with error: %v", err)
+	}
+}
+
+func InitializeNDGuardMetrics() {
+	if err := legacyregistry.Register(NDGuardDroppedPacketCount); err != nil {
+		klog.Errorf("Failed to register antrea_agent_nd_guard_dropped_packet_count with error: %v", err)
+	}
+}
+
+func InitializeServiceLoopGuardMetrics() {
+	if err := legacyregistry.Register(ServiceLoopGuardDroppedPacketCount); err != nil {
+		klog.Errorf("Failed to register antrea_agent_service_loop_guard_dropped_packet_count with error: %v", err)
+	}
+}
+
+func InitializeServiceSourceRangeMetrics() {
+	if err := legacyregistry.Register(ServiceSourceRangeDroppedPacketCount); err != nil {
+		klog.Errorf("Failed to register antrea_agent_service_source_range_dropped_packet_count with error: %v", err)
+	}
+}
+
+func InitializePodConnectionLimitMetrics() {
+	if err := legacyregistry.Register(PodConnectionLimitReachedCount); err != nil {
+		klog.Errorf("Failed to register antrea_agent_pod_connection_limit_reached_count with error: %v", err)
+	}
+}
+
+func InitializeMemoryGuardMetrics() {
+	if err := legacyregistry.Register(MemoryGuardDroppedItemCount); err != nil {
+		klog.Errorf("Failed to register antrea_agent_memory_guard_dropped_item_count with error: %v", err)
+	}
+}
+
+func InitializeServiceCIDRMetrics() {
+	if err := legacyregistry.Register(ServiceCIDRDiscoveryMismatch); err != nil {
+		klog.Errorf("Failed to register antrea_agent_service_cidr_discovery_mismatch with error: %v", err)
+	}
+}
+
+func InitializeOTelExporterMetrics() {
+	if err := legacyregistry.Register(OTelExporterExportedRecordCount); err != nil {
+		klog.Errorf("Failed to register antrea_agent_otel_exporter_exported_record_count with error: %v", err)
+	}
+	if err := legacyregistry.Register(OTelExporterDroppedRecordCount); err != nil {
+		klog.Errorf("Failed to register antrea_agent_otel_exporter_dropped_record_count with error: %v", err)
+	}
+}
+
+func InitializeAgentConditionMetrics() {
+	if err := legacyregistry.Register(AgentConditionStatus); err != nil {
+		klog.Errorf("Failed to register antrea_agent_condition_status with error: %v", err)
+	}
+}
@@ -0,0 +1,307 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nodelatency
+
+import (
+	"math"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/containernetworking/plugins/pkg/ip"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/informers"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	"antrea.io/antrea/pkg/agent/metrics"
+	"antrea.io/antrea/pkg/querier"
+)
+
+const (
+	controllerName = "AntreaAgentNodeLatencyMonitor"
+	// probeInterval is the interval between two rounds of probes.
+	probeInterval = 10 * time.Second
+	// probeTimeout is how long to wait for the replies of a round of probes. It must be
+	// shorter than probeInterval.
+	probeTimeout = 3 * time.Second
+	// unreachableThreshold is the number of consecutive failed probes after which a peer Node
+	// is reported as unreachable.
+	unreachableThreshold = 3
+	// lossWindow is the number of most recent probes used to compute the packet loss of a
+	// peer Node.
+	lossWindow = 10
+	// minPeersPerRound is the number of peer Nodes probed in each round regardless of the
+	// cluster size, so that all peer Nodes are probed in each round in small clusters.
+	minPeersPerRound = 32
+	// peersPerRoundFactor is multiplied by the square root of the number of peer Nodes to get
+	// the number of peer Nodes probed in each round in large clusters.
+	peersPerRoundFactor = 4
+)
+
+// prober sends probes to a set of IPs and waits for their replies.
+type prober interface {
+	// probe sends a probe to each IP, and returns the RTT of the replies received before
+	// timeout, keyed by IP string.
+	probe(ips []net.IP, timeout time.Duration) map[string]time.Duration
+}
+
+type peerState struct {
+	gatewayIP     net.IP
+	lastRTT       time.Duration
+	lastProbeTime time.Time
+	// results holds the results of the most recent probes, at most lossWindow of them.
+	results             []bool
+	consecutiveFailures int
+}
+
+func (s *peerState) packetLoss() float64 {
+	if len(s.results) == 0 {
+		return 0
+	}
+	failures := 0
+	for _, succeeded := range s.results {
+		if !succeeded {
+			failures++
+		}
+	}
+	return float64(failures) / float64(len(s.results))
+}
+
+// NodeLatencyMonitor periodically probes the gateway of each peer Node, i.e. the first IP of its
+// PodCIDR, so that the probes follow the same path as the inter-Node Pod traffic. It records the
+// RTT and the packet loss of each peer Node, and reports the peer Nodes which fail too many
+// consecutive probes as unreachable.
+type NodeLatencyMonitor struct {
+	nodeName         string
+	nodeLister       corelisters.NodeLister
+	nodeListerSynced cache.InformerSynced
+	isIPv4Enabled    bool
+	isIPv6Enabled    bool
+	prober           prober
+
+	mutex sync.RWMutex
+	// peers is keyed by the name of the peer Node.
+	peers map[string]*peerState
+	// cursor is the position, in the list of peer Nodes sorted by name, of the first peer Node
+	// to probe in the next round.
+	cursor int
+}
+
+var _ querier.AgentNodeLatencyQuerier = new(NodeLatencyMonitor)
+
+// NewNodeLatencyMonitor creates a NodeLatencyMonitor. Only the gateways of the IP families enabled
+// on the Node are probed.
+func NewNodeLatencyMonitor(nodeName string, informerFactory informers.SharedInformerFactory, isIPv4Enabled, isIPv6Enabled bool) *NodeLatencyMonitor {
+	nodeInformer := informerFactory.Core().V1().Nodes()
+	return &NodeLatencyMonitor{
+		nodeName:         nodeName,
+		nodeLister:       nodeInformer.Lister(),
+		nodeListerSynced: nodeInformer.Informer().HasSynced,
+		isIPv4Enabled:    isIPv4Enabled,
+		isIPv6Enabled:    isIPv6Enabled,
+		prober:           newICMPProber(),
+		peers:            map[string]*peerState{},
+	}
+}
+
+// Run probes the peer Nodes every probeInterval until stopCh is closed.
+func (m *NodeLatencyMonitor) Run(stopCh <-chan struct{}) {
+	klog.Infof("Starting %s", controllerName)
+	defer klog.Infof("Shutting down %s", controllerName)
+
+	if !cache.WaitForNamedCacheSync(controllerName, stopCh, m.nodeListerSynced) {
+		return
+	}
+	wait.Until(m.probeRound, probeInterval, stopCh)
+}
+
+// peerGatewayIP returns the gateway IP of the first PodCIDR of the Node whose IP family is
+// enabled, or nil if there is none.
+func (m *NodeLatencyMonitor) peerGatewayIP(node *corev1.Node) net.IP {
+	podCIDRs := node.Spec.PodCIDRs
+	if len(podCIDRs) == 0 && node.Spec.PodCIDR != "" {
+		podCIDRs = []string{node.Spec.PodCIDR}
+	}
+	for _, podCIDR := range podCIDRs {
+		podCIDRAddr, _, err := net.ParseCIDR(podCIDR)
+		if err != nil {
+			klog.Errorf("Failed to parse PodCIDR %s for Node %s", podCIDR, node.Name)
+			continue
+		}
+		isIPv4 := podCIDRAddr.To4() != nil
+		if (isIPv4 && m.isIPv4Enabled) || (!isIPv4 && m.isIPv6Enabled) {
+			return ip.NextIP(podCIDRAddr)
+		}
+	}
+	return nil
+}
+
+// syncPeers updates the peer Nodes with the current Nodes, and returns the names of the peer
+// Nodes, sorted.
+func (m *NodeLatencyMonitor) syncPeers(nodes []*corev1.Node) []string {
+	gatewayIPs := make(map[string]net.IP, len(nodes))
+	for _, node := range nodes {
+		if node.Name == m.nodeName {
+			continue
+		}
+		if gatewayIP := m.peerGatewayIP(node); gatewayIP != nil {
+			gatewayIPs[node.Name] = gatewayIP
+		}
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	for name := range m.peers {
+		if _, ok := gatewayIPs[name]; !ok {
+			delete(m.peers, name)
+			metrics.PeerNodeLatency.Delete(map[string]string{"peer_node": name})
+			metrics.PeerNodePacketLoss.Delete(map[string]string{"peer_node": name})
+		}
+	}
+	names := make([]string, 0, len(gatewayIPs))
+	for name, gatewayIP := range gatewayIPs {
+		names = append(names, name)
+		if state, ok := m.peers[name]; ok && state.gatewayIP.Equal(gatewayIP) {
+			continue
+		}
+		// The PodCIDR of a Node is not expected to change, start over if it does.
+		m.peers[name] = &peerState{gatewayIP: gatewayIP}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// peersPerRound returns how many of numPeers peer Nodes are probed in each round. It grows with
+// the square root of numPeers, so that the probe rate scales sub-linearly with the cluster size.
+func peersPerRound(numPeers int) int {
+	n := int(math.Ceil(math.Sqrt(float64(numPeers)) * peersPerRoundFactor))
+	if n < minPeersPerRound {
+		n = minPeersPerRound
+	}
+	if n > numPeers {
+		n = numPeers
+	}
+	return n
+}
+
+// selectPeers returns the peer Nodes to probe in this round, starting from the cursor and
+// wrapping around the sorted names, and advances the cursor.
+func (m *NodeLatencyMonitor) selectPeers(names []string) []string {
+	if len(names) == 0 {
+		return nil
+	}
+	n := peersPerRound(len(names))
+	// The list of peer Nodes may have shrunk since the last round.
+	start := m.cursor % len(names)
+	selected := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		selected = append(selected, names[(start+i)%len(names)])
+	}
+	m.cursor = (start + n) % len(names)
+	return selected
+}
+
+func (m *NodeLatencyMonitor) probeRound() {
+	nodes, err := m.nodeLister.List(labels.Everything())
+	if err != nil {
+		klog.Errorf("Failed to list Nodes: %v", err)
+		return
+	}
+	selected := m.selectPeers(m.syncPeers(nodes))
+	if len(selected) == 0 {
+		return
+	}
+
+	m.mutex.RLock()
+	gatewayIPs := make([]net.IP, 0, len(selected))
+	for _, name := range selected {
+		gatewayIPs = append(gatewayIPs, m.peers[name].gatewayIP)
+	}
+	m.mutex.RUnlock()
+
+	probeTime := time.Now()
+	rtts := m.prober.probe(gatewayIPs, probeTimeout)
+	m.recordResults(selected, rtts, probeTime)
+}
+
+func (m *NodeLatencyMonitor) recordResults(selected []string, rtts map[string]time.Duration, probeTime time.Time) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	for _, name := range selected {
+		state, ok := m.peers[name]
+		if !ok {
+			// The Node has been deleted during the round.
+			continue
+		}
+		state.lastProbeTime = probeTime
+		rtt, succeeded := rtts[state.gatewayIP.String()]
+		if succeeded {
+			state.lastRTT = rtt
+			state.consecutiveFailures = 0
+			metrics.PeerNodeLatency.WithLabelValues(name).Set(float64(rtt) / float64(time.Millisecond))
+		} else {
+			state.consecutiveFailures++
+			if state.consecutiveFailures == unreachableThreshold {
+				klog.Warningf("Peer Node %s (gateway IP %s) is unreachable after %d consecutive failed probes", name, state.gatewayIP, unreachableThreshold)
+			}
+		}
+		state.results = append(state.results, succeeded)
+		if len(state.results) > lossWindow {
+			state.results = state.results[1:]
+		}
+		metrics.PeerNodePacketLoss.WithLabelValues(name).Set(state.packetLoss())
+	}
+}
+
+// GetPeerNodeLatencies implements querier.AgentNodeLatencyQuerier.
+func (m *NodeLatencyMonitor) GetPeerNodeLatencies() []querier.PeerNodeLatency {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	latencies := make([]querier.PeerNodeLatency, 0, len(m.peers))
+	for name, state := range m.peers {
+		latencies = append(latencies, querier.PeerNodeLatency{
+			NodeName:            name,
+			GatewayIP:           state.gatewayIP,
+			LastRTT:             state.lastRTT,
+			LastProbeTime:       state.lastProbeTime,
+			PacketLoss:          state.packetLoss(),
+			ConsecutiveFailures: state.consecutiveFailures,
+			Reachable:           state.consecutiveFailures < unreachableThreshold,
+		})
+	}
+	sort.Slice(latencies, func(i, j int) bool {
+		return latencies[i].NodeName < latencies[j].NodeName
+	})
+	return latencies
+}
+
+// GetUnreachablePeerNodes implements querier.AgentNodeLatencyQuerier.
+func (m *NodeLatencyMonitor) GetUnreachablePeerNodes() []string {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	var names []string
+	for name, state := range m.peers {
+		if state.consecutiveFailures >= unreachableThreshold {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
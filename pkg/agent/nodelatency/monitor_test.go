@@ -0,0 +1,158 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nodelatency
+
+import (
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// fakeProber replies to the probes of the IPs in rtts, and records the probed IPs.
+type fakeProber struct {
+	rtts   map[string]time.Duration
+	probed [][]string
+}
+
+func (p *fakeProber) probe(ips []net.IP, timeout time.Duration) map[string]time.Duration {
+	var probed []string
+	replies := map[string]time.Duration{}
+	for _, ip := range ips {
+		probed = append(probed, ip.String())
+		if rtt, ok := p.rtts[ip.String()]; ok {
+			replies[ip.String()] = rtt
+		}
+	}
+	p.probed = append(p.probed, probed)
+	return replies
+}
+
+func newNode(name string, podCIDRs ...string) *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       corev1.NodeSpec{PodCIDRs: podCIDRs},
+	}
+}
+
+func newTestMonitor(t *testing.T, nodes ...*corev1.Node) (*NodeLatencyMonitor, *fakeProber) {
+	var objs []runtime.Object
+	for _, node := range nodes {
+		objs = append(objs, node)
+	}
+	client := fake.NewSimpleClientset(objs...)
+	informerFactory := informers.NewSharedInformerFactory(client, 0)
+	m := NewNodeLatencyMonitor("node0", informerFactory, true, false)
+	prober := &fakeProber{rtts: map[string]time.Duration{}}
+	m.prober = prober
+	stopCh := make(chan struct{})
+	t.Cleanup(func() { close(stopCh) })
+	informerFactory.Start(stopCh)
+	informerFactory.WaitForCacheSync(stopCh)
+	return m, prober
+}
+
+func TestPeersPerRound(t *testing.T) {
+	assert.Equal(t, 0, peersPerRound(0))
+	assert.Equal(t, 10, peersPerRound(10))
+	assert.Equal(t, 32, peersPerRound(32))
+	assert.Equal(t, 32, peersPerRound(64))
+	assert.Equal(t, 40, peersPerRound(100))
+	assert.Equal(t, 127, peersPerRound(1000))
+}
+
+func TestProbeRound(t *testing.T) {
+	m, prober := newTestMonitor(t,
+		newNode("node0", "10.10.0.0/24"),
+		newNode("node1", "10.10.1.0/24"),
+		// Only the gateway of the IPv4 PodCIDR is probed.
+		newNode("node2", "fd00:10:10:2::/64", "10.10.2.0/24"),
+		// A Node without PodCIDR is not probed.
+		newNode("node3"),
+	)
+	prober.rtts["10.10.1.1"] = 2 * time.Millisecond
+
+	for i := 0; i < unreachableThreshold; i++ {
+		m.probeRound()
+		assert.Equal(t, []string{"10.10.1.1", "10.10.2.1"}, prober.probed[i])
+	}
+	latencies := m.GetPeerNodeLatencies()
+	require.Len(t, latencies, 2)
+	assert.Equal(t, "node1", latencies[0].NodeName)
+	assert.Equal(t, 2*time.Millisecond, latencies[0].LastRTT)
+	assert.Equal(t, 0.0, latencies[0].PacketLoss)
+	assert.True(t, latencies[0].Reachable)
+	assert.Equal(t, "node2", latencies[1].NodeName)
+	assert.Equal(t, "10.10.2.1", latencies[1].GatewayIP.String())
+	assert.Equal(t, time.Duration(0), latencies[1].LastRTT)
+	assert.Equal(t, 1.0, latencies[1].PacketLoss)
+	assert.Equal(t, unreachableThreshold, latencies[1].ConsecutiveFailures)
+	assert.False(t, latencies[1].Reachable)
+	assert.Equal(t, []string{"node2"}, m.GetUnreachablePeerNodes())
+
+	// A successful probe makes the peer Node reachable again.
+	prober.rtts["10.10.2.1"] = 3 * time.Millisecond
+	m.probeRound()
+	latencies = m.GetPeerNodeLatencies()
+	assert.Equal(t, 3*time.Millisecond, latencies[1].LastRTT)
+	assert.Equal(t, 0.75, latencies[1].PacketLoss)
+	assert.True(t, latencies[1].Reachable)
+	assert.Empty(t, m.GetUnreachablePeerNodes())
+}
+
+func TestProbeRoundRotation(t *testing.T) {
+	numPeers := 100
+	nodes := []*corev1.Node{newNode("node0", "10.10.0.0/24")}
+	for i := 1; i <= numPeers; i++ {
+		nodes = append(nodes, newNode(fmt.Sprintf("node%03d", i), fmt.Sprintf("10.11.%d.0/24", i)))
+	}
+	m, prober := newTestMonitor(t, nodes...)
+
+	probedCount := map[string]int{}
+	// 5 rounds of 40 peer Nodes probe each peer Node exactly twice.
+	for i := 0; i < 5; i++ {
+		m.probeRound()
+		assert.Len(t, prober.probed[i], peersPerRound(numPeers))
+		for _, ip := range prober.probed[i] {
+			probedCount[ip]++
+		}
+	}
+	assert.Len(t, probedCount, numPeers)
+	for ip, count := range probedCount {
+		assert.Equal(t, 2, count, ip)
+	}
+}
+
+func TestSyncPeers(t *testing.T) {
+	m, prober := newTestMonitor(t, newNode("node0", "10.10.0.0/24"), newNode("node1", "10.10.1.0/24"))
+	m.probeRound()
+	require.Len(t, m.GetPeerNodeLatencies(), 1)
+
+	nodes := []*corev1.Node{newNode("node0", "10.10.0.0/24"), newNode("node2", "10.10.2.0/24")}
+	assert.Equal(t, []string{"node2"}, m.syncPeers(nodes))
+	latencies := m.GetPeerNodeLatencies()
+	require.Len(t, latencies, 1)
+	assert.Equal(t, "node2", latencies[0].NodeName)
+	assert.True(t, latencies[0].LastProbeTime.IsZero())
+	assert.Len(t, prober.probed, 1)
+}
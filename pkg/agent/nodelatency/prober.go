@@ -0,0 +1,145 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nodelatency
+
+import (
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+	"k8s.io/klog/v2"
+)
+
+type icmpFamily struct {
+	network       string
+	address       string
+	echoType      icmp.Type
+	echoReplyType icmp.Type
+}
+
+var (
+	icmpv4Family = icmpFamily{network: "ip4:icmp", address: "0.0.0.0", echoType: ipv4.ICMPTypeEcho, echoReplyType: ipv4.ICMPTypeEchoReply}
+	icmpv6Family = icmpFamily{network: "ip6:ipv6-icmp", address: "::", echoType: ipv6.ICMPTypeEchoRequest, echoReplyType: ipv6.ICMPTypeEchoReply}
+)
+
+// icmpProber probes IPs with ICMP echo requests. A raw socket is opened for each round of probes,
+// and all the echo requests of a round share the same sequence number, so that late replies to
+// the previous rounds are ignored.
+type icmpProber struct {
+	id  int
+	seq int
+}
+
+func newICMPProber() *icmpProber {
+	return &icmpProber{id: os.Getpid() & 0xffff}
+}
+
+func (p *icmpProber) probe(ips []net.IP, timeout time.Duration) map[string]time.Duration {
+	p.seq = (p.seq + 1) & 0xffff
+	var ipv4s, ipv6s []net.IP
+	for _, ip := range ips {
+		if ip.To4() != nil {
+			ipv4s = append(ipv4s, ip)
+		} else {
+			ipv6s = append(ipv6s, ip)
+		}
+	}
+
+	rtts := make(map[string]time.Duration, len(ips))
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	probeFamily := func(family icmpFamily, ips []net.IP) {
+		defer wg.Done()
+		familyRTTs := p.probeFamily(family, ips, timeout)
+		mutex.Lock()
+		defer mutex.Unlock()
+		for ip, rtt := range familyRTTs {
+			rtts[ip] = rtt
+		}
+	}
+	if len(ipv4s) > 0 {
+		wg.Add(1)
+		go probeFamily(icmpv4Family, ipv4s)
+	}
+	if len(ipv6s) > 0 {
+		wg.Add(1)
+		go probeFamily(icmpv6Family, ipv6s)
+	}
+	wg.Wait()
+	return rtts
+}
+
+func (p *icmpProber) probeFamily(family icmpFamily, ips []net.IP, timeout time.Duration) map[string]time.Duration {
+	rtts := make(map[string]time.Duration, len(ips))
+	conn, err := icmp.ListenPacket(family.network, family.address)
+	if err != nil {
+		klog.Errorf("Failed to open %s socket to probe peer Nodes: %v", family.network, err)
+		return rtts
+	}
+	defer conn.Close()
+	if err := conn.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+		klog.Errorf("Failed to set the read deadline of %s socket: %v", family.network, err)
+		return rtts
+	}
+
+	msg := icmp.Message{Type: family.echoType, Body: &icmp.Echo{ID: p.id, Seq: p.seq}}
+	// The checksum of ICMPv6 messages is computed by the kernel.
+	b, err := msg.Marshal(nil)
+	if err != nil {
+		klog.Errorf("Failed to marshal ICMP echo request: %v", err)
+		return rtts
+	}
+	sendTimes := make(map[string]time.Time, len(ips))
+	for _, ip := range ips {
+		sendTime := time.Now()
+		if _, err := conn.WriteTo(b, &net.IPAddr{IP: ip}); err != nil {
+			klog.V(2).Infof("Failed to send ICMP echo request to %s: %v", ip, err)
+			continue
+		}
+		sendTimes[ip.String()] = sendTime
+	}
+
+	buf := make([]byte, 1500)
+	for len(rtts) < len(sendTimes) {
+		n, peer, err := conn.ReadFrom(buf)
+		if err != nil {
+			// The read deadline is exceeded, the remaining probes have failed.
+			break
+		}
+		receiveTime := time.Now()
+		reply, err := icmp.ParseMessage(family.echoReplyType.Protocol(), buf[:n])
+		if err != nil || reply.Type != family.echoReplyType {
+			continue
+		}
+		// The raw socket receives all the ICMP messages of the Node.
+		echo, ok := reply.Body.(*icmp.Echo)
+		if !ok || echo.ID != p.id || echo.Seq != p.seq {
+			continue
+		}
+		peerAddr, ok := peer.(*net.IPAddr)
+		if !ok {
+			continue
+		}
+		ip := peerAddr.IP.String()
+		if sendTime, ok := sendTimes[ip]; ok {
+			rtts[ip] = receiveTime.Sub(sendTime)
+		}
+	}
+	return rtts
+}
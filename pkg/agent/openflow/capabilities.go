@@ -0,0 +1,151 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openflow
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/blang/semver"
+	"k8s.io/component-base/featuregate"
+	"k8s.io/klog/v2"
+
+	"antrea.io/antrea/pkg/features"
+	binding "antrea.io/antrea/pkg/ovs/openflow"
+)
+
+// ovsVersionRegexp extracts the version from the output of "ovs-appctl version", e.g.
+// "ovs-vswitchd (Open vSwitch) 2.14.0".
+var ovsVersionRegexp = regexp.MustCompile(`\(Open vSwitch\) (\d+\.\d+\.\d+)`)
+
+// capabilityRequirement describes what a capability requires from the switch.
+type capabilityRequirement struct {
+	// minOVSVersion is the first OVS version supporting the capability.
+	minOVSVersion semver.Version
+	// openFlowVersion is the OpenFlow version which must be negotiated with the switch, if any.
+	openFlowVersion string
+	// fallback describes what is done when the capability is not available. It is empty if the
+	// capability is required by the features depending on it.
+	fallback string
+}
+
+func (r capabilityRequirement) String() string {
+	if r.openFlowVersion != "" {
+		return fmt.Sprintf("Open vSwitch >= %s and OpenFlow version %s", r.minOVSVersion, r.openFlowVersion)
+	}
+	return fmt.Sprintf("Open vSwitch >= %s", r.minOVSVersion)
+}
+
+var capabilityRequirements = map[binding.Capability]capabilityRequirement{
+	// Meters are supported by the kernel datapath since OVS 2.10. They also depend on the Linux
+	// kernel version, see ovsMetersAreSupported.
+	binding.CapabilityMeter: {
+		minOVSVersion: semver.MustParse("2.10.0"),
+		fallback:      "packet-in messages are not rate-limited",
+	},
+	binding.CapabilityLearnDeleteLearned: {
+		minOVSVersion: semver.MustParse("2.6.0"),
+	},
+	binding.CapabilityOpenFlow15: {
+		minOVSVersion:   semver.MustParse("2.8.0"),
+		openFlowVersion: binding.OpenFlow15,
+		fallback:        "OpenFlow 1.3 with Nicira extensions is used instead",
+	},
+}
+
+// featureRequiredCapabilities lists, for each feature gate, the capabilities without which the
+// feature can not work. The agent fails to start if one of them is not available while the
+// feature gate is enabled.
+var featureRequiredCapabilities = map[featuregate.Feature][]binding.Capability{
+	// The learned session affinity flows must be removed with the Service flows.
+	features.AntreaProxy: {binding.CapabilityLearnDeleteLearned},
+}
+
+// getOVSVersion returns the version of the running ovs-vswitchd.
+func (c *client) getOVSVersion() (*semver.Version, error) {
+	out, execErr := c.ovsctlClient.RunAppctlCmd("version", false)
+	if execErr != nil {
+		return nil, fmt.Errorf("error when running ovs-appctl version: %v", execErr)
+	}
+	match := ovsVersionRegexp.FindSubmatch(out)
+	if match == nil {
+		return nil, fmt.Errorf("unexpected ovs-appctl version output: %s", out)
+	}
+	version, err := semver.Parse(string(match[1]))
+	if err != nil {
+		return nil, err
+	}
+	return &version, nil
+}
+
+// probeCapabilities detects the OpenFlow version negotiated with the switch, the OVS version and
+// the available optional capabilities. If the OVS version can not be detected, the capabilities
+// are assumed to be supported by it, as in previous Antrea versions.
+func (c *client) probeCapabilities() binding.Capabilities {
+	capabilities := binding.Capabilities{OpenFlowVersion: c.bridge.OpenFlowVersion()}
+	ovsVersion, err := c.getOVSVersion()
+	if err != nil {
+		klog.Warningf("Failed to detect OVS version, assuming that it supports all capabilities: %v", err)
+	} else {
+		capabilities.OVSVersion = ovsVersion.String()
+	}
+	for capability, requirement := range capabilityRequirements {
+		available := ovsVersion == nil || ovsVersion.GTE(requirement.minOVSVersion)
+		if requirement.openFlowVersion != "" && requirement.openFlowVersion != capabilities.OpenFlowVersion {
+			available = false
+		}
+		if capability == binding.CapabilityMeter && !ovsMetersAreSupported(c.ovsDatapathType) {
+			available = false
+		}
+		if available {
+			capabilities.Available = append(capabilities.Available, capability)
+		} else if requirement.fallback != "" {
+			klog.Infof("OVS capability %s is not available as it requires %s, %s", capability, requirement, requirement.fallback)
+		}
+	}
+	sort.Slice(capabilities.Available, func(i, j int) bool {
+		return capabilities.Available[i] < capabilities.Available[j]
+	})
+	klog.Infof("Detected OVS version %q, OpenFlow version %s, available capabilities: %v", capabilities.OVSVersion, capabilities.OpenFlowVersion, capabilities.Available)
+	return capabilities
+}
+
+// checkRequiredCapabilities returns an error if a capability required by an enabled feature gate
+// is not available.
+func (c *client) checkRequiredCapabilities() error {
+	enabledFeatures := map[featuregate.Feature]bool{
+		features.AntreaProxy:  c.enableProxy,
+		features.AntreaPolicy: c.enableAntreaPolicy,
+		features.Egress:       c.enableEgress,
+	}
+	for feature, capabilities := range featureRequiredCapabilities {
+		if !enabledFeatures[feature] {
+			continue
+		}
+		for _, capability := range capabilities {
+			if c.capabilities.Has(capability) {
+				continue
+			}
+			return fmt.Errorf("feature gate %s requires OVS capability %s, which needs %s, but the detected Open vSwitch version is %s and the negotiated OpenFlow version is %s",
+				feature, capability, capabilityRequirements[capability], c.capabilities.OVSVersion, c.capabilities.OpenFlowVersion)
+		}
+	}
+	return nil
+}
+
+func (c *client) GetOVSCapabilities() binding.Capabilities {
+	return c.capabilities
+}
@@ -18,6 +18,8 @@ import (
 	"fmt"
 	"math/rand"
 	"net"
+	"sort"
+	"time"
 
 	"github.com/contiv/libOpenflow/protocol"
 	"k8s.io/klog/v2"
@@ -30,36 +32,28 @@ import (
 	"antrea.io/antrea/third_party/proxy"
 )
 
-const maxRetryForOFSwitch = 5
-
-// Client is the interface to program OVS flows for entity connectivity of Antrea.
-type Client interface {
-	// Initialize sets up all basic flows on the specific OVS bridge. It returns a channel which
-	// is used to notify the caller in case of a reconnection, in which case ReplayFlows should
-	// be called to ensure that the set of OVS flows is correct. All flows programmed in the
-	// switch which match the current round number will be deleted before any new flow is
-	// installed.
-	Initialize(roundInfo types.RoundInfo, config *config.NodeConfig, encapMode config.TrafficEncapModeType) (<-chan struct{}, error)
+const (
+	maxRetryForOFSwitch = 5
+	// ipAnnouncementRate is the maximum number of IP announcements sent per second when Pod IPs
+	// move to this Node. ipAnnouncementBurst is the maximum number of announcements sent at once.
+	ipAnnouncementRate  = 10
+	ipAnnouncementBurst = 20
+)
 
+// PodConnectivityClient is the interface to program the OVS flows which connect the local Pods to each
+// other, to the remote Nodes, and to the external network.
+type PodConnectivityClient interface {
 	// InstallGatewayFlows sets up flows related to an OVS gateway port, the gateway must exist.
 	InstallGatewayFlows() error
 
-	// InstallClusterServiceCIDRFlows sets up the appropriate flows so that traffic can reach
-	// the different Services running in the Cluster. This method needs to be invoked once with
-	// the Cluster Service CIDR as a parameter.
-	InstallClusterServiceCIDRFlows(serviceNets []*net.IPNet) error
-
-	// InstallClusterServiceFlows sets up the appropriate flows so that traffic can reach
-	// the different Services running in the Cluster. This method needs to be invoked once.
-	InstallClusterServiceFlows() error
-
 	// InstallDefaultTunnelFlows sets up the classification flow for the default (flow based) tunnel.
 	InstallDefaultTunnelFlows() error
 
 	// InstallNodeFlows should be invoked when a connection to a remote Node is going to be set
-	// up. The hostname is used to identify the added flows. When IPSec tunnel is enabled,
-	// ipsecTunOFPort must be set to the OFPort number of the IPSec tunnel port to the remote Node;
-	// otherwise ipsecTunOFPort must be set to 0.
+	// up. The hostname is used to identify the added flows. When a tunnel port is dedicated to
+	// the remote Node (IPSec tunnel port or tunnel port created for a tunnel profile), tunOFPort
+	// must be set to its OFPort number; otherwise tunOFPort must be set to 0 and the default
+	// tunnel port is used.
 	// InstallNodeFlows has all-or-nothing semantics(call succeeds if all the flows are installed
 	// successfully, otherwise no flows will be installed). Calls to InstallNodeFlows are idempotent.
 	// Concurrent calls to InstallNodeFlows and / or UninstallNodeFlows are supported as long as they
@@ -68,7 +62,7 @@ type Client interface {
 		hostname string,
 		peerConfigs map[*net.IPNet]net.IP,
 		tunnelPeerIP net.IP,
-		ipsecTunOFPort uint32,
+		tunOFPort uint32,
 		peerNodeMAC net.HardwareAddr) error
 
 	// UninstallNodeFlows removes the connection to the remote Node specified with the
@@ -83,13 +77,100 @@ type Client interface {
 	// for different interfaceNames.
 	InstallPodFlows(interfaceName string, podInterfaceIPs []net.IP, podInterfaceMAC net.HardwareAddr, ofPort uint32) error
 
+	// UpdatePodFlows updates the flows installed by InstallPodFlows for the local Pod specified
+	// with the interfaceName, after its IPs have changed, e.g. when an address leased with DHCP
+	// has been bound to the Pod.
+	UpdatePodFlows(interfaceName string, podInterfaceIPs []net.IP, podInterfaceMAC net.HardwareAddr, ofPort uint32) error
+
+	// InstallPodDHCPFlows installs the flows forwarding the DHCP messages of a local Pod which
+	// obtains its addresses using DHCP. The DHCP replies sent to the Pod are sent to the
+	// controller with PacketInReasonDHCP as well. The flows are removed by UninstallPodFlows.
+	InstallPodDHCPFlows(interfaceName string, podInterfaceMAC net.HardwareAddr, ofPort uint32) error
+
+	// InstallPodConnectionLimitFlows limits the number of connections initiated by a local Pod to
+	// limit. The connections of the Pod are tracked in a dedicated conntrack zone whose number of
+	// entries is limited by the datapath, which drops the new connections above the limit. The
+	// zone is flushed first, so that the connections of a previous Pod using the same OVS port are
+	// not counted. The flows and the limit are removed by UninstallPodFlows.
+	InstallPodConnectionLimitFlows(interfaceName, podNamespace, podName string, ofPort uint32, limit uint32) error
+
+	// MonitorPodConnectionLimits periodically checks the number of connections of the Pods whose
+	// connections are limited, and reports the Pods reaching their limit with the
+	// PodConnectionLimitReachedCount metric, and in the logs if enableLogging is true. It blocks
+	// until stopCh is closed.
+	MonitorPodConnectionLimits(enableLogging bool, stopCh <-chan struct{})
+
 	// UninstallPodFlows removes the connection to the local Pod specified with the
 	// interfaceName. UninstallPodFlows will do nothing if no connection to the Pod was established.
 	UninstallPodFlows(interfaceName string) error
 
+	// InstallBridgeUplinkFlows installs Openflow flows between bridge local port and uplink port to support
+	// host networking.
+	// This function is only used for Windows platform.
+	InstallBridgeUplinkFlows() error
+
+	// InstallExternalFlows sets up flows to enable Pods to communicate to
+	// the external IP addresses. The flows identify the packets from local
+	// Pods to the external IP address, and mark the packets to be SNAT'd
+	// with the configured SNAT IPs. On Windows Node, the flows also perform
+	// SNAT with the Openflow NAT action.
+	InstallExternalFlows() error
+
+	// InstallSNATMarkFlows installs flows for a local SNAT IP. On Linux, a
+	// single flow is added to mark the packets tunnelled from remote Nodes
+	// that should be SNAT'd with the SNAT IP. On Windows, an extra flow is
+	// added to perform SNAT for the marked packets with the SNAT IP.
+	InstallSNATMarkFlows(snatIP net.IP, mark uint32) error
+
+	// UninstallSNATMarkFlows removes the flows installed to set the packet
+	// mark for a SNAT IP.
+	UninstallSNATMarkFlows(mark uint32) error
+
+	// InstallPodSNATFlows installs the SNAT flows for a local Pod. If the
+	// SNAT IP for the Pod is on the local Node, a non-zero SNAT ID should
+	// allocated for the SNAT IP, and the installed flow sets the SNAT IP
+	// mark on the egress packets from the ofPort; if the SNAT IP is on a
+	// remote Node, snatMark should be set to 0, and the installed flow
+	// tunnels egress packets to the remote Node using the SNAT IP as the
+	// tunnel destination, and the packets should be SNAT'd on the remote
+	// Node. As of now, a Pod can be configured to use only a single SNAT
+	// IP in a single address family (IPv4 or IPv6).
+	InstallPodSNATFlows(ofPort uint32, snatIP net.IP, snatMark uint32) error
+
+	// UninstallPodSNATFlows removes the SNAT flows for the local Pod.
+	UninstallPodSNATFlows(ofPort uint32) error
+
+	// GetTunnelVirtualMAC() returns globalVirtualMAC used for tunnel traffic.
+	GetTunnelVirtualMAC() net.HardwareAddr
+
+	// GetPodFlowKeys returns the keys (match strings) of the cached flows for a
+	// Pod.
+	GetPodFlowKeys(interfaceName string) []string
+
+	// ConfigureNDGuard enables the check of the IPv6 Neighbor Discovery messages sent by local Pods:
+	// Router Advertisements, and Neighbor Advertisements for addresses the Pods don't own, are dropped
+	// and counted, and logged if enableLogging is true. It must be called before Initialize.
+	ConfigureNDGuard(enable, enableLogging bool)
+}
+
+// ServiceClient is the interface to program the OVS flows implementing Services with AntreaProxy.
+type ServiceClient interface {
+	// InstallClusterServiceCIDRFlows sets up the appropriate flows so that traffic can reach
+	// the different Services running in the Cluster. This method needs to be invoked with the
+	// Cluster Service CIDRs as a parameter, and can be invoked again when they change, in which
+	// case the flows of the previous Service CIDRs are replaced. When AntreaProxy is enabled, it
+	// must be invoked after InstallClusterServiceFlows, and the flows steer the traffic received
+	// from the host gateway for the advertised Service CIDR into the AntreaProxy pipeline.
+	InstallClusterServiceCIDRFlows(serviceNets []*net.IPNet) error
+
+	// InstallClusterServiceFlows sets up the appropriate flows so that traffic can reach
+	// the different Services running in the Cluster. This method needs to be invoked once.
+	InstallClusterServiceFlows() error
+
 	// InstallServiceGroup installs a group for Service LB. Each endpoint
 	// is a bucket of the group. For now, each bucket has the same weight.
 	InstallServiceGroup(groupID binding.GroupIDType, withSessionAffinity bool, endpoints []proxy.Endpoint) error
+
 	// UninstallServiceGroup removes the group and its buckets that are
 	// installed by InstallServiceGroup.
 	UninstallServiceGroup(groupID binding.GroupIDType) error
@@ -98,6 +179,7 @@ type Client interface {
 	// If an Endpoint is on the current Node, then flows for hairpin and endpoint
 	// L2 forwarding should also be installed.
 	InstallEndpointFlows(protocol binding.Protocol, endpoints []proxy.Endpoint) error
+
 	// UninstallEndpointFlows removes flows of the Endpoint installed by
 	// InstallEndpointFlows.
 	UninstallEndpointFlows(protocol binding.Protocol, endpoint proxy.Endpoint) error
@@ -109,19 +191,46 @@ type Client interface {
 	// The group with the groupID must be installed before, otherwise the
 	// installation will fail.
 	InstallServiceFlows(groupID binding.GroupIDType, svcIP net.IP, svcPort uint16, protocol binding.Protocol, affinityTimeout uint16) error
+
 	// UninstallServiceFlows removes flows installed by InstallServiceFlows.
 	UninstallServiceFlows(svcIP net.IP, svcPort uint16, protocol binding.Protocol) error
+
+	// InstallServiceSourceRangeFlows installs flows restricting the clients accessing the Service
+	// with svcIP to sourceRanges: the packets from other sources are dropped before Endpoint
+	// selection. It can be called again with different sourceRanges to update the flows. The
+	// group with the groupID must be installed before, otherwise the installation will fail.
+	InstallServiceSourceRangeFlows(groupID binding.GroupIDType, svcIP net.IP, svcPort uint16, protocol binding.Protocol, affinityTimeout uint16, sourceRanges []net.IPNet) error
+
+	// UninstallServiceSourceRangeFlows removes flows installed by InstallServiceSourceRangeFlows.
+	UninstallServiceSourceRangeFlows(svcIP net.IP, svcPort uint16, protocol binding.Protocol) error
+
 	// InstallLoadBalancerServiceFromOutsideFlows installs flows for LoadBalancer Service traffic from outside node.
 	// The traffic is received from uplink port and will be forwarded to gateway by the installed flows. And then
-	// kube-proxy will handle the traffic.
+	// kube-proxy will handle the traffic. If disableSNAT is true, the traffic is load-balanced by the installed flows
+	// instead, so that it is not SNAT'd and the Endpoints see the client IP.
 	// This function is only used for Windows platform.
-	InstallLoadBalancerServiceFromOutsideFlows(svcIP net.IP, svcPort uint16, protocol binding.Protocol) error
+	InstallLoadBalancerServiceFromOutsideFlows(svcIP net.IP, svcPort uint16, protocol binding.Protocol, disableSNAT bool) error
+
 	// UninstallLoadBalancerServiceFromOutsideFlows removes flows installed by InstallLoadBalancerServiceFromOutsideFlows.
 	UninstallLoadBalancerServiceFromOutsideFlows(svcIP net.IP, svcPort uint16, protocol binding.Protocol) error
 
-	// GetFlowTableStatus should return an array of flow table status, all existing flow tables should be included in the list.
-	GetFlowTableStatus() []binding.TableStatus
+	// GetServiceFlowKeys returns the keys (match strings) of the cached
+	// flows for a Service (port) and its endpoints.
+	GetServiceFlowKeys(svcIP net.IP, svcPort uint16, protocol binding.Protocol, endpoints []proxy.Endpoint) []string
+
+	// GetEndpointDNATFlowKey returns the key (match string) of the cached DNAT
+	// flow for an Endpoint. An empty string is returned if the flow is not cached.
+	GetEndpointDNATFlowKey(protocol binding.Protocol, endpoint proxy.Endpoint) string
+
+	// ConfigureServiceLoopGuard configures the handling of the packets accessing a Service whose
+	// Endpoint is reselected too many times, which are dropped to prevent them from looping in the
+	// Service pipeline: they are counted, and logged if enableLogging is true. It must be called
+	// before Initialize.
+	ConfigureServiceLoopGuard(enableLogging bool)
+}
 
+// PolicyClient is the interface to program the OVS flows enforcing NetworkPolicies.
+type PolicyClient interface {
 	// InstallPolicyRuleFlows installs flows for a new NetworkPolicy rule. Rule should include all fields in the
 	// NetworkPolicy rule. Each ingress/egress policy rule installs Openflow entries on two tables, one for
 	// ruleTable and the other for dropTable. If a packet does not pass the ruleTable, it will be dropped by the
@@ -131,11 +240,25 @@ type Client interface {
 	// BatchInstallPolicyRuleFlows installs multiple flows for NetworkPolicy rules in batch.
 	BatchInstallPolicyRuleFlows(ofPolicyRules []*types.PolicyRule) error
 
+	// InstallPolicyBootstrapFlows installs the flows dropping the new connections from and to local Pods, except
+	// the connections with the peers in allowlist. They are used to fail closed until the NetworkPolicies have been
+	// received from the antrea-controller and enforced, after which UninstallPolicyBootstrapFlows should be called.
+	InstallPolicyBootstrapFlows(allowlist []types.PolicyBootstrapPeer) error
+
+	// UninstallPolicyBootstrapFlows removes the flows installed by InstallPolicyBootstrapFlows.
+	UninstallPolicyBootstrapFlows() error
+
 	// UninstallPolicyRuleFlows removes the Openflow entry relevant to the specified NetworkPolicy rule.
 	// It also returns a slice of stale ofPriorities used by ClusterNetworkPolicies.
 	// UninstallPolicyRuleFlows will do nothing if no Openflow entry for the rule is installed.
 	UninstallPolicyRuleFlows(ruleID uint32) ([]string, error)
 
+	// UninstallPolicyRuleFlowsAsync removes the Openflow entry relevant to the specified NetworkPolicy rule in the
+	// background, and returns a channel to which the result, including the stale ofPriorities, is sent once it's
+	// removed. InstallPolicyRuleFlows and BatchInstallPolicyRuleFlows wait for the pending uninstallation of a rule
+	// with the same ID.
+	UninstallPolicyRuleFlowsAsync(ruleID uint32) <-chan types.PolicyRuleUninstallResult
+
 	// AddPolicyRuleAddress adds one or multiple addresses to the specified NetworkPolicy rule. If addrType is true, the
 	// addresses are added to PolicyRule.From, else to PolicyRule.To.
 	AddPolicyRuleAddress(ruleID uint32, addrType types.AddressType, addresses []types.Address, priority *uint16) error
@@ -144,70 +267,6 @@ type Client interface {
 	// are removed from PolicyRule.From, else from PolicyRule.To.
 	DeletePolicyRuleAddress(ruleID uint32, addrType types.AddressType, addresses []types.Address, priority *uint16) error
 
-	// InstallBridgeUplinkFlows installs Openflow flows between bridge local port and uplink port to support
-	// host networking.
-	// This function is only used for Windows platform.
-	InstallBridgeUplinkFlows() error
-
-	// InstallExternalFlows sets up flows to enable Pods to communicate to
-	// the external IP addresses. The flows identify the packets from local
-	// Pods to the external IP address, and mark the packets to be SNAT'd
-	// with the configured SNAT IPs. On Windows Node, the flows also perform
-	// SNAT with the Openflow NAT action.
-	InstallExternalFlows() error
-
-	// InstallSNATMarkFlows installs flows for a local SNAT IP. On Linux, a
-	// single flow is added to mark the packets tunnelled from remote Nodes
-	// that should be SNAT'd with the SNAT IP. On Windows, an extra flow is
-	// added to perform SNAT for the marked packets with the SNAT IP.
-	InstallSNATMarkFlows(snatIP net.IP, mark uint32) error
-
-	// UninstallSNATMarkFlows removes the flows installed to set the packet
-	// mark for a SNAT IP.
-	UninstallSNATMarkFlows(mark uint32) error
-
-	// InstallPodSNATFlows installs the SNAT flows for a local Pod. If the
-	// SNAT IP for the Pod is on the local Node, a non-zero SNAT ID should
-	// allocated for the SNAT IP, and the installed flow sets the SNAT IP
-	// mark on the egress packets from the ofPort; if the SNAT IP is on a
-	// remote Node, snatMark should be set to 0, and the installed flow
-	// tunnels egress packets to the remote Node using the SNAT IP as the
-	// tunnel destination, and the packets should be SNAT'd on the remote
-	// Node. As of now, a Pod can be configured to use only a single SNAT
-	// IP in a single address family (IPv4 or IPv6).
-	InstallPodSNATFlows(ofPort uint32, snatIP net.IP, snatMark uint32) error
-
-	// UninstallPodSNATFlows removes the SNAT flows for the local Pod.
-	UninstallPodSNATFlows(ofPort uint32) error
-
-	// Disconnect disconnects the connection between client and OFSwitch.
-	Disconnect() error
-
-	// IsConnected returns the connection status between client and OFSwitch. The return value is true if the OFSwitch is connected.
-	IsConnected() bool
-
-	// ReplayFlows should be called when a spurious disconnection occurs. After we reconnect to
-	// the OFSwitch, we need to replay all the flows cached by the client. ReplayFlows will try
-	// to replay as many flows as possible, and will log an error when a flow cannot be
-	// installed.
-	ReplayFlows()
-
-	// DeleteStaleFlows deletes all flows from the previous round which are no longer needed. It
-	// should be called by the agent after all required flows have been installed / updated with
-	// the new round number.
-	DeleteStaleFlows() error
-
-	// GetTunnelVirtualMAC() returns globalVirtualMAC used for tunnel traffic.
-	GetTunnelVirtualMAC() net.HardwareAddr
-
-	// GetPodFlowKeys returns the keys (match strings) of the cached flows for a
-	// Pod.
-	GetPodFlowKeys(interfaceName string) []string
-
-	// GetServiceFlowKeys returns the keys (match strings) of the cached
-	// flows for a Service (port) and its endpoints.
-	GetServiceFlowKeys(svcIP net.IP, svcPort uint16, protocol binding.Protocol, endpoints []proxy.Endpoint) []string
-
 	// GetNetworkPolicyFlowKeys returns the keys (match strings) of the cached
 	// flows for a NetworkPolicy. Flows are grouped by policy rules, and duplicated
 	// entries can be added due to conjunctive match flows shared by multiple
@@ -218,38 +277,47 @@ type Client interface {
 	// the old priority with the desired one, for each priority update on that table.
 	ReassignFlowPriorities(updates map[uint16]uint16, table binding.TableIDType) error
 
-	// SubscribePacketIn subscribes to packet in messages for the given reason. Packets
-	// will be placed in the queue and if the queue is full, the packet in messages
-	// will be dropped. pktInQueue supports rate-limiting for the consumer, in order to
-	// constrain the compute resources that may be used by the consumer.
-	SubscribePacketIn(reason uint8, pktInQueue *binding.PacketInQueue) error
+	// Find Network Policy reference, OFpriority and rule name by conjunction ID.
+	GetPolicyInfoFromConjunction(ruleID uint32) (string, string, string)
 
-	// SendTraceflowPacket injects packet to specified OVS port for Openflow.
-	SendTraceflowPacket(dataplaneTag uint8, packet *binding.Packet, inPort uint32, outPort int32) error
+	// EnableK8sIsolationLogging makes the flows dropping the traffic isolated by K8s
+	// NetworkPolicies send the dropped packets to the controller for audit logging, for the
+	// ingress and egress directions respectively. It must be called before Initialize.
+	EnableK8sIsolationLogging(ingress, egress bool)
 
-	// InstallTraceflowFlows installs flows for a Traceflow request.
-	InstallTraceflowFlows(dataplaneTag uint8, liveTraffic, droppedOnly, receiverOnly bool, packet *binding.Packet, ofPort uint32, timeoutSeconds uint16) error
+	// Get traffic metrics of each NetworkPolicy rule.
+	NetworkPolicyMetrics() map[uint32]*types.RuleMetric
 
-	// UninstallTraceflowFlows uninstalls flows for a Traceflow request.
-	UninstallTraceflowFlows(dataplaneTag uint8) error
+	// Returns if IPv4 is supported on this Node or not.
+	IsIPv4Enabled() bool
 
-	// Initial tun_metadata0 in TLV map for Traceflow.
-	InitialTLVMap() error
+	// Returns if IPv6 is supported on this Node or not.
+	IsIPv6Enabled() bool
+}
 
-	// Find Network Policy reference and OFpriority by conjunction ID.
-	GetPolicyInfoFromConjunction(ruleID uint32) (string, string)
+// PacketInClient is the interface to receive the packets sent to the controller by the OVS flows.
+type PacketInClient interface {
+	// SubscribePacketIn subscribes to packet in messages for the given reason. Packets
+	// will be placed in the queue and if the queue is full, the packet in messages
+	// will be dropped. pktInQueue supports rate-limiting for the consumer, in order to
+	// constrain the compute resources that may be used by the consumer.
+	SubscribePacketIn(reason uint8, pktInQueue *binding.PacketInQueue) error
 
 	// RegisterPacketInHandler uses SubscribePacketIn to get PacketIn message and process received
 	// packets through registered handlers.
 	RegisterPacketInHandler(packetHandlerReason uint8, packetHandlerName string, packetInHandler interface{})
 
 	StartPacketInHandler(packetInStartedReason []uint8, stopCh <-chan struct{})
-	// Get traffic metrics of each NetworkPolicy rule.
-	NetworkPolicyMetrics() map[uint32]*types.RuleMetric
-	// Returns if IPv4 is supported on this Node or not.
-	IsIPv4Enabled() bool
-	// Returns if IPv6 is supported on this Node or not.
-	IsIPv6Enabled() bool
+
+	// ShrinkPacketInQueues limits the number of packets in each packet-in queue to
+	// PacketInQueueShrunkSize if shrink is true, to reduce the memory usage of the agent, or restores
+	// the size of the queues otherwise. The queued packets in excess are dropped, and their number is
+	// returned.
+	ShrinkPacketInQueues(shrink bool) int
+}
+
+// PacketOutClient is the interface to inject packets built by the agent into the OVS pipeline.
+type PacketOutClient interface {
 	// SendTCPPacketOut sends TCP packet as a packet-out to OVS.
 	SendTCPPacketOut(
 		srcMAC string,
@@ -264,6 +332,7 @@ type Client interface {
 		tcpAckNum uint32,
 		tcpFlag uint8,
 		isReject bool) error
+
 	// SendICMPPacketOut sends ICMP packet as a packet-out to OVS.
 	SendICMPPacketOut(
 		srcMAC string,
@@ -277,22 +346,137 @@ type Client interface {
 		icmpCode uint8,
 		icmpData []byte,
 		isReject bool) error
+
+	// SendIPAnnouncementPacketOut sends a gratuitous ARP for an IPv4 address, or an unsolicited
+	// Neighbor Advertisement for an IPv6 address, as a packet-out to OVS. It announces that ip
+	// is at mac to the neighbors connected to outPort.
+	SendIPAnnouncementPacketOut(mac net.HardwareAddr, ip net.IP, inPort uint32, outPort uint32) error
 }
 
+// TraceflowClient is the interface to program the OVS flows for Traceflow and PacketCapture requests.
+// The results of both are received as packet-in messages.
+type TraceflowClient interface {
+	PacketInClient
+
+	// SendTraceflowPacket injects packet to specified OVS port for Openflow.
+	SendTraceflowPacket(dataplaneTag uint8, packet *binding.Packet, inPort uint32, outPort int32) error
+
+	// InstallTraceflowFlows installs flows for a Traceflow request.
+	InstallTraceflowFlows(dataplaneTag uint8, liveTraffic, droppedOnly, receiverOnly bool, packet *binding.Packet, ofPort uint32, timeoutSeconds uint16) error
+
+	// UninstallTraceflowFlows uninstalls flows for a Traceflow request.
+	UninstallTraceflowFlows(dataplaneTag uint8) error
+
+	// InstallPacketCaptureFlows installs flows which send a copy of the packets matching the
+	// provided packet spec to the controller, for the PacketCapture request of the given name.
+	// The flows are removed by OVS after timeoutSeconds.
+	InstallPacketCaptureFlows(name string, packet *binding.Packet, timeoutSeconds uint16) error
+
+	// UninstallPacketCaptureFlows uninstalls flows for a PacketCapture request.
+	UninstallPacketCaptureFlows(name string) error
+
+	// Initial tun_metadata0 in TLV map for Traceflow.
+	InitialTLVMap() error
+}
+
+// Client is the interface to program OVS flows for entity connectivity of Antrea. It is composed of
+// feature-scoped interfaces, so that each consumer can depend only on the methods it uses.
+type Client interface {
+	PodConnectivityClient
+	ServiceClient
+	PolicyClient
+	PacketOutClient
+	TraceflowClient
+
+	// Initialize sets up all basic flows on the specific OVS bridge. It returns a channel which
+	// is used to notify the caller in case of a reconnection, in which case ReplayFlows should
+	// be called to ensure that the set of OVS flows is correct. All flows programmed in the
+	// switch which match the current round number will be deleted before any new flow is
+	// installed.
+	Initialize(roundInfo types.RoundInfo, config *config.NodeConfig, encapMode config.TrafficEncapModeType) (<-chan struct{}, error)
+
+	// GetFlowTableStatus should return an array of flow table status, all existing flow tables should be included in the list.
+	GetFlowTableStatus() []binding.TableStatus
+
+	// GetPipeline returns the tables of the pipeline generated by the client, ordered by table number.
+	GetPipeline() []binding.Table
+
+	// GetOVSCapabilities returns the OpenFlow version, the OVS version and the optional OVS
+	// capabilities detected by Initialize.
+	GetOVSCapabilities() binding.Capabilities
+
+	// Disconnect disconnects the connection between client and OFSwitch.
+	Disconnect() error
+
+	// IsConnected returns the connection status between client and OFSwitch. The return value is true if the OFSwitch is connected.
+	IsConnected() bool
+
+	// ReplayFlows should be called when a spurious disconnection occurs. After we reconnect to
+	// the OFSwitch, we need to replay all the flows cached by the client. ReplayFlows will try
+	// to replay as many flows as possible, and will log an error when a flow cannot be
+	// installed.
+	ReplayFlows()
+
+	// DeleteStaleFlows deletes all flows from the previous round which are no longer needed. It
+	// should be called by the agent after all required flows have been installed / updated with
+	// the new round number.
+	DeleteStaleFlows() error
+
+	// EnableFlowChangeTracking enables the recording of the latest size flow changes, with the
+	// objects which triggered them. It must be called before Initialize.
+	EnableFlowChangeTracking(size int)
+
+	// ConfigureConnectionProbe enables the keepalive probes of the connection to the OFSwitch, every
+	// interval, after failureThreshold of which without reply the connection is re-established and
+	// the flows are replayed. An interval of 0 disables the probes. It must be called before
+	// Initialize.
+	ConfigureConnectionProbe(interval time.Duration, failureThreshold int)
+
+	// GetFlowChanges returns the recorded flow changes, from the oldest to the latest. It returns
+	// nil if flow change tracking is not enabled.
+	GetFlowChanges() []types.FlowChange
+}
+
+var (
+	_ PodConnectivityClient = (*client)(nil)
+	_ ServiceClient         = (*client)(nil)
+	_ PolicyClient          = (*client)(nil)
+	_ PacketInClient        = (*client)(nil)
+	_ PacketOutClient       = (*client)(nil)
+	_ TraceflowClient       = (*client)(nil)
+	_ Client                = (*client)(nil)
+)
+
 // GetFlowTableStatus returns an array of flow table status.
 func (c *client) GetFlowTableStatus() []binding.TableStatus {
 	return c.bridge.DumpTableStatus()
 }
 
+// GetPipeline returns the tables of the pipeline, ordered by table number.
+func (c *client) GetPipeline() []binding.Table {
+	tables := make([]binding.Table, 0, len(c.pipeline))
+	for _, table := range c.pipeline {
+		tables = append(tables, table)
+	}
+	sort.Slice(tables, func(i, j int) bool {
+		return tables[i].GetID() < tables[j].GetID()
+	})
+	return tables
+}
+
 // IsConnected returns the connection status between client and OFSwitch.
 func (c *client) IsConnected() bool {
 	return c.bridge.IsConnected()
 }
 
+func (c *client) ConfigureConnectionProbe(interval time.Duration, failureThreshold int) {
+	c.bridge.SetConnectionProbe(interval, failureThreshold)
+}
+
 // addFlows installs the flows on the OVS bridge and then add them into the flow cache. If the flow cache exists,
 // it will return immediately, otherwise it will use Bundle to add all flows, and then add them into the flow cache.
 // If it fails to add the flows with Bundle, it will return the error and no flow cache is created.
-func (c *client) addFlows(cache *flowCategoryCache, flowCacheKey string, flows []binding.Flow) error {
+func (c *client) addFlows(cache *flowCategoryCache, flowCacheKey string, trigger types.FlowChangeTrigger, flows []binding.Flow) error {
 	_, ok := cache.Load(flowCacheKey)
 	// If a flow cache entry already exists for the key, return immediately. Otherwise, add the flows to the switch
 	// and populate the cache with them.
@@ -300,7 +484,7 @@ func (c *client) addFlows(cache *flowCategoryCache, flowCacheKey string, flows [
 		klog.V(2).Infof("Flows with cache key %s are already installed", flowCacheKey)
 		return nil
 	}
-	err := c.ofEntryOperations.AddAll(flows)
+	err := c.ofEntryOps(trigger).AddAll(flows)
 	if err != nil {
 		return err
 	}
@@ -314,7 +498,7 @@ func (c *client) addFlows(cache *flowCategoryCache, flowCacheKey string, flows [
 }
 
 // modifyFlows sets the flows of flowCategoryCache be exactly same as the provided slice for the given flowCacheKey.
-func (c *client) modifyFlows(cache *flowCategoryCache, flowCacheKey string, flows []binding.Flow) error {
+func (c *client) modifyFlows(cache *flowCategoryCache, flowCacheKey string, trigger types.FlowChangeTrigger, flows []binding.Flow) error {
 	oldFlowCacheI, ok := cache.Load(flowCacheKey)
 	fCache := flowCache{}
 	var err error
@@ -323,7 +507,7 @@ func (c *client) modifyFlows(cache *flowCategoryCache, flowCacheKey string, flow
 			fCache[flow.MatchString()] = flow
 		}
 
-		err = c.ofEntryOperations.AddAll(flows)
+		err = c.ofEntryOps(trigger).AddAll(flows)
 	} else {
 		var adds, mods, dels []binding.Flow
 		oldFlowCache := oldFlowCacheI.(flowCache)
@@ -341,7 +525,7 @@ func (c *client) modifyFlows(cache *flowCategoryCache, flowCacheKey string, flow
 				dels = append(dels, v)
 			}
 		}
-		err = c.ofEntryOperations.BundleOps(adds, mods, dels)
+		err = c.ofEntryOps(trigger).BundleOps(adds, mods, dels)
 	}
 	if err != nil {
 		return err
@@ -353,7 +537,7 @@ func (c *client) modifyFlows(cache *flowCategoryCache, flowCacheKey string, flow
 }
 
 // deleteFlows deletes all the flows in the flow cache indexed by the provided flowCacheKey.
-func (c *client) deleteFlows(cache *flowCategoryCache, flowCacheKey string) error {
+func (c *client) deleteFlows(cache *flowCategoryCache, flowCacheKey string, trigger types.FlowChangeTrigger) error {
 	fCacheI, ok := cache.Load(flowCacheKey)
 	if !ok {
 		// no matching flows found in the cache
@@ -365,7 +549,7 @@ func (c *client) deleteFlows(cache *flowCategoryCache, flowCacheKey string) erro
 	for _, flow := range fCache {
 		delFlows = append(delFlows, flow)
 	}
-	if err := c.ofEntryOperations.DeleteAll(delFlows); err != nil {
+	if err := c.ofEntryOps(trigger).DeleteAll(delFlows); err != nil {
 		return err
 	}
 	cache.Delete(flowCacheKey)
@@ -376,7 +560,7 @@ func (c *client) deleteFlows(cache *flowCategoryCache, flowCacheKey string) erro
 func (c *client) InstallNodeFlows(hostname string,
 	peerConfigs map[*net.IPNet]net.IP,
 	tunnelPeerIP net.IP,
-	ipsecTunOFPort uint32,
+	tunOFPort uint32,
 	remoteGatewayMAC net.HardwareAddr) error {
 	c.replayMutex.RLock()
 	defer c.replayMutex.RUnlock()
@@ -399,28 +583,52 @@ func (c *client) InstallNodeFlows(hostname string,
 		}
 	}
 
-	if ipsecTunOFPort != 0 {
-		// When IPSec tunnel is enabled, packets received from the remote Node are
-		// input from the Node's IPSec tunnel port, not the default tunnel port. So,
-		// add a separate tunnelClassifierFlow for the IPSec tunnel port.
-		flows = append(flows, c.tunnelClassifierFlow(ipsecTunOFPort, cookie.Node))
+	if tunOFPort != 0 {
+		// When a tunnel port is dedicated to the remote Node, packets received from
+		// the remote Node are input from the Node's tunnel port, not the default tunnel
+		// port. So, add a separate tunnelClassifierFlow for the Node's tunnel port, and
+		// output packets to the remote Node to the Node's tunnel port.
+		flows = append(flows, c.tunnelClassifierFlow(tunOFPort, cookie.Node))
+		flows = append(flows, c.l2ForwardCalcFlowToTunnelPeer(tunnelPeerIP, tunOFPort, cookie.Node))
 	}
 
 	// For Windows Noencap Mode, the OVS flows for Node need be be exactly same as the provided 'flows' slice because
 	// the Node flows may be processed more than once if the MAC annotation is updated.
-	return c.modifyFlows(c.nodeFlowCache, hostname, flows)
+	return c.modifyFlows(c.nodeFlowCache, hostname, types.FlowChangeTrigger{Kind: triggerKindNode, Name: hostname}, flows)
 }
 
 func (c *client) UninstallNodeFlows(hostname string) error {
 	c.replayMutex.RLock()
 	defer c.replayMutex.RUnlock()
-	return c.deleteFlows(c.nodeFlowCache, hostname)
+	return c.deleteFlows(c.nodeFlowCache, hostname, types.FlowChangeTrigger{Kind: triggerKindNode, Name: hostname})
 }
 
 func (c *client) InstallPodFlows(interfaceName string, podInterfaceIPs []net.IP, podInterfaceMAC net.HardwareAddr, ofPort uint32) error {
 	c.replayMutex.RLock()
 	defer c.replayMutex.RUnlock()
 
+	flows := c.podFlows(podInterfaceIPs, podInterfaceMAC, ofPort)
+	if err := c.addFlows(c.podFlowCache, interfaceName, types.FlowChangeTrigger{Kind: triggerKindPodInterface, Name: interfaceName}, flows); err != nil {
+		return err
+	}
+	c.announceMovedPodIPs(podInterfaceIPs, podInterfaceMAC, ofPort)
+	return nil
+}
+
+func (c *client) UpdatePodFlows(interfaceName string, podInterfaceIPs []net.IP, podInterfaceMAC net.HardwareAddr, ofPort uint32) error {
+	c.replayMutex.RLock()
+	defer c.replayMutex.RUnlock()
+
+	// The Pod may have been removed concurrently, in which case its flows must not be installed again.
+	if _, ok := c.podFlowCache.Load(interfaceName); !ok {
+		return fmt.Errorf("flows of Pod interface %s are not installed", interfaceName)
+	}
+	flows := c.podFlows(podInterfaceIPs, podInterfaceMAC, ofPort)
+	return c.modifyFlows(c.podFlowCache, interfaceName, types.FlowChangeTrigger{Kind: triggerKindPodInterface, Name: interfaceName}, flows)
+}
+
+// podFlows generates the flows installed for a local Pod by InstallPodFlows.
+func (c *client) podFlows(podInterfaceIPs []net.IP, podInterfaceMAC net.HardwareAddr, ofPort uint32) []binding.Flow {
 	localGatewayMAC := c.nodeConfig.GatewayConfig.MAC
 	flows := []binding.Flow{
 		c.podClassifierFlow(ofPort, cookie.Pod),
@@ -434,6 +642,10 @@ func (c *client) InstallPodFlows(interfaceName string, podInterfaceIPs []net.IP,
 	}
 	// Add IP SpoofGuard flows for all validate IPs.
 	flows = append(flows, c.podIPSpoofGuardFlow(podInterfaceIPs, podInterfaceMAC, ofPort, cookie.Pod)...)
+	// Add ND guard flows if the Pod has an IPv6 address.
+	if _, err := util.GetIPWithFamily(podInterfaceIPs, util.FamilyIPv6); c.enableNDGuard && err == nil {
+		flows = append(flows, c.ndGuardFlows(podInterfaceIPs, podInterfaceMAC, ofPort, cookie.Pod)...)
+	}
 	// Add L3 Routing flows to rewrite Pod's dst MAC for all validate IPs.
 	flows = append(flows, c.l3FwdFlowToPod(localGatewayMAC, podInterfaceIPs, podInterfaceMAC, cookie.Pod)...)
 
@@ -443,13 +655,99 @@ func (c *client) InstallPodFlows(interfaceName string, podInterfaceIPs []net.IP,
 			c.l3FwdFlowRouteToPod(podInterfaceIPs, podInterfaceMAC, cookie.Pod)...,
 		)
 	}
-	return c.addFlows(c.podFlowCache, interfaceName, flows)
+	return flows
+}
+
+// podDHCPFlowCacheKey returns the key of the flows installed by InstallPodDHCPFlows in the Pod
+// flow cache. They are cached separately from the flows installed by InstallPodFlows, which are
+// updated when the Pod IPs change.
+func podDHCPFlowCacheKey(interfaceName string) string {
+	return interfaceName + "/dhcp"
+}
+
+func (c *client) InstallPodDHCPFlows(interfaceName string, podInterfaceMAC net.HardwareAddr, ofPort uint32) error {
+	c.replayMutex.RLock()
+	defer c.replayMutex.RUnlock()
+
+	flows := c.dhcpFlows(podInterfaceMAC, ofPort, cookie.Pod)
+	return c.addFlows(c.podFlowCache, podDHCPFlowCacheKey(interfaceName), types.FlowChangeTrigger{Kind: triggerKindPodInterface, Name: interfaceName}, flows)
+}
+
+// podConnectionLimitFlowCacheKey returns the key of the flows installed by
+// InstallPodConnectionLimitFlows in the Pod flow cache.
+func podConnectionLimitFlowCacheKey(interfaceName string) string {
+	return interfaceName + "/connection-limit"
+}
+
+func (c *client) InstallPodConnectionLimitFlows(interfaceName, podNamespace, podName string, ofPort uint32, limit uint32) error {
+	c.replayMutex.RLock()
+	defer c.replayMutex.RUnlock()
+
+	zone := podConnectionLimitZone(ofPort)
+	if err := c.setPodConnectionLimit(interfaceName, podNamespace, podName, zone, limit); err != nil {
+		return err
+	}
+	flows := c.podConnectionLimitFlows(ofPort, zone, cookie.Pod)
+	return c.addFlows(c.podFlowCache, podConnectionLimitFlowCacheKey(interfaceName), types.FlowChangeTrigger{Kind: triggerKindPodInterface, Name: interfaceName}, flows)
+}
+
+// isLocalPodCIDRIP returns whether ip is allocated from the PodCIDRs of this Node.
+func (c *client) isLocalPodCIDRIP(ip net.IP) bool {
+	for _, podCIDR := range []*net.IPNet{c.nodeConfig.PodIPv4CIDR, c.nodeConfig.PodIPv6CIDR} {
+		if podCIDR != nil && podCIDR.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// announceMovedPodIPs sends a gratuitous ARP or an unsolicited Neighbor Advertisement for the Pod
+// IPs which may have existed on another Node before, so that the neighbors holding a stale entry
+// for them learn their new location right away instead of after the entry expires. This matters
+// in noEncap mode, where Pod IPs are directly visible to the host and to the underlay network.
+// IPs allocated from the PodCIDRs of this Node can not have existed on another Node, so only the
+// IPs outside of them are announced. Errors are logged only, as the announcements are best effort.
+func (c *client) announceMovedPodIPs(podInterfaceIPs []net.IP, podInterfaceMAC net.HardwareAddr, ofPort uint32) {
+	if !c.encapMode.SupportsNoEncap() || c.encapMode.IsNetworkPolicyOnly() {
+		return
+	}
+	for _, ip := range podInterfaceIPs {
+		if c.isLocalPodCIDRIP(ip) {
+			continue
+		}
+		if !c.ipAnnouncementLimiter.Allow() {
+			klog.Warningf("Skipping announcement of Pod IP %s because the rate limit is exceeded", ip)
+			continue
+		}
+		klog.V(2).Infof("Announcing Pod IP %s which may have moved from another Node", ip)
+		// The host learns the new location of the IP through the gateway interface.
+		if err := c.SendIPAnnouncementPacketOut(podInterfaceMAC, ip, ofPort, config.HostGatewayOFPort); err != nil {
+			klog.Errorf("Failed to announce Pod IP %s on the gateway interface: %v", ip, err)
+		}
+		// When the uplink is connected to the bridge, the underlay network must send the traffic
+		// for the IP to this Node, which routes it to the Pod.
+		if c.nodeConfig.UplinkNetConfig != nil {
+			if err := c.SendIPAnnouncementPacketOut(c.nodeConfig.UplinkNetConfig.MAC, ip, config.HostGatewayOFPort, config.UplinkOFPort); err != nil {
+				klog.Errorf("Failed to announce Pod IP %s on the uplink interface: %v", ip, err)
+			}
+		}
+	}
 }
 
 func (c *client) UninstallPodFlows(interfaceName string) error {
 	c.replayMutex.RLock()
 	defer c.replayMutex.RUnlock()
-	return c.deleteFlows(c.podFlowCache, interfaceName)
+	trigger := types.FlowChangeTrigger{Kind: triggerKindPodInterface, Name: interfaceName}
+	if err := c.deleteFlows(c.podFlowCache, podDHCPFlowCacheKey(interfaceName), trigger); err != nil {
+		return err
+	}
+	if err := c.deleteFlows(c.podFlowCache, podConnectionLimitFlowCacheKey(interfaceName), trigger); err != nil {
+		return err
+	}
+	if err := c.deletePodConnectionLimit(interfaceName); err != nil {
+		return err
+	}
+	return c.deleteFlows(c.podFlowCache, interfaceName, trigger)
 }
 
 func (c *client) getFlowKeysFromCache(cache *flowCategoryCache, cacheKey string) []string {
@@ -501,6 +799,10 @@ func generateEndpointFlowCacheKey(endpointIP string, endpointPort int, protocol
 	return fmt.Sprintf("E%s%s%x", endpointIP, protocol, endpointPort)
 }
 
+// serviceCIDRFlowCacheKey is the key of the flows installed by InstallClusterServiceCIDRFlows in
+// serviceFlowCache.
+const serviceCIDRFlowCacheKey = "ServiceCIDR"
+
 func generateServicePortFlowCacheKey(svcIP net.IP, svcPort uint16, protocol binding.Protocol) string {
 	return fmt.Sprintf("S%s%s%x", svcIP, protocol, svcPort)
 }
@@ -519,7 +821,7 @@ func (c *client) InstallEndpointFlows(protocol binding.Protocol, endpoints []pro
 		if endpoint.GetIsLocal() {
 			flows = append(flows, c.hairpinSNATFlow(endpointIP))
 		}
-		if err := c.addFlows(c.serviceFlowCache, cacheKey, flows); err != nil {
+		if err := c.addFlows(c.serviceFlowCache, cacheKey, types.FlowChangeTrigger{Kind: triggerKindEndpoint, Name: endpoint.String()}, flows); err != nil {
 			return err
 		}
 	}
@@ -535,7 +837,7 @@ func (c *client) UninstallEndpointFlows(protocol binding.Protocol, endpoint prox
 		return fmt.Errorf("error when getting port: %w", err)
 	}
 	cacheKey := generateEndpointFlowCacheKey(endpoint.IP(), port, protocol)
-	return c.deleteFlows(c.serviceFlowCache, cacheKey)
+	return c.deleteFlows(c.serviceFlowCache, cacheKey, types.FlowChangeTrigger{Kind: triggerKindEndpoint, Name: endpoint.String()})
 }
 
 func (c *client) InstallServiceFlows(groupID binding.GroupIDType, svcIP net.IP, svcPort uint16, protocol binding.Protocol, affinityTimeout uint16) error {
@@ -547,19 +849,40 @@ func (c *client) InstallServiceFlows(groupID binding.GroupIDType, svcIP net.IP,
 		flows = append(flows, c.serviceLearnFlow(groupID, svcIP, svcPort, protocol, affinityTimeout))
 	}
 	cacheKey := generateServicePortFlowCacheKey(svcIP, svcPort, protocol)
-	return c.addFlows(c.serviceFlowCache, cacheKey, flows)
+	return c.addFlows(c.serviceFlowCache, cacheKey, serviceTrigger(svcIP, svcPort, protocol), flows)
 }
 
 func (c *client) UninstallServiceFlows(svcIP net.IP, svcPort uint16, protocol binding.Protocol) error {
 	c.replayMutex.RLock()
 	defer c.replayMutex.RUnlock()
 	cacheKey := generateServicePortFlowCacheKey(svcIP, svcPort, protocol)
-	return c.deleteFlows(c.serviceFlowCache, cacheKey)
+	return c.deleteFlows(c.serviceFlowCache, cacheKey, serviceTrigger(svcIP, svcPort, protocol))
+}
+
+func generateServiceSourceRangeFlowCacheKey(svcIP net.IP, svcPort uint16, protocol binding.Protocol) string {
+	return fmt.Sprintf("R%s%s%x", svcIP, protocol, svcPort)
+}
+
+func (c *client) InstallServiceSourceRangeFlows(groupID binding.GroupIDType, svcIP net.IP, svcPort uint16, protocol binding.Protocol, affinityTimeout uint16, sourceRanges []net.IPNet) error {
+	c.replayMutex.RLock()
+	defer c.replayMutex.RUnlock()
+	flows := c.serviceSourceRangeFlows(groupID, svcIP, svcPort, protocol, affinityTimeout != 0, sourceRanges)
+	cacheKey := generateServiceSourceRangeFlowCacheKey(svcIP, svcPort, protocol)
+	return c.modifyFlows(c.serviceFlowCache, cacheKey, serviceTrigger(svcIP, svcPort, protocol), flows)
+}
+
+func (c *client) UninstallServiceSourceRangeFlows(svcIP net.IP, svcPort uint16, protocol binding.Protocol) error {
+	c.replayMutex.RLock()
+	defer c.replayMutex.RUnlock()
+	cacheKey := generateServiceSourceRangeFlowCacheKey(svcIP, svcPort, protocol)
+	return c.deleteFlows(c.serviceFlowCache, cacheKey, serviceTrigger(svcIP, svcPort, protocol))
 }
 
 func (c *client) GetServiceFlowKeys(svcIP net.IP, svcPort uint16, protocol binding.Protocol, endpoints []proxy.Endpoint) []string {
 	cacheKey := generateServicePortFlowCacheKey(svcIP, svcPort, protocol)
 	flowKeys := c.getFlowKeysFromCache(c.serviceFlowCache, cacheKey)
+	cacheKey = generateServiceSourceRangeFlowCacheKey(svcIP, svcPort, protocol)
+	flowKeys = append(flowKeys, c.getFlowKeysFromCache(c.serviceFlowCache, cacheKey)...)
 	for _, ep := range endpoints {
 		epPort, _ := ep.Port()
 		cacheKey = generateEndpointFlowCacheKey(ep.IP(), epPort, protocol)
@@ -568,12 +891,32 @@ func (c *client) GetServiceFlowKeys(svcIP net.IP, svcPort uint16, protocol bindi
 	return flowKeys
 }
 
+func (c *client) GetEndpointDNATFlowKey(protocol binding.Protocol, endpoint proxy.Endpoint) string {
+	port, err := endpoint.Port()
+	if err != nil {
+		return ""
+	}
+	cacheKey := generateEndpointFlowCacheKey(endpoint.IP(), port, protocol)
+	fCacheI, ok := c.serviceFlowCache.Load(cacheKey)
+	if !ok {
+		return ""
+	}
+	c.replayMutex.RLock()
+	defer c.replayMutex.RUnlock()
+	for _, flow := range fCacheI.(flowCache) {
+		if flow.GetTableID() == endpointDNATTable {
+			return flow.MatchString()
+		}
+	}
+	return ""
+}
+
 func (c *client) InstallClusterServiceFlows() error {
 	flows := []binding.Flow{
 		c.serviceNeedLBFlow(),
-		c.sessionAffinityReselectFlow(),
 		c.l2ForwardOutputServiceHairpinFlow(),
 	}
+	flows = append(flows, c.serviceReselectFlows()...)
 	if c.IsIPv4Enabled() {
 		flows = append(flows, c.serviceHairpinResponseDNATFlow(binding.ProtocolIP))
 		flows = append(flows, c.serviceLBBypassFlows(binding.ProtocolIP)...)
@@ -582,7 +925,7 @@ func (c *client) InstallClusterServiceFlows() error {
 		flows = append(flows, c.serviceHairpinResponseDNATFlow(binding.ProtocolIPv6))
 		flows = append(flows, c.serviceLBBypassFlows(binding.ProtocolIPv6)...)
 	}
-	if err := c.ofEntryOperations.AddAll(flows); err != nil {
+	if err := c.ofEntryOps(pipelineTrigger).AddAll(flows); err != nil {
 		return err
 	}
 	c.defaultServiceFlows = flows
@@ -590,12 +933,17 @@ func (c *client) InstallClusterServiceFlows() error {
 }
 
 func (c *client) InstallClusterServiceCIDRFlows(serviceNets []*net.IPNet) error {
-	flows := c.serviceCIDRDNATFlows(serviceNets)
-	if err := c.ofEntryOperations.AddAll(flows); err != nil {
-		return err
+	c.replayMutex.RLock()
+	defer c.replayMutex.RUnlock()
+	var flows []binding.Flow
+	if c.enableProxy {
+		flows = c.serviceGatewayFlows(serviceNets)
+	} else {
+		flows = c.serviceCIDRDNATFlows(serviceNets)
 	}
-	c.defaultServiceFlows = flows
-	return nil
+	// The flows are cached with the Service flows, so that the ones of the previous Service CIDRs
+	// are replaced when the method is invoked again.
+	return c.modifyFlows(c.serviceFlowCache, serviceCIDRFlowCacheKey, serviceCIDRTrigger, flows)
 }
 
 func (c *client) InstallGatewayFlows() error {
@@ -621,7 +969,7 @@ func (c *client) InstallGatewayFlows() error {
 	flows = append(flows, c.localProbeFlow(gatewayIPs, cookie.Default)...)
 	flows = append(flows, c.l3FwdFlowToGateway(gatewayIPs, gatewayConfig.MAC, cookie.Default)...)
 
-	if err := c.ofEntryOperations.AddAll(flows); err != nil {
+	if err := c.ofEntryOps(pipelineTrigger).AddAll(flows); err != nil {
 		return err
 	}
 	c.gatewayFlows = flows
@@ -633,7 +981,12 @@ func (c *client) InstallDefaultTunnelFlows() error {
 		c.tunnelClassifierFlow(config.DefaultTunOFPort, cookie.Default),
 		c.l2ForwardCalcFlow(globalVirtualMAC, config.DefaultTunOFPort, true, cookie.Default),
 	}
-	if err := c.ofEntryOperations.AddAll(flows); err != nil {
+	if c.nodeConfig.NodeMTU > 0 {
+		// Replies to the packets too large for the tunnel with ICMP errors carrying the tunnel MTU.
+		flows = append(flows, c.tunnelPMTUFlows(config.DefaultTunOFPort, cookie.Default)...)
+		c.RegisterPacketInHandler(uint8(PacketInReasonPMTU), "pmtu", newPMTUResponder(c))
+	}
+	if err := c.ofEntryOps(pipelineTrigger).AddAll(flows); err != nil {
 		return err
 	}
 	c.defaultTunnelFlows = flows
@@ -641,27 +994,32 @@ func (c *client) InstallDefaultTunnelFlows() error {
 }
 
 func (c *client) initialize() error {
-	if err := c.ofEntryOperations.AddAll(c.defaultFlows()); err != nil {
+	if err := c.ofEntryOps(pipelineTrigger).AddAll(c.defaultFlows()); err != nil {
 		return fmt.Errorf("failed to install default flows: %v", err)
 	}
-	if err := c.ofEntryOperations.Add(c.arpNormalFlow(cookie.Default)); err != nil {
+	if err := c.ofEntryOps(pipelineTrigger).Add(c.arpNormalFlow(cookie.Default)); err != nil {
 		return fmt.Errorf("failed to install arp normal flow: %v", err)
 	}
-	if err := c.ofEntryOperations.AddAll(c.ipv6Flows(cookie.Default)); err != nil {
+	if err := c.ofEntryOps(pipelineTrigger).AddAll(c.ipv6Flows(cookie.Default)); err != nil {
 		return fmt.Errorf("failed to install ipv6 flows: %v", err)
 	}
-	if err := c.ofEntryOperations.AddAll(c.decTTLFlows(cookie.Default)); err != nil {
+	if err := c.ofEntryOps(pipelineTrigger).AddAll(c.decTTLFlows(cookie.Default)); err != nil {
 		return fmt.Errorf("failed to install dec TTL flow on source Node: %v", err)
 	}
-	if err := c.ofEntryOperations.AddAll(c.l2ForwardOutputFlows(cookie.Default)); err != nil {
+	if err := c.ofEntryOps(pipelineTrigger).AddAll(c.l2ForwardOutputFlows(cookie.Default)); err != nil {
 		return fmt.Errorf("failed to install L2 forward output flows: %v", err)
 	}
-	if err := c.ofEntryOperations.AddAll(c.connectionTrackFlows(cookie.Default)); err != nil {
+	if err := c.ofEntryOps(pipelineTrigger).AddAll(c.connectionTrackFlows(cookie.Default)); err != nil {
 		return fmt.Errorf("failed to install connection track flows: %v", err)
 	}
-	if err := c.ofEntryOperations.AddAll(c.establishedConnectionFlows(cookie.Default)); err != nil {
+	if err := c.ofEntryOps(pipelineTrigger).AddAll(c.establishedConnectionFlows(cookie.Default)); err != nil {
 		return fmt.Errorf("failed to install flows to skip established connections: %v", err)
 	}
+	if c.enableProxy {
+		if err := c.ofEntryOps(pipelineTrigger).Add(c.serviceLoopGuardFlow(cookie.Default)); err != nil {
+			return fmt.Errorf("failed to install Service loop guard flow: %v", err)
+		}
+	}
 	if c.encapMode.IsNetworkPolicyOnly() {
 		if err := c.setupPolicyOnlyFlows(); err != nil {
 			return fmt.Errorf("failed to setup policy only flows: %w", err)
@@ -674,6 +1032,21 @@ func (c *client) initialize() error {
 		if err := c.genPacketInMeter(PacketInMeterIDTF, PacketInMeterRateTF).Add(); err != nil {
 			return fmt.Errorf("failed to install OpenFlow meter entry (meterID:%d, rate:%d) for TraceFlow packet-in rate limiting: %v", PacketInMeterIDTF, PacketInMeterRateTF, err)
 		}
+		if err := c.genPacketInMeter(PacketInMeterIDPC, PacketInMeterRatePC).Add(); err != nil {
+			return fmt.Errorf("failed to install OpenFlow meter entry (meterID:%d, rate:%d) for PacketCapture packet-in rate limiting: %v", PacketInMeterIDPC, PacketInMeterRatePC, err)
+		}
+		if err := c.genPacketInMeter(PacketInMeterIDNDGuard, PacketInMeterRateNDGuard).Add(); err != nil {
+			return fmt.Errorf("failed to install OpenFlow meter entry (meterID:%d, rate:%d) for ND guard packet-in rate limiting: %v", PacketInMeterIDNDGuard, PacketInMeterRateNDGuard, err)
+		}
+		if err := c.genPacketInMeter(PacketInMeterIDDHCP, PacketInMeterRateDHCP).Add(); err != nil {
+			return fmt.Errorf("failed to install OpenFlow meter entry (meterID:%d, rate:%d) for DHCP packet-in rate limiting: %v", PacketInMeterIDDHCP, PacketInMeterRateDHCP, err)
+		}
+		if err := c.genPacketInMeter(PacketInMeterIDServiceLoop, PacketInMeterRateServiceLoop).Add(); err != nil {
+			return fmt.Errorf("failed to install OpenFlow meter entry (meterID:%d, rate:%d) for Service loop guard packet-in rate limiting: %v", PacketInMeterIDServiceLoop, PacketInMeterRateServiceLoop, err)
+		}
+		if err := c.genPacketInMeter(PacketInMeterIDServiceSourceRange, PacketInMeterRateServiceSourceRange).Add(); err != nil {
+			return fmt.Errorf("failed to install OpenFlow meter entry (meterID:%d, rate:%d) for Service source range packet-in rate limiting: %v", PacketInMeterIDServiceSourceRange, PacketInMeterRateServiceSourceRange, err)
+		}
 	}
 	return nil
 }
@@ -698,14 +1071,20 @@ func (c *client) Initialize(roundInfo types.RoundInfo, nodeConfig *config.NodeCo
 	// Ignore first notification, it is not a "reconnection".
 	<-connCh
 
+	c.capabilities = c.probeCapabilities()
+	if err := c.checkRequiredCapabilities(); err != nil {
+		return nil, err
+	}
+	c.ovsMetersAreSupported = c.capabilities.Has(binding.CapabilityMeter)
+
 	c.roundInfo = roundInfo
-	c.cookieAllocator = cookie.NewAllocator(roundInfo.RoundNum)
+	c.cookieAllocator = cookie.NewAllocator(roundInfo.RoundNum, roundInfo.Generation)
 
 	// In the normal case, there should be no existing flows with the current round number. This
 	// is needed in case the agent was restarted before we had a chance to increment the round
 	// number (incrementing the round number happens once we are satisfied that stale flows from
 	// the previous round have been deleted).
-	if err := c.deleteFlowsByRoundNum(roundInfo.RoundNum); err != nil {
+	if err := c.deleteFlowsByRound(roundInfo.RoundNum, roundInfo.Generation); err != nil {
 		return nil, fmt.Errorf("error when deleting exiting flows for current round number: %v", err)
 	}
 
@@ -735,7 +1114,7 @@ func (c *client) InstallExternalFlows() error {
 		flows = append(flows, c.externalFlows(nodeIP, *c.nodeConfig.PodIPv6CIDR, localGatewayMAC)...)
 	}
 
-	if err := c.ofEntryOperations.AddAll(flows); err != nil {
+	if err := c.ofEntryOps(pipelineTrigger).AddAll(flows); err != nil {
 		return fmt.Errorf("failed to install flows for external communication: %v", err)
 	}
 	c.hostNetworkingFlows = append(c.hostNetworkingFlows, flows...)
@@ -747,14 +1126,35 @@ func (c *client) InstallSNATMarkFlows(snatIP net.IP, mark uint32) error {
 	cacheKey := fmt.Sprintf("s%x", mark)
 	c.replayMutex.RLock()
 	defer c.replayMutex.RUnlock()
-	return c.addFlows(c.snatFlowCache, cacheKey, flows)
+	return c.addFlows(c.snatFlowCache, cacheKey, snatMarkTrigger(mark), flows)
 }
 
 func (c *client) UninstallSNATMarkFlows(mark uint32) error {
 	cacheKey := fmt.Sprintf("s%x", mark)
 	c.replayMutex.RLock()
 	defer c.replayMutex.RUnlock()
-	return c.deleteFlows(c.snatFlowCache, cacheKey)
+	return c.deleteFlows(c.snatFlowCache, cacheKey, snatMarkTrigger(mark))
+}
+
+func (c *client) InstallPolicyBootstrapFlows(allowlist []types.PolicyBootstrapPeer) error {
+	c.replayMutex.RLock()
+	defer c.replayMutex.RUnlock()
+	flows := c.bootstrapDenyFlows(allowlist, cookie.Policy)
+	if err := c.ofEntryOps(types.FlowChangeTrigger{Kind: triggerKindPolicyBoot}).AddAll(flows); err != nil {
+		return err
+	}
+	c.policyBootstrapFlows = flows
+	return nil
+}
+
+func (c *client) UninstallPolicyBootstrapFlows() error {
+	c.replayMutex.RLock()
+	defer c.replayMutex.RUnlock()
+	if err := c.ofEntryOps(types.FlowChangeTrigger{Kind: triggerKindPolicyBoot}).DeleteAll(c.policyBootstrapFlows); err != nil {
+		return err
+	}
+	c.policyBootstrapFlows = nil
+	return nil
 }
 
 func (c *client) InstallPodSNATFlows(ofPort uint32, snatIP net.IP, snatMark uint32) error {
@@ -762,14 +1162,14 @@ func (c *client) InstallPodSNATFlows(ofPort uint32, snatIP net.IP, snatMark uint
 	cacheKey := fmt.Sprintf("p%x", ofPort)
 	c.replayMutex.RLock()
 	defer c.replayMutex.RUnlock()
-	return c.addFlows(c.snatFlowCache, cacheKey, flows)
+	return c.addFlows(c.snatFlowCache, cacheKey, podSNATTrigger(ofPort), flows)
 }
 
 func (c *client) UninstallPodSNATFlows(ofPort uint32) error {
 	cacheKey := fmt.Sprintf("p%x", ofPort)
 	c.replayMutex.RLock()
 	defer c.replayMutex.RUnlock()
-	return c.deleteFlows(c.snatFlowCache, cacheKey)
+	return c.deleteFlows(c.snatFlowCache, cacheKey, podSNATTrigger(ofPort))
 }
 
 func (c *client) ReplayFlows() {
@@ -784,7 +1184,7 @@ func (c *client) ReplayFlows() {
 		for _, flow := range flows {
 			flow.Reset()
 		}
-		if err := c.ofEntryOperations.AddAll(flows); err != nil {
+		if err := c.ofEntryOps(replayTrigger).AddAll(flows); err != nil {
 			klog.Errorf("Error when replaying fixed flows: %v", err)
 		}
 
@@ -797,6 +1197,10 @@ func (c *client) ReplayFlows() {
 	if len(c.hostNetworkingFlows) > 0 {
 		addFixedFlows(c.hostNetworkingFlows)
 	}
+	// policyBootstrapFlows are only installed until the first NetworkPolicy sync when the agent fails closed.
+	if len(c.policyBootstrapFlows) > 0 {
+		addFixedFlows(c.policyBootstrapFlows)
+	}
 
 	installCachedFlows := func(key, value interface{}) bool {
 		fCache := value.(flowCache)
@@ -807,7 +1211,7 @@ func (c *client) ReplayFlows() {
 			cachedFlows = append(cachedFlows, flow)
 		}
 
-		if err := c.ofEntryOperations.AddAll(cachedFlows); err != nil {
+		if err := c.ofEntryOps(replayTrigger).AddAll(cachedFlows); err != nil {
 			klog.Errorf("Error when replaying cached flows: %v", err)
 		}
 		return true
@@ -825,11 +1229,15 @@ func (c *client) ReplayFlows() {
 	c.podFlowCache.Range(installCachedFlows)
 	c.serviceFlowCache.Range(installCachedFlows)
 
+	c.replayPodConnectionLimits()
 	c.replayPolicyFlows()
 }
 
-func (c *client) deleteFlowsByRoundNum(roundNum uint64) error {
-	cookieID, cookieMask := cookie.CookieMaskForRound(roundNum)
+// deleteFlowsByRound deletes the flows whose cookie matches both the provided round number and
+// generation, so that flows installed by another generation sharing the same round number are
+// preserved.
+func (c *client) deleteFlowsByRound(roundNum, generation uint64) error {
+	cookieID, cookieMask := cookie.CookieMaskForRoundAndGeneration(roundNum, generation)
 	return c.bridge.DeleteFlowsByCookie(cookieID, cookieMask)
 }
 
@@ -838,7 +1246,12 @@ func (c *client) DeleteStaleFlows() error {
 		klog.V(2).Info("Previous round number is unset, no flows to delete")
 		return nil
 	}
-	return c.deleteFlowsByRoundNum(*c.roundInfo.PrevRoundNum)
+	prevRoundNum, prevGeneration := *c.roundInfo.PrevRoundNum, c.roundInfo.PrevGeneration
+	if prevRoundNum == c.roundInfo.RoundNum && prevGeneration == c.roundInfo.Generation {
+		klog.V(2).Info("Previous round is the current round, no flows to delete")
+		return nil
+	}
+	return c.deleteFlowsByRound(prevRoundNum, prevGeneration)
 }
 
 func (c *client) setupPolicyOnlyFlows() error {
@@ -849,7 +1262,12 @@ func (c *client) setupPolicyOnlyFlows() error {
 		// Replies any ARP request with the same global virtual MAC.
 		c.arpResponderStaticFlow(cookie.Default),
 	)
-	if err := c.ofEntryOperations.AddAll(flows); err != nil {
+	if c.IsIPv6Enabled() {
+		// Replies any Neighbor Solicitation with the same global virtual MAC.
+		flows = append(flows, c.ndResponderFlow(cookie.Default))
+		c.RegisterPacketInHandler(uint8(PacketInReasonND), "ndp", newNDPResponder(c))
+	}
+	if err := c.ofEntryOps(pipelineTrigger).AddAll(flows); err != nil {
 		return fmt.Errorf("failed to setup policy-only flows: %w", err)
 	}
 	return nil
@@ -925,15 +1343,24 @@ func (c *client) SendTraceflowPacket(dataplaneTag uint8, packet *binding.Packet,
 func (c *client) InstallTraceflowFlows(dataplaneTag uint8, liveTraffic, droppedOnly, receiverOnly bool, packet *binding.Packet, ofPort uint32, timeoutSeconds uint16) error {
 	cacheKey := fmt.Sprintf("%x", dataplaneTag)
 	flows := []binding.Flow{}
-	flows = append(flows, c.traceflowConnectionTrackFlows(dataplaneTag, receiverOnly, packet, ofPort, timeoutSeconds, cookie.Default)...)
-	flows = append(flows, c.traceflowL2ForwardOutputFlows(dataplaneTag, liveTraffic, droppedOnly, timeoutSeconds, cookie.Default)...)
-	flows = append(flows, c.traceflowNetworkPolicyFlows(dataplaneTag, timeoutSeconds, cookie.Default)...)
-	return c.addFlows(c.tfFlowCache, cacheKey, flows)
+	flows = append(flows, c.traceflowConnectionTrackFlows(dataplaneTag, receiverOnly, packet, ofPort, timeoutSeconds, cookie.Traceflow)...)
+	flows = append(flows, c.traceflowL2ForwardOutputFlows(dataplaneTag, liveTraffic, droppedOnly, timeoutSeconds, cookie.Traceflow)...)
+	flows = append(flows, c.traceflowNetworkPolicyFlows(dataplaneTag, timeoutSeconds, cookie.Traceflow)...)
+	return c.addFlows(c.tfFlowCache, cacheKey, traceflowTrigger(dataplaneTag), flows)
 }
 
 func (c *client) UninstallTraceflowFlows(dataplaneTag uint8) error {
 	cacheKey := fmt.Sprintf("%x", dataplaneTag)
-	return c.deleteFlows(c.tfFlowCache, cacheKey)
+	return c.deleteFlows(c.tfFlowCache, cacheKey, traceflowTrigger(dataplaneTag))
+}
+
+func (c *client) InstallPacketCaptureFlows(name string, packet *binding.Packet, timeoutSeconds uint16) error {
+	flows := c.packetCaptureL2ForwardOutputFlows(packet, timeoutSeconds, cookie.PacketCapture)
+	return c.addFlows(c.pcFlowCache, name, types.FlowChangeTrigger{Kind: triggerKindPacketCapture, Name: name}, flows)
+}
+
+func (c *client) UninstallPacketCaptureFlows(name string) error {
+	return c.deleteFlows(c.pcFlowCache, name, types.FlowChangeTrigger{Kind: triggerKindPacketCapture, Name: name})
 }
 
 // Add TLV map optClass 0x0104, optType 0x80 optLength 4 tunMetadataIndex 0 to store data plane tag
@@ -1067,3 +1494,19 @@ func (c *client) SendICMPPacketOut(
 	packetOutObj := packetOutBuilder.Done()
 	return c.bridge.SendPacketOut(packetOutObj)
 }
+
+// SendIPAnnouncementPacketOut generates a gratuitous ARP or an unsolicited Neighbor Advertisement
+// as a packet-out and sends it to OVS.
+func (c *client) SendIPAnnouncementPacketOut(mac net.HardwareAddr, ip net.IP, inPort uint32, outPort uint32) error {
+	var frame []byte
+	var err error
+	if ip.To4() != nil {
+		frame, err = binding.GratuitousARP(mac, ip)
+	} else {
+		frame, err = binding.UnsolicitedNeighborAdvertisement(mac, ip)
+	}
+	if err != nil {
+		return err
+	}
+	return c.bridge.SendRawPacketOut(inPort, outPort, frame)
+}
@@ -27,7 +27,7 @@ func (c *client) InstallBridgeUplinkFlows() error {
 	return nil
 }
 
-func (c *client) InstallLoadBalancerServiceFromOutsideFlows(svcIP net.IP, svcPort uint16, protocol binding.Protocol) error {
+func (c *client) InstallLoadBalancerServiceFromOutsideFlows(svcIP net.IP, svcPort uint16, protocol binding.Protocol, disableSNAT bool) error {
 	return nil
 }
 
@@ -18,6 +18,7 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -31,9 +32,12 @@ import (
 	"antrea.io/antrea/pkg/agent/config"
 	"antrea.io/antrea/pkg/agent/openflow/cookie"
 	oftest "antrea.io/antrea/pkg/agent/openflow/testing"
+	"antrea.io/antrea/pkg/agent/types"
 	binding "antrea.io/antrea/pkg/ovs/openflow"
 	ovsoftest "antrea.io/antrea/pkg/ovs/openflow/testing"
 	"antrea.io/antrea/pkg/ovs/ovsconfig"
+	ovsctltest "antrea.io/antrea/pkg/ovs/ovsctl/testing"
+	k8sproxy "antrea.io/antrea/third_party/proxy"
 )
 
 const bridgeName = "dummy-br"
@@ -102,7 +106,7 @@ func TestIdempotentFlowInstallation(t *testing.T) {
 			m := oftest.NewMockOFEntryOperations(ctrl)
 			ofClient := NewClient(bridgeName, bridgeMgmtAddr, ovsconfig.OVSDatapathSystem, true, false, false, false)
 			client := ofClient.(*client)
-			client.cookieAllocator = cookie.NewAllocator(0)
+			client.cookieAllocator = cookie.NewAllocator(0, 0)
 			client.ofEntryOperations = m
 			client.nodeConfig = nodeConfig
 
@@ -130,7 +134,7 @@ func TestIdempotentFlowInstallation(t *testing.T) {
 			m := oftest.NewMockOFEntryOperations(ctrl)
 			ofClient := NewClient(bridgeName, bridgeMgmtAddr, ovsconfig.OVSDatapathSystem, true, false, false, false)
 			client := ofClient.(*client)
-			client.cookieAllocator = cookie.NewAllocator(0)
+			client.cookieAllocator = cookie.NewAllocator(0, 0)
 			client.ofEntryOperations = m
 			client.nodeConfig = nodeConfig
 
@@ -171,7 +175,7 @@ func TestFlowInstallationFailed(t *testing.T) {
 			m := oftest.NewMockOFEntryOperations(ctrl)
 			ofClient := NewClient(bridgeName, bridgeMgmtAddr, ovsconfig.OVSDatapathSystem, true, false, false, false)
 			client := ofClient.(*client)
-			client.cookieAllocator = cookie.NewAllocator(0)
+			client.cookieAllocator = cookie.NewAllocator(0, 0)
 			client.ofEntryOperations = m
 			client.nodeConfig = nodeConfig
 
@@ -205,7 +209,7 @@ func TestConcurrentFlowInstallation(t *testing.T) {
 			m := oftest.NewMockOFEntryOperations(ctrl)
 			ofClient := NewClient(bridgeName, bridgeMgmtAddr, ovsconfig.OVSDatapathSystem, true, false, false, false)
 			client := ofClient.(*client)
-			client.cookieAllocator = cookie.NewAllocator(0)
+			client.cookieAllocator = cookie.NewAllocator(0, 0)
 			client.ofEntryOperations = m
 			client.nodeConfig = nodeConfig
 
@@ -395,7 +399,7 @@ func Test_client_SendTraceflowPacket(t *testing.T) {
 func prepareTraceflowFlow(ctrl *gomock.Controller) *client {
 	ofClient := NewClient(bridgeName, bridgeMgmtAddr, ovsconfig.OVSDatapathSystem, true, true, false, false)
 	c := ofClient.(*client)
-	c.cookieAllocator = cookie.NewAllocator(0)
+	c.cookieAllocator = cookie.NewAllocator(0, 0)
 	c.nodeConfig = nodeConfig
 	m := ovsoftest.NewMockBridge(ctrl)
 	m.EXPECT().AddFlowsInBundle(gomock.Any(), nil, nil).Return(nil).Times(1)
@@ -510,3 +514,345 @@ func prepareSetBasePacketOutBuilder(ctrl *gomock.Controller, success bool) *clie
 	}
 	return c
 }
+
+// TestDeleteStaleFlows simulates several agent restarts on a fake flow table, including one which
+// wraps around the round number and one in which the agent crashes before deleting stale flows,
+// and verifies that the flows installed by the current round are never deleted.
+func TestDeleteStaleFlows(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	const maxRoundNum = 1<<cookie.BitwidthRound - 1
+	// Cookies of the flows in the fake flow table. The flow table initially contains some flows
+	// installed by the round using the same round number as the last round, but a previous
+	// generation, which should not be deleted when cleaning up the flows of the last round.
+	flows := map[uint64]bool{
+		cookie.NewAllocator(1, 0).RequestWithObjectID(cookie.Pod, 100).Raw(): true,
+	}
+	m := ovsoftest.NewMockBridge(ctrl)
+	m.EXPECT().DeleteFlowsByCookie(gomock.Any(), gomock.Any()).DoAndReturn(func(cookieID, cookieMask uint64) error {
+		for flowCookie := range flows {
+			if flowCookie&cookieMask == cookieID {
+				delete(flows, flowCookie)
+			}
+		}
+		return nil
+	}).AnyTimes()
+
+	roundNum := func(num uint64) *uint64 { return &num }
+	tests := []struct {
+		roundInfo types.RoundInfo
+		crash     bool
+	}{
+		{types.RoundInfo{RoundNum: maxRoundNum, Generation: 0, PrevRoundNum: roundNum(maxRoundNum - 1), PrevGeneration: 0}, false},
+		{types.RoundInfo{RoundNum: 0, Generation: 1, PrevRoundNum: roundNum(maxRoundNum), PrevGeneration: 0}, true},
+		{types.RoundInfo{RoundNum: 0, Generation: 1, PrevRoundNum: roundNum(maxRoundNum), PrevGeneration: 0}, false},
+		{types.RoundInfo{RoundNum: 1, Generation: 1, PrevRoundNum: roundNum(0), PrevGeneration: 1}, false},
+		{types.RoundInfo{RoundNum: 2, Generation: 1, PrevRoundNum: roundNum(1), PrevGeneration: 1}, false},
+	}
+	for i, tt := range tests {
+		ofClient := NewClient(bridgeName, bridgeMgmtAddr, ovsconfig.OVSDatapathSystem, true, false, false, false)
+		c := ofClient.(*client)
+		c.bridge = m
+		c.roundInfo = tt.roundInfo
+		c.cookieAllocator = cookie.NewAllocator(tt.roundInfo.RoundNum, tt.roundInfo.Generation)
+
+		// Same sequence as in client.Initialize: delete the flows of the current round, then
+		// install the flows of the current round.
+		require.NoError(t, c.deleteFlowsByRound(tt.roundInfo.RoundNum, tt.roundInfo.Generation))
+		var currentFlows []uint64
+		for objectID := uint32(0); objectID < 3; objectID++ {
+			flowCookie := c.cookieAllocator.RequestWithObjectID(cookie.Pod, objectID).Raw()
+			flows[flowCookie] = true
+			currentFlows = append(currentFlows, flowCookie)
+		}
+		if tt.crash {
+			continue
+		}
+		require.NoError(t, c.DeleteStaleFlows())
+		for _, flowCookie := range currentFlows {
+			assert.True(t, flows[flowCookie], "Flow of the current round was deleted for restart %d", i)
+		}
+		assert.Len(t, flows, len(currentFlows)+1, "Unexpected number of flows for restart %d", i)
+	}
+	assert.True(t, flows[cookie.NewAllocator(1, 0).RequestWithObjectID(cookie.Pod, 100).Raw()], "Flow of a previous generation was deleted")
+}
+
+// TestFlowCookieCategories verifies that the cookies of the flows installed for each feature decode
+// to the feature's category, so that selecting the flows of a category never selects the flows of
+// another feature.
+func TestFlowCookieCategories(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	m := oftest.NewMockOFEntryOperations(ctrl)
+	ofClient := NewClient(bridgeName, bridgeMgmtAddr, ovsconfig.OVSDatapathSystem, true, false, false, false)
+	c := ofClient.(*client)
+	c.cookieAllocator = cookie.NewAllocator(1, 2)
+	c.ofEntryOperations = m
+	c.nodeConfig = nodeConfig
+	var installedFlows []binding.Flow
+	m.EXPECT().AddAll(gomock.Any()).DoAndReturn(func(flows []binding.Flow) error {
+		installedFlows = flows
+		return nil
+	}).AnyTimes()
+
+	svcIP := net.ParseIP("10.96.0.1")
+	endpoint := k8sproxy.NewBaseEndpointInfo("10.10.0.2", 8080, true, nil)
+	tests := []struct {
+		name      string
+		category  cookie.Category
+		installFn func() error
+	}{
+		{"Pod", cookie.Pod, func() error {
+			_, err := installPodFlows(ofClient, "pod1")
+			return err
+		}},
+		{"Node", cookie.Node, func() error {
+			_, err := installNodeFlows(ofClient, "node1")
+			return err
+		}},
+		{"Service", cookie.Service, func() error {
+			return c.InstallServiceFlows(1, svcIP, 80, binding.ProtocolTCP, 300)
+		}},
+		{"Endpoint", cookie.Service, func() error {
+			return c.InstallEndpointFlows(binding.ProtocolTCP, []k8sproxy.Endpoint{endpoint})
+		}},
+		{"Traceflow", cookie.Traceflow, func() error {
+			return c.InstallTraceflowFlows(1, false, false, false, nil, 0, 300)
+		}},
+		{"PacketCapture", cookie.PacketCapture, func() error {
+			return c.InstallPacketCaptureFlows("pc1", &binding.Packet{}, 300)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			installedFlows = nil
+			require.NoError(t, tt.installFn())
+			require.NotEmpty(t, installedFlows)
+			for _, flow := range installedFlows {
+				id := cookie.ID(flow.GetCookieID())
+				assert.Equal(t, tt.category, id.Category(), "Unexpected cookie %s for flow %s", id, flow.MatchString())
+				assert.Equal(t, uint64(1), id.Round())
+				assert.Equal(t, uint64(2), id.Generation())
+			}
+		})
+	}
+}
+
+func TestInstallClusterServiceCIDRFlowsWithProxy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	m := oftest.NewMockOFEntryOperations(ctrl)
+	ofClient := NewClient(bridgeName, bridgeMgmtAddr, ovsconfig.OVSDatapathSystem, true, false, false, false)
+	c := ofClient.(*client)
+	c.cookieAllocator = cookie.NewAllocator(0, 0)
+	c.ofEntryOperations = m
+	c.nodeConfig = nodeConfig
+
+	defaultServiceFlow := c.serviceNeedLBFlow()
+	c.defaultServiceFlows = []binding.Flow{defaultServiceFlow}
+	_, serviceCIDR, _ := net.ParseCIDR("10.96.0.0/12")
+	_, serviceCIDRv6, _ := net.ParseCIDR("fd00:10:96::/112")
+	m.EXPECT().AddAll(gomock.Any()).Return(nil).Times(1)
+	require.NoError(t, c.InstallClusterServiceCIDRFlows([]*net.IPNet{serviceCIDR, serviceCIDRv6}))
+	// The ARP responder flow for the virtual Service IP and the flow outputting the load-balanced
+	// packets back to the gateway must be installed, without changing the default Service flows.
+	assert.Equal(t, []binding.Flow{defaultServiceFlow}, c.defaultServiceFlows)
+	flowKeys := c.getFlowKeysFromCache(c.serviceFlowCache, serviceCIDRFlowCacheKey)
+	require.Len(t, flowKeys, 2)
+	var tables []string
+	for _, flowKey := range flowKeys {
+		tables = append(tables, strings.SplitN(flowKey, ",", 2)[0])
+	}
+	assert.ElementsMatch(t, []string{
+		fmt.Sprintf("table=%d", c.pipeline[arpResponderTable].GetID()),
+		fmt.Sprintf("table=%d", c.pipeline[L2ForwardingOutTable].GetID()),
+	}, tables)
+}
+
+func TestInstallClusterServiceCIDRFlowsUpdate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	m := oftest.NewMockOFEntryOperations(ctrl)
+	ofClient := NewClient(bridgeName, bridgeMgmtAddr, ovsconfig.OVSDatapathSystem, false, false, false, false)
+	c := ofClient.(*client)
+	c.cookieAllocator = cookie.NewAllocator(0, 0)
+	c.ofEntryOperations = m
+	c.nodeConfig = nodeConfig
+
+	_, serviceCIDR1, _ := net.ParseCIDR("10.96.0.0/12")
+	_, serviceCIDR2, _ := net.ParseCIDR("10.0.0.0/8")
+	m.EXPECT().AddAll(gomock.Any()).Return(nil).Times(1)
+	require.NoError(t, c.InstallClusterServiceCIDRFlows([]*net.IPNet{serviceCIDR1}))
+	oldFlowKeys := c.getFlowKeysFromCache(c.serviceFlowCache, serviceCIDRFlowCacheKey)
+	require.Len(t, oldFlowKeys, 1)
+
+	// The flow of the previous Service CIDR is replaced with the one of the new Service CIDR.
+	m.EXPECT().BundleOps(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(adds, mods, dels []binding.Flow) error {
+		require.Len(t, adds, 1)
+		assert.Contains(t, adds[0].MatchString(), "nw_dst=10.0.0.0/8")
+		assert.Empty(t, mods)
+		require.Len(t, dels, 1)
+		assert.Equal(t, oldFlowKeys[0], dels[0].MatchString())
+		return nil
+	}).Times(1)
+	require.NoError(t, c.InstallClusterServiceCIDRFlows([]*net.IPNet{serviceCIDR2}))
+	flowKeys := c.getFlowKeysFromCache(c.serviceFlowCache, serviceCIDRFlowCacheKey)
+	require.Len(t, flowKeys, 1)
+	assert.Contains(t, flowKeys[0], "nw_dst=10.0.0.0/8")
+}
+
+func TestGetPipeline(t *testing.T) {
+	for _, tc := range []struct {
+		name               string
+		enableProxy        bool
+		enableAntreaPolicy bool
+		enableEgress       bool
+	}{
+		{"default", true, true, false},
+		{"proxy disabled", false, false, false},
+		{"all features", true, true, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ofClient := NewClient(bridgeName, bridgeMgmtAddr, ovsconfig.OVSDatapathSystem, tc.enableProxy, tc.enableAntreaPolicy, tc.enableEgress, false)
+			tables := ofClient.GetPipeline()
+			require.NotEmpty(t, tables)
+			installed := make(map[binding.TableIDType]bool)
+			for i, table := range tables {
+				if i > 0 {
+					assert.Less(t, uint8(tables[i-1].GetID()), uint8(table.GetID()), "Tables should be ordered by ID")
+				}
+				// Every table of the pipeline must be registered so that its name can be resolved.
+				_, ok := GetFlowTable(table.GetID())
+				assert.True(t, ok, "Table %d is not registered", table.GetID())
+				installed[table.GetID()] = true
+			}
+			for _, table := range tables {
+				if next := table.GetNext(); next != binding.LastTableID {
+					assert.True(t, installed[next], "Next table %d of table %d is not installed", next, table.GetID())
+				}
+			}
+		})
+	}
+}
+
+func TestProbeCapabilities(t *testing.T) {
+	metersSupported := ovsMetersAreSupported(ovsconfig.OVSDatapathNetdev)
+	for _, tc := range []struct {
+		name               string
+		versionOutput      string
+		enableProxy        bool
+		expectedOVSVersion string
+		expectedAvailable  []binding.Capability
+		expectedErr        string
+	}{
+		{
+			name:               "recent OVS",
+			versionOutput:      "ovs-vswitchd (Open vSwitch) 2.14.0\n",
+			enableProxy:        true,
+			expectedOVSVersion: "2.14.0",
+			expectedAvailable:  []binding.Capability{binding.CapabilityLearnDeleteLearned, binding.CapabilityMeter},
+		},
+		{
+			name:               "OVS without meters",
+			versionOutput:      "ovs-vswitchd (Open vSwitch) 2.9.2\n",
+			enableProxy:        true,
+			expectedOVSVersion: "2.9.2",
+			expectedAvailable:  []binding.Capability{binding.CapabilityLearnDeleteLearned},
+		},
+		{
+			name:               "unknown OVS version",
+			versionOutput:      "unexpected\n",
+			enableProxy:        true,
+			expectedOVSVersion: "",
+			expectedAvailable:  []binding.Capability{binding.CapabilityLearnDeleteLearned, binding.CapabilityMeter},
+		},
+		{
+			name:               "old OVS with AntreaProxy disabled",
+			versionOutput:      "ovs-vswitchd (Open vSwitch) 2.5.0\n",
+			enableProxy:        false,
+			expectedOVSVersion: "2.5.0",
+		},
+		{
+			name:               "old OVS with AntreaProxy enabled",
+			versionOutput:      "ovs-vswitchd (Open vSwitch) 2.5.0\n",
+			enableProxy:        true,
+			expectedOVSVersion: "2.5.0",
+			expectedErr:        "feature gate AntreaProxy requires OVS capability LearnDeleteLearned, which needs Open vSwitch >= 2.6.0, but the detected Open vSwitch version is 2.5.0 and the negotiated OpenFlow version is OpenFlow13",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			m := ovsoftest.NewMockBridge(ctrl)
+			m.EXPECT().OpenFlowVersion().Return(binding.OpenFlow13)
+			ovsctlClient := ovsctltest.NewMockOVSCtlClient(ctrl)
+			ovsctlClient.EXPECT().RunAppctlCmd("version", false).Return([]byte(tc.versionOutput), nil)
+			c := &client{bridge: m, ovsctlClient: ovsctlClient, ovsDatapathType: ovsconfig.OVSDatapathNetdev, enableProxy: tc.enableProxy}
+
+			c.capabilities = c.probeCapabilities()
+			assert.Equal(t, binding.OpenFlow13, c.capabilities.OpenFlowVersion)
+			assert.Equal(t, tc.expectedOVSVersion, c.capabilities.OVSVersion)
+			// OpenFlow 1.5 is never available as it is not negotiated.
+			var expectedAvailable []binding.Capability
+			for _, capability := range tc.expectedAvailable {
+				if capability != binding.CapabilityMeter || metersSupported {
+					expectedAvailable = append(expectedAvailable, capability)
+				}
+			}
+			assert.Equal(t, expectedAvailable, c.capabilities.Available)
+			err := c.checkRequiredCapabilities()
+			if tc.expectedErr != "" {
+				assert.EqualError(t, err, tc.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestAnnounceMovedPodIPs(t *testing.T) {
+	_, podCIDR, _ := net.ParseCIDR("10.10.0.0/24")
+	podMAC, _ := net.ParseMAC("aa:bb:cc:dd:ee:ff")
+	podOFPort := uint32(10)
+	localIP := net.ParseIP("10.10.0.5")
+	movedIP := net.ParseIP("10.10.1.5")
+	movedIPv6 := net.ParseIP("fd00::5")
+	garp, _ := binding.GratuitousARP(podMAC, movedIP)
+	na, _ := binding.UnsolicitedNeighborAdvertisement(podMAC, movedIPv6)
+
+	for _, tc := range []struct {
+		name           string
+		encapMode      config.TrafficEncapModeType
+		expectedFrames [][]byte
+	}{
+		{
+			name:           "noEncap mode",
+			encapMode:      config.TrafficEncapModeNoEncap,
+			expectedFrames: [][]byte{garp, na},
+		},
+		{
+			name:      "encap mode",
+			encapMode: config.TrafficEncapModeEncap,
+		},
+		{
+			name:      "policy-only mode",
+			encapMode: config.TrafficEncapModeNetworkPolicyOnly,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			m := ovsoftest.NewMockBridge(ctrl)
+			ofClient := NewClient(bridgeName, bridgeMgmtAddr, ovsconfig.OVSDatapathSystem, true, false, false, false)
+			c := ofClient.(*client)
+			c.bridge = m
+			c.encapMode = tc.encapMode
+			c.nodeConfig = &config.NodeConfig{GatewayConfig: gatewayConfig, PodIPv4CIDR: podCIDR}
+			for _, frame := range tc.expectedFrames {
+				m.EXPECT().SendRawPacketOut(podOFPort, uint32(config.HostGatewayOFPort), frame).Return(nil).Times(1)
+			}
+			c.announceMovedPodIPs([]net.IP{localIP, movedIP, movedIPv6}, podMAC, podOFPort)
+		})
+	}
+}
@@ -27,25 +27,29 @@ import (
 
 func (c *client) InstallBridgeUplinkFlows() error {
 	flows := c.hostBridgeUplinkFlows(*c.nodeConfig.PodIPv4CIDR, cookie.Default)
-	if err := c.ofEntryOperations.AddAll(flows); err != nil {
+	if err := c.ofEntryOps(pipelineTrigger).AddAll(flows); err != nil {
 		return err
 	}
 	c.hostNetworkingFlows = flows
 	return nil
 }
 
-func (c *client) InstallLoadBalancerServiceFromOutsideFlows(svcIP net.IP, svcPort uint16, protocol binding.Protocol) error {
+func (c *client) InstallLoadBalancerServiceFromOutsideFlows(svcIP net.IP, svcPort uint16, protocol binding.Protocol, disableSNAT bool) error {
 	c.replayMutex.RLock()
 	defer c.replayMutex.RUnlock()
 	var flows []binding.Flow
-	flows = append(flows, c.loadBalancerServiceFromOutsideFlow(svcIP, svcPort, protocol))
+	if disableSNAT {
+		flows = append(flows, c.loadBalancerServiceFromOutsideNoSNATFlows(svcIP, svcPort, protocol)...)
+	} else {
+		flows = append(flows, c.loadBalancerServiceFromOutsideFlow(svcIP, svcPort, protocol))
+	}
 	cacheKey := fmt.Sprintf("L%s%s%x", svcIP, protocol, svcPort)
-	return c.addFlows(c.serviceFlowCache, cacheKey, flows)
+	return c.addFlows(c.serviceFlowCache, cacheKey, serviceTrigger(svcIP, svcPort, protocol), flows)
 }
 
 func (c *client) UninstallLoadBalancerServiceFromOutsideFlows(svcIP net.IP, svcPort uint16, protocol binding.Protocol) error {
 	c.replayMutex.RLock()
 	defer c.replayMutex.RUnlock()
 	cacheKey := fmt.Sprintf("L%s%s%x", svcIP, protocol, svcPort)
-	return c.deleteFlows(c.serviceFlowCache, cacheKey)
+	return c.deleteFlows(c.serviceFlowCache, cacheKey, serviceTrigger(svcIP, svcPort, protocol))
 }
@@ -0,0 +1,169 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openflow
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/time/rate"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+
+	"antrea.io/antrea/pkg/agent/metrics"
+)
+
+const (
+	// podConnectionLimitCheckInterval is the interval at which MonitorPodConnectionLimits checks
+	// the number of connections of the Pods whose connections are limited.
+	podConnectionLimitCheckInterval = 10 * time.Second
+
+	// The Pods reaching their limit are logged at most podConnectionLimitLogRate times per
+	// second, with bursts of podConnectionLimitLogBurst, so that a large number of misbehaving
+	// Pods cannot flood the agent logs.
+	podConnectionLimitLogRate  = rate.Limit(1)
+	podConnectionLimitLogBurst = 10
+)
+
+// podConnectionLimit is the connection limit of a local Pod.
+type podConnectionLimit struct {
+	podNamespace string
+	podName      string
+	// zone is the conntrack zone in which the connections of the Pod are tracked.
+	zone  int
+	limit uint32
+	// reached indicates whether the Pod had reached its limit at the last check, so that a Pod
+	// is only reported once until its number of connections goes below the limit.
+	reached bool
+}
+
+// podConnectionLimitZone returns the conntrack zone in which the connections of the Pod using the
+// provided OVS port are tracked. OVS port numbers are lower than 0xff00, hence the zones of the
+// Pods never collide with CtZone, CtZoneV6 and ctZoneSNAT.
+func podConnectionLimitZone(ofPort uint32) int {
+	return int(ofPort)
+}
+
+// setPodConnectionLimit flushes the conntrack zone of a Pod, and sets its limit in the datapath.
+func (c *client) setPodConnectionLimit(interfaceName, podNamespace, podName string, zone int, limit uint32) error {
+	if _, execErr := c.ovsctlClient.RunAppctlCmd("dpctl/flush-conntrack", false, fmt.Sprintf("zone=%d", zone)); execErr != nil {
+		return fmt.Errorf("error when flushing conntrack zone %d: %v", zone, execErr)
+	}
+	if _, execErr := c.ovsctlClient.RunAppctlCmd("dpctl/ct-set-limits", false, fmt.Sprintf("zone=%d,limit=%d", zone, limit)); execErr != nil {
+		return fmt.Errorf("error when setting the limit of conntrack zone %d: %v", zone, execErr)
+	}
+	c.podConnectionLimitsMutex.Lock()
+	defer c.podConnectionLimitsMutex.Unlock()
+	c.podConnectionLimits[interfaceName] = &podConnectionLimit{
+		podNamespace: podNamespace,
+		podName:      podName,
+		zone:         zone,
+		limit:        limit,
+	}
+	return nil
+}
+
+// deletePodConnectionLimit removes the limit of the conntrack zone of a Pod from the datapath, and
+// flushes the zone, so that the OVS port of the Pod can be reused by another Pod. It does nothing
+// if the connections of the Pod are not limited.
+func (c *client) deletePodConnectionLimit(interfaceName string) error {
+	c.podConnectionLimitsMutex.Lock()
+	defer c.podConnectionLimitsMutex.Unlock()
+	limit, ok := c.podConnectionLimits[interfaceName]
+	if !ok {
+		return nil
+	}
+	if _, execErr := c.ovsctlClient.RunAppctlCmd("dpctl/ct-del-limits", false, fmt.Sprintf("zone=%d", limit.zone)); execErr != nil {
+		return fmt.Errorf("error when deleting the limit of conntrack zone %d: %v", limit.zone, execErr)
+	}
+	if _, execErr := c.ovsctlClient.RunAppctlCmd("dpctl/flush-conntrack", false, fmt.Sprintf("zone=%d", limit.zone)); execErr != nil {
+		return fmt.Errorf("error when flushing conntrack zone %d: %v", limit.zone, execErr)
+	}
+	delete(c.podConnectionLimits, interfaceName)
+	return nil
+}
+
+// replayPodConnectionLimits sets the limits of the conntrack zones of the Pods again, as they are
+// lost when the datapath is reset.
+func (c *client) replayPodConnectionLimits() {
+	c.podConnectionLimitsMutex.Lock()
+	defer c.podConnectionLimitsMutex.Unlock()
+	for interfaceName, limit := range c.podConnectionLimits {
+		if _, execErr := c.ovsctlClient.RunAppctlCmd("dpctl/ct-set-limits", false, fmt.Sprintf("zone=%d,limit=%d", limit.zone, limit.limit)); execErr != nil {
+			klog.Errorf("Error when replaying the connection limit of interface %s: %v", interfaceName, execErr)
+		}
+	}
+}
+
+func (c *client) MonitorPodConnectionLimits(enableLogging bool, stopCh <-chan struct{}) {
+	logLimiter := rate.NewLimiter(podConnectionLimitLogRate, podConnectionLimitLogBurst)
+	wait.Until(func() {
+		c.checkPodConnectionLimits(enableLogging, logLimiter)
+	}, podConnectionLimitCheckInterval, stopCh)
+}
+
+func (c *client) checkPodConnectionLimits(enableLogging bool, logLimiter *rate.Limiter) {
+	c.podConnectionLimitsMutex.Lock()
+	defer c.podConnectionLimitsMutex.Unlock()
+	for interfaceName, limit := range c.podConnectionLimits {
+		output, execErr := c.ovsctlClient.RunAppctlCmd("dpctl/ct-get-limits", false, fmt.Sprintf("zone=%d", limit.zone))
+		if execErr != nil {
+			klog.Errorf("Error when getting the connection count of interface %s: %v", interfaceName, execErr)
+			continue
+		}
+		count, err := parseCTZoneCount(string(output), limit.zone)
+		if err != nil {
+			klog.Errorf("Error when getting the connection count of interface %s: %v", interfaceName, err)
+			continue
+		}
+		if count < limit.limit {
+			limit.reached = false
+			continue
+		}
+		if limit.reached {
+			continue
+		}
+		limit.reached = true
+		metrics.PodConnectionLimitReachedCount.Inc()
+		if enableLogging && logLimiter.Allow() {
+			klog.Infof("Pod %s/%s reached its connection limit %d, its new connections are dropped", limit.podNamespace, limit.podName, limit.limit)
+		}
+	}
+}
+
+// parseCTZoneCount returns the number of connections of a conntrack zone from the output of
+// "ovs-appctl dpctl/ct-get-limits", whose lines are formatted as "zone=N,limit=M,count=C".
+func parseCTZoneCount(output string, zone int) (uint32, error) {
+	zonePrefix := fmt.Sprintf("zone=%d,", zone)
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, zonePrefix) {
+			continue
+		}
+		for _, field := range strings.Split(line, ",") {
+			if !strings.HasPrefix(field, "count=") {
+				continue
+			}
+			count, err := strconv.ParseUint(strings.TrimPrefix(field, "count="), 10, 32)
+			if err != nil {
+				return 0, fmt.Errorf("error when converting '%s' to int", field)
+			}
+			return uint32(count), nil
+		}
+	}
+	return 0, fmt.Errorf("couldn't find count of zone %d in dpctl/ct-get-limits command output '%s'", zone, output)
+}
@@ -0,0 +1,91 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openflow
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+	"k8s.io/component-base/metrics/testutil"
+
+	"antrea.io/antrea/pkg/agent/metrics"
+	ovsctltest "antrea.io/antrea/pkg/ovs/ovsctl/testing"
+)
+
+func TestParseCTZoneCount(t *testing.T) {
+	output := "default limit=0\nzone=5,limit=100,count=3\nzone=51,limit=10,count=10\n"
+	count, err := parseCTZoneCount(output, 5)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(3), count)
+	count, err = parseCTZoneCount(output, 51)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(10), count)
+	_, err = parseCTZoneCount(output, 50)
+	assert.Error(t, err)
+	_, err = parseCTZoneCount("zone=5,limit=100,count=x", 5)
+	assert.Error(t, err)
+}
+
+func TestPodConnectionLimit(t *testing.T) {
+	metrics.InitializePodConnectionLimitMetrics()
+	getCount := func() float64 {
+		count, err := testutil.GetCounterMetricValue(metrics.PodConnectionLimitReachedCount)
+		require.NoError(t, err)
+		return count
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ovsctlClient := ovsctltest.NewMockOVSCtlClient(ctrl)
+	c := &client{ovsctlClient: ovsctlClient, podConnectionLimits: map[string]*podConnectionLimit{}}
+	logLimiter := rate.NewLimiter(podConnectionLimitLogRate, podConnectionLimitLogBurst)
+
+	// The zone is flushed before the limit is set, so that the connections of a previous Pod
+	// using the same port are not counted.
+	gomock.InOrder(
+		ovsctlClient.EXPECT().RunAppctlCmd("dpctl/flush-conntrack", false, "zone=5").Return(nil, nil),
+		ovsctlClient.EXPECT().RunAppctlCmd("dpctl/ct-set-limits", false, "zone=5,limit=10").Return(nil, nil),
+	)
+	require.NoError(t, c.setPodConnectionLimit("pod1-abcd", "ns1", "pod1", podConnectionLimitZone(5), 10))
+
+	// The Pod is only counted once until its number of connections goes below its limit.
+	initialCount := getCount()
+	for _, tc := range []struct {
+		connections   string
+		expectedCount float64
+	}{
+		{"9", initialCount},
+		{"10", initialCount + 1},
+		{"10", initialCount + 1},
+		{"8", initialCount + 1},
+		{"10", initialCount + 2},
+	} {
+		ovsctlClient.EXPECT().RunAppctlCmd("dpctl/ct-get-limits", false, "zone=5").Return([]byte("default limit=0\nzone=5,limit=10,count="+tc.connections+"\n"), nil)
+		c.checkPodConnectionLimits(true, logLimiter)
+		assert.Equal(t, tc.expectedCount, getCount())
+	}
+
+	gomock.InOrder(
+		ovsctlClient.EXPECT().RunAppctlCmd("dpctl/ct-del-limits", false, "zone=5").Return(nil, nil),
+		ovsctlClient.EXPECT().RunAppctlCmd("dpctl/flush-conntrack", false, "zone=5").Return(nil, nil),
+	)
+	require.NoError(t, c.deletePodConnectionLimit("pod1-abcd"))
+	assert.Empty(t, c.podConnectionLimits)
+	// Deleting the limit of a Pod whose connections are not limited does nothing.
+	require.NoError(t, c.deletePodConnectionLimit("pod1-abcd"))
+}
@@ -19,11 +19,14 @@ import (
 )
 
 const (
-	BitwidthRound           = 16
-	BitwidthCategory        = 8
-	BitwidthReserved        = 64 - BitwidthCategory - BitwidthRound
-	RoundMask        uint64 = 0xffff_0000_0000_0000
-	CategoryMask     uint64 = 0x0000_ff00_0000_0000
+	BitwidthRound             = 16
+	BitwidthCategory          = 8
+	BitwidthGeneration        = 8
+	BitwidthReserved          = 64 - BitwidthCategory - BitwidthRound
+	BitwidthObjectID          = BitwidthReserved - BitwidthGeneration
+	RoundMask          uint64 = 0xffff_0000_0000_0000
+	CategoryMask       uint64 = 0x0000_ff00_0000_0000
+	GenerationMask     uint64 = 0x0000_00ff_0000_0000
 )
 
 // Category represents the flow entry category.
@@ -37,6 +40,8 @@ const (
 	Service
 	Policy
 	SNAT
+	Traceflow
+	PacketCapture
 )
 
 func (c Category) String() string {
@@ -55,6 +60,10 @@ func (c Category) String() string {
 		return "Policy"
 	case SNAT:
 		return "SNAT"
+	case Traceflow:
+		return "Traceflow"
+	case PacketCapture:
+		return "PacketCapture"
 	default:
 		return "Invalid"
 	}
@@ -62,25 +71,35 @@ func (c Category) String() string {
 
 // ID defines segments a cookie ID contains. An ID is composed like:
 //  |------------------------- ID --------------------------|
-//  |- round 16bits -|- category 8bits -|- reserved 8bits -|- objectID 32bits -|
+//  |- round 16bits -|- category 8bits -|- generation 8bits -|- objectID 32bits -|
 // The round segment represents the round id.
 // The category segment represents the category of flow this ID belongs.
+// The generation segment is incremented every time the round number wraps around, so that flows
+// installed by rounds sharing the same round number can still be told apart.
 type ID uint64
 
-func newID(round uint64, cat Category, objectID uint32) ID {
+func newID(round, generation uint64, cat Category, objectID uint32) ID {
 	r := uint64(0)
 	r |= round << (64 - BitwidthRound)
 	r |= (uint64(cat) << BitwidthReserved) & CategoryMask
+	r |= (generation << BitwidthObjectID) & GenerationMask
 	r |= uint64(objectID)
 	return ID(r)
 }
 
 // CookieMaskForRound returns a cookie and mask value that can be used to select
-// all flows belonging to the provided round.
+// all flows belonging to the provided round, regardless of their generation.
 func CookieMaskForRound(round uint64) (uint64, uint64) {
 	return round << (64 - BitwidthRound), RoundMask
 }
 
+// CookieMaskForRoundAndGeneration returns a cookie and mask value that can be used
+// to select all flows belonging to the provided round and generation.
+func CookieMaskForRoundAndGeneration(round, generation uint64) (uint64, uint64) {
+	cookieID := newID(round, generation, Default, 0).Raw()
+	return cookieID, RoundMask | GenerationMask
+}
+
 // Raw returns the unit64 type value of the ID.
 func (i ID) Raw() uint64 {
 	return uint64(i)
@@ -91,6 +110,11 @@ func (i ID) Round() uint64 {
 	return i.Raw() >> (64 - BitwidthRound)
 }
 
+// Generation returns the generation of the ID.
+func (i ID) Generation() uint64 {
+	return (i.Raw() & GenerationMask) >> BitwidthObjectID
+}
+
 // Category returns the category of the ID.
 func (i ID) Category() Category {
 	return Category((i.Raw() & CategoryMask) >> BitwidthReserved)
@@ -98,7 +122,7 @@ func (i ID) Category() Category {
 
 // String returns the string representation of the ID.
 func (i ID) String() string {
-	return fmt.Sprintf("<round:%d,category:%s>", i.Round(), i.Category().String())
+	return fmt.Sprintf("<round:%d,generation:%d,category:%s>", i.Round(), i.Generation(), i.Category().String())
 }
 
 // Allocator defines operations of a cookie ID allocator.
@@ -110,21 +134,22 @@ type Allocator interface {
 }
 
 type allocator struct {
-	round uint64
+	round      uint64
+	generation uint64
 }
 
 // Request returns a ID with the given category.
 func (a *allocator) Request(cat Category) ID {
-	return newID(a.round, cat, 0)
+	return newID(a.round, a.generation, cat, 0)
 }
 
 func (a *allocator) RequestWithObjectID(cat Category, objectID uint32) ID {
-	return newID(a.round, cat, objectID)
+	return newID(a.round, a.generation, cat, objectID)
 }
 
-// NewAllocator creates a cookie ID allocator by using the given round number.
-// Only last 16 bits of the round number would be used.
-func NewAllocator(round uint64) Allocator {
-	a := &allocator{round: round}
+// NewAllocator creates a cookie ID allocator by using the given round number and generation.
+// Only last 16 bits of the round number and last 8 bits of the generation would be used.
+func NewAllocator(round, generation uint64) Allocator {
+	a := &allocator{round: round, generation: generation}
 	return a
 }
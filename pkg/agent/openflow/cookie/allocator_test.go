@@ -26,7 +26,7 @@ import (
 var result ID // Ensures that the call to Request is not optimized-out by the compiler.
 
 func BenchmarkIDAllocate(b *testing.B) {
-	a := NewAllocator(0)
+	a := NewAllocator(0, 0)
 	for i := 0; i < b.N; i++ {
 		result = a.Request(Default)
 	}
@@ -40,7 +40,8 @@ func TestConcurrentAllocate(t *testing.T) {
 	rand.Seed(time.Now().UnixNano())
 	// #nosec G404: random number generator not used for security purposes
 	round := rand.Uint64() >> (64 - BitwidthRound)
-	a := NewAllocator(round)
+	generation := rand.Uint64() >> (64 - BitwidthGeneration)
+	a := NewAllocator(round, generation)
 
 	eachGoroutine := func() {
 		var seq []Category
@@ -53,12 +54,14 @@ func TestConcurrentAllocate(t *testing.T) {
 		for i := 0; i < eachTotal/2; i++ {
 			id := a.Request(seq[i])
 			assert.Equal(t, round, id.Round(), id.String())
+			assert.Equal(t, generation, id.Generation(), id.String())
 			assert.Equal(t, seq[i].String(), id.Category().String(), id.String())
 		}
 
 		for i := 0; i < eachTotal; i++ {
 			id := a.Request(seq[i])
 			assert.Equal(t, round, id.Round(), id.String())
+			assert.Equal(t, generation, id.Generation(), id.String())
 			assert.Equal(t, seq[i].String(), id.Category().String(), id.String())
 		}
 
@@ -74,3 +77,16 @@ func TestConcurrentAllocate(t *testing.T) {
 	}
 	wg.Wait()
 }
+
+func TestCookieMaskForRoundAndGeneration(t *testing.T) {
+	a := NewAllocator(10, 2)
+	id := a.RequestWithObjectID(Policy, 0xffff_ffff)
+	cookieID, cookieMask := CookieMaskForRoundAndGeneration(10, 2)
+	assert.Equal(t, cookieID, id.Raw()&cookieMask)
+	// A flow with the same round number but installed by another generation must not be selected.
+	otherID := NewAllocator(10, 1).RequestWithObjectID(Policy, 0xffff_ffff)
+	assert.NotEqual(t, cookieID, otherID.Raw()&cookieMask)
+	// The generation must not overflow into the category segment.
+	assert.Equal(t, Policy, id.Category())
+	assert.Equal(t, uint64(0), NewAllocator(10, 1<<BitwidthGeneration).Request(Default).Generation())
+}
@@ -0,0 +1,242 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openflow
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"antrea.io/antrea/pkg/agent/openflow/cookie"
+	"antrea.io/antrea/pkg/agent/types"
+	"antrea.io/antrea/pkg/apis/controlplane/v1beta2"
+	binding "antrea.io/antrea/pkg/ovs/openflow"
+)
+
+// Kinds of the objects which trigger flow changes.
+const (
+	triggerKindPipeline      = "Pipeline"
+	triggerKindReplay        = "Replay"
+	triggerKindNode          = "Node"
+	triggerKindPodInterface  = "PodInterface"
+	triggerKindService       = "Service"
+	triggerKindEndpoint      = "Endpoint"
+	triggerKindSNAT          = "SNAT"
+	triggerKindNetworkPolicy = "NetworkPolicy"
+	triggerKindPolicyBoot    = "PolicyBootstrap"
+	triggerKindPriority      = "PolicyPriority"
+	triggerKindTraceflow     = "Traceflow"
+	triggerKindPacketCapture = "PacketCapture"
+)
+
+var (
+	// pipelineTrigger is the trigger of the flows installed when initializing the pipeline, which
+	// do not belong to any object.
+	pipelineTrigger = types.FlowChangeTrigger{Kind: triggerKindPipeline}
+	// replayTrigger is the trigger of the flows installed again when replaying the flows.
+	replayTrigger = types.FlowChangeTrigger{Kind: triggerKindReplay}
+	// serviceCIDRTrigger is the trigger of the flows installed for the cluster Service CIDRs.
+	serviceCIDRTrigger = types.FlowChangeTrigger{Kind: triggerKindService, Name: "ServiceCIDR"}
+)
+
+func serviceTrigger(svcIP net.IP, svcPort uint16, protocol binding.Protocol) types.FlowChangeTrigger {
+	name := fmt.Sprintf("%s/%s", net.JoinHostPort(svcIP.String(), strconv.Itoa(int(svcPort))), protocol)
+	return types.FlowChangeTrigger{Kind: triggerKindService, Name: name}
+}
+
+func snatMarkTrigger(mark uint32) types.FlowChangeTrigger {
+	return types.FlowChangeTrigger{Kind: triggerKindSNAT, Name: fmt.Sprintf("mark %#x", mark)}
+}
+
+func podSNATTrigger(ofPort uint32) types.FlowChangeTrigger {
+	return types.FlowChangeTrigger{Kind: triggerKindSNAT, Name: fmt.Sprintf("ofport %d", ofPort)}
+}
+
+func traceflowTrigger(dataplaneTag uint8) types.FlowChangeTrigger {
+	return types.FlowChangeTrigger{Kind: triggerKindTraceflow, Name: fmt.Sprintf("tag %d", dataplaneTag)}
+}
+
+func policyRuleTrigger(npRef *v1beta2.NetworkPolicyReference) types.FlowChangeTrigger {
+	if npRef == nil {
+		return types.FlowChangeTrigger{Kind: triggerKindNetworkPolicy}
+	}
+	return types.FlowChangeTrigger{Kind: triggerKindNetworkPolicy, Name: npRef.ToString()}
+}
+
+// flowChangeRecorder records the latest flow changes in a ring buffer of fixed size.
+type flowChangeRecorder struct {
+	mutex   sync.Mutex
+	changes []types.FlowChange
+	// next is the index of the slot of the next change, which holds the oldest change once the
+	// buffer is full.
+	next int
+	full bool
+}
+
+func newFlowChangeRecorder(size int) *flowChangeRecorder {
+	return &flowChangeRecorder{changes: make([]types.FlowChange, size)}
+}
+
+func (r *flowChangeRecorder) record(action ofAction, trigger types.FlowChangeTrigger, flows []binding.Flow) {
+	if len(flows) == 0 {
+		return
+	}
+	now := time.Now()
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for _, flow := range flows {
+		cookieID := flow.GetCookieID()
+		r.changes[r.next] = types.FlowChange{
+			Time:      now,
+			Operation: action.String(),
+			Category:  cookie.ID(cookieID).Category().String(),
+			Cookie:    cookieID,
+			TableID:   uint8(flow.GetTableID()),
+			Trigger:   trigger,
+		}
+		r.next++
+		if r.next == len(r.changes) {
+			r.next = 0
+			r.full = true
+		}
+	}
+}
+
+// list returns the recorded changes, from the oldest to the latest.
+func (r *flowChangeRecorder) list() []types.FlowChange {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if !r.full {
+		return append([]types.FlowChange{}, r.changes[:r.next]...)
+	}
+	changes := make([]types.FlowChange, 0, len(r.changes))
+	changes = append(changes, r.changes[r.next:]...)
+	return append(changes, r.changes[:r.next]...)
+}
+
+// recordingOFEntryOperations records the flow changes performed through the wrapped
+// OFEntryOperations, once they have been applied successfully.
+type recordingOFEntryOperations struct {
+	OFEntryOperations
+	recorder *flowChangeRecorder
+	trigger  types.FlowChangeTrigger
+}
+
+func (o *recordingOFEntryOperations) Add(flow binding.Flow) error {
+	return o.AddAll([]binding.Flow{flow})
+}
+
+func (o *recordingOFEntryOperations) Modify(flow binding.Flow) error {
+	return o.ModifyAll([]binding.Flow{flow})
+}
+
+func (o *recordingOFEntryOperations) Delete(flow binding.Flow) error {
+	return o.DeleteAll([]binding.Flow{flow})
+}
+
+func (o *recordingOFEntryOperations) AddAll(flows []binding.Flow) error {
+	if err := o.OFEntryOperations.AddAll(flows); err != nil {
+		return err
+	}
+	o.recorder.record(add, o.trigger, flows)
+	return nil
+}
+
+func (o *recordingOFEntryOperations) ModifyAll(flows []binding.Flow) error {
+	if err := o.OFEntryOperations.ModifyAll(flows); err != nil {
+		return err
+	}
+	o.recorder.record(mod, o.trigger, flows)
+	return nil
+}
+
+func (o *recordingOFEntryOperations) DeleteAll(flows []binding.Flow) error {
+	if err := o.OFEntryOperations.DeleteAll(flows); err != nil {
+		return err
+	}
+	o.recorder.record(del, o.trigger, flows)
+	return nil
+}
+
+func (o *recordingOFEntryOperations) BundleOps(adds []binding.Flow, mods []binding.Flow, dels []binding.Flow) error {
+	if err := o.OFEntryOperations.BundleOps(adds, mods, dels); err != nil {
+		return err
+	}
+	o.recorder.record(add, o.trigger, adds)
+	o.recorder.record(mod, o.trigger, mods)
+	o.recorder.record(del, o.trigger, dels)
+	return nil
+}
+
+// ofEntryOps returns the OFEntryOperations to use for the flow changes caused by the provided
+// trigger. When flow change tracking is disabled, it is ofEntryOperations itself, so that the
+// tracking has no overhead.
+func (c *client) ofEntryOps(trigger types.FlowChangeTrigger) OFEntryOperations {
+	if c.flowChangeRecorder == nil {
+		return c.ofEntryOperations
+	}
+	return &recordingOFEntryOperations{OFEntryOperations: c.ofEntryOperations, recorder: c.flowChangeRecorder, trigger: trigger}
+}
+
+// recordFlowChanges records the flow changes which are sent to the bridge directly instead of
+// through ofEntryOperations.
+func (c *client) recordFlowChanges(trigger types.FlowChangeTrigger, adds, mods, dels []binding.Flow) {
+	if c.flowChangeRecorder == nil {
+		return
+	}
+	c.flowChangeRecorder.record(add, trigger, adds)
+	c.flowChangeRecorder.record(mod, trigger, mods)
+	c.flowChangeRecorder.record(del, trigger, dels)
+}
+
+// recordConjunctiveFlowChanges records the conjunctive match flows and drop flows changed by the
+// conjMatchFlowContextChanges, with the objects which caused the changes.
+func (c *client) recordConjunctiveFlowChanges(changes []*conjMatchFlowContextChange) {
+	if c.flowChangeRecorder == nil {
+		return
+	}
+	for _, change := range changes {
+		for _, fc := range []*flowChange{change.matchFlow, change.dropFlow} {
+			if fc == nil {
+				continue
+			}
+			action := add
+			switch fc.changeType {
+			case modification:
+				action = mod
+			case deletion:
+				action = del
+			}
+			c.flowChangeRecorder.record(action, change.trigger, []binding.Flow{fc.flow})
+		}
+	}
+}
+
+// EnableFlowChangeTracking enables the recording of the latest flow changes, up to size changes.
+// It must be called before the client is initialized.
+func (c *client) EnableFlowChangeTracking(size int) {
+	c.flowChangeRecorder = newFlowChangeRecorder(size)
+}
+
+// GetFlowChanges returns the recorded flow changes, from the oldest to the latest. It returns nil
+// if flow change tracking is not enabled.
+func (c *client) GetFlowChanges() []types.FlowChange {
+	if c.flowChangeRecorder == nil {
+		return nil
+	}
+	return c.flowChangeRecorder.list()
+}
@@ -0,0 +1,93 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openflow
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"antrea.io/antrea/pkg/agent/openflow/cookie"
+	oftest "antrea.io/antrea/pkg/agent/openflow/testing"
+	"antrea.io/antrea/pkg/agent/types"
+	binding "antrea.io/antrea/pkg/ovs/openflow"
+	ovsoftest "antrea.io/antrea/pkg/ovs/openflow/testing"
+)
+
+func newMockFlow(ctrl *gomock.Controller, cookieID uint64, tableID binding.TableIDType) binding.Flow {
+	flow := ovsoftest.NewMockFlow(ctrl)
+	flow.EXPECT().GetCookieID().Return(cookieID).AnyTimes()
+	flow.EXPECT().GetTableID().Return(tableID).AnyTimes()
+	return flow
+}
+
+func TestFlowChangeRecorder(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	podCookie := cookie.NewAllocator(1, 0).Request(cookie.Pod).Raw()
+	recorder := newFlowChangeRecorder(3)
+	assert.Empty(t, recorder.list())
+
+	for i := 0; i < 4; i++ {
+		trigger := types.FlowChangeTrigger{Kind: triggerKindPodInterface, Name: fmt.Sprintf("pod%d", i)}
+		recorder.record(add, trigger, []binding.Flow{newMockFlow(ctrl, podCookie, ClassifierTable)})
+	}
+	changes := recorder.list()
+	// The oldest change must have been overwritten.
+	require.Len(t, changes, 3)
+	for i, change := range changes {
+		assert.Equal(t, "add", change.Operation)
+		assert.Equal(t, "Pod", change.Category)
+		assert.Equal(t, podCookie, change.Cookie)
+		assert.Equal(t, uint8(ClassifierTable), change.TableID)
+		assert.Equal(t, fmt.Sprintf("pod%d", i+1), change.Trigger.Name)
+	}
+}
+
+func TestOFEntryOpsRecordsFlowChanges(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := oftest.NewMockOFEntryOperations(ctrl)
+	c := &client{ofEntryOperations: m}
+	flow := newMockFlow(ctrl, 0, IngressRuleTable)
+	trigger := types.FlowChangeTrigger{Kind: triggerKindNetworkPolicy, Name: "K8sNetworkPolicy:ns1/np1"}
+
+	// When flow change tracking is disabled, the operations are not wrapped.
+	assert.Equal(t, OFEntryOperations(m), c.ofEntryOps(trigger))
+	assert.Nil(t, c.GetFlowChanges())
+
+	c.EnableFlowChangeTracking(10)
+	m.EXPECT().AddAll([]binding.Flow{flow}).Return(nil)
+	m.EXPECT().DeleteAll([]binding.Flow{flow}).Return(fmt.Errorf("bundle failed"))
+	m.EXPECT().BundleOps(nil, []binding.Flow{flow}, nil).Return(nil)
+	require.NoError(t, c.ofEntryOps(trigger).AddAll([]binding.Flow{flow}))
+	// Failed operations are not recorded.
+	require.Error(t, c.ofEntryOps(trigger).DeleteAll([]binding.Flow{flow}))
+	require.NoError(t, c.ofEntryOps(trigger).BundleOps(nil, []binding.Flow{flow}, nil))
+
+	changes := c.GetFlowChanges()
+	require.Len(t, changes, 2)
+	assert.Equal(t, "add", changes[0].Operation)
+	assert.Equal(t, "modify", changes[1].Operation)
+	for _, change := range changes {
+		assert.Equal(t, trigger, change.Trigger)
+		assert.Equal(t, uint8(IngressRuleTable), change.TableID)
+	}
+}
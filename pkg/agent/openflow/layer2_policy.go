@@ -0,0 +1,177 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openflow
+
+import (
+	"net"
+
+	"k8s.io/klog/v2"
+
+	"antrea.io/antrea/pkg/agent/openflow/cookie"
+	"antrea.io/antrea/pkg/agent/types"
+	"antrea.io/antrea/pkg/apis/controlplane/v1beta2"
+	crdv1alpha1 "antrea.io/antrea/pkg/apis/crd/v1alpha1"
+	binding "antrea.io/antrea/pkg/ovs/openflow"
+)
+
+const (
+	icmpv6RouterSolicitationType uint8 = 133
+)
+
+// ndTypes are the ICMPv6 types of the Neighbor Discovery messages matched by a layer 2 rule which
+// doesn't specify a type.
+var ndMessageTypes = []uint8{
+	icmpv6RouterSolicitationType,
+	icmpv6RouterAdvertisementType,
+	icmpv6NeighborSolicitationType,
+	icmpv6NeighborAdvertisementType,
+}
+
+// isLayer2Table returns true if the table enforces the layer 2 rules of Antrea-native policies.
+func isLayer2Table(tableID binding.TableIDType) bool {
+	return tableID == AntreaPolicyARPRuleTable || tableID == AntreaPolicyNDRuleTable
+}
+
+// layer2RuleFlows generates the flows realizing a layer 2 rule of an Antrea-native policy. Unlike the
+// other rules, layer 2 rules are not realized with conjunctive match flows: the ARP and Neighbor
+// Discovery messages are not committed to conntrack, and the rules only have a few addresses, so one
+// flow is installed for each combination of source OF port, target address and message type. The
+// target address is matched with the ARP target protocol address for ARP messages, and with the
+// Neighbor Discovery target for Neighbor Solicitation and Advertisement messages.
+func (c *client) layer2RuleFlows(rule *types.PolicyRule, priority *uint16) []binding.Flow {
+	var ofPriority uint16
+	if priority == nil {
+		ofPriority = priorityLow
+	} else {
+		ofPriority = *priority
+	}
+	var ofPorts []uint32
+	for _, addr := range rule.From {
+		if ofPort, ok := addr.GetValue().(int32); ok {
+			ofPorts = append(ofPorts, uint32(ofPort))
+		}
+	}
+	// A nil target matches all target addresses, the CIDRs of both address families which match all
+	// addresses are merged into it.
+	var targets []*net.IPNet
+	matchAllTargets := len(rule.To) == 0
+	for _, addr := range rule.To {
+		switch v := addr.GetValue().(type) {
+		case net.IP:
+			targets = append(targets, ipToIPNet(v))
+		case net.IPNet:
+			if ones, _ := v.Mask.Size(); ones == 0 {
+				matchAllTargets = true
+			} else {
+				targets = append(targets, &v)
+			}
+		}
+	}
+	if matchAllTargets {
+		targets = append(targets, nil)
+	}
+
+	var flows []binding.Flow
+	for _, ofPort := range ofPorts {
+		for _, target := range targets {
+			for _, svc := range rule.Service {
+				switch svc.Protocol {
+				case nil:
+					klog.Errorf("Layer 2 rule %d has no protocol", rule.FlowID)
+				case v1beta2.ProtocolARP:
+					if target != nil {
+						if target.IP.To4() == nil {
+							continue
+						}
+						if ones, bits := target.Mask.Size(); ones != bits {
+							// The ARP target protocol address cannot be matched with a mask, and
+							// such targets are rejected by the validation of the rules.
+							klog.Errorf("Layer 2 rule %d has an unsupported ARP target %s", rule.FlowID, target)
+							continue
+						}
+					}
+					fb := c.pipeline[AntreaPolicyARPRuleTable].BuildFlow(ofPriority).MatchProtocol(binding.ProtocolARP).
+						MatchInPort(ofPort)
+					if target != nil {
+						fb = fb.MatchARPTpa(target.IP)
+					}
+					flows = append(flows, c.layer2RuleActionFlow(fb, rule, AntreaPolicyARPRuleTable))
+				case v1beta2.ProtocolICMPv6:
+					if target != nil && target.IP.To4() != nil {
+						continue
+					}
+					icmpTypes := ndMessageTypes
+					if svc.ICMPType != nil {
+						icmpTypes = []uint8{uint8(*svc.ICMPType)}
+					}
+					for _, ndType := range icmpTypes {
+						hasTarget := ndType == icmpv6NeighborSolicitationType || ndType == icmpv6NeighborAdvertisementType
+						// Router Solicitation and Advertisement messages have no target, they are only
+						// matched by the rules matching all targets.
+						if target != nil && !hasTarget {
+							continue
+						}
+						fb := c.pipeline[AntreaPolicyNDRuleTable].BuildFlow(ofPriority).MatchProtocol(binding.ProtocolICMPv6).
+							MatchInPort(ofPort).
+							MatchICMPv6Type(ndType).
+							MatchICMPv6Code(0)
+						if target != nil {
+							fb = fb.MatchNDTarget(*target)
+						}
+						flows = append(flows, c.layer2RuleActionFlow(fb, rule, AntreaPolicyNDRuleTable))
+					}
+				}
+			}
+		}
+	}
+	return flows
+}
+
+// layer2RuleActionFlow adds the actions of a layer 2 rule to a flow. The rule ID is loaded into the
+// same register as for the other rules, so that the audit logging and Traceflow can find the rule
+// which the packet matched.
+func (c *client) layer2RuleActionFlow(fb binding.FlowBuilder, rule *types.PolicyRule, tableID binding.TableIDType) binding.Flow {
+	disposition := uint32(DispositionAllow)
+	conjReg := EgressReg
+	if rule.Action != nil && *rule.Action == crdv1alpha1.RuleActionDrop {
+		disposition = DispositionDrop
+		conjReg = CNPDenyConjIDReg
+	}
+	fb = fb.Action().LoadRegRange(int(conjReg), rule.FlowID, binding.Range{0, 31})
+	if rule.EnableLogging {
+		if c.ovsMetersAreSupported {
+			fb = fb.Action().Meter(PacketInMeterIDNP)
+		}
+		fb = fb.Action().LoadRegRange(int(marksReg), disposition, APDispositionMarkRange).
+			Action().LoadRegRange(int(marksReg), CustomReasonLogging, CustomReasonMarkRange).
+			Action().SendToController(uint8(PacketInReasonNP))
+	}
+	if disposition == DispositionDrop {
+		if !rule.EnableLogging {
+			fb = fb.Action().Drop()
+		}
+	} else {
+		fb = fb.Action().GotoTable(c.pipeline[tableID].GetNext())
+	}
+	return fb.Cookie(c.cookieAllocator.Request(cookie.Policy).Raw()).Done()
+}
+
+// ipToIPNet returns the host network of an IP address.
+func ipToIPNet(ip net.IP) *net.IPNet {
+	if ip.To4() != nil {
+		return &net.IPNet{IP: ip.To4(), Mask: net.CIDRMask(32, 32)}
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}
+}
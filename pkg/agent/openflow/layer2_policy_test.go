@@ -0,0 +1,131 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openflow
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"antrea.io/antrea/pkg/agent/openflow/cookie"
+	"antrea.io/antrea/pkg/agent/types"
+	"antrea.io/antrea/pkg/apis/controlplane/v1beta2"
+	crdv1alpha1 "antrea.io/antrea/pkg/apis/crd/v1alpha1"
+	"antrea.io/antrea/pkg/ovs/ovsconfig"
+)
+
+func TestLayer2RuleFlows(t *testing.T) {
+	ofClient := NewClient(bridgeName, bridgeMgmtAddr, ovsconfig.OVSDatapathSystem, true, true, false, false)
+	c := ofClient.(*client)
+	c.cookieAllocator = cookie.NewAllocator(0, 0)
+	drop := crdv1alpha1.RuleActionDrop
+	allow := crdv1alpha1.RuleActionAllow
+	protocolARP := v1beta2.ProtocolARP
+	protocolICMPv6 := v1beta2.ProtocolICMPv6
+	icmpTypeNS := int32(icmpv6NeighborSolicitationType)
+	_, allIPv4, _ := net.ParseCIDR("0.0.0.0/0")
+	_, allIPv6, _ := net.ParseCIDR("::/0")
+	_, ipv4Net, _ := net.ParseCIDR("10.0.0.0/24")
+	priority := uint16(100)
+
+	tests := []struct {
+		name            string
+		rule            *types.PolicyRule
+		expectedMatches []string
+	}{
+		{
+			name: "ARP to IPs",
+			rule: &types.PolicyRule{
+				From:    []types.Address{NewOFPortAddress(3), NewOFPortAddress(4)},
+				To:      []types.Address{NewIPAddress(net.ParseIP("10.0.0.1")), NewIPAddress(net.ParseIP("fd00::1")), NewIPNetAddress(*ipv4Net)},
+				Service: []v1beta2.Service{{Protocol: &protocolARP}},
+				Action:  &drop,
+				TableID: AntreaPolicyARPRuleTable,
+			},
+			expectedMatches: []string{
+				"table=18,arp,in_port=3,arp_tpa=10.0.0.1",
+				"table=18,arp,in_port=4,arp_tpa=10.0.0.1",
+			},
+		},
+		{
+			name: "ARP to all IPs",
+			rule: &types.PolicyRule{
+				From:    []types.Address{NewOFPortAddress(3)},
+				To:      []types.Address{NewIPNetAddress(*allIPv4), NewIPNetAddress(*allIPv6)},
+				Service: []v1beta2.Service{{Protocol: &protocolARP}},
+				Action:  &allow,
+				TableID: AntreaPolicyARPRuleTable,
+			},
+			expectedMatches: []string{
+				"table=18,arp,in_port=3",
+			},
+		},
+		{
+			name: "Neighbor Solicitation to an IP",
+			rule: &types.PolicyRule{
+				From:    []types.Address{NewOFPortAddress(3)},
+				To:      []types.Address{NewIPAddress(net.ParseIP("fd00::1")), NewIPAddress(net.ParseIP("10.0.0.1"))},
+				Service: []v1beta2.Service{{Protocol: &protocolICMPv6, ICMPType: &icmpTypeNS}},
+				Action:  &drop,
+				TableID: AntreaPolicyNDRuleTable,
+			},
+			expectedMatches: []string{
+				"table=19,icmpv6,in_port=3,icmp_type=135,icmp_code=0,nd_target=fd00::1/128",
+			},
+		},
+		{
+			name: "all Neighbor Discovery messages",
+			rule: &types.PolicyRule{
+				From:    []types.Address{NewOFPortAddress(3)},
+				To:      []types.Address{NewIPNetAddress(*allIPv6)},
+				Service: []v1beta2.Service{{Protocol: &protocolICMPv6}},
+				Action:  &allow,
+				TableID: AntreaPolicyNDRuleTable,
+			},
+			expectedMatches: []string{
+				"table=19,icmpv6,in_port=3,icmp_type=133,icmp_code=0",
+				"table=19,icmpv6,in_port=3,icmp_type=134,icmp_code=0",
+				"table=19,icmpv6,in_port=3,icmp_type=135,icmp_code=0",
+				"table=19,icmpv6,in_port=3,icmp_type=136,icmp_code=0",
+			},
+		},
+		{
+			name: "Neighbor Discovery messages to an IP",
+			rule: &types.PolicyRule{
+				From:    []types.Address{NewOFPortAddress(3)},
+				To:      []types.Address{NewIPAddress(net.ParseIP("fd00::1"))},
+				Service: []v1beta2.Service{{Protocol: &protocolICMPv6}},
+				Action:  &allow,
+				TableID: AntreaPolicyNDRuleTable,
+			},
+			expectedMatches: []string{
+				"table=19,icmpv6,in_port=3,icmp_type=135,icmp_code=0,nd_target=fd00::1/128",
+				"table=19,icmpv6,in_port=3,icmp_type=136,icmp_code=0,nd_target=fd00::1/128",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flows := c.layer2RuleFlows(tt.rule, &priority)
+			var matches []string
+			for _, flow := range flows {
+				assert.Equal(t, priority, flow.FlowPriority())
+				matches = append(matches, flow.MatchString())
+			}
+			assert.ElementsMatch(t, tt.expectedMatches, matches)
+		})
+	}
+}
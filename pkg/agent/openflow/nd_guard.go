@@ -0,0 +1,97 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openflow
+
+import (
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/contiv/libOpenflow/protocol"
+	"github.com/contiv/ofnet/ofctrl"
+	"golang.org/x/time/rate"
+	"k8s.io/klog/v2"
+
+	"antrea.io/antrea/pkg/agent/metrics"
+)
+
+const (
+	icmpv6RouterAdvertisementType uint8 = 134
+
+	// ndGuardDropPriority is the priority of the flows dropping the Neighbor Discovery messages
+	// sent by local Pods which are not allowed by ndGuardFlows. It's higher than the priority of the
+	// spoof guard flows, so that link-local sources are not enough for the messages to be allowed.
+	ndGuardDropPriority = priorityNormal + 1
+
+	// The dropped messages are logged at most ndGuardLogRate times per second, with bursts of
+	// ndGuardLogBurst, so that a misbehaving Pod cannot flood the agent logs.
+	ndGuardLogRate  = rate.Limit(10)
+	ndGuardLogBurst = 20
+)
+
+// ndMessageTypeNames are the values of the "type" label of NDGuardDroppedPacketCount.
+var ndMessageTypeNames = map[uint8]string{
+	icmpv6RouterAdvertisementType:   "RouterAdvertisement",
+	icmpv6NeighborAdvertisementType: "NeighborAdvertisement",
+}
+
+// ndGuardHandler handles the Neighbor Discovery messages sent by local Pods and dropped by
+// ndGuardFlows, i.e. Router Advertisements and Neighbor Advertisements for addresses the Pods don't
+// own. It counts them, and logs them if logging is enabled.
+type ndGuardHandler struct {
+	enableLogging bool
+	logLimiter    *rate.Limiter
+}
+
+func newNDGuardHandler(enableLogging bool) *ndGuardHandler {
+	return &ndGuardHandler{
+		enableLogging: enableLogging,
+		logLimiter:    rate.NewLimiter(ndGuardLogRate, ndGuardLogBurst),
+	}
+}
+
+// HandlePacketIn implements PacketInHandler.
+func (h *ndGuardHandler) HandlePacketIn(pktIn *ofctrl.PacketIn) error {
+	if pktIn.Data.Ethertype != protocol.IPv6_MSG {
+		return fmt.Errorf("unexpected Ethertype %#x for Neighbor Discovery message", pktIn.Data.Ethertype)
+	}
+	ipPkt, ok := pktIn.Data.Data.(*protocol.IPv6)
+	if !ok {
+		return errors.New("invalid IPv6 packet")
+	}
+	icmpPkt, ok := ipPkt.Data.(*protocol.ICMP)
+	if !ok {
+		return errors.New("packet is not an ICMPv6 message")
+	}
+	typeName, ok := ndMessageTypeNames[icmpPkt.Type]
+	if !ok {
+		return fmt.Errorf("unexpected ICMPv6 type %d for Neighbor Discovery message", icmpPkt.Type)
+	}
+	metrics.NDGuardDroppedPacketCount.WithLabelValues(typeName).Inc()
+	if !h.enableLogging || !h.logLimiter.Allow() {
+		return nil
+	}
+	inPort, err := getPacketInPort(pktIn)
+	if err != nil {
+		return err
+	}
+	if icmpPkt.Type == icmpv6NeighborAdvertisementType && len(icmpPkt.Data) >= ndpTargetOffset+net.IPv6len {
+		targetIP := net.IP(icmpPkt.Data[ndpTargetOffset : ndpTargetOffset+net.IPv6len])
+		klog.Infof("ND guard dropped %s for %s from port %d, MAC %s, IP %s", typeName, targetIP, inPort, pktIn.Data.HWSrc, ipPkt.NWSrc)
+	} else {
+		klog.Infof("ND guard dropped %s from port %d, MAC %s, IP %s", typeName, inPort, pktIn.Data.HWSrc, ipPkt.NWSrc)
+	}
+	return nil
+}
@@ -0,0 +1,227 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openflow
+
+import (
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/contiv/libOpenflow/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/component-base/metrics/testutil"
+
+	"antrea.io/antrea/pkg/agent/metrics"
+	"antrea.io/antrea/pkg/agent/openflow/cookie"
+	binding "antrea.io/antrea/pkg/ovs/openflow"
+	"antrea.io/antrea/pkg/ovs/ovsconfig"
+)
+
+const (
+	ndGuardTestPort = 3
+	ndGuardTestMAC  = "6a:e4:1b:2c:3d:4e"
+	ndGuardTestIP   = "fd00:10:244:1::2"
+)
+
+// newNDGuardTestPacket crafts a Neighbor Discovery message of the provided type, sent from srcMAC
+// and srcIP. target is the Target Address of Neighbor Solicitations and Advertisements, and is
+// ignored for Router Advertisements.
+func newNDGuardTestPacket(t *testing.T, icmpType uint8, srcMAC, srcIP, target string) []byte {
+	var icmpData []byte
+	if icmpType == icmpv6RouterAdvertisementType {
+		// Cur Hop Limit, flags, Router Lifetime, Reachable Time and Retrans Timer.
+		icmpData = []byte{0x40, 0x00, 0x07, 0x08, 0, 0, 0, 0, 0, 0, 0, 0}
+	} else {
+		icmpData = append(make([]byte, ndpTargetOffset), net.ParseIP(target).To16()...)
+	}
+	icmpPkt := &protocol.ICMP{Type: icmpType, Code: 0, Data: icmpData}
+	dstMAC, _ := net.ParseMAC("33:33:00:00:00:01")
+	hwSrc, err := net.ParseMAC(srcMAC)
+	require.NoError(t, err)
+	eth := protocol.Ethernet{
+		HWDst:     dstMAC,
+		HWSrc:     hwSrc,
+		Ethertype: protocol.IPv6_MSG,
+		Data: &protocol.IPv6{
+			Version:    6,
+			Length:     icmpPkt.Len(),
+			NextHeader: protocol.Type_IPv6ICMP,
+			HopLimit:   255,
+			NWSrc:      net.ParseIP(srcIP),
+			NWDst:      net.ParseIP("ff02::1"),
+			Data:       icmpPkt,
+		},
+	}
+	data, err := eth.MarshalBinary()
+	require.NoError(t, err)
+	return data
+}
+
+// ndGuardTestAllows returns whether the Neighbor Discovery message received on inPort is allowed by
+// the provided SpoofGuardTable flows, by matching it against the flows in priority order. Messages
+// which don't match any flow are not handled by the ND guard, and are considered allowed.
+func ndGuardTestAllows(t *testing.T, flows []binding.Flow, inPort uint32, data []byte) bool {
+	eth := protocol.Ethernet{}
+	require.NoError(t, eth.UnmarshalBinary(data))
+	icmpPkt := eth.Data.(*protocol.IPv6).Data.(*protocol.ICMP)
+	var matchedFlow binding.Flow
+	for _, flow := range flows {
+		if matchedFlow != nil && flow.FlowPriority() <= matchedFlow.FlowPriority() {
+			continue
+		}
+		matched := true
+		// Skip the table and the protocol.
+		for _, field := range strings.Split(flow.MatchString(), ",")[2:] {
+			kv := strings.SplitN(field, "=", 2)
+			switch kv[0] {
+			case "in_port":
+				matched = matched && kv[1] == strconv.Itoa(int(inPort))
+			case "dl_src":
+				matched = matched && kv[1] == eth.HWSrc.String()
+			case "icmp_type":
+				matched = matched && kv[1] == strconv.Itoa(int(icmpPkt.Type))
+			case "icmp_code":
+				matched = matched && kv[1] == strconv.Itoa(int(icmpPkt.Code))
+			case "nd_target":
+				_, targetNet, err := net.ParseCIDR(kv[1])
+				require.NoError(t, err)
+				target := net.IP(icmpPkt.Data[ndpTargetOffset : ndpTargetOffset+net.IPv6len])
+				matched = matched && targetNet.Contains(target)
+			default:
+				t.Fatalf("Unexpected match field %s in flow %s", kv[0], flow.MatchString())
+			}
+		}
+		if matched {
+			matchedFlow = flow
+		}
+	}
+	return matchedFlow == nil || matchedFlow.FlowPriority() != ndGuardDropPriority
+}
+
+func TestNDGuardFlows(t *testing.T) {
+	ofClient := NewClient(bridgeName, bridgeMgmtAddr, ovsconfig.OVSDatapathSystem, true, false, false, false)
+	c := ofClient.(*client)
+	c.cookieAllocator = cookie.NewAllocator(0, 0)
+	podMAC, _ := net.ParseMAC(ndGuardTestMAC)
+	podIPs := []net.IP{net.ParseIP("10.10.0.2"), net.ParseIP(ndGuardTestIP)}
+	flows := c.ndGuardFlows(podIPs, podMAC, ndGuardTestPort, cookie.Pod)
+
+	tests := []struct {
+		name          string
+		inPort        uint32
+		packet        []byte
+		expectedAllow bool
+	}{
+		{
+			name:          "Router Advertisement",
+			inPort:        ndGuardTestPort,
+			packet:        newNDGuardTestPacket(t, icmpv6RouterAdvertisementType, ndGuardTestMAC, "fe80::68e4:1bff:fe2c:3d4e", ""),
+			expectedAllow: false,
+		},
+		{
+			name:          "Router Advertisement from another port",
+			inPort:        ndGuardTestPort + 1,
+			packet:        newNDGuardTestPacket(t, icmpv6RouterAdvertisementType, "aa:bb:cc:dd:ee:ff", "fe80::1", ""),
+			expectedAllow: true,
+		},
+		{
+			name:          "Neighbor Solicitation",
+			inPort:        ndGuardTestPort,
+			packet:        newNDGuardTestPacket(t, icmpv6NeighborSolicitationType, ndGuardTestMAC, ndGuardTestIP, "fd00:10:244:1::1"),
+			expectedAllow: true,
+		},
+		{
+			name:          "Neighbor Advertisement for Pod IP",
+			inPort:        ndGuardTestPort,
+			packet:        newNDGuardTestPacket(t, icmpv6NeighborAdvertisementType, ndGuardTestMAC, ndGuardTestIP, ndGuardTestIP),
+			expectedAllow: true,
+		},
+		{
+			name:          "Neighbor Advertisement for link-local IP",
+			inPort:        ndGuardTestPort,
+			packet:        newNDGuardTestPacket(t, icmpv6NeighborAdvertisementType, ndGuardTestMAC, "fe80::68e4:1bff:fe2c:3d4e", "fe80::68e4:1bff:fe2c:3d4e"),
+			expectedAllow: true,
+		},
+		{
+			name:          "Neighbor Advertisement for gateway IP",
+			inPort:        ndGuardTestPort,
+			packet:        newNDGuardTestPacket(t, icmpv6NeighborAdvertisementType, ndGuardTestMAC, ndGuardTestIP, "fd00:10:244:1::1"),
+			expectedAllow: false,
+		},
+		{
+			name:          "Neighbor Advertisement from spoofed MAC",
+			inPort:        ndGuardTestPort,
+			packet:        newNDGuardTestPacket(t, icmpv6NeighborAdvertisementType, "aa:bb:cc:dd:ee:ff", ndGuardTestIP, ndGuardTestIP),
+			expectedAllow: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expectedAllow, ndGuardTestAllows(t, flows, tt.inPort, tt.packet))
+		})
+	}
+}
+
+func TestNDGuardHandler(t *testing.T) {
+	metrics.InitializeNDGuardMetrics()
+	getCount := func(typeName string) float64 {
+		count, err := testutil.GetCounterMetricValue(metrics.NDGuardDroppedPacketCount.WithLabelValues(typeName))
+		require.NoError(t, err)
+		return count
+	}
+
+	tests := []struct {
+		name             string
+		packet           []byte
+		expectedTypeName string
+		expectedErr      bool
+	}{
+		{
+			name:             "Router Advertisement",
+			packet:           newNDGuardTestPacket(t, icmpv6RouterAdvertisementType, ndGuardTestMAC, "fe80::68e4:1bff:fe2c:3d4e", ""),
+			expectedTypeName: "RouterAdvertisement",
+		},
+		{
+			name:             "Neighbor Advertisement",
+			packet:           newNDGuardTestPacket(t, icmpv6NeighborAdvertisementType, ndGuardTestMAC, ndGuardTestIP, "fd00:10:244:1::1"),
+			expectedTypeName: "NeighborAdvertisement",
+		},
+		{
+			name:        "Neighbor Solicitation",
+			packet:      newNDGuardTestPacket(t, icmpv6NeighborSolicitationType, ndGuardTestMAC, ndGuardTestIP, "fd00:10:244:1::1"),
+			expectedErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pktIn := newNDPTestPacketIn(t, tt.packet, ndGuardTestPort)
+			pktIn.Reason = uint8(PacketInReasonNDGuard)
+			var countBefore float64
+			if !tt.expectedErr {
+				countBefore = getCount(tt.expectedTypeName)
+			}
+
+			err := newNDGuardHandler(true).HandlePacketIn(pktIn)
+			if tt.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, countBefore+1, getCount(tt.expectedTypeName))
+		})
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openflow
+
+import (
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/contiv/libOpenflow/openflow13"
+	"github.com/contiv/libOpenflow/protocol"
+	"github.com/contiv/ofnet/ofctrl"
+	"k8s.io/klog/v2"
+
+	binding "antrea.io/antrea/pkg/ovs/openflow"
+)
+
+const (
+	icmpv6NeighborSolicitationType  uint8 = 135
+	icmpv6NeighborAdvertisementType uint8 = 136
+
+	// ndpTargetOffset is the offset of the target address in the ICMPv6 body (after the checksum)
+	// of Neighbor Solicitation and Neighbor Advertisement messages.
+	ndpTargetOffset = 4
+	// ndpOptionTargetLinkLayerAddress is the type of the Target Link-Layer Address option, and
+	// ndpOptionLinkLayerAddressLen is its length in units of 8 octets (RFC 4861, section 4.6.1).
+	ndpOptionTargetLinkLayerAddress uint8 = 2
+	ndpOptionLinkLayerAddressLen    uint8 = 1
+	// ndpAdvertisementFlags sets the Solicited and Override flags of a Neighbor Advertisement.
+	ndpAdvertisementFlags uint8 = 0x60
+	// ndpHopLimit is the hop limit required for all Neighbor Discovery messages, packets with any
+	// other value are discarded by the receiver.
+	ndpHopLimit uint8 = 255
+)
+
+// ndpResponder replies to IPv6 Neighbor Solicitations sent to the controller by ndResponderFlow
+// with Neighbor Advertisements carrying the global virtual MAC. It is the IPv6 counterpart of
+// arpResponderStaticFlow: the reply cannot be generated with OpenFlow actions only, as the
+// ICMPv6 checksum must be recomputed.
+type ndpResponder struct {
+	ofClient *client
+}
+
+func newNDPResponder(ofClient *client) *ndpResponder {
+	return &ndpResponder{ofClient: ofClient}
+}
+
+// HandlePacketIn implements PacketInHandler.
+func (r *ndpResponder) HandlePacketIn(pktIn *ofctrl.PacketIn) error {
+	if pktIn.Data.Ethertype != protocol.IPv6_MSG {
+		return fmt.Errorf("unexpected Ethertype %#x for Neighbor Solicitation", pktIn.Data.Ethertype)
+	}
+	ipPkt, ok := pktIn.Data.Data.(*protocol.IPv6)
+	if !ok {
+		return errors.New("invalid IPv6 packet")
+	}
+	icmpPkt, ok := ipPkt.Data.(*protocol.ICMP)
+	if !ok || icmpPkt.Type != icmpv6NeighborSolicitationType || icmpPkt.Code != 0 {
+		return errors.New("packet is not an ICMPv6 Neighbor Solicitation")
+	}
+	if len(icmpPkt.Data) < ndpTargetOffset+net.IPv6len {
+		return errors.New("Neighbor Solicitation is too short")
+	}
+	targetIP := net.IP(icmpPkt.Data[ndpTargetOffset : ndpTargetOffset+net.IPv6len])
+	if targetIP.IsMulticast() {
+		return fmt.Errorf("invalid multicast target address %s in Neighbor Solicitation", targetIP)
+	}
+	// Solicitations sent for Duplicate Address Detection use the unspecified address as the
+	// source and must not be answered, otherwise the sender would consider its address duplicated.
+	if ipPkt.NWSrc.IsUnspecified() {
+		klog.V(4).Infof("Ignoring Duplicate Address Detection Neighbor Solicitation for %s", targetIP)
+		return nil
+	}
+	inPort, err := getPacketInPort(pktIn)
+	if err != nil {
+		return err
+	}
+	return r.ofClient.sendNeighborAdvertisement(pktIn.Data.HWSrc, ipPkt.NWSrc, targetIP, globalVirtualMAC, inPort)
+}
+
+// getPacketInPort returns the OpenFlow port on which the packet sent to the controller was received.
+func getPacketInPort(pktIn *ofctrl.PacketIn) (uint32, error) {
+	for _, field := range pktIn.Match.Fields {
+		if field.Class != openflow13.OXM_CLASS_OPENFLOW_BASIC || field.Field != openflow13.OXM_FIELD_IN_PORT {
+			continue
+		}
+		if inPortField, ok := field.Value.(*openflow13.InPortField); ok {
+			return inPortField.InPort, nil
+		}
+	}
+	return 0, errors.New("in_port not found in PacketIn match")
+}
+
+// sendNeighborAdvertisement sends a solicited Neighbor Advertisement, which resolves targetIP to
+// targetMAC, to the requester through the port on which the solicitation was received.
+func (c *client) sendNeighborAdvertisement(dstMAC net.HardwareAddr, dstIP, targetIP net.IP, targetMAC net.HardwareAddr, outPort uint32) error {
+	icmpData := make([]byte, ndpTargetOffset+net.IPv6len+2+len(targetMAC))
+	icmpData[0] = ndpAdvertisementFlags
+	copy(icmpData[ndpTargetOffset:], targetIP.To16())
+	option := icmpData[ndpTargetOffset+net.IPv6len:]
+	option[0] = ndpOptionTargetLinkLayerAddress
+	option[1] = ndpOptionLinkLayerAddressLen
+	copy(option[2:], targetMAC)
+
+	packetOutObj := c.bridge.BuildPacketOut().
+		SetSrcMAC(targetMAC).
+		SetDstMAC(dstMAC).
+		SetSrcIP(targetIP).
+		SetDstIP(dstIP).
+		SetIPProtocol(binding.ProtocolICMPv6).
+		SetTTL(ndpHopLimit).
+		SetICMPType(icmpv6NeighborAdvertisementType).
+		SetICMPCode(0).
+		SetICMPData(icmpData).
+		SetInport(openflow13.P_CONTROLLER).
+		SetOutport(outPort).
+		Done()
+	return c.bridge.SendPacketOut(packetOutObj)
+}
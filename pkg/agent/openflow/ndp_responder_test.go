@@ -0,0 +1,145 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openflow
+
+import (
+	"net"
+	"testing"
+
+	"github.com/contiv/libOpenflow/openflow13"
+	"github.com/contiv/libOpenflow/protocol"
+	"github.com/contiv/ofnet/ofctrl"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	binding "antrea.io/antrea/pkg/ovs/openflow"
+	ovsoftest "antrea.io/antrea/pkg/ovs/openflow/testing"
+	"antrea.io/antrea/pkg/ovs/ovsconfig"
+)
+
+var (
+	// Neighbor Solicitation sent by Pod 6a:e4:1b:2c:3d:4e (fd00:10:244:1::2) for fd00:10:244:1::1,
+	// with a Source Link-Layer Address option.
+	ndpTestSolicitation = []byte{
+		0x33, 0x33, 0xff, 0x00, 0x00, 0x01, 0x6a, 0xe4, 0x1b, 0x2c, 0x3d, 0x4e, 0x86, 0xdd, 0x60, 0x00,
+		0x00, 0x00, 0x00, 0x20, 0x3a, 0xff, 0xfd, 0x00, 0x00, 0x10, 0x02, 0x44, 0x00, 0x01, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x02, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x01, 0xff, 0x00, 0x00, 0x01, 0x87, 0x00, 0xb7, 0x90, 0x00, 0x00, 0x00, 0x00, 0xfd, 0x00,
+		0x00, 0x10, 0x02, 0x44, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01, 0x01, 0x01,
+		0x6a, 0xe4, 0x1b, 0x2c, 0x3d, 0x4e,
+	}
+	// Solicited Neighbor Advertisement resolving fd00:10:244:1::1 to the global virtual MAC, with
+	// a Target Link-Layer Address option.
+	ndpTestAdvertisement = []byte{
+		0x6a, 0xe4, 0x1b, 0x2c, 0x3d, 0x4e, 0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff, 0x86, 0xdd, 0x60, 0x00,
+		0x00, 0x00, 0x00, 0x20, 0x3a, 0xff, 0xfd, 0x00, 0x00, 0x10, 0x02, 0x44, 0x00, 0x01, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x01, 0xfd, 0x00, 0x00, 0x10, 0x02, 0x44, 0x00, 0x01, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0x88, 0x00, 0xb1, 0x04, 0x60, 0x00, 0x00, 0x00, 0xfd, 0x00,
+		0x00, 0x10, 0x02, 0x44, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01, 0x02, 0x01,
+		0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff,
+	}
+)
+
+func newNDPTestPacketIn(t *testing.T, data []byte, inPort uint32) *ofctrl.PacketIn {
+	eth := protocol.Ethernet{}
+	require.NoError(t, eth.UnmarshalBinary(data))
+	return &ofctrl.PacketIn{
+		Reason: uint8(PacketInReasonND),
+		Match:  openflow13.Match{Fields: []openflow13.MatchField{*openflow13.NewInPortField(inPort)}},
+		Data:   eth,
+	}
+}
+
+func marshalPacketOut(t *testing.T, pktOut *ofctrl.PacketOut) []byte {
+	ipv6Header := *pktOut.IPv6Header
+	ipv6Header.Data = pktOut.ICMPHeader
+	eth := protocol.Ethernet{
+		HWDst:     pktOut.DstMAC,
+		HWSrc:     pktOut.SrcMAC,
+		Ethertype: protocol.IPv6_MSG,
+		Data:      &ipv6Header,
+	}
+	data, err := eth.MarshalBinary()
+	require.NoError(t, err)
+	return data
+}
+
+func TestNDPResponder(t *testing.T) {
+	tests := []struct {
+		name          string
+		pktIn         func(t *testing.T) *ofctrl.PacketIn
+		wantPacketOut []byte
+		wantErr       bool
+	}{
+		{
+			name: "Neighbor Solicitation",
+			pktIn: func(t *testing.T) *ofctrl.PacketIn {
+				return newNDPTestPacketIn(t, ndpTestSolicitation, 3)
+			},
+			wantPacketOut: ndpTestAdvertisement,
+		},
+		{
+			name: "Duplicate Address Detection",
+			pktIn: func(t *testing.T) *ofctrl.PacketIn {
+				pktIn := newNDPTestPacketIn(t, ndpTestSolicitation, 3)
+				pktIn.Data.Data.(*protocol.IPv6).NWSrc = net.IPv6unspecified
+				return pktIn
+			},
+		},
+		{
+			name: "Neighbor Advertisement",
+			pktIn: func(t *testing.T) *ofctrl.PacketIn {
+				return newNDPTestPacketIn(t, ndpTestAdvertisement, 3)
+			},
+			wantErr: true,
+		},
+		{
+			name: "missing in_port",
+			pktIn: func(t *testing.T) *ofctrl.PacketIn {
+				pktIn := newNDPTestPacketIn(t, ndpTestSolicitation, 3)
+				pktIn.Match.Fields = nil
+				return pktIn
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			ofClient := NewClient(bridgeName, bridgeMgmtAddr, ovsconfig.OVSDatapathSystem, true, false, false, false)
+			c := ofClient.(*client)
+			m := ovsoftest.NewMockBridge(ctrl)
+			c.bridge = m
+			if tt.wantPacketOut != nil {
+				bridge := binding.OFBridge{}
+				m.EXPECT().BuildPacketOut().Return(bridge.BuildPacketOut()).Times(1)
+				m.EXPECT().SendPacketOut(gomock.Any()).Do(func(pktOut *ofctrl.PacketOut) {
+					assert.Equal(t, uint32(openflow13.P_CONTROLLER), pktOut.InPort)
+					assert.Equal(t, uint32(3), pktOut.OutPort)
+					assert.Equal(t, tt.wantPacketOut, marshalPacketOut(t, pktOut))
+				}).Return(nil).Times(1)
+			}
+
+			err := newNDPResponder(c).HandlePacketIn(tt.pktIn(t))
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
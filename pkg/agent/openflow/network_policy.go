@@ -17,6 +17,7 @@ package openflow
 import (
 	"fmt"
 	"net"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -406,6 +407,16 @@ type conjMatchFlowContextChange struct {
 	// actChange is the changed conjunctive action. It is used to update the conjMatchFlowContext's actions. actChange
 	// is not nil.
 	actChange *actionChange
+	// trigger is the object which caused the change. It is recorded with the changed flows when flow change
+	// tracking is enabled.
+	trigger types.FlowChangeTrigger
+}
+
+// setFlowChangeTrigger sets the object which caused the conjMatchFlowContextChanges.
+func setFlowChangeTrigger(changes []*conjMatchFlowContextChange, trigger types.FlowChangeTrigger) {
+	for _, change := range changes {
+		change.trigger = trigger
+	}
 }
 
 // updateContextStatus changes conjMatchFlowContext's status, including,
@@ -503,8 +514,14 @@ type policyRuleConjunction struct {
 	actionFlows   []binding.Flow
 	metricFlows   []binding.Flow
 	// NetworkPolicy reference information for debugging usage.
-	npRef       *v1beta2.NetworkPolicyReference
+	npRef *v1beta2.NetworkPolicyReference
+	// ruleName is the name of the NetworkPolicy rule, which tells apart the rules of the same
+	// NetworkPolicy sharing the same OpenFlow priority.
+	ruleName    string
 	ruleTableID binding.TableIDType
+	// layer2Rule is the PolicyRule of a layer 2 rule, which is realized with actionFlows only. It
+	// is nil for the other rules.
+	layer2Rule *types.PolicyRule
 }
 
 // clause groups conjunctive match flows. Matches in a clause represent source addresses(for fromClause), or destination
@@ -819,19 +836,29 @@ func (c *policyRuleConjunction) getAddressClause(addrType types.AddressType) *cl
 // If the default drop flow is already installed before this error, all packets will be dropped by the default drop flow,
 // Otherwise all packets will be allowed.
 func (c *client) InstallPolicyRuleFlows(rule *types.PolicyRule) error {
+	c.waitForPolicyRuleUninstall(rule.FlowID)
 	c.replayMutex.RLock()
 	defer c.replayMutex.RUnlock()
-
-	conj := c.calculateActionFlowChangesForRule(rule)
-
 	c.conjMatchFlowLock.Lock()
 	defer c.conjMatchFlowLock.Unlock()
+	return c.installPolicyRuleFlows(rule)
+}
+
+// installPolicyRuleFlows installs the flows of a single PolicyRule. The caller must hold replayMutex and
+// conjMatchFlowLock.
+func (c *client) installPolicyRuleFlows(rule *types.PolicyRule) error {
+	conj := c.calculateActionFlowChangesForRule(rule)
+	if conj == nil {
+		return nil
+	}
 	ctxChanges := c.calculateMatchFlowChangesForRule(conj, rule, false)
+	trigger := policyRuleTrigger(rule.PolicyRef)
+	setFlowChangeTrigger(ctxChanges, trigger)
 
-	if err := c.ofEntryOperations.AddAll(conj.metricFlows); err != nil {
+	if err := c.ofEntryOps(trigger).AddAll(conj.metricFlows); err != nil {
 		return err
 	}
-	if err := c.ofEntryOperations.AddAll(conj.actionFlows); err != nil {
+	if err := c.ofEntryOps(trigger).AddAll(conj.actionFlows); err != nil {
 		return err
 	}
 	if err := c.applyConjunctiveMatchFlows(ctxChanges); err != nil {
@@ -852,8 +879,15 @@ func (c *client) calculateActionFlowChangesForRule(rule *types.PolicyRule) *poli
 		return nil
 	}
 	conj = &policyRuleConjunction{
-		id:    ruleOfID,
-		npRef: rule.PolicyRef,
+		id:       ruleOfID,
+		npRef:    rule.PolicyRef,
+		ruleName: rule.Name,
+	}
+	if isLayer2Table(rule.TableID) {
+		conj.ruleTableID = rule.TableID
+		conj.layer2Rule = rule
+		conj.actionFlows = c.layer2RuleFlows(rule, rule.Priority)
+		return conj
 	}
 	nClause, ruleTable, dropTable := conj.calculateClauses(rule, c)
 	conj.ruleTableID = rule.TableID
@@ -872,7 +906,11 @@ func (c *client) calculateActionFlowChangesForRule(rule *types.PolicyRule) *poli
 			actionFlows = append(actionFlows, c.conjunctionActionDenyFlow(ruleOfID, ruleTable.GetID(), rule.Priority, DispositionDrop, rule.EnableLogging))
 		} else if rule.IsAntreaNetworkPolicyRule() && *rule.Action == crdv1alpha1.RuleActionReject {
 			metricFlows = append(metricFlows, c.denyRuleMetricFlow(ruleOfID, isIngress))
-			actionFlows = append(actionFlows, c.conjunctionActionDenyFlow(ruleOfID, ruleTable.GetID(), rule.Priority, DispositionRej, rule.EnableLogging))
+			actionFlows = append(actionFlows, c.conjunctionActionDenyFlow(ruleOfID, ruleTable.GetID(), rule.Priority, DispositionReject, rule.EnableLogging))
+		} else if rule.IsAntreaNetworkPolicyRule() && *rule.Action == crdv1alpha1.RuleActionPass {
+			// The connections are not committed so that they are evaluated by the K8s NetworkPolicy
+			// rules, hence there are no metric flows.
+			actionFlows = append(actionFlows, c.conjunctionActionPassFlow(ruleOfID, ruleTable.GetID(), ruleTable.GetNext(), rule.Priority, rule.EnableLogging))
 		} else {
 			metricFlows = append(metricFlows, c.allowRulesMetricFlows(ruleOfID, isIngress)...)
 			actionFlows = append(actionFlows, c.conjunctionActionFlow(ruleOfID, ruleTable.GetID(), dropTable.GetNext(), rule.Priority, rule.EnableLogging)...)
@@ -898,32 +936,87 @@ func (c *client) calculateMatchFlowChangesForRule(conj *policyRuleConjunction, r
 	return ctxChanges
 }
 
+// PolicyRuleInstallError is returned by BatchInstallPolicyRuleFlows when the flows of some PolicyRules could
+// not be installed. The flows of all the other PolicyRules of the batch have been installed.
+type PolicyRuleInstallError struct {
+	// FailedRules maps the FlowID of each PolicyRule which could not be installed to the cause.
+	FailedRules map[uint32]error
+}
+
+func (e *PolicyRuleInstallError) Error() string {
+	ruleIDs := make([]uint32, 0, len(e.FailedRules))
+	for ruleID := range e.FailedRules {
+		ruleIDs = append(ruleIDs, ruleID)
+	}
+	sort.Slice(ruleIDs, func(i, j int) bool { return ruleIDs[i] < ruleIDs[j] })
+	msgs := make([]string, 0, len(ruleIDs))
+	for _, ruleID := range ruleIDs {
+		msgs = append(msgs, fmt.Sprintf("rule %d: %v", ruleID, e.FailedRules[ruleID]))
+	}
+	return fmt.Sprintf("failed to install flows for %d PolicyRule(s): %s", len(ruleIDs), strings.Join(msgs, "; "))
+}
+
 // BatchInstallPolicyRuleFlows installs flows for NetworkPolicy rules in case of agent restart. It calculates and
 // accumulates all Openflow entry updates required and installs all of them on OVS bridge in one bundle.
+// If the bundle fails, e.g. because one PolicyRule is malformed, the flows of each PolicyRule are installed
+// separately and a *PolicyRuleInstallError identifying the PolicyRules which could not be installed is
+// returned. PolicyRules which are already installed are skipped, so that a batch can be installed again after a
+// partial failure.
 func (c *client) BatchInstallPolicyRuleFlows(ofPolicyRules []*types.PolicyRule) error {
+	for _, rule := range ofPolicyRules {
+		c.waitForPolicyRuleUninstall(rule.FlowID)
+	}
 	c.replayMutex.RLock()
 	defer c.replayMutex.RUnlock()
+	c.conjMatchFlowLock.Lock()
+	defer c.conjMatchFlowLock.Unlock()
 
 	var allCtxChanges []*conjMatchFlowContextChange
 	var allFlows []binding.Flow
+	var rulesToInstall []*types.PolicyRule
 	var updatedConjunctions []*policyRuleConjunction
 
 	for _, rule := range ofPolicyRules {
 		conj := c.calculateActionFlowChangesForRule(rule)
+		if conj == nil {
+			continue
+		}
 		ctxChanges := c.calculateMatchFlowChangesForRule(conj, rule, true)
+		setFlowChangeTrigger(ctxChanges, policyRuleTrigger(rule.PolicyRef))
 		allFlows = append(allFlows, conj.actionFlows...)
 		allFlows = append(allFlows, conj.metricFlows...)
 		allCtxChanges = append(allCtxChanges, ctxChanges...)
+		rulesToInstall = append(rulesToInstall, rule)
 		updatedConjunctions = append(updatedConjunctions, conj)
 	}
 	// Send the changed Openflow entries to the OVS bridge.
-	if err := c.sendConjunctiveFlows(allCtxChanges, allFlows); err != nil {
-		return err
+	err := c.sendConjunctiveFlows(allCtxChanges, allFlows)
+	if err == nil {
+		// Update conjMatchFlowContexts as the expected status.
+		for _, conj := range updatedConjunctions {
+			// Add the policyRuleConjunction into policyCache
+			c.policyCache.Add(conj)
+			c.recordFlowChanges(policyRuleTrigger(conj.npRef), conj.actionFlows, nil, nil)
+			c.recordFlowChanges(policyRuleTrigger(conj.npRef), conj.metricFlows, nil, nil)
+		}
+		return nil
+	}
+	klog.Errorf("Failed to install flows for %d PolicyRules in one bundle, installing them separately: %v", len(rulesToInstall), err)
+	// The bundle is atomic, so no flow has been installed. Revert the conjMatchFlowContexts updated when calculating
+	// the changes, in reverse order as each calculation was based on the previous ones.
+	for i := len(updatedConjunctions) - 1; i >= 0; i-- {
+		for _, ctxChange := range updatedConjunctions[i].calculateChangesForRuleDeletion() {
+			ctxChange.updateContextStatus()
+		}
+	}
+	failedRules := map[uint32]error{}
+	for _, rule := range rulesToInstall {
+		if err := c.installPolicyRuleFlows(rule); err != nil {
+			failedRules[rule.FlowID] = err
+		}
 	}
-	// Update conjMatchFlowContexts as the expected status.
-	for _, conj := range updatedConjunctions {
-		// Add the policyRuleConjunction into policyCache
-		c.policyCache.Add(conj)
+	if len(failedRules) > 0 {
+		return &PolicyRuleInstallError{FailedRules: failedRules}
 	}
 	return nil
 }
@@ -965,7 +1058,11 @@ func (c *client) sendConjunctiveFlows(changes []*conjMatchFlowContextChange, flo
 			deleteFlows = append(deleteFlows, fc.flow)
 		}
 	}
-	return c.bridge.AddFlowsInBundle(addFlows, modifyFlows, deleteFlows)
+	if err := c.bridge.AddFlowsInBundle(addFlows, modifyFlows, deleteFlows); err != nil {
+		return err
+	}
+	c.recordConjunctiveFlowChanges(changes)
+	return nil
 }
 
 // ActionFlowPriorities returns the OF priorities of the actionFlows in the policyRuleConjunction
@@ -1114,13 +1211,16 @@ func (c *client) getPolicyRuleConjunction(ruleID uint32) *policyRuleConjunction
 	return conj.(*policyRuleConjunction)
 }
 
-func (c *client) GetPolicyInfoFromConjunction(ruleID uint32) (string, string) {
+func (c *client) GetPolicyInfoFromConjunction(ruleID uint32) (string, string, string) {
 	conjunction := c.getPolicyRuleConjunction(ruleID)
+	if conjunction == nil {
+		return "", "", ""
+	}
 	priorities := conjunction.ActionFlowPriorities()
-	if conjunction == nil || len(priorities) == 0 {
-		return "", ""
+	if len(priorities) == 0 {
+		return "", "", ""
 	}
-	return conjunction.npRef.ToString(), priorities[0]
+	return conjunction.npRef.ToString(), priorities[0], conjunction.ruleName
 }
 
 // UninstallPolicyRuleFlows removes the Openflow entry relevant to the specified NetworkPolicy rule.
@@ -1136,11 +1236,12 @@ func (c *client) UninstallPolicyRuleFlows(ruleID uint32) ([]string, error) {
 		return nil, nil
 	}
 	staleOFPriorities := c.getStalePriorities(conj)
+	trigger := policyRuleTrigger(conj.npRef)
 	// Delete action flows from the OVS bridge.
-	if err := c.ofEntryOperations.DeleteAll(conj.actionFlows); err != nil {
+	if err := c.ofEntryOps(trigger).DeleteAll(conj.actionFlows); err != nil {
 		return nil, err
 	}
-	if err := c.ofEntryOperations.DeleteAll(conj.metricFlows); err != nil {
+	if err := c.ofEntryOps(trigger).DeleteAll(conj.metricFlows); err != nil {
 		return nil, err
 	}
 
@@ -1148,15 +1249,57 @@ func (c *client) UninstallPolicyRuleFlows(ruleID uint32) ([]string, error) {
 	defer c.conjMatchFlowLock.Unlock()
 	// Get the conjMatchFlowContext changes.
 	ctxChanges := conj.calculateChangesForRuleDeletion()
+	setFlowChangeTrigger(ctxChanges, trigger)
 	// Send the changed OpenFlow entries to the OVS bridge and update the conjMatchFlowContext.
 	if err := c.applyConjunctiveMatchFlows(ctxChanges); err != nil {
 		return nil, err
 	}
 
 	c.policyCache.Delete(conj)
+	c.forgetPolicyMetrics(ruleID)
 	return staleOFPriorities, nil
 }
 
+// UninstallPolicyRuleFlowsAsync removes the Openflow entries relevant to the specified NetworkPolicy rule in the
+// background, and returns a channel to which the result is sent once they are removed. The flows of a rule with
+// the same ID are not installed before the uninstallation completes.
+func (c *client) UninstallPolicyRuleFlowsAsync(ruleID uint32) <-chan types.PolicyRuleUninstallResult {
+	resultCh := make(chan types.PolicyRuleUninstallResult, 1)
+	done := make(chan struct{})
+	c.pendingRuleUninstallsLock.Lock()
+	// The previous uninstallation of the rule, if it's still pending, must complete first.
+	prevDone := c.pendingRuleUninstalls[ruleID]
+	c.pendingRuleUninstalls[ruleID] = done
+	c.pendingRuleUninstallsLock.Unlock()
+
+	go func() {
+		if prevDone != nil {
+			<-prevDone
+		}
+		stalePriorities, err := c.UninstallPolicyRuleFlows(ruleID)
+		c.pendingRuleUninstallsLock.Lock()
+		if c.pendingRuleUninstalls[ruleID] == done {
+			delete(c.pendingRuleUninstalls, ruleID)
+		}
+		c.pendingRuleUninstallsLock.Unlock()
+		close(done)
+		resultCh <- types.PolicyRuleUninstallResult{StalePriorities: stalePriorities, Err: err}
+	}()
+	return resultCh
+}
+
+// waitForPolicyRuleUninstall blocks until the pending uninstallation of the rule, if any, completes. It must be
+// called without holding replayMutex, which the uninstallation acquires.
+func (c *client) waitForPolicyRuleUninstall(ruleID uint32) {
+	c.pendingRuleUninstallsLock.Lock()
+	done, pending := c.pendingRuleUninstalls[ruleID]
+	c.pendingRuleUninstallsLock.Unlock()
+	if pending {
+		klog.V(2).Infof("Waiting for the pending uninstallation of policyRuleConjunction %d", ruleID)
+		<-done
+	}
+}
+
 // getStalePriorities returns the ofPriorities that will be stale on the rule table where the
 // policyRuleConjunction is installed, after the deletion of that policyRuleConjunction.
 func (c *client) getStalePriorities(conj *policyRuleConjunction) (staleOFPriorities []string) {
@@ -1220,7 +1363,7 @@ func (c *client) replayPolicyFlows() {
 	for _, ctx := range c.globalConjMatchFlowCache {
 		addMatchFlows(ctx)
 	}
-	if err := c.ofEntryOperations.AddAll(flows); err != nil {
+	if err := c.ofEntryOps(replayTrigger).AddAll(flows); err != nil {
 		klog.Errorf("Error when replaying flows: %v", err)
 	}
 }
@@ -1238,6 +1381,9 @@ func (c *client) AddPolicyRuleAddress(ruleID uint32, addrType types.AddressType,
 	if conj == nil {
 		return newConjunctionNotFound(ruleID)
 	}
+	if conj.layer2Rule != nil {
+		return c.updateLayer2RuleAddresses(conj, addrType, addresses, nil, priority)
+	}
 	var clause = conj.getAddressClause(addrType)
 	// Check if the clause is nil or not. The clause is nil if the addrType is an unsupported type.
 	if clause == nil {
@@ -1247,6 +1393,7 @@ func (c *client) AddPolicyRuleAddress(ruleID uint32, addrType types.AddressType,
 	c.conjMatchFlowLock.Lock()
 	defer c.conjMatchFlowLock.Unlock()
 	flowChanges := clause.addAddrFlows(c, addrType, addresses, priority)
+	setFlowChangeTrigger(flowChanges, policyRuleTrigger(conj.npRef))
 	return c.applyConjunctiveMatchFlows(flowChanges)
 }
 
@@ -1263,6 +1410,9 @@ func (c *client) DeletePolicyRuleAddress(ruleID uint32, addrType types.AddressTy
 	if conj == nil {
 		return newConjunctionNotFound(ruleID)
 	}
+	if conj.layer2Rule != nil {
+		return c.updateLayer2RuleAddresses(conj, addrType, nil, addresses, priority)
+	}
 
 	var clause = conj.getAddressClause(addrType)
 	// Check if the clause is nil or not. The clause is nil if the addrType is an unsupported type.
@@ -1274,10 +1424,67 @@ func (c *client) DeletePolicyRuleAddress(ruleID uint32, addrType types.AddressTy
 	defer c.conjMatchFlowLock.Unlock()
 	// Remove policyRuleConjunction to actions of conjunctive match using specific address.
 	changes := clause.deleteAddrFlows(addrType, addresses, priority)
+	setFlowChangeTrigger(changes, policyRuleTrigger(conj.npRef))
 	// Update the Openflow entries on the OVS bridge, and update local cache.
 	return c.applyConjunctiveMatchFlows(changes)
 }
 
+// updateLayer2RuleAddresses adds and removes addresses of a layer 2 rule, and replaces the flows of
+// the rule which have changed.
+func (c *client) updateLayer2RuleAddresses(conj *policyRuleConjunction, addrType types.AddressType, added, deleted []types.Address, priority *uint16) error {
+	newRule := *conj.layer2Rule
+	updateAddresses := func(addresses []types.Address) []types.Address {
+		deletedSet := make(map[string]struct{}, len(deleted))
+		for _, addr := range deleted {
+			deletedSet[addr.GetMatchValue()] = struct{}{}
+		}
+		var newAddresses []types.Address
+		for _, addr := range addresses {
+			if _, ok := deletedSet[addr.GetMatchValue()]; !ok {
+				newAddresses = append(newAddresses, addr)
+			}
+		}
+		return append(newAddresses, added...)
+	}
+	switch addrType {
+	case types.SrcAddress:
+		newRule.From = updateAddresses(conj.layer2Rule.From)
+	case types.DstAddress:
+		newRule.To = updateAddresses(conj.layer2Rule.To)
+	default:
+		return fmt.Errorf("no clause is using addrType %d", addrType)
+	}
+	if priority != nil {
+		newRule.Priority = priority
+	}
+	newFlows := c.layer2RuleFlows(&newRule, newRule.Priority)
+	existingFlows := make(map[string]binding.Flow, len(conj.actionFlows))
+	for _, flow := range conj.actionFlows {
+		existingFlows[flow.MatchString()] = flow
+	}
+	var addFlows, delFlows []binding.Flow
+	for i, flow := range newFlows {
+		if existingFlow, ok := existingFlows[flow.MatchString()]; ok {
+			// Keep the installed flow, so that it can be deleted later.
+			newFlows[i] = existingFlow
+			delete(existingFlows, flow.MatchString())
+		} else {
+			addFlows = append(addFlows, flow)
+		}
+	}
+	for _, flow := range existingFlows {
+		delFlows = append(delFlows, flow)
+	}
+	if err := c.bridge.AddFlowsInBundle(addFlows, nil, delFlows); err != nil {
+		return err
+	}
+	c.recordFlowChanges(policyRuleTrigger(conj.npRef), addFlows, nil, delFlows)
+	newConj := *conj
+	newConj.layer2Rule = &newRule
+	newConj.actionFlows = newFlows
+	return c.policyCache.Update(&newConj)
+}
+
 func (c *client) GetNetworkPolicyFlowKeys(npName, npNamespace string) []string {
 	flowKeys := []string{}
 	// Hold replayMutex write lock to protect flows from being modified by
@@ -1310,6 +1517,9 @@ type flowUpdates struct {
 func (c *client) getMatchFlowUpdates(conj *policyRuleConjunction, newPriority uint16) (add, del []binding.Flow) {
 	allClause := []*clause{conj.fromClause, conj.toClause, conj.serviceClause}
 	for _, c := range allClause {
+		if c == nil {
+			continue
+		}
 		for _, ctx := range c.matches {
 			f := ctx.flow
 			updatedFlow := f.CopyToBuilder(newPriority, true).Done()
@@ -1362,7 +1572,9 @@ func (c *client) updateConjunctionActionFlows(conj *policyRuleConjunction, updat
 		serviceClause: conj.serviceClause,
 		actionFlows:   newActionFlows,
 		npRef:         conj.npRef,
+		ruleName:      conj.ruleName,
 		ruleTableID:   conj.ruleTableID,
+		layer2Rule:    conj.layer2Rule,
 	}
 	return newConj
 }
@@ -1371,6 +1583,9 @@ func (c *client) updateConjunctionActionFlows(conj *policyRuleConjunction, updat
 func (c *client) updateConjunctionMatchFlows(conj *policyRuleConjunction, newPriority uint16) {
 	allClause := []*clause{conj.fromClause, conj.toClause, conj.serviceClause}
 	for _, clause := range allClause {
+		if clause == nil {
+			continue
+		}
 		for i, ctx := range clause.matches {
 			delete(c.globalConjMatchFlowCache, ctx.generateGlobalMapKey())
 			f := ctx.flow
@@ -1433,6 +1648,7 @@ func (c *client) ReassignFlowPriorities(updates map[uint16]uint16, table binding
 	if err != nil {
 		return err
 	}
+	c.recordFlowChanges(types.FlowChangeTrigger{Kind: triggerKindPriority}, add, update, del)
 	for conjID, actionUpdates := range conjFlowUpdates {
 		originalConj, _, _ := c.policyCache.GetByKey(fmt.Sprint(conjID))
 		conj := originalConj.(*policyRuleConjunction)
@@ -1455,21 +1671,46 @@ func parseDropFlow(flowMap map[string]string) (uint32, types.RuleMetric) {
 	return uint32(id), m
 }
 
+// parseCTLabel parses the ct_label match of a dumped flow, e.g. "0x10000000000000001/0x100000000ffffffff", and
+// returns the high and low 64 bits of its value and mask.
+func parseCTLabel(ctLabel string) (high, low, highMask, lowMask uint64) {
+	parseHex := func(s string) (uint64, uint64) {
+		s = strings.TrimPrefix(s, "0x")
+		var h, l uint64
+		if len(s) > 16 {
+			h, _ = strconv.ParseUint(s[:len(s)-16], 16, 64)
+			s = s[len(s)-16:]
+		}
+		l, _ = strconv.ParseUint(s, 16, 64)
+		return h, l
+	}
+	value, mask := ctLabel, ""
+	if i := strings.Index(ctLabel, "/"); i != -1 {
+		value, mask = ctLabel[:i], ctLabel[i+1:]
+	}
+	high, low = parseHex(value)
+	highMask, lowMask = parseHex(mask)
+	return
+}
+
 func parseAllowFlow(flowMap map[string]string) (uint32, types.RuleMetric) {
 	m := types.RuleMetric{}
 	pkts, _ := strconv.ParseUint(flowMap["n_packets"], 10, 64)
 	m.Packets = pkts
-	if strings.Contains(flowMap["ct_state"], "+") { // ct_state=+new
-		m.Sessions = pkts
-	}
 	bytes, _ := strconv.ParseUint(flowMap["n_bytes"], 10, 64)
 	m.Bytes = bytes
-	ct_label := flowMap["ct_label"]
-	idRaw := ct_label[strings.Index(ct_label, "0x")+2 : strings.Index(ct_label, "/")]
-	if len(idRaw) > 8 { // only 32 bits are valid.
-		idRaw = idRaw[:len(idRaw)-8]
+	high, low, highMask, lowMask := parseCTLabel(flowMap["ct_label"])
+	// The ingress rule ID is stored in the 0..31 bits of the ct_label and the egress rule ID in the 32..63 bits.
+	id := low >> 32
+	sessionBit := uint64(1) << (metricEgressSessionRange[0] - 64)
+	if lowMask&0xffff_ffff != 0 {
+		id = low & 0xffff_ffff
+		sessionBit = uint64(1) << (metricIngressSessionRange[0] - 64)
+	}
+	// Only the flow matching the first packet of the connections which have not been counted yet counts sessions.
+	if strings.Contains(flowMap["ct_state"], "+new") && highMask&sessionBit != 0 && high&sessionBit == 0 {
+		m.Sessions = pkts
 	}
-	id, _ := strconv.ParseUint(idRaw, 16, 32)
 	return uint32(id), m
 }
 
@@ -1492,6 +1733,8 @@ func parseMetricFlow(flow string) (uint32, types.RuleMetric) {
 	flowMap := parseFlowToMap(flow)
 	// example allow flow format:
 	// table=101, n_packets=0, n_bytes=0, priority=200,ct_state=-new,ct_label=0x1/0xffffffff,ip actions=goto_table:105
+	// example allow flow counting sessions format:
+	// table=101, n_packets=2, n_bytes=148, priority=200,ct_state=+new,ct_label=0x1/0x100000000ffffffff,ip actions=ct(commit,table=105,zone=65520,exec(load:0x1->NXM_NX_CT_LABEL[64]))
 	// example drop flow format:
 	// table=101, n_packets=9, n_bytes=666, priority=200,reg0=0x100000/0x100000,reg3=0x5 actions=drop
 	if _, ok := flowMap[dropIdentifier]; ok {
@@ -1501,6 +1744,14 @@ func parseMetricFlow(flow string) (uint32, types.RuleMetric) {
 	}
 }
 
+// ruleMetricCounters stores the counters of the metric flows of a rule.
+type ruleMetricCounters struct {
+	// last is the metric read from the metric flows during the last collection.
+	last types.RuleMetric
+	// base is the metric accumulated by the metric flows before they were last reinstalled.
+	base types.RuleMetric
+}
+
 func (c *client) NetworkPolicyMetrics() map[uint32]*types.RuleMetric {
 	result := map[uint32]*types.RuleMetric{}
 	egressFlows, _ := c.ovsctlClient.DumpTableFlows(uint8(EgressMetricTable))
@@ -1520,13 +1771,39 @@ func (c *client) NetworkPolicyMetrics() map[uint32]*types.RuleMetric {
 			}
 		}
 	}
-	// We have two flows for each allow rule. One matches 'ct_state=+new'
-	// and counts the number of first packets, which is also the number
-	// of sessions (this is the reason why we have 2 flows). The other
-	// matches 'ct_state=-new' and is used to count all subsequent
-	// packets in the session. We need to merge metrics from these 2
-	// flows to get the correct number of total packets.
+	// We have three flows for each allow rule. One matches 'ct_state=+new'
+	// for the connections not counted yet and counts the number of first
+	// packets, which is also the number of sessions. Another one matches
+	// 'ct_state=+new' for the connections already counted, i.e. the
+	// retransmissions of the first packets. The last one matches
+	// 'ct_state=-new' and is used to count all subsequent packets in the
+	// session. We need to merge metrics from these 3 flows to get the
+	// correct number of total packets.
 	collectMetricsFromFlows(egressFlows)
 	collectMetricsFromFlows(ingressFlows)
+
+	c.policyMetricsLock.Lock()
+	defer c.policyMetricsLock.Unlock()
+	for ruleID, metric := range result {
+		counters, exists := c.policyMetrics[ruleID]
+		if !exists {
+			counters = &ruleMetricCounters{}
+			c.policyMetrics[ruleID] = counters
+		}
+		// The counters of the metric flows restart from zero when the flows are reinstalled, e.g. after OVS is
+		// restarted. Accumulate the metric read before, so that the metrics of the rule don't go backwards.
+		if metric.Packets < counters.last.Packets || metric.Bytes < counters.last.Bytes || metric.Sessions < counters.last.Sessions {
+			counters.base.Merge(&counters.last)
+		}
+		counters.last = *metric
+		metric.Merge(&counters.base)
+	}
 	return result
 }
+
+// forgetPolicyMetrics removes the counters of the rule, so that a new rule reusing the ID doesn't inherit them.
+func (c *client) forgetPolicyMetrics(ruleID uint32) {
+	c.policyMetricsLock.Lock()
+	defer c.policyMetricsLock.Unlock()
+	delete(c.policyMetrics, ruleID)
+}
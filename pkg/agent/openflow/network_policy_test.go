@@ -15,11 +15,13 @@
 package openflow
 
 import (
+	"errors"
 	"fmt"
 	"net"
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
@@ -37,6 +39,7 @@ import (
 	mocks "antrea.io/antrea/pkg/ovs/openflow/testing"
 	"antrea.io/antrea/pkg/ovs/ovsconfig"
 	ovsctltest "antrea.io/antrea/pkg/ovs/ovsctl/testing"
+	"antrea.io/antrea/pkg/util/flowcost"
 )
 
 var (
@@ -369,6 +372,85 @@ func TestBatchInstallPolicyRuleFlows(t *testing.T) {
 	assert.Equal(t, 3, len(c.GetNetworkPolicyFlowKeys("np2", "ns1")))
 }
 
+func TestBatchInstallPolicyRuleFlowsPartialFailure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	c = prepareClient(ctrl)
+	c.nodeConfig = &config.NodeConfig{PodIPv4CIDR: podIPv4CIDR, PodIPv6CIDR: nil}
+	c.ipProtocols = []binding.Protocol{binding.ProtocolIP}
+	bridge := mocks.NewMockBridge(ctrl)
+	c.bridge = bridge
+	defaultAction := crdv1alpha1.RuleActionAllow
+
+	ruleID1 := uint32(10)
+	rule1 := &types.PolicyRule{
+		Direction: v1beta2.DirectionOut,
+		From:      parseAddresses([]string{"192.168.1.40", "192.168.1.50"}),
+		Action:    &defaultAction,
+		To:        parseAddresses([]string{"0.0.0.0/0"}),
+		FlowID:    ruleID1,
+		TableID:   EgressRuleTable,
+		PolicyRef: &v1beta2.NetworkPolicyReference{
+			Type:      v1beta2.K8sNetworkPolicy,
+			Namespace: "ns1",
+			Name:      "np1",
+			UID:       "id1",
+		},
+	}
+	// rule2 shares the address 192.168.1.50 with rule1.
+	ruleID2 := uint32(20)
+	rule2 := &types.PolicyRule{
+		Direction: v1beta2.DirectionOut,
+		From:      parseAddresses([]string{"192.168.1.50", "192.168.1.60"}),
+		Action:    &defaultAction,
+		To:        parseAddresses([]string{"192.168.1.70"}),
+		FlowID:    ruleID2,
+		TableID:   EgressRuleTable,
+		PolicyRef: &v1beta2.NetworkPolicyReference{
+			Type:      v1beta2.K8sNetworkPolicy,
+			Namespace: "ns1",
+			Name:      "np2",
+			UID:       "id2",
+		},
+	}
+
+	outDropTable.EXPECT().BuildFlow(gomock.Any()).Return(newMockDropFlowBuilder(ctrl)).AnyTimes()
+	outTable.EXPECT().BuildFlow(gomock.Any()).Return(newMockRuleFlowBuilder(ctrl)).AnyTimes()
+	metricTable.EXPECT().BuildFlow(gomock.Any()).Return(newMockMetricFlowBuilder(ctrl)).AnyTimes()
+	ruleAction.EXPECT().Conjunction(gomock.Any(), gomock.Any(), gomock.Any()).Return(ruleFlowBuilder).AnyTimes()
+
+	installErr := errors.New("invalid flow")
+	gomock.InOrder(
+		// The bundle including the flows of both rules fails.
+		bridge.EXPECT().AddFlowsInBundle(gomock.Any(), gomock.Any(), gomock.Any()).Return(installErr),
+		// The flows of rule1 are installed separately.
+		bridge.EXPECT().AddFlowsInBundle(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil),
+		// The flows of rule2 fail again.
+		bridge.EXPECT().AddFlowsInBundle(gomock.Any(), gomock.Any(), gomock.Any()).Return(installErr),
+	)
+	err := c.BatchInstallPolicyRuleFlows([]*types.PolicyRule{rule1, rule2})
+	var ruleInstallErr *PolicyRuleInstallError
+	require.True(t, errors.As(err, &ruleInstallErr))
+	assert.Equal(t, map[uint32]error{ruleID2: installErr}, ruleInstallErr.FailedRules)
+	checkConjunctionConfig(t, ruleID1, 1, 2, 1, 0)
+	assert.Nil(t, c.getPolicyRuleConjunction(ruleID2))
+	conj1 := c.getPolicyRuleConjunction(ruleID1)
+	// The changes calculated for rule2 in the failed bundle must have been reverted.
+	checkConjMatchFlowActions(t, c, conj1.fromClause, parseAddresses([]string{"192.168.1.50"})[0], types.SrcAddress, 1, 0)
+	checkFlowCount(t, 3)
+
+	// Installing the same batch again only installs the flows of rule2.
+	bridge.EXPECT().AddFlowsInBundle(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(1)
+	err = c.BatchInstallPolicyRuleFlows([]*types.PolicyRule{rule1, rule2})
+	require.NoError(t, err)
+	assert.True(t, conj1 == c.getPolicyRuleConjunction(ruleID1), "policyRuleConjunction of rule1 should not be recreated")
+	checkConjunctionConfig(t, ruleID1, 1, 2, 1, 0)
+	checkConjunctionConfig(t, ruleID2, 1, 2, 1, 0)
+	checkConjMatchFlowActions(t, c, conj1.fromClause, parseAddresses([]string{"192.168.1.50"})[0], types.SrcAddress, 2, 0)
+	checkFlowCount(t, 5)
+}
+
 func TestConjMatchFlowContextKeyConflict(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -550,6 +632,140 @@ func TestInstallPolicyRuleFlowsInDualStackCluster(t *testing.T) {
 	require.Nil(t, err)
 }
 
+func TestPolicyRuleFlowsEstimation(t *testing.T) {
+	allowAction := crdv1alpha1.RuleActionAllow
+	dropAction := crdv1alpha1.RuleActionDrop
+	passAction := crdv1alpha1.RuleActionPass
+	priority := uint16(10000)
+	tcpProtocol := v1beta2.ProtocolTCP
+	port80 := intstr.FromInt(80)
+	port1000 := intstr.FromInt(1000)
+	port1008 := int32(1008)
+	npRef := &v1beta2.NetworkPolicyReference{Type: v1beta2.K8sNetworkPolicy, Namespace: "ns1", Name: "np1", UID: "id1"}
+	anpRef := &v1beta2.NetworkPolicyReference{Type: v1beta2.AntreaNetworkPolicy, Namespace: "ns1", Name: "anp1", UID: "id2"}
+	tests := []struct {
+		name          string
+		dualStack     bool
+		rule          *types.PolicyRule
+		estimatedRule flowcost.Rule
+	}{
+		{
+			name: "K8s NetworkPolicy rule",
+			rule: &types.PolicyRule{
+				Direction: v1beta2.DirectionOut,
+				From:      parseAddresses([]string{"192.168.1.30", "192.168.1.50"}),
+				To:        parseAddresses([]string{"192.168.2.10", "192.168.2.20", "192.168.2.30", "10.0.0.0/24"}),
+				Service:   []v1beta2.Service{{Protocol: &tcpProtocol, Port: &port80}, {Protocol: &tcpProtocol, Port: &port1000, EndPort: &port1008}},
+				Action:    &allowAction,
+				FlowID:    uint32(101),
+				TableID:   EgressRuleTable,
+				PolicyRef: npRef,
+			},
+			estimatedRule: flowcost.Rule{
+				IsK8sNetworkPolicy: true,
+				Action:             &allowAction,
+				AppliedToAddresses: 2,
+				PeerAddresses:      4,
+				ServiceMatches:     flowcost.ServiceMatches(&port80, nil, 1) + flowcost.ServiceMatches(&port1000, &port1008, 1),
+				IPFamilies:         1,
+			},
+		},
+		{
+			name:      "dual-stack K8s NetworkPolicy rule",
+			dualStack: true,
+			rule: &types.PolicyRule{
+				Direction: v1beta2.DirectionOut,
+				From:      parseAddresses([]string{"192.168.1.30", "fd12:ab:34:a001::4"}),
+				To:        parseAddresses([]string{"192.168.2.0/24", "fd12:ab:34:a002::/64"}),
+				Service:   []v1beta2.Service{{Protocol: &tcpProtocol, Port: &port80}},
+				Action:    &allowAction,
+				FlowID:    uint32(102),
+				TableID:   EgressRuleTable,
+				PolicyRef: npRef,
+			},
+			estimatedRule: flowcost.Rule{
+				IsK8sNetworkPolicy: true,
+				Action:             &allowAction,
+				AppliedToAddresses: 2,
+				PeerAddresses:      2,
+				ServiceMatches:     flowcost.ServiceMatches(&port80, nil, 2),
+				IPFamilies:         2,
+			},
+		},
+		{
+			name: "Antrea NetworkPolicy drop rule",
+			rule: &types.PolicyRule{
+				Direction: v1beta2.DirectionOut,
+				From:      parseAddresses([]string{"192.168.1.30"}),
+				To:        parseAddresses([]string{"192.168.2.10", "192.168.2.20"}),
+				Action:    &dropAction,
+				Priority:  &priority,
+				FlowID:    uint32(103),
+				TableID:   AntreaPolicyEgressRuleTable,
+				PolicyRef: anpRef,
+			},
+			estimatedRule: flowcost.Rule{
+				Action:             &dropAction,
+				AppliedToAddresses: 1,
+				PeerAddresses:      2,
+				IPFamilies:         1,
+			},
+		},
+		{
+			name: "Antrea NetworkPolicy pass rule",
+			rule: &types.PolicyRule{
+				Direction: v1beta2.DirectionOut,
+				From:      parseAddresses([]string{"192.168.1.30"}),
+				To:        parseAddresses([]string{"192.168.2.10"}),
+				Action:    &passAction,
+				Priority:  &priority,
+				FlowID:    uint32(104),
+				TableID:   AntreaPolicyEgressRuleTable,
+				PolicyRef: anpRef,
+			},
+			estimatedRule: flowcost.Rule{
+				Action:             &passAction,
+				AppliedToAddresses: 1,
+				PeerAddresses:      1,
+				IPFamilies:         1,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			c = prepareClient(ctrl)
+			if tt.dualStack {
+				c.nodeConfig = &config.NodeConfig{PodIPv4CIDR: podIPv4CIDR, PodIPv6CIDR: podIPv6CIDR}
+				c.ipProtocols = []binding.Protocol{binding.ProtocolIP, binding.ProtocolIPv6}
+			} else {
+				c.nodeConfig = &config.NodeConfig{PodIPv4CIDR: podIPv4CIDR, PodIPv6CIDR: nil}
+				c.ipProtocols = []binding.Protocol{binding.ProtocolIP}
+			}
+			outDropTable.EXPECT().BuildFlow(gomock.Any()).Return(newMockDropFlowBuilder(ctrl)).AnyTimes()
+			ruleBuilder := newMockRuleFlowBuilder(ctrl)
+			outTable.EXPECT().BuildFlow(gomock.Any()).Return(ruleBuilder).AnyTimes()
+			cnpOutTable.EXPECT().BuildFlow(gomock.Any()).Return(ruleBuilder).AnyTimes()
+			ruleAction.EXPECT().Conjunction(gomock.Any(), gomock.Any(), gomock.Any()).Return(ruleBuilder).AnyTimes()
+			metricTable.EXPECT().BuildFlow(gomock.Any()).Return(newMockMetricFlowBuilder(ctrl)).AnyTimes()
+			metricFlowBuilder.EXPECT().MatchReg(gomock.Any(), gomock.Any()).Return(metricFlowBuilder).AnyTimes()
+			metricCtAction := mocks.NewMockCTAction(ctrl)
+			metricCtAction.EXPECT().LoadToLabelRange(gomock.Any(), gomock.Any()).Return(metricCtAction).AnyTimes()
+			metricCtAction.EXPECT().CTDone().Return(metricFlowBuilder).AnyTimes()
+			metricAction.EXPECT().CT(true, gomock.Any(), gomock.Any()).Return(metricCtAction).AnyTimes()
+
+			conj := c.calculateActionFlowChangesForRule(tt.rule)
+			require.NotNil(t, conj)
+			ctxChanges := c.calculateMatchFlowChangesForRule(conj, tt.rule, false)
+			matchFlows, dropFlows := getChangedFlows(ctxChanges)
+			actualFlows := len(conj.actionFlows) + len(conj.metricFlows) + getChangedFlowOPCount(matchFlows, insertion) + getChangedFlowOPCount(dropFlows, insertion)
+			assert.Equal(t, actualFlows, tt.estimatedRule.Flows())
+		})
+	}
+}
+
 func getChangedFlowCount(flows []*flowChange) int {
 	var count int
 	for _, changedFlow := range flows {
@@ -757,8 +973,10 @@ func prepareClient(ctrl *gomock.Controller) *client {
 		globalConjMatchFlowCache: map[string]*conjMatchFlowContext{},
 		bridge:                   bridge,
 		ovsDatapathType:          ovsconfig.OVSDatapathNetdev,
+		policyMetrics:            map[uint32]*ruleMetricCounters{},
+		pendingRuleUninstalls:    map[uint32]chan struct{}{},
 	}
-	c.cookieAllocator = cookie.NewAllocator(0)
+	c.cookieAllocator = cookie.NewAllocator(0, 0)
 	m := oftest.NewMockOFEntryOperations(ctrl)
 	m.EXPECT().AddAll(gomock.Any()).Return(nil).AnyTimes()
 	m.EXPECT().DeleteAll(gomock.Any()).Return(nil).AnyTimes()
@@ -766,6 +984,145 @@ func prepareClient(ctrl *gomock.Controller) *client {
 	return c
 }
 
+func TestUninstallPolicyRuleFlowsAsync(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	c = prepareClient(ctrl)
+	m := oftest.NewMockOFEntryOperations(ctrl)
+	c.ofEntryOperations = m
+	ruleID := uint32(10)
+	npRef := &v1beta2.NetworkPolicyReference{Type: v1beta2.K8sNetworkPolicy, Namespace: "ns1", Name: "np1", UID: "id1"}
+	require.NoError(t, c.policyCache.Add(&policyRuleConjunction{id: ruleID, npRef: npRef, ruleTableID: EgressRuleTable}))
+
+	// Block the deletion of the action flows until the pending uninstallation is checked.
+	unblockDeletion := make(chan struct{})
+	gomock.InOrder(
+		m.EXPECT().DeleteAll(gomock.Any()).DoAndReturn(func(flows []binding.Flow) error {
+			<-unblockDeletion
+			return nil
+		}),
+		m.EXPECT().DeleteAll(gomock.Any()).Return(nil),
+	)
+	resultCh := c.UninstallPolicyRuleFlowsAsync(ruleID)
+
+	// The flows of a rule with the same ID must not be installed before the uninstallation completes.
+	waited := make(chan struct{})
+	go func() {
+		c.waitForPolicyRuleUninstall(ruleID)
+		close(waited)
+	}()
+	select {
+	case <-waited:
+		t.Fatal("waitForPolicyRuleUninstall returned before the uninstallation completed")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(unblockDeletion)
+	select {
+	case result := <-resultCh:
+		assert.NoError(t, result.Err)
+		assert.Empty(t, result.StalePriorities)
+	case <-time.After(time.Second):
+		t.Fatal("Uninstallation did not complete")
+	}
+	select {
+	case <-waited:
+	case <-time.After(time.Second):
+		t.Fatal("waitForPolicyRuleUninstall did not return after the uninstallation completed")
+	}
+	assert.Nil(t, c.getPolicyRuleConjunction(ruleID))
+	assert.Empty(t, c.pendingRuleUninstalls)
+}
+
+func TestGetPolicyInfoFromConjunction(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	c = prepareClient(ctrl)
+	npRef := &v1beta2.NetworkPolicyReference{Type: v1beta2.AntreaNetworkPolicy, Namespace: "ns1", Name: "np1", UID: "id1"}
+	// Both rules of the policy share the same OpenFlow priority, only the rule name tells them apart.
+	for _, conj := range []*policyRuleConjunction{
+		{id: 10, npRef: npRef, ruleName: "allow-web", ruleTableID: AntreaPolicyIngressRuleTable},
+		{id: 11, npRef: npRef, ruleName: "ingress-1", ruleTableID: AntreaPolicyIngressRuleTable},
+		{id: 12, npRef: npRef, ruleName: "no-action-flow", ruleTableID: AntreaPolicyIngressRuleTable},
+	} {
+		if conj.ruleName != "no-action-flow" {
+			flow := mocks.NewMockFlow(ctrl)
+			flow.EXPECT().FlowPriority().Return(uint16(44900)).AnyTimes()
+			conj.actionFlows = []binding.Flow{flow}
+		}
+		require.NoError(t, c.policyCache.Add(conj))
+	}
+
+	tests := []struct {
+		ruleID             uint32
+		expectedNPRef      string
+		expectedOFPriority string
+		expectedRuleName   string
+	}{
+		{10, npRef.ToString(), "44900", "allow-web"},
+		{11, npRef.ToString(), "44900", "ingress-1"},
+		{12, "", "", ""},
+		{13, "", "", ""},
+	}
+	for _, tt := range tests {
+		npRefStr, ofPriority, ruleName := c.GetPolicyInfoFromConjunction(tt.ruleID)
+		assert.Equal(t, tt.expectedNPRef, npRefStr, "Unexpected NetworkPolicy reference for rule %d", tt.ruleID)
+		assert.Equal(t, tt.expectedOFPriority, ofPriority, "Unexpected OpenFlow priority for rule %d", tt.ruleID)
+		assert.Equal(t, tt.expectedRuleName, ruleName, "Unexpected rule name for rule %d", tt.ruleID)
+	}
+}
+
+func TestDefaultDropFlowK8sIsolationLogging(t *testing.T) {
+	tests := []struct {
+		name                string
+		enableDenyTracking  bool
+		enableIngress       bool
+		enableEgress        bool
+		metersAreSupported  bool
+		expectedReason      uint32
+		expectedMeterAction bool
+	}{
+		{name: "disabled"},
+		{name: "ingress only", enableIngress: true},
+		{name: "egress", enableEgress: true, expectedReason: CustomReasonLogging},
+		{name: "egress with meters", enableEgress: true, metersAreSupported: true, expectedReason: CustomReasonLogging, expectedMeterAction: true},
+		{name: "deny tracking", enableDenyTracking: true, metersAreSupported: true, expectedReason: CustomReasonDeny},
+		{name: "deny tracking and egress", enableDenyTracking: true, enableEgress: true, expectedReason: CustomReasonDeny | CustomReasonLogging},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			c = prepareClient(ctrl)
+			c.enableDenyTracking = tt.enableDenyTracking
+			c.ovsMetersAreSupported = tt.metersAreSupported
+			c.EnableK8sIsolationLogging(tt.enableIngress, tt.enableEgress)
+
+			fb := mocks.NewMockFlowBuilder(ctrl)
+			action := mocks.NewMockAction(ctrl)
+			outDropTable.EXPECT().BuildFlow(K8sIsolationDropPriority).Return(fb)
+			fb.EXPECT().MatchProtocol(binding.ProtocolIP).Return(fb)
+			fb.EXPECT().MatchSrcIP(net.ParseIP("192.168.1.1")).Return(fb)
+			fb.EXPECT().Action().Return(action).AnyTimes()
+			fb.EXPECT().Cookie(gomock.Any()).Return(fb)
+			fb.EXPECT().Done().Return(mocks.NewMockFlow(ctrl))
+			// The packets are always dropped.
+			action.EXPECT().Drop().Return(fb)
+			if tt.expectedReason != 0 {
+				action.EXPECT().LoadRegRange(int(marksReg), uint32(DispositionDrop), APDispositionMarkRange).Return(fb)
+				action.EXPECT().LoadRegRange(int(marksReg), tt.expectedReason, CustomReasonMarkRange).Return(fb)
+				action.EXPECT().SendToController(uint8(PacketInReasonNP)).Return(fb)
+			}
+			if tt.expectedMeterAction {
+				action.EXPECT().Meter(uint32(PacketInMeterIDNP)).Return(fb)
+			}
+			c.defaultDropFlow(EgressDefaultTable, MatchSrcIP, net.ParseIP("192.168.1.1"))
+		})
+	}
+}
+
 func TestParseMetricFlow(t *testing.T) {
 	for name, tc := range map[string]struct {
 		flow   string
@@ -782,7 +1139,7 @@ func TestParseMetricFlow(t *testing.T) {
 			},
 		},
 		"New allow flow": {
-			flow: "table=101, n_packets=123, n_bytes=456, priority=200,ct_state=+new,ct_label=0x112345678/0xffffffff00000000,ip actions=goto_table:105",
+			flow: "table=101, n_packets=123, n_bytes=456, priority=200,ct_state=+new,ct_label=0x100000000/0x2ffffffff00000000,ip actions=ct(commit,table=105,zone=65520,exec(load:0x1->NXM_NX_CT_LABEL[65]))",
 			rule: 1,
 			metric: types.RuleMetric{
 				Bytes:    456,
@@ -790,6 +1147,24 @@ func TestParseMetricFlow(t *testing.T) {
 				Sessions: 123,
 			},
 		},
+		"New ingress allow flow": {
+			flow: "table=101, n_packets=123, n_bytes=456, priority=200,ct_state=+new,ct_label=0x5/0x100000000ffffffff,ip actions=ct(commit,table=105,zone=65520,exec(load:0x1->NXM_NX_CT_LABEL[64]))",
+			rule: 5,
+			metric: types.RuleMetric{
+				Bytes:    456,
+				Packets:  123,
+				Sessions: 123,
+			},
+		},
+		"Retransmission allow flow": {
+			flow: "table=101, n_packets=123, n_bytes=456, priority=200,ct_state=+new,ct_label=0x20000000100000000/0x2ffffffff00000000,ip actions=goto_table:105",
+			rule: 1,
+			metric: types.RuleMetric{
+				Bytes:    456,
+				Packets:  123,
+				Sessions: 0,
+			},
+		},
 		"Following allow flow": {
 			flow: "table=101, n_packets=123, n_bytes=456, priority=200,ct_state=-new,ct_label=0x1/0xffffffff,ip actions=goto_table:105",
 			rule: 1,
@@ -820,30 +1195,34 @@ func TestNetworkPolicyMetrics(t *testing.T) {
 		{
 			name: "Normal flows",
 			egressFlows: []string{
-				"table=61, n_packets=1, n_bytes=74, priority=200,ct_state=+new,ct_label=0x200000000/0xffffffff00000000,ip actions=goto_table:70",
+				"table=61, n_packets=1, n_bytes=74, priority=200,ct_state=+new,ct_label=0x200000000/0x2ffffffff00000000,ip actions=ct(commit,table=70,zone=65520,exec(load:0x1->NXM_NX_CT_LABEL[65]))",
+				"table=61, n_packets=1, n_bytes=74, priority=200,ct_state=+new,ct_label=0x20000000200000000/0x2ffffffff00000000,ip actions=goto_table:70",
 				"table=61, n_packets=11, n_bytes=1661, priority=200,ct_state=-new,ct_label=0x200000000/0xffffffff00000000,ip actions=goto_table:70",
-				"table=61, n_packets=0, n_bytes=0, priority=200,ct_state=+new,ct_label=0x600000000/0xffffffff00000000,ip actions=goto_table:70",
+				"table=61, n_packets=0, n_bytes=0, priority=200,ct_state=+new,ct_label=0x600000000/0x2ffffffff00000000,ip actions=ct(commit,table=70,zone=65520,exec(load:0x1->NXM_NX_CT_LABEL[65]))",
+				"table=61, n_packets=0, n_bytes=0, priority=200,ct_state=+new,ct_label=0x20000000600000000/0x2ffffffff00000000,ip actions=goto_table:70",
 				"table=61, n_packets=0, n_bytes=0, priority=200,ct_state=-new,ct_label=0x600000000/0xffffffff00000000,ip actions=goto_table:70",
 				"table=61, n_packets=4, n_bytes=336, priority=200,reg0=0x100000/0x100000,reg3=0x4 actions=drop",
 				"table=61, n_packets=0, n_bytes=0, priority=200,reg0=0x100000/0x100000,reg3=0x8 actions=drop",
 				"table=61, n_packets=1502362, n_bytes=601635949, priority=0 actions=goto_table:70",
 			},
 			ingressFlows: []string{
-				"table=101, n_packets=1, n_bytes=74, priority=200,ct_state=+new,ct_label=0x1/0xffffffff,ip actions=resubmit(,105)",
+				"table=101, n_packets=1, n_bytes=74, priority=200,ct_state=+new,ct_label=0x1/0x100000000ffffffff,ip actions=ct(commit,table=105,zone=65520,exec(load:0x1->NXM_NX_CT_LABEL[64]))",
+				"table=101, n_packets=0, n_bytes=0, priority=200,ct_state=+new,ct_label=0x10000000000000001/0x100000000ffffffff,ip actions=resubmit(,105)",
 				"table=101, n_packets=11, n_bytes=1661, priority=200,ct_state=-new,ct_label=0x1/0xffffffff,ip actions=resubmit(,105)",
-				"table=101, n_packets=2, n_bytes=148, priority=200,ct_state=+new,ct_label=0x5/0xffffffff,ip actions=resubmit(,105)",
+				"table=101, n_packets=2, n_bytes=148, priority=200,ct_state=+new,ct_label=0x5/0x100000000ffffffff,ip actions=ct(commit,table=105,zone=65520,exec(load:0x1->NXM_NX_CT_LABEL[64]))",
+				"table=101, n_packets=3, n_bytes=222, priority=200,ct_state=+new,ct_label=0x10000000000000005/0x100000000ffffffff,ip actions=resubmit(,105)",
 				"table=101, n_packets=12, n_bytes=943, priority=200,ct_state=-new,ct_label=0x5/0xffffffff,ip actions=resubmit(,105)",
 				"table=101, n_packets=0, n_bytes=0, priority=200,reg0=0x100000/0x100000,reg3=0x3 actions=drop",
 				"table=101, n_packets=4, n_bytes=338, priority=200,reg0=0x100000/0x100000,reg3=0xb actions=drop",
 				"table=101, n_packets=1407190, n_bytes=509746586, priority=0 actions=resubmit(,105)",
 			},
 			want: map[uint32]*types.RuleMetric{
-				2:  {Bytes: 1735, Sessions: 1, Packets: 12},
+				2:  {Bytes: 1809, Sessions: 1, Packets: 13},
 				6:  {Bytes: 0, Sessions: 0, Packets: 0},
 				4:  {Bytes: 336, Sessions: 4, Packets: 4},
 				8:  {Bytes: 0, Sessions: 0, Packets: 0},
 				1:  {Bytes: 1735, Sessions: 1, Packets: 12},
-				5:  {Bytes: 1091, Sessions: 2, Packets: 14},
+				5:  {Bytes: 1313, Sessions: 2, Packets: 17},
 				3:  {Bytes: 0, Sessions: 0, Packets: 0},
 				11: {Bytes: 338, Sessions: 4, Packets: 4},
 			},
@@ -853,9 +1232,11 @@ func TestNetworkPolicyMetrics(t *testing.T) {
 			egressFlows: []string{
 				"table=61, n_packets=0, n_bytes=0, hard_timeout=300, priority=202,ip,reg0=0x100000/0x100000,reg3=0x4,nw_tos=28 actions=controller(max_len=128,id=15768)",
 				"table=61, n_packets=0, n_bytes=0, hard_timeout=300, priority=202,ip,reg0=0x100000/0x100000,reg3=0x8,nw_tos=28 actions=controller(max_len=128,id=15768)",
-				"table=61, n_packets=1, n_bytes=74, priority=200,ct_state=+new,ct_label=0x200000000/0xffffffff00000000,ip actions=goto_table:70",
+				"table=61, n_packets=1, n_bytes=74, priority=200,ct_state=+new,ct_label=0x200000000/0x2ffffffff00000000,ip actions=ct(commit,table=70,zone=65520,exec(load:0x1->NXM_NX_CT_LABEL[65]))",
+				"table=61, n_packets=1, n_bytes=74, priority=200,ct_state=+new,ct_label=0x20000000200000000/0x2ffffffff00000000,ip actions=goto_table:70",
 				"table=61, n_packets=11, n_bytes=1661, priority=200,ct_state=-new,ct_label=0x200000000/0xffffffff00000000,ip actions=goto_table:70",
-				"table=61, n_packets=0, n_bytes=0, priority=200,ct_state=+new,ct_label=0x600000000/0xffffffff00000000,ip actions=goto_table:70",
+				"table=61, n_packets=0, n_bytes=0, priority=200,ct_state=+new,ct_label=0x600000000/0x2ffffffff00000000,ip actions=ct(commit,table=70,zone=65520,exec(load:0x1->NXM_NX_CT_LABEL[65]))",
+				"table=61, n_packets=0, n_bytes=0, priority=200,ct_state=+new,ct_label=0x20000000600000000/0x2ffffffff00000000,ip actions=goto_table:70",
 				"table=61, n_packets=0, n_bytes=0, priority=200,ct_state=-new,ct_label=0x600000000/0xffffffff00000000,ip actions=goto_table:70",
 				"table=61, n_packets=4, n_bytes=336, priority=200,reg0=0x100000/0x100000,reg3=0x4 actions=drop",
 				"table=61, n_packets=0, n_bytes=0, priority=200,reg0=0x100000/0x100000,reg3=0x8 actions=drop",
@@ -864,21 +1245,23 @@ func TestNetworkPolicyMetrics(t *testing.T) {
 			ingressFlows: []string{
 				"table=101, n_packets=0, n_bytes=0, hard_timeout=300, priority=202,ip,reg0=0x100000/0x100000,reg3=0x3,nw_tos=28 actions=controller(max_len=128,id=15768)",
 				"table=101, n_packets=0, n_bytes=0, hard_timeout=300, priority=202,ip,reg0=0x100000/0x100000,reg3=0xb,nw_tos=28 actions=controller(max_len=128,id=15768)",
-				"table=101, n_packets=1, n_bytes=74, priority=200,ct_state=+new,ct_label=0x1/0xffffffff,ip actions=resubmit(,105)",
+				"table=101, n_packets=1, n_bytes=74, priority=200,ct_state=+new,ct_label=0x1/0x100000000ffffffff,ip actions=ct(commit,table=105,zone=65520,exec(load:0x1->NXM_NX_CT_LABEL[64]))",
+				"table=101, n_packets=0, n_bytes=0, priority=200,ct_state=+new,ct_label=0x10000000000000001/0x100000000ffffffff,ip actions=resubmit(,105)",
 				"table=101, n_packets=11, n_bytes=1661, priority=200,ct_state=-new,ct_label=0x1/0xffffffff,ip actions=resubmit(,105)",
-				"table=101, n_packets=2, n_bytes=148, priority=200,ct_state=+new,ct_label=0x5/0xffffffff,ip actions=resubmit(,105)",
+				"table=101, n_packets=2, n_bytes=148, priority=200,ct_state=+new,ct_label=0x5/0x100000000ffffffff,ip actions=ct(commit,table=105,zone=65520,exec(load:0x1->NXM_NX_CT_LABEL[64]))",
+				"table=101, n_packets=3, n_bytes=222, priority=200,ct_state=+new,ct_label=0x10000000000000005/0x100000000ffffffff,ip actions=resubmit(,105)",
 				"table=101, n_packets=12, n_bytes=943, priority=200,ct_state=-new,ct_label=0x5/0xffffffff,ip actions=resubmit(,105)",
 				"table=101, n_packets=0, n_bytes=0, priority=200,reg0=0x100000/0x100000,reg3=0x3 actions=drop",
 				"table=101, n_packets=4, n_bytes=338, priority=200,reg0=0x100000/0x100000,reg3=0xb actions=drop",
 				"table=101, n_packets=1407190, n_bytes=509746586, priority=0 actions=resubmit(,105)",
 			},
 			want: map[uint32]*types.RuleMetric{
-				2:  {Bytes: 1735, Sessions: 1, Packets: 12},
+				2:  {Bytes: 1809, Sessions: 1, Packets: 13},
 				6:  {Bytes: 0, Sessions: 0, Packets: 0},
 				4:  {Bytes: 336, Sessions: 4, Packets: 4},
 				8:  {Bytes: 0, Sessions: 0, Packets: 0},
 				1:  {Bytes: 1735, Sessions: 1, Packets: 12},
-				5:  {Bytes: 1091, Sessions: 2, Packets: 14},
+				5:  {Bytes: 1313, Sessions: 2, Packets: 17},
 				3:  {Bytes: 0, Sessions: 0, Packets: 0},
 				11: {Bytes: 338, Sessions: 4, Packets: 4},
 			},
@@ -901,3 +1284,36 @@ func TestNetworkPolicyMetrics(t *testing.T) {
 		})
 	}
 }
+
+func TestNetworkPolicyMetricsAfterFlowsReinstalled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	c = prepareClient(ctrl)
+	mockOVSClient := ovsctltest.NewMockOVSCtlClient(ctrl)
+	c.ovsctlClient = mockOVSClient
+	ingressFlows := func(sessions, packets, bytes int) []string {
+		return []string{
+			fmt.Sprintf("table=101, n_packets=%d, n_bytes=%d, priority=200,ct_state=+new,ct_label=0x1/0x100000000ffffffff,ip actions=ct(commit,table=105,zone=65520,exec(load:0x1->NXM_NX_CT_LABEL[64]))", sessions, sessions*74),
+			fmt.Sprintf("table=101, n_packets=%d, n_bytes=%d, priority=200,ct_state=-new,ct_label=0x1/0xffffffff,ip actions=resubmit(,105)", packets-sessions, bytes-sessions*74),
+		}
+	}
+	expectDump := func(flows []string) {
+		gomock.InOrder(
+			mockOVSClient.EXPECT().DumpTableFlows(uint8(EgressMetricTable)).Return(nil, nil),
+			mockOVSClient.EXPECT().DumpTableFlows(uint8(IngressMetricTable)).Return(flows, nil),
+		)
+	}
+
+	expectDump(ingressFlows(2, 10, 1000))
+	assert.Equal(t, map[uint32]*types.RuleMetric{1: {Bytes: 1000, Sessions: 2, Packets: 10}}, c.NetworkPolicyMetrics())
+	// The flows are reinstalled and their counters restart from zero.
+	expectDump(ingressFlows(1, 3, 200))
+	assert.Equal(t, map[uint32]*types.RuleMetric{1: {Bytes: 1200, Sessions: 3, Packets: 13}}, c.NetworkPolicyMetrics())
+	expectDump(ingressFlows(2, 5, 400))
+	assert.Equal(t, map[uint32]*types.RuleMetric{1: {Bytes: 1400, Sessions: 4, Packets: 15}}, c.NetworkPolicyMetrics())
+	// A new rule reusing the ID of a deleted rule doesn't inherit its metrics.
+	c.forgetPolicyMetrics(1)
+	expectDump(ingressFlows(1, 3, 200))
+	assert.Equal(t, map[uint32]*types.RuleMetric{1: {Bytes: 200, Sessions: 1, Packets: 3}}, c.NetworkPolicyMetrics())
+}
@@ -35,20 +35,67 @@ const (
 	// Meter Entry ID.
 	PacketInMeterIDNP = 1
 	PacketInMeterIDTF = 2
+	PacketInMeterIDPC = 3
+	// PacketInMeterIDNDGuard is used for the Neighbor Discovery messages dropped by ndGuardFlows.
+	PacketInMeterIDNDGuard = 4
+	// PacketInMeterIDDHCP is used for the DHCP replies sent to the controller by dhcpFlows.
+	PacketInMeterIDDHCP = 5
+	// PacketInMeterIDServiceLoop is used for the packets dropped by serviceLoopGuardFlow.
+	PacketInMeterIDServiceLoop = 6
+	// PacketInMeterIDServiceSourceRange is used for the packets dropped by serviceSourceRangeFlows.
+	PacketInMeterIDServiceSourceRange = 7
 	// Meter Entry Rate. It is represented as number of events per second.
 	// Packets which exceed the rate will be dropped.
-	PacketInMeterRateNP = 100
-	PacketInMeterRateTF = 100
+	PacketInMeterRateNP      = 100
+	PacketInMeterRateTF      = 100
+	PacketInMeterRatePC      = 100
+	PacketInMeterRateNDGuard = 100
+	PacketInMeterRateDHCP    = 100
+	// The packets dropped by serviceLoopGuardFlow are only counted and logged, so a lower rate is
+	// enough.
+	PacketInMeterRateServiceLoop = 10
+	// The packets dropped by serviceSourceRangeFlows are only counted, but they are expected
+	// whenever a client outside of the source ranges accesses the Service.
+	PacketInMeterRateServiceSourceRange = 100
 
 	// PacketIn reasons
 	PacketInReasonTF ofpPacketInReason = 1
 	PacketInReasonNP ofpPacketInReason = 0
+	// PacketInReasonND is used for IPv6 Neighbor Solicitations answered by the agent in
+	// policy-only mode. Reason 2 is skipped as OVS uses it for packets with an invalid TTL.
+	PacketInReasonND ofpPacketInReason = 3
+	// PacketInReasonPMTU is used for packets which are too large to be output to the tunnel port,
+	// for which the agent sends ICMP Fragmentation Needed / ICMPv6 Packet Too Big messages.
+	PacketInReasonPMTU ofpPacketInReason = 4
+	// PacketInReasonPC is used for packets captured by PacketCapture requests.
+	PacketInReasonPC ofpPacketInReason = 5
+	// PacketInReasonNDGuard is used for the Neighbor Discovery messages sent by local Pods and
+	// dropped by ndGuardFlows, which are counted and logged by ndGuardHandler.
+	PacketInReasonNDGuard ofpPacketInReason = 6
+	// PacketInReasonDHCP is used for the DHCP replies sent to local Pods which obtain their
+	// addresses using DHCP, from which the agent learns the leased addresses.
+	PacketInReasonDHCP ofpPacketInReason = 7
+	// PacketInReasonServiceLoop is used for the packets accessing a Service which are dropped by
+	// serviceLoopGuardFlow, which are counted and logged by serviceLoopGuardHandler.
+	PacketInReasonServiceLoop ofpPacketInReason = 8
+	// PacketInReasonServiceSourceRange is used for the packets accessing a Service IP from a source
+	// outside of the source ranges of the Service, which are dropped by serviceSourceRangeFlows and
+	// counted by serviceSourceRangeHandler.
+	PacketInReasonServiceSourceRange ofpPacketInReason = 9
+	// PacketInMaxLenReject is the maximum number of bytes of the packets rejected by NetworkPolicy
+	// rules sent in the packet-in, instead of the default 128 bytes, so that the ICMP reject
+	// responses can quote the whole IP header, options and extension headers included, and the
+	// first 8 bytes of the payload of the original packet.
+	PacketInMaxLenReject uint16 = 512
 	// PacketInQueueSize defines the size of PacketInQueue.
 	// When PacketInQueue reaches PacketInQueueSize, new packet-in will be dropped.
 	PacketInQueueSize = 200
 	// PacketInQueueRate defines the maximum frequency of getting items from PacketInQueue.
 	// PacketInQueueRate is represented as number of events per second.
 	PacketInQueueRate = 100
+	// PacketInQueueShrunkSize is the maximum number of packets in each PacketInQueue when the
+	// queues are shrunk by ShrinkPacketInQueues.
+	PacketInQueueShrunkSize = 20
 )
 
 // RegisterPacketInHandler stores controller handler in a map of map with reason and name as keys.
@@ -90,10 +137,30 @@ func (c *client) StartPacketInHandler(packetInStartedReason []uint8, stopCh <-ch
 		err := c.subscribeFeaturePacketIn(featurePacketIn)
 		if err != nil {
 			klog.Errorf("received error %+v while subscribing packetin for each feature", err)
+			continue
 		}
+		c.packetInQueuesMutex.Lock()
+		c.packetInQueues = append(c.packetInQueues, featurePacketIn.packetInQueue)
+		c.packetInQueuesMutex.Unlock()
 	}
 }
 
+// ShrinkPacketInQueues limits the number of packets in each packet-in queue to
+// PacketInQueueShrunkSize if shrink is true, or restores the size of the queues otherwise.
+func (c *client) ShrinkPacketInQueues(shrink bool) int {
+	limit := PacketInQueueSize
+	if shrink {
+		limit = PacketInQueueShrunkSize
+	}
+	c.packetInQueuesMutex.Lock()
+	defer c.packetInQueuesMutex.Unlock()
+	dropped := 0
+	for _, queue := range c.packetInQueues {
+		dropped += queue.SetLimit(limit)
+	}
+	return dropped
+}
+
 func (c *client) subscribeFeaturePacketIn(featurePacketIn *featureStartPacketIn) error {
 	err := c.SubscribePacketIn(featurePacketIn.reason, featurePacketIn.packetInQueue)
 	if err != nil {
@@ -25,6 +25,7 @@ import (
 
 	"github.com/contiv/libOpenflow/protocol"
 	"github.com/contiv/ofnet/ofctrl"
+	"golang.org/x/time/rate"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/klog/v2"
 
@@ -32,6 +33,7 @@ import (
 	"antrea.io/antrea/pkg/agent/metrics"
 	"antrea.io/antrea/pkg/agent/openflow/cookie"
 	"antrea.io/antrea/pkg/agent/types"
+	"antrea.io/antrea/pkg/features"
 	binding "antrea.io/antrea/pkg/ovs/openflow"
 	"antrea.io/antrea/pkg/ovs/ovsconfig"
 	"antrea.io/antrea/pkg/ovs/ovsctl"
@@ -43,7 +45,10 @@ const (
 	// Flow table id index
 	ClassifierTable              binding.TableIDType = 0
 	uplinkTable                  binding.TableIDType = 5
+	dhcpTable                    binding.TableIDType = 8
 	spoofGuardTable              binding.TableIDType = 10
+	AntreaPolicyARPRuleTable     binding.TableIDType = 18
+	AntreaPolicyNDRuleTable      binding.TableIDType = 19
 	arpResponderTable            binding.TableIDType = 20
 	ipv6Table                    binding.TableIDType = 21
 	serviceHairpinTable          binding.TableIDType = 29
@@ -69,6 +74,7 @@ const (
 	IngressMetricTable           binding.TableIDType = 101
 	conntrackCommitTable         binding.TableIDType = 105
 	hairpinSNATTable             binding.TableIDType = 106
+	connectionLimitTable         binding.TableIDType = 108
 	L2ForwardingOutTable         binding.TableIDType = 110
 
 	// Flow priority level
@@ -77,6 +83,9 @@ const (
 	priorityLow             = uint16(190)
 	priorityMiss            = uint16(0)
 	priorityTopAntreaPolicy = uint16(64990)
+	// K8sIsolationDropPriority is the priority of the flows dropping the packets isolated by K8s
+	// NetworkPolicies.
+	K8sIsolationDropPriority = priorityNormal
 
 	// Index for priority cache
 	priorityIndex = "priority"
@@ -91,6 +100,12 @@ const (
 	ipv6MulticastAddr = "FF00::/8"
 	// IPv6 link-local prefix
 	ipv6LinkLocalAddr = "FE80::/10"
+
+	// UDP ports of DHCP clients and servers.
+	dhcpClientPort   = uint16(68)
+	dhcpServerPort   = uint16(67)
+	dhcpv6ClientPort = uint16(546)
+	dhcpv6ServerPort = uint16(547)
 )
 
 type ofAction int32
@@ -118,54 +133,86 @@ var (
 	// egressTables map records all IDs of tables related to
 	// egress rules.
 	egressTables = map[binding.TableIDType]struct{}{
+		AntreaPolicyARPRuleTable:    {},
+		AntreaPolicyNDRuleTable:     {},
 		AntreaPolicyEgressRuleTable: {},
 		EgressRuleTable:             {},
 		EgressDefaultTable:          {},
 	}
 
-	FlowTables = []struct {
-		Number binding.TableIDType
-		Name   string
-	}{
-		{ClassifierTable, "Classification"},
-		{uplinkTable, "Uplink"},
-		{spoofGuardTable, "SpoofGuard"},
-		{arpResponderTable, "ARPResponder"},
-		{ipv6Table, "IPv6"},
-		{serviceHairpinTable, "ServiceHairpin"},
-		{conntrackTable, "ConntrackZone"},
-		{conntrackStateTable, "ConntrackState"},
-		{dnatTable, "DNAT(SessionAffinity)"},
-		{sessionAffinityTable, "SessionAffinity"},
-		{serviceLBTable, "ServiceLB"},
-		{endpointDNATTable, "EndpointDNAT"},
-		{AntreaPolicyEgressRuleTable, "AntreaPolicyEgressRule"},
-		{EgressRuleTable, "EgressRule"},
-		{EgressDefaultTable, "EgressDefaultRule"},
-		{EgressMetricTable, "EgressMetric"},
-		{l3ForwardingTable, "L3Forwarding"},
-		{snatTable, "SNAT"},
-		{l3DecTTLTable, "IPTTLDec"},
-		{l2ForwardingCalcTable, "L2Forwarding"},
-		{AntreaPolicyIngressRuleTable, "AntreaPolicyIngressRule"},
-		{IngressRuleTable, "IngressRule"},
-		{IngressDefaultTable, "IngressDefaultRule"},
-		{IngressMetricTable, "IngressMetric"},
-		{conntrackCommitTable, "ConntrackCommit"},
-		{hairpinSNATTable, "HairpinSNATTable"},
-		{L2ForwardingOutTable, "Output"},
+	// FlowTables is the registry of all the tables of the pipeline, ordered by table number. The
+	// table names used in antctl, Traceflow results and audit logs are derived from it.
+	FlowTables = []FlowTable{
+		{ClassifierTable, "Classification", "Classify packets by their input port", featureCore},
+		{uplinkTable, "Uplink", "Forward packets received from the uplink", featureCore},
+		{dhcpTable, "DHCP", "Forward the DHCP messages of Pods obtaining their addresses using DHCP", featureCore},
+		{spoofGuardTable, "SpoofGuard", "Drop packets with spoofed IP or MAC addresses", featureCore},
+		{AntreaPolicyARPRuleTable, "AntreaPolicyARPRule", "Enforce layer 2 rules of Antrea-native policies for ARP", featureAntreaPolicy},
+		{AntreaPolicyNDRuleTable, "AntreaPolicyNDRule", "Enforce layer 2 rules of Antrea-native policies for IPv6 Neighbor Discovery", featureAntreaPolicy},
+		{arpResponderTable, "ARPResponder", "Reply to ARP requests for remote gateways and virtual IPs", featureCore},
+		{ipv6Table, "IPv6", "Handle IPv6 Neighbor Discovery and multicast packets", featureCore},
+		{serviceHairpinTable, "ServiceHairpin", "Mark hairpin Service traffic", featureAntreaProxy},
+		{conntrackTable, "ConntrackZone", "Send packets to the conntrack zone", featureCore},
+		{conntrackStateTable, "ConntrackState", "Handle packets based on their conntrack state", featureCore},
+		{dnatTable, "DNAT(SessionAffinity)", "Forward Service traffic to the gateway, or learn Service session affinity when AntreaProxy is enabled", featureCore},
+		{sessionAffinityTable, "SessionAffinity", "Learn Service session affinity", featureAntreaProxy},
+		{serviceLBTable, "ServiceLB", "Select an Endpoint for Service traffic", featureAntreaProxy},
+		{endpointDNATTable, "EndpointDNAT", "Perform DNAT to the selected Endpoint", featureAntreaProxy},
+		{AntreaPolicyEgressRuleTable, "AntreaPolicyEgressRule", "Enforce egress rules of Antrea-native policies", featureAntreaPolicy},
+		{EgressRuleTable, "EgressRule", "Enforce egress rules of K8s NetworkPolicies", featureNetworkPolicy},
+		{EgressDefaultTable, "EgressDefaultRule", "Drop egress traffic of isolated Pods", featureNetworkPolicy},
+		{EgressMetricTable, "EgressMetric", "Collect egress NetworkPolicy stats", featureNetworkPolicy},
+		{l3ForwardingTable, "L3Forwarding", "Route packets based on their destination IP", featureCore},
+		{snatTable, "SNAT", "Perform SNAT for Egress traffic", featureEgress},
+		{l3DecTTLTable, "IPTTLDec", "Decrement the TTL of routed packets", featureCore},
+		{l2ForwardingCalcTable, "L2Forwarding", "Select the output port based on the destination MAC", featureCore},
+		{AntreaPolicyIngressRuleTable, "AntreaPolicyIngressRule", "Enforce ingress rules of Antrea-native policies", featureAntreaPolicy},
+		{IngressRuleTable, "IngressRule", "Enforce ingress rules of K8s NetworkPolicies", featureNetworkPolicy},
+		{IngressDefaultTable, "IngressDefaultRule", "Drop ingress traffic of isolated Pods", featureNetworkPolicy},
+		{IngressMetricTable, "IngressMetric", "Collect ingress NetworkPolicy stats", featureNetworkPolicy},
+		{conntrackCommitTable, "ConntrackCommit", "Commit new connections to conntrack", featureCore},
+		{hairpinSNATTable, "HairpinSNATTable", "Perform SNAT for hairpin Service traffic", featureAntreaProxy},
+		{connectionLimitTable, "ConnectionLimit", "Track the connections of the Pods with a connection limit", featureCore},
+		{L2ForwardingOutTable, "Output", "Output packets to the selected port", featureCore},
 	}
 )
 
-// GetFlowTableName returns the flow table name given the table number. An empty
-// string is returned if the table cannot be found.
-func GetFlowTableName(tableNumber binding.TableIDType) string {
+// Features which own tables of the pipeline. featureCore represents the tables which are always
+// part of the pipeline.
+const (
+	featureCore          = "Core"
+	featureNetworkPolicy = "NetworkPolicy"
+	featureAntreaProxy   = string(features.AntreaProxy)
+	featureAntreaPolicy  = string(features.AntreaPolicy)
+	featureEgress        = string(features.Egress)
+)
+
+// FlowTable describes a table of the pipeline.
+type FlowTable struct {
+	Number binding.TableIDType
+	Name   string
+	// Purpose is a short description of what the table does.
+	Purpose string
+	// Feature is the feature which owns the table.
+	Feature string
+}
+
+// GetFlowTable returns the flow table registered with the given table number,
+// and a boolean value indicating whether it is found.
+func GetFlowTable(tableNumber binding.TableIDType) (FlowTable, bool) {
 	for _, t := range FlowTables {
 		if t.Number == tableNumber {
-			return t.Name
+			return t, true
 		}
 	}
-	return ""
+	return FlowTable{}, false
+}
+
+// GetFlowTableName returns the flow table name given the table number. An empty
+// string is returned if the table cannot be found.
+func GetFlowTableName(tableNumber binding.TableIDType) string {
+	t, _ := GetFlowTable(tableNumber)
+	return t.Name
 }
 
 // GetFlowTableNumber does a case insensitive lookup of the table name, and
@@ -208,6 +255,15 @@ func GetAntreaPolicyMultiTierTables() []binding.TableIDType {
 	}
 }
 
+// GetAntreaPolicyLayer2Tables returns the tables enforcing the layer 2 rules of
+// ClusterNetworkPolicies, which are shared by all the Tiers.
+func GetAntreaPolicyLayer2Tables() []binding.TableIDType {
+	return []binding.TableIDType{
+		AntreaPolicyARPRuleTable,
+		AntreaPolicyNDRuleTable,
+	}
+}
+
 type regType uint
 
 func (rt regType) number() string {
@@ -224,7 +280,8 @@ func (rt regType) reg() string {
 
 const (
 	// marksReg stores traffic-source mark and pod-found mark.
-	// traffic-source resides in [0..15], pod-found resides in [16], Antrea Policy disposition in [21-22], Custom Reasons in [24-26]
+	// traffic-source resides in [0..15], pod-found resides in [16], Antrea Policy disposition in [21-22], Custom Reasons in [24-26],
+	// Service Endpoint reselection count in [29-30]
 	marksReg        regType = 0
 	PortCacheReg    regType = 1
 	swapReg         regType = 2
@@ -256,6 +313,15 @@ const (
 	macRewriteMark = 0b1
 	// cnpDenyMark indicates the packet is denied(Drop/Reject).
 	cnpDenyMark = 0b1
+	// pmtuCheckedMark indicates the size of the packet has been checked against the tunnel MTU.
+	pmtuCheckedMark = 0b1
+	// pmtuPktLargerMark indicates the packet is too large to be output to the tunnel port.
+	pmtuPktLargerMark = 0b1
+
+	// maxServiceReselections is the number of times the Endpoint of a packet accessing a Service can
+	// be reselected before the packet is dropped by serviceLoopGuardFlow. It must fit in
+	// serviceReselectCountRange.
+	maxServiceReselections = 3
 
 	// gatewayCTMark is used to to mark connections initiated through the host gateway interface
 	// (i.e. for which the first packet of the connection was received through the gateway).
@@ -265,9 +331,10 @@ const (
 	// disposition is loaded in marksReg [21-22]
 	DispositionMarkReg regType = 0
 	// disposition marks the flow action
-	DispositionAllow = 0b00
-	DispositionDrop  = 0b01
-	DispositionRej   = 0b10
+	DispositionAllow  = 0b00
+	DispositionDrop   = 0b01
+	DispositionReject = 0b10
+	DispositionPass   = 0b11
 
 	// custom reason is loaded in marksReg [24-26]
 	// The custom reason mark is used to indicate the reason(s) for sending the packet
@@ -292,10 +359,21 @@ const (
 	CustomReasonDeny = 0b100
 )
 
-var DispositionToString = map[uint32]string{
-	DispositionAllow: "Allow",
-	DispositionDrop:  "Drop",
-	DispositionRej:   "Reject",
+var dispositionNames = map[uint32]string{
+	DispositionAllow:  "Allow",
+	DispositionDrop:   "Drop",
+	DispositionReject: "Reject",
+	DispositionPass:   "Pass",
+}
+
+// DispositionToString returns the name of the disposition loaded in APDispositionMarkRange.
+// A value unknown to this version of the agent, e.g. one introduced by a newer version, is
+// returned as "Unknown(<value>)" instead of an empty string.
+func DispositionToString(disposition uint32) string {
+	if name, ok := dispositionNames[disposition]; ok {
+		return name
+	}
+	return fmt.Sprintf("Unknown(%d)", disposition)
 }
 
 var (
@@ -323,6 +401,15 @@ var (
 	// the reason of sending packet to the controller. It could have more bits to
 	// support more customReason in the future.
 	CustomReasonMarkRange = binding.Range{24, 26}
+	// pmtuCheckedMarkRange takes the 27th bit of register marksReg to indicate if the
+	// size of the packet has been checked against the tunnel MTU. Its value is 0x1 if yes.
+	pmtuCheckedMarkRange = binding.Range{27, 27}
+	// pmtuPktLargerMarkRange takes the 28th bit of register marksReg to store the result
+	// of the check. Its value is 0x1 if the packet is larger than the tunnel MTU.
+	pmtuPktLargerMarkRange = binding.Range{28, 28}
+	// serviceReselectCountRange takes the 29 to 30 bits of register marksReg to count how many times
+	// the Endpoint of a packet accessing a Service has been reselected, see serviceReselectFlows.
+	serviceReselectCountRange = binding.Range{29, 30}
 	// endpointIPRegRange takes a 32-bit range of register endpointIPReg to store
 	// the selected Service Endpoint IP.
 	endpointIPRegRange = binding.Range{0, 31}
@@ -340,6 +427,12 @@ var (
 	metricIngressRuleIDRange = binding.Range{0, 31}
 	// metricEgressRuleIDRange takes 32..63 range of ct_label to store the egress rule ID.
 	metricEgressRuleIDRange = binding.Range{32, 63}
+	// metricIngressSessionRange takes the 64th bit of ct_label to mark the connections which have been counted as a
+	// session of the ingress rule.
+	metricIngressSessionRange = binding.Range{64, 64}
+	// metricEgressSessionRange takes the 65th bit of ct_label to mark the connections which have been counted as a
+	// session of the egress rule.
+	metricEgressSessionRange = binding.Range{65, 65}
 
 	// traceflowTagToSRange stores Traceflow dataplane tag to DSCP bits of
 	// IP header ToS field.
@@ -390,15 +483,22 @@ type client struct {
 	bridge             binding.Bridge
 	egressEntryTable   binding.TableIDType
 	ingressEntryTable  binding.TableIDType
+	arpEntryTable      binding.TableIDType
+	ipv6EntryTable     binding.TableIDType
 	pipeline           map[binding.TableIDType]binding.Table
 	// Flow caches for corresponding deletions.
-	nodeFlowCache, podFlowCache, serviceFlowCache, snatFlowCache, tfFlowCache *flowCategoryCache
+	nodeFlowCache, podFlowCache, serviceFlowCache, snatFlowCache, tfFlowCache, pcFlowCache *flowCategoryCache
 	// "fixed" flows installed by the agent after initialization and which do not change during
 	// the lifetime of the client.
 	gatewayFlows, defaultServiceFlows, defaultTunnelFlows, hostNetworkingFlows []binding.Flow
+	// policyBootstrapFlows are the flows installed by InstallPolicyBootstrapFlows, until they are uninstalled.
+	policyBootstrapFlows []binding.Flow
 	// ofEntryOperations is a wrapper interface for OpenFlow entry Add / Modify / Delete operations. It
 	// enables convenient mocking in unit tests.
 	ofEntryOperations OFEntryOperations
+	// flowChangeRecorder records the latest flow changes for debugging. It is nil if flow change
+	// tracking is not enabled.
+	flowChangeRecorder *flowChangeRecorder
 	// policyCache is a storage that supports listing policyRuleConjunction with different indexers.
 	// It's guaranteed that one policyRuleConjunction is processed by at most one goroutine at any given time.
 	policyCache       cache.Indexer
@@ -416,13 +516,42 @@ type client struct {
 	ovsDatapathType ovsconfig.OVSDatapathType
 	// ovsMetersAreSupported indicates whether the OVS datapath supports OpenFlow meters.
 	ovsMetersAreSupported bool
+	// enableK8sIngressIsolationLogging and enableK8sEgressIsolationLogging indicate whether the
+	// packets dropped by the isolation of K8s NetworkPolicies are logged, see
+	// EnableK8sIsolationLogging.
+	enableK8sIngressIsolationLogging bool
+	enableK8sEgressIsolationLogging  bool
+	// enableNDGuard and enableNDGuardLogging indicate whether the Neighbor Discovery messages sent
+	// by local Pods are checked, and whether the dropped messages are logged, see ConfigureNDGuard.
+	enableNDGuard        bool
+	enableNDGuardLogging bool
+	// ipAnnouncementLimiter rate-limits the announcements of the Pod IPs which move to this Node.
+	ipAnnouncementLimiter *rate.Limiter
+	// capabilities are the OpenFlow version, the OVS version and the optional OVS capabilities
+	// detected when connecting to the switch.
+	capabilities binding.Capabilities
 	// packetInHandlers stores handler to process PacketIn event. Each packetin reason can have multiple handlers registered.
 	// When a packetin arrives, openflow send packet to registered handlers in this map.
 	packetInHandlers map[uint8]map[string]PacketInHandler
+	// packetInQueues are the queues of the packet-in reasons started by StartPacketInHandler,
+	// protected by packetInQueuesMutex.
+	packetInQueues      []*binding.PacketInQueue
+	packetInQueuesMutex sync.Mutex
 	// Supported IP Protocols (IP or IPv6) on the current Node.
 	ipProtocols []binding.Protocol
 	// ovsctlClient is the interface for executing OVS "ovs-ofctl" and "ovs-appctl" commands.
 	ovsctlClient ovsctl.OVSCtlClient
+	// podConnectionLimits stores the connection limits of the local Pods installed by
+	// InstallPodConnectionLimitFlows, keyed by interface name.
+	podConnectionLimits      map[string]*podConnectionLimit
+	podConnectionLimitsMutex sync.Mutex
+	// policyMetrics stores the counters of the metric flows of each rule, keyed by rule ID.
+	policyMetrics     map[uint32]*ruleMetricCounters
+	policyMetricsLock sync.Mutex
+	// pendingRuleUninstalls stores the rules being uninstalled by UninstallPolicyRuleFlowsAsync. It's a
+	// mapping from rule ID to a channel which is closed once the uninstallation completes.
+	pendingRuleUninstalls     map[uint32]chan struct{}
+	pendingRuleUninstallsLock sync.Mutex
 }
 
 func (c *client) GetTunnelVirtualMAC() net.HardwareAddr {
@@ -578,15 +707,15 @@ func (c *client) podClassifierFlow(podOFPort uint32, category cookie.Category) b
 }
 
 // connectionTrackFlows generates flows that redirect traffic to ct_zone and handle traffic according to ct_state:
-// 1) commit new connections to ct_zone(0xfff0) in the conntrackCommitTable.
-// 2) Add ct_mark on the packet if it is sent to the switch from the host gateway.
-// 3) Allow traffic if it hits ct_mark and is sent from the host gateway.
-// 4) Drop all invalid traffic.
-// 5) Let other traffic go to the sessionAffinityTable first and then the serviceLBTable.
-//    The sessionAffinityTable is a side-effect table which means traffic will not
-//    be resubmitted to any table. serviceLB does Endpoint selection for traffic
-//    to a Service.
-// 6) Add a flow to bypass reject response packet sent by the controller.
+//  1. commit new connections to ct_zone(0xfff0) in the conntrackCommitTable.
+//  2. Add ct_mark on the packet if it is sent to the switch from the host gateway.
+//  3. Allow traffic if it hits ct_mark and is sent from the host gateway.
+//  4. Drop all invalid traffic.
+//  5. Let other traffic go to the sessionAffinityTable first and then the serviceLBTable.
+//     The sessionAffinityTable is a side-effect table which means traffic will not
+//     be resubmitted to any table. serviceLB does Endpoint selection for traffic
+//     to a Service.
+//  6. Add a flow to bypass reject response packet sent by the controller.
 func (c *client) connectionTrackFlows(category cookie.Category) []binding.Flow {
 	connectionTrackTable := c.pipeline[conntrackTable]
 	connectionTrackStateTable := c.pipeline[conntrackStateTable]
@@ -947,6 +1076,21 @@ func (c *client) l2ForwardCalcFlow(dstMAC net.HardwareAddr, ofPort uint32, skipI
 	// the default flow of L2ForwardingOutTable.
 }
 
+// l2ForwardCalcFlowToTunnelPeer generates the flow that matches the tunnel destination of the
+// packets to a remote Node, and loads the OFPort of the tunnel port dedicated to the remote Node
+// to reg. It takes precedence over the flow which loads the default tunnel port.
+func (c *client) l2ForwardCalcFlowToTunnelPeer(tunnelPeer net.IP, tunOFPort uint32, category cookie.Category) binding.Flow {
+	l2FwdCalcTable := c.pipeline[l2ForwardingCalcTable]
+	return l2FwdCalcTable.BuildFlow(priorityNormal+1).
+		MatchDstMAC(globalVirtualMAC).
+		MatchTunnelDst(tunnelPeer).
+		Action().LoadRegRange(int(PortCacheReg), tunOFPort, ofPortRegRange).
+		Action().LoadRegRange(int(marksReg), portFoundMark, ofPortMarkRange).
+		Action().GotoTable(l2FwdCalcTable.GetNext()).
+		Cookie(c.cookieAllocator.Request(category).Raw()).
+		Done()
+}
+
 // traceflowL2ForwardOutputFlows generates Traceflow specific flows that outputs traceflow packets
 // to OVS port and Antrea Agent after L2forwarding calculation.
 func (c *client) traceflowL2ForwardOutputFlows(dataplaneTag uint8, liveTraffic, droppedOnly bool, timeout uint16, category cookie.Category) []binding.Flow {
@@ -1079,6 +1223,45 @@ func (c *client) traceflowL2ForwardOutputFlows(dataplaneTag uint8, liveTraffic,
 	return flows
 }
 
+// packetCaptureL2ForwardOutputFlows generates the flows that send a copy of the packets matching a
+// PacketCapture request to the controller, and output them to the OVS port after L2 forwarding
+// calculation. The flows have a higher priority than the default output flows and a lower priority
+// than the Traceflow flows, so packets sent by a Traceflow request are not captured.
+func (c *client) packetCaptureL2ForwardOutputFlows(packet *binding.Packet, timeout uint16, category cookie.Category) []binding.Flow {
+	ipProtocol := binding.ProtocolIP
+	switch packet.IPProto {
+	case protocol.Type_TCP:
+		ipProtocol = binding.ProtocolTCP
+	case protocol.Type_UDP:
+		ipProtocol = binding.ProtocolUDP
+	case protocol.Type_ICMP:
+		ipProtocol = binding.ProtocolICMP
+	}
+	fb := c.pipeline[L2ForwardingOutTable].BuildFlow(priorityNormal+1).
+		MatchProtocol(ipProtocol).
+		MatchRegRange(int(marksReg), portFoundMark, ofPortMarkRange).
+		SetHardTimeout(timeout).
+		Cookie(c.cookieAllocator.Request(category).Raw())
+	if packet.SourceIP != nil {
+		fb = fb.MatchSrcIP(packet.SourceIP)
+	}
+	if packet.DestinationIP != nil {
+		fb = fb.MatchDstIP(packet.DestinationIP)
+	}
+	if packet.SourcePort != 0 {
+		fb = fb.MatchSrcPort(packet.SourcePort, nil)
+	}
+	if packet.DestinationPort != 0 {
+		fb = fb.MatchDstPort(packet.DestinationPort, nil)
+	}
+	if c.ovsMetersAreSupported {
+		fb = fb.Action().Meter(PacketInMeterIDPC)
+	}
+	fb = fb.Action().SendToController(uint8(PacketInReasonPC)).
+		Action().OutputRegRange(int(PortCacheReg), ofPortRegRange)
+	return []binding.Flow{fb.Done()}
+}
+
 // l2ForwardOutputServiceHairpinFlow uses in_port action for Service
 // hairpin packets to avoid packets from being dropped by OVS.
 func (c *client) l2ForwardOutputServiceHairpinFlow() binding.Flow {
@@ -1107,6 +1290,41 @@ func (c *client) l2ForwardOutputFlows(category cookie.Category) []binding.Flow {
 	return flows
 }
 
+// tunnelPMTUFlows generates the flows to send the packets received from the gateway which are too large to be
+// output to the tunnel port to the controller, so that pmtuResponder can reply to the sender with an ICMP
+// Fragmentation Needed or an ICMPv6 Packet Too Big message. Otherwise these packets would be dropped silently after
+// encapsulation. As OpenFlow cannot match the packet length, two flows are generated for each IP family:
+//  1. a flow storing the result of check_pkt_larger in marksReg and resubmitting the packet to L2ForwardingOutTable,
+//     which marks the packet as checked to avoid matching the flow again;
+//  2. a flow sending the checked packets which are too large to the controller.
+//
+// The other packets are output by the flows generated by l2ForwardOutputFlows.
+func (c *client) tunnelPMTUFlows(tunnelOFPort uint32, category cookie.Category) []binding.Flow {
+	// The length checked by check_pkt_larger includes the Ethernet header.
+	maxPktLen := uint16(c.nodeConfig.NodeMTU + ethernetHeaderLen)
+	var flows []binding.Flow
+	for _, ipProtocol := range c.ipProtocols {
+		flows = append(flows,
+			c.pipeline[L2ForwardingOutTable].BuildFlow(priorityHigh).MatchProtocol(ipProtocol).
+				MatchRegRange(int(marksReg), markTrafficFromGateway, binding.Range{0, 15}).
+				MatchRegRange(int(marksReg), portFoundMark, ofPortMarkRange).
+				MatchRegRange(int(PortCacheReg), tunnelOFPort, ofPortRegRange).
+				MatchRegRange(int(marksReg), 0, pmtuCheckedMarkRange).
+				Action().LoadRegRange(int(marksReg), pmtuCheckedMark, pmtuCheckedMarkRange).
+				Action().CheckPktLarger(maxPktLen, int(marksReg), pmtuPktLargerMarkRange[0]).
+				Action().ResubmitToTable(L2ForwardingOutTable).
+				Cookie(c.cookieAllocator.Request(category).Raw()).
+				Done(),
+			c.pipeline[L2ForwardingOutTable].BuildFlow(priorityHigh).MatchProtocol(ipProtocol).
+				MatchRegRange(int(marksReg), pmtuPktLargerMark, pmtuPktLargerMarkRange).
+				Action().SendToController(uint8(PacketInReasonPMTU)).
+				Cookie(c.cookieAllocator.Request(category).Raw()).
+				Done(),
+		)
+	}
+	return flows
+}
+
 // l3FwdFlowToPod generates the L3 forward flows for traffic from tunnel to a
 // local Pod. It rewrites the destination MAC (should be globalVirtualMAC) to
 // the Pod interface MAC, and rewrites the source MAC to the gateway interface
@@ -1279,6 +1497,18 @@ func (c *client) arpResponderStaticFlow(category cookie.Category) binding.Flow {
 
 }
 
+// ndResponderFlow generates the flow to send IPv6 Neighbor Solicitations to the controller, so that
+// they are answered with the global virtual MAC by ndpResponder. This flow is the IPv6 counterpart of
+// arpResponderStaticFlow and is used in policy-only mode.
+func (c *client) ndResponderFlow(category cookie.Category) binding.Flow {
+	return c.pipeline[ipv6Table].BuildFlow(priorityHigh).MatchProtocol(binding.ProtocolICMPv6).
+		MatchICMPv6Type(icmpv6NeighborSolicitationType).
+		MatchICMPv6Code(0).
+		Action().SendToController(uint8(PacketInReasonND)).
+		Cookie(c.cookieAllocator.Request(category).Raw()).
+		Done()
+}
+
 // podIPSpoofGuardFlow generates the flow to check IP traffic sent out from local pod. Traffic from host gateway interface
 // will not be checked, since it might be pod to service traffic or host namespace traffic.
 func (c *client) podIPSpoofGuardFlow(ifIPs []net.IP, ifMAC net.HardwareAddr, ifOFPort uint32, category cookie.Category) []binding.Flow {
@@ -1300,7 +1530,7 @@ func (c *client) podIPSpoofGuardFlow(ifIPs []net.IP, ifMAC net.HardwareAddr, ifO
 				MatchInPort(ifOFPort).
 				MatchSrcMAC(ifMAC).
 				MatchSrcIP(ifIP).
-				Action().GotoTable(ipv6Table).
+				Action().GotoTable(c.ipv6EntryTable).
 				Cookie(c.cookieAllocator.Request(category).Raw()).
 				Done())
 		}
@@ -1344,7 +1574,7 @@ func (c *client) gatewayARPSpoofGuardFlow(gatewayIP net.IP, gatewayMAC net.Hardw
 		MatchInPort(config.HostGatewayOFPort).
 		MatchARPSha(gatewayMAC).
 		MatchARPSpa(gatewayIP).
-		Action().GotoTable(arpResponderTable).
+		Action().GotoTable(c.arpEntryTable).
 		Cookie(c.cookieAllocator.Request(category).Raw()).
 		Done()
 }
@@ -1355,21 +1585,157 @@ func (c *client) arpSpoofGuardFlow(ifIP net.IP, ifMAC net.HardwareAddr, ifOFPort
 		MatchInPort(ifOFPort).
 		MatchARPSha(ifMAC).
 		MatchARPSpa(ifIP).
-		Action().GotoTable(arpResponderTable).
+		Action().GotoTable(c.arpEntryTable).
 		Cookie(c.cookieAllocator.Request(category).Raw()).
 		Done()
 }
 
-// sessionAffinityReselectFlow generates the flow which resubmits the service accessing
-// packet back to serviceLBTable if there is no endpointDNAT flow matched. This
-// case will occur if an Endpoint is removed and is the learned Endpoint
-// selection of the Service.
-func (c *client) sessionAffinityReselectFlow() binding.Flow {
-	return c.pipeline[endpointDNATTable].BuildFlow(priorityLow).
+// ndGuardFlows generates the flows to check the IPv6 Neighbor Discovery messages sent out from local Pod interfaces.
+// It is the IPv6 counterpart of arpSpoofGuardFlow: Router Advertisements are dropped, and Neighbor Advertisements
+// are dropped unless they are sent from the Pod's MAC, for one of the Pod's IPv6 addresses or for a link-local
+// address. The dropped messages are sent to the controller to be counted and logged.
+func (c *client) ndGuardFlows(ifIPs []net.IP, ifMAC net.HardwareAddr, ifOFPort uint32, category cookie.Category) []binding.Flow {
+	ipSpoofGuardTable := c.pipeline[spoofGuardTable]
+	_, ipv6LinkLocalIpnet, _ := net.ParseCIDR(ipv6LinkLocalAddr)
+	targets := []net.IPNet{*ipv6LinkLocalIpnet}
+	for _, ifIP := range ifIPs {
+		if ifIP.To4() == nil {
+			targets = append(targets, net.IPNet{IP: ifIP, Mask: net.CIDRMask(128, 128)})
+		}
+	}
+	var flows []binding.Flow
+	for _, target := range targets {
+		flows = append(flows, ipSpoofGuardTable.BuildFlow(priorityHigh).MatchProtocol(binding.ProtocolICMPv6).
+			MatchInPort(ifOFPort).
+			MatchSrcMAC(ifMAC).
+			MatchICMPv6Type(icmpv6NeighborAdvertisementType).
+			MatchICMPv6Code(0).
+			MatchNDTarget(target).
+			Action().GotoTable(c.ipv6EntryTable).
+			Cookie(c.cookieAllocator.Request(category).Raw()).
+			Done())
+	}
+	for _, icmp6Type := range []uint8{icmpv6RouterAdvertisementType, icmpv6NeighborAdvertisementType} {
+		flowBuilder := ipSpoofGuardTable.BuildFlow(ndGuardDropPriority).MatchProtocol(binding.ProtocolICMPv6).
+			MatchInPort(ifOFPort).
+			MatchICMPv6Type(icmp6Type)
+		if c.ovsMetersAreSupported {
+			flowBuilder = flowBuilder.Action().Meter(PacketInMeterIDNDGuard)
+		}
+		flows = append(flows, flowBuilder.Action().SendToController(uint8(PacketInReasonNDGuard)).
+			Cookie(c.cookieAllocator.Request(category).Raw()).
+			Done())
+	}
+	return flows
+}
+
+// dhcpFlows generates the flows to forward the DHCP messages of a local Pod which obtains its addresses using DHCP.
+// The DHCP messages sent by the Pod don't have a source address yet, and are broadcast or multicast to the DHCP
+// servers, so they bypass the SpoofGuardTable and are forwarded by the normal L2 processing of OVS. The replies
+// of the DHCP servers, which are unicast to the MAC of the Pod, are output to the Pod and sent to the controller
+// as well, so that the agent can learn the leased addresses and bind them to the Pod.
+func (c *client) dhcpFlows(ifMAC net.HardwareAddr, ifOFPort uint32, category cookie.Category) []binding.Flow {
+	dhcpFlowTable := c.pipeline[dhcpTable]
+	var flows []binding.Flow
+	for _, dhcp := range []struct {
+		protocol   binding.Protocol
+		clientPort uint16
+		serverPort uint16
+	}{
+		{binding.ProtocolUDP, dhcpClientPort, dhcpServerPort},
+		{binding.ProtocolUDPv6, dhcpv6ClientPort, dhcpv6ServerPort},
+	} {
+		flows = append(flows, dhcpFlowTable.BuildFlow(priorityNormal).MatchProtocol(dhcp.protocol).
+			MatchInPort(ifOFPort).
+			MatchSrcMAC(ifMAC).
+			MatchSrcPort(dhcp.clientPort, nil).
+			MatchDstPort(dhcp.serverPort, nil).
+			Action().Normal().
+			Cookie(c.cookieAllocator.Request(category).Raw()).
+			Done())
+		flowBuilder := dhcpFlowTable.BuildFlow(priorityNormal).MatchProtocol(dhcp.protocol).
+			MatchDstMAC(ifMAC).
+			MatchSrcPort(dhcp.serverPort, nil).
+			MatchDstPort(dhcp.clientPort, nil)
+		if c.ovsMetersAreSupported {
+			flowBuilder = flowBuilder.Action().Meter(PacketInMeterIDDHCP)
+		}
+		flows = append(flows, flowBuilder.Action().SendToController(uint8(PacketInReasonDHCP)).
+			Action().Output(int(ifOFPort)).
+			Cookie(c.cookieAllocator.Request(category).Raw()).
+			Done())
+	}
+	return flows
+}
+
+// podConnectionLimitFlows generates the flows to track the connections initiated by a local Pod in the conntrack
+// zone of the Pod, whose number of entries is limited by the datapath: the first packet of a new connection is
+// dropped when the connection cannot be committed because the zone is full. The following packets of the
+// connections, and the reply packets sent to the Pod, are tracked in the zone as well, so that the closed
+// connections leave the zone as soon as conntrack expires them instead of being counted until the timeout of
+// unreplied connections. The connections initiated by the peers of the Pod are not tracked in the zone.
+func (c *client) podConnectionLimitFlows(ifOFPort uint32, zone int, category cookie.Category) []binding.Flow {
+	connectionLimitFlowTable := c.pipeline[connectionLimitTable]
+	var flows []binding.Flow
+	for _, proto := range c.ipProtocols {
+		flows = append(flows,
+			connectionLimitFlowTable.BuildFlow(priorityNormal).MatchProtocol(proto).
+				MatchInPort(ifOFPort).
+				MatchCTStateNew(true).MatchCTStateTrk(true).
+				Action().CT(true, connectionLimitFlowTable.GetNext(), zone).CTDone().
+				Cookie(c.cookieAllocator.Request(category).Raw()).
+				Done(),
+			connectionLimitFlowTable.BuildFlow(priorityNormal).MatchProtocol(proto).
+				MatchInPort(ifOFPort).
+				MatchCTStateNew(false).MatchCTStateRpl(false).MatchCTStateTrk(true).
+				Action().CT(false, connectionLimitFlowTable.GetNext(), zone).CTDone().
+				Cookie(c.cookieAllocator.Request(category).Raw()).
+				Done(),
+			connectionLimitFlowTable.BuildFlow(priorityNormal).MatchProtocol(proto).
+				MatchRegRange(int(marksReg), portFoundMark, ofPortMarkRange).
+				MatchRegRange(int(PortCacheReg), ifOFPort, ofPortRegRange).
+				MatchCTStateRpl(true).MatchCTStateTrk(true).
+				Action().CT(false, connectionLimitFlowTable.GetNext(), zone).CTDone().
+				Cookie(c.cookieAllocator.Request(category).Raw()).
+				Done(),
+		)
+	}
+	return flows
+}
+
+// serviceReselectFlows generate the flows which resubmit the Service accessing packets back to
+// serviceLBTable if there is no endpointDNAT flow matched. This case will occur if an Endpoint is
+// removed and is the learned Endpoint selection of the Service. The reselections of a packet are
+// counted in serviceReselectCountRange, and there is no flow for the last count, so that a packet
+// selecting Endpoints without endpointDNAT flows, e.g. because of stale Service groups, cannot loop
+// between serviceLBTable and endpointDNATTable: it is dropped by serviceLoopGuardFlow instead.
+func (c *client) serviceReselectFlows() []binding.Flow {
+	var flows []binding.Flow
+	for count := uint32(0); count < maxServiceReselections; count++ {
+		flows = append(flows, c.pipeline[endpointDNATTable].BuildFlow(priorityLow).
+			MatchRegRange(int(serviceLearnReg), marksRegServiceSelected, serviceLearnRegRange).
+			MatchRegRange(int(marksReg), count, serviceReselectCountRange).
+			Action().LoadRegRange(int(serviceLearnReg), marksRegServiceNeedLB, serviceLearnRegRange).
+			Action().LoadRegRange(int(marksReg), count+1, serviceReselectCountRange).
+			Action().ResubmitToTable(serviceLBTable).
+			Cookie(c.cookieAllocator.Request(cookie.Service).Raw()).
+			Done())
+	}
+	return flows
+}
+
+// serviceLoopGuardFlow generates the flow which drops the Service accessing packets whose Endpoint
+// has been reselected maxServiceReselections times without matching an endpointDNAT flow. The
+// dropped packets are sent to the controller to be counted and logged.
+func (c *client) serviceLoopGuardFlow(category cookie.Category) binding.Flow {
+	flowBuilder := c.pipeline[endpointDNATTable].BuildFlow(priorityLow).
 		MatchRegRange(int(serviceLearnReg), marksRegServiceSelected, serviceLearnRegRange).
-		Action().LoadRegRange(int(serviceLearnReg), marksRegServiceNeedLB, serviceLearnRegRange).
-		Action().ResubmitToTable(serviceLBTable).
-		Cookie(c.cookieAllocator.Request(cookie.Service).Raw()).
+		MatchRegRange(int(marksReg), maxServiceReselections, serviceReselectCountRange)
+	if c.ovsMetersAreSupported {
+		flowBuilder = flowBuilder.Action().Meter(PacketInMeterIDServiceLoop)
+	}
+	return flowBuilder.Action().SendToController(uint8(PacketInReasonServiceLoop)).
+		Cookie(c.cookieAllocator.Request(category).Raw()).
 		Done()
 }
 
@@ -1381,7 +1747,7 @@ func (c *client) gatewayIPSpoofGuardFlows(category cookie.Category) []binding.Fl
 	for _, proto := range c.ipProtocols {
 		nextTable := ipSpoofGuardTable.GetNext()
 		if proto == binding.ProtocolIPv6 {
-			nextTable = ipv6Table
+			nextTable = c.ipv6EntryTable
 		}
 		flows = append(flows,
 			ipSpoofGuardTable.BuildFlow(priorityNormal).MatchProtocol(proto).
@@ -1412,6 +1778,36 @@ func (c *client) serviceCIDRDNATFlows(serviceCIDRs []*net.IPNet) []binding.Flow
 	return flows
 }
 
+// serviceGatewayFlows generates the flows for the traffic sent by the Node network to the Service
+// CIDR advertised through the host gateway, which is routed to the gateway with VirtualServiceIPv4
+// as the next hop:
+//  1. an ARP responder flow resolving VirtualServiceIPv4 to the global virtual MAC, so that the
+//     packets enter the AntreaProxy pipeline and are load-balanced like the ones from local Pods.
+//  2. a flow outputting the load-balanced packets back to the gateway (with the in_port action)
+//     when the selected Endpoint must be reached through the gateway, i.e. when it runs on another
+//     Node. The same flow applies to the reply packets of these connections.
+//
+// Only IPv4 Service CIDRs are supported.
+func (c *client) serviceGatewayFlows(serviceCIDRs []*net.IPNet) []binding.Flow {
+	for _, serviceCIDR := range serviceCIDRs {
+		if serviceCIDR == nil || serviceCIDR.IP.To4() == nil {
+			continue
+		}
+		return []binding.Flow{
+			c.arpResponderFlow(config.VirtualServiceIPv4, cookie.Service),
+			c.pipeline[L2ForwardingOutTable].BuildFlow(priorityHigh).MatchProtocol(binding.ProtocolIP).
+				MatchInPort(config.HostGatewayOFPort).
+				MatchRegRange(int(PortCacheReg), config.HostGatewayOFPort, ofPortRegRange).
+				MatchCTMark(ServiceCTMark, nil).
+				Action().SetSrcMAC(globalVirtualMAC).
+				Action().OutputInPort().
+				Cookie(c.cookieAllocator.Request(cookie.Service).Raw()).
+				Done(),
+		}
+	}
+	return nil
+}
+
 // serviceNeedLBFlow generates flows to mark packets as LB needed.
 func (c *client) serviceNeedLBFlow() binding.Flow {
 	return c.pipeline[sessionAffinityTable].BuildFlow(priorityMiss).
@@ -1432,29 +1828,52 @@ func (c *client) allowRulesMetricFlows(conjunctionID uint32, ingress bool) []bin
 	metricTableID := IngressMetricTable
 	offset := 0
 	// We use the 0..31 bits of the ct_label to store the ingress rule ID and use the 32..63 bits to store the
-	// egress rule ID.
+	// egress rule ID. The 64th and 65th bits mark the connections already counted as a session of the ingress and
+	// egress rule respectively.
 	labelRange := metricIngressRuleIDRange
+	sessionRange := metricIngressSessionRange
 	if !ingress {
 		metricTableID = EgressMetricTable
 		offset = 32
 		labelRange = metricEgressRuleIDRange
+		sessionRange = metricEgressSessionRange
 	}
-	metricFlow := func(isCTNew bool, protocol binding.Protocol) binding.Flow {
+	nextTable := c.pipeline[metricTableID].GetNext()
+	metricFlowBuilder := func(isCTNew bool, protocol binding.Protocol) binding.FlowBuilder {
 		return c.pipeline[metricTableID].BuildFlow(priorityNormal).
 			MatchProtocol(protocol).
 			MatchCTStateNew(isCTNew).
 			MatchCTLabelRange(0, uint64(conjunctionID)<<offset, labelRange).
-			Action().GotoTable(c.pipeline[metricTableID].GetNext()).
-			Cookie(c.cookieAllocator.Request(cookie.Policy).Raw()).
-			Done()
+			Cookie(c.cookieAllocator.Request(cookie.Policy).Raw())
 	}
 	var flows []binding.Flow
-	// These two flows track the number of sessions in addition to the packet and byte counts.
-	// The flow matching 'ct_state=+new' tracks the number of sessions and byte count of the first packet for each
-	// session.
+	// These three flows track the number of sessions in addition to the packet and byte counts.
+	// The flow matching 'ct_state=+new' and the connections not marked yet tracks the number of sessions and byte
+	// count of the first packet for each session. It marks the connection, so that the retransmissions of the first
+	// packet, which are still 'ct_state=+new' until a reply is seen, are not counted as new sessions.
+	// The flow matching 'ct_state=+new' and the marked connections tracks the byte/packet count of these
+	// retransmissions.
 	// The flow matching 'ct_state=-new' tracks the byte/packet count of an established connection (both directions).
 	for _, proto := range c.ipProtocols {
-		flows = append(flows, metricFlow(true, proto), metricFlow(false, proto))
+		ctZone := CtZone
+		if proto == binding.ProtocolIPv6 {
+			ctZone = CtZoneV6
+		}
+		flows = append(flows,
+			metricFlowBuilder(true, proto).
+				MatchCTLabelRange(0, 0, sessionRange).
+				Action().CT(true, nextTable, ctZone).
+				LoadToLabelRange(1, &sessionRange).
+				CTDone().
+				Done(),
+			metricFlowBuilder(true, proto).
+				MatchCTLabelRange(uint64(1)<<(sessionRange[0]-64), 0, sessionRange).
+				Action().GotoTable(nextTable).
+				Done(),
+			metricFlowBuilder(false, proto).
+				Action().GotoTable(nextTable).
+				Done(),
+		)
 	}
 	return flows
 }
@@ -1484,7 +1903,7 @@ func (c *client) ipv6Flows(category cookie.Category) []binding.Flow {
 		// so that these packets will not be dropped.
 		c.pipeline[spoofGuardTable].BuildFlow(priorityNormal).MatchProtocol(binding.ProtocolIPv6).
 			MatchSrcIPNet(*ipv6LinkLocalIpnet).
-			Action().GotoTable(ipv6Table).
+			Action().GotoTable(c.ipv6EntryTable).
 			Cookie(c.cookieAllocator.Request(category).Raw()).
 			Done(),
 		// Handle IPv6 Neighbor Solicitation and Neighbor Advertisement as a regular L2 learning Switch by using normal.
@@ -1583,25 +2002,28 @@ func (c *client) conjunctionActionDenyFlow(conjunctionID uint32, tableID binding
 	var customReason int
 	if c.enableDenyTracking {
 		customReason += CustomReasonDeny
-		flowBuilder = flowBuilder.
-			Action().LoadRegRange(int(marksReg), disposition, APDispositionMarkRange)
 	}
 	if enableLogging {
 		customReason += CustomReasonLogging
-		flowBuilder = flowBuilder.
-			Action().LoadRegRange(int(marksReg), disposition, APDispositionMarkRange)
 	}
-	if disposition == DispositionRej {
+	if disposition == DispositionReject {
 		customReason += CustomReasonReject
 	}
 
-	if enableLogging || c.enableDenyTracking || disposition == DispositionRej {
+	if customReason != 0 {
 		if c.ovsMetersAreSupported {
 			flowBuilder = flowBuilder.Action().Meter(PacketInMeterIDNP)
 		}
+		// The disposition is loaded for every packet-in, so that the controller never reads
+		// the default value (Allow) for a denied packet.
 		flowBuilder = flowBuilder.
-			Action().LoadRegRange(int(marksReg), uint32(customReason), CustomReasonMarkRange).
-			Action().SendToController(uint8(PacketInReasonNP))
+			Action().LoadRegRange(int(marksReg), disposition, APDispositionMarkRange).
+			Action().LoadRegRange(int(marksReg), uint32(customReason), CustomReasonMarkRange)
+		if disposition == DispositionReject {
+			flowBuilder = flowBuilder.Action().SendToControllerWithMaxLen(uint8(PacketInReasonNP), PacketInMaxLenReject)
+		} else {
+			flowBuilder = flowBuilder.Action().SendToController(uint8(PacketInReasonNP))
+		}
 	}
 
 	// We do not drop the packet immediately but send the packet to the metric table to update the rule metrics.
@@ -1610,6 +2032,33 @@ func (c *client) conjunctionActionDenyFlow(conjunctionID uint32, tableID binding
 		Done()
 }
 
+// conjunctionActionPassFlow generates the flow to make the packets matching the conjunction skip
+// the remaining Antrea-native policy rules of the table, so that they are evaluated by the K8s
+// NetworkPolicy rules, and then by the baseline Tier rules. The connections are not committed, so
+// that the next stages decide whether they are allowed.
+func (c *client) conjunctionActionPassFlow(conjunctionID uint32, tableID binding.TableIDType, nextTable binding.TableIDType, priority *uint16, enableLogging bool) binding.Flow {
+	ofPriority := *priority
+	conjReg := IngressReg
+	if _, ok := egressTables[tableID]; ok {
+		conjReg = EgressReg
+	}
+	flowBuilder := c.pipeline[tableID].BuildFlow(ofPriority).
+		MatchConjID(conjunctionID).
+		Action().LoadRegRange(int(conjReg), conjunctionID, binding.Range{0, 31}) // Traceflow.
+	if enableLogging {
+		if c.ovsMetersAreSupported {
+			flowBuilder = flowBuilder.Action().Meter(PacketInMeterIDNP)
+		}
+		flowBuilder = flowBuilder.
+			Action().LoadRegRange(int(marksReg), DispositionPass, APDispositionMarkRange).
+			Action().LoadRegRange(int(marksReg), CustomReasonLogging, CustomReasonMarkRange).
+			Action().SendToController(uint8(PacketInReasonNP))
+	}
+	return flowBuilder.Action().GotoTable(nextTable).
+		Cookie(c.cookieAllocator.Request(cookie.Policy).Raw()).
+		Done()
+}
+
 func (c *client) Disconnect() error {
 	return c.bridge.Disconnect()
 }
@@ -1672,6 +2121,75 @@ func (c *client) establishedConnectionFlows(category cookie.Category) (flows []b
 	return allEstFlows
 }
 
+// bootstrapDenyFlows generates the flows dropping the new connections from and to local Pods, except the connections
+// with the peers in allowlist. They are installed in the default tables with a lower priority than the default drop
+// flows of NetworkPolicies, so that they only apply to the traffic not allowed by the NetworkPolicy rules, and the
+// rules can be enforced before the flows are removed without any connection allowed by the rules being dropped in
+// the meantime. Established connections skip the rule and default tables, hence they are not affected, and neither
+// are the liveness probes sent by kubelet, which bypass the ingress rules.
+func (c *client) bootstrapDenyFlows(allowlist []types.PolicyBootstrapPeer, category cookie.Category) []binding.Flow {
+	egressDefaultTable := c.pipeline[EgressDefaultTable]
+	ingressDefaultTable := c.pipeline[IngressDefaultTable]
+	// The ingress traffic to these ports is not destined to a local Pod.
+	nonPodOFPorts := []uint32{config.DefaultTunOFPort, config.HostGatewayOFPort}
+	if runtime.IsWindowsPlatform() {
+		nonPodOFPorts = append(nonPodOFPorts, config.UplinkOFPort)
+	}
+	var flows []binding.Flow
+	for _, ipProtocol := range c.ipProtocols {
+		flows = append(flows,
+			egressDefaultTable.BuildFlow(priorityLow).
+				MatchProtocol(ipProtocol).
+				MatchRegRange(int(marksReg), markTrafficFromLocal, binding.Range{0, 15}).
+				Action().Drop().
+				Cookie(c.cookieAllocator.Request(category).Raw()).
+				Done(),
+			ingressDefaultTable.BuildFlow(priorityLow).
+				MatchProtocol(ipProtocol).
+				MatchRegRange(int(marksReg), portFoundMark, ofPortMarkRange).
+				Action().Drop().
+				Cookie(c.cookieAllocator.Request(category).Raw()).
+				Done(),
+		)
+		for _, ofPort := range nonPodOFPorts {
+			flows = append(flows, ingressDefaultTable.BuildFlow(priorityLow+1).
+				MatchProtocol(ipProtocol).
+				MatchReg(int(PortCacheReg), ofPort).
+				Action().GotoTable(ingressDefaultTable.GetNext()).
+				Cookie(c.cookieAllocator.Request(category).Raw()).
+				Done())
+		}
+	}
+	for _, peer := range allowlist {
+		isIPv6 := peer.IPNet.IP.To4() == nil
+		protocol := getIPProtocol(peer.IPNet.IP)
+		if peer.Port != 0 {
+			protocol = getServiceMatchType(&peer.Protocol, !isIPv6, isIPv6)[0].GetOFProtocol()
+		}
+		egressFlowBuilder := egressDefaultTable.BuildFlow(priorityLow+1).
+			MatchProtocol(protocol).
+			MatchRegRange(int(marksReg), markTrafficFromLocal, binding.Range{0, 15}).
+			MatchDstIPNet(peer.IPNet)
+		ingressFlowBuilder := ingressDefaultTable.BuildFlow(priorityLow+1).
+			MatchProtocol(protocol).
+			MatchRegRange(int(marksReg), portFoundMark, ofPortMarkRange).
+			MatchSrcIPNet(peer.IPNet)
+		if peer.Port != 0 {
+			egressFlowBuilder = egressFlowBuilder.MatchDstPort(peer.Port, nil)
+			ingressFlowBuilder = ingressFlowBuilder.MatchDstPort(peer.Port, nil)
+		}
+		flows = append(flows,
+			egressFlowBuilder.Action().GotoTable(egressDefaultTable.GetNext()).
+				Cookie(c.cookieAllocator.Request(category).Raw()).
+				Done(),
+			ingressFlowBuilder.Action().GotoTable(ingressDefaultTable.GetNext()).
+				Cookie(c.cookieAllocator.Request(category).Raw()).
+				Done(),
+		)
+	}
+	return flows
+}
+
 func (c *client) addFlowMatch(fb binding.FlowBuilder, matchKey *types.MatchKey, matchValue interface{}) binding.FlowBuilder {
 	switch matchKey {
 	case MatchDstOFPort:
@@ -1746,31 +2264,74 @@ func (c *client) conjunctiveMatchFlow(tableID binding.TableIDType, matchKey *typ
 	return fb.Cookie(c.cookieAllocator.Request(cookie.Policy).Raw()).Done()
 }
 
-// defaultDropFlow generates the flow to drop packets if the match condition is matched.
+// defaultDropFlow generates the flow to drop packets if the match condition is matched. The packets
+// are sent to the controller as well if deny tracking is enabled, or if the logging of the packets
+// dropped by K8s NetworkPolicy isolation is enabled for the direction of the table.
 func (c *client) defaultDropFlow(tableID binding.TableIDType, matchKey *types.MatchKey, matchValue interface{}) binding.Flow {
-	fb := c.pipeline[tableID].BuildFlow(priorityNormal)
+	fb := c.addFlowMatch(c.pipeline[tableID].BuildFlow(K8sIsolationDropPriority), matchKey, matchValue)
+	var customReason int
 	if c.enableDenyTracking {
-		return c.addFlowMatch(fb, matchKey, matchValue).
-			Action().Drop().
-			Action().LoadRegRange(int(marksReg), DispositionDrop, APDispositionMarkRange).
-			Action().LoadRegRange(int(marksReg), CustomReasonDeny, CustomReasonMarkRange).
-			Action().SendToController(uint8(PacketInReasonNP)).
-			Cookie(c.cookieAllocator.Request(cookie.Default).Raw()).
-			Done()
+		customReason += CustomReasonDeny
 	}
-	return c.addFlowMatch(fb, matchKey, matchValue).
-		Action().Drop().
-		Cookie(c.cookieAllocator.Request(cookie.Default).Raw()).
+	if c.isK8sIsolationLoggingEnabled(tableID) {
+		customReason += CustomReasonLogging
+	}
+	fb = fb.Action().Drop()
+	if customReason != 0 {
+		if customReason&CustomReasonLogging != 0 && c.ovsMetersAreSupported {
+			fb = fb.Action().Meter(PacketInMeterIDNP)
+		}
+		fb = fb.Action().LoadRegRange(int(marksReg), DispositionDrop, APDispositionMarkRange).
+			Action().LoadRegRange(int(marksReg), uint32(customReason), CustomReasonMarkRange).
+			Action().SendToController(uint8(PacketInReasonNP))
+	}
+	return fb.Cookie(c.cookieAllocator.Request(cookie.Default).Raw()).
 		Done()
 }
 
+// isK8sIsolationLoggingEnabled returns whether the packets dropped by K8s NetworkPolicy isolation in
+// the provided default drop table must be logged.
+func (c *client) isK8sIsolationLoggingEnabled(tableID binding.TableIDType) bool {
+	if tableID == EgressDefaultTable {
+		return c.enableK8sEgressIsolationLogging
+	}
+	return c.enableK8sIngressIsolationLogging
+}
+
+// EnableK8sIsolationLogging enables the logging of the packets dropped by K8s NetworkPolicy
+// isolation, for the ingress and egress directions respectively. It must be called before
+// Initialize.
+func (c *client) EnableK8sIsolationLogging(ingress, egress bool) {
+	c.enableK8sIngressIsolationLogging = ingress
+	c.enableK8sEgressIsolationLogging = egress
+}
+
+// ConfigureNDGuard configures the check of the Neighbor Discovery messages sent by local Pods
+// with IPv6 addresses, see ndGuardFlows. It must be called before Initialize.
+func (c *client) ConfigureNDGuard(enable, enableLogging bool) {
+	c.enableNDGuard = enable
+	c.enableNDGuardLogging = enableLogging
+	if enable {
+		c.RegisterPacketInHandler(uint8(PacketInReasonNDGuard), "ndguard", newNDGuardHandler(enableLogging))
+	}
+}
+
+// ConfigureServiceLoopGuard configures the handling of the Service accessing packets dropped by
+// serviceLoopGuardFlow. It must be called before Initialize.
+func (c *client) ConfigureServiceLoopGuard(enableLogging bool) {
+	if c.enableProxy {
+		c.RegisterPacketInHandler(uint8(PacketInReasonServiceLoop), "serviceloopguard", newServiceLoopGuardHandler(enableLogging))
+	}
+}
+
 // localProbeFlow generates the flow to forward locally generated packets to conntrackCommitTable, bypassing ingress
 // rules of Network Policies. The packets are sent by kubelet to probe the liveness/readiness of local Pods.
 // On Linux and when OVS kernel datapath is used, it identifies locally generated packets by matching the
 // HostLocalSourceMark, otherwise it matches the source IP. The difference is because:
-// 1. On Windows, kube-proxy userspace mode is used, and currently there is no way to distinguish kubelet generated
-//    traffic from kube-proxy proxied traffic.
-// 2. pkt_mark field is not properly supported for OVS userspace (netdev) datapath.
+//  1. On Windows, kube-proxy userspace mode is used, and currently there is no way to distinguish kubelet generated
+//     traffic from kube-proxy proxied traffic.
+//  2. pkt_mark field is not properly supported for OVS userspace (netdev) datapath.
+//
 // Note that there is a defect in the latter way that NodePort Service access by external clients will be masqueraded as
 // a local gateway IP to bypass Network Policies. See https://github.com/antrea-io/antrea/issues/280.
 // TODO: Fix it after replacing kube-proxy with AntreaProxy.
@@ -1915,6 +2476,49 @@ func (c *client) loadBalancerServiceFromOutsideFlow(svcIP net.IP, svcPort uint16
 		Done()
 }
 
+// loadBalancerServiceFromOutsideNoSNATFlows generates the flows to load-balance LoadBalancer service traffic
+// from outside node in OVS, instead of forwarding it to kube-proxy which SNATs it. The packets enter CtZone and
+// then:
+//  1. new connections to the Service go through Endpoint selection like the traffic from local Pods;
+//  2. packets of existing connections, which have been DNAT'd by the ct action, skip Endpoint selection.
+//
+// The client IP is preserved. If the selected Endpoint is on another Node, its reply packets do not go back
+// through this Node, and must be routed to the client by the infrastructure.
+// These flows are for Windows Node only.
+func (c *client) loadBalancerServiceFromOutsideNoSNATFlows(svcIP net.IP, svcPort uint16, protocol binding.Protocol) []binding.Flow {
+	ctStateTable := c.pipeline[conntrackStateTable]
+	return []binding.Flow{
+		c.pipeline[uplinkTable].BuildFlow(priorityHigh).
+			MatchProtocol(protocol).
+			MatchDstPort(svcPort, nil).
+			MatchRegRange(int(marksReg), markTrafficFromUplink, binding.Range{0, 15}).
+			MatchDstIP(svcIP).
+			Action().CT(false, conntrackStateTable, CtZone).NAT().CTDone().
+			Cookie(c.cookieAllocator.Request(cookie.Service).Raw()).
+			Done(),
+		ctStateTable.BuildFlow(priorityHigh).
+			MatchProtocol(protocol).
+			MatchDstPort(svcPort, nil).
+			MatchRegRange(int(marksReg), markTrafficFromUplink, binding.Range{0, 15}).
+			MatchDstIP(svcIP).
+			MatchCTStateNew(true).MatchCTStateTrk(true).
+			Action().ResubmitToTable(sessionAffinityTable).
+			Action().ResubmitToTable(serviceLBTable).
+			Cookie(c.cookieAllocator.Request(cookie.Service).Raw()).
+			Done(),
+		ctStateTable.BuildFlow(priorityHigh).
+			MatchProtocol(protocol).
+			MatchRegRange(int(marksReg), markTrafficFromUplink, binding.Range{0, 15}).
+			MatchCTStateNew(false).MatchCTStateTrk(true).
+			MatchCTDstIP(svcIP).
+			MatchCTDstPort(svcPort).
+			MatchCTProtocol(protocol).
+			Action().GotoTable(ctStateTable.GetNext()).
+			Cookie(c.cookieAllocator.Request(cookie.Service).Raw()).
+			Done(),
+	}
+}
+
 // serviceLearnFlow generates the flow with learn action which adds new flows in
 // sessionAffinityTable according to the Endpoint selection decision.
 func (c *client) serviceLearnFlow(groupID binding.GroupIDType, svcIP net.IP, svcPort uint16, protocol binding.Protocol, affinityTimeout uint16) binding.Flow {
@@ -1982,6 +2586,16 @@ func (c *client) serviceLearnFlow(groupID binding.GroupIDType, svcIP net.IP, svc
 // serviceLBFlow generates the flow which uses the specific group to do Endpoint
 // selection.
 func (c *client) serviceLBFlow(groupID binding.GroupIDType, svcIP net.IP, svcPort uint16, protocol binding.Protocol, withSessionAffinity bool) binding.Flow {
+	flowBuilder := c.pipeline[serviceLBTable].BuildFlow(priorityNormal).
+		MatchProtocol(protocol).
+		MatchDstPort(svcPort, nil).
+		MatchDstIP(svcIP)
+	return c.serviceLBActions(flowBuilder, groupID, withSessionAffinity)
+}
+
+// serviceLBActions adds the actions selecting an Endpoint with the specific group to the Service
+// accessing packets matched by flowBuilder, and builds the flow.
+func (c *client) serviceLBActions(flowBuilder binding.FlowBuilder, groupID binding.GroupIDType, withSessionAffinity bool) binding.Flow {
 	var lbResultMark uint32
 	if withSessionAffinity {
 		lbResultMark = marksRegServiceNeedLearn
@@ -1989,11 +2603,7 @@ func (c *client) serviceLBFlow(groupID binding.GroupIDType, svcIP net.IP, svcPor
 		lbResultMark = marksRegServiceSelected
 	}
 
-	return c.pipeline[serviceLBTable].BuildFlow(priorityNormal).
-		MatchProtocol(protocol).
-		MatchDstPort(svcPort, nil).
-		MatchDstIP(svcIP).
-		MatchRegRange(int(serviceLearnReg), marksRegServiceNeedLB, serviceLearnRegRange).
+	return flowBuilder.MatchRegRange(int(serviceLearnReg), marksRegServiceNeedLB, serviceLearnRegRange).
 		Action().LoadRegRange(int(serviceLearnReg), lbResultMark, serviceLearnRegRange).
 		Action().LoadRegRange(int(marksReg), macRewriteMark, macRewriteMarkRange).
 		Action().Group(groupID).
@@ -2001,6 +2611,40 @@ func (c *client) serviceLBFlow(groupID binding.GroupIDType, svcIP net.IP, svcPor
 		Done()
 }
 
+// serviceSourceRangeFlows generates the flows which restrict the clients of a Service IP to the
+// source ranges of the Service. The packets from the source ranges select an Endpoint with the
+// specific group, like with serviceLBFlow but at a higher priority, and the packets from other
+// sources are dropped before selecting an Endpoint. The dropped packets are sent to the
+// controller to be counted. The source ranges of the other IP family than svcIP are ignored.
+func (c *client) serviceSourceRangeFlows(groupID binding.GroupIDType, svcIP net.IP, svcPort uint16, protocol binding.Protocol, withSessionAffinity bool, sourceRanges []net.IPNet) []binding.Flow {
+	lbTable := c.pipeline[serviceLBTable]
+	isIPv6 := svcIP.To4() == nil
+	var flows []binding.Flow
+	for _, sourceRange := range sourceRanges {
+		if (sourceRange.IP.To4() == nil) != isIPv6 {
+			continue
+		}
+		flowBuilder := lbTable.BuildFlow(priorityHigh).
+			MatchProtocol(protocol).
+			MatchDstPort(svcPort, nil).
+			MatchDstIP(svcIP).
+			MatchSrcIPNet(sourceRange)
+		flows = append(flows, c.serviceLBActions(flowBuilder, groupID, withSessionAffinity))
+	}
+	dropFlowBuilder := lbTable.BuildFlow(serviceSourceRangeDropPriority).
+		MatchProtocol(protocol).
+		MatchDstPort(svcPort, nil).
+		MatchDstIP(svcIP).
+		MatchRegRange(int(serviceLearnReg), marksRegServiceNeedLB, serviceLearnRegRange)
+	if c.ovsMetersAreSupported {
+		dropFlowBuilder = dropFlowBuilder.Action().Meter(PacketInMeterIDServiceSourceRange)
+	}
+	flows = append(flows, dropFlowBuilder.Action().SendToController(uint8(PacketInReasonServiceSourceRange)).
+		Cookie(c.cookieAllocator.Request(cookie.Service).Raw()).
+		Done())
+	return flows
+}
+
 // endpointDNATFlow generates the flow which transforms the Service Cluster IP
 // to the Endpoint IP according to the Endpoint selection decision which is stored
 // in regs.
@@ -2147,7 +2791,8 @@ func (c *client) genPacketInMeter(meterID binding.MeterIDType, rate uint32) bind
 func (c *client) generatePipeline() {
 	bridge := c.bridge
 	c.pipeline = map[binding.TableIDType]binding.Table{
-		ClassifierTable:    bridge.CreateTable(ClassifierTable, spoofGuardTable, binding.TableMissActionDrop),
+		ClassifierTable:    bridge.CreateTable(ClassifierTable, dhcpTable, binding.TableMissActionDrop),
+		dhcpTable:          bridge.CreateTable(dhcpTable, spoofGuardTable, binding.TableMissActionNext),
 		arpResponderTable:  bridge.CreateTable(arpResponderTable, binding.LastTableID, binding.TableMissActionDrop),
 		conntrackTable:     bridge.CreateTable(conntrackTable, conntrackStateTable, binding.TableMissActionNone),
 		EgressRuleTable:    bridge.CreateTable(EgressRuleTable, EgressDefaultTable, binding.TableMissActionNext),
@@ -2160,6 +2805,7 @@ func (c *client) generatePipeline() {
 		IngressRuleTable:      bridge.CreateTable(IngressRuleTable, IngressDefaultTable, binding.TableMissActionNext),
 		IngressDefaultTable:   bridge.CreateTable(IngressDefaultTable, IngressMetricTable, binding.TableMissActionNext),
 		IngressMetricTable:    bridge.CreateTable(IngressMetricTable, conntrackCommitTable, binding.TableMissActionNext),
+		connectionLimitTable:  bridge.CreateTable(connectionLimitTable, L2ForwardingOutTable, binding.TableMissActionNext),
 		L2ForwardingOutTable:  bridge.CreateTable(L2ForwardingOutTable, binding.LastTableID, binding.TableMissActionDrop),
 	}
 	if c.enableProxy {
@@ -2171,13 +2817,13 @@ func (c *client) generatePipeline() {
 		c.pipeline[serviceLBTable] = bridge.CreateTable(serviceLBTable, endpointDNATTable, binding.TableMissActionNext)
 		c.pipeline[endpointDNATTable] = bridge.CreateTable(endpointDNATTable, c.egressEntryTable, binding.TableMissActionNext)
 		c.pipeline[conntrackCommitTable] = bridge.CreateTable(conntrackCommitTable, hairpinSNATTable, binding.TableMissActionNext)
-		c.pipeline[hairpinSNATTable] = bridge.CreateTable(hairpinSNATTable, L2ForwardingOutTable, binding.TableMissActionNext)
+		c.pipeline[hairpinSNATTable] = bridge.CreateTable(hairpinSNATTable, connectionLimitTable, binding.TableMissActionNext)
 	} else {
 		c.pipeline[spoofGuardTable] = bridge.CreateTable(spoofGuardTable, conntrackTable, binding.TableMissActionDrop)
 		c.pipeline[ipv6Table] = bridge.CreateTable(ipv6Table, conntrackTable, binding.TableMissActionNext)
 		c.pipeline[conntrackStateTable] = bridge.CreateTable(conntrackStateTable, dnatTable, binding.TableMissActionNext)
 		c.pipeline[dnatTable] = bridge.CreateTable(dnatTable, c.egressEntryTable, binding.TableMissActionNext)
-		c.pipeline[conntrackCommitTable] = bridge.CreateTable(conntrackCommitTable, L2ForwardingOutTable, binding.TableMissActionNext)
+		c.pipeline[conntrackCommitTable] = bridge.CreateTable(conntrackCommitTable, connectionLimitTable, binding.TableMissActionNext)
 	}
 	// The default SNAT is implemented with OVS on Windows.
 	if c.enableEgress || runtime.IsWindowsPlatform() {
@@ -2187,6 +2833,8 @@ func (c *client) generatePipeline() {
 		c.pipeline[uplinkTable] = bridge.CreateTable(uplinkTable, spoofGuardTable, binding.TableMissActionNone)
 	}
 	if c.enableAntreaPolicy {
+		c.pipeline[AntreaPolicyARPRuleTable] = bridge.CreateTable(AntreaPolicyARPRuleTable, arpResponderTable, binding.TableMissActionNext)
+		c.pipeline[AntreaPolicyNDRuleTable] = bridge.CreateTable(AntreaPolicyNDRuleTable, ipv6Table, binding.TableMissActionNext)
 		c.pipeline[AntreaPolicyEgressRuleTable] = bridge.CreateTable(AntreaPolicyEgressRuleTable, EgressRuleTable, binding.TableMissActionNext)
 		c.pipeline[AntreaPolicyIngressRuleTable] = bridge.CreateTable(AntreaPolicyIngressRuleTable, IngressRuleTable, binding.TableMissActionNext)
 	}
@@ -2209,23 +2857,32 @@ func NewClient(bridgeName, mgmtAddr string, ovsDatapathType ovsconfig.OVSDatapat
 		podFlowCache:             newFlowCategoryCache(),
 		serviceFlowCache:         newFlowCategoryCache(),
 		tfFlowCache:              newFlowCategoryCache(),
+		pcFlowCache:              newFlowCategoryCache(),
 		policyCache:              policyCache,
 		groupCache:               sync.Map{},
 		globalConjMatchFlowCache: map[string]*conjMatchFlowContext{},
 		packetInHandlers:         map[uint8]map[string]PacketInHandler{},
 		ovsctlClient:             ovsctl.NewClient(bridgeName),
 		ovsDatapathType:          ovsDatapathType,
-		ovsMetersAreSupported:    ovsMetersAreSupported(ovsDatapathType),
+		podConnectionLimits:      map[string]*podConnectionLimit{},
+		policyMetrics:            map[uint32]*ruleMetricCounters{},
+		pendingRuleUninstalls:    map[uint32]chan struct{}{},
+		ipAnnouncementLimiter:    rate.NewLimiter(ipAnnouncementRate, ipAnnouncementBurst),
 	}
 	c.ofEntryOperations = c
 	if enableAntreaPolicy {
 		c.egressEntryTable, c.ingressEntryTable = AntreaPolicyEgressRuleTable, AntreaPolicyIngressRuleTable
+		c.arpEntryTable, c.ipv6EntryTable = AntreaPolicyARPRuleTable, AntreaPolicyNDRuleTable
 	} else {
 		c.egressEntryTable, c.ingressEntryTable = EgressRuleTable, IngressRuleTable
+		c.arpEntryTable, c.ipv6EntryTable = arpResponderTable, ipv6Table
 	}
 	if enableEgress {
 		c.snatFlowCache = newFlowCategoryCache()
 	}
+	if enableProxy {
+		c.RegisterPacketInHandler(uint8(PacketInReasonServiceSourceRange), "servicesourcerange", &serviceSourceRangeHandler{})
+	}
 	c.generatePipeline()
 	return c
 }
@@ -0,0 +1,58 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openflow
+
+import (
+	"testing"
+
+	"github.com/contiv/ofnet/ofctrl"
+	"github.com/stretchr/testify/assert"
+	"github.com/vmware/go-ipfix/pkg/registry"
+
+	"antrea.io/antrea/pkg/agent/flowexporter"
+)
+
+func TestDispositionRoundTrip(t *testing.T) {
+	tests := []struct {
+		disposition  uint32
+		expectedName string
+		// expectedRuleAction is the action reported by the Flow Exporter for the disposition.
+		expectedRuleAction uint8
+	}{
+		{DispositionAllow, "Allow", registry.NetworkPolicyRuleActionAllow},
+		{DispositionDrop, "Drop", registry.NetworkPolicyRuleActionDrop},
+		{DispositionReject, "Reject", registry.NetworkPolicyRuleActionReject},
+		{DispositionPass, "Pass", registry.NetworkPolicyRuleActionNoAction},
+	}
+	// Every value which fits in APDispositionMarkRange must be a known disposition.
+	assert.Len(t, tests, 1<<APDispositionMarkRange.Length())
+	assert.Len(t, dispositionNames, len(tests))
+	for _, tt := range tests {
+		t.Run(tt.expectedName, func(t *testing.T) {
+			// Other bits of the register must not leak into the disposition.
+			regValue := tt.disposition<<APDispositionMarkRange[0] | cnpDenyMark<<cnpDenyMarkRange[0] | CustomReasonLogging<<CustomReasonMarkRange[0]
+			decoded := ofctrl.GetUint32ValueWithRange(regValue, APDispositionMarkRange.ToNXRange())
+			assert.Equal(t, tt.disposition, decoded)
+			name := DispositionToString(decoded)
+			assert.Equal(t, tt.expectedName, name)
+			assert.Equal(t, tt.expectedRuleAction, flowexporter.RuleActionToUint8(name))
+		})
+	}
+}
+
+func TestDispositionToStringUnknown(t *testing.T) {
+	assert.Equal(t, "Unknown(4)", DispositionToString(4))
+	assert.Equal(t, "Unknown(255)", DispositionToString(255))
+}
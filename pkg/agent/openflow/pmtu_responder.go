@@ -0,0 +1,177 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openflow
+
+import (
+	"encoding/binary"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/contiv/libOpenflow/openflow13"
+	"github.com/contiv/libOpenflow/protocol"
+	"github.com/contiv/ofnet/ofctrl"
+	"golang.org/x/time/rate"
+	"k8s.io/klog/v2"
+
+	"antrea.io/antrea/pkg/agent/config"
+)
+
+const (
+	ethernetHeaderLen = 14
+
+	icmpDstUnreachableType      uint8 = 3
+	icmpFragmentationNeededCode uint8 = 4
+	icmpv6PacketTooBigType      uint8 = 2
+
+	// ipv4DontFragmentFlag is the DF bit of the 3-bit Flags field of the IPv4 header.
+	ipv4DontFragmentFlag uint16 = 0b010
+	// icmpPMTUHeaderLen is the length of the ICMP header following the checksum in both Fragmentation
+	// Needed (2 unused bytes and the next-hop MTU) and Packet Too Big (the MTU) messages.
+	icmpPMTUHeaderLen = 4
+	// ipv4QuotedPayloadLen is the length of the payload of the original packet, after its IP header,
+	// quoted in ICMP Fragmentation Needed messages (RFC 792).
+	ipv4QuotedPayloadLen = 8
+	// ipv6MaxQuotedLen is the maximum length of the original packet quoted in ICMPv6 Packet Too Big
+	// messages, so that the message doesn't exceed the minimum IPv6 MTU (RFC 4443, section 2.4):
+	// 1280 bytes minus the IPv6 header and the ICMPv6 header.
+	ipv6MaxQuotedLen = 1280 - 40 - 8
+
+	// Each source gets at most pmtuResponderBurst messages at once, then pmtuResponderRate
+	// messages per second, so that the agent cannot be used to flood a host with ICMP errors.
+	pmtuResponderRate  = rate.Limit(1)
+	pmtuResponderBurst = 3
+	// pmtuResponderMaxSources is the maximum number of sources tracked for rate limiting. When it's
+	// reached, the sources not seen for pmtuResponderSourceIdleTimeout are forgotten, and the
+	// messages to new sources are dropped if there is still no room for them.
+	pmtuResponderMaxSources        = 1024
+	pmtuResponderSourceIdleTimeout = time.Minute
+)
+
+// pmtuSourceLimiter rate limits the messages sent to a source.
+type pmtuSourceLimiter struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// pmtuResponder replies to the packets sent to the controller by tunnelPMTUFlows, which are too
+// large to be sent through the tunnel, with ICMP Fragmentation Needed or ICMPv6 Packet Too Big
+// messages carrying the tunnel MTU, so that the senders can discover the path MTU. The messages are
+// output to the gateway port, and use the destination of the original packet as their source IP,
+// like the reject responses of NetworkPolicies.
+// IPv4 packets without the DF flag are ignored, and are dropped as they were before.
+type pmtuResponder struct {
+	ofClient *client
+	// limiters is keyed by source IP. It's only accessed by HandlePacketIn, which is never called
+	// concurrently for the same reason.
+	limiters map[string]*pmtuSourceLimiter
+	// now is used to get the current time, it can be overridden in tests.
+	now func() time.Time
+}
+
+func newPMTUResponder(ofClient *client) *pmtuResponder {
+	return &pmtuResponder{
+		ofClient: ofClient,
+		limiters: map[string]*pmtuSourceLimiter{},
+		now:      time.Now,
+	}
+}
+
+// HandlePacketIn implements PacketInHandler.
+func (r *pmtuResponder) HandlePacketIn(pktIn *ofctrl.PacketIn) error {
+	mtu := r.ofClient.nodeConfig.NodeMTU
+	var (
+		srcIP, dstIP       net.IP
+		icmpType, icmpCode uint8
+		icmpData           []byte
+		isIPv6             bool
+	)
+	switch ipPkt := pktIn.Data.Data.(type) {
+	case *protocol.IPv4:
+		if ipPkt.Flags&ipv4DontFragmentFlag == 0 {
+			klog.V(4).Infof("Ignoring packet from %s to %s larger than the tunnel MTU without DF flag", ipPkt.NWSrc, ipPkt.NWDst)
+			return nil
+		}
+		ipData, err := ipPkt.MarshalBinary()
+		if err != nil {
+			return err
+		}
+		quotedLen := int(ipPkt.IHL)*4 + ipv4QuotedPayloadLen
+		if quotedLen > len(ipData) {
+			quotedLen = len(ipData)
+		}
+		icmpData = make([]byte, icmpPMTUHeaderLen+quotedLen)
+		binary.BigEndian.PutUint16(icmpData[2:], uint16(mtu))
+		copy(icmpData[icmpPMTUHeaderLen:], ipData[:quotedLen])
+		srcIP, dstIP = ipPkt.NWDst, ipPkt.NWSrc
+		icmpType, icmpCode = icmpDstUnreachableType, icmpFragmentationNeededCode
+	case *protocol.IPv6:
+		ipData, err := ipPkt.MarshalBinary()
+		if err != nil {
+			return err
+		}
+		quotedLen := len(ipData)
+		if quotedLen > ipv6MaxQuotedLen {
+			quotedLen = ipv6MaxQuotedLen
+		}
+		icmpData = make([]byte, icmpPMTUHeaderLen+quotedLen)
+		binary.BigEndian.PutUint32(icmpData, uint32(mtu))
+		copy(icmpData[icmpPMTUHeaderLen:], ipData[:quotedLen])
+		srcIP, dstIP = ipPkt.NWDst, ipPkt.NWSrc
+		icmpType, icmpCode = icmpv6PacketTooBigType, 0
+		isIPv6 = true
+	default:
+		return errors.New("packet larger than the tunnel MTU is neither IPv4 nor IPv6")
+	}
+	if !r.allow(dstIP) {
+		klog.V(4).Infof("Rate limiting path MTU discovery messages to %s", dstIP)
+		return nil
+	}
+	return r.ofClient.SendICMPPacketOut(
+		globalVirtualMAC.String(),
+		r.ofClient.nodeConfig.GatewayConfig.MAC.String(),
+		srcIP.String(),
+		dstIP.String(),
+		uint32(openflow13.P_CONTROLLER),
+		int32(config.HostGatewayOFPort),
+		isIPv6,
+		icmpType,
+		icmpCode,
+		icmpData,
+		false)
+}
+
+// allow returns whether a message can be sent to ip according to the rate limit of this source.
+func (r *pmtuResponder) allow(ip net.IP) bool {
+	now := r.now()
+	key := ip.String()
+	l, exists := r.limiters[key]
+	if !exists {
+		if len(r.limiters) >= pmtuResponderMaxSources {
+			for k, v := range r.limiters {
+				if now.Sub(v.lastSeen) > pmtuResponderSourceIdleTimeout {
+					delete(r.limiters, k)
+				}
+			}
+			if len(r.limiters) >= pmtuResponderMaxSources {
+				return false
+			}
+		}
+		l = &pmtuSourceLimiter{limiter: rate.NewLimiter(pmtuResponderRate, pmtuResponderBurst)}
+		r.limiters[key] = l
+	}
+	l.lastSeen = now
+	return l.limiter.AllowN(now, 1)
+}
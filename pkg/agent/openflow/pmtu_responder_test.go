@@ -0,0 +1,226 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openflow
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/contiv/libOpenflow/openflow13"
+	"github.com/contiv/libOpenflow/protocol"
+	"github.com/contiv/ofnet/ofctrl"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"antrea.io/antrea/pkg/agent/config"
+	binding "antrea.io/antrea/pkg/ovs/openflow"
+	ovsoftest "antrea.io/antrea/pkg/ovs/openflow/testing"
+	"antrea.io/antrea/pkg/ovs/ovsconfig"
+)
+
+const pmtuTestMTU = 1450
+
+var (
+	pmtuTestGatewayMAC, _ = net.ParseMAC("0a:00:00:00:00:01")
+	pmtuTestClientIPv4    = net.ParseIP("192.168.77.100").To4()
+	pmtuTestPodIPv4       = net.ParseIP("10.10.1.2").To4()
+	pmtuTestClientIPv6    = net.ParseIP("fd00:77::100")
+	pmtuTestPodIPv6       = net.ParseIP("fd00:10:10:1::2")
+)
+
+func newPMTUTestUDP(payloadLen int) *protocol.UDP {
+	return &protocol.UDP{
+		PortSrc: 34567,
+		PortDst: 8080,
+		Length:  uint16(8 + payloadLen),
+		Data:    make([]byte, payloadLen),
+	}
+}
+
+func newPMTUTestIPv4PacketIn(flags uint16) *ofctrl.PacketIn {
+	udp := newPMTUTestUDP(1500)
+	ipPkt := &protocol.IPv4{
+		Version:  4,
+		IHL:      5,
+		Length:   20 + udp.Length,
+		Flags:    flags,
+		TTL:      63,
+		Protocol: protocol.Type_UDP,
+		NWSrc:    pmtuTestClientIPv4,
+		NWDst:    pmtuTestPodIPv4,
+		Data:     udp,
+	}
+	return &ofctrl.PacketIn{
+		Reason: uint8(PacketInReasonPMTU),
+		Data:   protocol.Ethernet{HWDst: globalVirtualMAC, HWSrc: pmtuTestGatewayMAC, Ethertype: protocol.IPv4_MSG, Data: ipPkt},
+	}
+}
+
+func newPMTUTestIPv6PacketIn() *ofctrl.PacketIn {
+	udp := newPMTUTestUDP(1500)
+	ipPkt := &protocol.IPv6{
+		Version:    6,
+		Length:     udp.Length,
+		NextHeader: protocol.Type_UDP,
+		HopLimit:   63,
+		NWSrc:      pmtuTestClientIPv6,
+		NWDst:      pmtuTestPodIPv6,
+		Data:       udp,
+	}
+	return &ofctrl.PacketIn{
+		Reason: uint8(PacketInReasonPMTU),
+		Data:   protocol.Ethernet{HWDst: globalVirtualMAC, HWSrc: pmtuTestGatewayMAC, Ethertype: protocol.IPv6_MSG, Data: ipPkt},
+	}
+}
+
+func newPMTUTestClient(ctrl *gomock.Controller) (*client, *ovsoftest.MockBridge) {
+	ofClient := NewClient(bridgeName, bridgeMgmtAddr, ovsconfig.OVSDatapathSystem, true, false, false, false)
+	c := ofClient.(*client)
+	c.nodeConfig = &config.NodeConfig{
+		NodeMTU:       pmtuTestMTU,
+		GatewayConfig: &config.GatewayConfig{MAC: pmtuTestGatewayMAC},
+	}
+	m := ovsoftest.NewMockBridge(ctrl)
+	c.bridge = m
+	return c, m
+}
+
+func TestPMTUResponder(t *testing.T) {
+	tests := []struct {
+		name          string
+		pktIn         *ofctrl.PacketIn
+		expectPktOut  bool
+		wantSrcIP     net.IP
+		wantDstIP     net.IP
+		wantICMPType  uint8
+		wantICMPCode  uint8
+		wantMTU       uint32
+		wantQuotedLen int
+	}{
+		{
+			name:          "IPv4 with DF",
+			pktIn:         newPMTUTestIPv4PacketIn(ipv4DontFragmentFlag),
+			expectPktOut:  true,
+			wantSrcIP:     pmtuTestPodIPv4,
+			wantDstIP:     pmtuTestClientIPv4,
+			wantICMPType:  icmpDstUnreachableType,
+			wantICMPCode:  icmpFragmentationNeededCode,
+			wantMTU:       pmtuTestMTU,
+			wantQuotedLen: 20 + 8,
+		},
+		{
+			name:         "IPv4 without DF",
+			pktIn:        newPMTUTestIPv4PacketIn(0),
+			expectPktOut: false,
+		},
+		{
+			name:          "IPv6",
+			pktIn:         newPMTUTestIPv6PacketIn(),
+			expectPktOut:  true,
+			wantSrcIP:     pmtuTestPodIPv6,
+			wantDstIP:     pmtuTestClientIPv6,
+			wantICMPType:  icmpv6PacketTooBigType,
+			wantICMPCode:  0,
+			wantMTU:       pmtuTestMTU,
+			wantQuotedLen: ipv6MaxQuotedLen,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			c, m := newPMTUTestClient(ctrl)
+			if tt.expectPktOut {
+				originalPkt, err := tt.pktIn.Data.Data.MarshalBinary()
+				require.NoError(t, err)
+				bridge := binding.OFBridge{}
+				m.EXPECT().BuildPacketOut().Return(bridge.BuildPacketOut()).Times(1)
+				m.EXPECT().SendPacketOut(gomock.Any()).Do(func(pktOut *ofctrl.PacketOut) {
+					assert.Equal(t, uint32(openflow13.P_CONTROLLER), pktOut.InPort)
+					assert.Equal(t, uint32(config.HostGatewayOFPort), pktOut.OutPort)
+					assert.Equal(t, globalVirtualMAC, pktOut.SrcMAC)
+					assert.Equal(t, pmtuTestGatewayMAC, pktOut.DstMAC)
+					if tt.wantSrcIP.To4() != nil {
+						require.NotNil(t, pktOut.IPHeader)
+						assert.True(t, tt.wantSrcIP.Equal(pktOut.IPHeader.NWSrc))
+						assert.True(t, tt.wantDstIP.Equal(pktOut.IPHeader.NWDst))
+					} else {
+						require.NotNil(t, pktOut.IPv6Header)
+						assert.True(t, tt.wantSrcIP.Equal(pktOut.IPv6Header.NWSrc))
+						assert.True(t, tt.wantDstIP.Equal(pktOut.IPv6Header.NWDst))
+					}
+					require.NotNil(t, pktOut.ICMPHeader)
+					assert.Equal(t, tt.wantICMPType, pktOut.ICMPHeader.Type)
+					assert.Equal(t, tt.wantICMPCode, pktOut.ICMPHeader.Code)
+					data := pktOut.ICMPHeader.Data
+					require.Len(t, data, icmpPMTUHeaderLen+tt.wantQuotedLen)
+					assert.Equal(t, tt.wantMTU, binary.BigEndian.Uint32(data[:icmpPMTUHeaderLen]))
+					assert.Equal(t, originalPkt[:tt.wantQuotedLen], data[icmpPMTUHeaderLen:])
+				}).Return(nil).Times(1)
+			}
+
+			err := newPMTUResponder(c).HandlePacketIn(tt.pktIn)
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestPMTUResponderRateLimiting(t *testing.T) {
+	for _, ips := range [][2]net.IP{
+		{pmtuTestClientIPv4, net.ParseIP("192.168.77.101")},
+		{pmtuTestClientIPv6, net.ParseIP("fd00:77::101")},
+	} {
+		t.Run(fmt.Sprintf("IPv6=%t", ips[0].To4() == nil), func(t *testing.T) {
+			now := time.Now()
+			r := newPMTUResponder(nil)
+			r.now = func() time.Time { return now }
+
+			for i := 0; i < pmtuResponderBurst; i++ {
+				assert.True(t, r.allow(ips[0]), "Message %d should be allowed", i)
+			}
+			assert.False(t, r.allow(ips[0]), "Message exceeding the burst should be dropped")
+			// Other sources have their own limit.
+			assert.True(t, r.allow(ips[1]))
+			// The source gets a new message after 1 / pmtuResponderRate.
+			now = now.Add(time.Second)
+			assert.True(t, r.allow(ips[0]))
+			assert.False(t, r.allow(ips[0]))
+		})
+	}
+}
+
+func TestPMTUResponderMaxSources(t *testing.T) {
+	now := time.Now()
+	r := newPMTUResponder(nil)
+	r.now = func() time.Time { return now }
+	sourceIP := func(i int) net.IP {
+		ip := make(net.IP, net.IPv4len)
+		binary.BigEndian.PutUint32(ip, 0xc0a80000+uint32(i))
+		return ip
+	}
+	for i := 0; i < pmtuResponderMaxSources; i++ {
+		require.True(t, r.allow(sourceIP(i)))
+	}
+	newSource := sourceIP(pmtuResponderMaxSources)
+	assert.False(t, r.allow(newSource), "New sources should be dropped when too many sources are tracked")
+	// The idle sources are forgotten to make room for the new one.
+	now = now.Add(pmtuResponderSourceIdleTimeout + time.Second)
+	assert.True(t, r.allow(newSource))
+	assert.Len(t, r.limiters, 1)
+}
@@ -0,0 +1,88 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openflow
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ServiceGroupBucket is a bucket of the OVS group of a Service port, which selects an Endpoint.
+type ServiceGroupBucket struct {
+	// Index is the position of the bucket in the group.
+	Index        int
+	Weight       int
+	EndpointIP   net.IP
+	EndpointPort uint16
+}
+
+var (
+	bucketWeightRegexp       = regexp.MustCompile(`(?:^|,)weight:(\d+)`)
+	bucketEndpointIPRegexp   = regexp.MustCompile(fmt.Sprintf(`load:0x([0-9a-f]+)->NXM_NX_REG%d\[\]`, endpointIPReg))
+	bucketEndpointIPv6Regexp = regexp.MustCompile(fmt.Sprintf(`(?:set_field|load):0x([0-9a-f]+)->(?:xxreg%d|NXM_NX_XXREG%d\[\])`, endpointIPv6XXReg, endpointIPv6XXReg))
+	bucketEndpointPortRegexp = regexp.MustCompile(fmt.Sprintf(`load:0x([0-9a-f]+)->NXM_NX_REG%d\[%d\.\.%d\]`, endpointPortReg, endpointPortRegRange[0], endpointPortRegRange[1]))
+)
+
+// ParseServiceGroupBuckets parses the buckets of a Service group from its dump, as returned by
+// "ovs-ofctl dump-groups". The buckets are installed in the order of the Endpoints provided when
+// the group was last updated, so the Endpoint selected by each bucket can only be known from the
+// dump. Buckets which do not select an Endpoint are skipped.
+func ParseServiceGroupBuckets(groupStr string) []ServiceGroupBucket {
+	// The first segment holds the group properties.
+	bucketStrs := strings.Split(groupStr, ",bucket=")[1:]
+	buckets := make([]ServiceGroupBucket, 0, len(bucketStrs))
+	for i, bucketStr := range bucketStrs {
+		bucket := ServiceGroupBucket{Index: i}
+		if match := bucketWeightRegexp.FindStringSubmatch(bucketStr); match != nil {
+			bucket.Weight, _ = strconv.Atoi(match[1])
+		}
+		if match := bucketEndpointIPRegexp.FindStringSubmatch(bucketStr); match != nil {
+			ipVal, err := strconv.ParseUint(match[1], 16, 32)
+			if err != nil {
+				continue
+			}
+			bucket.EndpointIP = make(net.IP, net.IPv4len)
+			binary.BigEndian.PutUint32(bucket.EndpointIP, uint32(ipVal))
+		} else if match := bucketEndpointIPv6Regexp.FindStringSubmatch(bucketStr); match != nil {
+			if len(match[1]) > 2*net.IPv6len {
+				continue
+			}
+			// Leading zeros are not printed.
+			ipVal, err := hex.DecodeString(strings.Repeat("0", 2*net.IPv6len-len(match[1])) + match[1])
+			if err != nil {
+				continue
+			}
+			bucket.EndpointIP = ipVal
+		} else {
+			continue
+		}
+		match := bucketEndpointPortRegexp.FindStringSubmatch(bucketStr)
+		if match == nil {
+			continue
+		}
+		portVal, err := strconv.ParseUint(match[1], 16, 16)
+		if err != nil {
+			continue
+		}
+		bucket.EndpointPort = uint16(portVal)
+		buckets = append(buckets, bucket)
+	}
+	return buckets
+}
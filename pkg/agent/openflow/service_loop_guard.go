@@ -0,0 +1,65 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openflow
+
+import (
+	"fmt"
+
+	"github.com/contiv/libOpenflow/protocol"
+	"github.com/contiv/ofnet/ofctrl"
+	"golang.org/x/time/rate"
+	"k8s.io/klog/v2"
+
+	"antrea.io/antrea/pkg/agent/metrics"
+)
+
+const (
+	// The dropped packets are logged at most serviceLoopGuardLogRate times per second, with bursts
+	// of serviceLoopGuardLogBurst, as a looping Service is usually accessed repeatedly.
+	serviceLoopGuardLogRate  = rate.Limit(1)
+	serviceLoopGuardLogBurst = 10
+)
+
+// serviceLoopGuardHandler handles the packets accessing a Service which are dropped by
+// serviceLoopGuardFlow, because their Endpoint was reselected maxServiceReselections times without
+// matching an endpointDNAT flow. It counts them, and logs them if logging is enabled.
+type serviceLoopGuardHandler struct {
+	enableLogging bool
+	logLimiter    *rate.Limiter
+}
+
+func newServiceLoopGuardHandler(enableLogging bool) *serviceLoopGuardHandler {
+	return &serviceLoopGuardHandler{
+		enableLogging: enableLogging,
+		logLimiter:    rate.NewLimiter(serviceLoopGuardLogRate, serviceLoopGuardLogBurst),
+	}
+}
+
+// HandlePacketIn implements PacketInHandler.
+func (h *serviceLoopGuardHandler) HandlePacketIn(pktIn *ofctrl.PacketIn) error {
+	metrics.ServiceLoopGuardDroppedPacketCount.Inc()
+	if !h.enableLogging || !h.logLimiter.Allow() {
+		return nil
+	}
+	switch ipPkt := pktIn.Data.Data.(type) {
+	case *protocol.IPv4:
+		klog.Infof("Service loop guard dropped a packet from %s to Service IP %s, protocol %d, after %d Endpoint reselections", ipPkt.NWSrc, ipPkt.NWDst, ipPkt.Protocol, maxServiceReselections)
+	case *protocol.IPv6:
+		klog.Infof("Service loop guard dropped a packet from %s to Service IP %s, protocol %d, after %d Endpoint reselections", ipPkt.NWSrc, ipPkt.NWDst, ipPkt.NextHeader, maxServiceReselections)
+	default:
+		return fmt.Errorf("unexpected Ethertype %#x for Service packet", pktIn.Data.Ethertype)
+	}
+	return nil
+}
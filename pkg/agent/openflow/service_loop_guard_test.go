@@ -0,0 +1,123 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openflow
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/contiv/libOpenflow/protocol"
+	"github.com/contiv/ofnet/ofctrl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/component-base/metrics/testutil"
+
+	"antrea.io/antrea/pkg/agent/metrics"
+	"antrea.io/antrea/pkg/agent/openflow/cookie"
+	binding "antrea.io/antrea/pkg/ovs/openflow"
+	"antrea.io/antrea/pkg/ovs/ovsconfig"
+)
+
+// serviceLoopGuardTestMatch returns the flow of endpointDNATTable matched by a packet whose Endpoint
+// has been selected and reselected count times, and which doesn't match any endpointDNAT flow.
+func serviceLoopGuardTestMatch(t *testing.T, flows []binding.Flow, count uint32) binding.Flow {
+	selected := fmt.Sprintf("reg%d[%d..%d]=0x%x", serviceLearnReg, serviceLearnRegRange[0], serviceLearnRegRange[1], marksRegServiceSelected)
+	reselectCount := fmt.Sprintf("reg%d[%d..%d]=0x%x", marksReg, serviceReselectCountRange[0], serviceReselectCountRange[1], count)
+	var matchedFlow binding.Flow
+	for _, flow := range flows {
+		matchers := strings.Split(flow.MatchString(), ",")[1:]
+		require.Len(t, matchers, 2, "Unexpected matchers in flow %s", flow.MatchString())
+		if matchers[0] == selected && matchers[1] == reselectCount {
+			require.Nil(t, matchedFlow, "Packet matches several flows")
+			matchedFlow = flow
+		}
+	}
+	return matchedFlow
+}
+
+func TestServiceLoopGuardFlows(t *testing.T) {
+	ofClient := NewClient(bridgeName, bridgeMgmtAddr, ovsconfig.OVSDatapathSystem, true, false, false, false)
+	c := ofClient.(*client)
+	c.cookieAllocator = cookie.NewAllocator(0, 0)
+	reselectFlows := c.serviceReselectFlows()
+	guardFlow := c.serviceLoopGuardFlow(cookie.Default)
+	flows := append(reselectFlows, guardFlow)
+
+	require.Len(t, reselectFlows, maxServiceReselections)
+	for _, flow := range flows {
+		assert.Equal(t, priorityLow, flow.FlowPriority())
+	}
+	// A packet whose Endpoint keeps missing the endpointDNAT flows is reselected
+	// maxServiceReselections times, then dropped by the guard.
+	for count := uint32(0); count < maxServiceReselections; count++ {
+		assert.Equal(t, reselectFlows[count], serviceLoopGuardTestMatch(t, flows, count))
+	}
+	assert.Equal(t, guardFlow, serviceLoopGuardTestMatch(t, flows, maxServiceReselections))
+}
+
+func TestServiceLoopGuardHandler(t *testing.T) {
+	metrics.InitializeServiceLoopGuardMetrics()
+	getCount := func() float64 {
+		count, err := testutil.GetCounterMetricValue(metrics.ServiceLoopGuardDroppedPacketCount)
+		require.NoError(t, err)
+		return count
+	}
+
+	tests := []struct {
+		name        string
+		eth         protocol.Ethernet
+		expectedErr bool
+	}{
+		{
+			name: "IPv4",
+			eth: protocol.Ethernet{
+				Ethertype: protocol.IPv4_MSG,
+				Data:      &protocol.IPv4{NWSrc: net.ParseIP("10.10.0.2"), NWDst: net.ParseIP("10.96.0.10"), Protocol: protocol.Type_TCP},
+			},
+		},
+		{
+			name: "IPv6",
+			eth: protocol.Ethernet{
+				Ethertype: protocol.IPv6_MSG,
+				Data:      &protocol.IPv6{NWSrc: net.ParseIP("fd00:10:244:1::2"), NWDst: net.ParseIP("fd00:10:96::10"), NextHeader: protocol.Type_UDP},
+			},
+		},
+		{
+			name: "ARP",
+			eth: protocol.Ethernet{
+				Ethertype: protocol.ARP_MSG,
+				Data:      &protocol.ARP{},
+			},
+			expectedErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pktIn := &ofctrl.PacketIn{Reason: uint8(PacketInReasonServiceLoop), Data: tt.eth}
+			countBefore := getCount()
+
+			err := newServiceLoopGuardHandler(true).HandlePacketIn(pktIn)
+			// The dropped packets are counted even if they cannot be logged.
+			assert.Equal(t, countBefore+1, getCount())
+			if tt.expectedErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
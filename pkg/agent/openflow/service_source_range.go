@@ -0,0 +1,36 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openflow
+
+import (
+	"github.com/contiv/ofnet/ofctrl"
+
+	"antrea.io/antrea/pkg/agent/metrics"
+)
+
+// serviceSourceRangeDropPriority is the priority of the flow dropping the packets accessing a
+// Service IP from a source outside of the source ranges of the Service. It's lower than the
+// priority of the flows allowing the source ranges, and higher than the priority of serviceLBFlow,
+// which would select an Endpoint for any source.
+const serviceSourceRangeDropPriority = priorityNormal + 1
+
+// serviceSourceRangeHandler counts the packets dropped by serviceSourceRangeFlows.
+type serviceSourceRangeHandler struct{}
+
+// HandlePacketIn implements PacketInHandler.
+func (h *serviceSourceRangeHandler) HandlePacketIn(_ *ofctrl.PacketIn) error {
+	metrics.ServiceSourceRangeDroppedPacketCount.Inc()
+	return nil
+}
@@ -0,0 +1,91 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openflow
+
+import (
+	"net"
+	"testing"
+
+	"github.com/contiv/ofnet/ofctrl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/component-base/metrics/testutil"
+
+	"antrea.io/antrea/pkg/agent/metrics"
+	"antrea.io/antrea/pkg/agent/openflow/cookie"
+	binding "antrea.io/antrea/pkg/ovs/openflow"
+	"antrea.io/antrea/pkg/ovs/ovsconfig"
+)
+
+func TestServiceSourceRangeFlows(t *testing.T) {
+	ofClient := NewClient(bridgeName, bridgeMgmtAddr, ovsconfig.OVSDatapathSystem, true, false, false, false)
+	c := ofClient.(*client)
+	c.cookieAllocator = cookie.NewAllocator(0, 0)
+	_, ipv4Range, _ := net.ParseCIDR("192.168.1.0/24")
+	_, ipv6Range, _ := net.ParseCIDR("fd00:1::/64")
+	sourceRanges := []net.IPNet{*ipv4Range, *ipv6Range}
+
+	tests := []struct {
+		name          string
+		svcIP         net.IP
+		protocol      binding.Protocol
+		expectedAllow string
+	}{
+		{
+			name:          "IPv4",
+			svcIP:         net.ParseIP("169.254.0.1"),
+			protocol:      binding.ProtocolTCP,
+			expectedAllow: "nw_src=192.168.1.0/24",
+		},
+		{
+			name:          "IPv6",
+			svcIP:         net.ParseIP("fd00:2::1"),
+			protocol:      binding.ProtocolTCPv6,
+			expectedAllow: "ipv6_src=fd00:1::/64",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lbFlow := c.serviceLBFlow(1, tt.svcIP, 80, tt.protocol, false)
+			flows := c.serviceSourceRangeFlows(1, tt.svcIP, 80, tt.protocol, false, sourceRanges)
+			// The source range of the other IP family is ignored.
+			require.Len(t, flows, 2)
+			allowFlow, dropFlow := flows[0], flows[1]
+			assert.Contains(t, allowFlow.MatchString(), tt.expectedAllow)
+			assert.Equal(t, serviceLBTable, allowFlow.GetTableID())
+			assert.Equal(t, serviceLBTable, dropFlow.GetTableID())
+			// The packets from the source ranges must be load-balanced before the other
+			// packets are dropped, and the packets from other sources must be dropped
+			// before being load-balanced by serviceLBFlow.
+			assert.Greater(t, allowFlow.FlowPriority(), dropFlow.FlowPriority())
+			assert.Greater(t, dropFlow.FlowPriority(), lbFlow.FlowPriority())
+			assert.NotContains(t, dropFlow.MatchString(), "_src=")
+		})
+	}
+}
+
+func TestServiceSourceRangeHandler(t *testing.T) {
+	metrics.InitializeServiceSourceRangeMetrics()
+	getCount := func() float64 {
+		count, err := testutil.GetCounterMetricValue(metrics.ServiceSourceRangeDroppedPacketCount)
+		require.NoError(t, err)
+		return count
+	}
+
+	countBefore := getCount()
+	pktIn := &ofctrl.PacketIn{Reason: uint8(PacketInReasonServiceSourceRange)}
+	require.NoError(t, (&serviceSourceRangeHandler{}).HandlePacketIn(pktIn))
+	assert.Equal(t, countBefore+1, getCount())
+}
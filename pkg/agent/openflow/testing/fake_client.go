@@ -0,0 +1,1008 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testing
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"antrea.io/antrea/pkg/agent/config"
+	"antrea.io/antrea/pkg/agent/types"
+	"antrea.io/antrea/pkg/apis/controlplane/v1beta2"
+	crdv1alpha1 "antrea.io/antrea/pkg/apis/crd/v1alpha1"
+	binding "antrea.io/antrea/pkg/ovs/openflow"
+	thirdpartynp "antrea.io/antrea/third_party/networkpolicy"
+	"antrea.io/antrea/third_party/proxy"
+)
+
+const (
+	// FakeTableID is the table in which FakeClient counts the flows which are not NetworkPolicy
+	// flows, as it doesn't model the tables of the pipeline.
+	FakeTableID binding.TableIDType = 0
+
+	// k8sRulePriority is the priority of the action flows of the K8s NetworkPolicy rules, which
+	// don't have a priority.
+	k8sRulePriority = uint16(190)
+	// portCacheReg is the register matched with the OVS port of the local Pods the ingress rules
+	// are applied to.
+	portCacheReg = 1
+
+	// The cache keys of the flows which are not NetworkPolicy flows. The keys of the Pod and Node
+	// flows are the interface names and the hostnames respectively.
+	policyBootstrapFlowsKey = "policy-bootstrap"
+)
+
+var tunnelVirtualMAC, _ = net.ParseMAC("aa:bb:cc:dd:ee:ff")
+
+// Packet describes a packet classified by FakeClient.Classify.
+type Packet struct {
+	// InPort is the OVS port of the local Pod sending the packet, 0 if it's not sent by a local Pod.
+	InPort int32
+	// OutPort is the OVS port of the local Pod receiving the packet, 0 if it's not received by a
+	// local Pod.
+	OutPort int32
+	SrcIP   net.IP
+	DstIP   net.IP
+	// Protocol is the transport protocol of the packet. It defaults to TCP.
+	Protocol v1beta2.Protocol
+	DstPort  uint16
+}
+
+// FakeClient is an in-memory implementation of the openflow Client interface, for the tests of the
+// agent controllers which need to assert the end state of the flows without a running OVS.
+//
+// The NetworkPolicy rules are kept in the same way as the real client: each rule is identified by
+// its FlowID, the conjunctive match flows are shared by the rules matching the same address or
+// port with the same priority, and the flow keys are formatted like the match strings of the real
+// flows. The K8s NetworkPolicy isolation flows are not reported by GetNetworkPolicyFlowKeys as
+// their tables are not modeled, but they are enforced by Classify. The flows of the Pods, Nodes
+// and Services are cached with the same keys as the real client, and are counted in FakeTableID.
+// The other methods succeed without effect.
+type FakeClient struct {
+	mutex       sync.RWMutex
+	ipv4Enabled bool
+	ipv6Enabled bool
+	connected   bool
+	// policyRules are copies of the installed PolicyRules, keyed by FlowID.
+	policyRules map[uint32]*types.PolicyRule
+	// flows maps the cache keys of the flows which are not NetworkPolicy flows to their keys.
+	flows map[string][]string
+	// groups maps the IDs of the Service groups to their Endpoints.
+	groups map[binding.GroupIDType][]proxy.Endpoint
+	// tableUpdateTimes are the times of the last update of each table.
+	tableUpdateTimes map[binding.TableIDType]time.Time
+}
+
+// NewFakeClient returns a FakeClient for a Node with the provided IP families enabled.
+func NewFakeClient(ipv4Enabled, ipv6Enabled bool) *FakeClient {
+	return &FakeClient{
+		ipv4Enabled:      ipv4Enabled,
+		ipv6Enabled:      ipv6Enabled,
+		connected:        true,
+		policyRules:      map[uint32]*types.PolicyRule{},
+		flows:            map[string][]string{},
+		groups:           map[binding.GroupIDType][]proxy.Endpoint{},
+		tableUpdateTimes: map[binding.TableIDType]time.Time{},
+	}
+}
+
+// PolicyRules returns copies of the installed PolicyRules, sorted by FlowID.
+func (c *FakeClient) PolicyRules() []*types.PolicyRule {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	rules := make([]*types.PolicyRule, 0, len(c.policyRules))
+	for _, rule := range c.policyRules {
+		rules = append(rules, copyPolicyRule(rule))
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].FlowID < rules[j].FlowID })
+	return rules
+}
+
+// Classify simulates the classification of a packet by the NetworkPolicy flows of a direction. It
+// returns whether the packet is allowed, and the rule which allowed or denied it. The rule is nil
+// if no rule applies to the packet, or if it's dropped by the isolation of K8s NetworkPolicies.
+// The Antrea-native policy rules are evaluated before the K8s NetworkPolicy rules, in decreasing
+// order of priority; the baseline Tier is not modeled.
+func (c *FakeClient) Classify(packet *Packet, direction v1beta2.Direction) (bool, *types.PolicyRule) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	var antreaRules, k8sRules []*types.PolicyRule
+	for _, rule := range c.sortedPolicyRulesLocked() {
+		if rule.Direction != direction {
+			continue
+		}
+		if rule.IsAntreaNetworkPolicyRule() {
+			antreaRules = append(antreaRules, rule)
+		} else {
+			k8sRules = append(k8sRules, rule)
+		}
+	}
+	sort.SliceStable(antreaRules, func(i, j int) bool {
+		return rulePriority(antreaRules[i]) > rulePriority(antreaRules[j])
+	})
+	for _, rule := range antreaRules {
+		if !ruleMatches(rule, packet) {
+			continue
+		}
+		action := crdv1alpha1.RuleActionAllow
+		if rule.Action != nil {
+			action = *rule.Action
+		}
+		if action == crdv1alpha1.RuleActionPass {
+			break
+		}
+		return action == crdv1alpha1.RuleActionAllow, copyPolicyRule(rule)
+	}
+	isolated := false
+	for _, rule := range k8sRules {
+		if ruleMatches(rule, packet) {
+			return true, copyPolicyRule(rule)
+		}
+		// The workloads the rule is applied to are isolated in the direction of the rule.
+		if direction == v1beta2.DirectionOut {
+			isolated = isolated || addressesMatch(rule.From, types.SrcAddress, packet)
+		} else {
+			isolated = isolated || addressesMatch(rule.To, types.DstAddress, packet)
+		}
+	}
+	return !isolated, nil
+}
+
+func (c *FakeClient) Initialize(roundInfo types.RoundInfo, config *config.NodeConfig, encapMode config.TrafficEncapModeType) (<-chan struct{}, error) {
+	return make(chan struct{}), nil
+}
+
+func (c *FakeClient) InstallGatewayFlows() error {
+	return nil
+}
+
+func (c *FakeClient) InstallClusterServiceCIDRFlows(serviceNets []*net.IPNet) error {
+	return nil
+}
+
+func (c *FakeClient) InstallClusterServiceFlows() error {
+	return nil
+}
+
+func (c *FakeClient) InstallDefaultTunnelFlows() error {
+	return nil
+}
+
+func (c *FakeClient) InstallNodeFlows(hostname string, peerConfigs map[*net.IPNet]net.IP, tunnelPeerIP net.IP, tunOFPort uint32, peerNodeMAC net.HardwareAddr) error {
+	var flowKeys []string
+	for peerPodCIDR, peerGatewayIP := range peerConfigs {
+		flowKeys = append(flowKeys, ipMatchString("dst", peerPodCIDR.String(), peerPodCIDR.IP.To4() != nil))
+		flowKeys = append(flowKeys, ipMatchString("dst", peerGatewayIP.String(), peerGatewayIP.To4() != nil))
+	}
+	sort.Strings(flowKeys)
+	c.setFlows(hostname, flowKeys)
+	return nil
+}
+
+func (c *FakeClient) UninstallNodeFlows(hostname string) error {
+	c.setFlows(hostname, nil)
+	return nil
+}
+
+func (c *FakeClient) InstallPodFlows(interfaceName string, podInterfaceIPs []net.IP, podInterfaceMAC net.HardwareAddr, ofPort uint32) error {
+	c.setFlows(interfaceName, podFlowKeys(podInterfaceIPs, podInterfaceMAC, ofPort))
+	return nil
+}
+
+func (c *FakeClient) UpdatePodFlows(interfaceName string, podInterfaceIPs []net.IP, podInterfaceMAC net.HardwareAddr, ofPort uint32) error {
+	return c.InstallPodFlows(interfaceName, podInterfaceIPs, podInterfaceMAC, ofPort)
+}
+
+func (c *FakeClient) InstallPodDHCPFlows(interfaceName string, podInterfaceMAC net.HardwareAddr, ofPort uint32) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.flows[interfaceName] = append(c.flows[interfaceName], fmt.Sprintf("udp,in_port=%d,dl_src=%s,tp_dst=0x43", ofPort, podInterfaceMAC))
+	c.tableUpdateTimes[FakeTableID] = time.Now()
+	return nil
+}
+
+func (c *FakeClient) InstallPodConnectionLimitFlows(interfaceName, podNamespace, podName string, ofPort uint32, limit uint32) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.flows[interfaceName] = append(c.flows[interfaceName], fmt.Sprintf("ip,in_port=%d,ct_state=+new+trk", ofPort))
+	c.tableUpdateTimes[FakeTableID] = time.Now()
+	return nil
+}
+
+func (c *FakeClient) MonitorPodConnectionLimits(enableLogging bool, stopCh <-chan struct{}) {
+}
+
+func (c *FakeClient) UninstallPodFlows(interfaceName string) error {
+	c.setFlows(interfaceName, nil)
+	return nil
+}
+
+func (c *FakeClient) InstallServiceGroup(groupID binding.GroupIDType, withSessionAffinity bool, endpoints []proxy.Endpoint) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.groups[groupID] = append([]proxy.Endpoint(nil), endpoints...)
+	return nil
+}
+
+func (c *FakeClient) UninstallServiceGroup(groupID binding.GroupIDType) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if _, exists := c.groups[groupID]; !exists {
+		return fmt.Errorf("group %d delete failed", groupID)
+	}
+	delete(c.groups, groupID)
+	return nil
+}
+
+// Groups returns the Endpoints of the installed Service groups, keyed by group ID.
+func (c *FakeClient) Groups() map[binding.GroupIDType][]proxy.Endpoint {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	groups := make(map[binding.GroupIDType][]proxy.Endpoint, len(c.groups))
+	for groupID, endpoints := range c.groups {
+		groups[groupID] = append([]proxy.Endpoint(nil), endpoints...)
+	}
+	return groups
+}
+
+func (c *FakeClient) InstallEndpointFlows(protocol binding.Protocol, endpoints []proxy.Endpoint) error {
+	for _, endpoint := range endpoints {
+		port, err := endpoint.Port()
+		if err != nil {
+			return fmt.Errorf("error when getting port: %w", err)
+		}
+		c.setFlows(endpointFlowCacheKey(endpoint.IP(), port, protocol), []string{
+			fmt.Sprintf("%s,reg3=0x%x,reg4=0x%x", protocol, ipToUint32(net.ParseIP(endpoint.IP())), port),
+		})
+	}
+	return nil
+}
+
+func (c *FakeClient) UninstallEndpointFlows(protocol binding.Protocol, endpoint proxy.Endpoint) error {
+	port, err := endpoint.Port()
+	if err != nil {
+		return fmt.Errorf("error when getting port: %w", err)
+	}
+	c.setFlows(endpointFlowCacheKey(endpoint.IP(), port, protocol), nil)
+	return nil
+}
+
+func (c *FakeClient) InstallServiceFlows(groupID binding.GroupIDType, svcIP net.IP, svcPort uint16, protocol binding.Protocol, affinityTimeout uint16) error {
+	if err := c.checkGroup(groupID); err != nil {
+		return err
+	}
+	c.setFlows(serviceFlowCacheKey(svcIP, svcPort, protocol), []string{serviceMatchString(svcIP, svcPort, protocol)})
+	return nil
+}
+
+func (c *FakeClient) UninstallServiceFlows(svcIP net.IP, svcPort uint16, protocol binding.Protocol) error {
+	c.setFlows(serviceFlowCacheKey(svcIP, svcPort, protocol), nil)
+	return nil
+}
+
+func (c *FakeClient) InstallServiceSourceRangeFlows(groupID binding.GroupIDType, svcIP net.IP, svcPort uint16, protocol binding.Protocol, affinityTimeout uint16, sourceRanges []net.IPNet) error {
+	if err := c.checkGroup(groupID); err != nil {
+		return err
+	}
+	flowKeys := make([]string, 0, len(sourceRanges))
+	for _, sourceRange := range sourceRanges {
+		src := "nw_src"
+		if sourceRange.IP.To4() == nil {
+			src = "ipv6_src"
+		}
+		flowKeys = append(flowKeys, fmt.Sprintf("%s,%s=%s", serviceMatchString(svcIP, svcPort, protocol), src, sourceRange.String()))
+	}
+	c.setFlows(serviceSourceRangeFlowCacheKey(svcIP, svcPort, protocol), flowKeys)
+	return nil
+}
+
+func (c *FakeClient) UninstallServiceSourceRangeFlows(svcIP net.IP, svcPort uint16, protocol binding.Protocol) error {
+	c.setFlows(serviceSourceRangeFlowCacheKey(svcIP, svcPort, protocol), nil)
+	return nil
+}
+
+func (c *FakeClient) InstallLoadBalancerServiceFromOutsideFlows(svcIP net.IP, svcPort uint16, protocol binding.Protocol, disableSNAT bool) error {
+	return nil
+}
+
+func (c *FakeClient) UninstallLoadBalancerServiceFromOutsideFlows(svcIP net.IP, svcPort uint16, protocol binding.Protocol) error {
+	return nil
+}
+
+func (c *FakeClient) GetFlowTableStatus() []binding.TableStatus {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	flowCounts := map[binding.TableIDType]uint{}
+	for tableID := range c.tableUpdateTimes {
+		flowCounts[tableID] = 0
+	}
+	for _, flowKeys := range c.flows {
+		flowCounts[FakeTableID] += uint(len(flowKeys))
+	}
+	// The conjunctive match flows are shared by the rules of a table matching the same address
+	// or port with the same priority.
+	matchFlows := map[string]bool{}
+	for _, rule := range c.policyRules {
+		actionFlowKeys, matchFlowKeys := c.policyRuleFlowKeysLocked(rule)
+		flowCounts[rule.TableID] += uint(len(actionFlowKeys))
+		for _, key := range matchFlowKeys {
+			globalKey := fmt.Sprintf("%s,priority=%d", key, rulePriority(rule))
+			if !matchFlows[globalKey] {
+				matchFlows[globalKey] = true
+				flowCounts[rule.TableID]++
+			}
+		}
+	}
+	tableStatus := make([]binding.TableStatus, 0, len(flowCounts))
+	for tableID, flowCount := range flowCounts {
+		tableStatus = append(tableStatus, binding.TableStatus{
+			ID:         uint(tableID),
+			FlowCount:  flowCount,
+			UpdateTime: c.tableUpdateTimes[tableID],
+		})
+	}
+	sort.Slice(tableStatus, func(i, j int) bool { return tableStatus[i].ID < tableStatus[j].ID })
+	return tableStatus
+}
+
+func (c *FakeClient) GetPipeline() []binding.Table {
+	return nil
+}
+
+func (c *FakeClient) GetOVSCapabilities() binding.Capabilities {
+	return binding.Capabilities{}
+}
+
+func (c *FakeClient) InstallPolicyRuleFlows(ofPolicyRule *types.PolicyRule) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.installPolicyRuleLocked(ofPolicyRule)
+	return nil
+}
+
+func (c *FakeClient) BatchInstallPolicyRuleFlows(ofPolicyRules []*types.PolicyRule) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for _, rule := range ofPolicyRules {
+		c.installPolicyRuleLocked(rule)
+	}
+	return nil
+}
+
+func (c *FakeClient) InstallPolicyBootstrapFlows(allowlist []types.PolicyBootstrapPeer) error {
+	flowKeys := make([]string, 0, len(allowlist))
+	for _, peer := range allowlist {
+		flowKeys = append(flowKeys, ipMatchString("src", peer.IPNet.String(), peer.IPNet.IP.To4() != nil))
+	}
+	c.setFlows(policyBootstrapFlowsKey, flowKeys)
+	return nil
+}
+
+func (c *FakeClient) UninstallPolicyBootstrapFlows() error {
+	c.setFlows(policyBootstrapFlowsKey, nil)
+	return nil
+}
+
+func (c *FakeClient) UninstallPolicyRuleFlows(ruleID uint32) ([]string, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	rule, exists := c.policyRules[ruleID]
+	if !exists {
+		return nil, nil
+	}
+	delete(c.policyRules, ruleID)
+	c.tableUpdateTimes[rule.TableID] = time.Now()
+	// Like the real client, the priorities of the Antrea-native policy rules which are no longer
+	// used in the table are stale.
+	if rule.Priority == nil {
+		return nil, nil
+	}
+	for _, r := range c.policyRules {
+		if r.TableID == rule.TableID && r.Priority != nil && *r.Priority == *rule.Priority {
+			return nil, nil
+		}
+	}
+	return []string{strconv.Itoa(int(*rule.Priority))}, nil
+}
+
+func (c *FakeClient) UninstallPolicyRuleFlowsAsync(ruleID uint32) <-chan types.PolicyRuleUninstallResult {
+	ch := make(chan types.PolicyRuleUninstallResult, 1)
+	stalePriorities, err := c.UninstallPolicyRuleFlows(ruleID)
+	ch <- types.PolicyRuleUninstallResult{StalePriorities: stalePriorities, Err: err}
+	return ch
+}
+
+func (c *FakeClient) AddPolicyRuleAddress(ruleID uint32, addrType types.AddressType, addresses []types.Address, priority *uint16) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	rule, err := c.getAddressClauseLocked(ruleID, addrType)
+	if err != nil {
+		return err
+	}
+	existing := rule.To
+	if addrType == types.SrcAddress {
+		existing = rule.From
+	}
+	for _, addr := range addresses {
+		if indexOfAddress(existing, addr, addrType) < 0 {
+			existing = append(existing, addr)
+		}
+	}
+	if addrType == types.SrcAddress {
+		rule.From = existing
+	} else {
+		rule.To = existing
+	}
+	c.tableUpdateTimes[rule.TableID] = time.Now()
+	return nil
+}
+
+func (c *FakeClient) DeletePolicyRuleAddress(ruleID uint32, addrType types.AddressType, addresses []types.Address, priority *uint16) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	rule, err := c.getAddressClauseLocked(ruleID, addrType)
+	if err != nil {
+		return err
+	}
+	existing := rule.To
+	if addrType == types.SrcAddress {
+		existing = rule.From
+	}
+	for _, addr := range addresses {
+		if i := indexOfAddress(existing, addr, addrType); i >= 0 {
+			existing = append(existing[:i], existing[i+1:]...)
+		}
+	}
+	if addrType == types.SrcAddress {
+		rule.From = existing
+	} else {
+		rule.To = existing
+	}
+	c.tableUpdateTimes[rule.TableID] = time.Now()
+	return nil
+}
+
+func (c *FakeClient) InstallBridgeUplinkFlows() error {
+	return nil
+}
+
+func (c *FakeClient) InstallExternalFlows() error {
+	return nil
+}
+
+func (c *FakeClient) InstallSNATMarkFlows(snatIP net.IP, mark uint32) error {
+	c.setFlows(fmt.Sprintf("s%x", mark), []string{fmt.Sprintf("pkt_mark=%d", mark)})
+	return nil
+}
+
+func (c *FakeClient) UninstallSNATMarkFlows(mark uint32) error {
+	c.setFlows(fmt.Sprintf("s%x", mark), nil)
+	return nil
+}
+
+func (c *FakeClient) InstallPodSNATFlows(ofPort uint32, snatIP net.IP, snatMark uint32) error {
+	c.setFlows(fmt.Sprintf("p%x", ofPort), []string{fmt.Sprintf("in_port=%d", ofPort)})
+	return nil
+}
+
+func (c *FakeClient) UninstallPodSNATFlows(ofPort uint32) error {
+	c.setFlows(fmt.Sprintf("p%x", ofPort), nil)
+	return nil
+}
+
+func (c *FakeClient) Disconnect() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.connected = false
+	return nil
+}
+
+func (c *FakeClient) IsConnected() bool {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.connected
+}
+
+func (c *FakeClient) ReplayFlows() {
+}
+
+func (c *FakeClient) DeleteStaleFlows() error {
+	return nil
+}
+
+func (c *FakeClient) GetTunnelVirtualMAC() net.HardwareAddr {
+	return tunnelVirtualMAC
+}
+
+func (c *FakeClient) GetPodFlowKeys(interfaceName string) []string {
+	return c.getFlows(interfaceName)
+}
+
+func (c *FakeClient) GetServiceFlowKeys(svcIP net.IP, svcPort uint16, protocol binding.Protocol, endpoints []proxy.Endpoint) []string {
+	flowKeys := c.getFlows(serviceFlowCacheKey(svcIP, svcPort, protocol))
+	flowKeys = append(flowKeys, c.getFlows(serviceSourceRangeFlowCacheKey(svcIP, svcPort, protocol))...)
+	for _, endpoint := range endpoints {
+		port, _ := endpoint.Port()
+		flowKeys = append(flowKeys, c.getFlows(endpointFlowCacheKey(endpoint.IP(), port, protocol))...)
+	}
+	return flowKeys
+}
+
+func (c *FakeClient) GetEndpointDNATFlowKey(protocol binding.Protocol, endpoint proxy.Endpoint) string {
+	port, err := endpoint.Port()
+	if err != nil {
+		return ""
+	}
+	flowKeys := c.getFlows(endpointFlowCacheKey(endpoint.IP(), port, protocol))
+	if len(flowKeys) == 0 {
+		return ""
+	}
+	return flowKeys[0]
+}
+
+func (c *FakeClient) GetNetworkPolicyFlowKeys(npName, npNamespace string) []string {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	flowKeys := []string{}
+	for _, rule := range c.sortedPolicyRulesLocked() {
+		if rule.PolicyRef.Name != npName || rule.PolicyRef.Namespace != npNamespace {
+			continue
+		}
+		actionFlowKeys, matchFlowKeys := c.policyRuleFlowKeysLocked(rule)
+		flowKeys = append(flowKeys, actionFlowKeys...)
+		flowKeys = append(flowKeys, matchFlowKeys...)
+	}
+	return flowKeys
+}
+
+func (c *FakeClient) ReassignFlowPriorities(updates map[uint16]uint16, table binding.TableIDType) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for _, rule := range c.policyRules {
+		if rule.TableID != table || rule.Priority == nil {
+			continue
+		}
+		if newPriority, exists := updates[*rule.Priority]; exists {
+			rule.Priority = &newPriority
+		}
+	}
+	c.tableUpdateTimes[table] = time.Now()
+	return nil
+}
+
+func (c *FakeClient) SubscribePacketIn(reason uint8, pktInQueue *binding.PacketInQueue) error {
+	return nil
+}
+
+func (c *FakeClient) SendTraceflowPacket(dataplaneTag uint8, packet *binding.Packet, inPort uint32, outPort int32) error {
+	return nil
+}
+
+func (c *FakeClient) InstallTraceflowFlows(dataplaneTag uint8, liveTraffic, droppedOnly, receiverOnly bool, packet *binding.Packet, ofPort uint32, timeoutSeconds uint16) error {
+	return nil
+}
+
+func (c *FakeClient) UninstallTraceflowFlows(dataplaneTag uint8) error {
+	return nil
+}
+
+func (c *FakeClient) InstallPacketCaptureFlows(name string, packet *binding.Packet, timeoutSeconds uint16) error {
+	return nil
+}
+
+func (c *FakeClient) UninstallPacketCaptureFlows(name string) error {
+	return nil
+}
+
+func (c *FakeClient) InitialTLVMap() error {
+	return nil
+}
+
+func (c *FakeClient) GetPolicyInfoFromConjunction(ruleID uint32) (string, string, string) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	rule, exists := c.policyRules[ruleID]
+	// Like the real client, no policy is found for the rules without action flows.
+	if !exists || numClauses(rule) <= 1 {
+		return "", "", ""
+	}
+	return rule.PolicyRef.ToString(), strconv.Itoa(int(rulePriority(rule))), rule.Name
+}
+
+func (c *FakeClient) EnableK8sIsolationLogging(ingress, egress bool) {
+}
+
+func (c *FakeClient) ConfigureNDGuard(enable, enableLogging bool) {
+}
+
+func (c *FakeClient) ConfigureConnectionProbe(interval time.Duration, failureThreshold int) {
+}
+
+func (c *FakeClient) ConfigureServiceLoopGuard(enableLogging bool) {
+}
+
+func (c *FakeClient) RegisterPacketInHandler(packetHandlerReason uint8, packetHandlerName string, packetInHandler interface{}) {
+}
+
+func (c *FakeClient) StartPacketInHandler(packetInStartedReason []uint8, stopCh <-chan struct{}) {
+}
+
+func (c *FakeClient) ShrinkPacketInQueues(shrink bool) int {
+	return 0
+}
+
+func (c *FakeClient) NetworkPolicyMetrics() map[uint32]*types.RuleMetric {
+	return map[uint32]*types.RuleMetric{}
+}
+
+func (c *FakeClient) IsIPv4Enabled() bool {
+	return c.ipv4Enabled
+}
+
+func (c *FakeClient) IsIPv6Enabled() bool {
+	return c.ipv6Enabled
+}
+
+func (c *FakeClient) SendTCPPacketOut(srcMAC string, dstMAC string, srcIP string, dstIP string, inPort uint32, outPort int32, isIPv6 bool, tcpSrcPort uint16, tcpDstPort uint16, tcpAckNum uint32, tcpFlag uint8, isReject bool) error {
+	return nil
+}
+
+func (c *FakeClient) SendICMPPacketOut(srcMAC string, dstMAC string, srcIP string, dstIP string, inPort uint32, outPort int32, isIPv6 bool, icmpType uint8, icmpCode uint8, icmpData []byte, isReject bool) error {
+	return nil
+}
+
+func (c *FakeClient) SendIPAnnouncementPacketOut(mac net.HardwareAddr, ip net.IP, inPort uint32, outPort uint32) error {
+	return nil
+}
+
+func (c *FakeClient) EnableFlowChangeTracking(size int) {
+}
+
+func (c *FakeClient) GetFlowChanges() []types.FlowChange {
+	return nil
+}
+
+// installPolicyRuleLocked stores a copy of a PolicyRule. Like the real client, a rule which is
+// already installed is skipped. The caller must hold the mutex.
+func (c *FakeClient) installPolicyRuleLocked(rule *types.PolicyRule) {
+	if _, exists := c.policyRules[rule.FlowID]; exists {
+		return
+	}
+	c.policyRules[rule.FlowID] = copyPolicyRule(rule)
+	c.tableUpdateTimes[rule.TableID] = time.Now()
+}
+
+// getAddressClauseLocked returns the installed rule whose addresses of addrType are updated. The
+// caller must hold the mutex.
+func (c *FakeClient) getAddressClauseLocked(ruleID uint32, addrType types.AddressType) (*types.PolicyRule, error) {
+	rule, exists := c.policyRules[ruleID]
+	if !exists {
+		return nil, fmt.Errorf("policyRuleConjunction with ID %d not found", ruleID)
+	}
+	if (addrType == types.SrcAddress && rule.From == nil) || (addrType == types.DstAddress && rule.To == nil) {
+		return nil, fmt.Errorf("no clause is using addrType %d", addrType)
+	}
+	return rule, nil
+}
+
+// sortedPolicyRulesLocked returns the installed rules sorted by FlowID. The caller must hold the
+// mutex.
+func (c *FakeClient) sortedPolicyRulesLocked() []*types.PolicyRule {
+	rules := make([]*types.PolicyRule, 0, len(c.policyRules))
+	for _, rule := range c.policyRules {
+		rules = append(rules, rule)
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].FlowID < rules[j].FlowID })
+	return rules
+}
+
+// policyRuleFlowKeysLocked returns the keys of the action flows and of the conjunctive match flows
+// of a rule. Like the real client, no flow is installed for a rule with a single clause. The
+// caller must hold the mutex.
+func (c *FakeClient) policyRuleFlowKeysLocked(rule *types.PolicyRule) ([]string, []string) {
+	if numClauses(rule) <= 1 {
+		return nil, nil
+	}
+	var actionFlowKeys, matchFlowKeys []string
+	table := fmt.Sprintf("table=%d", rule.TableID)
+	if rule.Action == nil || *rule.Action == crdv1alpha1.RuleActionAllow {
+		for _, protocol := range c.ipProtocols() {
+			actionFlowKeys = append(actionFlowKeys, fmt.Sprintf("%s,%s,conj_id=%d", table, protocol, rule.FlowID))
+		}
+	} else {
+		actionFlowKeys = append(actionFlowKeys, fmt.Sprintf("%s,conj_id=%d", table, rule.FlowID))
+	}
+	for _, addr := range rule.From {
+		matchFlowKeys = append(matchFlowKeys, table+","+addressMatchString(addr, types.SrcAddress))
+	}
+	for _, addr := range rule.To {
+		matchFlowKeys = append(matchFlowKeys, table+","+addressMatchString(addr, types.DstAddress))
+	}
+	for _, service := range rule.Service {
+		for _, key := range c.serviceMatchStrings(service) {
+			matchFlowKeys = append(matchFlowKeys, table+","+key)
+		}
+	}
+	return actionFlowKeys, matchFlowKeys
+}
+
+// serviceMatchStrings returns the match strings of the conjunctive match flows of a Service, for
+// each enabled IP family and for each bitwise match of its port range.
+func (c *FakeClient) serviceMatchStrings(service v1beta2.Service) []string {
+	var protocols []binding.Protocol
+	switch serviceProtocol(service.Protocol) {
+	case v1beta2.ProtocolUDP:
+		protocols = c.filterIPFamilies(binding.ProtocolUDP, binding.ProtocolUDPv6)
+	case v1beta2.ProtocolSCTP:
+		protocols = c.filterIPFamilies(binding.ProtocolSCTP, binding.ProtocolSCTPv6)
+	default:
+		protocols = c.filterIPFamilies(binding.ProtocolTCP, binding.ProtocolTCPv6)
+	}
+	var portMatches []string
+	if service.Port != nil && service.EndPort != nil && *service.EndPort > service.Port.IntVal {
+		portRange := thirdpartynp.PortRange{Start: uint16(service.Port.IntVal), End: uint16(*service.EndPort)}
+		bitRanges, _ := portRange.BitwiseMatch()
+		for _, bitRange := range bitRanges {
+			portMatches = append(portMatches, fmt.Sprintf(",tp_dst=0x%x/0x%x", bitRange.Value, bitRange.Mask))
+		}
+	} else if service.Port != nil && service.Port.IntVal > 0 {
+		portMatches = append(portMatches, fmt.Sprintf(",tp_dst=0x%x", service.Port.IntVal))
+	} else {
+		portMatches = append(portMatches, "")
+	}
+	var matchStrings []string
+	for _, protocol := range protocols {
+		for _, portMatch := range portMatches {
+			matchStrings = append(matchStrings, string(protocol)+portMatch)
+		}
+	}
+	return matchStrings
+}
+
+func (c *FakeClient) filterIPFamilies(ipv4Protocol, ipv6Protocol binding.Protocol) []binding.Protocol {
+	var protocols []binding.Protocol
+	if c.ipv4Enabled {
+		protocols = append(protocols, ipv4Protocol)
+	}
+	if c.ipv6Enabled {
+		protocols = append(protocols, ipv6Protocol)
+	}
+	return protocols
+}
+
+func (c *FakeClient) ipProtocols() []binding.Protocol {
+	return c.filterIPFamilies(binding.ProtocolIP, binding.ProtocolIPv6)
+}
+
+func (c *FakeClient) checkGroup(groupID binding.GroupIDType) error {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	if _, exists := c.groups[groupID]; !exists {
+		return fmt.Errorf("group %d is not installed", groupID)
+	}
+	return nil
+}
+
+// setFlows replaces the flows cached with a key, or deletes them if flowKeys is empty.
+func (c *FakeClient) setFlows(cacheKey string, flowKeys []string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if len(flowKeys) == 0 {
+		if _, exists := c.flows[cacheKey]; !exists {
+			return
+		}
+		delete(c.flows, cacheKey)
+	} else {
+		c.flows[cacheKey] = flowKeys
+	}
+	c.tableUpdateTimes[FakeTableID] = time.Now()
+}
+
+func (c *FakeClient) getFlows(cacheKey string) []string {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return append([]string(nil), c.flows[cacheKey]...)
+}
+
+func podFlowKeys(podInterfaceIPs []net.IP, podInterfaceMAC net.HardwareAddr, ofPort uint32) []string {
+	flowKeys := []string{
+		fmt.Sprintf("in_port=%d", ofPort),
+		fmt.Sprintf("dl_dst=%s", podInterfaceMAC),
+	}
+	for _, ip := range podInterfaceIPs {
+		isIPv4 := ip.To4() != nil
+		flowKeys = append(flowKeys,
+			fmt.Sprintf("%s,in_port=%d,dl_src=%s", ipMatchString("src", ip.String(), isIPv4), ofPort, podInterfaceMAC),
+			ipMatchString("dst", ip.String(), isIPv4))
+	}
+	return flowKeys
+}
+
+// ipMatchString returns the match string of a source or destination IP address or CIDR, with the
+// protocol of its IP family.
+func ipMatchString(direction, ip string, isIPv4 bool) string {
+	if isIPv4 {
+		return fmt.Sprintf("ip,nw_%s=%s", direction, ip)
+	}
+	return fmt.Sprintf("ipv6,ipv6_%s=%s", direction, ip)
+}
+
+func serviceMatchString(svcIP net.IP, svcPort uint16, protocol binding.Protocol) string {
+	dst := "nw_dst"
+	if svcIP.To4() == nil {
+		dst = "ipv6_dst"
+	}
+	return fmt.Sprintf("%s,%s=%s,tp_dst=0x%x", protocol, dst, svcIP, svcPort)
+}
+
+// The cache keys of the Service and Endpoint flows are the same as the ones of the real client.
+
+func endpointFlowCacheKey(endpointIP string, endpointPort int, protocol binding.Protocol) string {
+	return fmt.Sprintf("E%s%s%x", endpointIP, protocol, endpointPort)
+}
+
+func serviceFlowCacheKey(svcIP net.IP, svcPort uint16, protocol binding.Protocol) string {
+	return fmt.Sprintf("S%s%s%x", svcIP, protocol, svcPort)
+}
+
+func serviceSourceRangeFlowCacheKey(svcIP net.IP, svcPort uint16, protocol binding.Protocol) string {
+	return fmt.Sprintf("R%s%s%x", svcIP, protocol, svcPort)
+}
+
+func ipToUint32(ip net.IP) uint32 {
+	ipv4 := ip.To4()
+	if ipv4 == nil {
+		return 0
+	}
+	return uint32(ipv4[0])<<24 | uint32(ipv4[1])<<16 | uint32(ipv4[2])<<8 | uint32(ipv4[3])
+}
+
+// addressMatchString returns the match string of the conjunctive match flow of an address, in the
+// same format as the real client.
+func addressMatchString(addr types.Address, addrType types.AddressType) string {
+	matchKey := addr.GetMatchKey(addrType)
+	if matchKey.GetValueCategory() == types.OFPortAddr {
+		if addrType == types.SrcAddress {
+			return fmt.Sprintf("in_port=%s", addr.GetMatchValue())
+		}
+		return fmt.Sprintf("reg%d=0x%x", portCacheReg, addr.GetValue().(int32))
+	}
+	return fmt.Sprintf("%s,%s=%s", matchKey.GetOFProtocol(), matchKey.GetKeyString(), addr.GetMatchValue())
+}
+
+func indexOfAddress(addresses []types.Address, addr types.Address, addrType types.AddressType) int {
+	for i, a := range addresses {
+		if addressMatchString(a, addrType) == addressMatchString(addr, addrType) {
+			return i
+		}
+	}
+	return -1
+}
+
+func copyPolicyRule(rule *types.PolicyRule) *types.PolicyRule {
+	ruleCopy := *rule
+	if rule.From != nil {
+		ruleCopy.From = append([]types.Address{}, rule.From...)
+	}
+	if rule.To != nil {
+		ruleCopy.To = append([]types.Address{}, rule.To...)
+	}
+	if rule.Service != nil {
+		ruleCopy.Service = append([]v1beta2.Service{}, rule.Service...)
+	}
+	if rule.Priority != nil {
+		priority := *rule.Priority
+		ruleCopy.Priority = &priority
+	}
+	return &ruleCopy
+}
+
+func numClauses(rule *types.PolicyRule) int {
+	n := 0
+	if rule.From != nil {
+		n++
+	}
+	if rule.To != nil {
+		n++
+	}
+	if rule.Service != nil {
+		n++
+	}
+	return n
+}
+
+func rulePriority(rule *types.PolicyRule) uint16 {
+	if rule.Priority == nil {
+		return k8sRulePriority
+	}
+	return *rule.Priority
+}
+
+func serviceProtocol(protocol *v1beta2.Protocol) v1beta2.Protocol {
+	if protocol == nil {
+		return v1beta2.ProtocolTCP
+	}
+	return *protocol
+}
+
+// ruleMatches returns whether a packet matches all the clauses of a rule. A nil clause matches all
+// packets, an empty clause matches none.
+func ruleMatches(rule *types.PolicyRule, packet *Packet) bool {
+	if numClauses(rule) <= 1 {
+		return false
+	}
+	if rule.From != nil && !addressesMatch(rule.From, types.SrcAddress, packet) {
+		return false
+	}
+	if rule.To != nil && !addressesMatch(rule.To, types.DstAddress, packet) {
+		return false
+	}
+	if rule.Service != nil && !servicesMatch(rule.Service, packet) {
+		return false
+	}
+	return true
+}
+
+func addressesMatch(addresses []types.Address, addrType types.AddressType, packet *Packet) bool {
+	ip, ofPort := packet.DstIP, packet.OutPort
+	if addrType == types.SrcAddress {
+		ip, ofPort = packet.SrcIP, packet.InPort
+	}
+	for _, addr := range addresses {
+		switch value := addr.GetValue().(type) {
+		case int32:
+			if ofPort != 0 && value == ofPort {
+				return true
+			}
+		case net.IP:
+			if value.Equal(ip) {
+				return true
+			}
+		case net.IPNet:
+			if ip != nil && value.Contains(ip) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func servicesMatch(services []v1beta2.Service, packet *Packet) bool {
+	protocol := packet.Protocol
+	if protocol == "" {
+		protocol = v1beta2.ProtocolTCP
+	}
+	for _, service := range services {
+		if serviceProtocol(service.Protocol) != protocol {
+			continue
+		}
+		// A named port which could not be resolved matches all ports, like in the real client.
+		if service.Port == nil || service.Port.IntVal == 0 {
+			return true
+		}
+		endPort := service.Port.IntVal
+		if service.EndPort != nil && *service.EndPort > endPort {
+			endPort = *service.EndPort
+		}
+		if int32(packet.DstPort) >= service.Port.IntVal && int32(packet.DstPort) <= endPort {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,186 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testing
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"antrea.io/antrea/pkg/agent/openflow"
+	"antrea.io/antrea/pkg/agent/types"
+	"antrea.io/antrea/pkg/apis/controlplane/v1beta2"
+	crdv1alpha1 "antrea.io/antrea/pkg/apis/crd/v1alpha1"
+)
+
+var _ openflow.Client = &FakeClient{}
+
+var (
+	np1 = &v1beta2.NetworkPolicyReference{Type: v1beta2.K8sNetworkPolicy, Namespace: "ns1", Name: "np1"}
+	np2 = &v1beta2.NetworkPolicyReference{Type: v1beta2.AntreaNetworkPolicy, Namespace: "ns1", Name: "np2"}
+
+	port80  = intstr.FromInt(80)
+	service = v1beta2.Service{Port: &port80}
+)
+
+func TestPodFlowKeys(t *testing.T) {
+	c := NewFakeClient(true, false)
+	mac, _ := net.ParseMAC("aa:bb:cc:dd:ee:01")
+	require.NoError(t, c.InstallPodFlows("pod1-abcd", []net.IP{net.ParseIP("10.0.0.2")}, mac, 3))
+	assert.ElementsMatch(t, []string{
+		"in_port=3",
+		"dl_dst=aa:bb:cc:dd:ee:01",
+		"ip,nw_src=10.0.0.2,in_port=3,dl_src=aa:bb:cc:dd:ee:01",
+		"ip,nw_dst=10.0.0.2",
+	}, c.GetPodFlowKeys("pod1-abcd"))
+	require.Len(t, c.GetFlowTableStatus(), 1)
+	assert.Equal(t, uint(4), c.GetFlowTableStatus()[0].FlowCount)
+
+	require.NoError(t, c.UninstallPodFlows("pod1-abcd"))
+	assert.Empty(t, c.GetPodFlowKeys("pod1-abcd"))
+	assert.Equal(t, uint(0), c.GetFlowTableStatus()[0].FlowCount)
+}
+
+func TestPolicyRuleFlows(t *testing.T) {
+	c := NewFakeClient(true, false)
+	priority := uint16(100)
+	drop := crdv1alpha1.RuleActionDrop
+	k8sRule := &types.PolicyRule{
+		Direction: v1beta2.DirectionIn,
+		From:      []types.Address{openflow.NewIPAddress(net.ParseIP("1.1.1.1"))},
+		To:        []types.Address{openflow.NewOFPortAddress(1)},
+		Service:   []v1beta2.Service{service},
+		FlowID:    1,
+		TableID:   openflow.IngressRuleTable,
+		PolicyRef: np1,
+	}
+	antreaRule := &types.PolicyRule{
+		Direction: v1beta2.DirectionIn,
+		From:      []types.Address{openflow.NewIPAddress(net.ParseIP("1.1.1.2"))},
+		To:        []types.Address{openflow.NewOFPortAddress(1)},
+		Action:    &drop,
+		Priority:  &priority,
+		FlowID:    2,
+		TableID:   openflow.AntreaPolicyIngressRuleTable,
+		PolicyRef: np2,
+	}
+	require.NoError(t, c.BatchInstallPolicyRuleFlows([]*types.PolicyRule{k8sRule, antreaRule}))
+
+	assert.Equal(t, []string{
+		"table=90,ip,conj_id=1",
+		"table=90,ip,nw_src=1.1.1.1",
+		"table=90,reg1=0x1",
+		"table=90,tcp,tp_dst=0x50",
+	}, c.GetNetworkPolicyFlowKeys("np1", "ns1"))
+	assert.Equal(t, []string{
+		"table=85,conj_id=2",
+		"table=85,ip,nw_src=1.1.1.2",
+		"table=85,reg1=0x1",
+	}, c.GetNetworkPolicyFlowKeys("np2", "ns1"))
+
+	npRef, ofPriority, _ := c.GetPolicyInfoFromConjunction(1)
+	assert.Equal(t, np1.ToString(), npRef)
+	assert.Equal(t, "190", ofPriority)
+	npRef, ofPriority, _ = c.GetPolicyInfoFromConjunction(2)
+	assert.Equal(t, np2.ToString(), npRef)
+	assert.Equal(t, "100", ofPriority)
+
+	require.NoError(t, c.AddPolicyRuleAddress(1, types.SrcAddress, []types.Address{openflow.NewIPAddress(net.ParseIP("1.1.1.3"))}, nil))
+	assert.Contains(t, c.GetNetworkPolicyFlowKeys("np1", "ns1"), "table=90,ip,nw_src=1.1.1.3")
+	assert.EqualError(t, c.AddPolicyRuleAddress(3, types.SrcAddress, nil, nil), "policyRuleConjunction with ID 3 not found")
+
+	require.NoError(t, c.ReassignFlowPriorities(map[uint16]uint16{100: 101}, openflow.AntreaPolicyIngressRuleTable))
+	stalePriorities, err := c.UninstallPolicyRuleFlows(2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"101"}, stalePriorities)
+	assert.Empty(t, c.GetNetworkPolicyFlowKeys("np2", "ns1"))
+	assert.Len(t, c.PolicyRules(), 1)
+}
+
+func TestClassify(t *testing.T) {
+	c := NewFakeClient(true, false)
+	priority := uint16(100)
+	drop := crdv1alpha1.RuleActionDrop
+	k8sRule := &types.PolicyRule{
+		Direction: v1beta2.DirectionIn,
+		From:      []types.Address{openflow.NewIPNetAddress(*newCIDR("10.0.0.0/24"))},
+		To:        []types.Address{openflow.NewOFPortAddress(1)},
+		Service:   []v1beta2.Service{service},
+		FlowID:    1,
+		TableID:   openflow.IngressRuleTable,
+		PolicyRef: np1,
+	}
+	antreaRule := &types.PolicyRule{
+		Direction: v1beta2.DirectionIn,
+		From:      []types.Address{openflow.NewIPAddress(net.ParseIP("10.0.0.2"))},
+		To:        []types.Address{openflow.NewOFPortAddress(1)},
+		Action:    &drop,
+		Priority:  &priority,
+		FlowID:    2,
+		TableID:   openflow.AntreaPolicyIngressRuleTable,
+		PolicyRef: np2,
+	}
+	require.NoError(t, c.BatchInstallPolicyRuleFlows([]*types.PolicyRule{k8sRule, antreaRule}))
+
+	tests := []struct {
+		name            string
+		packet          *Packet
+		expectedAllowed bool
+		expectedFlowID  uint32
+	}{
+		{
+			name:            "allowed by K8s rule",
+			packet:          &Packet{OutPort: 1, SrcIP: net.ParseIP("10.0.0.1"), DstPort: 80},
+			expectedAllowed: true,
+			expectedFlowID:  1,
+		},
+		{
+			name:            "dropped by Antrea rule",
+			packet:          &Packet{OutPort: 1, SrcIP: net.ParseIP("10.0.0.2"), DstPort: 80},
+			expectedAllowed: false,
+			expectedFlowID:  2,
+		},
+		{
+			name:            "isolated by K8s NetworkPolicy",
+			packet:          &Packet{OutPort: 1, SrcIP: net.ParseIP("10.0.0.1"), DstPort: 443},
+			expectedAllowed: false,
+		},
+		{
+			name:            "not selected",
+			packet:          &Packet{OutPort: 2, SrcIP: net.ParseIP("10.0.0.1"), DstPort: 443},
+			expectedAllowed: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			allowed, rule := c.Classify(tt.packet, v1beta2.DirectionIn)
+			assert.Equal(t, tt.expectedAllowed, allowed)
+			if tt.expectedFlowID == 0 {
+				assert.Nil(t, rule)
+			} else {
+				require.NotNil(t, rule)
+				assert.Equal(t, tt.expectedFlowID, rule.FlowID)
+			}
+		})
+	}
+}
+
+func newCIDR(cidrStr string) *net.IPNet {
+	_, tmpIPNet, _ := net.ParseCIDR(cidrStr)
+	return tmpIPNet
+}
@@ -14,7 +14,7 @@
 //
 
 // Code generated by MockGen. DO NOT EDIT.
-// Source: antrea.io/antrea/pkg/agent/openflow (interfaces: Client,OFEntryOperations)
+// Source: antrea.io/antrea/pkg/agent/openflow (interfaces: Client,OFEntryOperations,PacketInClient,PacketOutClient,PodConnectivityClient,PolicyClient,ServiceClient,TraceflowClient)
 
 // Package testing is a generated GoMock package.
 package testing
@@ -27,6 +27,7 @@ import (
 	gomock "github.com/golang/mock/gomock"
 	net "net"
 	reflect "reflect"
+	time "time"
 )
 
 // MockClient is a mock of Client interface
@@ -80,6 +81,42 @@ func (mr *MockClientMockRecorder) BatchInstallPolicyRuleFlows(arg0 interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BatchInstallPolicyRuleFlows", reflect.TypeOf((*MockClient)(nil).BatchInstallPolicyRuleFlows), arg0)
 }
 
+// ConfigureConnectionProbe mocks base method
+func (m *MockClient) ConfigureConnectionProbe(arg0 time.Duration, arg1 int) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ConfigureConnectionProbe", arg0, arg1)
+}
+
+// ConfigureConnectionProbe indicates an expected call of ConfigureConnectionProbe
+func (mr *MockClientMockRecorder) ConfigureConnectionProbe(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfigureConnectionProbe", reflect.TypeOf((*MockClient)(nil).ConfigureConnectionProbe), arg0, arg1)
+}
+
+// ConfigureNDGuard mocks base method
+func (m *MockClient) ConfigureNDGuard(arg0, arg1 bool) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ConfigureNDGuard", arg0, arg1)
+}
+
+// ConfigureNDGuard indicates an expected call of ConfigureNDGuard
+func (mr *MockClientMockRecorder) ConfigureNDGuard(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfigureNDGuard", reflect.TypeOf((*MockClient)(nil).ConfigureNDGuard), arg0, arg1)
+}
+
+// ConfigureServiceLoopGuard mocks base method
+func (m *MockClient) ConfigureServiceLoopGuard(arg0 bool) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ConfigureServiceLoopGuard", arg0)
+}
+
+// ConfigureServiceLoopGuard indicates an expected call of ConfigureServiceLoopGuard
+func (mr *MockClientMockRecorder) ConfigureServiceLoopGuard(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfigureServiceLoopGuard", reflect.TypeOf((*MockClient)(nil).ConfigureServiceLoopGuard), arg0)
+}
+
 // DeletePolicyRuleAddress mocks base method
 func (m *MockClient) DeletePolicyRuleAddress(arg0 uint32, arg1 types.AddressType, arg2 []types.Address, arg3 *uint16) error {
 	m.ctrl.T.Helper()
@@ -122,6 +159,58 @@ func (mr *MockClientMockRecorder) Disconnect() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Disconnect", reflect.TypeOf((*MockClient)(nil).Disconnect))
 }
 
+// EnableFlowChangeTracking mocks base method
+func (m *MockClient) EnableFlowChangeTracking(arg0 int) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "EnableFlowChangeTracking", arg0)
+}
+
+// EnableFlowChangeTracking indicates an expected call of EnableFlowChangeTracking
+func (mr *MockClientMockRecorder) EnableFlowChangeTracking(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnableFlowChangeTracking", reflect.TypeOf((*MockClient)(nil).EnableFlowChangeTracking), arg0)
+}
+
+// EnableK8sIsolationLogging mocks base method
+func (m *MockClient) EnableK8sIsolationLogging(arg0, arg1 bool) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "EnableK8sIsolationLogging", arg0, arg1)
+}
+
+// EnableK8sIsolationLogging indicates an expected call of EnableK8sIsolationLogging
+func (mr *MockClientMockRecorder) EnableK8sIsolationLogging(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnableK8sIsolationLogging", reflect.TypeOf((*MockClient)(nil).EnableK8sIsolationLogging), arg0, arg1)
+}
+
+// GetEndpointDNATFlowKey mocks base method
+func (m *MockClient) GetEndpointDNATFlowKey(arg0 openflow.Protocol, arg1 proxy.Endpoint) string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEndpointDNATFlowKey", arg0, arg1)
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// GetEndpointDNATFlowKey indicates an expected call of GetEndpointDNATFlowKey
+func (mr *MockClientMockRecorder) GetEndpointDNATFlowKey(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEndpointDNATFlowKey", reflect.TypeOf((*MockClient)(nil).GetEndpointDNATFlowKey), arg0, arg1)
+}
+
+// GetFlowChanges mocks base method
+func (m *MockClient) GetFlowChanges() []types.FlowChange {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFlowChanges")
+	ret0, _ := ret[0].([]types.FlowChange)
+	return ret0
+}
+
+// GetFlowChanges indicates an expected call of GetFlowChanges
+func (mr *MockClientMockRecorder) GetFlowChanges() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFlowChanges", reflect.TypeOf((*MockClient)(nil).GetFlowChanges))
+}
+
 // GetFlowTableStatus mocks base method
 func (m *MockClient) GetFlowTableStatus() []openflow.TableStatus {
 	m.ctrl.T.Helper()
@@ -150,6 +239,34 @@ func (mr *MockClientMockRecorder) GetNetworkPolicyFlowKeys(arg0, arg1 interface{
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNetworkPolicyFlowKeys", reflect.TypeOf((*MockClient)(nil).GetNetworkPolicyFlowKeys), arg0, arg1)
 }
 
+// GetOVSCapabilities mocks base method
+func (m *MockClient) GetOVSCapabilities() openflow.Capabilities {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOVSCapabilities")
+	ret0, _ := ret[0].(openflow.Capabilities)
+	return ret0
+}
+
+// GetOVSCapabilities indicates an expected call of GetOVSCapabilities
+func (mr *MockClientMockRecorder) GetOVSCapabilities() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOVSCapabilities", reflect.TypeOf((*MockClient)(nil).GetOVSCapabilities))
+}
+
+// GetPipeline mocks base method
+func (m *MockClient) GetPipeline() []openflow.Table {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPipeline")
+	ret0, _ := ret[0].([]openflow.Table)
+	return ret0
+}
+
+// GetPipeline indicates an expected call of GetPipeline
+func (mr *MockClientMockRecorder) GetPipeline() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPipeline", reflect.TypeOf((*MockClient)(nil).GetPipeline))
+}
+
 // GetPodFlowKeys mocks base method
 func (m *MockClient) GetPodFlowKeys(arg0 string) []string {
 	m.ctrl.T.Helper()
@@ -165,12 +282,13 @@ func (mr *MockClientMockRecorder) GetPodFlowKeys(arg0 interface{}) *gomock.Call
 }
 
 // GetPolicyInfoFromConjunction mocks base method
-func (m *MockClient) GetPolicyInfoFromConjunction(arg0 uint32) (string, string) {
+func (m *MockClient) GetPolicyInfoFromConjunction(arg0 uint32) (string, string, string) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetPolicyInfoFromConjunction", arg0)
 	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(string)
-	return ret0, ret1
+	ret2, _ := ret[2].(string)
+	return ret0, ret1, ret2
 }
 
 // GetPolicyInfoFromConjunction indicates an expected call of GetPolicyInfoFromConjunction
@@ -335,17 +453,17 @@ func (mr *MockClientMockRecorder) InstallGatewayFlows() *gomock.Call {
 }
 
 // InstallLoadBalancerServiceFromOutsideFlows mocks base method
-func (m *MockClient) InstallLoadBalancerServiceFromOutsideFlows(arg0 net.IP, arg1 uint16, arg2 openflow.Protocol) error {
+func (m *MockClient) InstallLoadBalancerServiceFromOutsideFlows(arg0 net.IP, arg1 uint16, arg2 openflow.Protocol, arg3 bool) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "InstallLoadBalancerServiceFromOutsideFlows", arg0, arg1, arg2)
+	ret := m.ctrl.Call(m, "InstallLoadBalancerServiceFromOutsideFlows", arg0, arg1, arg2, arg3)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // InstallLoadBalancerServiceFromOutsideFlows indicates an expected call of InstallLoadBalancerServiceFromOutsideFlows
-func (mr *MockClientMockRecorder) InstallLoadBalancerServiceFromOutsideFlows(arg0, arg1, arg2 interface{}) *gomock.Call {
+func (mr *MockClientMockRecorder) InstallLoadBalancerServiceFromOutsideFlows(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InstallLoadBalancerServiceFromOutsideFlows", reflect.TypeOf((*MockClient)(nil).InstallLoadBalancerServiceFromOutsideFlows), arg0, arg1, arg2)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InstallLoadBalancerServiceFromOutsideFlows", reflect.TypeOf((*MockClient)(nil).InstallLoadBalancerServiceFromOutsideFlows), arg0, arg1, arg2, arg3)
 }
 
 // InstallNodeFlows mocks base method
@@ -362,6 +480,48 @@ func (mr *MockClientMockRecorder) InstallNodeFlows(arg0, arg1, arg2, arg3, arg4
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InstallNodeFlows", reflect.TypeOf((*MockClient)(nil).InstallNodeFlows), arg0, arg1, arg2, arg3, arg4)
 }
 
+// InstallPacketCaptureFlows mocks base method
+func (m *MockClient) InstallPacketCaptureFlows(arg0 string, arg1 *openflow.Packet, arg2 uint16) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InstallPacketCaptureFlows", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InstallPacketCaptureFlows indicates an expected call of InstallPacketCaptureFlows
+func (mr *MockClientMockRecorder) InstallPacketCaptureFlows(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InstallPacketCaptureFlows", reflect.TypeOf((*MockClient)(nil).InstallPacketCaptureFlows), arg0, arg1, arg2)
+}
+
+// InstallPodConnectionLimitFlows mocks base method
+func (m *MockClient) InstallPodConnectionLimitFlows(arg0, arg1, arg2 string, arg3, arg4 uint32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InstallPodConnectionLimitFlows", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InstallPodConnectionLimitFlows indicates an expected call of InstallPodConnectionLimitFlows
+func (mr *MockClientMockRecorder) InstallPodConnectionLimitFlows(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InstallPodConnectionLimitFlows", reflect.TypeOf((*MockClient)(nil).InstallPodConnectionLimitFlows), arg0, arg1, arg2, arg3, arg4)
+}
+
+// InstallPodDHCPFlows mocks base method
+func (m *MockClient) InstallPodDHCPFlows(arg0 string, arg1 net.HardwareAddr, arg2 uint32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InstallPodDHCPFlows", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InstallPodDHCPFlows indicates an expected call of InstallPodDHCPFlows
+func (mr *MockClientMockRecorder) InstallPodDHCPFlows(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InstallPodDHCPFlows", reflect.TypeOf((*MockClient)(nil).InstallPodDHCPFlows), arg0, arg1, arg2)
+}
+
 // InstallPodFlows mocks base method
 func (m *MockClient) InstallPodFlows(arg0 string, arg1 []net.IP, arg2 net.HardwareAddr, arg3 uint32) error {
 	m.ctrl.T.Helper()
@@ -390,6 +550,20 @@ func (mr *MockClientMockRecorder) InstallPodSNATFlows(arg0, arg1, arg2 interface
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InstallPodSNATFlows", reflect.TypeOf((*MockClient)(nil).InstallPodSNATFlows), arg0, arg1, arg2)
 }
 
+// InstallPolicyBootstrapFlows mocks base method
+func (m *MockClient) InstallPolicyBootstrapFlows(arg0 []types.PolicyBootstrapPeer) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InstallPolicyBootstrapFlows", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InstallPolicyBootstrapFlows indicates an expected call of InstallPolicyBootstrapFlows
+func (mr *MockClientMockRecorder) InstallPolicyBootstrapFlows(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InstallPolicyBootstrapFlows", reflect.TypeOf((*MockClient)(nil).InstallPolicyBootstrapFlows), arg0)
+}
+
 // InstallPolicyRuleFlows mocks base method
 func (m *MockClient) InstallPolicyRuleFlows(arg0 *types.PolicyRule) error {
 	m.ctrl.T.Helper()
@@ -446,6 +620,20 @@ func (mr *MockClientMockRecorder) InstallServiceGroup(arg0, arg1, arg2 interface
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InstallServiceGroup", reflect.TypeOf((*MockClient)(nil).InstallServiceGroup), arg0, arg1, arg2)
 }
 
+// InstallServiceSourceRangeFlows mocks base method
+func (m *MockClient) InstallServiceSourceRangeFlows(arg0 openflow.GroupIDType, arg1 net.IP, arg2 uint16, arg3 openflow.Protocol, arg4 uint16, arg5 []net.IPNet) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InstallServiceSourceRangeFlows", arg0, arg1, arg2, arg3, arg4, arg5)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InstallServiceSourceRangeFlows indicates an expected call of InstallServiceSourceRangeFlows
+func (mr *MockClientMockRecorder) InstallServiceSourceRangeFlows(arg0, arg1, arg2, arg3, arg4, arg5 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InstallServiceSourceRangeFlows", reflect.TypeOf((*MockClient)(nil).InstallServiceSourceRangeFlows), arg0, arg1, arg2, arg3, arg4, arg5)
+}
+
 // InstallTraceflowFlows mocks base method
 func (m *MockClient) InstallTraceflowFlows(arg0 byte, arg1, arg2, arg3 bool, arg4 *openflow.Packet, arg5 uint32, arg6 uint16) error {
 	m.ctrl.T.Helper()
@@ -502,6 +690,18 @@ func (mr *MockClientMockRecorder) IsIPv6Enabled() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsIPv6Enabled", reflect.TypeOf((*MockClient)(nil).IsIPv6Enabled))
 }
 
+// MonitorPodConnectionLimits mocks base method
+func (m *MockClient) MonitorPodConnectionLimits(arg0 bool, arg1 <-chan struct{}) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "MonitorPodConnectionLimits", arg0, arg1)
+}
+
+// MonitorPodConnectionLimits indicates an expected call of MonitorPodConnectionLimits
+func (mr *MockClientMockRecorder) MonitorPodConnectionLimits(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MonitorPodConnectionLimits", reflect.TypeOf((*MockClient)(nil).MonitorPodConnectionLimits), arg0, arg1)
+}
+
 // NetworkPolicyMetrics mocks base method
 func (m *MockClient) NetworkPolicyMetrics() map[uint32]*types.RuleMetric {
 	m.ctrl.T.Helper()
@@ -568,6 +768,20 @@ func (mr *MockClientMockRecorder) SendICMPPacketOut(arg0, arg1, arg2, arg3, arg4
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendICMPPacketOut", reflect.TypeOf((*MockClient)(nil).SendICMPPacketOut), arg0, arg1, arg2, arg3, arg4, arg5, arg6, arg7, arg8, arg9, arg10)
 }
 
+// SendIPAnnouncementPacketOut mocks base method
+func (m *MockClient) SendIPAnnouncementPacketOut(arg0 net.HardwareAddr, arg1 net.IP, arg2, arg3 uint32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendIPAnnouncementPacketOut", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendIPAnnouncementPacketOut indicates an expected call of SendIPAnnouncementPacketOut
+func (mr *MockClientMockRecorder) SendIPAnnouncementPacketOut(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendIPAnnouncementPacketOut", reflect.TypeOf((*MockClient)(nil).SendIPAnnouncementPacketOut), arg0, arg1, arg2, arg3)
+}
+
 // SendTCPPacketOut mocks base method
 func (m *MockClient) SendTCPPacketOut(arg0, arg1, arg2, arg3 string, arg4 uint32, arg5 int32, arg6 bool, arg7, arg8 uint16, arg9 uint32, arg10 byte, arg11 bool) error {
 	m.ctrl.T.Helper()
@@ -596,6 +810,20 @@ func (mr *MockClientMockRecorder) SendTraceflowPacket(arg0, arg1, arg2, arg3 int
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendTraceflowPacket", reflect.TypeOf((*MockClient)(nil).SendTraceflowPacket), arg0, arg1, arg2, arg3)
 }
 
+// ShrinkPacketInQueues mocks base method
+func (m *MockClient) ShrinkPacketInQueues(arg0 bool) int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ShrinkPacketInQueues", arg0)
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// ShrinkPacketInQueues indicates an expected call of ShrinkPacketInQueues
+func (mr *MockClientMockRecorder) ShrinkPacketInQueues(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ShrinkPacketInQueues", reflect.TypeOf((*MockClient)(nil).ShrinkPacketInQueues), arg0)
+}
+
 // StartPacketInHandler mocks base method
 func (m *MockClient) StartPacketInHandler(arg0 []byte, arg1 <-chan struct{}) {
 	m.ctrl.T.Helper()
@@ -664,6 +892,20 @@ func (mr *MockClientMockRecorder) UninstallNodeFlows(arg0 interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UninstallNodeFlows", reflect.TypeOf((*MockClient)(nil).UninstallNodeFlows), arg0)
 }
 
+// UninstallPacketCaptureFlows mocks base method
+func (m *MockClient) UninstallPacketCaptureFlows(arg0 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UninstallPacketCaptureFlows", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UninstallPacketCaptureFlows indicates an expected call of UninstallPacketCaptureFlows
+func (mr *MockClientMockRecorder) UninstallPacketCaptureFlows(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UninstallPacketCaptureFlows", reflect.TypeOf((*MockClient)(nil).UninstallPacketCaptureFlows), arg0)
+}
+
 // UninstallPodFlows mocks base method
 func (m *MockClient) UninstallPodFlows(arg0 string) error {
 	m.ctrl.T.Helper()
@@ -692,6 +934,20 @@ func (mr *MockClientMockRecorder) UninstallPodSNATFlows(arg0 interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UninstallPodSNATFlows", reflect.TypeOf((*MockClient)(nil).UninstallPodSNATFlows), arg0)
 }
 
+// UninstallPolicyBootstrapFlows mocks base method
+func (m *MockClient) UninstallPolicyBootstrapFlows() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UninstallPolicyBootstrapFlows")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UninstallPolicyBootstrapFlows indicates an expected call of UninstallPolicyBootstrapFlows
+func (mr *MockClientMockRecorder) UninstallPolicyBootstrapFlows() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UninstallPolicyBootstrapFlows", reflect.TypeOf((*MockClient)(nil).UninstallPolicyBootstrapFlows))
+}
+
 // UninstallPolicyRuleFlows mocks base method
 func (m *MockClient) UninstallPolicyRuleFlows(arg0 uint32) ([]string, error) {
 	m.ctrl.T.Helper()
@@ -707,6 +963,20 @@ func (mr *MockClientMockRecorder) UninstallPolicyRuleFlows(arg0 interface{}) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UninstallPolicyRuleFlows", reflect.TypeOf((*MockClient)(nil).UninstallPolicyRuleFlows), arg0)
 }
 
+// UninstallPolicyRuleFlowsAsync mocks base method
+func (m *MockClient) UninstallPolicyRuleFlowsAsync(arg0 uint32) <-chan types.PolicyRuleUninstallResult {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UninstallPolicyRuleFlowsAsync", arg0)
+	ret0, _ := ret[0].(<-chan types.PolicyRuleUninstallResult)
+	return ret0
+}
+
+// UninstallPolicyRuleFlowsAsync indicates an expected call of UninstallPolicyRuleFlowsAsync
+func (mr *MockClientMockRecorder) UninstallPolicyRuleFlowsAsync(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UninstallPolicyRuleFlowsAsync", reflect.TypeOf((*MockClient)(nil).UninstallPolicyRuleFlowsAsync), arg0)
+}
+
 // UninstallSNATMarkFlows mocks base method
 func (m *MockClient) UninstallSNATMarkFlows(arg0 uint32) error {
 	m.ctrl.T.Helper()
@@ -749,6 +1019,20 @@ func (mr *MockClientMockRecorder) UninstallServiceGroup(arg0 interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UninstallServiceGroup", reflect.TypeOf((*MockClient)(nil).UninstallServiceGroup), arg0)
 }
 
+// UninstallServiceSourceRangeFlows mocks base method
+func (m *MockClient) UninstallServiceSourceRangeFlows(arg0 net.IP, arg1 uint16, arg2 openflow.Protocol) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UninstallServiceSourceRangeFlows", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UninstallServiceSourceRangeFlows indicates an expected call of UninstallServiceSourceRangeFlows
+func (mr *MockClientMockRecorder) UninstallServiceSourceRangeFlows(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UninstallServiceSourceRangeFlows", reflect.TypeOf((*MockClient)(nil).UninstallServiceSourceRangeFlows), arg0, arg1, arg2)
+}
+
 // UninstallTraceflowFlows mocks base method
 func (m *MockClient) UninstallTraceflowFlows(arg0 byte) error {
 	m.ctrl.T.Helper()
@@ -763,6 +1047,20 @@ func (mr *MockClientMockRecorder) UninstallTraceflowFlows(arg0 interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UninstallTraceflowFlows", reflect.TypeOf((*MockClient)(nil).UninstallTraceflowFlows), arg0)
 }
 
+// UpdatePodFlows mocks base method
+func (m *MockClient) UpdatePodFlows(arg0 string, arg1 []net.IP, arg2 net.HardwareAddr, arg3 uint32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePodFlows", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdatePodFlows indicates an expected call of UpdatePodFlows
+func (mr *MockClientMockRecorder) UpdatePodFlows(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePodFlows", reflect.TypeOf((*MockClient)(nil).UpdatePodFlows), arg0, arg1, arg2, arg3)
+}
+
 // MockOFEntryOperations is a mock of OFEntryOperations interface
 type MockOFEntryOperations struct {
 	ctrl     *gomock.Controller
@@ -911,3 +1209,1052 @@ func (mr *MockOFEntryOperationsMockRecorder) ModifyAll(arg0 interface{}) *gomock
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ModifyAll", reflect.TypeOf((*MockOFEntryOperations)(nil).ModifyAll), arg0)
 }
+
+// MockPacketInClient is a mock of PacketInClient interface
+type MockPacketInClient struct {
+	ctrl     *gomock.Controller
+	recorder *MockPacketInClientMockRecorder
+}
+
+// MockPacketInClientMockRecorder is the mock recorder for MockPacketInClient
+type MockPacketInClientMockRecorder struct {
+	mock *MockPacketInClient
+}
+
+// NewMockPacketInClient creates a new mock instance
+func NewMockPacketInClient(ctrl *gomock.Controller) *MockPacketInClient {
+	mock := &MockPacketInClient{ctrl: ctrl}
+	mock.recorder = &MockPacketInClientMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockPacketInClient) EXPECT() *MockPacketInClientMockRecorder {
+	return m.recorder
+}
+
+// RegisterPacketInHandler mocks base method
+func (m *MockPacketInClient) RegisterPacketInHandler(arg0 byte, arg1 string, arg2 interface{}) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "RegisterPacketInHandler", arg0, arg1, arg2)
+}
+
+// RegisterPacketInHandler indicates an expected call of RegisterPacketInHandler
+func (mr *MockPacketInClientMockRecorder) RegisterPacketInHandler(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterPacketInHandler", reflect.TypeOf((*MockPacketInClient)(nil).RegisterPacketInHandler), arg0, arg1, arg2)
+}
+
+// ShrinkPacketInQueues mocks base method
+func (m *MockPacketInClient) ShrinkPacketInQueues(arg0 bool) int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ShrinkPacketInQueues", arg0)
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// ShrinkPacketInQueues indicates an expected call of ShrinkPacketInQueues
+func (mr *MockPacketInClientMockRecorder) ShrinkPacketInQueues(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ShrinkPacketInQueues", reflect.TypeOf((*MockPacketInClient)(nil).ShrinkPacketInQueues), arg0)
+}
+
+// StartPacketInHandler mocks base method
+func (m *MockPacketInClient) StartPacketInHandler(arg0 []byte, arg1 <-chan struct{}) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "StartPacketInHandler", arg0, arg1)
+}
+
+// StartPacketInHandler indicates an expected call of StartPacketInHandler
+func (mr *MockPacketInClientMockRecorder) StartPacketInHandler(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartPacketInHandler", reflect.TypeOf((*MockPacketInClient)(nil).StartPacketInHandler), arg0, arg1)
+}
+
+// SubscribePacketIn mocks base method
+func (m *MockPacketInClient) SubscribePacketIn(arg0 byte, arg1 *openflow.PacketInQueue) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SubscribePacketIn", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SubscribePacketIn indicates an expected call of SubscribePacketIn
+func (mr *MockPacketInClientMockRecorder) SubscribePacketIn(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SubscribePacketIn", reflect.TypeOf((*MockPacketInClient)(nil).SubscribePacketIn), arg0, arg1)
+}
+
+// MockPacketOutClient is a mock of PacketOutClient interface
+type MockPacketOutClient struct {
+	ctrl     *gomock.Controller
+	recorder *MockPacketOutClientMockRecorder
+}
+
+// MockPacketOutClientMockRecorder is the mock recorder for MockPacketOutClient
+type MockPacketOutClientMockRecorder struct {
+	mock *MockPacketOutClient
+}
+
+// NewMockPacketOutClient creates a new mock instance
+func NewMockPacketOutClient(ctrl *gomock.Controller) *MockPacketOutClient {
+	mock := &MockPacketOutClient{ctrl: ctrl}
+	mock.recorder = &MockPacketOutClientMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockPacketOutClient) EXPECT() *MockPacketOutClientMockRecorder {
+	return m.recorder
+}
+
+// SendICMPPacketOut mocks base method
+func (m *MockPacketOutClient) SendICMPPacketOut(arg0, arg1, arg2, arg3 string, arg4 uint32, arg5 int32, arg6 bool, arg7, arg8 byte, arg9 []byte, arg10 bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendICMPPacketOut", arg0, arg1, arg2, arg3, arg4, arg5, arg6, arg7, arg8, arg9, arg10)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendICMPPacketOut indicates an expected call of SendICMPPacketOut
+func (mr *MockPacketOutClientMockRecorder) SendICMPPacketOut(arg0, arg1, arg2, arg3, arg4, arg5, arg6, arg7, arg8, arg9, arg10 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendICMPPacketOut", reflect.TypeOf((*MockPacketOutClient)(nil).SendICMPPacketOut), arg0, arg1, arg2, arg3, arg4, arg5, arg6, arg7, arg8, arg9, arg10)
+}
+
+// SendIPAnnouncementPacketOut mocks base method
+func (m *MockPacketOutClient) SendIPAnnouncementPacketOut(arg0 net.HardwareAddr, arg1 net.IP, arg2, arg3 uint32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendIPAnnouncementPacketOut", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendIPAnnouncementPacketOut indicates an expected call of SendIPAnnouncementPacketOut
+func (mr *MockPacketOutClientMockRecorder) SendIPAnnouncementPacketOut(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendIPAnnouncementPacketOut", reflect.TypeOf((*MockPacketOutClient)(nil).SendIPAnnouncementPacketOut), arg0, arg1, arg2, arg3)
+}
+
+// SendTCPPacketOut mocks base method
+func (m *MockPacketOutClient) SendTCPPacketOut(arg0, arg1, arg2, arg3 string, arg4 uint32, arg5 int32, arg6 bool, arg7, arg8 uint16, arg9 uint32, arg10 byte, arg11 bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendTCPPacketOut", arg0, arg1, arg2, arg3, arg4, arg5, arg6, arg7, arg8, arg9, arg10, arg11)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendTCPPacketOut indicates an expected call of SendTCPPacketOut
+func (mr *MockPacketOutClientMockRecorder) SendTCPPacketOut(arg0, arg1, arg2, arg3, arg4, arg5, arg6, arg7, arg8, arg9, arg10, arg11 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendTCPPacketOut", reflect.TypeOf((*MockPacketOutClient)(nil).SendTCPPacketOut), arg0, arg1, arg2, arg3, arg4, arg5, arg6, arg7, arg8, arg9, arg10, arg11)
+}
+
+// MockPodConnectivityClient is a mock of PodConnectivityClient interface
+type MockPodConnectivityClient struct {
+	ctrl     *gomock.Controller
+	recorder *MockPodConnectivityClientMockRecorder
+}
+
+// MockPodConnectivityClientMockRecorder is the mock recorder for MockPodConnectivityClient
+type MockPodConnectivityClientMockRecorder struct {
+	mock *MockPodConnectivityClient
+}
+
+// NewMockPodConnectivityClient creates a new mock instance
+func NewMockPodConnectivityClient(ctrl *gomock.Controller) *MockPodConnectivityClient {
+	mock := &MockPodConnectivityClient{ctrl: ctrl}
+	mock.recorder = &MockPodConnectivityClientMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockPodConnectivityClient) EXPECT() *MockPodConnectivityClientMockRecorder {
+	return m.recorder
+}
+
+// ConfigureNDGuard mocks base method
+func (m *MockPodConnectivityClient) ConfigureNDGuard(arg0, arg1 bool) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ConfigureNDGuard", arg0, arg1)
+}
+
+// ConfigureNDGuard indicates an expected call of ConfigureNDGuard
+func (mr *MockPodConnectivityClientMockRecorder) ConfigureNDGuard(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfigureNDGuard", reflect.TypeOf((*MockPodConnectivityClient)(nil).ConfigureNDGuard), arg0, arg1)
+}
+
+// GetPodFlowKeys mocks base method
+func (m *MockPodConnectivityClient) GetPodFlowKeys(arg0 string) []string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPodFlowKeys", arg0)
+	ret0, _ := ret[0].([]string)
+	return ret0
+}
+
+// GetPodFlowKeys indicates an expected call of GetPodFlowKeys
+func (mr *MockPodConnectivityClientMockRecorder) GetPodFlowKeys(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPodFlowKeys", reflect.TypeOf((*MockPodConnectivityClient)(nil).GetPodFlowKeys), arg0)
+}
+
+// GetTunnelVirtualMAC mocks base method
+func (m *MockPodConnectivityClient) GetTunnelVirtualMAC() net.HardwareAddr {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTunnelVirtualMAC")
+	ret0, _ := ret[0].(net.HardwareAddr)
+	return ret0
+}
+
+// GetTunnelVirtualMAC indicates an expected call of GetTunnelVirtualMAC
+func (mr *MockPodConnectivityClientMockRecorder) GetTunnelVirtualMAC() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTunnelVirtualMAC", reflect.TypeOf((*MockPodConnectivityClient)(nil).GetTunnelVirtualMAC))
+}
+
+// InstallBridgeUplinkFlows mocks base method
+func (m *MockPodConnectivityClient) InstallBridgeUplinkFlows() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InstallBridgeUplinkFlows")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InstallBridgeUplinkFlows indicates an expected call of InstallBridgeUplinkFlows
+func (mr *MockPodConnectivityClientMockRecorder) InstallBridgeUplinkFlows() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InstallBridgeUplinkFlows", reflect.TypeOf((*MockPodConnectivityClient)(nil).InstallBridgeUplinkFlows))
+}
+
+// InstallDefaultTunnelFlows mocks base method
+func (m *MockPodConnectivityClient) InstallDefaultTunnelFlows() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InstallDefaultTunnelFlows")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InstallDefaultTunnelFlows indicates an expected call of InstallDefaultTunnelFlows
+func (mr *MockPodConnectivityClientMockRecorder) InstallDefaultTunnelFlows() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InstallDefaultTunnelFlows", reflect.TypeOf((*MockPodConnectivityClient)(nil).InstallDefaultTunnelFlows))
+}
+
+// InstallExternalFlows mocks base method
+func (m *MockPodConnectivityClient) InstallExternalFlows() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InstallExternalFlows")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InstallExternalFlows indicates an expected call of InstallExternalFlows
+func (mr *MockPodConnectivityClientMockRecorder) InstallExternalFlows() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InstallExternalFlows", reflect.TypeOf((*MockPodConnectivityClient)(nil).InstallExternalFlows))
+}
+
+// InstallGatewayFlows mocks base method
+func (m *MockPodConnectivityClient) InstallGatewayFlows() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InstallGatewayFlows")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InstallGatewayFlows indicates an expected call of InstallGatewayFlows
+func (mr *MockPodConnectivityClientMockRecorder) InstallGatewayFlows() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InstallGatewayFlows", reflect.TypeOf((*MockPodConnectivityClient)(nil).InstallGatewayFlows))
+}
+
+// InstallNodeFlows mocks base method
+func (m *MockPodConnectivityClient) InstallNodeFlows(arg0 string, arg1 map[*net.IPNet]net.IP, arg2 net.IP, arg3 uint32, arg4 net.HardwareAddr) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InstallNodeFlows", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InstallNodeFlows indicates an expected call of InstallNodeFlows
+func (mr *MockPodConnectivityClientMockRecorder) InstallNodeFlows(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InstallNodeFlows", reflect.TypeOf((*MockPodConnectivityClient)(nil).InstallNodeFlows), arg0, arg1, arg2, arg3, arg4)
+}
+
+// InstallPodConnectionLimitFlows mocks base method
+func (m *MockPodConnectivityClient) InstallPodConnectionLimitFlows(arg0, arg1, arg2 string, arg3, arg4 uint32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InstallPodConnectionLimitFlows", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InstallPodConnectionLimitFlows indicates an expected call of InstallPodConnectionLimitFlows
+func (mr *MockPodConnectivityClientMockRecorder) InstallPodConnectionLimitFlows(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InstallPodConnectionLimitFlows", reflect.TypeOf((*MockPodConnectivityClient)(nil).InstallPodConnectionLimitFlows), arg0, arg1, arg2, arg3, arg4)
+}
+
+// InstallPodDHCPFlows mocks base method
+func (m *MockPodConnectivityClient) InstallPodDHCPFlows(arg0 string, arg1 net.HardwareAddr, arg2 uint32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InstallPodDHCPFlows", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InstallPodDHCPFlows indicates an expected call of InstallPodDHCPFlows
+func (mr *MockPodConnectivityClientMockRecorder) InstallPodDHCPFlows(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InstallPodDHCPFlows", reflect.TypeOf((*MockPodConnectivityClient)(nil).InstallPodDHCPFlows), arg0, arg1, arg2)
+}
+
+// InstallPodFlows mocks base method
+func (m *MockPodConnectivityClient) InstallPodFlows(arg0 string, arg1 []net.IP, arg2 net.HardwareAddr, arg3 uint32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InstallPodFlows", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InstallPodFlows indicates an expected call of InstallPodFlows
+func (mr *MockPodConnectivityClientMockRecorder) InstallPodFlows(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InstallPodFlows", reflect.TypeOf((*MockPodConnectivityClient)(nil).InstallPodFlows), arg0, arg1, arg2, arg3)
+}
+
+// InstallPodSNATFlows mocks base method
+func (m *MockPodConnectivityClient) InstallPodSNATFlows(arg0 uint32, arg1 net.IP, arg2 uint32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InstallPodSNATFlows", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InstallPodSNATFlows indicates an expected call of InstallPodSNATFlows
+func (mr *MockPodConnectivityClientMockRecorder) InstallPodSNATFlows(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InstallPodSNATFlows", reflect.TypeOf((*MockPodConnectivityClient)(nil).InstallPodSNATFlows), arg0, arg1, arg2)
+}
+
+// InstallSNATMarkFlows mocks base method
+func (m *MockPodConnectivityClient) InstallSNATMarkFlows(arg0 net.IP, arg1 uint32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InstallSNATMarkFlows", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InstallSNATMarkFlows indicates an expected call of InstallSNATMarkFlows
+func (mr *MockPodConnectivityClientMockRecorder) InstallSNATMarkFlows(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InstallSNATMarkFlows", reflect.TypeOf((*MockPodConnectivityClient)(nil).InstallSNATMarkFlows), arg0, arg1)
+}
+
+// MonitorPodConnectionLimits mocks base method
+func (m *MockPodConnectivityClient) MonitorPodConnectionLimits(arg0 bool, arg1 <-chan struct{}) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "MonitorPodConnectionLimits", arg0, arg1)
+}
+
+// MonitorPodConnectionLimits indicates an expected call of MonitorPodConnectionLimits
+func (mr *MockPodConnectivityClientMockRecorder) MonitorPodConnectionLimits(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MonitorPodConnectionLimits", reflect.TypeOf((*MockPodConnectivityClient)(nil).MonitorPodConnectionLimits), arg0, arg1)
+}
+
+// UninstallNodeFlows mocks base method
+func (m *MockPodConnectivityClient) UninstallNodeFlows(arg0 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UninstallNodeFlows", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UninstallNodeFlows indicates an expected call of UninstallNodeFlows
+func (mr *MockPodConnectivityClientMockRecorder) UninstallNodeFlows(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UninstallNodeFlows", reflect.TypeOf((*MockPodConnectivityClient)(nil).UninstallNodeFlows), arg0)
+}
+
+// UninstallPodFlows mocks base method
+func (m *MockPodConnectivityClient) UninstallPodFlows(arg0 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UninstallPodFlows", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UninstallPodFlows indicates an expected call of UninstallPodFlows
+func (mr *MockPodConnectivityClientMockRecorder) UninstallPodFlows(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UninstallPodFlows", reflect.TypeOf((*MockPodConnectivityClient)(nil).UninstallPodFlows), arg0)
+}
+
+// UninstallPodSNATFlows mocks base method
+func (m *MockPodConnectivityClient) UninstallPodSNATFlows(arg0 uint32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UninstallPodSNATFlows", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UninstallPodSNATFlows indicates an expected call of UninstallPodSNATFlows
+func (mr *MockPodConnectivityClientMockRecorder) UninstallPodSNATFlows(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UninstallPodSNATFlows", reflect.TypeOf((*MockPodConnectivityClient)(nil).UninstallPodSNATFlows), arg0)
+}
+
+// UninstallSNATMarkFlows mocks base method
+func (m *MockPodConnectivityClient) UninstallSNATMarkFlows(arg0 uint32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UninstallSNATMarkFlows", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UninstallSNATMarkFlows indicates an expected call of UninstallSNATMarkFlows
+func (mr *MockPodConnectivityClientMockRecorder) UninstallSNATMarkFlows(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UninstallSNATMarkFlows", reflect.TypeOf((*MockPodConnectivityClient)(nil).UninstallSNATMarkFlows), arg0)
+}
+
+// UpdatePodFlows mocks base method
+func (m *MockPodConnectivityClient) UpdatePodFlows(arg0 string, arg1 []net.IP, arg2 net.HardwareAddr, arg3 uint32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePodFlows", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdatePodFlows indicates an expected call of UpdatePodFlows
+func (mr *MockPodConnectivityClientMockRecorder) UpdatePodFlows(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePodFlows", reflect.TypeOf((*MockPodConnectivityClient)(nil).UpdatePodFlows), arg0, arg1, arg2, arg3)
+}
+
+// MockPolicyClient is a mock of PolicyClient interface
+type MockPolicyClient struct {
+	ctrl     *gomock.Controller
+	recorder *MockPolicyClientMockRecorder
+}
+
+// MockPolicyClientMockRecorder is the mock recorder for MockPolicyClient
+type MockPolicyClientMockRecorder struct {
+	mock *MockPolicyClient
+}
+
+// NewMockPolicyClient creates a new mock instance
+func NewMockPolicyClient(ctrl *gomock.Controller) *MockPolicyClient {
+	mock := &MockPolicyClient{ctrl: ctrl}
+	mock.recorder = &MockPolicyClientMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockPolicyClient) EXPECT() *MockPolicyClientMockRecorder {
+	return m.recorder
+}
+
+// AddPolicyRuleAddress mocks base method
+func (m *MockPolicyClient) AddPolicyRuleAddress(arg0 uint32, arg1 types.AddressType, arg2 []types.Address, arg3 *uint16) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddPolicyRuleAddress", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddPolicyRuleAddress indicates an expected call of AddPolicyRuleAddress
+func (mr *MockPolicyClientMockRecorder) AddPolicyRuleAddress(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddPolicyRuleAddress", reflect.TypeOf((*MockPolicyClient)(nil).AddPolicyRuleAddress), arg0, arg1, arg2, arg3)
+}
+
+// BatchInstallPolicyRuleFlows mocks base method
+func (m *MockPolicyClient) BatchInstallPolicyRuleFlows(arg0 []*types.PolicyRule) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BatchInstallPolicyRuleFlows", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// BatchInstallPolicyRuleFlows indicates an expected call of BatchInstallPolicyRuleFlows
+func (mr *MockPolicyClientMockRecorder) BatchInstallPolicyRuleFlows(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BatchInstallPolicyRuleFlows", reflect.TypeOf((*MockPolicyClient)(nil).BatchInstallPolicyRuleFlows), arg0)
+}
+
+// DeletePolicyRuleAddress mocks base method
+func (m *MockPolicyClient) DeletePolicyRuleAddress(arg0 uint32, arg1 types.AddressType, arg2 []types.Address, arg3 *uint16) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeletePolicyRuleAddress", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeletePolicyRuleAddress indicates an expected call of DeletePolicyRuleAddress
+func (mr *MockPolicyClientMockRecorder) DeletePolicyRuleAddress(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePolicyRuleAddress", reflect.TypeOf((*MockPolicyClient)(nil).DeletePolicyRuleAddress), arg0, arg1, arg2, arg3)
+}
+
+// EnableK8sIsolationLogging mocks base method
+func (m *MockPolicyClient) EnableK8sIsolationLogging(arg0, arg1 bool) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "EnableK8sIsolationLogging", arg0, arg1)
+}
+
+// EnableK8sIsolationLogging indicates an expected call of EnableK8sIsolationLogging
+func (mr *MockPolicyClientMockRecorder) EnableK8sIsolationLogging(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnableK8sIsolationLogging", reflect.TypeOf((*MockPolicyClient)(nil).EnableK8sIsolationLogging), arg0, arg1)
+}
+
+// GetNetworkPolicyFlowKeys mocks base method
+func (m *MockPolicyClient) GetNetworkPolicyFlowKeys(arg0, arg1 string) []string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNetworkPolicyFlowKeys", arg0, arg1)
+	ret0, _ := ret[0].([]string)
+	return ret0
+}
+
+// GetNetworkPolicyFlowKeys indicates an expected call of GetNetworkPolicyFlowKeys
+func (mr *MockPolicyClientMockRecorder) GetNetworkPolicyFlowKeys(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNetworkPolicyFlowKeys", reflect.TypeOf((*MockPolicyClient)(nil).GetNetworkPolicyFlowKeys), arg0, arg1)
+}
+
+// GetPolicyInfoFromConjunction mocks base method
+func (m *MockPolicyClient) GetPolicyInfoFromConjunction(arg0 uint32) (string, string, string) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPolicyInfoFromConjunction", arg0)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(string)
+	return ret0, ret1, ret2
+}
+
+// GetPolicyInfoFromConjunction indicates an expected call of GetPolicyInfoFromConjunction
+func (mr *MockPolicyClientMockRecorder) GetPolicyInfoFromConjunction(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPolicyInfoFromConjunction", reflect.TypeOf((*MockPolicyClient)(nil).GetPolicyInfoFromConjunction), arg0)
+}
+
+// InstallPolicyBootstrapFlows mocks base method
+func (m *MockPolicyClient) InstallPolicyBootstrapFlows(arg0 []types.PolicyBootstrapPeer) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InstallPolicyBootstrapFlows", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InstallPolicyBootstrapFlows indicates an expected call of InstallPolicyBootstrapFlows
+func (mr *MockPolicyClientMockRecorder) InstallPolicyBootstrapFlows(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InstallPolicyBootstrapFlows", reflect.TypeOf((*MockPolicyClient)(nil).InstallPolicyBootstrapFlows), arg0)
+}
+
+// InstallPolicyRuleFlows mocks base method
+func (m *MockPolicyClient) InstallPolicyRuleFlows(arg0 *types.PolicyRule) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InstallPolicyRuleFlows", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InstallPolicyRuleFlows indicates an expected call of InstallPolicyRuleFlows
+func (mr *MockPolicyClientMockRecorder) InstallPolicyRuleFlows(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InstallPolicyRuleFlows", reflect.TypeOf((*MockPolicyClient)(nil).InstallPolicyRuleFlows), arg0)
+}
+
+// IsIPv4Enabled mocks base method
+func (m *MockPolicyClient) IsIPv4Enabled() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsIPv4Enabled")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// IsIPv4Enabled indicates an expected call of IsIPv4Enabled
+func (mr *MockPolicyClientMockRecorder) IsIPv4Enabled() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsIPv4Enabled", reflect.TypeOf((*MockPolicyClient)(nil).IsIPv4Enabled))
+}
+
+// IsIPv6Enabled mocks base method
+func (m *MockPolicyClient) IsIPv6Enabled() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsIPv6Enabled")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// IsIPv6Enabled indicates an expected call of IsIPv6Enabled
+func (mr *MockPolicyClientMockRecorder) IsIPv6Enabled() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsIPv6Enabled", reflect.TypeOf((*MockPolicyClient)(nil).IsIPv6Enabled))
+}
+
+// NetworkPolicyMetrics mocks base method
+func (m *MockPolicyClient) NetworkPolicyMetrics() map[uint32]*types.RuleMetric {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NetworkPolicyMetrics")
+	ret0, _ := ret[0].(map[uint32]*types.RuleMetric)
+	return ret0
+}
+
+// NetworkPolicyMetrics indicates an expected call of NetworkPolicyMetrics
+func (mr *MockPolicyClientMockRecorder) NetworkPolicyMetrics() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NetworkPolicyMetrics", reflect.TypeOf((*MockPolicyClient)(nil).NetworkPolicyMetrics))
+}
+
+// ReassignFlowPriorities mocks base method
+func (m *MockPolicyClient) ReassignFlowPriorities(arg0 map[uint16]uint16, arg1 openflow.TableIDType) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReassignFlowPriorities", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReassignFlowPriorities indicates an expected call of ReassignFlowPriorities
+func (mr *MockPolicyClientMockRecorder) ReassignFlowPriorities(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReassignFlowPriorities", reflect.TypeOf((*MockPolicyClient)(nil).ReassignFlowPriorities), arg0, arg1)
+}
+
+// UninstallPolicyBootstrapFlows mocks base method
+func (m *MockPolicyClient) UninstallPolicyBootstrapFlows() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UninstallPolicyBootstrapFlows")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UninstallPolicyBootstrapFlows indicates an expected call of UninstallPolicyBootstrapFlows
+func (mr *MockPolicyClientMockRecorder) UninstallPolicyBootstrapFlows() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UninstallPolicyBootstrapFlows", reflect.TypeOf((*MockPolicyClient)(nil).UninstallPolicyBootstrapFlows))
+}
+
+// UninstallPolicyRuleFlows mocks base method
+func (m *MockPolicyClient) UninstallPolicyRuleFlows(arg0 uint32) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UninstallPolicyRuleFlows", arg0)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UninstallPolicyRuleFlows indicates an expected call of UninstallPolicyRuleFlows
+func (mr *MockPolicyClientMockRecorder) UninstallPolicyRuleFlows(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UninstallPolicyRuleFlows", reflect.TypeOf((*MockPolicyClient)(nil).UninstallPolicyRuleFlows), arg0)
+}
+
+// UninstallPolicyRuleFlowsAsync mocks base method
+func (m *MockPolicyClient) UninstallPolicyRuleFlowsAsync(arg0 uint32) <-chan types.PolicyRuleUninstallResult {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UninstallPolicyRuleFlowsAsync", arg0)
+	ret0, _ := ret[0].(<-chan types.PolicyRuleUninstallResult)
+	return ret0
+}
+
+// UninstallPolicyRuleFlowsAsync indicates an expected call of UninstallPolicyRuleFlowsAsync
+func (mr *MockPolicyClientMockRecorder) UninstallPolicyRuleFlowsAsync(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UninstallPolicyRuleFlowsAsync", reflect.TypeOf((*MockPolicyClient)(nil).UninstallPolicyRuleFlowsAsync), arg0)
+}
+
+// MockServiceClient is a mock of ServiceClient interface
+type MockServiceClient struct {
+	ctrl     *gomock.Controller
+	recorder *MockServiceClientMockRecorder
+}
+
+// MockServiceClientMockRecorder is the mock recorder for MockServiceClient
+type MockServiceClientMockRecorder struct {
+	mock *MockServiceClient
+}
+
+// NewMockServiceClient creates a new mock instance
+func NewMockServiceClient(ctrl *gomock.Controller) *MockServiceClient {
+	mock := &MockServiceClient{ctrl: ctrl}
+	mock.recorder = &MockServiceClientMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockServiceClient) EXPECT() *MockServiceClientMockRecorder {
+	return m.recorder
+}
+
+// ConfigureServiceLoopGuard mocks base method
+func (m *MockServiceClient) ConfigureServiceLoopGuard(arg0 bool) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ConfigureServiceLoopGuard", arg0)
+}
+
+// ConfigureServiceLoopGuard indicates an expected call of ConfigureServiceLoopGuard
+func (mr *MockServiceClientMockRecorder) ConfigureServiceLoopGuard(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfigureServiceLoopGuard", reflect.TypeOf((*MockServiceClient)(nil).ConfigureServiceLoopGuard), arg0)
+}
+
+// GetEndpointDNATFlowKey mocks base method
+func (m *MockServiceClient) GetEndpointDNATFlowKey(arg0 openflow.Protocol, arg1 proxy.Endpoint) string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEndpointDNATFlowKey", arg0, arg1)
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// GetEndpointDNATFlowKey indicates an expected call of GetEndpointDNATFlowKey
+func (mr *MockServiceClientMockRecorder) GetEndpointDNATFlowKey(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEndpointDNATFlowKey", reflect.TypeOf((*MockServiceClient)(nil).GetEndpointDNATFlowKey), arg0, arg1)
+}
+
+// GetServiceFlowKeys mocks base method
+func (m *MockServiceClient) GetServiceFlowKeys(arg0 net.IP, arg1 uint16, arg2 openflow.Protocol, arg3 []proxy.Endpoint) []string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetServiceFlowKeys", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].([]string)
+	return ret0
+}
+
+// GetServiceFlowKeys indicates an expected call of GetServiceFlowKeys
+func (mr *MockServiceClientMockRecorder) GetServiceFlowKeys(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetServiceFlowKeys", reflect.TypeOf((*MockServiceClient)(nil).GetServiceFlowKeys), arg0, arg1, arg2, arg3)
+}
+
+// InstallClusterServiceCIDRFlows mocks base method
+func (m *MockServiceClient) InstallClusterServiceCIDRFlows(arg0 []*net.IPNet) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InstallClusterServiceCIDRFlows", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InstallClusterServiceCIDRFlows indicates an expected call of InstallClusterServiceCIDRFlows
+func (mr *MockServiceClientMockRecorder) InstallClusterServiceCIDRFlows(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InstallClusterServiceCIDRFlows", reflect.TypeOf((*MockServiceClient)(nil).InstallClusterServiceCIDRFlows), arg0)
+}
+
+// InstallClusterServiceFlows mocks base method
+func (m *MockServiceClient) InstallClusterServiceFlows() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InstallClusterServiceFlows")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InstallClusterServiceFlows indicates an expected call of InstallClusterServiceFlows
+func (mr *MockServiceClientMockRecorder) InstallClusterServiceFlows() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InstallClusterServiceFlows", reflect.TypeOf((*MockServiceClient)(nil).InstallClusterServiceFlows))
+}
+
+// InstallEndpointFlows mocks base method
+func (m *MockServiceClient) InstallEndpointFlows(arg0 openflow.Protocol, arg1 []proxy.Endpoint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InstallEndpointFlows", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InstallEndpointFlows indicates an expected call of InstallEndpointFlows
+func (mr *MockServiceClientMockRecorder) InstallEndpointFlows(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InstallEndpointFlows", reflect.TypeOf((*MockServiceClient)(nil).InstallEndpointFlows), arg0, arg1)
+}
+
+// InstallLoadBalancerServiceFromOutsideFlows mocks base method
+func (m *MockServiceClient) InstallLoadBalancerServiceFromOutsideFlows(arg0 net.IP, arg1 uint16, arg2 openflow.Protocol, arg3 bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InstallLoadBalancerServiceFromOutsideFlows", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InstallLoadBalancerServiceFromOutsideFlows indicates an expected call of InstallLoadBalancerServiceFromOutsideFlows
+func (mr *MockServiceClientMockRecorder) InstallLoadBalancerServiceFromOutsideFlows(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InstallLoadBalancerServiceFromOutsideFlows", reflect.TypeOf((*MockServiceClient)(nil).InstallLoadBalancerServiceFromOutsideFlows), arg0, arg1, arg2, arg3)
+}
+
+// InstallServiceFlows mocks base method
+func (m *MockServiceClient) InstallServiceFlows(arg0 openflow.GroupIDType, arg1 net.IP, arg2 uint16, arg3 openflow.Protocol, arg4 uint16) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InstallServiceFlows", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InstallServiceFlows indicates an expected call of InstallServiceFlows
+func (mr *MockServiceClientMockRecorder) InstallServiceFlows(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InstallServiceFlows", reflect.TypeOf((*MockServiceClient)(nil).InstallServiceFlows), arg0, arg1, arg2, arg3, arg4)
+}
+
+// InstallServiceGroup mocks base method
+func (m *MockServiceClient) InstallServiceGroup(arg0 openflow.GroupIDType, arg1 bool, arg2 []proxy.Endpoint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InstallServiceGroup", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InstallServiceGroup indicates an expected call of InstallServiceGroup
+func (mr *MockServiceClientMockRecorder) InstallServiceGroup(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InstallServiceGroup", reflect.TypeOf((*MockServiceClient)(nil).InstallServiceGroup), arg0, arg1, arg2)
+}
+
+// InstallServiceSourceRangeFlows mocks base method
+func (m *MockServiceClient) InstallServiceSourceRangeFlows(arg0 openflow.GroupIDType, arg1 net.IP, arg2 uint16, arg3 openflow.Protocol, arg4 uint16, arg5 []net.IPNet) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InstallServiceSourceRangeFlows", arg0, arg1, arg2, arg3, arg4, arg5)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InstallServiceSourceRangeFlows indicates an expected call of InstallServiceSourceRangeFlows
+func (mr *MockServiceClientMockRecorder) InstallServiceSourceRangeFlows(arg0, arg1, arg2, arg3, arg4, arg5 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InstallServiceSourceRangeFlows", reflect.TypeOf((*MockServiceClient)(nil).InstallServiceSourceRangeFlows), arg0, arg1, arg2, arg3, arg4, arg5)
+}
+
+// UninstallEndpointFlows mocks base method
+func (m *MockServiceClient) UninstallEndpointFlows(arg0 openflow.Protocol, arg1 proxy.Endpoint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UninstallEndpointFlows", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UninstallEndpointFlows indicates an expected call of UninstallEndpointFlows
+func (mr *MockServiceClientMockRecorder) UninstallEndpointFlows(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UninstallEndpointFlows", reflect.TypeOf((*MockServiceClient)(nil).UninstallEndpointFlows), arg0, arg1)
+}
+
+// UninstallLoadBalancerServiceFromOutsideFlows mocks base method
+func (m *MockServiceClient) UninstallLoadBalancerServiceFromOutsideFlows(arg0 net.IP, arg1 uint16, arg2 openflow.Protocol) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UninstallLoadBalancerServiceFromOutsideFlows", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UninstallLoadBalancerServiceFromOutsideFlows indicates an expected call of UninstallLoadBalancerServiceFromOutsideFlows
+func (mr *MockServiceClientMockRecorder) UninstallLoadBalancerServiceFromOutsideFlows(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UninstallLoadBalancerServiceFromOutsideFlows", reflect.TypeOf((*MockServiceClient)(nil).UninstallLoadBalancerServiceFromOutsideFlows), arg0, arg1, arg2)
+}
+
+// UninstallServiceFlows mocks base method
+func (m *MockServiceClient) UninstallServiceFlows(arg0 net.IP, arg1 uint16, arg2 openflow.Protocol) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UninstallServiceFlows", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UninstallServiceFlows indicates an expected call of UninstallServiceFlows
+func (mr *MockServiceClientMockRecorder) UninstallServiceFlows(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UninstallServiceFlows", reflect.TypeOf((*MockServiceClient)(nil).UninstallServiceFlows), arg0, arg1, arg2)
+}
+
+// UninstallServiceGroup mocks base method
+func (m *MockServiceClient) UninstallServiceGroup(arg0 openflow.GroupIDType) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UninstallServiceGroup", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UninstallServiceGroup indicates an expected call of UninstallServiceGroup
+func (mr *MockServiceClientMockRecorder) UninstallServiceGroup(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UninstallServiceGroup", reflect.TypeOf((*MockServiceClient)(nil).UninstallServiceGroup), arg0)
+}
+
+// UninstallServiceSourceRangeFlows mocks base method
+func (m *MockServiceClient) UninstallServiceSourceRangeFlows(arg0 net.IP, arg1 uint16, arg2 openflow.Protocol) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UninstallServiceSourceRangeFlows", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UninstallServiceSourceRangeFlows indicates an expected call of UninstallServiceSourceRangeFlows
+func (mr *MockServiceClientMockRecorder) UninstallServiceSourceRangeFlows(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UninstallServiceSourceRangeFlows", reflect.TypeOf((*MockServiceClient)(nil).UninstallServiceSourceRangeFlows), arg0, arg1, arg2)
+}
+
+// MockTraceflowClient is a mock of TraceflowClient interface
+type MockTraceflowClient struct {
+	ctrl     *gomock.Controller
+	recorder *MockTraceflowClientMockRecorder
+}
+
+// MockTraceflowClientMockRecorder is the mock recorder for MockTraceflowClient
+type MockTraceflowClientMockRecorder struct {
+	mock *MockTraceflowClient
+}
+
+// NewMockTraceflowClient creates a new mock instance
+func NewMockTraceflowClient(ctrl *gomock.Controller) *MockTraceflowClient {
+	mock := &MockTraceflowClient{ctrl: ctrl}
+	mock.recorder = &MockTraceflowClientMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockTraceflowClient) EXPECT() *MockTraceflowClientMockRecorder {
+	return m.recorder
+}
+
+// InitialTLVMap mocks base method
+func (m *MockTraceflowClient) InitialTLVMap() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InitialTLVMap")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InitialTLVMap indicates an expected call of InitialTLVMap
+func (mr *MockTraceflowClientMockRecorder) InitialTLVMap() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InitialTLVMap", reflect.TypeOf((*MockTraceflowClient)(nil).InitialTLVMap))
+}
+
+// InstallPacketCaptureFlows mocks base method
+func (m *MockTraceflowClient) InstallPacketCaptureFlows(arg0 string, arg1 *openflow.Packet, arg2 uint16) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InstallPacketCaptureFlows", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InstallPacketCaptureFlows indicates an expected call of InstallPacketCaptureFlows
+func (mr *MockTraceflowClientMockRecorder) InstallPacketCaptureFlows(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InstallPacketCaptureFlows", reflect.TypeOf((*MockTraceflowClient)(nil).InstallPacketCaptureFlows), arg0, arg1, arg2)
+}
+
+// InstallTraceflowFlows mocks base method
+func (m *MockTraceflowClient) InstallTraceflowFlows(arg0 byte, arg1, arg2, arg3 bool, arg4 *openflow.Packet, arg5 uint32, arg6 uint16) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InstallTraceflowFlows", arg0, arg1, arg2, arg3, arg4, arg5, arg6)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InstallTraceflowFlows indicates an expected call of InstallTraceflowFlows
+func (mr *MockTraceflowClientMockRecorder) InstallTraceflowFlows(arg0, arg1, arg2, arg3, arg4, arg5, arg6 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InstallTraceflowFlows", reflect.TypeOf((*MockTraceflowClient)(nil).InstallTraceflowFlows), arg0, arg1, arg2, arg3, arg4, arg5, arg6)
+}
+
+// RegisterPacketInHandler mocks base method
+func (m *MockTraceflowClient) RegisterPacketInHandler(arg0 byte, arg1 string, arg2 interface{}) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "RegisterPacketInHandler", arg0, arg1, arg2)
+}
+
+// RegisterPacketInHandler indicates an expected call of RegisterPacketInHandler
+func (mr *MockTraceflowClientMockRecorder) RegisterPacketInHandler(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterPacketInHandler", reflect.TypeOf((*MockTraceflowClient)(nil).RegisterPacketInHandler), arg0, arg1, arg2)
+}
+
+// SendTraceflowPacket mocks base method
+func (m *MockTraceflowClient) SendTraceflowPacket(arg0 byte, arg1 *openflow.Packet, arg2 uint32, arg3 int32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendTraceflowPacket", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendTraceflowPacket indicates an expected call of SendTraceflowPacket
+func (mr *MockTraceflowClientMockRecorder) SendTraceflowPacket(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendTraceflowPacket", reflect.TypeOf((*MockTraceflowClient)(nil).SendTraceflowPacket), arg0, arg1, arg2, arg3)
+}
+
+// ShrinkPacketInQueues mocks base method
+func (m *MockTraceflowClient) ShrinkPacketInQueues(arg0 bool) int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ShrinkPacketInQueues", arg0)
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// ShrinkPacketInQueues indicates an expected call of ShrinkPacketInQueues
+func (mr *MockTraceflowClientMockRecorder) ShrinkPacketInQueues(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ShrinkPacketInQueues", reflect.TypeOf((*MockTraceflowClient)(nil).ShrinkPacketInQueues), arg0)
+}
+
+// StartPacketInHandler mocks base method
+func (m *MockTraceflowClient) StartPacketInHandler(arg0 []byte, arg1 <-chan struct{}) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "StartPacketInHandler", arg0, arg1)
+}
+
+// StartPacketInHandler indicates an expected call of StartPacketInHandler
+func (mr *MockTraceflowClientMockRecorder) StartPacketInHandler(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartPacketInHandler", reflect.TypeOf((*MockTraceflowClient)(nil).StartPacketInHandler), arg0, arg1)
+}
+
+// SubscribePacketIn mocks base method
+func (m *MockTraceflowClient) SubscribePacketIn(arg0 byte, arg1 *openflow.PacketInQueue) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SubscribePacketIn", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SubscribePacketIn indicates an expected call of SubscribePacketIn
+func (mr *MockTraceflowClientMockRecorder) SubscribePacketIn(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SubscribePacketIn", reflect.TypeOf((*MockTraceflowClient)(nil).SubscribePacketIn), arg0, arg1)
+}
+
+// UninstallPacketCaptureFlows mocks base method
+func (m *MockTraceflowClient) UninstallPacketCaptureFlows(arg0 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UninstallPacketCaptureFlows", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UninstallPacketCaptureFlows indicates an expected call of UninstallPacketCaptureFlows
+func (mr *MockTraceflowClientMockRecorder) UninstallPacketCaptureFlows(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UninstallPacketCaptureFlows", reflect.TypeOf((*MockTraceflowClient)(nil).UninstallPacketCaptureFlows), arg0)
+}
+
+// UninstallTraceflowFlows mocks base method
+func (m *MockTraceflowClient) UninstallTraceflowFlows(arg0 byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UninstallTraceflowFlows", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UninstallTraceflowFlows indicates an expected call of UninstallTraceflowFlows
+func (mr *MockTraceflowClientMockRecorder) UninstallTraceflowFlows(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UninstallTraceflowFlows", reflect.TypeOf((*MockTraceflowClient)(nil).UninstallTraceflowFlows), arg0)
+}
@@ -0,0 +1,279 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otelexporter
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"k8s.io/klog/v2"
+
+	"antrea.io/antrea/pkg/agent/metrics"
+	"antrea.io/antrea/pkg/version"
+)
+
+const (
+	// scopeName is the name of the instrumentation scope of the exported log records.
+	scopeName = "antrea.io/antrea/pkg/agent/otelexporter"
+
+	// exportTimeout bounds the time taken by a request to the collector.
+	exportTimeout = 10 * time.Second
+	// maxExportAttempts is the number of times a batch is sent to the collector before it is
+	// dropped. The batches are retried with an exponential backoff between minRetryDelay and
+	// maxRetryDelay, during which the new log records are queued.
+	maxExportAttempts = 5
+	minRetryDelay     = 1 * time.Second
+	maxRetryDelay     = 30 * time.Second
+	// finalExportTimeout bounds the time spent sending the queued log records when the exporter
+	// stops.
+	finalExportTimeout = 5 * time.Second
+
+	dropReasonQueueFull    = "queue_full"
+	dropReasonExportFailed = "export_failed"
+)
+
+// Options are the settings of the Exporter.
+type Options struct {
+	// Endpoint is the address of the OTLP gRPC endpoint of the collector, as "<HOST>:<PORT>".
+	Endpoint string
+	// Insecure disables TLS.
+	Insecure bool
+	// CACertPath is the path of the CA certificate verifying the collector, the system CAs are
+	// used if it's empty.
+	CACertPath string
+	// ServerName overrides the server name verified in the certificate of the collector.
+	ServerName string
+	// Headers are added to the requests, e.g. for authentication.
+	Headers map[string]string
+	// QueueSize is the maximum number of log records waiting to be sent.
+	QueueSize int
+	// BatchSize is the maximum number of log records sent in a request.
+	BatchSize int
+	// FlushInterval is the interval at which an incomplete batch is sent.
+	FlushInterval time.Duration
+	// NodeName is the name of the Node, set as an attribute of the resource of the log records.
+	NodeName string
+}
+
+// Exporter exports the audit log entries and the flow records to an OpenTelemetry collector, as
+// OTLP log records. The log records are queued and sent in batches by Run, so that exporting a
+// record never blocks: when the collector is slow or unreachable, the queue fills up and the new log
+// records are dropped and counted.
+type Exporter struct {
+	conn          *grpc.ClientConn
+	headers       metadata.MD
+	resource      []keyValue
+	queue         chan *logRecord
+	batchSize     int
+	flushInterval time.Duration
+	minRetryDelay time.Duration
+	maxRetryDelay time.Duration
+	// droppedRecords is the number of log records dropped because the queue was full since the
+	// last warning.
+	droppedRecords uint64
+}
+
+// NewExporter returns an Exporter sending the log records to the collector at opts.Endpoint. The
+// connection to the collector is established in the background, and re-established when it's lost.
+func NewExporter(opts Options) (*Exporter, error) {
+	if opts.Insecure {
+		return newExporter(opts, grpc.WithInsecure())
+	}
+	tlsConfig := &tls.Config{
+		ServerName: opts.ServerName,
+		MinVersion: tls.VersionTLS12,
+	}
+	if opts.CACertPath != "" {
+		caCert, err := ioutil.ReadFile(opts.CACertPath)
+		if err != nil {
+			return nil, fmt.Errorf("error when reading the CA certificate of the OpenTelemetry collector: %v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("no valid certificate in %s", opts.CACertPath)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	return newExporter(opts, grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)))
+}
+
+func newExporter(opts Options, dialOptions ...grpc.DialOption) (*Exporter, error) {
+	dialOptions = append(dialOptions, grpc.WithDefaultCallOptions(grpc.ForceCodec(codec{})))
+	conn, err := grpc.Dial(opts.Endpoint, dialOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("error when connecting to the OpenTelemetry collector %s: %v", opts.Endpoint, err)
+	}
+	return &Exporter{
+		conn:    conn,
+		headers: metadata.New(opts.Headers),
+		resource: []keyValue{
+			{"service.name", "antrea-agent"},
+			{"service.version", version.GetFullVersion()},
+			{"k8s.node.name", opts.NodeName},
+		},
+		queue:         make(chan *logRecord, opts.QueueSize),
+		batchSize:     opts.BatchSize,
+		flushInterval: opts.FlushInterval,
+		minRetryDelay: minRetryDelay,
+		maxRetryDelay: maxRetryDelay,
+	}, nil
+}
+
+// ExportAuditLog queues the log record of an audit log entry. It doesn't block.
+func (e *Exporter) ExportAuditLog(record *AuditLogRecord) {
+	e.enqueue(newAuditLogRecord(record))
+}
+
+// ExportFlowRecord queues the log record of a flow record. It doesn't block.
+func (e *Exporter) ExportFlowRecord(record *FlowRecord) {
+	e.enqueue(newFlowLogRecord(record))
+}
+
+func (e *Exporter) enqueue(record *logRecord) {
+	select {
+	case e.queue <- record:
+	default:
+		metrics.OTelExporterDroppedRecordCount.WithLabelValues(record.recordType, dropReasonQueueFull).Inc()
+		atomic.AddUint64(&e.droppedRecords, 1)
+	}
+}
+
+// Run sends the queued log records in batches until stopCh is closed. A batch is sent when it's
+// full, or every flushInterval otherwise. When stopCh is closed, the log records which are still
+// queued are sent one last time before the connection to the collector is closed.
+func (e *Exporter) Run(stopCh <-chan struct{}) {
+	defer e.conn.Close()
+	ticker := time.NewTicker(e.flushInterval)
+	defer ticker.Stop()
+	batch := make([]*logRecord, 0, e.batchSize)
+	for {
+		select {
+		case record := <-e.queue:
+			batch = append(batch, record)
+			if len(batch) < e.batchSize {
+				continue
+			}
+		case <-ticker.C:
+			if dropped := atomic.SwapUint64(&e.droppedRecords, 0); dropped > 0 {
+				klog.Warningf("Dropped %d log records because the queue of the OpenTelemetry exporter was full", dropped)
+			}
+			if len(batch) == 0 {
+				continue
+			}
+		case <-stopCh:
+			e.exportQueued(batch)
+			return
+		}
+		if !e.exportWithRetry(batch, stopCh) {
+			e.exportQueued(batch)
+			return
+		}
+		batch = batch[:0]
+	}
+}
+
+// exportWithRetry sends a batch until it's accepted by the collector, or maxExportAttempts is
+// reached, in which case the batch is dropped. It returns false if stopCh was closed before the
+// batch could be sent.
+func (e *Exporter) exportWithRetry(batch []*logRecord, stopCh <-chan struct{}) bool {
+	delay := e.minRetryDelay
+	for attempt := 1; ; attempt++ {
+		err := e.export(batch, exportTimeout)
+		if err == nil {
+			return true
+		}
+		if attempt == maxExportAttempts {
+			klog.Errorf("Dropped %d log records after %d failed exports to the OpenTelemetry collector: %v", len(batch), attempt, err)
+			countDroppedRecords(batch, dropReasonExportFailed)
+			return true
+		}
+		klog.Warningf("Failed to export %d log records to the OpenTelemetry collector, retrying in %v: %v", len(batch), delay, err)
+		select {
+		case <-time.After(delay):
+		case <-stopCh:
+			return false
+		}
+		delay *= 2
+		if delay > e.maxRetryDelay {
+			delay = e.maxRetryDelay
+		}
+	}
+}
+
+// exportQueued sends batch and the queued log records once, without retrying, so that the collector
+// gets the latest log records when the agent stops.
+func (e *Exporter) exportQueued(batch []*logRecord) {
+drain:
+	for {
+		select {
+		case record := <-e.queue:
+			batch = append(batch, record)
+		default:
+			break drain
+		}
+	}
+	deadline := time.Now().Add(finalExportTimeout)
+	for len(batch) > 0 {
+		n := len(batch)
+		if n > e.batchSize {
+			n = e.batchSize
+		}
+		if err := e.export(batch[:n], time.Until(deadline)); err != nil {
+			klog.Errorf("Dropped %d log records which could not be exported to the OpenTelemetry collector before stopping: %v", len(batch), err)
+			countDroppedRecords(batch, dropReasonExportFailed)
+			return
+		}
+		batch = batch[n:]
+	}
+}
+
+// export sends a batch of log records to the collector.
+func (e *Exporter) export(batch []*logRecord, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(metadata.NewOutgoingContext(context.Background(), e.headers), timeout)
+	defer cancel()
+	request := &exportLogsRequest{
+		resource:     e.resource,
+		scopeName:    scopeName,
+		scopeVersion: version.GetFullVersion(),
+		records:      batch,
+	}
+	response := &exportLogsResponse{}
+	if err := e.conn.Invoke(ctx, logsExportMethod, request, response); err != nil {
+		return err
+	}
+	// The log records rejected by the collector are not retried, as they would be rejected again.
+	if response.rejectedLogRecords > 0 {
+		klog.Warningf("The OpenTelemetry collector rejected %d of %d log records: %s", response.rejectedLogRecords, len(batch), response.errorMessage)
+	}
+	for _, record := range batch {
+		metrics.OTelExporterExportedRecordCount.WithLabelValues(record.recordType).Inc()
+	}
+	klog.V(4).Infof("Exported %d log records to the OpenTelemetry collector", len(batch))
+	return nil
+}
+
+func countDroppedRecords(batch []*logRecord, reason string) {
+	for _, record := range batch {
+		metrics.OTelExporterDroppedRecordCount.WithLabelValues(record.recordType, reason).Inc()
+	}
+}
@@ -0,0 +1,394 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otelexporter
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"antrea.io/antrea/pkg/agent/flowexporter"
+)
+
+// String implements grpc.Codec, so that the fake collector can decode the requests with codec.
+func (codec) String() string {
+	return "proto"
+}
+
+func (r *exportLogsResponse) marshal() ([]byte, error) {
+	if r.rejectedLogRecords == 0 && r.errorMessage == "" {
+		return nil, nil
+	}
+	partialSuccess := appendVarintField(nil, 1, uint64(r.rejectedLogRecords))
+	partialSuccess = appendStringField(partialSuccess, 2, r.errorMessage)
+	return appendBytesField(nil, 1, partialSuccess), nil
+}
+
+type collectedRecord struct {
+	timestamp    time.Time
+	severityText string
+	body         interface{}
+	attributes   map[string]interface{}
+}
+
+// collectedRequest is an ExportLogsServiceRequest decoded by the fake collector.
+type collectedRequest struct {
+	headers   metadata.MD
+	resource  map[string]interface{}
+	scopeName string
+	records   []collectedRecord
+}
+
+func decodeAnyValue(b []byte) (interface{}, error) {
+	var value interface{}
+	err := forEachField(b, func(field int, v uint64, data []byte) error {
+		switch field {
+		case 1:
+			value = string(data)
+		case 2:
+			value = v != 0
+		case 3:
+			value = int64(v)
+		}
+		return nil
+	})
+	return value, err
+}
+
+func decodeKeyValue(b []byte, attributes map[string]interface{}) error {
+	var key string
+	var value interface{}
+	err := forEachField(b, func(field int, _ uint64, data []byte) error {
+		var err error
+		switch field {
+		case 1:
+			key = string(data)
+		case 2:
+			value, err = decodeAnyValue(data)
+		}
+		return err
+	})
+	attributes[key] = value
+	return err
+}
+
+func decodeLogRecord(b []byte) (collectedRecord, error) {
+	record := collectedRecord{attributes: map[string]interface{}{}}
+	err := forEachField(b, func(field int, v uint64, data []byte) error {
+		var err error
+		switch field {
+		case 1:
+			record.timestamp = time.Unix(0, int64(v))
+		case 3:
+			record.severityText = string(data)
+		case 5:
+			record.body, err = decodeAnyValue(data)
+		case 6:
+			err = decodeKeyValue(data, record.attributes)
+		}
+		return err
+	})
+	return record, err
+}
+
+func (r *collectedRequest) unmarshal(b []byte) error {
+	r.resource = map[string]interface{}{}
+	// ExportLogsServiceRequest.resource_logs
+	return forEachField(b, func(_ int, _ uint64, resourceLogs []byte) error {
+		return forEachField(resourceLogs, func(field int, _ uint64, data []byte) error {
+			switch field {
+			case 1: // ResourceLogs.resource
+				return forEachField(data, func(_ int, _ uint64, attribute []byte) error {
+					return decodeKeyValue(attribute, r.resource)
+				})
+			case 2: // ResourceLogs.scope_logs
+				return forEachField(data, func(field int, _ uint64, data []byte) error {
+					switch field {
+					case 1: // ScopeLogs.scope
+						return forEachField(data, func(field int, _ uint64, data []byte) error {
+							if field == 1 {
+								r.scopeName = string(data)
+							}
+							return nil
+						})
+					case 2: // ScopeLogs.log_records
+						record, err := decodeLogRecord(data)
+						r.records = append(r.records, record)
+						return err
+					}
+					return nil
+				})
+			}
+			return nil
+		})
+	})
+}
+
+// fakeCollector is an in-process OTLP collector receiving the requests of the exporter.
+type fakeCollector struct {
+	requests chan *collectedRequest
+	// failures is the number of requests failed before the requests are accepted.
+	failures int32
+	attempts int32
+}
+
+func (c *fakeCollector) export(ctx context.Context, request *collectedRequest) (*exportLogsResponse, error) {
+	atomic.AddInt32(&c.attempts, 1)
+	if atomic.AddInt32(&c.failures, -1) >= 0 {
+		return nil, status.Error(codes.Unavailable, "collector unavailable")
+	}
+	request.headers, _ = metadata.FromIncomingContext(ctx)
+	c.requests <- request
+	return &exportLogsResponse{}, nil
+}
+
+func (c *fakeCollector) expectRequest(t *testing.T) *collectedRequest {
+	select {
+	case request := <-c.requests:
+		return request
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected a request to the collector, got none")
+	}
+	return nil
+}
+
+// startFakeCollector serves the OTLP logs service of collector on an in-memory listener, and
+// returns an exporter connected to it.
+func startFakeCollector(t *testing.T, collector *fakeCollector, opts Options) (*Exporter, func()) {
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer(grpc.CustomCodec(codec{}))
+	server.RegisterService(&grpc.ServiceDesc{
+		ServiceName: "opentelemetry.proto.collector.logs.v1.LogsService",
+		HandlerType: (*interface{})(nil),
+		Methods: []grpc.MethodDesc{{
+			MethodName: "Export",
+			Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, _ grpc.UnaryServerInterceptor) (interface{}, error) {
+				request := &collectedRequest{}
+				if err := dec(request); err != nil {
+					return nil, err
+				}
+				return srv.(*fakeCollector).export(ctx, request)
+			},
+		}},
+	}, collector)
+	go server.Serve(listener)
+
+	opts.Endpoint = "bufnet"
+	exporter, err := newExporter(opts, grpc.WithInsecure(), grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+		return listener.Dial()
+	}))
+	require.NoError(t, err)
+	return exporter, server.Stop
+}
+
+func newFakeCollector() *fakeCollector {
+	return &fakeCollector{requests: make(chan *collectedRequest, 10)}
+}
+
+func newTestAuditLogRecord(i int) *AuditLogRecord {
+	return &AuditLogRecord{
+		Timestamp:     time.Unix(1600000000, 0),
+		TableName:     "AntreaPolicyIngressRule",
+		NetworkPolicy: "AntreaNetworkPolicy:ns1/anp1",
+		RuleName:      "rule1",
+		Disposition:   "Drop",
+		OFPriority:    "44900",
+		SourceIP:      fmt.Sprintf("10.10.0.%d", i),
+		DestinationIP: "10.10.1.1",
+		PacketLength:  60,
+		Protocol:      "TCP",
+		Message:       "AntreaPolicyIngressRule AntreaNetworkPolicy:ns1/anp1 Drop 44900",
+	}
+}
+
+func TestExporterBatching(t *testing.T) {
+	collector := newFakeCollector()
+	exporter, stop := startFakeCollector(t, collector, Options{
+		Headers:       map[string]string{"Authorization": "Bearer token"},
+		QueueSize:     10,
+		BatchSize:     2,
+		FlushInterval: 100 * time.Millisecond,
+		NodeName:      "node1",
+	})
+	defer stop()
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go exporter.Run(stopCh)
+
+	for i := 0; i < 3; i++ {
+		exporter.ExportAuditLog(newTestAuditLogRecord(i))
+	}
+	// The full batch is sent right away, and the remaining record after the flush interval.
+	request := collector.expectRequest(t)
+	require.Len(t, request.records, 2)
+	assert.Equal(t, []string{"Bearer token"}, request.headers.Get("authorization"))
+	assert.Equal(t, "antrea-agent", request.resource["service.name"])
+	assert.Equal(t, "node1", request.resource["k8s.node.name"])
+	assert.Equal(t, scopeName, request.scopeName)
+	record := request.records[0]
+	assert.Equal(t, time.Unix(1600000000, 0), record.timestamp)
+	assert.Equal(t, "WARN", record.severityText)
+	assert.Equal(t, "AntreaPolicyIngressRule AntreaNetworkPolicy:ns1/anp1 Drop 44900", record.body)
+	assert.Equal(t, map[string]interface{}{
+		"recordType":    "audit_log",
+		"tableName":     "AntreaPolicyIngressRule",
+		"networkPolicy": "AntreaNetworkPolicy:ns1/anp1",
+		"ruleName":      "rule1",
+		"disposition":   "Drop",
+		"ofPriority":    "44900",
+		"sourceIP":      "10.10.0.0",
+		"destinationIP": "10.10.1.1",
+		"packetLength":  int64(60),
+		"protocol":      "TCP",
+	}, record.attributes)
+	assert.Equal(t, "10.10.0.1", request.records[1].attributes["sourceIP"])
+
+	request = collector.expectRequest(t)
+	require.Len(t, request.records, 1)
+	assert.Equal(t, "10.10.0.2", request.records[0].attributes["sourceIP"])
+}
+
+func TestExporterRetry(t *testing.T) {
+	collector := newFakeCollector()
+	exporter, stop := startFakeCollector(t, collector, Options{QueueSize: 10, BatchSize: 1, FlushInterval: time.Second})
+	defer stop()
+	exporter.minRetryDelay = 10 * time.Millisecond
+	exporter.maxRetryDelay = 20 * time.Millisecond
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go exporter.Run(stopCh)
+
+	// The first batch is dropped after maxExportAttempts failures, and the next one is retried
+	// until it's accepted.
+	atomic.StoreInt32(&collector.failures, maxExportAttempts+2)
+	exporter.ExportAuditLog(newTestAuditLogRecord(0))
+	exporter.ExportAuditLog(newTestAuditLogRecord(1))
+	request := collector.expectRequest(t)
+	require.Len(t, request.records, 1)
+	assert.Equal(t, "10.10.0.1", request.records[0].attributes["sourceIP"])
+	assert.Equal(t, int32(maxExportAttempts+3), atomic.LoadInt32(&collector.attempts))
+}
+
+func TestExporterQueueFull(t *testing.T) {
+	collector := newFakeCollector()
+	exporter, stop := startFakeCollector(t, collector, Options{QueueSize: 2, BatchSize: 2, FlushInterval: time.Second})
+	defer stop()
+
+	// The exporter is not running, so the records beyond the queue size are dropped without
+	// blocking.
+	for i := 0; i < 5; i++ {
+		exporter.ExportAuditLog(newTestAuditLogRecord(i))
+	}
+	assert.Len(t, exporter.queue, 2)
+	assert.Equal(t, uint64(3), atomic.LoadUint64(&exporter.droppedRecords))
+}
+
+func TestExporterStop(t *testing.T) {
+	collector := newFakeCollector()
+	exporter, stop := startFakeCollector(t, collector, Options{QueueSize: 10, BatchSize: 2, FlushInterval: time.Hour})
+	defer stop()
+	for i := 0; i < 3; i++ {
+		exporter.ExportAuditLog(newTestAuditLogRecord(i))
+	}
+	stopCh := make(chan struct{})
+	close(stopCh)
+	// The queued records are sent in batches before Run returns.
+	exporter.Run(stopCh)
+	var sourceIPs []interface{}
+	for _, expected := range []int{2, 1} {
+		request := collector.expectRequest(t)
+		require.Len(t, request.records, expected)
+		for _, record := range request.records {
+			sourceIPs = append(sourceIPs, record.attributes["sourceIP"])
+		}
+	}
+	assert.Equal(t, []interface{}{"10.10.0.0", "10.10.0.1", "10.10.0.2"}, sourceIPs)
+}
+
+func TestFlowLogRecord(t *testing.T) {
+	startTime := time.Unix(1600000000, 0)
+	conn := &flowexporter.Connection{
+		StartTime: startTime,
+		StopTime:  startTime.Add(10 * time.Second),
+		FlowKey: flowexporter.Tuple{
+			SourceAddress:      net.ParseIP("fd00:10:10::1"),
+			DestinationAddress: net.ParseIP("fd00:10:96::a"),
+			Protocol:           6,
+			SourcePort:         34567,
+			DestinationPort:    80,
+		},
+		OriginalPackets:            10,
+		OriginalBytes:              1000,
+		ReversePackets:             5,
+		ReverseBytes:               500,
+		SourcePodNamespace:         "ns1",
+		SourcePodName:              "pod1",
+		DestinationServicePortName: "ns1/svc1:http",
+		DestinationServiceAddress:  net.ParseIP("fd00:10:96::a"),
+		DestinationServicePort:     80,
+		TCPState:                   "ESTABLISHED",
+	}
+	request := &exportLogsRequest{records: []*logRecord{newFlowLogRecord(&FlowRecord{
+		Conn:             conn,
+		PacketDeltaCount: 4,
+		OctetDeltaCount:  400,
+		FlowEndReason:    2,
+		FlowType:         1,
+		SourceNodeName:   "node1",
+	})}}
+	data, err := request.marshal()
+	require.NoError(t, err)
+	decoded := &collectedRequest{}
+	require.NoError(t, decoded.unmarshal(data))
+	require.Len(t, decoded.records, 1)
+	record := decoded.records[0]
+	assert.Equal(t, conn.StopTime, record.timestamp)
+	assert.Equal(t, "INFO", record.severityText)
+	assert.Equal(t, "[fd00:10:10::1]:34567 -> [fd00:10:96::a]:80 protocol 6", record.body)
+	for attribute, value := range map[string]interface{}{
+		"recordType":               "flow_record",
+		"flowStartSeconds":         int64(1600000000),
+		"flowEndSeconds":           int64(1600000010),
+		"flowEndReason":            int64(2),
+		"sourceIPv6Address":        "fd00:10:10::1",
+		"destinationIPv6Address":   "fd00:10:96::a",
+		"destinationTransportPort": int64(80),
+		"packetTotalCount":         int64(10),
+		"packetDeltaCount":         int64(4),
+		"octetDeltaCount":          int64(400),
+		"reverseOctetTotalCount":   int64(500),
+		"sourcePodName":            "pod1",
+		"sourceNodeName":           "node1",
+		"destinationNodeName":      "",
+		"destinationClusterIP":     "fd00:10:96::a",
+		"destinationServicePort":   int64(80),
+		"tcpState":                 "ESTABLISHED",
+		"flowType":                 int64(1),
+	} {
+		assert.Equal(t, value, record.attributes[attribute], "Unexpected value of attribute %s", attribute)
+	}
+	assert.NotContains(t, record.attributes, "sourceIPv4Address")
+}
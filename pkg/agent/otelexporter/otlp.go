@@ -0,0 +1,288 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otelexporter
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+)
+
+// This file encodes the messages of the OTLP logs service in the protobuf wire format. Only the
+// fields used by the exporter are encoded, so that the exporter doesn't depend on the generated
+// OTLP protobuf packages. The field numbers are the ones of opentelemetry-proto v0.19.0:
+// https://github.com/open-telemetry/opentelemetry-proto/tree/v0.19.0/opentelemetry/proto
+
+// The wire types of the protobuf encoding.
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+// logsExportMethod is the full name of the gRPC method exporting log records to an OTLP collector.
+const logsExportMethod = "/opentelemetry.proto.collector.logs.v1.LogsService/Export"
+
+// severity is the severity of a log record, as an OTLP SeverityNumber and its short name.
+type severity struct {
+	number uint64
+	text   string
+}
+
+var (
+	severityInfo = severity{number: 9, text: "INFO"}
+	severityWarn = severity{number: 13, text: "WARN"}
+)
+
+// keyValue is an attribute of the resource or of a log record. The value must be a string, an
+// int64 or a bool.
+type keyValue struct {
+	key   string
+	value interface{}
+}
+
+// logRecord is an OTLP LogRecord.
+type logRecord struct {
+	// recordType is the type of the record used as the label of the metrics. It is not exported.
+	recordType   string
+	timestamp    time.Time
+	observedTime time.Time
+	severity     severity
+	body         string
+	attributes   []keyValue
+}
+
+// exportLogsRequest is an OTLP ExportLogsServiceRequest with a single ResourceLogs and a single
+// ScopeLogs.
+type exportLogsRequest struct {
+	resource     []keyValue
+	scopeName    string
+	scopeVersion string
+	records      []*logRecord
+}
+
+// exportLogsResponse is an OTLP ExportLogsServiceResponse. The collector sets the partial success
+// when it rejects some of the log records, which must not be retried.
+type exportLogsResponse struct {
+	rejectedLogRecords int64
+	errorMessage       string
+}
+
+func appendVarint(b []byte, v uint64) []byte {
+	for v >= 0x80 {
+		b = append(b, byte(v)|0x80)
+		v >>= 7
+	}
+	return append(b, byte(v))
+}
+
+func appendTag(b []byte, field int, wireType int) []byte {
+	return appendVarint(b, uint64(field)<<3|uint64(wireType))
+}
+
+func appendVarintField(b []byte, field int, v uint64) []byte {
+	b = appendTag(b, field, wireVarint)
+	return appendVarint(b, v)
+}
+
+func appendFixed64Field(b []byte, field int, v uint64) []byte {
+	b = appendTag(b, field, wireFixed64)
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], v)
+	return append(b, buf[:]...)
+}
+
+func appendBytesField(b []byte, field int, v []byte) []byte {
+	b = appendTag(b, field, wireBytes)
+	b = appendVarint(b, uint64(len(v)))
+	return append(b, v...)
+}
+
+func appendStringField(b []byte, field int, v string) []byte {
+	return appendBytesField(b, field, []byte(v))
+}
+
+// appendAnyValue appends the fields of an AnyValue holding v.
+func appendAnyValue(b []byte, v interface{}) []byte {
+	switch v := v.(type) {
+	case string:
+		return appendStringField(b, 1, v)
+	case bool:
+		if v {
+			return appendVarintField(b, 2, 1)
+		}
+		return appendVarintField(b, 2, 0)
+	case int64:
+		return appendVarintField(b, 3, uint64(v))
+	default:
+		return appendStringField(b, 1, fmt.Sprint(v))
+	}
+}
+
+func appendKeyValueField(b []byte, field int, kv keyValue) []byte {
+	m := appendStringField(nil, 1, kv.key)
+	m = appendBytesField(m, 2, appendAnyValue(nil, kv.value))
+	return appendBytesField(b, field, m)
+}
+
+func (r *logRecord) marshal() []byte {
+	b := appendFixed64Field(nil, 1, uint64(r.timestamp.UnixNano()))
+	b = appendVarintField(b, 2, r.severity.number)
+	b = appendStringField(b, 3, r.severity.text)
+	b = appendBytesField(b, 5, appendAnyValue(nil, r.body))
+	for _, attribute := range r.attributes {
+		b = appendKeyValueField(b, 6, attribute)
+	}
+	return appendFixed64Field(b, 11, uint64(r.observedTime.UnixNano()))
+}
+
+func (r *exportLogsRequest) marshal() ([]byte, error) {
+	var resource []byte
+	for _, attribute := range r.resource {
+		resource = appendKeyValueField(resource, 1, attribute)
+	}
+	scope := appendStringField(nil, 1, r.scopeName)
+	scope = appendStringField(scope, 2, r.scopeVersion)
+	scopeLogs := appendBytesField(nil, 1, scope)
+	for _, record := range r.records {
+		scopeLogs = appendBytesField(scopeLogs, 2, record.marshal())
+	}
+	resourceLogs := appendBytesField(nil, 1, resource)
+	resourceLogs = appendBytesField(resourceLogs, 2, scopeLogs)
+	return appendBytesField(nil, 1, resourceLogs), nil
+}
+
+// consumeVarint decodes the varint at the start of b, and returns it with its length.
+func consumeVarint(b []byte) (uint64, int, error) {
+	var v uint64
+	for i := 0; i < len(b) && i < 10; i++ {
+		v |= uint64(b[i]&0x7f) << (7 * i)
+		if b[i] < 0x80 {
+			return v, i + 1, nil
+		}
+	}
+	return 0, 0, fmt.Errorf("invalid varint")
+}
+
+// consumeField decodes the field at the start of b. It returns the field number, the wire type,
+// the value of a varint or fixed field, or the bytes of a length-delimited field, and the length of
+// the field.
+func consumeField(b []byte) (field int, wireType int, v uint64, data []byte, n int, err error) {
+	tag, n, err := consumeVarint(b)
+	if err != nil {
+		return 0, 0, 0, nil, 0, err
+	}
+	field, wireType = int(tag>>3), int(tag&0x7)
+	switch wireType {
+	case wireVarint:
+		var m int
+		v, m, err = consumeVarint(b[n:])
+		n += m
+	case wireFixed64:
+		if len(b) < n+8 {
+			return 0, 0, 0, nil, 0, fmt.Errorf("truncated fixed64 field %d", field)
+		}
+		v = binary.LittleEndian.Uint64(b[n:])
+		n += 8
+	case wireFixed32:
+		if len(b) < n+4 {
+			return 0, 0, 0, nil, 0, fmt.Errorf("truncated fixed32 field %d", field)
+		}
+		v = uint64(binary.LittleEndian.Uint32(b[n:]))
+		n += 4
+	case wireBytes:
+		var length uint64
+		var m int
+		length, m, err = consumeVarint(b[n:])
+		if err == nil && uint64(len(b)-n-m) < length {
+			err = fmt.Errorf("truncated length-delimited field %d", field)
+		}
+		if err == nil {
+			data = b[n+m : n+m+int(length)]
+			n += m + int(length)
+		}
+	default:
+		err = fmt.Errorf("unsupported wire type %d of field %d", wireType, field)
+	}
+	if err != nil {
+		return 0, 0, 0, nil, 0, err
+	}
+	return field, wireType, v, data, n, nil
+}
+
+// forEachField calls fn with every field of the message b.
+func forEachField(b []byte, fn func(field int, v uint64, data []byte) error) error {
+	for len(b) > 0 {
+		field, _, v, data, n, err := consumeField(b)
+		if err != nil {
+			return err
+		}
+		if err := fn(field, v, data); err != nil {
+			return err
+		}
+		b = b[n:]
+	}
+	return nil
+}
+
+func (r *exportLogsResponse) unmarshal(b []byte) error {
+	return forEachField(b, func(field int, _ uint64, data []byte) error {
+		if field != 1 {
+			return nil
+		}
+		return forEachField(data, func(field int, v uint64, data []byte) error {
+			switch field {
+			case 1:
+				r.rejectedLogRecords = int64(v)
+			case 2:
+				r.errorMessage = string(data)
+			}
+			return nil
+		})
+	})
+}
+
+type marshaler interface {
+	marshal() ([]byte, error)
+}
+
+type unmarshaler interface {
+	unmarshal([]byte) error
+}
+
+// codec is the gRPC codec of the OTLP messages. Its name is "proto", so that the messages are sent
+// with the content-subtype expected by the collector.
+type codec struct{}
+
+func (codec) Marshal(v interface{}) ([]byte, error) {
+	m, ok := v.(marshaler)
+	if !ok {
+		return nil, fmt.Errorf("cannot marshal %T", v)
+	}
+	return m.marshal()
+}
+
+func (codec) Unmarshal(data []byte, v interface{}) error {
+	m, ok := v.(unmarshaler)
+	if !ok {
+		return fmt.Errorf("cannot unmarshal %T", v)
+	}
+	return m.unmarshal(data)
+}
+
+func (codec) Name() string {
+	return "proto"
+}
@@ -0,0 +1,161 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otelexporter
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"antrea.io/antrea/pkg/agent/flowexporter"
+)
+
+// The types of the exported records, set as the "recordType" attribute of the log records and used
+// as the label of the metrics.
+const (
+	recordTypeAuditLog   = "audit_log"
+	recordTypeFlowRecord = "flow_record"
+)
+
+// AuditLogRecord is an audit log entry, written for a packet matching an Antrea-native policy rule
+// with logging enabled, or dropped by K8s NetworkPolicy isolation.
+type AuditLogRecord struct {
+	Timestamp     time.Time
+	TableName     string
+	NetworkPolicy string
+	// RuleName is empty for the packets dropped by K8s NetworkPolicy isolation.
+	RuleName      string
+	Disposition   string
+	OFPriority    string
+	SourceIP      string
+	DestinationIP string
+	PacketLength  uint16
+	Protocol      string
+	// Message is the entry as written to the audit log file, used as the body of the log record.
+	Message string
+}
+
+// FlowRecord is a record of a connection sent by the flow exporter. The attributes of its log
+// record are named after the IPFIX information elements of the records sent to the IPFIX
+// collector.
+type FlowRecord struct {
+	Conn *flowexporter.Connection
+	// The packets and bytes of the connection since its previous record, in both directions.
+	PacketDeltaCount        uint64
+	OctetDeltaCount         uint64
+	ReversePacketDeltaCount uint64
+	ReverseOctetDeltaCount  uint64
+	FlowEndReason           uint8
+	FlowType                uint8
+	// The Node names are only set for the local Pods.
+	SourceNodeName      string
+	DestinationNodeName string
+}
+
+func newAuditLogRecord(record *AuditLogRecord) *logRecord {
+	// The dropped and rejected packets are warnings, like in the Windows Event Log.
+	recordSeverity := severityInfo
+	if record.Disposition == "Drop" || record.Disposition == "Reject" {
+		recordSeverity = severityWarn
+	}
+	return &logRecord{
+		recordType:   recordTypeAuditLog,
+		timestamp:    record.Timestamp,
+		observedTime: time.Now(),
+		severity:     recordSeverity,
+		body:         record.Message,
+		attributes: []keyValue{
+			{"recordType", recordTypeAuditLog},
+			{"tableName", record.TableName},
+			{"networkPolicy", record.NetworkPolicy},
+			{"ruleName", record.RuleName},
+			{"disposition", record.Disposition},
+			{"ofPriority", record.OFPriority},
+			{"sourceIP", record.SourceIP},
+			{"destinationIP", record.DestinationIP},
+			{"packetLength", int64(record.PacketLength)},
+			{"protocol", record.Protocol},
+		},
+	}
+}
+
+func newFlowLogRecord(record *FlowRecord) *logRecord {
+	conn := record.Conn
+	sourceAddress, destinationAddress := "sourceIPv4Address", "destinationIPv4Address"
+	if conn.FlowKey.SourceAddress.To4() == nil {
+		sourceAddress, destinationAddress = "sourceIPv6Address", "destinationIPv6Address"
+	}
+	attributes := []keyValue{
+		{"recordType", recordTypeFlowRecord},
+		{"flowStartSeconds", conn.StartTime.Unix()},
+		{"flowEndSeconds", conn.StopTime.Unix()},
+		{"flowEndReason", int64(record.FlowEndReason)},
+		{sourceAddress, conn.FlowKey.SourceAddress.String()},
+		{destinationAddress, conn.FlowKey.DestinationAddress.String()},
+		{"sourceTransportPort", int64(conn.FlowKey.SourcePort)},
+		{"destinationTransportPort", int64(conn.FlowKey.DestinationPort)},
+		{"protocolIdentifier", int64(conn.FlowKey.Protocol)},
+		{"packetTotalCount", int64(conn.OriginalPackets)},
+		{"octetTotalCount", int64(conn.OriginalBytes)},
+		{"packetDeltaCount", int64(record.PacketDeltaCount)},
+		{"octetDeltaCount", int64(record.OctetDeltaCount)},
+		{"reversePacketTotalCount", int64(conn.ReversePackets)},
+		{"reverseOctetTotalCount", int64(conn.ReverseBytes)},
+		{"reversePacketDeltaCount", int64(record.ReversePacketDeltaCount)},
+		{"reverseOctetDeltaCount", int64(record.ReverseOctetDeltaCount)},
+		{"sourcePodNamespace", conn.SourcePodNamespace},
+		{"sourcePodName", conn.SourcePodName},
+		{"sourceNodeName", record.SourceNodeName},
+		{"destinationPodNamespace", conn.DestinationPodNamespace},
+		{"destinationPodName", conn.DestinationPodName},
+		{"destinationNodeName", record.DestinationNodeName},
+		{"ingressNetworkPolicyName", conn.IngressNetworkPolicyName},
+		{"ingressNetworkPolicyNamespace", conn.IngressNetworkPolicyNamespace},
+		{"ingressNetworkPolicyType", int64(conn.IngressNetworkPolicyType)},
+		{"ingressNetworkPolicyRuleName", conn.IngressNetworkPolicyRuleName},
+		{"ingressNetworkPolicyRuleAction", int64(conn.IngressNetworkPolicyRuleAction)},
+		{"egressNetworkPolicyName", conn.EgressNetworkPolicyName},
+		{"egressNetworkPolicyNamespace", conn.EgressNetworkPolicyNamespace},
+		{"egressNetworkPolicyType", int64(conn.EgressNetworkPolicyType)},
+		{"egressNetworkPolicyRuleName", conn.EgressNetworkPolicyRuleName},
+		{"egressNetworkPolicyRuleAction", int64(conn.EgressNetworkPolicyRuleAction)},
+		{"tcpState", conn.TCPState},
+		{"flowType", int64(record.FlowType)},
+	}
+	if conn.DestinationServicePortName != "" {
+		attributes = append(attributes,
+			keyValue{"destinationClusterIP", conn.DestinationServiceAddress.String()},
+			keyValue{"destinationServicePort", int64(conn.DestinationServicePort)},
+			keyValue{"destinationServicePortName", conn.DestinationServicePortName},
+		)
+	}
+	return &logRecord{
+		recordType:   recordTypeFlowRecord,
+		timestamp:    conn.StopTime,
+		observedTime: time.Now(),
+		severity:     severityInfo,
+		body:         flowRecordMessage(conn),
+		attributes:   attributes,
+	}
+}
+
+// flowRecordMessage returns the 5-tuple of the connection, used as the body of its log records.
+func flowRecordMessage(conn *flowexporter.Connection) string {
+	return fmt.Sprintf("%s -> %s protocol %d",
+		net.JoinHostPort(conn.FlowKey.SourceAddress.String(), strconv.Itoa(int(conn.FlowKey.SourcePort))),
+		net.JoinHostPort(conn.FlowKey.DestinationAddress.String(), strconv.Itoa(int(conn.FlowKey.DestinationPort))),
+		conn.FlowKey.Protocol)
+}
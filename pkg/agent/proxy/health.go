@@ -0,0 +1,60 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"fmt"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+
+	crdv1beta1 "antrea.io/antrea/pkg/apis/crd/v1beta1"
+	"antrea.io/antrea/pkg/monitor/condition"
+)
+
+// proxyHealth is shared by the IPv4 and IPv6 proxiers, which report the health of AntreaProxy with
+// the same ProxyHealthy condition.
+var proxyHealth = newHealthTracker(condition.Set)
+
+// healthTracker sets the ProxyHealthy condition of the agent according to the number of operations
+// which failed in the last sync of each proxier.
+type healthTracker struct {
+	mutex        sync.Mutex
+	ipv4Failures int
+	ipv6Failures int
+	setCondition func(conditionType crdv1beta1.AgentConditionType, status corev1.ConditionStatus, reason, message string)
+}
+
+func newHealthTracker(setCondition func(conditionType crdv1beta1.AgentConditionType, status corev1.ConditionStatus, reason, message string)) *healthTracker {
+	return &healthTracker{setCondition: setCondition}
+}
+
+// syncDone records the number of operations which failed in the last sync of the IPv4 or IPv6
+// proxier. The failed operations are retried in the next sync.
+func (t *healthTracker) syncDone(isIPv6 bool, failures int) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if isIPv6 {
+		t.ipv6Failures = failures
+	} else {
+		t.ipv4Failures = failures
+	}
+	total := t.ipv4Failures + t.ipv6Failures
+	if total == 0 {
+		t.setCondition(crdv1beta1.ProxyHealthy, corev1.ConditionTrue, "", "")
+		return
+	}
+	t.setCondition(crdv1beta1.ProxyHealthy, corev1.ConditionFalse, "SyncFailed", fmt.Sprintf("%d Service or Endpoint operation(s) failed in the last sync", total))
+}
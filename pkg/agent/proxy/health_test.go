@@ -0,0 +1,55 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	crdv1beta1 "antrea.io/antrea/pkg/apis/crd/v1beta1"
+	"antrea.io/antrea/pkg/monitor/condition"
+)
+
+func TestHealthTracker(t *testing.T) {
+	conditionManager := condition.NewManager()
+	tracker := newHealthTracker(conditionManager.Set)
+	getCondition := func() crdv1beta1.AgentCondition {
+		conditions := conditionManager.List(metav1.Now())
+		require.Len(t, conditions, 1)
+		return conditions[0]
+	}
+
+	tracker.syncDone(false, 0)
+	assert.Equal(t, corev1.ConditionTrue, getCondition().Status)
+
+	// The failures of both proxiers are reported until each of them syncs successfully.
+	tracker.syncDone(false, 2)
+	tracker.syncDone(true, 1)
+	c := getCondition()
+	assert.Equal(t, corev1.ConditionFalse, c.Status)
+	assert.Equal(t, "SyncFailed", c.Reason)
+	assert.Equal(t, "3 Service or Endpoint operation(s) failed in the last sync", c.Message)
+	tracker.syncDone(false, 0)
+	assert.Equal(t, "1 Service or Endpoint operation(s) failed in the last sync", getCondition().Message)
+	tracker.syncDone(true, 0)
+	c = getCondition()
+	assert.Equal(t, corev1.ConditionTrue, c.Status)
+	assert.Empty(t, c.Reason)
+	assert.Empty(t, c.Message)
+}
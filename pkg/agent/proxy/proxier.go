@@ -60,6 +60,10 @@ type Proxier interface {
 	// flows and the OVS group IDs for a Service. False is returned if the
 	// Service is not found.
 	GetServiceFlowKeys(serviceName, namespace string) ([]string, []binding.GroupIDType, bool)
+	// GetServiceEndpoints returns the installed Endpoints and the OVS group
+	// ID of each port of a Service. False is returned if the Service is not
+	// found.
+	GetServiceEndpoints(serviceName, namespace string) ([]types.ServicePortEndpoints, bool)
 	// GetServiceByIP returns the ServicePortName struct for the given serviceString(ClusterIP:Port/Proto).
 	// False is returned if the serviceString is not found in serviceStringMap.
 	GetServiceByIP(serviceStr string) (k8sproxy.ServicePortName, bool)
@@ -101,7 +105,7 @@ type proxier struct {
 
 	runner              *k8sproxy.BoundedFrequencyRunner
 	stopChan            <-chan struct{}
-	ofClient            openflow.Client
+	ofClient            openflow.ServiceClient
 	isIPv6              bool
 	enableEndpointSlice bool
 }
@@ -117,8 +121,9 @@ func (p *proxier) isInitialized() bool {
 // removeStaleServices removes all expired Services. Once a Service is deleted, all
 // its Endpoints will be expired, and the removeStaleEndpoints method takes
 // responsibility for cleaning up, thus we don't need to call removeEndpoint in this
-// function.
-func (p *proxier) removeStaleServices() {
+// function. It returns the number of operations which failed.
+func (p *proxier) removeStaleServices() int {
+	failures := 0
 	for svcPortName, svcPort := range p.serviceInstalledMap {
 		if _, ok := p.serviceMap[svcPortName]; ok {
 			continue
@@ -130,12 +135,19 @@ func (p *proxier) removeStaleServices() {
 		}
 		if err := p.ofClient.UninstallServiceFlows(svcInfo.ClusterIP(), uint16(svcInfo.Port()), svcInfo.OFProtocol); err != nil {
 			klog.Errorf("Failed to remove flows of Service %v: %v", svcPortName, err)
+			failures++
 			continue
 		}
-		for _, ingress := range svcInfo.LoadBalancerIPStrings() {
+		if err := p.uninstallServiceSourceRangeFlows(svcInfo, svcInfo.LoadBalancerIPStrings()); err != nil {
+			klog.Errorf("Failed to remove source range flows of Service %v: %v", svcPortName, err)
+			failures++
+			continue
+		}
+		for _, ingress := range loadBalancerAndExternalIPStrings(svcInfo) {
 			if ingress != "" {
 				if err := p.uninstallLoadBalancerServiceFlows(net.ParseIP(ingress), uint16(svcInfo.Port()), svcInfo.OFProtocol); err != nil {
 					klog.Errorf("Error when removing Service flows: %v", err)
+					failures++
 					continue
 				}
 			}
@@ -143,12 +155,14 @@ func (p *proxier) removeStaleServices() {
 		groupID, _ := p.groupCounter.Get(svcPortName)
 		if err := p.ofClient.UninstallServiceGroup(groupID); err != nil {
 			klog.Errorf("Failed to remove flows of Service %v: %v", svcPortName, err)
+			failures++
 			continue
 		}
 		delete(p.serviceInstalledMap, svcPortName)
 		p.deleteServiceByIP(svcInfo.String())
 		p.groupCounter.Recycle(svcPortName)
 	}
+	return failures
 }
 
 func getBindingProtoForIPProto(endpointIP string, protocol corev1.Protocol) binding.Protocol {
@@ -196,13 +210,15 @@ func (p *proxier) removeEndpoint(endpoint k8sproxy.Endpoint, protocol binding.Pr
 }
 
 // removeStaleEndpoints compares Endpoints we installed with Endpoints we expected. All installed but unexpected Endpoints
-// will be deleted by using removeEndpoint.
-func (p *proxier) removeStaleEndpoints() {
+// will be deleted by using removeEndpoint. It returns the number of operations which failed.
+func (p *proxier) removeStaleEndpoints() int {
+	failures := 0
 	for svcPortName, installedEps := range p.endpointsInstalledMap {
 		for installedEpName, installedEp := range installedEps {
 			if _, ok := p.endpointsMap[svcPortName][installedEpName]; !ok {
 				if _, err := p.removeEndpoint(installedEp, getBindingProtoForIPProto(installedEp.IP(), svcPortName.Protocol)); err != nil {
 					klog.Errorf("Error when removing Endpoint %v for %v", installedEp, svcPortName)
+					failures++
 					continue
 				}
 				delete(installedEps, installedEpName)
@@ -212,6 +228,7 @@ func (p *proxier) removeStaleEndpoints() {
 			delete(p.endpointsInstalledMap, svcPortName)
 		}
 	}
+	return failures
 }
 
 func serviceIdentityChanged(svcInfo, pSvcInfo *types.ServiceInfo) bool {
@@ -241,7 +258,63 @@ func smallSliceDifference(s1, s2 []string) []string {
 	return diff
 }
 
-func (p *proxier) installServices() {
+// loadBalancerAndExternalIPStrings returns the ingress IPs of a LoadBalancer Service and the
+// external IPs of a Service, which are accessed through the same flows.
+func loadBalancerAndExternalIPStrings(svcInfo *types.ServiceInfo) []string {
+	return append(svcInfo.LoadBalancerIPStrings(), svcInfo.ExternalIPStrings()...)
+}
+
+// sourceRangesChanged returns whether the loadBalancerSourceRanges of the Service have changed.
+func sourceRangesChanged(svcInfo, pSvcInfo *types.ServiceInfo) bool {
+	return len(smallSliceDifference(svcInfo.LoadBalancerSourceRanges(), pSvcInfo.LoadBalancerSourceRanges())) > 0 ||
+		len(smallSliceDifference(pSvcInfo.LoadBalancerSourceRanges(), svcInfo.LoadBalancerSourceRanges())) > 0
+}
+
+// installServiceSourceRangeFlows restricts the clients of the LoadBalancer ingress IPs of the
+// Service to its loadBalancerSourceRanges. Like kube-proxy, the source ranges don't apply to the
+// ClusterIP and the external IPs of the Service.
+func (p *proxier) installServiceSourceRangeFlows(groupID binding.GroupIDType, svcInfo *types.ServiceInfo) error {
+	if len(svcInfo.LoadBalancerSourceRanges()) == 0 {
+		return nil
+	}
+	var sourceRanges []net.IPNet
+	for _, sourceRange := range svcInfo.LoadBalancerSourceRanges() {
+		_, ipNet, err := net.ParseCIDR(sourceRange)
+		if err != nil {
+			return fmt.Errorf("invalid loadBalancerSourceRange %s: %w", sourceRange, err)
+		}
+		sourceRanges = append(sourceRanges, *ipNet)
+	}
+	for _, ingress := range svcInfo.LoadBalancerIPStrings() {
+		if ingress != "" {
+			if err := p.ofClient.InstallServiceSourceRangeFlows(groupID, net.ParseIP(ingress), uint16(svcInfo.Port()), svcInfo.OFProtocol, uint16(svcInfo.StickyMaxAgeSeconds()), sourceRanges); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// uninstallServiceSourceRangeFlows removes the flows installed by installServiceSourceRangeFlows
+// for the given LoadBalancer ingress IPs of the Service, if it has source ranges.
+func (p *proxier) uninstallServiceSourceRangeFlows(svcInfo *types.ServiceInfo, ingressIPs []string) error {
+	if len(svcInfo.LoadBalancerSourceRanges()) == 0 {
+		return nil
+	}
+	for _, ingress := range ingressIPs {
+		if ingress != "" {
+			if err := p.ofClient.UninstallServiceSourceRangeFlows(net.ParseIP(ingress), uint16(svcInfo.Port()), svcInfo.OFProtocol); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// installServices installs the flows of the Services and Endpoints which are expected but not
+// installed yet, or which have changed. It returns the number of operations which failed.
+func (p *proxier) installServices() int {
+	failures := 0
 	for svcPortName, svcPort := range p.serviceMap {
 		svcInfo := svcPort.(*types.ServiceInfo)
 		groupID, _ := p.groupCounter.Get(svcPortName)
@@ -261,8 +334,10 @@ func (p *proxier) installServices() {
 		var needRemoval, needUpdateService, needUpdateEndpoints bool
 		if ok { // Need to update.
 			pSvcInfo = installedSvcPort.(*types.ServiceInfo)
-			needRemoval = serviceIdentityChanged(svcInfo, pSvcInfo) || (svcInfo.SessionAffinityType() != pSvcInfo.SessionAffinityType())
-			needUpdateService = needRemoval || (svcInfo.StickyMaxAgeSeconds() != pSvcInfo.StickyMaxAgeSeconds())
+			needRemoval = serviceIdentityChanged(svcInfo, pSvcInfo) || (svcInfo.SessionAffinityType() != pSvcInfo.SessionAffinityType()) ||
+				(svcInfo.ExternalSNATDisabled != pSvcInfo.ExternalSNATDisabled)
+			needUpdateService = needRemoval || (svcInfo.StickyMaxAgeSeconds() != pSvcInfo.StickyMaxAgeSeconds()) ||
+				sourceRangesChanged(svcInfo, pSvcInfo)
 			needUpdateEndpoints = pSvcInfo.SessionAffinityType() != svcInfo.SessionAffinityType()
 		} else { // Need to install.
 			needUpdateService = true
@@ -310,11 +385,17 @@ func (p *proxier) installServices() {
 
 		var deletedLoadBalancerIPs, addedLoadBalancerIPs []string
 		if pSvcInfo != nil {
-			deletedLoadBalancerIPs = smallSliceDifference(pSvcInfo.LoadBalancerIPStrings(), svcInfo.LoadBalancerIPStrings())
-			addedLoadBalancerIPs = smallSliceDifference(svcInfo.LoadBalancerIPStrings(), pSvcInfo.LoadBalancerIPStrings())
+			deletedLoadBalancerIPs = smallSliceDifference(loadBalancerAndExternalIPStrings(pSvcInfo), loadBalancerAndExternalIPStrings(svcInfo))
+			addedLoadBalancerIPs = smallSliceDifference(loadBalancerAndExternalIPStrings(svcInfo), loadBalancerAndExternalIPStrings(pSvcInfo))
+			// The source range flows of an IP moved between the external IPs and the
+			// LoadBalancer ingress IPs must be updated.
+			if len(smallSliceDifference(pSvcInfo.LoadBalancerIPStrings(), svcInfo.LoadBalancerIPStrings())) > 0 ||
+				len(smallSliceDifference(svcInfo.LoadBalancerIPStrings(), pSvcInfo.LoadBalancerIPStrings())) > 0 {
+				needUpdateService = true
+			}
 		} else {
 			deletedLoadBalancerIPs = []string{}
-			addedLoadBalancerIPs = svcInfo.LoadBalancerIPStrings()
+			addedLoadBalancerIPs = loadBalancerAndExternalIPStrings(svcInfo)
 		}
 		if len(deletedLoadBalancerIPs) > 0 || len(addedLoadBalancerIPs) > 0 {
 			needUpdateService = true
@@ -335,11 +416,13 @@ func (p *proxier) installServices() {
 			err := p.ofClient.InstallEndpointFlows(svcInfo.OFProtocol, endpointUpdateList)
 			if err != nil {
 				klog.Errorf("Error when installing Endpoints flows: %v", err)
+				failures++
 				continue
 			}
 			err = p.ofClient.InstallServiceGroup(groupID, svcInfo.StickyMaxAgeSeconds() != 0, endpointUpdateList)
 			if err != nil {
 				klog.Errorf("Error when installing Endpoints groups: %v", err)
+				failures++
 				continue
 			}
 			for _, e := range endpointUpdateList {
@@ -357,47 +440,72 @@ func (p *proxier) installServices() {
 			if needRemoval {
 				if err := p.ofClient.UninstallServiceFlows(pSvcInfo.ClusterIP(), uint16(pSvcInfo.Port()), pSvcInfo.OFProtocol); err != nil {
 					klog.Errorf("Failed to remove flows of Service %v: %v", svcPortName, err)
+					failures++
 					continue
 				}
 			}
 			if err := p.ofClient.InstallServiceFlows(groupID, svcInfo.ClusterIP(), uint16(svcInfo.Port()), svcInfo.OFProtocol, uint16(svcInfo.StickyMaxAgeSeconds())); err != nil {
 				klog.Errorf("Error when installing Service flows: %v", err)
+				failures++
 				continue
 			}
-			// Install OpenFlow entries for the ingress IPs of LoadBalancer Service.
+			// Install OpenFlow entries for the ingress IPs of LoadBalancer Service
+			// and the external IPs of Service.
 			// The LoadBalancer Service should be accessible from Pod, Node and
 			// external host.
 			var toDelete, toAdd []string
 			if needRemoval {
-				toDelete = pSvcInfo.LoadBalancerIPStrings()
-				toAdd = svcInfo.LoadBalancerIPStrings()
+				toDelete = loadBalancerAndExternalIPStrings(pSvcInfo)
+				toAdd = loadBalancerAndExternalIPStrings(svcInfo)
 			} else {
 				toDelete = deletedLoadBalancerIPs
 				toAdd = addedLoadBalancerIPs
 			}
+			if pSvcInfo != nil {
+				// Remove the source range flows of the ingress IPs which are removed, or
+				// of all the ingress IPs if the flows are reinstalled with another port or
+				// protocol, or if the Service has no source ranges anymore.
+				staleIngressIPs := pSvcInfo.LoadBalancerIPStrings()
+				if !needRemoval && len(svcInfo.LoadBalancerSourceRanges()) > 0 {
+					staleIngressIPs = smallSliceDifference(pSvcInfo.LoadBalancerIPStrings(), svcInfo.LoadBalancerIPStrings())
+				}
+				if err := p.uninstallServiceSourceRangeFlows(pSvcInfo, staleIngressIPs); err != nil {
+					klog.Errorf("Error when removing source range flows of Service %v: %v", svcPortName, err)
+					failures++
+					continue
+				}
+			}
 			for _, ingress := range toDelete {
 				if ingress != "" {
 					// It is safe to access pSvcInfo here. If this is a new Service,
 					// then toDelete will be an empty slice.
 					if err := p.uninstallLoadBalancerServiceFlows(net.ParseIP(ingress), uint16(pSvcInfo.Port()), pSvcInfo.OFProtocol); err != nil {
 						klog.Errorf("Error when removing LoadBalancer Service flows: %v", err)
+						failures++
 						continue
 					}
 				}
 			}
 			for _, ingress := range toAdd {
 				if ingress != "" {
-					if err := p.installLoadBalancerServiceFlows(groupID, net.ParseIP(ingress), uint16(svcInfo.Port()), svcInfo.OFProtocol, uint16(svcInfo.StickyMaxAgeSeconds())); err != nil {
+					if err := p.installLoadBalancerServiceFlows(groupID, net.ParseIP(ingress), uint16(svcInfo.Port()), svcInfo.OFProtocol, uint16(svcInfo.StickyMaxAgeSeconds()), svcInfo.ExternalSNATDisabled); err != nil {
 						klog.Errorf("Error when installing LoadBalancer Service flows: %v", err)
+						failures++
 						continue
 					}
 				}
 			}
+			if err := p.installServiceSourceRangeFlows(groupID, svcInfo); err != nil {
+				klog.Errorf("Error when installing source range flows of Service %v: %v", svcPortName, err)
+				failures++
+				continue
+			}
 		}
 
 		p.serviceInstalledMap[svcPortName] = svcPort
 		p.addServiceByIP(svcInfo.String(), svcPortName)
 	}
+	return failures
 }
 
 // syncProxyRules applies current changes in change trackers and then updates
@@ -432,9 +540,10 @@ func (p *proxier) syncProxyRules() {
 	p.endpointsChanges.Update(p.endpointsMap)
 	p.serviceChanges.Update(p.serviceMap)
 
-	p.removeStaleServices()
-	p.installServices()
-	p.removeStaleEndpoints()
+	failures := p.removeStaleServices()
+	failures += p.installServices()
+	failures += p.removeStaleEndpoints()
+	proxyHealth.syncDone(p.isIPv6, failures)
 
 	counter := 0
 	for _, endpoints := range p.endpointsMap {
@@ -612,10 +721,57 @@ func (p *proxier) GetServiceFlowKeys(serviceName, namespace string) ([]string, [
 	return flows, groups, found
 }
 
+func (p *proxier) GetServiceEndpoints(serviceName, namespace string) ([]types.ServicePortEndpoints, bool) {
+	namespacedName := k8sapitypes.NamespacedName{Namespace: namespace, Name: serviceName}
+	p.serviceEndpointsMapsMutex.Lock()
+	defer p.serviceEndpointsMapsMutex.Unlock()
+
+	var svcPorts []types.ServicePortEndpoints
+	found := false
+	for svcPortName := range p.serviceMap {
+		if namespacedName != svcPortName.NamespacedName {
+			continue
+		}
+		found = true
+
+		installedSvcPort, ok := p.serviceInstalledMap[svcPortName]
+		if !ok {
+			// Service flows not installed.
+			continue
+		}
+		svcInfo := installedSvcPort.(*types.ServiceInfo)
+		groupID, _ := p.groupCounter.Get(svcPortName)
+		endpoints := p.endpointsInstalledMap[svcPortName]
+		svcEndpoints := make([]types.ServiceEndpoint, 0, len(endpoints))
+		for _, ep := range endpoints {
+			svcEndpoints = append(svcEndpoints, types.ServiceEndpoint{
+				Endpoint:    ep,
+				DNATFlowKey: p.ofClient.GetEndpointDNATFlowKey(svcInfo.OFProtocol, ep),
+			})
+		}
+		sort.Slice(svcEndpoints, func(i, j int) bool {
+			return svcEndpoints[i].String() < svcEndpoints[j].String()
+		})
+		svcPorts = append(svcPorts, types.ServicePortEndpoints{
+			ServicePortName: svcPortName,
+			ClusterIP:       svcInfo.ClusterIP(),
+			Port:            svcInfo.Port(),
+			Protocol:        svcInfo.Protocol(),
+			GroupID:         groupID,
+			Endpoints:       svcEndpoints,
+		})
+	}
+	sort.Slice(svcPorts, func(i, j int) bool {
+		return svcPorts[i].ServicePortName.Port < svcPorts[j].ServicePortName.Port
+	})
+
+	return svcPorts, found
+}
+
 func NewProxier(
 	hostname string,
 	informerFactory informers.SharedInformerFactory,
-	ofClient openflow.Client,
+	ofClient openflow.ServiceClient,
 	isIPv6 bool) *proxier {
 	recorder := record.NewBroadcaster().NewRecorder(
 		runtime.NewScheme(),
@@ -681,6 +837,14 @@ func (p *metaProxierWrapper) GetServiceFlowKeys(serviceName, namespace string) (
 	return append(v4Flows, v6Flows...), append(v4Groups, v6Groups...), v4Found || v6Found
 }
 
+func (p *metaProxierWrapper) GetServiceEndpoints(serviceName, namespace string) ([]types.ServicePortEndpoints, bool) {
+	v4SvcPorts, v4Found := p.ipv4Proxier.GetServiceEndpoints(serviceName, namespace)
+	v6SvcPorts, v6Found := p.ipv6Proxier.GetServiceEndpoints(serviceName, namespace)
+
+	// Return the union of IPv4 and IPv6 Service ports.
+	return append(v4SvcPorts, v6SvcPorts...), v4Found || v6Found
+}
+
 func (p *metaProxierWrapper) GetServiceByIP(serviceStr string) (k8sproxy.ServicePortName, bool) {
 	// Format of serviceStr is <clusterIP>:<svcPort>/<protocol>.
 	lastColonIndex := strings.LastIndex(serviceStr, ":")
@@ -692,7 +856,7 @@ func (p *metaProxierWrapper) GetServiceByIP(serviceStr string) (k8sproxy.Service
 }
 
 func NewDualStackProxier(
-	hostname string, informerFactory informers.SharedInformerFactory, ofClient openflow.Client) *metaProxierWrapper {
+	hostname string, informerFactory informers.SharedInformerFactory, ofClient openflow.ServiceClient) *metaProxierWrapper {
 
 	// Create an ipv4 instance of the single-stack proxier
 	ipv4Proxier := NewProxier(hostname, informerFactory, ofClient, false)
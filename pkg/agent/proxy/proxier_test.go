@@ -81,7 +81,7 @@ func makeTestEndpoints(namespace, name string, eptFunc func(*corev1.Endpoints))
 	return ept
 }
 
-func NewFakeProxier(ofClient openflow.Client, isIPv6 bool) *proxier {
+func NewFakeProxier(ofClient openflow.ServiceClient, isIPv6 bool) *proxier {
 	hostname := "localhost"
 	eventBroadcaster := record.NewBroadcaster()
 	recorder := eventBroadcaster.NewRecorder(
@@ -114,7 +114,7 @@ func NewFakeProxier(ofClient openflow.Client, isIPv6 bool) *proxier {
 func testClusterIP(t *testing.T, svcIP net.IP, epIP net.IP, isIPv6 bool) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
-	mockOFClient := ofmock.NewMockClient(ctrl)
+	mockOFClient := ofmock.NewMockServiceClient(ctrl)
 	fp := NewFakeProxier(mockOFClient, isIPv6)
 
 	svcPort := 80
@@ -164,7 +164,7 @@ func testClusterIP(t *testing.T, svcIP net.IP, epIP net.IP, isIPv6 bool) {
 func TestLoadbalancer(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
-	mockOFClient := ofmock.NewMockClient(ctrl)
+	mockOFClient := ofmock.NewMockServiceClient(ctrl)
 	fp := NewFakeProxier(mockOFClient, false)
 
 	svcIPv4 := net.ParseIP("10.20.30.41")
@@ -211,11 +211,93 @@ func TestLoadbalancer(t *testing.T) {
 	mockOFClient.EXPECT().InstallEndpointFlows(binding.ProtocolTCP, gomock.Any()).Times(1)
 	mockOFClient.EXPECT().InstallServiceFlows(groupID, svcIPv4, uint16(svcPort), binding.ProtocolTCP, uint16(0)).Times(1)
 	mockOFClient.EXPECT().InstallServiceFlows(groupID, loadBalancerIPv4, uint16(svcPort), binding.ProtocolTCP, uint16(0)).Times(1)
-	mockOFClient.EXPECT().InstallLoadBalancerServiceFromOutsideFlows(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+	mockOFClient.EXPECT().InstallLoadBalancerServiceFromOutsideFlows(gomock.Any(), gomock.Any(), gomock.Any(), false).AnyTimes()
 
 	fp.syncProxyRules()
 }
 
+func TestLoadBalancerSourceRanges(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockOFClient := ofmock.NewMockServiceClient(ctrl)
+	fp := NewFakeProxier(mockOFClient, false)
+
+	svcIPv4 := net.ParseIP("10.20.30.41")
+	svcPort := 80
+	loadBalancerIPv4 := net.ParseIP("169.254.0.1")
+	externalIPv4 := net.ParseIP("50.60.70.81")
+	svcPortName := k8sproxy.ServicePortName{
+		NamespacedName: makeNamespaceName("ns1", "svc1"),
+		Port:           "80",
+		Protocol:       corev1.ProtocolTCP,
+	}
+	serviceFunc := func(sourceRanges ...string) func(svc *corev1.Service) {
+		return func(svc *corev1.Service) {
+			svc.Spec.ClusterIP = svcIPv4.String()
+			svc.Spec.ExternalIPs = []string{externalIPv4.String()}
+			svc.Spec.LoadBalancerIP = loadBalancerIPv4.String()
+			svc.Spec.LoadBalancerSourceRanges = sourceRanges
+			svc.Spec.Type = corev1.ServiceTypeLoadBalancer
+			svc.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{IP: loadBalancerIPv4.String()}}
+			svc.Spec.Ports = []corev1.ServicePort{{
+				Name:     svcPortName.Port,
+				Port:     int32(svcPort),
+				Protocol: corev1.ProtocolTCP,
+			}}
+		}
+	}
+	service := makeTestService(svcPortName.Namespace, svcPortName.Name, serviceFunc("192.168.1.0/24", "fd00:1::/64"))
+	makeServiceMap(fp, service)
+	makeEndpointsMap(fp,
+		makeTestEndpoints(svcPortName.Namespace, svcPortName.Name, func(ept *corev1.Endpoints) {
+			ept.Subsets = []corev1.EndpointSubset{{
+				Addresses: []corev1.EndpointAddress{{
+					IP: "10.180.0.1",
+				}},
+				Ports: []corev1.EndpointPort{{
+					Name:     svcPortName.Port,
+					Port:     int32(svcPort),
+					Protocol: corev1.ProtocolTCP,
+				}},
+			}}
+		}),
+	)
+	parseCIDRs := func(cidrs ...string) []net.IPNet {
+		var ipNets []net.IPNet
+		for _, cidr := range cidrs {
+			_, ipNet, _ := net.ParseCIDR(cidr)
+			ipNets = append(ipNets, *ipNet)
+		}
+		return ipNets
+	}
+
+	groupID, _ := fp.groupCounter.Get(svcPortName)
+	mockOFClient.EXPECT().InstallServiceGroup(groupID, false, gomock.Any()).Times(1)
+	mockOFClient.EXPECT().InstallEndpointFlows(binding.ProtocolTCP, gomock.Any()).Times(1)
+	mockOFClient.EXPECT().InstallServiceFlows(groupID, svcIPv4, uint16(svcPort), binding.ProtocolTCP, uint16(0)).Times(1)
+	mockOFClient.EXPECT().InstallServiceFlows(groupID, loadBalancerIPv4, uint16(svcPort), binding.ProtocolTCP, uint16(0)).Times(1)
+	mockOFClient.EXPECT().InstallServiceFlows(groupID, externalIPv4, uint16(svcPort), binding.ProtocolTCP, uint16(0)).Times(1)
+	mockOFClient.EXPECT().InstallLoadBalancerServiceFromOutsideFlows(gomock.Any(), gomock.Any(), gomock.Any(), false).AnyTimes()
+	// The source ranges only apply to the LoadBalancer ingress IP, and the source range of the
+	// other IP family is ignored.
+	mockOFClient.EXPECT().InstallServiceSourceRangeFlows(groupID, loadBalancerIPv4, uint16(svcPort), binding.ProtocolTCP, uint16(0), parseCIDRs("192.168.1.0/24")).Times(1)
+	fp.syncProxyRules()
+
+	// The flows are updated when the source ranges change.
+	serviceNew := makeTestService(svcPortName.Namespace, svcPortName.Name, serviceFunc("192.168.1.0/24", "192.168.2.0/24"))
+	mockOFClient.EXPECT().InstallServiceFlows(groupID, svcIPv4, uint16(svcPort), binding.ProtocolTCP, uint16(0)).Times(1)
+	mockOFClient.EXPECT().InstallServiceSourceRangeFlows(groupID, loadBalancerIPv4, uint16(svcPort), binding.ProtocolTCP, uint16(0), parseCIDRs("192.168.1.0/24", "192.168.2.0/24")).Times(1)
+	fp.serviceChanges.OnServiceUpdate(service, serviceNew)
+	fp.syncProxyRules()
+
+	// The flows are removed when the source ranges are removed.
+	serviceNoRanges := makeTestService(svcPortName.Namespace, svcPortName.Name, serviceFunc())
+	mockOFClient.EXPECT().InstallServiceFlows(groupID, svcIPv4, uint16(svcPort), binding.ProtocolTCP, uint16(0)).Times(1)
+	mockOFClient.EXPECT().UninstallServiceSourceRangeFlows(loadBalancerIPv4, uint16(svcPort), binding.ProtocolTCP).Times(1)
+	fp.serviceChanges.OnServiceUpdate(serviceNew, serviceNoRanges)
+	fp.syncProxyRules()
+}
+
 func TestClusterIPv4(t *testing.T) {
 	testClusterIP(t, net.ParseIP("10.20.30.41"), net.ParseIP("10.180.0.1"), false)
 }
@@ -227,7 +309,7 @@ func TestClusterIPv6(t *testing.T) {
 func TestDualStackService(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
-	mockOFClient := ofmock.NewMockClient(ctrl)
+	mockOFClient := ofmock.NewMockServiceClient(ctrl)
 	fpv4 := NewFakeProxier(mockOFClient, false)
 	fpv6 := NewFakeProxier(mockOFClient, true)
 	metaProxier := k8sproxy.NewMetaProxier(fpv4, fpv6)
@@ -302,7 +384,7 @@ func TestDualStackService(t *testing.T) {
 func testClusterIPRemoval(t *testing.T, svcIP net.IP, epIP net.IP, isIPv6 bool) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
-	mockOFClient := ofmock.NewMockClient(ctrl)
+	mockOFClient := ofmock.NewMockServiceClient(ctrl)
 	fp := NewFakeProxier(mockOFClient, isIPv6)
 
 	svcPort := 80
@@ -366,7 +448,7 @@ func TestClusterIPRemovalIPv6(t *testing.T) {
 func testClusterIPNoEndpoint(t *testing.T, svcIP net.IP, isIPv6 bool) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
-	mockOFClient := ofmock.NewMockClient(ctrl)
+	mockOFClient := ofmock.NewMockServiceClient(ctrl)
 	fp := NewFakeProxier(mockOFClient, isIPv6)
 
 	svcPort := 80
@@ -403,7 +485,7 @@ func TestClusterIPNoEndpointIPv6(t *testing.T) {
 func testClusterIPRemoveSamePortEndpoint(t *testing.T, svcIP net.IP, epIP net.IP, isIPv6 bool) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
-	mockOFClient := ofmock.NewMockClient(ctrl)
+	mockOFClient := ofmock.NewMockServiceClient(ctrl)
 	fp := NewFakeProxier(mockOFClient, isIPv6)
 
 	svcPort := 80
@@ -494,7 +576,7 @@ func TestClusterIPRemoveSamePortEndpointIPv6(t *testing.T) {
 func testClusterIPRemoveEndpoints(t *testing.T, svcIP net.IP, epIP net.IP, isIPv6 bool) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
-	mockOFClient := ofmock.NewMockClient(ctrl)
+	mockOFClient := ofmock.NewMockServiceClient(ctrl)
 	fp := NewFakeProxier(mockOFClient, isIPv6)
 
 	svcPort := 80
@@ -553,7 +635,7 @@ func TestClusterIPRemoveEndpointsIPv6(t *testing.T) {
 func testSessionAffinityNoEndpoint(t *testing.T, svcExternalIPs net.IP, svcIP net.IP, epIP net.IP, isIPv6 bool) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
-	mockOFClient := ofmock.NewMockClient(ctrl)
+	mockOFClient := ofmock.NewMockServiceClient(ctrl)
 	fp := NewFakeProxier(mockOFClient, isIPv6)
 
 	svcPort := 80
@@ -606,6 +688,8 @@ func testSessionAffinityNoEndpoint(t *testing.T, svcExternalIPs net.IP, svcIP ne
 	mockOFClient.EXPECT().InstallServiceGroup(groupID, true, gomock.Any()).Times(1)
 	mockOFClient.EXPECT().InstallEndpointFlows(bindingProtocol, gomock.Any()).Times(1)
 	mockOFClient.EXPECT().InstallServiceFlows(groupID, svcIP, uint16(svcPort), bindingProtocol, uint16(corev1.DefaultClientIPServiceAffinitySeconds)).Times(1)
+	mockOFClient.EXPECT().InstallServiceFlows(groupID, svcExternalIPs, uint16(svcPort), bindingProtocol, uint16(corev1.DefaultClientIPServiceAffinitySeconds)).Times(1)
+	mockOFClient.EXPECT().InstallLoadBalancerServiceFromOutsideFlows(gomock.Any(), gomock.Any(), gomock.Any(), false).AnyTimes()
 
 	fp.syncProxyRules()
 }
@@ -621,7 +705,7 @@ func TestSessionAffinityNoEndpointIPv6(t *testing.T) {
 func testSessionAffinity(t *testing.T, svcExternalIPs net.IP, svcIP net.IP, isIPv6 bool) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
-	mockOFClient := ofmock.NewMockClient(ctrl)
+	mockOFClient := ofmock.NewMockServiceClient(ctrl)
 	fp := NewFakeProxier(mockOFClient, isIPv6)
 
 	svcPort := 80
@@ -668,7 +752,7 @@ func TestSessionAffinityIPv6(t *testing.T) {
 func testPortChange(t *testing.T, svcIP net.IP, epIP net.IP, isIPv6 bool) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
-	mockOFClient := ofmock.NewMockClient(ctrl)
+	mockOFClient := ofmock.NewMockServiceClient(ctrl)
 	fp := NewFakeProxier(mockOFClient, isIPv6)
 
 	svcPort1 := 80
@@ -741,7 +825,7 @@ func TestPortChangeIPv6(t *testing.T) {
 func TestServicesWithSameEndpoints(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
-	mockOFClient := ofmock.NewMockClient(ctrl)
+	mockOFClient := ofmock.NewMockServiceClient(ctrl)
 	fp := NewFakeProxier(mockOFClient, false)
 	epIP := net.ParseIP("10.50.60.71")
 	svcIP1 := net.ParseIP("10.180.30.41")
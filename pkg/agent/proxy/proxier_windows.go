@@ -24,12 +24,13 @@ import (
 // installLoadBalancerServiceFlows installs OpenFlow entries for LoadBalancer Service.
 // The rules for traffic from local Pod to LoadBalancer Service are the same with rules for Cluster Service.
 // For the LoadBalancer Service traffic from outside, specific rules are install to forward the packets
-// to the host network to let kube-proxy handle the traffic.
-func (p *proxier) installLoadBalancerServiceFlows(groupID binding.GroupIDType, svcIP net.IP, svcPort uint16, protocol binding.Protocol, affinityTimeout uint16) error {
+// to the host network to let kube-proxy handle the traffic, which SNATs it. If externalSNATDisabled is
+// true, the packets are load-balanced in OVS instead, without SNAT.
+func (p *proxier) installLoadBalancerServiceFlows(groupID binding.GroupIDType, svcIP net.IP, svcPort uint16, protocol binding.Protocol, affinityTimeout uint16, externalSNATDisabled bool) error {
 	if err := p.ofClient.InstallServiceFlows(groupID, svcIP, svcPort, protocol, affinityTimeout); err != nil {
 		return err
 	}
-	if err := p.ofClient.InstallLoadBalancerServiceFromOutsideFlows(svcIP, svcPort, protocol); err != nil {
+	if err := p.ofClient.InstallLoadBalancerServiceFromOutsideFlows(svcIP, svcPort, protocol, externalSNATDisabled); err != nil {
 		return err
 	}
 	return nil
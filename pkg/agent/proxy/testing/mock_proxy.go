@@ -20,6 +20,7 @@
 package testing
 
 import (
+	types "antrea.io/antrea/pkg/agent/proxy/types"
 	openflow "antrea.io/antrea/pkg/ovs/openflow"
 	proxy "antrea.io/antrea/third_party/proxy"
 	gomock "github.com/golang/mock/gomock"
@@ -78,6 +79,21 @@ func (mr *MockProxierMockRecorder) GetServiceByIP(arg0 interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetServiceByIP", reflect.TypeOf((*MockProxier)(nil).GetServiceByIP), arg0)
 }
 
+// GetServiceEndpoints mocks base method
+func (m *MockProxier) GetServiceEndpoints(arg0, arg1 string) ([]types.ServicePortEndpoints, bool) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetServiceEndpoints", arg0, arg1)
+	ret0, _ := ret[0].([]types.ServicePortEndpoints)
+	ret1, _ := ret[1].(bool)
+	return ret0, ret1
+}
+
+// GetServiceEndpoints indicates an expected call of GetServiceEndpoints
+func (mr *MockProxierMockRecorder) GetServiceEndpoints(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetServiceEndpoints", reflect.TypeOf((*MockProxier)(nil).GetServiceEndpoints), arg0, arg1)
+}
+
 // GetServiceFlowKeys mocks base method
 func (m *MockProxier) GetServiceFlowKeys(arg0, arg1 string) ([]string, []openflow.GroupIDType, bool) {
 	m.ctrl.T.Helper()
@@ -15,9 +15,13 @@
 package types
 
 import (
+	"net"
+
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
 	utilnet "k8s.io/utils/net"
 
+	agenttypes "antrea.io/antrea/pkg/agent/types"
 	"antrea.io/antrea/pkg/ovs/openflow"
 	k8sproxy "antrea.io/antrea/third_party/proxy"
 )
@@ -27,6 +31,9 @@ type ServiceInfo struct {
 	*k8sproxy.BaseServiceInfo
 	// cache for performance
 	OFProtocol openflow.Protocol
+	// ExternalSNATDisabled is true if the traffic from outside the cluster to the LoadBalancer
+	// Service must not be SNAT'd, so that the Endpoints see the client IP.
+	ExternalSNATDisabled bool
 }
 
 // NewServiceInfo returns a new k8sproxy.ServicePort which abstracts a serviceInfo.
@@ -47,12 +54,60 @@ func NewServiceInfo(port *corev1.ServicePort, service *corev1.Service, baseInfo
 			info.OFProtocol = openflow.ProtocolSCTP
 		}
 	}
+	info.ExternalSNATDisabled = externalSNATDisabled(service)
 	return info
 }
 
+// externalSNATDisabled returns whether the Service disables the SNAT of the traffic from outside the
+// cluster with the ServiceExternalSNATDisabledAnnotationKey annotation. The annotation is only
+// honored for LoadBalancer Services with the "Cluster" externalTrafficPolicy: the client IP is
+// already preserved with the "Local" policy, which never forwards the traffic to another Node.
+// With the annotation, the reply traffic from an Endpoint on another Node does not go back through
+// the Node which received the request, and it is up to the infrastructure to route it to the client.
+func externalSNATDisabled(service *corev1.Service) bool {
+	value, ok := service.Annotations[agenttypes.ServiceExternalSNATDisabledAnnotationKey]
+	if !ok {
+		return false
+	}
+	if value != "true" {
+		if value != "false" {
+			klog.V(2).Infof("Ignoring invalid value %q of annotation %s of Service %s/%s", value, agenttypes.ServiceExternalSNATDisabledAnnotationKey, service.Namespace, service.Name)
+		}
+		return false
+	}
+	if service.Spec.Type != corev1.ServiceTypeLoadBalancer {
+		klog.V(2).Infof("Ignoring annotation %s of Service %s/%s which is not a LoadBalancer Service", agenttypes.ServiceExternalSNATDisabledAnnotationKey, service.Namespace, service.Name)
+		return false
+	}
+	if service.Spec.ExternalTrafficPolicy == corev1.ServiceExternalTrafficPolicyTypeLocal {
+		klog.V(2).Infof("Ignoring annotation %s of Service %s/%s whose externalTrafficPolicy is Local", agenttypes.ServiceExternalSNATDisabledAnnotationKey, service.Namespace, service.Name)
+		return false
+	}
+	return true
+}
+
 // NewEndpointInfo returns a new k8sproxy.Endpoint which abstracts an endpointsInfo.
 func NewEndpointInfo(baseInfo *k8sproxy.BaseEndpointInfo) k8sproxy.Endpoint {
 	return baseInfo
 }
 
 type EndpointsMap map[k8sproxy.ServicePortName]map[string]k8sproxy.Endpoint
+
+// ServicePortEndpoints describes the Endpoints installed for a Service port, and the OVS group
+// which load-balances the traffic of the Service port across them.
+type ServicePortEndpoints struct {
+	ServicePortName k8sproxy.ServicePortName
+	ClusterIP       net.IP
+	Port            int
+	Protocol        corev1.Protocol
+	GroupID         openflow.GroupIDType
+	Endpoints       []ServiceEndpoint
+}
+
+// ServiceEndpoint is an Endpoint installed for a Service port.
+type ServiceEndpoint struct {
+	k8sproxy.Endpoint
+	// DNATFlowKey is the key (match string) of the cached DNAT flow of the Endpoint. It is empty
+	// if the flow is not cached.
+	DNATFlowKey string
+}
@@ -0,0 +1,83 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	agenttypes "antrea.io/antrea/pkg/agent/types"
+)
+
+func TestExternalSNATDisabled(t *testing.T) {
+	tests := []struct {
+		name                  string
+		annotations           map[string]string
+		serviceType           corev1.ServiceType
+		externalTrafficPolicy corev1.ServiceExternalTrafficPolicyType
+		expected              bool
+	}{
+		{
+			name:                  "no annotation",
+			serviceType:           corev1.ServiceTypeLoadBalancer,
+			externalTrafficPolicy: corev1.ServiceExternalTrafficPolicyTypeCluster,
+		},
+		{
+			name:                  "LoadBalancer with Cluster policy",
+			annotations:           map[string]string{agenttypes.ServiceExternalSNATDisabledAnnotationKey: "true"},
+			serviceType:           corev1.ServiceTypeLoadBalancer,
+			externalTrafficPolicy: corev1.ServiceExternalTrafficPolicyTypeCluster,
+			expected:              true,
+		},
+		{
+			name:                  "annotation set to false",
+			annotations:           map[string]string{agenttypes.ServiceExternalSNATDisabledAnnotationKey: "false"},
+			serviceType:           corev1.ServiceTypeLoadBalancer,
+			externalTrafficPolicy: corev1.ServiceExternalTrafficPolicyTypeCluster,
+		},
+		{
+			name:                  "invalid annotation value",
+			annotations:           map[string]string{agenttypes.ServiceExternalSNATDisabledAnnotationKey: "yes"},
+			serviceType:           corev1.ServiceTypeLoadBalancer,
+			externalTrafficPolicy: corev1.ServiceExternalTrafficPolicyTypeCluster,
+		},
+		{
+			name:                  "LoadBalancer with Local policy",
+			annotations:           map[string]string{agenttypes.ServiceExternalSNATDisabledAnnotationKey: "true"},
+			serviceType:           corev1.ServiceTypeLoadBalancer,
+			externalTrafficPolicy: corev1.ServiceExternalTrafficPolicyTypeLocal,
+		},
+		{
+			name:        "ClusterIP",
+			annotations: map[string]string{agenttypes.ServiceExternalSNATDisabledAnnotationKey: "true"},
+			serviceType: corev1.ServiceTypeClusterIP,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "svc1", Annotations: tt.annotations},
+				Spec: corev1.ServiceSpec{
+					Type:                  tt.serviceType,
+					ExternalTrafficPolicy: tt.externalTrafficPolicy,
+				},
+			}
+			assert.Equal(t, tt.expected, externalSNATDisabled(service))
+		})
+	}
+}
@@ -15,7 +15,9 @@
 package querier
 
 import (
+	"fmt"
 	"strconv"
+	"strings"
 
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -27,6 +29,8 @@ import (
 	"antrea.io/antrea/pkg/agent/openflow"
 	"antrea.io/antrea/pkg/agent/proxy"
 	"antrea.io/antrea/pkg/apis/crd/v1beta1"
+	"antrea.io/antrea/pkg/log"
+	"antrea.io/antrea/pkg/monitor/condition"
 	"antrea.io/antrea/pkg/ovs/ovsconfig"
 	"antrea.io/antrea/pkg/ovs/ovsctl"
 	"antrea.io/antrea/pkg/querier"
@@ -34,6 +38,10 @@ import (
 
 var _ AgentQuerier = new(agentQuerier)
 
+// maxUnreachableNodesInCondition is the maximum number of unreachable peer Nodes listed in the
+// message of the PeerNodesReachable condition.
+const maxUnreachableNodesInCondition = 10
+
 type AgentQuerier interface {
 	GetNodeConfig() *config.NodeConfig
 	GetNetworkConfig() *config.NetworkConfig
@@ -55,7 +63,12 @@ type agentQuerier struct {
 	ovsBridgeClient          ovsconfig.OVSBridgeClient
 	proxier                  proxy.Proxier
 	networkPolicyInfoQuerier querier.AgentNetworkPolicyInfoQuerier
-	apiPort                  int
+	// nodeLatencyQuerier is nil when NodeLatencyMonitor is disabled.
+	nodeLatencyQuerier querier.AgentNodeLatencyQuerier
+	// conditionManager maintains the conditions of the agent, including the ones set by
+	// other modules.
+	conditionManager *condition.Manager
+	apiPort          int
 }
 
 func NewAgentQuerier(
@@ -67,6 +80,8 @@ func NewAgentQuerier(
 	ovsBridgeClient ovsconfig.OVSBridgeClient,
 	proxier proxy.Proxier,
 	networkPolicyInfoQuerier querier.AgentNetworkPolicyInfoQuerier,
+	nodeLatencyQuerier querier.AgentNodeLatencyQuerier,
+	conditionManager *condition.Manager,
 	apiPort int,
 ) *agentQuerier {
 	return &agentQuerier{
@@ -78,6 +93,8 @@ func NewAgentQuerier(
 		ovsBridgeClient:          ovsBridgeClient,
 		proxier:                  proxier,
 		networkPolicyInfoQuerier: networkPolicyInfoQuerier,
+		nodeLatencyQuerier:       nodeLatencyQuerier,
+		conditionManager:         conditionManager,
 		apiPort:                  apiPort}
 }
 
@@ -141,43 +158,37 @@ func (aq agentQuerier) getOVSFlowTable() map[string]int32 {
 	return flowTable
 }
 
-// getAgentConditions gets current conditions of agent pod.
+// getAgentConditions gets current conditions of agent pod. The conditions which can only be
+// known by probing are set in conditionManager first, and are returned along with the conditions
+// set by other modules when their state changes, e.g. OpenflowConnectionUp which is set by the
+// OpenFlow bridge when it connects to or disconnects from OVS.
 func (aq agentQuerier) getAgentConditions(ovsConnected bool) []v1beta1.AgentCondition {
 	lastHeartbeatTime := metav1.Now()
 	controllerConnectionStatus := v1.ConditionTrue
 	ovsdbConnectionStatus := v1.ConditionTrue
-	openflowConnectionStatus := v1.ConditionTrue
 	if !aq.networkPolicyInfoQuerier.GetControllerConnectionStatus() {
 		controllerConnectionStatus = v1.ConditionFalse
 	}
 	if !ovsConnected {
 		ovsdbConnectionStatus = v1.ConditionFalse
 	}
-	if !aq.ofClient.IsConnected() {
-		openflowConnectionStatus = v1.ConditionFalse
-	}
-	return []v1beta1.AgentCondition{
-		{
-			Type:              v1beta1.AgentHealthy,
-			Status:            v1.ConditionTrue,
-			LastHeartbeatTime: lastHeartbeatTime,
-		},
-		{
-			Type:              v1beta1.ControllerConnectionUp,
-			Status:            controllerConnectionStatus,
-			LastHeartbeatTime: lastHeartbeatTime,
-		},
-		{
-			Type:              v1beta1.OVSDBConnectionUp,
-			Status:            ovsdbConnectionStatus,
-			LastHeartbeatTime: lastHeartbeatTime,
-		},
-		{
-			Type:              v1beta1.OpenflowConnectionUp,
-			Status:            openflowConnectionStatus,
-			LastHeartbeatTime: lastHeartbeatTime,
-		},
+	aq.conditionManager.Set(v1beta1.AgentHealthy, v1.ConditionTrue, "", "")
+	aq.conditionManager.Set(v1beta1.ControllerConnectionUp, controllerConnectionStatus, "", "")
+	aq.conditionManager.Set(v1beta1.OVSDBConnectionUp, ovsdbConnectionStatus, "", "")
+	if aq.nodeLatencyQuerier != nil {
+		status, reason, message := v1.ConditionTrue, "", ""
+		if unreachableNodes := aq.nodeLatencyQuerier.GetUnreachablePeerNodes(); len(unreachableNodes) > 0 {
+			status = v1.ConditionFalse
+			reason = "ProbesFailed"
+			message = fmt.Sprintf("Unreachable peer Nodes: %s", strings.Join(unreachableNodes, ", "))
+			// Keep AntreaAgentInfo small when many peer Nodes are unreachable.
+			if len(unreachableNodes) > maxUnreachableNodesInCondition {
+				message = fmt.Sprintf("Unreachable peer Nodes: %s and %d more", strings.Join(unreachableNodes[:maxUnreachableNodesInCondition], ", "), len(unreachableNodes)-maxUnreachableNodesInCondition)
+			}
+		}
+		aq.conditionManager.Set(v1beta1.PeerNodesReachable, status, reason, message)
 	}
+	return aq.conditionManager.List(lastHeartbeatTime)
 }
 
 // getNetworkPolicyControllerInfo gets current network policy controller info
@@ -192,7 +203,7 @@ func (aq agentQuerier) getNetworkPolicyControllerInfo() v1beta1.NetworkPolicyCon
 
 // GetAgentInfo gets current agent pod info.
 func (aq agentQuerier) GetAgentInfo(agentInfo *v1beta1.AntreaAgentInfo, partial bool) {
-	// LocalPodNum, FlowTable, NetworkPolicyControllerInfo, OVSVersion and AgentConditions can be changed, so reset these fields.
+	// LocalPodNum, FlowTable, NetworkPolicyControllerInfo, OVSVersion, AgentConditions and LogLevel can be changed, so reset these fields.
 	// Only these fields are updated when partial is true.
 	agentInfo.Name = aq.nodeConfig.Name
 	agentInfo.LocalPodNum = int32(aq.interfaceStore.GetContainerInterfaceNum())
@@ -206,6 +217,8 @@ func (aq agentQuerier) GetAgentInfo(agentInfo *v1beta1.AntreaAgentInfo, partial
 		agentInfo.OVSInfo.Version = ovsVersion
 	}
 	agentInfo.AgentConditions = aq.getAgentConditions(ovsConnected)
+	logLevel, _ := strconv.Atoi(log.GetCurrentLogLevel())
+	agentInfo.LogLevel = int32(logLevel)
 
 	// Some other fields are needed when partial if false.
 	if !partial {
@@ -222,6 +235,12 @@ func (aq agentQuerier) GetAgentInfo(agentInfo *v1beta1.AntreaAgentInfo, partial
 		}
 		agentInfo.NodeSubnets = nodeSubnets
 		agentInfo.OVSInfo.BridgeName = aq.nodeConfig.OVSBridge
+		capabilities := aq.ofClient.GetOVSCapabilities()
+		agentInfo.OVSInfo.OpenFlowVersion = capabilities.OpenFlowVersion
+		agentInfo.OVSInfo.Capabilities = make([]string, 0, len(capabilities.Available))
+		for _, capability := range capabilities.Available {
+			agentInfo.OVSInfo.Capabilities = append(agentInfo.OVSInfo.Capabilities, string(capability))
+		}
 		agentInfo.APIPort = aq.apiPort
 	}
 }
@@ -15,11 +15,13 @@
 package querier
 
 import (
+	"fmt"
 	"net"
 	"testing"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
@@ -27,13 +29,23 @@ import (
 	interfacestoretest "antrea.io/antrea/pkg/agent/interfacestore/testing"
 	openflowtest "antrea.io/antrea/pkg/agent/openflow/testing"
 	"antrea.io/antrea/pkg/apis/crd/v1beta1"
+	"antrea.io/antrea/pkg/monitor/condition"
 	binding "antrea.io/antrea/pkg/ovs/openflow"
 	ovsconfigtest "antrea.io/antrea/pkg/ovs/ovsconfig/testing"
+	"antrea.io/antrea/pkg/querier"
 	queriertest "antrea.io/antrea/pkg/querier/testing"
 )
 
 const ovsVersion = "2.10.0"
 
+// newConditionManager returns a condition.Manager in which the OpenFlow bridge has reported that it
+// is connected.
+func newConditionManager() *condition.Manager {
+	m := condition.NewManager()
+	m.Set(v1beta1.OpenflowConnectionUp, corev1.ConditionTrue, "", "")
+	return m
+}
+
 func getIPNet(ip string) *net.IPNet {
 	_, ipNet, _ := net.ParseCIDR(ip)
 	return ipNet
@@ -54,6 +66,11 @@ func TestAgentQuerierGetAgentInfo(t *testing.T) {
 		},
 	}).AnyTimes()
 	ofClient.EXPECT().IsConnected().Return(true).AnyTimes()
+	ofClient.EXPECT().GetOVSCapabilities().Return(binding.Capabilities{
+		OpenFlowVersion: binding.OpenFlow13,
+		OVSVersion:      ovsVersion,
+		Available:       []binding.Capability{binding.CapabilityLearnDeleteLearned, binding.CapabilityMeter},
+	}).AnyTimes()
 
 	ovsBridgeClient := ovsconfigtest.NewMockOVSBridgeClient(ctrl)
 	ovsBridgeClient.EXPECT().GetOVSVersion().Return(ovsVersion, nil).AnyTimes()
@@ -85,9 +102,11 @@ func TestAgentQuerierGetAgentInfo(t *testing.T) {
 				NodeRef:     corev1.ObjectReference{Kind: "Node", Name: "foo"},
 				NodeSubnets: []string{},
 				OVSInfo: v1beta1.OVSInfo{
-					Version:    ovsVersion,
-					BridgeName: "br-int",
-					FlowTable:  map[string]int32{"1": 2},
+					Version:         ovsVersion,
+					BridgeName:      "br-int",
+					FlowTable:       map[string]int32{"1": 2},
+					OpenFlowVersion: "OpenFlow13",
+					Capabilities:    []string{"LearnDeleteLearned", "Meter"},
 				},
 				NetworkPolicyControllerInfo: v1beta1.NetworkPolicyControllerInfo{
 					NetworkPolicyNum:  10,
@@ -133,9 +152,11 @@ func TestAgentQuerierGetAgentInfo(t *testing.T) {
 				NodeRef:     corev1.ObjectReference{Kind: "Node", Name: "foo"},
 				NodeSubnets: []string{"20.20.20.0/24", "2001:ab03:cd04:55ef::/64"},
 				OVSInfo: v1beta1.OVSInfo{
-					Version:    ovsVersion,
-					BridgeName: "br-int",
-					FlowTable:  map[string]int32{"1": 2},
+					Version:         ovsVersion,
+					BridgeName:      "br-int",
+					FlowTable:       map[string]int32{"1": 2},
+					OpenFlowVersion: "OpenFlow13",
+					Capabilities:    []string{"LearnDeleteLearned", "Meter"},
 				},
 				NetworkPolicyControllerInfo: v1beta1.NetworkPolicyControllerInfo{
 					NetworkPolicyNum:  10,
@@ -174,6 +195,7 @@ func TestAgentQuerierGetAgentInfo(t *testing.T) {
 				ofClient:                 ofClient,
 				ovsBridgeClient:          ovsBridgeClient,
 				networkPolicyInfoQuerier: networkPolicyInfoQuerier,
+				conditionManager:         newConditionManager(),
 				apiPort:                  tt.apiPort,
 			}
 			agentInfo := &v1beta1.AntreaAgentInfo{}
@@ -191,3 +213,113 @@ func TestAgentQuerierGetAgentInfo(t *testing.T) {
 		})
 	}
 }
+
+type fakeNodeLatencyQuerier struct {
+	unreachableNodes []string
+}
+
+func (q *fakeNodeLatencyQuerier) GetPeerNodeLatencies() []querier.PeerNodeLatency {
+	return nil
+}
+
+func (q *fakeNodeLatencyQuerier) GetUnreachablePeerNodes() []string {
+	return q.unreachableNodes
+}
+
+func TestAgentQuerierPeerNodesReachableCondition(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ofClient := openflowtest.NewMockClient(ctrl)
+	ofClient.EXPECT().IsConnected().Return(true).AnyTimes()
+	networkPolicyInfoQuerier := queriertest.NewMockAgentNetworkPolicyInfoQuerier(ctrl)
+	networkPolicyInfoQuerier.EXPECT().GetControllerConnectionStatus().Return(true).AnyTimes()
+
+	var manyNodes []string
+	for i := 0; i < 12; i++ {
+		manyNodes = append(manyNodes, fmt.Sprintf("node%02d", i))
+	}
+	tests := []struct {
+		name               string
+		nodeLatencyQuerier querier.AgentNodeLatencyQuerier
+		expectedCondition  *v1beta1.AgentCondition
+	}{
+		{
+			name: "NodeLatencyMonitor disabled",
+		},
+		{
+			name:               "all peer Nodes reachable",
+			nodeLatencyQuerier: &fakeNodeLatencyQuerier{},
+			expectedCondition: &v1beta1.AgentCondition{
+				Type:   v1beta1.PeerNodesReachable,
+				Status: corev1.ConditionTrue,
+			},
+		},
+		{
+			name:               "unreachable peer Nodes",
+			nodeLatencyQuerier: &fakeNodeLatencyQuerier{unreachableNodes: []string{"node1", "node2"}},
+			expectedCondition: &v1beta1.AgentCondition{
+				Type:    v1beta1.PeerNodesReachable,
+				Status:  corev1.ConditionFalse,
+				Reason:  "ProbesFailed",
+				Message: "Unreachable peer Nodes: node1, node2",
+			},
+		},
+		{
+			name:               "many unreachable peer Nodes",
+			nodeLatencyQuerier: &fakeNodeLatencyQuerier{unreachableNodes: manyNodes},
+			expectedCondition: &v1beta1.AgentCondition{
+				Type:    v1beta1.PeerNodesReachable,
+				Status:  corev1.ConditionFalse,
+				Reason:  "ProbesFailed",
+				Message: "Unreachable peer Nodes: node00, node01, node02, node03, node04, node05, node06, node07, node08, node09 and 2 more",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			aq := agentQuerier{
+				ofClient:                 ofClient,
+				networkPolicyInfoQuerier: networkPolicyInfoQuerier,
+				nodeLatencyQuerier:       tt.nodeLatencyQuerier,
+				conditionManager:         newConditionManager(),
+			}
+			conditions := aq.getAgentConditions(true)
+			if tt.expectedCondition == nil {
+				assert.Len(t, conditions, 4)
+				return
+			}
+			require.Len(t, conditions, 5)
+			peerCondition := conditions[4]
+			// Exclude LastHeartbeatTime and LastTransitionTime which we cannot predict.
+			peerCondition.LastHeartbeatTime = v1.Time{}
+			peerCondition.LastTransitionTime = v1.Time{}
+			assert.Equal(t, *tt.expectedCondition, peerCondition)
+		})
+	}
+}
+
+func TestAgentQuerierModuleConditions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	networkPolicyInfoQuerier := queriertest.NewMockAgentNetworkPolicyInfoQuerier(ctrl)
+	networkPolicyInfoQuerier.EXPECT().GetControllerConnectionStatus().Return(true).AnyTimes()
+
+	conditionManager := newConditionManager()
+	conditionManager.Set(v1beta1.ProxyHealthy, corev1.ConditionFalse, "SyncFailed", "Failed to sync 1 Service(s)")
+	aq := agentQuerier{
+		networkPolicyInfoQuerier: networkPolicyInfoQuerier,
+		conditionManager:         conditionManager,
+	}
+	conditions := aq.getAgentConditions(false)
+	require.Len(t, conditions, 5)
+	// The conditions are listed in a fixed order, regardless of the module which set them.
+	assert.Equal(t, v1beta1.OVSDBConnectionUp, conditions[2].Type)
+	assert.Equal(t, corev1.ConditionFalse, conditions[2].Status)
+	assert.Equal(t, v1beta1.ProxyHealthy, conditions[4].Type)
+	assert.Equal(t, "SyncFailed", conditions[4].Reason)
+	assert.Equal(t, "Failed to sync 1 Service(s)", conditions[4].Message)
+	assert.Equal(t, conditions[0].LastHeartbeatTime, conditions[4].LastHeartbeatTime)
+	assert.False(t, conditions[4].LastTransitionTime.IsZero())
+}
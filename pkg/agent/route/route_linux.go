@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"net"
 	"reflect"
+	"sort"
 	"strconv"
 	"sync"
 	"time"
@@ -60,6 +61,15 @@ const (
 // Client implements Interface.
 var _ Interface = &Client{}
 
+var jumpRules = []iptables.JumpRule{
+	{Table: iptables.RawTable, SrcChain: iptables.PreRoutingChain, DstChain: antreaPreRoutingChain, Comment: "jump to Antrea prerouting rules"},
+	{Table: iptables.RawTable, SrcChain: iptables.OutputChain, DstChain: antreaOutputChain, Comment: "jump to Antrea output rules"},
+	{Table: iptables.FilterTable, SrcChain: iptables.ForwardChain, DstChain: antreaForwardChain, Comment: "jump to Antrea forwarding rules"},
+	{Table: iptables.NATTable, SrcChain: iptables.PostRoutingChain, DstChain: antreaPostRoutingChain, Comment: "jump to Antrea postrouting rules"},
+	{Table: iptables.MangleTable, SrcChain: iptables.PreRoutingChain, DstChain: antreaMangleChain, Comment: "jump to Antrea mangle rules"}, // TODO: unify the chain naming style
+	{Table: iptables.MangleTable, SrcChain: iptables.OutputChain, DstChain: antreaOutputChain, Comment: "jump to Antrea output rules"},
+}
+
 var (
 	// globalVMAC is used in the IPv6 neighbor configuration to advertise ND solicitation for the IPv6 address of the
 	// host gateway interface on other Nodes.
@@ -84,11 +94,14 @@ type Client struct {
 	markToSNATIP sync.Map
 	// iptablesInitialized is used to notify when iptables initialization is done.
 	iptablesInitialized chan struct{}
+	// serviceRoute is the route of the Service CIDR via the host gateway. It's nil if the
+	// Service CIDR is not advertised.
+	serviceRoute *netlink.Route
 }
 
 // NewClient returns a route client.
-// TODO: remove param serviceCIDR after kube-proxy is replaced by Antrea Proxy. This param is not used in this file;
-// leaving it here is to be compatible with the implementation on Windows.
+// TODO: remove param serviceCIDR after kube-proxy is replaced by Antrea Proxy. This param is only used in this file
+// when the Service CIDR is advertised through the host gateway.
 func NewClient(serviceCIDR *net.IPNet, networkConfig *config.NetworkConfig, noSNAT bool) (*Client, error) {
 	return &Client{
 		serviceCIDR:   serviceCIDR,
@@ -102,6 +115,7 @@ func NewClient(serviceCIDR *net.IPNet, networkConfig *config.NetworkConfig, noSN
 func (c *Client) Initialize(nodeConfig *config.NodeConfig, done func()) error {
 	c.nodeConfig = nodeConfig
 	c.iptablesInitialized = make(chan struct{})
+	c.serviceRoute = c.newServiceRoute()
 
 	// Sets up the ipset that will be used in iptables.
 	if err := c.syncIPSet(); err != nil {
@@ -171,6 +185,14 @@ func (c *Client) syncRoutes() error {
 		}
 		routeMap[r.Dst.String()] = r
 	}
+	if c.serviceRoute != nil {
+		r, ok := routeMap[c.serviceRoute.Dst.String()]
+		if !ok || !routeEqual(c.serviceRoute, r) {
+			if err := netlink.RouteReplace(c.serviceRoute); err != nil {
+				klog.Errorf("Failed to add route for the Service CIDR to the gateway: %v", err)
+			}
+		}
+	}
 	c.nodeRoutes.Range(func(_, v interface{}) bool {
 		for _, route := range v.([]*netlink.Route) {
 			r, ok := routeMap[route.Dst.String()]
@@ -230,10 +252,10 @@ func getIPSetName(ip net.IP) string {
 }
 
 // writeEKSMangleRule writes an additional iptables mangle rule to the
-// iptablesData buffer, which is required to ensure that the reverse path for
+// builder, which is required to ensure that the reverse path for
 // NodePort Service traffic is correct on EKS.
 // See https://github.com/antrea-io/antrea/issues/678.
-func (c *Client) writeEKSMangleRule(iptablesData *bytes.Buffer) {
+func (c *Client) writeEKSMangleRule(builder *iptables.ChainBuilder) {
 	// TODO: the following should be taking into account:
 	//   1) AWS_VPC_CNI_NODE_PORT_SUPPORT may be set to false (by default is
 	//   true), in which case we do not need to install the rule.
@@ -244,12 +266,10 @@ func (c *Client) writeEKSMangleRule(iptablesData *bytes.Buffer) {
 	// it does exist we can scan for the mark value and use that in our
 	// rule.
 	klog.V(2).Infof("Add iptable mangle rule for EKS to ensure correct reverse path for NodePort Service traffic")
-	writeLine(iptablesData, []string{
-		"-A", antreaMangleChain,
-		"-m", "comment", "--comment", `"Antrea: AWS, primary ENI"`,
+	builder.AppendRule(iptables.MangleTable, antreaMangleChain, "AWS, primary ENI",
 		"-i", c.nodeConfig.GatewayConfig.Name, "-j", "CONNMARK",
 		"--restore-mark", "--nfmask", "0x80", "--ctmask", "0x80",
-	}...)
+	)
 }
 
 // syncIPTables ensure that the iptables infrastructure we use is set up.
@@ -265,22 +285,8 @@ func (c *Client) syncIPTables() error {
 	// Create the antrea managed chains and link them to built-in chains.
 	// We cannot use iptables-restore for these jump rules because there
 	// are non antrea managed rules in built-in chains.
-	jumpRules := []struct{ table, srcChain, dstChain, comment string }{
-		{iptables.RawTable, iptables.PreRoutingChain, antreaPreRoutingChain, "Antrea: jump to Antrea prerouting rules"},
-		{iptables.RawTable, iptables.OutputChain, antreaOutputChain, "Antrea: jump to Antrea output rules"},
-		{iptables.FilterTable, iptables.ForwardChain, antreaForwardChain, "Antrea: jump to Antrea forwarding rules"},
-		{iptables.NATTable, iptables.PostRoutingChain, antreaPostRoutingChain, "Antrea: jump to Antrea postrouting rules"},
-		{iptables.MangleTable, iptables.PreRoutingChain, antreaMangleChain, "Antrea: jump to Antrea mangle rules"}, // TODO: unify the chain naming style
-		{iptables.MangleTable, iptables.OutputChain, antreaOutputChain, "Antrea: jump to Antrea output rules"},
-	}
-	for _, rule := range jumpRules {
-		if err := c.ipt.EnsureChain(rule.table, rule.dstChain); err != nil {
-			return err
-		}
-		ruleSpec := []string{"-j", rule.dstChain, "-m", "comment", "--comment", rule.comment}
-		if err := c.ipt.EnsureRule(rule.table, rule.srcChain, ruleSpec); err != nil {
-			return err
-		}
+	if err := c.ipt.EnsureJumpRules(jumpRules); err != nil {
+		return err
 	}
 
 	snatMarkToIPv4 := map[uint32]net.IP{}
@@ -297,33 +303,31 @@ func (c *Client) syncIPTables() error {
 	})
 	// Use iptables-restore to configure IPv4 settings.
 	if v4Enabled {
-		iptablesData := c.restoreIptablesData(c.nodeConfig.PodIPv4CIDR, antreaPodIPSet, snatMarkToIPv4)
+		builder := c.buildAntreaChains(c.nodeConfig.PodIPv4CIDR, antreaPodIPSet, snatMarkToIPv4)
 		// Setting --noflush to keep the previous contents (i.e. non antrea managed chains) of the tables.
-		if err := c.ipt.Restore(iptablesData.Bytes(), false, false); err != nil {
+		if err := c.ipt.Restore(builder.Render(), false, false); err != nil {
 			return err
 		}
 	}
 
 	// Use ip6tables-restore to configure IPv6 settings.
 	if v6Enabled {
-		iptablesData := c.restoreIptablesData(c.nodeConfig.PodIPv6CIDR, antreaPodIP6Set, snatMarkToIPv6)
+		builder := c.buildAntreaChains(c.nodeConfig.PodIPv6CIDR, antreaPodIP6Set, snatMarkToIPv6)
 		// Setting --noflush to keep the previous contents (i.e. non antrea managed chains) of the tables.
-		if err := c.ipt.Restore(iptablesData.Bytes(), false, true); err != nil {
+		if err := c.ipt.Restore(builder.Render(), false, true); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func (c *Client) restoreIptablesData(podCIDR *net.IPNet, podIPSet string, snatMarkToIP map[uint32]net.IP) *bytes.Buffer {
-	// Create required rules in the antrea chains.
-	// Use iptables-restore as it flushes the involved chains and creates the desired rules
-	// with a single call, instead of string matching to clean up stale rules.
-	iptablesData := bytes.NewBuffer(nil)
-	// Write head lines anyway so the undesired rules can be deleted when changing encap mode.
-	writeLine(iptablesData, "*raw")
-	writeLine(iptablesData, iptables.MakeChainLine(antreaPreRoutingChain))
-	writeLine(iptablesData, iptables.MakeChainLine(antreaOutputChain))
+// buildAntreaChains renders the whole content of the Antrea managed chains. The chains are
+// replaced by iptables-restore on every sync, which removes the stale rules and restores the order
+// of the rules, instead of string matching to find the rules to clean up.
+func (c *Client) buildAntreaChains(podCIDR *net.IPNet, podIPSet string, snatMarkToIP map[uint32]net.IP) *iptables.ChainBuilder {
+	builder := iptables.NewChainBuilder()
+	// Declare the chains anyway so the undesired rules can be deleted when changing encap mode.
+	builder.AddChain(iptables.RawTable, antreaPreRoutingChain).AddChain(iptables.RawTable, antreaOutputChain)
 	if c.networkConfig.TrafficEncapMode.SupportsEncap() {
 		// For Geneve and VXLAN encapsulation packets, the request and response packets don't belong to a UDP connection
 		// so tracking them doesn't give the normal benefits of conntrack. Besides, kube-proxy may install great number
@@ -336,85 +340,95 @@ func (c *Client) restoreIptablesData(podCIDR *net.IPNet, podIPSet string, snatMa
 			udpPort = vxlanPort
 		}
 		if udpPort > 0 {
-			writeLine(iptablesData, []string{
-				"-A", antreaPreRoutingChain,
-				"-m", "comment", "--comment", `"Antrea: do not track incoming encapsulation packets"`,
+			builder.AppendRule(iptables.RawTable, antreaPreRoutingChain, "do not track incoming encapsulation packets",
 				"-m", "udp", "-p", "udp", "--dport", strconv.Itoa(udpPort),
 				"-m", "addrtype", "--dst-type", "LOCAL",
 				"-j", iptables.NoTrackTarget,
-			}...)
-			writeLine(iptablesData, []string{
-				"-A", antreaOutputChain,
-				"-m", "comment", "--comment", `"Antrea: do not track outgoing encapsulation packets"`,
+			)
+			builder.AppendRule(iptables.RawTable, antreaOutputChain, "do not track outgoing encapsulation packets",
 				"-m", "udp", "-p", "udp", "--dport", strconv.Itoa(udpPort),
 				"-m", "addrtype", "--src-type", "LOCAL",
 				"-j", iptables.NoTrackTarget,
-			}...)
+			)
 		}
 	}
-	writeLine(iptablesData, "COMMIT")
 
-	// Write head lines anyway so the undesired rules can be deleted when noEncap -> encap.
-	writeLine(iptablesData, "*mangle")
-	writeLine(iptablesData, iptables.MakeChainLine(antreaMangleChain))
-	writeLine(iptablesData, iptables.MakeChainLine(antreaOutputChain))
+	// Declare the chains anyway so the undesired rules can be deleted when noEncap -> encap.
+	builder.AddChain(iptables.MangleTable, antreaMangleChain).AddChain(iptables.MangleTable, antreaOutputChain)
 
 	// When Antrea is used to enforce NetworkPolicies in EKS, an additional iptables
 	// mangle rule is required. See https://github.com/antrea-io/antrea/issues/678.
 	if env.IsCloudEKS() {
-		c.writeEKSMangleRule(iptablesData)
+		c.writeEKSMangleRule(builder)
 	}
 
 	// To make liveness/readiness probe traffic bypass ingress rules of Network Policies, mark locally generated packets
 	// that will be sent to OVS so we can identify them later in the OVS pipeline.
 	// It must match source address because kube-proxy ipvs mode will redirect ingress packets to output chain, and they
 	// will have non local source addresses.
-	writeLine(iptablesData, []string{
-		"-A", antreaOutputChain,
-		"-m", "comment", "--comment", `"Antrea: mark LOCAL output packets"`,
+	builder.AppendRule(iptables.MangleTable, antreaOutputChain, "mark LOCAL output packets",
 		"-m", "addrtype", "--src-type", "LOCAL",
 		"-o", c.nodeConfig.GatewayConfig.Name,
 		"-j", iptables.MarkTarget, "--or-mark", fmt.Sprintf("%#08x", types.HostLocalSourceMark),
-	}...)
-	writeLine(iptablesData, "COMMIT")
-
-	writeLine(iptablesData, "*filter")
-	writeLine(iptablesData, iptables.MakeChainLine(antreaForwardChain))
-	writeLine(iptablesData, []string{
-		"-A", antreaForwardChain,
-		"-m", "comment", "--comment", `"Antrea: accept packets from local Pods"`,
+	)
+
+	// The MSS of the forwarded SYN packets must be clamped before they are accepted by the
+	// following rules.
+	if c.networkConfig.EnableTCPMSSClamping {
+		mss := strconv.Itoa(c.getTCPMSS(podCIDR))
+		builder.AppendRule(iptables.FilterTable, antreaForwardChain, "clamp TCP MSS of Pod to external packets",
+			"-i", c.nodeConfig.GatewayConfig.Name, "!", "-o", c.nodeConfig.GatewayConfig.Name,
+			"-p", "tcp", "-m", "tcp", "--tcp-flags", "SYN,RST", "SYN",
+			"-j", iptables.TCPMSSTarget, "--set-mss", mss,
+		)
+		builder.AppendRule(iptables.FilterTable, antreaForwardChain, "clamp TCP MSS of external to Pod packets",
+			"!", "-i", c.nodeConfig.GatewayConfig.Name, "-o", c.nodeConfig.GatewayConfig.Name,
+			"-p", "tcp", "-m", "tcp", "--tcp-flags", "SYN,RST", "SYN",
+			"-j", iptables.TCPMSSTarget, "--set-mss", mss,
+		)
+	}
+	builder.AppendRule(iptables.FilterTable, antreaForwardChain, "accept packets from local Pods",
 		"-i", c.nodeConfig.GatewayConfig.Name,
 		"-j", iptables.AcceptTarget,
-	}...)
-	writeLine(iptablesData, []string{
-		"-A", antreaForwardChain,
-		"-m", "comment", "--comment", `"Antrea: accept packets to local Pods"`,
+	)
+	builder.AppendRule(iptables.FilterTable, antreaForwardChain, "accept packets to local Pods",
 		"-o", c.nodeConfig.GatewayConfig.Name,
 		"-j", iptables.AcceptTarget,
-	}...)
-	writeLine(iptablesData, "COMMIT")
-
-	writeLine(iptablesData, "*nat")
-	writeLine(iptablesData, iptables.MakeChainLine(antreaPostRoutingChain))
-	// Egress rules must be inserted before the default masquerade rule.
-	for snatMark, snatIP := range snatMarkToIP {
-		// Cannot reuse snatRuleSpec to generate the rule as it doesn't have "`" in the comment.
-		writeLine(iptablesData, []string{
-			"-A", antreaPostRoutingChain,
-			"-m", "comment", "--comment", `"Antrea: SNAT Pod to external packets"`,
+	)
+
+	builder.AddChain(iptables.NATTable, antreaPostRoutingChain)
+	// Egress rules must be inserted before the default masquerade rule. They are sorted by mark so
+	// that the rendered chain doesn't depend on the iteration order of the map.
+	snatMarks := make([]uint32, 0, len(snatMarkToIP))
+	for snatMark := range snatMarkToIP {
+		snatMarks = append(snatMarks, snatMark)
+	}
+	sort.Slice(snatMarks, func(i, j int) bool { return snatMarks[i] < snatMarks[j] })
+	for _, snatMark := range snatMarks {
+		builder.AppendRule(iptables.NATTable, antreaPostRoutingChain, "SNAT Pod to external packets",
 			"!", "-o", c.nodeConfig.GatewayConfig.Name,
 			"-m", "mark", "--mark", fmt.Sprintf("%#08x/%#08x", snatMark, types.SNATIPMarkMask),
-			"-j", iptables.SNATTarget, "--to", snatIP.String(),
-		}...)
+			"-j", iptables.SNATTarget, "--to", snatMarkToIP[snatMark].String(),
+		)
 	}
 
 	if !c.noSNAT {
-		writeLine(iptablesData, []string{
-			"-A", antreaPostRoutingChain,
-			"-m", "comment", "--comment", `"Antrea: masquerade Pod to external packets"`,
+		builder.AppendRule(iptables.NATTable, antreaPostRoutingChain, "masquerade Pod to external packets",
 			"-s", podCIDR.String(), "-m", "set", "!", "--match-set", podIPSet, "dst",
 			"-j", iptables.MasqueradeTarget,
-		}...)
+		)
+	}
+
+	// Traffic from the Node network to an advertised ClusterIP is load-balanced by AntreaProxy
+	// after it has been routed to the gateway interface. It must be masqueraded with the gateway
+	// IP, so that the reply traffic from an Endpoint running on another Node goes back through
+	// this Node, where the connection was load-balanced.
+	if c.serviceRoute != nil && (c.serviceRoute.Dst.IP.To4() == nil) == (podCIDR.IP.To4() == nil) {
+		builder.AppendRule(iptables.NATTable, antreaPostRoutingChain, "masquerade external to ClusterIP packets",
+			"-d", c.serviceRoute.Dst.String(), "-o", c.nodeConfig.GatewayConfig.Name,
+			"-m", "addrtype", "!", "--src-type", "LOCAL",
+			"-j", iptables.MasqueradeTarget,
+		)
 	}
 
 	// For local traffic going out of the gateway interface, if the source IP does not match any
@@ -422,17 +436,27 @@ func (c *Client) restoreIptablesData(podCIDR *net.IPNet, podIPSet string, snatMa
 	// that ARP requests may advertise a different source IP address, in which case they will be
 	// dropped by the SpoofGuard table in the OVS pipeline. See description for the arp_announce
 	// sysctl parameter.
-	writeLine(iptablesData, []string{
-		"-A", antreaPostRoutingChain,
-		"-m", "comment", "--comment", `"Antrea: masquerade LOCAL traffic"`,
+	builder.AppendRule(iptables.NATTable, antreaPostRoutingChain, "masquerade LOCAL traffic",
 		"-o", c.nodeConfig.GatewayConfig.Name,
 		"-m", "addrtype", "!", "--src-type", "LOCAL", "--limit-iface-out",
 		"-m", "addrtype", "--src-type", "LOCAL",
 		"-j", iptables.MasqueradeTarget, "--random-fully",
-	}...)
+	)
+	return builder
+}
 
-	writeLine(iptablesData, "COMMIT")
-	return iptablesData
+// getTCPMSS returns the MSS to set in the SYN packets of the provided IP family. Unless it is
+// configured, it is derived from the Node MTU, which already accounts for the tunnel overhead.
+func (c *Client) getTCPMSS(podCIDR *net.IPNet) int {
+	if c.networkConfig.TCPMSS != 0 {
+		return c.networkConfig.TCPMSS
+	}
+	// The size of the IPv4 or IPv6 header and of the TCP header, without options.
+	headerLen := 20 + 20
+	if podCIDR.IP.To4() == nil {
+		headerLen = 40 + 20
+	}
+	return c.nodeConfig.NodeMTU - headerLen
 }
 
 func (c *Client) initIPRoutes() error {
@@ -443,9 +467,29 @@ func (c *Client) initIPRoutes() error {
 			return fmt.Errorf("failed to add address %s to gw %s: %v", gwIP, gwLink.Attrs().Name, err)
 		}
 	}
+	if c.serviceRoute != nil {
+		if err := netlink.RouteReplace(c.serviceRoute); err != nil {
+			return fmt.Errorf("failed to install route for Service CIDR %s with netlink. Route config: %s. Error: %v", c.serviceCIDR, c.serviceRoute.String(), err)
+		}
+	}
 	return nil
 }
 
+// newServiceRoute returns the route of the Service CIDR via the host gateway. The next hop is a
+// virtual IP which is resolved by the OVS pipeline, so that the packets can be load-balanced by
+// AntreaProxy. It returns nil if the Service CIDR should not be advertised.
+func (c *Client) newServiceRoute() *netlink.Route {
+	if !c.networkConfig.AdvertiseServiceCIDR || c.serviceCIDR == nil || c.serviceCIDR.IP.To4() == nil {
+		return nil
+	}
+	return &netlink.Route{
+		Dst:       c.serviceCIDR,
+		Gw:        config.VirtualServiceIPv4,
+		LinkIndex: c.nodeConfig.GatewayConfig.LinkIndex,
+		Flags:     int(netlink.FLAG_ONLINK),
+	}
+}
+
 // Reconcile removes orphaned podCIDRs from ipset and removes routes to orphaned podCIDRs
 // based on the desired podCIDRs.
 func (c *Client) Reconcile(podCIDRs []string) error {
@@ -489,6 +533,9 @@ func (c *Client) Reconcile(podCIDRs []string) error {
 		if desiredPodCIDRs.Has(route.Dst.String()) {
 			continue
 		}
+		if c.serviceRoute != nil && route.Dst.String() == c.serviceRoute.Dst.String() {
+			continue
+		}
 		klog.Infof("Deleting unknown route %v", route)
 		if err := netlink.RouteDel(&route); err != nil && err != unix.ESRCH {
 			return err
@@ -658,18 +705,6 @@ func (c *Client) DeleteRoutes(podCIDR *net.IPNet) error {
 }
 
 // Join all words with spaces, terminate with newline and write to buf.
-func writeLine(buf *bytes.Buffer, words ...string) {
-	// We avoid strings.Join for performance reasons.
-	for i := range words {
-		buf.WriteString(words[i])
-		if i < len(words)-1 {
-			buf.WriteByte(' ')
-		} else {
-			buf.WriteByte('\n')
-		}
-	}
-}
-
 // MigrateRoutesToGw moves routes (including assigned IP addresses if any) from link linkName to
 // host gateway.
 func (c *Client) MigrateRoutesToGw(linkName string) error {
@@ -773,3 +808,74 @@ func (c *Client) DeleteSNATRule(mark uint32) error {
 	snatIP := value.(net.IP)
 	return c.ipt.DeleteRule(iptables.NATTable, antreaPostRoutingChain, c.snatRuleSpec(snatIP, mark))
 }
+
+// CleanupIPTables deletes the Antrea managed iptables chains and the rules jumping to them from
+// the built-in chains. It can be called on a Node from which Antrea has been partially removed.
+func CleanupIPTables(enableIPv4, enableIPv6 bool) error {
+	ipt, err := iptables.New(enableIPv4, enableIPv6)
+	if err != nil {
+		return fmt.Errorf("error creating IPTables instance: %v", err)
+	}
+	for _, rule := range jumpRules {
+		if err := ipt.DeleteRule(rule.Table, rule.SrcChain, rule.RuleSpec()); err != nil {
+			return err
+		}
+	}
+	for _, rule := range jumpRules {
+		// DeleteChain creates the chain before deleting it if it doesn't exist.
+		if err := ipt.DeleteChain(rule.Table, rule.DstChain); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CleanupIPSets destroys the Antrea managed ipsets. It must be called after the iptables rules
+// referencing them have been deleted.
+func CleanupIPSets() error {
+	for _, ipsetName := range []string{antreaPodIPSet, antreaPodIP6Set} {
+		if err := ipset.DestroyIPSet(ipsetName); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CleanupGatewayRoutes deletes the routes on the host gateway interface. It does nothing if the
+// interface doesn't exist.
+func CleanupGatewayRoutes(gatewayName string) error {
+	link, err := netlink.LinkByName(gatewayName)
+	if err != nil {
+		if _, ok := err.(netlink.LinkNotFoundError); ok {
+			return nil
+		}
+		return fmt.Errorf("error getting link %s: %v", gatewayName, err)
+	}
+	filter := &netlink.Route{LinkIndex: link.Attrs().Index}
+	routes, err := netlink.RouteListFiltered(netlink.FAMILY_ALL, filter, netlink.RT_FILTER_OIF)
+	if err != nil {
+		return fmt.Errorf("error listing routes on link %s: %v", gatewayName, err)
+	}
+	for i := range routes {
+		if err := netlink.RouteDel(&routes[i]); err != nil && err != unix.ESRCH {
+			return fmt.Errorf("error deleting route %s on link %s: %v", routes[i].String(), gatewayName, err)
+		}
+	}
+	return nil
+}
+
+// CleanupGatewayLink deletes the host gateway interface. It does nothing if the interface doesn't
+// exist, which is the case once the OVS bridge has been deleted.
+func CleanupGatewayLink(gatewayName string) error {
+	link, err := netlink.LinkByName(gatewayName)
+	if err != nil {
+		if _, ok := err.(netlink.LinkNotFoundError); ok {
+			return nil
+		}
+		return fmt.Errorf("error getting link %s: %v", gatewayName, err)
+	}
+	if err := netlink.LinkDel(link); err != nil {
+		return fmt.Errorf("error deleting link %s: %v", gatewayName, err)
+	}
+	return nil
+}
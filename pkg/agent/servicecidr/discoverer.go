@@ -0,0 +1,340 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package servicecidr
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/informers"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	"antrea.io/antrea/pkg/agent/metrics"
+)
+
+const (
+	controllerName = "AntreaAgentServiceCIDRDiscoverer"
+	// How long to wait before retrying the processing of a Service CIDR change.
+	minRetryDelay = 5 * time.Second
+	maxRetryDelay = 300 * time.Second
+	// discoverKey is the only key of the queue, as the Service CIDRs are always computed from
+	// all the Services.
+	discoverKey = "serviceCIDRs"
+	// The kubernetes Service in the default Namespace is allocated the first IP of the Service
+	// CIDR by kube-apiserver.
+	kubernetesServiceNamespace = "default"
+	kubernetesServiceName      = "kubernetes"
+)
+
+var ipFamilies = []corev1.IPFamily{corev1.IPv4Protocol, corev1.IPv6Protocol}
+
+// ServiceCIDRHandler is called with the IPv4 and IPv6 Service CIDRs in use when they change.
+// Either of them can be nil.
+type ServiceCIDRHandler func(serviceCIDR, serviceCIDRv6 *net.IPNet) error
+
+// Discoverer infers the Service CIDRs of the cluster, i.e. the --service-cluster-ip-range of
+// kube-apiserver, from the ClusterIPs of the Services. The first IP of the range is allocated to
+// the kubernetes Service, and the discovered Service CIDR of each IP family is the smallest CIDR
+// covering the network address of the range and the ClusterIPs of that family. It only expands
+// as Services are created, so that a deleted Service never causes the flows to be reprogrammed.
+//
+// The Service CIDR of an IP family which is set explicitly in the configuration is authoritative:
+// it is always used, and the Discoverer only reports whether the discovered Service CIDR is
+// included in it. Otherwise, the configured (default) Service CIDR is replaced with the discovered
+// one when the latter is not included in it, and the handler is called with the Service CIDRs in
+// use whenever they change.
+type Discoverer struct {
+	serviceLister       corelisters.ServiceLister
+	serviceListerSynced cache.InformerSynced
+	queue               workqueue.RateLimitingInterface
+	handler             ServiceCIDRHandler
+	// configured holds the Service CIDR of each IP family in the configuration, if any.
+	configured map[corev1.IPFamily]*net.IPNet
+	// static indicates whether the Service CIDR of each IP family is set explicitly in the
+	// configuration.
+	static map[corev1.IPFamily]bool
+
+	mutex sync.RWMutex
+	// discovered holds the Service CIDR of each IP family discovered so far.
+	discovered map[corev1.IPFamily]*net.IPNet
+	// mismatch indicates whether the discovered Service CIDR of each IP family is not included
+	// in the configured one.
+	mismatch map[corev1.IPFamily]bool
+	// current holds the Service CIDR of each IP family in use.
+	current map[corev1.IPFamily]*net.IPNet
+}
+
+// NewDiscoverer creates a Discoverer. serviceCIDR and serviceCIDRv6 are the Service CIDRs in
+// use when it is created, and staticServiceCIDR and staticServiceCIDRv6 indicate whether they
+// are set explicitly in the configuration.
+func NewDiscoverer(informerFactory informers.SharedInformerFactory,
+	serviceCIDR, serviceCIDRv6 *net.IPNet,
+	staticServiceCIDR, staticServiceCIDRv6 bool,
+	handler ServiceCIDRHandler) *Discoverer {
+	serviceInformer := informerFactory.Core().V1().Services()
+	d := &Discoverer{
+		serviceLister:       serviceInformer.Lister(),
+		serviceListerSynced: serviceInformer.Informer().HasSynced,
+		queue:               workqueue.NewNamedRateLimitingQueue(workqueue.NewItemExponentialFailureRateLimiter(minRetryDelay, maxRetryDelay), "servicecidr"),
+		handler:             handler,
+		configured: map[corev1.IPFamily]*net.IPNet{
+			corev1.IPv4Protocol: serviceCIDR,
+			corev1.IPv6Protocol: serviceCIDRv6,
+		},
+		static: map[corev1.IPFamily]bool{
+			corev1.IPv4Protocol: staticServiceCIDR && serviceCIDR != nil,
+			corev1.IPv6Protocol: staticServiceCIDRv6 && serviceCIDRv6 != nil,
+		},
+		discovered: map[corev1.IPFamily]*net.IPNet{},
+		mismatch:   map[corev1.IPFamily]bool{},
+		current: map[corev1.IPFamily]*net.IPNet{
+			corev1.IPv4Protocol: serviceCIDR,
+			corev1.IPv6Protocol: serviceCIDRv6,
+		},
+	}
+	serviceInformer.Informer().AddEventHandler(
+		cache.ResourceEventHandlerFuncs{
+			// A ClusterIP cannot be removed from a Service without deleting it, and deleted
+			// Services do not shrink the discovered Service CIDRs.
+			AddFunc: func(_ interface{}) {
+				d.queue.Add(discoverKey)
+			},
+			UpdateFunc: func(_, _ interface{}) {
+				d.queue.Add(discoverKey)
+			},
+		},
+	)
+	return d
+}
+
+// Run discovers the Service CIDRs until stopCh is closed.
+func (d *Discoverer) Run(stopCh <-chan struct{}) {
+	defer d.queue.ShutDown()
+
+	klog.Infof("Starting %s", controllerName)
+	defer klog.Infof("Shutting down %s", controllerName)
+
+	if !cache.WaitForNamedCacheSync(controllerName, stopCh, d.serviceListerSynced) {
+		return
+	}
+	go wait.Until(d.worker, time.Second, stopCh)
+	<-stopCh
+}
+
+// GetServiceCIDRs returns the IPv4 and IPv6 Service CIDRs in use. Either of them can be nil.
+func (d *Discoverer) GetServiceCIDRs() (*net.IPNet, *net.IPNet) {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+	return d.current[corev1.IPv4Protocol], d.current[corev1.IPv6Protocol]
+}
+
+func (d *Discoverer) worker() {
+	for d.processNextWorkItem() {
+	}
+}
+
+func (d *Discoverer) processNextWorkItem() bool {
+	key, quit := d.queue.Get()
+	if quit {
+		return false
+	}
+	defer d.queue.Done(key)
+
+	if err := d.sync(); err == nil {
+		d.queue.Forget(key)
+	} else {
+		d.queue.AddRateLimited(key)
+		klog.Errorf("Error syncing Service CIDRs, requeuing. Error: %v", err)
+	}
+	return true
+}
+
+func (d *Discoverer) sync() error {
+	services, err := d.serviceLister.List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	discovered := discoverServiceCIDRs(services)
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	current := map[corev1.IPFamily]*net.IPNet{}
+	changed := false
+	for _, family := range ipFamilies {
+		if discovered[family] != nil {
+			d.discovered[family] = mergeCIDRs(d.discovered[family], discovered[family])
+		}
+		d.updateMismatch(family)
+		current[family] = d.serviceCIDRInUse(family)
+		if !cidrEqual(current[family], d.current[family]) {
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	klog.Infof("Service CIDRs changed from %v to %v", cidrsString(d.current), cidrsString(current))
+	if d.handler != nil {
+		if err := d.handler(current[corev1.IPv4Protocol], current[corev1.IPv6Protocol]); err != nil {
+			return err
+		}
+	}
+	d.current = current
+	return nil
+}
+
+// updateMismatch checks whether the discovered Service CIDR of the IP family is included in the
+// configured one, and reports it when it changes. It must be called with the mutex held.
+func (d *Discoverer) updateMismatch(family corev1.IPFamily) {
+	configured, discovered := d.configured[family], d.discovered[family]
+	mismatch := configured != nil && discovered != nil && !cidrContains(configured, discovered)
+	if mismatch == d.mismatch[family] {
+		return
+	}
+	d.mismatch[family] = mismatch
+	if mismatch {
+		klog.Warningf("The discovered %s Service CIDR %s is not included in the configured Service CIDR %s, which may not match the --service-cluster-ip-range of kube-apiserver", family, discovered, configured)
+		metrics.ServiceCIDRDiscoveryMismatch.WithLabelValues(string(family)).Set(1)
+	} else {
+		metrics.ServiceCIDRDiscoveryMismatch.WithLabelValues(string(family)).Set(0)
+	}
+}
+
+// serviceCIDRInUse returns the Service CIDR of the IP family which should be used. It must be
+// called with the mutex held.
+func (d *Discoverer) serviceCIDRInUse(family corev1.IPFamily) *net.IPNet {
+	configured, discovered := d.configured[family], d.discovered[family]
+	if d.static[family] || discovered == nil {
+		return configured
+	}
+	if configured != nil && !d.mismatch[family] {
+		return configured
+	}
+	return discovered
+}
+
+// discoverServiceCIDRs returns the smallest CIDR of each IP family covering the ClusterIPs of the
+// Services, and the network address of the Service CIDR inferred from the ClusterIP of the
+// kubernetes Service.
+func discoverServiceCIDRs(services []*corev1.Service) map[corev1.IPFamily]*net.IPNet {
+	discovered := map[corev1.IPFamily]*net.IPNet{}
+	for _, service := range services {
+		clusterIPs := service.Spec.ClusterIPs
+		if len(clusterIPs) == 0 && service.Spec.ClusterIP != "" {
+			clusterIPs = []string{service.Spec.ClusterIP}
+		}
+		for _, clusterIP := range clusterIPs {
+			// Headless Services have "None" as ClusterIP, which is ignored.
+			ipAddr := net.ParseIP(clusterIP)
+			if ipAddr == nil {
+				continue
+			}
+			if ipv4 := ipAddr.To4(); ipv4 != nil {
+				ipAddr = ipv4
+			}
+			family := ipFamily(ipAddr)
+			discovered[family] = coveringCIDR(discovered[family], ipAddr)
+			if service.Namespace == kubernetesServiceNamespace && service.Name == kubernetesServiceName {
+				discovered[family] = coveringCIDR(discovered[family], prevIP(ipAddr))
+			}
+		}
+	}
+	return discovered
+}
+
+// prevIP returns the IP preceding ipAddr.
+func prevIP(ipAddr net.IP) net.IP {
+	prev := make(net.IP, len(ipAddr))
+	copy(prev, ipAddr)
+	for i := len(prev) - 1; i >= 0; i-- {
+		prev[i]--
+		if prev[i] != 0xff {
+			break
+		}
+	}
+	return prev
+}
+
+func ipFamily(ipAddr net.IP) corev1.IPFamily {
+	if ipAddr.To4() != nil {
+		return corev1.IPv4Protocol
+	}
+	return corev1.IPv6Protocol
+}
+
+// coveringCIDR returns the smallest CIDR including cidr and ipAddr. cidr can be nil, in which
+// case the CIDR of ipAddr only is returned.
+func coveringCIDR(cidr *net.IPNet, ipAddr net.IP) *net.IPNet {
+	if ipv4 := ipAddr.To4(); ipv4 != nil {
+		ipAddr = ipv4
+	}
+	bits := len(ipAddr) * 8
+	if cidr == nil {
+		return &net.IPNet{IP: ipAddr, Mask: net.CIDRMask(bits, bits)}
+	}
+	ones, _ := cidr.Mask.Size()
+	for ; ones > 0; ones-- {
+		mask := net.CIDRMask(ones, bits)
+		if ipAddr.Mask(mask).Equal(cidr.IP.Mask(mask)) {
+			break
+		}
+	}
+	mask := net.CIDRMask(ones, bits)
+	return &net.IPNet{IP: cidr.IP.Mask(mask), Mask: mask}
+}
+
+// mergeCIDRs returns the smallest CIDR including a and b, which must be of the same IP family. a
+// can be nil, in which case b is returned.
+func mergeCIDRs(a, b *net.IPNet) *net.IPNet {
+	if a == nil {
+		return b
+	}
+	merged := coveringCIDR(a, b.IP)
+	if cidrContains(merged, b) {
+		return merged
+	}
+	return coveringCIDR(b, a.IP)
+}
+
+// cidrContains returns whether the CIDR a includes the CIDR b.
+func cidrContains(a, b *net.IPNet) bool {
+	aOnes, aBits := a.Mask.Size()
+	bOnes, bBits := b.Mask.Size()
+	return aBits == bBits && aOnes <= bOnes && a.Contains(b.IP)
+}
+
+func cidrEqual(a, b *net.IPNet) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.String() == b.String()
+}
+
+func cidrsString(cidrs map[corev1.IPFamily]*net.IPNet) []string {
+	var s []string
+	for _, family := range ipFamilies {
+		if cidrs[family] != nil {
+			s = append(s, cidrs[family].String())
+		}
+	}
+	return s
+}
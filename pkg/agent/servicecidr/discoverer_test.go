@@ -0,0 +1,198 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package servicecidr
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+)
+
+func newService(namespace, name string, clusterIPs ...string) *corev1.Service {
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Spec:       corev1.ServiceSpec{ClusterIPs: clusterIPs},
+	}
+	if len(clusterIPs) > 0 {
+		service.Spec.ClusterIP = clusterIPs[0]
+	}
+	return service
+}
+
+func mustParseCIDR(cidr string) *net.IPNet {
+	_, ipNet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		panic(err)
+	}
+	return ipNet
+}
+
+func TestDiscoverServiceCIDRs(t *testing.T) {
+	discovered := discoverServiceCIDRs([]*corev1.Service{
+		newService("default", "kubernetes", "10.96.0.1"),
+		newService("ns1", "svc1", "10.96.3.10", "fd00:10:96::a"),
+		newService("ns1", "svc2", "fd00:10:96::1:5"),
+		newService("ns1", "headless", "None"),
+		newService("ns1", "external"),
+	})
+	assert.Equal(t, "10.96.0.0/22", discovered[corev1.IPv4Protocol].String())
+	assert.Equal(t, "fd00:10:96::/111", discovered[corev1.IPv6Protocol].String())
+}
+
+func TestMergeCIDRs(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected string
+	}{
+		{"10.96.0.0/24", "10.96.0.0/24", "10.96.0.0/24"},
+		{"10.96.0.0/24", "10.96.1.0/24", "10.96.0.0/23"},
+		{"10.96.0.0/16", "10.96.1.0/24", "10.96.0.0/16"},
+		{"10.96.1.0/24", "10.96.0.0/16", "10.96.0.0/16"},
+		{"10.96.0.0/12", "172.30.0.0/16", "0.0.0.0/0"},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s+%s", tt.a, tt.b), func(t *testing.T) {
+			assert.Equal(t, tt.expected, mergeCIDRs(mustParseCIDR(tt.a), mustParseCIDR(tt.b)).String())
+		})
+	}
+}
+
+func TestDiscovererSync(t *testing.T) {
+	tests := []struct {
+		name                string
+		serviceCIDR         string
+		staticServiceCIDR   bool
+		services            []*corev1.Service
+		expectedServiceCIDR string
+		expectedMismatch    bool
+	}{
+		{
+			name:                "default included",
+			serviceCIDR:         "10.96.0.0/12",
+			services:            []*corev1.Service{newService("default", "kubernetes", "10.96.0.1"), newService("ns1", "svc1", "10.100.0.1")},
+			expectedServiceCIDR: "10.96.0.0/12",
+		},
+		{
+			name:                "default replaced",
+			serviceCIDR:         "10.96.0.0/12",
+			services:            []*corev1.Service{newService("default", "kubernetes", "172.30.0.1"), newService("ns1", "svc1", "172.30.200.1")},
+			expectedServiceCIDR: "172.30.0.0/16",
+			expectedMismatch:    true,
+		},
+		{
+			name:                "static",
+			serviceCIDR:         "10.96.0.0/12",
+			staticServiceCIDR:   true,
+			services:            []*corev1.Service{newService("default", "kubernetes", "172.30.0.1"), newService("ns1", "svc1", "172.30.200.1")},
+			expectedServiceCIDR: "10.96.0.0/12",
+			expectedMismatch:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var objs []runtime.Object
+			for _, service := range tt.services {
+				objs = append(objs, service)
+			}
+			client := fake.NewSimpleClientset(objs...)
+			informerFactory := informers.NewSharedInformerFactory(client, 0)
+			var handledServiceCIDR *net.IPNet
+			handled := 0
+			d := NewDiscoverer(informerFactory, mustParseCIDR(tt.serviceCIDR), nil, tt.staticServiceCIDR, false, func(serviceCIDR, serviceCIDRv6 *net.IPNet) error {
+				handledServiceCIDR = serviceCIDR
+				handled++
+				return nil
+			})
+			stopCh := make(chan struct{})
+			defer close(stopCh)
+			informerFactory.Start(stopCh)
+			require.True(t, cache.WaitForCacheSync(stopCh, d.serviceListerSynced))
+
+			require.NoError(t, d.sync())
+			serviceCIDR, serviceCIDRv6 := d.GetServiceCIDRs()
+			assert.Equal(t, tt.expectedServiceCIDR, serviceCIDR.String())
+			assert.Nil(t, serviceCIDRv6)
+			assert.Equal(t, tt.expectedMismatch, d.mismatch[corev1.IPv4Protocol])
+			if tt.expectedServiceCIDR == tt.serviceCIDR {
+				assert.Equal(t, 0, handled)
+			} else {
+				assert.Equal(t, 1, handled)
+				assert.Equal(t, tt.expectedServiceCIDR, handledServiceCIDR.String())
+			}
+		})
+	}
+}
+
+func TestDiscovererSyncExpand(t *testing.T) {
+	client := fake.NewSimpleClientset(newService("default", "kubernetes", "fd00:10:96::1"))
+	informerFactory := informers.NewSharedInformerFactory(client, 0)
+	var handledServiceCIDRs []string
+	var handlerErr error
+	d := NewDiscoverer(informerFactory, mustParseCIDR("10.96.0.0/12"), nil, false, false, func(serviceCIDR, serviceCIDRv6 *net.IPNet) error {
+		if handlerErr != nil {
+			return handlerErr
+		}
+		handledServiceCIDRs = append(handledServiceCIDRs, serviceCIDRv6.String())
+		return nil
+	})
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	informerFactory.Start(stopCh)
+	require.True(t, cache.WaitForCacheSync(stopCh, d.serviceListerSynced))
+
+	// The IPv6 Service CIDR is not configured, so the discovered one is used.
+	require.NoError(t, d.sync())
+	assert.Equal(t, []string{"fd00:10:96::/127"}, handledServiceCIDRs)
+
+	// The Service CIDRs in use are not updated if the handler fails.
+	handlerErr = fmt.Errorf("failed to install flows")
+	service := newService("ns1", "svc1", "fd00:10:96::f0")
+	_, err := client.CoreV1().Services("ns1").Create(context.TODO(), service, metav1.CreateOptions{})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		services, _ := d.serviceLister.List(labels.Everything())
+		return len(services) == 2
+	}, time.Second, 10*time.Millisecond)
+	assert.Error(t, d.sync())
+	_, serviceCIDRv6 := d.GetServiceCIDRs()
+	assert.Equal(t, "fd00:10:96::/127", serviceCIDRv6.String())
+
+	handlerErr = nil
+	require.NoError(t, d.sync())
+	assert.Equal(t, []string{"fd00:10:96::/127", "fd00:10:96::/120"}, handledServiceCIDRs)
+
+	// Deleting a Service does not shrink the discovered Service CIDR.
+	require.NoError(t, client.CoreV1().Services("ns1").Delete(context.TODO(), "svc1", metav1.DeleteOptions{}))
+	require.Eventually(t, func() bool {
+		services, _ := d.serviceLister.List(labels.Everything())
+		return len(services) == 1
+	}, time.Second, 10*time.Millisecond)
+	require.NoError(t, d.sync())
+	_, serviceCIDRv6 = d.GetServiceCIDRs()
+	assert.Equal(t, "fd00:10:96::/120", serviceCIDRv6.String())
+	assert.Len(t, handledServiceCIDRs, 2)
+}
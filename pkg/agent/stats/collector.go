@@ -54,14 +54,14 @@ type Collector struct {
 	// antrea-controller.
 	antreaClientProvider agent.AntreaClientProvider
 	// ofClient is the Openflow interface that can fetch the statistic of the Openflow entries.
-	ofClient             openflow.Client
+	ofClient             openflow.PolicyClient
 	networkPolicyQuerier querier.AgentNetworkPolicyInfoQuerier
 	// lastStatsCollection is the last statistics that has been reported to antrea-controller successfully.
 	// It is used to calculate the delta of the statistics that will be reported.
 	lastStatsCollection *statsCollection
 }
 
-func NewCollector(antreaClientProvider agent.AntreaClientProvider, ofClient openflow.Client, npQuerier querier.AgentNetworkPolicyInfoQuerier) *Collector {
+func NewCollector(antreaClientProvider agent.AntreaClientProvider, ofClient openflow.PolicyClient, npQuerier querier.AgentNetworkPolicyInfoQuerier) *Collector {
 	nodeName, _ := env.GetNodeName()
 	manager := &Collector{
 		nodeName:             nodeName,
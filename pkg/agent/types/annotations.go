@@ -17,4 +17,22 @@ package types
 const (
 	// NodeMACAddressAnnotationKey represents the key of the Node's MAC address in the Annotations of the Node.
 	NodeMACAddressAnnotationKey string = "node.antrea.io/mac-address"
+
+	// NodeTunnelConfigAnnotationKey represents the key of the Node's tunnel type and options in the Annotations of
+	// the Node. It is compared with the local configuration to detect the peer Nodes which cannot be reached through
+	// the tunnel.
+	NodeTunnelConfigAnnotationKey string = "node.antrea.io/tunnel-config"
+
+	// ServiceExternalSNATDisabledAnnotationKey represents the key of the annotation which disables the SNAT of
+	// the traffic from outside the cluster to a LoadBalancer Service, in order to preserve the client IP.
+	ServiceExternalSNATDisabledAnnotationKey string = "service.antrea.io/disable-external-snat"
+
+	// PodDHCPAnnotationKey represents the key of the annotation which lets a Pod obtain its addresses using DHCP
+	// when set to "true". It is only read when the Pod network is set up.
+	PodDHCPAnnotationKey string = "pod.antrea.io/dhcp"
+
+	// PodConnectionLimitAnnotationKey represents the key of the annotation which sets the maximum number of
+	// connections initiated by a Pod which are tracked at a time, overriding the default limit of the agent. "0"
+	// disables the limit. It is only read when the Pod network is set up.
+	PodConnectionLimitAnnotationKey string = "pod.antrea.io/connection-limit"
 )
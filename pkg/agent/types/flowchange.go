@@ -0,0 +1,36 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import "time"
+
+// FlowChangeTrigger identifies the high-level object whose change caused a flow operation, e.g. the
+// NetworkPolicy, the Pod interface or the Service.
+type FlowChangeTrigger struct {
+	Kind string
+	Name string
+}
+
+// FlowChange is a flow operation recorded by the flow change tracking of the OpenFlow client.
+type FlowChange struct {
+	Time time.Time
+	// Operation is one of "add", "modify" and "delete".
+	Operation string
+	// Category is the cookie category of the flow.
+	Category string
+	Cookie   uint64
+	TableID  uint8
+	Trigger  FlowChangeTrigger
+}
@@ -15,6 +15,8 @@
 package types
 
 import (
+	"net"
+
 	"antrea.io/antrea/pkg/apis/controlplane/v1beta2"
 	secv1alpha1 "antrea.io/antrea/pkg/apis/crd/v1alpha1"
 	binding "antrea.io/antrea/pkg/ovs/openflow"
@@ -142,6 +144,25 @@ func (m *RuleMetric) Merge(m1 *RuleMetric) {
 	m.Sessions += m1.Sessions
 }
 
+// PolicyBootstrapPeer is a peer which local Pods can connect to, and can be connected by, before the first
+// NetworkPolicy sync when the agent fails closed.
+type PolicyBootstrapPeer struct {
+	IPNet net.IPNet
+	// Protocol is the protocol of the allowed traffic. It's ignored if Port is 0.
+	Protocol v1beta2.Protocol
+	// Port is the destination port of the allowed traffic. 0 means all ports of all protocols.
+	Port uint16
+}
+
+// PolicyRuleUninstallResult is the result of the asynchronous uninstallation of the Openflow entries of a
+// NetworkPolicy rule.
+type PolicyRuleUninstallResult struct {
+	// StalePriorities are the ofPriorities used by ClusterNetworkPolicies which are stale after the
+	// uninstallation.
+	StalePriorities []string
+	Err             error
+}
+
 // A BitRange is a representation of a range of values from base value with a
 // bitmask applied.
 type BitRange struct {
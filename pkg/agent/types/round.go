@@ -15,11 +15,16 @@
 package types
 
 // RoundInfo identifies the current agent "round". Each round is indentified by
-// a round number, which is incremented every time the agent is restarted. The
-// round number is persisted on the Node in OVSDB.
+// a round number, which is incremented every time the agent is restarted, and a
+// generation, which is incremented every time the round number wraps around.
+// Both are persisted on the Node in OVSDB.
 type RoundInfo struct {
-	RoundNum uint64
+	RoundNum   uint64
+	Generation uint64
 	// PrevRoundNum is nil if this is the first round or the previous round
 	// number could not be retrieved.
 	PrevRoundNum *uint64
+	// PrevGeneration is the generation of the previous round. It is only
+	// meaningful when PrevRoundNum is not nil.
+	PrevGeneration uint64
 }
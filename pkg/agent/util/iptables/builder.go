@@ -0,0 +1,192 @@
+// +build !windows
+
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package iptables
+
+import (
+	"bytes"
+	"strings"
+)
+
+// OwnerCommentPrefix prefixes the comment of every rule owned by Antrea. It is the marker used to
+// tell the Antrea rules apart from the rules of other agents in the built-in chains.
+const OwnerCommentPrefix = "Antrea:"
+
+// ownerComment returns the comment of a rule owned by Antrea.
+func ownerComment(comment string) string {
+	return OwnerCommentPrefix + " " + comment
+}
+
+// JumpRule is a rule in a built-in chain which jumps to an Antrea managed chain.
+type JumpRule struct {
+	Table    string
+	SrcChain string
+	DstChain string
+	// Comment is the description of the rule, without OwnerCommentPrefix.
+	Comment string
+}
+
+// RuleSpec returns the rule specification of the jump rule, as accepted by the iptables command.
+func (r JumpRule) RuleSpec() []string {
+	return []string{"-j", r.DstChain, "-m", "comment", "--comment", ownerComment(r.Comment)}
+}
+
+type builderTable struct {
+	name   string
+	chains []string
+	rules  map[string][]string
+}
+
+// ChainBuilder renders the whole content of Antrea managed chains as iptables-restore input. When
+// the input is restored with --noflush, every declared chain is flushed and rewritten, while the
+// other chains of the tables are left untouched. Restoring the same input is therefore idempotent,
+// and restores the exact rules and their order however the chains were modified in between.
+type ChainBuilder struct {
+	tables []*builderTable
+}
+
+func NewChainBuilder() *ChainBuilder {
+	return &ChainBuilder{}
+}
+
+func (b *ChainBuilder) getTable(table string) *builderTable {
+	for _, t := range b.tables {
+		if t.name == table {
+			return t
+		}
+	}
+	t := &builderTable{name: table, rules: map[string][]string{}}
+	b.tables = append(b.tables, t)
+	return t
+}
+
+// AddChain declares an Antrea managed chain. A declared chain is rendered even if it has no rule,
+// so that stale rules are removed from it.
+func (b *ChainBuilder) AddChain(table, chain string) *ChainBuilder {
+	t := b.getTable(table)
+	if _, exists := t.rules[chain]; !exists {
+		t.chains = append(t.chains, chain)
+		t.rules[chain] = nil
+	}
+	return b
+}
+
+// AppendRule appends a rule to an Antrea managed chain, declaring the chain if needed. The rule
+// is marked with the provided comment, prefixed with OwnerCommentPrefix.
+func (b *ChainBuilder) AppendRule(table, chain, comment string, ruleSpec ...string) *ChainBuilder {
+	b.AddChain(table, chain)
+	t := b.getTable(table)
+	words := append([]string{"-A", chain, "-m", "comment", "--comment", `"` + ownerComment(comment) + `"`}, ruleSpec...)
+	t.rules[chain] = append(t.rules[chain], strings.Join(words, " "))
+	return b
+}
+
+// Render returns the iptables-restore input of the declared chains. Tables and chains are rendered
+// in the order they were declared, and rules in the order they were appended.
+func (b *ChainBuilder) Render() []byte {
+	buf := bytes.NewBuffer(nil)
+	for _, t := range b.tables {
+		buf.WriteString("*" + t.name + "\n")
+		for _, chain := range t.chains {
+			buf.WriteString(MakeChainLine(chain) + "\n")
+		}
+		for _, chain := range t.chains {
+			for _, rule := range t.rules[chain] {
+				buf.WriteString(rule + "\n")
+			}
+		}
+		buf.WriteString("COMMIT\n")
+	}
+	return buf.Bytes()
+}
+
+// splitRuleLine splits a rule listed by "iptables -S" into words, unquoting the quoted words.
+func splitRuleLine(line string) []string {
+	var words []string
+	var word strings.Builder
+	inWord, quoted, escaped := false, false, false
+	for _, r := range line {
+		switch {
+		case escaped:
+			word.WriteRune(r)
+			escaped = false
+		case r == '\\' && quoted:
+			escaped = true
+		case r == '"':
+			quoted = !quoted
+			inWord = true
+		case r == ' ' && !quoted:
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words
+}
+
+// ruleField returns the value following the first occurrence of flag in ruleSpec.
+func ruleField(ruleSpec []string, flag string) string {
+	for i := 0; i < len(ruleSpec)-1; i++ {
+		if ruleSpec[i] == flag {
+			return ruleSpec[i+1]
+		}
+	}
+	return ""
+}
+
+// jumpRuleChanges computes the changes to make to a built-in chain, whose current rules are
+// listed by "iptables -S", so that each of the provided jump rules exists exactly once in it, and
+// no other rule owned by Antrea does. Rules which are not owned by Antrea are never changed.
+func jumpRuleChanges(chain string, current []string, rules []JumpRule) (toDelete, toAppend [][]string) {
+	found := make([]bool, len(rules))
+	for _, line := range current {
+		words := splitRuleLine(line)
+		if len(words) < 2 || words[0] != "-A" || words[1] != chain {
+			continue
+		}
+		ruleSpec := words[2:]
+		comment := ruleField(ruleSpec, "--comment")
+		if !strings.HasPrefix(comment, OwnerCommentPrefix) {
+			continue
+		}
+		matched := false
+		for i, rule := range rules {
+			if !found[i] && ruleField(ruleSpec, "-j") == rule.DstChain && comment == ownerComment(rule.Comment) {
+				found[i] = true
+				matched = true
+				break
+			}
+		}
+		// Duplicated and stale rules are deleted.
+		if !matched {
+			toDelete = append(toDelete, ruleSpec)
+		}
+	}
+	for i, rule := range rules {
+		if !found[i] {
+			toAppend = append(toAppend, rule.RuleSpec())
+		}
+	}
+	return toDelete, toAppend
+}
@@ -0,0 +1,276 @@
+// +build !windows
+
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package iptables
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTables models the chains of the iptables tables, each chain being the list of its rules in
+// the "iptables -S" format.
+type fakeTables map[string]map[string][]string
+
+func (f fakeTables) copy() fakeTables {
+	c := fakeTables{}
+	for table, chains := range f {
+		c[table] = map[string][]string{}
+		for chain, rules := range chains {
+			c[table][chain] = append([]string(nil), rules...)
+		}
+	}
+	return c
+}
+
+// restoreNoFlush applies iptables-restore input the way "iptables-restore --noflush" does: the
+// declared chains are flushed (or created), and the rules are appended to them.
+func (f fakeTables) restoreNoFlush(t *testing.T, data []byte) {
+	var table string
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		switch {
+		case strings.HasPrefix(line, "*"):
+			table = line[1:]
+			if f[table] == nil {
+				f[table] = map[string][]string{}
+			}
+		case strings.HasPrefix(line, ":"):
+			f[table][strings.Fields(line[1:])[0]] = nil
+		case strings.HasPrefix(line, "-A "):
+			chain := strings.Fields(line)[1]
+			_, exists := f[table][chain]
+			require.True(t, exists, "Chain %s must be declared before its rules", chain)
+			f[table][chain] = append(f[table][chain], line)
+		case line == "COMMIT":
+			table = ""
+		default:
+			t.Fatalf("Unexpected line %q", line)
+		}
+	}
+}
+
+func (f fakeTables) applyJumpRuleChanges(table, chain string, rules []JumpRule) {
+	toDelete, toAppend := jumpRuleChanges(chain, f[table][chain], rules)
+	for _, ruleSpec := range toDelete {
+		for i, line := range f[table][chain] {
+			if strings.Join(splitRuleLine(line)[2:], " ") == strings.Join(ruleSpec, " ") {
+				f[table][chain] = append(f[table][chain][:i], f[table][chain][i+1:]...)
+				break
+			}
+		}
+	}
+	for _, ruleSpec := range toAppend {
+		f[table][chain] = append(f[table][chain], listRule(chain, ruleSpec))
+	}
+}
+
+// listRule formats a rule the way "iptables -S" does, quoting the words with spaces.
+func listRule(chain string, ruleSpec []string) string {
+	words := []string{"-A", chain}
+	for _, word := range ruleSpec {
+		if strings.Contains(word, " ") {
+			word = `"` + word + `"`
+		}
+		words = append(words, word)
+	}
+	return strings.Join(words, " ")
+}
+
+// mutateChain randomly changes a chain like other agents or users could do.
+func mutateChain(r *rand.Rand, rules []string, chain string) []string {
+	rules = append([]string(nil), rules...)
+	switch r.Intn(5) {
+	case 0:
+		r.Shuffle(len(rules), func(i, j int) { rules[i], rules[j] = rules[j], rules[i] })
+	case 1:
+		if len(rules) > 0 {
+			i := r.Intn(len(rules))
+			rules = append(rules[:i], rules[i+1:]...)
+		}
+	case 2:
+		if len(rules) > 0 {
+			rules = append(rules, rules[r.Intn(len(rules))])
+		}
+	case 3:
+		foreign := fmt.Sprintf("-A %s -s 10.10.%d.0/24 -j ACCEPT", chain, r.Intn(256))
+		i := r.Intn(len(rules) + 1)
+		rules = append(rules[:i], append([]string{foreign}, rules[i:]...)...)
+	case 4:
+		rules = nil
+	}
+	return rules
+}
+
+func newTestBuilder() *ChainBuilder {
+	return NewChainBuilder().
+		AddChain(RawTable, "ANTREA-PREROUTING").
+		AppendRule(FilterTable, "ANTREA-FORWARD", "accept packets from local Pods", "-i", "antrea-gw0", "-j", AcceptTarget).
+		AppendRule(FilterTable, "ANTREA-FORWARD", "accept packets to local Pods", "-o", "antrea-gw0", "-j", AcceptTarget).
+		AppendRule(NATTable, "ANTREA-POSTROUTING", "SNAT Pod to external packets", "-m", "mark", "--mark", "0x01/0xff", "-j", SNATTarget, "--to", "1.1.1.1").
+		AppendRule(NATTable, "ANTREA-POSTROUTING", "masquerade Pod to external packets", "-s", "10.10.0.0/24", "-j", MasqueradeTarget)
+}
+
+func TestChainBuilderRender(t *testing.T) {
+	expected := `*raw
+:ANTREA-PREROUTING - [0:0]
+COMMIT
+*filter
+:ANTREA-FORWARD - [0:0]
+-A ANTREA-FORWARD -m comment --comment "Antrea: accept packets from local Pods" -i antrea-gw0 -j ACCEPT
+-A ANTREA-FORWARD -m comment --comment "Antrea: accept packets to local Pods" -o antrea-gw0 -j ACCEPT
+COMMIT
+*nat
+:ANTREA-POSTROUTING - [0:0]
+-A ANTREA-POSTROUTING -m comment --comment "Antrea: SNAT Pod to external packets" -m mark --mark 0x01/0xff -j SNAT --to 1.1.1.1
+-A ANTREA-POSTROUTING -m comment --comment "Antrea: masquerade Pod to external packets" -s 10.10.0.0/24 -j MASQUERADE
+COMMIT
+`
+	assert.Equal(t, expected, string(newTestBuilder().Render()))
+	assert.Equal(t, expected, string(newTestBuilder().Render()), "Rendering must be stable")
+}
+
+func TestChainBuilderReconcile(t *testing.T) {
+	tables := fakeTables{
+		FilterTable: {
+			ForwardChain:   {"-A FORWARD -j KUBE-FORWARD"},
+			"KUBE-FORWARD": {"-A KUBE-FORWARD -m conntrack --ctstate INVALID -j DROP"},
+		},
+		NATTable: {
+			PostRoutingChain:   {"-A POSTROUTING -j KUBE-POSTROUTING"},
+			"KUBE-POSTROUTING": {"-A KUBE-POSTROUTING -j MASQUERADE"},
+		},
+	}
+	data := newTestBuilder().Render()
+	tables.restoreNoFlush(t, data)
+	canonical := tables.copy()
+	assert.Len(t, canonical[NATTable]["ANTREA-POSTROUTING"], 2)
+
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 500; i++ {
+		for table, chains := range tables {
+			for chain, rules := range chains {
+				if strings.HasPrefix(chain, "ANTREA-") && r.Intn(2) == 0 {
+					tables[table][chain] = mutateChain(r, rules, chain)
+				}
+			}
+		}
+		tables.restoreNoFlush(t, data)
+		require.Equal(t, canonical, tables, "Iteration %d: reconciliation must restore the canonical chains", i)
+	}
+}
+
+func TestJumpRuleChanges(t *testing.T) {
+	rules := []JumpRule{
+		{Table: NATTable, SrcChain: PostRoutingChain, DstChain: "ANTREA-POSTROUTING", Comment: "jump to Antrea postrouting rules"},
+	}
+	jump := `-A POSTROUTING -m comment --comment "Antrea: jump to Antrea postrouting rules" -j ANTREA-POSTROUTING`
+	jumpSpec := []string{"-m", "comment", "--comment", "Antrea: jump to Antrea postrouting rules", "-j", "ANTREA-POSTROUTING"}
+	tcs := []struct {
+		name             string
+		current          []string
+		expectedToDelete [][]string
+		expectedToAppend [][]string
+	}{
+		{
+			name:             "missing",
+			current:          []string{"-P POSTROUTING ACCEPT", "-A POSTROUTING -j KUBE-POSTROUTING"},
+			expectedToAppend: [][]string{rules[0].RuleSpec()},
+		},
+		{
+			name:    "present",
+			current: []string{"-P POSTROUTING ACCEPT", "-A POSTROUTING -j KUBE-POSTROUTING", jump},
+		},
+		{
+			name:             "duplicated",
+			current:          []string{jump, "-A POSTROUTING -j KUBE-POSTROUTING", jump},
+			expectedToDelete: [][]string{jumpSpec},
+		},
+		{
+			name: "stale",
+			current: []string{
+				`-A POSTROUTING -m comment --comment "Antrea: jump to old rules" -j ANTREA-OLD`,
+				`-A POSTROUTING -m comment --comment "kube-proxy rules" -j KUBE-POSTROUTING`,
+			},
+			expectedToDelete: [][]string{{"-m", "comment", "--comment", "Antrea: jump to old rules", "-j", "ANTREA-OLD"}},
+			expectedToAppend: [][]string{rules[0].RuleSpec()},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			toDelete, toAppend := jumpRuleChanges(PostRoutingChain, tc.current, rules)
+			assert.Equal(t, tc.expectedToDelete, toDelete)
+			assert.Equal(t, tc.expectedToAppend, toAppend)
+		})
+	}
+}
+
+func TestJumpRulesReconcile(t *testing.T) {
+	rules := []JumpRule{
+		{Table: MangleTable, SrcChain: OutputChain, DstChain: "ANTREA-OUTPUT", Comment: "jump to Antrea output rules"},
+		{Table: MangleTable, SrcChain: OutputChain, DstChain: "ANTREA-EXTRA", Comment: "jump to Antrea extra rules"},
+	}
+	foreign := []string{"-A OUTPUT -j KUBE-MARK", "-A OUTPUT -m comment --comment \"other agent\" -j OTHER"}
+	tables := fakeTables{MangleTable: {OutputChain: append([]string(nil), foreign...)}}
+	stale := listRule(OutputChain, JumpRule{DstChain: "ANTREA-OLD", Comment: "jump to old rules"}.RuleSpec())
+
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 500; i++ {
+		current := tables[MangleTable][OutputChain]
+		switch r.Intn(3) {
+		case 0:
+			current = mutateChain(r, current, OutputChain)
+		case 1:
+			current = append(current, stale)
+		case 2:
+			r.Shuffle(len(current), func(i, j int) { current[i], current[j] = current[j], current[i] })
+		}
+		tables[MangleTable][OutputChain] = current
+		tables.applyJumpRuleChanges(MangleTable, OutputChain, rules)
+
+		counts := map[string]int{}
+		for _, line := range tables[MangleTable][OutputChain] {
+			counts[line]++
+		}
+		for _, rule := range rules {
+			require.Equal(t, 1, counts[listRule(OutputChain, rule.RuleSpec())], "Iteration %d: jump rule to %s must exist exactly once", i, rule.DstChain)
+		}
+		require.Zero(t, counts[stale], "Iteration %d: stale rule must be deleted", i)
+		// The rules of other agents are never changed by the reconciliation.
+		toDelete, toAppend := jumpRuleChanges(OutputChain, tables[MangleTable][OutputChain], rules)
+		require.Empty(t, toDelete, "Iteration %d: reconciliation must be idempotent", i)
+		require.Empty(t, toAppend, "Iteration %d: reconciliation must be idempotent", i)
+	}
+}
+
+func TestSplitRuleLine(t *testing.T) {
+	assert.Equal(t,
+		[]string{"-A", "FORWARD", "-m", "comment", "--comment", "Antrea: jump to Antrea forwarding rules", "-j", "ANTREA-FORWARD"},
+		splitRuleLine(`-A FORWARD -m comment --comment "Antrea: jump to Antrea forwarding rules" -j ANTREA-FORWARD`))
+	assert.Equal(t,
+		[]string{"-A", "OUTPUT", "-m", "comment", "--comment", `say "hi"`, "-j", "ACCEPT"},
+		splitRuleLine(`-A OUTPUT -m comment --comment "say \"hi\"" -j ACCEPT`))
+}
+
+func TestParseBackend(t *testing.T) {
+	assert.Equal(t, BackendNFTables, parseBackend("iptables v1.8.4 (nf_tables)\n"))
+	assert.Equal(t, BackendLegacy, parseBackend("iptables v1.8.4 (legacy)\n"))
+	assert.Equal(t, BackendLegacy, parseBackend("iptables v1.6.1\n"))
+}
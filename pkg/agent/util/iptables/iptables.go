@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"os/exec"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/blang/semver"
@@ -40,6 +41,7 @@ const (
 	ConnTrackTarget  = "CT"
 	NoTrackTarget    = "NOTRACK"
 	SNATTarget       = "SNAT"
+	TCPMSSTarget     = "TCPMSS"
 
 	PreRoutingChain  = "PREROUTING"
 	ForwardChain     = "FORWARD"
@@ -58,6 +60,14 @@ const (
 	ProtocolIPv6
 )
 
+// Backend is the kernel backend used by the iptables commands.
+type Backend string
+
+const (
+	BackendLegacy   Backend = "legacy"
+	BackendNFTables Backend = "nf_tables"
+)
+
 // https://netfilter.org/projects/iptables/files/changes-iptables-1.6.2.txt:
 // iptables-restore: support acquiring the lock.
 var restoreWaitSupportedMinVersion = semver.Version{Major: 1, Minor: 6, Patch: 2}
@@ -66,11 +76,16 @@ type Client struct {
 	ipts []*iptables.IPTables
 	// restoreWaitSupported indicates whether iptables-restore (or ip6tables-restore) supports --wait flag.
 	restoreWaitSupported bool
+	// backends stores the backend detected at runtime for each enabled protocol. The restore and
+	// save commands of the same backend are used, so that they operate on the same rules as the
+	// iptables command.
+	backends map[iptables.Protocol]Backend
 }
 
 func New(enableIPV4, enableIPV6 bool) (*Client, error) {
 	var ipts []*iptables.IPTables
 	var restoreWaitSupported bool
+	backends := map[iptables.Protocol]Backend{}
 	if enableIPV4 {
 		ipt, err := iptables.New()
 		if err != nil {
@@ -78,6 +93,7 @@ func New(enableIPV4, enableIPV6 bool) (*Client, error) {
 		}
 		ipts = append(ipts, ipt)
 		restoreWaitSupported = isRestoreWaitSupported(ipt)
+		backends[iptables.ProtocolIPv4] = detectBackend("iptables")
 	}
 	if enableIPV6 {
 		ip6t, err := iptables.NewWithProtocol(iptables.ProtocolIPv6)
@@ -88,8 +104,48 @@ func New(enableIPV4, enableIPV6 bool) (*Client, error) {
 		if !restoreWaitSupported {
 			restoreWaitSupported = isRestoreWaitSupported(ip6t)
 		}
+		backends[iptables.ProtocolIPv6] = detectBackend("ip6tables")
+	}
+	return &Client{ipts: ipts, restoreWaitSupported: restoreWaitSupported, backends: backends}, nil
+}
+
+// detectBackend returns the backend used by the provided iptables command, which is reported in
+// its version since iptables 1.8. Older versions only support the legacy backend.
+func detectBackend(cmd string) Backend {
+	output, err := exec.Command(cmd, "--version").CombinedOutput()
+	if err != nil {
+		klog.Warningf("Failed to get the version of %s, assuming legacy backend: %v", cmd, err)
+		return BackendLegacy
+	}
+	backend := parseBackend(string(output))
+	klog.V(2).Infof("Detected %s backend for %s", backend, cmd)
+	return backend
+}
+
+// parseBackend parses the output of "iptables --version", e.g. "iptables v1.8.4 (nf_tables)".
+func parseBackend(version string) Backend {
+	if strings.Contains(version, "("+string(BackendNFTables)+")") {
+		return BackendNFTables
+	}
+	return BackendLegacy
+}
+
+// backendCommand returns the command of the detected backend for the provided protocol, e.g.
+// "iptables-nft-restore" for "iptables" and "-restore". It falls back to the generic command if
+// the backend specific one is not installed.
+func (c *Client) backendCommand(protocol iptables.Protocol, suffix string) string {
+	cmd := "iptables"
+	if protocol == iptables.ProtocolIPv6 {
+		cmd = "ip6tables"
+	}
+	backendSuffix := "-legacy"
+	if c.backends[protocol] == BackendNFTables {
+		backendSuffix = "-nft"
 	}
-	return &Client{ipts: ipts, restoreWaitSupported: restoreWaitSupported}, nil
+	if _, err := exec.LookPath(cmd + backendSuffix + suffix); err == nil {
+		return cmd + backendSuffix + suffix
+	}
+	return cmd + suffix
 }
 
 func isRestoreWaitSupported(ipt *iptables.IPTables) bool {
@@ -150,6 +206,54 @@ func (c *Client) EnsureRule(table string, chain string, ruleSpec []string) error
 	return nil
 }
 
+// EnsureJumpRules creates the Antrea managed chains the provided rules jump to, and ensures that
+// each rule exists exactly once in its built-in chain. Unlike EnsureRule, it also deletes the
+// duplicated rules and the stale rules owned by Antrea (i.e. whose comment starts with
+// OwnerCommentPrefix) from the built-in chains, which may be left by other agents rewriting the
+// chains or by previous Antrea versions.
+func (c *Client) EnsureJumpRules(rules []JumpRule) error {
+	for _, rule := range rules {
+		if err := c.EnsureChain(rule.Table, rule.DstChain); err != nil {
+			return err
+		}
+	}
+	type builtinChain struct {
+		table, chain string
+	}
+	var chains []builtinChain
+	chainRules := map[builtinChain][]JumpRule{}
+	for _, rule := range rules {
+		key := builtinChain{rule.Table, rule.SrcChain}
+		if _, exists := chainRules[key]; !exists {
+			chains = append(chains, key)
+		}
+		chainRules[key] = append(chainRules[key], rule)
+	}
+	for idx := range c.ipts {
+		ipt := c.ipts[idx]
+		for _, key := range chains {
+			current, err := ipt.List(key.table, key.chain)
+			if err != nil {
+				return fmt.Errorf("error listing rules from table %s chain %s: %v", key.table, key.chain, err)
+			}
+			toDelete, toAppend := jumpRuleChanges(key.chain, current, chainRules[key])
+			for _, ruleSpec := range toDelete {
+				if err := ipt.Delete(key.table, key.chain, ruleSpec...); err != nil {
+					return fmt.Errorf("error deleting rule %v from table %s chain %s: %v", ruleSpec, key.table, key.chain, err)
+				}
+				klog.V(2).Infof("Deleted rule %v from table %s chain %s", ruleSpec, key.table, key.chain)
+			}
+			for _, ruleSpec := range toAppend {
+				if err := ipt.Append(key.table, key.chain, ruleSpec...); err != nil {
+					return fmt.Errorf("error appending rule %v to table %s chain %s: %v", ruleSpec, key.table, key.chain, err)
+				}
+				klog.V(2).Infof("Appended rule %v to table %s chain %s", ruleSpec, key.table, key.chain)
+			}
+		}
+	}
+	return nil
+}
+
 // InsertRule checks if target rule already exists, inserts it if not.
 func (c *Client) InsertRule(protocol Protocol, table string, chain string, ruleSpec []string) error {
 	for idx := range c.ipts {
@@ -192,7 +296,7 @@ func (c *Client) DeleteRule(table string, chain string, ruleSpec []string) error
 			return fmt.Errorf("error checking if rule %v exists in table %s chain %s: %v", ruleSpec, table, chain, err)
 		}
 		if !exist {
-			return nil
+			continue
 		}
 		if err := c.ipts[idx].Delete(table, chain, ruleSpec...); err != nil {
 			return fmt.Errorf("error deleting rule %v from table %s chain %s: %v", ruleSpec, table, chain, err)
@@ -239,10 +343,11 @@ func (c *Client) Restore(data []byte, flush bool, useIPv6 bool) error {
 	if !flush {
 		args = append(args, "--noflush")
 	}
-	iptablesCmd := "iptables-restore"
+	protocol := iptables.ProtocolIPv4
 	if useIPv6 {
-		iptablesCmd = "ip6tables-restore"
+		protocol = iptables.ProtocolIPv6
 	}
+	iptablesCmd := c.backendCommand(protocol, "-restore")
 	cmd := exec.Command(iptablesCmd, args...)
 	cmd.Stdin = bytes.NewBuffer(data)
 	stderr := &bytes.Buffer{}
@@ -275,14 +380,7 @@ func (c *Client) Restore(data []byte, flush bool, useIPv6 bool) error {
 func (c *Client) Save() ([]byte, error) {
 	var output []byte
 	for idx := range c.ipts {
-		var cmd string
-		ipt := c.ipts[idx]
-		switch ipt.Proto() {
-		case iptables.ProtocolIPv6:
-			cmd = "ip6tables-save"
-		default:
-			cmd = "iptables-save"
-		}
+		cmd := c.backendCommand(c.ipts[idx].Proto(), "-save")
 		data, err := exec.Command(cmd, "-c").CombinedOutput()
 		if err != nil {
 			return nil, err
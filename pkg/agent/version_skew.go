@@ -0,0 +1,86 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import (
+	"net/http"
+	"sync"
+
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+	"k8s.io/client-go/transport"
+	"k8s.io/klog/v2"
+
+	"antrea.io/antrea/pkg/version"
+)
+
+// versionRoundTripper reports the version of the antrea-agent and the name of its Node in its
+// requests to the Antrea Controller, which records them and checks the version skew. It also checks
+// the version skew with the version of the Antrea Controller reported in the responses.
+type versionRoundTripper struct {
+	rt           http.RoundTripper
+	agentVersion string
+	nodeName     string
+	// mutex protects controllerVersion.
+	mutex sync.Mutex
+	// controllerVersion is the last version of the Antrea Controller which was checked, so that a
+	// warning is only logged once per version.
+	controllerVersion string
+}
+
+func newVersionRoundTripper(agentVersion, nodeName string) transport.WrapperFunc {
+	return func(rt http.RoundTripper) http.RoundTripper {
+		return &versionRoundTripper{
+			rt:           rt,
+			agentVersion: agentVersion,
+			nodeName:     nodeName,
+		}
+	}
+}
+
+func (t *versionRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = utilnet.CloneRequest(req)
+	req.Header.Set(version.AgentVersionHeader, t.agentVersion)
+	req.Header.Set(version.AgentNodeNameHeader, t.nodeName)
+	resp, err := t.rt.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if controllerVersion := resp.Header.Get(version.ControllerVersionHeader); controllerVersion != "" {
+		t.checkControllerVersion(controllerVersion)
+	}
+	return resp, nil
+}
+
+func (t *versionRoundTripper) checkControllerVersion(controllerVersion string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.controllerVersion == controllerVersion {
+		return
+	}
+	t.controllerVersion = controllerVersion
+	supported, err := version.IsSupportedSkew(controllerVersion, t.agentVersion)
+	if err != nil {
+		klog.Warningf("Cannot check the version skew between the antrea-agent and the Antrea Controller: %v", err)
+		return
+	}
+	if !supported {
+		klog.Warningf("The antrea-agent runs version %s and the Antrea Controller runs version %s: version skews larger than %d minor version are not supported and may cause NetworkPolicies to be enforced incorrectly",
+			t.agentVersion, controllerVersion, version.MaxMinorVersionSkew)
+	}
+}
+
+func (t *versionRoundTripper) WrappedRoundTripper() http.RoundTripper {
+	return t.rt
+}
@@ -19,12 +19,24 @@ import (
 	"reflect"
 
 	"antrea.io/antrea/pkg/agent/apiserver/handlers/agentinfo"
+	"antrea.io/antrea/pkg/agent/apiserver/handlers/flowchanges"
+	"antrea.io/antrea/pkg/agent/apiserver/handlers/flowexporter"
+	agentnetworkpolicy "antrea.io/antrea/pkg/agent/apiserver/handlers/networkpolicy"
+	"antrea.io/antrea/pkg/agent/apiserver/handlers/nodelatency"
 	"antrea.io/antrea/pkg/agent/apiserver/handlers/ovsflows"
+	agentpipeline "antrea.io/antrea/pkg/agent/apiserver/handlers/pipeline"
 	"antrea.io/antrea/pkg/agent/apiserver/handlers/podinterface"
+	"antrea.io/antrea/pkg/agent/apiserver/handlers/serviceendpoints"
+	agentclient "antrea.io/antrea/pkg/agent/client"
 	"antrea.io/antrea/pkg/agent/openflow"
 	fallbackversion "antrea.io/antrea/pkg/antctl/fallback/version"
+	"antrea.io/antrea/pkg/antctl/raw/auditlogs"
+	"antrea.io/antrea/pkg/antctl/raw/checkconfig"
+	"antrea.io/antrea/pkg/antctl/raw/convertpolicy"
 	"antrea.io/antrea/pkg/antctl/raw/featuregates"
+	"antrea.io/antrea/pkg/antctl/raw/packetcapture"
 	"antrea.io/antrea/pkg/antctl/raw/proxy"
+	"antrea.io/antrea/pkg/antctl/raw/resync"
 	"antrea.io/antrea/pkg/antctl/raw/supportbundle"
 	"antrea.io/antrea/pkg/antctl/raw/traceflow"
 	"antrea.io/antrea/pkg/antctl/transform/addressgroup"
@@ -32,9 +44,12 @@ import (
 	"antrea.io/antrea/pkg/antctl/transform/controllerinfo"
 	"antrea.io/antrea/pkg/antctl/transform/networkpolicy"
 	"antrea.io/antrea/pkg/antctl/transform/ovstracing"
+	"antrea.io/antrea/pkg/antctl/transform/pipeline"
 	"antrea.io/antrea/pkg/antctl/transform/version"
 	cpv1beta "antrea.io/antrea/pkg/apis/controlplane/v1beta2"
 	systemv1beta1 "antrea.io/antrea/pkg/apis/system/v1beta1"
+	"antrea.io/antrea/pkg/apiserver/handlers/largestgroups"
+	"antrea.io/antrea/pkg/apiserver/handlers/policyquota"
 	controllerinforest "antrea.io/antrea/pkg/apiserver/registry/system/controllerinfo"
 	"antrea.io/antrea/pkg/client/clientset/versioned/scheme"
 	controllernetworkpolicy "antrea.io/antrea/pkg/controller/networkpolicy"
@@ -76,7 +91,9 @@ var CommandList = &commandList{
 			example: `  Show the current log verbosity level
   $ antctl log-level
   Set the log verbosity level to 2
-  $ antctl log-level 2`,
+  $ antctl log-level 2
+  Set the log verbosity level to 4 for 10 minutes
+  $ antctl log-level 4 --ttl 10m`,
 			commandGroup: flat,
 			controllerEndpoint: &endpoint{
 				nonResourceEndpoint: &nonResourceEndpoint{
@@ -87,8 +104,13 @@ var CommandList = &commandList{
 							usage: "The integer log verbosity level to set",
 							arg:   true,
 						},
+						{
+							name:  "ttl",
+							usage: "Revert the log verbosity level after this duration, e.g. 10m. The level is kept if not provided",
+						},
 					},
-					outputType: single,
+					mutatingParams: []string{"level"},
+					outputType:     single,
 				},
 			},
 			agentEndpoint: &endpoint{
@@ -100,8 +122,13 @@ var CommandList = &commandList{
 							usage: "The integer log verbosity level to set",
 							arg:   true,
 						},
+						{
+							name:  "ttl",
+							usage: "Revert the log verbosity level after this duration, e.g. 10m. The level is kept if not provided",
+						},
 					},
-					outputType: single,
+					mutatingParams: []string{"level"},
+					outputType:     single,
 				},
 			},
 			transformedResponse: reflect.TypeOf(0),
@@ -117,6 +144,8 @@ var CommandList = &commandList{
   $ antctl get networkpolicy
   Get the list of all control plane NetworkPolicies, sorted by the order in which the policies are evaluated.
   $ antctl get networkpolicy --sort-by=effectivePriority
+  Get the OpenFlow flows realizing a control plane NetworkPolicy on all the Nodes it spans (supported by controller only)
+  $ antctl get networkpolicy 6001549b-ba63-4752-8267-30f52b4332db --flows --all-nodes
   Get the control plane NetworkPolicy with a specific source (supported by agent only)
   $ antctl get networkpolicy -S allow-http -n ns1
   Get the list of control plane NetworkPolicies whose source NetworkPolicies are in a Namespace (supported by agent only)
@@ -124,17 +153,20 @@ var CommandList = &commandList{
   Get the list of control plane NetworkPolicies with a specific source Type (supported by agent only)
   $ antctl get networkpolicy -T acnp
   Get the list of control plane NetworkPolicies applied to a Pod (supported by agent only)
-  $ antctl get networkpolicy -p pod1 -n ns1`,
+  $ antctl get networkpolicy -p pod1 -n ns1
+  Watch the control plane NetworkPolicies applied to a Pod (supported by agent only)
+  $ antctl get networkpolicy -p pod1 -n ns1 --watch`,
 			commandGroup: get,
 			controllerEndpoint: &endpoint{
 				resourceEndpoint: &resourceEndpoint{
 					groupVersionResource: &cpv1beta.NetworkPolicyVersionResource,
 				},
 				addonTransform: networkpolicy.Transform,
+				flowsPath:      "/policyflows",
 			},
 			agentEndpoint: &endpoint{
 				nonResourceEndpoint: &nonResourceEndpoint{
-					path: "/networkpolicies",
+					path: agentclient.NetworkPoliciesPath,
 					params: []flagInfo{
 						{
 							name:  "name",
@@ -163,6 +195,10 @@ var CommandList = &commandList{
 						},
 					},
 					outputType: multiple,
+					watch: &watchEndpoint{
+						frameType:         reflect.TypeOf(agentnetworkpolicy.WatchEvent{}),
+						newFrameTransform: networkpolicy.WatchTransform,
+					},
 				},
 				addonTransform: networkpolicy.Transform,
 			},
@@ -237,6 +273,60 @@ var CommandList = &commandList{
 			commandGroup:        get,
 			transformedResponse: reflect.TypeOf(controllerinfo.Response{}),
 		},
+		{
+			use:     "largestgroups",
+			aliases: []string{"largestgroup", "lg"},
+			short:   "Print the largest AddressGroups and AppliedToGroups",
+			long:    "Print the AddressGroups and AppliedToGroups with the most members, in decreasing order of size, along with the policies referencing them. It helps finding the groups responsible for the load of the controller and of the agents.",
+			example: `  Print the 10 largest groups
+  $ antctl get largestgroups
+  Print the 3 largest groups
+  $ antctl get largestgroups -l 3
+  Print the 10 largest groups in JSON format
+  $ antctl get largestgroups -o json`,
+			controllerEndpoint: &endpoint{
+				nonResourceEndpoint: &nonResourceEndpoint{
+					path: "/largestgroups",
+					params: []flagInfo{
+						{
+							name:      "limit",
+							usage:     "Maximum number of groups to print",
+							shorthand: "l",
+						},
+					},
+					outputType: multiple,
+				},
+			},
+			commandGroup:        get,
+			transformedResponse: reflect.TypeOf(largestgroups.Response{}),
+		},
+		{
+			use:     "policyquota",
+			aliases: []string{"policyquotas", "pq"},
+			short:   "Print the usages of the NetworkPolicy flow quotas",
+			long:    "Print the estimated number of flows of the NetworkPolicies of each Namespace against the flow quota of the Namespace, along with the policies which are not enforced as they exceed the quota. Nothing is printed if no quota is configured.",
+			example: `  Print the usages of the quotas of all Namespaces
+  $ antctl get policyquota
+  Print the usage of the quota of Namespace ns1
+  $ antctl get policyquota -n ns1
+  Print the usages of the quotas of all Namespaces in JSON format
+  $ antctl get policyquota -o json`,
+			controllerEndpoint: &endpoint{
+				nonResourceEndpoint: &nonResourceEndpoint{
+					path: "/policyquotas",
+					params: []flagInfo{
+						{
+							name:      "namespace",
+							usage:     "Namespace of the quota to print",
+							shorthand: "n",
+						},
+					},
+					outputType: multiple,
+				},
+			},
+			commandGroup:        get,
+			transformedResponse: reflect.TypeOf(policyquota.Response{}),
+		},
 		{
 			use:     "agentinfo",
 			aliases: []string{"agentinfos", "ai"},
@@ -244,7 +334,7 @@ var CommandList = &commandList{
 			long:    "Print agent's basic information including version, deployment, Node subnet, OVS info, AgentConditions, etc.",
 			agentEndpoint: &endpoint{
 				nonResourceEndpoint: &nonResourceEndpoint{
-					path:       "/agentinfo",
+					path:       agentclient.AgentInfoPath,
 					outputType: single,
 				},
 			},
@@ -266,7 +356,7 @@ var CommandList = &commandList{
   $ antctl get podinterface`,
 			agentEndpoint: &endpoint{
 				nonResourceEndpoint: &nonResourceEndpoint{
-					path: "/podinterfaces",
+					path: agentclient.PodInterfacesPath,
 					params: []flagInfo{
 						{
 							name:  "name",
@@ -304,11 +394,15 @@ var CommandList = &commandList{
   $ antctl get ovsflows -G 10,20
   Dump all OVS groups
   $ antctl get ovsflows -G all
+  Watch the number of flows in each flow Table
+  $ antctl get ovsflows --watch
+  Watch the number of flows in specific flow Tables, as JSON lines
+  $ antctl get ovsflows --watch -T IngressRule,EgressRule -o json
 
   Antrea OVS Flow Tables:` + generateFlowTableHelpMsg(),
 			agentEndpoint: &endpoint{
 				nonResourceEndpoint: &nonResourceEndpoint{
-					path: "/ovsflows",
+					path: agentclient.OVSFlowsPath,
 					params: []flagInfo{
 						{
 							name:      "namespace",
@@ -327,7 +421,7 @@ var CommandList = &commandList{
 						},
 						{
 							name:      "networkpolicy",
-							usage:     "NetworkPolicy name. If present, Namespace must be provided.",
+							usage:     "NetworkPolicy name. If present, Namespace must be provided, unless it is an Antrea ClusterNetworkPolicy.",
 							shorthand: "N",
 						},
 						{
@@ -342,11 +436,135 @@ var CommandList = &commandList{
 						},
 					},
 					outputType: multiple,
+					watch: &watchEndpoint{
+						frameType: reflect.TypeOf([]ovsflows.TableFlowCount{}),
+						newFrameTransform: func(_ map[string]string) func(frame interface{}) (interface{}, error) {
+							return func(frame interface{}) (interface{}, error) {
+								return frame, nil
+							}
+						},
+					},
 				},
 			},
 			commandGroup:        get,
 			transformedResponse: reflect.TypeOf(ovsflows.Response{}),
 		},
+		{
+			use:     "serviceendpoints",
+			aliases: []string{"serviceendpoint", "svcep"},
+			short:   "Print the OVS group and buckets of a Service",
+			long:    "Print the OVS group which load-balances each port of a Service on the Node, and the Endpoints selected by its buckets. For each Endpoint, the index and the weight of its bucket, whether it is local to the Node, and whether its DNAT flow is installed are shown. AntreaProxy must be enabled.",
+			example: `  Print the OVS group and buckets of a Service
+  $ antctl get serviceendpoints ns1/svc1
+  Print the OVS group and buckets of a Service in JSON format
+  $ antctl get serviceendpoints ns1/svc1 -o json`,
+			agentEndpoint: &endpoint{
+				nonResourceEndpoint: &nonResourceEndpoint{
+					path: "/proxy/endpoints",
+					params: []flagInfo{
+						{
+							name:  "service",
+							usage: "Service in the <namespace>/<name> format",
+							arg:   true,
+						},
+					},
+					outputType: multiple,
+				},
+			},
+			commandGroup:        get,
+			transformedResponse: reflect.TypeOf(serviceendpoints.Response{}),
+		},
+		{
+			use:     "nodelatency",
+			aliases: []string{"nl"},
+			short:   "Print the latency to peer Nodes",
+			long:    "Print the round-trip time of the last successful probe of each peer Node's gateway, the ratio of failed recent probes, and whether the peer Node is reachable. NodeLatencyMonitor must be enabled.",
+			example: `  Print the latency to all peer Nodes
+  $ antctl get nodelatency
+  Print the latency to a peer Node
+  $ antctl get nodelatency node1
+  Print the latency to all peer Nodes in JSON format
+  $ antctl get nodelatency -o json`,
+			agentEndpoint: &endpoint{
+				nonResourceEndpoint: &nonResourceEndpoint{
+					path: "/nodelatency",
+					params: []flagInfo{
+						{
+							name:  "node",
+							usage: "Name of a peer Node",
+							arg:   true,
+						},
+					},
+					outputType: multiple,
+				},
+			},
+			commandGroup:        get,
+			transformedResponse: reflect.TypeOf(nodelatency.Response{}),
+		},
+		{
+			use:     "flowexporter",
+			aliases: []string{"fe"},
+			short:   "Print the status of the flow exporter",
+			long:    "Print the status of the export of the flow records to each IPFIX collector: whether the collector is connected, the number of flow records sent to it and filtered out by its record filter, and the number of failures with the last error. FlowExporter must be enabled.",
+			example: `  Print the status of all the flow collectors
+  $ antctl get flowexporter
+  Print the status of a flow collector
+  $ antctl get flowexporter security
+  Print the status of all the flow collectors in JSON format
+  $ antctl get flowexporter -o json`,
+			agentEndpoint: &endpoint{
+				nonResourceEndpoint: &nonResourceEndpoint{
+					path: "/flowexporter",
+					params: []flagInfo{
+						{
+							name:  "collector",
+							usage: "Name of a flow collector",
+							arg:   true,
+						},
+					},
+					outputType: multiple,
+				},
+			},
+			commandGroup:        get,
+			transformedResponse: reflect.TypeOf(flowexporter.Response{}),
+		},
+		{
+			use:     "pipeline",
+			aliases: []string{"pl"},
+			short:   "Print the OVS pipeline",
+			long:    "Print the chain of OVS flow tables installed by the agent, including the next table, the action for table misses, the feature owning each table and its number of flows.",
+			example: `  Print the OVS pipeline
+  $ antctl get pipeline
+  Print the OVS pipeline in JSON format
+  $ antctl get pipeline -o json`,
+			agentEndpoint: &endpoint{
+				nonResourceEndpoint: &nonResourceEndpoint{
+					path:       "/pipeline",
+					outputType: multiple,
+				},
+				addonTransform: pipeline.Transform,
+			},
+			commandGroup:        get,
+			transformedResponse: reflect.TypeOf(agentpipeline.Table{}),
+		},
+		{
+			use:     "flowchanges",
+			aliases: []string{"flowchange", "fc"},
+			short:   "Print the latest OVS flow changes",
+			long:    "Print the latest OVS flow changes performed by the agent, with the object which triggered each of them, e.g. the NetworkPolicy, the Pod interface or the Service. It is useful to find out the cause of flow churn. Flow change tracking must be enabled with the --flow-change-tracking-size flag of antrea-agent.",
+			example: `  Print the latest OVS flow changes
+  $ antctl get flowchanges
+  Print the latest OVS flow changes in JSON format
+  $ antctl get flowchanges -o json`,
+			agentEndpoint: &endpoint{
+				nonResourceEndpoint: &nonResourceEndpoint{
+					path:       "/debug/flowchanges",
+					outputType: multiple,
+				},
+			},
+			commandGroup:        get,
+			transformedResponse: reflect.TypeOf(flowchanges.Response{}),
+		},
 		{
 			use:   "trace-packet",
 			short: "OVS packet tracing",
@@ -431,6 +649,35 @@ var CommandList = &commandList{
 			},
 			transformedResponse: reflect.TypeOf(controllernetworkpolicy.EndpointQueryResponse{}),
 		},
+		{
+			use:     "policyconflicts",
+			aliases: []string{"policyconflict"},
+			short:   "Report Antrea-native policy rules which may be shadowed for an endpoint.",
+			long:    "Report the Antrea-native policy rules applied to an endpoint which may be shadowed by a rule with higher precedence and the opposite action, or by the isolation of the endpoint by a K8s NetworkPolicy. The report is advisory: a shadowed rule may still take effect for the traffic not matched by the shadowing rule.",
+			example: `  Query the policy conflicts of a Pod
+  $ antctl query policyconflicts -p pod1 -n ns1
+`,
+			commandGroup: query,
+			controllerEndpoint: &endpoint{
+				nonResourceEndpoint: &nonResourceEndpoint{
+					path: "/policyconflicts",
+					params: []flagInfo{
+						{
+							name:      "namespace",
+							usage:     "Namespace of the endpoint (defaults to 'default')",
+							shorthand: "n",
+						},
+						{
+							name:      "pod",
+							usage:     "Name of a Pod endpoint",
+							shorthand: "p",
+						},
+					},
+					outputType: single,
+				},
+			},
+			transformedResponse: reflect.TypeOf(controllernetworkpolicy.PolicyConflictResponse{}),
+		},
 	},
 	rawCommands: []rawCommand{
 		{
@@ -443,17 +690,45 @@ var CommandList = &commandList{
 			supportAgent:      true,
 			supportController: true,
 		},
+		{
+			cobraCommand:      packetcapture.Command,
+			supportAgent:      false,
+			supportController: true,
+		},
 		{
 			cobraCommand:      proxy.Command,
 			supportAgent:      false,
 			supportController: true,
 		},
+		{
+			cobraCommand:      resync.Command,
+			supportAgent:      true,
+			supportController: true,
+		},
 		{
 			cobraCommand:      featuregates.Command,
 			supportAgent:      true,
 			supportController: true,
 			commandGroup:      get,
 		},
+		{
+			cobraCommand:      auditlogs.Command,
+			supportAgent:      true,
+			supportController: true,
+			commandGroup:      get,
+		},
+		{
+			cobraCommand:      checkconfig.Command,
+			supportAgent:      true,
+			supportController: true,
+			commandGroup:      check,
+		},
+		{
+			cobraCommand:      convertpolicy.Command,
+			supportAgent:      false,
+			supportController: true,
+			commandGroup:      convert,
+		},
 	},
 	codec: scheme.Codecs,
 }
@@ -17,13 +17,17 @@ package antctl
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/golang/mock/gomock"
 	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
+	"antrea.io/antrea/pkg/antctl/runtime"
 	antreaversion "antrea.io/antrea/pkg/version"
 )
 
@@ -82,3 +86,79 @@ func TestExtraArgs(t *testing.T) {
 	cmd.Execute()
 	assert.Contains(t, bufOut.String(), fmt.Sprintf("unknown command %q for", extraArg))
 }
+
+// TestNetworkPolicyFlows verifies that the flows of a NetworkPolicy are requested from the
+// "/policyflows" endpoint of the controller, and printed grouped by Node.
+func TestNetworkPolicyFlows(t *testing.T) {
+	mode := runtime.Mode
+	runtime.Mode = runtime.ModeController
+	defer func() { runtime.Mode = mode }()
+	var def *commandDefinition
+	for i := range CommandList.definitions {
+		if CommandList.definitions[i].use == "networkpolicy" {
+			def = &CommandList.definitions[i]
+		}
+	}
+	require.NotNil(t, def)
+	resp := `{"policy":"AntreaNetworkPolicy:ns1/anp1","nodes":{"node2":{"error":"the policy is not realized by the antrea-agent"},"node1":{"flows":["table=AntreaPolicyIngressRule, priority=44900,conj_id=1 actions=goto_table:IngressMetric"]}}}`
+
+	for _, tc := range []struct {
+		name           string
+		args           []string
+		expectRequest  bool
+		expectedOutput string
+	}{
+		{
+			name:          "table",
+			args:          []string{"uid1", "--flows", "--all-nodes"},
+			expectRequest: true,
+			expectedOutput: `Policy: AntreaNetworkPolicy:ns1/anp1
+
+Node: node1
+table=AntreaPolicyIngressRule, priority=44900,conj_id=1 actions=goto_table:IngressMetric
+
+Node: node2
+Error: the policy is not realized by the antrea-agent
+`,
+		},
+		{
+			name:           "json",
+			args:           []string{"uid1", "--flows", "--all-nodes", "-o", "json"},
+			expectRequest:  true,
+			expectedOutput: `"error": "the policy is not realized by the antrea-agent"`,
+		},
+		{
+			name:           "without all-nodes",
+			args:           []string{"uid1", "--flows"},
+			expectedOutput: "--flows must be used with --all-nodes",
+		},
+		{
+			name:           "without name",
+			args:           []string{"--flows", "--all-nodes"},
+			expectedOutput: "the name of the networkpolicy must be provided with --flows",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			client := NewMockAntctlClient(ctrl)
+			if tc.expectRequest {
+				client.EXPECT().request(gomock.Any()).DoAndReturn(func(opt *requestOption) (io.Reader, error) {
+					require.NotNil(t, opt.commandDefinition.controllerEndpoint)
+					assert.Equal(t, "/policyflows", opt.commandDefinition.controllerEndpoint.nonResourceEndpoint.path)
+					assert.Equal(t, map[string]string{"name": "uid1"}, opt.args)
+					return strings.NewReader(resp), nil
+				})
+			}
+			var bufOut bytes.Buffer
+			cmd := &cobra.Command{Use: def.use, SilenceUsage: true}
+			def.applyFlagsToCommand(cmd)
+			cmd.RunE = def.newCommandRunE(client, &bufOut)
+			cmd.SetOut(&bufOut)
+			cmd.SetErr(&bufOut)
+			cmd.SetArgs(tc.args)
+			cmd.Execute()
+			assert.Contains(t, bufOut.String(), tc.expectedOutput)
+		})
+	}
+}
@@ -20,18 +20,23 @@ import (
 	"fmt"
 	"io"
 	"net"
+	"net/http"
 	"net/url"
 	"time"
 
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
 	genericapiserver "k8s.io/apiserver/pkg/server"
 	"k8s.io/client-go/rest"
 
 	agentapiserver "antrea.io/antrea/pkg/agent/apiserver"
+	"antrea.io/antrea/pkg/agent/apiserver/handlers"
+	agentclient "antrea.io/antrea/pkg/agent/client"
 	"antrea.io/antrea/pkg/antctl/runtime"
 	"antrea.io/antrea/pkg/apis"
 	controllerapiserver "antrea.io/antrea/pkg/apiserver"
+	antrea "antrea.io/antrea/pkg/client/clientset/versioned"
 )
 
 // requestOption describes options to issue requests.
@@ -54,6 +59,9 @@ type requestOption struct {
 
 type AntctlClient interface {
 	request(opt *requestOption) (io.Reader, error)
+	// watch issues a watch request to the non-resource endpoint of the command. The returned
+	// stream is closed when ctx is cancelled.
+	watch(ctx context.Context, opt *requestOption) (io.ReadCloser, error)
 }
 
 // client issues requests to endpoints.
@@ -103,7 +111,31 @@ func (c *client) request(opt *requestOption) (io.Reader, error) {
 	return c.nonResourceRequest(e.nonResourceEndpoint, opt)
 }
 
-func (c *client) nonResourceRequest(e *nonResourceEndpoint, opt *requestOption) (io.Reader, error) {
+func (c *client) watch(ctx context.Context, opt *requestOption) (io.ReadCloser, error) {
+	var e *endpoint
+	if runtime.Mode == runtime.ModeAgent {
+		e = opt.commandDefinition.agentEndpoint
+	} else {
+		e = opt.commandDefinition.controllerEndpoint
+	}
+	if e.nonResourceEndpoint == nil || e.nonResourceEndpoint.watch == nil {
+		return nil, fmt.Errorf("%s does not support watch", opt.commandDefinition.use)
+	}
+	// The request timeout does not apply to a watch, which only ends when ctx is cancelled.
+	getter, err := c.nonResourceGetter(e.nonResourceEndpoint, opt, map[string]string{handlers.WatchParam: "true"})
+	if err != nil {
+		return nil, err
+	}
+	// The error is returned as is, so that the caller can tell whether the request should be
+	// retried from the status code.
+	return getter.Stream(ctx)
+}
+
+// nonResourceGetter returns a request to the non-resource endpoint, with the arguments of
+// the requestOption and the extra parameters provided as query parameters. It is a POST
+// request if one of the mutating parameters of the endpoint is provided, and a GET request
+// otherwise.
+func (c *client) nonResourceGetter(e *nonResourceEndpoint, opt *requestOption, extraParams map[string]string) (*rest.Request, error) {
 	kubeconfig, err := c.resolveKubeconfig(opt)
 	if err != nil {
 		return nil, err
@@ -120,9 +152,50 @@ func (c *client) nonResourceRequest(e *nonResourceEndpoint, opt *requestOption)
 	for k, v := range opt.args {
 		q.Set(k, v)
 	}
+	for k, v := range extraParams {
+		q.Set(k, v)
+	}
 	u.RawQuery = q.Encode()
-	getter := restClient.Get().RequestURI(u.RequestURI()).Timeout(opt.timeout)
-	result, err := getter.DoRaw(context.TODO())
+	return restClient.Verb(e.verb(opt.args)).RequestURI(u.RequestURI()), nil
+}
+
+// agentRequest issues the request to the non-resource endpoint of the Agent API with the client
+// provided to the external consumers of the API.
+func (c *client) agentRequest(e *nonResourceEndpoint, opt *requestOption) ([]byte, error) {
+	kubeconfig, err := c.resolveKubeconfig(opt)
+	if err != nil {
+		return nil, err
+	}
+	if opt.server != "" {
+		kubeconfig.Host = opt.server
+	}
+	agentClient, err := agentclient.New(kubeconfig, agentclient.Options{Timeout: opt.timeout})
+	if err != nil {
+		return nil, err
+	}
+	params := url.Values{}
+	for k, v := range opt.args {
+		params.Set(k, v)
+	}
+	if e.verb(opt.args) == http.MethodPost {
+		return agentClient.Post(context.TODO(), e.path, params)
+	}
+	return agentClient.Get(context.TODO(), e.path, params)
+}
+
+func (c *client) nonResourceRequest(e *nonResourceEndpoint, opt *requestOption) (io.Reader, error) {
+	var result []byte
+	var err error
+	if runtime.Mode == runtime.ModeAgent {
+		result, err = c.agentRequest(e, opt)
+	} else {
+		var getter *rest.Request
+		getter, err = c.nonResourceGetter(e, opt, nil)
+		if err != nil {
+			return nil, err
+		}
+		result, err = getter.Timeout(opt.timeout).DoRaw(context.TODO())
+	}
 	if err != nil {
 		statusErr, ok := err.(*errors.StatusError)
 		if !ok {
@@ -133,6 +206,26 @@ func (c *client) nonResourceRequest(e *nonResourceEndpoint, opt *requestOption)
 	return bytes.NewReader(result), nil
 }
 
+// apiAggregationHint retrieves the AntreaControllerInfo, which is stored as a CRD and therefore does
+// not depend on the K8s API aggregation layer, and generates a hint from it if antrea-controller runs
+// without the aggregation layer.
+func (c *client) apiAggregationHint(e *resourceEndpoint, opt *requestOption) string {
+	kubeconfig, err := runtime.ResolveKubeconfig(opt.kubeconfig)
+	if err != nil {
+		return ""
+	}
+	kubeconfig.Timeout = opt.timeout
+	antreaClientset, err := antrea.NewForConfig(kubeconfig)
+	if err != nil {
+		return ""
+	}
+	controllerInfo, err := antreaClientset.CrdV1beta1().AntreaControllerInfos().Get(context.TODO(), "antrea-controller", metav1.GetOptions{})
+	if err != nil {
+		return ""
+	}
+	return generateAPIAggregationHint(controllerInfo, opt.commandDefinition, e.groupVersionResource.Group)
+}
+
 func (c *client) resourceRequest(e *resourceEndpoint, opt *requestOption) (io.Reader, error) {
 	kubeconfig, err := c.resolveKubeconfig(opt)
 	if err != nil {
@@ -169,7 +262,15 @@ func (c *client) resourceRequest(e *resourceEndpoint, opt *requestOption) (io.Re
 	}
 	result := resGetter.Do(context.TODO())
 	if result.Error() != nil {
-		return nil, generateMessage(opt, result)
+		errMsg := generateMessage(opt, result)
+		// Out of the antrea-controller Pod, the APIs served by antrea-controller are accessed
+		// through the K8s API aggregation layer, which may not be available.
+		if runtime.Mode == runtime.ModeController && !runtime.InPod && opt.server == "" {
+			if hint := c.apiAggregationHint(e, opt); hint != "" {
+				return nil, fmt.Errorf("%w\n%s", errMsg, hint)
+			}
+		}
+		return nil, errMsg
 	}
 	raw, err := result.Raw()
 	if err != nil {
@@ -19,6 +19,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"net/http"
 	"reflect"
 	"sort"
 	"strconv"
@@ -34,6 +35,7 @@ import (
 	"antrea.io/antrea/pkg/antctl/runtime"
 	"antrea.io/antrea/pkg/antctl/transform/common"
 	"antrea.io/antrea/pkg/apis/controlplane/v1beta2"
+	"antrea.io/antrea/pkg/apiserver/handlers/policyflows"
 	"antrea.io/antrea/pkg/controller/networkpolicy"
 )
 
@@ -76,6 +78,8 @@ const (
 	flat commandGroup = iota
 	get
 	query
+	check
+	convert
 )
 
 var groupCommands = map[commandGroup]*cobra.Command{
@@ -89,6 +93,16 @@ var groupCommands = map[commandGroup]*cobra.Command{
 		Short: "Execute a user-provided query",
 		Long:  "Execute a user-provided query",
 	},
+	check: {
+		Use:   "check",
+		Short: "Check the configuration of a component",
+		Long:  "Check the configuration of a component",
+	},
+	convert: {
+		Use:   "convert",
+		Short: "Convert resources to Antrea resources",
+		Long:  "Convert resources to Antrea resources",
+	},
 }
 
 type endpointResponder interface {
@@ -142,6 +156,23 @@ type nonResourceEndpoint struct {
 	path       string
 	params     []flagInfo
 	outputType OutputType
+	// mutatingParams are the names of the params which change the state of the component.
+	// When one of them is provided, the request is sent with the POST method, so that it is
+	// authorized like the other requests which change the state of the component.
+	mutatingParams []string
+	// watch is set if the endpoint supports streaming updates with the "watch" query parameter.
+	watch *watchEndpoint
+}
+
+// watchEndpoint describes how to output the objects streamed by a non-resource endpoint in
+// watch mode.
+type watchEndpoint struct {
+	// frameType is the type of each JSON object in the stream.
+	frameType reflect.Type
+	// newFrameTransform returns a function which transforms each decoded frame to the object
+	// to output. It is called for each new connection, so the returned function can keep the
+	// state built from the previous frames (e.g. to apply watch events).
+	newFrameTransform func(opts map[string]string) func(frame interface{}) (interface{}, error)
 }
 
 func (e *nonResourceEndpoint) flags() []flagInfo {
@@ -152,6 +183,16 @@ func (e *nonResourceEndpoint) OutputType() OutputType {
 	return e.outputType
 }
 
+// verb returns the HTTP method of the request to the endpoint with the provided args.
+func (e *nonResourceEndpoint) verb(args map[string]string) string {
+	for _, param := range e.mutatingParams {
+		if args[param] != "" {
+			return http.MethodPost
+		}
+	}
+	return http.MethodGet
+}
+
 // endpoint is used to specified the API for an antctl running against antrea-controller.
 type endpoint struct {
 	resourceEndpoint    *resourceEndpoint
@@ -165,6 +206,11 @@ type endpoint struct {
 	// function. This is useful if a command still needs to output useful
 	// information in case of error.
 	requestErrorFallback func() (io.Reader, error)
+	// flowsPath is the path of the non-resource endpoint which returns the OpenFlow flows
+	// realizing the object on each Node it spans, as a policyflows.Response. When it is set, the
+	// "flows" and "all-nodes" flags are added to the command, and the endpoint is requested
+	// instead of the one of the command when "flows" is provided.
+	flowsPath string
 }
 
 // flagInfo represents a command-line flag that can be provided when invoking an antctl command.
@@ -243,6 +289,36 @@ func (cd *commandDefinition) getEndpoint() endpointResponder {
 	return nil
 }
 
+// getWatchEndpoint returns the watchEndpoint of the command in current mode, or nil if the
+// command does not support watch.
+func (cd *commandDefinition) getWatchEndpoint() *watchEndpoint {
+	var e *endpoint
+	if runtime.Mode == runtime.ModeAgent {
+		e = cd.agentEndpoint
+	} else {
+		e = cd.controllerEndpoint
+	}
+	if e == nil || e.nonResourceEndpoint == nil {
+		return nil
+	}
+	return e.nonResourceEndpoint.watch
+}
+
+// getFlowsPath returns the flowsPath of the endpoint of the command in current mode, or an empty
+// string if the command cannot print the flows of the object.
+func (cd *commandDefinition) getFlowsPath() string {
+	if runtime.Mode == runtime.ModeAgent {
+		if cd.agentEndpoint != nil {
+			return cd.agentEndpoint.flowsPath
+		}
+	} else if runtime.Mode == runtime.ModeController {
+		if cd.controllerEndpoint != nil {
+			return cd.controllerEndpoint.flowsPath
+		}
+	}
+	return ""
+}
+
 func (cd *commandDefinition) getRequestErrorFallback() func() (io.Reader, error) {
 	if runtime.Mode == runtime.ModeAgent {
 		if cd.agentEndpoint != nil {
@@ -314,7 +390,7 @@ func (cd *commandDefinition) validate() []error {
 		errs = append(errs, fmt.Errorf("%s: command for controller must define one endpoint", cd.use))
 	}
 	empty := struct{}{}
-	existingFlags := map[string]struct{}{"output": empty, "help": empty, "kubeconfig": empty, "timeout": empty, "verbose": empty}
+	existingFlags := map[string]struct{}{"output": empty, "help": empty, "kubeconfig": empty, "timeout": empty, "verbose": empty, "watch": empty, "flows": empty, "all-nodes": empty}
 	if endpoint := cd.getEndpoint(); endpoint != nil {
 		for _, f := range endpoint.flags() {
 			if len(f.name) == 0 {
@@ -419,6 +495,12 @@ func respTransformer(obj interface{}) (interface{}, error) {
 	return target, nil
 }
 
+// isEmptyList returns whether obj is a slice with no element.
+func isEmptyList(obj interface{}) bool {
+	v := reflect.ValueOf(obj)
+	return v.Kind() == reflect.Slice && v.Len() == 0
+}
+
 // tableOutputForGetCommands formats the table output for "get" commands.
 func (cd *commandDefinition) tableOutputForGetCommands(obj interface{}, writer io.Writer) error {
 	var list []common.TableOutput
@@ -581,13 +663,13 @@ func (cd *commandDefinition) tableOutputForQueryEndpoint(obj interface{}, writer
 		// transform applied policies to string representation
 		policies := make([][]string, 0)
 		for _, policy := range endpoint.Policies {
-			policyStr := []string{policy.Name, policy.Namespace, string(policy.UID)}
+			policyStr := []string{policy.Name, policy.Namespace, string(policy.UID), policy.ServiceAccount}
 			policies = append(policies, policyStr)
 		}
 		// transform egress and ingress rules to string representation
 		egress, ingress := make([][]string, 0), make([][]string, 0)
 		for _, rule := range endpoint.Rules {
-			ruleStr := []string{rule.Name, rule.Namespace, strconv.Itoa(rule.RuleIndex), string(rule.UID)}
+			ruleStr := []string{rule.Name, rule.Namespace, strconv.Itoa(rule.RuleIndex), string(rule.UID), rule.ServiceAccount}
 			if rule.Direction == v1beta2.DirectionIn {
 				ingress = append(ingress, ruleStr)
 			} else if rule.Direction == v1beta2.DirectionOut {
@@ -604,7 +686,7 @@ func (cd *commandDefinition) tableOutputForQueryEndpoint(obj interface{}, writer
 		if nonEmpty {
 			policyLabel = []string{"Applied Policies:"}
 		}
-		if err := constructSection([][]string{policyLabel}, [][]string{{"Name", "Namespace", "UID"}}, policies, nonEmpty); err != nil {
+		if err := constructSection([][]string{policyLabel}, [][]string{{"Name", "Namespace", "UID", "ServiceAccount"}}, policies, nonEmpty); err != nil {
 			return err
 		}
 		// egress rules
@@ -613,7 +695,7 @@ func (cd *commandDefinition) tableOutputForQueryEndpoint(obj interface{}, writer
 		if nonEmpty {
 			egressLabel = []string{"Egress Rules:"}
 		}
-		if err := constructSection([][]string{egressLabel}, [][]string{{"Name", "Namespace", "Index", "UID"}}, egress, nonEmpty); err != nil {
+		if err := constructSection([][]string{egressLabel}, [][]string{{"Name", "Namespace", "Index", "UID", "ServiceAccount"}}, egress, nonEmpty); err != nil {
 			return err
 		}
 		// ingress rules
@@ -622,13 +704,89 @@ func (cd *commandDefinition) tableOutputForQueryEndpoint(obj interface{}, writer
 		if nonEmpty {
 			ingressLabel = []string{"Ingress Rules:"}
 		}
-		if err := constructSection([][]string{ingressLabel}, [][]string{{"Name", "Namespace", "Index", "UID"}}, ingress, nonEmpty); err != nil {
+		if err := constructSection([][]string{ingressLabel}, [][]string{{"Name", "Namespace", "Index", "UID", "ServiceAccount"}}, ingress, nonEmpty); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
+// tableOutputForPolicyFlows prints the flows of a policy grouped by Node, in the order of the Node
+// names. The Nodes whose flows could not be retrieved are printed with the error.
+func tableOutputForPolicyFlows(resp *policyflows.Response, writer io.Writer) error {
+	var buffer bytes.Buffer
+	buffer.WriteString("Policy: " + resp.Policy + "\n")
+	if len(resp.Nodes) == 0 {
+		buffer.WriteString("Nodes: None\n")
+	}
+	nodeNames := make([]string, 0, len(resp.Nodes))
+	for nodeName := range resp.Nodes {
+		nodeNames = append(nodeNames, nodeName)
+	}
+	sort.Strings(nodeNames)
+	for _, nodeName := range nodeNames {
+		nodeFlows := resp.Nodes[nodeName]
+		buffer.WriteString("\nNode: " + nodeName + "\n")
+		if nodeFlows.Error != "" {
+			buffer.WriteString("Error: " + nodeFlows.Error + "\n")
+			continue
+		}
+		if len(nodeFlows.Flows) == 0 {
+			buffer.WriteString("<NONE>\n")
+		}
+		for _, flow := range nodeFlows.Flows {
+			buffer.WriteString(flow + "\n")
+		}
+	}
+	if _, err := io.Copy(writer, &buffer); err != nil {
+		return fmt.Errorf("error when copy output into writer: %w", err)
+	}
+	return nil
+}
+
+// tableOutputForQueryPolicyConflicts prints the policy conflicts of an endpoint as a table,
+// preceded by a reminder that the conflicts are advisory.
+func (cd *commandDefinition) tableOutputForQueryPolicyConflicts(obj interface{}, writer io.Writer) error {
+	policyConflictResponse := obj.(*networkpolicy.PolicyConflictResponse)
+	var buffer bytes.Buffer
+	buffer.WriteString("Endpoint " + policyConflictResponse.Namespace + "/" + policyConflictResponse.Name + "\n")
+	if policyConflictResponse.Advisory {
+		buffer.WriteString("Note: conflicts are advisory, the shadowed rules may still take effect for some traffic.\n")
+	}
+	if len(policyConflictResponse.Conflicts) == 0 {
+		buffer.WriteString("Policy Conflicts: None\n")
+	} else {
+		buffer.WriteString("Policy Conflicts:\n")
+	}
+	if _, err := io.Copy(writer, &buffer); err != nil {
+		return fmt.Errorf("error when copy output into writer: %w", err)
+	}
+	if len(policyConflictResponse.Conflicts) == 0 {
+		return nil
+	}
+	ruleToString := func(rule networkpolicy.ConflictRule) string {
+		policy := rule.Name
+		if rule.Namespace != "" {
+			policy = rule.Namespace + "/" + policy
+		}
+		if rule.Action == networkpolicy.ConflictActionIsolation {
+			return fmt.Sprintf("%s:%s", rule.Type, policy)
+		}
+		return fmt.Sprintf("%s:%s[%d]", rule.Type, policy, rule.RuleIndex)
+	}
+	rows := [][]string{{"DIRECTION", "SHADOWED-RULE", "ACTION", "SHADOWING-RULE", "ACTION"}}
+	for _, conflict := range policyConflictResponse.Conflicts {
+		rows = append(rows, []string{
+			string(conflict.Direction),
+			ruleToString(conflict.ShadowedRule), conflict.ShadowedRule.Action,
+			ruleToString(conflict.ShadowingRule), conflict.ShadowingRule.Action,
+		})
+	}
+	numRows, numCols := len(rows), len(rows[0])
+	widths := getColumnWidths(numRows, numCols, rows)
+	return constructTable(numRows, numCols, widths, rows, writer)
+}
+
 func (cd *commandDefinition) tableOutput(obj interface{}, writer io.Writer) error {
 	target, err := respTransformer(obj)
 	if err != nil {
@@ -745,10 +903,17 @@ func (cd *commandDefinition) output(resp io.Reader, writer io.Writer, ft formatt
 		return cd.yamlOutput(obj, writer)
 	case tableFormatter:
 		if cd.commandGroup == get {
+			// Tell a Pod known to have no object from an unknown Pod, for which an error is returned.
+			if pod := args["pod"]; pod != "" && isEmptyList(obj) {
+				_, err = fmt.Fprintf(writer, "No %s found for Pod %s/%s\n", cd.use, args["namespace"], pod)
+				return err
+			}
 			return cd.tableOutputForGetCommands(obj, writer)
 		} else if cd.commandGroup == query {
 			if cd.controllerEndpoint.nonResourceEndpoint.path == "/endpoint" {
 				return cd.tableOutputForQueryEndpoint(obj, writer)
+			} else if cd.controllerEndpoint.nonResourceEndpoint.path == "/policyconflicts" {
+				return cd.tableOutputForQueryPolicyConflicts(obj, writer)
 			}
 		} else {
 			return cd.tableOutput(obj, writer)
@@ -817,14 +982,22 @@ func (cd *commandDefinition) newCommandRunE(c AntctlClient, out io.Writer) func(
 		if err != nil {
 			return err
 		}
-
-		resp, requestErr := c.request(&requestOption{
+		opt := &requestOption{
 			commandDefinition: cd,
 			kubeconfig:        kubeconfigPath,
 			args:              argMap,
 			timeout:           timeout,
 			server:            server,
-		})
+		}
+		if watch, _ := cmd.Flags().GetBool("watch"); watch {
+			return cd.watch(c, opt, out, formatterType(outputFormat))
+		}
+		if showFlows, _ := cmd.Flags().GetBool("flows"); showFlows {
+			allNodes, _ := cmd.Flags().GetBool("all-nodes")
+			return cd.flows(c, opt, allNodes, out, formatterType(outputFormat))
+		}
+
+		resp, requestErr := c.request(opt)
 		if requestErr != nil {
 			fallback := cd.getRequestErrorFallback()
 			if fallback == nil {
@@ -843,6 +1016,50 @@ func (cd *commandDefinition) newCommandRunE(c AntctlClient, out io.Writer) func(
 	}
 }
 
+// flows requests the OpenFlow flows realizing the object named in the args from the endpoint at
+// flowsPath, and outputs them grouped by Node.
+func (cd *commandDefinition) flows(c AntctlClient, opt *requestOption, allNodes bool, out io.Writer, ft formatterType) error {
+	if !allNodes {
+		return fmt.Errorf("--flows must be used with --all-nodes")
+	}
+	name := opt.args["name"]
+	if name == "" {
+		return fmt.Errorf("the name of the %s must be provided with --flows", cd.use)
+	}
+	flowsEndpoint := &endpoint{nonResourceEndpoint: &nonResourceEndpoint{path: cd.getFlowsPath(), outputType: single}}
+	flowsCD := &commandDefinition{
+		use:                 cd.use,
+		commandGroup:        cd.commandGroup,
+		transformedResponse: reflect.TypeOf(policyflows.Response{}),
+	}
+	if runtime.Mode == runtime.ModeAgent {
+		flowsCD.agentEndpoint = flowsEndpoint
+	} else {
+		flowsCD.controllerEndpoint = flowsEndpoint
+	}
+	flowsOpt := *opt
+	flowsOpt.commandDefinition = flowsCD
+	flowsOpt.args = map[string]string{"name": name}
+	resp, err := c.request(&flowsOpt)
+	if err != nil {
+		return err
+	}
+	var flowsResp policyflows.Response
+	if err := json.NewDecoder(resp).Decode(&flowsResp); err != nil {
+		return fmt.Errorf("error when decoding response: %w", err)
+	}
+	switch ft {
+	case jsonFormatter:
+		return flowsCD.jsonOutput(&flowsResp, out)
+	case yamlFormatter:
+		return flowsCD.yamlOutput(&flowsResp, out)
+	case tableFormatter:
+		return tableOutputForPolicyFlows(&flowsResp, out)
+	default:
+		return fmt.Errorf("unsupported format type: %v", ft)
+	}
+}
+
 // applyFlagsToCommand sets up args and flags for the command.
 func (cd *commandDefinition) applyFlagsToCommand(cmd *cobra.Command) {
 	var hasFlag bool
@@ -866,6 +1083,13 @@ func (cd *commandDefinition) applyFlagsToCommand(cmd *cobra.Command) {
 	} else {
 		cmd.Flags().StringP("output", "o", "yaml", "output format: json|table|yaml")
 	}
+	if cd.getWatchEndpoint() != nil {
+		cmd.Flags().BoolP("watch", "w", false, "After printing the current state, keep printing updates until interrupted")
+	}
+	if cd.getFlowsPath() != "" {
+		cmd.Flags().Bool("flows", false, fmt.Sprintf("Print the OpenFlow flows realizing the %s, grouped by Node. --all-nodes must be provided", cd.use))
+		cmd.Flags().Bool("all-nodes", false, fmt.Sprintf("Retrieve the flows from the antrea-agents of all the Nodes the %s spans", cd.use))
+	}
 }
 
 // applyExampleToCommand generates examples according to the commandDefinition.
@@ -39,6 +39,7 @@ import (
 	"antrea.io/antrea/pkg/antctl/transform/networkpolicy"
 	cpv1beta "antrea.io/antrea/pkg/apis/controlplane/v1beta2"
 	"antrea.io/antrea/pkg/apis/crd/v1beta1"
+	"antrea.io/antrea/pkg/querier"
 )
 
 type Foobar struct {
@@ -127,8 +128,8 @@ kube-system/antrea-controller-55b9bcd59f-h9ll4 node-control-plane Healthy 1
 					},
 				},
 			},
-			expected: `POD                        NODE        STATUS  NODE-SUBNET                   NETWORK-POLICIES ADDRESS-GROUPS APPLIED-TO-GROUPS LOCAL-PODS
-kube-system/antrea-agent-0 node-worker Healthy 192.168.1.0/24,192.168.1.1/24 1                1              2                 3         
+			expected: `POD                        NODE        STATUS  VERSION NODE-SUBNET                   NETWORK-POLICIES ADDRESS-GROUPS APPLIED-TO-GROUPS LOCAL-PODS
+kube-system/antrea-agent-0 node-worker Healthy v0.4.0  192.168.1.0/24,192.168.1.1/24 1                1              2                 3         
 `,
 		},
 		{
@@ -155,7 +156,9 @@ foo2
 				{
 					NetworkPolicy: &cpv1beta.NetworkPolicy{
 						ObjectMeta: metav1.ObjectMeta{
-							Name: "6001549b-ba63-4752-8267-30f52b4332db",
+							Name:        "6001549b-ba63-4752-8267-30f52b4332db",
+							Generation:  1,
+							Annotations: map[string]string{querier.LastUpdateTimeAnnotation: "2021-06-01T10:00:00Z"},
 						},
 						AppliedToGroups: []string{"32ef631b-6817-5a18-86eb-93f4abf0467c", "c4c59cfe-9160-5de5-a85b-01a58d11963e"},
 						Rules: []cpv1beta.NetworkPolicyRule{
@@ -175,7 +178,8 @@ foo2
 				{
 					NetworkPolicy: &cpv1beta.NetworkPolicy{
 						ObjectMeta: metav1.ObjectMeta{
-							Name: "880db7e8-fc2a-4030-aefe-09afc5f341ad",
+							Name:       "880db7e8-fc2a-4030-aefe-09afc5f341ad",
+							Generation: 2,
 						},
 						TierPriority:    &AntreaPolicyTierPriority,
 						Priority:        &AntreaPolicyPriority,
@@ -199,9 +203,9 @@ foo2
 					},
 				},
 			},
-			expected: `NAME                                 APPLIED-TO                                       RULES SOURCE                                TIER-PRIORITY PRIORITY
-6001549b-ba63-4752-8267-30f52b4332db 32ef631b-6817-5a18-86eb-93f4abf0467c + 1 more... 1     K8sNetworkPolicy:default/allow-all    <NONE>        <NONE>  
-880db7e8-fc2a-4030-aefe-09afc5f341ad 32ef631b-6817-5a18-86eb-93f4abf0467c             2     AntreaNetworkPolicy:default/allow-all 250           1       
+			expected: `NAME                                 APPLIED-TO                                       RULES SOURCE                                TIER-PRIORITY PRIORITY GENERATION LAST-UPDATE         
+6001549b-ba63-4752-8267-30f52b4332db 32ef631b-6817-5a18-86eb-93f4abf0467c + 1 more... 1     K8sNetworkPolicy:default/allow-all    <NONE>        <NONE>   1          2021-06-01T10:00:00Z
+880db7e8-fc2a-4030-aefe-09afc5f341ad 32ef631b-6817-5a18-86eb-93f4abf0467c             2     AntreaNetworkPolicy:default/allow-all 250           1        2          <NONE>              
 `,
 		},
 		{
@@ -213,15 +217,16 @@ foo2
 						{IP: "127.0.0.1"}, {IP: "192.168.0.1"}, {IP: "127.0.0.2"},
 						{IP: "127.0.0.3"}, {IP: "10.0.0.3"}, {IP: "127.0.0.5"}, {IP: "127.0.0.6"},
 					},
+					LastUpdateTime: "2021-06-01T10:00:00Z",
 				},
 				{
 					Name: "GroupName2",
 					Pods: []common.GroupMember{},
 				},
 			},
-			expected: `NAME       POD-IPS                                           
-GroupName1 10.0.0.3,127.0.0.1,127.0.0.2,127.0.0.3 + 2 more...
-GroupName2 <NONE>                                            
+			expected: `NAME       POD-IPS                                            LAST-UPDATE         
+GroupName1 10.0.0.3,127.0.0.1,127.0.0.2,127.0.0.3 + 2 more... 2021-06-01T10:00:00Z
+GroupName2 <NONE>                                             <NONE>              
 `,
 		},
 		{
@@ -239,8 +244,8 @@ GroupName2 <NONE>
 					}},
 				},
 			},
-			expected: `NAME      PODS                                           
-GroupName PodNamespace/nginx-6db489d4b7-324rc + 1 more...
+			expected: `NAME      PODS                                            LAST-UPDATE
+GroupName PodNamespace/nginx-6db489d4b7-324rc + 1 more... <NONE>     
 `,
 		},
 		{
@@ -254,8 +259,8 @@ GroupName PodNamespace/nginx-6db489d4b7-324rc + 1 more...
 				Name: "GroupName",
 				Pods: []common.GroupMember{},
 			},
-			expected: `NAME      PODS  
-GroupName <NONE>
+			expected: `NAME      PODS   LAST-UPDATE
+GroupName <NONE> <NONE>     
 `,
 		},
 		{
@@ -26,6 +26,8 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/serializer"
 	"k8s.io/klog/v2"
 
+	"antrea.io/antrea/pkg/antctl/raw/checkconfig"
+	"antrea.io/antrea/pkg/antctl/raw/convertpolicy"
 	"antrea.io/antrea/pkg/antctl/runtime"
 )
 
@@ -143,6 +145,15 @@ func (cl *commandList) GetDebugCommands(mode string) [][]string {
 			// cannot be used as is in e2e tests.
 			continue
 		}
+		if cmd.cobraCommand == checkconfig.Command && mode == runtime.ModeController {
+			// There is no default configuration file to check in
+			// the Controller Pod.
+			continue
+		}
+		if cmd.cobraCommand == convertpolicy.Command {
+			// There is no file to convert in the Pods.
+			continue
+		}
 		if mode == runtime.ModeController && cmd.supportController ||
 			mode == runtime.ModeAgent && cmd.supportAgent {
 			var currentCommand []string
@@ -18,8 +18,13 @@ import (
 	"fmt"
 	"net/http"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/client-go/rest"
+
+	crdv1beta1 "antrea.io/antrea/pkg/apis/crd/v1beta1"
+	"antrea.io/antrea/pkg/apis/stats"
+	legacystats "antrea.io/antrea/pkg/legacyapis/stats"
 )
 
 func generateMessage(opt *requestOption, result rest.Result) error {
@@ -32,7 +37,7 @@ func generateMessage(opt *requestOption, result rest.Result) error {
 }
 
 func generateMessageForStatusErr(cd *commandDefinition, args map[string]string, statusErr *errors.StatusError) error {
-	if statusErr.ErrStatus.Details.Causes == nil || len(statusErr.ErrStatus.Details.Causes[0].Message) == 0 {
+	if statusErr.ErrStatus.Details == nil || statusErr.ErrStatus.Details.Causes == nil || len(statusErr.ErrStatus.Details.Causes[0].Message) == 0 {
 		return generate(cd, args, int(statusErr.ErrStatus.Code), statusErr.Error())
 	}
 	return fmt.Errorf("%s: %s", statusErr.ErrStatus.Reason, statusErr.ErrStatus.Details.Causes[0].Message)
@@ -56,3 +61,19 @@ func generate(cd *commandDefinition, args map[string]string, code int, err strin
 		return fmt.Errorf("Unknown error")
 	}
 }
+
+// generateAPIAggregationHint returns a hint explaining why the command is unavailable if
+// antrea-controller reports that it runs without the K8s API aggregation layer, in which case the
+// APIs it serves cannot be reached through the K8s apiserver. It returns an empty string otherwise.
+func generateAPIAggregationHint(controllerInfo *crdv1beta1.AntreaControllerInfo, cd *commandDefinition, group string) string {
+	for _, condition := range controllerInfo.ControllerConditions {
+		if condition.Type != crdv1beta1.ControllerAPIAggregationAvailable || condition.Status != corev1.ConditionFalse {
+			continue
+		}
+		if group == stats.GroupName || group == legacystats.GroupName {
+			return fmt.Sprintf("antrea-controller is running without the K8s API aggregation layer, \"%s\" is unavailable because the stats API is disabled", cd.use)
+		}
+		return fmt.Sprintf("antrea-controller is running without the K8s API aggregation layer, \"%s\" is only available from within the antrea-controller Pod (using \"kubectl exec\")", cd.use)
+	}
+	return ""
+}
@@ -19,6 +19,9 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+
+	crdv1beta1 "antrea.io/antrea/pkg/apis/crd/v1beta1"
 )
 
 func TestGenerate(t *testing.T) {
@@ -67,3 +70,50 @@ func TestGenerate(t *testing.T) {
 		assert.Equal(t, tc.expected, generated.Error())
 	}
 }
+
+func TestGenerateAPIAggregationHint(t *testing.T) {
+	cd := &commandDefinition{use: "foo"}
+	controllerInfo := func(status corev1.ConditionStatus) *crdv1beta1.AntreaControllerInfo {
+		return &crdv1beta1.AntreaControllerInfo{
+			ControllerConditions: []crdv1beta1.ControllerCondition{
+				{Type: crdv1beta1.ControllerHealthy, Status: corev1.ConditionTrue},
+				{Type: crdv1beta1.ControllerAPIAggregationAvailable, Status: status},
+			},
+		}
+	}
+	for _, tc := range []struct {
+		name           string
+		controllerInfo *crdv1beta1.AntreaControllerInfo
+		group          string
+		expected       string
+	}{
+		{
+			name:           "aggregation available",
+			controllerInfo: controllerInfo(corev1.ConditionTrue),
+			group:          "controlplane.antrea.io",
+			expected:       "",
+		},
+		{
+			name:           "no condition",
+			controllerInfo: &crdv1beta1.AntreaControllerInfo{},
+			group:          "controlplane.antrea.io",
+			expected:       "",
+		},
+		{
+			name:           "aggregation unavailable",
+			controllerInfo: controllerInfo(corev1.ConditionFalse),
+			group:          "controlplane.antrea.io",
+			expected:       `antrea-controller is running without the K8s API aggregation layer, "foo" is only available from within the antrea-controller Pod (using "kubectl exec")`,
+		},
+		{
+			name:           "stats API disabled",
+			controllerInfo: controllerInfo(corev1.ConditionFalse),
+			group:          "stats.antrea.io",
+			expected:       `antrea-controller is running without the K8s API aggregation layer, "foo" is unavailable because the stats API is disabled`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, generateAPIAggregationHint(tc.controllerInfo, cd, tc.group))
+		})
+	}
+}
@@ -20,6 +20,7 @@
 package antctl
 
 import (
+	context "context"
 	gomock "github.com/golang/mock/gomock"
 	io "io"
 	reflect "reflect"
@@ -62,3 +63,18 @@ func (mr *MockAntctlClientMockRecorder) request(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "request", reflect.TypeOf((*MockAntctlClient)(nil).request), arg0)
 }
+
+// watch mocks base method
+func (m *MockAntctlClient) watch(arg0 context.Context, arg1 *requestOption) (io.ReadCloser, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "watch", arg0, arg1)
+	ret0, _ := ret[0].(io.ReadCloser)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// watch indicates an expected call of watch
+func (mr *MockAntctlClientMockRecorder) watch(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "watch", reflect.TypeOf((*MockAntctlClient)(nil).watch), arg0, arg1)
+}
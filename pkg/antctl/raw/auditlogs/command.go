@@ -0,0 +1,358 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auditlogs
+
+import (
+	"container/heap"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/klog/v2"
+
+	"antrea.io/antrea/pkg/agent/apiserver/handlers/auditlogs"
+	"antrea.io/antrea/pkg/antctl/raw"
+	"antrea.io/antrea/pkg/antctl/runtime"
+	antrea "antrea.io/antrea/pkg/client/clientset/versioned"
+	"antrea.io/antrea/pkg/util/k8s"
+)
+
+// Command is the auditlogs command implementation.
+var Command *cobra.Command
+
+var option = &struct {
+	since         string
+	until         string
+	policy        string
+	disposition   string
+	tail          int
+	labelSelector string
+	output        string
+}{}
+
+var remoteControllerExample = strings.Trim(`
+  Get the audit logs of all Nodes
+  $ antctl get auditlogs
+  Get the drops of a policy in the last 10 minutes across the cluster
+  $ antctl get auditlogs --policy default/test-anp --disposition Drop --since 10m
+  Get the last 20 drops of each Node, from the entries kept in memory by the agents
+  $ antctl get auditlogs --disposition Drop --tail 20
+  Get the audit logs of specific Nodes filtered by name, with support for wildcard expressions
+  $ antctl get auditlogs '*worker*'
+  Get the audit logs of specific Nodes filtered by label selectors, in a time range, as JSON lines
+  $ antctl get auditlogs -l kubernetes.io/os=linux --since 2021-05-01T10:00:00Z --until 2021-05-01T11:00:00Z -o json
+`, "\n")
+
+func init() {
+	Command = &cobra.Command{
+		Use:   "auditlogs",
+		Short: "Get the audit logs of Antrea-native policies",
+	}
+	Command.Flags().StringVar(&option.since, "since", "", "only return entries newer than a relative duration like 10m, or an RFC3339 time")
+	Command.Flags().StringVar(&option.until, "until", "", "only return entries older than a relative duration like 10m, or an RFC3339 time")
+	Command.Flags().StringVar(&option.policy, "policy", "", "only return entries of the policy, e.g. AntreaNetworkPolicy:default/test-anp or default/test-anp")
+	Command.Flags().StringVar(&option.disposition, "disposition", "", "only return entries with the disposition, e.g. Allow, Drop or Reject")
+	Command.Flags().IntVar(&option.tail, "tail", 0, "only return the last entries of each Node, read from the recent entries kept in memory by the agent instead of the log files")
+	Command.Flags().StringVarP(&option.output, "output", "o", "table", "output format: table or json")
+	if runtime.Mode == runtime.ModeAgent {
+		Command.RunE = agentRunE
+		Command.Long = "Get the audit logs of Antrea-native policies written by the current Antrea agent."
+	} else if runtime.Mode == runtime.ModeController && runtime.InPod {
+		Command.RunE = controllerLocalRunE
+		Command.Long = "Get the audit logs of Antrea-native policies written by the Antrea agents."
+	} else if runtime.Mode == runtime.ModeController && !runtime.InPod {
+		Command.Use += " [nodeName]"
+		Command.Long = "Get the audit logs of Antrea-native policies written by the Antrea agents. The entries of all the selected Nodes are merged by timestamp."
+		Command.Example = remoteControllerExample
+		Command.Flags().StringVarP(&option.labelSelector, "label-selector", "l", "", "selector (label query) to filter Nodes, supports '=', '==', and '!='.(e.g. -l key1=value1,key2=value2)")
+		Command.RunE = controllerRemoteRunE
+	}
+}
+
+// parseTime parses a relative duration in the past, or an RFC3339 time.
+func parseTime(value string, now time.Time) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(-d).Format(time.RFC3339), nil
+	}
+	if _, err := time.Parse(time.RFC3339, value); err != nil {
+		return "", fmt.Errorf("invalid time %q, it must be a duration like 10m or an RFC3339 time", value)
+	}
+	return value, nil
+}
+
+func requestURI() (string, error) {
+	now := time.Now()
+	since, err := parseTime(option.since, now)
+	if err != nil {
+		return "", err
+	}
+	until, err := parseTime(option.until, now)
+	if err != nil {
+		return "", err
+	}
+	query := url.Values{}
+	for param, value := range map[string]string{"since": since, "until": until, "policy": option.policy, "disposition": option.disposition} {
+		if value != "" {
+			query.Set(param, value)
+		}
+	}
+	path := "/auditlogs"
+	if option.tail > 0 {
+		path = "/auditlogs/recent"
+		query.Set("limit", strconv.Itoa(option.tail))
+	} else if option.tail < 0 {
+		return "", fmt.Errorf("invalid tail %d, it must be a positive number", option.tail)
+	}
+	u := url.URL{Path: path, RawQuery: query.Encode()}
+	return u.RequestURI(), nil
+}
+
+func agentRunE(cmd *cobra.Command, _ []string) error {
+	kubeconfig, err := raw.ResolveKubeconfig(cmd)
+	if err != nil {
+		return err
+	}
+	kubeconfig.GroupVersion = &schema.GroupVersion{Group: "", Version: ""}
+	raw.SetupKubeconfig(kubeconfig)
+	client, err := rest.RESTClientFor(kubeconfig)
+	if err != nil {
+		return fmt.Errorf("error when creating rest client: %w", err)
+	}
+	return getAuditLogs(map[string]*rest.RESTClient{"": client}, os.Stdout)
+}
+
+func controllerLocalRunE(_ *cobra.Command, _ []string) error {
+	return fmt.Errorf("getting the audit logs from the controller Pod is not supported, run the command out-of-cluster or in an agent Pod")
+}
+
+func controllerRemoteRunE(cmd *cobra.Command, args []string) error {
+	kubeconfig, err := raw.ResolveKubeconfig(cmd)
+	if err != nil {
+		return err
+	}
+	kubeconfig.GroupVersion = &schema.GroupVersion{Group: "", Version: ""}
+	restconfigTmpl := rest.CopyConfig(kubeconfig)
+	raw.SetupKubeconfig(restconfigTmpl)
+
+	k8sClientset, antreaClientset, err := raw.SetupClients(kubeconfig)
+	if err != nil {
+		return fmt.Errorf("failed to create clientset: %w", err)
+	}
+	nameFilter := "*"
+	if len(args) == 1 {
+		nameFilter = args[0]
+	}
+	agentClients, err := createAgentClients(k8sClientset, antreaClientset, restconfigTmpl, nameFilter)
+	if err != nil {
+		return fmt.Errorf("error when creating agent clients: %w", err)
+	}
+	if len(agentClients) == 0 {
+		return fmt.Errorf("no matched Nodes found to get audit logs")
+	}
+	return getAuditLogs(agentClients, os.Stdout)
+}
+
+// createAgentClients returns the clients of the agents running on the Nodes matching the name
+// filter and the label selector, indexed by Node name.
+func createAgentClients(k8sClientset kubernetes.Interface, antreaClientset antrea.Interface, cfgTmpl *rest.Config, nameFilter string) (map[string]*rest.RESTClient, error) {
+	clients := map[string]*rest.RESTClient{}
+	nodeAgentInfoMap := map[string]string{}
+	agentInfoList, err := antreaClientset.CrdV1beta1().AntreaAgentInfos().List(context.TODO(), metav1.ListOptions{ResourceVersion: "0"})
+	if err != nil {
+		return nil, err
+	}
+	for _, agentInfo := range agentInfoList.Items {
+		nodeAgentInfoMap[agentInfo.NodeRef.Name] = fmt.Sprint(agentInfo.APIPort)
+	}
+	nodeList, err := k8sClientset.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{LabelSelector: option.labelSelector, ResourceVersion: "0"})
+	if err != nil {
+		return nil, err
+	}
+	for i := range nodeList.Items {
+		node := nodeList.Items[i]
+		if hit, _ := filepath.Match(nameFilter, node.Name); !hit {
+			continue
+		}
+		port, ok := nodeAgentInfoMap[node.Name]
+		if !ok {
+			continue
+		}
+		ip, err := k8s.GetNodeAddr(&node)
+		if err != nil {
+			klog.Warningf("Error when parsing IP of Node %s", node.Name)
+			continue
+		}
+		cfg := rest.CopyConfig(cfgTmpl)
+		cfg.Host = fmt.Sprintf("https://%s", net.JoinHostPort(ip.String(), port))
+		client, err := rest.RESTClientFor(cfg)
+		if err != nil {
+			klog.Warningf("Error when creating agent client for Node %s", node.Name)
+			continue
+		}
+		clients[node.Name] = client
+	}
+	return clients, nil
+}
+
+// entryStream is the stream of audit log entries of an agent, sorted by timestamp.
+type entryStream struct {
+	node    string
+	body    io.ReadCloser
+	decoder *json.Decoder
+	// head is the next entry of the stream.
+	head *auditlogs.Entry
+}
+
+// next decodes the next entry of the stream, and returns false when the stream is exhausted.
+func (s *entryStream) next() bool {
+	if !s.decoder.More() {
+		return false
+	}
+	e := new(auditlogs.Entry)
+	if err := s.decoder.Decode(e); err != nil {
+		klog.Warningf("Failed to decode the audit logs of Node %s: %v", s.node, err)
+		return false
+	}
+	s.head = e
+	return true
+}
+
+// entryHeap merges the entry streams of the agents by timestamp. It only holds the next entry of
+// each stream, so that the memory usage does not depend on the number of entries.
+type entryHeap []*entryStream
+
+func (h entryHeap) Len() int            { return len(h) }
+func (h entryHeap) Less(i, j int) bool  { return h[i].head.Timestamp.Before(h[j].head.Timestamp) }
+func (h entryHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *entryHeap) Push(x interface{}) { *h = append(*h, x.(*entryStream)) }
+func (h *entryHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	s := old[n-1]
+	*h = old[:n-1]
+	return s
+}
+
+type entryWriter interface {
+	write(e *auditlogs.Entry) error
+	flush() error
+}
+
+// tableFlushInterval is the number of rows after which the table is flushed, so that the rows
+// are not all buffered to compute the width of the columns.
+const tableFlushInterval = 100
+
+type tableWriter struct {
+	w    *tabwriter.Writer
+	rows int
+}
+
+func newTableWriter(out io.Writer) *tableWriter {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TIMESTAMP\tNODE\tPOLICY\tRULE\tDISPOSITION\tPRIORITY\tSOURCE\tDESTINATION\tPROTOCOL\tLENGTH")
+	return &tableWriter{w: w}
+}
+
+func (t *tableWriter) write(e *auditlogs.Entry) error {
+	if _, err := fmt.Fprintf(t.w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%d\n", e.Timestamp.Format(time.RFC3339Nano), e.Node, e.Policy,
+		e.Rule, e.Disposition, e.Priority, e.SrcIP, e.DestIP, e.Protocol, e.Length); err != nil {
+		return err
+	}
+	if t.rows++; t.rows%tableFlushInterval == 0 {
+		return t.w.Flush()
+	}
+	return nil
+}
+
+func (t *tableWriter) flush() error {
+	return t.w.Flush()
+}
+
+type jsonWriter struct {
+	encoder *json.Encoder
+}
+
+func (j *jsonWriter) write(e *auditlogs.Entry) error {
+	return j.encoder.Encode(e)
+}
+
+func (j *jsonWriter) flush() error {
+	return nil
+}
+
+// getAuditLogs requests the audit logs of the agents and writes their entries to out, merged by
+// timestamp. Agents which cannot be reached are skipped with a warning.
+func getAuditLogs(agentClients map[string]*rest.RESTClient, out io.Writer) error {
+	var w entryWriter
+	switch option.output {
+	case "table":
+		w = newTableWriter(out)
+	case "json":
+		w = &jsonWriter{encoder: json.NewEncoder(out)}
+	default:
+		return fmt.Errorf("unsupported output format %q", option.output)
+	}
+	uri, err := requestURI()
+	if err != nil {
+		return err
+	}
+
+	h := make(entryHeap, 0, len(agentClients))
+	for node, client := range agentClients {
+		body, err := client.Get().RequestURI(uri).Stream(context.TODO())
+		if err != nil {
+			if len(agentClients) == 1 {
+				return fmt.Errorf("error when requesting the audit logs: %w", err)
+			}
+			klog.Warningf("Skipping Node %s, error when requesting its audit logs: %v", node, err)
+			continue
+		}
+		defer body.Close()
+		s := &entryStream{node: node, body: body, decoder: json.NewDecoder(body)}
+		if s.next() {
+			h = append(h, s)
+		}
+	}
+	heap.Init(&h)
+	for h.Len() > 0 {
+		s := h[0]
+		if err := w.write(s.head); err != nil {
+			return err
+		}
+		if s.next() {
+			heap.Fix(&h, 0)
+		} else {
+			heap.Pop(&h)
+		}
+	}
+	return w.flush()
+}
@@ -0,0 +1,129 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auditlogs
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+
+	"antrea.io/antrea/pkg/agent/apiserver/handlers/auditlogs"
+	"antrea.io/antrea/pkg/client/clientset/versioned/scheme"
+)
+
+func TestParseTime(t *testing.T) {
+	now := time.Date(2021, 5, 1, 12, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		value    string
+		expected string
+		err      bool
+	}{
+		{value: "", expected: ""},
+		{value: "10m", expected: "2021-05-01T11:50:00Z"},
+		{value: "2021-05-01T10:00:00Z", expected: "2021-05-01T10:00:00Z"},
+		{value: "yesterday", err: true},
+	} {
+		got, err := parseTime(tc.value, now)
+		if tc.err {
+			assert.Error(t, err, tc.value)
+			continue
+		}
+		require.NoError(t, err, tc.value)
+		assert.Equal(t, tc.expected, got)
+	}
+}
+
+func TestRequestURI(t *testing.T) {
+	defer func() {
+		option.disposition = ""
+		option.tail = 0
+	}()
+	option.disposition = "Drop"
+	uri, err := requestURI()
+	require.NoError(t, err)
+	assert.Equal(t, "/auditlogs?disposition=Drop", uri)
+
+	option.tail = 20
+	uri, err = requestURI()
+	require.NoError(t, err)
+	assert.Equal(t, "/auditlogs/recent?disposition=Drop&limit=20", uri)
+
+	option.tail = -1
+	_, err = requestURI()
+	assert.Error(t, err)
+}
+
+func newAgentServer(t *testing.T, node string, minutes ...int) (*httptest.Server, *rest.RESTClient) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/auditlogs", r.URL.Path)
+		assert.Equal(t, "Drop", r.URL.Query().Get("disposition"))
+		encoder := json.NewEncoder(w)
+		for _, m := range minutes {
+			encoder.Encode(&auditlogs.Entry{
+				Timestamp:   time.Date(2021, 5, 1, 10, m, 0, 0, time.UTC),
+				Node:        node,
+				Disposition: "Drop",
+			})
+		}
+	}))
+	client, err := rest.RESTClientFor(&rest.Config{
+		Host: server.URL,
+		ContentConfig: rest.ContentConfig{
+			GroupVersion:         &schema.GroupVersion{},
+			NegotiatedSerializer: scheme.Codecs.WithoutConversion(),
+		},
+	})
+	require.NoError(t, err)
+	return server, client
+}
+
+func TestGetAuditLogs(t *testing.T) {
+	server1, client1 := newAgentServer(t, "node1", 1, 4, 5)
+	defer server1.Close()
+	server2, client2 := newAgentServer(t, "node2", 2, 3, 6)
+	defer server2.Close()
+	server3, client3 := newAgentServer(t, "node3")
+	defer server3.Close()
+
+	option.output = "json"
+	option.disposition = "Drop"
+	defer func() {
+		option.output = "table"
+		option.disposition = ""
+	}()
+	var out bytes.Buffer
+	err := getAuditLogs(map[string]*rest.RESTClient{"node1": client1, "node2": client2, "node3": client3}, &out)
+	require.NoError(t, err)
+
+	var nodes []string
+	var minutes []int
+	decoder := json.NewDecoder(&out)
+	for decoder.More() {
+		var e auditlogs.Entry
+		require.NoError(t, decoder.Decode(&e))
+		nodes = append(nodes, e.Node)
+		minutes = append(minutes, e.Timestamp.Minute())
+	}
+	assert.Equal(t, []int{1, 2, 3, 4, 5, 6}, minutes)
+	assert.Equal(t, []string{"node1", "node2", "node2", "node1", "node1", "node2"}, nodes)
+}
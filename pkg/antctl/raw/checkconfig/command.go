@@ -0,0 +1,116 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package checkconfig
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	"antrea.io/antrea/pkg/antctl/runtime"
+	agentconfig "antrea.io/antrea/pkg/config/agent"
+)
+
+// agentConfigFile is the path of the configuration file in the antrea-agent container.
+const agentConfigFile = "/etc/antrea/antrea-agent.conf"
+
+// Command is the "check config" command implementation.
+var Command *cobra.Command
+
+var option = &struct {
+	file     string
+	podCIDRs []string
+}{}
+
+var example = strings.Trim(`
+  Check an antrea-agent configuration file before deploying it
+  $ antctl check config -f antrea-agent.conf
+  Check the configuration file against the PodCIDRs of a Node as well
+  $ antctl check config -f antrea-agent.conf --pod-cidr 10.10.1.0/24 --pod-cidr fd00:10:10:1::/64
+`, "\n")
+
+func init() {
+	Command = &cobra.Command{
+		Use:   "config",
+		Short: "Check an antrea-agent configuration file",
+		Long: "Check an antrea-agent configuration file offline, with the same rules as antrea-agent at startup: the ranges of the " +
+			"numeric options and the consistency of the options which depend on each other. All the violations are reported at once.",
+		Example: example,
+		Args:    cobra.NoArgs,
+		RunE:    runE,
+	}
+	defaultFile := ""
+	if runtime.Mode == runtime.ModeAgent {
+		defaultFile = agentConfigFile
+	}
+	Command.Flags().StringVarP(&option.file, "file", "f", defaultFile, "path of the antrea-agent configuration file")
+	Command.Flags().StringSliceVar(&option.podCIDRs, "pod-cidr", nil, "PodCIDR of the Node the configuration is used on, to check the options which depend on it. Can be repeated for dual-stack Nodes")
+}
+
+func runE(cmd *cobra.Command, _ []string) error {
+	if option.file == "" {
+		return fmt.Errorf("the path of the configuration file must be provided with --file")
+	}
+	data, err := ioutil.ReadFile(option.file)
+	if err != nil {
+		return fmt.Errorf("error when reading configuration file: %w", err)
+	}
+	node, err := parseNodeInfo(option.podCIDRs)
+	if err != nil {
+		return err
+	}
+	return check(cmd.OutOrStdout(), data, node)
+}
+
+func parseNodeInfo(podCIDRs []string) (*agentconfig.NodeInfo, error) {
+	if len(podCIDRs) == 0 {
+		return nil, nil
+	}
+	node := &agentconfig.NodeInfo{}
+	for _, cidr := range podCIDRs {
+		_, podCIDR, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid PodCIDR %s: %w", cidr, err)
+		}
+		node.PodCIDRs = append(node.PodCIDRs, podCIDR)
+	}
+	return node, nil
+}
+
+// check validates the configuration file content and writes the violations to out. It returns an
+// error if the configuration is invalid.
+func check(out io.Writer, data []byte, node *agentconfig.NodeInfo) error {
+	c := &agentconfig.AgentConfig{}
+	// antrea-agent fails to start if the file includes unknown options.
+	if err := yaml.UnmarshalStrict(data, c); err != nil {
+		return fmt.Errorf("error when parsing configuration file: %w", err)
+	}
+	err := agentconfig.Validate(c, node)
+	if err == nil {
+		fmt.Fprintln(out, "The configuration is valid")
+		return nil
+	}
+	errs := err.(utilerrors.Aggregate).Errors()
+	for _, err := range errs {
+		fmt.Fprintf(out, "- %v\n", err)
+	}
+	return fmt.Errorf("found %d violation(s) in the configuration", len(errs))
+}
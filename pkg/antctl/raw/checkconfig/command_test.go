@@ -0,0 +1,88 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package checkconfig
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name           string
+		config         string
+		podCIDRs       []string
+		expectedOutput string
+		expectedErr    string
+	}{
+		{
+			name: "valid",
+			config: `
+apiPort: 10360
+defaultMTU: 1400
+`,
+			expectedOutput: "The configuration is valid\n",
+		},
+		{
+			name: "all violations",
+			config: `
+featureGates:
+  FlowExporter: true
+apiPort: 70000
+defaultMTU: 10
+flowPollInterval: 10s
+activeFlowExportTimeout: 5s
+`,
+			expectedOutput: "- apiPort 70000 is out of range [1, 65535]\n" +
+				"- defaultMTU 10 is out of range [68, 65535]\n" +
+				"- activeFlowExportTimeout 5s must be greater than or equal to flowPollInterval 10s\n",
+			expectedErr: "found 3 violation(s) in the configuration",
+		},
+		{
+			name: "Service CIDR overlapping with PodCIDR",
+			config: `
+featureGates:
+  AntreaProxy: false
+serviceCIDR: 10.96.0.0/12
+`,
+			podCIDRs:       []string{"10.100.1.0/24"},
+			expectedOutput: "- Service CIDR 10.96.0.0/12 overlaps with the PodCIDR 10.100.1.0/24 of the Node\n",
+			expectedErr:    "found 1 violation(s) in the configuration",
+		},
+		{
+			name:        "unknown option",
+			config:      "podMTU: 1400\n",
+			expectedErr: "error when parsing configuration file",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := parseNodeInfo(tt.podCIDRs)
+			require.NoError(t, err)
+			out := new(bytes.Buffer)
+			err = check(out, []byte(tt.config), node)
+			if tt.expectedErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.expectedOutput, out.String())
+		})
+	}
+}
@@ -0,0 +1,529 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package calico converts Calico v3 GlobalNetworkPolicies and NetworkPolicies to Antrea-native
+// policies, and reports the constructs which cannot be converted. It only reads the provided
+// manifests and never accesses a cluster.
+package calico
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	crdv1alpha1 "antrea.io/antrea/pkg/apis/crd/v1alpha1"
+)
+
+const (
+	// The range of the priorities of Antrea-native policies within a Tier.
+	minPriority = float64(1)
+	maxPriority = float64(10000)
+
+	// calicoLabelPrefix is the prefix of the labels Calico sets on the endpoints and Namespaces,
+	// which are not available with Antrea.
+	calicoLabelPrefix = "projectcalico.org/"
+	// calicoNamespaceNameLabel is the label Calico sets on Namespaces to their name. It is
+	// converted to the label set by K8s.
+	calicoNamespaceNameLabel = "projectcalico.org/name"
+	k8sNamespaceNameLabel    = "kubernetes.io/metadata.name"
+
+	// calicoK8sPolicyPrefix is the prefix of the name of the Calico NetworkPolicies which mirror
+	// K8s NetworkPolicies, as listed by calicoctl.
+	calicoK8sPolicyPrefix = "knp.default."
+	calicoDefaultTier     = "default"
+)
+
+// Options are the options of the conversion.
+type Options struct {
+	// Tier is the Tier of the converted policies which are in the default Calico tier. The
+	// policies are in the default Antrea Tier if it is empty.
+	Tier string
+}
+
+// Issue is a construct of a Calico policy which cannot be converted. Depending on the construct,
+// the rule or the whole policy is left out of the conversion.
+type Issue struct {
+	// Policy is the kind and name of the Calico policy, e.g. "GlobalNetworkPolicy allow-dns".
+	Policy string
+	// Rule is the rule of the policy, e.g. "ingress rule 1". It is empty if the issue is about
+	// the policy.
+	Rule    string
+	Message string
+}
+
+func (i Issue) String() string {
+	if i.Rule == "" {
+		return fmt.Sprintf("%s: %s", i.Policy, i.Message)
+	}
+	return fmt.Sprintf("%s, %s: %s", i.Policy, i.Rule, i.Message)
+}
+
+// Result is the result of a conversion.
+type Result struct {
+	ClusterNetworkPolicies []crdv1alpha1.ClusterNetworkPolicy
+	NetworkPolicies        []crdv1alpha1.NetworkPolicy
+	Issues                 []Issue
+}
+
+type converter struct {
+	options Options
+	result  *Result
+}
+
+// Convert converts the Calico GlobalNetworkPolicies and NetworkPolicies of the provided YAML
+// documents to Antrea ClusterNetworkPolicies and Antrea NetworkPolicies respectively. The other
+// resources are reported and skipped. An error is only returned if the documents cannot be parsed.
+func Convert(data []byte, options Options) (*Result, error) {
+	c := &converter{options: options, result: &Result{}}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	for i := 1; ; i++ {
+		obj := &calicoObject{}
+		if err := decoder.Decode(obj); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("error when parsing YAML document %d: %w", i, err)
+		}
+		c.convertObject(obj)
+	}
+	return c.result, nil
+}
+
+func (c *converter) addIssue(policy, rule, format string, args ...interface{}) {
+	c.result.Issues = append(c.result.Issues, Issue{Policy: policy, Rule: rule, Message: fmt.Sprintf(format, args...)})
+}
+
+func (c *converter) convertObject(obj *calicoObject) {
+	if obj.Kind == "" {
+		// Empty document.
+		return
+	}
+	name := obj.Metadata.Name
+	if obj.Metadata.Namespace != "" {
+		name = obj.Metadata.Namespace + "/" + name
+	}
+	policy := obj.Kind + " " + name
+	if obj.APIVersion != calicoAPIVersion {
+		c.addIssue(policy, "", "apiVersion %s is not supported, only %s resources are converted", obj.APIVersion, calicoAPIVersion)
+		return
+	}
+	switch obj.Kind {
+	case kindGlobalNetworkPolicyList, kindNetworkPolicyList:
+		for _, item := range obj.Items {
+			if item.APIVersion == "" {
+				item.APIVersion = obj.APIVersion
+			}
+			if item.Kind == "" {
+				item.Kind = strings.TrimSuffix(obj.Kind, "List")
+			}
+			c.convertObject(item)
+		}
+	case kindGlobalNetworkPolicy:
+		spec, ok := c.convertSpec(policy, obj, true)
+		if !ok {
+			c.addIssue(policy, "", "the policy is not converted")
+			return
+		}
+		c.result.ClusterNetworkPolicies = append(c.result.ClusterNetworkPolicies, crdv1alpha1.ClusterNetworkPolicy{
+			TypeMeta:   metav1.TypeMeta{APIVersion: crdv1alpha1.SchemeGroupVersion.String(), Kind: "ClusterNetworkPolicy"},
+			ObjectMeta: metav1.ObjectMeta{Name: convertName(obj)},
+			Spec: crdv1alpha1.ClusterNetworkPolicySpec{
+				Tier:      spec.Tier,
+				Priority:  spec.Priority,
+				AppliedTo: spec.AppliedTo,
+				Ingress:   spec.Ingress,
+				Egress:    spec.Egress,
+			},
+		})
+	case kindNetworkPolicy:
+		if strings.HasPrefix(obj.Metadata.Name, calicoK8sPolicyPrefix) {
+			c.addIssue(policy, "", "the policy mirrors a K8s NetworkPolicy, which is enforced by Antrea as is")
+			return
+		}
+		spec, ok := c.convertSpec(policy, obj, false)
+		if !ok {
+			c.addIssue(policy, "", "the policy is not converted")
+			return
+		}
+		c.result.NetworkPolicies = append(c.result.NetworkPolicies, crdv1alpha1.NetworkPolicy{
+			TypeMeta:   metav1.TypeMeta{APIVersion: crdv1alpha1.SchemeGroupVersion.String(), Kind: "NetworkPolicy"},
+			ObjectMeta: metav1.ObjectMeta{Name: convertName(obj), Namespace: obj.Metadata.Namespace},
+			Spec:       *spec,
+		})
+	default:
+		c.addIssue(policy, "", "kind %s is not supported", obj.Kind)
+	}
+}
+
+// convertName returns the name of the Antrea-native policy converted from obj: the name of the
+// Calico policy without its tier prefix.
+func convertName(obj *calicoObject) string {
+	if obj.Spec.Tier != "" {
+		return strings.TrimPrefix(obj.Metadata.Name, obj.Spec.Tier+".")
+	}
+	return obj.Metadata.Name
+}
+
+// convertSpec converts the spec of a Calico policy. The spec of Antrea NetworkPolicies is returned
+// for both kinds, as it has the same fields as the one of ClusterNetworkPolicies. It returns false
+// if the policy cannot be converted.
+func (c *converter) convertSpec(policy string, obj *calicoObject, global bool) (*crdv1alpha1.NetworkPolicySpec, bool) {
+	spec := &obj.Spec
+	switch {
+	case spec.DoNotTrack:
+		c.addIssue(policy, "", "doNotTrack policies are not supported")
+		return nil, false
+	case spec.PreDNAT:
+		c.addIssue(policy, "", "preDNAT policies are not supported")
+		return nil, false
+	case spec.ServiceAccountSelector != "":
+		c.addIssue(policy, "", "serviceAccountSelector is not supported")
+		return nil, false
+	}
+	if spec.ApplyOnForward {
+		c.addIssue(policy, "", "applyOnForward is ignored, as it only applies to host endpoints")
+	}
+	podSelector, err := c.convertSelector(spec.Selector, false)
+	if err != nil {
+		c.addIssue(policy, "", "selector: %v", err)
+		return nil, false
+	}
+	appliedTo := crdv1alpha1.NetworkPolicyPeer{PodSelector: podSelector}
+	if spec.NamespaceSelector != "" {
+		if !global {
+			c.addIssue(policy, "", "namespaceSelector is only supported in GlobalNetworkPolicies")
+			return nil, false
+		}
+		appliedTo.NamespaceSelector, err = c.convertSelector(spec.NamespaceSelector, true)
+		if err != nil {
+			c.addIssue(policy, "", "namespaceSelector: %v", err)
+			return nil, false
+		}
+	}
+
+	result := &crdv1alpha1.NetworkPolicySpec{
+		Tier:      c.options.Tier,
+		Priority:  c.convertOrder(policy, spec.Order),
+		AppliedTo: []crdv1alpha1.NetworkPolicyPeer{appliedTo},
+	}
+	if spec.Tier != "" && spec.Tier != calicoDefaultTier {
+		result.Tier = spec.Tier
+	}
+	for i := range spec.Ingress {
+		if rule, ok := c.convertRule(policy, fmt.Sprintf("ingress rule %d", i+1), &spec.Ingress[i], true); ok {
+			result.Ingress = append(result.Ingress, *rule)
+		}
+	}
+	for i := range spec.Egress {
+		if rule, ok := c.convertRule(policy, fmt.Sprintf("egress rule %d", i+1), &spec.Egress[i], false); ok {
+			result.Egress = append(result.Egress, *rule)
+		}
+	}
+	return result, true
+}
+
+// convertOrder converts the order of a Calico policy to the priority of an Antrea-native policy.
+// Both are evaluated in increasing order, but the priorities start at 1 and are bounded. The
+// policies without order are evaluated last by Calico, and get the lowest priority.
+func (c *converter) convertOrder(policy string, order *float64) float64 {
+	if order == nil {
+		return maxPriority
+	}
+	priority := *order + 1
+	if priority < minPriority {
+		c.addIssue(policy, "", "order %v is below the range of Antrea priorities, it is converted to priority %v", *order, minPriority)
+		return minPriority
+	}
+	if priority > maxPriority {
+		c.addIssue(policy, "", "order %v is above the range of Antrea priorities, it is converted to priority %v", *order, maxPriority)
+		return maxPriority
+	}
+	return priority
+}
+
+// convertSelector converts a Calico selector of endpoints or Namespaces. The label Calico sets on
+// Namespaces to their name is replaced with the one set by K8s, and the other Calico labels are
+// rejected.
+func (c *converter) convertSelector(expr string, namespaceSelector bool) (*metav1.LabelSelector, error) {
+	selector, err := parseSelector(expr)
+	if err != nil {
+		return nil, err
+	}
+	convertKey := func(key string) (string, error) {
+		if namespaceSelector && key == calicoNamespaceNameLabel {
+			return k8sNamespaceNameLabel, nil
+		}
+		if strings.HasPrefix(key, calicoLabelPrefix) {
+			return "", fmt.Errorf("label %s is set by Calico and is not available with Antrea", key)
+		}
+		return key, nil
+	}
+	if len(selector.MatchLabels) > 0 {
+		matchLabels := make(map[string]string, len(selector.MatchLabels))
+		for key, value := range selector.MatchLabels {
+			key, err := convertKey(key)
+			if err != nil {
+				return nil, err
+			}
+			matchLabels[key] = value
+		}
+		selector.MatchLabels = matchLabels
+	}
+	for i := range selector.MatchExpressions {
+		key, err := convertKey(selector.MatchExpressions[i].Key)
+		if err != nil {
+			return nil, err
+		}
+		selector.MatchExpressions[i].Key = key
+	}
+	return selector, nil
+}
+
+// convertRule converts a Calico rule. For ingress rules, the source selects the peers and the
+// destination only provides the ports, and conversely for egress rules. It returns false if the
+// rule cannot be converted, as leaving out a part of it would change the traffic it matches.
+func (c *converter) convertRule(policy, ruleName string, rule *calicoRule, ingress bool) (*crdv1alpha1.Rule, bool) {
+	var problems []string
+	unsupported := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	var action crdv1alpha1.RuleAction
+	switch rule.Action {
+	case "Allow":
+		action = crdv1alpha1.RuleActionAllow
+	case "Deny":
+		action = crdv1alpha1.RuleActionDrop
+	case "Pass":
+		action = crdv1alpha1.RuleActionPass
+	case "Log":
+		unsupported("the Log action is not supported, enable logging on the following rules instead")
+	default:
+		unsupported("action %q is not supported", rule.Action)
+	}
+	if rule.NotProtocol != "" {
+		unsupported("notProtocol is not supported")
+	}
+	if rule.IPVersion != nil {
+		unsupported("ipVersion is not supported")
+	}
+	if rule.ICMP != nil || rule.NotICMP != nil {
+		unsupported("ICMP types and codes are not supported")
+	}
+	if rule.HTTP != nil {
+		unsupported("HTTP match criteria are not supported")
+	}
+
+	peerEntity, portEntity, peerSide, portSide := &rule.Source, &rule.Destination, "source", "destination"
+	if !ingress {
+		peerEntity, portEntity, peerSide, portSide = &rule.Destination, &rule.Source, "destination", "source"
+	}
+	if len(portEntity.Nets) > 0 || len(portEntity.NotNets) > 0 || portEntity.Selector != "" || portEntity.NotSelector != "" ||
+		portEntity.NamespaceSelector != "" || portEntity.ServiceAccounts != nil || portEntity.Services != nil {
+		unsupported("%s match criteria other than ports are not supported", portSide)
+	}
+	if len(peerEntity.Ports) > 0 || len(peerEntity.NotPorts) > 0 {
+		unsupported("%s ports are not supported", peerSide)
+	}
+	if len(portEntity.NotPorts) > 0 {
+		unsupported("notPorts is not supported")
+	}
+	if len(peerEntity.NotNets) > 0 || peerEntity.NotSelector != "" {
+		unsupported("notNets and notSelector are not supported")
+	}
+	if peerEntity.ServiceAccounts != nil || peerEntity.Services != nil {
+		unsupported("serviceAccounts and services are not supported")
+	}
+
+	peers, err := c.convertPeers(peerEntity)
+	if err != nil {
+		unsupported("%s: %v", peerSide, err)
+	}
+	ports, err := convertPorts(rule.Protocol, portEntity.Ports)
+	if err != nil {
+		unsupported("%v", err)
+	}
+
+	if len(problems) > 0 {
+		for _, problem := range problems {
+			c.addIssue(policy, ruleName, "%s", problem)
+		}
+		c.addIssue(policy, ruleName, "the rule is not converted")
+		return nil, false
+	}
+	result := &crdv1alpha1.Rule{Action: &action, Ports: ports}
+	if ingress {
+		result.From = peers
+	} else {
+		result.To = peers
+	}
+	return result, true
+}
+
+// convertPeers converts the networks and selectors of a Calico entity rule. Each network is
+// converted to an ipBlock peer. Both cannot be set at once, as Calico matches the endpoints
+// selected by the selectors and in the networks.
+func (c *converter) convertPeers(entity *calicoEntityRule) ([]crdv1alpha1.NetworkPolicyPeer, error) {
+	if len(entity.Nets) > 0 {
+		if entity.Selector != "" || entity.NamespaceSelector != "" {
+			return nil, fmt.Errorf("nets cannot be combined with selectors")
+		}
+		var peers []crdv1alpha1.NetworkPolicyPeer
+		for _, cidr := range entity.Nets {
+			if _, _, err := net.ParseCIDR(cidr); err != nil {
+				return nil, fmt.Errorf("invalid net %s", cidr)
+			}
+			peers = append(peers, crdv1alpha1.NetworkPolicyPeer{IPBlock: &crdv1alpha1.IPBlock{CIDR: cidr}})
+		}
+		return peers, nil
+	}
+	if entity.Selector == "" && entity.NamespaceSelector == "" {
+		return nil, nil
+	}
+	peer := crdv1alpha1.NetworkPolicyPeer{}
+	var err error
+	if entity.Selector != "" {
+		if peer.PodSelector, err = c.convertSelector(entity.Selector, false); err != nil {
+			return nil, err
+		}
+	}
+	if entity.NamespaceSelector != "" {
+		if peer.NamespaceSelector, err = c.convertSelector(entity.NamespaceSelector, true); err != nil {
+			return nil, err
+		}
+	}
+	return []crdv1alpha1.NetworkPolicyPeer{peer}, nil
+}
+
+// convertPorts converts the protocol and ports of a Calico rule.
+func convertPorts(protocol string, ports []string) ([]crdv1alpha1.NetworkPolicyPort, error) {
+	if protocol == "" {
+		if len(ports) > 0 {
+			return nil, fmt.Errorf("ports require a protocol")
+		}
+		return nil, nil
+	}
+	var proto v1.Protocol
+	switch strings.ToUpper(protocol) {
+	case "TCP", "6":
+		proto = v1.ProtocolTCP
+	case "UDP", "17":
+		proto = v1.ProtocolUDP
+	case "SCTP", "132":
+		proto = v1.ProtocolSCTP
+	case "ICMP", "ICMPV6", "1", "58":
+		return nil, fmt.Errorf("protocol %s is not supported by Antrea-native policies", protocol)
+	default:
+		return nil, fmt.Errorf("protocol %s is not supported, only TCP, UDP and SCTP are", protocol)
+	}
+	if len(ports) == 0 {
+		return []crdv1alpha1.NetworkPolicyPort{{Protocol: &proto}}, nil
+	}
+	var result []crdv1alpha1.NetworkPolicyPort
+	for _, port := range ports {
+		p := proto
+		policyPort := crdv1alpha1.NetworkPolicyPort{Protocol: &p}
+		if bounds := strings.SplitN(port, ":", 2); len(bounds) == 2 {
+			start, err1 := parsePortNumber(bounds[0])
+			end, err2 := parsePortNumber(bounds[1])
+			if err1 != nil || err2 != nil || start > end {
+				return nil, fmt.Errorf("invalid port range %s", port)
+			}
+			startPort := intstr.FromInt(int(start))
+			policyPort.Port = &startPort
+			if end > start {
+				policyPort.EndPort = &end
+			}
+		} else if number, err := parsePortNumber(port); err == nil {
+			portNumber := intstr.FromInt(int(number))
+			policyPort.Port = &portNumber
+		} else if _, convErr := strconv.Atoi(port); convErr == nil {
+			return nil, fmt.Errorf("invalid port %s", port)
+		} else {
+			// A named port.
+			portName := intstr.FromString(port)
+			policyPort.Port = &portName
+		}
+		result = append(result, policyPort)
+	}
+	return result, nil
+}
+
+func parsePortNumber(s string) (int32, error) {
+	port, err := strconv.ParseUint(s, 10, 16)
+	if err != nil || port == 0 {
+		return 0, fmt.Errorf("invalid port number %s", s)
+	}
+	return int32(port), nil
+}
+
+// WriteYAML writes the converted policies to w as YAML documents, the ClusterNetworkPolicies first.
+func (r *Result) WriteYAML(w io.Writer) error {
+	var objects []runtime.Object
+	for i := range r.ClusterNetworkPolicies {
+		objects = append(objects, &r.ClusterNetworkPolicies[i])
+	}
+	for i := range r.NetworkPolicies {
+		objects = append(objects, &r.NetworkPolicies[i])
+	}
+	for i, obj := range objects {
+		u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+		if err != nil {
+			return err
+		}
+		// The status is set by the Antrea Controller.
+		delete(u, "status")
+		data, err := yaml.Marshal(pruneEmptyValues(u))
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			if _, err := io.WriteString(w, "---\n"); err != nil {
+				return err
+			}
+		}
+		if _, err := w.Write(data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pruneEmptyValues removes the null values and the empty strings of the fields which are not
+// omitted when empty, e.g. the rule names and creationTimestamp, to keep the manifests short.
+func pruneEmptyValues(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if field == nil || field == "" {
+				delete(v, key)
+				continue
+			}
+			v[key] = pruneEmptyValues(field)
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = pruneEmptyValues(v[i])
+		}
+	}
+	return value
+}
@@ -0,0 +1,265 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package calico
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	crdv1alpha1 "antrea.io/antrea/pkg/apis/crd/v1alpha1"
+)
+
+var (
+	actionAllow = crdv1alpha1.RuleActionAllow
+	actionDrop  = crdv1alpha1.RuleActionDrop
+	actionPass  = crdv1alpha1.RuleActionPass
+	protocolTCP = v1.ProtocolTCP
+	protocolUDP = v1.ProtocolUDP
+)
+
+func newPort(protocol *v1.Protocol, port intstr.IntOrString) crdv1alpha1.NetworkPolicyPort {
+	return crdv1alpha1.NetworkPolicyPort{Protocol: protocol, Port: &port}
+}
+
+func newCNP(name string, spec crdv1alpha1.ClusterNetworkPolicySpec) crdv1alpha1.ClusterNetworkPolicy {
+	return crdv1alpha1.ClusterNetworkPolicy{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "crd.antrea.io/v1alpha1", Kind: "ClusterNetworkPolicy"},
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       spec,
+	}
+}
+
+func convertTestFile(t *testing.T, name string, options Options) *Result {
+	data, err := ioutil.ReadFile(filepath.Join("testdata", name))
+	require.NoError(t, err)
+	result, err := Convert(data, options)
+	require.NoError(t, err)
+	return result
+}
+
+func TestConvertNamespacedPolicy(t *testing.T) {
+	result := convertTestFile(t, "namespaced-policy.yaml", Options{})
+	expectedPolicy := crdv1alpha1.NetworkPolicy{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "crd.antrea.io/v1alpha1", Kind: "NetworkPolicy"},
+		ObjectMeta: metav1.ObjectMeta{Name: "allow-tcp-6379", Namespace: "production"},
+		Spec: crdv1alpha1.NetworkPolicySpec{
+			Priority: 10000,
+			AppliedTo: []crdv1alpha1.NetworkPolicyPeer{
+				{PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"role": "database"}}},
+			},
+			Ingress: []crdv1alpha1.Rule{{
+				Action: &actionAllow,
+				Ports:  []crdv1alpha1.NetworkPolicyPort{newPort(&protocolTCP, intstr.FromInt(6379))},
+				From: []crdv1alpha1.NetworkPolicyPeer{
+					{PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"role": "frontend"}}},
+				},
+			}},
+			Egress: []crdv1alpha1.Rule{{Action: &actionAllow}},
+		},
+	}
+	assert.Equal(t, []crdv1alpha1.NetworkPolicy{expectedPolicy}, result.NetworkPolicies)
+	assert.Empty(t, result.ClusterNetworkPolicies)
+	assert.Empty(t, result.Issues)
+}
+
+func TestConvertGlobalPolicies(t *testing.T) {
+	result := convertTestFile(t, "global-policies.yaml", Options{Tier: "application"})
+	endPort := int32(8080)
+	rangePort := newPort(&protocolTCP, intstr.FromInt(8000))
+	rangePort.EndPort = &endPort
+	expectedPolicies := []crdv1alpha1.ClusterNetworkPolicy{
+		newCNP("default-app-policy", crdv1alpha1.ClusterNetworkPolicySpec{
+			Tier:     "application",
+			Priority: 10000,
+			AppliedTo: []crdv1alpha1.NetworkPolicyPeer{{
+				PodSelector: &metav1.LabelSelector{},
+				NamespaceSelector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+					{Key: "kubernetes.io/metadata.name", Operator: metav1.LabelSelectorOpExists},
+					{Key: "kubernetes.io/metadata.name", Operator: metav1.LabelSelectorOpNotIn, Values: []string{"kube-system", "calico-system"}},
+				}},
+			}},
+			Egress: []crdv1alpha1.Rule{
+				{
+					Action: &actionAllow,
+					Ports:  []crdv1alpha1.NetworkPolicyPort{newPort(&protocolUDP, intstr.FromInt(53))},
+					To: []crdv1alpha1.NetworkPolicyPeer{{
+						PodSelector:       &metav1.LabelSelector{MatchLabels: map[string]string{"k8s-app": "kube-dns"}},
+						NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"kubernetes.io/metadata.name": "kube-system"}},
+					}},
+				},
+				{Action: &actionDrop},
+			},
+		}),
+		newCNP("block-external", crdv1alpha1.ClusterNetworkPolicySpec{
+			Tier:     "security",
+			Priority: 11,
+			AppliedTo: []crdv1alpha1.NetworkPolicyPeer{{
+				PodSelector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+					{Key: "app", Operator: metav1.LabelSelectorOpIn, Values: []string{"web", "api"}},
+					{Key: "trusted", Operator: metav1.LabelSelectorOpDoesNotExist},
+				}},
+			}},
+			Ingress: []crdv1alpha1.Rule{
+				{
+					Action: &actionDrop,
+					Ports:  []crdv1alpha1.NetworkPolicyPort{rangePort, newPort(&protocolTCP, intstr.FromString("http"))},
+					From: []crdv1alpha1.NetworkPolicyPeer{
+						{IPBlock: &crdv1alpha1.IPBlock{CIDR: "192.0.2.0/24"}},
+						{IPBlock: &crdv1alpha1.IPBlock{CIDR: "2001:db8::/32"}},
+					},
+				},
+				{
+					Action: &actionPass,
+					From:   []crdv1alpha1.NetworkPolicyPeer{{NamespaceSelector: &metav1.LabelSelector{}}},
+				},
+			},
+		}),
+	}
+	assert.Equal(t, expectedPolicies, result.ClusterNetworkPolicies)
+	assert.Empty(t, result.NetworkPolicies)
+	assert.Empty(t, result.Issues)
+}
+
+func TestConvertUnsupported(t *testing.T) {
+	result := convertTestFile(t, "unsupported.yaml", Options{})
+	// Only the supported rule of the first policy is converted.
+	expectedPolicies := []crdv1alpha1.ClusterNetworkPolicy{
+		newCNP("mixed", crdv1alpha1.ClusterNetworkPolicySpec{
+			Priority: 10000,
+			AppliedTo: []crdv1alpha1.NetworkPolicyPeer{{
+				PodSelector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+					{Key: "env", Operator: metav1.LabelSelectorOpNotIn, Values: []string{"dev"}},
+				}},
+			}},
+			Ingress: []crdv1alpha1.Rule{{
+				Action: &actionAllow,
+				Ports:  []crdv1alpha1.NetworkPolicyPort{newPort(&protocolTCP, intstr.FromInt(443))},
+			}},
+		}),
+	}
+	assert.Equal(t, expectedPolicies, result.ClusterNetworkPolicies)
+	assert.Empty(t, result.NetworkPolicies)
+
+	var issues []string
+	for _, issue := range result.Issues {
+		issues = append(issues, issue.String())
+	}
+	assert.Equal(t, []string{
+		"GlobalNetworkPolicy mixed: order 20000 is above the range of Antrea priorities, it is converted to priority 10000",
+		"GlobalNetworkPolicy mixed, ingress rule 1: ICMP types and codes are not supported",
+		"GlobalNetworkPolicy mixed, ingress rule 1: protocol ICMP is not supported by Antrea-native policies",
+		"GlobalNetworkPolicy mixed, ingress rule 1: the rule is not converted",
+		"GlobalNetworkPolicy mixed, ingress rule 2: the Log action is not supported, enable logging on the following rules instead",
+		"GlobalNetworkPolicy mixed, ingress rule 2: the rule is not converted",
+		"GlobalNetworkPolicy mixed, ingress rule 3: notNets and notSelector are not supported",
+		"GlobalNetworkPolicy mixed, ingress rule 3: the rule is not converted",
+		`GlobalNetworkPolicy mixed, egress rule 1: destination: unsupported selector "app == 'a' || app == 'b'": unexpected "||", only the conjunctions (&&) of ==, !=, in, not in, has() and all() are supported`,
+		"GlobalNetworkPolicy mixed, egress rule 1: the rule is not converted",
+		"GlobalNetworkPolicy host-pre-dnat: preDNAT policies are not supported",
+		"GlobalNetworkPolicy host-pre-dnat: the policy is not converted",
+		"NetworkPolicy default/knp.default.allow-all: the policy mirrors a K8s NetworkPolicy, which is enforced by Antrea as is",
+		"HostEndpoint node1-eth0: kind HostEndpoint is not supported",
+		"NetworkPolicy default/k8s-policy: apiVersion networking.k8s.io/v1 is not supported, only projectcalico.org/v3 resources are converted",
+	}, issues)
+}
+
+func TestConvertPorts(t *testing.T) {
+	tests := []struct {
+		name          string
+		protocol      string
+		ports         []string
+		expectedPorts []crdv1alpha1.NetworkPolicyPort
+		expectedErr   string
+	}{
+		{
+			name:          "protocol number",
+			protocol:      "17",
+			expectedPorts: []crdv1alpha1.NetworkPolicyPort{{Protocol: &protocolUDP}},
+		},
+		{
+			name:          "single port range",
+			protocol:      "tcp",
+			ports:         []string{"80:80"},
+			expectedPorts: []crdv1alpha1.NetworkPolicyPort{newPort(&protocolTCP, intstr.FromInt(80))},
+		},
+		{
+			name:        "ports without protocol",
+			ports:       []string{"80"},
+			expectedErr: "ports require a protocol",
+		},
+		{
+			name:        "out of range port",
+			protocol:    "TCP",
+			ports:       []string{"70000"},
+			expectedErr: "invalid port 70000",
+		},
+		{
+			name:        "reversed port range",
+			protocol:    "TCP",
+			ports:       []string{"90:80"},
+			expectedErr: "invalid port range 90:80",
+		},
+		{
+			name:        "unsupported protocol",
+			protocol:    "UDPLite",
+			expectedErr: "protocol UDPLite is not supported, only TCP, UDP and SCTP are",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ports, err := convertPorts(tt.protocol, tt.ports)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedPorts, ports)
+		})
+	}
+}
+
+func TestWriteYAML(t *testing.T) {
+	result := convertTestFile(t, "global-policies.yaml", Options{})
+	result.NetworkPolicies = convertTestFile(t, "namespaced-policy.yaml", Options{}).NetworkPolicies
+	var buf bytes.Buffer
+	require.NoError(t, result.WriteYAML(&buf))
+	output := buf.String()
+
+	assert.Equal(t, 2, bytes.Count(buf.Bytes(), []byte("\n---\n")))
+	assert.Contains(t, output, "kind: ClusterNetworkPolicy\n")
+	assert.Contains(t, output, "kind: NetworkPolicy\n")
+	assert.Contains(t, output, "  name: block-external\n")
+	assert.Contains(t, output, "  namespace: production\n")
+	assert.Contains(t, output, "endPort: 8080\n")
+	// The fields set by K8s and the Antrea Controller, and the empty fields, are left out.
+	assert.NotContains(t, output, "status")
+	assert.NotContains(t, output, "creationTimestamp")
+	assert.NotContains(t, output, "null")
+	assert.NotContains(t, output, `""`)
+}
+
+func TestConvertInvalidYAML(t *testing.T) {
+	_, err := Convert([]byte("kind: GlobalNetworkPolicy\n---\nkind: [\n"), Options{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "error when parsing YAML document 2")
+}
@@ -0,0 +1,280 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package calico
+
+import (
+	"fmt"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// selectorTokenizer splits a Calico selector expression into tokens: label keys and keywords,
+// quoted label values, and operators.
+type selectorTokenizer struct {
+	expr string
+	pos  int
+}
+
+type selectorToken struct {
+	value string
+	// quoted is true if the token is a quoted label value.
+	quoted bool
+}
+
+var selectorOperators = []string{"==", "!=", "&&", "||", "!", "(", ")", "{", "}", ","}
+
+func isSelectorKeyChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.IndexByte("_./-", c) >= 0
+}
+
+// next returns the next token, or nil at the end of the expression.
+func (t *selectorTokenizer) next() (*selectorToken, error) {
+	for t.pos < len(t.expr) && (t.expr[t.pos] == ' ' || t.expr[t.pos] == '\t') {
+		t.pos++
+	}
+	if t.pos == len(t.expr) {
+		return nil, nil
+	}
+	if c := t.expr[t.pos]; c == '\'' || c == '"' {
+		end := strings.IndexByte(t.expr[t.pos+1:], c)
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated label value in selector %q", t.expr)
+		}
+		value := t.expr[t.pos+1 : t.pos+1+end]
+		t.pos += end + 2
+		return &selectorToken{value: value, quoted: true}, nil
+	}
+	for _, op := range selectorOperators {
+		if strings.HasPrefix(t.expr[t.pos:], op) {
+			t.pos += len(op)
+			return &selectorToken{value: op}, nil
+		}
+	}
+	start := t.pos
+	for t.pos < len(t.expr) && isSelectorKeyChar(t.expr[t.pos]) {
+		t.pos++
+	}
+	if start == t.pos {
+		return nil, fmt.Errorf("unexpected character %q in selector %q", t.expr[t.pos], t.expr)
+	}
+	return &selectorToken{value: t.expr[start:t.pos]}, nil
+}
+
+// selectorParser parses the subset of the Calico selector syntax which can be expressed with a
+// label selector: the conjunctions ("&&") of equality, inequality, set membership and label
+// existence requirements, and all().
+type selectorParser struct {
+	tokenizer *selectorTokenizer
+	// token is the current token, nil at the end of the expression.
+	token *selectorToken
+}
+
+func (p *selectorParser) advance() error {
+	token, err := p.tokenizer.next()
+	if err != nil {
+		return err
+	}
+	p.token = token
+	return nil
+}
+
+func (p *selectorParser) unsupported() error {
+	if p.token == nil {
+		return fmt.Errorf("unexpected end of selector %q", p.tokenizer.expr)
+	}
+	return fmt.Errorf("unsupported selector %q: unexpected %q, only the conjunctions (&&) of ==, !=, in, not in, has() and all() are supported", p.tokenizer.expr, p.token.value)
+}
+
+// expect consumes the current token if it is the provided operator or keyword, and fails otherwise.
+func (p *selectorParser) expect(value string) error {
+	if p.token == nil || p.token.quoted || p.token.value != value {
+		return p.unsupported()
+	}
+	return p.advance()
+}
+
+// key consumes the current token if it is a label key and returns it.
+func (p *selectorParser) key() (string, error) {
+	if p.token == nil || p.token.quoted || !isSelectorKeyChar(p.token.value[0]) {
+		return "", p.unsupported()
+	}
+	key := p.token.value
+	return key, p.advance()
+}
+
+// value consumes the current token if it is a quoted label value and returns it.
+func (p *selectorParser) value() (string, error) {
+	if p.token == nil || !p.token.quoted {
+		return "", p.unsupported()
+	}
+	value := p.token.value
+	return value, p.advance()
+}
+
+// values consumes a set of quoted label values, e.g. {'a', 'b'}, and returns them.
+func (p *selectorParser) values() ([]string, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	var values []string
+	for {
+		value, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+		if p.token != nil && !p.token.quoted && p.token.value == "}" {
+			return values, p.advance()
+		}
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// requirement parses one requirement of the conjunction and adds it to selector.
+func (p *selectorParser) requirement(selector *metav1.LabelSelector) error {
+	addExpression := func(key string, op metav1.LabelSelectorOperator, values []string) {
+		selector.MatchExpressions = append(selector.MatchExpressions, metav1.LabelSelectorRequirement{Key: key, Operator: op, Values: values})
+	}
+	if p.token != nil && !p.token.quoted && p.token.value == "!" {
+		// Only the negation of has() is supported.
+		if err := p.advance(); err != nil {
+			return err
+		}
+		if err := p.expect("has"); err != nil {
+			return err
+		}
+		key, err := p.function()
+		if err != nil {
+			return err
+		}
+		addExpression(key, metav1.LabelSelectorOpDoesNotExist, nil)
+		return nil
+	}
+	key, err := p.key()
+	if err != nil {
+		return err
+	}
+	if key == "all" || key == "has" {
+		if p.token != nil && !p.token.quoted && p.token.value == "(" {
+			if key == "all" {
+				if err := p.advance(); err != nil {
+					return err
+				}
+				return p.expect(")")
+			}
+			key, err := p.function()
+			if err != nil {
+				return err
+			}
+			addExpression(key, metav1.LabelSelectorOpExists, nil)
+			return nil
+		}
+	}
+	if p.token == nil || p.token.quoted {
+		return p.unsupported()
+	}
+	switch p.token.value {
+	case "==":
+		if err := p.advance(); err != nil {
+			return err
+		}
+		value, err := p.value()
+		if err != nil {
+			return err
+		}
+		if existing, ok := selector.MatchLabels[key]; ok && existing != value {
+			// Keep the contradicting requirements, which select nothing, as in Calico.
+			addExpression(key, metav1.LabelSelectorOpIn, []string{value})
+			return nil
+		}
+		if selector.MatchLabels == nil {
+			selector.MatchLabels = map[string]string{}
+		}
+		selector.MatchLabels[key] = value
+	case "!=":
+		if err := p.advance(); err != nil {
+			return err
+		}
+		value, err := p.value()
+		if err != nil {
+			return err
+		}
+		addExpression(key, metav1.LabelSelectorOpNotIn, []string{value})
+	case "in":
+		if err := p.advance(); err != nil {
+			return err
+		}
+		values, err := p.values()
+		if err != nil {
+			return err
+		}
+		addExpression(key, metav1.LabelSelectorOpIn, values)
+	case "not":
+		if err := p.advance(); err != nil {
+			return err
+		}
+		if err := p.expect("in"); err != nil {
+			return err
+		}
+		values, err := p.values()
+		if err != nil {
+			return err
+		}
+		addExpression(key, metav1.LabelSelectorOpNotIn, values)
+	default:
+		return p.unsupported()
+	}
+	return nil
+}
+
+// function parses the parenthesized label key argument of has().
+func (p *selectorParser) function() (string, error) {
+	if err := p.expect("("); err != nil {
+		return "", err
+	}
+	key, err := p.key()
+	if err != nil {
+		return "", err
+	}
+	return key, p.expect(")")
+}
+
+// parseSelector converts a Calico selector expression to a label selector. An empty expression and
+// all() select everything, and are converted to an empty label selector. The expressions which
+// cannot be expressed with a label selector, e.g. the disjunctions, are rejected.
+func parseSelector(expr string) (*metav1.LabelSelector, error) {
+	p := &selectorParser{tokenizer: &selectorTokenizer{expr: expr}}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	selector := &metav1.LabelSelector{}
+	if p.token == nil {
+		return selector, nil
+	}
+	for {
+		if err := p.requirement(selector); err != nil {
+			return nil, err
+		}
+		if p.token == nil {
+			return selector, nil
+		}
+		if err := p.expect("&&"); err != nil {
+			return nil, err
+		}
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package calico
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestParseSelector(t *testing.T) {
+	tests := []struct {
+		expr             string
+		expectedSelector *metav1.LabelSelector
+		expectedErr      string
+	}{
+		{
+			expr:             "",
+			expectedSelector: &metav1.LabelSelector{},
+		},
+		{
+			expr:             "all()",
+			expectedSelector: &metav1.LabelSelector{},
+		},
+		{
+			expr:             `role == "db" && tier=='backend'`,
+			expectedSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"role": "db", "tier": "backend"}},
+		},
+		{
+			expr: "role == 'db' && role == 'web'",
+			expectedSelector: &metav1.LabelSelector{
+				MatchLabels:      map[string]string{"role": "db"},
+				MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "role", Operator: metav1.LabelSelectorOpIn, Values: []string{"web"}}},
+			},
+		},
+		{
+			expr: "has(app.kubernetes.io/name) && !has(canary) && env != 'dev' && zone in {'a', 'b'} && rack not in {'r1'}",
+			expectedSelector: &metav1.LabelSelector{
+				MatchExpressions: []metav1.LabelSelectorRequirement{
+					{Key: "app.kubernetes.io/name", Operator: metav1.LabelSelectorOpExists},
+					{Key: "canary", Operator: metav1.LabelSelectorOpDoesNotExist},
+					{Key: "env", Operator: metav1.LabelSelectorOpNotIn, Values: []string{"dev"}},
+					{Key: "zone", Operator: metav1.LabelSelectorOpIn, Values: []string{"a", "b"}},
+					{Key: "rack", Operator: metav1.LabelSelectorOpNotIn, Values: []string{"r1"}},
+				},
+			},
+		},
+		{
+			expr:             "has == 'x'",
+			expectedSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"has": "x"}},
+		},
+		{
+			expr:        "role == 'db' || role == 'web'",
+			expectedErr: `unsupported selector "role == 'db' || role == 'web'": unexpected "||"`,
+		},
+		{
+			expr:        "(role == 'db')",
+			expectedErr: `unexpected "("`,
+		},
+		{
+			expr:        "!(role == 'db')",
+			expectedErr: `unexpected "("`,
+		},
+		{
+			expr:        "global()",
+			expectedErr: `unexpected "("`,
+		},
+		{
+			expr:        "name starts with 'web'",
+			expectedErr: `unexpected "starts"`,
+		},
+		{
+			expr:        "role == db",
+			expectedErr: `unexpected "db"`,
+		},
+		{
+			expr:        "role == 'db",
+			expectedErr: "unterminated label value",
+		},
+		{
+			expr:        "role ==",
+			expectedErr: "unexpected end of selector",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			selector, err := parseSelector(tt.expr)
+			if tt.expectedErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedSelector, selector)
+		})
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package calico
+
+// The types below are the subset of the Calico v3 API (projectcalico.org/v3) which is read by the
+// converter, so that Antrea doesn't depend on the Calico API module. The fields which cannot be
+// converted are decoded as well, to report them.
+
+const (
+	calicoAPIVersion = "projectcalico.org/v3"
+
+	kindGlobalNetworkPolicy = "GlobalNetworkPolicy"
+	kindNetworkPolicy       = "NetworkPolicy"
+	// The List kinds are the output of "calicoctl get -o yaml".
+	kindGlobalNetworkPolicyList = "GlobalNetworkPolicyList"
+	kindNetworkPolicyList       = "NetworkPolicyList"
+)
+
+type calicoObject struct {
+	APIVersion string          `yaml:"apiVersion"`
+	Kind       string          `yaml:"kind"`
+	Metadata   calicoMetadata  `yaml:"metadata"`
+	Spec       calicoSpec      `yaml:"spec"`
+	Items      []*calicoObject `yaml:"items"`
+}
+
+type calicoMetadata struct {
+	Name      string `yaml:"name"`
+	Namespace string `yaml:"namespace"`
+}
+
+type calicoSpec struct {
+	Tier                   string       `yaml:"tier"`
+	Order                  *float64     `yaml:"order"`
+	Selector               string       `yaml:"selector"`
+	NamespaceSelector      string       `yaml:"namespaceSelector"`
+	ServiceAccountSelector string       `yaml:"serviceAccountSelector"`
+	Types                  []string     `yaml:"types"`
+	Ingress                []calicoRule `yaml:"ingress"`
+	Egress                 []calicoRule `yaml:"egress"`
+	DoNotTrack             bool         `yaml:"doNotTrack"`
+	PreDNAT                bool         `yaml:"preDNAT"`
+	ApplyOnForward         bool         `yaml:"applyOnForward"`
+}
+
+type calicoRule struct {
+	Action string `yaml:"action"`
+	// Protocol is a name, e.g. TCP, or a number.
+	Protocol    string           `yaml:"protocol"`
+	NotProtocol string           `yaml:"notProtocol"`
+	IPVersion   *int             `yaml:"ipVersion"`
+	ICMP        interface{}      `yaml:"icmp"`
+	NotICMP     interface{}      `yaml:"notICMP"`
+	HTTP        interface{}      `yaml:"http"`
+	Source      calicoEntityRule `yaml:"source"`
+	Destination calicoEntityRule `yaml:"destination"`
+}
+
+type calicoEntityRule struct {
+	Nets              []string `yaml:"nets"`
+	NotNets           []string `yaml:"notNets"`
+	Selector          string   `yaml:"selector"`
+	NotSelector       string   `yaml:"notSelector"`
+	NamespaceSelector string   `yaml:"namespaceSelector"`
+	// Ports are numbers, ranges, e.g. "8080:8090", or named ports.
+	Ports           []string    `yaml:"ports"`
+	NotPorts        []string    `yaml:"notPorts"`
+	ServiceAccounts interface{} `yaml:"serviceAccounts"`
+	Services        interface{} `yaml:"services"`
+}
@@ -0,0 +1,108 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convertpolicy
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"antrea.io/antrea/pkg/antctl/raw/convertpolicy/calico"
+)
+
+// Command is the "convert policy" command implementation.
+var Command *cobra.Command
+
+var option = &struct {
+	file   string
+	output string
+	tier   string
+}{}
+
+var example = strings.Trim(`
+  Convert the Calico policies exported with calicoctl to Antrea-native policies
+  $ calicoctl get globalnetworkpolicies -o yaml > calico-policies.yaml
+  $ antctl convert policy -f calico-policies.yaml -o antrea-policies.yaml
+  Convert the Calico policies in the default tier to Antrea-native policies in the securityops Tier
+  $ antctl convert policy -f calico-policies.yaml --tier securityops
+`, "\n")
+
+func init() {
+	Command = &cobra.Command{
+		Use:   "policy",
+		Short: "Convert Calico policies to Antrea-native policies",
+		Long: "Convert Calico v3 GlobalNetworkPolicies and NetworkPolicies to Antrea ClusterNetworkPolicies and Antrea NetworkPolicies " +
+			"respectively, offline. The policies are evaluated in the same order, as the Calico orders are converted to Antrea priorities. " +
+			"The rules and policies which cannot be converted are reported and left out. Calico denies the traffic of the endpoints " +
+			"selected by a policy which is not matched by any rule of the tier, whereas Antrea-native policies only apply to the traffic " +
+			"matched by their rules: add a policy with a Drop rule at the end of the Tier if needed.",
+		Example: example,
+		Args:    cobra.NoArgs,
+		RunE:    runE,
+	}
+	Command.Flags().StringVarP(&option.file, "file", "f", "", "path of the file with the Calico policies, - for stdin")
+	Command.Flags().StringVarP(&option.output, "output", "o", "", "path of the file to write the Antrea-native policies to, instead of stdout")
+	Command.Flags().StringVar(&option.tier, "tier", "application", "Tier of the converted policies which are in the default Calico tier. The policies in other Calico tiers are converted to the Antrea Tier with the same name, which must be created")
+}
+
+func runE(cmd *cobra.Command, _ []string) error {
+	if option.file == "" {
+		return fmt.Errorf("the path of the file with the Calico policies must be provided with --file")
+	}
+	var data []byte
+	var err error
+	if option.file == "-" {
+		data, err = ioutil.ReadAll(cmd.InOrStdin())
+	} else {
+		data, err = ioutil.ReadFile(option.file)
+	}
+	if err != nil {
+		return fmt.Errorf("error when reading the Calico policies: %w", err)
+	}
+	out := cmd.OutOrStdout()
+	if option.output != "" {
+		f, err := os.Create(option.output)
+		if err != nil {
+			return fmt.Errorf("error when creating output file: %w", err)
+		}
+		defer f.Close()
+		out = f
+	}
+	return convert(out, cmd.ErrOrStderr(), data, calico.Options{Tier: option.tier})
+}
+
+// convert writes the Antrea-native policies converted from the Calico policies in data to out, and
+// the report of the constructs which cannot be converted to report.
+func convert(out, report io.Writer, data []byte, options calico.Options) error {
+	result, err := calico.Convert(data, options)
+	if err != nil {
+		return err
+	}
+	if err := result.WriteYAML(out); err != nil {
+		return fmt.Errorf("error when writing the Antrea-native policies: %w", err)
+	}
+	fmt.Fprintf(report, "Converted %d ClusterNetworkPolicies and %d NetworkPolicies\n", len(result.ClusterNetworkPolicies), len(result.NetworkPolicies))
+	if len(result.Issues) > 0 {
+		fmt.Fprintf(report, "Found %d unsupported construct(s):\n", len(result.Issues))
+		for _, issue := range result.Issues {
+			fmt.Fprintf(report, "- %s\n", issue)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convertpolicy
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"antrea.io/antrea/pkg/antctl/raw/convertpolicy/calico"
+)
+
+func TestConvert(t *testing.T) {
+	policies := `
+apiVersion: projectcalico.org/v3
+kind: GlobalNetworkPolicy
+metadata:
+  name: allow-web
+spec:
+  order: 100
+  selector: app == 'web'
+  ingress:
+  - action: Allow
+    protocol: TCP
+    destination:
+      ports: [80]
+  - action: Allow
+    protocol: ICMP
+`
+	var out, report bytes.Buffer
+	require.NoError(t, convert(&out, &report, []byte(policies), calico.Options{Tier: "securityops"}))
+	assert.Contains(t, out.String(), "kind: ClusterNetworkPolicy\n")
+	assert.Contains(t, out.String(), "  tier: securityops\n")
+	assert.Contains(t, out.String(), "  priority: 101\n")
+	assert.Equal(t, "Converted 1 ClusterNetworkPolicies and 0 NetworkPolicies\n"+
+		"Found 2 unsupported construct(s):\n"+
+		"- GlobalNetworkPolicy allow-web, ingress rule 2: protocol ICMP is not supported by Antrea-native policies\n"+
+		"- GlobalNetworkPolicy allow-web, ingress rule 2: the rule is not converted\n", report.String())
+}
+
+func TestConvertInvalidFile(t *testing.T) {
+	var out, report bytes.Buffer
+	err := convert(&out, &report, []byte("kind: [\n"), calico.Options{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "error when parsing YAML document 1")
+	assert.Empty(t, out.String())
+}
@@ -26,9 +26,9 @@ import (
 	"k8s.io/client-go/rest"
 
 	agentapiserver "antrea.io/antrea/pkg/agent/apiserver"
+	agentclient "antrea.io/antrea/pkg/agent/client"
 	"antrea.io/antrea/pkg/antctl/runtime"
 	"antrea.io/antrea/pkg/apis"
-	clusterinformationv1beta1 "antrea.io/antrea/pkg/apis/crd/v1beta1"
 	controllerapiserver "antrea.io/antrea/pkg/apiserver"
 	antrea "antrea.io/antrea/pkg/client/clientset/versioned"
 	"antrea.io/antrea/pkg/client/clientset/versioned/scheme"
@@ -77,33 +77,7 @@ func SetupKubeconfig(kubeconfig *rest.Config) {
 }
 
 func CreateAgentClientCfg(k8sClientset kubernetes.Interface, antreaClientset antrea.Interface, cfgTmpl *rest.Config, nodeName string) (*rest.Config, error) {
-	node, err := k8sClientset.CoreV1().Nodes().Get(context.TODO(), nodeName, metav1.GetOptions{})
-	if err != nil {
-		return nil, fmt.Errorf("error when looking up Node %s: %w", nodeName, err)
-	}
-	// TODO: filter by Node name, but that would require API support
-	agentInfoList, err := antreaClientset.CrdV1beta1().AntreaAgentInfos().List(context.TODO(), metav1.ListOptions{ResourceVersion: "0"})
-	if err != nil {
-		return nil, err
-	}
-	var agentInfo *clusterinformationv1beta1.AntreaAgentInfo
-	for i := range agentInfoList.Items {
-		ai := agentInfoList.Items[i]
-		if ai.NodeRef.Name == nodeName {
-			agentInfo = &ai
-			break
-		}
-	}
-	if agentInfo == nil {
-		return nil, fmt.Errorf("no Antrea Agent found for Node name %s", nodeName)
-	}
-	nodeIP, err := k8s.GetNodeAddr(node)
-	if err != nil {
-		return nil, fmt.Errorf("error when parsing IP of Node %s", nodeName)
-	}
-	cfg := rest.CopyConfig(cfgTmpl)
-	cfg.Host = fmt.Sprintf("https://%s", net.JoinHostPort(nodeIP.String(), fmt.Sprint(agentInfo.APIPort)))
-	return cfg, nil
+	return agentclient.ConfigForNode(context.TODO(), k8sClientset, antreaClientset, cfgTmpl, nodeName)
 }
 
 func CreateControllerClientCfg(k8sClientset kubernetes.Interface, antreaClientset antrea.Interface, cfgTmpl *rest.Config) (*rest.Config, error) {
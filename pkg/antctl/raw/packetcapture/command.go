@@ -0,0 +1,272 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package packetcapture
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/rest"
+	"k8s.io/klog/v2"
+
+	"antrea.io/antrea/pkg/antctl/raw"
+	"antrea.io/antrea/pkg/antctl/runtime"
+	"antrea.io/antrea/pkg/apis/crd/v1alpha1"
+)
+
+// statusTimeout is how long the command waits for the status of the PacketCapture after its
+// duration elapsed.
+const statusTimeout = 30 * time.Second
+
+var (
+	Command *cobra.Command
+	option  = &struct {
+		source      string
+		destination string
+		flow        string
+		maxPackets  int32
+		duration    time.Duration
+		file        string
+		nowait      bool
+	}{}
+)
+
+var protocols = map[string]string{
+	"icmp": "ICMP",
+	"tcp":  "TCP",
+	"udp":  "UDP",
+}
+
+func init() {
+	Command = &cobra.Command{
+		Use:     "packetcapture",
+		Short:   "Capture the live traffic of a Pod",
+		Long:    "Capture the live traffic of a Pod to a pcap file. The packets are captured by the Antrea agent running on the Node of the source Pod, or of the destination Pod if the source is not a Pod.",
+		Aliases: []string{"pc", "packetcaptures"},
+		Example: `  Capture 100 packets from pod1 to pod2, both Pods are in Namespace default, to the file pod1-to-pod2.pcap
+  $antctl packetcapture -S pod1 -D pod2 -w pod1-to-pod2.pcap
+  Capture the TCP packets from any source to pod1 in Namespace ns1 on port 80, for 2 minutes
+  $antctl packetcapture -D ns1/pod1 -f tcp,tcp_dst=80 --duration 2m
+  Capture at most 1000 UDP packets from pod1 to a destination IP
+  $antctl packetcapture -S pod1 -D 10.0.0.10 -f udp -n 1000
+`,
+		RunE: runE,
+		Args: cobra.NoArgs,
+	}
+
+	Command.Flags().StringVarP(&option.source, "source", "S", "", "source of the captured packets: Namespace/Pod, Pod, or IP")
+	Command.Flags().StringVarP(&option.destination, "destination", "D", "", "destination of the captured packets: Namespace/Pod, Pod, or IP")
+	Command.Flags().StringVarP(&option.flow, "flow", "f", "", "specify the protocol and ports of the captured packets: icmp, tcp, udp, tcp_src, tcp_dst, udp_src, udp_dst")
+	Command.Flags().Int32VarP(&option.maxPackets, "max-packets", "n", v1alpha1.DefaultPacketCaptureMaxPackets, "number of packets after which the capture stops")
+	Command.Flags().DurationVarP(&option.duration, "duration", "d", time.Duration(v1alpha1.DefaultPacketCaptureTimeout)*time.Second, "duration of the capture, it cannot exceed 5 minutes")
+	Command.Flags().StringVarP(&option.file, "file", "w", "", "path of the pcap file to write, defaults to <PacketCapture name>.pcap")
+	Command.Flags().BoolVarP(&option.nowait, "nowait", "", false, "if set, command returns without downloading the captured packets")
+	if runtime.Mode == runtime.ModeAgent || runtime.InPod {
+		Command.RunE = func(_ *cobra.Command, _ []string) error {
+			return errors.New("capturing packets is only supported when running antctl out-of-cluster")
+		}
+	}
+}
+
+func runE(cmd *cobra.Command, _ []string) error {
+	pc, err := newPacketCapture()
+	if err != nil {
+		return fmt.Errorf("error when filling up PacketCapture config: %w", err)
+	}
+
+	kubeconfig, err := raw.ResolveKubeconfig(cmd)
+	if err != nil {
+		return err
+	}
+	k8sClientset, antreaClientset, err := raw.SetupClients(kubeconfig)
+	if err != nil {
+		return fmt.Errorf("failed to create clientset: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err = antreaClientset.CrdV1alpha1().PacketCaptures().Create(ctx, pc, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("error when creating PacketCapture, is PacketCapture feature gate enabled? %w", err)
+	}
+	if option.nowait {
+		fmt.Printf("PacketCapture %s created\n", pc.Name)
+		return nil
+	}
+	defer func() {
+		if err := antreaClientset.CrdV1alpha1().PacketCaptures().Delete(context.TODO(), pc.Name, metav1.DeleteOptions{}); err != nil {
+			klog.Errorf("error when deleting PacketCapture: %+v", err)
+		}
+	}()
+
+	var res *v1alpha1.PacketCapture
+	err = wait.Poll(1*time.Second, option.duration+statusTimeout, func() (bool, error) {
+		res, err = antreaClientset.CrdV1alpha1().PacketCaptures().Get(context.TODO(), pc.Name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		return res.Status.Phase == v1alpha1.PacketCaptureSucceeded || res.Status.Phase == v1alpha1.PacketCaptureFailed, nil
+	})
+	if err == wait.ErrWaitTimeout {
+		return errors.New("timeout waiting for PacketCapture done, is the source or destination Pod running?")
+	} else if err != nil {
+		return fmt.Errorf("error when retrieving PacketCapture: %w", err)
+	}
+	if res.Status.Phase == v1alpha1.PacketCaptureFailed {
+		return fmt.Errorf("PacketCapture failed: %s", res.Status.Reason)
+	}
+
+	kubeconfig.GroupVersion = &schema.GroupVersion{Group: "", Version: ""}
+	raw.SetupKubeconfig(kubeconfig)
+	agentCfg, err := raw.CreateAgentClientCfg(k8sClientset, antreaClientset, kubeconfig, res.Status.NodeName)
+	if err != nil {
+		return fmt.Errorf("error when creating agent client config: %w", err)
+	}
+	agentClient, err := rest.RESTClientFor(agentCfg)
+	if err != nil {
+		return fmt.Errorf("error when creating agent client: %w", err)
+	}
+	file := option.file
+	if file == "" {
+		file = pc.Name + ".pcap"
+	}
+	if err := download(agentClient, pc.Name, file); err != nil {
+		return fmt.Errorf("error when downloading the captured packets: %w", err)
+	}
+	fmt.Printf("Captured %d packets on Node %s to %s (%s)\n", res.Status.NumCapturedPackets, res.Status.NodeName, file, res.Status.Reason)
+	return nil
+}
+
+// parsePeer parses a peer in the format of Namespace/Pod, Pod or IP, and returns the name of the
+// peer used in the PacketCapture name.
+func parsePeer(value string) (v1alpha1.PacketCapturePeer, string, error) {
+	var peer v1alpha1.PacketCapturePeer
+	if value == "" {
+		return peer, "any", nil
+	}
+	if ip := net.ParseIP(value); ip != nil {
+		if ip.To4() == nil {
+			return peer, "", errors.New("only IPv4 addresses are supported")
+		}
+		peer.IP = ip.String()
+		return peer, peer.IP, nil
+	}
+	split := strings.Split(value, "/")
+	if len(split) == 1 {
+		peer.Namespace = "default"
+		peer.Pod = split[0]
+		return peer, peer.Pod, nil
+	} else if len(split) == 2 && len(split[0]) != 0 && len(split[1]) != 0 {
+		peer.Namespace = split[0]
+		peer.Pod = split[1]
+		return peer, fmt.Sprintf("%s-%s", peer.Namespace, peer.Pod), nil
+	}
+	return peer, "", errors.New("it should be in the format of Namespace/Pod or Pod, or an IPv4 address")
+}
+
+func newPacketCapture() (*v1alpha1.PacketCapture, error) {
+	if option.duration <= 0 || option.duration > time.Duration(v1alpha1.MaxPacketCaptureTimeout)*time.Second {
+		return nil, fmt.Errorf("duration must be between 1s and %ds", v1alpha1.MaxPacketCaptureTimeout)
+	}
+	if option.maxPackets <= 0 || option.maxPackets > v1alpha1.MaxPacketCaptureMaxPackets {
+		return nil, fmt.Errorf("max-packets must be between 1 and %d", v1alpha1.MaxPacketCaptureMaxPackets)
+	}
+	src, srcName, err := parsePeer(option.source)
+	if err != nil {
+		return nil, fmt.Errorf("invalid source: %w", err)
+	}
+	dst, dstName, err := parsePeer(option.destination)
+	if err != nil {
+		return nil, fmt.Errorf("invalid destination: %w", err)
+	}
+	if src.Pod == "" && dst.Pod == "" {
+		return nil, errors.New("one of source and destination must be a Pod")
+	}
+	pc := &v1alpha1.PacketCapture{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: fmt.Sprintf("%s-to-%s-%s", srcName, dstName, rand.String(8)),
+		},
+		Spec: v1alpha1.PacketCaptureSpec{
+			Source:      src,
+			Destination: dst,
+			MaxPackets:  option.maxPackets,
+			Timeout:     uint16(option.duration.Seconds()),
+		},
+	}
+	if err := parseFlow(option.flow, &pc.Spec); err != nil {
+		return nil, fmt.Errorf("failed to parse flow: %w", err)
+	}
+	return pc, nil
+}
+
+// parseFlow sets the protocol and ports of the spec from a flow like "tcp,tcp_dst=80".
+func parseFlow(flow string, spec *v1alpha1.PacketCaptureSpec) error {
+	for _, field := range strings.Split(strings.ReplaceAll(flow, " ", ""), ",") {
+		if field == "" {
+			continue
+		}
+		if protocol, ok := protocols[field]; ok {
+			spec.Protocol = protocol
+			continue
+		}
+		kv := strings.Split(field, "=")
+		if len(kv) != 2 {
+			return fmt.Errorf("%s is not valid in flow", field)
+		}
+		port, err := strconv.ParseUint(kv[1], 10, 16)
+		if err != nil || port == 0 {
+			return fmt.Errorf("invalid port in %s", field)
+		}
+		switch kv[0] {
+		case "tcp_src", "udp_src":
+			spec.SrcPort = int32(port)
+		case "tcp_dst", "udp_dst":
+			spec.DstPort = int32(port)
+		default:
+			return fmt.Errorf("%s is not valid in flow", field)
+		}
+		spec.Protocol = protocols[strings.Split(kv[0], "_")[0]]
+	}
+	return nil
+}
+
+// download writes the pcap file of the PacketCapture served by the agent to path.
+func download(client *rest.RESTClient, name, path string) error {
+	uri := url.URL{Path: "/packetcaptures", RawQuery: url.Values{"name": []string{name}}.Encode()}
+	body, err := client.Get().RequestURI(uri.RequestURI()).Stream(context.TODO())
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(f, body)
+	return err
+}
@@ -0,0 +1,98 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package packetcapture
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"antrea.io/antrea/pkg/apis/crd/v1alpha1"
+)
+
+func TestParseFlow(t *testing.T) {
+	tcs := []struct {
+		flow     string
+		success  bool
+		expected v1alpha1.PacketCaptureSpec
+	}{
+		{
+			flow:     "",
+			success:  true,
+			expected: v1alpha1.PacketCaptureSpec{},
+		},
+		{
+			flow:     "icmp",
+			success:  true,
+			expected: v1alpha1.PacketCaptureSpec{Protocol: "ICMP"},
+		},
+		{
+			flow:     "udp,udp_src=1234,udp_dst=4321",
+			success:  true,
+			expected: v1alpha1.PacketCaptureSpec{Protocol: "UDP", SrcPort: 1234, DstPort: 4321},
+		},
+		{
+			flow:     "tcp_dst=80",
+			success:  true,
+			expected: v1alpha1.PacketCaptureSpec{Protocol: "TCP", DstPort: 80},
+		},
+		{
+			flow:    "tcp,tcp_dst=70000",
+			success: false,
+		},
+		{
+			flow:    "tcp,ttl=2",
+			success: false,
+		},
+	}
+
+	for _, tc := range tcs {
+		var spec v1alpha1.PacketCaptureSpec
+		err := parseFlow(tc.flow, &spec)
+		if tc.success {
+			assert.NoError(t, err, "flow %s", tc.flow)
+			assert.Equal(t, tc.expected, spec)
+		} else {
+			assert.Error(t, err, "flow %s", tc.flow)
+		}
+	}
+}
+
+func TestParsePeer(t *testing.T) {
+	tcs := []struct {
+		value        string
+		success      bool
+		expected     v1alpha1.PacketCapturePeer
+		expectedName string
+	}{
+		{value: "", success: true, expectedName: "any"},
+		{value: "pod1", success: true, expected: v1alpha1.PacketCapturePeer{Namespace: "default", Pod: "pod1"}, expectedName: "pod1"},
+		{value: "ns1/pod1", success: true, expected: v1alpha1.PacketCapturePeer{Namespace: "ns1", Pod: "pod1"}, expectedName: "ns1-pod1"},
+		{value: "10.0.0.1", success: true, expected: v1alpha1.PacketCapturePeer{IP: "10.0.0.1"}, expectedName: "10.0.0.1"},
+		{value: "fd00::1", success: false},
+		{value: "ns1/", success: false},
+	}
+
+	for _, tc := range tcs {
+		peer, name, err := parsePeer(tc.value)
+		if tc.success {
+			assert.NoError(t, err, "peer %s", tc.value)
+			assert.Equal(t, tc.expected, peer)
+			assert.Equal(t, tc.expectedName, name)
+		} else {
+			assert.Error(t, err, "peer %s", tc.value)
+		}
+	}
+}
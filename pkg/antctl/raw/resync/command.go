@@ -0,0 +1,138 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resync
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+
+	"antrea.io/antrea/pkg/antctl/raw"
+	"antrea.io/antrea/pkg/antctl/runtime"
+	"antrea.io/antrea/pkg/querier"
+)
+
+// Command is the resync command implementation.
+var Command *cobra.Command
+
+var option = &struct {
+	nodeName string
+	output   string
+}{}
+
+var remoteControllerExample = strings.Trim(`
+  Resync the NetworkPolicies of the Antrea agent running on Node node1
+  $ antctl resync --node node1
+  Resync the NetworkPolicies of the Antrea agent running on Node node1 and print the corrections in JSON format
+  $ antctl resync --node node1 -o json
+`, "\n")
+
+func init() {
+	Command = &cobra.Command{
+		Use:   "resync",
+		Short: "Resync the NetworkPolicies of an Antrea agent with the Antrea controller",
+		Long: "Resync the NetworkPolicies, AddressGroups and AppliedToGroups of an Antrea agent with the Antrea controller, without restarting the agent. " +
+			"The agent relists them from the controller, reconciles the rules which changed without removing their existing flows first, " +
+			"and reports the corrections made to its cache. Concurrent resyncs of the same agent are coalesced.",
+		Args: cobra.NoArgs,
+	}
+	Command.Flags().StringVarP(&option.output, "output", "o", "table", "output format: table or json")
+	if runtime.Mode == runtime.ModeAgent {
+		Command.RunE = agentRunE
+	} else if runtime.Mode == runtime.ModeController && runtime.InPod {
+		Command.RunE = controllerLocalRunE
+	} else if runtime.Mode == runtime.ModeController && !runtime.InPod {
+		Command.Example = remoteControllerExample
+		Command.Flags().StringVar(&option.nodeName, "node", "", "name of the Node of the Antrea agent to resync")
+		Command.RunE = controllerRemoteRunE
+	}
+}
+
+func agentRunE(cmd *cobra.Command, _ []string) error {
+	kubeconfig, err := raw.ResolveKubeconfig(cmd)
+	if err != nil {
+		return err
+	}
+	kubeconfig.GroupVersion = &schema.GroupVersion{Group: "", Version: ""}
+	raw.SetupKubeconfig(kubeconfig)
+	client, err := rest.RESTClientFor(kubeconfig)
+	if err != nil {
+		return fmt.Errorf("error when creating rest client: %w", err)
+	}
+	return resync(client, cmd.OutOrStdout())
+}
+
+func controllerLocalRunE(_ *cobra.Command, _ []string) error {
+	return fmt.Errorf("resyncing an agent from the controller Pod is not supported, run the command out-of-cluster or in an agent Pod")
+}
+
+func controllerRemoteRunE(cmd *cobra.Command, _ []string) error {
+	if option.nodeName == "" {
+		return fmt.Errorf("the Node of the agent to resync must be provided with --node")
+	}
+	kubeconfig, err := raw.ResolveKubeconfig(cmd)
+	if err != nil {
+		return err
+	}
+	k8sClientset, antreaClientset, err := raw.SetupClients(kubeconfig)
+	if err != nil {
+		return fmt.Errorf("failed to create clientset: %w", err)
+	}
+	kubeconfig.GroupVersion = &schema.GroupVersion{Group: "", Version: ""}
+	raw.SetupKubeconfig(kubeconfig)
+	agentCfg, err := raw.CreateAgentClientCfg(k8sClientset, antreaClientset, kubeconfig, option.nodeName)
+	if err != nil {
+		return fmt.Errorf("error when creating agent client config: %w", err)
+	}
+	client, err := rest.RESTClientFor(agentCfg)
+	if err != nil {
+		return fmt.Errorf("error when creating agent client: %w", err)
+	}
+	return resync(client, cmd.OutOrStdout())
+}
+
+// resync requests the agent to resync its NetworkPolicies and writes the corrections to out.
+func resync(client rest.Interface, out io.Writer) error {
+	if option.output != "table" && option.output != "json" {
+		return fmt.Errorf("unsupported output format %q", option.output)
+	}
+	data, err := client.Post().AbsPath("/resync").Do(context.TODO()).Raw()
+	if err != nil {
+		return fmt.Errorf("error when resyncing the agent: %w", err)
+	}
+	var summary querier.NetworkPolicyResyncSummary
+	if err := json.Unmarshal(data, &summary); err != nil {
+		return fmt.Errorf("error when decoding the resync summary: %w", err)
+	}
+	if option.output == "json" {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(summary)
+	}
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "OBJECT\tADDED\tREMOVED\tUPDATED")
+	fmt.Fprintf(w, "NetworkPolicies\t%d\t%d\t%d\n", summary.PoliciesAdded, summary.PoliciesRemoved, summary.PoliciesUpdated)
+	fmt.Fprintf(w, "AddressGroups\t%d\t%d\t%d\n", summary.AddressGroupsAdded, summary.AddressGroupsRemoved, summary.AddressGroupsUpdated)
+	fmt.Fprintf(w, "AppliedToGroups\t%d\t%d\t%d\n", summary.AppliedToGroupsAdded, summary.AppliedToGroupsRemoved, summary.AppliedToGroupsUpdated)
+	fmt.Fprintf(w, "GroupMembers\t%d\t%d\t-\n", summary.GroupMembersAdded, summary.GroupMembersRemoved)
+	return w.Flush()
+}
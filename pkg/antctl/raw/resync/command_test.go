@@ -0,0 +1,61 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resync
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+
+	"antrea.io/antrea/pkg/client/clientset/versioned/scheme"
+	"antrea.io/antrea/pkg/querier"
+)
+
+func TestResync(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/resync", r.URL.Path)
+		json.NewEncoder(w).Encode(&querier.NetworkPolicyResyncSummary{PoliciesAdded: 1, AddressGroupsUpdated: 2, GroupMembersAdded: 3, GroupMembersRemoved: 1})
+	}))
+	defer server.Close()
+	client, err := rest.RESTClientFor(&rest.Config{
+		Host: server.URL,
+		ContentConfig: rest.ContentConfig{
+			GroupVersion:         &schema.GroupVersion{},
+			NegotiatedSerializer: scheme.Codecs.WithoutConversion(),
+		},
+	})
+	require.NoError(t, err)
+
+	defer func(output string) { option.output = output }(option.output)
+	option.output = "table"
+	var out bytes.Buffer
+	require.NoError(t, resync(client, &out))
+	assert.Equal(t, "OBJECT           ADDED  REMOVED  UPDATED\n"+
+		"NetworkPolicies  1      0        0\n"+
+		"AddressGroups    0      0        2\n"+
+		"AppliedToGroups  0      0        0\n"+
+		"GroupMembers     3      1        -\n", out.String())
+
+	option.output = "yaml"
+	assert.Error(t, resync(client, &out))
+}
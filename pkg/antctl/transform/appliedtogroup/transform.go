@@ -21,11 +21,14 @@ import (
 	"antrea.io/antrea/pkg/antctl/transform"
 	"antrea.io/antrea/pkg/antctl/transform/common"
 	cpv1beta "antrea.io/antrea/pkg/apis/controlplane/v1beta2"
+	"antrea.io/antrea/pkg/querier"
 )
 
 type Response struct {
 	Name string               `json:"name" yaml:"name"`
 	Pods []common.GroupMember `json:"pods,omitempty"`
+	// LastUpdateTime is the time the Agent last received the group. It is only set by the Agent.
+	LastUpdateTime string `json:"lastUpdateTime,omitempty"`
 }
 
 func listTransform(l interface{}, opts map[string]string) (interface{}, error) {
@@ -45,7 +48,7 @@ func objectTransform(o interface{}, _ map[string]string) (interface{}, error) {
 	for _, pod := range group.GroupMembers {
 		pods = append(pods, common.GroupMemberPodTransform(pod))
 	}
-	return Response{Name: group.GetName(), Pods: pods}, nil
+	return Response{Name: group.GetName(), Pods: pods, LastUpdateTime: group.Annotations[querier.LastUpdateTimeAnnotation]}, nil
 }
 
 func Transform(reader io.Reader, single bool, opts map[string]string) (interface{}, error) {
@@ -61,7 +64,7 @@ func Transform(reader io.Reader, single bool, opts map[string]string) (interface
 var _ common.TableOutput = new(Response)
 
 func (r Response) GetTableHeader() []string {
-	return []string{"NAME", "PODS"}
+	return []string{"NAME", "PODS", "LAST-UPDATE"}
 }
 
 func (r Response) GetPodNames(maxColumnLength int) string {
@@ -73,7 +76,7 @@ func (r Response) GetPodNames(maxColumnLength int) string {
 }
 
 func (r Response) GetTableRow(maxColumnLength int) []string {
-	return []string{r.Name, r.GetPodNames(maxColumnLength)}
+	return []string{r.Name, r.GetPodNames(maxColumnLength), r.LastUpdateTime}
 }
 
 func (r Response) SortRows() bool {
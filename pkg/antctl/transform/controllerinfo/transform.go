@@ -36,6 +36,7 @@ type Response struct {
 	NetworkPolicyControllerInfo crdv1beta1.NetworkPolicyControllerInfo `json:"networkPolicyControllerInfo,omitempty"` // Antrea Controller NetworkPolicy information
 	ConnectedAgentNum           int32                                  `json:"connectedAgentNum,omitempty"`           // Number of agents which are connected to this controller
 	ControllerConditions        []crdv1beta1.ControllerCondition       `json:"controllerConditions,omitempty"`        // Controller condition contains types like ControllerHealthy
+	AgentVersions               map[string]string                      `json:"agentVersions,omitempty"`               // Versions of the agents which are connected to this controller, keyed by Node name
 }
 
 func Transform(reader io.Reader, _ bool, _ map[string]string) (interface{}, error) {
@@ -57,6 +58,7 @@ func Transform(reader io.Reader, _ bool, _ map[string]string) (interface{}, erro
 		NetworkPolicyControllerInfo: controllerInfo.NetworkPolicyControllerInfo,
 		ConnectedAgentNum:           controllerInfo.ConnectedAgentNum,
 		ControllerConditions:        controllerInfo.ControllerConditions,
+		AgentVersions:               controllerInfo.AgentVersions,
 	}
 	return resp, nil
 }
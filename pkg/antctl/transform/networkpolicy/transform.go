@@ -20,10 +20,15 @@ import (
 	"sort"
 	"strconv"
 
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+
+	agentnetworkpolicy "antrea.io/antrea/pkg/agent/apiserver/handlers/networkpolicy"
 	"antrea.io/antrea/pkg/antctl/transform"
 	"antrea.io/antrea/pkg/antctl/transform/common"
 	cpv1beta "antrea.io/antrea/pkg/apis/controlplane/v1beta2"
 	"antrea.io/antrea/pkg/controller/networkpolicy"
+	"antrea.io/antrea/pkg/querier"
 )
 
 type Response struct {
@@ -63,6 +68,25 @@ func Transform(reader io.Reader, single bool, opts map[string]string) (interface
 	)(reader, single)
 }
 
+// WatchTransform returns a function which applies the agent NetworkPolicy watch events to the
+// NetworkPolicies received so far, and returns all of them transformed like a list.
+func WatchTransform(opts map[string]string) func(frame interface{}) (interface{}, error) {
+	policies := map[types.UID]cpv1beta.NetworkPolicy{}
+	return func(frame interface{}) (interface{}, error) {
+		event := frame.(agentnetworkpolicy.WatchEvent)
+		if event.Type == watch.Deleted {
+			delete(policies, event.Object.UID)
+		} else {
+			policies[event.Object.UID] = event.Object
+		}
+		policyList := &cpv1beta.NetworkPolicyList{Items: make([]cpv1beta.NetworkPolicy, 0, len(policies))}
+		for _, np := range policies {
+			policyList.Items = append(policyList.Items, np)
+		}
+		return listTransform(policyList, opts)
+	}
+}
+
 const sortByEffectivePriority = "effectivePriority"
 
 // Compute a tierPriority value in between the application tier and the baseline tier,
@@ -121,7 +145,7 @@ func priorityToString(p interface{}) string {
 var _ common.TableOutput = new(Response)
 
 func (r Response) GetTableHeader() []string {
-	return []string{"NAME", "APPLIED-TO", "RULES", "SOURCE", "TIER-PRIORITY", "PRIORITY"}
+	return []string{"NAME", "APPLIED-TO", "RULES", "SOURCE", "TIER-PRIORITY", "PRIORITY", "GENERATION", "LAST-UPDATE"}
 }
 
 func (r Response) GetTableRow(maxColumnLength int) []string {
@@ -129,6 +153,7 @@ func (r Response) GetTableRow(maxColumnLength int) []string {
 		r.Name, common.GenerateTableElementWithSummary(r.AppliedToGroups, maxColumnLength),
 		strconv.Itoa(len(r.Rules)), r.SourceRef.ToString(),
 		priorityToString(r.TierPriority), priorityToString(r.Priority),
+		strconv.FormatInt(r.Generation, 10), r.Annotations[querier.LastUpdateTimeAnnotation],
 	}
 }
 
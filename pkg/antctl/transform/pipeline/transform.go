@@ -0,0 +1,32 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pipeline
+
+import (
+	"encoding/json"
+	"io"
+
+	"antrea.io/antrea/pkg/agent/apiserver/handlers/pipeline"
+)
+
+// Transform extracts the tables from the pipeline response, so that antctl
+// prints one table of the pipeline per row.
+func Transform(reader io.Reader, _ bool, _ map[string]string) (interface{}, error) {
+	resp := new(pipeline.Response)
+	if err := json.NewDecoder(reader).Decode(resp); err != nil {
+		return nil, err
+	}
+	return resp.Tables, nil
+}
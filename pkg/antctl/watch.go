@@ -0,0 +1,149 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package antctl
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"reflect"
+	"syscall"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// watchRetryInterval is the interval between two connection attempts when the watch
+	// stream is interrupted.
+	watchRetryInterval = 2 * time.Second
+	// clearScreen moves the cursor to the top left corner and clears the terminal.
+	clearScreen = "\033[H\033[2J"
+)
+
+// watch keeps printing the objects streamed by the endpoint of the command until the command
+// is interrupted. The connection is re-established if the stream is interrupted, unless the
+// server rejected the request.
+func (cd *commandDefinition) watch(c AntctlClient, opt *requestOption, out io.Writer, ft formatterType) error {
+	if cd.getWatchEndpoint() == nil {
+		return fmt.Errorf("%s does not support watch", cd.use)
+	}
+	switch ft {
+	case jsonFormatter, yamlFormatter, tableFormatter:
+	default:
+		return fmt.Errorf("unsupported format type: %v", ft)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+	go func() {
+		select {
+		case <-sigCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	for {
+		err := cd.watchOnce(ctx, c, opt, out, ft)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if statusErr, ok := err.(*errors.StatusError); ok && statusErr.Status().Code < 500 {
+			return generateMessageForStatusErr(cd, opt.args, statusErr)
+		}
+		if err != nil {
+			klog.Errorf("Watch interrupted, retrying in %v: %v", watchRetryInterval, err)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(watchRetryInterval):
+		}
+	}
+}
+
+// watchOnce prints the objects received from a single watch connection, until the stream ends
+// or ctx is cancelled.
+func (cd *commandDefinition) watchOnce(ctx context.Context, c AntctlClient, opt *requestOption, out io.Writer, ft formatterType) error {
+	stream, err := c.watch(ctx, opt)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+	watchEndpoint := cd.getWatchEndpoint()
+	frameTransform := watchEndpoint.newFrameTransform(opt.args)
+	decoder := json.NewDecoder(stream)
+	for {
+		ref := reflect.New(watchEndpoint.frameType)
+		if err := decoder.Decode(ref.Interface()); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return fmt.Errorf("error when decoding watch stream: %w", err)
+		}
+		obj, err := frameTransform(reflect.Indirect(ref).Interface())
+		if err != nil {
+			return fmt.Errorf("error when doing local transform: %w", err)
+		}
+		if err := cd.watchOutput(obj, out, ft); err != nil {
+			return err
+		}
+	}
+}
+
+// watchOutput prints a transformed frame: one compact line per frame in json format, one
+// document per frame in yaml format, or the table refreshing the terminal in table format.
+func (cd *commandDefinition) watchOutput(obj interface{}, out io.Writer, ft formatterType) error {
+	switch ft {
+	case jsonFormatter:
+		if err := json.NewEncoder(out).Encode(obj); err != nil {
+			return fmt.Errorf("error when outputing in json format: %w", err)
+		}
+		return nil
+	case yamlFormatter:
+		if _, err := io.WriteString(out, "---\n"); err != nil {
+			return fmt.Errorf("error when outputing in yaml format: %w", err)
+		}
+		return cd.yamlOutput(obj, out)
+	default:
+		if isTerminal(out) {
+			if _, err := io.WriteString(out, clearScreen); err != nil {
+				return fmt.Errorf("error when clearing the screen: %w", err)
+			}
+		}
+		return cd.tableOutputForGetCommands(obj, out)
+	}
+}
+
+// isTerminal returns whether out is a terminal, in which case the table is refreshed in place
+// instead of being appended to the previous ones.
+func isTerminal(out io.Writer) bool {
+	f, ok := out.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
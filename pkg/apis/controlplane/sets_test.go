@@ -3,6 +3,8 @@ package controlplane
 import (
 	"net"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
 )
 
 func BenchmarkNormalizeGroupMemberPod(b *testing.B) {
@@ -31,3 +33,139 @@ func BenchmarkInsert(b *testing.B) {
 		pods.Insert(pod)
 	}
 }
+
+func newGroupMemberPod(name, ip string) *GroupMember {
+	return &GroupMember{
+		Pod: &PodReference{Namespace: "ns", Name: name},
+		IPs: []IPAddress{IPAddress(net.ParseIP(ip))},
+	}
+}
+
+var (
+	pod1 = newGroupMemberPod("pod1", "1.1.1.1")
+	pod2 = newGroupMemberPod("pod2", "1.1.1.2")
+	pod3 = newGroupMemberPod("pod3", "1.1.1.3")
+	// pod1Ports has the same key as pod1, but different named ports.
+	pod1Ports = &GroupMember{
+		Pod:   pod1.Pod,
+		IPs:   pod1.IPs,
+		Ports: []NamedPort{{Port: 80, Name: "http", Protocol: ProtocolTCP}},
+	}
+)
+
+func TestGroupMemberSetAlgebra(t *testing.T) {
+	tests := []struct {
+		name                 string
+		s, o                 GroupMemberSet
+		expectedUnion        GroupMemberSet
+		expectedDifference   GroupMemberSet
+		expectedIntersection GroupMemberSet
+	}{
+		{
+			name:                 "empty sets",
+			s:                    NewGroupMemberSet(),
+			o:                    NewGroupMemberSet(),
+			expectedUnion:        NewGroupMemberSet(),
+			expectedDifference:   NewGroupMemberSet(),
+			expectedIntersection: NewGroupMemberSet(),
+		},
+		{
+			name:                 "nil set",
+			s:                    NewGroupMemberSet(pod1),
+			o:                    nil,
+			expectedUnion:        NewGroupMemberSet(pod1),
+			expectedDifference:   NewGroupMemberSet(pod1),
+			expectedIntersection: NewGroupMemberSet(),
+		},
+		{
+			name:                 "disjoint sets",
+			s:                    NewGroupMemberSet(pod1),
+			o:                    NewGroupMemberSet(pod2, pod3),
+			expectedUnion:        NewGroupMemberSet(pod1, pod2, pod3),
+			expectedDifference:   NewGroupMemberSet(pod1),
+			expectedIntersection: NewGroupMemberSet(),
+		},
+		{
+			name:                 "overlapping sets",
+			s:                    NewGroupMemberSet(pod1, pod2),
+			o:                    NewGroupMemberSet(pod2, pod3),
+			expectedUnion:        NewGroupMemberSet(pod1, pod2, pod3),
+			expectedDifference:   NewGroupMemberSet(pod1),
+			expectedIntersection: NewGroupMemberSet(pod2),
+		},
+		{
+			name:                 "subset",
+			s:                    NewGroupMemberSet(pod2),
+			o:                    NewGroupMemberSet(pod1, pod2, pod3),
+			expectedUnion:        NewGroupMemberSet(pod1, pod2, pod3),
+			expectedDifference:   NewGroupMemberSet(),
+			expectedIntersection: NewGroupMemberSet(pod2),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewGroupMemberSet(tt.s.Items()...)
+			assert.Equal(t, tt.expectedUnion, tt.s.Union(tt.o))
+			assert.Equal(t, tt.expectedDifference, tt.s.Difference(tt.o))
+			assert.Equal(t, tt.expectedIntersection, tt.s.Intersection(tt.o))
+			assert.Equal(t, tt.expectedIntersection, tt.o.Intersection(tt.s))
+			// The operations must not modify the sets.
+			assert.Equal(t, s, tt.s)
+		})
+	}
+}
+
+func TestGroupMemberSetIntersectionKeepsItemsOfReceiver(t *testing.T) {
+	s := NewGroupMemberSet(pod1Ports)
+	o := NewGroupMemberSet(pod1, pod2, pod3)
+	assert.Same(t, pod1Ports, s.Intersection(o).Items()[0])
+	assert.Same(t, pod1, o.Intersection(s).Items()[0])
+}
+
+func TestGroupMemberSetMerge(t *testing.T) {
+	keepExisting := func(existing, item *GroupMember) *GroupMember {
+		return existing
+	}
+	tests := []struct {
+		name     string
+		s, o     GroupMemberSet
+		resolve  func(existing, item *GroupMember) *GroupMember
+		expected []*GroupMember
+	}{
+		{
+			name:     "no conflict",
+			s:        NewGroupMemberSet(pod1),
+			o:        NewGroupMemberSet(pod2),
+			expected: []*GroupMember{pod1, pod2},
+		},
+		{
+			name:     "nil set",
+			s:        NewGroupMemberSet(pod1),
+			o:        nil,
+			expected: []*GroupMember{pod1},
+		},
+		{
+			name:     "conflict without resolve",
+			s:        NewGroupMemberSet(pod1, pod2),
+			o:        NewGroupMemberSet(pod1Ports, pod3),
+			expected: []*GroupMember{pod1Ports, pod2, pod3},
+		},
+		{
+			name:     "conflict resolved",
+			s:        NewGroupMemberSet(pod1, pod2),
+			o:        NewGroupMemberSet(pod1Ports, pod3),
+			resolve:  keepExisting,
+			expected: []*GroupMember{pod1, pod2, pod3},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := NewGroupMemberSet(tt.o.Items()...)
+			result := tt.s.Merge(tt.o, tt.resolve)
+			assert.ElementsMatch(t, tt.expected, result.Items())
+			// The set is merged in place, o is not modified.
+			assert.ElementsMatch(t, tt.expected, tt.s.Items())
+			assert.True(t, o.Equal(tt.o))
+		})
+	}
+}
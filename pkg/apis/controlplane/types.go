@@ -234,6 +234,12 @@ const (
 	ProtocolUDP Protocol = "UDP"
 	// ProtocolSCTP is the SCTP protocol.
 	ProtocolSCTP Protocol = "SCTP"
+	// ProtocolARP is the ARP protocol, only used by the layer 2 rules of
+	// ClusterNetworkPolicies.
+	ProtocolARP Protocol = "ARP"
+	// ProtocolICMPv6 is the ICMPv6 protocol, only used by the layer 2 rules of
+	// ClusterNetworkPolicies to match Neighbor Discovery messages.
+	ProtocolICMPv6 Protocol = "ICMPv6"
 )
 
 // Service describes a port to allow traffic on.
@@ -249,6 +255,11 @@ type Service struct {
 	// It can only be specified when a numerical `port` is specified.
 	// +optional
 	EndPort *int32
+	// ICMPType is the ICMPv6 message type which traffic must match. It can only be
+	// specified when protocol is ICMPv6. If not specified, this matches all the
+	// Neighbor Discovery message types.
+	// +optional
+	ICMPType *int32
 }
 
 // NetworkPolicyPeer describes a peer of NetworkPolicyRules.
@@ -321,6 +332,11 @@ type NetworkPolicyNodeStatus struct {
 	NodeName string
 	// The generation realized by the Node.
 	Generation int64
+	// The time at which the Node realized the generation.
+	RealizationTime metav1.Time
+	// The reason why the Node failed to realize some rules of the generation. It's empty if there
+	// is no failure, in which case the generation is considered realized by the Node.
+	RealizationError string
 }
 
 type GroupReference struct {
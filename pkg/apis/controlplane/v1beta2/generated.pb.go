@@ -1976,6 +1976,21 @@ func (m *NetworkPolicyNodeStatus) MarshalToSizedBuffer(dAtA []byte) (int, error)
 	_ = i
 	var l int
 	_ = l
+	i -= len(m.RealizationError)
+	copy(dAtA[i:], m.RealizationError)
+	i = encodeVarintGenerated(dAtA, i, uint64(len(m.RealizationError)))
+	i--
+	dAtA[i] = 0x22
+	{
+		size, err := m.RealizationTime.MarshalToSizedBuffer(dAtA[:i])
+		if err != nil {
+			return 0, err
+		}
+		i -= size
+		i = encodeVarintGenerated(dAtA, i, uint64(size))
+	}
+	i--
+	dAtA[i] = 0x1a
 	i = encodeVarintGenerated(dAtA, i, uint64(m.Generation))
 	i--
 	dAtA[i] = 0x10
@@ -2402,6 +2417,11 @@ func (m *Service) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if m.ICMPType != nil {
+		i = encodeVarintGenerated(dAtA, i, uint64(*m.ICMPType))
+		i--
+		dAtA[i] = 0x20
+	}
 	if m.EndPort != nil {
 		i = encodeVarintGenerated(dAtA, i, uint64(*m.EndPort))
 		i--
@@ -2839,6 +2859,10 @@ func (m *NetworkPolicyNodeStatus) Size() (n int) {
 	l = len(m.NodeName)
 	n += 1 + l + sovGenerated(uint64(l))
 	n += 1 + sovGenerated(uint64(m.Generation))
+	l = m.RealizationTime.Size()
+	n += 1 + l + sovGenerated(uint64(l))
+	l = len(m.RealizationError)
+	n += 1 + l + sovGenerated(uint64(l))
 	return n
 }
 
@@ -3010,6 +3034,9 @@ func (m *Service) Size() (n int) {
 	if m.EndPort != nil {
 		n += 1 + sovGenerated(uint64(*m.EndPort))
 	}
+	if m.ICMPType != nil {
+		n += 1 + sovGenerated(uint64(*m.ICMPType))
+	}
 	return n
 }
 
@@ -3349,6 +3376,8 @@ func (this *NetworkPolicyNodeStatus) String() string {
 	s := strings.Join([]string{`&NetworkPolicyNodeStatus{`,
 		`NodeName:` + fmt.Sprintf("%v", this.NodeName) + `,`,
 		`Generation:` + fmt.Sprintf("%v", this.Generation) + `,`,
+		`RealizationTime:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.RealizationTime), "Time", "v1.Time", 1), `&`, ``, 1) + `,`,
+		`RealizationError:` + fmt.Sprintf("%v", this.RealizationError) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -3485,6 +3514,7 @@ func (this *Service) String() string {
 		`Protocol:` + valueToStringGenerated(this.Protocol) + `,`,
 		`Port:` + strings.Replace(fmt.Sprintf("%v", this.Port), "IntOrString", "intstr.IntOrString", 1) + `,`,
 		`EndPort:` + valueToStringGenerated(this.EndPort) + `,`,
+		`ICMPType:` + valueToStringGenerated(this.ICMPType) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -6112,6 +6142,71 @@ func (m *NetworkPolicyNodeStatus) Unmarshal(dAtA []byte) error {
 					break
 				}
 			}
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field RealizationTime", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowGenerated
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthGenerated
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthGenerated
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if err := m.RealizationTime.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field RealizationError", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowGenerated
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthGenerated
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthGenerated
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.RealizationError = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipGenerated(dAtA[iNdEx:])
@@ -7429,6 +7524,26 @@ func (m *Service) Unmarshal(dAtA []byte) error {
 				}
 			}
 			m.EndPort = &v
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ICMPType", wireType)
+			}
+			var v int32
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowGenerated
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.ICMPType = &v
 		default:
 			iNdEx = preIndex
 			skippy, err := skipGenerated(dAtA[iNdEx:])
@@ -118,6 +118,36 @@ func (s GroupMemberSet) Union(o GroupMemberSet) GroupMemberSet {
 	return result
 }
 
+// Intersection returns a new set which includes the items in both s and o.
+func (s GroupMemberSet) Intersection(o GroupMemberSet) GroupMemberSet {
+	small, large := s, o
+	if len(o) < len(s) {
+		small, large = o, s
+	}
+	result := GroupMemberSet{}
+	for key := range small {
+		if _, contained := large[key]; contained {
+			// Always keep the item of s, regardless of which set is iterated.
+			result[key] = s[key]
+		}
+	}
+	return result
+}
+
+// Merge inserts the items of o into s in place and returns s. When an item of o
+// has the same key as an item of s, e.g. a Pod whose named ports changed, resolve
+// is called with the item of s and the item of o, and the returned item is kept.
+// If resolve is nil, the item of o is kept.
+func (s GroupMemberSet) Merge(o GroupMemberSet, resolve func(existing, item *GroupMember) *GroupMember) GroupMemberSet {
+	for key, item := range o {
+		if existing, contained := s[key]; contained && resolve != nil {
+			item = resolve(existing, item)
+		}
+		s[key] = item
+	}
+	return s
+}
+
 // IsSuperset returns true if and only if s1 is a superset of s2.
 func (s GroupMemberSet) IsSuperset(o GroupMemberSet) bool {
 	for key := range o {
@@ -244,6 +244,12 @@ const (
 	ProtocolUDP Protocol = "UDP"
 	// ProtocolSCTP is the SCTP protocol.
 	ProtocolSCTP Protocol = "SCTP"
+	// ProtocolARP is the ARP protocol, only used by the layer 2 rules of
+	// ClusterNetworkPolicies.
+	ProtocolARP Protocol = "ARP"
+	// ProtocolICMPv6 is the ICMPv6 protocol, only used by the layer 2 rules of
+	// ClusterNetworkPolicies to match Neighbor Discovery messages.
+	ProtocolICMPv6 Protocol = "ICMPv6"
 )
 
 // Service describes a port to allow traffic on.
@@ -259,6 +265,11 @@ type Service struct {
 	// It can only be specified when a numerical `port` is specified.
 	// +optional
 	EndPort *int32 `json:"endPort,omitempty" protobuf:"bytes,3,opt,name=endPort"`
+	// ICMPType is the ICMPv6 message type which traffic must match. It can only be
+	// specified when protocol is ICMPv6. If not specified, this matches all the
+	// Neighbor Discovery message types.
+	// +optional
+	ICMPType *int32 `json:"icmpType,omitempty" protobuf:"varint,4,opt,name=icmpType"`
 }
 
 // NetworkPolicyPeer describes a peer of NetworkPolicyRules.
@@ -334,6 +345,11 @@ type NetworkPolicyNodeStatus struct {
 	NodeName string `json:"nodeName,omitempty" protobuf:"bytes,1,opt,name=nodeName"`
 	// The generation realized by the Node.
 	Generation int64 `json:"generation,omitempty" protobuf:"varint,2,opt,name=generation"`
+	// The time at which the Node realized the generation.
+	RealizationTime metav1.Time `json:"realizationTime,omitempty" protobuf:"bytes,3,opt,name=realizationTime"`
+	// The reason why the Node failed to realize some rules of the generation. It's empty if there
+	// is no failure, in which case the generation is considered realized by the Node.
+	RealizationError string `json:"realizationError,omitempty" protobuf:"bytes,4,opt,name=realizationError"`
 }
 
 type GroupReference struct {
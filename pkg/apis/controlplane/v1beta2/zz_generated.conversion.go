@@ -817,6 +817,8 @@ func Convert_controlplane_NetworkPolicyList_To_v1beta2_NetworkPolicyList(in *con
 func autoConvert_v1beta2_NetworkPolicyNodeStatus_To_controlplane_NetworkPolicyNodeStatus(in *NetworkPolicyNodeStatus, out *controlplane.NetworkPolicyNodeStatus, s conversion.Scope) error {
 	out.NodeName = in.NodeName
 	out.Generation = in.Generation
+	out.RealizationTime = in.RealizationTime
+	out.RealizationError = in.RealizationError
 	return nil
 }
 
@@ -828,6 +830,8 @@ func Convert_v1beta2_NetworkPolicyNodeStatus_To_controlplane_NetworkPolicyNodeSt
 func autoConvert_controlplane_NetworkPolicyNodeStatus_To_v1beta2_NetworkPolicyNodeStatus(in *controlplane.NetworkPolicyNodeStatus, out *NetworkPolicyNodeStatus, s conversion.Scope) error {
 	out.NodeName = in.NodeName
 	out.Generation = in.Generation
+	out.RealizationTime = in.RealizationTime
+	out.RealizationError = in.RealizationError
 	return nil
 }
 
@@ -1030,6 +1034,7 @@ func autoConvert_v1beta2_Service_To_controlplane_Service(in *Service, out *contr
 	out.Protocol = (*controlplane.Protocol)(unsafe.Pointer(in.Protocol))
 	out.Port = (*intstr.IntOrString)(unsafe.Pointer(in.Port))
 	out.EndPort = (*int32)(unsafe.Pointer(in.EndPort))
+	out.ICMPType = (*int32)(unsafe.Pointer(in.ICMPType))
 	return nil
 }
 
@@ -1042,6 +1047,7 @@ func autoConvert_controlplane_Service_To_v1beta2_Service(in *controlplane.Servic
 	out.Protocol = (*Protocol)(unsafe.Pointer(in.Protocol))
 	out.Port = (*intstr.IntOrString)(unsafe.Pointer(in.Port))
 	out.EndPort = (*int32)(unsafe.Pointer(in.EndPort))
+	out.ICMPType = (*int32)(unsafe.Pointer(in.ICMPType))
 	return nil
 }
 
@@ -651,6 +651,7 @@ func (in *NetworkPolicyList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NetworkPolicyNodeStatus) DeepCopyInto(out *NetworkPolicyNodeStatus) {
 	*out = *in
+	in.RealizationTime.DeepCopyInto(&out.RealizationTime)
 	return
 }
 
@@ -774,7 +775,9 @@ func (in *NetworkPolicyStatus) DeepCopyInto(out *NetworkPolicyStatus) {
 	if in.Nodes != nil {
 		in, out := &in.Nodes, &out.Nodes
 		*out = make([]NetworkPolicyNodeStatus, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 	return
 }
@@ -878,6 +881,11 @@ func (in *Service) DeepCopyInto(out *Service) {
 		*out = new(int32)
 		**out = **in
 	}
+	if in.ICMPType != nil {
+		in, out := &in.ICMPType, &out.ICMPType
+		*out = new(int32)
+		**out = **in
+	}
 	return
 }
 
@@ -0,0 +1,337 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	"encoding/json"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"antrea.io/antrea/pkg/apis/crd/v1beta1"
+)
+
+// V1beta1FieldsAnnotation is the annotation in which the fields of a v1beta1 Antrea-native policy
+// that cannot be represented in v1alpha1 are preserved when the policy is converted to v1alpha1,
+// so that converting it back to v1beta1 is lossless. It is removed when converting to v1beta1.
+const V1beta1FieldsAnnotation = "crd.antrea.io/v1beta1-fields"
+
+// v1beta1Fields is the value of the V1beta1FieldsAnnotation annotation.
+type v1beta1Fields struct {
+	// IPBlockExcept is keyed by the path of the NetworkPolicyPeer whose IPBlock has the Except
+	// CIDRs, e.g. "spec.ingress[0].from[1]".
+	IPBlockExcept map[string][]string `json:"ipBlockExcept,omitempty"`
+	// Layer2 is the layer 2 rules of a ClusterNetworkPolicy.
+	Layer2 []v1beta1.Layer2Rule `json:"layer2,omitempty"`
+}
+
+// Convert_v1alpha1_NetworkPolicy_To_v1beta1_NetworkPolicy converts a v1alpha1 NetworkPolicy to the
+// v1beta1 hub version. TypeMeta is left to the caller.
+func Convert_v1alpha1_NetworkPolicy_To_v1beta1_NetworkPolicy(in *NetworkPolicy, out *v1beta1.NetworkPolicy) error {
+	fields, err := popV1beta1Fields(&in.ObjectMeta, &out.ObjectMeta)
+	if err != nil {
+		return err
+	}
+	out.Spec = v1beta1.NetworkPolicySpec{
+		Tier:      in.Spec.Tier,
+		Priority:  in.Spec.Priority,
+		AppliedTo: convertPeersToV1beta1(in.Spec.AppliedTo, "spec.appliedTo", fields),
+		Ingress:   convertRulesToV1beta1(in.Spec.Ingress, "spec.ingress", fields),
+		Egress:    convertRulesToV1beta1(in.Spec.Egress, "spec.egress", fields),
+	}
+	out.Status = convertStatusToV1beta1(&in.Status)
+	return nil
+}
+
+// Convert_v1beta1_NetworkPolicy_To_v1alpha1_NetworkPolicy converts a v1beta1 NetworkPolicy to
+// v1alpha1. TypeMeta is left to the caller.
+func Convert_v1beta1_NetworkPolicy_To_v1alpha1_NetworkPolicy(in *v1beta1.NetworkPolicy, out *NetworkPolicy) error {
+	fields := &v1beta1Fields{}
+	out.Spec = NetworkPolicySpec{
+		Tier:      in.Spec.Tier,
+		Priority:  in.Spec.Priority,
+		AppliedTo: convertPeersFromV1beta1(in.Spec.AppliedTo, "spec.appliedTo", fields),
+		Ingress:   convertRulesFromV1beta1(in.Spec.Ingress, "spec.ingress", fields),
+		Egress:    convertRulesFromV1beta1(in.Spec.Egress, "spec.egress", fields),
+	}
+	out.Status = convertStatusFromV1beta1(&in.Status)
+	return pushV1beta1Fields(&in.ObjectMeta, &out.ObjectMeta, fields)
+}
+
+// Convert_v1alpha1_ClusterNetworkPolicy_To_v1beta1_ClusterNetworkPolicy converts a v1alpha1
+// ClusterNetworkPolicy to the v1beta1 hub version. TypeMeta is left to the caller.
+func Convert_v1alpha1_ClusterNetworkPolicy_To_v1beta1_ClusterNetworkPolicy(in *ClusterNetworkPolicy, out *v1beta1.ClusterNetworkPolicy) error {
+	fields, err := popV1beta1Fields(&in.ObjectMeta, &out.ObjectMeta)
+	if err != nil {
+		return err
+	}
+	out.Spec = v1beta1.ClusterNetworkPolicySpec{
+		Tier:      in.Spec.Tier,
+		Priority:  in.Spec.Priority,
+		AppliedTo: convertPeersToV1beta1(in.Spec.AppliedTo, "spec.appliedTo", fields),
+		Ingress:   convertRulesToV1beta1(in.Spec.Ingress, "spec.ingress", fields),
+		Egress:    convertRulesToV1beta1(in.Spec.Egress, "spec.egress", fields),
+		Layer2:    fields.Layer2,
+	}
+	out.Status = convertStatusToV1beta1(&in.Status)
+	return nil
+}
+
+// Convert_v1beta1_ClusterNetworkPolicy_To_v1alpha1_ClusterNetworkPolicy converts a v1beta1
+// ClusterNetworkPolicy to v1alpha1. TypeMeta is left to the caller.
+func Convert_v1beta1_ClusterNetworkPolicy_To_v1alpha1_ClusterNetworkPolicy(in *v1beta1.ClusterNetworkPolicy, out *ClusterNetworkPolicy) error {
+	fields := &v1beta1Fields{Layer2: in.Spec.Layer2}
+	out.Spec = ClusterNetworkPolicySpec{
+		Tier:      in.Spec.Tier,
+		Priority:  in.Spec.Priority,
+		AppliedTo: convertPeersFromV1beta1(in.Spec.AppliedTo, "spec.appliedTo", fields),
+		Ingress:   convertRulesFromV1beta1(in.Spec.Ingress, "spec.ingress", fields),
+		Egress:    convertRulesFromV1beta1(in.Spec.Egress, "spec.egress", fields),
+	}
+	out.Status = convertStatusFromV1beta1(&in.Status)
+	return pushV1beta1Fields(&in.ObjectMeta, &out.ObjectMeta, fields)
+}
+
+// popV1beta1Fields copies in to out without the V1beta1FieldsAnnotation annotation, and returns
+// the fields preserved in the annotation.
+func popV1beta1Fields(in, out *metav1.ObjectMeta) (*v1beta1Fields, error) {
+	*out = *in
+	fields := &v1beta1Fields{}
+	value, ok := in.Annotations[V1beta1FieldsAnnotation]
+	if !ok {
+		return fields, nil
+	}
+	if err := json.Unmarshal([]byte(value), fields); err != nil {
+		return nil, fmt.Errorf("invalid value for annotation %s: %v", V1beta1FieldsAnnotation, err)
+	}
+	out.Annotations = make(map[string]string, len(in.Annotations)-1)
+	for k, v := range in.Annotations {
+		if k != V1beta1FieldsAnnotation {
+			out.Annotations[k] = v
+		}
+	}
+	if len(out.Annotations) == 0 {
+		out.Annotations = nil
+	}
+	return fields, nil
+}
+
+// pushV1beta1Fields copies in to out, and preserves the fields in the V1beta1FieldsAnnotation
+// annotation if there is any.
+func pushV1beta1Fields(in, out *metav1.ObjectMeta, fields *v1beta1Fields) error {
+	*out = *in
+	if len(fields.IPBlockExcept) == 0 && len(fields.Layer2) == 0 {
+		return nil
+	}
+	value, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	out.Annotations = make(map[string]string, len(in.Annotations)+1)
+	for k, v := range in.Annotations {
+		out.Annotations[k] = v
+	}
+	out.Annotations[V1beta1FieldsAnnotation] = string(value)
+	return nil
+}
+
+// convertStatusToV1beta1 converts the flat realization latency fields of v1alpha1 to the nested
+// v1beta1 ones. As the v1alpha1 fields are omitted when empty, a zero latency is not set.
+func convertStatusToV1beta1(in *NetworkPolicyStatus) v1beta1.NetworkPolicyStatus {
+	out := v1beta1.NetworkPolicyStatus{
+		Phase:                v1beta1.NetworkPolicyPhase(in.Phase),
+		ObservedGeneration:   in.ObservedGeneration,
+		CurrentNodesRealized: in.CurrentNodesRealized,
+		DesiredNodesRealized: in.DesiredNodesRealized,
+	}
+	if in.RealizationLatencyP50Milliseconds != 0 || in.RealizationLatencyP99Milliseconds != 0 {
+		out.RealizationLatency = &v1beta1.RealizationLatency{
+			P50Milliseconds: in.RealizationLatencyP50Milliseconds,
+			P99Milliseconds: in.RealizationLatencyP99Milliseconds,
+		}
+	}
+	if in.Conditions != nil {
+		out.Conditions = make([]v1beta1.NetworkPolicyCondition, len(in.Conditions))
+		for i := range in.Conditions {
+			out.Conditions[i] = v1beta1.NetworkPolicyCondition{
+				Type:    v1beta1.NetworkPolicyConditionType(in.Conditions[i].Type),
+				Status:  in.Conditions[i].Status,
+				Reason:  in.Conditions[i].Reason,
+				Message: in.Conditions[i].Message,
+			}
+		}
+	}
+	return out
+}
+
+func convertStatusFromV1beta1(in *v1beta1.NetworkPolicyStatus) NetworkPolicyStatus {
+	out := NetworkPolicyStatus{
+		Phase:                NetworkPolicyPhase(in.Phase),
+		ObservedGeneration:   in.ObservedGeneration,
+		CurrentNodesRealized: in.CurrentNodesRealized,
+		DesiredNodesRealized: in.DesiredNodesRealized,
+	}
+	if in.RealizationLatency != nil {
+		out.RealizationLatencyP50Milliseconds = in.RealizationLatency.P50Milliseconds
+		out.RealizationLatencyP99Milliseconds = in.RealizationLatency.P99Milliseconds
+	}
+	if in.Conditions != nil {
+		out.Conditions = make([]NetworkPolicyCondition, len(in.Conditions))
+		for i := range in.Conditions {
+			out.Conditions[i] = NetworkPolicyCondition{
+				Type:    NetworkPolicyConditionType(in.Conditions[i].Type),
+				Status:  in.Conditions[i].Status,
+				Reason:  in.Conditions[i].Reason,
+				Message: in.Conditions[i].Message,
+			}
+		}
+	}
+	return out
+}
+
+func convertRulesToV1beta1(in []Rule, path string, fields *v1beta1Fields) []v1beta1.Rule {
+	if in == nil {
+		return nil
+	}
+	out := make([]v1beta1.Rule, len(in))
+	for i := range in {
+		rulePath := fmt.Sprintf("%s[%d]", path, i)
+		out[i] = v1beta1.Rule{
+			Action:        (*v1beta1.RuleAction)(in[i].Action),
+			Ports:         convertPortsToV1beta1(in[i].Ports),
+			From:          convertPeersToV1beta1(in[i].From, rulePath+".from", fields),
+			To:            convertPeersToV1beta1(in[i].To, rulePath+".to", fields),
+			Name:          in[i].Name,
+			EnableLogging: in[i].EnableLogging,
+			AppliedTo:     convertPeersToV1beta1(in[i].AppliedTo, rulePath+".appliedTo", fields),
+		}
+	}
+	return out
+}
+
+func convertRulesFromV1beta1(in []v1beta1.Rule, path string, fields *v1beta1Fields) []Rule {
+	if in == nil {
+		return nil
+	}
+	out := make([]Rule, len(in))
+	for i := range in {
+		rulePath := fmt.Sprintf("%s[%d]", path, i)
+		out[i] = Rule{
+			Action:        (*RuleAction)(in[i].Action),
+			Ports:         convertPortsFromV1beta1(in[i].Ports),
+			From:          convertPeersFromV1beta1(in[i].From, rulePath+".from", fields),
+			To:            convertPeersFromV1beta1(in[i].To, rulePath+".to", fields),
+			Name:          in[i].Name,
+			EnableLogging: in[i].EnableLogging,
+			AppliedTo:     convertPeersFromV1beta1(in[i].AppliedTo, rulePath+".appliedTo", fields),
+		}
+	}
+	return out
+}
+
+func convertPortsToV1beta1(in []NetworkPolicyPort) []v1beta1.NetworkPolicyPort {
+	if in == nil {
+		return nil
+	}
+	out := make([]v1beta1.NetworkPolicyPort, len(in))
+	for i := range in {
+		out[i] = v1beta1.NetworkPolicyPort{
+			Protocol: in[i].Protocol,
+			Port:     in[i].Port,
+			EndPort:  in[i].EndPort,
+		}
+	}
+	return out
+}
+
+func convertPortsFromV1beta1(in []v1beta1.NetworkPolicyPort) []NetworkPolicyPort {
+	if in == nil {
+		return nil
+	}
+	out := make([]NetworkPolicyPort, len(in))
+	for i := range in {
+		out[i] = NetworkPolicyPort{
+			Protocol: in[i].Protocol,
+			Port:     in[i].Port,
+			EndPort:  in[i].EndPort,
+		}
+	}
+	return out
+}
+
+// convertPeersToV1beta1 converts NetworkPolicyPeers to v1beta1, restoring the Except CIDRs of
+// their IPBlocks from fields.
+func convertPeersToV1beta1(in []NetworkPolicyPeer, path string, fields *v1beta1Fields) []v1beta1.NetworkPolicyPeer {
+	if in == nil {
+		return nil
+	}
+	out := make([]v1beta1.NetworkPolicyPeer, len(in))
+	for i := range in {
+		out[i] = v1beta1.NetworkPolicyPeer{
+			PodSelector:            in[i].PodSelector,
+			NamespaceSelector:      in[i].NamespaceSelector,
+			ExternalEntitySelector: in[i].ExternalEntitySelector,
+			Group:                  in[i].Group,
+			NodeSelector:           in[i].NodeSelector,
+		}
+		if in[i].IPBlock != nil {
+			out[i].IPBlock = &v1beta1.IPBlock{
+				CIDR:   in[i].IPBlock.CIDR,
+				Except: fields.IPBlockExcept[fmt.Sprintf("%s[%d]", path, i)],
+			}
+		}
+		if in[i].Namespaces != nil {
+			out[i].Namespaces = &v1beta1.PeerNamespaces{Match: v1beta1.NamespaceMatchType(in[i].Namespaces.Match)}
+		}
+		if in[i].ServiceAccount != nil {
+			out[i].ServiceAccount = &v1beta1.NamespacedName{Name: in[i].ServiceAccount.Name, Namespace: in[i].ServiceAccount.Namespace}
+		}
+	}
+	return out
+}
+
+// convertPeersFromV1beta1 converts NetworkPolicyPeers from v1beta1, saving the Except CIDRs of
+// their IPBlocks to fields.
+func convertPeersFromV1beta1(in []v1beta1.NetworkPolicyPeer, path string, fields *v1beta1Fields) []NetworkPolicyPeer {
+	if in == nil {
+		return nil
+	}
+	out := make([]NetworkPolicyPeer, len(in))
+	for i := range in {
+		out[i] = NetworkPolicyPeer{
+			PodSelector:            in[i].PodSelector,
+			NamespaceSelector:      in[i].NamespaceSelector,
+			ExternalEntitySelector: in[i].ExternalEntitySelector,
+			Group:                  in[i].Group,
+			NodeSelector:           in[i].NodeSelector,
+		}
+		if in[i].IPBlock != nil {
+			out[i].IPBlock = &IPBlock{CIDR: in[i].IPBlock.CIDR}
+			if len(in[i].IPBlock.Except) > 0 {
+				if fields.IPBlockExcept == nil {
+					fields.IPBlockExcept = map[string][]string{}
+				}
+				fields.IPBlockExcept[fmt.Sprintf("%s[%d]", path, i)] = in[i].IPBlock.Except
+			}
+		}
+		if in[i].Namespaces != nil {
+			out[i].Namespaces = &PeerNamespaces{Match: NamespaceMatchType(in[i].Namespaces.Match)}
+		}
+		if in[i].ServiceAccount != nil {
+			out[i].ServiceAccount = &NamespacedName{Name: in[i].ServiceAccount.Name, Namespace: in[i].ServiceAccount.Namespace}
+		}
+	}
+	return out
+}
@@ -0,0 +1,160 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	"testing"
+
+	fuzz "github.com/google/gofuzz"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/diff"
+
+	"antrea.io/antrea/pkg/apis/crd/v1beta1"
+)
+
+const fuzzIterations = 1000
+
+func newFuzzer() *fuzz.Fuzzer {
+	return fuzz.New().NilChance(0.2).NumElements(0, 3).Funcs(
+		// TypeMeta is set by the caller of the conversion functions, and the other fields of
+		// ObjectMeta are copied as a whole.
+		func(t *metav1.TypeMeta, c fuzz.Continue) {},
+		func(m *metav1.ObjectMeta, c fuzz.Continue) {
+			c.Fuzz(&m.Name)
+			c.Fuzz(&m.Namespace)
+			c.Fuzz(&m.Labels)
+			c.Fuzz(&m.Annotations)
+		},
+		// A zero realization latency cannot be represented in v1alpha1.
+		func(s *v1beta1.NetworkPolicyStatus, c fuzz.Continue) {
+			c.FuzzNoCustom(s)
+			if s.RealizationLatency != nil && *s.RealizationLatency == (v1beta1.RealizationLatency{}) {
+				s.RealizationLatency = nil
+			}
+		},
+	)
+}
+
+func TestNetworkPolicyRoundTrip(t *testing.T) {
+	f := newFuzzer()
+	for i := 0; i < fuzzIterations; i++ {
+		hub := &v1beta1.NetworkPolicy{}
+		f.Fuzz(hub)
+		spoke := &NetworkPolicy{}
+		require.NoError(t, Convert_v1beta1_NetworkPolicy_To_v1alpha1_NetworkPolicy(hub.DeepCopy(), spoke))
+		restored := &v1beta1.NetworkPolicy{}
+		require.NoError(t, Convert_v1alpha1_NetworkPolicy_To_v1beta1_NetworkPolicy(spoke, restored))
+		require.True(t, apiequality.Semantic.DeepEqual(hub, restored), "v1beta1 -> v1alpha1 -> v1beta1 round trip failed:\n%s", diff.ObjectReflectDiff(hub, restored))
+
+		spoke = &NetworkPolicy{}
+		f.Fuzz(spoke)
+		hub = &v1beta1.NetworkPolicy{}
+		require.NoError(t, Convert_v1alpha1_NetworkPolicy_To_v1beta1_NetworkPolicy(spoke.DeepCopy(), hub))
+		restoredSpoke := &NetworkPolicy{}
+		require.NoError(t, Convert_v1beta1_NetworkPolicy_To_v1alpha1_NetworkPolicy(hub, restoredSpoke))
+		require.True(t, apiequality.Semantic.DeepEqual(spoke, restoredSpoke), "v1alpha1 -> v1beta1 -> v1alpha1 round trip failed:\n%s", diff.ObjectReflectDiff(spoke, restoredSpoke))
+	}
+}
+
+func TestClusterNetworkPolicyRoundTrip(t *testing.T) {
+	f := newFuzzer()
+	for i := 0; i < fuzzIterations; i++ {
+		hub := &v1beta1.ClusterNetworkPolicy{}
+		f.Fuzz(hub)
+		spoke := &ClusterNetworkPolicy{}
+		require.NoError(t, Convert_v1beta1_ClusterNetworkPolicy_To_v1alpha1_ClusterNetworkPolicy(hub.DeepCopy(), spoke))
+		restored := &v1beta1.ClusterNetworkPolicy{}
+		require.NoError(t, Convert_v1alpha1_ClusterNetworkPolicy_To_v1beta1_ClusterNetworkPolicy(spoke, restored))
+		require.True(t, apiequality.Semantic.DeepEqual(hub, restored), "v1beta1 -> v1alpha1 -> v1beta1 round trip failed:\n%s", diff.ObjectReflectDiff(hub, restored))
+
+		spoke = &ClusterNetworkPolicy{}
+		f.Fuzz(spoke)
+		hub = &v1beta1.ClusterNetworkPolicy{}
+		require.NoError(t, Convert_v1alpha1_ClusterNetworkPolicy_To_v1beta1_ClusterNetworkPolicy(spoke.DeepCopy(), hub))
+		restoredSpoke := &ClusterNetworkPolicy{}
+		require.NoError(t, Convert_v1beta1_ClusterNetworkPolicy_To_v1alpha1_ClusterNetworkPolicy(hub, restoredSpoke))
+		require.True(t, apiequality.Semantic.DeepEqual(spoke, restoredSpoke), "v1alpha1 -> v1beta1 -> v1alpha1 round trip failed:\n%s", diff.ObjectReflectDiff(spoke, restoredSpoke))
+	}
+}
+
+func TestConvertIPBlockExcept(t *testing.T) {
+	hub := &v1beta1.ClusterNetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "acnp", Annotations: map[string]string{"foo": "bar"}},
+		Spec: v1beta1.ClusterNetworkPolicySpec{
+			Egress: []v1beta1.Rule{{
+				To: []v1beta1.NetworkPolicyPeer{
+					{IPBlock: &v1beta1.IPBlock{CIDR: "10.0.0.0/16"}},
+					{IPBlock: &v1beta1.IPBlock{CIDR: "10.1.0.0/16", Except: []string{"10.1.1.0/24"}}},
+				},
+			}},
+		},
+	}
+	spoke := &ClusterNetworkPolicy{}
+	require.NoError(t, Convert_v1beta1_ClusterNetworkPolicy_To_v1alpha1_ClusterNetworkPolicy(hub, spoke))
+	assert.Equal(t, &IPBlock{CIDR: "10.1.0.0/16"}, spoke.Spec.Egress[0].To[1].IPBlock)
+	assert.Equal(t, map[string]string{
+		"foo":                   "bar",
+		V1beta1FieldsAnnotation: `{"ipBlockExcept":{"spec.egress[0].to[1]":["10.1.1.0/24"]}}`,
+	}, spoke.Annotations)
+	// The annotations of the converted object must not be shared.
+	assert.Equal(t, map[string]string{"foo": "bar"}, hub.Annotations)
+
+	restored := &v1beta1.ClusterNetworkPolicy{}
+	require.NoError(t, Convert_v1alpha1_ClusterNetworkPolicy_To_v1beta1_ClusterNetworkPolicy(spoke, restored))
+	assert.Equal(t, hub, restored)
+
+	spoke.Annotations[V1beta1FieldsAnnotation] = "invalid"
+	assert.Error(t, Convert_v1alpha1_ClusterNetworkPolicy_To_v1beta1_ClusterNetworkPolicy(spoke, restored))
+}
+
+func TestConvertLayer2Rules(t *testing.T) {
+	drop := v1beta1.RuleActionDrop
+	hub := &v1beta1.ClusterNetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "acnp"},
+		Spec: v1beta1.ClusterNetworkPolicySpec{
+			Layer2: []v1beta1.Layer2Rule{{
+				Action:   &drop,
+				Protocol: v1beta1.Layer2ProtocolARP,
+				To:       []v1beta1.NetworkPolicyPeer{{IPBlock: &v1beta1.IPBlock{CIDR: "10.0.0.0/16"}}},
+			}},
+		},
+	}
+	spoke := &ClusterNetworkPolicy{}
+	require.NoError(t, Convert_v1beta1_ClusterNetworkPolicy_To_v1alpha1_ClusterNetworkPolicy(hub, spoke))
+	assert.Equal(t, map[string]string{
+		V1beta1FieldsAnnotation: `{"layer2":[{"action":"Drop","protocol":"ARP","to":[{"ipBlock":{"cidr":"10.0.0.0/16"}}]}]}`,
+	}, spoke.Annotations)
+
+	restored := &v1beta1.ClusterNetworkPolicy{}
+	require.NoError(t, Convert_v1alpha1_ClusterNetworkPolicy_To_v1beta1_ClusterNetworkPolicy(spoke, restored))
+	assert.Equal(t, hub, restored)
+}
+
+func TestConvertRealizationLatency(t *testing.T) {
+	spoke := &NetworkPolicy{Status: NetworkPolicyStatus{
+		Phase:                             NetworkPolicyRealized,
+		RealizationLatencyP50Milliseconds: 100,
+		RealizationLatencyP99Milliseconds: 300,
+	}}
+	hub := &v1beta1.NetworkPolicy{}
+	require.NoError(t, Convert_v1alpha1_NetworkPolicy_To_v1beta1_NetworkPolicy(spoke, hub))
+	assert.Equal(t, v1beta1.NetworkPolicyStatus{
+		Phase:              v1beta1.NetworkPolicyRealized,
+		RealizationLatency: &v1beta1.RealizationLatency{P50Milliseconds: 100, P99Milliseconds: 300},
+	}, hub.Status)
+}
@@ -47,6 +47,9 @@ const (
 	ActionForwarded TraceflowAction = "Forwarded"
 	ActionDropped   TraceflowAction = "Dropped"
 	ActionRejected  TraceflowAction = "Rejected"
+	// ActionPassed indicates that the packet matched an Antrea-native policy rule with the Pass
+	// action, and was deferred to K8s NetworkPolicies and the baseline Tier.
+	ActionPassed TraceflowAction = "Passed"
 	// ActionForwardedOutOfOverlay indicates that the packet has been forwarded out of the network
 	// managed by Antrea. This indicates that the Traceflow request can be considered complete.
 	ActionForwardedOutOfOverlay TraceflowAction = "ForwardedOutOfOverlay"
@@ -254,6 +257,8 @@ type Observation struct {
 	DstMAC string `json:"dstMAC,omitempty" yaml:"dstMAC,omitempty"`
 	// NetworkPolicy is the combination of Namespace and NetworkPolicyName.
 	NetworkPolicy string `json:"networkPolicy,omitempty" yaml:"networkPolicy,omitempty"`
+	// NetworkPolicyRule is the name of the NetworkPolicy rule.
+	NetworkPolicyRule string `json:"networkPolicyRule,omitempty" yaml:"networkPolicyRule,omitempty"`
 	// TTL is the observation TTL.
 	TTL int32 `json:"ttl,omitempty" yaml:"ttl,omitempty"`
 	// TranslatedSrcIP is the translated source IP.
@@ -273,6 +278,98 @@ type TraceflowList struct {
 	Items []Traceflow `json:"items"`
 }
 
+type PacketCapturePhase string
+
+const (
+	PacketCaptureRunning   PacketCapturePhase = "Running"
+	PacketCaptureSucceeded PacketCapturePhase = "Succeeded"
+	PacketCaptureFailed    PacketCapturePhase = "Failed"
+)
+
+const (
+	// DefaultPacketCaptureMaxPackets is the number of packets captured when
+	// MaxPackets is not set.
+	DefaultPacketCaptureMaxPackets int32 = 100
+	// MaxPacketCaptureMaxPackets is the upper bound of MaxPackets.
+	MaxPacketCaptureMaxPackets int32 = 10000
+	// DefaultPacketCaptureTimeout is the timeout in seconds when Timeout is
+	// not set.
+	DefaultPacketCaptureTimeout uint16 = 60
+	// MaxPacketCaptureTimeout is the upper bound of Timeout in seconds.
+	MaxPacketCaptureTimeout uint16 = 300
+)
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// PacketCapture captures the live traffic of a Pod into a pcap file, on the
+// Node where the Pod is running.
+type PacketCapture struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   PacketCaptureSpec   `json:"spec,omitempty"`
+	Status PacketCaptureStatus `json:"status,omitempty"`
+}
+
+// PacketCaptureSpec describes the spec of the PacketCapture.
+type PacketCaptureSpec struct {
+	// Source and Destination select the captured packets. At least one of
+	// them must be a Pod. Packets are captured on the Node of the source Pod
+	// if it is set, otherwise on the Node of the destination Pod.
+	Source      PacketCapturePeer `json:"source,omitempty"`
+	Destination PacketCapturePeer `json:"destination,omitempty"`
+	// Protocol is the IP protocol of the captured packets: TCP, UDP or ICMP.
+	// Packets of all protocols are captured if not set.
+	Protocol string `json:"protocol,omitempty"`
+	// SrcPort and DstPort are the transport ports of the captured packets.
+	// They can only be set when Protocol is TCP or UDP.
+	SrcPort int32 `json:"srcPort,omitempty"`
+	DstPort int32 `json:"dstPort,omitempty"`
+	// MaxPackets is the number of packets after which the capture stops.
+	// Defaults to 100 and cannot exceed 10000.
+	MaxPackets int32 `json:"maxPackets,omitempty"`
+	// Timeout specifies the duration of the capture in seconds. Defaults to
+	// 60 seconds and cannot exceed 300 seconds.
+	Timeout uint16 `json:"timeout,omitempty"`
+}
+
+// PacketCapturePeer describes the source or destination of the captured
+// packets.
+type PacketCapturePeer struct {
+	// Namespace is the Namespace of the Pod.
+	Namespace string `json:"namespace,omitempty"`
+	// Pod is the name of the Pod, exclusive with IP.
+	Pod string `json:"pod,omitempty"`
+	// IP is the IPv4 address, exclusive with Pod.
+	IP string `json:"ip,omitempty"`
+}
+
+// PacketCaptureStatus describes current status of the PacketCapture.
+type PacketCaptureStatus struct {
+	// Phase is the PacketCapture phase.
+	Phase PacketCapturePhase `json:"phase,omitempty"`
+	// Reason is a message indicating the reason of the phase.
+	Reason string `json:"reason,omitempty"`
+	// NodeName is the Node where the packets are captured. The pcap file can
+	// be downloaded from the antrea-agent running on this Node.
+	NodeName string `json:"nodeName,omitempty"`
+	// NumCapturedPackets is the number of packets written to the pcap file.
+	NumCapturedPackets int32 `json:"numCapturedPackets,omitempty"`
+	// FileSize is the size of the pcap file in bytes.
+	FileSize int64 `json:"fileSize,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+type PacketCaptureList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []PacketCapture `json:"items"`
+}
+
 // +genclient
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
@@ -337,6 +434,40 @@ type NetworkPolicyStatus struct {
 	CurrentNodesRealized int32 `json:"currentNodesRealized"`
 	// The total number of nodes that should realize the NetworkPolicy.
 	DesiredNodesRealized int32 `json:"desiredNodesRealized"`
+	// The median latency, in milliseconds, between the creation of the observed generation in the
+	// kube-apiserver and its realization, across the nodes that have realized it.
+	RealizationLatencyP50Milliseconds int64 `json:"realizationLatencyP50Milliseconds,omitempty"`
+	// The 99th percentile latency, in milliseconds, between the creation of the observed generation
+	// in the kube-apiserver and its realization, across the nodes that have realized it.
+	RealizationLatencyP99Milliseconds int64 `json:"realizationLatencyP99Milliseconds,omitempty"`
+	// The latest available observations of the NetworkPolicy's state.
+	// +optional
+	Conditions []NetworkPolicyCondition `json:"conditions,omitempty"`
+}
+
+// NetworkPolicyConditionType describes the type of a NetworkPolicy condition.
+type NetworkPolicyConditionType string
+
+// These are the valid values for NetworkPolicyConditionType.
+const (
+	// NetworkPolicyRealizationFailure means some Nodes failed to realize some rules of the
+	// NetworkPolicy, and keep retrying.
+	NetworkPolicyRealizationFailure NetworkPolicyConditionType = "RealizationFailure"
+	// NetworkPolicyQuotaExceeded means the estimated number of OpenFlow flows of the
+	// NetworkPolicy exceeds the flow quota left in its Namespace, hence it's not enforced.
+	NetworkPolicyQuotaExceeded NetworkPolicyConditionType = "QuotaExceeded"
+)
+
+// NetworkPolicyCondition describes the state of a NetworkPolicy at a certain point.
+type NetworkPolicyCondition struct {
+	Type   NetworkPolicyConditionType `json:"type"`
+	Status v1.ConditionStatus         `json:"status"`
+	// A machine-readable reason for the condition.
+	// +optional
+	Reason string `json:"reason,omitempty"`
+	// A human-readable message with details about the condition.
+	// +optional
+	Message string `json:"message,omitempty"`
 }
 
 // Rule describes the traffic allowed to/from the workloads selected by
@@ -412,6 +543,27 @@ type NetworkPolicyPeer struct {
 	// a stand-alone selector. A Group cannot be set with any other
 	// selector.
 	Group string `json:"group,omitempty"`
+	// Select all Pods running with the referenced ServiceAccount as
+	// workloads in AppliedTo/To/From fields. The ServiceAccount of an
+	// AppliedTo of an Antrea NetworkPolicy must be in the NetworkPolicy's
+	// Namespace.
+	// Cannot be set with any other selector.
+	// +optional
+	ServiceAccount *NamespacedName `json:"serviceAccount,omitempty"`
+	// Select Nodes matched by this selector, as peers in the To field of
+	// ClusterNetworkPolicy egress rules. The rule matches the InternalIP and
+	// ExternalIP addresses of the selected Nodes, which are kept in sync with
+	// the Node objects.
+	// Cannot be set with any other selector.
+	// +optional
+	NodeSelector *metav1.LabelSelector `json:"nodeSelector,omitempty"`
+}
+
+// NamespacedName refers to a Namespace scoped resource.
+// All fields must be used together.
+type NamespacedName struct {
+	Name      string `json:"name,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
 }
 
 type PeerNamespaces struct {
@@ -461,6 +613,10 @@ const (
 	// RuleActionReject indicates that the traffic matching the rule must be rejected and the
 	// client will receive a response.
 	RuleActionReject RuleAction = "Reject"
+	// RuleActionPass indicates that the traffic must skip the remaining rules of the Antrea-native
+	// policies and be evaluated by K8s NetworkPolicies, and then by the baseline Tier. It cannot
+	// be used in the baseline Tier.
+	RuleActionPass RuleAction = "Pass"
 )
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -546,6 +702,15 @@ type TierSpec struct {
 	// Description is an optional field to add more information regarding
 	// the purpose of this Tier.
 	Description string `json:"description,omitempty"`
+	// DefaultAction specifies the action applied to the traffic of Pods which reaches the end of
+	// this Tier without matching any rule of its policies. It can be Allow, Drop or Pass. When
+	// not set, the traffic proceeds to the next Tier.
+	// +optional
+	DefaultAction *RuleAction `json:"defaultAction,omitempty"`
+	// EnableLogging indicates whether or not to generate logs for the traffic which the default
+	// action is applied to.
+	// +optional
+	EnableLogging bool `json:"enableLogging,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
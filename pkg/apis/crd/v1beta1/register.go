@@ -46,6 +46,10 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 		&AntreaControllerInfoList{},
 		&AntreaAgentInfo{},
 		&AntreaAgentInfoList{},
+		&NetworkPolicy{},
+		&NetworkPolicyList{},
+		&ClusterNetworkPolicy{},
+		&ClusterNetworkPolicyList{},
 	)
 
 	metav1.AddToGroupVersion(
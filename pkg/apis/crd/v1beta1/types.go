@@ -17,6 +17,7 @@ package v1beta1
 import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 // +genclient
@@ -36,12 +37,15 @@ type AntreaAgentInfo struct {
 	LocalPodNum                 int32                       `json:"localPodNum,omitempty"`                 // The number of Pods which the agent is in charge of
 	AgentConditions             []AgentCondition            `json:"agentConditions,omitempty"`             // Agent condition contains types like AgentHealthy
 	APIPort                     int                         `json:"apiPort,omitempty"`                     // The port of antrea agent API Server
+	LogLevel                    int32                       `json:"logLevel"`                              // The current log verbosity level of antrea agent
 }
 
 type OVSInfo struct {
-	Version    string           `json:"version,omitempty"`
-	BridgeName string           `json:"bridgeName,omitempty"`
-	FlowTable  map[string]int32 `json:"flowTable,omitempty"` // Key: flow table name, Value: flow number
+	Version         string           `json:"version,omitempty"`
+	BridgeName      string           `json:"bridgeName,omitempty"`
+	FlowTable       map[string]int32 `json:"flowTable,omitempty"`       // Key: flow table name, Value: flow number
+	OpenFlowVersion string           `json:"openFlowVersion,omitempty"` // OpenFlow version negotiated with OVS
+	Capabilities    []string         `json:"capabilities,omitempty"`    // Optional OVS capabilities available to the agent
 }
 
 type AgentConditionType string
@@ -51,14 +55,20 @@ const (
 	ControllerConnectionUp AgentConditionType = "ControllerConnectionUp" // Status True/False is used to mark the connection status between Agent and Controller.
 	OVSDBConnectionUp      AgentConditionType = "OVSDBConnectionUp"      // Status True/False is used to mark OVSDB connection status.
 	OpenflowConnectionUp   AgentConditionType = "OpenflowConnectionUp"   // Status True/False is used to mark Openflow connection status.
+	PeerNodesReachable     AgentConditionType = "PeerNodesReachable"     // Status False is used to mark that some peer Nodes failed consecutive latency probes, only reported when NodeLatencyMonitor is enabled.
+	ControlplaneSynced     AgentConditionType = "ControlplaneSynced"     // Status True/False is used to mark whether the NetworkPolicy watches have received the current state from Controller.
+	ProxyHealthy           AgentConditionType = "ProxyHealthy"           // Status False is used to mark that AntreaProxy failed to install the flows of some Services in its last sync, only reported when AntreaProxy is enabled.
+	FlowExporterConnected  AgentConditionType = "FlowExporterConnected"  // Status True/False is used to mark whether flow records can be exported to the collector, only reported when FlowExporter is enabled.
+	AuditLoggingReady      AgentConditionType = "AuditLoggingReady"      // Status False is used to mark that the audit logs cannot be written, only reported when audit logging is enabled.
 )
 
 type AgentCondition struct {
-	Type              AgentConditionType     `json:"type"`              // One of the AgentConditionType listed above
-	Status            corev1.ConditionStatus `json:"status"`            // Mark certain type status, one of True, False, Unknown
-	LastHeartbeatTime metav1.Time            `json:"lastHeartbeatTime"` // The timestamp when AntreaAgentInfo is created/updated, ideally heartbeat interval is 60s
-	Reason            string                 `json:"reason,omitempty"`  // Brief reason
-	Message           string                 `json:"message,omitempty"` // Human readable message indicating details
+	Type               AgentConditionType     `json:"type"`                         // One of the AgentConditionType listed above
+	Status             corev1.ConditionStatus `json:"status"`                       // Mark certain type status, one of True, False, Unknown
+	LastHeartbeatTime  metav1.Time            `json:"lastHeartbeatTime"`            // The timestamp when AntreaAgentInfo is created/updated, ideally heartbeat interval is 60s
+	LastTransitionTime metav1.Time            `json:"lastTransitionTime,omitempty"` // The timestamp when the status of the condition last changed
+	Reason             string                 `json:"reason,omitempty"`             // Brief reason
+	Message            string                 `json:"message,omitempty"`            // Human readable message indicating details
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -86,6 +96,7 @@ type AntreaControllerInfo struct {
 	ConnectedAgentNum           int32                       `json:"connectedAgentNum,omitempty"`           // Number of agents which are connected to this controller
 	ControllerConditions        []ControllerCondition       `json:"controllerConditions,omitempty"`        // Controller condition contains types like ControllerHealthy
 	APIPort                     int                         `json:"apiPort,omitempty"`                     // The port of antrea controller API Server
+	AgentVersions               map[string]string           `json:"agentVersions,omitempty"`               // Versions of the agents which are connected to this controller, keyed by Node name
 }
 
 type NetworkPolicyControllerInfo struct {
@@ -106,7 +117,8 @@ type AntreaControllerInfoList struct {
 type ControllerConditionType string
 
 const (
-	ControllerHealthy ControllerConditionType = "ControllerHealthy" // Status is always set to be True and LastHeartbeatTime is used to check Controller health status.
+	ControllerHealthy                 ControllerConditionType = "ControllerHealthy"       // Status is always set to be True and LastHeartbeatTime is used to check Controller health status.
+	ControllerAPIAggregationAvailable ControllerConditionType = "APIAggregationAvailable" // Status False is used to mark that the APIServices backed by Controller cannot be registered, and that the aggregated APIs are unavailable.
 )
 
 type ControllerCondition struct {
@@ -116,3 +128,385 @@ type ControllerCondition struct {
 	Reason            string                  `json:"reason,omitempty"`  // Brief reason
 	Message           string                  `json:"message,omitempty"` // Human readable message indicating details
 }
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+type NetworkPolicy struct {
+	metav1.TypeMeta `json:",inline"`
+	// Standard metadata of the object.
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Specification of the desired behavior of NetworkPolicy.
+	Spec NetworkPolicySpec `json:"spec"`
+	// Most recently observed status of the NetworkPolicy.
+	Status NetworkPolicyStatus `json:"status"`
+}
+
+// NetworkPolicySpec defines the desired state for NetworkPolicy.
+type NetworkPolicySpec struct {
+	// Tier specifies the tier to which this NetworkPolicy belongs to.
+	// The NetworkPolicy order will be determined based on the combination of the
+	// Tier's Priority and the NetworkPolicy's own Priority. If not specified,
+	// this policy will be created in the Application Tier right above the K8s
+	// NetworkPolicy which resides at the bottom.
+	Tier string `json:"tier,omitempty"`
+	// Priority specfies the order of the NetworkPolicy relative to other
+	// NetworkPolicies.
+	Priority float64 `json:"priority"`
+	// Select workloads on which the rules will be applied to. Cannot be set in
+	// conjunction with AppliedTo in each rule.
+	// +optional
+	AppliedTo []NetworkPolicyPeer `json:"appliedTo,omitempty"`
+	// Set of ingress rules evaluated based on the order in which they are set.
+	// Currently Ingress rule supports setting the `From` field but not the `To`
+	// field within a Rule.
+	// +optional
+	Ingress []Rule `json:"ingress"`
+	// Set of egress rules evaluated based on the order in which they are set.
+	// Currently Egress rule supports setting the `To` field but not the `From`
+	// field within a Rule.
+	// +optional
+	Egress []Rule `json:"egress"`
+}
+
+// NetworkPolicyPhase defines the phase in which a NetworkPolicy is.
+type NetworkPolicyPhase string
+
+// These are the valid values for NetworkPolicyPhase.
+const (
+	// NetworkPolicyPending means the NetworkPolicy has been accepted by the system, but it has not been processed by Antrea.
+	NetworkPolicyPending NetworkPolicyPhase = "Pending"
+	// NetworkPolicyRealizing means the NetworkPolicy has been observed by Antrea and is being realized.
+	NetworkPolicyRealizing NetworkPolicyPhase = "Realizing"
+	// NetworkPolicyRealized means the NetworkPolicy has been enforced to all Pods on all Nodes it applies to.
+	NetworkPolicyRealized NetworkPolicyPhase = "Realized"
+)
+
+// NetworkPolicyStatus represents information about the status of a NetworkPolicy.
+type NetworkPolicyStatus struct {
+	// The phase of a NetworkPolicy is a simple, high-level summary of the NetworkPolicy's status.
+	Phase NetworkPolicyPhase `json:"phase"`
+	// The generation observed by Antrea.
+	ObservedGeneration int64 `json:"observedGeneration"`
+	// The number of nodes that have realized the NetworkPolicy.
+	CurrentNodesRealized int32 `json:"currentNodesRealized"`
+	// The total number of nodes that should realize the NetworkPolicy.
+	DesiredNodesRealized int32 `json:"desiredNodesRealized"`
+	// The latency between the creation of the observed generation in the kube-apiserver and
+	// its realization, across the nodes that have realized it.
+	// +optional
+	RealizationLatency *RealizationLatency `json:"realizationLatency,omitempty"`
+	// The latest available observations of the NetworkPolicy's state.
+	// +optional
+	Conditions []NetworkPolicyCondition `json:"conditions,omitempty"`
+}
+
+// NetworkPolicyConditionType describes the type of a NetworkPolicy condition.
+type NetworkPolicyConditionType string
+
+// These are the valid values for NetworkPolicyConditionType.
+const (
+	// NetworkPolicyRealizationFailure means some Nodes failed to realize some rules of the
+	// NetworkPolicy, and keep retrying.
+	NetworkPolicyRealizationFailure NetworkPolicyConditionType = "RealizationFailure"
+	// NetworkPolicyQuotaExceeded means the estimated number of OpenFlow flows of the
+	// NetworkPolicy exceeds the flow quota left in its Namespace, hence it's not enforced.
+	NetworkPolicyQuotaExceeded NetworkPolicyConditionType = "QuotaExceeded"
+)
+
+// NetworkPolicyCondition describes the state of a NetworkPolicy at a certain point.
+type NetworkPolicyCondition struct {
+	Type   NetworkPolicyConditionType `json:"type"`
+	Status corev1.ConditionStatus     `json:"status"`
+	// A machine-readable reason for the condition.
+	// +optional
+	Reason string `json:"reason,omitempty"`
+	// A human-readable message with details about the condition.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// RealizationLatency describes the distribution of the realization latency of a NetworkPolicy.
+type RealizationLatency struct {
+	// The median latency, in milliseconds.
+	P50Milliseconds int64 `json:"p50Milliseconds"`
+	// The 99th percentile latency, in milliseconds.
+	P99Milliseconds int64 `json:"p99Milliseconds"`
+}
+
+// Rule describes the traffic allowed to/from the workloads selected by
+// Spec.AppliedTo. Based on the action specified in the rule, traffic is either
+// allowed or denied which exactly match the specified ports and protocol.
+type Rule struct {
+	// Action specifies the action to be applied on the rule.
+	Action *RuleAction `json:"action"`
+	// Set of port and protocol allowed/denied by the rule. If this field is unset
+	// or empty, this rule matches all ports.
+	// +optional
+	Ports []NetworkPolicyPort `json:"ports,omitempty"`
+	// Rule is matched if traffic originates from workloads selected by
+	// this field. If this field is empty, this rule matches all sources.
+	// +optional
+	From []NetworkPolicyPeer `json:"from"`
+	// Rule is matched if traffic is intended for workloads selected by
+	// this field. If this field is empty or missing, this rule matches all
+	// destinations.
+	// +optional
+	To []NetworkPolicyPeer `json:"to"`
+	// Name describes the intention of this rule.
+	// Name should be unique within the policy.
+	// +optional
+	Name string `json:"name"`
+	// EnableLogging is used to indicate if agent should generate logs
+	// when rules are matched. Should be default to false.
+	EnableLogging bool `json:"enableLogging"`
+	// Select workloads on which this rule will be applied to. Cannot be set in
+	// conjunction with NetworkPolicySpec/ClusterNetworkPolicySpec.AppliedTo.
+	// +optional
+	AppliedTo []NetworkPolicyPeer `json:"appliedTo,omitempty"`
+}
+
+// NetworkPolicyPeer describes the grouping selector of workloads.
+type NetworkPolicyPeer struct {
+	// IPBlock describes the IPAddresses/IPBlocks that is matched in to/from.
+	// IPBlock cannot be set as part of the AppliedTo field.
+	// Cannot be set with any other selector.
+	// +optional
+	IPBlock *IPBlock `json:"ipBlock,omitempty"`
+	// Select Pods from NetworkPolicy's Namespace as workloads in
+	// AppliedTo/To/From fields. If set with NamespaceSelector, Pods are
+	// matched from Namespaces matched by the NamespaceSelector.
+	// Cannot be set with any other selector except NamespaceSelector.
+	// +optional
+	PodSelector *metav1.LabelSelector `json:"podSelector,omitempty"`
+	// Select all Pods from Namespaces matched by this selector, as
+	// workloads in To/From fields. If set with PodSelector,
+	// Pods are matched from Namespaces matched by the NamespaceSelector.
+	// Cannot be set with any other selector except PodSelector or
+	// ExternalEntitySelector. Cannot be set with Namespaces.
+	// +optional
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+	// Select Pod/ExternalEntity from Namespaces matched by specifc criteria.
+	// Current supported criteria is match: Self, which selects from the same
+	// Namespace of the appliedTo workloads.
+	// Cannot be set with any other selector except PodSelector or
+	// ExternalEntitySelector. This field can only be set when NetworkPolicyPeer
+	// is created for ClusterNetworkPolicy ingress/egress rules.
+	// Cannot be set with NamespaceSelector.
+	// +optional
+	Namespaces *PeerNamespaces `json:"namespaces,omitempty"`
+	// Select ExternalEntities from NetworkPolicy's Namespace as workloads
+	// in AppliedTo/To/From fields. If set with NamespaceSelector,
+	// ExternalEntities are matched from Namespaces matched by the
+	// NamespaceSelector.
+	// Cannot be set with any other selector except NamespaceSelector.
+	// +optional
+	ExternalEntitySelector *metav1.LabelSelector `json:"externalEntitySelector,omitempty"`
+	// Group is the name of the ClusterGroup which can be set as an
+	// AppliedTo or within an Ingress or Egress rule in place of
+	// a stand-alone selector. A Group cannot be set with any other
+	// selector.
+	Group string `json:"group,omitempty"`
+	// Select all Pods running with the referenced ServiceAccount as
+	// workloads in AppliedTo/To/From fields. The ServiceAccount of an
+	// AppliedTo of an Antrea NetworkPolicy must be in the NetworkPolicy's
+	// Namespace.
+	// Cannot be set with any other selector.
+	// +optional
+	ServiceAccount *NamespacedName `json:"serviceAccount,omitempty"`
+	// Select Nodes matched by this selector, as peers in the To field of
+	// ClusterNetworkPolicy egress rules. The rule matches the InternalIP and
+	// ExternalIP addresses of the selected Nodes, which are kept in sync with
+	// the Node objects.
+	// Cannot be set with any other selector.
+	// +optional
+	NodeSelector *metav1.LabelSelector `json:"nodeSelector,omitempty"`
+}
+
+// NamespacedName refers to a Namespace scoped resource.
+// All fields must be used together.
+type NamespacedName struct {
+	Name      string `json:"name,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+type PeerNamespaces struct {
+	Match NamespaceMatchType `json:"match,omitempty"`
+}
+
+// NamespaceMatchType describes Namespace matching strategy.
+type NamespaceMatchType string
+
+const (
+	NamespaceMatchSelf NamespaceMatchType = "Self"
+)
+
+// IPBlock describes a particular CIDR (Ex. "192.168.1.1/24") that is allowed
+// or denied to/from the workloads matched by a Spec.AppliedTo.
+type IPBlock struct {
+	// CIDR is a string representing the IP Block
+	// Valid examples are "192.168.1.1/24".
+	CIDR string `json:"cidr"`
+	// Except is a slice of CIDRs that should not be included within the IP Block.
+	// Valid examples are "192.168.1.1/24". Except values will be rejected if they
+	// are outside the CIDR range.
+	// +optional
+	Except []string `json:"except,omitempty"`
+}
+
+// NetworkPolicyPort describes the port and protocol to match in a rule.
+type NetworkPolicyPort struct {
+	// The protocol (TCP, UDP, or SCTP) which traffic must match.
+	// If not specified, this field defaults to TCP.
+	// +optional
+	Protocol *corev1.Protocol `json:"protocol,omitempty"`
+	// The port on the given protocol. This can be either a numerical
+	// or named port on a Pod. If this field is not provided, this
+	// matches all port names and numbers.
+	// +optional
+	Port *intstr.IntOrString `json:"port,omitempty"`
+	// EndPort defines the end of the port range, being the end included within the range.
+	// It can only be specified when a numerical `port` is specified.
+	// +optional
+	EndPort *int32 `json:"endPort,omitempty"`
+}
+
+// RuleAction describes the action to be applied on traffic matching a rule.
+type RuleAction string
+
+const (
+	// RuleActionAllow describes that the traffic matching the rule must be allowed.
+	RuleActionAllow RuleAction = "Allow"
+	// RuleActionDrop describes that the traffic matching the rule must be dropped.
+	RuleActionDrop RuleAction = "Drop"
+	// RuleActionReject indicates that the traffic matching the rule must be rejected and the
+	// client will receive a response.
+	RuleActionReject RuleAction = "Reject"
+	// RuleActionPass indicates that the traffic must skip the remaining rules of the Antrea-native
+	// policies and be evaluated by K8s NetworkPolicies, and then by the baseline Tier. It cannot
+	// be used in the baseline Tier.
+	RuleActionPass RuleAction = "Pass"
+)
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+type NetworkPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []NetworkPolicy `json:"items"`
+}
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+type ClusterNetworkPolicy struct {
+	metav1.TypeMeta `json:",inline"`
+	// Standard metadata of the object.
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Specification of the desired behavior of ClusterNetworkPolicy.
+	Spec ClusterNetworkPolicySpec `json:"spec"`
+	// Most recently observed status of the NetworkPolicy.
+	Status NetworkPolicyStatus `json:"status"`
+}
+
+// ClusterNetworkPolicySpec defines the desired state for ClusterNetworkPolicy.
+type ClusterNetworkPolicySpec struct {
+	// Tier specifies the tier to which this ClusterNetworkPolicy belongs to.
+	// The ClusterNetworkPolicy order will be determined based on the
+	// combination of the Tier's Priority and the ClusterNetworkPolicy's own
+	// Priority. If not specified, this policy will be created in the Application
+	// Tier right above the K8s NetworkPolicy which resides at the bottom.
+	Tier string `json:"tier,omitempty"`
+	// Priority specfies the order of the ClusterNetworkPolicy relative to
+	// other AntreaClusterNetworkPolicies.
+	Priority float64 `json:"priority"`
+	// Select workloads on which the rules will be applied to. Cannot be set in
+	// conjunction with AppliedTo in each rule.
+	// +optional
+	AppliedTo []NetworkPolicyPeer `json:"appliedTo,omitempty"`
+	// Set of ingress rules evaluated based on the order in which they are set.
+	// Currently Ingress rule supports setting the `From` field but not the `To`
+	// field within a Rule.
+	// +optional
+	Ingress []Rule `json:"ingress"`
+	// Set of egress rules evaluated based on the order in which they are set.
+	// Currently Egress rule supports setting the `To` field but not the `From`
+	// field within a Rule.
+	// +optional
+	Egress []Rule `json:"egress"`
+	// Set of layer 2 rules evaluated based on the order in which they are set.
+	// They control the ARP and Neighbor Discovery messages sent by the
+	// workloads they are applied to, which are allowed when no rule matches.
+	// +optional
+	Layer2 []Layer2Rule `json:"layer2,omitempty"`
+}
+
+// Layer2Protocol is the protocol of the messages matched by a Layer2Rule.
+type Layer2Protocol string
+
+const (
+	// Layer2ProtocolARP matches ARP requests and replies.
+	Layer2ProtocolARP Layer2Protocol = "ARP"
+	// Layer2ProtocolICMPv6ND matches ICMPv6 Neighbor Discovery messages.
+	Layer2ProtocolICMPv6ND Layer2Protocol = "ICMPv6ND"
+)
+
+// NDType is the type of an ICMPv6 Neighbor Discovery message.
+type NDType string
+
+const (
+	NDTypeRouterSolicitation    NDType = "RouterSolicitation"
+	NDTypeRouterAdvertisement   NDType = "RouterAdvertisement"
+	NDTypeNeighborSolicitation  NDType = "NeighborSolicitation"
+	NDTypeNeighborAdvertisement NDType = "NeighborAdvertisement"
+)
+
+// Layer2Rule describes a rule matching the ARP or Neighbor Discovery messages
+// sent by the workloads it is applied to.
+type Layer2Rule struct {
+	// Action specifies the action to be applied on the rule. Only Allow and
+	// Drop are supported.
+	Action *RuleAction `json:"action"`
+	// Protocol of the messages matched by the rule.
+	Protocol Layer2Protocol `json:"protocol"`
+	// NDType restricts the rule to a type of Neighbor Discovery messages. It
+	// can only be set when Protocol is ICMPv6ND. If this field is unset, this
+	// rule matches all the Neighbor Discovery message types.
+	// +optional
+	NDType *NDType `json:"ndType,omitempty"`
+	// Rule is matched if the target address of the message, i.e. the ARP
+	// target protocol address or the Neighbor Discovery target address,
+	// belongs to workloads selected by this field. If this field is empty,
+	// this rule matches all target addresses. Router Solicitation and Router
+	// Advertisement messages have no target address hence can only be
+	// matched by a rule with an empty To.
+	// +optional
+	To []NetworkPolicyPeer `json:"to,omitempty"`
+	// Name describes the intention of this rule.
+	// Name should be unique within the policy.
+	// +optional
+	Name string `json:"name,omitempty"`
+	// EnableLogging is used to indicate if agent should generate logs
+	// when rules are matched. Should be default to false.
+	// +optional
+	EnableLogging bool `json:"enableLogging,omitempty"`
+	// Select workloads on which this rule will be applied to. Cannot be set in
+	// conjunction with ClusterNetworkPolicySpec.AppliedTo.
+	// +optional
+	AppliedTo []NetworkPolicyPeer `json:"appliedTo,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+type ClusterNetworkPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []ClusterNetworkPolicy `json:"items"`
+}
@@ -16,6 +16,7 @@ package apiserver
 
 import (
 	"context"
+	"fmt"
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -38,7 +39,12 @@ import (
 	"antrea.io/antrea/pkg/apiserver/certificate"
 	"antrea.io/antrea/pkg/apiserver/handlers/endpoint"
 	"antrea.io/antrea/pkg/apiserver/handlers/featuregates"
+	"antrea.io/antrea/pkg/apiserver/handlers/groupevents"
+	"antrea.io/antrea/pkg/apiserver/handlers/largestgroups"
 	"antrea.io/antrea/pkg/apiserver/handlers/loglevel"
+	"antrea.io/antrea/pkg/apiserver/handlers/policyconflict"
+	"antrea.io/antrea/pkg/apiserver/handlers/policyflows"
+	"antrea.io/antrea/pkg/apiserver/handlers/policyquota"
 	"antrea.io/antrea/pkg/apiserver/handlers/webhook"
 	"antrea.io/antrea/pkg/apiserver/registry/controlplane/egressgroup"
 	"antrea.io/antrea/pkg/apiserver/registry/controlplane/nodestatssummary"
@@ -54,9 +60,11 @@ import (
 	"antrea.io/antrea/pkg/apiserver/registry/system/supportbundle"
 	"antrea.io/antrea/pkg/apiserver/storage"
 	"antrea.io/antrea/pkg/controller/egress"
+	controllergroupevents "antrea.io/antrea/pkg/controller/groupevents"
 	controllernetworkpolicy "antrea.io/antrea/pkg/controller/networkpolicy"
 	"antrea.io/antrea/pkg/controller/querier"
 	"antrea.io/antrea/pkg/controller/stats"
+	"antrea.io/antrea/pkg/controller/traceflow"
 	"antrea.io/antrea/pkg/features"
 	legacycontrolplane "antrea.io/antrea/pkg/legacyapis/controlplane"
 	legacycpinstall "antrea.io/antrea/pkg/legacyapis/controlplane/install"
@@ -76,6 +84,10 @@ var (
 	TokenPath = "/var/run/antrea/apiserver/loopback-client-token"
 )
 
+// controlplaneAPIServiceName is the name of the APIService of the current controlplane API group, which
+// is used to check whether the APIServices backed by antrea-controller are registered.
+const controlplaneAPIServiceName = "v1beta2.controlplane.antrea.io"
+
 func init() {
 	cpinstall.Install(Scheme)
 	systeminstall.Install(Scheme)
@@ -98,11 +110,17 @@ type ExtraConfig struct {
 	egressGroupStore              storage.Interface
 	controllerQuerier             querier.ControllerQuerier
 	endpointQuerier               controllernetworkpolicy.EndpointQuerier
+	groupEventRecorder            *controllergroupevents.Recorder
 	networkPolicyController       *controllernetworkpolicy.NetworkPolicyController
 	egressController              *egress.EgressController
 	caCertController              *certificate.CACertController
 	statsAggregator               *stats.Aggregator
 	networkPolicyStatusController *controllernetworkpolicy.StatusController
+	policyFlowGetter              policyflows.AgentFlowGetter
+	// apiAggregationAvailable is false when the APIServices backed by antrea-controller cannot be
+	// registered, in which case the APIs which are only meant to be consumed through the K8s API
+	// aggregation layer are not installed.
+	apiAggregationAvailable bool
 }
 
 // Config defines the config for Antrea apiserver.
@@ -141,8 +159,11 @@ func NewConfig(
 	controllerQuerier querier.ControllerQuerier,
 	networkPolicyStatusController *controllernetworkpolicy.StatusController,
 	endpointQuerier controllernetworkpolicy.EndpointQuerier,
+	groupEventRecorder *controllergroupevents.Recorder,
 	npController *controllernetworkpolicy.NetworkPolicyController,
-	egressController *egress.EgressController) *Config {
+	egressController *egress.EgressController,
+	policyFlowGetter policyflows.AgentFlowGetter,
+	apiAggregationAvailable bool) *Config {
 	return &Config{
 		genericConfig: genericConfig,
 		extraConfig: ExtraConfig{
@@ -155,9 +176,12 @@ func NewConfig(
 			statsAggregator:               statsAggregator,
 			controllerQuerier:             controllerQuerier,
 			endpointQuerier:               endpointQuerier,
+			groupEventRecorder:            groupEventRecorder,
 			networkPolicyController:       npController,
 			networkPolicyStatusController: networkPolicyStatusController,
 			egressController:              egressController,
+			policyFlowGetter:              policyFlowGetter,
+			apiAggregationAvailable:       apiAggregationAvailable,
 		},
 	}
 }
@@ -167,9 +191,9 @@ func (c *Config) Complete(informers informers.SharedInformerFactory) completedCo
 }
 
 func installAPIGroup(s *APIServer, c completedConfig) error {
-	addressGroupStorage := addressgroup.NewREST(c.extraConfig.addressGroupStore)
-	appliedToGroupStorage := appliedtogroup.NewREST(c.extraConfig.appliedToGroupStore)
-	networkPolicyStorage := networkpolicy.NewREST(c.extraConfig.networkPolicyStore)
+	addressGroupStorage := addressgroup.NewREST(c.extraConfig.addressGroupStore, c.extraConfig.networkPolicyController.AddressGroupStoreSynced())
+	appliedToGroupStorage := appliedtogroup.NewREST(c.extraConfig.appliedToGroupStore, c.extraConfig.networkPolicyController.AppliedToGroupStoreSynced())
+	networkPolicyStorage := networkpolicy.NewREST(c.extraConfig.networkPolicyStore, c.extraConfig.networkPolicyController.NetworkPolicyStoreSynced())
 	networkPolicyStatusStorage := networkpolicy.NewStatusREST(c.extraConfig.networkPolicyStatusController)
 	clusterGroupMembershipStorage := clustergroupmember.NewREST(c.extraConfig.networkPolicyController)
 	groupAssociationStorage := groupassociation.NewREST(c.extraConfig.networkPolicyController)
@@ -195,14 +219,19 @@ func installAPIGroup(s *APIServer, c completedConfig) error {
 	systemStorage["supportbundles/download"] = bundleStorage.Download
 	systemGroup.VersionedResourcesStorageMap["v1beta1"] = systemStorage
 
-	statsGroup := genericapiserver.NewDefaultAPIGroupInfo(apistats.GroupName, Scheme, metav1.ParameterCodec, Codecs)
-	statsStorage := map[string]rest.Storage{}
-	statsStorage["networkpolicystats"] = networkpolicystats.NewREST(c.extraConfig.statsAggregator)
-	statsStorage["antreaclusternetworkpolicystats"] = antreaclusternetworkpolicystats.NewREST(c.extraConfig.statsAggregator)
-	statsStorage["antreanetworkpolicystats"] = antreanetworkpolicystats.NewREST(c.extraConfig.statsAggregator)
-	statsGroup.VersionedResourcesStorageMap["v1alpha1"] = statsStorage
-
-	groups := []*genericapiserver.APIGroupInfo{&cpGroup, &systemGroup, &statsGroup}
+	groups := []*genericapiserver.APIGroupInfo{&cpGroup, &systemGroup}
+
+	// The stats API is only consumed through the K8s API aggregation layer.
+	var statsStorage map[string]rest.Storage
+	if c.extraConfig.apiAggregationAvailable {
+		statsGroup := genericapiserver.NewDefaultAPIGroupInfo(apistats.GroupName, Scheme, metav1.ParameterCodec, Codecs)
+		statsStorage = map[string]rest.Storage{}
+		statsStorage["networkpolicystats"] = networkpolicystats.NewREST(c.extraConfig.statsAggregator)
+		statsStorage["antreaclusternetworkpolicystats"] = antreaclusternetworkpolicystats.NewREST(c.extraConfig.statsAggregator)
+		statsStorage["antreanetworkpolicystats"] = antreanetworkpolicystats.NewREST(c.extraConfig.statsAggregator)
+		statsGroup.VersionedResourcesStorageMap["v1alpha1"] = statsStorage
+		groups = append(groups, &statsGroup)
+	}
 
 	// legacy groups
 	legacyCPGroup := genericapiserver.NewDefaultAPIGroupInfo(legacycontrolplane.GroupName, Scheme, metav1.ParameterCodec, Codecs)
@@ -225,11 +254,14 @@ func installAPIGroup(s *APIServer, c completedConfig) error {
 	legacySystemGroup := genericapiserver.NewDefaultAPIGroupInfo(legacysystem.GroupName, Scheme, metav1.ParameterCodec, Codecs)
 	legacySystemGroup.VersionedResourcesStorageMap["v1beta1"] = systemStorage
 
-	legacyStatsGroup := genericapiserver.NewDefaultAPIGroupInfo(legacyapistats.GroupName, Scheme, metav1.ParameterCodec, Codecs)
-	legacyStatsGroup.VersionedResourcesStorageMap["v1alpha1"] = statsStorage
-
 	// legacy API groups
-	groups = append(groups, &legacyCPGroup, &legacySystemGroup, &legacyStatsGroup)
+	groups = append(groups, &legacyCPGroup, &legacySystemGroup)
+
+	if statsStorage != nil {
+		legacyStatsGroup := genericapiserver.NewDefaultAPIGroupInfo(legacyapistats.GroupName, Scheme, metav1.ParameterCodec, Codecs)
+		legacyStatsGroup.VersionedResourcesStorageMap["v1alpha1"] = statsStorage
+		groups = append(groups, &legacyStatsGroup)
+	}
 
 	for _, apiGroupInfo := range groups {
 		if err := s.GenericAPIServer.InstallAPIGroup(apiGroupInfo); err != nil {
@@ -258,6 +290,22 @@ func (c completedConfig) New() (*APIServer, error) {
 	return s, nil
 }
 
+// CheckAPIAggregation checks whether the APIServices backed by antrea-controller are registered with
+// the K8s API aggregation layer. Some managed K8s offerings restrict APIService registration, in
+// which case the APIService cannot be retrieved, and false is returned. An error is returned if
+// availability cannot be determined.
+func CheckAPIAggregation(aggregatorClient clientset.Interface) (bool, error) {
+	_, err := aggregatorClient.ApiregistrationV1().APIServices().Get(context.TODO(), controlplaneAPIServiceName, metav1.GetOptions{})
+	if err == nil {
+		return true, nil
+	}
+	if apierrors.IsNotFound(err) || apierrors.IsForbidden(err) || apierrors.IsMethodNotSupported(err) {
+		klog.Warningf("APIService %s is unavailable, running without the K8s API aggregation layer: %v", controlplaneAPIServiceName, err)
+		return false, nil
+	}
+	return false, fmt.Errorf("error getting APIService %s: %v", controlplaneAPIServiceName, err)
+}
+
 // CleanupDeprecatedAPIServices deletes the registered APIService resources for
 // the deprecated Antrea API groups.
 func CleanupDeprecatedAPIServices(aggregatorClient clientset.Interface) error {
@@ -284,6 +332,11 @@ func installHandlers(c *ExtraConfig, s *genericapiserver.GenericAPIServer) {
 	s.Handler.NonGoRestfulMux.HandleFunc("/loglevel", loglevel.HandleFunc())
 	s.Handler.NonGoRestfulMux.HandleFunc("/featuregates", featuregates.HandleFunc(c.k8sClient))
 	s.Handler.NonGoRestfulMux.HandleFunc("/endpoint", endpoint.HandleFunc(c.endpointQuerier))
+	s.Handler.NonGoRestfulMux.HandleFunc("/policyconflicts", policyconflict.HandleFunc(c.endpointQuerier))
+	s.Handler.NonGoRestfulMux.HandleFunc("/groupevents", groupevents.HandleFunc(c.groupEventRecorder))
+	s.Handler.NonGoRestfulMux.HandleFunc("/largestgroups", largestgroups.HandleFunc(c.endpointQuerier))
+	s.Handler.NonGoRestfulMux.HandleFunc("/policyquotas", policyquota.HandleFunc(c.endpointQuerier))
+	s.Handler.NonGoRestfulMux.HandleFunc("/policyflows", policyflows.HandleFunc(c.networkPolicyStore, c.policyFlowGetter))
 	// Webhook to mutate Namespace labels and add its metadata.name as a label
 	s.Handler.NonGoRestfulMux.HandleFunc("/mutate/namespace", webhook.HandleMutationLabels())
 	if features.DefaultFeatureGate.Enabled(features.AntreaPolicy) {
@@ -303,6 +356,8 @@ func installHandlers(c *ExtraConfig, s *genericapiserver.GenericAPIServer) {
 
 		// Install handlers for CRD conversion between versions
 		s.Handler.NonGoRestfulMux.HandleFunc("/convert/clustergroup", webhook.HandleCRDConversion(controllernetworkpolicy.ConvertClusterGroupCRD))
+		s.Handler.NonGoRestfulMux.HandleFunc("/convert/networkpolicy", webhook.HandleCRDConversion(controllernetworkpolicy.ConvertNetworkPolicyCRD))
+		s.Handler.NonGoRestfulMux.HandleFunc("/convert/clusternetworkpolicy", webhook.HandleCRDConversion(controllernetworkpolicy.ConvertClusterNetworkPolicyCRD))
 
 		// Install a post start hook to initialize Tiers on start-up
 		s.AddPostStartHook("initialize-tiers", func(context genericapiserver.PostStartHookContext) error {
@@ -315,4 +370,8 @@ func installHandlers(c *ExtraConfig, s *genericapiserver.GenericAPIServer) {
 		s.Handler.NonGoRestfulMux.HandleFunc("/validate/externalippool", webhook.HandlerForValidateFunc(c.egressController.ValidateExternalIPPool))
 		s.Handler.NonGoRestfulMux.HandleFunc("/validate/egress", webhook.HandlerForValidateFunc(c.egressController.ValidateEgress))
 	}
+
+	if features.DefaultFeatureGate.Enabled(features.Traceflow) {
+		s.Handler.NonGoRestfulMux.HandleFunc("/validate/traceflow", webhook.HandlerForValidateFunc(traceflow.ValidateTraceflow))
+	}
 }
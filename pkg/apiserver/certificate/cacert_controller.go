@@ -70,6 +70,8 @@ var (
 	}
 	crdsWithConversionWebhooks = []string{
 		"clustergroups.crd.antrea.io",
+		"networkpolicies.crd.antrea.io",
+		"clusternetworkpolicies.crd.antrea.io",
 	}
 )
 
@@ -83,7 +85,9 @@ type CACertController struct {
 	// queue only ever has one item, but it has nice error handling backoff/retry semantics
 	queue workqueue.RateLimitingInterface
 
-	client             kubernetes.Interface
+	client kubernetes.Interface
+	// aggregatorClient is nil when antrea-controller runs without the K8s API aggregation layer,
+	// in which case there is no APIService to publish the CA certificate to.
 	aggregatorClient   clientset.Interface
 	apiExtensionClient apiextensionclientset.Interface
 }
@@ -142,8 +146,10 @@ func (c *CACertController) syncCACert() error {
 		return err
 	}
 
-	if err := c.syncAPIServices(caCert); err != nil {
-		return err
+	if c.aggregatorClient != nil {
+		if err := c.syncAPIServices(caCert); err != nil {
+			return err
+		}
 	}
 
 	if features.DefaultFeatureGate.Enabled(features.AntreaPolicy) {
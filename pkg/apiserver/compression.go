@@ -0,0 +1,192 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apiserver
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+
+	"k8s.io/klog/v2"
+
+	"antrea.io/antrea/pkg/apis/controlplane"
+	"antrea.io/antrea/pkg/controller/metrics"
+)
+
+const (
+	// compressionThresholdBytes is the minimum size of a list or get response for it to be
+	// compressed. Smaller responses are sent as they are, as compressing them saves little
+	// bandwidth for the CPU it costs. Watch responses are always compressed as they are long-lived
+	// streams.
+	compressionThresholdBytes = 4096
+
+	encodingGzip = "gzip"
+)
+
+var controlplanePathPrefix = "/apis/" + controlplane.GroupName + "/"
+
+// WithControlplaneCompression compresses the responses of the controlplane API with gzip when
+// the client accepts it, which reduces the bandwidth consumed by the agents connecting to the
+// controller over constrained links. The compression is transparent to the content type, so it
+// applies to both JSON and protobuf responses.
+func WithControlplaneCompression(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if !strings.HasPrefix(req.URL.Path, controlplanePathPrefix) || !acceptsGzip(req) {
+			handler.ServeHTTP(w, req)
+			return
+		}
+		// Prevent the generic apiserver from compressing the response a second time.
+		req.Header.Del("Accept-Encoding")
+		cw := &compressionResponseWriter{
+			ResponseWriter: w,
+			threshold:      compressionThresholdBytes,
+		}
+		if isWatchRequest(req) {
+			cw.threshold = 0
+		}
+		defer cw.close()
+		handler.ServeHTTP(cw, req)
+	})
+}
+
+func acceptsGzip(req *http.Request) bool {
+	for _, header := range req.Header.Values("Accept-Encoding") {
+		for _, encoding := range strings.Split(header, ",") {
+			if strings.TrimSpace(strings.SplitN(encoding, ";", 2)[0]) == encodingGzip {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func isWatchRequest(req *http.Request) bool {
+	if strings.Contains(req.URL.Path, "/watch/") {
+		return true
+	}
+	watch := req.URL.Query().Get("watch")
+	return watch == "true" || watch == "1"
+}
+
+// countingWriter counts the bytes written to the wrapped Writer.
+type countingWriter struct {
+	io.Writer
+}
+
+func (w countingWriter) Write(p []byte) (int, error) {
+	n, err := w.Writer.Write(p)
+	metrics.ControlplaneCompressedResponseBytes.Add(float64(n))
+	return n, err
+}
+
+// compressionResponseWriter buffers the response until it reaches the threshold, then compresses
+// it with gzip. A response which is complete before reaching the threshold is sent uncompressed.
+type compressionResponseWriter struct {
+	http.ResponseWriter
+	threshold  int
+	statusCode int
+	buf        []byte
+	// gzipWriter is set once the response is compressed.
+	gzipWriter *gzip.Writer
+	// written is true once the response header has been written to the wrapped ResponseWriter.
+	written bool
+}
+
+func (w *compressionResponseWriter) WriteHeader(statusCode int) {
+	if w.statusCode == 0 {
+		w.statusCode = statusCode
+	}
+}
+
+func (w *compressionResponseWriter) Write(p []byte) (int, error) {
+	if w.gzipWriter != nil {
+		return w.writeCompressed(p)
+	}
+	w.buf = append(w.buf, p...)
+	if len(w.buf) >= w.threshold {
+		if err := w.startCompression(); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// Flush implements http.Flusher, which is required to serve watch requests.
+func (w *compressionResponseWriter) Flush() {
+	if !w.written {
+		if err := w.startCompression(); err != nil {
+			klog.Errorf("Error when compressing controlplane response: %v", err)
+			return
+		}
+	}
+	if w.gzipWriter != nil {
+		if err := w.gzipWriter.Flush(); err != nil {
+			klog.Errorf("Error when flushing compressed controlplane response: %v", err)
+			return
+		}
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *compressionResponseWriter) writeHeader() {
+	if w.statusCode == 0 {
+		w.statusCode = http.StatusOK
+	}
+	w.ResponseWriter.WriteHeader(w.statusCode)
+	w.written = true
+}
+
+func (w *compressionResponseWriter) startCompression() error {
+	header := w.ResponseWriter.Header()
+	header.Set("Content-Encoding", encodingGzip)
+	header.Add("Vary", "Accept-Encoding")
+	header.Del("Content-Length")
+	w.writeHeader()
+	w.gzipWriter = gzip.NewWriter(countingWriter{w.ResponseWriter})
+	buf := w.buf
+	w.buf = nil
+	_, err := w.writeCompressed(buf)
+	return err
+}
+
+func (w *compressionResponseWriter) writeCompressed(p []byte) (int, error) {
+	n, err := w.gzipWriter.Write(p)
+	metrics.ControlplaneRawResponseBytes.Add(float64(n))
+	return n, err
+}
+
+// close sends the buffered response uncompressed if it never reached the threshold, or
+// terminates the gzip stream.
+func (w *compressionResponseWriter) close() {
+	if w.gzipWriter != nil {
+		if err := w.gzipWriter.Close(); err != nil {
+			klog.Errorf("Error when closing compressed controlplane response: %v", err)
+		}
+		return
+	}
+	// Nothing was written, let the wrapped ResponseWriter handle the empty response itself.
+	if w.statusCode == 0 && len(w.buf) == 0 {
+		return
+	}
+	w.writeHeader()
+	if len(w.buf) > 0 {
+		if _, err := w.ResponseWriter.Write(w.buf); err != nil {
+			klog.Errorf("Error when writing controlplane response: %v", err)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apiserver
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithControlplaneCompression(t *testing.T) {
+	smallBody := []byte("small")
+	largeBody := bytes.Repeat([]byte("addressgroup"), compressionThresholdBytes)
+	tests := []struct {
+		name             string
+		path             string
+		acceptEncoding   string
+		body             []byte
+		expectCompressed bool
+	}{
+		{
+			name:             "large list",
+			path:             "/apis/controlplane.antrea.io/v1beta2/addressgroups",
+			acceptEncoding:   "gzip",
+			body:             largeBody,
+			expectCompressed: true,
+		},
+		{
+			name:           "small list",
+			path:           "/apis/controlplane.antrea.io/v1beta2/addressgroups",
+			acceptEncoding: "gzip",
+			body:           smallBody,
+		},
+		{
+			name:             "small watch",
+			path:             "/apis/controlplane.antrea.io/v1beta2/addressgroups?watch=true",
+			acceptEncoding:   "deflate, gzip;q=0.8",
+			body:             smallBody,
+			expectCompressed: true,
+		},
+		{
+			name: "gzip not accepted",
+			path: "/apis/controlplane.antrea.io/v1beta2/addressgroups",
+			body: largeBody,
+		},
+		{
+			name:           "other API",
+			path:           "/apis/stats.antrea.io/v1alpha1/networkpolicystats",
+			acceptEncoding: "gzip",
+			body:           largeBody,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := WithControlplaneCompression(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				w.Header().Set("Content-Type", "application/vnd.kubernetes.protobuf")
+				w.WriteHeader(http.StatusOK)
+				// Write the body in chunks to cover the buffering before reaching the threshold.
+				for i := 0; i < len(tt.body); i += 1000 {
+					end := i + 1000
+					if end > len(tt.body) {
+						end = len(tt.body)
+					}
+					w.Write(tt.body[i:end])
+				}
+			}))
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, req)
+
+			assert.Equal(t, http.StatusOK, recorder.Code)
+			assert.Equal(t, "application/vnd.kubernetes.protobuf", recorder.Header().Get("Content-Type"))
+			body := recorder.Body.Bytes()
+			if tt.expectCompressed {
+				assert.Equal(t, "gzip", recorder.Header().Get("Content-Encoding"))
+				zr, err := gzip.NewReader(bytes.NewReader(body))
+				require.NoError(t, err)
+				body, err = ioutil.ReadAll(zr)
+				require.NoError(t, err)
+			} else {
+				assert.Empty(t, recorder.Header().Get("Content-Encoding"))
+			}
+			assert.Equal(t, tt.body, body)
+		})
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package groupevents
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"antrea.io/antrea/pkg/controller/groupevents"
+)
+
+// HandleFunc creates a http.HandlerFunc which serves the membership changes of AddressGroups
+// recorded by the Recorder, one JSON object per line. The retained events are written first, then
+// the new events are streamed if the "watch" query parameter is "true". The events can be filtered
+// by the policy referring to the group with the "policy" query parameter, and the events up to a
+// given ID can be skipped with the "since" query parameter.
+func HandleFunc(recorder *groupevents.Recorder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		var since uint64
+		if s := query.Get("since"); s != "" {
+			var err error
+			if since, err = strconv.ParseUint(s, 10, 64); err != nil {
+				http.Error(w, "invalid since: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		watch := query.Get("watch") == "true"
+		history, events, cancel := recorder.Subscribe(since, groupevents.Filter{Policy: query.Get("policy")}, watch)
+		defer cancel()
+
+		w.Header().Set("Content-Type", "application/json")
+		encoder := json.NewEncoder(w)
+		for i := range history {
+			if err := encoder.Encode(&history[i]); err != nil {
+				return
+			}
+		}
+		if !watch {
+			return
+		}
+		flusher, _ := w.(http.Flusher)
+		flush := func() {
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+		flush()
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case event, ok := <-events:
+				// The channel is closed when the consumer is too slow, it can reconnect with the
+				// ID of the last event it got.
+				if !ok {
+					return
+				}
+				if err := encoder.Encode(&event); err != nil {
+					return
+				}
+				flush()
+			}
+		}
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package groupevents
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"antrea.io/antrea/pkg/apis/controlplane"
+	"antrea.io/antrea/pkg/controller/groupevents"
+	"antrea.io/antrea/pkg/controller/networkpolicy/store"
+	antreatypes "antrea.io/antrea/pkg/controller/types"
+)
+
+func TestHandleFunc(t *testing.T) {
+	addressGroupStore := store.NewAddressGroupStore()
+	recorder := groupevents.NewRecorder(addressGroupStore, store.NewNetworkPolicyStore(), groupevents.DefaultRetention)
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go recorder.Run(stopCh)
+	require.Eventually(t, func() bool {
+		return addressGroupStore.GetWatchersNum() == 1
+	}, 2*time.Second, 10*time.Millisecond)
+	require.NoError(t, addressGroupStore.Create(&antreatypes.AddressGroup{
+		Name: "group1",
+		GroupMembers: controlplane.NewGroupMemberSet(
+			&controlplane.GroupMember{Pod: &controlplane.PodReference{Namespace: "ns1", Name: "pod1"}},
+			&controlplane.GroupMember{Pod: &controlplane.PodReference{Namespace: "ns1", Name: "pod2"}},
+		),
+	}))
+	require.Eventually(t, func() bool {
+		events, _, _ := recorder.Subscribe(0, groupevents.Filter{}, false)
+		return len(events) == 2
+	}, 2*time.Second, 10*time.Millisecond)
+
+	testCases := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expectedIDs    []uint64
+	}{
+		{
+			name:           "all events",
+			query:          "",
+			expectedStatus: http.StatusOK,
+			expectedIDs:    []uint64{1, 2},
+		},
+		{
+			name:           "events since",
+			query:          "?since=1",
+			expectedStatus: http.StatusOK,
+			expectedIDs:    []uint64{2},
+		},
+		{
+			name:           "events of unknown policy",
+			query:          "?policy=acnp1",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "invalid since",
+			query:          "?since=foo",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+	handler := HandleFunc(recorder)
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, "/groupevents"+tc.query, nil)
+			require.NoError(t, err)
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, req)
+			assert.Equal(t, tc.expectedStatus, recorder.Code)
+			if tc.expectedStatus != http.StatusOK {
+				return
+			}
+			var ids []uint64
+			decoder := json.NewDecoder(strings.NewReader(recorder.Body.String()))
+			for decoder.More() {
+				var event groupevents.Event
+				require.NoError(t, decoder.Decode(&event))
+				assert.Equal(t, "group1", event.Group)
+				ids = append(ids, event.ID)
+			}
+			assert.Equal(t, tc.expectedIDs, ids)
+		})
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package largestgroups
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"antrea.io/antrea/pkg/antctl/transform/common"
+	"antrea.io/antrea/pkg/controller/networkpolicy"
+)
+
+// defaultLimit is the number of groups returned when the "limit" query parameter is not provided.
+const defaultLimit = 10
+
+// Response is the response struct of largestgroups command.
+type Response struct {
+	networkpolicy.GroupSize
+}
+
+// HandleFunc creates a http.HandlerFunc which uses an EndpointQuerier to report the
+// AddressGroups and AppliedToGroups with the most members, and the policies referencing them.
+func HandleFunc(eq networkpolicy.EndpointQuerier) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		limit := defaultLimit
+		if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+			var err error
+			limit, err = strconv.Atoi(limitStr)
+			if err != nil || limit <= 0 {
+				http.Error(w, "limit must be a positive integer", http.StatusBadRequest)
+				return
+			}
+		}
+		groups, err := eq.QueryLargestGroups(limit)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		resps := make([]Response, 0, len(groups))
+		for _, group := range groups {
+			resps = append(resps, Response{group})
+		}
+		if err := json.NewEncoder(w).Encode(resps); err != nil {
+			http.Error(w, "failed to encode response: "+err.Error(), http.StatusInternalServerError)
+		}
+	}
+}
+
+var _ common.TableOutput = new(Response)
+
+func (r Response) GetTableHeader() []string {
+	return []string{"TYPE", "NAME", "SIZE", "POLICIES"}
+}
+
+func (r Response) GetTableRow(maxColumnLength int) []string {
+	policies := make([]string, 0, len(r.Policies))
+	for _, policy := range r.Policies {
+		name := policy.Name
+		if policy.Namespace != "" {
+			name = policy.Namespace + "/" + name
+		}
+		policies = append(policies, string(policy.Type)+":"+name)
+	}
+	return []string{r.Type, r.Name, strconv.Itoa(r.Size), common.GenerateTableElementWithSummary(policies, maxColumnLength)}
+}
+
+// SortRows returns false as the groups are already sorted by decreasing size.
+func (r Response) SortRows() bool {
+	return false
+}
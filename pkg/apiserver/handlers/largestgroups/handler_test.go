@@ -0,0 +1,127 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package largestgroups
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	cpv1beta "antrea.io/antrea/pkg/apis/controlplane/v1beta2"
+	"antrea.io/antrea/pkg/controller/networkpolicy"
+	queriermock "antrea.io/antrea/pkg/controller/networkpolicy/testing"
+)
+
+func TestHandleFunc(t *testing.T) {
+	groups := []networkpolicy.GroupSize{
+		{
+			Type: networkpolicy.GroupTypeAddressGroup,
+			Name: "ag1",
+			Size: 100,
+			Policies: []networkpolicy.GroupPolicyRef{
+				{PolicyRef: networkpolicy.PolicyRef{Namespace: "ns1", Name: "np1"}, Type: cpv1beta.K8sNetworkPolicy},
+			},
+		},
+		{
+			Type: networkpolicy.GroupTypeAppliedToGroup,
+			Name: "atg1",
+			Size: 10,
+		},
+	}
+	testCases := []struct {
+		name              string
+		query             string
+		mockLimit         int
+		mockResponse      []networkpolicy.GroupSize
+		mockErr           error
+		expectedStatus    int
+		expectedResponses []Response
+	}{
+		{
+			name:              "default limit",
+			query:             "",
+			mockLimit:         defaultLimit,
+			mockResponse:      groups,
+			expectedStatus:    http.StatusOK,
+			expectedResponses: []Response{{groups[0]}, {groups[1]}},
+		},
+		{
+			name:              "limit",
+			query:             "?limit=1",
+			mockLimit:         1,
+			mockResponse:      groups[:1],
+			expectedStatus:    http.StatusOK,
+			expectedResponses: []Response{{groups[0]}},
+		},
+		{
+			name:              "no group",
+			query:             "?limit=5",
+			mockLimit:         5,
+			expectedStatus:    http.StatusOK,
+			expectedResponses: []Response{},
+		},
+		{
+			name:           "invalid limit",
+			query:          "?limit=-1",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "query error",
+			query:          "?limit=2",
+			mockLimit:      2,
+			mockErr:        fmt.Errorf("query error"),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+			mockQuerier := queriermock.NewMockEndpointQuerier(mockCtrl)
+			if tc.mockLimit != 0 {
+				mockQuerier.EXPECT().QueryLargestGroups(tc.mockLimit).Return(tc.mockResponse, tc.mockErr)
+			}
+			req, err := http.NewRequest(http.MethodGet, tc.query, nil)
+			require.NoError(t, err)
+			recorder := httptest.NewRecorder()
+			HandleFunc(mockQuerier).ServeHTTP(recorder, req)
+			assert.Equal(t, tc.expectedStatus, recorder.Code)
+			if tc.expectedResponses != nil {
+				var received []Response
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &received))
+				assert.Equal(t, tc.expectedResponses, received)
+			}
+		})
+	}
+}
+
+func TestGetTableRow(t *testing.T) {
+	resp := Response{networkpolicy.GroupSize{
+		Type: networkpolicy.GroupTypeAddressGroup,
+		Name: "ag1",
+		Size: 100,
+		Policies: []networkpolicy.GroupPolicyRef{
+			{PolicyRef: networkpolicy.PolicyRef{Name: "acnp1"}, Type: cpv1beta.AntreaClusterNetworkPolicy},
+			{PolicyRef: networkpolicy.PolicyRef{Namespace: "ns1", Name: "np1"}, Type: cpv1beta.K8sNetworkPolicy},
+		},
+	}}
+	assert.Equal(t, []string{"AddressGroup", "ag1", "100", "AntreaClusterNetworkPolicy:acnp1,K8sNetworkPolicy:ns1/np1"}, resp.GetTableRow(100))
+}
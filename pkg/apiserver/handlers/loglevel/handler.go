@@ -16,8 +16,10 @@ package loglevel
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"k8s.io/klog/v2"
 
@@ -25,22 +27,51 @@ import (
 )
 
 // HandleFunc returns the function which can handle the /loglevel API request.
+// A GET request returns the current log verbosity level. A POST or PUT request
+// sets the level provided with the "level" query parameter. The level is
+// reverted after the duration provided with the optional "ttl" query parameter.
+// Setting the level requires the permission to post or put "/loglevel", like the
+// other requests which change the state of the component.
 func HandleFunc() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		level := r.URL.Query().Get("level")
-		if level != "" {
-			err := log.SetLogLevel(level)
-			if err != nil {
-				http.Error(w, err.Error(), http.StatusBadRequest)
+		ttlStr := r.URL.Query().Get("ttl")
+		switch r.Method {
+		case http.MethodGet:
+			if level != "" || ttlStr != "" {
+				http.Error(w, "setting the log level requires a POST or PUT request", http.StatusMethodNotAllowed)
 				return
 			}
-		} else {
 			levelNum, _ := strconv.Atoi(log.GetCurrentLogLevel())
 			err := json.NewEncoder(w).Encode(levelNum)
 			if err != nil {
 				w.WriteHeader(http.StatusInternalServerError)
 				klog.Errorf("Error when encoding log level to json: %v", err)
 			}
+		case http.MethodPost, http.MethodPut:
+			if level == "" {
+				http.Error(w, "missing level", http.StatusBadRequest)
+				return
+			}
+			var ttl time.Duration
+			if ttlStr != "" {
+				var err error
+				ttl, err = time.ParseDuration(ttlStr)
+				if err != nil || ttl <= 0 {
+					http.Error(w, fmt.Sprintf("invalid ttl %q: must be a positive duration", ttlStr), http.StatusBadRequest)
+					return
+				}
+			}
+			if levelNum, err := strconv.Atoi(level); err != nil || levelNum < 0 {
+				http.Error(w, fmt.Sprintf("invalid level %q: must be a non-negative integer", level), http.StatusBadRequest)
+				return
+			}
+			if err := log.SetLogLevelWithTTL(level, ttl); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		default:
+			http.Error(w, fmt.Sprintf("method %s not allowed", r.Method), http.StatusMethodNotAllowed)
 		}
 	}
 }
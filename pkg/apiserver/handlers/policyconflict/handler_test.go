@@ -0,0 +1,100 @@
+// Copyright 2020 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policyconflict
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"antrea.io/antrea/pkg/controller/networkpolicy"
+	queriermock "antrea.io/antrea/pkg/controller/networkpolicy/testing"
+)
+
+func TestHandleFunc(t *testing.T) {
+	conflictResponse := &networkpolicy.PolicyConflictResponse{
+		Namespace: "ns1",
+		Name:      "pod1",
+		Advisory:  true,
+		Conflicts: []networkpolicy.PolicyConflict{
+			{
+				ShadowedRule:  networkpolicy.ConflictRule{PolicyRef: networkpolicy.PolicyRef{Name: "anp1"}, Action: "Allow"},
+				ShadowingRule: networkpolicy.ConflictRule{PolicyRef: networkpolicy.PolicyRef{Name: "acnp1"}, Action: "Drop"},
+			},
+		},
+	}
+	testCases := []struct {
+		name             string
+		query            string
+		mockNamespace    string
+		mockResponse     *networkpolicy.PolicyConflictResponse
+		mockErr          error
+		expectedStatus   int
+		expectedResponse *networkpolicy.PolicyConflictResponse
+	}{
+		{
+			name:           "missing Pod",
+			query:          "?namespace=ns1",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Pod not found",
+			query:          "?namespace=ns1&pod=pod1",
+			mockNamespace:  "ns1",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "query error",
+			query:          "?pod=pod1",
+			mockNamespace:  "default",
+			mockErr:        fmt.Errorf("query error"),
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name:             "conflicts",
+			query:            "?namespace=ns1&pod=pod1",
+			mockNamespace:    "ns1",
+			mockResponse:     conflictResponse,
+			expectedStatus:   http.StatusOK,
+			expectedResponse: conflictResponse,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+			mockQuerier := queriermock.NewMockEndpointQuerier(mockCtrl)
+			if tc.mockNamespace != "" {
+				mockQuerier.EXPECT().QueryPolicyConflicts(tc.mockNamespace, "pod1").Return(tc.mockResponse, tc.mockErr)
+			}
+			req, err := http.NewRequest(http.MethodGet, tc.query, nil)
+			require.NoError(t, err)
+			recorder := httptest.NewRecorder()
+			HandleFunc(mockQuerier).ServeHTTP(recorder, req)
+			assert.Equal(t, tc.expectedStatus, recorder.Code)
+			if tc.expectedResponse != nil {
+				var received networkpolicy.PolicyConflictResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &received))
+				assert.Equal(t, *tc.expectedResponse, received)
+			}
+		})
+	}
+}
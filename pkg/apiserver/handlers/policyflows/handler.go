@@ -0,0 +1,202 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policyflows
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/klog/v2"
+
+	agentclient "antrea.io/antrea/pkg/agent/client"
+	"antrea.io/antrea/pkg/apis/controlplane"
+	"antrea.io/antrea/pkg/apiserver/storage"
+	"antrea.io/antrea/pkg/client/clientset/versioned"
+	"antrea.io/antrea/pkg/controller/types"
+	"antrea.io/antrea/pkg/util/k8s"
+)
+
+const (
+	// defaultTimeout is the time limit of the requests to the antrea-agents when the request to the
+	// handler doesn't provide one.
+	defaultTimeout = 10 * time.Second
+	// maxTimeout is lower than the timeout of the requests to the antrea-controller API, so that the
+	// flows of the antrea-agents which responded are returned when some antrea-agents don't respond.
+	maxTimeout = 50 * time.Second
+	// maxConcurrentRequests is the maximum number of antrea-agents queried at the same time.
+	maxConcurrentRequests = 50
+)
+
+// Response is the response of the "/policyflows" endpoint.
+type Response struct {
+	// Policy is the reference of the original policy, e.g. "AntreaNetworkPolicy:default/test-anp".
+	Policy string `json:"policy"`
+	// Nodes maps the name of each Node the policy spans to the flows realizing the policy on it.
+	Nodes map[string]NodeFlows `json:"nodes"`
+}
+
+// NodeFlows are the OpenFlow flows realizing a policy on a Node.
+type NodeFlows struct {
+	Flows []string `json:"flows,omitempty"`
+	// Error is set if the flows could not be retrieved from the antrea-agent of the Node.
+	Error string `json:"error,omitempty"`
+}
+
+// AgentFlowGetter gets the OpenFlow flows realizing a policy from the antrea-agent of a Node.
+type AgentFlowGetter interface {
+	GetPolicyFlows(ctx context.Context, nodeName string, policy *controlplane.NetworkPolicyReference) ([]string, error)
+}
+
+type agentFlowGetter struct {
+	nodeLister corelisters.NodeLister
+	crdClient  versioned.Interface
+	config     *rest.Config
+}
+
+// NewAgentFlowGetter returns an AgentFlowGetter which queries the "/ovsflows" endpoint of the Agent
+// API. The credentials of config are used to authenticate to the antrea-agents, whose self-signed
+// certificates are not verified.
+func NewAgentFlowGetter(nodeLister corelisters.NodeLister, crdClient versioned.Interface, config *rest.Config) AgentFlowGetter {
+	config = rest.CopyConfig(config)
+	config.Insecure = true
+	config.CAFile = ""
+	config.CAData = nil
+	return &agentFlowGetter{
+		nodeLister: nodeLister,
+		crdClient:  crdClient,
+		config:     config,
+	}
+}
+
+func (g *agentFlowGetter) GetPolicyFlows(ctx context.Context, nodeName string, policy *controlplane.NetworkPolicyReference) ([]string, error) {
+	node, err := g.nodeLister.Get(nodeName)
+	if err != nil {
+		return nil, err
+	}
+	nodeIP, err := k8s.GetNodeAddr(node)
+	if err != nil {
+		return nil, err
+	}
+	// The AntreaAgentInfo of an antrea-agent is named after its Node.
+	agentInfo, err := g.crdClient.CrdV1beta1().AntreaAgentInfos().Get(ctx, nodeName, metav1.GetOptions{ResourceVersion: "0"})
+	if err != nil {
+		return nil, fmt.Errorf("error when getting the AntreaAgentInfo: %w", err)
+	}
+	config := rest.CopyConfig(g.config)
+	config.Host = fmt.Sprintf("https://%s", net.JoinHostPort(nodeIP.String(), fmt.Sprint(agentInfo.APIPort)))
+	client, err := agentclient.New(config, agentclient.Options{})
+	if err != nil {
+		return nil, err
+	}
+	flows, err := client.OVSFlows(ctx, agentclient.OVSFlowFilter{NetworkPolicy: policy.Name, Namespace: policy.Namespace})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, fmt.Errorf("the policy is not realized by the antrea-agent")
+		}
+		return nil, err
+	}
+	flowStrs := make([]string, 0, len(flows))
+	for _, flow := range flows {
+		flowStrs = append(flowStrs, flow.Flow)
+	}
+	return flowStrs, nil
+}
+
+// getNodeFlows gets the flows realizing the policy from the antrea-agents of the Nodes concurrently.
+// The Nodes whose antrea-agent didn't respond before ctx is done are reported with an error.
+func getNodeFlows(ctx context.Context, getter AgentFlowGetter, policy *controlplane.NetworkPolicyReference, nodeNames []string) map[string]NodeFlows {
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	nodes := make(map[string]NodeFlows, len(nodeNames))
+	sem := make(chan struct{}, maxConcurrentRequests)
+	for _, nodeName := range nodeNames {
+		nodeName := nodeName
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var nodeFlows NodeFlows
+			select {
+			case sem <- struct{}{}:
+				flows, err := getter.GetPolicyFlows(ctx, nodeName, policy)
+				<-sem
+				if err != nil {
+					nodeFlows.Error = err.Error()
+				} else {
+					nodeFlows.Flows = flows
+				}
+			case <-ctx.Done():
+				nodeFlows.Error = fmt.Sprintf("the antrea-agent was not queried in time: %v", ctx.Err())
+			}
+			mutex.Lock()
+			defer mutex.Unlock()
+			nodes[nodeName] = nodeFlows
+		}()
+	}
+	wg.Wait()
+	return nodes
+}
+
+// HandleFunc returns the function which can handle API requests to "/policyflows". It returns the
+// OpenFlow flows realizing the control plane NetworkPolicy provided with the "name" query parameter
+// on each Node the policy spans, which are retrieved from the antrea-agents of the Nodes. The
+// "timeout" query parameter sets the time limit of the requests to the antrea-agents, e.g. "5s".
+// The flows of the antrea-agents which responded in time are returned along with an error for each
+// of the other Nodes.
+func HandleFunc(networkPolicyStore storage.Interface, getter AgentFlowGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := r.URL.Query().Get("name")
+		if name == "" {
+			http.Error(w, "name must be provided", http.StatusBadRequest)
+			return
+		}
+		timeout := defaultTimeout
+		if value := r.URL.Query().Get("timeout"); value != "" {
+			var err error
+			if timeout, err = time.ParseDuration(value); err != nil || timeout <= 0 || timeout > maxTimeout {
+				http.Error(w, fmt.Sprintf("invalid timeout, it must be a positive duration up to %v", maxTimeout), http.StatusBadRequest)
+				return
+			}
+		}
+		obj, exists, err := networkPolicyStore.Get(name)
+		if err != nil {
+			klog.Errorf("Failed to get NetworkPolicy %s: %v", name, err)
+			http.Error(w, "failed to get NetworkPolicy", http.StatusInternalServerError)
+			return
+		}
+		if !exists {
+			http.Error(w, fmt.Sprintf("NetworkPolicy %s not found", name), http.StatusNotFound)
+			return
+		}
+		policy := obj.(*types.NetworkPolicy)
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+		resp := Response{
+			Policy: policy.SourceRef.ToString(),
+			Nodes:  getNodeFlows(ctx, getter, policy.SourceRef, policy.NodeNames.List()),
+		}
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			http.Error(w, "failed to encode response: "+err.Error(), http.StatusInternalServerError)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policyflows
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"antrea.io/antrea/pkg/apis/controlplane"
+	"antrea.io/antrea/pkg/controller/networkpolicy/store"
+	"antrea.io/antrea/pkg/controller/types"
+)
+
+// fakeFlowGetter returns the flows of the Nodes in flows, and an error for the other Nodes. The
+// requests to the Nodes in hungNodes only return when their context is done.
+type fakeFlowGetter struct {
+	flows     map[string][]string
+	hungNodes sets.String
+}
+
+func (g *fakeFlowGetter) GetPolicyFlows(ctx context.Context, nodeName string, policy *controlplane.NetworkPolicyReference) ([]string, error) {
+	if g.hungNodes.Has(nodeName) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	flows, ok := g.flows[nodeName]
+	if !ok {
+		return nil, fmt.Errorf("connection refused")
+	}
+	return flows, nil
+}
+
+func TestHandleFunc(t *testing.T) {
+	policyStore := store.NewNetworkPolicyStore()
+	require.NoError(t, policyStore.Create(&types.NetworkPolicy{
+		Name:      "uid1",
+		SourceRef: &controlplane.NetworkPolicyReference{Type: controlplane.AntreaNetworkPolicy, Namespace: "ns1", Name: "anp1"},
+		SpanMeta:  types.SpanMeta{NodeNames: sets.NewString("node1", "node2", "node3", "node4")},
+	}))
+	getter := &fakeFlowGetter{
+		flows: map[string][]string{
+			"node1": {"table=AntreaPolicyIngressRule, priority=44900,conj_id=1 actions=goto_table:IngressMetric"},
+			"node2": {},
+			"node4": {"table=AntreaPolicyIngressRule, priority=44900,conj_id=1 actions=goto_table:IngressMetric"},
+		},
+		hungNodes: sets.NewString("node4"),
+	}
+	testCases := []struct {
+		name             string
+		query            string
+		expectedStatus   int
+		expectedResponse *Response
+	}{
+		{
+			name:           "partial result",
+			query:          "?name=uid1&timeout=100ms",
+			expectedStatus: http.StatusOK,
+			expectedResponse: &Response{
+				Policy: "AntreaNetworkPolicy:ns1/anp1",
+				Nodes: map[string]NodeFlows{
+					"node1": {Flows: getter.flows["node1"]},
+					"node2": {},
+					"node3": {Error: "connection refused"},
+					"node4": {Error: context.DeadlineExceeded.Error()},
+				},
+			},
+		},
+		{
+			name:           "missing name",
+			query:          "",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid timeout",
+			query:          "?name=uid1&timeout=10m",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "unknown policy",
+			query:          "?name=uid2",
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := HandleFunc(policyStore, getter)
+			req, err := http.NewRequest(http.MethodGet, "/policyflows"+tc.query, nil)
+			require.NoError(t, err)
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, req)
+			require.Equal(t, tc.expectedStatus, recorder.Code)
+			if tc.expectedResponse == nil {
+				return
+			}
+			var resp Response
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &resp))
+			assert.Equal(t, *tc.expectedResponse, resp)
+		})
+	}
+}
+
+func TestGetNodeFlowsConcurrency(t *testing.T) {
+	// All the Nodes are reported with an error when the context is done, including the ones waiting
+	// for one of the maxConcurrentRequests requests in flight.
+	nodeNames := make([]string, 0, maxConcurrentRequests+1)
+	for i := 0; i <= maxConcurrentRequests; i++ {
+		nodeNames = append(nodeNames, fmt.Sprintf("node%d", i))
+	}
+	getter := &fakeFlowGetter{hungNodes: sets.NewString(nodeNames...)}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	nodes := getNodeFlows(ctx, getter, &controlplane.NetworkPolicyReference{Type: controlplane.AntreaClusterNetworkPolicy, Name: "acnp1"}, nodeNames)
+	require.Len(t, nodes, len(nodeNames))
+	for _, nodeName := range nodeNames {
+		assert.NotEmpty(t, nodes[nodeName].Error)
+		assert.Empty(t, nodes[nodeName].Flows)
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policyquota
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"antrea.io/antrea/pkg/antctl/transform/common"
+	"antrea.io/antrea/pkg/controller/networkpolicy"
+)
+
+// Response is the response struct of policyquota command.
+type Response struct {
+	networkpolicy.PolicyQuotaUsage
+}
+
+// HandleFunc creates a http.HandlerFunc which uses an EndpointQuerier to report the usages of the
+// NetworkPolicy flow quotas of the Namespaces, and the policies exceeding them.
+func HandleFunc(eq networkpolicy.EndpointQuerier) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		namespace := r.URL.Query().Get("namespace")
+		usages, err := eq.QueryPolicyQuotas(namespace)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		resps := make([]Response, 0, len(usages))
+		for _, usage := range usages {
+			resps = append(resps, Response{usage})
+		}
+		if err := json.NewEncoder(w).Encode(resps); err != nil {
+			http.Error(w, "failed to encode response: "+err.Error(), http.StatusInternalServerError)
+		}
+	}
+}
+
+var _ common.TableOutput = new(Response)
+
+func (r Response) GetTableHeader() []string {
+	return []string{"NAMESPACE", "QUOTA", "FLOWS", "POLICIES", "EXCEEDING"}
+}
+
+func (r Response) GetTableRow(maxColumnLength int) []string {
+	exceeding := make([]string, 0, len(r.ExceedingPolicies))
+	for _, policy := range r.ExceedingPolicies {
+		exceeding = append(exceeding, string(policy.Type)+":"+policy.Name+"("+strconv.Itoa(policy.Flows)+")")
+	}
+	return []string{r.Namespace, strconv.Itoa(r.Quota), strconv.Itoa(r.Flows), strconv.Itoa(r.Policies), common.GenerateTableElementWithSummary(exceeding, maxColumnLength)}
+}
+
+// SortRows returns false as the usages are already sorted by Namespace.
+func (r Response) SortRows() bool {
+	return false
+}
@@ -0,0 +1,121 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policyquota
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	cpv1beta "antrea.io/antrea/pkg/apis/controlplane/v1beta2"
+	"antrea.io/antrea/pkg/controller/networkpolicy"
+	queriermock "antrea.io/antrea/pkg/controller/networkpolicy/testing"
+)
+
+func TestHandleFunc(t *testing.T) {
+	usages := []networkpolicy.PolicyQuotaUsage{
+		{
+			Namespace: "ns1",
+			Quota:     100,
+			Flows:     80,
+			Policies:  2,
+			ExceedingPolicies: []networkpolicy.QuotaPolicyRef{
+				{PolicyRef: networkpolicy.PolicyRef{Namespace: "ns1", Name: "np2"}, Type: cpv1beta.K8sNetworkPolicy, Flows: 30},
+			},
+		},
+		{
+			Namespace: "ns2",
+			Quota:     100,
+			Flows:     10,
+			Policies:  1,
+		},
+	}
+	testCases := []struct {
+		name              string
+		query             string
+		mockNamespace     string
+		mockResponse      []networkpolicy.PolicyQuotaUsage
+		mockErr           error
+		expectedStatus    int
+		expectedResponses []Response
+	}{
+		{
+			name:              "all Namespaces",
+			query:             "",
+			mockResponse:      usages,
+			expectedStatus:    http.StatusOK,
+			expectedResponses: []Response{{usages[0]}, {usages[1]}},
+		},
+		{
+			name:              "Namespace",
+			query:             "?namespace=ns2",
+			mockNamespace:     "ns2",
+			mockResponse:      usages[1:],
+			expectedStatus:    http.StatusOK,
+			expectedResponses: []Response{{usages[1]}},
+		},
+		{
+			name:              "quota disabled",
+			query:             "",
+			expectedStatus:    http.StatusOK,
+			expectedResponses: []Response{},
+		},
+		{
+			name:           "query error",
+			query:          "?namespace=ns1",
+			mockNamespace:  "ns1",
+			mockErr:        fmt.Errorf("query error"),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+			mockQuerier := queriermock.NewMockEndpointQuerier(mockCtrl)
+			mockQuerier.EXPECT().QueryPolicyQuotas(tc.mockNamespace).Return(tc.mockResponse, tc.mockErr)
+			req, err := http.NewRequest(http.MethodGet, tc.query, nil)
+			require.NoError(t, err)
+			recorder := httptest.NewRecorder()
+			HandleFunc(mockQuerier).ServeHTTP(recorder, req)
+			assert.Equal(t, tc.expectedStatus, recorder.Code)
+			if tc.expectedResponses != nil {
+				var received []Response
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &received))
+				assert.Equal(t, tc.expectedResponses, received)
+			}
+		})
+	}
+}
+
+func TestGetTableRow(t *testing.T) {
+	resp := Response{networkpolicy.PolicyQuotaUsage{
+		Namespace: "ns1",
+		Quota:     100,
+		Flows:     80,
+		Policies:  3,
+		ExceedingPolicies: []networkpolicy.QuotaPolicyRef{
+			{PolicyRef: networkpolicy.PolicyRef{Namespace: "ns1", Name: "anp1"}, Type: cpv1beta.AntreaNetworkPolicy, Flows: 40},
+			{PolicyRef: networkpolicy.PolicyRef{Namespace: "ns1", Name: "np1"}, Type: cpv1beta.K8sNetworkPolicy, Flows: 30},
+		},
+	}}
+	assert.Equal(t, []string{"ns1", "100", "80", "3", "AntreaNetworkPolicy:anp1(40),K8sNetworkPolicy:np1(30)"}, resp.GetTableRow(100))
+}
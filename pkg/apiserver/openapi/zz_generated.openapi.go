@@ -2394,9 +2394,25 @@ func schema_pkg_apis_controlplane_v1beta2_NetworkPolicyNodeStatus(ref common.Ref
 							Format:      "int64",
 						},
 					},
+					"realizationTime": {
+						SchemaProps: spec.SchemaProps{
+							Description: "The time at which the Node realized the generation.",
+							Default:     map[string]interface{}{},
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
+					"realizationError": {
+						SchemaProps: spec.SchemaProps{
+							Description: "The reason why the Node failed to realize some rules of the generation. It's empty if there is no failure, in which case the generation is considered realized by the Node.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
 				},
 			},
 		},
+		Dependencies: []string{
+			"k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
 	}
 }
 
@@ -2865,9 +2881,16 @@ func schema_pkg_apis_crd_v1beta1_AgentCondition(ref common.ReferenceCallback) co
 							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
 						},
 					},
-					"reason": {
+					"lastTransitionTime": {
 						SchemaProps: spec.SchemaProps{
 							Description: "The timestamp when AntreaAgentInfo is created/updated, ideally heartbeat interval is 60s",
+							Default:     map[string]interface{}{},
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
+					"reason": {
+						SchemaProps: spec.SchemaProps{
+							Description: "The timestamp when the status of the condition last changed",
 							Type:        []string{"string"},
 							Format:      "",
 						},
@@ -16,6 +16,7 @@ package networkpolicy
 
 import (
 	"context"
+	"fmt"
 
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/apis/meta/internalversion"
@@ -35,6 +36,8 @@ import (
 // REST implements rest.Storage for NetworkPolicies.
 type REST struct {
 	networkPolicyStore storage.Interface
+	// storeSynced is closed once the initial computation of NetworkPolicies is done.
+	storeSynced <-chan struct{}
 }
 
 var (
@@ -46,8 +49,9 @@ var (
 )
 
 // NewREST returns a REST object that will work against API services.
-func NewREST(networkPolicyStore storage.Interface) *REST {
-	return &REST{networkPolicyStore}
+// Watches are not served before storeSynced is closed, which can be nil if the store doesn't need to be waited for.
+func NewREST(networkPolicyStore storage.Interface, storeSynced <-chan struct{}) *REST {
+	return &REST{networkPolicyStore, storeSynced}
 }
 
 func (r *REST) New() runtime.Object {
@@ -94,6 +98,9 @@ func (r *REST) NamespaceScoped() bool {
 }
 
 func (r *REST) Watch(ctx context.Context, options *internalversion.ListOptions) (watch.Interface, error) {
+	if err := networkpolicy.WaitForStoreSynced(ctx, r.storeSynced); err != nil {
+		return nil, errors.NewServiceUnavailable(fmt.Sprintf("the initial computation of NetworkPolicies is not done: %v", err))
+	}
 	key, label, field := networkpolicy.GetSelectors(options)
 	return r.networkPolicyStore.Watch(ctx, key, label, field)
 }
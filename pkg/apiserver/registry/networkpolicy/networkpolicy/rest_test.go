@@ -71,7 +71,7 @@ func TestRESTList(t *testing.T) {
 			for _, obj := range tt.networkPolicies {
 				storage.Create(obj)
 			}
-			r := NewREST(storage)
+			r := NewREST(storage, nil)
 			actualObj, err := r.List(context.TODO(), &internalversion.ListOptions{LabelSelector: tt.labelSelector})
 			assert.NoError(t, err)
 			assert.ElementsMatch(t, tt.expectedObj.(*controlplane.NetworkPolicyList).Items, actualObj.(*controlplane.NetworkPolicyList).Items)
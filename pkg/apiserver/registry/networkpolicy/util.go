@@ -15,6 +15,8 @@
 package networkpolicy
 
 import (
+	"context"
+
 	"k8s.io/apimachinery/pkg/apis/meta/internalversion"
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/labels"
@@ -33,3 +35,18 @@ func GetSelectors(options *internalversion.ListOptions) (string, labels.Selector
 	key, _ := field.RequiresExactMatch("metadata.name")
 	return key, label, field
 }
+
+// WaitForStoreSynced blocks until the provided channel is closed, i.e. the initial computation of the store is done,
+// so that watchers don't receive a partial state of the store as initial events. It returns an error if the context
+// is done first. A nil channel means that the store doesn't need to be waited for.
+func WaitForStoreSynced(ctx context.Context, storeSynced <-chan struct{}) error {
+	if storeSynced == nil {
+		return nil
+	}
+	select {
+	case <-storeSynced:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright 2019 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package networkpolicy
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWaitForStoreSynced(t *testing.T) {
+	closedCh := make(chan struct{})
+	close(closedCh)
+	tests := []struct {
+		name        string
+		storeSynced chan struct{}
+		expectErr   bool
+	}{
+		{
+			name:        "nil channel",
+			storeSynced: nil,
+			expectErr:   false,
+		},
+		{
+			name:        "synced",
+			storeSynced: closedCh,
+			expectErr:   false,
+		},
+		{
+			name:        "not synced",
+			storeSynced: make(chan struct{}),
+			expectErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			err := WaitForStoreSynced(ctx, tt.storeSynced)
+			if tt.expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
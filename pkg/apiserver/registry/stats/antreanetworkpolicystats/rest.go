@@ -25,6 +25,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/apiserver/pkg/endpoints/request"
 	"k8s.io/apiserver/pkg/registry/rest"
 
@@ -46,12 +47,15 @@ var (
 	_ rest.Scoper  = &REST{}
 	_ rest.Getter  = &REST{}
 	_ rest.Lister  = &REST{}
+	_ rest.Watcher = &REST{}
 )
 
 type statsProvider interface {
 	ListAntreaNetworkPolicyStats(namespace string) []statsv1alpha1.AntreaNetworkPolicyStats
 
 	GetAntreaNetworkPolicyStats(namespace, name string) (*statsv1alpha1.AntreaNetworkPolicyStats, bool)
+
+	WatchAntreaNetworkPolicyStats(namespace string, labelSelector labels.Selector) watch.Interface
 }
 
 func (r *REST) New() runtime.Object {
@@ -59,7 +63,7 @@ func (r *REST) New() runtime.Object {
 }
 
 func (r *REST) NewList() runtime.Object {
-	return &statsv1alpha1.NetworkPolicyStatsList{}
+	return &statsv1alpha1.AntreaNetworkPolicyStatsList{}
 }
 
 func (r *REST) List(ctx context.Context, options *internalversion.ListOptions) (runtime.Object, error) {
@@ -105,6 +109,23 @@ func (r *REST) Get(ctx context.Context, name string, options *metav1.GetOptions)
 	return metric, nil
 }
 
+// Watch watches the stats of the Antrea NetworkPolicies in the Namespace of the request, or in all
+// Namespaces if the request has no Namespace. The current stats are sent as ADDED events first.
+func (r *REST) Watch(ctx context.Context, options *internalversion.ListOptions) (watch.Interface, error) {
+	if !features.DefaultFeatureGate.Enabled(features.NetworkPolicyStats) {
+		return nil, errors.NewBadRequest("feature NetworkPolicyStats disabled")
+	}
+	if !features.DefaultFeatureGate.Enabled(features.AntreaPolicy) {
+		return nil, errors.NewBadRequest("feature AntreaPolicy disabled")
+	}
+	labelSelector := labels.Everything()
+	if options != nil && options.LabelSelector != nil {
+		labelSelector = options.LabelSelector
+	}
+	ns, _ := request.NamespaceFrom(ctx)
+	return r.statsProvider.WatchAntreaNetworkPolicyStats(ns, labelSelector), nil
+}
+
 var swaggerMetadataDescriptions = metav1.ObjectMeta{}.SwaggerDoc()
 
 func (r *REST) ConvertToTable(ctx context.Context, obj runtime.Object, tableOptions runtime.Object) (*metav1.Table, error) {
@@ -24,6 +24,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/apiserver/pkg/endpoints/request"
 	featuregatetesting "k8s.io/component-base/featuregate/testing"
 
@@ -33,6 +34,8 @@ import (
 
 type fakeStatsProvider struct {
 	stats map[string]map[string]statsv1alpha1.AntreaNetworkPolicyStats
+	// watchedNamespace is the Namespace of the last watch.
+	watchedNamespace *string
 }
 
 func (p *fakeStatsProvider) ListAntreaNetworkPolicyStats(namespace string) []statsv1alpha1.AntreaNetworkPolicyStats {
@@ -60,6 +63,11 @@ func (p *fakeStatsProvider) GetAntreaNetworkPolicyStats(namespace, name string)
 	return &m, true
 }
 
+func (p *fakeStatsProvider) WatchAntreaNetworkPolicyStats(namespace string, labelSelector labels.Selector) watch.Interface {
+	p.watchedNamespace = &namespace
+	return watch.NewEmptyWatch()
+}
+
 func TestRESTGet(t *testing.T) {
 	tests := []struct {
 		name                      string
@@ -329,3 +337,59 @@ func TestRESTList(t *testing.T) {
 		})
 	}
 }
+
+func TestRESTWatch(t *testing.T) {
+	tests := []struct {
+		name                      string
+		networkPolicyStatsEnabled bool
+		antreaPolicyEnabled       bool
+		npNamespace               string
+		expectedErr               bool
+	}{
+		{
+			name:                      "NetworkPolicyStats feature disabled",
+			networkPolicyStatsEnabled: false,
+			antreaPolicyEnabled:       true,
+			expectedErr:               true,
+		},
+		{
+			name:                      "AntreaPolicy feature disabled",
+			networkPolicyStatsEnabled: true,
+			antreaPolicyEnabled:       false,
+			expectedErr:               true,
+		},
+		{
+			name:                      "all namespaces",
+			networkPolicyStatsEnabled: true,
+			antreaPolicyEnabled:       true,
+			npNamespace:               "",
+		},
+		{
+			name:                      "one namespace",
+			networkPolicyStatsEnabled: true,
+			antreaPolicyEnabled:       true,
+			npNamespace:               "foo",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer featuregatetesting.SetFeatureGateDuringTest(t, features.DefaultFeatureGate, features.NetworkPolicyStats, tt.networkPolicyStatsEnabled)()
+			defer featuregatetesting.SetFeatureGateDuringTest(t, features.DefaultFeatureGate, features.AntreaPolicy, tt.antreaPolicyEnabled)()
+
+			p := &fakeStatsProvider{}
+			r := &REST{statsProvider: p}
+			ctx := request.WithNamespace(context.TODO(), tt.npNamespace)
+			w, err := r.Watch(ctx, &internalversion.ListOptions{})
+			if tt.expectedErr {
+				require.Error(t, err)
+				assert.Nil(t, p.watchedNamespace)
+				return
+			}
+			require.NoError(t, err)
+			defer w.Stop()
+			// The watch is scoped to the Namespace of the request.
+			require.NotNil(t, p.watchedNamespace)
+			assert.Equal(t, tt.npNamespace, *p.watchedNamespace)
+		})
+	}
+}
@@ -260,6 +260,7 @@ func (r *supportBundleREST) collectAgent(ctx context.Context) (*systemv1beta1.Su
 		dumper.DumpAgentInfo,
 		dumper.DumpHeapPprof,
 		dumper.DumpOVSPorts,
+		dumper.DumpCrashJournal,
 	)
 }
 
@@ -81,4 +81,8 @@ type Interface interface {
 
 	// GetWatchersNum gets the number of watchers for the store.
 	GetWatchersNum() int
+
+	// Shutdown stops accepting new watchers, notifies existing watchers that the store is shutting down and
+	// terminates them. It blocks until all watchers have been terminated or the provided context is done.
+	Shutdown(ctx context.Context)
 }
@@ -20,6 +20,7 @@ import (
 	"sync"
 	"time"
 
+	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -72,7 +73,12 @@ type store struct {
 	// watchers is a mapping from the index of a watcher to the watcher.
 	watchers watchersMap
 
+	// stopCh is closed when the store has dispatched the shutdownEvent and stopped dispatching events.
 	stopCh chan struct{}
+	// stopOnce guarantees Shutdown will perform exactly once.
+	stopOnce sync.Once
+	// stopped indicates the store has been shut down and no longer accepts watchers. It's protected by watcherMutex.
+	stopped bool
 	// timer is used when sending events to watchers. Hold it here to avoid unnecessary
 	// re-allocation for each event.
 	timer *time.Timer
@@ -118,7 +124,11 @@ func (s *store) processEvent(event antreastorage.InternalEvent) {
 		// Monitor if this gets backed up, and how much.
 		klog.V(1).Infof("%v objects queued in incoming channel", curLen)
 	}
-	s.incoming <- event
+	select {
+	case s.incoming <- event:
+	case <-s.stopCh:
+		// The store has been shut down, no watcher will receive the event.
+	}
 }
 
 // Get returns the object matching the provided key along with a boolean value
@@ -258,11 +268,17 @@ func (s *store) Watch(ctx context.Context, key string, labelSelector labels.Sele
 		s.watcherMutex.Lock()
 		defer s.watcherMutex.Unlock()
 
+		if s.stopped {
+			return nil
+		}
 		w := newStoreWatcher(watcherChanSize, selectors, forgetWatcher(s, s.watcherIdx), s.newFunc)
 		s.watchers[s.watcherIdx] = w
 		s.watcherIdx++
 		return w
 	}()
+	if watcher == nil {
+		return nil, errors.NewServiceUnavailable("the store is shutting down")
+	}
 
 	// Specify current resourceVersion so that old events that were currently buffered in incoming channel won't be
 	// delivered to the watcher twice when initEvents already have them.
@@ -278,6 +294,48 @@ func (s *store) GetWatchersNum() int {
 	return len(s.watchers)
 }
 
+// Shutdown stops accepting new watchers and queues a shutdownEvent after all pending events, so that existing
+// watchers receive the pending events first, then learn that the watch is terminated by the server and can
+// re-establish it with another instance. It waits for the watchers to deliver the events until ctx is done, after
+// which the remaining watchers are stopped forcibly.
+func (s *store) Shutdown(ctx context.Context) {
+	s.stopOnce.Do(func() {
+		func() {
+			s.watcherMutex.Lock()
+			defer s.watcherMutex.Unlock()
+			s.stopped = true
+		}()
+		func() {
+			s.eventMutex.Lock()
+			defer s.eventMutex.Unlock()
+			s.processEvent(&shutdownEvent{})
+		}()
+		// stopCh is closed once the shutdownEvent has been dispatched.
+		select {
+		case <-s.stopCh:
+		case <-ctx.Done():
+		}
+
+		watchers := func() []*storeWatcher {
+			s.watcherMutex.RLock()
+			defer s.watcherMutex.RUnlock()
+			watchers := make([]*storeWatcher, 0, len(s.watchers))
+			for _, w := range s.watchers {
+				watchers = append(watchers, w)
+			}
+			return watchers
+		}()
+		klog.V(2).Infof("Shutting down store with %d watchers", len(watchers))
+		for _, w := range watchers {
+			select {
+			case <-w.processed:
+			case <-ctx.Done():
+			}
+			w.Stop()
+		}
+	})
+}
+
 func forgetWatcher(s *store, index int) func() {
 	return func() {
 		s.watcherMutex.Lock()
@@ -295,6 +353,10 @@ func (s *store) dispatchEvents() {
 				return
 			}
 			s.dispatchEvent(event)
+			if _, ok := event.(*shutdownEvent); ok {
+				close(s.stopCh)
+				return
+			}
 		case <-s.stopCh:
 			return
 		}
@@ -18,11 +18,14 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	goruntime "runtime"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/labels"
@@ -527,3 +530,47 @@ func TestRamStoreWatchTimeout(t *testing.T) {
 	}
 	assert.Equal(t, 1, store.GetWatchersNum(), "Unexpected watchers number")
 }
+
+func TestRamStoreShutdown(t *testing.T) {
+	goroutines := goruntime.NumGoroutine()
+
+	store := NewStore(cache.MetaNamespaceKeyFunc, cache.Indexers{}, testGenEvent, testSelectFunc, func() runtime.Object { return new(v1.Pod) })
+	store.Create(&v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod1"}})
+	w, err := store.Watch(context.Background(), "", labels.Everything(), fields.Everything())
+	require.NoError(t, err)
+	// The event may still be pending in the incoming channel when Shutdown is called, it should be delivered to the
+	// watcher before the shutdown event.
+	store.Create(&v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod2"}})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	store.Shutdown(ctx)
+	// Shutdown should be idempotent.
+	store.Shutdown(ctx)
+
+	var events []watch.Event
+	for event := range w.ResultChan() {
+		events = append(events, event)
+	}
+	require.Len(t, events, 4)
+	assert.Equal(t, watch.Event{Type: watch.Added, Object: &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod1"}}}, events[0])
+	assert.Equal(t, watch.Event{Type: watch.Bookmark, Object: &v1.Pod{}}, events[1])
+	assert.Equal(t, watch.Event{Type: watch.Added, Object: &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod2"}}}, events[2])
+	assert.Equal(t, watch.Error, events[3].Type)
+	status, ok := events[3].Object.(*metav1.Status)
+	require.True(t, ok)
+	assert.Equal(t, metav1.StatusReasonServiceUnavailable, status.Reason)
+	assert.Equal(t, 0, store.GetWatchersNum())
+
+	_, err = store.Watch(context.Background(), "", labels.Everything(), fields.Everything())
+	assert.True(t, errors.IsServiceUnavailable(err))
+	// Updating the store after shutdown must not block.
+	for i := 0; i < 200; i++ {
+		store.Update(&v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod1", Labels: map[string]string{"index": fmt.Sprint(i)}}})
+	}
+
+	// Neither the dispatching goroutine nor the watcher goroutine should be left running.
+	assert.Eventually(t, func() bool {
+		return goruntime.NumGoroutine() <= goroutines
+	}, time.Second, 10*time.Millisecond, "Goroutines leaked after shutting down the store")
+}
@@ -19,6 +19,7 @@ import (
 	"sync"
 	"time"
 
+	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/klog/v2"
@@ -39,6 +40,20 @@ func (b *bookmarkEvent) GetResourceVersion() uint64 {
 	return b.resourceVersion
 }
 
+// shutdownEvent is sent to watchers when the store is shutting down. It's converted to an Error event carrying a
+// ServiceUnavailable status, which tells clients that the watch is terminated by the server on purpose and should be
+// re-established.
+type shutdownEvent struct{}
+
+func (s *shutdownEvent) ToWatchEvent(selectors *storage.Selectors, isInitEvent bool) *watch.Event {
+	status := errors.NewServiceUnavailable("the server is shutting down").Status()
+	return &watch.Event{Type: watch.Error, Object: &status}
+}
+
+func (s *shutdownEvent) GetResourceVersion() uint64 {
+	return 0
+}
+
 // storeWatcher implements watch.Interface
 type storeWatcher struct {
 	// input represents the channel for incoming internal events that should be processed.
@@ -46,6 +61,8 @@ type storeWatcher struct {
 	// result represents the channel for outgoing events that will be sent to the client.
 	result chan watch.Event
 	done   chan struct{}
+	// processed is closed when process returns.
+	processed chan struct{}
 	// selectors represent a watcher's conditions to select objects.
 	selectors *storage.Selectors
 	// forget is used to cleanup the watcher.
@@ -61,6 +78,7 @@ func newStoreWatcher(chanSize int, selectors *storage.Selectors, forget func(),
 		input:     make(chan storage.InternalEvent, chanSize),
 		result:    make(chan watch.Event, chanSize),
 		done:      make(chan struct{}),
+		processed: make(chan struct{}),
 		selectors: selectors,
 		forget:    forget,
 		newFunc:   newFunc,
@@ -103,6 +121,7 @@ func (w *storeWatcher) add(event storage.InternalEvent, timer *time.Timer) bool
 // process first sends initEvents and then keeps sending events got from channel input
 // if they are newer than the specified resourceVersion.
 func (w *storeWatcher) process(ctx context.Context, initEvents []storage.InternalEvent, resourceVersion uint64) {
+	defer close(w.processed)
 	for _, event := range initEvents {
 		w.sendWatchEvent(event, true)
 	}
@@ -122,6 +141,11 @@ func (w *storeWatcher) process(ctx context.Context, initEvents []storage.Interna
 				klog.V(4).Info("The input channel has been closed, stopping process for watcher")
 				return
 			}
+			if _, ok := event.(*shutdownEvent); ok {
+				klog.V(4).Info("The store is shutting down, stopping process for watcher")
+				w.sendWatchEvent(event, false)
+				return
+			}
 			if event.GetResourceVersion() > resourceVersion {
 				w.sendWatchEvent(event, false)
 			}
@@ -0,0 +1,54 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apiserver
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"antrea.io/antrea/pkg/controller/agentversion"
+	"antrea.io/antrea/pkg/version"
+)
+
+// WithAgentVersionCheck records the versions reported by the antrea-agents in their requests to the
+// controlplane API, and reports the version of the antrea-controller in the responses so that the
+// antrea-agents can check the version skew as well. When rejectUnsupportedSkew is true, the
+// requests of the antrea-agents whose version skew with the antrea-controller is not supported are
+// rejected, so that they keep enforcing the NetworkPolicies they already received until the
+// upgrade is complete.
+func WithAgentVersionCheck(handler http.Handler, tracker *agentversion.Tracker, rejectUnsupportedSkew bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if !strings.HasPrefix(req.URL.Path, controlplanePathPrefix) {
+			handler.ServeHTTP(w, req)
+			return
+		}
+		w.Header().Set(version.ControllerVersionHeader, tracker.ControllerVersion())
+		agentVersion := req.Header.Get(version.AgentVersionHeader)
+		nodeName := req.Header.Get(version.AgentNodeNameHeader)
+		// The request is not sent by an antrea-agent, or by an antrea-agent which does not report
+		// its version.
+		if agentVersion == "" || nodeName == "" {
+			handler.ServeHTTP(w, req)
+			return
+		}
+		if !tracker.Observe(nodeName, agentVersion) && rejectUnsupportedSkew {
+			msg := fmt.Sprintf("the version skew between the antrea-agent (%s) and the antrea-controller (%s) is not supported", agentVersion, tracker.ControllerVersion())
+			http.Error(w, msg, http.StatusForbidden)
+			return
+		}
+		handler.ServeHTTP(w, req)
+	})
+}
@@ -0,0 +1,101 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apiserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"antrea.io/antrea/pkg/controller/agentversion"
+	"antrea.io/antrea/pkg/version"
+)
+
+func TestWithAgentVersionCheck(t *testing.T) {
+	tests := []struct {
+		name                  string
+		path                  string
+		agentVersion          string
+		nodeName              string
+		rejectUnsupportedSkew bool
+		expectedCode          int
+		expectedVersions      map[string]string
+	}{
+		{
+			name:             "supported skew",
+			path:             "/apis/controlplane.antrea.io/v1beta2/addressgroups",
+			agentVersion:     "v1.3.0",
+			nodeName:         "node1",
+			expectedCode:     http.StatusOK,
+			expectedVersions: map[string]string{"node1": "v1.3.0"},
+		},
+		{
+			name:             "unsupported skew",
+			path:             "/apis/controlplane.antrea.io/v1beta2/addressgroups",
+			agentVersion:     "v1.4.0",
+			nodeName:         "node1",
+			expectedCode:     http.StatusOK,
+			expectedVersions: map[string]string{"node1": "v1.4.0"},
+		},
+		{
+			name:                  "unsupported skew rejected",
+			path:                  "/apis/controlplane.antrea.io/v1beta2/addressgroups?watch=true",
+			agentVersion:          "v1.4.0",
+			nodeName:              "node1",
+			rejectUnsupportedSkew: true,
+			expectedCode:          http.StatusForbidden,
+			expectedVersions:      map[string]string{"node1": "v1.4.0"},
+		},
+		{
+			name:                  "no version",
+			path:                  "/apis/controlplane.antrea.io/v1beta2/addressgroups",
+			rejectUnsupportedSkew: true,
+			expectedCode:          http.StatusOK,
+			expectedVersions:      map[string]string{},
+		},
+		{
+			name:                  "other API",
+			path:                  "/apis/stats.antrea.io/v1alpha1/networkpolicystats",
+			agentVersion:          "v1.4.0",
+			nodeName:              "node1",
+			rejectUnsupportedSkew: true,
+			expectedCode:          http.StatusOK,
+			expectedVersions:      map[string]string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			informerFactory := informers.NewSharedInformerFactory(fake.NewSimpleClientset(), 0)
+			tracker := agentversion.NewTracker("v1.2.0", informerFactory.Core().V1().Nodes())
+			handler := WithAgentVersionCheck(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}), tracker, tt.rejectUnsupportedSkew)
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.agentVersion != "" {
+				req.Header.Set(version.AgentVersionHeader, tt.agentVersion)
+				req.Header.Set(version.AgentNodeNameHeader, tt.nodeName)
+			}
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tt.expectedCode, recorder.Code)
+			assert.Equal(t, tt.expectedVersions, tracker.GetAgentVersions())
+		})
+	}
+}
@@ -34,6 +34,10 @@ func (c *FakeCrdV1alpha1) NetworkPolicies(namespace string) v1alpha1.NetworkPoli
 	return &FakeNetworkPolicies{c, namespace}
 }
 
+func (c *FakeCrdV1alpha1) PacketCaptures() v1alpha1.PacketCaptureInterface {
+	return &FakePacketCaptures{c}
+}
+
 func (c *FakeCrdV1alpha1) Tiers() v1alpha1.TierInterface {
 	return &FakeTiers{c}
 }
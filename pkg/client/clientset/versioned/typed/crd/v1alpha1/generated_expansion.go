@@ -20,6 +20,8 @@ type ClusterNetworkPolicyExpansion interface{}
 
 type NetworkPolicyExpansion interface{}
 
+type PacketCaptureExpansion interface{}
+
 type TierExpansion interface{}
 
 type TraceflowExpansion interface{}
@@ -26,6 +26,8 @@ type CrdV1beta1Interface interface {
 	RESTClient() rest.Interface
 	AntreaAgentInfosGetter
 	AntreaControllerInfosGetter
+	ClusterNetworkPoliciesGetter
+	NetworkPoliciesGetter
 }
 
 // CrdV1beta1Client is used to interact with features provided by the crd.antrea.io group.
@@ -41,6 +43,14 @@ func (c *CrdV1beta1Client) AntreaControllerInfos() AntreaControllerInfoInterface
 	return newAntreaControllerInfos(c)
 }
 
+func (c *CrdV1beta1Client) ClusterNetworkPolicies() ClusterNetworkPolicyInterface {
+	return newClusterNetworkPolicies(c)
+}
+
+func (c *CrdV1beta1Client) NetworkPolicies(namespace string) NetworkPolicyInterface {
+	return newNetworkPolicies(c, namespace)
+}
+
 // NewForConfig creates a new CrdV1beta1Client for the given config.
 func NewForConfig(c *rest.Config) (*CrdV1beta1Client, error) {
 	config := *c
@@ -19,3 +19,7 @@ package v1beta1
 type AntreaAgentInfoExpansion interface{}
 
 type AntreaControllerInfoExpansion interface{}
+
+type ClusterNetworkPolicyExpansion interface{}
+
+type NetworkPolicyExpansion interface{}
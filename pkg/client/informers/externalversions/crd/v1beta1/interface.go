@@ -26,6 +26,10 @@ type Interface interface {
 	AntreaAgentInfos() AntreaAgentInfoInformer
 	// AntreaControllerInfos returns a AntreaControllerInfoInformer.
 	AntreaControllerInfos() AntreaControllerInfoInformer
+	// ClusterNetworkPolicies returns a ClusterNetworkPolicyInformer.
+	ClusterNetworkPolicies() ClusterNetworkPolicyInformer
+	// NetworkPolicies returns a NetworkPolicyInformer.
+	NetworkPolicies() NetworkPolicyInformer
 }
 
 type version struct {
@@ -48,3 +52,13 @@ func (v *version) AntreaAgentInfos() AntreaAgentInfoInformer {
 func (v *version) AntreaControllerInfos() AntreaControllerInfoInformer {
 	return &antreaControllerInfoInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
 }
+
+// ClusterNetworkPolicies returns a ClusterNetworkPolicyInformer.
+func (v *version) ClusterNetworkPolicies() ClusterNetworkPolicyInformer {
+	return &clusterNetworkPolicyInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
+
+// NetworkPolicies returns a NetworkPolicyInformer.
+func (v *version) NetworkPolicies() NetworkPolicyInformer {
+	return &networkPolicyInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
@@ -23,3 +23,15 @@ type AntreaAgentInfoListerExpansion interface{}
 // AntreaControllerInfoListerExpansion allows custom methods to be added to
 // AntreaControllerInfoLister.
 type AntreaControllerInfoListerExpansion interface{}
+
+// ClusterNetworkPolicyListerExpansion allows custom methods to be added to
+// ClusterNetworkPolicyLister.
+type ClusterNetworkPolicyListerExpansion interface{}
+
+// NetworkPolicyListerExpansion allows custom methods to be added to
+// NetworkPolicyLister.
+type NetworkPolicyListerExpansion interface{}
+
+// NetworkPolicyNamespaceListerExpansion allows custom methods to be added to
+// NetworkPolicyNamespaceLister.
+type NetworkPolicyNamespaceListerExpansion interface{}
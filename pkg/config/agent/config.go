@@ -0,0 +1,426 @@
+// Copyright 2019 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package agent defines the configuration of antrea-agent, which is read from the antrea-agent.conf
+// file, and the rules validating it.
+package agent
+
+import (
+	componentbaseconfig "k8s.io/component-base/config"
+)
+
+type AgentConfig struct {
+	// featureGates is a map of feature names to bools that enable or disable experimental features.
+	FeatureGates map[string]bool `yaml:"featureGates,omitempty"`
+
+	CNISocket string `yaml:"cniSocket,omitempty"`
+	// clientConnection specifies the kubeconfig file and client connection settings for the agent
+	// to communicate with the apiserver.
+	ClientConnection componentbaseconfig.ClientConnectionConfiguration `yaml:"clientConnection"`
+	// AntreaClientConnection specifies the kubeconfig file and client connection settings for the
+	// agent to communicate with the Antrea Controller apiserver.
+	AntreaClientConnection componentbaseconfig.ClientConnectionConfiguration `yaml:"antreaClientConnection"`
+	// Request the Antrea Controller to compress the controlplane API responses with gzip, which
+	// reduces the bandwidth consumed when the Node is connected to the Controller over a
+	// constrained link, at the cost of some CPU on both sides. Small responses are never
+	// compressed. Defaults to false.
+	EnableControlplaneCompression bool `yaml:"enableControlplaneCompression,omitempty"`
+	// Name of the OpenVSwitch bridge antrea-agent will create and use.
+	// Make sure it doesn't conflict with your existing OpenVSwitch bridges.
+	// Defaults to br-int.
+	OVSBridge string `yaml:"ovsBridge,omitempty"`
+	// Datapath type to use for the OpenVSwitch bridge created by Antrea. Supported values are:
+	// - system
+	// - netdev
+	// 'system' is the default value and corresponds to the kernel datapath. Use 'netdev' to run
+	// OVS in userspace mode. Userspace mode requires the tun device driver to be available.
+	OVSDatapathType string `yaml:"ovsDatapathType,omitempty"`
+	// Runtime data directory used by Open vSwitch.
+	// Default value:
+	// - On Linux platform: /var/run/openvswitch
+	// - On Windows platform: C:\openvswitch\var\run\openvswitch
+	OVSRunDir string `yaml:"ovsRunDir,omitempty"`
+	// Name of the interface antrea-agent will create and use for host <--> pod communication.
+	// Make sure it doesn't conflict with your existing interfaces.
+	// Defaults to antrea-gw0.
+	HostGateway string `yaml:"hostGateway,omitempty"`
+	// MAC address to assign to the host gateway interface. It should be a unicast address. If
+	// omitted, the MAC address is allocated automatically when the gateway interface is created.
+	// Changing the value on an existing Node will reprogram the gateway interface and its flows.
+	GatewayMAC string `yaml:"gatewayMAC,omitempty"`
+	// IPv4 address to assign to the host gateway interface. It must be a host address within the
+	// IPv4 PodCIDR of the Node. If omitted, the first address of the PodCIDR is used. It is
+	// ignored in the networkPolicyOnly mode, in which the gateway uses the Node IP.
+	GatewayIPv4 string `yaml:"gatewayIPv4,omitempty"`
+	// IPv6 address to assign to the host gateway interface. It must be a host address within the
+	// IPv6 PodCIDR of the Node. If omitted, the first address of the PodCIDR is used. It is
+	// ignored in the networkPolicyOnly mode, in which the gateway uses the Node IP.
+	GatewayIPv6 string `yaml:"gatewayIPv6,omitempty"`
+	// Determines how traffic is encapsulated. It has the following options:
+	// encap(default):    Inter-node Pod traffic is always encapsulated and Pod to external network
+	//                    traffic is SNAT'd.
+	// noEncap:           Inter-node Pod traffic is not encapsulated; Pod to external network traffic is
+	//                    SNAT'd if noSNAT is not set to true. Underlying network must be capable of
+	//                    supporting Pod traffic across IP subnets.
+	// hybrid:            noEncap if source and destination Nodes are on the same subnet, otherwise encap.
+	// networkPolicyOnly: Antrea enforces NetworkPolicy only, and utilizes CNI chaining and delegates Pod
+	//                    IPAM and connectivity to the primary CNI.
+	TrafficEncapMode string `yaml:"trafficEncapMode,omitempty"`
+	// Whether or not to SNAT (using the Node IP) the egress traffic from a Pod to the external network.
+	// This option is for the noEncap traffic mode only, and the default value is false. In the noEncap
+	// mode, if the cluster's Pod CIDR is reachable from the external network, then the Pod traffic to
+	// the external network needs not be SNAT'd. In the networkPolicyOnly mode, antrea-agent never
+	// performs SNAT and this option will be ignored; for other modes it must be set to false.
+	NoSNAT bool `yaml:"noSNAT,omitempty"`
+	// How the interfaces of the Pods, created by the primary CNI, are discovered in the networkPolicyOnly mode.
+	// The host interfaces of the Pods are attached to OVS to enforce NetworkPolicies.
+	PolicyOnlyInterfaceDiscovery PolicyOnlyInterfaceDiscoveryConfig `yaml:"policyOnlyInterfaceDiscovery,omitempty"`
+	// Whether or not to install a route for the Service CIDR via the host gateway interface, so
+	// that machines on the Node network which route the Service CIDR to the Node can reach
+	// ClusterIPs through AntreaProxy. This option is for the noEncap traffic mode only, and requires
+	// serviceCIDR to be set to the IPv4 Service CIDR of the cluster. The traffic is always SNAT'd
+	// to the IP address of the host gateway interface, so that the reply traffic goes through the
+	// same Node, even if the selected Endpoint runs on another Node. As a consequence, the
+	// Endpoint doesn't see the original source IP. Defaults to false.
+	AdvertiseServiceCIDR bool `yaml:"advertiseServiceCIDR,omitempty"`
+	// Tunnel protocols used for encapsulating traffic across Nodes. Supported values:
+	// - geneve (default)
+	// - vxlan
+	// - gre
+	// - stt
+	TunnelType string `yaml:"tunnelType,omitempty"`
+	// The destination port of the tunnel traffic, for the geneve, vxlan and stt tunnel types.
+	// It must be the same on all Nodes, as it is also the port on which tunnel traffic is
+	// received. If omitted, the default port of the tunnel type is used (6081 for geneve, 4789 for
+	// vxlan, 7471 for stt).
+	TunnelPort int `yaml:"tunnelPort,omitempty"`
+	// The DSCP value to set in the outer IP header of the tunnel traffic, between 0 and 63, or
+	// "inherit" to copy the DSCP value of the encapsulated packet. If omitted, the DSCP value of
+	// the tunnel traffic is 0. It applies to the traffic of the shared tunnel port and of the IPsec
+	// tunnel ports, not to the tunnel ports of the tunnel profiles.
+	TunnelDSCP string `yaml:"tunnelDSCP,omitempty"`
+	// Tunnel profiles for peer Nodes which require specific tunnel options, e.g. a different
+	// destination port or TOS settings for the Nodes in a given zone. A dedicated tunnel port is
+	// created for each peer Node selected by a profile, with the profile's options set on the
+	// OVS interface in addition to the remote IP of the Node. Other peer Nodes use the shared
+	// flow-based tunnel port. If a Node is selected by multiple profiles, the first one is used.
+	// Tunnel profiles cannot be used with IPsec encryption.
+	TunnelProfiles []TunnelProfile `yaml:"tunnelProfiles,omitempty"`
+	// Default MTU to use for the host gateway interface and the network interface of each Pod.
+	// If omitted, antrea-agent will discover the MTU of the Node's primary interface and
+	// also adjust MTU to accommodate for tunnel encapsulation overhead (if applicable).
+	DefaultMTU int `yaml:"defaultMTU,omitempty"`
+	// Clamp the TCP MSS of the connections between local Pods and the external network, by
+	// rewriting the MSS option of the SYN packets forwarded through the host gateway interface. It
+	// avoids relying on Path MTU Discovery when the MTU of a path is lower than the Pod MTU and
+	// the ICMP "Fragmentation Needed" messages are blocked. This option is only supported on Linux
+	// Nodes. Defaults to false.
+	TCPMSSClamping bool `yaml:"tcpMSSClamping,omitempty"`
+	// The MSS value to set in the SYN packets when tcpMSSClamping is enabled. If omitted, it is
+	// derived from the Pod MTU (which accounts for the tunnel overhead) minus the size of the IP
+	// and TCP headers.
+	TCPMSS int `yaml:"tcpMSS,omitempty"`
+	// Mount location of the /proc directory. The default is "/host", which is appropriate when
+	// antrea-agent is run as part of the Antrea DaemonSet (and the host's /proc directory is mounted
+	// as /host/proc in the antrea-agent container). When running antrea-agent as a process,
+	// hostProcPathPrefix should be set to "/" in the YAML config.
+	HostProcPathPrefix string `yaml:"hostProcPathPrefix,omitempty"`
+	// ClusterIP CIDR range for Services. It's required when AntreaProxy is not enabled, and should be
+	// set to the same value as the one specified by --service-cluster-ip-range for kube-apiserver. When
+	// AntreaProxy is enabled, this parameter is not needed and will be ignored if provided.
+	// If it's not set, the agent discovers the Service CIDR from the ClusterIPs of the Services, and
+	// uses the discovered one instead of the default value when they disagree. When it's set, it's
+	// always used, and the agent only reports whether the discovered Service CIDR is included in it.
+	// Default is 10.96.0.0/12
+	ServiceCIDR string `yaml:"serviceCIDR,omitempty"`
+	// ClusterIP CIDR range for IPv6 Services. It's required when using kube-proxy to provide IPv6 Service in a Dual-Stack
+	// cluster or an IPv6 only cluster. The value should be the same as the configuration for kube-apiserver specified by
+	// --service-cluster-ip-range. When AntreaProxy is enabled, this parameter is not needed.
+	// No default value for this field. If it's not set, the agent discovers the IPv6 Service CIDR
+	// from the ClusterIPs of the Services.
+	ServiceCIDRv6 string `yaml:"serviceCIDRv6,omitempty"`
+	// Whether or not to enable IPSec (ESP) encryption for Pod traffic across Nodes. IPSec encryption
+	// is supported only for the GRE tunnel type. Antrea uses Preshared Key (PSK) for IKE
+	// authentication. When IPSec tunnel is enabled, the PSK value must be passed to Antrea Agent
+	// through an environment variable: ANTREA_IPSEC_PSK.
+	// Defaults to false.
+	EnableIPSecTunnel bool `yaml:"enableIPSecTunnel,omitempty"`
+	// APIPort is the port for the antrea-agent APIServer to serve on.
+	// Defaults to 10350.
+	APIPort int `yaml:"apiPort,omitempty"`
+
+	// ClusterMembershipPort is the server port used by the antrea-agent to run a gossip-based cluster membership protocol. Currently it's used only when the Egress feature is enabled.
+	// Defaults to 10351.
+	ClusterMembershipPort int `yaml:"clusterPort,omitempty"`
+
+	// Enable metrics exposure via Prometheus. Initializes Prometheus metrics listener
+	// Defaults to true.
+	EnablePrometheusMetrics bool `yaml:"enablePrometheusMetrics,omitempty"`
+	// Provide the IPFIX collector address as a string with format <HOST>:[<PORT>][:<PROTO>].
+	// HOST can either be the DNS name or the IP of the Flow Collector. For example,
+	// "flow-aggregator.flow-aggregator.svc" can be provided as DNS name to connect
+	// to the Antrea Flow Aggregator service. If IP, it can be either IPv4 or IPv6.
+	// However, IPv6 address should be wrapped with [].
+	// If PORT is empty, we default to 4739, the standard IPFIX port.
+	// If no PROTO is given, we consider "tcp" as default. We support "tcp" and
+	// "udp" L4 transport protocols.
+	// Defaults to "flow-aggregator.flow-aggregator.svc:4739:tcp".
+	FlowCollectorAddr string `yaml:"flowCollectorAddr,omitempty"`
+	// List of the IPFIX collectors the flow records are exported to, each with its own address, TLS
+	// credentials, record filter and template refresh interval. When it is set, flowCollectorAddr is
+	// ignored. A collector which is down does not affect the export to the other collectors.
+	FlowCollectors []FlowCollectorConfig `yaml:"flowCollectors,omitempty"`
+	// Provide flow poll interval in format "0s". This determines how often flow
+	// exporter dumps connections in conntrack module. Flow poll interval should
+	// be greater than or equal to 1s(one second).
+	// Defaults to "5s". Valid time units are "ns", "us" (or "µs"), "ms", "s",
+	// "m", "h".
+	FlowPollInterval string `yaml:"flowPollInterval,omitempty"`
+	// Provide the active flow export timeout, which is the timeout after which
+	// a flow record is sent to the collector for active flows. Thus, for flows
+	// with a continuous stream of packets, a flow record will be exported to the
+	// collector once the elapsed time since the last export event is equal to the
+	// value of this timeout.
+	// Defaults to "30s". Valid time units are "ns", "us" (or "µs"), "ms", "s",
+	// "m", "h".
+	ActiveFlowExportTimeout string `yaml:"activeFlowExportTimeout,omitempty"`
+	// Provide the idle flow export timeout, which is the timeout after which a
+	// flow record is sent to the collector for idle flows. A flow is considered
+	// idle if no packet matching this flow has been observed since the last export
+	// event.
+	// Defaults to "15s". Valid time units are "ns", "us" (or "µs"), "ms", "s",
+	// "m", "h".
+	IdleFlowExportTimeout string `yaml:"idleFlowExportTimeout,omitempty"`
+	// Provide the port range used by NodePortLocal. When the NodePortLocal feature is enabled, a port from that range will be assigned
+	// whenever a Pod's container defines a specific port to be exposed (each container can define a list of ports as pod.spec.containers[].ports),
+	// and all Node traffic directed to that port will be forwarded to the Pod.
+	NPLPortRange string `yaml:"nplPortRange,omitempty"`
+	// Provide the address of Kubernetes apiserver, to override any value provided in kubeconfig or InClusterConfig.
+	// Defaults to "". It must be a host string, a host:port pair, or a URL to the base of the apiserver.
+	KubeAPIServerOverride string `yaml:"kubeAPIServerOverride,omitempty"`
+	// Cipher suites to use.
+	TLSCipherSuites string `yaml:"tlsCipherSuites,omitempty"`
+	// TLS min version.
+	TLSMinVersion string `yaml:"tlsMinVersion,omitempty"`
+	// Determines how NetworkPolicies are enforced after the agent starts and before it receives them from the
+	// antrea-controller for the first time. With "failOpen", no NetworkPolicy is enforced until then. With
+	// "failClosed", the new connections from and to local Pods are dropped until the NetworkPolicies are enforced,
+	// except the connections with the peers in policyBootstrapAllowlist.
+	// Defaults to "failOpen".
+	PolicyBootstrapMode string `yaml:"policyBootstrapMode,omitempty"`
+	// The peers which local Pods can connect to, and can be connected by, before the NetworkPolicies are enforced
+	// in the "failClosed" policy bootstrap mode, e.g. the cluster DNS.
+	PolicyBootstrapAllowlist []PolicyBootstrapPeer `yaml:"policyBootstrapAllowlist,omitempty"`
+	// The number of workers reconciling the NetworkPolicy rules in parallel. The rules of a NetworkPolicy are always
+	// reconciled by the same worker, one change at a time, while the rules of different NetworkPolicies are reconciled
+	// concurrently. Must be between 1 and 64.
+	// Defaults to 4.
+	NetworkPolicyWorkers int `yaml:"networkPolicyWorkers,omitempty"`
+	// The ICMP response sent for the UDP datagrams rejected by Antrea-native policy rules. With "adminProhibited",
+	// they are answered with ICMP host administratively prohibited (ICMPv6 administratively prohibited), like the
+	// other non-TCP packets. With "portUnreachable", they are answered with ICMP port unreachable (ICMPv6 port
+	// unreachable), which makes DNS resolvers fail over to the next server immediately.
+	// Defaults to "adminProhibited".
+	UDPRejectMode string `yaml:"udpRejectMode,omitempty"`
+	// Audit logging of the packets dropped because their Pods are isolated by K8s NetworkPolicies, i.e. the packets
+	// which are not allowed by any K8s NetworkPolicy rule. The packets are logged with the "K8sDefaultDrop" policy
+	// reference, in the same file as the packets matching Antrea-native policy rules with logging enabled.
+	K8sIsolationLogging K8sIsolationLoggingConfig `yaml:"k8sIsolationLogging,omitempty"`
+	// Where the audit logs of Antrea-native policies and K8s NetworkPolicy isolation are written.
+	AuditLogging AuditLoggingConfig `yaml:"auditLogging,omitempty"`
+	// Guard against the IPv6 Neighbor Discovery spoofing of local Pods: the Router Advertisements sent by Pods, and
+	// the Neighbor Advertisements sent by Pods for addresses they don't own, are dropped. It is the IPv6 counterpart
+	// of the ARP spoof guard, and only applies to the Pods with an IPv6 address.
+	NDGuard NDGuardConfig `yaml:"ndGuard,omitempty"`
+	// Guard against the packets accessing a Service looping between the Endpoint selection and the DNAT to the
+	// Endpoint: a packet whose Endpoint is reselected too many times, e.g. because of stale Service flows, is
+	// dropped and counted. The guard is always enabled with AntreaProxy.
+	ServiceLoopGuard ServiceLoopGuardConfig `yaml:"serviceLoopGuard,omitempty"`
+	// Limit of the number of connections initiated by each local Pod which are tracked at a time, so that a
+	// misbehaving Pod cannot exhaust the conntrack table of the Node: the new connections of a Pod are dropped once
+	// it reaches its limit, until some of its connections are closed. The limit of a Pod can be overridden with the
+	// "pod.antrea.io/connection-limit" annotation.
+	PodConnectionLimit PodConnectionLimitConfig `yaml:"podConnectionLimit,omitempty"`
+	// Guard against the agent being OOM killed when its buffers grow, e.g. the flow records while the flow collector is
+	// down: when the memory usage of the agent is above a watermark of its cgroup memory limit, the oldest flow
+	// records and deny connections are dropped, and the packet-in queues are shrunk.
+	MemoryGuard MemoryGuardConfig `yaml:"memoryGuard,omitempty"`
+	// Export of the audit logs and the flow records to an OpenTelemetry collector, as OTLP log records sent over
+	// gRPC. Only applicable when the OTelExporter feature is enabled.
+	OTelExporter OTelExporterConfig `yaml:"otelExporter,omitempty"`
+	// Application-level keepalive probes of the OpenFlow and OVSDB connections to Open vSwitch, which detect the
+	// connections which are dead without being closed, e.g. because ovs-vswitchd or ovsdb-server is hung, and
+	// re-establish them immediately. The flows are replayed once the OpenFlow connection is re-established.
+	OVSConnectionProbe OVSConnectionProbeConfig `yaml:"ovsConnectionProbe,omitempty"`
+}
+
+type OVSConnectionProbeConfig struct {
+	// Interval between the probes of the OpenFlow connection. "0s" disables the probes, in which case a dead
+	// connection is only detected by the OpenFlow echo requests of the agent, after at least 3 seconds, or when
+	// it is closed. Defaults to "2s".
+	OpenFlowInterval string `yaml:"openflowInterval,omitempty"`
+	// Interval between the probes of the OVSDB connection. "0s" disables the probes. Defaults to "2s".
+	OVSDBInterval string `yaml:"ovsdbInterval,omitempty"`
+	// Number of probe intervals without reply after which a connection is considered dead, hence a dead
+	// connection is detected within (failureThreshold + 1) intervals. Must be between 1 and 10. Defaults to 3.
+	FailureThreshold int `yaml:"failureThreshold,omitempty"`
+}
+
+type OTelExporterConfig struct {
+	// Address of the OTLP gRPC endpoint of the collector, as "<HOST>:<PORT>". Required when the OTelExporter
+	// feature is enabled.
+	Endpoint string `yaml:"endpoint,omitempty"`
+	// Connect to the collector without TLS. Defaults to false.
+	Insecure bool `yaml:"insecure,omitempty"`
+	// Path of the CA certificate used to verify the certificate of the collector. Defaults to the system CAs.
+	CACertPath string `yaml:"caCertPath,omitempty"`
+	// Server name used to verify the certificate of the collector. Defaults to the host of the endpoint.
+	ServerName string `yaml:"serverName,omitempty"`
+	// Headers added to the requests sent to the collector, e.g. for authentication.
+	Headers map[string]string `yaml:"headers,omitempty"`
+	// Export the audit logs of Antrea-native policies and K8s NetworkPolicy isolation. Defaults to true.
+	ExportAuditLogs bool `yaml:"exportAuditLogs"`
+	// Export the flow records of the flow exporter, instead of sending them to the IPFIX collector. Requires the
+	// FlowExporter feature. Defaults to true.
+	ExportFlowRecords bool `yaml:"exportFlowRecords"`
+	// Maximum number of log records buffered while the collector is slow or unreachable. The new log records are
+	// dropped when it is reached. Defaults to 10000.
+	QueueSize int `yaml:"queueSize,omitempty"`
+	// Maximum number of log records sent in a request. Defaults to 512.
+	BatchSize int `yaml:"batchSize,omitempty"`
+	// Maximum time a log record is buffered before it is sent, if the batch is not full. Defaults to "5s".
+	FlushInterval string `yaml:"flushInterval,omitempty"`
+}
+
+type FlowCollectorConfig struct {
+	// Name of the collector, which identifies it in the logs and in the status of the flow exporter. It must be
+	// unique.
+	Name string `yaml:"name"`
+	// Address of the collector, with the same format as flowCollectorAddr.
+	Address string `yaml:"address"`
+	// Credentials used with the "tls" protocol. Defaults to the credentials of the Flow Aggregator.
+	TLS FlowCollectorTLSConfig `yaml:"tls,omitempty"`
+	// Filter of the flow records exported to the collector. Defaults to all the flow records.
+	RecordFilter FlowRecordFilterConfig `yaml:"recordFilter,omitempty"`
+	// Interval at which the templates are sent again to the collector with the "udp" protocol, so that a collector
+	// which restarted can decode the flow records. Ignored with the "tcp" and "tls" protocols. Defaults to "30m".
+	TemplateRefreshInterval string `yaml:"templateRefreshInterval,omitempty"`
+}
+
+type FlowCollectorTLSConfig struct {
+	// Path of the CA certificate used to verify the certificate of the collector. When it is empty, the CA
+	// certificate and the client certificate of the Flow Aggregator are used.
+	CACertPath string `yaml:"caCertPath,omitempty"`
+	// Path of the client certificate presented to the collector. Defaults to no client certificate.
+	ClientCertPath string `yaml:"clientCertPath,omitempty"`
+	// Path of the key of the client certificate. Required when clientCertPath is set.
+	ClientKeyPath string `yaml:"clientKeyPath,omitempty"`
+}
+
+type FlowRecordFilterConfig struct {
+	// Types of the flows whose records are exported: "IntraNode", "InterNode", "ToExternal" or "FromExternal".
+	// Defaults to all the types.
+	FlowTypes []string `yaml:"flowTypes,omitempty"`
+	// Namespaces of the Pods whose flow records are exported: a record is exported if its source or destination
+	// Pod is in one of them. Defaults to all the Namespaces.
+	Namespaces []string `yaml:"namespaces,omitempty"`
+	// Do not export the records of the connections denied by NetworkPolicies. Defaults to false.
+	ExcludeDenyConnections bool `yaml:"excludeDenyConnections,omitempty"`
+}
+
+type PolicyOnlyInterfaceDiscoveryConfig struct {
+	// Strategy used to discover the host interface of a Pod. Supported values:
+	// - vethPeer (default): the peer of the veth in the network namespace of the Pod, e.g. for the AWS VPC CNI.
+	// - cniResult: the host interface in the result of the previous plugin in the CNI chain.
+	// - prefix: the veth whose name starts with prefix and whose peer is the interface of the Pod, e.g. "lxc"
+	//   for Cilium.
+	Strategy string `yaml:"strategy,omitempty"`
+	// Prefix of the names of the host interfaces with the prefix strategy.
+	Prefix string `yaml:"prefix,omitempty"`
+}
+
+type MemoryGuardConfig struct {
+	// Enable the memory guard. Defaults to true.
+	Enable bool `yaml:"enable"`
+	// Percentage of the cgroup memory limit of the agent above which the buffers are reduced. Defaults to 90.
+	Watermark int `yaml:"watermark,omitempty"`
+}
+
+type ServiceLoopGuardConfig struct {
+	// Enable logging of the packets dropped by the Service loop guard. Defaults to false.
+	EnableLogging bool `yaml:"enableLogging,omitempty"`
+}
+
+type PodConnectionLimitConfig struct {
+	// Connection limit of the Pods without the "pod.antrea.io/connection-limit" annotation. Defaults to 0, which
+	// means that their connections are not limited.
+	DefaultLimit int `yaml:"defaultLimit,omitempty"`
+	// Enable logging of the Pods reaching their connection limit. Defaults to false.
+	EnableLogging bool `yaml:"enableLogging,omitempty"`
+}
+
+type NDGuardConfig struct {
+	// Enable the Neighbor Discovery guard. Defaults to true.
+	Enable bool `yaml:"enable"`
+	// Enable logging of the Neighbor Discovery messages dropped by the guard. Defaults to false.
+	EnableLogging bool `yaml:"enableLogging,omitempty"`
+}
+
+type K8sIsolationLoggingConfig struct {
+	// Enable logging of the ingress packets dropped by K8s NetworkPolicy isolation. Defaults to false.
+	Ingress bool `yaml:"ingress,omitempty"`
+	// Enable logging of the egress packets dropped by K8s NetworkPolicy isolation. Defaults to false.
+	Egress bool `yaml:"egress,omitempty"`
+}
+
+type AuditLoggingConfig struct {
+	// Destination of the audit logs: "file" writes them to a rotated np.log file, "eventLog" writes
+	// them to the Windows Event Log, under the "AntreaPolicyAudit" source of the Application log.
+	// "eventLog" is only supported on Windows. Defaults to "file".
+	Destination string `yaml:"destination,omitempty"`
+	// Directory of np.log with the "file" destination. Defaults to the "networkpolicy" subdirectory
+	// of the agent log directory, i.e. /var/log/antrea/networkpolicy on Linux and
+	// C:\k\antrea\logs\networkpolicy on Windows.
+	LogDir string `yaml:"logDir,omitempty"`
+	// Format of the entries of np.log with the "file" destination: "text" writes space-separated
+	// fields, "json" writes one JSON object with explicit keys per line, which is easier to parse
+	// by log collectors. The format cannot be changed without restarting the agent. Defaults to
+	// "text".
+	Format string `yaml:"format,omitempty"`
+	// Window during which the audit log entries of identical packets, i.e. with the same source and
+	// destination IPs, destination port, protocol, policy and disposition, are aggregated into a
+	// single entry suffixed with the number of packets, e.g. "[10 packets]", or with a "packetCount"
+	// key in the "json" format. "0s" disables the aggregation. Must not be longer than "1m".
+	// Defaults to "1s".
+	AggregationWindow string `yaml:"aggregationWindow,omitempty"`
+}
+
+type PolicyBootstrapPeer struct {
+	// IP block of the peer in CIDR notation.
+	CIDR string `yaml:"cidr"`
+	// Protocol of the allowed traffic: TCP, UDP or SCTP. Defaults to TCP if port is set.
+	Protocol string `yaml:"protocol,omitempty"`
+	// Destination port of the allowed traffic. If omitted, the traffic to all ports is allowed.
+	Port int `yaml:"port,omitempty"`
+}
+
+type TunnelProfile struct {
+	// Name of the profile, which must be unique.
+	Name string `yaml:"name"`
+	// Labels of the peer Nodes the profile applies to. An empty selector selects all the Nodes.
+	NodeSelector map[string]string `yaml:"nodeSelector,omitempty"`
+	// OVS interface options of the tunnel ports, e.g. dst_port, tos, ttl or df_default. The
+	// remote_ip, local_ip, key and psk options are managed by Antrea and cannot be set.
+	Options map[string]string `yaml:"options,omitempty"`
+}
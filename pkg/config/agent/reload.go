@@ -0,0 +1,131 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"antrea.io/antrea/pkg/util/flowexport"
+)
+
+// DynamicOptions are the options which can be changed without restarting the agent. The changes of
+// the other options are ignored until the agent is restarted.
+var DynamicOptions = sets.NewString(
+	"flowPollInterval",
+	"activeFlowExportTimeout",
+	"idleFlowExportTimeout",
+	"auditLogging.destination",
+	"auditLogging.logDir",
+	"memoryGuard.watermark",
+)
+
+// ChangedOptions returns the sorted names of the options whose values differ between oldConfig and
+// newConfig. The options of a nested configuration are named after their path, e.g.
+// "auditLogging.logDir", while maps and lists are compared as a whole.
+func ChangedOptions(oldConfig, newConfig *AgentConfig) []string {
+	var changed []string
+	diffStructs("", reflect.ValueOf(*oldConfig), reflect.ValueOf(*newConfig), &changed)
+	sort.Strings(changed)
+	return changed
+}
+
+// CopyOptions sets the given options of dst to their values in src. Unknown options are ignored.
+func CopyOptions(dst, src *AgentConfig, options []string) {
+	for _, option := range options {
+		dstValue, ok := optionValue(reflect.ValueOf(dst).Elem(), option)
+		if !ok {
+			continue
+		}
+		srcValue, _ := optionValue(reflect.ValueOf(src).Elem(), option)
+		dstValue.Set(srcValue)
+	}
+}
+
+// FlowExportIntervals returns the flow poll interval and the active and idle flow export timeouts
+// set in c, or their defaults.
+func FlowExportIntervals(c *AgentConfig) (time.Duration, time.Duration, time.Duration, error) {
+	pollInterval, activeFlowTimeout, idleFlowTimeout := DefaultFlowPollInterval, DefaultActiveFlowExportTimeout, DefaultIdleFlowExportTimeout
+	var err error
+	if c.FlowPollInterval != "" {
+		if pollInterval, err = flowexport.ParseFlowIntervalString(c.FlowPollInterval); err != nil {
+			return 0, 0, 0, err
+		}
+	}
+	if c.ActiveFlowExportTimeout != "" {
+		if activeFlowTimeout, err = time.ParseDuration(c.ActiveFlowExportTimeout); err != nil {
+			return 0, 0, 0, err
+		}
+	}
+	if c.IdleFlowExportTimeout != "" {
+		if idleFlowTimeout, err = time.ParseDuration(c.IdleFlowExportTimeout); err != nil {
+			return 0, 0, 0, err
+		}
+	}
+	return pollInterval, activeFlowTimeout, idleFlowTimeout, nil
+}
+
+// optionName returns the name of the option of a field, as set in the configuration file.
+func optionName(field reflect.StructField) string {
+	name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+	if name == "" {
+		// The default name of gopkg.in/yaml.v2.
+		name = strings.ToLower(field.Name)
+	}
+	return name
+}
+
+// optionValue returns the field of the option in config, a struct value.
+func optionValue(config reflect.Value, option string) (reflect.Value, bool) {
+	value := config
+	for _, name := range strings.Split(option, ".") {
+		if value.Kind() != reflect.Struct {
+			return reflect.Value{}, false
+		}
+		found := false
+		for i := 0; i < value.NumField(); i++ {
+			if field := value.Type().Field(i); field.PkgPath == "" && optionName(field) == name {
+				value = value.Field(i)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return reflect.Value{}, false
+		}
+	}
+	return value, true
+}
+
+func diffStructs(prefix string, oldValue, newValue reflect.Value, changed *[]string) {
+	t := oldValue.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).PkgPath != "" {
+			// Unexported fields are not options.
+			continue
+		}
+		name := prefix + optionName(t.Field(i))
+		oldField, newField := oldValue.Field(i), newValue.Field(i)
+		if oldField.Kind() == reflect.Struct {
+			diffStructs(name+".", oldField, newField, changed)
+		} else if !reflect.DeepEqual(oldField.Interface(), newField.Interface()) {
+			*changed = append(*changed, name)
+		}
+	}
+}
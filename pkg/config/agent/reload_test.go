@@ -0,0 +1,73 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	componentbaseconfig "k8s.io/component-base/config"
+)
+
+func TestChangedOptions(t *testing.T) {
+	oldConfig := &AgentConfig{
+		FeatureGates:             map[string]bool{"FlowExporter": true},
+		ClientConnection:         componentbaseconfig.ClientConnectionConfiguration{Kubeconfig: "/etc/kubeconfig"},
+		FlowPollInterval:         "5s",
+		AuditLogging:             AuditLoggingConfig{Destination: AuditLogDestinationFile},
+		PolicyBootstrapAllowlist: []PolicyBootstrapPeer{{CIDR: "10.96.0.10/32"}},
+	}
+	newConfig := *oldConfig
+	assert.Empty(t, ChangedOptions(oldConfig, &newConfig))
+
+	newConfig.FeatureGates = map[string]bool{"FlowExporter": false}
+	newConfig.ClientConnection.QPS = 10
+	newConfig.FlowPollInterval = "10s"
+	newConfig.AuditLogging.LogDir = "/var/log/audit"
+	newConfig.PolicyBootstrapAllowlist = []PolicyBootstrapPeer{{CIDR: "10.96.0.10/32", Port: 53}}
+	assert.Equal(t, []string{
+		"auditLogging.logDir",
+		"clientConnection.qps",
+		"featureGates",
+		"flowPollInterval",
+		"policyBootstrapAllowlist",
+	}, ChangedOptions(oldConfig, &newConfig))
+}
+
+func TestCopyOptions(t *testing.T) {
+	dst := &AgentConfig{FlowPollInterval: "5s", OVSBridge: "br-int", MemoryGuard: MemoryGuardConfig{Enable: true, Watermark: 90}}
+	src := &AgentConfig{FlowPollInterval: "10s", OVSBridge: "br-test", MemoryGuard: MemoryGuardConfig{Enable: false, Watermark: 80}}
+	CopyOptions(dst, src, []string{"flowPollInterval", "memoryGuard.watermark", "memoryGuard.unknown", "unknown.option"})
+	assert.Equal(t, &AgentConfig{FlowPollInterval: "10s", OVSBridge: "br-int", MemoryGuard: MemoryGuardConfig{Enable: true, Watermark: 80}}, dst)
+}
+
+func TestFlowExportIntervals(t *testing.T) {
+	pollInterval, activeFlowTimeout, idleFlowTimeout, err := FlowExportIntervals(&AgentConfig{})
+	require.NoError(t, err)
+	assert.Equal(t, DefaultFlowPollInterval, pollInterval)
+	assert.Equal(t, DefaultActiveFlowExportTimeout, activeFlowTimeout)
+	assert.Equal(t, DefaultIdleFlowExportTimeout, idleFlowTimeout)
+
+	pollInterval, activeFlowTimeout, idleFlowTimeout, err = FlowExportIntervals(&AgentConfig{FlowPollInterval: "1s", ActiveFlowExportTimeout: "1m", IdleFlowExportTimeout: "10s"})
+	require.NoError(t, err)
+	assert.Equal(t, time.Second, pollInterval)
+	assert.Equal(t, time.Minute, activeFlowTimeout)
+	assert.Equal(t, 10*time.Second, idleFlowTimeout)
+
+	_, _, _, err = FlowExportIntervals(&AgentConfig{IdleFlowExportTimeout: "10"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,494 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"runtime"
+	"strconv"
+	"time"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	componentbaseconfig "k8s.io/component-base/config"
+	"k8s.io/component-base/featuregate"
+
+	nplutil "antrea.io/antrea/pkg/agent/nodeportlocal/util"
+	"antrea.io/antrea/pkg/apis"
+	"antrea.io/antrea/pkg/features"
+	"antrea.io/antrea/pkg/util/flowexport"
+)
+
+const (
+	DefaultFlowPollInterval        = 5 * time.Second
+	DefaultActiveFlowExportTimeout = 30 * time.Second
+	DefaultIdleFlowExportTimeout   = 15 * time.Second
+	DefaultNPLPortRange            = "40000-41000"
+	DefaultMemoryGuardWatermark    = 90
+	DefaultNetworkPolicyWorkers    = 4
+	DefaultOTelExporterQueueSize   = 10000
+	DefaultOTelExporterBatchSize   = 512
+	DefaultOTelExporterFlushPeriod = 5 * time.Second
+
+	DefaultAuditLogAggregationWindow = time.Second
+
+	DefaultOVSConnectionProbeInterval         = 2 * time.Second
+	DefaultOVSConnectionProbeFailureThreshold = 3
+
+	AuditLogDestinationFile     = "file"
+	AuditLogDestinationEventLog = "eventLog"
+
+	AuditLogFormatText = "text"
+	AuditLogFormatJSON = "json"
+
+	PolicyOnlyInterfaceDiscoveryVethPeer  = "vethPeer"
+	PolicyOnlyInterfaceDiscoveryCNIResult = "cniResult"
+	PolicyOnlyInterfaceDiscoveryPrefix    = "prefix"
+
+	// TunnelDSCPInherit is the value of tunnelDSCP which copies the DSCP value of the encapsulated
+	// packets to the tunnel traffic.
+	TunnelDSCPInherit = "inherit"
+
+	// minMTU is the minimum MTU of IPv4 links (RFC 791), and maxMTU is the size of the largest IPv4
+	// packet.
+	minMTU = 68
+	maxMTU = 65535
+	// minTCPMSS is the minimum MSS which must be supported by every IPv4 host (RFC 879), and
+	// maxTCPMSS is the MSS of the largest IPv4 packet.
+	minTCPMSS = 536
+	maxTCPMSS = 65495
+	// tcpIPHeaderLen is the size of the IPv4 and TCP headers without options, i.e. the difference
+	// between the MTU and the MSS.
+	tcpIPHeaderLen = 40
+
+	minPort = 1
+	maxPort = 65535
+
+	// maxDSCP is the largest value of the 6-bit DSCP field.
+	maxDSCP = 63
+
+	// maxNetworkPolicyWorkers bounds the concurrency of the NetworkPolicy rule reconciliation, as
+	// the workers eventually contend for the OVS bridge.
+	maxNetworkPolicyWorkers = 64
+
+	// maxPodConnectionLimit is the largest connection limit of a Pod, which is bounded by the
+	// size of the conntrack table anyway.
+	maxPodConnectionLimit = math.MaxInt32
+
+	// maxInterfaceNameLen is the maximum length of the name of a Linux interface, i.e. IFNAMSIZ
+	// without the terminating null byte.
+	maxInterfaceNameLen = 15
+
+	// maxAuditLogAggregationWindow bounds the delay after which the aggregated audit log entries are
+	// written.
+	maxAuditLogAggregationWindow = time.Minute
+
+	// maxOTelExporterQueueSize bounds the memory used by the log records buffered while the
+	// OpenTelemetry collector is unreachable.
+	maxOTelExporterQueueSize = 1000000
+
+	// maxOVSConnectionProbeFailureThreshold bounds the time a dead OVS connection can go unnoticed.
+	maxOVSConnectionProbeFailureThreshold = 10
+)
+
+// NodeInfo is the information about the Node running antrea-agent which some rules depend on. It is
+// only known once the agent has retrieved its Node.
+type NodeInfo struct {
+	// PodCIDRs are the PodCIDRs allocated to the Node.
+	PodCIDRs []*net.IPNet
+}
+
+// Rule is a constraint on a configuration option, or on a group of options which depend on each
+// other. Rules don't stop at the first violation, so that all the mistakes in a configuration can
+// be reported at once.
+type Rule struct {
+	// Name identifies the rule in tests and logs.
+	Name string
+	// Validate returns the violations of the rule by the configuration, if any. node is nil when
+	// the Node is unknown, e.g. when a configuration file is checked offline, in which case the
+	// constraints depending on the Node are not checked. The configuration must not be modified.
+	Validate func(c *AgentConfig, node *NodeInfo) []error
+}
+
+// Rules are the rules checked by Validate. A new option should come with a rule checking its
+// range, and its consistency with the options it depends on.
+var Rules = []Rule{
+	{Name: "ports", Validate: validatePorts},
+	{Name: "defaultMTU", Validate: validateDefaultMTU},
+	{Name: "tcpMSS", Validate: validateTCPMSS},
+	{Name: "tunnel", Validate: validateTunnel},
+	{Name: "serviceCIDRs", Validate: validateServiceCIDRs},
+	{Name: "flowExportIntervals", Validate: validateFlowExportIntervals},
+	{Name: "nplPortRange", Validate: validateNPLPortRange},
+	{Name: "clientConnections", Validate: validateClientConnections},
+	{Name: "memoryGuardWatermark", Validate: validateMemoryGuardWatermark},
+	{Name: "auditLogDestination", Validate: validateAuditLogDestination},
+	{Name: "auditLogFormat", Validate: validateAuditLogFormat},
+	{Name: "auditLogAggregationWindow", Validate: validateAuditLogAggregationWindow},
+	{Name: "networkPolicyWorkers", Validate: validateNetworkPolicyWorkers},
+	{Name: "podConnectionLimit", Validate: validatePodConnectionLimit},
+	{Name: "otelExporter", Validate: validateOTelExporter},
+	{Name: "policyOnlyInterfaceDiscovery", Validate: validatePolicyOnlyInterfaceDiscovery},
+	{Name: "ovsConnectionProbe", Validate: validateOVSConnectionProbe},
+}
+
+// Validate checks the configuration against all the Rules. It returns an aggregate of all the
+// violations, or nil if the configuration is valid. The options which are not set are not checked,
+// as their default values are valid.
+func Validate(c *AgentConfig, node *NodeInfo) error {
+	var errs []error
+	for _, rule := range Rules {
+		errs = append(errs, rule.Validate(c, node)...)
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// featureEnabled returns whether the feature is enabled by the configuration, or by default.
+func featureEnabled(c *AgentConfig, feature featuregate.Feature) bool {
+	if enabled, ok := c.FeatureGates[string(feature)]; ok {
+		return enabled
+	}
+	return features.DefaultAntreaFeatureGates[feature].Default
+}
+
+func checkRange(option string, value, min, max int) error {
+	if value < min || value > max {
+		return fmt.Errorf("%s %d is out of range [%d, %d]", option, value, min, max)
+	}
+	return nil
+}
+
+func validatePorts(c *AgentConfig, _ *NodeInfo) []error {
+	var errs []error
+	apiPort, clusterPort := apis.AntreaAgentAPIPort, apis.AntreaAgentClusterMembershipPort
+	if c.APIPort != 0 {
+		apiPort = c.APIPort
+		if err := checkRange("apiPort", c.APIPort, minPort, maxPort); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if c.ClusterMembershipPort != 0 {
+		clusterPort = c.ClusterMembershipPort
+		if err := checkRange("clusterPort", c.ClusterMembershipPort, minPort, maxPort); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if apiPort == clusterPort {
+		errs = append(errs, fmt.Errorf("apiPort and clusterPort must be different, both are %d", apiPort))
+	}
+	return errs
+}
+
+func validateDefaultMTU(c *AgentConfig, _ *NodeInfo) []error {
+	if c.DefaultMTU == 0 {
+		return nil
+	}
+	if err := checkRange("defaultMTU", c.DefaultMTU, minMTU, maxMTU); err != nil {
+		return []error{err}
+	}
+	return nil
+}
+
+func validateTCPMSS(c *AgentConfig, _ *NodeInfo) []error {
+	if c.TCPMSS == 0 {
+		return nil
+	}
+	var errs []error
+	if !c.TCPMSSClamping {
+		errs = append(errs, fmt.Errorf("tcpMSS is only applicable when tcpMSSClamping is enabled"))
+	}
+	if err := checkRange("tcpMSS", c.TCPMSS, minTCPMSS, maxTCPMSS); err != nil {
+		errs = append(errs, err)
+	}
+	if c.DefaultMTU != 0 && c.TCPMSS > c.DefaultMTU-tcpIPHeaderLen {
+		errs = append(errs, fmt.Errorf("tcpMSS %d is larger than defaultMTU %d minus the IP and TCP headers", c.TCPMSS, c.DefaultMTU))
+	}
+	return errs
+}
+
+func validateTunnel(c *AgentConfig, _ *NodeInfo) []error {
+	var errs []error
+	if c.TunnelPort != 0 {
+		if c.TunnelType == "gre" {
+			errs = append(errs, fmt.Errorf("tunnelPort is not applicable to the gre tunnel type"))
+		}
+		if err := checkRange("tunnelPort", c.TunnelPort, minPort, maxPort); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if c.TunnelDSCP != "" && c.TunnelDSCP != TunnelDSCPInherit {
+		if dscp, err := strconv.Atoi(c.TunnelDSCP); err != nil {
+			errs = append(errs, fmt.Errorf("tunnelDSCP %s must be a number or %s", c.TunnelDSCP, TunnelDSCPInherit))
+		} else if err := checkRange("tunnelDSCP", dscp, 0, maxDSCP); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+func validateServiceCIDRs(c *AgentConfig, node *NodeInfo) []error {
+	var errs []error
+	var serviceCIDRs []*net.IPNet
+	if c.ServiceCIDR != "" {
+		if _, serviceCIDR, err := net.ParseCIDR(c.ServiceCIDR); err != nil {
+			errs = append(errs, fmt.Errorf("Service CIDR %s is invalid", c.ServiceCIDR))
+		} else {
+			serviceCIDRs = append(serviceCIDRs, serviceCIDR)
+		}
+	}
+	if c.ServiceCIDRv6 != "" {
+		if _, serviceCIDR, err := net.ParseCIDR(c.ServiceCIDRv6); err != nil || serviceCIDR.IP.To4() != nil {
+			errs = append(errs, fmt.Errorf("Service CIDR v6 %s is invalid", c.ServiceCIDRv6))
+		} else {
+			serviceCIDRs = append(serviceCIDRs, serviceCIDR)
+		}
+	}
+	// The Service CIDRs are ignored when AntreaProxy is enabled.
+	if node == nil || featureEnabled(c, features.AntreaProxy) {
+		return errs
+	}
+	for _, serviceCIDR := range serviceCIDRs {
+		for _, podCIDR := range node.PodCIDRs {
+			if serviceCIDR.Contains(podCIDR.IP) || podCIDR.Contains(serviceCIDR.IP) {
+				errs = append(errs, fmt.Errorf("Service CIDR %s overlaps with the PodCIDR %s of the Node", serviceCIDR, podCIDR))
+			}
+		}
+	}
+	return errs
+}
+
+func validateFlowExportIntervals(c *AgentConfig, _ *NodeInfo) []error {
+	if !featureEnabled(c, features.FlowExporter) {
+		return nil
+	}
+	var errs []error
+	pollInterval := DefaultFlowPollInterval
+	if c.FlowPollInterval != "" {
+		interval, err := flowexport.ParseFlowIntervalString(c.FlowPollInterval)
+		if err != nil {
+			// Don't compare the export timeouts with an invalid poll interval.
+			return []error{fmt.Errorf("flowPollInterval %s is invalid: %v", c.FlowPollInterval, err)}
+		}
+		pollInterval = interval
+	}
+	for _, timeout := range []struct {
+		option string
+		value  string
+	}{
+		{"activeFlowExportTimeout", c.ActiveFlowExportTimeout},
+		{"idleFlowExportTimeout", c.IdleFlowExportTimeout},
+	} {
+		if timeout.value == "" {
+			continue
+		}
+		d, err := time.ParseDuration(timeout.value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s %s is invalid: %v", timeout.option, timeout.value, err))
+		} else if d < pollInterval {
+			errs = append(errs, fmt.Errorf("%s %s must be greater than or equal to flowPollInterval %s", timeout.option, timeout.value, pollInterval))
+		}
+	}
+	return errs
+}
+
+func validateNPLPortRange(c *AgentConfig, _ *NodeInfo) []error {
+	if c.NPLPortRange == "" || !featureEnabled(c, features.NodePortLocal) {
+		return nil
+	}
+	start, end, err := nplutil.ParsePortsRange(c.NPLPortRange)
+	if err != nil {
+		return []error{fmt.Errorf("nplPortRange %s is invalid: %v", c.NPLPortRange, err)}
+	}
+	if start < minPort || end > maxPort {
+		return []error{fmt.Errorf("nplPortRange %s is out of range [%d, %d]", c.NPLPortRange, minPort, maxPort)}
+	}
+	return nil
+}
+
+func validateClientConnections(c *AgentConfig, _ *NodeInfo) []error {
+	var errs []error
+	for _, connection := range []struct {
+		option string
+		config *componentbaseconfig.ClientConnectionConfiguration
+	}{
+		{"clientConnection", &c.ClientConnection},
+		{"antreaClientConnection", &c.AntreaClientConnection},
+	} {
+		if connection.config.QPS < 0 {
+			errs = append(errs, fmt.Errorf("%s.qps %v must not be negative", connection.option, connection.config.QPS))
+		}
+		if connection.config.Burst < 0 {
+			errs = append(errs, fmt.Errorf("%s.burst %d must not be negative", connection.option, connection.config.Burst))
+		}
+	}
+	return errs
+}
+
+func validateMemoryGuardWatermark(c *AgentConfig, _ *NodeInfo) []error {
+	if !c.MemoryGuard.Enable || c.MemoryGuard.Watermark == 0 {
+		return nil
+	}
+	if err := checkRange("memoryGuard.watermark", c.MemoryGuard.Watermark, 1, 100); err != nil {
+		return []error{err}
+	}
+	return nil
+}
+
+func validateAuditLogDestination(c *AgentConfig, _ *NodeInfo) []error {
+	switch c.AuditLogging.Destination {
+	case "", AuditLogDestinationFile:
+	case AuditLogDestinationEventLog:
+		if runtime.GOOS != "windows" {
+			return []error{fmt.Errorf("auditLogging.destination %s is only supported on Windows", AuditLogDestinationEventLog)}
+		}
+		if c.AuditLogging.LogDir != "" {
+			return []error{fmt.Errorf("auditLogging.logDir is only applicable to the %s destination", AuditLogDestinationFile)}
+		}
+	default:
+		return []error{fmt.Errorf("auditLogging.destination %s is unknown", c.AuditLogging.Destination)}
+	}
+	return nil
+}
+
+func validateAuditLogFormat(c *AgentConfig, _ *NodeInfo) []error {
+	switch c.AuditLogging.Format {
+	case "", AuditLogFormatText:
+	case AuditLogFormatJSON:
+		if c.AuditLogging.Destination == AuditLogDestinationEventLog {
+			return []error{fmt.Errorf("auditLogging.format %s is only applicable to the %s destination", AuditLogFormatJSON, AuditLogDestinationFile)}
+		}
+	default:
+		return []error{fmt.Errorf("auditLogging.format %s is unknown", c.AuditLogging.Format)}
+	}
+	return nil
+}
+
+func validateAuditLogAggregationWindow(c *AgentConfig, _ *NodeInfo) []error {
+	if c.AuditLogging.AggregationWindow == "" {
+		return nil
+	}
+	window, err := time.ParseDuration(c.AuditLogging.AggregationWindow)
+	if err != nil {
+		return []error{fmt.Errorf("auditLogging.aggregationWindow %s is invalid: %v", c.AuditLogging.AggregationWindow, err)}
+	}
+	if window < 0 || window > maxAuditLogAggregationWindow {
+		return []error{fmt.Errorf("auditLogging.aggregationWindow %s must be between 0s and %s", c.AuditLogging.AggregationWindow, maxAuditLogAggregationWindow)}
+	}
+	return nil
+}
+
+func validateNetworkPolicyWorkers(c *AgentConfig, _ *NodeInfo) []error {
+	if c.NetworkPolicyWorkers == 0 {
+		return nil
+	}
+	if err := checkRange("networkPolicyWorkers", c.NetworkPolicyWorkers, 1, maxNetworkPolicyWorkers); err != nil {
+		return []error{err}
+	}
+	return nil
+}
+
+func validatePodConnectionLimit(c *AgentConfig, _ *NodeInfo) []error {
+	if err := checkRange("podConnectionLimit.defaultLimit", c.PodConnectionLimit.DefaultLimit, 0, maxPodConnectionLimit); err != nil {
+		return []error{err}
+	}
+	return nil
+}
+
+func validateOTelExporter(c *AgentConfig, _ *NodeInfo) []error {
+	if !featureEnabled(c, features.OTelExporter) {
+		return nil
+	}
+	var errs []error
+	exporter := &c.OTelExporter
+	if exporter.Endpoint == "" {
+		errs = append(errs, fmt.Errorf("otelExporter.endpoint must be set when OTelExporter is enabled"))
+	} else if _, _, err := net.SplitHostPort(exporter.Endpoint); err != nil {
+		errs = append(errs, fmt.Errorf("otelExporter.endpoint %s is invalid: %v", exporter.Endpoint, err))
+	}
+	if exporter.Insecure && (exporter.CACertPath != "" || exporter.ServerName != "") {
+		errs = append(errs, fmt.Errorf("otelExporter.caCertPath and otelExporter.serverName are not applicable when otelExporter.insecure is true"))
+	}
+	if exporter.QueueSize != 0 {
+		if err := checkRange("otelExporter.queueSize", exporter.QueueSize, 1, maxOTelExporterQueueSize); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if exporter.BatchSize != 0 {
+		queueSize := exporter.QueueSize
+		if queueSize == 0 {
+			queueSize = DefaultOTelExporterQueueSize
+		}
+		if err := checkRange("otelExporter.batchSize", exporter.BatchSize, 1, queueSize); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if exporter.FlushInterval != "" {
+		if d, err := time.ParseDuration(exporter.FlushInterval); err != nil {
+			errs = append(errs, fmt.Errorf("otelExporter.flushInterval %s is invalid: %v", exporter.FlushInterval, err))
+		} else if d <= 0 {
+			errs = append(errs, fmt.Errorf("otelExporter.flushInterval %s must be positive", exporter.FlushInterval))
+		}
+	}
+	return errs
+}
+
+func validatePolicyOnlyInterfaceDiscovery(c *AgentConfig, _ *NodeInfo) []error {
+	discovery := c.PolicyOnlyInterfaceDiscovery
+	switch discovery.Strategy {
+	case "", PolicyOnlyInterfaceDiscoveryVethPeer, PolicyOnlyInterfaceDiscoveryCNIResult:
+		if discovery.Prefix != "" {
+			return []error{fmt.Errorf("policyOnlyInterfaceDiscovery.prefix is only applicable to the %s strategy", PolicyOnlyInterfaceDiscoveryPrefix)}
+		}
+	case PolicyOnlyInterfaceDiscoveryPrefix:
+		if discovery.Prefix == "" {
+			return []error{fmt.Errorf("policyOnlyInterfaceDiscovery.prefix is required by the %s strategy", PolicyOnlyInterfaceDiscoveryPrefix)}
+		}
+		if len(discovery.Prefix) >= maxInterfaceNameLen {
+			return []error{fmt.Errorf("policyOnlyInterfaceDiscovery.prefix %s must be shorter than %d characters", discovery.Prefix, maxInterfaceNameLen)}
+		}
+	default:
+		return []error{fmt.Errorf("policyOnlyInterfaceDiscovery.strategy %s is unknown", discovery.Strategy)}
+	}
+	return nil
+}
+
+func validateOVSConnectionProbe(c *AgentConfig, _ *NodeInfo) []error {
+	var errs []error
+	probe := &c.OVSConnectionProbe
+	for _, interval := range []struct {
+		name  string
+		value string
+	}{
+		{"ovsConnectionProbe.openflowInterval", probe.OpenFlowInterval},
+		{"ovsConnectionProbe.ovsdbInterval", probe.OVSDBInterval},
+	} {
+		if interval.value == "" {
+			continue
+		}
+		d, err := time.ParseDuration(interval.value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s %s is invalid: %v", interval.name, interval.value, err))
+		} else if d < 0 {
+			errs = append(errs, fmt.Errorf("%s %s must not be negative", interval.name, interval.value))
+		}
+	}
+	if probe.FailureThreshold != 0 {
+		if err := checkRange("ovsConnectionProbe.failureThreshold", probe.FailureThreshold, 1, maxOVSConnectionProbeFailureThreshold); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
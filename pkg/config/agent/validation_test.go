@@ -0,0 +1,210 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	componentbaseconfig "k8s.io/component-base/config"
+)
+
+func TestRules(t *testing.T) {
+	_, podCIDR, _ := net.ParseCIDR("10.96.1.0/24")
+	_, podCIDRv6, _ := net.ParseCIDR("fd00:10:96::/64")
+	node := &NodeInfo{PodCIDRs: []*net.IPNet{podCIDR, podCIDRv6}}
+	tests := []struct {
+		name         string
+		validate     func(c *AgentConfig, node *NodeInfo) []error
+		config       AgentConfig
+		node         *NodeInfo
+		expectedErrs int
+	}{
+		{name: "default ports", validate: validatePorts},
+		{name: "valid ports", validate: validatePorts, config: AgentConfig{APIPort: 10360, ClusterMembershipPort: 10361}},
+		{name: "ports out of range", validate: validatePorts, config: AgentConfig{APIPort: -1, ClusterMembershipPort: 65536}, expectedErrs: 2},
+		{name: "same ports", validate: validatePorts, config: AgentConfig{APIPort: 10351}, expectedErrs: 1},
+		{name: "default MTU", validate: validateDefaultMTU},
+		{name: "valid MTU", validate: validateDefaultMTU, config: AgentConfig{DefaultMTU: 1450}},
+		{name: "MTU too small", validate: validateDefaultMTU, config: AgentConfig{DefaultMTU: 10}, expectedErrs: 1},
+		{name: "MTU too large", validate: validateDefaultMTU, config: AgentConfig{DefaultMTU: 70000}, expectedErrs: 1},
+		{name: "valid TCP MSS", validate: validateTCPMSS, config: AgentConfig{TCPMSSClamping: true, TCPMSS: 1360, DefaultMTU: 1400}},
+		{name: "TCP MSS without clamping", validate: validateTCPMSS, config: AgentConfig{TCPMSS: 1360}, expectedErrs: 1},
+		{name: "TCP MSS out of range", validate: validateTCPMSS, config: AgentConfig{TCPMSSClamping: true, TCPMSS: 100}, expectedErrs: 1},
+		{name: "TCP MSS larger than MTU", validate: validateTCPMSS, config: AgentConfig{TCPMSSClamping: true, TCPMSS: 1400, DefaultMTU: 1400}, expectedErrs: 1},
+		{name: "all TCP MSS violations", validate: validateTCPMSS, config: AgentConfig{TCPMSS: 100, DefaultMTU: 100}, expectedErrs: 3},
+		{name: "valid tunnel options", validate: validateTunnel, config: AgentConfig{TunnelPort: 6082, TunnelDSCP: "46"}},
+		{name: "inherited tunnel DSCP", validate: validateTunnel, config: AgentConfig{TunnelDSCP: TunnelDSCPInherit}},
+		{name: "tunnel port with gre", validate: validateTunnel, config: AgentConfig{TunnelType: "gre", TunnelPort: 6082}, expectedErrs: 1},
+		{name: "tunnel options out of range", validate: validateTunnel, config: AgentConfig{TunnelPort: 70000, TunnelDSCP: "64"}, expectedErrs: 2},
+		{name: "invalid tunnel DSCP", validate: validateTunnel, config: AgentConfig{TunnelDSCP: "ef"}, expectedErrs: 1},
+		{name: "valid Service CIDRs", validate: validateServiceCIDRs, config: AgentConfig{ServiceCIDR: "10.96.0.0/24", ServiceCIDRv6: "fd00:10:97::/112"}},
+		{name: "invalid Service CIDRs", validate: validateServiceCIDRs, config: AgentConfig{ServiceCIDR: "10.96.0.0", ServiceCIDRv6: "10.96.0.0/24"}, expectedErrs: 2},
+		{
+			name:     "Service CIDRs not overlapping with PodCIDRs",
+			validate: validateServiceCIDRs,
+			config:   AgentConfig{FeatureGates: map[string]bool{"AntreaProxy": false}, ServiceCIDR: "10.96.0.0/24", ServiceCIDRv6: "fd00:10:97::/112"},
+			node:     node,
+		},
+		{
+			name:         "Service CIDRs overlapping with PodCIDRs",
+			validate:     validateServiceCIDRs,
+			config:       AgentConfig{FeatureGates: map[string]bool{"AntreaProxy": false}, ServiceCIDR: "10.96.0.0/12", ServiceCIDRv6: "fd00:10:96::/112"},
+			node:         node,
+			expectedErrs: 2,
+		},
+		{
+			name:     "Service CIDRs ignored by AntreaProxy",
+			validate: validateServiceCIDRs,
+			config:   AgentConfig{ServiceCIDR: "10.96.0.0/12"},
+			node:     node,
+		},
+		{
+			name:     "valid flow export intervals",
+			validate: validateFlowExportIntervals,
+			config:   AgentConfig{FeatureGates: map[string]bool{"FlowExporter": true}, FlowPollInterval: "10s", ActiveFlowExportTimeout: "60s", IdleFlowExportTimeout: "10s"},
+		},
+		{
+			name:         "invalid poll interval",
+			validate:     validateFlowExportIntervals,
+			config:       AgentConfig{FeatureGates: map[string]bool{"FlowExporter": true}, FlowPollInterval: "0s"},
+			expectedErrs: 1,
+		},
+		{
+			name:         "export timeouts shorter than poll interval",
+			validate:     validateFlowExportIntervals,
+			config:       AgentConfig{FeatureGates: map[string]bool{"FlowExporter": true}, FlowPollInterval: "20s", ActiveFlowExportTimeout: "10s", IdleFlowExportTimeout: "15s"},
+			expectedErrs: 2,
+		},
+		{
+			name:         "export timeout shorter than default poll interval",
+			validate:     validateFlowExportIntervals,
+			config:       AgentConfig{FeatureGates: map[string]bool{"FlowExporter": true}, IdleFlowExportTimeout: "1s"},
+			expectedErrs: 1,
+		},
+		{
+			name:     "flow exporter disabled",
+			validate: validateFlowExportIntervals,
+			config:   AgentConfig{FlowPollInterval: "0s"},
+		},
+		{
+			name:     "valid NPL port range",
+			validate: validateNPLPortRange,
+			config:   AgentConfig{FeatureGates: map[string]bool{"NodePortLocal": true}, NPLPortRange: "61000-62000"},
+		},
+		{
+			name:         "empty NPL port range",
+			validate:     validateNPLPortRange,
+			config:       AgentConfig{FeatureGates: map[string]bool{"NodePortLocal": true}, NPLPortRange: "61000-61000"},
+			expectedErrs: 1,
+		},
+		{
+			name:         "NPL port range out of range",
+			validate:     validateNPLPortRange,
+			config:       AgentConfig{FeatureGates: map[string]bool{"NodePortLocal": true}, NPLPortRange: "65000-70000"},
+			expectedErrs: 1,
+		},
+		{
+			name:     "valid client connections",
+			validate: validateClientConnections,
+			config:   AgentConfig{ClientConnection: componentbaseconfig.ClientConnectionConfiguration{QPS: 50, Burst: 100}},
+		},
+		{
+			name:     "negative client connection limits",
+			validate: validateClientConnections,
+			config: AgentConfig{
+				ClientConnection:       componentbaseconfig.ClientConnectionConfiguration{QPS: -1},
+				AntreaClientConnection: componentbaseconfig.ClientConnectionConfiguration{Burst: -1},
+			},
+			expectedErrs: 2,
+		},
+		{name: "valid memory guard watermark", validate: validateMemoryGuardWatermark, config: AgentConfig{MemoryGuard: MemoryGuardConfig{Enable: true, Watermark: 80}}},
+		{name: "memory guard watermark out of range", validate: validateMemoryGuardWatermark, config: AgentConfig{MemoryGuard: MemoryGuardConfig{Enable: true, Watermark: 150}}, expectedErrs: 1},
+		{name: "memory guard disabled", validate: validateMemoryGuardWatermark, config: AgentConfig{MemoryGuard: MemoryGuardConfig{Watermark: 150}}},
+		{name: "file audit log destination", validate: validateAuditLogDestination, config: AgentConfig{AuditLogging: AuditLoggingConfig{Destination: "file", LogDir: "/var/log/audit"}}},
+		{name: "unknown audit log destination", validate: validateAuditLogDestination, config: AgentConfig{AuditLogging: AuditLoggingConfig{Destination: "syslog"}}, expectedErrs: 1},
+		{name: "json audit log format", validate: validateAuditLogFormat, config: AgentConfig{AuditLogging: AuditLoggingConfig{Destination: "file", Format: "json"}}},
+		{name: "json audit log format with event log", validate: validateAuditLogFormat, config: AgentConfig{AuditLogging: AuditLoggingConfig{Destination: "eventLog", Format: "json"}}, expectedErrs: 1},
+		{name: "unknown audit log format", validate: validateAuditLogFormat, config: AgentConfig{AuditLogging: AuditLoggingConfig{Format: "xml"}}, expectedErrs: 1},
+		{name: "valid audit log aggregation window", validate: validateAuditLogAggregationWindow, config: AgentConfig{AuditLogging: AuditLoggingConfig{AggregationWindow: "5s"}}},
+		{name: "disabled audit log aggregation", validate: validateAuditLogAggregationWindow, config: AgentConfig{AuditLogging: AuditLoggingConfig{AggregationWindow: "0s"}}},
+		{name: "invalid audit log aggregation window", validate: validateAuditLogAggregationWindow, config: AgentConfig{AuditLogging: AuditLoggingConfig{AggregationWindow: "5"}}, expectedErrs: 1},
+		{name: "audit log aggregation window too long", validate: validateAuditLogAggregationWindow, config: AgentConfig{AuditLogging: AuditLoggingConfig{AggregationWindow: "2m"}}, expectedErrs: 1},
+		{name: "valid NetworkPolicy workers", validate: validateNetworkPolicyWorkers, config: AgentConfig{NetworkPolicyWorkers: 16}},
+		{name: "negative NetworkPolicy workers", validate: validateNetworkPolicyWorkers, config: AgentConfig{NetworkPolicyWorkers: -1}, expectedErrs: 1},
+		{name: "too many NetworkPolicy workers", validate: validateNetworkPolicyWorkers, config: AgentConfig{NetworkPolicyWorkers: 128}, expectedErrs: 1},
+		{name: "valid Pod connection limit", validate: validatePodConnectionLimit, config: AgentConfig{PodConnectionLimit: PodConnectionLimitConfig{DefaultLimit: 10000}}},
+		{name: "negative Pod connection limit", validate: validatePodConnectionLimit, config: AgentConfig{PodConnectionLimit: PodConnectionLimitConfig{DefaultLimit: -1}}, expectedErrs: 1},
+		{
+			name:     "valid OpenTelemetry exporter",
+			validate: validateOTelExporter,
+			config: AgentConfig{
+				FeatureGates: map[string]bool{"OTelExporter": true},
+				OTelExporter: OTelExporterConfig{Endpoint: "otel-collector.observability.svc:4317", QueueSize: 1000, BatchSize: 100, FlushInterval: "1s"},
+			},
+		},
+		{
+			name:         "OpenTelemetry exporter without endpoint",
+			validate:     validateOTelExporter,
+			config:       AgentConfig{FeatureGates: map[string]bool{"OTelExporter": true}},
+			expectedErrs: 1,
+		},
+		{
+			name:     "invalid OpenTelemetry exporter",
+			validate: validateOTelExporter,
+			config: AgentConfig{
+				FeatureGates: map[string]bool{"OTelExporter": true},
+				OTelExporter: OTelExporterConfig{Endpoint: "otel-collector", Insecure: true, CACertPath: "/etc/ca.crt", QueueSize: 100, BatchSize: 200, FlushInterval: "0s"},
+			},
+			expectedErrs: 4,
+		},
+		{name: "OpenTelemetry exporter disabled", validate: validateOTelExporter},
+		{name: "default interface discovery", validate: validatePolicyOnlyInterfaceDiscovery},
+		{name: "CNI result interface discovery", validate: validatePolicyOnlyInterfaceDiscovery, config: AgentConfig{PolicyOnlyInterfaceDiscovery: PolicyOnlyInterfaceDiscoveryConfig{Strategy: "cniResult"}}},
+		{name: "prefix interface discovery", validate: validatePolicyOnlyInterfaceDiscovery, config: AgentConfig{PolicyOnlyInterfaceDiscovery: PolicyOnlyInterfaceDiscoveryConfig{Strategy: "prefix", Prefix: "lxc"}}},
+		{name: "prefix interface discovery without prefix", validate: validatePolicyOnlyInterfaceDiscovery, config: AgentConfig{PolicyOnlyInterfaceDiscovery: PolicyOnlyInterfaceDiscoveryConfig{Strategy: "prefix"}}, expectedErrs: 1},
+		{name: "prefix too long", validate: validatePolicyOnlyInterfaceDiscovery, config: AgentConfig{PolicyOnlyInterfaceDiscovery: PolicyOnlyInterfaceDiscoveryConfig{Strategy: "prefix", Prefix: "averylongprefix"}}, expectedErrs: 1},
+		{name: "prefix with veth peer interface discovery", validate: validatePolicyOnlyInterfaceDiscovery, config: AgentConfig{PolicyOnlyInterfaceDiscovery: PolicyOnlyInterfaceDiscoveryConfig{Prefix: "lxc"}}, expectedErrs: 1},
+		{name: "unknown interface discovery", validate: validatePolicyOnlyInterfaceDiscovery, config: AgentConfig{PolicyOnlyInterfaceDiscovery: PolicyOnlyInterfaceDiscoveryConfig{Strategy: "name"}}, expectedErrs: 1},
+		{name: "valid OVS connection probe", validate: validateOVSConnectionProbe, config: AgentConfig{OVSConnectionProbe: OVSConnectionProbeConfig{OpenFlowInterval: "1s", OVSDBInterval: "500ms", FailureThreshold: 2}}},
+		{name: "disabled OVS connection probe", validate: validateOVSConnectionProbe, config: AgentConfig{OVSConnectionProbe: OVSConnectionProbeConfig{OpenFlowInterval: "0s", OVSDBInterval: "0s"}}},
+		{name: "invalid OVS connection probe", validate: validateOVSConnectionProbe, config: AgentConfig{OVSConnectionProbe: OVSConnectionProbeConfig{OpenFlowInterval: "2", OVSDBInterval: "-1s", FailureThreshold: 20}}, expectedErrs: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := tt.validate(&tt.config, tt.node)
+			assert.Len(t, errs, tt.expectedErrs, "Unexpected violations: %v", errs)
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Validate(&AgentConfig{}, nil))
+
+	c := &AgentConfig{
+		FeatureGates:            map[string]bool{"FlowExporter": true},
+		APIPort:                 70000,
+		DefaultMTU:              10,
+		FlowPollInterval:        "10s",
+		ActiveFlowExportTimeout: "5s",
+	}
+	err := Validate(c, nil)
+	require.Error(t, err)
+	// All the violations are returned, not only the first one.
+	assert.Len(t, err.(utilerrors.Aggregate).Errors(), 3)
+}
@@ -0,0 +1,144 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package agentversion tracks the versions of the antrea-agents connecting to the
+// antrea-controller, and detects the antrea-agents whose version skew with the antrea-controller is
+// not supported.
+package agentversion
+
+import (
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	coreinformers "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	"antrea.io/antrea/pkg/controller/metrics"
+	"antrea.io/antrea/pkg/version"
+)
+
+// agentVersion is the version of an antrea-agent and whether its skew with the antrea-controller is
+// supported.
+type agentVersion struct {
+	version   string
+	supported bool
+}
+
+// Tracker records the version of the antrea-agent of each Node, as reported in the requests of the
+// antrea-agents to the antrea-controller.
+type Tracker struct {
+	controllerVersion string
+	// mutex protects versions.
+	mutex sync.RWMutex
+	// versions maps the names of the Nodes to the versions of their antrea-agents.
+	versions map[string]agentVersion
+}
+
+// NewTracker creates a Tracker. The versions of the antrea-agents are forgotten when their Nodes
+// are deleted.
+func NewTracker(controllerVersion string, nodeInformer coreinformers.NodeInformer) *Tracker {
+	t := &Tracker{
+		controllerVersion: controllerVersion,
+		versions:          map[string]agentVersion{},
+	}
+	nodeInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		DeleteFunc: t.deleteNode,
+	})
+	return t
+}
+
+// ControllerVersion returns the version of the antrea-controller.
+func (t *Tracker) ControllerVersion() string {
+	return t.controllerVersion
+}
+
+// Observe records the version of the antrea-agent of a Node, and returns false if its version skew
+// with the antrea-controller is not supported. A version which cannot be parsed is considered as
+// supported, so that the antrea-agents are not rejected because of unexpected build information. A
+// warning is logged every time a new version is observed for a Node.
+func (t *Tracker) Observe(nodeName, agentVersionStr string) bool {
+	t.mutex.RLock()
+	v, exists := t.versions[nodeName]
+	t.mutex.RUnlock()
+	if exists && v.version == agentVersionStr {
+		return v.supported
+	}
+
+	supported, err := version.IsSupportedSkew(t.controllerVersion, agentVersionStr)
+	if err != nil {
+		klog.Warningf("Cannot check the version skew between the antrea-agent of Node %s and the antrea-controller: %v", nodeName, err)
+		supported = true
+	} else if !supported {
+		klog.Warningf("The antrea-agent of Node %s runs version %s and the antrea-controller runs version %s: version skews larger than %d minor version are not supported and may cause NetworkPolicies to be enforced incorrectly",
+			nodeName, agentVersionStr, t.controllerVersion, version.MaxMinorVersionSkew)
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.versions[nodeName] = agentVersion{version: agentVersionStr, supported: supported}
+	t.updateMetrics()
+	return supported
+}
+
+// GetAgentVersions returns the versions of the antrea-agents, keyed by the names of their Nodes.
+func (t *Tracker) GetAgentVersions() map[string]string {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	versions := make(map[string]string, len(t.versions))
+	for nodeName, v := range t.versions {
+		versions[nodeName] = v.version
+	}
+	return versions
+}
+
+func (t *Tracker) deleteNode(old interface{}) {
+	node, ok := old.(*corev1.Node)
+	if !ok {
+		tombstone, ok := old.(cache.DeletedFinalStateUnknown)
+		if !ok {
+			klog.Errorf("Error decoding object when deleting Node, invalid type: %v", old)
+			return
+		}
+		node, ok = tombstone.Obj.(*corev1.Node)
+		if !ok {
+			klog.Errorf("Error decoding object tombstone when deleting Node, invalid type: %v", tombstone.Obj)
+			return
+		}
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if _, exists := t.versions[node.Name]; !exists {
+		return
+	}
+	delete(t.versions, node.Name)
+	t.updateMetrics()
+}
+
+// updateMetrics must be called with mutex locked.
+func (t *Tracker) updateMetrics() {
+	agentsPerVersion := map[string]int{}
+	unsupportedAgents := 0
+	for _, v := range t.versions {
+		agentsPerVersion[v.version]++
+		if !v.supported {
+			unsupportedAgents++
+		}
+	}
+	metrics.AgentVersions.Reset()
+	for v, num := range agentsPerVersion {
+		metrics.AgentVersions.WithLabelValues(v).Set(float64(num))
+	}
+	metrics.AgentsWithUnsupportedVersionSkew.Set(float64(unsupportedAgents))
+}
@@ -0,0 +1,59 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agentversion
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+)
+
+func newTestTracker(controllerVersion string) *Tracker {
+	informerFactory := informers.NewSharedInformerFactory(fake.NewSimpleClientset(), 0)
+	return NewTracker(controllerVersion, informerFactory.Core().V1().Nodes())
+}
+
+func TestTrackerObserve(t *testing.T) {
+	tracker := newTestTracker("v1.2.0")
+	assert.True(t, tracker.Observe("node1", "v1.2.0"))
+	assert.True(t, tracker.Observe("node2", "v1.3.0-c5b5aa4"))
+	assert.False(t, tracker.Observe("node3", "v1.4.0"))
+	// A version which cannot be parsed is not rejected.
+	assert.True(t, tracker.Observe("node4", "UNKNOWN"))
+	assert.Equal(t, map[string]string{
+		"node1": "v1.2.0",
+		"node2": "v1.3.0-c5b5aa4",
+		"node3": "v1.4.0",
+		"node4": "UNKNOWN",
+	}, tracker.GetAgentVersions())
+
+	// The antrea-agent of node3 is downgraded.
+	assert.True(t, tracker.Observe("node3", "v1.1.0"))
+	assert.Equal(t, "v1.1.0", tracker.GetAgentVersions()["node3"])
+}
+
+func TestTrackerDeleteNode(t *testing.T) {
+	tracker := newTestTracker("v1.2.0")
+	tracker.Observe("node1", "v1.2.0")
+	tracker.Observe("node2", "v1.2.0")
+	tracker.deleteNode(&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}})
+	tracker.deleteNode(cache.DeletedFinalStateUnknown{Key: "node2", Obj: &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node2"}}})
+	assert.Empty(t, tracker.GetAgentVersions())
+}
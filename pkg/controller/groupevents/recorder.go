@@ -0,0 +1,361 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package groupevents records the membership changes of AddressGroups, i.e. the workloads which
+// become or stop being peers of NetworkPolicy rules, and serves them to external consumers.
+package groupevents
+
+import (
+	"context"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/klog/v2"
+
+	"antrea.io/antrea/pkg/apis/controlplane"
+	"antrea.io/antrea/pkg/apiserver/storage"
+	"antrea.io/antrea/pkg/controller/networkpolicy/store"
+	antreatypes "antrea.io/antrea/pkg/controller/types"
+)
+
+const (
+	// DefaultRetention is the number of most recent events kept by the Recorder, so that a
+	// consumer connecting late gets the recent history.
+	DefaultRetention = 1000
+	// subscriberChanSize is the size of the channel of a subscriber. A subscriber which doesn't
+	// consume its events fast enough is terminated, and can resume from the last event it got.
+	subscriberChanSize = 100
+	// rewatchInterval is the interval after which the AddressGroup store is watched again when
+	// the watch is terminated.
+	rewatchInterval = time.Second
+)
+
+// EventType is the type of a membership change.
+type EventType string
+
+const (
+	// MemberAdded means that the member entered the group.
+	MemberAdded EventType = "MemberAdded"
+	// MemberRemoved means that the member left the group, or that the group was deleted.
+	MemberRemoved EventType = "MemberRemoved"
+)
+
+// Member identifies the workload whose membership changed.
+type Member struct {
+	Pod            *controlplane.PodReference            `json:"pod,omitempty"`
+	ExternalEntity *controlplane.ExternalEntityReference `json:"externalEntity,omitempty"`
+	IPs            []string                              `json:"ips,omitempty"`
+}
+
+// PolicyReference identifies a NetworkPolicy which refers to a group in its peers.
+type PolicyReference struct {
+	Type      controlplane.NetworkPolicyType `json:"type"`
+	Namespace string                         `json:"namespace,omitempty"`
+	Name      string                         `json:"name"`
+}
+
+// Event is a membership change of an AddressGroup.
+type Event struct {
+	// ID increases by one for each event. A consumer can use the ID of the last event it got to
+	// resume the stream of events.
+	ID       uint64            `json:"id"`
+	Time     time.Time         `json:"time"`
+	Type     EventType         `json:"type"`
+	Group    string            `json:"group"`
+	Policies []PolicyReference `json:"policies,omitempty"`
+	Member   Member            `json:"member"`
+}
+
+// Filter selects the events to serve to a consumer.
+type Filter struct {
+	// Policy is the name, or "<Namespace>/<Name>" for namespaced policies, of the policy which
+	// must refer to the group of the events. An empty Policy selects all events.
+	Policy string
+}
+
+func (f Filter) matches(e *Event) bool {
+	if f.Policy == "" {
+		return true
+	}
+	for _, p := range e.Policies {
+		if p.Name == f.Policy || p.Namespace+"/"+p.Name == f.Policy {
+			return true
+		}
+	}
+	return false
+}
+
+type subscriber struct {
+	filter Filter
+	ch     chan Event
+}
+
+// Recorder watches the AddressGroup store and records the members added to and removed from each
+// AddressGroup, using the patches computed by the store. It keeps the most recent events in a
+// bounded buffer and broadcasts new events to subscribers.
+type Recorder struct {
+	addressGroupStore  storage.Interface
+	networkPolicyStore storage.Interface
+
+	mutex sync.Mutex
+	// members stores the members of each AddressGroup as last observed, keyed by member key.
+	members map[string]map[string]Member
+	// policies stores the policies referring to each AddressGroup as last observed, so that they
+	// can be reported when the group is deleted.
+	policies map[string][]PolicyReference
+	// events is a ring buffer of the most recent events, events[start] being the oldest.
+	events      []Event
+	start       int
+	retention   int
+	lastID      uint64
+	subscribers map[int]*subscriber
+	nextSubID   int
+}
+
+// NewRecorder returns a Recorder which keeps the retention most recent events.
+func NewRecorder(addressGroupStore, networkPolicyStore storage.Interface, retention int) *Recorder {
+	return &Recorder{
+		addressGroupStore:  addressGroupStore,
+		networkPolicyStore: networkPolicyStore,
+		members:            map[string]map[string]Member{},
+		policies:           map[string][]PolicyReference{},
+		retention:          retention,
+		subscribers:        map[int]*subscriber{},
+	}
+}
+
+// Run watches the AddressGroup store until stopCh is closed, watching it again whenever the
+// watch is terminated.
+func (r *Recorder) Run(stopCh <-chan struct{}) {
+	klog.Info("Starting group event recorder")
+	defer klog.Info("Shutting down group event recorder")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-stopCh
+		cancel()
+	}()
+	wait.Until(func() { r.watch(ctx) }, rewatchInterval, stopCh)
+}
+
+func (r *Recorder) watch(ctx context.Context) {
+	// Groups deleted while the store was not watched don't generate events, they are detected by
+	// comparing the observed groups with the current ones.
+	current := map[string]bool{}
+	for _, obj := range r.addressGroupStore.List() {
+		current[obj.(*antreatypes.AddressGroup).Name] = true
+	}
+	r.mutex.Lock()
+	for name := range r.members {
+		if !current[name] {
+			r.removeGroupLocked(name)
+		}
+	}
+	r.mutex.Unlock()
+
+	w, err := r.addressGroupStore.Watch(ctx, "", labels.Everything(), fields.Everything())
+	if err != nil {
+		klog.Errorf("Failed to watch AddressGroups: %v", err)
+		return
+	}
+	defer w.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-w.ResultChan():
+			if !ok {
+				klog.Info("Watch of AddressGroups terminated, watching them again")
+				return
+			}
+			r.handleEvent(event)
+		}
+	}
+}
+
+func (r *Recorder) handleEvent(event watch.Event) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	switch event.Type {
+	case watch.Added:
+		group := event.Object.(*controlplane.AddressGroup)
+		// The group may have been observed before the watch was terminated, only the difference
+		// with the observed members is recorded.
+		observed := r.members[group.Name]
+		current := make(map[string]Member, len(group.GroupMembers))
+		for i := range group.GroupMembers {
+			member := toMember(&group.GroupMembers[i])
+			current[memberKey(member)] = member
+		}
+		policies := r.getPolicies(group.Name)
+		for key, member := range current {
+			if _, exists := observed[key]; !exists {
+				r.recordLocked(MemberAdded, group.Name, policies, member)
+			}
+		}
+		for key, member := range observed {
+			if _, exists := current[key]; !exists {
+				r.recordLocked(MemberRemoved, group.Name, policies, member)
+			}
+		}
+		r.members[group.Name] = current
+		r.policies[group.Name] = policies
+	case watch.Modified:
+		patch := event.Object.(*controlplane.AddressGroupPatch)
+		members, exists := r.members[patch.Name]
+		if !exists {
+			members = map[string]Member{}
+			r.members[patch.Name] = members
+		}
+		policies := r.getPolicies(patch.Name)
+		for i := range patch.AddedGroupMembers {
+			member := toMember(&patch.AddedGroupMembers[i])
+			members[memberKey(member)] = member
+			r.recordLocked(MemberAdded, patch.Name, policies, member)
+		}
+		for i := range patch.RemovedGroupMembers {
+			member := toMember(&patch.RemovedGroupMembers[i])
+			delete(members, memberKey(member))
+			r.recordLocked(MemberRemoved, patch.Name, policies, member)
+		}
+		r.policies[patch.Name] = policies
+	case watch.Deleted:
+		// Only the metadata of the group is set in Deleted events.
+		group := event.Object.(*controlplane.AddressGroup)
+		r.removeGroupLocked(group.Name)
+	}
+}
+
+// removeGroupLocked records the removal of all the observed members of a deleted group.
+func (r *Recorder) removeGroupLocked(name string) {
+	policies := r.policies[name]
+	for _, member := range r.members[name] {
+		r.recordLocked(MemberRemoved, name, policies, member)
+	}
+	delete(r.members, name)
+	delete(r.policies, name)
+}
+
+// getPolicies returns the policies referring to the group in their peers.
+func (r *Recorder) getPolicies(group string) []PolicyReference {
+	objs, err := r.networkPolicyStore.GetByIndex(store.AddressGroupIndex, group)
+	if err != nil {
+		klog.Errorf("Failed to get the NetworkPolicies referring to AddressGroup %s: %v", group, err)
+		return nil
+	}
+	var policies []PolicyReference
+	for _, obj := range objs {
+		ref := obj.(*antreatypes.NetworkPolicy).SourceRef
+		if ref == nil {
+			continue
+		}
+		policies = append(policies, PolicyReference{Type: ref.Type, Namespace: ref.Namespace, Name: ref.Name})
+	}
+	sort.Slice(policies, func(i, j int) bool {
+		if policies[i].Namespace != policies[j].Namespace {
+			return policies[i].Namespace < policies[j].Namespace
+		}
+		return policies[i].Name < policies[j].Name
+	})
+	return policies
+}
+
+func (r *Recorder) recordLocked(eventType EventType, group string, policies []PolicyReference, member Member) {
+	r.lastID++
+	event := Event{
+		ID:       r.lastID,
+		Time:     time.Now(),
+		Type:     eventType,
+		Group:    group,
+		Policies: policies,
+		Member:   member,
+	}
+	if len(r.events) < r.retention {
+		r.events = append(r.events, event)
+	} else {
+		r.events[r.start] = event
+		r.start = (r.start + 1) % r.retention
+	}
+	for id, sub := range r.subscribers {
+		if !sub.filter.matches(&event) {
+			continue
+		}
+		select {
+		case sub.ch <- event:
+		default:
+			klog.Warningf("Group event subscriber %d is too slow, terminating it", id)
+			close(sub.ch)
+			delete(r.subscribers, id)
+		}
+	}
+}
+
+// Subscribe returns the retained events matching the filter whose ID is greater than since, and,
+// if watch is true, a channel delivering the new events matching the filter. The channel is
+// closed when the subscriber is too slow to consume the events. The returned function must be
+// called to release the subscription.
+func (r *Recorder) Subscribe(since uint64, filter Filter, watch bool) ([]Event, <-chan Event, func()) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	var history []Event
+	for i := 0; i < len(r.events); i++ {
+		event := &r.events[(r.start+i)%len(r.events)]
+		if event.ID > since && filter.matches(event) {
+			history = append(history, *event)
+		}
+	}
+	if !watch {
+		return history, nil, func() {}
+	}
+	id := r.nextSubID
+	r.nextSubID++
+	sub := &subscriber{filter: filter, ch: make(chan Event, subscriberChanSize)}
+	r.subscribers[id] = sub
+	cancel := func() {
+		r.mutex.Lock()
+		defer r.mutex.Unlock()
+		if _, exists := r.subscribers[id]; exists {
+			close(sub.ch)
+			delete(r.subscribers, id)
+		}
+	}
+	return history, sub.ch, cancel
+}
+
+func toMember(m *controlplane.GroupMember) Member {
+	member := Member{Pod: m.Pod, ExternalEntity: m.ExternalEntity}
+	for _, ip := range m.IPs {
+		member.IPs = append(member.IPs, net.IP(ip).String())
+	}
+	return member
+}
+
+// memberKey returns the key identifying a member in a group.
+func memberKey(m Member) string {
+	if m.Pod != nil {
+		return "Pod:" + m.Pod.Namespace + "/" + m.Pod.Name
+	}
+	if m.ExternalEntity != nil {
+		return "ExternalEntity:" + m.ExternalEntity.Namespace + "/" + m.ExternalEntity.Name
+	}
+	return "IP:" + strings.Join(m.IPs, ",")
+}
@@ -0,0 +1,126 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package groupevents
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"antrea.io/antrea/pkg/apis/controlplane"
+	"antrea.io/antrea/pkg/controller/networkpolicy/store"
+	antreatypes "antrea.io/antrea/pkg/controller/types"
+)
+
+func newPodMember(namespace, name, ip string) *controlplane.GroupMember {
+	return &controlplane.GroupMember{
+		Pod: &controlplane.PodReference{Namespace: namespace, Name: name},
+		IPs: []controlplane.IPAddress{controlplane.IPAddress(net.ParseIP(ip))},
+	}
+}
+
+func eventSummaries(events []Event) []string {
+	var summaries []string
+	for _, e := range events {
+		summaries = append(summaries, string(e.Type)+" "+e.Group+" "+memberKey(e.Member))
+	}
+	return summaries
+}
+
+func TestRecorder(t *testing.T) {
+	addressGroupStore := store.NewAddressGroupStore()
+	networkPolicyStore := store.NewNetworkPolicyStore()
+	require.NoError(t, networkPolicyStore.Create(&antreatypes.NetworkPolicy{
+		Name:      "uid1",
+		SourceRef: &controlplane.NetworkPolicyReference{Type: controlplane.AntreaClusterNetworkPolicy, Name: "acnp1", UID: "uid1"},
+		Rules: []controlplane.NetworkPolicyRule{
+			{Direction: controlplane.DirectionIn, From: controlplane.NetworkPolicyPeer{AddressGroups: []string{"group1"}}},
+		},
+	}))
+	recorder := NewRecorder(addressGroupStore, networkPolicyStore, DefaultRetention)
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go recorder.Run(stopCh)
+	require.Eventually(t, func() bool {
+		return addressGroupStore.GetWatchersNum() == 1
+	}, 2*time.Second, 10*time.Millisecond)
+
+	pod1 := newPodMember("ns1", "pod1", "1.1.1.1")
+	pod2 := newPodMember("ns1", "pod2", "1.1.1.2")
+	require.NoError(t, addressGroupStore.Create(&antreatypes.AddressGroup{
+		Name:         "group1",
+		GroupMembers: controlplane.NewGroupMemberSet(pod1),
+	}))
+	require.NoError(t, addressGroupStore.Update(&antreatypes.AddressGroup{
+		Name:         "group1",
+		GroupMembers: controlplane.NewGroupMemberSet(pod2),
+	}))
+	require.NoError(t, addressGroupStore.Delete("group1"))
+
+	expected := []string{
+		"MemberAdded group1 Pod:ns1/pod1",
+		"MemberAdded group1 Pod:ns1/pod2",
+		"MemberRemoved group1 Pod:ns1/pod1",
+		"MemberRemoved group1 Pod:ns1/pod2",
+	}
+	var events []Event
+	require.Eventually(t, func() bool {
+		events, _, _ = recorder.Subscribe(0, Filter{}, false)
+		return len(events) == len(expected)
+	}, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, expected, eventSummaries(events))
+	for i, e := range events {
+		assert.Equal(t, uint64(i+1), e.ID)
+		assert.Equal(t, []PolicyReference{{Type: controlplane.AntreaClusterNetworkPolicy, Name: "acnp1"}}, e.Policies)
+	}
+	assert.Equal(t, []string{"1.1.1.1"}, events[0].Member.IPs)
+}
+
+func TestSubscribe(t *testing.T) {
+	recorder := NewRecorder(store.NewAddressGroupStore(), store.NewNetworkPolicyStore(), 3)
+	policy1 := []PolicyReference{{Type: controlplane.AntreaNetworkPolicy, Namespace: "ns1", Name: "anp1"}}
+	policy2 := []PolicyReference{{Type: controlplane.K8sNetworkPolicy, Namespace: "ns2", Name: "np2"}}
+	record := func(group string, policies []PolicyReference, name string) {
+		recorder.mutex.Lock()
+		defer recorder.mutex.Unlock()
+		recorder.recordLocked(MemberAdded, group, policies, Member{Pod: &controlplane.PodReference{Namespace: "ns1", Name: name}})
+	}
+	for _, name := range []string{"pod1", "pod2", "pod3", "pod4"} {
+		record("group1", policy1, name)
+	}
+
+	// Only the 3 most recent events are retained.
+	events, _, _ := recorder.Subscribe(0, Filter{}, false)
+	assert.Equal(t, []string{"MemberAdded group1 Pod:ns1/pod2", "MemberAdded group1 Pod:ns1/pod3", "MemberAdded group1 Pod:ns1/pod4"}, eventSummaries(events))
+	events, _, _ = recorder.Subscribe(3, Filter{}, false)
+	assert.Equal(t, []string{"MemberAdded group1 Pod:ns1/pod4"}, eventSummaries(events))
+	events, _, _ = recorder.Subscribe(0, Filter{Policy: "anp2"}, false)
+	assert.Empty(t, events)
+
+	history, ch, cancel := recorder.Subscribe(0, Filter{Policy: "ns2/np2"}, true)
+	assert.Empty(t, history)
+	record("group1", policy1, "pod5")
+	record("group2", policy2, "pod6")
+	event := <-ch
+	assert.Equal(t, "group2", event.Group)
+	assert.Equal(t, uint64(6), event.ID)
+	cancel()
+	_, ok := <-ch
+	assert.False(t, ok, "expected the channel to be closed after cancel")
+	cancel()
+}
@@ -34,6 +34,9 @@ import (
 const (
 	// Cluster scoped selectors are stored under empty Namespace in the selectorItemIndex.
 	emptyNamespace = ""
+	// ServiceAccountLabelKey is the reserved label key with which Pods are labeled with the name of their
+	// ServiceAccount in the index, so that a PodSelector can select Pods by ServiceAccount.
+	ServiceAccountLabelKey = "internal.antrea.io/service-account"
 )
 
 type eventHandler func(group string)
@@ -176,6 +179,11 @@ type GroupEntityIndex struct {
 	// It's used to filter potential selectorItems when matching an labelItem.
 	// Cluster scoped selectorItems are stored under empty Namespace "".
 	selectorItemIndex map[entityType]map[string]sets.String
+	// selectorLabelIndex is nested map from entityType to Namespace to an inverted index from labels to keys of
+	// selectorItems. It's used to narrow down the selectorItems that may match a new labelItem, which is created when
+	// an entity is added or its labels are updated, instead of matching all selectorItems in its Namespace.
+	// Cluster scoped selectorItems are stored under empty Namespace "".
+	selectorLabelIndex map[entityType]map[string]*labelSelectorIndex
 
 	// namespaceLabels stores label sets of all Namespaces.
 	namespaceLabels map[string]labels.Set
@@ -212,6 +220,7 @@ func NewGroupEntityIndex() *GroupEntityIndex {
 		labelItemIndex:             map[entityType]map[string]sets.String{podEntityType: {}, externalEntityType: {}},
 		selectorItems:              map[string]*selectorItem{},
 		selectorItemIndex:          map[entityType]map[string]sets.String{podEntityType: {}, externalEntityType: {}},
+		selectorLabelIndex:         map[entityType]map[string]*labelSelectorIndex{podEntityType: {}, externalEntityType: {}},
 		namespaceLabels:            map[string]labels.Set{},
 		eventHandlers:              map[GroupType][]eventHandler{},
 		eventChan:                  make(chan string, 1000),
@@ -368,7 +377,7 @@ func (i *GroupEntityIndex) deleteEntityFromLabelItem(label, entity string) *labe
 // It's called when there is no existing labelItem for a label set.
 func (i *GroupEntityIndex) createLabelItem(entityType entityType, eItem *entityItem) *labelItem {
 	lItem := &labelItem{
-		labels:           eItem.entity.GetLabels(),
+		labels:           getEntityLabels(eItem.entity),
 		namespace:        eItem.entity.GetNamespace(),
 		entityType:       entityType,
 		entityItemKeys:   sets.NewString(),
@@ -385,8 +394,12 @@ func (i *GroupEntityIndex) createLabelItem(entityType entityType, eItem *entityI
 	labelItemKeys.Insert(eItem.labelItemKey)
 
 	// Scan potential selectorItems and associate the new labelItem with the matched ones.
-	scanSelectorItems := func(selectorItemKeys sets.String) {
-		for sKey := range selectorItemKeys {
+	scanSelectorItems := func(namespace string) {
+		selectorIndex, exists := i.selectorLabelIndex[entityType][namespace]
+		if !exists {
+			return
+		}
+		for sKey := range selectorIndex.candidates(lItem.labels) {
 			sItem := i.selectorItems[sKey]
 			matched := i.match(lItem.entityType, lItem.labels, lItem.namespace, sItem.selector)
 			if matched {
@@ -396,11 +409,9 @@ func (i *GroupEntityIndex) createLabelItem(entityType entityType, eItem *entityI
 		}
 	}
 	// SelectorItems in the same Namespace may match the labelItem.
-	localSelectorItemKeys, _ := i.selectorItemIndex[entityType][eItem.entity.GetNamespace()]
-	scanSelectorItems(localSelectorItemKeys)
+	scanSelectorItems(lItem.namespace)
 	// Cluster scoped selectorItems may match the labelItem.
-	clusterSelectorItemKeys, _ := i.selectorItemIndex[entityType][emptyNamespace]
-	scanSelectorItems(clusterSelectorItemKeys)
+	scanSelectorItems(emptyNamespace)
 	return lItem
 }
 
@@ -549,6 +560,11 @@ func (i *GroupEntityIndex) deleteGroupFromSelectorItem(sKey, gKey string) *selec
 	if len(i.selectorItemIndex[entityType][sItem.selector.Namespace]) == 0 {
 		delete(i.selectorItemIndex[entityType], sItem.selector.Namespace)
 	}
+	// Delete it from the selectorLabelIndex.
+	i.selectorLabelIndex[entityType][sItem.selector.Namespace].delete(sKey)
+	if i.selectorLabelIndex[entityType][sItem.selector.Namespace].isEmpty() {
+		delete(i.selectorLabelIndex[entityType], sItem.selector.Namespace)
+	}
 
 	// Delete the selectorItem from matched labelItems.
 	for lKey := range sItem.labelItemKeys {
@@ -579,6 +595,17 @@ func (i *GroupEntityIndex) createSelectorItem(gItem *groupItem) *selectorItem {
 		i.selectorItemIndex[entityType][sItem.selector.Namespace] = selectorItemKeys
 	}
 	selectorItemKeys.Insert(gItem.selectorItemKey)
+	// Add it to the selectorLabelIndex.
+	selectorIndex, exists := i.selectorLabelIndex[entityType][sItem.selector.Namespace]
+	if !exists {
+		selectorIndex = newLabelSelectorIndex()
+		i.selectorLabelIndex[entityType][sItem.selector.Namespace] = selectorIndex
+	}
+	objSelector := sItem.selector.PodSelector
+	if entityType == externalEntityType {
+		objSelector = sItem.selector.ExternalEntitySelector
+	}
+	selectorIndex.add(gItem.selectorItemKey, objSelector)
 
 	// Scan potential labelItems and associates the new selectorItem with the matched ones.
 	if sItem.selector.Namespace != "" {
@@ -813,7 +840,23 @@ func getEntityItemKeyByName(entityType entityType, namespace, name string) strin
 
 // getLabelItemKey returns the label key used in labelItems.
 func getLabelItemKey(entityType entityType, obj metav1.Object) string {
-	return fmt.Sprint(entityType) + "/" + obj.GetNamespace() + "/" + labels.Set(obj.GetLabels()).String()
+	return fmt.Sprint(entityType) + "/" + obj.GetNamespace() + "/" + getEntityLabels(obj).String()
+}
+
+// getEntityLabels returns the labels used to match the entity against selectors. For Pods, they include the name
+// of the Pod's ServiceAccount under the reserved ServiceAccountLabelKey, which overrides any user-defined label with
+// the same key.
+func getEntityLabels(entity metav1.Object) labels.Set {
+	pod, ok := entity.(*v1.Pod)
+	if !ok || pod.Spec.ServiceAccountName == "" {
+		return entity.GetLabels()
+	}
+	entityLabels := make(labels.Set, len(pod.Labels)+1)
+	for k, v := range pod.Labels {
+		entityLabels[k] = v
+	}
+	entityLabels[ServiceAccountLabelKey] = pod.Spec.ServiceAccountName
+	return entityLabels
 }
 
 // getGroupItemKey returns the group key used in groupItems.
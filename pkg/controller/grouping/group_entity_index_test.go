@@ -38,6 +38,11 @@ var (
 	podFoo2                 = newPod("default", "podFoo2", map[string]string{"app": "foo"})
 	podBar1                 = newPod("default", "podBar1", map[string]string{"app": "bar"})
 	podFoo1InOtherNamespace = newPod("other", "podFoo1", map[string]string{"app": "foo"})
+	// Fake Pods running with ServiceAccounts
+	podFoo1WithServiceAccount                 = newPodWithServiceAccount("default", "podFoo1", map[string]string{"app": "foo"}, "sa1")
+	podBar1WithServiceAccount                 = newPodWithServiceAccount("default", "podBar1", map[string]string{"app": "bar"}, "sa1")
+	podFoo1InOtherNamespaceWithServiceAccount = newPodWithServiceAccount("other", "podFoo1", map[string]string{"app": "foo"}, "sa1")
+	podWithServiceAccountLabel                = newPodWithServiceAccount("default", "podSA", map[string]string{ServiceAccountLabelKey: "sa1"}, "default")
 	// Fake ExternalEntities
 	eeFoo1                 = newExternalEntity("default", "eeFoo1", map[string]string{"app": "foo"})
 	eeFoo2                 = newExternalEntity("default", "eeFoo2", map[string]string{"app": "foo"})
@@ -100,6 +105,12 @@ func newPod(namespace, name string, labels map[string]string) *v1.Pod {
 	}
 }
 
+func newPodWithServiceAccount(namespace, name string, labels map[string]string, serviceAccount string) *v1.Pod {
+	pod := newPod(namespace, name, labels)
+	pod.Spec.ServiceAccountName = serviceAccount
+	return pod
+}
+
 func newExternalEntity(namespace, name string, labels map[string]string) *v1alpha2.ExternalEntity {
 	return &v1alpha2.ExternalEntity{
 		ObjectMeta: metav1.ObjectMeta{
@@ -163,6 +174,12 @@ func TestGroupEntityIndexGetEntities(t *testing.T) {
 			inputGroupSelector:       types.NewGroupSelector("", &metav1.LabelSelector{MatchLabels: map[string]string{"app": "foo"}}, &metav1.LabelSelector{MatchLabels: nsOther.Labels}, nil),
 			expectedPods:             []*v1.Pod{},
 		},
+		{
+			name:               "namespace scoped pod selector on service account",
+			existingPods:       []*v1.Pod{podFoo1WithServiceAccount, podBar1WithServiceAccount, podFoo2, podFoo1InOtherNamespaceWithServiceAccount, podWithServiceAccountLabel},
+			inputGroupSelector: types.NewGroupSelector("default", &metav1.LabelSelector{MatchLabels: map[string]string{ServiceAccountLabelKey: "sa1"}}, nil, nil),
+			expectedPods:       []*v1.Pod{podFoo1WithServiceAccount, podBar1WithServiceAccount},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -350,6 +367,8 @@ func TestGroupEntityIndexDeleteGroup(t *testing.T) {
 	assert.Empty(t, index.selectorItems)
 	assert.Empty(t, index.selectorItemIndex[podEntityType])
 	assert.Empty(t, index.selectorItemIndex[externalEntityType])
+	assert.Empty(t, index.selectorLabelIndex[podEntityType])
+	assert.Empty(t, index.selectorLabelIndex[externalEntityType])
 	for _, lItem := range index.labelItems {
 		assert.Empty(t, lItem.selectorItemKeys)
 	}
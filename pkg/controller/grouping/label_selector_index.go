@@ -0,0 +1,162 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grouping
+
+import (
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// labelSelectorIndex is an inverted index from labels to the label selectors that may match them.
+//
+// A label selector can only match a label set if all its requirements are satisfied, so a selector having a
+// requirement that needs a label key to be present (In, Equals, Exists, GreaterThan, LessThan) is indexed by that
+// requirement only: label sets without the label key, or with a value not in the requirement, can never match it.
+// Selectors without such requirement (e.g. the empty selector, or selectors having only NotIn or DoesNotExist
+// requirements) are not indexed and are always returned as candidates.
+//
+// The index is conservative: the candidates returned for a label set are a superset of the selectors matching it,
+// and the caller is expected to evaluate the candidates against the label set.
+type labelSelectorIndex struct {
+	// keyValueIndex is a map from label key to label value to keys of the selectors that require the label.
+	keyValueIndex map[string]map[string]sets.String
+	// keyIndex is a map from label key to keys of the selectors that require the label key to exist, regardless of
+	// its value.
+	keyIndex map[string]sets.String
+	// unindexedKeys contains keys of the selectors that cannot be indexed.
+	unindexedKeys sets.String
+	// indexedRequirements stores the requirement that each indexed selector is indexed by.
+	indexedRequirements map[string]*labels.Requirement
+}
+
+func newLabelSelectorIndex() *labelSelectorIndex {
+	return &labelSelectorIndex{
+		keyValueIndex:       map[string]map[string]sets.String{},
+		keyIndex:            map[string]sets.String{},
+		unindexedKeys:       sets.NewString(),
+		indexedRequirements: map[string]*labels.Requirement{},
+	}
+}
+
+// add adds a selector to the index. A nil selector is handled as the empty selector.
+func (idx *labelSelectorIndex) add(key string, selector labels.Selector) {
+	r := getIndexableRequirement(selector)
+	if r == nil {
+		idx.unindexedKeys.Insert(key)
+		return
+	}
+	idx.indexedRequirements[key] = r
+	if r.Operator() == selection.In || r.Operator() == selection.Equals || r.Operator() == selection.DoubleEquals {
+		valueIndex, exists := idx.keyValueIndex[r.Key()]
+		if !exists {
+			valueIndex = map[string]sets.String{}
+			idx.keyValueIndex[r.Key()] = valueIndex
+		}
+		for value := range r.Values() {
+			keys, exists := valueIndex[value]
+			if !exists {
+				keys = sets.NewString()
+				valueIndex[value] = keys
+			}
+			keys.Insert(key)
+		}
+		return
+	}
+	keys, exists := idx.keyIndex[r.Key()]
+	if !exists {
+		keys = sets.NewString()
+		idx.keyIndex[r.Key()] = keys
+	}
+	keys.Insert(key)
+}
+
+// delete removes a selector from the index.
+func (idx *labelSelectorIndex) delete(key string) {
+	r, exists := idx.indexedRequirements[key]
+	if !exists {
+		idx.unindexedKeys.Delete(key)
+		return
+	}
+	delete(idx.indexedRequirements, key)
+	if r.Operator() == selection.In || r.Operator() == selection.Equals || r.Operator() == selection.DoubleEquals {
+		valueIndex := idx.keyValueIndex[r.Key()]
+		for value := range r.Values() {
+			valueIndex[value].Delete(key)
+			if len(valueIndex[value]) == 0 {
+				delete(valueIndex, value)
+			}
+		}
+		if len(valueIndex) == 0 {
+			delete(idx.keyValueIndex, r.Key())
+		}
+		return
+	}
+	idx.keyIndex[r.Key()].Delete(key)
+	if len(idx.keyIndex[r.Key()]) == 0 {
+		delete(idx.keyIndex, r.Key())
+	}
+}
+
+// candidates returns keys of the selectors that may match the provided label set.
+func (idx *labelSelectorIndex) candidates(labelSet labels.Set) sets.String {
+	candidates := sets.NewString().Union(idx.unindexedKeys)
+	for key, value := range labelSet {
+		if keys, exists := idx.keyIndex[key]; exists {
+			candidates = candidates.Union(keys)
+		}
+		if keys, exists := idx.keyValueIndex[key][value]; exists {
+			candidates = candidates.Union(keys)
+		}
+	}
+	return candidates
+}
+
+// isEmpty returns whether the index contains no selector.
+func (idx *labelSelectorIndex) isEmpty() bool {
+	return len(idx.unindexedKeys) == 0 && len(idx.indexedRequirements) == 0
+}
+
+// getIndexableRequirement returns the most selective requirement of the selector that requires a label key to be
+// present, or nil if there is no such requirement.
+func getIndexableRequirement(selector labels.Selector) *labels.Requirement {
+	if selector == nil {
+		return nil
+	}
+	requirements, selectable := selector.Requirements()
+	if !selectable {
+		return nil
+	}
+	var keyRequirement *labels.Requirement
+	var valueRequirement *labels.Requirement
+	for i := range requirements {
+		r := &requirements[i]
+		switch r.Operator() {
+		case selection.In, selection.Equals, selection.DoubleEquals:
+			// Prefer the requirement with the fewest values, as it's the most selective one.
+			if valueRequirement == nil || r.Values().Len() < valueRequirement.Values().Len() {
+				valueRequirement = r
+			}
+		case selection.Exists, selection.GreaterThan, selection.LessThan:
+			if keyRequirement == nil {
+				keyRequirement = r
+			}
+		}
+	}
+	if valueRequirement != nil {
+		return valueRequirement
+	}
+	return keyRequirement
+}
@@ -0,0 +1,191 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grouping
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"antrea.io/antrea/pkg/controller/types"
+)
+
+var (
+	randomLabelKeys   = []string{"app", "tier", "env", "version"}
+	randomLabelValues = []string{"a", "b", "c"}
+	randomOperators   = []metav1.LabelSelectorOperator{
+		metav1.LabelSelectorOpIn,
+		metav1.LabelSelectorOpNotIn,
+		metav1.LabelSelectorOpExists,
+		metav1.LabelSelectorOpDoesNotExist,
+	}
+)
+
+func randomLabels(r *rand.Rand) map[string]string {
+	labelSet := map[string]string{}
+	for _, key := range randomLabelKeys {
+		if r.Intn(2) == 0 {
+			labelSet[key] = randomLabelValues[r.Intn(len(randomLabelValues))]
+		}
+	}
+	return labelSet
+}
+
+func randomLabelSelector(r *rand.Rand) *metav1.LabelSelector {
+	selector := &metav1.LabelSelector{}
+	for _, key := range randomLabelKeys {
+		switch r.Intn(4) {
+		case 0:
+			if selector.MatchLabels == nil {
+				selector.MatchLabels = map[string]string{}
+			}
+			selector.MatchLabels[key] = randomLabelValues[r.Intn(len(randomLabelValues))]
+		case 1:
+			requirement := metav1.LabelSelectorRequirement{Key: key, Operator: randomOperators[r.Intn(len(randomOperators))]}
+			if requirement.Operator == metav1.LabelSelectorOpIn || requirement.Operator == metav1.LabelSelectorOpNotIn {
+				for _, i := range r.Perm(len(randomLabelValues))[:1+r.Intn(len(randomLabelValues))] {
+					requirement.Values = append(requirement.Values, randomLabelValues[i])
+				}
+			}
+			selector.MatchExpressions = append(selector.MatchExpressions, requirement)
+		}
+	}
+	return selector
+}
+
+func TestLabelSelectorIndex(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	index := newLabelSelectorIndex()
+	selectors := map[string]labels.Selector{}
+	for i := 0; i < 200; i++ {
+		selector, err := metav1.LabelSelectorAsSelector(randomLabelSelector(r))
+		require.NoError(t, err)
+		key := fmt.Sprintf("selector-%d", i)
+		selectors[key] = selector
+		index.add(key, selector)
+	}
+	// A nil selector cannot be indexed but must be handled.
+	selectors["nil"] = nil
+	index.add("nil", nil)
+
+	verify := func() {
+		for i := 0; i < 200; i++ {
+			labelSet := labels.Set(randomLabels(r))
+			candidates := index.candidates(labelSet)
+			expected := sets.NewString()
+			actual := sets.NewString()
+			for key, selector := range selectors {
+				if selector != nil && selector.Matches(labelSet) {
+					expected.Insert(key)
+					if candidates.Has(key) {
+						actual.Insert(key)
+					}
+				}
+			}
+			assert.Equal(t, expected, actual, "Indexed and brute-force results differ for labels %v", labelSet)
+			assert.True(t, candidates.Has("nil"), "Unindexed selectors must always be candidates")
+		}
+	}
+	verify()
+
+	// Delete half of the selectors and verify the results again.
+	for key := range selectors {
+		if r.Intn(2) == 0 {
+			index.delete(key)
+			delete(selectors, key)
+		}
+	}
+	index.delete("nil")
+	index.add("nil", nil)
+	selectors["nil"] = nil
+	verify()
+
+	for key := range selectors {
+		index.delete(key)
+	}
+	assert.True(t, index.isEmpty())
+	assert.Empty(t, index.keyIndex)
+	assert.Empty(t, index.keyValueIndex)
+}
+
+func TestGroupEntityIndexWithRandomFixtures(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	index := NewGroupEntityIndex()
+
+	namespaces := []*v1.Namespace{
+		newNamespace("ns1", map[string]string{"env": "a"}),
+		newNamespace("ns2", map[string]string{"env": "b"}),
+		newNamespace("ns3", map[string]string{"env": "a", "tier": "c"}),
+	}
+	for _, ns := range namespaces {
+		index.AddNamespace(ns)
+	}
+	var groups []*group
+	for i := 0; i < 100; i++ {
+		var groupSelector *types.GroupSelector
+		switch r.Intn(3) {
+		case 0:
+			groupSelector = types.NewGroupSelector(namespaces[r.Intn(len(namespaces))].Name, randomLabelSelector(r), nil, nil)
+		case 1:
+			groupSelector = types.NewGroupSelector("", randomLabelSelector(r), randomLabelSelector(r), nil)
+		case 2:
+			groupSelector = types.NewGroupSelector("", randomLabelSelector(r), nil, nil)
+		}
+		groups = append(groups, &group{groupType: groupType1, groupName: fmt.Sprintf("group-%d", i), groupSelector: groupSelector})
+	}
+	var pods []*v1.Pod
+	for i := 0; i < 200; i++ {
+		pods = append(pods, newPod(namespaces[r.Intn(len(namespaces))].Name, fmt.Sprintf("pod-%d", i), randomLabels(r)))
+	}
+
+	// Interleave the additions of Pods and groups so that both labelItems and selectorItems are created while the
+	// other side is partially populated.
+	for i := 0; i < len(pods) || i < len(groups); i++ {
+		if i < len(pods) {
+			index.AddPod(pods[i])
+		}
+		if i < len(groups) {
+			index.AddGroup(groups[i].groupType, groups[i].groupName, groups[i].groupSelector)
+		}
+	}
+	// Update the labels of some Pods.
+	for i, pod := range pods {
+		if r.Intn(2) == 0 {
+			pods[i] = copyAndMutatePod(pod, func(p *v1.Pod) {
+				p.Labels = randomLabels(r)
+			})
+			index.AddPod(pods[i])
+		}
+	}
+
+	for _, pod := range pods {
+		var expected []string
+		for _, g := range groups {
+			if index.match(podEntityType, pod.Labels, pod.Namespace, g.groupSelector) {
+				expected = append(expected, g.groupName)
+			}
+		}
+		actualGroups, exists := index.GetGroupsForPod(pod.Namespace, pod.Name)
+		require.True(t, exists)
+		assert.ElementsMatch(t, expected, actualGroups[groupType1], "Indexed and brute-force results differ for Pod %s/%s", pod.Namespace, pod.Name)
+	}
+}
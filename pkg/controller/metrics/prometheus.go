@@ -68,6 +68,36 @@ var (
 		Help:           "The duration of syncing internal-networkpolicy",
 		StabilityLevel: metrics.ALPHA,
 	})
+	SizeAppliedToGroup = metrics.NewHistogram(&metrics.HistogramOpts{
+		Namespace:      metricNamespaceAntrea,
+		Subsystem:      metricSubsystemController,
+		Name:           "applied_to_group_size",
+		Help:           "The number of members of applied-to-groups, observed every time an applied-to-group is updated",
+		Buckets:        metrics.ExponentialBuckets(1, 4, 10),
+		StabilityLevel: metrics.ALPHA,
+	})
+	SizeAddressGroup = metrics.NewHistogram(&metrics.HistogramOpts{
+		Namespace:      metricNamespaceAntrea,
+		Subsystem:      metricSubsystemController,
+		Name:           "address_group_size",
+		Help:           "The number of members of address-groups, observed every time an address-group is updated",
+		Buckets:        metrics.ExponentialBuckets(1, 4, 10),
+		StabilityLevel: metrics.ALPHA,
+	})
+	UpdatesAppliedToGroup = metrics.NewCounter(&metrics.CounterOpts{
+		Namespace:      metricNamespaceAntrea,
+		Subsystem:      metricSubsystemController,
+		Name:           "applied_to_group_updates_total",
+		Help:           "The total number of updates of applied-to-groups which changed their members",
+		StabilityLevel: metrics.ALPHA,
+	})
+	UpdatesAddressGroup = metrics.NewCounter(&metrics.CounterOpts{
+		Namespace:      metricNamespaceAntrea,
+		Subsystem:      metricSubsystemController,
+		Name:           "address_group_updates_total",
+		Help:           "The total number of updates of address-groups which changed their members",
+		StabilityLevel: metrics.ALPHA,
+	})
 	LengthAppliedToGroupQueue = metrics.NewGauge(&metrics.GaugeOpts{
 		Namespace:      metricNamespaceAntrea,
 		Subsystem:      metricSubsystemController,
@@ -110,6 +140,64 @@ var (
 		Help:           "The total number of actual status updates performed for Antrea ClusterNetworkPolicy Custom Resources",
 		StabilityLevel: metrics.ALPHA,
 	})
+	DurationNetworkPolicyRealization = metrics.NewHistogram(&metrics.HistogramOpts{
+		Namespace:      metricNamespaceAntrea,
+		Subsystem:      metricSubsystemController,
+		Name:           "network_policy_realization_duration_seconds",
+		Help:           "The duration between the creation of a NetworkPolicy generation in the kube-apiserver and its realization on a Node, subject to clock skew between Nodes",
+		Buckets:        metrics.ExponentialBuckets(0.05, 2, 12),
+		StabilityLevel: metrics.ALPHA,
+	})
+	DurationNetworkPolicyRealizationAck = metrics.NewHistogram(&metrics.HistogramOpts{
+		Namespace:      metricNamespaceAntrea,
+		Subsystem:      metricSubsystemController,
+		Name:           "network_policy_realization_ack_duration_seconds",
+		Help:           "The duration between the processing of a NetworkPolicy generation by the antrea-controller and the reception of its realization status from a Node",
+		Buckets:        metrics.ExponentialBuckets(0.05, 2, 12),
+		StabilityLevel: metrics.ALPHA,
+	})
+	ControlplaneRawResponseBytes = metrics.NewCounter(&metrics.CounterOpts{
+		Namespace:      metricNamespaceAntrea,
+		Subsystem:      metricSubsystemController,
+		Name:           "controlplane_response_raw_bytes",
+		Help:           "The total number of bytes of the controlplane API responses sent with gzip content-encoding, before compression",
+		StabilityLevel: metrics.ALPHA,
+	})
+	ControlplaneCompressedResponseBytes = metrics.NewCounter(&metrics.CounterOpts{
+		Namespace:      metricNamespaceAntrea,
+		Subsystem:      metricSubsystemController,
+		Name:           "controlplane_response_compressed_bytes",
+		Help:           "The total number of bytes of the controlplane API responses sent with gzip content-encoding, after compression",
+		StabilityLevel: metrics.ALPHA,
+	})
+	NetworkPolicyFlowQuotaUsage = metrics.NewGaugeVec(&metrics.GaugeOpts{
+		Namespace:      metricNamespaceAntrea,
+		Subsystem:      metricSubsystemController,
+		Name:           "network_policy_flow_quota_usage",
+		Help:           "The estimated number of OpenFlow flows of the enforced NetworkPolicies of a Namespace, which is counted against its flow quota",
+		StabilityLevel: metrics.ALPHA,
+	}, []string{"namespace"})
+	NetworkPoliciesOverFlowQuota = metrics.NewGaugeVec(&metrics.GaugeOpts{
+		Namespace:      metricNamespaceAntrea,
+		Subsystem:      metricSubsystemController,
+		Name:           "network_policies_over_flow_quota",
+		Help:           "The number of NetworkPolicies of a Namespace which are not enforced as they exceed its flow quota",
+		StabilityLevel: metrics.ALPHA,
+	}, []string{"namespace"})
+	AgentVersions = metrics.NewGaugeVec(&metrics.GaugeOpts{
+		Namespace:      metricNamespaceAntrea,
+		Subsystem:      metricSubsystemController,
+		Name:           "agent_versions",
+		Help:           "The number of antrea-agents running a version, as reported by the antrea-agents connecting to the antrea-controller",
+		StabilityLevel: metrics.ALPHA,
+	}, []string{"version"})
+	AgentsWithUnsupportedVersionSkew = metrics.NewGauge(&metrics.GaugeOpts{
+		Namespace:      metricNamespaceAntrea,
+		Subsystem:      metricSubsystemController,
+		Name:           "agents_with_unsupported_version_skew",
+		Help:           "The number of antrea-agents whose version differs from the version of the antrea-controller by more than one minor version",
+		StabilityLevel: metrics.ALPHA,
+	})
 )
 
 // Initialize Prometheus metrics collection.
@@ -134,6 +222,18 @@ func InitializePrometheusMetrics() {
 	if err := legacyregistry.Register(DurationInternalNetworkPolicySyncing); err != nil {
 		klog.Errorf("Failed to register antrea_controller_network_policy_sync_duration_milliseconds with Prometheus: %s", err.Error())
 	}
+	if err := legacyregistry.Register(SizeAppliedToGroup); err != nil {
+		klog.Errorf("Failed to register antrea_controller_applied_to_group_size with Prometheus: %s", err.Error())
+	}
+	if err := legacyregistry.Register(SizeAddressGroup); err != nil {
+		klog.Errorf("Failed to register antrea_controller_address_group_size with Prometheus: %s", err.Error())
+	}
+	if err := legacyregistry.Register(UpdatesAppliedToGroup); err != nil {
+		klog.Errorf("Failed to register antrea_controller_applied_to_group_updates_total with Prometheus: %s", err.Error())
+	}
+	if err := legacyregistry.Register(UpdatesAddressGroup); err != nil {
+		klog.Errorf("Failed to register antrea_controller_address_group_updates_total with Prometheus: %s", err.Error())
+	}
 	if err := legacyregistry.Register(LengthAppliedToGroupQueue); err != nil {
 		klog.Errorf("Failed to register antrea_controller_length_applied_to_group_queue with Prometheus: %s", err.Error())
 	}
@@ -149,4 +249,28 @@ func InitializePrometheusMetrics() {
 	if err := legacyregistry.Register(AntreaClusterNetworkPolicyStatusUpdates); err != nil {
 		klog.Errorf("Failed to register antrea_controller_acnp_status_updates with Prometheus: %s", err.Error())
 	}
+	if err := legacyregistry.Register(DurationNetworkPolicyRealization); err != nil {
+		klog.Errorf("Failed to register antrea_controller_network_policy_realization_duration_seconds with Prometheus: %s", err.Error())
+	}
+	if err := legacyregistry.Register(DurationNetworkPolicyRealizationAck); err != nil {
+		klog.Errorf("Failed to register antrea_controller_network_policy_realization_ack_duration_seconds with Prometheus: %s", err.Error())
+	}
+	if err := legacyregistry.Register(ControlplaneRawResponseBytes); err != nil {
+		klog.Errorf("Failed to register antrea_controller_controlplane_response_raw_bytes with Prometheus: %s", err.Error())
+	}
+	if err := legacyregistry.Register(ControlplaneCompressedResponseBytes); err != nil {
+		klog.Errorf("Failed to register antrea_controller_controlplane_response_compressed_bytes with Prometheus: %s", err.Error())
+	}
+	if err := legacyregistry.Register(NetworkPolicyFlowQuotaUsage); err != nil {
+		klog.Errorf("Failed to register antrea_controller_network_policy_flow_quota_usage with Prometheus: %s", err.Error())
+	}
+	if err := legacyregistry.Register(NetworkPoliciesOverFlowQuota); err != nil {
+		klog.Errorf("Failed to register antrea_controller_network_policies_over_flow_quota with Prometheus: %s", err.Error())
+	}
+	if err := legacyregistry.Register(AgentVersions); err != nil {
+		klog.Errorf("Failed to register antrea_controller_agent_versions with Prometheus: %s", err.Error())
+	}
+	if err := legacyregistry.Register(AgentsWithUnsupportedVersionSkew); err != nil {
+		klog.Errorf("Failed to register antrea_controller_agents_with_unsupported_version_skew with Prometheus: %s", err.Error())
+	}
 }
@@ -21,6 +21,7 @@ import (
 
 	"antrea.io/antrea/pkg/apis/controlplane"
 	crdv1alpha1 "antrea.io/antrea/pkg/apis/crd/v1alpha1"
+	crdv1beta1 "antrea.io/antrea/pkg/apis/crd/v1beta1"
 	antreatypes "antrea.io/antrea/pkg/controller/types"
 )
 
@@ -28,7 +29,7 @@ import (
 // which can be consumed by agents to configure corresponding rules on the Nodes.
 func (n *NetworkPolicyController) addANP(obj interface{}) {
 	defer n.heartbeat("addANP")
-	np := obj.(*crdv1alpha1.NetworkPolicy)
+	np := obj.(*crdv1beta1.NetworkPolicy)
 	klog.Infof("Processing Antrea NetworkPolicy %s/%s ADD event", np.Namespace, np.Name)
 	// Create an internal NetworkPolicy object corresponding to this
 	// NetworkPolicy and enqueue task to internal NetworkPolicy Workqueue.
@@ -43,14 +44,14 @@ func (n *NetworkPolicyController) addANP(obj interface{}) {
 // which can be consumed by agents to configure corresponding rules on the Nodes.
 func (n *NetworkPolicyController) updateANP(old, cur interface{}) {
 	defer n.heartbeat("updateANP")
-	curNP := cur.(*crdv1alpha1.NetworkPolicy)
+	curNP := cur.(*crdv1beta1.NetworkPolicy)
 	klog.Infof("Processing Antrea NetworkPolicy %s/%s UPDATE event", curNP.Namespace, curNP.Name)
 	// Update an internal NetworkPolicy, corresponding to this NetworkPolicy and
 	// enqueue task to internal NetworkPolicy Workqueue.
 	curInternalNP := n.processAntreaNetworkPolicy(curNP)
 	klog.V(2).Infof("Updating existing internal NetworkPolicy %s for %s", curInternalNP.Name, curInternalNP.SourceRef.ToString())
-	// Retrieve old crdv1alpha1.NetworkPolicy object.
-	oldNP := old.(*crdv1alpha1.NetworkPolicy)
+	// Retrieve old crdv1beta1.NetworkPolicy object.
+	oldNP := old.(*crdv1beta1.NetworkPolicy)
 	// Old and current NetworkPolicy share the same key.
 	key := internalNetworkPolicyKeyFunc(oldNP)
 	// Lock access to internal NetworkPolicy store such that concurrent access
@@ -87,14 +88,14 @@ func (n *NetworkPolicyController) updateANP(old, cur interface{}) {
 // deleteANP receives AntreaNetworkPolicy DELETED events and deletes resources
 // which can be consumed by agents to delete corresponding rules on the Nodes.
 func (n *NetworkPolicyController) deleteANP(old interface{}) {
-	np, ok := old.(*crdv1alpha1.NetworkPolicy)
+	np, ok := old.(*crdv1beta1.NetworkPolicy)
 	if !ok {
 		tombstone, ok := old.(cache.DeletedFinalStateUnknown)
 		if !ok {
 			klog.Errorf("Error decoding object when deleting Antrea NetworkPolicy, invalid type: %v", old)
 			return
 		}
-		np, ok = tombstone.Obj.(*crdv1alpha1.NetworkPolicy)
+		np, ok = tombstone.Obj.(*crdv1beta1.NetworkPolicy)
 		if !ok {
 			klog.Errorf("Error decoding object tombstone when deleting Antrea NetworkPolicy, invalid type: %v", tombstone.Obj)
 			return
@@ -115,15 +116,16 @@ func (n *NetworkPolicyController) deleteANP(old interface{}) {
 		n.deleteDereferencedAppliedToGroup(atg)
 	}
 	n.deleteDereferencedAddressGroups(oldInternalNP)
+	n.releasePolicyQuota(key, oldInternalNP.SourceRef)
 }
 
 // processAntreaNetworkPolicy creates an internal NetworkPolicy instance
-// corresponding to the crdv1alpha1.NetworkPolicy object. This method
+// corresponding to the crdv1beta1.NetworkPolicy object. This method
 // does not commit the internal NetworkPolicy in store, instead returns an
 // instance to the caller wherein, it will be either stored as a new Object
 // in case of ADD event or modified and store the updated instance, in case
 // of an UPDATE event.
-func (n *NetworkPolicyController) processAntreaNetworkPolicy(np *crdv1alpha1.NetworkPolicy) *antreatypes.NetworkPolicy {
+func (n *NetworkPolicyController) processAntreaNetworkPolicy(np *crdv1beta1.NetworkPolicy) *antreatypes.NetworkPolicy {
 	appliedToPerRule := len(np.Spec.AppliedTo) == 0
 	// appliedToGroupNames tracks all distinct appliedToGroups referred to by the Antrea NetworkPolicy,
 	// either in the spec section or in ingress/egress rules.
@@ -131,8 +133,7 @@ func (n *NetworkPolicyController) processAntreaNetworkPolicy(np *crdv1alpha1.Net
 	appliedToGroupNamesSet := sets.String{}
 	// Create AppliedToGroup for each AppliedTo present in AntreaNetworkPolicy spec.
 	for _, at := range np.Spec.AppliedTo {
-		appliedToGroupNamesSet.Insert(n.createAppliedToGroup(
-			np.Namespace, at.PodSelector, at.NamespaceSelector, at.ExternalEntitySelector))
+		appliedToGroupNamesSet.Insert(n.createAppliedToGroupForCRD(np.Namespace, at))
 	}
 	rules := make([]controlplane.NetworkPolicyRule, 0, len(np.Spec.Ingress)+len(np.Spec.Egress))
 	// Compute NetworkPolicyRule for Ingress Rule.
@@ -142,7 +143,7 @@ func (n *NetworkPolicyController) processAntreaNetworkPolicy(np *crdv1alpha1.Net
 		var appliedToGroupNamesForRule []string
 		// Create AppliedToGroup for each AppliedTo present in the ingress rule.
 		for _, at := range ingressRule.AppliedTo {
-			atGroup := n.createAppliedToGroup(np.Namespace, at.PodSelector, at.NamespaceSelector, at.ExternalEntitySelector)
+			atGroup := n.createAppliedToGroupForCRD(np.Namespace, at)
 			appliedToGroupNamesForRule = append(appliedToGroupNamesForRule, atGroup)
 			appliedToGroupNamesSet.Insert(atGroup)
 		}
@@ -151,7 +152,7 @@ func (n *NetworkPolicyController) processAntreaNetworkPolicy(np *crdv1alpha1.Net
 			From:            *n.toAntreaPeerForCRD(ingressRule.From, np, controlplane.DirectionIn, namedPortExists),
 			Services:        services,
 			Name:            ingressRule.Name,
-			Action:          ingressRule.Action,
+			Action:          (*crdv1alpha1.RuleAction)(ingressRule.Action),
 			Priority:        int32(idx),
 			EnableLogging:   ingressRule.EnableLogging,
 			AppliedToGroups: appliedToGroupNamesForRule,
@@ -164,7 +165,7 @@ func (n *NetworkPolicyController) processAntreaNetworkPolicy(np *crdv1alpha1.Net
 		var appliedToGroupNamesForRule []string
 		// Create AppliedToGroup for each AppliedTo present in the ingress rule.
 		for _, at := range egressRule.AppliedTo {
-			atGroup := n.createAppliedToGroup(np.Namespace, at.PodSelector, at.NamespaceSelector, at.ExternalEntitySelector)
+			atGroup := n.createAppliedToGroupForCRD(np.Namespace, at)
 			appliedToGroupNamesForRule = append(appliedToGroupNamesForRule, atGroup)
 			appliedToGroupNamesSet.Insert(atGroup)
 		}
@@ -173,7 +174,7 @@ func (n *NetworkPolicyController) processAntreaNetworkPolicy(np *crdv1alpha1.Net
 			To:              *n.toAntreaPeerForCRD(egressRule.To, np, controlplane.DirectionOut, namedPortExists),
 			Services:        services,
 			Name:            egressRule.Name,
-			Action:          egressRule.Action,
+			Action:          (*crdv1alpha1.RuleAction)(egressRule.Action),
 			Priority:        int32(idx),
 			EnableLogging:   egressRule.EnableLogging,
 			AppliedToGroups: appliedToGroupNamesForRule,
@@ -187,14 +188,15 @@ func (n *NetworkPolicyController) processAntreaNetworkPolicy(np *crdv1alpha1.Net
 			Name:      np.Name,
 			UID:       np.UID,
 		},
-		Name:             internalNetworkPolicyKeyFunc(np),
-		UID:              np.UID,
-		Generation:       np.Generation,
-		AppliedToGroups:  appliedToGroupNamesSet.List(),
-		Rules:            rules,
-		Priority:         &np.Spec.Priority,
-		TierPriority:     &tierPriority,
-		AppliedToPerRule: appliedToPerRule,
+		Name:                internalNetworkPolicyKeyFunc(np),
+		UID:                 np.UID,
+		Generation:          np.Generation,
+		GenerationTimestamp: generationTimestamp(np),
+		AppliedToGroups:     appliedToGroupNamesSet.List(),
+		Rules:               sortRules(rules),
+		Priority:            &np.Spec.Priority,
+		TierPriority:        &tierPriority,
+		AppliedToPerRule:    appliedToPerRule,
 	}
 	return internalNetworkPolicy
 }
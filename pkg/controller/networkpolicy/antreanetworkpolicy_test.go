@@ -23,6 +23,7 @@ import (
 
 	"antrea.io/antrea/pkg/apis/controlplane"
 	crdv1alpha1 "antrea.io/antrea/pkg/apis/crd/v1alpha1"
+	crdv1beta1 "antrea.io/antrea/pkg/apis/crd/v1beta1"
 	antreatypes "antrea.io/antrea/pkg/controller/types"
 )
 
@@ -35,32 +36,33 @@ var (
 
 func TestProcessAntreaNetworkPolicy(t *testing.T) {
 	p10 := float64(10)
-	allowAction := crdv1alpha1.RuleActionAllow
+	allowAction := crdv1beta1.RuleActionAllow
+	cpAllowAction := crdv1alpha1.RuleActionAllow
 	protocolTCP := controlplane.ProtocolTCP
 	tests := []struct {
 		name                    string
-		inputPolicy             *crdv1alpha1.NetworkPolicy
+		inputPolicy             *crdv1beta1.NetworkPolicy
 		expectedPolicy          *antreatypes.NetworkPolicy
 		expectedAppliedToGroups int
 		expectedAddressGroups   int
 	}{
 		{
 			name: "rules-with-same-selectors",
-			inputPolicy: &crdv1alpha1.NetworkPolicy{
+			inputPolicy: &crdv1beta1.NetworkPolicy{
 				ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "npA", UID: "uidA"},
-				Spec: crdv1alpha1.NetworkPolicySpec{
-					AppliedTo: []crdv1alpha1.NetworkPolicyPeer{
+				Spec: crdv1beta1.NetworkPolicySpec{
+					AppliedTo: []crdv1beta1.NetworkPolicyPeer{
 						{PodSelector: &selectorA},
 					},
 					Priority: p10,
-					Ingress: []crdv1alpha1.Rule{
+					Ingress: []crdv1beta1.Rule{
 						{
-							Ports: []crdv1alpha1.NetworkPolicyPort{
+							Ports: []crdv1beta1.NetworkPolicyPort{
 								{
 									Port: &int80,
 								},
 							},
-							From: []crdv1alpha1.NetworkPolicyPeer{
+							From: []crdv1beta1.NetworkPolicyPeer{
 								{
 									PodSelector:       &selectorB,
 									NamespaceSelector: &selectorC,
@@ -69,14 +71,14 @@ func TestProcessAntreaNetworkPolicy(t *testing.T) {
 							Action: &allowAction,
 						},
 					},
-					Egress: []crdv1alpha1.Rule{
+					Egress: []crdv1beta1.Rule{
 						{
-							Ports: []crdv1alpha1.NetworkPolicyPort{
+							Ports: []crdv1beta1.NetworkPolicyPort{
 								{
 									Port: &int81,
 								},
 							},
-							To: []crdv1alpha1.NetworkPolicyPeer{
+							To: []crdv1beta1.NetworkPolicyPeer{
 								{
 									PodSelector:       &selectorB,
 									NamespaceSelector: &selectorC,
@@ -111,7 +113,7 @@ func TestProcessAntreaNetworkPolicy(t *testing.T) {
 							},
 						},
 						Priority: 0,
-						Action:   &allowAction,
+						Action:   &cpAllowAction,
 					},
 					{
 						Direction: controlplane.DirectionOut,
@@ -125,7 +127,7 @@ func TestProcessAntreaNetworkPolicy(t *testing.T) {
 							},
 						},
 						Priority: 0,
-						Action:   &allowAction,
+						Action:   &cpAllowAction,
 					},
 				},
 				AppliedToGroups: []string{getNormalizedUID(toGroupSelector("ns1", &selectorA, nil, nil).NormalizedName)},
@@ -135,21 +137,21 @@ func TestProcessAntreaNetworkPolicy(t *testing.T) {
 		},
 		{
 			name: "rules-with-different-selectors",
-			inputPolicy: &crdv1alpha1.NetworkPolicy{
+			inputPolicy: &crdv1beta1.NetworkPolicy{
 				ObjectMeta: metav1.ObjectMeta{Namespace: "ns2", Name: "npB", UID: "uidB"},
-				Spec: crdv1alpha1.NetworkPolicySpec{
-					AppliedTo: []crdv1alpha1.NetworkPolicyPeer{
+				Spec: crdv1beta1.NetworkPolicySpec{
+					AppliedTo: []crdv1beta1.NetworkPolicyPeer{
 						{PodSelector: &selectorA},
 					},
 					Priority: p10,
-					Ingress: []crdv1alpha1.Rule{
+					Ingress: []crdv1beta1.Rule{
 						{
-							Ports: []crdv1alpha1.NetworkPolicyPort{
+							Ports: []crdv1beta1.NetworkPolicyPort{
 								{
 									Port: &int80,
 								},
 							},
-							From: []crdv1alpha1.NetworkPolicyPeer{
+							From: []crdv1beta1.NetworkPolicyPeer{
 								{
 									PodSelector: &selectorB,
 								},
@@ -157,12 +159,12 @@ func TestProcessAntreaNetworkPolicy(t *testing.T) {
 							Action: &allowAction,
 						},
 						{
-							Ports: []crdv1alpha1.NetworkPolicyPort{
+							Ports: []crdv1beta1.NetworkPolicyPort{
 								{
 									Port: &int81,
 								},
 							},
-							From: []crdv1alpha1.NetworkPolicyPeer{
+							From: []crdv1beta1.NetworkPolicyPeer{
 								{
 									NamespaceSelector: &selectorC,
 								},
@@ -196,7 +198,7 @@ func TestProcessAntreaNetworkPolicy(t *testing.T) {
 							},
 						},
 						Priority: 0,
-						Action:   &allowAction,
+						Action:   &cpAllowAction,
 					},
 					{
 						Direction: controlplane.DirectionIn,
@@ -210,7 +212,7 @@ func TestProcessAntreaNetworkPolicy(t *testing.T) {
 							},
 						},
 						Priority: 1,
-						Action:   &allowAction,
+						Action:   &cpAllowAction,
 					},
 				},
 				AppliedToGroups: []string{getNormalizedUID(toGroupSelector("ns2", &selectorA, nil, nil).NormalizedName)},
@@ -220,24 +222,24 @@ func TestProcessAntreaNetworkPolicy(t *testing.T) {
 		},
 		{
 			name: "appliedTo-per-rule",
-			inputPolicy: &crdv1alpha1.NetworkPolicy{
+			inputPolicy: &crdv1beta1.NetworkPolicy{
 				ObjectMeta: metav1.ObjectMeta{Namespace: "ns3", Name: "npC", UID: "uidC"},
-				Spec: crdv1alpha1.NetworkPolicySpec{
+				Spec: crdv1beta1.NetworkPolicySpec{
 					AppliedTo: nil,
 					Priority:  p10,
-					Ingress: []crdv1alpha1.Rule{
+					Ingress: []crdv1beta1.Rule{
 						{
-							AppliedTo: []crdv1alpha1.NetworkPolicyPeer{
+							AppliedTo: []crdv1beta1.NetworkPolicyPeer{
 								{
 									PodSelector: &selectorA,
 								},
 							},
-							Ports: []crdv1alpha1.NetworkPolicyPort{
+							Ports: []crdv1beta1.NetworkPolicyPort{
 								{
 									Port: &int80,
 								},
 							},
-							From: []crdv1alpha1.NetworkPolicyPeer{
+							From: []crdv1beta1.NetworkPolicyPeer{
 								{
 									PodSelector: &selectorB,
 								},
@@ -245,17 +247,17 @@ func TestProcessAntreaNetworkPolicy(t *testing.T) {
 							Action: &allowAction,
 						},
 						{
-							AppliedTo: []crdv1alpha1.NetworkPolicyPeer{
+							AppliedTo: []crdv1beta1.NetworkPolicyPeer{
 								{
 									PodSelector: &selectorB,
 								},
 							},
-							Ports: []crdv1alpha1.NetworkPolicyPort{
+							Ports: []crdv1beta1.NetworkPolicyPort{
 								{
 									Port: &int81,
 								},
 							},
-							From: []crdv1alpha1.NetworkPolicyPeer{
+							From: []crdv1beta1.NetworkPolicyPeer{
 								{
 									NamespaceSelector: &selectorC,
 								},
@@ -290,7 +292,7 @@ func TestProcessAntreaNetworkPolicy(t *testing.T) {
 							},
 						},
 						Priority: 0,
-						Action:   &allowAction,
+						Action:   &cpAllowAction,
 					},
 					{
 						Direction:       controlplane.DirectionIn,
@@ -305,7 +307,7 @@ func TestProcessAntreaNetworkPolicy(t *testing.T) {
 							},
 						},
 						Priority: 1,
-						Action:   &allowAction,
+						Action:   &cpAllowAction,
 					},
 				},
 				AppliedToGroups: []string{
@@ -319,23 +321,23 @@ func TestProcessAntreaNetworkPolicy(t *testing.T) {
 		},
 		{
 			name: "with-port-range",
-			inputPolicy: &crdv1alpha1.NetworkPolicy{
+			inputPolicy: &crdv1beta1.NetworkPolicy{
 				ObjectMeta: metav1.ObjectMeta{Namespace: "ns4", Name: "npD", UID: "uidD"},
-				Spec: crdv1alpha1.NetworkPolicySpec{
-					AppliedTo: []crdv1alpha1.NetworkPolicyPeer{
+				Spec: crdv1beta1.NetworkPolicySpec{
+					AppliedTo: []crdv1beta1.NetworkPolicyPeer{
 						{PodSelector: &selectorA},
 					},
 					Priority: p10,
-					Ingress: []crdv1alpha1.Rule{
+					Ingress: []crdv1beta1.Rule{
 						{
-							Ports: []crdv1alpha1.NetworkPolicyPort{
+							Ports: []crdv1beta1.NetworkPolicyPort{
 								{
 									Protocol: &k8sProtocolTCP,
 									Port:     &int1000,
 									EndPort:  &int32For1999,
 								},
 							},
-							From: []crdv1alpha1.NetworkPolicyPeer{
+							From: []crdv1beta1.NetworkPolicyPeer{
 								{
 									PodSelector:       &selectorB,
 									NamespaceSelector: &selectorC,
@@ -371,7 +373,7 @@ func TestProcessAntreaNetworkPolicy(t *testing.T) {
 							},
 						},
 						Priority: 0,
-						Action:   &allowAction,
+						Action:   &cpAllowAction,
 					},
 				},
 				AppliedToGroups: []string{getNormalizedUID(toGroupSelector("ns4", &selectorA, nil, nil).NormalizedName)},
@@ -379,10 +381,68 @@ func TestProcessAntreaNetworkPolicy(t *testing.T) {
 			expectedAppliedToGroups: 1,
 			expectedAddressGroups:   1,
 		},
+		{
+			name: "with-service-accounts",
+			inputPolicy: &crdv1beta1.NetworkPolicy{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "ns5", Name: "npE", UID: "uidE"},
+				Spec: crdv1beta1.NetworkPolicySpec{
+					AppliedTo: []crdv1beta1.NetworkPolicyPeer{
+						{ServiceAccount: &crdv1beta1.NamespacedName{Namespace: "ns5", Name: "sa1"}},
+					},
+					Priority: p10,
+					Ingress: []crdv1beta1.Rule{
+						{
+							Ports: []crdv1beta1.NetworkPolicyPort{
+								{
+									Port: &int80,
+								},
+							},
+							From: []crdv1beta1.NetworkPolicyPeer{
+								{ServiceAccount: &crdv1beta1.NamespacedName{Namespace: "ns6", Name: "sa2"}},
+							},
+							Action: &allowAction,
+						},
+					},
+				},
+			},
+			expectedPolicy: &antreatypes.NetworkPolicy{
+				UID:  "uidE",
+				Name: "uidE",
+				SourceRef: &controlplane.NetworkPolicyReference{
+					Type:      controlplane.AntreaNetworkPolicy,
+					Namespace: "ns5",
+					Name:      "npE",
+					UID:       "uidE",
+				},
+				Priority:     &p10,
+				TierPriority: &DefaultTierPriority,
+				Rules: []controlplane.NetworkPolicyRule{
+					{
+						Direction: controlplane.DirectionIn,
+						From: controlplane.NetworkPolicyPeer{
+							AddressGroups: []string{getNormalizedUID(toGroupSelector("ns6", toServiceAccountPodSelector("sa2"), nil, nil).NormalizedName)},
+						},
+						Services: []controlplane.Service{
+							{
+								Protocol: &protocolTCP,
+								Port:     &int80,
+							},
+						},
+						Priority: 0,
+						Action:   &cpAllowAction,
+					},
+				},
+				AppliedToGroups: []string{getNormalizedUID(toGroupSelector("ns5", toServiceAccountPodSelector("sa1"), nil, nil).NormalizedName)},
+			},
+			expectedAppliedToGroups: 1,
+			expectedAddressGroups:   1,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			_, c := newController()
+			// The rules are stored in canonical order.
+			sortRules(tt.expectedPolicy.Rules)
 			assert.Equal(t, tt.expectedPolicy, c.processAntreaNetworkPolicy(tt.inputPolicy))
 			assert.Equal(t, tt.expectedAddressGroups, len(c.addressGroupStore.List()))
 			assert.Equal(t, tt.expectedAppliedToGroups, len(c.appliedToGroupStore.List()))
@@ -392,7 +452,8 @@ func TestProcessAntreaNetworkPolicy(t *testing.T) {
 
 func TestAddANP(t *testing.T) {
 	p10 := float64(10)
-	allowAction := crdv1alpha1.RuleActionAllow
+	allowAction := crdv1beta1.RuleActionAllow
+	cpAllowAction := crdv1alpha1.RuleActionAllow
 	protocolTCP := controlplane.ProtocolTCP
 	int80 := intstr.FromInt(80)
 	selectorAll := metav1.LabelSelector{}
@@ -400,29 +461,29 @@ func TestAddANP(t *testing.T) {
 	matchAllPeerEgress.AddressGroups = []string{getNormalizedUID(toGroupSelector("", nil, &selectorAll, nil).NormalizedName)}
 	tests := []struct {
 		name               string
-		inputPolicy        *crdv1alpha1.NetworkPolicy
+		inputPolicy        *crdv1beta1.NetworkPolicy
 		expPolicy          *antreatypes.NetworkPolicy
 		expAppliedToGroups int
 		expAddressGroups   int
 	}{
 		{
 			name: "application-tier-policy",
-			inputPolicy: &crdv1alpha1.NetworkPolicy{
+			inputPolicy: &crdv1beta1.NetworkPolicy{
 				ObjectMeta: metav1.ObjectMeta{Namespace: "nsA", Name: "anpA", UID: "uidA"},
-				Spec: crdv1alpha1.NetworkPolicySpec{
-					AppliedTo: []crdv1alpha1.NetworkPolicyPeer{
+				Spec: crdv1beta1.NetworkPolicySpec{
+					AppliedTo: []crdv1beta1.NetworkPolicyPeer{
 						{PodSelector: &selectorA},
 					},
 					Priority: p10,
 					Tier:     "Application",
-					Ingress: []crdv1alpha1.Rule{
+					Ingress: []crdv1beta1.Rule{
 						{
-							Ports: []crdv1alpha1.NetworkPolicyPort{
+							Ports: []crdv1beta1.NetworkPolicyPort{
 								{
 									Port: &int80,
 								},
 							},
-							From: []crdv1alpha1.NetworkPolicyPeer{
+							From: []crdv1beta1.NetworkPolicyPeer{
 								{
 									PodSelector:            &selectorB,
 									NamespaceSelector:      &selectorC,
@@ -458,7 +519,7 @@ func TestAddANP(t *testing.T) {
 							},
 						},
 						Priority: 0,
-						Action:   &allowAction,
+						Action:   &cpAllowAction,
 					},
 				},
 				AppliedToGroups: []string{getNormalizedUID(toGroupSelector("nsA", &selectorA, nil, nil).NormalizedName)},
@@ -468,23 +529,23 @@ func TestAddANP(t *testing.T) {
 		},
 		{
 			name: "with-port-range",
-			inputPolicy: &crdv1alpha1.NetworkPolicy{
+			inputPolicy: &crdv1beta1.NetworkPolicy{
 				ObjectMeta: metav1.ObjectMeta{Namespace: "nsB", Name: "npB", UID: "uidB"},
-				Spec: crdv1alpha1.NetworkPolicySpec{
-					AppliedTo: []crdv1alpha1.NetworkPolicyPeer{
+				Spec: crdv1beta1.NetworkPolicySpec{
+					AppliedTo: []crdv1beta1.NetworkPolicyPeer{
 						{PodSelector: &selectorA},
 					},
 					Priority: p10,
-					Ingress: []crdv1alpha1.Rule{
+					Ingress: []crdv1beta1.Rule{
 						{
-							Ports: []crdv1alpha1.NetworkPolicyPort{
+							Ports: []crdv1beta1.NetworkPolicyPort{
 								{
 									Protocol: &k8sProtocolTCP,
 									Port:     &int1000,
 									EndPort:  &int32For1999,
 								},
 							},
-							From: []crdv1alpha1.NetworkPolicyPeer{
+							From: []crdv1beta1.NetworkPolicyPeer{
 								{
 									PodSelector:       &selectorB,
 									NamespaceSelector: &selectorC,
@@ -520,7 +581,7 @@ func TestAddANP(t *testing.T) {
 							},
 						},
 						Priority: 0,
-						Action:   &allowAction,
+						Action:   &cpAllowAction,
 					},
 				},
 				AppliedToGroups: []string{getNormalizedUID(toGroupSelector("nsB", &selectorA, nil, nil).NormalizedName)},
@@ -537,6 +598,7 @@ func TestAddANP(t *testing.T) {
 			actualPolicyObj, _, _ := npc.internalNetworkPolicyStore.Get(key)
 			actualPolicy := actualPolicyObj.(*antreatypes.NetworkPolicy)
 
+			sortRules(tt.expPolicy.Rules)
 			assert.Equal(t, tt.expPolicy, actualPolicy)
 			assert.Equal(t, tt.expAddressGroups, len(npc.addressGroupStore.List()))
 			assert.Equal(t, tt.expAppliedToGroups, len(npc.appliedToGroupStore.List()))
@@ -561,15 +623,15 @@ func TestDeleteANP(t *testing.T) {
 }
 
 // util functions for testing.
-func getANP() *crdv1alpha1.NetworkPolicy {
+func getANP() *crdv1beta1.NetworkPolicy {
 	p10 := float64(10)
-	allowAction := crdv1alpha1.RuleActionAllow
+	allowAction := crdv1beta1.RuleActionAllow
 	selectorA := metav1.LabelSelector{MatchLabels: map[string]string{"foo1": "bar1"}}
 	selectorB := metav1.LabelSelector{MatchLabels: map[string]string{"foo2": "bar2"}}
 	selectorC := metav1.LabelSelector{MatchLabels: map[string]string{"foo3": "bar3"}}
-	ingressRules := []crdv1alpha1.Rule{
+	ingressRules := []crdv1beta1.Rule{
 		{
-			From: []crdv1alpha1.NetworkPolicyPeer{
+			From: []crdv1beta1.NetworkPolicyPeer{
 				{
 					NamespaceSelector: &selectorB,
 				},
@@ -577,9 +639,9 @@ func getANP() *crdv1alpha1.NetworkPolicy {
 			Action: &allowAction,
 		},
 	}
-	egressRules := []crdv1alpha1.Rule{
+	egressRules := []crdv1beta1.Rule{
 		{
-			To: []crdv1alpha1.NetworkPolicyPeer{
+			To: []crdv1beta1.NetworkPolicyPeer{
 				{
 					ExternalEntitySelector: &selectorC,
 				},
@@ -587,10 +649,10 @@ func getANP() *crdv1alpha1.NetworkPolicy {
 			Action: &allowAction,
 		},
 	}
-	npObj := &crdv1alpha1.NetworkPolicy{
+	npObj := &crdv1beta1.NetworkPolicy{
 		ObjectMeta: metav1.ObjectMeta{Namespace: "test-ns", Name: "test-anp"},
-		Spec: crdv1alpha1.NetworkPolicySpec{
-			AppliedTo: []crdv1alpha1.NetworkPolicyPeer{
+		Spec: crdv1beta1.NetworkPolicySpec{
+			AppliedTo: []crdv1beta1.NetworkPolicyPeer{
 				{PodSelector: &selectorA},
 			},
 			Priority: p10,
@@ -25,8 +25,8 @@ import (
 	"k8s.io/klog/v2"
 
 	"antrea.io/antrea/pkg/apis/controlplane"
-	crdv1alpha1 "antrea.io/antrea/pkg/apis/crd/v1alpha1"
 	crdv1alpha3 "antrea.io/antrea/pkg/apis/crd/v1alpha3"
+	crdv1beta1 "antrea.io/antrea/pkg/apis/crd/v1beta1"
 	"antrea.io/antrea/pkg/controller/networkpolicy/store"
 	antreatypes "antrea.io/antrea/pkg/controller/types"
 )
@@ -182,6 +182,8 @@ func (c *NetworkPolicyController) processNextInternalGroupWorkItem() bool {
 	if quit {
 		return false
 	}
+	c.storeSyncState.internalGroupProgress.start()
+	defer c.storeSyncState.internalGroupProgress.done()
 	defer c.internalGroupQueue.Done(key)
 
 	err := c.syncInternalGroup(key.(string))
@@ -231,12 +233,9 @@ func (n *NetworkPolicyController) syncInternalGroup(key string) error {
 		n.internalGroupStore.Update(updatedGrp)
 	}
 	// Update the ClusterGroup status to Realized as Antrea has recognized the Group and
-	// processed its group members.
-	err = n.updateGroupStatus(cg, v1.ConditionTrue)
-	if err != nil {
-		klog.Errorf("Failed to update ClusterGroup %s GroupMembersComputed condition to %s: %v", cg.Name, v1.ConditionTrue, err)
-		return err
-	}
+	// processed its group members. The update is done asynchronously, so that the changes
+	// are propagated even if the K8s API is unavailable.
+	n.enqueueGroupStatus(cg.Name)
 	n.triggerParentGroupSync(grp)
 	return n.triggerCNPUpdates(cg)
 }
@@ -265,7 +264,7 @@ func (n *NetworkPolicyController) triggerCNPUpdates(cg *crdv1alpha3.ClusterGroup
 		return err
 	}
 	for _, obj := range cnps {
-		cnp := obj.(*crdv1alpha1.ClusterNetworkPolicy)
+		cnp := obj.(*crdv1beta1.ClusterNetworkPolicy)
 		// Re-process ClusterNetworkPolicies which may be affected due to updates to CG.
 		curInternalNP := n.processClusterNetworkPolicy(cnp)
 		klog.V(2).Infof("Updating existing internal NetworkPolicy %s for %s", curInternalNP.Name, curInternalNP.SourceRef.ToString())
@@ -305,6 +304,50 @@ func (n *NetworkPolicyController) triggerCNPUpdates(cg *crdv1alpha3.ClusterGroup
 	return nil
 }
 
+func (n *NetworkPolicyController) enqueueGroupStatus(key string) {
+	klog.V(4).Infof("Adding new key %s to Group status queue", key)
+	n.groupStatusQueue.Add(key)
+}
+
+func (n *NetworkPolicyController) groupStatusWorker() {
+	for n.processNextGroupStatusWorkItem() {
+	}
+}
+
+// processNextGroupStatusWorkItem processes an item in the "groupStatus" work queue, by calling
+// syncGroupStatus. If the status fails to be updated, the item is requeued with an exponential
+// backoff. It returns false if and only if the work queue was shutdown.
+func (n *NetworkPolicyController) processNextGroupStatusWorkItem() bool {
+	key, quit := n.groupStatusQueue.Get()
+	if quit {
+		return false
+	}
+	defer n.groupStatusQueue.Done(key)
+
+	err := n.syncGroupStatus(key.(string))
+	if err != nil {
+		handleStatusUpdateError(n.groupStatusQueue, key.(string), err, "ClusterGroup")
+		return true
+	}
+	n.groupStatusQueue.Forget(key)
+	return true
+}
+
+// syncGroupStatus sets the GroupMembersComputed condition of a ClusterGroup whose internal Group
+// has been synced.
+func (n *NetworkPolicyController) syncGroupStatus(key string) error {
+	if _, found, _ := n.internalGroupStore.Get(key); !found {
+		klog.V(2).Infof("Internal group %s not found, skip updating status", key)
+		return nil
+	}
+	cg, err := n.cgLister.Get(key)
+	if err != nil {
+		klog.V(2).Infof("Didn't find the ClusterGroup %s, skip updating status", key)
+		return nil
+	}
+	return n.updateGroupStatus(cg, v1.ConditionTrue)
+}
+
 // updateGroupStatus updates the Status subresource for a ClusterGroup.
 func (n *NetworkPolicyController) updateGroupStatus(cg *crdv1alpha3.ClusterGroup, cStatus v1.ConditionStatus) error {
 	condStatus := crdv1alpha3.GroupCondition{
@@ -322,7 +365,9 @@ func (n *NetworkPolicyController) updateGroupStatus(cg *crdv1alpha3.ClusterGroup
 	klog.V(4).Infof("Updating ClusterGroup %s status to %#v", cg.Name, condStatus)
 	toUpdate := cg.DeepCopy()
 	toUpdate.Status = status
-	_, err := n.crdClient.CrdV1alpha3().ClusterGroups().UpdateStatus(context.TODO(), toUpdate, metav1.UpdateOptions{})
+	ctx, cancel := context.WithTimeout(context.TODO(), statusUpdateTimeout)
+	defer cancel()
+	_, err := n.crdClient.CrdV1alpha3().ClusterGroups().UpdateStatus(ctx, toUpdate, metav1.UpdateOptions{})
 	return err
 }
 
@@ -15,17 +15,29 @@
 package networkpolicy
 
 import (
+	"context"
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/watch"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/util/workqueue"
 
 	"antrea.io/antrea/pkg/apis/controlplane"
-	crdv1alpha1 "antrea.io/antrea/pkg/apis/crd/v1alpha1"
 	crdv1alpha2 "antrea.io/antrea/pkg/apis/crd/v1alpha2"
 	crdv1alpha3 "antrea.io/antrea/pkg/apis/crd/v1alpha3"
+	crdv1beta1 "antrea.io/antrea/pkg/apis/crd/v1beta1"
+	fakeversioned "antrea.io/antrea/pkg/client/clientset/versioned/fake"
 	antreatypes "antrea.io/antrea/pkg/controller/types"
 )
 
@@ -89,7 +101,7 @@ func TestProcessClusterGroup(t *testing.T) {
 			inputGroup: &crdv1alpha3.ClusterGroup{
 				ObjectMeta: metav1.ObjectMeta{Name: "cgD", UID: "uidD"},
 				Spec: crdv1alpha3.GroupSpec{
-					IPBlocks: []crdv1alpha1.IPBlock{
+					IPBlocks: []crdv1beta1.IPBlock{
 						{
 							CIDR: cidr,
 						},
@@ -211,7 +223,7 @@ func TestAddClusterGroup(t *testing.T) {
 			inputGroup: &crdv1alpha3.ClusterGroup{
 				ObjectMeta: metav1.ObjectMeta{Name: "cgD", UID: "uidD"},
 				Spec: crdv1alpha3.GroupSpec{
-					IPBlocks: []crdv1alpha1.IPBlock{
+					IPBlocks: []crdv1beta1.IPBlock{
 						{
 							CIDR: cidr,
 						},
@@ -308,7 +320,7 @@ func TestUpdateClusterGroup(t *testing.T) {
 			updatedGroup: &crdv1alpha3.ClusterGroup{
 				ObjectMeta: metav1.ObjectMeta{Name: "cgA", UID: "uidA"},
 				Spec: crdv1alpha3.GroupSpec{
-					IPBlocks: []crdv1alpha1.IPBlock{
+					IPBlocks: []crdv1beta1.IPBlock{
 						{
 							CIDR: cidr,
 						},
@@ -828,3 +840,122 @@ func TestGetGroupMembers(t *testing.T) {
 		})
 	}
 }
+
+// TestClusterGroupWithAPIServerUnavailable simulates an outage of the kube-apiserver, during which
+// all the writes fail, and verifies that the changes of the members of a ClusterGroup which is
+// already known are still propagated to the agents, while the update of the ClusterGroup status
+// is retried until the outage ends.
+func TestClusterGroupWithAPIServerUnavailable(t *testing.T) {
+	selector := metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}}
+	cg := &crdv1alpha3.ClusterGroup{
+		ObjectMeta: metav1.ObjectMeta{Name: "cgA", UID: "uidA"},
+		Spec:       crdv1alpha3.GroupSpec{PodSelector: &selector},
+	}
+	allowAction := crdv1beta1.RuleActionAllow
+	cnp := &crdv1beta1.ClusterNetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "cnpA", UID: "uidB"},
+		Spec: crdv1beta1.ClusterNetworkPolicySpec{
+			AppliedTo: []crdv1beta1.NetworkPolicyPeer{{Group: cg.Name}},
+			Priority:  10,
+			Ingress: []crdv1beta1.Rule{
+				{
+					Ports:  []crdv1beta1.NetworkPolicyPort{{Port: &int80}},
+					Action: &allowAction,
+				},
+			},
+		},
+	}
+	newWebPod := func(name, nodeName, ip string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "nsA", UID: types.UID(name), Labels: map[string]string{"app": "web"}},
+			Spec:       corev1.PodSpec{NodeName: nodeName},
+			Status:     corev1.PodStatus{PodIP: ip, PodIPs: []corev1.PodIP{{IP: ip}}},
+		}
+	}
+
+	_, c := newController()
+	c.groupStatusQueue = workqueue.NewNamedRateLimitingQueue(workqueue.NewItemExponentialFailureRateLimiter(time.Millisecond, 10*time.Millisecond), "groupStatus")
+	crdClient := c.crdClient.(*fakeversioned.Clientset)
+	apiServerAvailable := false
+	crdClient.PrependReactor("*", "*", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		switch action.GetVerb() {
+		case "create", "update", "patch", "delete":
+			if !apiServerAvailable {
+				return true, nil, fmt.Errorf("kube-apiserver is unavailable")
+			}
+		}
+		return false, nil, nil
+	})
+	// processQueues processes the items of the work queues until they are all empty, as the
+	// workers would do.
+	processQueues := func() {
+		for c.internalGroupQueue.Len() > 0 || c.appliedToGroupQueue.Len() > 0 || c.addressGroupQueue.Len() > 0 || c.internalNetworkPolicyQueue.Len() > 0 {
+			for c.internalGroupQueue.Len() > 0 {
+				c.processNextInternalGroupWorkItem()
+			}
+			for c.appliedToGroupQueue.Len() > 0 {
+				c.processNextAppliedToGroupWorkItem()
+			}
+			for c.addressGroupQueue.Len() > 0 {
+				c.processNextAddressGroupWorkItem()
+			}
+			for c.internalNetworkPolicyQueue.Len() > 0 {
+				c.processNextInternalNetworkPolicyWorkItem()
+			}
+		}
+	}
+
+	// The objects were received by the informers before the outage.
+	require.NoError(t, crdClient.Tracker().Add(cg))
+	c.cgStore.Add(cg)
+	c.addClusterGroup(cg)
+	c.cnpStore.Add(cnp)
+	c.addCNP(cnp)
+	c.groupingInterface.AddPod(newWebPod("pod1", "node1", "1.1.1.1"))
+	processQueues()
+	npKey := internalNetworkPolicyKeyFunc(cnp)
+	npObj, found, _ := c.internalNetworkPolicyStore.Get(npKey)
+	require.True(t, found)
+	assert.Equal(t, sets.NewString("node1"), npObj.(*antreatypes.NetworkPolicy).NodeNames)
+
+	// The status of the ClusterGroup fails to be updated, and is retried.
+	require.Equal(t, 1, c.groupStatusQueue.Len())
+	c.processNextGroupStatusWorkItem()
+	assert.Equal(t, 1, c.groupStatusQueue.NumRequeues(cg.Name))
+
+	// A new member of the ClusterGroup is still propagated to the agent of its Node.
+	watcher, err := c.internalNetworkPolicyStore.Watch(context.TODO(), "", labels.Everything(), fields.SelectorFromSet(fields.Set{"nodeName": "node2"}))
+	require.NoError(t, err)
+	defer watcher.Stop()
+	c.groupingInterface.AddPod(newWebPod("pod2", "node2", "1.1.1.2"))
+	processQueues()
+	func() {
+		timeout := time.After(time.Second)
+		for {
+			select {
+			case event := <-watcher.ResultChan():
+				if event.Type == watch.Bookmark {
+					continue
+				}
+				assert.Equal(t, watch.Added, event.Type)
+				assert.Equal(t, "uidB", event.Object.(*controlplane.NetworkPolicy).Name)
+				return
+			case <-timeout:
+				t.Fatalf("The NetworkPolicy was not sent to the agent of node2")
+			}
+		}
+	}()
+
+	// The status is updated once the kube-apiserver is available again.
+	assert.Eventually(t, func() bool {
+		return c.groupStatusQueue.Len() == 1
+	}, time.Second, 5*time.Millisecond)
+	apiServerAvailable = true
+	c.processNextGroupStatusWorkItem()
+	assert.Equal(t, 0, c.groupStatusQueue.NumRequeues(cg.Name))
+	updatedCG, err := crdClient.CrdV1alpha3().ClusterGroups().Get(context.TODO(), cg.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Len(t, updatedCG.Status.Conditions, 1)
+	assert.Equal(t, crdv1alpha3.GroupMembersComputed, updatedCG.Status.Conditions[0].Type)
+	assert.Equal(t, corev1.ConditionTrue, updatedCG.Status.Conditions[0].Status)
+}
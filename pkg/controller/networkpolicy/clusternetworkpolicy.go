@@ -24,6 +24,7 @@ import (
 
 	"antrea.io/antrea/pkg/apis/controlplane"
 	crdv1alpha1 "antrea.io/antrea/pkg/apis/crd/v1alpha1"
+	crdv1beta1 "antrea.io/antrea/pkg/apis/crd/v1beta1"
 	"antrea.io/antrea/pkg/controller/networkpolicy/store"
 	antreatypes "antrea.io/antrea/pkg/controller/types"
 	utilsets "antrea.io/antrea/pkg/util/sets"
@@ -33,7 +34,7 @@ import (
 // which can be consumed by agents to configure corresponding rules on the Nodes.
 func (n *NetworkPolicyController) addCNP(obj interface{}) {
 	defer n.heartbeat("addCNP")
-	cnp := obj.(*crdv1alpha1.ClusterNetworkPolicy)
+	cnp := obj.(*crdv1beta1.ClusterNetworkPolicy)
 	klog.Infof("Processing ClusterNetworkPolicy %s ADD event", cnp.Name)
 	// Create an internal NetworkPolicy object corresponding to this
 	// ClusterNetworkPolicy and enqueue task to internal NetworkPolicy Workqueue.
@@ -48,14 +49,14 @@ func (n *NetworkPolicyController) addCNP(obj interface{}) {
 // which can be consumed by agents to configure corresponding rules on the Nodes.
 func (n *NetworkPolicyController) updateCNP(old, cur interface{}) {
 	defer n.heartbeat("updateCNP")
-	curCNP := cur.(*crdv1alpha1.ClusterNetworkPolicy)
+	curCNP := cur.(*crdv1beta1.ClusterNetworkPolicy)
 	klog.Infof("Processing ClusterNetworkPolicy %s UPDATE event", curCNP.Name)
 	// Update an internal NetworkPolicy, corresponding to this NetworkPolicy and
 	// enqueue task to internal NetworkPolicy Workqueue.
 	curInternalNP := n.processClusterNetworkPolicy(curCNP)
 	klog.V(2).Infof("Updating existing internal NetworkPolicy %s for %s", curInternalNP.Name, curInternalNP.SourceRef.ToString())
-	// Retrieve old crdv1alpha1.NetworkPolicy object.
-	oldCNP := old.(*crdv1alpha1.ClusterNetworkPolicy)
+	// Retrieve old crdv1beta1.NetworkPolicy object.
+	oldCNP := old.(*crdv1beta1.ClusterNetworkPolicy)
 	// Old and current NetworkPolicy share the same key.
 	key := internalNetworkPolicyKeyFunc(oldCNP)
 	// Lock access to internal NetworkPolicy store such that concurrent access
@@ -92,14 +93,14 @@ func (n *NetworkPolicyController) updateCNP(old, cur interface{}) {
 // deleteCNP receives ClusterNetworkPolicy DELETED events and deletes resources
 // which can be consumed by agents to delete corresponding rules on the Nodes.
 func (n *NetworkPolicyController) deleteCNP(old interface{}) {
-	cnp, ok := old.(*crdv1alpha1.ClusterNetworkPolicy)
+	cnp, ok := old.(*crdv1beta1.ClusterNetworkPolicy)
 	if !ok {
 		tombstone, ok := old.(cache.DeletedFinalStateUnknown)
 		if !ok {
 			klog.Errorf("Error decoding object when deleting ClusterNetworkPolicy, invalid type: %v", old)
 			return
 		}
-		cnp, ok = tombstone.Obj.(*crdv1alpha1.ClusterNetworkPolicy)
+		cnp, ok = tombstone.Obj.(*crdv1beta1.ClusterNetworkPolicy)
 		if !ok {
 			klog.Errorf("Error decoding object tombstone when deleting ClusterNetworkPolicy, invalid type: %v", tombstone.Obj)
 			return
@@ -128,7 +129,7 @@ func (n *NetworkPolicyController) deleteCNP(old interface{}) {
 
 // reprocessCNP is triggered by Namespace ADD/UPDATE/DELETE events when they impact the
 // per-namespace rules of a CNP.
-func (n *NetworkPolicyController) reprocessCNP(cnp *crdv1alpha1.ClusterNetworkPolicy) {
+func (n *NetworkPolicyController) reprocessCNP(cnp *crdv1beta1.ClusterNetworkPolicy) {
 	key := internalNetworkPolicyKeyFunc(cnp)
 	n.internalNetworkPolicyMutex.Lock()
 	oldInternalNPObj, exist, _ := n.internalNetworkPolicyStore.Get(key)
@@ -248,12 +249,12 @@ func (n *NetworkPolicyController) deleteNamespace(old interface{}) {
 }
 
 // processClusterNetworkPolicy creates an internal NetworkPolicy instance
-// corresponding to the crdv1alpha1.ClusterNetworkPolicy object. This method
+// corresponding to the crdv1beta1.ClusterNetworkPolicy object. This method
 // does not commit the internal NetworkPolicy in store, instead returns an
 // instance to the caller wherein, it will be either stored as a new Object
 // in case of ADD event or modified and store the updated instance, in case
 // of an UPDATE event.
-func (n *NetworkPolicyController) processClusterNetworkPolicy(cnp *crdv1alpha1.ClusterNetworkPolicy) *antreatypes.NetworkPolicy {
+func (n *NetworkPolicyController) processClusterNetworkPolicy(cnp *crdv1beta1.ClusterNetworkPolicy) *antreatypes.NetworkPolicy {
 	hasPerNamespaceRule := hasPerNamespaceRule(cnp)
 	// If one of the ACNP rule is a per-namespace rule (a peer in that rule has namspaces.Match set
 	// to Self), the policy will need to be converted to appliedTo per rule policy, as the appliedTo
@@ -278,7 +279,7 @@ func (n *NetworkPolicyController) processClusterNetworkPolicy(cnp *crdv1alpha1.C
 			affectedNS, selectors := n.getAffectedNamespacesForAppliedTo(at)
 			affectedNamespaceSelectors = append(affectedNamespaceSelectors, selectors...)
 			for _, ns := range affectedNS {
-				atg := n.createAppliedToGroup(ns, at.PodSelector, nil, at.ExternalEntitySelector)
+				atg := n.createNamespacedAppliedToGroupForCRD(ns, at)
 				atgNamesSet.Insert(atg)
 				clusterAppliedToAffectedNS = append(clusterAppliedToAffectedNS, ns)
 				atgForNamespace = append(atgForNamespace, atg)
@@ -286,7 +287,7 @@ func (n *NetworkPolicyController) processClusterNetworkPolicy(cnp *crdv1alpha1.C
 		}
 	}
 	var rules []controlplane.NetworkPolicyRule
-	processRules := func(cnpRules []crdv1alpha1.Rule, direction controlplane.Direction) {
+	processRules := func(cnpRules []crdv1beta1.Rule, direction controlplane.Direction) {
 		for idx, cnpRule := range cnpRules {
 			services, namedPortExists := toAntreaServicesForCRD(cnpRule.Ports)
 			clusterPeers, perNSPeers := splitPeersByScope(cnpRule, direction)
@@ -295,7 +296,7 @@ func (n *NetworkPolicyController) processClusterNetworkPolicy(cnp *crdv1alpha1.C
 					Direction:       dir,
 					Services:        services,
 					Name:            cnpRule.Name,
-					Action:          cnpRule.Action,
+					Action:          (*crdv1alpha1.RuleAction)(cnpRule.Action),
 					Priority:        int32(idx),
 					EnableLogging:   cnpRule.EnableLogging,
 					AppliedToGroups: ruleAppliedTos,
@@ -331,7 +332,7 @@ func (n *NetworkPolicyController) processClusterNetworkPolicy(cnp *crdv1alpha1.C
 						affectedNS, selectors := n.getAffectedNamespacesForAppliedTo(at)
 						affectedNamespaceSelectors = append(affectedNamespaceSelectors, selectors...)
 						for _, ns := range affectedNS {
-							atg := n.createAppliedToGroup(ns, at.PodSelector, nil, at.ExternalEntitySelector)
+							atg := n.createNamespacedAppliedToGroupForCRD(ns, at)
 							atgNamesSet.Insert(atg)
 							klog.V(4).Infof("Adding a new per-namespace rule with appliedTo %v for rule %d of %s", atg, idx, cnp.Name)
 							addRule(n.toNamespacedPeerForCRD(perNSPeers, ns), direction, []string{atg})
@@ -345,14 +346,34 @@ func (n *NetworkPolicyController) processClusterNetworkPolicy(cnp *crdv1alpha1.C
 	processRules(cnp.Spec.Ingress, controlplane.DirectionIn)
 	// Compute NetworkPolicyRules for Egress Rules.
 	processRules(cnp.Spec.Egress, controlplane.DirectionOut)
+	// Compute NetworkPolicyRules for Layer2 Rules. They are egress rules as they match the messages
+	// sent by the workloads they are applied to, and their peers are the target addresses.
+	for idx, layer2Rule := range cnp.Spec.Layer2 {
+		ruleAppliedTos := layer2Rule.AppliedTo
+		if appliedToPerRule && len(cnp.Spec.AppliedTo) > 0 {
+			ruleAppliedTos = cnp.Spec.AppliedTo
+		}
+		ruleATGNames := n.processClusterAppliedTo(ruleAppliedTos, atgNamesSet)
+		rules = append(rules, controlplane.NetworkPolicyRule{
+			Direction:       controlplane.DirectionOut,
+			To:              *n.toAntreaPeerForCRD(layer2Rule.To, cnp, controlplane.DirectionOut, false),
+			Services:        toAntreaServicesForLayer2(&cnp.Spec.Layer2[idx]),
+			Name:            layer2Rule.Name,
+			Action:          (*crdv1alpha1.RuleAction)(layer2Rule.Action),
+			Priority:        int32(idx),
+			EnableLogging:   layer2Rule.EnableLogging,
+			AppliedToGroups: ruleATGNames,
+		})
+	}
 	// Create AppliedToGroup for each AppliedTo present in ClusterNetworkPolicy spec.
 	if !hasPerNamespaceRule {
 		n.processClusterAppliedTo(cnp.Spec.AppliedTo, atgNamesSet)
 	}
 	tierPriority := n.getTierPriority(cnp.Spec.Tier)
 	internalNetworkPolicy := &antreatypes.NetworkPolicy{
-		Name:       internalNetworkPolicyKeyFunc(cnp),
-		Generation: cnp.Generation,
+		Name:                internalNetworkPolicyKeyFunc(cnp),
+		Generation:          cnp.Generation,
+		GenerationTimestamp: generationTimestamp(cnp),
 		SourceRef: &controlplane.NetworkPolicyReference{
 			Type: controlplane.AntreaClusterNetworkPolicy,
 			Name: cnp.Name,
@@ -360,7 +381,7 @@ func (n *NetworkPolicyController) processClusterNetworkPolicy(cnp *crdv1alpha1.C
 		},
 		UID:                   cnp.UID,
 		AppliedToGroups:       atgNamesSet.List(),
-		Rules:                 rules,
+		Rules:                 sortRules(rules),
 		Priority:              &cnp.Spec.Priority,
 		TierPriority:          &tierPriority,
 		AppliedToPerRule:      appliedToPerRule,
@@ -370,17 +391,17 @@ func (n *NetworkPolicyController) processClusterNetworkPolicy(cnp *crdv1alpha1.C
 }
 
 // hasPerNamespaceRule returns true if there is at least one per-namespace rule
-func hasPerNamespaceRule(cnp *crdv1alpha1.ClusterNetworkPolicy) bool {
+func hasPerNamespaceRule(cnp *crdv1beta1.ClusterNetworkPolicy) bool {
 	for _, ingress := range cnp.Spec.Ingress {
 		for _, peer := range ingress.From {
-			if peer.Namespaces != nil && peer.Namespaces.Match == crdv1alpha1.NamespaceMatchSelf {
+			if peer.Namespaces != nil && peer.Namespaces.Match == crdv1beta1.NamespaceMatchSelf {
 				return true
 			}
 		}
 	}
 	for _, egress := range cnp.Spec.Egress {
 		for _, peer := range egress.To {
-			if peer.Namespaces != nil && peer.Namespaces.Match == crdv1alpha1.NamespaceMatchSelf {
+			if peer.Namespaces != nil && peer.Namespaces.Match == crdv1beta1.NamespaceMatchSelf {
 				return true
 			}
 		}
@@ -390,14 +411,14 @@ func hasPerNamespaceRule(cnp *crdv1alpha1.ClusterNetworkPolicy) bool {
 
 // processClusterAppliedTo processes appliedTo groups in Antrea ClusterNetworkPolicy set
 // at cluster level (appliedTo groups which will not need to be split by Namespaces).
-func (n *NetworkPolicyController) processClusterAppliedTo(appliedTo []crdv1alpha1.NetworkPolicyPeer, appliedToGroupNamesSet sets.String) []string {
+func (n *NetworkPolicyController) processClusterAppliedTo(appliedTo []crdv1beta1.NetworkPolicyPeer, appliedToGroupNamesSet sets.String) []string {
 	var appliedToGroupNames []string
 	for _, at := range appliedTo {
 		var atg string
 		if at.Group != "" {
 			atg = n.processAppliedToGroupForCG(at.Group)
 		} else {
-			atg = n.createAppliedToGroup("", at.PodSelector, at.NamespaceSelector, at.ExternalEntitySelector)
+			atg = n.createAppliedToGroupForCRD("", at)
 		}
 		if atg != "" {
 			appliedToGroupNames = append(appliedToGroupNames, atg)
@@ -409,14 +430,14 @@ func (n *NetworkPolicyController) processClusterAppliedTo(appliedTo []crdv1alpha
 
 // splitPeersByScope splits the ClusterNetworkPolicy peers in the rule by whether the peer
 // is cluster-scoped or per-namespace.
-func splitPeersByScope(rule crdv1alpha1.Rule, dir controlplane.Direction) ([]crdv1alpha1.NetworkPolicyPeer, []crdv1alpha1.NetworkPolicyPeer) {
-	var clusterPeers, perNSPeers []crdv1alpha1.NetworkPolicyPeer
+func splitPeersByScope(rule crdv1beta1.Rule, dir controlplane.Direction) ([]crdv1beta1.NetworkPolicyPeer, []crdv1beta1.NetworkPolicyPeer) {
+	var clusterPeers, perNSPeers []crdv1beta1.NetworkPolicyPeer
 	peers := rule.From
 	if dir == controlplane.DirectionOut {
 		peers = rule.To
 	}
 	for _, peer := range peers {
-		if peer.Namespaces != nil && peer.Namespaces.Match == crdv1alpha1.NamespaceMatchSelf {
+		if peer.Namespaces != nil && peer.Namespaces.Match == crdv1beta1.NamespaceMatchSelf {
 			perNSPeers = append(perNSPeers, peer)
 		} else {
 			clusterPeers = append(clusterPeers, peer)
@@ -428,10 +449,14 @@ func splitPeersByScope(rule crdv1alpha1.Rule, dir controlplane.Direction) ([]crd
 // getAffectedNamespacesForAppliedTo computes the Namespaces currently affected by the appliedTo
 // Namespace selectors. It also returns the list of Namespace selectors used to compute affected
 // Namespaces.
-func (n *NetworkPolicyController) getAffectedNamespacesForAppliedTo(appliedTo crdv1alpha1.NetworkPolicyPeer) ([]string, []labels.Selector) {
+func (n *NetworkPolicyController) getAffectedNamespacesForAppliedTo(appliedTo crdv1beta1.NetworkPolicyPeer) ([]string, []labels.Selector) {
 	var affectedNS []string
 	var affectedNamespaceSelectors []labels.Selector
 
+	// The Pods running with a ServiceAccount can only be in the ServiceAccount's Namespace.
+	if appliedTo.ServiceAccount != nil {
+		return []string{appliedTo.ServiceAccount.Namespace}, affectedNamespaceSelectors
+	}
 	nsLabelSelector := appliedTo.NamespaceSelector
 	if appliedTo.Group != "" {
 		cg, err := n.cgLister.Get(appliedTo.Group)
@@ -458,6 +483,15 @@ func (n *NetworkPolicyController) getAffectedNamespacesForAppliedTo(appliedTo cr
 	return affectedNS, affectedNamespaceSelectors
 }
 
+// createNamespacedAppliedToGroupForCRD creates an AppliedToGroup for the workloads selected by the provided
+// appliedTo in a particular Namespace, which must be one of the appliedTo's affected Namespaces.
+func (n *NetworkPolicyController) createNamespacedAppliedToGroupForCRD(namespace string, appliedTo crdv1beta1.NetworkPolicyPeer) string {
+	if appliedTo.ServiceAccount != nil {
+		return n.createAppliedToGroup(namespace, toServiceAccountPodSelector(appliedTo.ServiceAccount.Name), nil, nil)
+	}
+	return n.createAppliedToGroup(namespace, appliedTo.PodSelector, nil, appliedTo.ExternalEntitySelector)
+}
+
 // getUniqueNSSelectors dedups the Namespace selectors, which are used as index to re-process
 // affected ClusterNetworkPolicy when there is Namespace CRUD events. Note that when there is
 // an empty selector in the list, this function will simply return a list with only one empty
@@ -18,6 +18,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
@@ -25,6 +26,7 @@ import (
 	"antrea.io/antrea/pkg/apis/controlplane"
 	crdv1alpha1 "antrea.io/antrea/pkg/apis/crd/v1alpha1"
 	crdv1alpha3 "antrea.io/antrea/pkg/apis/crd/v1alpha3"
+	crdv1beta1 "antrea.io/antrea/pkg/apis/crd/v1beta1"
 	antreatypes "antrea.io/antrea/pkg/controller/types"
 )
 
@@ -51,9 +53,15 @@ func TestProcessClusterNetworkPolicy(t *testing.T) {
 		},
 	}
 
-	allowAction := crdv1alpha1.RuleActionAllow
-	dropAction := crdv1alpha1.RuleActionDrop
+	allowAction := crdv1beta1.RuleActionAllow
+	cpAllowAction := crdv1alpha1.RuleActionAllow
+	dropAction := crdv1beta1.RuleActionDrop
+	cpDropAction := crdv1alpha1.RuleActionDrop
 	protocolTCP := controlplane.ProtocolTCP
+	protocolARP := controlplane.ProtocolARP
+	protocolICMPv6 := controlplane.ProtocolICMPv6
+	ndTypeNS := crdv1beta1.NDTypeNeighborSolicitation
+	icmpTypeNS := int32(135)
 	selectorA := metav1.LabelSelector{MatchLabels: map[string]string{"foo1": "bar1"}}
 	selectorB := metav1.LabelSelector{MatchLabels: map[string]string{"foo2": "bar2"}}
 	selectorC := metav1.LabelSelector{MatchLabels: map[string]string{"foo3": "bar3"}}
@@ -67,28 +75,28 @@ func TestProcessClusterNetworkPolicy(t *testing.T) {
 	}
 	tests := []struct {
 		name                    string
-		inputPolicy             *crdv1alpha1.ClusterNetworkPolicy
+		inputPolicy             *crdv1beta1.ClusterNetworkPolicy
 		expectedPolicy          *antreatypes.NetworkPolicy
 		expectedAppliedToGroups int
 		expectedAddressGroups   int
 	}{
 		{
 			name: "rules-with-same-selectors",
-			inputPolicy: &crdv1alpha1.ClusterNetworkPolicy{
+			inputPolicy: &crdv1beta1.ClusterNetworkPolicy{
 				ObjectMeta: metav1.ObjectMeta{Name: "cnpA", UID: "uidA"},
-				Spec: crdv1alpha1.ClusterNetworkPolicySpec{
-					AppliedTo: []crdv1alpha1.NetworkPolicyPeer{
+				Spec: crdv1beta1.ClusterNetworkPolicySpec{
+					AppliedTo: []crdv1beta1.NetworkPolicyPeer{
 						{PodSelector: &selectorA},
 					},
 					Priority: p10,
-					Ingress: []crdv1alpha1.Rule{
+					Ingress: []crdv1beta1.Rule{
 						{
-							Ports: []crdv1alpha1.NetworkPolicyPort{
+							Ports: []crdv1beta1.NetworkPolicyPort{
 								{
 									Port: &int80,
 								},
 							},
-							From: []crdv1alpha1.NetworkPolicyPeer{
+							From: []crdv1beta1.NetworkPolicyPeer{
 								{
 									PodSelector:       &selectorB,
 									NamespaceSelector: &selectorC,
@@ -97,14 +105,14 @@ func TestProcessClusterNetworkPolicy(t *testing.T) {
 							Action: &allowAction,
 						},
 					},
-					Egress: []crdv1alpha1.Rule{
+					Egress: []crdv1beta1.Rule{
 						{
-							Ports: []crdv1alpha1.NetworkPolicyPort{
+							Ports: []crdv1beta1.NetworkPolicyPort{
 								{
 									Port: &int81,
 								},
 							},
-							To: []crdv1alpha1.NetworkPolicyPeer{
+							To: []crdv1beta1.NetworkPolicyPeer{
 								{
 									PodSelector:       &selectorB,
 									NamespaceSelector: &selectorC,
@@ -138,7 +146,7 @@ func TestProcessClusterNetworkPolicy(t *testing.T) {
 							},
 						},
 						Priority: 0,
-						Action:   &allowAction,
+						Action:   &cpAllowAction,
 					},
 					{
 						Direction: controlplane.DirectionOut,
@@ -152,7 +160,7 @@ func TestProcessClusterNetworkPolicy(t *testing.T) {
 							},
 						},
 						Priority: 0,
-						Action:   &allowAction,
+						Action:   &cpAllowAction,
 					},
 				},
 				AppliedToGroups: []string{getNormalizedUID(toGroupSelector("", &selectorA, nil, nil).NormalizedName)},
@@ -162,21 +170,21 @@ func TestProcessClusterNetworkPolicy(t *testing.T) {
 		},
 		{
 			name: "rules-with-different-selectors",
-			inputPolicy: &crdv1alpha1.ClusterNetworkPolicy{
+			inputPolicy: &crdv1beta1.ClusterNetworkPolicy{
 				ObjectMeta: metav1.ObjectMeta{Namespace: "", Name: "cnpB", UID: "uidB"},
-				Spec: crdv1alpha1.ClusterNetworkPolicySpec{
-					AppliedTo: []crdv1alpha1.NetworkPolicyPeer{
+				Spec: crdv1beta1.ClusterNetworkPolicySpec{
+					AppliedTo: []crdv1beta1.NetworkPolicyPeer{
 						{PodSelector: &selectorA},
 					},
 					Priority: p10,
-					Ingress: []crdv1alpha1.Rule{
+					Ingress: []crdv1beta1.Rule{
 						{
-							Ports: []crdv1alpha1.NetworkPolicyPort{
+							Ports: []crdv1beta1.NetworkPolicyPort{
 								{
 									Port: &int80,
 								},
 							},
-							From: []crdv1alpha1.NetworkPolicyPeer{
+							From: []crdv1beta1.NetworkPolicyPeer{
 								{
 									PodSelector: &selectorB,
 								},
@@ -184,12 +192,12 @@ func TestProcessClusterNetworkPolicy(t *testing.T) {
 							Action: &allowAction,
 						},
 						{
-							Ports: []crdv1alpha1.NetworkPolicyPort{
+							Ports: []crdv1beta1.NetworkPolicyPort{
 								{
 									Port: &int81,
 								},
 							},
-							From: []crdv1alpha1.NetworkPolicyPeer{
+							From: []crdv1beta1.NetworkPolicyPeer{
 								{
 									NamespaceSelector: &selectorC,
 								},
@@ -222,7 +230,7 @@ func TestProcessClusterNetworkPolicy(t *testing.T) {
 							},
 						},
 						Priority: 0,
-						Action:   &allowAction,
+						Action:   &cpAllowAction,
 					},
 					{
 						Direction: controlplane.DirectionIn,
@@ -236,7 +244,7 @@ func TestProcessClusterNetworkPolicy(t *testing.T) {
 							},
 						},
 						Priority: 1,
-						Action:   &allowAction,
+						Action:   &cpAllowAction,
 					},
 				},
 				AppliedToGroups: []string{getNormalizedUID(toGroupSelector("", &selectorA, nil, nil).NormalizedName)},
@@ -246,22 +254,22 @@ func TestProcessClusterNetworkPolicy(t *testing.T) {
 		},
 		{
 			name: "with-tier-A",
-			inputPolicy: &crdv1alpha1.ClusterNetworkPolicy{
+			inputPolicy: &crdv1beta1.ClusterNetworkPolicy{
 				ObjectMeta: metav1.ObjectMeta{Namespace: "", Name: "cnpC", UID: "uidC"},
-				Spec: crdv1alpha1.ClusterNetworkPolicySpec{
-					AppliedTo: []crdv1alpha1.NetworkPolicyPeer{
+				Spec: crdv1beta1.ClusterNetworkPolicySpec{
+					AppliedTo: []crdv1beta1.NetworkPolicyPeer{
 						{PodSelector: &selectorA},
 					},
 					Priority: p10,
 					Tier:     "tier-A",
-					Ingress: []crdv1alpha1.Rule{
+					Ingress: []crdv1beta1.Rule{
 						{
-							Ports: []crdv1alpha1.NetworkPolicyPort{
+							Ports: []crdv1beta1.NetworkPolicyPort{
 								{
 									Port: &int80,
 								},
 							},
-							From: []crdv1alpha1.NetworkPolicyPeer{
+							From: []crdv1beta1.NetworkPolicyPeer{
 								{
 									PodSelector: &selectorB,
 								},
@@ -269,12 +277,12 @@ func TestProcessClusterNetworkPolicy(t *testing.T) {
 							Action: &allowAction,
 						},
 						{
-							Ports: []crdv1alpha1.NetworkPolicyPort{
+							Ports: []crdv1beta1.NetworkPolicyPort{
 								{
 									Port: &int81,
 								},
 							},
-							From: []crdv1alpha1.NetworkPolicyPeer{
+							From: []crdv1beta1.NetworkPolicyPeer{
 								{
 									NamespaceSelector: &selectorC,
 								},
@@ -307,7 +315,7 @@ func TestProcessClusterNetworkPolicy(t *testing.T) {
 							},
 						},
 						Priority: 0,
-						Action:   &allowAction,
+						Action:   &cpAllowAction,
 					},
 					{
 						Direction: controlplane.DirectionIn,
@@ -321,7 +329,7 @@ func TestProcessClusterNetworkPolicy(t *testing.T) {
 							},
 						},
 						Priority: 1,
-						Action:   &allowAction,
+						Action:   &cpAllowAction,
 					},
 				},
 				AppliedToGroups: []string{getNormalizedUID(toGroupSelector("", &selectorA, nil, nil).NormalizedName)},
@@ -331,23 +339,23 @@ func TestProcessClusterNetworkPolicy(t *testing.T) {
 		},
 		{
 			name: "with-port-range",
-			inputPolicy: &crdv1alpha1.ClusterNetworkPolicy{
+			inputPolicy: &crdv1beta1.ClusterNetworkPolicy{
 				ObjectMeta: metav1.ObjectMeta{Namespace: "", Name: "cnpD", UID: "uidD"},
-				Spec: crdv1alpha1.ClusterNetworkPolicySpec{
-					AppliedTo: []crdv1alpha1.NetworkPolicyPeer{
+				Spec: crdv1beta1.ClusterNetworkPolicySpec{
+					AppliedTo: []crdv1beta1.NetworkPolicyPeer{
 						{PodSelector: &selectorA},
 					},
 					Priority: p10,
-					Ingress: []crdv1alpha1.Rule{
+					Ingress: []crdv1beta1.Rule{
 						{
-							Ports: []crdv1alpha1.NetworkPolicyPort{
+							Ports: []crdv1beta1.NetworkPolicyPort{
 								{
 									Protocol: &k8sProtocolTCP,
 									Port:     &int1000,
 									EndPort:  &int32For1999,
 								},
 							},
-							From: []crdv1alpha1.NetworkPolicyPeer{
+							From: []crdv1beta1.NetworkPolicyPeer{
 								{
 									PodSelector: &selectorB,
 								},
@@ -381,7 +389,7 @@ func TestProcessClusterNetworkPolicy(t *testing.T) {
 							},
 						},
 						Priority: 0,
-						Action:   &allowAction,
+						Action:   &cpAllowAction,
 					},
 				},
 				AppliedToGroups: []string{getNormalizedUID(toGroupSelector("", &selectorA, nil, nil).NormalizedName)},
@@ -391,24 +399,24 @@ func TestProcessClusterNetworkPolicy(t *testing.T) {
 		},
 		{
 			name: "appliedTo-per-rule",
-			inputPolicy: &crdv1alpha1.ClusterNetworkPolicy{
+			inputPolicy: &crdv1beta1.ClusterNetworkPolicy{
 				ObjectMeta: metav1.ObjectMeta{Name: "cnpH", UID: "uidH"},
-				Spec: crdv1alpha1.ClusterNetworkPolicySpec{
+				Spec: crdv1beta1.ClusterNetworkPolicySpec{
 					AppliedTo: nil,
 					Priority:  p10,
-					Ingress: []crdv1alpha1.Rule{
+					Ingress: []crdv1beta1.Rule{
 						{
-							AppliedTo: []crdv1alpha1.NetworkPolicyPeer{
+							AppliedTo: []crdv1beta1.NetworkPolicyPeer{
 								{
 									PodSelector: &selectorA,
 								},
 							},
-							Ports: []crdv1alpha1.NetworkPolicyPort{
+							Ports: []crdv1beta1.NetworkPolicyPort{
 								{
 									Port: &int80,
 								},
 							},
-							From: []crdv1alpha1.NetworkPolicyPeer{
+							From: []crdv1beta1.NetworkPolicyPeer{
 								{
 									PodSelector: &selectorB,
 								},
@@ -416,18 +424,18 @@ func TestProcessClusterNetworkPolicy(t *testing.T) {
 							Action: &allowAction,
 						},
 						{
-							AppliedTo: []crdv1alpha1.NetworkPolicyPeer{
+							AppliedTo: []crdv1beta1.NetworkPolicyPeer{
 								{
 									PodSelector:       &selectorB,
 									NamespaceSelector: &selectorC,
 								},
 							},
-							Ports: []crdv1alpha1.NetworkPolicyPort{
+							Ports: []crdv1beta1.NetworkPolicyPort{
 								{
 									Port: &int81,
 								},
 							},
-							From: []crdv1alpha1.NetworkPolicyPeer{
+							From: []crdv1beta1.NetworkPolicyPeer{
 								{
 									NamespaceSelector: &selectorC,
 								},
@@ -461,7 +469,7 @@ func TestProcessClusterNetworkPolicy(t *testing.T) {
 							},
 						},
 						Priority: 0,
-						Action:   &allowAction,
+						Action:   &cpAllowAction,
 					},
 					{
 						Direction:       controlplane.DirectionIn,
@@ -476,7 +484,7 @@ func TestProcessClusterNetworkPolicy(t *testing.T) {
 							},
 						},
 						Priority: 1,
-						Action:   &allowAction,
+						Action:   &cpAllowAction,
 					},
 				},
 				AppliedToGroups: []string{
@@ -490,21 +498,21 @@ func TestProcessClusterNetworkPolicy(t *testing.T) {
 		},
 		{
 			name: "with-cluster-group-ingress-egress",
-			inputPolicy: &crdv1alpha1.ClusterNetworkPolicy{
+			inputPolicy: &crdv1beta1.ClusterNetworkPolicy{
 				ObjectMeta: metav1.ObjectMeta{Name: "cnpI", UID: "uidI"},
-				Spec: crdv1alpha1.ClusterNetworkPolicySpec{
-					AppliedTo: []crdv1alpha1.NetworkPolicyPeer{
+				Spec: crdv1beta1.ClusterNetworkPolicySpec{
+					AppliedTo: []crdv1beta1.NetworkPolicyPeer{
 						{PodSelector: &selectorA},
 					},
 					Priority: p10,
-					Ingress: []crdv1alpha1.Rule{
+					Ingress: []crdv1beta1.Rule{
 						{
-							Ports: []crdv1alpha1.NetworkPolicyPort{
+							Ports: []crdv1beta1.NetworkPolicyPort{
 								{
 									Port: &int80,
 								},
 							},
-							From: []crdv1alpha1.NetworkPolicyPeer{
+							From: []crdv1beta1.NetworkPolicyPeer{
 								{
 									Group: cgA.Name,
 								},
@@ -512,14 +520,14 @@ func TestProcessClusterNetworkPolicy(t *testing.T) {
 							Action: &allowAction,
 						},
 					},
-					Egress: []crdv1alpha1.Rule{
+					Egress: []crdv1beta1.Rule{
 						{
-							Ports: []crdv1alpha1.NetworkPolicyPort{
+							Ports: []crdv1beta1.NetworkPolicyPort{
 								{
 									Port: &int81,
 								},
 							},
-							To: []crdv1alpha1.NetworkPolicyPeer{
+							To: []crdv1beta1.NetworkPolicyPeer{
 								{
 									Group: cgA.Name,
 								},
@@ -552,7 +560,7 @@ func TestProcessClusterNetworkPolicy(t *testing.T) {
 							},
 						},
 						Priority: 0,
-						Action:   &allowAction,
+						Action:   &cpAllowAction,
 					},
 					{
 						Direction: controlplane.DirectionOut,
@@ -566,7 +574,7 @@ func TestProcessClusterNetworkPolicy(t *testing.T) {
 							},
 						},
 						Priority: 0,
-						Action:   &allowAction,
+						Action:   &cpAllowAction,
 					},
 				},
 				AppliedToGroups: []string{getNormalizedUID(toGroupSelector("", &selectorA, nil, nil).NormalizedName)},
@@ -576,23 +584,23 @@ func TestProcessClusterNetworkPolicy(t *testing.T) {
 		},
 		{
 			name: "with-applied-to-cluster-group-ingress-egress",
-			inputPolicy: &crdv1alpha1.ClusterNetworkPolicy{
+			inputPolicy: &crdv1beta1.ClusterNetworkPolicy{
 				ObjectMeta: metav1.ObjectMeta{Name: "cnpI", UID: "uidI"},
-				Spec: crdv1alpha1.ClusterNetworkPolicySpec{
+				Spec: crdv1beta1.ClusterNetworkPolicySpec{
 					Priority: p10,
-					Ingress: []crdv1alpha1.Rule{
+					Ingress: []crdv1beta1.Rule{
 						{
-							Ports: []crdv1alpha1.NetworkPolicyPort{
+							Ports: []crdv1beta1.NetworkPolicyPort{
 								{
 									Port: &int80,
 								},
 							},
-							From: []crdv1alpha1.NetworkPolicyPeer{
+							From: []crdv1beta1.NetworkPolicyPeer{
 								{
 									PodSelector: &selectorB,
 								},
 							},
-							AppliedTo: []crdv1alpha1.NetworkPolicyPeer{
+							AppliedTo: []crdv1beta1.NetworkPolicyPeer{
 								{
 									Group: cgA.Name,
 								},
@@ -600,19 +608,19 @@ func TestProcessClusterNetworkPolicy(t *testing.T) {
 							Action: &allowAction,
 						},
 					},
-					Egress: []crdv1alpha1.Rule{
+					Egress: []crdv1beta1.Rule{
 						{
-							Ports: []crdv1alpha1.NetworkPolicyPort{
+							Ports: []crdv1beta1.NetworkPolicyPort{
 								{
 									Port: &int81,
 								},
 							},
-							To: []crdv1alpha1.NetworkPolicyPeer{
+							To: []crdv1beta1.NetworkPolicyPeer{
 								{
 									PodSelector: &selectorB,
 								},
 							},
-							AppliedTo: []crdv1alpha1.NetworkPolicyPeer{
+							AppliedTo: []crdv1beta1.NetworkPolicyPeer{
 								{
 									Group: cgA.Name,
 								},
@@ -646,7 +654,7 @@ func TestProcessClusterNetworkPolicy(t *testing.T) {
 							},
 						},
 						Priority: 0,
-						Action:   &allowAction,
+						Action:   &cpAllowAction,
 					},
 					{
 						Direction:       controlplane.DirectionOut,
@@ -661,7 +669,7 @@ func TestProcessClusterNetworkPolicy(t *testing.T) {
 							},
 						},
 						Priority: 0,
-						Action:   &allowAction,
+						Action:   &cpAllowAction,
 					},
 				},
 				AppliedToGroups:  []string{cgA.Name},
@@ -672,38 +680,38 @@ func TestProcessClusterNetworkPolicy(t *testing.T) {
 		},
 		{
 			name: "with-per-namespace-rule",
-			inputPolicy: &crdv1alpha1.ClusterNetworkPolicy{
+			inputPolicy: &crdv1beta1.ClusterNetworkPolicy{
 				ObjectMeta: metav1.ObjectMeta{Name: "cnpI", UID: "uidI"},
-				Spec: crdv1alpha1.ClusterNetworkPolicySpec{
-					AppliedTo: []crdv1alpha1.NetworkPolicyPeer{
+				Spec: crdv1beta1.ClusterNetworkPolicySpec{
+					AppliedTo: []crdv1beta1.NetworkPolicyPeer{
 						{
 							NamespaceSelector: &metav1.LabelSelector{},
 						},
 					},
 					Priority: p10,
-					Ingress: []crdv1alpha1.Rule{
+					Ingress: []crdv1beta1.Rule{
 						{
-							Ports: []crdv1alpha1.NetworkPolicyPort{
+							Ports: []crdv1beta1.NetworkPolicyPort{
 								{
 									Port: &int80,
 								},
 							},
-							From: []crdv1alpha1.NetworkPolicyPeer{
+							From: []crdv1beta1.NetworkPolicyPeer{
 								{
-									Namespaces: &crdv1alpha1.PeerNamespaces{
-										Match: crdv1alpha1.NamespaceMatchSelf,
+									Namespaces: &crdv1beta1.PeerNamespaces{
+										Match: crdv1beta1.NamespaceMatchSelf,
 									},
 								},
 							},
 							Action: &allowAction,
 						},
 						{
-							Ports: []crdv1alpha1.NetworkPolicyPort{
+							Ports: []crdv1beta1.NetworkPolicyPort{
 								{
 									Port: &int81,
 								},
 							},
-							From: []crdv1alpha1.NetworkPolicyPeer{
+							From: []crdv1beta1.NetworkPolicyPeer{
 								{
 									NamespaceSelector: &selectorA,
 								},
@@ -737,7 +745,7 @@ func TestProcessClusterNetworkPolicy(t *testing.T) {
 							},
 						},
 						Priority: 0,
-						Action:   &allowAction,
+						Action:   &cpAllowAction,
 					},
 					{
 						Direction:       controlplane.DirectionIn,
@@ -752,7 +760,7 @@ func TestProcessClusterNetworkPolicy(t *testing.T) {
 							},
 						},
 						Priority: 0,
-						Action:   &allowAction,
+						Action:   &cpAllowAction,
 					},
 					{
 						Direction:       controlplane.DirectionIn,
@@ -767,7 +775,7 @@ func TestProcessClusterNetworkPolicy(t *testing.T) {
 							},
 						},
 						Priority: 1,
-						Action:   &allowAction,
+						Action:   &cpAllowAction,
 					},
 				},
 				AppliedToGroups: []string{
@@ -783,27 +791,27 @@ func TestProcessClusterNetworkPolicy(t *testing.T) {
 		},
 		{
 			name: "with-per-namespace-rule-applied-to-per-rule",
-			inputPolicy: &crdv1alpha1.ClusterNetworkPolicy{
+			inputPolicy: &crdv1beta1.ClusterNetworkPolicy{
 				ObjectMeta: metav1.ObjectMeta{Name: "cnpJ", UID: "uidJ"},
-				Spec: crdv1alpha1.ClusterNetworkPolicySpec{
+				Spec: crdv1beta1.ClusterNetworkPolicySpec{
 					Priority: p10,
-					Ingress: []crdv1alpha1.Rule{
+					Ingress: []crdv1beta1.Rule{
 						{
-							AppliedTo: []crdv1alpha1.NetworkPolicyPeer{
+							AppliedTo: []crdv1beta1.NetworkPolicyPeer{
 								{
 									NamespaceSelector: &selectorA,
 									PodSelector:       &selectorA,
 								},
 							},
-							Ports: []crdv1alpha1.NetworkPolicyPort{
+							Ports: []crdv1beta1.NetworkPolicyPort{
 								{
 									Port: &int80,
 								},
 							},
-							From: []crdv1alpha1.NetworkPolicyPeer{
+							From: []crdv1beta1.NetworkPolicyPeer{
 								{
-									Namespaces: &crdv1alpha1.PeerNamespaces{
-										Match: crdv1alpha1.NamespaceMatchSelf,
+									Namespaces: &crdv1beta1.PeerNamespaces{
+										Match: crdv1beta1.NamespaceMatchSelf,
 									},
 									PodSelector: &selectorA,
 								},
@@ -811,20 +819,20 @@ func TestProcessClusterNetworkPolicy(t *testing.T) {
 							Action: &dropAction,
 						},
 						{
-							AppliedTo: []crdv1alpha1.NetworkPolicyPeer{
+							AppliedTo: []crdv1beta1.NetworkPolicyPeer{
 								{
 									NamespaceSelector: &selectorB,
 								},
 							},
-							Ports: []crdv1alpha1.NetworkPolicyPort{
+							Ports: []crdv1beta1.NetworkPolicyPort{
 								{
 									Port: &int81,
 								},
 							},
-							From: []crdv1alpha1.NetworkPolicyPeer{
+							From: []crdv1beta1.NetworkPolicyPeer{
 								{
-									Namespaces: &crdv1alpha1.PeerNamespaces{
-										Match: crdv1alpha1.NamespaceMatchSelf,
+									Namespaces: &crdv1beta1.PeerNamespaces{
+										Match: crdv1beta1.NamespaceMatchSelf,
 									},
 								},
 							},
@@ -857,7 +865,7 @@ func TestProcessClusterNetworkPolicy(t *testing.T) {
 							},
 						},
 						Priority: 0,
-						Action:   &dropAction,
+						Action:   &cpDropAction,
 					},
 					{
 						Direction:       controlplane.DirectionIn,
@@ -872,7 +880,7 @@ func TestProcessClusterNetworkPolicy(t *testing.T) {
 							},
 						},
 						Priority: 1,
-						Action:   &dropAction,
+						Action:   &cpDropAction,
 					},
 				},
 				AppliedToGroups: []string{
@@ -885,6 +893,73 @@ func TestProcessClusterNetworkPolicy(t *testing.T) {
 			expectedAppliedToGroups: 2,
 			expectedAddressGroups:   2,
 		},
+		{
+			name: "with-layer2-rules",
+			inputPolicy: &crdv1beta1.ClusterNetworkPolicy{
+				ObjectMeta: metav1.ObjectMeta{Name: "cnpL2", UID: "uidL2"},
+				Spec: crdv1beta1.ClusterNetworkPolicySpec{
+					AppliedTo: []crdv1beta1.NetworkPolicyPeer{
+						{PodSelector: &selectorA},
+					},
+					Priority: p10,
+					Layer2: []crdv1beta1.Layer2Rule{
+						{
+							Protocol: crdv1beta1.Layer2ProtocolARP,
+							To: []crdv1beta1.NetworkPolicyPeer{
+								{PodSelector: &selectorB},
+							},
+							Action: &dropAction,
+						},
+						{
+							Protocol: crdv1beta1.Layer2ProtocolICMPv6ND,
+							NDType:   &ndTypeNS,
+							Action:   &allowAction,
+						},
+					},
+				},
+			},
+			expectedPolicy: &antreatypes.NetworkPolicy{
+				UID:  "uidL2",
+				Name: "uidL2",
+				SourceRef: &controlplane.NetworkPolicyReference{
+					Type: controlplane.AntreaClusterNetworkPolicy,
+					Name: "cnpL2",
+					UID:  "uidL2",
+				},
+				Priority:     &p10,
+				TierPriority: &DefaultTierPriority,
+				Rules: []controlplane.NetworkPolicyRule{
+					{
+						Direction: controlplane.DirectionOut,
+						To: controlplane.NetworkPolicyPeer{
+							AddressGroups: []string{getNormalizedUID(toGroupSelector("", &selectorB, nil, nil).NormalizedName)},
+						},
+						Services: []controlplane.Service{
+							{
+								Protocol: &protocolARP,
+							},
+						},
+						Priority: 0,
+						Action:   &cpDropAction,
+					},
+					{
+						Direction: controlplane.DirectionOut,
+						To:        matchAllPeer,
+						Services: []controlplane.Service{
+							{
+								Protocol: &protocolICMPv6,
+								ICMPType: &icmpTypeNS,
+							},
+						},
+						Priority: 1,
+						Action:   &cpAllowAction,
+					},
+				},
+				AppliedToGroups: []string{getNormalizedUID(toGroupSelector("", &selectorA, nil, nil).NormalizedName)},
+			},
+			expectedAppliedToGroups: 1,
+			expectedAddressGroups:   1,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -926,7 +1001,8 @@ func TestAddCNP(t *testing.T) {
 			Priority: DefaultTierPriority,
 		},
 	}
-	allowAction := crdv1alpha1.RuleActionAllow
+	allowAction := crdv1beta1.RuleActionAllow
+	cpAllowAction := crdv1alpha1.RuleActionAllow
 	protocolTCP := controlplane.ProtocolTCP
 	selectorA := metav1.LabelSelector{MatchLabels: map[string]string{"foo1": "bar1"}}
 	selectorB := metav1.LabelSelector{MatchLabels: map[string]string{"foo2": "bar2"}}
@@ -936,29 +1012,29 @@ func TestAddCNP(t *testing.T) {
 	matchAllPeerEgress.AddressGroups = []string{getNormalizedUID(toGroupSelector("", nil, &selectorAll, nil).NormalizedName)}
 	tests := []struct {
 		name               string
-		inputPolicy        *crdv1alpha1.ClusterNetworkPolicy
+		inputPolicy        *crdv1beta1.ClusterNetworkPolicy
 		expPolicy          *antreatypes.NetworkPolicy
 		expAppliedToGroups int
 		expAddressGroups   int
 	}{
 		{
 			name: "application-tier-policy",
-			inputPolicy: &crdv1alpha1.ClusterNetworkPolicy{
+			inputPolicy: &crdv1beta1.ClusterNetworkPolicy{
 				ObjectMeta: metav1.ObjectMeta{Name: "cnpA", UID: "uidA"},
-				Spec: crdv1alpha1.ClusterNetworkPolicySpec{
-					AppliedTo: []crdv1alpha1.NetworkPolicyPeer{
+				Spec: crdv1beta1.ClusterNetworkPolicySpec{
+					AppliedTo: []crdv1beta1.NetworkPolicyPeer{
 						{PodSelector: &selectorA},
 					},
 					Priority: p10,
 					Tier:     "application",
-					Ingress: []crdv1alpha1.Rule{
+					Ingress: []crdv1beta1.Rule{
 						{
-							Ports: []crdv1alpha1.NetworkPolicyPort{
+							Ports: []crdv1beta1.NetworkPolicyPort{
 								{
 									Port: &int80,
 								},
 							},
-							From: []crdv1alpha1.NetworkPolicyPeer{
+							From: []crdv1beta1.NetworkPolicyPeer{
 								{
 									PodSelector:       &selectorB,
 									NamespaceSelector: &selectorC,
@@ -992,7 +1068,7 @@ func TestAddCNP(t *testing.T) {
 							},
 						},
 						Priority: 0,
-						Action:   &allowAction,
+						Action:   &cpAllowAction,
 					},
 				},
 				AppliedToGroups: []string{getNormalizedUID(toGroupSelector("", &selectorA, nil, nil).NormalizedName)},
@@ -1002,21 +1078,21 @@ func TestAddCNP(t *testing.T) {
 		},
 		{
 			name: "empty-tier-policy",
-			inputPolicy: &crdv1alpha1.ClusterNetworkPolicy{
+			inputPolicy: &crdv1beta1.ClusterNetworkPolicy{
 				ObjectMeta: metav1.ObjectMeta{Name: "cnpB", UID: "uidB"},
-				Spec: crdv1alpha1.ClusterNetworkPolicySpec{
-					AppliedTo: []crdv1alpha1.NetworkPolicyPeer{
+				Spec: crdv1beta1.ClusterNetworkPolicySpec{
+					AppliedTo: []crdv1beta1.NetworkPolicyPeer{
 						{PodSelector: &selectorA},
 					},
 					Priority: p10,
-					Ingress: []crdv1alpha1.Rule{
+					Ingress: []crdv1beta1.Rule{
 						{
-							Ports: []crdv1alpha1.NetworkPolicyPort{
+							Ports: []crdv1beta1.NetworkPolicyPort{
 								{
 									Port: &int80,
 								},
 							},
-							From: []crdv1alpha1.NetworkPolicyPeer{
+							From: []crdv1beta1.NetworkPolicyPeer{
 								{
 									PodSelector:       &selectorB,
 									NamespaceSelector: &selectorC,
@@ -1050,7 +1126,7 @@ func TestAddCNP(t *testing.T) {
 							},
 						},
 						Priority: 0,
-						Action:   &allowAction,
+						Action:   &cpAllowAction,
 					},
 				},
 				AppliedToGroups: []string{getNormalizedUID(toGroupSelector("", &selectorA, nil, nil).NormalizedName)},
@@ -1060,22 +1136,22 @@ func TestAddCNP(t *testing.T) {
 		},
 		{
 			name: "emergency-tier-policy",
-			inputPolicy: &crdv1alpha1.ClusterNetworkPolicy{
+			inputPolicy: &crdv1beta1.ClusterNetworkPolicy{
 				ObjectMeta: metav1.ObjectMeta{Name: "cnpD", UID: "uidD"},
-				Spec: crdv1alpha1.ClusterNetworkPolicySpec{
-					AppliedTo: []crdv1alpha1.NetworkPolicyPeer{
+				Spec: crdv1beta1.ClusterNetworkPolicySpec{
+					AppliedTo: []crdv1beta1.NetworkPolicyPeer{
 						{PodSelector: &selectorA},
 					},
 					Priority: p10,
 					Tier:     "emergency",
-					Ingress: []crdv1alpha1.Rule{
+					Ingress: []crdv1beta1.Rule{
 						{
-							Ports: []crdv1alpha1.NetworkPolicyPort{
+							Ports: []crdv1beta1.NetworkPolicyPort{
 								{
 									Port: &int80,
 								},
 							},
-							From: []crdv1alpha1.NetworkPolicyPeer{
+							From: []crdv1beta1.NetworkPolicyPeer{
 								{
 									PodSelector:       &selectorB,
 									NamespaceSelector: &selectorC,
@@ -1109,7 +1185,7 @@ func TestAddCNP(t *testing.T) {
 							},
 						},
 						Priority: 0,
-						Action:   &allowAction,
+						Action:   &cpAllowAction,
 					},
 				},
 				AppliedToGroups: []string{getNormalizedUID(toGroupSelector("", &selectorA, nil, nil).NormalizedName)},
@@ -1119,22 +1195,22 @@ func TestAddCNP(t *testing.T) {
 		},
 		{
 			name: "static-tier-policy",
-			inputPolicy: &crdv1alpha1.ClusterNetworkPolicy{
+			inputPolicy: &crdv1beta1.ClusterNetworkPolicy{
 				ObjectMeta: metav1.ObjectMeta{Name: "cnpE", UID: "uidE"},
-				Spec: crdv1alpha1.ClusterNetworkPolicySpec{
-					AppliedTo: []crdv1alpha1.NetworkPolicyPeer{
+				Spec: crdv1beta1.ClusterNetworkPolicySpec{
+					AppliedTo: []crdv1beta1.NetworkPolicyPeer{
 						{PodSelector: &selectorA},
 					},
 					Priority: p10,
 					Tier:     "Emergency",
-					Ingress: []crdv1alpha1.Rule{
+					Ingress: []crdv1beta1.Rule{
 						{
-							Ports: []crdv1alpha1.NetworkPolicyPort{
+							Ports: []crdv1beta1.NetworkPolicyPort{
 								{
 									Port: &int80,
 								},
 							},
-							From: []crdv1alpha1.NetworkPolicyPeer{
+							From: []crdv1beta1.NetworkPolicyPeer{
 								{
 									PodSelector:       &selectorB,
 									NamespaceSelector: &selectorC,
@@ -1168,7 +1244,7 @@ func TestAddCNP(t *testing.T) {
 							},
 						},
 						Priority: 0,
-						Action:   &allowAction,
+						Action:   &cpAllowAction,
 					},
 				},
 				AppliedToGroups: []string{getNormalizedUID(toGroupSelector("", &selectorA, nil, nil).NormalizedName)},
@@ -1178,21 +1254,21 @@ func TestAddCNP(t *testing.T) {
 		},
 		{
 			name: "rules-with-same-selectors",
-			inputPolicy: &crdv1alpha1.ClusterNetworkPolicy{
+			inputPolicy: &crdv1beta1.ClusterNetworkPolicy{
 				ObjectMeta: metav1.ObjectMeta{Name: "cnpF", UID: "uidF"},
-				Spec: crdv1alpha1.ClusterNetworkPolicySpec{
-					AppliedTo: []crdv1alpha1.NetworkPolicyPeer{
+				Spec: crdv1beta1.ClusterNetworkPolicySpec{
+					AppliedTo: []crdv1beta1.NetworkPolicyPeer{
 						{PodSelector: &selectorA},
 					},
 					Priority: p10,
-					Ingress: []crdv1alpha1.Rule{
+					Ingress: []crdv1beta1.Rule{
 						{
-							Ports: []crdv1alpha1.NetworkPolicyPort{
+							Ports: []crdv1beta1.NetworkPolicyPort{
 								{
 									Port: &int80,
 								},
 							},
-							From: []crdv1alpha1.NetworkPolicyPeer{
+							From: []crdv1beta1.NetworkPolicyPeer{
 								{
 									PodSelector:       &selectorB,
 									NamespaceSelector: &selectorC,
@@ -1201,14 +1277,14 @@ func TestAddCNP(t *testing.T) {
 							Action: &allowAction,
 						},
 					},
-					Egress: []crdv1alpha1.Rule{
+					Egress: []crdv1beta1.Rule{
 						{
-							Ports: []crdv1alpha1.NetworkPolicyPort{
+							Ports: []crdv1beta1.NetworkPolicyPort{
 								{
 									Port: &int81,
 								},
 							},
-							To: []crdv1alpha1.NetworkPolicyPeer{
+							To: []crdv1beta1.NetworkPolicyPeer{
 								{
 									PodSelector:       &selectorB,
 									NamespaceSelector: &selectorC,
@@ -1242,7 +1318,7 @@ func TestAddCNP(t *testing.T) {
 							},
 						},
 						Priority: 0,
-						Action:   &allowAction,
+						Action:   &cpAllowAction,
 					},
 					{
 						Direction: controlplane.DirectionOut,
@@ -1256,7 +1332,7 @@ func TestAddCNP(t *testing.T) {
 							},
 						},
 						Priority: 0,
-						Action:   &allowAction,
+						Action:   &cpAllowAction,
 					},
 				},
 				AppliedToGroups: []string{getNormalizedUID(toGroupSelector("", &selectorA, nil, nil).NormalizedName)},
@@ -1266,21 +1342,21 @@ func TestAddCNP(t *testing.T) {
 		},
 		{
 			name: "rules-with-different-selectors",
-			inputPolicy: &crdv1alpha1.ClusterNetworkPolicy{
+			inputPolicy: &crdv1beta1.ClusterNetworkPolicy{
 				ObjectMeta: metav1.ObjectMeta{Name: "cnpG", UID: "uidG"},
-				Spec: crdv1alpha1.ClusterNetworkPolicySpec{
-					AppliedTo: []crdv1alpha1.NetworkPolicyPeer{
+				Spec: crdv1beta1.ClusterNetworkPolicySpec{
+					AppliedTo: []crdv1beta1.NetworkPolicyPeer{
 						{PodSelector: &selectorA},
 					},
 					Priority: p10,
-					Ingress: []crdv1alpha1.Rule{
+					Ingress: []crdv1beta1.Rule{
 						{
-							Ports: []crdv1alpha1.NetworkPolicyPort{
+							Ports: []crdv1beta1.NetworkPolicyPort{
 								{
 									Port: &int80,
 								},
 							},
-							From: []crdv1alpha1.NetworkPolicyPeer{
+							From: []crdv1beta1.NetworkPolicyPeer{
 								{
 									PodSelector: &selectorB,
 								},
@@ -1288,12 +1364,12 @@ func TestAddCNP(t *testing.T) {
 							Action: &allowAction,
 						},
 						{
-							Ports: []crdv1alpha1.NetworkPolicyPort{
+							Ports: []crdv1beta1.NetworkPolicyPort{
 								{
 									Port: &int81,
 								},
 							},
-							From: []crdv1alpha1.NetworkPolicyPeer{
+							From: []crdv1beta1.NetworkPolicyPeer{
 								{
 									NamespaceSelector: &selectorC,
 								},
@@ -1326,7 +1402,7 @@ func TestAddCNP(t *testing.T) {
 							},
 						},
 						Priority: 0,
-						Action:   &allowAction,
+						Action:   &cpAllowAction,
 					},
 					{
 						Direction: controlplane.DirectionIn,
@@ -1340,7 +1416,7 @@ func TestAddCNP(t *testing.T) {
 							},
 						},
 						Priority: 1,
-						Action:   &allowAction,
+						Action:   &cpAllowAction,
 					},
 				},
 				AppliedToGroups: []string{getNormalizedUID(toGroupSelector("", &selectorA, nil, nil).NormalizedName)},
@@ -1350,23 +1426,23 @@ func TestAddCNP(t *testing.T) {
 		},
 		{
 			name: "with-port-range",
-			inputPolicy: &crdv1alpha1.ClusterNetworkPolicy{
+			inputPolicy: &crdv1beta1.ClusterNetworkPolicy{
 				ObjectMeta: metav1.ObjectMeta{Namespace: "", Name: "cnpH", UID: "uidH"},
-				Spec: crdv1alpha1.ClusterNetworkPolicySpec{
-					AppliedTo: []crdv1alpha1.NetworkPolicyPeer{
+				Spec: crdv1beta1.ClusterNetworkPolicySpec{
+					AppliedTo: []crdv1beta1.NetworkPolicyPeer{
 						{PodSelector: &selectorA},
 					},
 					Priority: p10,
-					Ingress: []crdv1alpha1.Rule{
+					Ingress: []crdv1beta1.Rule{
 						{
-							Ports: []crdv1alpha1.NetworkPolicyPort{
+							Ports: []crdv1beta1.NetworkPolicyPort{
 								{
 									Protocol: &k8sProtocolTCP,
 									Port:     &int1000,
 									EndPort:  &int32For1999,
 								},
 							},
-							From: []crdv1alpha1.NetworkPolicyPeer{
+							From: []crdv1beta1.NetworkPolicyPeer{
 								{
 									PodSelector: &selectorB,
 								},
@@ -1400,7 +1476,7 @@ func TestAddCNP(t *testing.T) {
 							},
 						},
 						Priority: 0,
-						Action:   &allowAction,
+						Action:   &cpAllowAction,
 					},
 				},
 				AppliedToGroups: []string{getNormalizedUID(toGroupSelector("", &selectorA, nil, nil).NormalizedName)},
@@ -1418,6 +1494,7 @@ func TestAddCNP(t *testing.T) {
 			key := internalNetworkPolicyKeyFunc(tt.inputPolicy)
 			actualPolicyObj, _, _ := npc.internalNetworkPolicyStore.Get(key)
 			actualPolicy := actualPolicyObj.(*antreatypes.NetworkPolicy)
+			sortRules(tt.expPolicy.Rules)
 			assert.Equal(t, tt.expPolicy, actualPolicy)
 			assert.Equal(t, tt.expAddressGroups, len(npc.addressGroupStore.List()))
 			assert.Equal(t, tt.expAppliedToGroups, len(npc.appliedToGroupStore.List()))
@@ -1486,6 +1563,55 @@ func TestGetTierPriority(t *testing.T) {
 	}
 }
 
+func TestTierDefaultPolicy(t *testing.T) {
+	dropAction := crdv1alpha1.RuleActionDrop
+	tier := &crdv1alpha1.Tier{
+		ObjectMeta: metav1.ObjectMeta{Name: "tA", UID: "uidA"},
+		Spec: crdv1alpha1.TierSpec{
+			Priority:      int32(100),
+			DefaultAction: &dropAction,
+			EnableLogging: true,
+		},
+	}
+	_, npc := newController()
+	npc.tierStore.Add(tier)
+	npc.addTierDefaultPolicy(tier)
+	key := tierDefaultPolicyPrefix + "uidA"
+	obj, found, _ := npc.internalNetworkPolicyStore.Get(key)
+	require.True(t, found, "expected internal NetworkPolicy for the default action of the Tier")
+	policy := obj.(*antreatypes.NetworkPolicy)
+	assert.Equal(t, tierDefaultPolicyPrefix+"tA", policy.SourceRef.Name)
+	assert.Equal(t, int32(100), *policy.TierPriority)
+	assert.Equal(t, tierDefaultPolicyPriority, *policy.Priority)
+	require.Len(t, policy.Rules, 2)
+	for _, rule := range policy.Rules {
+		assert.Equal(t, dropAction, *rule.Action)
+		assert.True(t, rule.EnableLogging)
+		if rule.Direction == controlplane.DirectionIn {
+			assert.Equal(t, matchAllPeer, rule.From)
+		} else {
+			assert.Equal(t, matchAllPeer, rule.To)
+		}
+	}
+
+	// A change of the Tier priority is reflected in the policy.
+	updatedTier := tier.DeepCopy()
+	updatedTier.Spec.Priority = int32(110)
+	npc.tierStore.Update(updatedTier)
+	npc.updateTierDefaultPolicy(tier, updatedTier)
+	obj, found, _ = npc.internalNetworkPolicyStore.Get(key)
+	require.True(t, found, "expected internal NetworkPolicy for the default action of the Tier")
+	assert.Equal(t, int32(110), *obj.(*antreatypes.NetworkPolicy).TierPriority)
+
+	// Unsetting the default action deletes the policy.
+	noActionTier := updatedTier.DeepCopy()
+	noActionTier.Spec.DefaultAction = nil
+	npc.tierStore.Update(noActionTier)
+	npc.updateTierDefaultPolicy(updatedTier, noActionTier)
+	_, found, _ = npc.internalNetworkPolicyStore.Get(key)
+	assert.False(t, found, "expected internal NetworkPolicy to be deleted")
+}
+
 func TestProcessRefCG(t *testing.T) {
 	selectorA := metav1.LabelSelector{MatchLabels: map[string]string{"foo1": "bar1"}}
 	cidr := "10.0.0.0/24"
@@ -1501,7 +1627,7 @@ func TestProcessRefCG(t *testing.T) {
 	cgB := crdv1alpha3.ClusterGroup{
 		ObjectMeta: metav1.ObjectMeta{Name: "cgB", UID: "uidB"},
 		Spec: crdv1alpha3.GroupSpec{
-			IPBlocks: []crdv1alpha1.IPBlock{
+			IPBlocks: []crdv1beta1.IPBlock{
 				{
 					CIDR: cidr,
 				},
@@ -1579,7 +1705,7 @@ func TestProcessAppliedToGroupsForCGs(t *testing.T) {
 	cgB := crdv1alpha3.ClusterGroup{
 		ObjectMeta: metav1.ObjectMeta{Name: "cgB", UID: "uidB"},
 		Spec: crdv1alpha3.GroupSpec{
-			IPBlocks: []crdv1alpha1.IPBlock{
+			IPBlocks: []crdv1beta1.IPBlock{
 				{
 					CIDR: cidr,
 				},
@@ -1634,15 +1760,15 @@ func TestProcessAppliedToGroupsForCGs(t *testing.T) {
 
 // util functions for testing.
 
-func getCNP() *crdv1alpha1.ClusterNetworkPolicy {
+func getCNP() *crdv1beta1.ClusterNetworkPolicy {
 	p10 := float64(10)
-	allowAction := crdv1alpha1.RuleActionAllow
+	allowAction := crdv1beta1.RuleActionAllow
 	selectorA := metav1.LabelSelector{MatchLabels: map[string]string{"foo1": "bar1"}}
 	selectorB := metav1.LabelSelector{MatchLabels: map[string]string{"foo2": "bar2"}}
 	selectorC := metav1.LabelSelector{MatchLabels: map[string]string{"foo3": "bar3"}}
-	ingressRules := []crdv1alpha1.Rule{
+	ingressRules := []crdv1beta1.Rule{
 		{
-			From: []crdv1alpha1.NetworkPolicyPeer{
+			From: []crdv1beta1.NetworkPolicyPeer{
 				{
 					NamespaceSelector: &selectorB,
 				},
@@ -1650,9 +1776,9 @@ func getCNP() *crdv1alpha1.ClusterNetworkPolicy {
 			Action: &allowAction,
 		},
 	}
-	egressRules := []crdv1alpha1.Rule{
+	egressRules := []crdv1beta1.Rule{
 		{
-			To: []crdv1alpha1.NetworkPolicyPeer{
+			To: []crdv1beta1.NetworkPolicyPeer{
 				{
 					PodSelector: &selectorC,
 				},
@@ -1660,10 +1786,10 @@ func getCNP() *crdv1alpha1.ClusterNetworkPolicy {
 			Action: &allowAction,
 		},
 	}
-	npObj := &crdv1alpha1.ClusterNetworkPolicy{
+	npObj := &crdv1beta1.ClusterNetworkPolicy{
 		ObjectMeta: metav1.ObjectMeta{Name: "test-cnp"},
-		Spec: crdv1alpha1.ClusterNetworkPolicySpec{
-			AppliedTo: []crdv1alpha1.NetworkPolicyPeer{
+		Spec: crdv1beta1.ClusterNetworkPolicySpec{
+			AppliedTo: []crdv1beta1.NetworkPolicyPeer{
 				{PodSelector: &selectorA},
 			},
 			Priority: p10,
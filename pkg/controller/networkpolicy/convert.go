@@ -19,7 +19,11 @@ import (
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/klog/v2"
+
+	crdv1alpha1 "antrea.io/antrea/pkg/apis/crd/v1alpha1"
+	crdv1beta1 "antrea.io/antrea/pkg/apis/crd/v1beta1"
 )
 
 func statusErrorWithMessage(msg string, params ...interface{}) metav1.Status {
@@ -67,3 +71,69 @@ func ConvertClusterGroupCRD(Object *unstructured.Unstructured, toVersion string)
 		Status: metav1.StatusSuccess,
 	}
 }
+
+// ConvertNetworkPolicyCRD converts an Antrea NetworkPolicy between v1alpha1 and v1beta1.
+func ConvertNetworkPolicyCRD(Object *unstructured.Unstructured, toVersion string) (*unstructured.Unstructured, metav1.Status) {
+	klog.V(2).Infof("Converting CRD for Antrea NetworkPolicy %s/%s", Object.GetNamespace(), Object.GetName())
+	v1alpha1Policy, v1beta1Policy := &crdv1alpha1.NetworkPolicy{}, &crdv1beta1.NetworkPolicy{}
+	return convertPolicyCRD(Object, toVersion, v1alpha1Policy, v1beta1Policy,
+		func() error {
+			return crdv1alpha1.Convert_v1alpha1_NetworkPolicy_To_v1beta1_NetworkPolicy(v1alpha1Policy, v1beta1Policy)
+		},
+		func() error {
+			return crdv1alpha1.Convert_v1beta1_NetworkPolicy_To_v1alpha1_NetworkPolicy(v1beta1Policy, v1alpha1Policy)
+		})
+}
+
+// ConvertClusterNetworkPolicyCRD converts an Antrea ClusterNetworkPolicy between v1alpha1 and v1beta1.
+func ConvertClusterNetworkPolicyCRD(Object *unstructured.Unstructured, toVersion string) (*unstructured.Unstructured, metav1.Status) {
+	klog.V(2).Infof("Converting CRD for Antrea ClusterNetworkPolicy %s", Object.GetName())
+	v1alpha1Policy, v1beta1Policy := &crdv1alpha1.ClusterNetworkPolicy{}, &crdv1beta1.ClusterNetworkPolicy{}
+	return convertPolicyCRD(Object, toVersion, v1alpha1Policy, v1beta1Policy,
+		func() error {
+			return crdv1alpha1.Convert_v1alpha1_ClusterNetworkPolicy_To_v1beta1_ClusterNetworkPolicy(v1alpha1Policy, v1beta1Policy)
+		},
+		func() error {
+			return crdv1alpha1.Convert_v1beta1_ClusterNetworkPolicy_To_v1alpha1_ClusterNetworkPolicy(v1beta1Policy, v1alpha1Policy)
+		})
+}
+
+// convertPolicyCRD decodes Object into the typed policy of its version, converts it to the typed
+// policy of toVersion with toV1beta1 or toV1alpha1, and encodes the result.
+func convertPolicyCRD(Object *unstructured.Unstructured, toVersion string, v1alpha1Policy, v1beta1Policy interface{}, toV1beta1, toV1alpha1 func() error) (*unstructured.Unstructured, metav1.Status) {
+	fromVersion := Object.GetAPIVersion()
+	if toVersion == fromVersion {
+		return nil, statusErrorWithMessage("conversion from a version to itself should not call the webhook: %s", toVersion)
+	}
+	var from, to interface{}
+	var convert func() error
+	switch {
+	case fromVersion == crdv1alpha1.SchemeGroupVersion.String() && toVersion == crdv1beta1.SchemeGroupVersion.String():
+		from, to, convert = v1alpha1Policy, v1beta1Policy, toV1beta1
+	case fromVersion == crdv1beta1.SchemeGroupVersion.String() && toVersion == crdv1alpha1.SchemeGroupVersion.String():
+		from, to, convert = v1beta1Policy, v1alpha1Policy, toV1alpha1
+	default:
+		return nil, statusErrorWithMessage("unexpected conversion from version %q to version %q", fromVersion, toVersion)
+	}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(Object.Object, from); err != nil {
+		return nil, statusErrorWithMessage("failed to decode %s: %v", Object.GetName(), err)
+	}
+	if err := convert(); err != nil {
+		return nil, statusErrorWithMessage("failed to convert %s: %v", Object.GetName(), err)
+	}
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(to)
+	if err != nil {
+		return nil, statusErrorWithMessage("failed to encode %s: %v", Object.GetName(), err)
+	}
+	// Status is not a pointer in the typed policies, don't add an empty one to an object
+	// without status, e.g. a policy being created.
+	if _, ok := Object.Object["status"]; !ok {
+		delete(content, "status")
+	}
+	convertedObject := &unstructured.Unstructured{Object: content}
+	convertedObject.SetAPIVersion(toVersion)
+	convertedObject.SetKind(Object.GetKind())
+	return convertedObject, metav1.Status{
+		Status: metav1.StatusSuccess,
+	}
+}
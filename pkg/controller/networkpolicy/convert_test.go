@@ -0,0 +1,105 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package networkpolicy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	crdv1alpha1 "antrea.io/antrea/pkg/apis/crd/v1alpha1"
+)
+
+func firstIngressPeer(t *testing.T, object *unstructured.Unstructured) map[string]interface{} {
+	ingress, _, _ := unstructured.NestedSlice(object.Object, "spec", "ingress")
+	require.Len(t, ingress, 1)
+	from, _, _ := unstructured.NestedSlice(ingress[0].(map[string]interface{}), "from")
+	require.Len(t, from, 1)
+	return from[0].(map[string]interface{})
+}
+
+func TestConvertNetworkPolicyCRD(t *testing.T) {
+	v1beta1Object := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "crd.antrea.io/v1beta1",
+		"kind":       "NetworkPolicy",
+		"metadata": map[string]interface{}{
+			"name":      "anp",
+			"namespace": "ns1",
+		},
+		"spec": map[string]interface{}{
+			"priority": float64(5),
+			"ingress": []interface{}{
+				map[string]interface{}{
+					"action": "Allow",
+					"from": []interface{}{
+						map[string]interface{}{
+							"ipBlock": map[string]interface{}{
+								"cidr":   "10.0.0.0/16",
+								"except": []interface{}{"10.0.1.0/24"},
+							},
+						},
+					},
+				},
+			},
+		},
+		"status": map[string]interface{}{
+			"phase": "Realized",
+			"realizationLatency": map[string]interface{}{
+				"p50Milliseconds": int64(100),
+				"p99Milliseconds": int64(300),
+			},
+		},
+	}}
+
+	v1alpha1Object, status := ConvertNetworkPolicyCRD(v1beta1Object, "crd.antrea.io/v1alpha1")
+	require.Equal(t, metav1.StatusSuccess, status.Status, status.Message)
+	assert.Equal(t, "crd.antrea.io/v1alpha1", v1alpha1Object.GetAPIVersion())
+	assert.Equal(t, "NetworkPolicy", v1alpha1Object.GetKind())
+	assert.Equal(t, `{"ipBlockExcept":{"spec.ingress[0].from[0]":["10.0.1.0/24"]}}`, v1alpha1Object.GetAnnotations()[crdv1alpha1.V1beta1FieldsAnnotation])
+	p50, _, _ := unstructured.NestedInt64(v1alpha1Object.Object, "status", "realizationLatencyP50Milliseconds")
+	assert.Equal(t, int64(100), p50)
+	_, found, _ := unstructured.NestedFieldNoCopy(firstIngressPeer(t, v1alpha1Object), "ipBlock", "except")
+	assert.False(t, found)
+
+	restored, status := ConvertNetworkPolicyCRD(v1alpha1Object, "crd.antrea.io/v1beta1")
+	require.Equal(t, metav1.StatusSuccess, status.Status, status.Message)
+	assert.Empty(t, restored.GetAnnotations())
+	except, _, _ := unstructured.NestedStringSlice(firstIngressPeer(t, restored), "ipBlock", "except")
+	assert.Equal(t, []string{"10.0.1.0/24"}, except)
+	latency, _, _ := unstructured.NestedMap(restored.Object, "status", "realizationLatency")
+	assert.Equal(t, map[string]interface{}{"p50Milliseconds": int64(100), "p99Milliseconds": int64(300)}, latency)
+}
+
+func TestConvertNetworkPolicyCRDWithoutStatus(t *testing.T) {
+	object := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "crd.antrea.io/v1alpha1",
+		"kind":       "ClusterNetworkPolicy",
+		"metadata": map[string]interface{}{
+			"name": "acnp",
+		},
+		"spec": map[string]interface{}{
+			"priority": float64(5),
+		},
+	}}
+	converted, status := ConvertClusterNetworkPolicyCRD(object, "crd.antrea.io/v1beta1")
+	require.Equal(t, metav1.StatusSuccess, status.Status, status.Message)
+	assert.NotContains(t, converted.Object, "status")
+
+	_, status = ConvertClusterNetworkPolicyCRD(object, "crd.antrea.io/v1alpha2")
+	assert.Equal(t, metav1.StatusFailure, status.Status)
+}
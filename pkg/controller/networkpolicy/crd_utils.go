@@ -22,23 +22,24 @@ import (
 	"k8s.io/klog/v2"
 
 	"antrea.io/antrea/pkg/apis/controlplane"
-	"antrea.io/antrea/pkg/apis/crd/v1alpha1"
+	"antrea.io/antrea/pkg/apis/crd/v1beta1"
+	"antrea.io/antrea/pkg/controller/grouping"
 	"antrea.io/antrea/pkg/controller/networkpolicy/store"
 	antreatypes "antrea.io/antrea/pkg/controller/types"
 )
 
 var (
-	// matchAllPodsPeerCrd is a v1alpha1.NetworkPolicyPeer matching all
+	// matchAllPodsPeerCrd is a v1beta1.NetworkPolicyPeer matching all
 	// Pods from all Namespaces.
-	matchAllPodsPeerCrd = v1alpha1.NetworkPolicyPeer{
+	matchAllPodsPeerCrd = v1beta1.NetworkPolicyPeer{
 		NamespaceSelector: &metav1.LabelSelector{},
 	}
 )
 
-// toAntreaServicesForCRD converts a slice of v1alpha1.NetworkPolicyPort
+// toAntreaServicesForCRD converts a slice of v1beta1.NetworkPolicyPort
 // objects to a slice of Antrea Service objects. A bool is returned along with
 // the Service objects to indicate whether any named port exists.
-func toAntreaServicesForCRD(npPorts []v1alpha1.NetworkPolicyPort) ([]controlplane.Service, bool) {
+func toAntreaServicesForCRD(npPorts []v1beta1.NetworkPolicyPort) ([]controlplane.Service, bool) {
 	var antreaServices []controlplane.Service
 	var namedPortExists bool
 	for _, npPort := range npPorts {
@@ -54,17 +55,57 @@ func toAntreaServicesForCRD(npPorts []v1alpha1.NetworkPolicyPort) ([]controlplan
 	return antreaServices, namedPortExists
 }
 
-// toAntreaIPBlockForCRD converts a v1alpha1.IPBlock to an Antrea IPBlock.
-func toAntreaIPBlockForCRD(ipBlock *v1alpha1.IPBlock) (*controlplane.IPBlock, error) {
+// ndTypes maps the Neighbor Discovery message types to their ICMPv6 types.
+var ndTypes = map[v1beta1.NDType]int32{
+	v1beta1.NDTypeRouterSolicitation:    133,
+	v1beta1.NDTypeRouterAdvertisement:   134,
+	v1beta1.NDTypeNeighborSolicitation:  135,
+	v1beta1.NDTypeNeighborAdvertisement: 136,
+}
+
+// toAntreaServicesForLayer2 converts the protocol of a v1beta1.Layer2Rule to
+// a slice of Antrea Service objects.
+func toAntreaServicesForLayer2(rule *v1beta1.Layer2Rule) []controlplane.Service {
+	if rule.Protocol == v1beta1.Layer2ProtocolARP {
+		protocol := controlplane.ProtocolARP
+		return []controlplane.Service{{Protocol: &protocol}}
+	}
+	protocol := controlplane.ProtocolICMPv6
+	service := controlplane.Service{Protocol: &protocol}
+	if rule.NDType != nil {
+		icmpType := ndTypes[*rule.NDType]
+		service.ICMPType = &icmpType
+	}
+	return []controlplane.Service{service}
+}
+
+// toServiceAccountPodSelector returns a Pod selector matching the Pods running with the ServiceAccount of
+// the provided name. The ServiceAccount's Namespace must be used as the Namespace of the selector.
+func toServiceAccountPodSelector(name string) *metav1.LabelSelector {
+	return &metav1.LabelSelector{
+		MatchLabels: map[string]string{grouping.ServiceAccountLabelKey: name},
+	}
+}
+
+// toAntreaIPBlockForCRD converts a v1beta1.IPBlock to an Antrea IPBlock.
+func toAntreaIPBlockForCRD(ipBlock *v1beta1.IPBlock) (*controlplane.IPBlock, error) {
 	// Convert the allowed IPBlock to networkpolicy.IPNet.
 	ipNet, err := cidrStrToIPNet(ipBlock.CIDR)
 	if err != nil {
 		return nil, err
 	}
+	exceptNets := []controlplane.IPNet{}
+	for _, exc := range ipBlock.Except {
+		// Convert the except IPBlock to networkpolicy.IPNet.
+		exceptNet, err := cidrStrToIPNet(exc)
+		if err != nil {
+			return nil, err
+		}
+		exceptNets = append(exceptNets, *exceptNet)
+	}
 	antreaIPBlock := &controlplane.IPBlock{
-		CIDR: *ipNet,
-		// secv1alpha.IPBlock does not have the Except slices.
-		Except: []controlplane.IPNet{},
+		CIDR:   *ipNet,
+		Except: exceptNets,
 	}
 	return antreaIPBlock, nil
 }
@@ -72,7 +113,7 @@ func toAntreaIPBlockForCRD(ipBlock *v1alpha1.IPBlock) (*controlplane.IPBlock, er
 // toAntreaPeerForCRD creates a Antrea controlplane NetworkPolicyPeer for crdv1alpha1 NetworkPolicyPeer.
 // It is used when peer's Namespaces are not matched by NamespaceMatchTypes, for which the controlplane
 // NetworkPolicyPeers will need to be created on a per Namespace basis.
-func (n *NetworkPolicyController) toAntreaPeerForCRD(peers []v1alpha1.NetworkPolicyPeer,
+func (n *NetworkPolicyController) toAntreaPeerForCRD(peers []v1beta1.NetworkPolicyPeer,
 	np metav1.Object, dir controlplane.Direction, namedPortExists bool) *controlplane.NetworkPolicyPeer {
 	var addressGroups []string
 	// NetworkPolicyPeer is supposed to match all addresses when it is empty and no clusterGroup is present.
@@ -94,9 +135,9 @@ func (n *NetworkPolicyController) toAntreaPeerForCRD(peers []v1alpha1.NetworkPol
 	}
 	var ipBlocks []controlplane.IPBlock
 	for _, peer := range peers {
-		// A v1alpha1.NetworkPolicyPeer will either have an IPBlock or a
+		// A v1beta1.NetworkPolicyPeer will either have an IPBlock or a
 		// podSelector and/or namespaceSelector set or a reference to the
-		// ClusterGroup.
+		// ClusterGroup or a ServiceAccount or a nodeSelector.
 		if peer.IPBlock != nil {
 			ipBlock, err := toAntreaIPBlockForCRD(peer.IPBlock)
 			if err != nil {
@@ -111,6 +152,12 @@ func (n *NetworkPolicyController) toAntreaPeerForCRD(peers []v1alpha1.NetworkPol
 			} else if len(groupIPBlocks) > 0 {
 				ipBlocks = append(ipBlocks, groupIPBlocks...)
 			}
+		} else if peer.ServiceAccount != nil {
+			normalizedUID := n.createAddressGroup(peer.ServiceAccount.Namespace, toServiceAccountPodSelector(peer.ServiceAccount.Name), nil, nil)
+			addressGroups = append(addressGroups, normalizedUID)
+		} else if peer.NodeSelector != nil {
+			normalizedUID := n.createAddressGroupForNodeSelector(peer.NodeSelector)
+			addressGroups = append(addressGroups, normalizedUID)
 		} else {
 			normalizedUID := n.createAddressGroup(np.GetNamespace(), peer.PodSelector, peer.NamespaceSelector, peer.ExternalEntitySelector)
 			addressGroups = append(addressGroups, normalizedUID)
@@ -122,7 +169,7 @@ func (n *NetworkPolicyController) toAntreaPeerForCRD(peers []v1alpha1.NetworkPol
 // toNamespacedPeerForCRD creates an Antrea controlplane NetworkPolicyPeer for crdv1alpha1 NetworkPolicyPeer
 // for a particular Namespace. It is used when a single crdv1alpha1 NetworkPolicyPeer maps to multiple
 // controlplane NetworkPolicyPeers because the appliedTo workloads reside in different Namespaces.
-func (n *NetworkPolicyController) toNamespacedPeerForCRD(peers []v1alpha1.NetworkPolicyPeer, namespace string) *controlplane.NetworkPolicyPeer {
+func (n *NetworkPolicyController) toNamespacedPeerForCRD(peers []v1beta1.NetworkPolicyPeer, namespace string) *controlplane.NetworkPolicyPeer {
 	var addressGroups []string
 	for _, peer := range peers {
 		normalizedUID := n.createAddressGroup(namespace, peer.PodSelector, nil, peer.ExternalEntitySelector)
@@ -131,6 +178,16 @@ func (n *NetworkPolicyController) toNamespacedPeerForCRD(peers []v1alpha1.Networ
 	return &controlplane.NetworkPolicyPeer{AddressGroups: addressGroups}
 }
 
+// createAppliedToGroupForCRD creates an AppliedToGroup for a crdv1alpha1 NetworkPolicyPeer which doesn't
+// refer to a ClusterGroup, and returns its key. The Pods running with a ServiceAccount are selected from the
+// ServiceAccount's Namespace, the other selectors are resolved in the provided Namespace.
+func (n *NetworkPolicyController) createAppliedToGroupForCRD(namespace string, appliedTo v1beta1.NetworkPolicyPeer) string {
+	if appliedTo.ServiceAccount != nil {
+		return n.createAppliedToGroup(appliedTo.ServiceAccount.Namespace, toServiceAccountPodSelector(appliedTo.ServiceAccount.Name), nil, nil)
+	}
+	return n.createAppliedToGroup(namespace, appliedTo.PodSelector, appliedTo.NamespaceSelector, appliedTo.ExternalEntitySelector)
+}
+
 // createAppliedToGroupForClusterGroupCRD creates an AppliedToGroup object corresponding to a
 // internal Group. If the AppliedToGroup already exists, it returns the key
 // otherwise it copies the internal Group contents to an AppliedToGroup resource and returns
@@ -24,18 +24,18 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"antrea.io/antrea/pkg/apis/controlplane"
-	crdv1alpha1 "antrea.io/antrea/pkg/apis/crd/v1alpha1"
 	crdv1alpha3 "antrea.io/antrea/pkg/apis/crd/v1alpha3"
+	crdv1beta1 "antrea.io/antrea/pkg/apis/crd/v1beta1"
 )
 
 func TestToAntreaServicesForCRD(t *testing.T) {
 	tables := []struct {
-		ports              []crdv1alpha1.NetworkPolicyPort
+		ports              []crdv1beta1.NetworkPolicyPort
 		expServices        []controlplane.Service
 		expNamedPortExists bool
 	}{
 		{
-			ports: []crdv1alpha1.NetworkPolicyPort{
+			ports: []crdv1beta1.NetworkPolicyPort{
 				{
 					Protocol: &k8sProtocolTCP,
 					Port:     &int80,
@@ -50,7 +50,7 @@ func TestToAntreaServicesForCRD(t *testing.T) {
 			expNamedPortExists: false,
 		},
 		{
-			ports: []crdv1alpha1.NetworkPolicyPort{
+			ports: []crdv1beta1.NetworkPolicyPort{
 				{
 					Protocol: &k8sProtocolTCP,
 					Port:     &strHTTP,
@@ -65,7 +65,7 @@ func TestToAntreaServicesForCRD(t *testing.T) {
 			expNamedPortExists: true,
 		},
 		{
-			ports: []crdv1alpha1.NetworkPolicyPort{
+			ports: []crdv1beta1.NetworkPolicyPort{
 				{
 					Protocol: &k8sProtocolTCP,
 					Port:     &int1000,
@@ -95,12 +95,12 @@ func TestToAntreaIPBlockForCRD(t *testing.T) {
 		PrefixLength: 24,
 	}
 	tables := []struct {
-		ipBlock  *crdv1alpha1.IPBlock
+		ipBlock  *crdv1beta1.IPBlock
 		expValue controlplane.IPBlock
 		err      error
 	}{
 		{
-			&crdv1alpha1.IPBlock{
+			&crdv1beta1.IPBlock{
 				CIDR: "10.0.0.0/24",
 			},
 			controlplane.IPBlock{
@@ -109,7 +109,28 @@ func TestToAntreaIPBlockForCRD(t *testing.T) {
 			nil,
 		},
 		{
-			&crdv1alpha1.IPBlock{
+			&crdv1beta1.IPBlock{
+				CIDR:   "10.0.0.0/24",
+				Except: []string{"10.0.0.128/25"},
+			},
+			controlplane.IPBlock{
+				CIDR: expIPNet,
+				Except: []controlplane.IPNet{
+					{IP: ipStrToIPAddress("10.0.0.128"), PrefixLength: 25},
+				},
+			},
+			nil,
+		},
+		{
+			&crdv1beta1.IPBlock{
+				CIDR:   "10.0.0.0/24",
+				Except: []string{"10.0.0.128"},
+			},
+			controlplane.IPBlock{},
+			fmt.Errorf("invalid format for IPBlock CIDR: 10.0.0.128"),
+		},
+		{
+			&crdv1beta1.IPBlock{
 				CIDR: "10.0.0.0",
 			},
 			controlplane.IPBlock{},
@@ -133,18 +154,23 @@ func TestToAntreaIPBlockForCRD(t *testing.T) {
 		if table.expValue.CIDR.PrefixLength != ipNet.PrefixLength {
 			t.Errorf("Unexpected PrefixLength in Antrea IPBlock conversion. Expected %v, got %v", table.expValue.CIDR.PrefixLength, ipNet.PrefixLength)
 		}
+		assert.Equal(t, len(table.expValue.Except), len(antreaIPBlock.Except))
+		for i := range antreaIPBlock.Except {
+			assert.Equal(t, table.expValue.Except[i].IP, antreaIPBlock.Except[i].IP)
+			assert.Equal(t, table.expValue.Except[i].PrefixLength, antreaIPBlock.Except[i].PrefixLength)
+		}
 	}
 }
 
 func TestToAntreaPeerForCRD(t *testing.T) {
-	testCNPObj := &crdv1alpha1.ClusterNetworkPolicy{
+	testCNPObj := &crdv1beta1.ClusterNetworkPolicy{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: "cnpA",
 		},
 	}
 	cidr := "10.0.0.0/16"
 	cidrIPNet, _ := cidrStrToIPNet(cidr)
-	selectorIP := crdv1alpha1.IPBlock{CIDR: cidr}
+	selectorIP := crdv1beta1.IPBlock{CIDR: cidr}
 	selectorA := metav1.LabelSelector{MatchLabels: map[string]string{"foo1": "bar1"}}
 	selectorB := metav1.LabelSelector{MatchLabels: map[string]string{"foo2": "bar2"}}
 	selectorC := metav1.LabelSelector{MatchLabels: map[string]string{"foo3": "bar3"}}
@@ -160,7 +186,7 @@ func TestToAntreaPeerForCRD(t *testing.T) {
 	}
 	tests := []struct {
 		name            string
-		inPeers         []crdv1alpha1.NetworkPolicyPeer
+		inPeers         []crdv1beta1.NetworkPolicyPeer
 		outPeer         controlplane.NetworkPolicyPeer
 		direction       controlplane.Direction
 		namedPortExists bool
@@ -168,7 +194,7 @@ func TestToAntreaPeerForCRD(t *testing.T) {
 	}{
 		{
 			name: "pod-ns-selector-peer-ingress",
-			inPeers: []crdv1alpha1.NetworkPolicyPeer{
+			inPeers: []crdv1beta1.NetworkPolicyPeer{
 				{
 					PodSelector:       &selectorA,
 					NamespaceSelector: &selectorB,
@@ -187,7 +213,7 @@ func TestToAntreaPeerForCRD(t *testing.T) {
 		},
 		{
 			name: "pod-ns-selector-peer-egress",
-			inPeers: []crdv1alpha1.NetworkPolicyPeer{
+			inPeers: []crdv1beta1.NetworkPolicyPeer{
 				{
 					PodSelector:       &selectorA,
 					NamespaceSelector: &selectorB,
@@ -206,7 +232,7 @@ func TestToAntreaPeerForCRD(t *testing.T) {
 		},
 		{
 			name: "ipblock-selector-peer-ingress",
-			inPeers: []crdv1alpha1.NetworkPolicyPeer{
+			inPeers: []crdv1beta1.NetworkPolicyPeer{
 				{
 					IPBlock: &selectorIP,
 				},
@@ -222,7 +248,7 @@ func TestToAntreaPeerForCRD(t *testing.T) {
 		},
 		{
 			name: "ipblock-selector-peer-egress",
-			inPeers: []crdv1alpha1.NetworkPolicyPeer{
+			inPeers: []crdv1beta1.NetworkPolicyPeer{
 				{
 					IPBlock: &selectorIP,
 				},
@@ -238,13 +264,13 @@ func TestToAntreaPeerForCRD(t *testing.T) {
 		},
 		{
 			name:      "empty-peer-ingress",
-			inPeers:   []crdv1alpha1.NetworkPolicyPeer{},
+			inPeers:   []crdv1beta1.NetworkPolicyPeer{},
 			outPeer:   matchAllPeer,
 			direction: controlplane.DirectionIn,
 		},
 		{
 			name: "peer-ingress-with-cg",
-			inPeers: []crdv1alpha1.NetworkPolicyPeer{
+			inPeers: []crdv1beta1.NetworkPolicyPeer{
 				{
 					Group: cgA.Name,
 				},
@@ -256,20 +282,20 @@ func TestToAntreaPeerForCRD(t *testing.T) {
 		},
 		{
 			name:            "empty-peer-egress-with-named-port",
-			inPeers:         []crdv1alpha1.NetworkPolicyPeer{},
+			inPeers:         []crdv1beta1.NetworkPolicyPeer{},
 			outPeer:         matchAllPodsPeer,
 			direction:       controlplane.DirectionOut,
 			namedPortExists: true,
 		},
 		{
 			name:      "empty-peer-egress-without-named-port",
-			inPeers:   []crdv1alpha1.NetworkPolicyPeer{},
+			inPeers:   []crdv1beta1.NetworkPolicyPeer{},
 			outPeer:   matchAllPeer,
 			direction: controlplane.DirectionOut,
 		},
 		{
 			name: "peer-egress-with-cg",
-			inPeers: []crdv1alpha1.NetworkPolicyPeer{
+			inPeers: []crdv1beta1.NetworkPolicyPeer{
 				{
 					Group: cgA.Name,
 				},
@@ -22,6 +22,7 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 
 	cpv1beta "antrea.io/antrea/pkg/apis/controlplane/v1beta2"
+	"antrea.io/antrea/pkg/controller/grouping"
 	"antrea.io/antrea/pkg/controller/networkpolicy/store"
 	antreatypes "antrea.io/antrea/pkg/controller/types"
 )
@@ -32,6 +33,16 @@ type EndpointQuerier interface {
 	// along with the list NetworkPolicies which select the provided Pod in one of their policy
 	// rules (ingress or egress).
 	QueryNetworkPolicies(namespace string, podName string) (*EndpointQueryResponse, error)
+	// QueryPolicyConflicts returns the Antrea-native policy rules applied to the provided Pod which
+	// may be shadowed by a rule with higher precedence and the opposite action. The result is
+	// advisory.
+	QueryPolicyConflicts(namespace string, podName string) (*PolicyConflictResponse, error)
+	// QueryLargestGroups returns the AddressGroups and AppliedToGroups with the most members, up
+	// to limit groups, along with the policies referencing them.
+	QueryLargestGroups(limit int) ([]GroupSize, error)
+	// QueryPolicyQuotas returns the usages of the flow quotas of the Namespaces, or of the
+	// provided Namespace if it's not empty.
+	QueryPolicyQuotas(namespace string) ([]PolicyQuotaUsage, error)
 }
 
 // endpointQuerier implements the EndpointQuerier interface
@@ -59,12 +70,18 @@ type PolicyRef struct {
 
 type Policy struct {
 	PolicyRef
+	// ServiceAccount is the name of the endpoint's ServiceAccount if the policy applies to the endpoint
+	// through it.
+	ServiceAccount string `json:"serviceaccount,omitempty"`
 }
 
 type Rule struct {
 	PolicyRef
 	Direction cpv1beta.Direction `json:"direction,omitempty"`
 	RuleIndex int                `json:"ruleindex,omitempty"`
+	// ServiceAccount is the name of the endpoint's ServiceAccount if the rule selects the endpoint
+	// through it.
+	ServiceAccount string `json:"serviceaccount,omitempty"`
 }
 
 // NewEndpointQuerier returns a new *endpointQuerier.
@@ -86,22 +103,29 @@ func (eq *endpointQuerier) QueryNetworkPolicies(namespace string, podName string
 		return nil, nil
 	}
 	type ruleTemp struct {
-		policy *antreatypes.NetworkPolicy
-		index  int
+		policy         *antreatypes.NetworkPolicy
+		index          int
+		serviceAccount string
 	}
 	// create network policies categories
-	applied := make([]*antreatypes.NetworkPolicy, 0)
 	ingress := make([]*ruleTemp, 0)
 	egress := make([]*ruleTemp, 0)
 	// get all appliedToGroups using filter, then get applied policies using appliedToGroup
-	appliedToGroupKeys := groups[appliedToGroupType]
-	// We iterate over all AppliedToGroups (same for AddressGroups below). This is acceptable
-	// since this implementation only supports user queries (in particular through antctl) and
-	// should resturn within a reasonable amount of time. We experimented with adding Pod
-	// Indexers to the AppliedToGroup and AddressGroup stores, but we felt that this use case
-	// did not justify the memory overhead. If we can find another use for the Indexers as part
-	// of the NetworkPolicy Controller implementation, we may consider adding them back.
-	for _, appliedToGroupKey := range appliedToGroupKeys {
+	applied, err := eq.getAppliedPolicies(groups[appliedToGroupType])
+	if err != nil {
+		return nil, err
+	}
+	// get the policies applied to the endpoint through its ServiceAccount
+	appliedServiceAccounts := make(map[types.UID]string)
+	for _, appliedToGroupKey := range groups[appliedToGroupType] {
+		appliedToGroup, found, _ := eq.networkPolicyController.appliedToGroupStore.Get(appliedToGroupKey)
+		if !found {
+			continue
+		}
+		serviceAccount := getSelectedServiceAccount(appliedToGroup.(*antreatypes.AppliedToGroup).Selector)
+		if serviceAccount == "" {
+			continue
+		}
 		policies, err := eq.networkPolicyController.internalNetworkPolicyStore.GetByIndex(
 			store.AppliedToGroupIndex,
 			appliedToGroupKey,
@@ -110,7 +134,7 @@ func (eq *endpointQuerier) QueryNetworkPolicies(namespace string, podName string
 			return nil, err
 		}
 		for _, policy := range policies {
-			applied = append(applied, policy.(*antreatypes.NetworkPolicy))
+			appliedServiceAccounts[policy.(*antreatypes.NetworkPolicy).UID] = serviceAccount
 		}
 	}
 	// get all addressGroups using filter, then get ingress and egress policies using addressGroup
@@ -120,6 +144,7 @@ func (eq *endpointQuerier) QueryNetworkPolicies(namespace string, podName string
 		if !found {
 			continue
 		}
+		serviceAccount := getSelectedServiceAccount(addressGroup.(*antreatypes.AddressGroup).Selector)
 		policies, err := eq.networkPolicyController.internalNetworkPolicyStore.GetByIndex(
 			store.AddressGroupIndex,
 			addressGroupKey,
@@ -133,7 +158,7 @@ func (eq *endpointQuerier) QueryNetworkPolicies(namespace string, podName string
 			for _, rule := range policy.(*antreatypes.NetworkPolicy).Rules {
 				for _, addressGroupTrial := range rule.To.AddressGroups {
 					if addressGroupTrial == string(addressGroup.(*antreatypes.AddressGroup).UID) {
-						egress = append(egress, &ruleTemp{policy: policy.(*antreatypes.NetworkPolicy), index: egressIndex})
+						egress = append(egress, &ruleTemp{policy: policy.(*antreatypes.NetworkPolicy), index: egressIndex, serviceAccount: serviceAccount})
 						egressIndex++
 						// an AddressGroup can only be referenced in a rule once
 						break
@@ -141,7 +166,7 @@ func (eq *endpointQuerier) QueryNetworkPolicies(namespace string, podName string
 				}
 				for _, addressGroupTrial := range rule.From.AddressGroups {
 					if addressGroupTrial == string(addressGroup.(*antreatypes.AddressGroup).UID) {
-						ingress = append(ingress, &ruleTemp{policy: policy.(*antreatypes.NetworkPolicy), index: ingressIndex})
+						ingress = append(ingress, &ruleTemp{policy: policy.(*antreatypes.NetworkPolicy), index: ingressIndex, serviceAccount: serviceAccount})
 						ingressIndex++
 						// an AddressGroup can only be referenced in a rule once
 						break
@@ -159,6 +184,7 @@ func (eq *endpointQuerier) QueryNetworkPolicies(namespace string, podName string
 				Name:      internalPolicy.SourceRef.Name,
 				UID:       internalPolicy.SourceRef.UID,
 			},
+			ServiceAccount: appliedServiceAccounts[internalPolicy.UID],
 		}
 		responsePolicies = append(responsePolicies, responsePolicy)
 	}
@@ -171,8 +197,9 @@ func (eq *endpointQuerier) QueryNetworkPolicies(namespace string, podName string
 				Name:      internalPolicy.policy.SourceRef.Name,
 				UID:       internalPolicy.policy.SourceRef.UID,
 			},
-			Direction: cpv1beta.DirectionOut,
-			RuleIndex: internalPolicy.index,
+			Direction:      cpv1beta.DirectionOut,
+			RuleIndex:      internalPolicy.index,
+			ServiceAccount: internalPolicy.serviceAccount,
 		}
 		responseRules = append(responseRules, newRule)
 	}
@@ -183,8 +210,9 @@ func (eq *endpointQuerier) QueryNetworkPolicies(namespace string, podName string
 				Name:      internalPolicy.policy.SourceRef.Name,
 				UID:       internalPolicy.policy.SourceRef.UID,
 			},
-			Direction: cpv1beta.DirectionIn,
-			RuleIndex: internalPolicy.index,
+			Direction:      cpv1beta.DirectionIn,
+			RuleIndex:      internalPolicy.index,
+			ServiceAccount: internalPolicy.serviceAccount,
 		}
 		responseRules = append(responseRules, newRule)
 	}
@@ -197,3 +225,39 @@ func (eq *endpointQuerier) QueryNetworkPolicies(namespace string, podName string
 	}
 	return &EndpointQueryResponse{[]Endpoint{endpoint}}, nil
 }
+
+// getSelectedServiceAccount returns the name of the ServiceAccount whose Pods are selected by the provided
+// GroupSelector, if the GroupSelector was created for a ServiceAccount peer.
+func getSelectedServiceAccount(selector antreatypes.GroupSelector) string {
+	if selector.PodSelector == nil {
+		return ""
+	}
+	serviceAccount, _ := selector.PodSelector.RequiresExactMatch(grouping.ServiceAccountLabelKey)
+	return serviceAccount
+}
+
+// getAppliedPolicies returns the internal NetworkPolicies applied to any of the provided
+// AppliedToGroups. A NetworkPolicy applied to several of the AppliedToGroups is returned once
+// for each of them.
+func (eq *endpointQuerier) getAppliedPolicies(appliedToGroupKeys []string) ([]*antreatypes.NetworkPolicy, error) {
+	applied := make([]*antreatypes.NetworkPolicy, 0)
+	// We iterate over all AppliedToGroups (same for AddressGroups in QueryNetworkPolicies). This
+	// is acceptable since this implementation only supports user queries (in particular through
+	// antctl) and should resturn within a reasonable amount of time. We experimented with adding
+	// Pod Indexers to the AppliedToGroup and AddressGroup stores, but we felt that this use case
+	// did not justify the memory overhead. If we can find another use for the Indexers as part
+	// of the NetworkPolicy Controller implementation, we may consider adding them back.
+	for _, appliedToGroupKey := range appliedToGroupKeys {
+		policies, err := eq.networkPolicyController.internalNetworkPolicyStore.GetByIndex(
+			store.AppliedToGroupIndex,
+			appliedToGroupKey,
+		)
+		if err != nil {
+			return nil, err
+		}
+		for _, policy := range policies {
+			applied = append(applied, policy.(*antreatypes.NetworkPolicy))
+		}
+	}
+	return applied, nil
+}
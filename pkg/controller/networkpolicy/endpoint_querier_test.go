@@ -156,7 +156,7 @@ func makeControllerAndEndpointQuerier(objects ...runtime.Object) *endpointQuerie
 	querier := NewEndpointQuerier(c.NetworkPolicyController)
 	// start informers and run controller
 	c.informerFactory.Start(stopCh)
-	go c.Run(stopCh)
+	go c.Run(DefaultWorkers, stopCh)
 	// wait until computation is done, we assume it is done when no signal has been received on heartbeat channel for 3s.
 	idleTimeout := 3 * time.Second
 	timer := time.NewTimer(idleTimeout)
@@ -216,10 +216,10 @@ func TestEndpointQuery(t *testing.T) {
 					{
 						Namespace: "testNamespace",
 						Name:      "podA",
-						Policies:  []Policy{{policyRef0}},
+						Policies:  []Policy{{PolicyRef: policyRef0}},
 						Rules: []Rule{
-							{policyRef0, v1beta2.DirectionOut, 0},
-							{policyRef0, v1beta2.DirectionIn, 0},
+							{PolicyRef: policyRef0, Direction: v1beta2.DirectionOut, RuleIndex: 0},
+							{PolicyRef: policyRef0, Direction: v1beta2.DirectionIn, RuleIndex: 0},
 						},
 					},
 				},
@@ -236,12 +236,12 @@ func TestEndpointQuery(t *testing.T) {
 						Namespace: "testNamespace",
 						Name:      "podA",
 						Policies: []Policy{
-							{policyRef0},
-							{policyRef1},
+							{PolicyRef: policyRef0},
+							{PolicyRef: policyRef1},
 						},
 						Rules: []Rule{
-							{policyRef0, v1beta2.DirectionOut, 0},
-							{policyRef0, v1beta2.DirectionIn, 0},
+							{PolicyRef: policyRef0, Direction: v1beta2.DirectionOut, RuleIndex: 0},
+							{PolicyRef: policyRef0, Direction: v1beta2.DirectionIn, RuleIndex: 0},
 						},
 					},
 				},
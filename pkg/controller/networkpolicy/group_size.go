@@ -0,0 +1,129 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package networkpolicy
+
+import (
+	"sort"
+
+	"antrea.io/antrea/pkg/apis/controlplane"
+	cpv1beta "antrea.io/antrea/pkg/apis/controlplane/v1beta2"
+	"antrea.io/antrea/pkg/controller/networkpolicy/store"
+	antreatypes "antrea.io/antrea/pkg/controller/types"
+)
+
+const (
+	GroupTypeAddressGroup   = "AddressGroup"
+	GroupTypeAppliedToGroup = "AppliedToGroup"
+)
+
+// GroupSize is the reply struct for antctl largest groups queries. It describes the number of
+// members of an AddressGroup or an AppliedToGroup and the policies referencing it.
+type GroupSize struct {
+	Type     string           `json:"type"`
+	Name     string           `json:"name"`
+	Size     int              `json:"size"`
+	Policies []GroupPolicyRef `json:"policies,omitempty"`
+}
+
+// GroupPolicyRef references a policy which uses a group.
+type GroupPolicyRef struct {
+	PolicyRef
+	Type cpv1beta.NetworkPolicyType `json:"type,omitempty"`
+}
+
+// addressGroupSize returns the number of members of an AddressGroup.
+func addressGroupSize(g *antreatypes.AddressGroup) int {
+	return len(g.GroupMembers)
+}
+
+// appliedToGroupSize returns the number of members of an AppliedToGroup, across all Nodes.
+func appliedToGroupSize(g *antreatypes.AppliedToGroup) int {
+	size := 0
+	for _, members := range g.GroupMemberByNode {
+		size += len(members)
+	}
+	return size
+}
+
+// groupMemberByNodeEqual returns whether two AppliedToGroups have the same members on the same
+// Nodes.
+func groupMemberByNodeEqual(a, b map[string]controlplane.GroupMemberSet) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for node, members := range a {
+		if !members.Equal(b[node]) {
+			return false
+		}
+	}
+	return true
+}
+
+// QueryLargestGroups returns the AddressGroups and AppliedToGroups with the most members, in
+// decreasing order of size, along with the policies referencing them. All the groups are returned
+// if limit is not positive.
+func (eq *endpointQuerier) QueryLargestGroups(limit int) ([]GroupSize, error) {
+	n := eq.networkPolicyController
+	var groups []GroupSize
+	for _, obj := range n.addressGroupStore.List() {
+		addressGroup := obj.(*antreatypes.AddressGroup)
+		groups = append(groups, GroupSize{Type: GroupTypeAddressGroup, Name: addressGroup.Name, Size: addressGroupSize(addressGroup)})
+	}
+	for _, obj := range n.appliedToGroupStore.List() {
+		appliedToGroup := obj.(*antreatypes.AppliedToGroup)
+		groups = append(groups, GroupSize{Type: GroupTypeAppliedToGroup, Name: appliedToGroup.Name, Size: appliedToGroupSize(appliedToGroup)})
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Size != groups[j].Size {
+			return groups[i].Size > groups[j].Size
+		}
+		if groups[i].Type != groups[j].Type {
+			return groups[i].Type < groups[j].Type
+		}
+		return groups[i].Name < groups[j].Name
+	})
+	if limit > 0 && len(groups) > limit {
+		groups = groups[:limit]
+	}
+	for i := range groups {
+		indexName := store.AddressGroupIndex
+		if groups[i].Type == GroupTypeAppliedToGroup {
+			indexName = store.AppliedToGroupIndex
+		}
+		policies, err := n.internalNetworkPolicyStore.GetByIndex(indexName, groups[i].Name)
+		if err != nil {
+			return nil, err
+		}
+		for _, obj := range policies {
+			policy := obj.(*antreatypes.NetworkPolicy)
+			groups[i].Policies = append(groups[i].Policies, GroupPolicyRef{
+				PolicyRef: PolicyRef{
+					Namespace: policy.SourceRef.Namespace,
+					Name:      policy.SourceRef.Name,
+					UID:       policy.SourceRef.UID,
+				},
+				Type: cpv1beta.NetworkPolicyType(policy.SourceRef.Type),
+			})
+		}
+		sort.Slice(groups[i].Policies, func(a, b int) bool {
+			pa, pb := groups[i].Policies[a], groups[i].Policies[b]
+			if pa.Namespace != pb.Namespace {
+				return pa.Namespace < pb.Namespace
+			}
+			return pa.Name < pb.Name
+		})
+	}
+	return groups, nil
+}
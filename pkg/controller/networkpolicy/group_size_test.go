@@ -0,0 +1,93 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package networkpolicy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"antrea.io/antrea/pkg/apis/controlplane"
+	cpv1beta "antrea.io/antrea/pkg/apis/controlplane/v1beta2"
+	antreatypes "antrea.io/antrea/pkg/controller/types"
+)
+
+func TestQueryLargestGroups(t *testing.T) {
+	pod := func(name string) *controlplane.GroupMember {
+		return &controlplane.GroupMember{Pod: &controlplane.PodReference{Namespace: "ns1", Name: name}}
+	}
+	_, c := newController()
+	require.NoError(t, c.addressGroupStore.Create(&antreatypes.AddressGroup{
+		UID:          "ag1",
+		Name:         "ag1",
+		GroupMembers: controlplane.NewGroupMemberSet(pod("pod1"), pod("pod2"), pod("pod3")),
+	}))
+	require.NoError(t, c.addressGroupStore.Create(&antreatypes.AddressGroup{
+		UID:  "ag2",
+		Name: "ag2",
+	}))
+	require.NoError(t, c.appliedToGroupStore.Create(&antreatypes.AppliedToGroup{
+		UID:  "atg1",
+		Name: "atg1",
+		GroupMemberByNode: map[string]controlplane.GroupMemberSet{
+			"node1": controlplane.NewGroupMemberSet(pod("pod1")),
+			"node2": controlplane.NewGroupMemberSet(pod("pod2")),
+		},
+	}))
+	require.NoError(t, c.internalNetworkPolicyStore.Create(&antreatypes.NetworkPolicy{
+		UID:             "uid-np1",
+		Name:            "uid-np1",
+		SourceRef:       &controlplane.NetworkPolicyReference{Type: controlplane.K8sNetworkPolicy, Namespace: "ns1", Name: "np1", UID: "uid-np1"},
+		AppliedToGroups: []string{"atg1"},
+		Rules: []controlplane.NetworkPolicyRule{
+			{Direction: controlplane.DirectionIn, From: controlplane.NetworkPolicyPeer{AddressGroups: []string{"ag1"}}},
+		},
+	}))
+	require.NoError(t, c.internalNetworkPolicyStore.Create(&antreatypes.NetworkPolicy{
+		UID:             "uid-acnp1",
+		Name:            "uid-acnp1",
+		SourceRef:       &controlplane.NetworkPolicyReference{Type: controlplane.AntreaClusterNetworkPolicy, Name: "acnp1", UID: "uid-acnp1"},
+		AppliedToGroups: []string{"atg1"},
+	}))
+	querier := NewEndpointQuerier(c.NetworkPolicyController)
+
+	np1Ref := GroupPolicyRef{PolicyRef: PolicyRef{Namespace: "ns1", Name: "np1", UID: "uid-np1"}, Type: cpv1beta.K8sNetworkPolicy}
+	acnp1Ref := GroupPolicyRef{PolicyRef: PolicyRef{Name: "acnp1", UID: "uid-acnp1"}, Type: cpv1beta.AntreaClusterNetworkPolicy}
+	groups, err := querier.QueryLargestGroups(0)
+	require.NoError(t, err)
+	assert.Equal(t, []GroupSize{
+		{Type: GroupTypeAddressGroup, Name: "ag1", Size: 3, Policies: []GroupPolicyRef{np1Ref}},
+		{Type: GroupTypeAppliedToGroup, Name: "atg1", Size: 2, Policies: []GroupPolicyRef{acnp1Ref, np1Ref}},
+		{Type: GroupTypeAddressGroup, Name: "ag2", Size: 0},
+	}, groups)
+
+	groups, err = querier.QueryLargestGroups(1)
+	require.NoError(t, err)
+	assert.Equal(t, []GroupSize{
+		{Type: GroupTypeAddressGroup, Name: "ag1", Size: 3, Policies: []GroupPolicyRef{np1Ref}},
+	}, groups)
+}
+
+func TestGroupMemberByNodeEqual(t *testing.T) {
+	pod1 := &controlplane.GroupMember{Pod: &controlplane.PodReference{Namespace: "ns1", Name: "pod1"}}
+	pod2 := &controlplane.GroupMember{Pod: &controlplane.PodReference{Namespace: "ns1", Name: "pod2"}}
+	a := map[string]controlplane.GroupMemberSet{"node1": controlplane.NewGroupMemberSet(pod1)}
+	assert.True(t, groupMemberByNodeEqual(a, map[string]controlplane.GroupMemberSet{"node1": controlplane.NewGroupMemberSet(pod1)}))
+	assert.False(t, groupMemberByNodeEqual(a, map[string]controlplane.GroupMemberSet{"node2": controlplane.NewGroupMemberSet(pod1)}))
+	assert.False(t, groupMemberByNodeEqual(a, map[string]controlplane.GroupMemberSet{"node1": controlplane.NewGroupMemberSet(pod2)}))
+	assert.False(t, groupMemberByNodeEqual(a, nil))
+	assert.True(t, groupMemberByNodeEqual(nil, map[string]controlplane.GroupMemberSet{}))
+}
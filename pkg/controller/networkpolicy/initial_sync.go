@@ -0,0 +1,128 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package networkpolicy
+
+import (
+	"sync/atomic"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// How often to check whether the initial computation of the stores is done.
+	initialSyncCheckInterval = 100 * time.Millisecond
+	// How many consecutive checks a queue must be found idle in before the stores depending on it are considered
+	// synced. A single check cannot tell an idle queue from a queue whose item has just been handed to a worker.
+	initialSyncIdleChecks = 2
+)
+
+// queueProgress tracks the number of items of a work queue being processed by workers.
+type queueProgress struct {
+	inFlight int32
+}
+
+func (p *queueProgress) start() {
+	atomic.AddInt32(&p.inFlight, 1)
+}
+
+func (p *queueProgress) done() {
+	atomic.AddInt32(&p.inFlight, -1)
+}
+
+// idle returns whether the queue has neither pending items nor items being processed. Items waiting to be retried
+// after a failure are not counted, so that a single failing item doesn't prevent a store from being served.
+func (p *queueProgress) idle(queue workqueue.Interface) bool {
+	return queue.Len() == 0 && atomic.LoadInt32(&p.inFlight) == 0
+}
+
+// storeSyncState tracks the initial computation of the AppliedToGroup, internal NetworkPolicy and AddressGroup
+// stores, so that each of them can be served as soon as it's complete instead of waiting for all of them.
+// The queues feed each other in the following order: internalGroup -> appliedToGroup -> internalNetworkPolicy ->
+// addressGroup, hence a store is synced once its own queue and all the queues before it are idle.
+type storeSyncState struct {
+	appliedToGroupProgress        queueProgress
+	addressGroupProgress          queueProgress
+	internalNetworkPolicyProgress queueProgress
+	internalGroupProgress         queueProgress
+
+	appliedToGroupStoreSynced        chan struct{}
+	internalNetworkPolicyStoreSynced chan struct{}
+	addressGroupStoreSynced          chan struct{}
+}
+
+func newStoreSyncState() storeSyncState {
+	return storeSyncState{
+		appliedToGroupStoreSynced:        make(chan struct{}),
+		internalNetworkPolicyStoreSynced: make(chan struct{}),
+		addressGroupStoreSynced:          make(chan struct{}),
+	}
+}
+
+// AppliedToGroupStoreSynced returns a channel which is closed once the initial computation of AppliedToGroups is done.
+func (n *NetworkPolicyController) AppliedToGroupStoreSynced() <-chan struct{} {
+	return n.storeSyncState.appliedToGroupStoreSynced
+}
+
+// AddressGroupStoreSynced returns a channel which is closed once the initial computation of AddressGroups is done.
+func (n *NetworkPolicyController) AddressGroupStoreSynced() <-chan struct{} {
+	return n.storeSyncState.addressGroupStoreSynced
+}
+
+// NetworkPolicyStoreSynced returns a channel which is closed once the initial computation of internal
+// NetworkPolicies is done.
+func (n *NetworkPolicyController) NetworkPolicyStoreSynced() <-chan struct{} {
+	return n.storeSyncState.internalNetworkPolicyStoreSynced
+}
+
+// trackInitialSync closes the synced channel of each store once its initial computation is done. It must be called
+// after the informer caches are synced and the workers are started.
+func (n *NetworkPolicyController) trackInitialSync(stopCh <-chan struct{}) {
+	s := &n.storeSyncState
+	startTime := time.Now()
+	idleChecks := map[chan struct{}]int{}
+	// check closes the synced channel if the queues it depends on have been idle for enough consecutive checks and
+	// returns whether the channel is closed.
+	check := func(synced chan struct{}, name string, idle bool) bool {
+		select {
+		case <-synced:
+			return true
+		default:
+		}
+		if !idle {
+			idleChecks[synced] = 0
+			return false
+		}
+		idleChecks[synced]++
+		if idleChecks[synced] < initialSyncIdleChecks {
+			return false
+		}
+		klog.Infof("Initial computation of %s finished in %v", name, time.Since(startTime))
+		close(synced)
+		return true
+	}
+	wait.PollImmediateUntil(initialSyncCheckInterval, func() (bool, error) {
+		groupsIdle := s.internalGroupProgress.idle(n.internalGroupQueue)
+		atgIdle := groupsIdle && s.appliedToGroupProgress.idle(n.appliedToGroupQueue)
+		npIdle := atgIdle && s.internalNetworkPolicyProgress.idle(n.internalNetworkPolicyQueue)
+		agIdle := npIdle && s.addressGroupProgress.idle(n.addressGroupQueue)
+		atgSynced := check(s.appliedToGroupStoreSynced, "AppliedToGroups", atgIdle)
+		npSynced := check(s.internalNetworkPolicyStoreSynced, "NetworkPolicies", atgSynced && npIdle)
+		agSynced := check(s.addressGroupStoreSynced, "AddressGroups", npSynced && agIdle)
+		return agSynced, nil
+	}, stopCh)
+}
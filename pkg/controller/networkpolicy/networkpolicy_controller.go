@@ -19,6 +19,10 @@
 package networkpolicy
 
 import (
+	"bytes"
+	"crypto/sha1" // #nosec G505: not used for security purposes
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"net"
 	"reflect"
@@ -33,6 +37,7 @@ import (
 	networkingv1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/apimachinery/pkg/util/sets"
@@ -40,21 +45,26 @@ import (
 	coreinformers "k8s.io/client-go/informers/core/v1"
 	networkinginformers "k8s.io/client-go/informers/networking/v1"
 	clientset "k8s.io/client-go/kubernetes"
+	typedv1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	corelisters "k8s.io/client-go/listers/core/v1"
 	networkinglisters "k8s.io/client-go/listers/networking/v1"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog/v2"
 
 	"antrea.io/antrea/pkg/apis/controlplane"
 	secv1alpha1 "antrea.io/antrea/pkg/apis/crd/v1alpha1"
 	"antrea.io/antrea/pkg/apis/crd/v1alpha2"
+	crdv1beta1 "antrea.io/antrea/pkg/apis/crd/v1beta1"
 	"antrea.io/antrea/pkg/apiserver/storage"
 	"antrea.io/antrea/pkg/client/clientset/versioned"
 	secinformers "antrea.io/antrea/pkg/client/informers/externalversions/crd/v1alpha1"
 	crdv1a3informers "antrea.io/antrea/pkg/client/informers/externalversions/crd/v1alpha3"
+	crdv1b1informers "antrea.io/antrea/pkg/client/informers/externalversions/crd/v1beta1"
 	seclisters "antrea.io/antrea/pkg/client/listers/crd/v1alpha1"
 	crdv1a3listers "antrea.io/antrea/pkg/client/listers/crd/v1alpha3"
+	crdv1b1listers "antrea.io/antrea/pkg/client/listers/crd/v1beta1"
 	"antrea.io/antrea/pkg/controller/grouping"
 	"antrea.io/antrea/pkg/controller/metrics"
 	"antrea.io/antrea/pkg/controller/networkpolicy/store"
@@ -74,7 +84,7 @@ const (
 	minRetryDelay = 5 * time.Second
 	maxRetryDelay = 300 * time.Second
 	// Default number of workers processing a NetworkPolicy change.
-	defaultWorkers = 4
+	DefaultWorkers = 4
 	// Default rule priority for K8s NetworkPolicy rules.
 	defaultRulePriority = -1
 	// TierIndex is used to index ClusterNetworkPolicies by Tier names.
@@ -140,6 +150,13 @@ type NetworkPolicyController struct {
 	// serviceListerSynced is a function which returns true if the Service shared informer has been synced at least once.
 	serviceListerSynced cache.InformerSynced
 
+	nodeInformer coreinformers.NodeInformer
+	// nodeLister is able to list/get Nodes and is populated by the shared informer passed to
+	// NewNetworkPolicyController.
+	nodeLister corelisters.NodeLister
+	// nodeListerSynced is a function which returns true if the Node shared informer has been synced at least once.
+	nodeListerSynced cache.InformerSynced
+
 	networkPolicyInformer networkinginformers.NetworkPolicyInformer
 	// networkPolicyLister is able to list/get Network Policies and is populated by the shared informer passed to
 	// NewNetworkPolicyController.
@@ -147,17 +164,17 @@ type NetworkPolicyController struct {
 	// networkPolicyListerSynced is a function which returns true if the Network Policy shared informer has been synced at least once.
 	networkPolicyListerSynced cache.InformerSynced
 
-	cnpInformer secinformers.ClusterNetworkPolicyInformer
+	cnpInformer crdv1b1informers.ClusterNetworkPolicyInformer
 	// cnpLister is able to list/get AntreaClusterNetworkPolicies and is populated by the shared informer passed to
 	// NewClusterNetworkPolicyController.
-	cnpLister seclisters.ClusterNetworkPolicyLister
+	cnpLister crdv1b1listers.ClusterNetworkPolicyLister
 	// cnpListerSynced is a function which returns true if the AntreaClusterNetworkPolicies shared informer has been synced at least once.
 	cnpListerSynced cache.InformerSynced
 
-	anpInformer secinformers.NetworkPolicyInformer
+	anpInformer crdv1b1informers.NetworkPolicyInformer
 	// anpLister is able to list/get AntreaNetworkPolicies and is populated by the shared informer passed to
 	// NewNetworkPolicyController.
-	anpLister seclisters.NetworkPolicyLister
+	anpLister crdv1b1listers.NetworkPolicyLister
 	// anpListerSynced is a function which returns true if the AntreaNetworkPolicies shared informer has been synced at least once.
 	anpListerSynced cache.InformerSynced
 
@@ -198,6 +215,12 @@ type NetworkPolicyController struct {
 	// internalGroupQueue maintains the networkpolicy.Group objects that needs to be
 	// synced.
 	internalGroupQueue workqueue.RateLimitingInterface
+	// groupStatusQueue maintains the ClusterGroups whose status needs to be updated.
+	// It's separate from internalGroupQueue so that failing to update the status
+	// doesn't prevent the changes of the ClusterGroups from being propagated.
+	groupStatusQueue workqueue.RateLimitingInterface
+	// storeSyncState tracks the initial computation of the stores.
+	storeSyncState storeSyncState
 
 	// internalNetworkPolicyMutex protects the internalNetworkPolicyStore from
 	// concurrent access during updates to the internal NetworkPolicy object.
@@ -206,6 +229,11 @@ type NetworkPolicyController struct {
 	groupingInterface grouping.Interface
 	// Added as a member to the struct to allow injection for testing.
 	groupingInterfaceSynced func() bool
+	// policyQuota decides which K8s NetworkPolicies and Antrea NetworkPolicies fit in the flow
+	// quota of their Namespaces. It's nil when no quota is configured.
+	policyQuota *policyQuota
+	// eventRecorder records the Events of the NetworkPolicies exceeding their flow quota.
+	eventRecorder record.EventRecorder
 	// heartbeatCh is an internal channel for testing. It's used to know whether all tasks have been
 	// processed, and to count executions of each function.
 	heartbeatCh chan heartbeat
@@ -222,15 +250,17 @@ func NewNetworkPolicyController(kubeClient clientset.Interface,
 	groupingInterface grouping.Interface,
 	namespaceInformer coreinformers.NamespaceInformer,
 	serviceInformer coreinformers.ServiceInformer,
+	nodeInformer coreinformers.NodeInformer,
 	networkPolicyInformer networkinginformers.NetworkPolicyInformer,
-	cnpInformer secinformers.ClusterNetworkPolicyInformer,
-	anpInformer secinformers.NetworkPolicyInformer,
+	cnpInformer crdv1b1informers.ClusterNetworkPolicyInformer,
+	anpInformer crdv1b1informers.NetworkPolicyInformer,
 	tierInformer secinformers.TierInformer,
 	cgInformer crdv1a3informers.ClusterGroupInformer,
 	addressGroupStore storage.Interface,
 	appliedToGroupStore storage.Interface,
 	internalNetworkPolicyStore storage.Interface,
-	internalGroupStore storage.Interface) *NetworkPolicyController {
+	internalGroupStore storage.Interface,
+	flowQuotaPerNamespace int) *NetworkPolicyController {
 	n := &NetworkPolicyController{
 		kubeClient:                 kubeClient,
 		crdClient:                  crdClient,
@@ -245,8 +275,16 @@ func NewNetworkPolicyController(kubeClient clientset.Interface,
 		addressGroupQueue:          workqueue.NewNamedRateLimitingQueue(workqueue.NewItemExponentialFailureRateLimiter(minRetryDelay, maxRetryDelay), "addressGroup"),
 		internalNetworkPolicyQueue: workqueue.NewNamedRateLimitingQueue(workqueue.NewItemExponentialFailureRateLimiter(minRetryDelay, maxRetryDelay), "internalNetworkPolicy"),
 		internalGroupQueue:         workqueue.NewNamedRateLimitingQueue(workqueue.NewItemExponentialFailureRateLimiter(minRetryDelay, maxRetryDelay), "internalGroup"),
+		groupStatusQueue:           workqueue.NewNamedRateLimitingQueue(workqueue.NewItemExponentialFailureRateLimiter(minRetryDelay, maxRetryDelay), "groupStatus"),
 		groupingInterface:          groupingInterface,
 		groupingInterfaceSynced:    groupingInterface.HasSynced,
+		storeSyncState:             newStoreSyncState(),
+	}
+	if flowQuotaPerNamespace > 0 {
+		n.policyQuota = newPolicyQuota(flowQuotaPerNamespace)
+		eventBroadcaster := record.NewBroadcaster()
+		eventBroadcaster.StartRecordingToSink(&typedv1.EventSinkImpl{Interface: kubeClient.CoreV1().Events("")})
+		n.eventRecorder = eventBroadcaster.NewRecorder(runtime.NewScheme(), v1.EventSource{Component: "antrea-controller"})
 	}
 	n.groupingInterface.AddEventHandler(appliedToGroupType, n.enqueueAppliedToGroup)
 	n.groupingInterface.AddEventHandler(addressGroupType, n.enqueueAddressGroup)
@@ -268,6 +306,9 @@ func NewNetworkPolicyController(kubeClient clientset.Interface,
 		n.serviceInformer = serviceInformer
 		n.serviceLister = serviceInformer.Lister()
 		n.serviceListerSynced = serviceInformer.Informer().HasSynced
+		n.nodeInformer = nodeInformer
+		n.nodeLister = nodeInformer.Lister()
+		n.nodeListerSynced = nodeInformer.Informer().HasSynced
 		n.cnpInformer = cnpInformer
 		n.cnpLister = cnpInformer.Lister()
 		n.cnpListerSynced = cnpInformer.Informer().HasSynced
@@ -297,6 +338,15 @@ func NewNetworkPolicyController(kubeClient clientset.Interface,
 			},
 			resyncPeriod,
 		)
+		// Add handlers for Node events, which update the AddressGroups selecting Nodes.
+		n.nodeInformer.Informer().AddEventHandlerWithResyncPeriod(
+			cache.ResourceEventHandlerFuncs{
+				AddFunc:    n.addNode,
+				UpdateFunc: n.updateNode,
+				DeleteFunc: n.deleteNode,
+			},
+			resyncPeriod,
+		)
 		tierInformer.Informer().AddIndexers(
 			cache.Indexers{
 				PriorityIndex: func(obj interface{}) ([]string, error) {
@@ -308,17 +358,25 @@ func NewNetworkPolicyController(kubeClient clientset.Interface,
 				},
 			},
 		)
+		tierInformer.Informer().AddEventHandlerWithResyncPeriod(
+			cache.ResourceEventHandlerFuncs{
+				AddFunc:    n.addTierDefaultPolicy,
+				UpdateFunc: n.updateTierDefaultPolicy,
+				DeleteFunc: n.deleteTierDefaultPolicy,
+			},
+			resyncPeriod,
+		)
 		cnpInformer.Informer().AddIndexers(
 			cache.Indexers{
 				TierIndex: func(obj interface{}) ([]string, error) {
-					cnp, ok := obj.(*secv1alpha1.ClusterNetworkPolicy)
+					cnp, ok := obj.(*crdv1beta1.ClusterNetworkPolicy)
 					if !ok {
 						return []string{}, nil
 					}
 					return []string{cnp.Spec.Tier}, nil
 				},
 				ClusterGroupIndex: func(obj interface{}) ([]string, error) {
-					cnp, ok := obj.(*secv1alpha1.ClusterNetworkPolicy)
+					cnp, ok := obj.(*crdv1beta1.ClusterNetworkPolicy)
 					if !ok {
 						return []string{}, nil
 					}
@@ -328,10 +386,10 @@ func NewNetworkPolicyController(kubeClient clientset.Interface,
 							groupNames.Insert(appTo.Group)
 						}
 					}
-					if len(cnp.Spec.Ingress) == 0 && len(cnp.Spec.Egress) == 0 {
+					if len(cnp.Spec.Ingress) == 0 && len(cnp.Spec.Egress) == 0 && len(cnp.Spec.Layer2) == 0 {
 						return groupNames.List(), nil
 					}
-					appendGroups := func(rule secv1alpha1.Rule) {
+					appendGroups := func(rule crdv1beta1.Rule) {
 						for _, peer := range rule.To {
 							if peer.Group != "" {
 								groupNames.Insert(peer.Group)
@@ -354,6 +412,9 @@ func NewNetworkPolicyController(kubeClient clientset.Interface,
 					for _, rule := range cnp.Spec.Ingress {
 						appendGroups(rule)
 					}
+					for _, rule := range cnp.Spec.Layer2 {
+						appendGroups(crdv1beta1.Rule{To: rule.To, AppliedTo: rule.AppliedTo})
+					}
 					return groupNames.List(), nil
 				},
 			},
@@ -369,7 +430,7 @@ func NewNetworkPolicyController(kubeClient clientset.Interface,
 		anpInformer.Informer().AddIndexers(
 			cache.Indexers{
 				TierIndex: func(obj interface{}) ([]string, error) {
-					anp, ok := obj.(*secv1alpha1.NetworkPolicy)
+					anp, ok := obj.(*crdv1beta1.NetworkPolicy)
 					if !ok {
 						return []string{}, nil
 					}
@@ -649,12 +710,62 @@ func (n *NetworkPolicyController) processNetworkPolicy(np *networkingv1.NetworkP
 			UID:       np.UID,
 		},
 		AppliedToGroups: appliedToGroupNames,
-		Rules:           rules,
+		Rules:           sortRules(rules),
 		Generation:      np.Generation,
 	}
 	return internalNetworkPolicy
 }
 
+// sortRules sorts the rules of an internal NetworkPolicy in a canonical order: by name, then by direction, then by
+// the hash of their content, and returns them. The order of the rules is not significant to the agents, which
+// identify rules by their content and get the precedence of Antrea-native policy rules from their Priority field.
+// However, recomputing an unchanged policy must produce an equal object, otherwise its watchers would receive an
+// update and reconcile all its rules again.
+func sortRules(rules []controlplane.NetworkPolicyRule) []controlplane.NetworkPolicyRule {
+	if len(rules) < 2 {
+		return rules
+	}
+	sorter := &ruleSorter{rules: rules, hashes: make([]string, len(rules))}
+	for i := range rules {
+		sorter.hashes[i] = hashNetworkPolicyRule(&rules[i])
+	}
+	sort.Sort(sorter)
+	return rules
+}
+
+// ruleSorter implements sort.Interface to sort rules along with the hashes of their content.
+type ruleSorter struct {
+	rules  []controlplane.NetworkPolicyRule
+	hashes []string
+}
+
+func (s *ruleSorter) Len() int {
+	return len(s.rules)
+}
+
+func (s *ruleSorter) Less(i, j int) bool {
+	if s.rules[i].Name != s.rules[j].Name {
+		return s.rules[i].Name < s.rules[j].Name
+	}
+	if s.rules[i].Direction != s.rules[j].Direction {
+		return s.rules[i].Direction < s.rules[j].Direction
+	}
+	return s.hashes[i] < s.hashes[j]
+}
+
+func (s *ruleSorter) Swap(i, j int) {
+	s.rules[i], s.rules[j] = s.rules[j], s.rules[i]
+	s.hashes[i], s.hashes[j] = s.hashes[j], s.hashes[i]
+}
+
+// hashNetworkPolicyRule calculates a string based on the rule's content.
+func hashNetworkPolicyRule(r *controlplane.NetworkPolicyRule) string {
+	hash := sha1.New() // #nosec G401: not used for security purposes
+	b, _ := json.Marshal(r)
+	hash.Write(b)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
 func (n *NetworkPolicyController) toAntreaPeer(peers []networkingv1.NetworkPolicyPeer, np *networkingv1.NetworkPolicy, dir controlplane.Direction, namedPortExists bool) *controlplane.NetworkPolicyPeer {
 	var addressGroups []string
 	// Empty NetworkPolicyPeer is supposed to match all addresses.
@@ -791,6 +902,7 @@ func (n *NetworkPolicyController) deleteNetworkPolicy(old interface{}) {
 	}
 	n.deleteDereferencedAppliedToGroup(oldAppliedToGroupUID)
 	n.deleteDereferencedAddressGroups(oldInternalNP)
+	n.releasePolicyQuota(key, oldInternalNP.SourceRef)
 }
 
 // addService retrieves all internal Groups which refers to this Service
@@ -923,12 +1035,15 @@ func (n *NetworkPolicyController) enqueueInternalNetworkPolicy(key string) {
 	metrics.LengthInternalNetworkPolicyQueue.Set(float64(n.internalNetworkPolicyQueue.Len()))
 }
 
-// Run begins watching and syncing of a NetworkPolicyController.
-func (n *NetworkPolicyController) Run(stopCh <-chan struct{}) {
+// Run begins watching and syncing of a NetworkPolicyController. workers is the number of workers processing each
+// work queue. As a work queue never hands out the same key to multiple workers, the computation of different groups
+// and policies can happen in parallel, which is what makes the initial computation faster at scale.
+func (n *NetworkPolicyController) Run(workers int, stopCh <-chan struct{}) {
 	defer n.appliedToGroupQueue.ShutDown()
 	defer n.addressGroupQueue.ShutDown()
 	defer n.internalNetworkPolicyQueue.ShutDown()
 	defer n.internalGroupQueue.ShutDown()
+	defer n.groupStatusQueue.ShutDown()
 
 	klog.Infof("Starting %s", controllerName)
 	defer klog.Infof("Shutting down %s", controllerName)
@@ -936,18 +1051,20 @@ func (n *NetworkPolicyController) Run(stopCh <-chan struct{}) {
 	cacheSyncs := []cache.InformerSynced{n.networkPolicyListerSynced, n.groupingInterfaceSynced}
 	// Only wait for cnpListerSynced and anpListerSynced when AntreaPolicy feature gate is enabled.
 	if features.DefaultFeatureGate.Enabled(features.AntreaPolicy) {
-		cacheSyncs = append(cacheSyncs, n.cnpListerSynced, n.anpListerSynced, n.cgListerSynced)
+		cacheSyncs = append(cacheSyncs, n.cnpListerSynced, n.anpListerSynced, n.cgListerSynced, n.nodeListerSynced)
 	}
 	if !cache.WaitForNamedCacheSync(controllerName, stopCh, cacheSyncs...) {
 		return
 	}
 
-	for i := 0; i < defaultWorkers; i++ {
+	for i := 0; i < workers; i++ {
 		go wait.Until(n.appliedToGroupWorker, time.Second, stopCh)
 		go wait.Until(n.addressGroupWorker, time.Second, stopCh)
 		go wait.Until(n.internalNetworkPolicyWorker, time.Second, stopCh)
 		go wait.Until(n.internalGroupWorker, time.Second, stopCh)
+		go wait.Until(n.groupStatusWorker, time.Second, stopCh)
 	}
+	go n.trackInitialSync(stopCh)
 	<-stopCh
 }
 
@@ -986,6 +1103,8 @@ func (n *NetworkPolicyController) processNextInternalNetworkPolicyWorkItem() boo
 	if quit {
 		return false
 	}
+	n.storeSyncState.internalNetworkPolicyProgress.start()
+	defer n.storeSyncState.internalNetworkPolicyProgress.done()
 	// We call Done here so the workqueue knows we have finished processing this item. We also
 	// must remember to call Forget if we do not want this work item being re-queued. For
 	// example, we do not call Forget if a transient error occurs, instead the item is put back
@@ -1018,6 +1137,8 @@ func (n *NetworkPolicyController) processNextAddressGroupWorkItem() bool {
 	if quit {
 		return false
 	}
+	n.storeSyncState.addressGroupProgress.start()
+	defer n.storeSyncState.addressGroupProgress.done()
 	defer n.addressGroupQueue.Done(key)
 
 	err := n.syncAddressGroup(key.(string))
@@ -1046,6 +1167,8 @@ func (n *NetworkPolicyController) processNextAppliedToGroupWorkItem() bool {
 	if quit {
 		return false
 	}
+	n.storeSyncState.appliedToGroupProgress.start()
+	defer n.storeSyncState.appliedToGroupProgress.done()
 	defer n.appliedToGroupQueue.Done(key)
 
 	err := n.syncAppliedToGroup(key.(string))
@@ -1101,12 +1224,31 @@ func (n *NetworkPolicyController) syncAddressGroup(key string) error {
 	}
 	klog.V(2).Infof("Updating existing AddressGroup %s with %d Pods/ExternalEntities and %d Nodes", key, len(memberSet), addrGroupNodeNames.Len())
 	n.addressGroupStore.Update(updatedAddressGroup)
+	metrics.SizeAddressGroup.Observe(float64(addressGroupSize(updatedAddressGroup)))
+	if !memberSet.Equal(addressGroup.GroupMembers) {
+		metrics.UpdatesAddressGroup.Inc()
+		// The number of flows of the policies referencing the AddressGroup depends on the
+		// number of its members.
+		if n.policyQuota != nil && len(memberSet) != len(addressGroup.GroupMembers) {
+			for _, internalNPObj := range nps {
+				internalNP := internalNPObj.(*antreatypes.NetworkPolicy)
+				if isQuotaEnforced(internalNP.SourceRef) {
+					npKey, _ := store.NetworkPolicyKeyFunc(internalNP)
+					n.enqueueInternalNetworkPolicy(npKey)
+				}
+			}
+		}
+	}
 	return nil
 }
 
 // getAddressGroupMemberSet knows how to construct a GroupMemberSet that contains
 // all the entities selected by an AddressGroup.
 func (n *NetworkPolicyController) getAddressGroupMemberSet(g *antreatypes.AddressGroup) controlplane.GroupMemberSet {
+	// This AddressGroup selects Nodes.
+	if g.Selector.NodeSelector != nil {
+		return n.getNodeMemberSet(g.Selector.NodeSelector)
+	}
 	// Check if an internal Group object exists corresponding to this AddressGroup.
 	groupObj, found, _ := n.internalGroupStore.Get(g.Name)
 	if found {
@@ -1270,6 +1412,10 @@ func (n *NetworkPolicyController) syncAppliedToGroup(key string) error {
 	klog.V(2).Infof("Updating existing AppliedToGroup %s with %d Pods and %d External Entities on %d Nodes",
 		key, scheduledPodNum, scheduledExtEntityNum, appGroupNodeNames.Len())
 	n.appliedToGroupStore.Update(updatedAppliedToGroup)
+	metrics.SizeAppliedToGroup.Observe(float64(appliedToGroupSize(updatedAppliedToGroup)))
+	if !groupMemberByNodeEqual(memberSetByNode, appliedToGroup.GroupMemberByNode) {
+		metrics.UpdatesAppliedToGroup.Inc()
+	}
 	// Get all internal NetworkPolicy objects that refers this AppliedToGroup.
 	// Note that this must be executed after storing the result, to ensure that
 	// both of the NetworkPolicies that referred it before storing it and the
@@ -1365,6 +1511,13 @@ func (n *NetworkPolicyController) syncInternalNetworkPolicy(key string) error {
 		appGroup := appGroupObj.(*antreatypes.AppliedToGroup)
 		utilsets.Merge(nodeNames, appGroup.SpanMeta.NodeNames)
 	}
+	// The policies exceeding the flow quota of their Namespace span no Node, so that the agents
+	// don't enforce them.
+	quotaExceeded := false
+	if n.policyQuota != nil && isQuotaEnforced(internalNP.SourceRef) && !n.applyPolicyQuota(key, internalNP) {
+		quotaExceeded = true
+		nodeNames = sets.String{}
+	}
 	updatedNetworkPolicy := &antreatypes.NetworkPolicy{
 		UID:                   internalNP.UID,
 		Name:                  internalNP.Name,
@@ -1377,6 +1530,8 @@ func (n *NetworkPolicyController) syncInternalNetworkPolicy(key string) error {
 		PerNamespaceSelectors: internalNP.PerNamespaceSelectors,
 		SpanMeta:              antreatypes.SpanMeta{NodeNames: nodeNames},
 		Generation:            internalNP.Generation,
+		GenerationTimestamp:   internalNP.GenerationTimestamp,
+		QuotaExceeded:         quotaExceeded,
 	}
 	klog.V(4).Infof("Updating internal NetworkPolicy %s with %d Nodes", key, nodeNames.Len())
 	n.internalNetworkPolicyStore.Update(updatedNetworkPolicy)
@@ -1439,6 +1594,22 @@ func internalNetworkPolicyKeyFunc(obj metav1.Object) string {
 	return string(obj.GetUID())
 }
 
+// generationTimestamp returns the time at which the current generation of the object was created in
+// the kube-apiserver. As it's not recorded explicitly in the object metadata, it's approximated with
+// the latest time at which a field manager set fields of the spec, or the creation time of the object.
+func generationTimestamp(obj metav1.Object) time.Time {
+	timestamp := obj.GetCreationTimestamp().Time
+	for _, entry := range obj.GetManagedFields() {
+		if entry.Time == nil || entry.FieldsV1 == nil || !bytes.Contains(entry.FieldsV1.Raw, []byte(`"f:spec"`)) {
+			continue
+		}
+		if entry.Time.After(timestamp) {
+			timestamp = entry.Time.Time
+		}
+	}
+	return timestamp
+}
+
 // internalGroupKeyFunc knows how to generate the key for an internal Group based on the object metadata
 // of the corresponding ClusterGroup resource. Currently the Name of the ClusterGroup is used to ensure uniqueness.
 func internalGroupKeyFunc(obj metav1.Object) string {
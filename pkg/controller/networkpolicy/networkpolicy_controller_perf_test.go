@@ -252,7 +252,7 @@ func testComputeNetworkPolicy(t *testing.T, maxExecutionTime time.Duration, name
 	c.informerFactory.Start(stopCh)
 	c.informerFactory.WaitForCacheSync(stopCh)
 	go c.groupingController.Run(stopCh)
-	go c.Run(stopCh)
+	go c.Run(DefaultWorkers, stopCh)
 
 	// Block until all computation is done.
 	<-stopCh
@@ -504,6 +504,54 @@ func benchmarkInit(b *testing.B, namespaces []*corev1.Namespace, networkPolicies
 	}
 }
 
+/*
+BenchmarkStartupWithWorkers measures the time it takes for a started NetworkPolicyController to finish the initial
+computation of each store, i.e. the time before the store can be watched, with different numbers of workers. The
+time of each store is reported as a custom metric, e.g.:
+
+go test -run=None -bench=BenchmarkStartupWithWorkers -benchtime=3x ./pkg/controller/networkpolicy
+*/
+func BenchmarkStartupWithWorkers(b *testing.B) {
+	namespaces, networkPolicies, pods := getXLargeScaleWithSmallNamespaces()
+	for _, workers := range []int{1, 4, 8, 16} {
+		b.Run(fmt.Sprintf("%d-workers", workers), func(b *testing.B) {
+			benchmarkStartup(b, workers, namespaces, networkPolicies, pods)
+		})
+	}
+}
+
+func benchmarkStartup(b *testing.B, workers int, namespaces []*corev1.Namespace, networkPolicies []*networkingv1.NetworkPolicy, pods []*corev1.Pod) {
+	disableLogToStderr()
+
+	objs := toRunTimeObjects(namespaces, networkPolicies, pods)
+	var atgDuration, npDuration, agDuration time.Duration
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		_, c := newController(objs...)
+		stopCh := make(chan struct{})
+		b.StartTimer()
+
+		start := time.Now()
+		c.informerFactory.Start(stopCh)
+		c.crdInformerFactory.Start(stopCh)
+		go c.groupingController.Run(stopCh)
+		go c.Run(workers, stopCh)
+		<-c.AppliedToGroupStoreSynced()
+		atgDuration += time.Since(start)
+		<-c.NetworkPolicyStoreSynced()
+		npDuration += time.Since(start)
+		<-c.AddressGroupStoreSynced()
+		agDuration += time.Since(start)
+
+		b.StopTimer()
+		close(stopCh)
+	}
+	b.ReportMetric(atgDuration.Seconds()/float64(b.N), "appliedtogroups-s/op")
+	b.ReportMetric(npDuration.Seconds()/float64(b.N), "networkpolicies-s/op")
+	b.ReportMetric(agDuration.Seconds()/float64(b.N), "addressgroups-s/op")
+}
+
 func disableLogToStderr() {
 	klogFlagSet := flag.NewFlagSet("klog", flag.ContinueOnError)
 	klog.InitFlags(klogFlagSet)
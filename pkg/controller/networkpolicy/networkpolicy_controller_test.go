@@ -17,6 +17,7 @@ package networkpolicy
 import (
 	"bytes"
 	"fmt"
+	"math/rand"
 	"net"
 	"reflect"
 	"testing"
@@ -104,15 +105,17 @@ func newController(objects ...runtime.Object) (*fake.Clientset, *networkPolicyCo
 		groupEntityIndex,
 		informerFactory.Core().V1().Namespaces(),
 		informerFactory.Core().V1().Services(),
+		informerFactory.Core().V1().Nodes(),
 		informerFactory.Networking().V1().NetworkPolicies(),
-		crdInformerFactory.Crd().V1alpha1().ClusterNetworkPolicies(),
-		crdInformerFactory.Crd().V1alpha1().NetworkPolicies(),
+		crdInformerFactory.Crd().V1beta1().ClusterNetworkPolicies(),
+		crdInformerFactory.Crd().V1beta1().NetworkPolicies(),
 		crdInformerFactory.Crd().V1alpha1().Tiers(),
 		cgInformer,
 		addressGroupStore,
 		appliedToGroupStore,
 		internalNetworkPolicyStore,
-		internalGroupStore)
+		internalGroupStore,
+		0)
 	npController.namespaceLister = informerFactory.Core().V1().Namespaces().Lister()
 	npController.namespaceListerSynced = alwaysReady
 	npController.networkPolicyListerSynced = alwaysReady
@@ -124,12 +127,14 @@ func newController(objects ...runtime.Object) (*fake.Clientset, *networkPolicyCo
 	npController.cgListerSynced = alwaysReady
 	npController.serviceLister = informerFactory.Core().V1().Services().Lister()
 	npController.serviceListerSynced = alwaysReady
+	npController.nodeLister = informerFactory.Core().V1().Nodes().Lister()
+	npController.nodeListerSynced = alwaysReady
 	return client, &networkPolicyController{
 		npController,
 		informerFactory.Core().V1().Namespaces().Informer().GetStore(),
 		informerFactory.Core().V1().Services().Informer().GetStore(),
 		informerFactory.Networking().V1().NetworkPolicies().Informer().GetStore(),
-		crdInformerFactory.Crd().V1alpha1().ClusterNetworkPolicies().Informer().GetStore(),
+		crdInformerFactory.Crd().V1beta1().ClusterNetworkPolicies().Informer().GetStore(),
 		crdInformerFactory.Crd().V1alpha1().Tiers().Informer().GetStore(),
 		crdInformerFactory.Crd().V1alpha3().ClusterGroups().Informer().GetStore(),
 		appliedToGroupStore,
@@ -169,14 +174,16 @@ func newControllerWithoutEventHandler(objects ...runtime.Object) (*fake.Clientse
 		addressGroupQueue:          workqueue.NewNamedRateLimitingQueue(workqueue.NewItemExponentialFailureRateLimiter(minRetryDelay, maxRetryDelay), "addressGroup"),
 		internalNetworkPolicyQueue: workqueue.NewNamedRateLimitingQueue(workqueue.NewItemExponentialFailureRateLimiter(minRetryDelay, maxRetryDelay), "internalNetworkPolicy"),
 		internalGroupQueue:         workqueue.NewNamedRateLimitingQueue(workqueue.NewItemExponentialFailureRateLimiter(minRetryDelay, maxRetryDelay), "internalGroup"),
+		groupStatusQueue:           workqueue.NewNamedRateLimitingQueue(workqueue.NewItemExponentialFailureRateLimiter(minRetryDelay, maxRetryDelay), "groupStatus"),
 		groupingInterface:          groupEntityIndex,
+		storeSyncState:             newStoreSyncState(),
 	}
 	return client, &networkPolicyController{
 		npController,
 		informerFactory.Core().V1().Namespaces().Informer().GetStore(),
 		informerFactory.Core().V1().Services().Informer().GetStore(),
 		informerFactory.Networking().V1().NetworkPolicies().Informer().GetStore(),
-		crdInformerFactory.Crd().V1alpha1().ClusterNetworkPolicies().Informer().GetStore(),
+		crdInformerFactory.Crd().V1beta1().ClusterNetworkPolicies().Informer().GetStore(),
 		crdInformerFactory.Crd().V1alpha1().Tiers().Informer().GetStore(),
 		cgStore,
 		appliedToGroupStore,
@@ -601,6 +608,7 @@ func TestAddNetworkPolicy(t *testing.T) {
 			key := internalNetworkPolicyKeyFunc(tt.inputPolicy)
 			actualPolicyObj, _, _ := npc.internalNetworkPolicyStore.Get(key)
 			actualPolicy := actualPolicyObj.(*antreatypes.NetworkPolicy)
+			sortRules(tt.expPolicy.Rules)
 			assert.Equal(t, tt.expPolicy, actualPolicy)
 			assert.Equal(t, tt.expAddressGroups, len(npc.addressGroupStore.List()))
 			assert.Equal(t, tt.expAppliedToGroups, len(npc.appliedToGroupStore.List()))
@@ -983,6 +991,7 @@ func TestUpdateNetworkPolicy(t *testing.T) {
 			if actualAddressGroups := len(npc.addressGroupStore.List()); actualAddressGroups != tt.expAddressGroups {
 				t.Errorf("updateNetworkPolicy() got %v, want %v", actualAddressGroups, tt.expAddressGroups)
 			}
+			sortRules(tt.expNetworkPolicy.Rules)
 			if !reflect.DeepEqual(actualPolicy, tt.expNetworkPolicy) {
 				t.Errorf("updateNetworkPolicy() got %#v, want %#v", actualPolicy, tt.expNetworkPolicy)
 			}
@@ -2708,6 +2717,7 @@ func TestProcessNetworkPolicy(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			_, c := newController()
 
+			sortRules(tt.expectedPolicy.Rules)
 			if actualPolicy := c.processNetworkPolicy(tt.inputPolicy); !reflect.DeepEqual(actualPolicy, tt.expectedPolicy) {
 				t.Errorf("processNetworkPolicy() got %v, want %v", actualPolicy, tt.expectedPolicy)
 			}
@@ -2723,6 +2733,64 @@ func TestProcessNetworkPolicy(t *testing.T) {
 	}
 }
 
+func TestSortRules(t *testing.T) {
+	rules := []controlplane.NetworkPolicyRule{
+		{Direction: controlplane.DirectionIn, From: controlplane.NetworkPolicyPeer{AddressGroups: []string{"ag1"}}},
+		{Direction: controlplane.DirectionIn, From: controlplane.NetworkPolicyPeer{AddressGroups: []string{"ag2"}}},
+		{Direction: controlplane.DirectionOut, To: controlplane.NetworkPolicyPeer{AddressGroups: []string{"ag1"}}},
+		{Direction: controlplane.DirectionOut, Name: "egress", To: controlplane.NetworkPolicyPeer{AddressGroups: []string{"ag1"}}},
+		{Direction: controlplane.DirectionIn, Name: "ingress", From: controlplane.NetworkPolicyPeer{AddressGroups: []string{"ag1"}}, AppliedToGroups: []string{"atg1"}},
+		{Direction: controlplane.DirectionIn, Name: "ingress", From: controlplane.NetworkPolicyPeer{AddressGroups: []string{"ag1"}}, AppliedToGroups: []string{"atg2"}},
+	}
+	expected := sortRules(append([]controlplane.NetworkPolicyRule(nil), rules...))
+	// Rules without name come first, then ingress rules before egress rules.
+	assert.Equal(t, "", expected[0].Name)
+	assert.Equal(t, controlplane.DirectionIn, expected[0].Direction)
+	assert.Equal(t, controlplane.DirectionOut, expected[2].Direction)
+	assert.Equal(t, "egress", expected[3].Name)
+
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 20; i++ {
+		permuted := make([]controlplane.NetworkPolicyRule, len(rules))
+		for j, k := range r.Perm(len(rules)) {
+			permuted[j] = rules[k]
+		}
+		assert.Equal(t, expected, sortRules(permuted), "Rules should be sorted in the same order regardless of the input order")
+	}
+}
+
+func TestProcessNetworkPolicyRuleOrder(t *testing.T) {
+	selectorA := metav1.LabelSelector{MatchLabels: map[string]string{"foo1": "bar1"}}
+	selectorB := metav1.LabelSelector{MatchLabels: map[string]string{"foo2": "bar2"}}
+	ingressRules := []networkingv1.NetworkPolicyIngressRule{
+		{From: []networkingv1.NetworkPolicyPeer{{PodSelector: &selectorA}}},
+		{From: []networkingv1.NetworkPolicyPeer{{PodSelector: &selectorB}}},
+		{Ports: []networkingv1.NetworkPolicyPort{{Protocol: &k8sProtocolTCP, Port: &int80}}},
+	}
+	egressRules := []networkingv1.NetworkPolicyEgressRule{
+		{To: []networkingv1.NetworkPolicyPeer{{PodSelector: &selectorA}}},
+		{To: []networkingv1.NetworkPolicyPeer{{PodSelector: &selectorB}}},
+	}
+	newPolicy := func(ingress []networkingv1.NetworkPolicyIngressRule, egress []networkingv1.NetworkPolicyEgressRule) *networkingv1.NetworkPolicy {
+		return &networkingv1.NetworkPolicy{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "nsA", Name: "npA", UID: "uidA"},
+			Spec: networkingv1.NetworkPolicySpec{
+				PodSelector: selectorA,
+				Ingress:     ingress,
+				Egress:      egress,
+			},
+		}
+	}
+	_, c := newController()
+	expectedPolicy := c.processNetworkPolicy(newPolicy(ingressRules, egressRules))
+	permutedPolicy := c.processNetworkPolicy(newPolicy(
+		[]networkingv1.NetworkPolicyIngressRule{ingressRules[2], ingressRules[0], ingressRules[1]},
+		[]networkingv1.NetworkPolicyEgressRule{egressRules[1], egressRules[0]},
+	))
+	// The internal NetworkPolicies must be equal so that updating the store doesn't generate any event.
+	assert.Equal(t, expectedPolicy, permutedPolicy)
+}
+
 func TestPodToGroupMember(t *testing.T) {
 	namedPod := getPod("", "", "", "", true)
 	unNamedPod := getPod("", "", "", "", false)
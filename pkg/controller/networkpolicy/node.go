@@ -0,0 +1,180 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package networkpolicy
+
+import (
+	"fmt"
+	"net"
+	"reflect"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	"antrea.io/antrea/pkg/apis/controlplane"
+	antreatypes "antrea.io/antrea/pkg/controller/types"
+)
+
+// toNodeGroupSelector converts a nodeSelector to a GroupSelector. The NormalizedName of the
+// GroupSelector cannot collide with the ones of the Pod and ExternalEntity GroupSelectors.
+func toNodeGroupSelector(nodeSelector *metav1.LabelSelector) *antreatypes.GroupSelector {
+	nSelector, _ := metav1.LabelSelectorAsSelector(nodeSelector)
+	return &antreatypes.GroupSelector{
+		NormalizedName: fmt.Sprintf("nodeSelector=%s", nSelector.String()),
+		NodeSelector:   nSelector,
+	}
+}
+
+// createAddressGroupForNodeSelector creates an AddressGroup object corresponding to a
+// NetworkPolicyPeer object selecting Nodes. Unlike the AddressGroups selecting Pods and
+// ExternalEntities, it is not added to the grouping interface: its GroupMembers are computed from
+// the Nodes when it is synced, and it is enqueued when a selected Node changes.
+func (n *NetworkPolicyController) createAddressGroupForNodeSelector(nodeSelector *metav1.LabelSelector) string {
+	groupSelector := toNodeGroupSelector(nodeSelector)
+	normalizedUID := getNormalizedUID(groupSelector.NormalizedName)
+	// Get or create an AddressGroup for the generated UID.
+	_, found, _ := n.addressGroupStore.Get(normalizedUID)
+	if found {
+		return normalizedUID
+	}
+	addressGroup := &antreatypes.AddressGroup{
+		UID:      types.UID(normalizedUID),
+		Name:     normalizedUID,
+		Selector: *groupSelector,
+	}
+	klog.V(2).Infof("Creating new AddressGroup %s with selector (%s)", addressGroup.Name, addressGroup.Selector.NormalizedName)
+	n.addressGroupStore.Create(addressGroup)
+	n.enqueueAddressGroup(normalizedUID)
+	return normalizedUID
+}
+
+// getNodeMemberSet knows how to construct a GroupMemberSet that contains the addresses of the
+// Nodes selected by nodeSelector.
+func (n *NetworkPolicyController) getNodeMemberSet(nodeSelector labels.Selector) controlplane.GroupMemberSet {
+	groupMemberSet := controlplane.GroupMemberSet{}
+	nodes, err := n.nodeLister.List(nodeSelector)
+	if err != nil {
+		klog.Errorf("Failed to list Nodes with selector %s: %v", nodeSelector.String(), err)
+		return groupMemberSet
+	}
+	for _, node := range nodes {
+		if member := nodeToGroupMember(node); member != nil {
+			groupMemberSet.Insert(member)
+		}
+	}
+	return groupMemberSet
+}
+
+// nodeToGroupMember converts a Node to a GroupMember holding all its InternalIP and ExternalIP
+// addresses, of both address families. It returns nil if the Node has no such address.
+func nodeToGroupMember(node *v1.Node) *controlplane.GroupMember {
+	ips := getNodeIPs(node)
+	if len(ips) == 0 {
+		return nil
+	}
+	member := &controlplane.GroupMember{}
+	for _, ip := range ips.List() {
+		member.IPs = append(member.IPs, ipStrToIPAddress(ip))
+	}
+	return member
+}
+
+// getNodeIPs returns the InternalIP and ExternalIP addresses of a Node. A Node can have multiple
+// addresses of each type and of each address family.
+func getNodeIPs(node *v1.Node) sets.String {
+	ips := sets.NewString()
+	for _, addr := range node.Status.Addresses {
+		if addr.Type != v1.NodeInternalIP && addr.Type != v1.NodeExternalIP {
+			continue
+		}
+		ip := net.ParseIP(addr.Address)
+		if ip == nil {
+			klog.Warningf("Ignoring invalid %s %s of Node %s", addr.Type, addr.Address, node.Name)
+			continue
+		}
+		ips.Insert(ip.String())
+	}
+	return ips
+}
+
+// filterAddressGroupsForNode returns the keys of the AddressGroups which select the Node.
+func (n *NetworkPolicyController) filterAddressGroupsForNode(node *v1.Node) sets.String {
+	addressGroupKeys := sets.NewString()
+	nodeLabels := labels.Set(node.Labels)
+	for _, obj := range n.addressGroupStore.List() {
+		addressGroup := obj.(*antreatypes.AddressGroup)
+		if addressGroup.Selector.NodeSelector != nil && addressGroup.Selector.NodeSelector.Matches(nodeLabels) {
+			addressGroupKeys.Insert(addressGroup.Name)
+		}
+	}
+	return addressGroupKeys
+}
+
+// addNode retrieves all AddressGroups which select this Node and enqueues them for further
+// processing.
+func (n *NetworkPolicyController) addNode(obj interface{}) {
+	defer n.heartbeat("addNode")
+	node := obj.(*v1.Node)
+	klog.V(2).Infof("Processing Node %s ADD event", node.Name)
+	for key := range n.filterAddressGroupsForNode(node) {
+		n.enqueueAddressGroup(key)
+	}
+}
+
+// updateNode retrieves all AddressGroups which select this Node, before or after the update, and
+// enqueues them for further processing if the labels or the addresses of the Node changed.
+func (n *NetworkPolicyController) updateNode(oldObj, curObj interface{}) {
+	defer n.heartbeat("updateNode")
+	oldNode := oldObj.(*v1.Node)
+	curNode := curObj.(*v1.Node)
+	// No need to trigger processing of groups if there is no change in the Node labels or addresses.
+	if reflect.DeepEqual(oldNode.Labels, curNode.Labels) && getNodeIPs(oldNode).Equal(getNodeIPs(curNode)) {
+		klog.V(4).Infof("No change in Node %s. Skipping AddressGroup evaluation.", curNode.Name)
+		return
+	}
+	klog.V(2).Infof("Processing Node %s UPDATE event", curNode.Name)
+	addressGroupKeys := n.filterAddressGroupsForNode(oldNode).Union(n.filterAddressGroupsForNode(curNode))
+	for key := range addressGroupKeys {
+		n.enqueueAddressGroup(key)
+	}
+}
+
+// deleteNode retrieves all AddressGroups which select this Node and enqueues them for further
+// processing.
+func (n *NetworkPolicyController) deleteNode(old interface{}) {
+	node, ok := old.(*v1.Node)
+	if !ok {
+		tombstone, ok := old.(cache.DeletedFinalStateUnknown)
+		if !ok {
+			klog.Errorf("Error decoding object when deleting Node, invalid type: %v", old)
+			return
+		}
+		node, ok = tombstone.Obj.(*v1.Node)
+		if !ok {
+			klog.Errorf("Error decoding object tombstone when deleting Node, invalid type: %v", tombstone.Obj)
+			return
+		}
+	}
+	defer n.heartbeat("deleteNode")
+
+	klog.V(2).Infof("Processing Node %s DELETE event", node.Name)
+	for key := range n.filterAddressGroupsForNode(node) {
+		n.enqueueAddressGroup(key)
+	}
+}
@@ -0,0 +1,192 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package networkpolicy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"antrea.io/antrea/pkg/apis/controlplane"
+	crdv1beta1 "antrea.io/antrea/pkg/apis/crd/v1beta1"
+	antreatypes "antrea.io/antrea/pkg/controller/types"
+)
+
+func newTestNode(name string, labels map[string]string, addresses ...corev1.NodeAddress) *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels},
+		Status:     corev1.NodeStatus{Addresses: addresses},
+	}
+}
+
+func nodeMember(ips ...string) *controlplane.GroupMember {
+	member := &controlplane.GroupMember{}
+	for _, ip := range ips {
+		member.IPs = append(member.IPs, ipStrToIPAddress(ip))
+	}
+	return member
+}
+
+func TestNodeToGroupMember(t *testing.T) {
+	tests := []struct {
+		name           string
+		node           *corev1.Node
+		expectedMember *controlplane.GroupMember
+	}{
+		{
+			name: "single address",
+			node: newTestNode("node1", nil,
+				corev1.NodeAddress{Type: corev1.NodeInternalIP, Address: "172.16.0.1"}),
+			expectedMember: nodeMember("172.16.0.1"),
+		},
+		{
+			name: "multiple addresses per family",
+			node: newTestNode("node1", nil,
+				corev1.NodeAddress{Type: corev1.NodeInternalIP, Address: "172.16.0.1"},
+				corev1.NodeAddress{Type: corev1.NodeInternalIP, Address: "172.16.1.1"},
+				corev1.NodeAddress{Type: corev1.NodeExternalIP, Address: "1.1.1.1"},
+				corev1.NodeAddress{Type: corev1.NodeInternalIP, Address: "fd00::1"},
+				corev1.NodeAddress{Type: corev1.NodeExternalIP, Address: "2001:db8::1"},
+				corev1.NodeAddress{Type: corev1.NodeExternalIP, Address: "2001:db8::2"},
+				corev1.NodeAddress{Type: corev1.NodeHostName, Address: "node1"}),
+			expectedMember: nodeMember("1.1.1.1", "172.16.0.1", "172.16.1.1", "2001:db8::1", "2001:db8::2", "fd00::1"),
+		},
+		{
+			name: "duplicate and invalid addresses",
+			node: newTestNode("node1", nil,
+				corev1.NodeAddress{Type: corev1.NodeInternalIP, Address: "172.16.0.1"},
+				corev1.NodeAddress{Type: corev1.NodeExternalIP, Address: "172.16.0.1"},
+				corev1.NodeAddress{Type: corev1.NodeExternalIP, Address: "invalid"}),
+			expectedMember: nodeMember("172.16.0.1"),
+		},
+		{
+			name: "no address",
+			node: newTestNode("node1", nil,
+				corev1.NodeAddress{Type: corev1.NodeHostName, Address: "node1"}),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expectedMember, nodeToGroupMember(tt.node))
+		})
+	}
+}
+
+func TestAddressGroupForNodeSelector(t *testing.T) {
+	node1 := newTestNode("node1", map[string]string{"role": "infra"},
+		corev1.NodeAddress{Type: corev1.NodeInternalIP, Address: "172.16.0.1"},
+		corev1.NodeAddress{Type: corev1.NodeInternalIP, Address: "fd00::1"})
+	node2 := newTestNode("node2", map[string]string{"role": "infra"},
+		corev1.NodeAddress{Type: corev1.NodeInternalIP, Address: "172.16.0.2"},
+		corev1.NodeAddress{Type: corev1.NodeExternalIP, Address: "1.1.1.2"},
+		corev1.NodeAddress{Type: corev1.NodeExternalIP, Address: "1.1.1.3"})
+	node3 := newTestNode("node3", map[string]string{"role": "worker"},
+		corev1.NodeAddress{Type: corev1.NodeInternalIP, Address: "172.16.0.3"})
+	_, npc := newController()
+	nodeStore := npc.informerFactory.Core().V1().Nodes().Informer().GetStore()
+	for _, node := range []*corev1.Node{node1, node2, node3} {
+		nodeStore.Add(node)
+	}
+
+	p10 := float64(10)
+	allowAction := crdv1beta1.RuleActionAllow
+	cnp := &crdv1beta1.ClusterNetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "cnpA", UID: "uidA"},
+		Spec: crdv1beta1.ClusterNetworkPolicySpec{
+			AppliedTo: []crdv1beta1.NetworkPolicyPeer{
+				{PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "client"}}},
+			},
+			Priority: p10,
+			Egress: []crdv1beta1.Rule{
+				{
+					To: []crdv1beta1.NetworkPolicyPeer{
+						{NodeSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"role": "infra"}}},
+					},
+					Action: &allowAction,
+				},
+			},
+		},
+	}
+	policy := npc.processClusterNetworkPolicy(cnp)
+	require.Len(t, policy.Rules, 1)
+	require.Len(t, policy.Rules[0].To.AddressGroups, 1)
+	key := policy.Rules[0].To.AddressGroups[0]
+	assert.Equal(t, getNormalizedUID(toNodeGroupSelector(cnp.Spec.Egress[0].To[0].NodeSelector).NormalizedName), key)
+
+	getMembers := func() controlplane.GroupMemberSet {
+		require.NoError(t, npc.syncAddressGroup(key))
+		obj, found, _ := npc.addressGroupStore.Get(key)
+		require.True(t, found)
+		return obj.(*antreatypes.AddressGroup).GroupMembers
+	}
+	expectEnqueued := func(enqueued bool) {
+		if enqueued {
+			require.Equal(t, 1, npc.addressGroupQueue.Len())
+			item, _ := npc.addressGroupQueue.Get()
+			assert.Equal(t, key, item)
+			npc.addressGroupQueue.Done(item)
+		} else {
+			assert.Equal(t, 0, npc.addressGroupQueue.Len())
+		}
+	}
+	expectEnqueued(true)
+	assert.Equal(t, controlplane.NewGroupMemberSet(
+		nodeMember("172.16.0.1", "fd00::1"),
+		nodeMember("1.1.1.2", "1.1.1.3", "172.16.0.2"),
+	), getMembers())
+
+	// An address change of a selected Node is propagated.
+	updatedNode2 := node2.DeepCopy()
+	updatedNode2.Status.Addresses = updatedNode2.Status.Addresses[:2]
+	nodeStore.Update(updatedNode2)
+	npc.updateNode(node2, updatedNode2)
+	expectEnqueued(true)
+	assert.Equal(t, controlplane.NewGroupMemberSet(
+		nodeMember("172.16.0.1", "fd00::1"),
+		nodeMember("1.1.1.2", "172.16.0.2"),
+	), getMembers())
+
+	// Updates of unselected Nodes and updates which change neither the labels nor the addresses are
+	// ignored.
+	updatedNode3 := node3.DeepCopy()
+	updatedNode3.Status.Addresses[0].Address = "172.16.0.4"
+	npc.updateNode(node3, updatedNode3)
+	updatedNode1 := node1.DeepCopy()
+	updatedNode1.Status.Conditions = []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}}
+	npc.updateNode(node1, updatedNode1)
+	expectEnqueued(false)
+
+	// A Node which starts matching the selector is added.
+	updatedNode3.Labels = map[string]string{"role": "infra"}
+	nodeStore.Update(updatedNode3)
+	npc.updateNode(node3, updatedNode3)
+	expectEnqueued(true)
+	assert.Equal(t, 3, len(getMembers()))
+
+	// A Node which stops matching the selector is removed.
+	nodeStore.Update(node3)
+	npc.updateNode(updatedNode3, node3)
+	expectEnqueued(true)
+	assert.Equal(t, 2, len(getMembers()))
+
+	// A deleted Node is removed.
+	nodeStore.Delete(node1)
+	npc.deleteNode(node1)
+	expectEnqueued(true)
+	assert.Equal(t, controlplane.NewGroupMemberSet(nodeMember("1.1.1.2", "172.16.0.2")), getMembers())
+}
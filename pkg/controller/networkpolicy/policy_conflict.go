@@ -0,0 +1,395 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package networkpolicy
+
+import (
+	"net"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"antrea.io/antrea/pkg/apis/controlplane"
+	cpv1beta "antrea.io/antrea/pkg/apis/controlplane/v1beta2"
+	crdv1alpha1 "antrea.io/antrea/pkg/apis/crd/v1alpha1"
+	antreatypes "antrea.io/antrea/pkg/controller/types"
+)
+
+const (
+	// conflictReasonRule is reported when an Antrea-native policy rule is preceded by a rule with
+	// the opposite action which matches some of the same traffic.
+	conflictReasonRule = "a rule with higher precedence and the opposite action matches some of the same traffic"
+	// conflictReasonIsolation is reported when a baseline tier allow rule is preceded by the
+	// isolation of the Pod by a K8s NetworkPolicy.
+	conflictReasonIsolation = "the Pod is isolated by a K8s NetworkPolicy, which drops the traffic it does not allow before baseline tier rules are evaluated"
+
+	// ConflictActionIsolation is the action reported for the implicit isolation of K8s NetworkPolicies.
+	ConflictActionIsolation = "Isolation"
+)
+
+// effectiveTierPriorityK8sNP is a tier priority in between the application tier and the baseline
+// tier, used to order K8s NetworkPolicy rules against Antrea-native policy rules.
+var effectiveTierPriorityK8sNP = float64(DefaultTierPriority+BaselineTierPriority) / 2
+
+// PolicyConflictResponse is the reply struct for antctl policy conflict queries. The conflicts are
+// advisory: they are computed from the policy rules and the group members known by the
+// controller, and a conflict only means that a rule may not take effect for some traffic.
+type PolicyConflictResponse struct {
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name,omitempty"`
+	// Advisory is always true, as a reminder that the conflicts are not verified against the
+	// datapath.
+	Advisory  bool             `json:"advisory"`
+	Conflicts []PolicyConflict `json:"conflicts,omitempty"`
+}
+
+// PolicyConflict describes an Antrea-native policy rule applied to the Pod which may be
+// shadowed by another rule with higher precedence and the opposite action.
+type PolicyConflict struct {
+	Direction     cpv1beta.Direction `json:"direction,omitempty"`
+	ShadowedRule  ConflictRule       `json:"shadowedRule"`
+	ShadowingRule ConflictRule       `json:"shadowingRule"`
+	Reason        string             `json:"reason,omitempty"`
+}
+
+// ConflictRule references a policy rule involved in a PolicyConflict.
+type ConflictRule struct {
+	PolicyRef
+	Type cpv1beta.NetworkPolicyType `json:"type,omitempty"`
+	// RuleIndex is the index of the rule among the rules of the policy in the same direction.
+	// It is not set for the isolation of K8s NetworkPolicies.
+	RuleIndex int    `json:"ruleIndex"`
+	RuleName  string `json:"ruleName,omitempty"`
+	// Action is the action of the rule, or "Isolation" for the implicit isolation of K8s
+	// NetworkPolicies.
+	Action string `json:"action,omitempty"`
+}
+
+// appliedRule is a policy rule applied to the queried Pod.
+type appliedRule struct {
+	policy *antreatypes.NetworkPolicy
+	rule   *controlplane.NetworkPolicyRule
+	// index is the index of the rule among the rules of the policy in the same direction.
+	index int
+}
+
+func (r *appliedRule) isK8sNetworkPolicy() bool {
+	return r.policy.SourceRef.Type == controlplane.K8sNetworkPolicy
+}
+
+func (r *appliedRule) tierPriority() float64 {
+	if r.policy.TierPriority == nil {
+		return effectiveTierPriorityK8sNP
+	}
+	return float64(*r.policy.TierPriority)
+}
+
+func (r *appliedRule) action() crdv1alpha1.RuleAction {
+	if r.rule.Action == nil {
+		return crdv1alpha1.RuleActionAllow
+	}
+	return *r.rule.Action
+}
+
+// precedes returns whether rule r is evaluated before rule o. Rules of different policies with
+// the same tier and policy priorities are not ordered, and neither are K8s NetworkPolicy rules.
+func (r *appliedRule) precedes(o *appliedRule) bool {
+	if r.tierPriority() != o.tierPriority() {
+		return r.tierPriority() < o.tierPriority()
+	}
+	if r.isK8sNetworkPolicy() || o.isK8sNetworkPolicy() {
+		return false
+	}
+	if r.policy.Priority == nil || o.policy.Priority == nil {
+		return false
+	}
+	if *r.policy.Priority != *o.policy.Priority {
+		return *r.policy.Priority < *o.policy.Priority
+	}
+	return r.policy.UID == o.policy.UID && r.rule.Priority < o.rule.Priority
+}
+
+func (r *appliedRule) toConflictRule() ConflictRule {
+	return ConflictRule{
+		PolicyRef: PolicyRef{
+			Namespace: r.policy.SourceRef.Namespace,
+			Name:      r.policy.SourceRef.Name,
+			UID:       r.policy.SourceRef.UID,
+		},
+		Type:      cpv1beta.NetworkPolicyType(r.policy.SourceRef.Type),
+		RuleIndex: r.index,
+		RuleName:  r.rule.Name,
+		Action:    string(r.action()),
+	}
+}
+
+func (r *appliedRule) isDeny() bool {
+	return r.action() == crdv1alpha1.RuleActionDrop || r.action() == crdv1alpha1.RuleActionReject
+}
+
+// QueryPolicyConflicts evaluates every pair of policy rules applied to the selected Pod, and
+// reports the Antrea-native rules which may be shadowed:
+// - by a rule with higher precedence and the opposite action (allow versus drop or reject),
+//   when the peers and the services of the two rules overlap;
+// - by the isolation of the Pod by a K8s NetworkPolicy, for allow rules in the baseline tier.
+// The overlap of peers is computed from the current members of the AddressGroups and from the
+// IPBlocks, ignoring their exceptions.
+func (eq *endpointQuerier) QueryPolicyConflicts(namespace string, podName string) (*PolicyConflictResponse, error) {
+	groups, exists := eq.networkPolicyController.groupingInterface.GetGroupsForPod(namespace, podName)
+	if !exists {
+		return nil, nil
+	}
+	appliedToGroupKeys := sets.NewString(groups[appliedToGroupType]...)
+	policies, err := eq.getAppliedPolicies(appliedToGroupKeys.List())
+	if err != nil {
+		return nil, err
+	}
+
+	var rules []*appliedRule
+	// isolatingPolicies are the K8s NetworkPolicies isolating the Pod in each direction.
+	isolatingPolicies := map[controlplane.Direction][]*antreatypes.NetworkPolicy{}
+	seenPolicies := sets.NewString()
+	for _, policy := range policies {
+		if seenPolicies.Has(policy.Name) {
+			continue
+		}
+		seenPolicies.Insert(policy.Name)
+		directionIndexes := map[controlplane.Direction]int{}
+		for i := range policy.Rules {
+			rule := &policy.Rules[i]
+			index := directionIndexes[rule.Direction]
+			directionIndexes[rule.Direction]++
+			if policy.SourceRef.Type == controlplane.K8sNetworkPolicy {
+				// A K8s NetworkPolicy isolates the Pods it applies to in the direction of its rules.
+				if index == 0 {
+					isolatingPolicies[rule.Direction] = append(isolatingPolicies[rule.Direction], policy)
+				}
+				// The deny-all rule only marks the isolation of the Pods.
+				if rule.Action == nil {
+					continue
+				}
+			}
+			if len(rule.AppliedToGroups) > 0 && !appliedToGroupKeys.HasAny(rule.AppliedToGroups...) {
+				continue
+			}
+			rules = append(rules, &appliedRule{policy: policy, rule: rule, index: index})
+		}
+	}
+
+	conflicts := make([]PolicyConflict, 0)
+	for _, shadowed := range rules {
+		if shadowed.isK8sNetworkPolicy() {
+			continue
+		}
+		for _, shadowing := range rules {
+			if shadowing.rule.Direction != shadowed.rule.Direction || !shadowing.precedes(shadowed) {
+				continue
+			}
+			if shadowing.isDeny() == shadowed.isDeny() {
+				continue
+			}
+			if !servicesOverlap(shadowing.rule.Services, shadowed.rule.Services) ||
+				!eq.peersOverlap(getRulePeer(shadowing.rule), getRulePeer(shadowed.rule)) {
+				continue
+			}
+			conflicts = append(conflicts, PolicyConflict{
+				Direction:     cpv1beta.Direction(shadowed.rule.Direction),
+				ShadowedRule:  shadowed.toConflictRule(),
+				ShadowingRule: shadowing.toConflictRule(),
+				Reason:        conflictReasonRule,
+			})
+		}
+		// K8s NetworkPolicies don't isolate workloads from ARP and Neighbor Discovery messages.
+		if shadowed.tierPriority() > effectiveTierPriorityK8sNP && !shadowed.isDeny() && !isLayer2Services(shadowed.rule.Services) {
+			for _, policy := range isolatingPolicies[shadowed.rule.Direction] {
+				conflicts = append(conflicts, PolicyConflict{
+					Direction:    cpv1beta.Direction(shadowed.rule.Direction),
+					ShadowedRule: shadowed.toConflictRule(),
+					ShadowingRule: ConflictRule{
+						PolicyRef: PolicyRef{
+							Namespace: policy.SourceRef.Namespace,
+							Name:      policy.SourceRef.Name,
+							UID:       policy.SourceRef.UID,
+						},
+						Type:   cpv1beta.NetworkPolicyType(policy.SourceRef.Type),
+						Action: ConflictActionIsolation,
+					},
+					Reason: conflictReasonIsolation,
+				})
+			}
+		}
+	}
+	sort.Slice(conflicts, func(i, j int) bool {
+		a, b := conflicts[i], conflicts[j]
+		if a.Direction != b.Direction {
+			return a.Direction < b.Direction
+		}
+		if a.ShadowedRule.UID != b.ShadowedRule.UID {
+			return a.ShadowedRule.UID < b.ShadowedRule.UID
+		}
+		if a.ShadowedRule.RuleIndex != b.ShadowedRule.RuleIndex {
+			return a.ShadowedRule.RuleIndex < b.ShadowedRule.RuleIndex
+		}
+		if a.ShadowingRule.UID != b.ShadowingRule.UID {
+			return a.ShadowingRule.UID < b.ShadowingRule.UID
+		}
+		return a.ShadowingRule.RuleIndex < b.ShadowingRule.RuleIndex
+	})
+	return &PolicyConflictResponse{
+		Namespace: namespace,
+		Name:      podName,
+		Advisory:  true,
+		Conflicts: conflicts,
+	}, nil
+}
+
+func getRulePeer(rule *controlplane.NetworkPolicyRule) *controlplane.NetworkPolicyPeer {
+	if rule.Direction == controlplane.DirectionIn {
+		return &rule.From
+	}
+	return &rule.To
+}
+
+// peerAddresses is the set of addresses matched by a NetworkPolicyPeer.
+type peerAddresses struct {
+	matchAll      bool
+	addressGroups sets.String
+	ips           []net.IP
+	cidrs         []*net.IPNet
+}
+
+func (eq *endpointQuerier) getPeerAddresses(peer *controlplane.NetworkPolicyPeer) *peerAddresses {
+	addresses := &peerAddresses{addressGroups: sets.NewString(peer.AddressGroups...)}
+	if len(peer.AddressGroups) == 0 && len(peer.IPBlocks) == 0 {
+		addresses.matchAll = true
+		return addresses
+	}
+	for _, ipBlock := range peer.IPBlocks {
+		addresses.cidrs = append(addresses.cidrs, &net.IPNet{
+			IP:   net.IP(ipBlock.CIDR.IP),
+			Mask: net.CIDRMask(int(ipBlock.CIDR.PrefixLength), len(ipBlock.CIDR.IP)*8),
+		})
+	}
+	for _, addressGroupKey := range peer.AddressGroups {
+		addressGroup, found, _ := eq.networkPolicyController.addressGroupStore.Get(addressGroupKey)
+		if !found {
+			continue
+		}
+		for _, member := range addressGroup.(*antreatypes.AddressGroup).GroupMembers {
+			for _, ip := range member.IPs {
+				addresses.ips = append(addresses.ips, net.IP(ip))
+			}
+		}
+	}
+	return addresses
+}
+
+// peersOverlap returns whether some addresses are matched by both peers.
+func (eq *endpointQuerier) peersOverlap(peer1, peer2 *controlplane.NetworkPolicyPeer) bool {
+	a, b := eq.getPeerAddresses(peer1), eq.getPeerAddresses(peer2)
+	if a.matchAll || b.matchAll || a.addressGroups.HasAny(b.addressGroups.List()...) {
+		return true
+	}
+	for _, cidrA := range a.cidrs {
+		for _, cidrB := range b.cidrs {
+			if cidrA.Contains(cidrB.IP) || cidrB.Contains(cidrA.IP) {
+				return true
+			}
+		}
+		for _, ip := range b.ips {
+			if cidrA.Contains(ip) {
+				return true
+			}
+		}
+	}
+	for _, ipA := range a.ips {
+		for _, cidrB := range b.cidrs {
+			if cidrB.Contains(ipA) {
+				return true
+			}
+		}
+		for _, ipB := range b.ips {
+			if ipA.Equal(ipB) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// servicesOverlap returns whether some traffic is matched by both lists of services. An empty
+// list matches all traffic.
+func servicesOverlap(services1, services2 []controlplane.Service) bool {
+	// The layer 2 rules are enforced in dedicated tables, hence never overlap the other rules.
+	if isLayer2Services(services1) != isLayer2Services(services2) {
+		return false
+	}
+	if len(services1) == 0 || len(services2) == 0 {
+		return true
+	}
+	for i := range services1 {
+		for j := range services2 {
+			if serviceOverlap(&services1[i], &services2[j]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func serviceOverlap(s1, s2 *controlplane.Service) bool {
+	protocol1, protocol2 := controlplane.ProtocolTCP, controlplane.ProtocolTCP
+	if s1.Protocol != nil {
+		protocol1 = *s1.Protocol
+	}
+	if s2.Protocol != nil {
+		protocol2 = *s2.Protocol
+	}
+	if protocol1 != protocol2 {
+		return false
+	}
+	if s1.ICMPType != nil && s2.ICMPType != nil && *s1.ICMPType != *s2.ICMPType {
+		return false
+	}
+	if s1.Port == nil || s2.Port == nil {
+		return true
+	}
+	// Named ports are resolved by the agents, assume they may overlap.
+	if s1.Port.Type == intstr.String || s2.Port.Type == intstr.String {
+		return true
+	}
+	start1, end1 := getPortRange(s1)
+	start2, end2 := getPortRange(s2)
+	return start1 <= end2 && start2 <= end1
+}
+
+// isLayer2Services returns whether the services are the ones of a layer 2 rule of a
+// ClusterNetworkPolicy.
+func isLayer2Services(services []controlplane.Service) bool {
+	for _, s := range services {
+		if s.Protocol != nil && (*s.Protocol == controlplane.ProtocolARP || *s.Protocol == controlplane.ProtocolICMPv6) {
+			return true
+		}
+	}
+	return false
+}
+
+func getPortRange(s *controlplane.Service) (int32, int32) {
+	start := s.Port.IntVal
+	if s.EndPort != nil {
+		return start, *s.EndPort
+	}
+	return start, start
+}
@@ -0,0 +1,251 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package networkpolicy
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"antrea.io/antrea/pkg/apis/controlplane"
+	cpv1beta "antrea.io/antrea/pkg/apis/controlplane/v1beta2"
+	crdv1alpha1 "antrea.io/antrea/pkg/apis/crd/v1alpha1"
+	antreatypes "antrea.io/antrea/pkg/controller/types"
+)
+
+func TestQueryPolicyConflicts(t *testing.T) {
+	allowAction := crdv1alpha1.RuleActionAllow
+	dropAction := crdv1alpha1.RuleActionDrop
+	securityOpsTierPriority := int32(100)
+	applicationTierPriority := DefaultTierPriority
+	baselineTierPriority := BaselineTierPriority
+	policyPriority := float64(1)
+	int90 := int32(90)
+	int443 := intstr.FromInt(443)
+
+	acnpDrop := &antreatypes.NetworkPolicy{
+		Name:            "uid-acnp",
+		UID:             "uid-acnp",
+		SourceRef:       &controlplane.NetworkPolicyReference{Type: controlplane.AntreaClusterNetworkPolicy, Name: "acnp-drop", UID: "uid-acnp"},
+		TierPriority:    &securityOpsTierPriority,
+		Priority:        &policyPriority,
+		AppliedToGroups: []string{"atg1"},
+		Rules: []controlplane.NetworkPolicyRule{
+			{
+				Direction: controlplane.DirectionIn,
+				From:      controlplane.NetworkPolicyPeer{AddressGroups: []string{"ag1"}},
+				Services:  []controlplane.Service{{Protocol: &protocolTCP, Port: &int80}},
+				Action:    &dropAction,
+			},
+		},
+	}
+	anpAllow := &antreatypes.NetworkPolicy{
+		Name:            "uid-anp",
+		UID:             "uid-anp",
+		SourceRef:       &controlplane.NetworkPolicyReference{Type: controlplane.AntreaNetworkPolicy, Namespace: "ns1", Name: "anp-allow", UID: "uid-anp"},
+		TierPriority:    &applicationTierPriority,
+		Priority:        &policyPriority,
+		AppliedToGroups: []string{"atg1"},
+		Rules: []controlplane.NetworkPolicyRule{
+			{
+				Direction: controlplane.DirectionIn,
+				From:      controlplane.NetworkPolicyPeer{IPBlocks: []controlplane.IPBlock{{CIDR: controlplane.IPNet{IP: controlplane.IPAddress(net.ParseIP("10.0.0.0").To4()), PrefixLength: 24}}}},
+				Services:  []controlplane.Service{{Protocol: &protocolTCP, Port: &int80, EndPort: &int90}},
+				Name:      "allow-web",
+				Action:    &allowAction,
+			},
+			{
+				Direction: controlplane.DirectionIn,
+				From:      controlplane.NetworkPolicyPeer{IPBlocks: []controlplane.IPBlock{{CIDR: controlplane.IPNet{IP: controlplane.IPAddress(net.ParseIP("10.0.0.0").To4()), PrefixLength: 24}}}},
+				Services:  []controlplane.Service{{Protocol: &protocolTCP, Port: &int443}},
+				Name:      "allow-https",
+				Priority:  1,
+				Action:    &allowAction,
+			},
+		},
+	}
+	baselineAllow := &antreatypes.NetworkPolicy{
+		Name:            "uid-baseline",
+		UID:             "uid-baseline",
+		SourceRef:       &controlplane.NetworkPolicyReference{Type: controlplane.AntreaClusterNetworkPolicy, Name: "baseline-allow", UID: "uid-baseline"},
+		TierPriority:    &baselineTierPriority,
+		Priority:        &policyPriority,
+		AppliedToGroups: []string{"atg1"},
+		Rules: []controlplane.NetworkPolicyRule{
+			{
+				Direction: controlplane.DirectionIn,
+				Action:    &allowAction,
+			},
+		},
+	}
+	knp := &antreatypes.NetworkPolicy{
+		Name:            "uid-knp",
+		UID:             "uid-knp",
+		SourceRef:       &controlplane.NetworkPolicyReference{Type: controlplane.K8sNetworkPolicy, Namespace: "ns1", Name: "default-deny", UID: "uid-knp"},
+		AppliedToGroups: []string{"atg1"},
+		Rules:           []controlplane.NetworkPolicyRule{denyAllIngressRule},
+	}
+
+	_, c := newController()
+	c.groupingInterface.AddNamespace(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns1"}})
+	c.groupingInterface.AddPod(&corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "pod1", Labels: map[string]string{"app": "web"}},
+		Spec:       corev1.PodSpec{NodeName: "node1"},
+		Status:     corev1.PodStatus{PodIP: "10.0.0.1"},
+	})
+	c.groupingInterface.AddGroup(appliedToGroupType, "atg1", antreatypes.NewGroupSelector("ns1", &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}}, nil, nil))
+	require.NoError(t, c.addressGroupStore.Create(&antreatypes.AddressGroup{
+		UID:  "ag1",
+		Name: "ag1",
+		GroupMembers: controlplane.NewGroupMemberSet(&controlplane.GroupMember{
+			IPs: []controlplane.IPAddress{controlplane.IPAddress(net.ParseIP("10.0.0.2"))},
+		}),
+	}))
+	for _, policy := range []*antreatypes.NetworkPolicy{acnpDrop, anpAllow, baselineAllow, knp} {
+		require.NoError(t, c.internalNetworkPolicyStore.Create(policy))
+	}
+
+	querier := NewEndpointQuerier(c.NetworkPolicyController)
+	response, err := querier.QueryPolicyConflicts("ns1", "non-existing-pod")
+	require.NoError(t, err)
+	assert.Nil(t, response)
+
+	response, err = querier.QueryPolicyConflicts("ns1", "pod1")
+	require.NoError(t, err)
+	acnpDropRule := ConflictRule{
+		PolicyRef: PolicyRef{Name: "acnp-drop", UID: "uid-acnp"},
+		Type:      cpv1beta.AntreaClusterNetworkPolicy,
+		Action:    "Drop",
+	}
+	baselineAllowRule := ConflictRule{
+		PolicyRef: PolicyRef{Name: "baseline-allow", UID: "uid-baseline"},
+		Type:      cpv1beta.AntreaClusterNetworkPolicy,
+		Action:    "Allow",
+	}
+	expected := &PolicyConflictResponse{
+		Namespace: "ns1",
+		Name:      "pod1",
+		Advisory:  true,
+		Conflicts: []PolicyConflict{
+			{
+				Direction: cpv1beta.DirectionIn,
+				ShadowedRule: ConflictRule{
+					PolicyRef: PolicyRef{Namespace: "ns1", Name: "anp-allow", UID: "uid-anp"},
+					Type:      cpv1beta.AntreaNetworkPolicy,
+					RuleName:  "allow-web",
+					Action:    "Allow",
+				},
+				ShadowingRule: acnpDropRule,
+				Reason:        conflictReasonRule,
+			},
+			{
+				Direction:     cpv1beta.DirectionIn,
+				ShadowedRule:  baselineAllowRule,
+				ShadowingRule: acnpDropRule,
+				Reason:        conflictReasonRule,
+			},
+			{
+				Direction:    cpv1beta.DirectionIn,
+				ShadowedRule: baselineAllowRule,
+				ShadowingRule: ConflictRule{
+					PolicyRef: PolicyRef{Namespace: "ns1", Name: "default-deny", UID: "uid-knp"},
+					Type:      cpv1beta.K8sNetworkPolicy,
+					Action:    ConflictActionIsolation,
+				},
+				Reason: conflictReasonIsolation,
+			},
+		},
+	}
+	assert.Equal(t, expected, response)
+}
+
+func TestServicesOverlap(t *testing.T) {
+	protocolUDP := controlplane.ProtocolUDP
+	int85 := intstr.FromInt(85)
+	int32For90 := int32(90)
+	protocolARP := controlplane.ProtocolARP
+	protocolICMPv6 := controlplane.ProtocolICMPv6
+	typeNS, typeNA := int32(135), int32(136)
+	tests := []struct {
+		name      string
+		services1 []controlplane.Service
+		services2 []controlplane.Service
+		expected  bool
+	}{
+		{
+			name:      "all traffic",
+			services2: []controlplane.Service{{Protocol: &protocolTCP, Port: &int80}},
+			expected:  true,
+		},
+		{
+			name:      "default protocol",
+			services1: []controlplane.Service{{Port: &int80}},
+			services2: []controlplane.Service{{Protocol: &protocolTCP, Port: &int80}},
+			expected:  true,
+		},
+		{
+			name:      "different protocols",
+			services1: []controlplane.Service{{Protocol: &protocolUDP, Port: &int80}},
+			services2: []controlplane.Service{{Protocol: &protocolTCP, Port: &int80}},
+			expected:  false,
+		},
+		{
+			name:      "port in range",
+			services1: []controlplane.Service{{Protocol: &protocolTCP, Port: &int80, EndPort: &int32For90}},
+			services2: []controlplane.Service{{Protocol: &protocolTCP, Port: &int85}},
+			expected:  true,
+		},
+		{
+			name:      "port out of range",
+			services1: []controlplane.Service{{Protocol: &protocolTCP, Port: &int80, EndPort: &int32For90}},
+			services2: []controlplane.Service{{Protocol: &protocolTCP, Port: &int1000}},
+			expected:  false,
+		},
+		{
+			name:      "named port",
+			services1: []controlplane.Service{{Protocol: &protocolTCP, Port: &strHTTP}},
+			services2: []controlplane.Service{{Protocol: &protocolTCP, Port: &int1000}},
+			expected:  true,
+		},
+		{
+			name:      "layer 2 rule and all traffic",
+			services2: []controlplane.Service{{Protocol: &protocolARP}},
+			expected:  false,
+		},
+		{
+			name:      "all Neighbor Discovery messages",
+			services1: []controlplane.Service{{Protocol: &protocolICMPv6}},
+			services2: []controlplane.Service{{Protocol: &protocolICMPv6, ICMPType: &typeNS}},
+			expected:  true,
+		},
+		{
+			name:      "different Neighbor Discovery messages",
+			services1: []controlplane.Service{{Protocol: &protocolICMPv6, ICMPType: &typeNA}},
+			services2: []controlplane.Service{{Protocol: &protocolICMPv6, ICMPType: &typeNS}},
+			expected:  false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, servicesOverlap(tt.services1, tt.services2))
+			assert.Equal(t, tt.expected, servicesOverlap(tt.services2, tt.services1))
+		})
+	}
+}
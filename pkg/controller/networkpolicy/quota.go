@@ -0,0 +1,418 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package networkpolicy
+
+import (
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	"antrea.io/antrea/pkg/apis/controlplane"
+	cpv1beta "antrea.io/antrea/pkg/apis/controlplane/v1beta2"
+	"antrea.io/antrea/pkg/controller/metrics"
+	antreatypes "antrea.io/antrea/pkg/controller/types"
+	"antrea.io/antrea/pkg/util/flowcost"
+)
+
+const (
+	quotaExceededReason = "FlowQuotaExceeded"
+	quotaAdmittedReason = "FlowQuotaAdmitted"
+)
+
+// PolicyQuotaUsage is the reply struct for antctl policy quota queries. It describes the usage of
+// the flow quota of a Namespace.
+type PolicyQuotaUsage struct {
+	Namespace string `json:"namespace"`
+	// Quota is the maximum number of flows of the NetworkPolicies of the Namespace.
+	Quota int `json:"quota"`
+	// Flows is the estimated number of flows of the enforced NetworkPolicies of the Namespace.
+	Flows int `json:"flows"`
+	// Policies is the number of NetworkPolicies of the Namespace.
+	Policies int `json:"policies"`
+	// ExceedingPolicies are the NetworkPolicies of the Namespace which are not enforced as they
+	// exceed the quota.
+	ExceedingPolicies []QuotaPolicyRef `json:"exceedingPolicies,omitempty"`
+}
+
+// QuotaPolicyRef references a policy which exceeds the flow quota of its Namespace.
+type QuotaPolicyRef struct {
+	PolicyRef
+	Type  cpv1beta.NetworkPolicyType `json:"type,omitempty"`
+	Flows int                        `json:"flows"`
+}
+
+// policyUsage is the estimated number of flows of a namespaced NetworkPolicy.
+type policyUsage struct {
+	key               string
+	sourceRef         *controlplane.NetworkPolicyReference
+	creationTimestamp time.Time
+	flows             int
+	admitted          bool
+}
+
+// policyQuota decides which NetworkPolicies of each Namespace fit in the flow quota of the
+// Namespace. The policies are considered in the order of their creation, and a policy is admitted
+// if its flows fit in the quota left by the older admitted policies. This way, creating a policy
+// or growing its groups never evicts an older policy.
+type policyQuota struct {
+	flowQuota int
+
+	mutex sync.RWMutex
+	// namespaces maps the Namespaces to the usages of their policies, keyed by the keys of the
+	// internal NetworkPolicies.
+	namespaces map[string]map[string]*policyUsage
+}
+
+func newPolicyQuota(flowQuota int) *policyQuota {
+	return &policyQuota{
+		flowQuota:  flowQuota,
+		namespaces: map[string]map[string]*policyUsage{},
+	}
+}
+
+// update records the estimated number of flows of a policy, and returns whether the policy is
+// admitted and whether it was admitted before, along with the keys of the other policies of the
+// Namespace whose admission changed. A new policy is considered as admitted before.
+func (q *policyQuota) update(key string, sourceRef *controlplane.NetworkPolicyReference, creationTimestamp time.Time, flows int) (bool, bool, []string) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	usages, exists := q.namespaces[sourceRef.Namespace]
+	if !exists {
+		usages = map[string]*policyUsage{}
+		q.namespaces[sourceRef.Namespace] = usages
+	}
+	usage, exists := usages[key]
+	if !exists {
+		usage = &policyUsage{key: key, sourceRef: sourceRef, creationTimestamp: creationTimestamp, admitted: true}
+		usages[key] = usage
+	}
+	wasAdmitted := usage.admitted
+	usage.flows = flows
+	changed := q.admitLocked(sourceRef.Namespace)
+	var others []string
+	for _, k := range changed {
+		if k != key {
+			others = append(others, k)
+		}
+	}
+	return usage.admitted, wasAdmitted, others
+}
+
+// delete forgets a deleted policy, and returns the keys of the other policies of the Namespace
+// which are admitted in the quota it released.
+func (q *policyQuota) delete(key, namespace string) []string {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	usages, exists := q.namespaces[namespace]
+	if !exists {
+		return nil
+	}
+	if _, exists := usages[key]; !exists {
+		return nil
+	}
+	delete(usages, key)
+	if len(usages) == 0 {
+		delete(q.namespaces, namespace)
+		metrics.NetworkPolicyFlowQuotaUsage.DeleteLabelValues(namespace)
+		metrics.NetworkPoliciesOverFlowQuota.DeleteLabelValues(namespace)
+		return nil
+	}
+	return q.admitLocked(namespace)
+}
+
+// admitLocked re-evaluates the admission of the policies of a Namespace, and returns the keys of
+// the policies whose admission changed. The caller must hold the mutex.
+func (q *policyQuota) admitLocked(namespace string) []string {
+	sortedUsages := q.sortedUsagesLocked(namespace)
+	var changed []string
+	flows, exceeding := 0, 0
+	for _, usage := range sortedUsages {
+		admitted := flows+usage.flows <= q.flowQuota
+		if admitted {
+			flows += usage.flows
+		} else {
+			exceeding++
+		}
+		if admitted != usage.admitted {
+			usage.admitted = admitted
+			changed = append(changed, usage.key)
+		}
+	}
+	metrics.NetworkPolicyFlowQuotaUsage.WithLabelValues(namespace).Set(float64(flows))
+	metrics.NetworkPoliciesOverFlowQuota.WithLabelValues(namespace).Set(float64(exceeding))
+	return changed
+}
+
+// sortedUsagesLocked returns the usages of the policies of a Namespace in the order of their
+// creation. The caller must hold the mutex.
+func (q *policyQuota) sortedUsagesLocked(namespace string) []*policyUsage {
+	usages := make([]*policyUsage, 0, len(q.namespaces[namespace]))
+	for _, usage := range q.namespaces[namespace] {
+		usages = append(usages, usage)
+	}
+	sort.Slice(usages, func(i, j int) bool {
+		if !usages[i].creationTimestamp.Equal(usages[j].creationTimestamp) {
+			return usages[i].creationTimestamp.Before(usages[j].creationTimestamp)
+		}
+		return usages[i].key < usages[j].key
+	})
+	return usages
+}
+
+// list returns the usages of the quotas of the Namespaces, or of the provided Namespace if it's
+// not empty, sorted by Namespace.
+func (q *policyQuota) list(namespace string) []PolicyQuotaUsage {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+	var namespaces []string
+	if namespace != "" {
+		namespaces = []string{namespace}
+	} else {
+		for ns := range q.namespaces {
+			namespaces = append(namespaces, ns)
+		}
+		sort.Strings(namespaces)
+	}
+	quotaUsages := make([]PolicyQuotaUsage, 0, len(namespaces))
+	for _, ns := range namespaces {
+		quotaUsage := PolicyQuotaUsage{Namespace: ns, Quota: q.flowQuota}
+		for _, usage := range q.sortedUsagesLocked(ns) {
+			quotaUsage.Policies++
+			if usage.admitted {
+				quotaUsage.Flows += usage.flows
+				continue
+			}
+			quotaUsage.ExceedingPolicies = append(quotaUsage.ExceedingPolicies, QuotaPolicyRef{
+				PolicyRef: PolicyRef{
+					Namespace: usage.sourceRef.Namespace,
+					Name:      usage.sourceRef.Name,
+					UID:       usage.sourceRef.UID,
+				},
+				Type:  cpv1beta.NetworkPolicyType(usage.sourceRef.Type),
+				Flows: usage.flows,
+			})
+		}
+		quotaUsages = append(quotaUsages, quotaUsage)
+	}
+	return quotaUsages
+}
+
+// isQuotaEnforced returns whether the flows of a policy are counted against the quota of a
+// Namespace, which is the case of the K8s NetworkPolicies and the Antrea NetworkPolicies.
+func isQuotaEnforced(sourceRef *controlplane.NetworkPolicyReference) bool {
+	return sourceRef.Type == controlplane.K8sNetworkPolicy || sourceRef.Type == controlplane.AntreaNetworkPolicy
+}
+
+// QueryPolicyQuotas returns the usages of the flow quotas of the Namespaces, or of the provided
+// Namespace if it's not empty. It returns nil if no quota is configured.
+func (eq *endpointQuerier) QueryPolicyQuotas(namespace string) ([]PolicyQuotaUsage, error) {
+	n := eq.networkPolicyController
+	if n.policyQuota == nil {
+		return nil, nil
+	}
+	return n.policyQuota.list(namespace), nil
+}
+
+// applyPolicyQuota estimates the number of flows of an internal NetworkPolicy spanning the
+// provided Nodes, and returns whether the policy fits in the flow quota of its Namespace. The
+// other policies of the Namespace whose admission changed are enqueued, so that they are enforced
+// or withdrawn.
+func (n *NetworkPolicyController) applyPolicyQuota(key string, internalNP *antreatypes.NetworkPolicy) bool {
+	creationTimestamp, found := n.policyCreationTimestamp(internalNP.SourceRef)
+	if !found {
+		// The policy is being deleted.
+		return true
+	}
+	flows := n.estimatePolicyFlows(internalNP)
+	admitted, wasAdmitted, changed := n.policyQuota.update(key, internalNP.SourceRef, creationTimestamp, flows)
+	for _, k := range changed {
+		n.enqueueInternalNetworkPolicy(k)
+	}
+	if !admitted && wasAdmitted {
+		klog.Infof("%s exceeds the flow quota of its Namespace with %d estimated flows, it will not be enforced", internalNP.SourceRef.ToString(), flows)
+		n.recordPolicyEvent(internalNP.SourceRef, v1.EventTypeWarning, quotaExceededReason,
+			"The estimated %d OpenFlow flows of the policy exceed the flow quota left in Namespace %s, the policy is not enforced", flows, internalNP.SourceRef.Namespace)
+	} else if admitted && !wasAdmitted {
+		klog.Infof("%s fits in the flow quota of its Namespace with %d estimated flows, it will be enforced", internalNP.SourceRef.ToString(), flows)
+		n.recordPolicyEvent(internalNP.SourceRef, v1.EventTypeNormal, quotaAdmittedReason,
+			"The estimated %d OpenFlow flows of the policy fit in the flow quota left in Namespace %s, the policy is enforced", flows, internalNP.SourceRef.Namespace)
+	}
+	return admitted
+}
+
+// releasePolicyQuota releases the quota used by a deleted policy, and enqueues the other policies
+// of the Namespace which fit in the released quota.
+func (n *NetworkPolicyController) releasePolicyQuota(key string, sourceRef *controlplane.NetworkPolicyReference) {
+	if n.policyQuota == nil || !isQuotaEnforced(sourceRef) {
+		return
+	}
+	for _, k := range n.policyQuota.delete(key, sourceRef.Namespace) {
+		n.enqueueInternalNetworkPolicy(k)
+	}
+}
+
+// policyCreationTimestamp returns the creation time of the original NetworkPolicy, and false if it
+// doesn't exist anymore.
+func (n *NetworkPolicyController) policyCreationTimestamp(sourceRef *controlplane.NetworkPolicyReference) (time.Time, bool) {
+	switch sourceRef.Type {
+	case controlplane.K8sNetworkPolicy:
+		np, err := n.networkPolicyLister.NetworkPolicies(sourceRef.Namespace).Get(sourceRef.Name)
+		if err != nil {
+			return time.Time{}, false
+		}
+		return np.CreationTimestamp.Time, true
+	case controlplane.AntreaNetworkPolicy:
+		anp, err := n.anpLister.NetworkPolicies(sourceRef.Namespace).Get(sourceRef.Name)
+		if err != nil {
+			return time.Time{}, false
+		}
+		return anp.CreationTimestamp.Time, true
+	}
+	return time.Time{}, false
+}
+
+// recordPolicyEvent records an Event for the original NetworkPolicy.
+func (n *NetworkPolicyController) recordPolicyEvent(sourceRef *controlplane.NetworkPolicyReference, eventType, reason, messageFmt string, args ...interface{}) {
+	ref := &v1.ObjectReference{
+		Namespace: sourceRef.Namespace,
+		Name:      sourceRef.Name,
+		UID:       sourceRef.UID,
+	}
+	if sourceRef.Type == controlplane.K8sNetworkPolicy {
+		ref.Kind, ref.APIVersion = "NetworkPolicy", "networking.k8s.io/v1"
+	} else {
+		ref.Kind, ref.APIVersion = "NetworkPolicy", "crd.antrea.io/v1beta1"
+	}
+	n.eventRecorder.Eventf(ref, eventType, reason, messageFmt, args...)
+}
+
+// estimatePolicyFlows returns the estimated number of OpenFlow flows installed by the agents to
+// realize an internal NetworkPolicy, summed over the Nodes where its rules are applied.
+func (n *NetworkPolicyController) estimatePolicyFlows(internalNP *antreatypes.NetworkPolicy) int {
+	isK8sNetworkPolicy := internalNP.SourceRef.Type == controlplane.K8sNetworkPolicy
+	flows := 0
+	for i := range internalNP.Rules {
+		rule := &internalNP.Rules[i]
+		appliedToGroups := internalNP.AppliedToGroups
+		if len(rule.AppliedToGroups) > 0 {
+			appliedToGroups = rule.AppliedToGroups
+		}
+		peer := &rule.From
+		if rule.Direction == controlplane.DirectionOut {
+			peer = &rule.To
+		}
+		peerIPs := 0
+		for _, name := range peer.AddressGroups {
+			if obj, found, _ := n.addressGroupStore.Get(name); found {
+				for _, member := range obj.(*antreatypes.AddressGroup).GroupMembers {
+					peerIPs += len(member.IPs)
+				}
+			}
+		}
+		for nodeName, members := range n.appliedToMembersByNode(appliedToGroups) {
+			hasIPv4, hasIPv6 := false, false
+			appliedToAddresses := 0
+			for _, member := range members {
+				for _, ip := range member.IPs {
+					if net.IP(ip).To4() != nil {
+						hasIPv4 = true
+					} else {
+						hasIPv6 = true
+					}
+				}
+				// The ingress rules match the OVS ports of the workloads, the egress rules
+				// match their IPs.
+				if rule.Direction == controlplane.DirectionIn {
+					appliedToAddresses++
+				} else {
+					appliedToAddresses += len(member.IPs)
+				}
+			}
+			if !hasIPv6 {
+				// Assume IPv4 when the IPs are unknown.
+				hasIPv4 = true
+			}
+			ipFamilies := 0
+			if hasIPv4 {
+				ipFamilies++
+			}
+			if hasIPv6 {
+				ipFamilies++
+			}
+			peerAddresses := peerIPs
+			for j := range peer.IPBlocks {
+				block := &peer.IPBlocks[j]
+				if isIPv4 := net.IP(block.CIDR.IP).To4() != nil; (isIPv4 && !hasIPv4) || (!isIPv4 && !hasIPv6) {
+					continue
+				}
+				except := make([]*net.IPNet, 0, len(block.Except))
+				for k := range block.Except {
+					except = append(except, ipNetToNetIPNet(block.Except[k]))
+				}
+				peerAddresses += flowcost.IPBlockAddresses(ipNetToNetIPNet(block.CIDR), except)
+			}
+			serviceMatches := 0
+			for _, service := range rule.Services {
+				serviceMatches += flowcost.ServiceMatches(service.Port, service.EndPort, ipFamilies)
+			}
+			flowRule := &flowcost.Rule{
+				IsK8sNetworkPolicy: isK8sNetworkPolicy,
+				Action:             rule.Action,
+				AppliedToAddresses: appliedToAddresses,
+				PeerAddresses:      peerAddresses,
+				ServiceMatches:     serviceMatches,
+				IPFamilies:         ipFamilies,
+			}
+			flows += flowRule.Flows()
+			klog.V(4).Infof("Estimated %d flows for rule %d of %s on Node %s", flowRule.Flows(), i, internalNP.SourceRef.ToString(), nodeName)
+		}
+	}
+	return flows
+}
+
+// appliedToMembersByNode returns the members of the AppliedToGroups grouped by Node.
+func (n *NetworkPolicyController) appliedToMembersByNode(appliedToGroups []string) map[string]controlplane.GroupMemberSet {
+	membersByNode := map[string]controlplane.GroupMemberSet{}
+	for _, name := range appliedToGroups {
+		obj, found, _ := n.appliedToGroupStore.Get(name)
+		if !found {
+			continue
+		}
+		for nodeName, members := range obj.(*antreatypes.AppliedToGroup).GroupMemberByNode {
+			if existing, exists := membersByNode[nodeName]; exists {
+				membersByNode[nodeName] = existing.Union(members)
+			} else {
+				membersByNode[nodeName] = members
+			}
+		}
+	}
+	return membersByNode
+}
+
+// ipNetToNetIPNet converts a controlplane.IPNet to a *net.IPNet, normalizing the non-standard
+// CIDRs in the same way as the agents.
+func ipNetToNetIPNet(ipNet controlplane.IPNet) *net.IPNet {
+	ip := net.IP(ipNet.IP)
+	ipLen := net.IPv4len
+	if ip.To4() == nil {
+		ipLen = net.IPv6len
+	}
+	mask := net.CIDRMask(int(ipNet.PrefixLength), 8*ipLen)
+	return &net.IPNet{IP: ip.Mask(mask), Mask: mask}
+}
@@ -0,0 +1,130 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package networkpolicy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"antrea.io/antrea/pkg/apis/controlplane"
+	cpv1beta "antrea.io/antrea/pkg/apis/controlplane/v1beta2"
+	antreatypes "antrea.io/antrea/pkg/controller/types"
+)
+
+func TestPolicyQuota(t *testing.T) {
+	ref := func(name string) *controlplane.NetworkPolicyReference {
+		return &controlplane.NetworkPolicyReference{Type: controlplane.K8sNetworkPolicy, Namespace: "ns1", Name: name, UID: "uid-" + name}
+	}
+	t0 := time.Date(2021, 6, 1, 10, 0, 0, 0, time.UTC)
+	q := newPolicyQuota(100)
+
+	admitted, wasAdmitted, others := q.update("uid-np1", ref("np1"), t0, 60)
+	assert.True(t, admitted)
+	assert.True(t, wasAdmitted)
+	assert.Empty(t, others)
+	// np2 doesn't fit in the quota left by np1.
+	admitted, wasAdmitted, others = q.update("uid-np2", ref("np2"), t0.Add(time.Minute), 50)
+	assert.False(t, admitted)
+	assert.True(t, wasAdmitted)
+	assert.Empty(t, others)
+	// np3 is newer than np2 but fits in the quota left by np1.
+	admitted, _, others = q.update("uid-np3", ref("np3"), t0.Add(2*time.Minute), 40)
+	assert.True(t, admitted)
+	assert.Empty(t, others)
+
+	assert.Equal(t, []PolicyQuotaUsage{
+		{
+			Namespace: "ns1",
+			Quota:     100,
+			Flows:     100,
+			Policies:  3,
+			ExceedingPolicies: []QuotaPolicyRef{
+				{PolicyRef: PolicyRef{Namespace: "ns1", Name: "np2", UID: "uid-np2"}, Type: cpv1beta.K8sNetworkPolicy, Flows: 50},
+			},
+		},
+	}, q.list(""))
+
+	// Growing np1 never evicts it, but evicts the newer np3.
+	admitted, wasAdmitted, others = q.update("uid-np1", ref("np1"), t0, 70)
+	assert.True(t, admitted)
+	assert.True(t, wasAdmitted)
+	assert.Equal(t, []string{"uid-np3"}, others)
+
+	// Deleting np1 releases enough quota for np2 and np3.
+	assert.ElementsMatch(t, []string{"uid-np2", "uid-np3"}, q.delete("uid-np1", "ns1"))
+	admitted, wasAdmitted, _ = q.update("uid-np2", ref("np2"), t0.Add(time.Minute), 50)
+	assert.True(t, admitted)
+	assert.True(t, wasAdmitted)
+	assert.Equal(t, []PolicyQuotaUsage{{Namespace: "ns1", Quota: 100, Flows: 90, Policies: 2}}, q.list("ns1"))
+
+	assert.Empty(t, q.delete("uid-np2", "ns1"))
+	assert.Empty(t, q.delete("uid-np3", "ns1"))
+	assert.Empty(t, q.list(""))
+	assert.Equal(t, []PolicyQuotaUsage{{Namespace: "ns2", Quota: 100}}, q.list("ns2"))
+}
+
+func TestEstimatePolicyFlows(t *testing.T) {
+	pod := func(name, ip string) *controlplane.GroupMember {
+		return &controlplane.GroupMember{
+			Pod: &controlplane.PodReference{Namespace: "ns1", Name: name},
+			IPs: []controlplane.IPAddress{ipStrToIPAddress(ip)},
+		}
+	}
+	_, c := newController()
+	require.NoError(t, c.addressGroupStore.Create(&antreatypes.AddressGroup{
+		UID:          "ag1",
+		Name:         "ag1",
+		GroupMembers: controlplane.NewGroupMemberSet(pod("pod3", "10.0.0.3"), pod("pod4", "10.0.0.4"), pod("pod5", "10.0.0.5")),
+	}))
+	require.NoError(t, c.appliedToGroupStore.Create(&antreatypes.AppliedToGroup{
+		UID:  "atg1",
+		Name: "atg1",
+		GroupMemberByNode: map[string]controlplane.GroupMemberSet{
+			"node1": controlplane.NewGroupMemberSet(pod("pod1", "10.0.0.1")),
+			"node2": controlplane.NewGroupMemberSet(pod("pod2", "10.0.0.2")),
+		},
+	}))
+	port80 := intstr.FromInt(80)
+	internalNP := &antreatypes.NetworkPolicy{
+		UID:             "uid-np1",
+		Name:            "uid-np1",
+		SourceRef:       &controlplane.NetworkPolicyReference{Type: controlplane.K8sNetworkPolicy, Namespace: "ns1", Name: "np1", UID: "uid-np1"},
+		AppliedToGroups: []string{"atg1"},
+		Rules: []controlplane.NetworkPolicyRule{
+			{
+				Direction: controlplane.DirectionIn,
+				From: controlplane.NetworkPolicyPeer{
+					AddressGroups: []string{"ag1"},
+					IPBlocks: []controlplane.IPBlock{
+						{
+							CIDR:   controlplane.IPNet{IP: ipStrToIPAddress("10.1.0.0"), PrefixLength: 16},
+							Except: []controlplane.IPNet{{IP: ipStrToIPAddress("10.1.0.0"), PrefixLength: 24}},
+						},
+						// IPv6 is not enabled on the Nodes.
+						{CIDR: controlplane.IPNet{IP: ipStrToIPAddress("fd00::"), PrefixLength: 64}},
+					},
+				},
+				Services: []controlplane.Service{{Port: &port80}},
+			},
+		},
+	}
+	// On each Node: 1 appliedTo address, 3 peer IPs, 8 IPBlock CIDRs, 1 service match, 4 allow
+	// flows and 1 drop flow.
+	assert.Equal(t, 2*(1+3+8+1+4+1), c.estimatePolicyFlows(internalNP))
+}
@@ -16,9 +16,14 @@ package networkpolicy
 
 import (
 	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
+	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/labels"
@@ -29,19 +34,31 @@ import (
 	"k8s.io/klog/v2"
 
 	"antrea.io/antrea/pkg/apis/controlplane"
-	crdv1alpha1 "antrea.io/antrea/pkg/apis/crd/v1alpha1"
+	crdv1beta1 "antrea.io/antrea/pkg/apis/crd/v1beta1"
 	"antrea.io/antrea/pkg/apiserver/storage"
 	antreaclientset "antrea.io/antrea/pkg/client/clientset/versioned"
-	crdinformers "antrea.io/antrea/pkg/client/informers/externalversions/crd/v1alpha1"
-	crdlisters "antrea.io/antrea/pkg/client/listers/crd/v1alpha1"
+	crdinformers "antrea.io/antrea/pkg/client/informers/externalversions/crd/v1beta1"
+	crdlisters "antrea.io/antrea/pkg/client/listers/crd/v1beta1"
 	"antrea.io/antrea/pkg/controller/metrics"
 	antreatypes "antrea.io/antrea/pkg/controller/types"
 )
 
 const (
 	statusControllerName = "NetworkPolicyStatusController"
+	// maxReportedRealizationFailures is the maximum number of Nodes whose realization errors are
+	// included in the status of a NetworkPolicy.
+	maxReportedRealizationFailures = 3
+	// statusUpdateTimeout is the timeout of a request updating the status of a resource, so that the
+	// workers are not blocked for long when the kube-apiserver is unavailable.
+	statusUpdateTimeout = 10 * time.Second
 )
 
+// generationReceipt is the time at which a NetworkPolicy generation was first observed.
+type generationReceipt struct {
+	generation int64
+	timestamp  time.Time
+}
+
 // StatusController is responsible for synchronizing the status of Antrea ClusterNetworkPolicy and Antrea NetworkPolicy.
 type StatusController struct {
 	// npControlInterface knows how to update Antrea NetworkPolicy status.
@@ -59,6 +76,14 @@ type StatusController struct {
 	statuses     map[string]map[string]*controlplane.NetworkPolicyNodeStatus
 	statusesLock sync.RWMutex
 
+	// generationReceipts keeps the time at which the current generation of each NetworkPolicy was
+	// first observed by the StatusController, keyed by the NetworkPolicy keys. It's used to measure
+	// the realization latency on the controller side, which is not subject to clock skew.
+	generationReceipts map[string]generationReceipt
+	// startTime is the time at which the StatusController was created. The realization latency is
+	// not measured for generations created before it, as they may have been realized long ago.
+	startTime time.Time
+
 	// cnpLister is able to list/get ClusterNetworkPolicies and is populated by the shared informer passed to
 	// NewClusterNetworkPolicyController.
 	cnpLister crdlisters.ClusterNetworkPolicyLister
@@ -81,6 +106,8 @@ func NewStatusController(antreaClient antreaclientset.Interface, internalNetwork
 		queue:                      workqueue.NewNamedRateLimitingQueue(workqueue.NewItemExponentialFailureRateLimiter(minRetryDelay, maxRetryDelay), "networkpolicy"),
 		internalNetworkPolicyStore: internalNetworkPolicyStore,
 		statuses:                   map[string]map[string]*controlplane.NetworkPolicyNodeStatus{},
+		generationReceipts:         map[string]generationReceipt{},
+		startTime:                  time.Now(),
 		cnpListerSynced:            cnpInformer.Informer().HasSynced,
 		anpListerSynced:            anpInformer.Informer().HasSynced,
 	}
@@ -107,8 +134,8 @@ func NewStatusController(antreaClient antreaclientset.Interface, internalNetwork
 }
 
 func (c *StatusController) updateCNP(old, cur interface{}) {
-	curCNP := cur.(*crdv1alpha1.ClusterNetworkPolicy)
-	oldCNP := old.(*crdv1alpha1.ClusterNetworkPolicy)
+	curCNP := cur.(*crdv1beta1.ClusterNetworkPolicy)
+	oldCNP := old.(*crdv1beta1.ClusterNetworkPolicy)
 	if oldCNP.Status == curCNP.Status {
 		return
 	}
@@ -117,8 +144,8 @@ func (c *StatusController) updateCNP(old, cur interface{}) {
 }
 
 func (c *StatusController) updateANP(old, cur interface{}) {
-	curANP := cur.(*crdv1alpha1.NetworkPolicy)
-	oldANP := old.(*crdv1alpha1.NetworkPolicy)
+	curANP := cur.(*crdv1beta1.NetworkPolicy)
+	oldANP := old.(*crdv1beta1.NetworkPolicy)
 	if oldANP.Status == curANP.Status {
 		return
 	}
@@ -128,11 +155,13 @@ func (c *StatusController) updateANP(old, cur interface{}) {
 
 func (c *StatusController) UpdateStatus(status *controlplane.NetworkPolicyStatus) error {
 	key := status.Name
-	_, found, _ := c.internalNetworkPolicyStore.Get(key)
+	internalNPObj, found, _ := c.internalNetworkPolicyStore.Get(key)
 	if !found {
 		klog.Infof("NetworkPolicy %s has been deleted, skip updating its status", key)
 		return nil
 	}
+	internalNP := internalNPObj.(*antreatypes.NetworkPolicy)
+	ackTime := time.Now()
 	func() {
 		c.statusesLock.Lock()
 		defer c.statusesLock.Unlock()
@@ -142,13 +171,109 @@ func (c *StatusController) UpdateStatus(status *controlplane.NetworkPolicyStatus
 			c.statuses[key] = statusPerNode
 		}
 		for i := range status.Nodes {
-			statusPerNode[status.Nodes[i].NodeName] = &status.Nodes[i]
+			nodeStatus := &status.Nodes[i]
+			// Only the first report of the current generation from a Node without error is a realization.
+			oldStatus, exists := statusPerNode[nodeStatus.NodeName]
+			if isRealizedByNode(internalNP, nodeStatus) && (!exists || !isRealizedByNode(internalNP, oldStatus)) {
+				c.observeRealizationLocked(key, internalNP, nodeStatus, ackTime)
+			}
+			statusPerNode[nodeStatus.NodeName] = nodeStatus
 		}
 	}()
 	c.queue.Add(key)
 	return nil
 }
 
+// observeGeneration records the time at which a NetworkPolicy generation is first observed.
+func (c *StatusController) observeGeneration(key string, generation int64) {
+	c.statusesLock.Lock()
+	defer c.statusesLock.Unlock()
+	if receipt, exists := c.generationReceipts[key]; exists && receipt.generation == generation {
+		return
+	}
+	c.generationReceipts[key] = generationReceipt{generation: generation, timestamp: time.Now()}
+}
+
+// observeRealizationLocked records the realization latency metrics for a Node which has just
+// realized the current generation of a NetworkPolicy. statusesLock must be held by the caller.
+func (c *StatusController) observeRealizationLocked(key string, internalNP *antreatypes.NetworkPolicy, nodeStatus *controlplane.NetworkPolicyNodeStatus, ackTime time.Time) {
+	// GenerationTimestamp has a granularity of one second.
+	if internalNP.GenerationTimestamp.Before(c.startTime.Truncate(time.Second)) {
+		return
+	}
+	if latency, ok := realizationLatency(internalNP, nodeStatus); ok {
+		metrics.DurationNetworkPolicyRealization.Observe(latency.Seconds())
+	}
+	if receipt, exists := c.generationReceipts[key]; exists && receipt.generation == internalNP.Generation {
+		metrics.DurationNetworkPolicyRealizationAck.Observe(ackTime.Sub(receipt.timestamp).Seconds())
+	}
+}
+
+// isRealizedByNode returns whether the status reports that the Node realized the current generation
+// of the NetworkPolicy, which is not the case if the Node failed to realize some of its rules.
+func isRealizedByNode(internalNP *antreatypes.NetworkPolicy, nodeStatus *controlplane.NetworkPolicyNodeStatus) bool {
+	return nodeStatus.Generation == internalNP.Generation && nodeStatus.RealizationError == ""
+}
+
+// realizationFailureCondition returns the condition reporting the Nodes which failed to realize
+// the current generation of a NetworkPolicy. Only the errors of the first Nodes are included, so
+// that the size of the status is bounded.
+func realizationFailureCondition(sortedFailures []string) crdv1beta1.NetworkPolicyCondition {
+	failures := sortedFailures
+	if len(failures) > maxReportedRealizationFailures {
+		failures = failures[:maxReportedRealizationFailures]
+	}
+	message := fmt.Sprintf("Failed to realize on %d Node(s): %s", len(sortedFailures), strings.Join(failures, "; "))
+	if len(sortedFailures) > len(failures) {
+		message += fmt.Sprintf("; and %d more", len(sortedFailures)-len(failures))
+	}
+	return crdv1beta1.NetworkPolicyCondition{
+		Type:    crdv1beta1.NetworkPolicyRealizationFailure,
+		Status:  corev1.ConditionTrue,
+		Reason:  "RuleRealizationFailed",
+		Message: message,
+	}
+}
+
+// quotaExceededCondition returns the condition reporting that a NetworkPolicy is not enforced as
+// it exceeds the flow quota left in its Namespace.
+func quotaExceededCondition(namespace string) crdv1beta1.NetworkPolicyCondition {
+	return crdv1beta1.NetworkPolicyCondition{
+		Type:    crdv1beta1.NetworkPolicyQuotaExceeded,
+		Status:  corev1.ConditionTrue,
+		Reason:  quotaExceededReason,
+		Message: fmt.Sprintf("The estimated OpenFlow flows of the policy exceed the flow quota left in Namespace %s, the policy is not enforced on any Node", namespace),
+	}
+}
+
+// realizationLatency returns the latency between the creation of the current generation of the
+// NetworkPolicy in the kube-apiserver and its realization on a Node, and false if it's unknown.
+// As the realization time is reported by the Node, the latency is subject to clock skew between the
+// Nodes, and is rounded up to 0 if the Node's clock is behind.
+func realizationLatency(internalNP *antreatypes.NetworkPolicy, nodeStatus *controlplane.NetworkPolicyNodeStatus) (time.Duration, bool) {
+	if internalNP.GenerationTimestamp.IsZero() || nodeStatus.RealizationTime.IsZero() {
+		return 0, false
+	}
+	latency := nodeStatus.RealizationTime.Sub(internalNP.GenerationTimestamp)
+	if latency < 0 {
+		latency = 0
+	}
+	return latency, true
+}
+
+// latencyPercentile returns the provided percentile of the sorted latencies, using the nearest-rank
+// method. It returns 0 if there is no latency.
+func latencyPercentile(sortedLatencies []time.Duration, percentile int) time.Duration {
+	if len(sortedLatencies) == 0 {
+		return 0
+	}
+	rank := (percentile*len(sortedLatencies) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sortedLatencies[rank-1]
+}
+
 func (c *StatusController) getNodeStatuses(key string) []*controlplane.NetworkPolicyNodeStatus {
 	c.statusesLock.RLock()
 	defer c.statusesLock.RUnlock()
@@ -167,6 +292,7 @@ func (c *StatusController) clearStatuses(key string) {
 	c.statusesLock.Lock()
 	defer c.statusesLock.Unlock()
 	delete(c.statuses, key)
+	delete(c.generationReceipts, key)
 }
 
 func (c *StatusController) deleteNodeStatus(key string, nodeName string) {
@@ -220,6 +346,9 @@ func (c *StatusController) watchInternalNetworkPolicy() {
 			if np.SourceRef.Type == controlplane.K8sNetworkPolicy {
 				continue
 			}
+			if event.Type != watch.Deleted {
+				c.observeGeneration(np.Name, np.Generation)
+			}
 			c.queue.Add(np.Name)
 		}
 	}
@@ -244,12 +373,24 @@ func (c *StatusController) processNextWorkItem() bool {
 		return true
 	}
 
-	klog.Errorf("Failed to sync NetworkPolicy status %s: %v", key, err)
-	c.queue.AddRateLimited(key)
-
+	handleStatusUpdateError(c.queue, key.(string), err, "NetworkPolicy")
 	return true
 }
 
+// handleStatusUpdateError requeues the key of a resource whose status failed to be updated. The
+// update is retried with an exponential backoff capped by the rate limiter of the queue, and as the
+// queue deduplicates the keys, there is at most one pending update per resource. Only the first
+// failure of each resource is logged as an error, to avoid flooding the logs when the kube-apiserver
+// is unavailable.
+func handleStatusUpdateError(queue workqueue.RateLimitingInterface, key string, err error, kind string) {
+	if queue.NumRequeues(key) == 0 {
+		klog.Errorf("Failed to update %s status %s, will retry: %v", kind, key, err)
+	} else {
+		klog.V(2).Infof("Failed to update %s status %s again, will retry: %v", kind, key, err)
+	}
+	queue.AddRateLimited(key)
+}
+
 // syncHandler calculates the NetworkPolicy status based on the desired state from the internalNetworkPolicyStore and
 // the actual state from the statuses map, and syncs it with the Kubernetes API.
 // Each status update from agents can trigger syncHandler, however, the status updates' arrival time should not differ
@@ -265,12 +406,16 @@ func (c *StatusController) syncHandler(key string) error {
 		return nil
 	}
 	internalNP := internalNPObj.(*antreatypes.NetworkPolicy)
+	// The policies implementing the default action of Tiers have no resource to report status to.
+	if strings.HasPrefix(internalNP.SourceRef.Name, tierDefaultPolicyPrefix) {
+		return nil
+	}
 
 	// It means the NetworkPolicy hasn't been processed once. Set it to Pending to differentiate from NetworkPolicies
 	// that spans 0 Node.
 	if internalNP.SpanMeta.NodeNames == nil {
-		status := &crdv1alpha1.NetworkPolicyStatus{
-			Phase:              crdv1alpha1.NetworkPolicyPending,
+		status := &crdv1beta1.NetworkPolicyStatus{
+			Phase:              crdv1beta1.NetworkPolicyPending,
 			ObservedGeneration: internalNP.Generation,
 		}
 		if internalNP.SourceRef.Type == controlplane.AntreaNetworkPolicy {
@@ -281,6 +426,8 @@ func (c *StatusController) syncHandler(key string) error {
 	}
 	desiredNodes := len(internalNP.SpanMeta.NodeNames)
 	currentNodes := 0
+	var latencies []time.Duration
+	var failures []string
 	statuses := c.getNodeStatuses(key)
 	for _, status := range statuses {
 		// The node is no longer in the span of this policy, delete its status.
@@ -288,22 +435,44 @@ func (c *StatusController) syncHandler(key string) error {
 			c.deleteNodeStatus(key, status.NodeName)
 			continue
 		}
-		if status.Generation == internalNP.Generation {
-			currentNodes += 1
+		if status.Generation != internalNP.Generation {
+			continue
+		}
+		if status.RealizationError != "" {
+			failures = append(failures, fmt.Sprintf("%s: %s", status.NodeName, status.RealizationError))
+			continue
+		}
+		currentNodes += 1
+		if latency, ok := realizationLatency(internalNP, status); ok {
+			latencies = append(latencies, latency)
 		}
 	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
 
-	phase := crdv1alpha1.NetworkPolicyRealizing
+	phase := crdv1beta1.NetworkPolicyRealizing
 	if currentNodes == desiredNodes {
-		phase = crdv1alpha1.NetworkPolicyRealized
+		phase = crdv1beta1.NetworkPolicyRealized
 	}
 
-	status := &crdv1alpha1.NetworkPolicyStatus{
+	status := &crdv1beta1.NetworkPolicyStatus{
 		Phase:                phase,
 		ObservedGeneration:   internalNP.Generation,
 		CurrentNodesRealized: int32(currentNodes),
 		DesiredNodesRealized: int32(desiredNodes),
 	}
+	if len(latencies) > 0 {
+		status.RealizationLatency = &crdv1beta1.RealizationLatency{
+			P50Milliseconds: latencyPercentile(latencies, 50).Milliseconds(),
+			P99Milliseconds: latencyPercentile(latencies, 99).Milliseconds(),
+		}
+	}
+	if len(failures) > 0 {
+		sort.Strings(failures)
+		status.Conditions = []crdv1beta1.NetworkPolicyCondition{realizationFailureCondition(failures)}
+	}
+	if internalNP.QuotaExceeded {
+		status.Conditions = append(status.Conditions, quotaExceededCondition(internalNP.SourceRef.Namespace))
+	}
 	klog.V(2).Infof("Updating NetworkPolicy %s status: %v", internalNP.SourceRef.ToString(), status)
 	if internalNP.SourceRef.Type == controlplane.AntreaNetworkPolicy {
 		return c.npControlInterface.UpdateAntreaNetworkPolicyStatus(internalNP.SourceRef.Namespace, internalNP.SourceRef.Name, status)
@@ -315,8 +484,8 @@ func (c *StatusController) syncHandler(key string) error {
 // networkPolicyControlInterface is an interface that knows how to update Antrea NetworkPolicy status.
 // It's created as an interface to allow testing.
 type networkPolicyControlInterface interface {
-	UpdateAntreaNetworkPolicyStatus(namespace, name string, status *crdv1alpha1.NetworkPolicyStatus) error
-	UpdateAntreaClusterNetworkPolicyStatus(name string, status *crdv1alpha1.NetworkPolicyStatus) error
+	UpdateAntreaNetworkPolicyStatus(namespace, name string, status *crdv1beta1.NetworkPolicyStatus) error
+	UpdateAntreaClusterNetworkPolicyStatus(name string, status *crdv1beta1.NetworkPolicyStatus) error
 }
 
 type networkPolicyControl struct {
@@ -325,35 +494,39 @@ type networkPolicyControl struct {
 	anpLister    crdlisters.NetworkPolicyLister
 }
 
-func (c *networkPolicyControl) UpdateAntreaNetworkPolicyStatus(namespace, name string, status *crdv1alpha1.NetworkPolicyStatus) error {
+func (c *networkPolicyControl) UpdateAntreaNetworkPolicyStatus(namespace, name string, status *crdv1beta1.NetworkPolicyStatus) error {
 	anp, err := c.anpLister.NetworkPolicies(namespace).Get(name)
 	if err != nil {
 		klog.Infof("Didn't find the original Antrea NetworkPolicy %s/%s, skip updating status", namespace, name)
 		return nil
 	}
-	if anp.Status == *status {
+	if reflect.DeepEqual(anp.Status, *status) {
 		return nil
 	}
 	metrics.AntreaNetworkPolicyStatusUpdates.Inc()
 	toUpdate := anp.DeepCopy()
 	toUpdate.Status = *status
-	_, err = c.antreaClient.CrdV1alpha1().NetworkPolicies(namespace).UpdateStatus(context.TODO(), toUpdate, v1.UpdateOptions{})
+	ctx, cancel := context.WithTimeout(context.TODO(), statusUpdateTimeout)
+	defer cancel()
+	_, err = c.antreaClient.CrdV1beta1().NetworkPolicies(namespace).UpdateStatus(ctx, toUpdate, v1.UpdateOptions{})
 	return err
 }
 
-func (c *networkPolicyControl) UpdateAntreaClusterNetworkPolicyStatus(name string, status *crdv1alpha1.NetworkPolicyStatus) error {
+func (c *networkPolicyControl) UpdateAntreaClusterNetworkPolicyStatus(name string, status *crdv1beta1.NetworkPolicyStatus) error {
 	cnp, err := c.cnpLister.Get(name)
 	if err != nil {
 		klog.Infof("Didn't find the original Antrea ClusterNetworkPolicy %s, skip updating status", name)
 		return nil
 	}
 	// If the current status equals to the desired status, no need to update.
-	if cnp.Status == *status {
+	if reflect.DeepEqual(cnp.Status, *status) {
 		return nil
 	}
 	metrics.AntreaClusterNetworkPolicyStatusUpdates.Inc()
 	toUpdate := cnp.DeepCopy()
 	toUpdate.Status = *status
-	_, err = c.antreaClient.CrdV1alpha1().ClusterNetworkPolicies().UpdateStatus(context.TODO(), toUpdate, v1.UpdateOptions{})
+	ctx, cancel := context.WithTimeout(context.TODO(), statusUpdateTimeout)
+	defer cancel()
+	_, err = c.antreaClient.CrdV1beta1().ClusterNetworkPolicies().UpdateStatus(ctx, toUpdate, v1.UpdateOptions{})
 	return err
 }
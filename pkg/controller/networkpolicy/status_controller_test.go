@@ -21,13 +21,15 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/util/workqueue"
 
 	"antrea.io/antrea/pkg/apis/controlplane"
-	crdv1alpha1 "antrea.io/antrea/pkg/apis/crd/v1alpha1"
+	crdv1beta1 "antrea.io/antrea/pkg/apis/crd/v1beta1"
 	"antrea.io/antrea/pkg/apiserver/storage"
 	antreaclientset "antrea.io/antrea/pkg/client/clientset/versioned"
 	antreafakeclientset "antrea.io/antrea/pkg/client/clientset/versioned/fake"
@@ -38,31 +40,31 @@ import (
 
 type fakeNetworkPolicyControl struct {
 	sync.Mutex
-	anpStatus *crdv1alpha1.NetworkPolicyStatus
-	cnpStatus *crdv1alpha1.NetworkPolicyStatus
+	anpStatus *crdv1beta1.NetworkPolicyStatus
+	cnpStatus *crdv1beta1.NetworkPolicyStatus
 }
 
-func (c *fakeNetworkPolicyControl) UpdateAntreaNetworkPolicyStatus(namespace, name string, status *crdv1alpha1.NetworkPolicyStatus) error {
+func (c *fakeNetworkPolicyControl) UpdateAntreaNetworkPolicyStatus(namespace, name string, status *crdv1beta1.NetworkPolicyStatus) error {
 	c.Lock()
 	defer c.Unlock()
 	c.anpStatus = status
 	return nil
 }
 
-func (c *fakeNetworkPolicyControl) UpdateAntreaClusterNetworkPolicyStatus(name string, status *crdv1alpha1.NetworkPolicyStatus) error {
+func (c *fakeNetworkPolicyControl) UpdateAntreaClusterNetworkPolicyStatus(name string, status *crdv1beta1.NetworkPolicyStatus) error {
 	c.Lock()
 	defer c.Unlock()
 	c.cnpStatus = status
 	return nil
 }
 
-func (c *fakeNetworkPolicyControl) getAntreaNetworkPolicyStatus() *crdv1alpha1.NetworkPolicyStatus {
+func (c *fakeNetworkPolicyControl) getAntreaNetworkPolicyStatus() *crdv1beta1.NetworkPolicyStatus {
 	c.Lock()
 	defer c.Unlock()
 	return c.anpStatus
 }
 
-func (c *fakeNetworkPolicyControl) getAntreaClusterNetworkPolicyStatus() *crdv1alpha1.NetworkPolicyStatus {
+func (c *fakeNetworkPolicyControl) getAntreaClusterNetworkPolicyStatus() *crdv1beta1.NetworkPolicyStatus {
 	c.Lock()
 	defer c.Unlock()
 	return c.cnpStatus
@@ -75,13 +77,15 @@ func newTestStatusController(initialObjects ...runtime.Object) (*StatusControlle
 	antreaInformerFactory := antreainformers.NewSharedInformerFactory(antreaClientset, 0)
 	networkPolicyControl := &fakeNetworkPolicyControl{}
 
-	cnpInformer := antreaInformerFactory.Crd().V1alpha1().ClusterNetworkPolicies()
-	anpInformer := antreaInformerFactory.Crd().V1alpha1().NetworkPolicies()
+	cnpInformer := antreaInformerFactory.Crd().V1beta1().ClusterNetworkPolicies()
+	anpInformer := antreaInformerFactory.Crd().V1beta1().NetworkPolicies()
 	statusController := &StatusController{
 		npControlInterface:         networkPolicyControl,
 		queue:                      workqueue.NewNamedRateLimitingQueue(workqueue.NewItemExponentialFailureRateLimiter(minRetryDelay, maxRetryDelay), "networkpolicy"),
 		internalNetworkPolicyStore: networkPolicyStore,
 		statuses:                   map[string]map[string]*controlplane.NetworkPolicyNodeStatus{},
+		generationReceipts:         map[string]generationReceipt{},
+		startTime:                  time.Now(),
 		cnpListerSynced:            cnpInformer.Informer().HasSynced,
 		anpListerSynced:            anpInformer.Informer().HasSynced,
 	}
@@ -113,7 +117,7 @@ func newNetworkPolicyStatus(name string, nodeName string, generation int64) *con
 
 func toAntreaNetworkPolicy(inp *types.NetworkPolicy) runtime.Object {
 	if inp.SourceRef.Type == controlplane.AntreaNetworkPolicy {
-		return &crdv1alpha1.NetworkPolicy{
+		return &crdv1beta1.NetworkPolicy{
 			ObjectMeta: v1.ObjectMeta{
 				Namespace:  inp.SourceRef.Namespace,
 				Name:       inp.SourceRef.Name,
@@ -121,7 +125,7 @@ func toAntreaNetworkPolicy(inp *types.NetworkPolicy) runtime.Object {
 			},
 		}
 	} else {
-		return &crdv1alpha1.ClusterNetworkPolicy{
+		return &crdv1beta1.ClusterNetworkPolicy{
 			ObjectMeta: v1.ObjectMeta{
 				Name:       inp.SourceRef.Name,
 				Generation: inp.Generation,
@@ -150,8 +154,8 @@ func TestCreateAntreaNetworkPolicy(t *testing.T) {
 		name                         string
 		networkPolicy                []*types.NetworkPolicy
 		collectedNetworkPolicyStatus []*controlplane.NetworkPolicyStatus
-		expectedANPStatus            *crdv1alpha1.NetworkPolicyStatus
-		expectedCNPStatus            *crdv1alpha1.NetworkPolicyStatus
+		expectedANPStatus            *crdv1beta1.NetworkPolicyStatus
+		expectedCNPStatus            *crdv1beta1.NetworkPolicyStatus
 	}{
 		{
 			name: "no realization status",
@@ -159,14 +163,14 @@ func TestCreateAntreaNetworkPolicy(t *testing.T) {
 				newInternalNetworkPolicy("anp1", 1, []string{"node1", "node2"}, newAntreaNetworkPolicyReference("ns1", "anp1")),
 				newInternalNetworkPolicy("cnp1", 1, []string{"node1", "node2"}, newAntreaClusterNetworkPolicyReference("cnp1")),
 			},
-			expectedANPStatus: &crdv1alpha1.NetworkPolicyStatus{
-				Phase:                crdv1alpha1.NetworkPolicyRealizing,
+			expectedANPStatus: &crdv1beta1.NetworkPolicyStatus{
+				Phase:                crdv1beta1.NetworkPolicyRealizing,
 				ObservedGeneration:   1,
 				CurrentNodesRealized: 0,
 				DesiredNodesRealized: 2,
 			},
-			expectedCNPStatus: &crdv1alpha1.NetworkPolicyStatus{
-				Phase:                crdv1alpha1.NetworkPolicyRealizing,
+			expectedCNPStatus: &crdv1beta1.NetworkPolicyStatus{
+				Phase:                crdv1beta1.NetworkPolicyRealizing,
 				ObservedGeneration:   1,
 				CurrentNodesRealized: 0,
 				DesiredNodesRealized: 2,
@@ -184,14 +188,14 @@ func TestCreateAntreaNetworkPolicy(t *testing.T) {
 				newNetworkPolicyStatus("cnp1", "node1", 2),
 				newNetworkPolicyStatus("cnp1", "node2", 3),
 			},
-			expectedANPStatus: &crdv1alpha1.NetworkPolicyStatus{
-				Phase:                crdv1alpha1.NetworkPolicyRealizing,
+			expectedANPStatus: &crdv1beta1.NetworkPolicyStatus{
+				Phase:                crdv1beta1.NetworkPolicyRealizing,
 				ObservedGeneration:   2,
 				CurrentNodesRealized: 1,
 				DesiredNodesRealized: 2,
 			},
-			expectedCNPStatus: &crdv1alpha1.NetworkPolicyStatus{
-				Phase:                crdv1alpha1.NetworkPolicyRealizing,
+			expectedCNPStatus: &crdv1beta1.NetworkPolicyStatus{
+				Phase:                crdv1beta1.NetworkPolicyRealizing,
 				ObservedGeneration:   3,
 				CurrentNodesRealized: 1,
 				DesiredNodesRealized: 2,
@@ -209,14 +213,14 @@ func TestCreateAntreaNetworkPolicy(t *testing.T) {
 				newNetworkPolicyStatus("cnp1", "node1", 4),
 				newNetworkPolicyStatus("cnp1", "node2", 4),
 			},
-			expectedANPStatus: &crdv1alpha1.NetworkPolicyStatus{
-				Phase:                crdv1alpha1.NetworkPolicyRealized,
+			expectedANPStatus: &crdv1beta1.NetworkPolicyStatus{
+				Phase:                crdv1beta1.NetworkPolicyRealized,
 				ObservedGeneration:   3,
 				CurrentNodesRealized: 2,
 				DesiredNodesRealized: 2,
 			},
-			expectedCNPStatus: &crdv1alpha1.NetworkPolicyStatus{
-				Phase:                crdv1alpha1.NetworkPolicyRealized,
+			expectedCNPStatus: &crdv1beta1.NetworkPolicyStatus{
+				Phase:                crdv1beta1.NetworkPolicyRealized,
 				ObservedGeneration:   4,
 				CurrentNodesRealized: 2,
 				DesiredNodesRealized: 2,
@@ -268,14 +272,14 @@ func TestUpdateAntreaNetworkPolicy(t *testing.T) {
 	statusController.UpdateStatus(newNetworkPolicyStatus("cnp1", "node5", 2))
 	// TODO: Use a determinate mechanism.
 	time.Sleep(500 * time.Millisecond)
-	assert.Equal(t, &crdv1alpha1.NetworkPolicyStatus{
-		Phase:                crdv1alpha1.NetworkPolicyRealized,
+	assert.Equal(t, &crdv1beta1.NetworkPolicyStatus{
+		Phase:                crdv1beta1.NetworkPolicyRealized,
 		ObservedGeneration:   1,
 		CurrentNodesRealized: 2,
 		DesiredNodesRealized: 2,
 	}, networkPolicyControl.getAntreaNetworkPolicyStatus())
-	assert.Equal(t, &crdv1alpha1.NetworkPolicyStatus{
-		Phase:                crdv1alpha1.NetworkPolicyRealized,
+	assert.Equal(t, &crdv1beta1.NetworkPolicyStatus{
+		Phase:                crdv1beta1.NetworkPolicyRealized,
 		ObservedGeneration:   2,
 		CurrentNodesRealized: 3,
 		DesiredNodesRealized: 3,
@@ -287,14 +291,14 @@ func TestUpdateAntreaNetworkPolicy(t *testing.T) {
 	networkPolicyStore.Update(cnp1Updated)
 	// TODO: Use a determinate mechanism.
 	time.Sleep(500 * time.Millisecond)
-	assert.Equal(t, &crdv1alpha1.NetworkPolicyStatus{
-		Phase:                crdv1alpha1.NetworkPolicyRealizing,
+	assert.Equal(t, &crdv1beta1.NetworkPolicyStatus{
+		Phase:                crdv1beta1.NetworkPolicyRealizing,
 		ObservedGeneration:   2,
 		CurrentNodesRealized: 0,
 		DesiredNodesRealized: 3,
 	}, networkPolicyControl.getAntreaNetworkPolicyStatus())
-	assert.Equal(t, &crdv1alpha1.NetworkPolicyStatus{
-		Phase:                crdv1alpha1.NetworkPolicyRealizing,
+	assert.Equal(t, &crdv1beta1.NetworkPolicyStatus{
+		Phase:                crdv1beta1.NetworkPolicyRealizing,
 		ObservedGeneration:   3,
 		CurrentNodesRealized: 0,
 		DesiredNodesRealized: 2,
@@ -326,6 +330,106 @@ func TestDeleteAntreaNetworkPolicy(t *testing.T) {
 	assert.Empty(t, statusController.getNodeStatuses(initialNetworkPolicy.Name))
 }
 
+func TestNetworkPolicyRealizationLatency(t *testing.T) {
+	generationTimestamp := time.Now().Truncate(time.Second)
+	nodes := []string{"node1", "node2", "node3", "node4"}
+	anp1 := newInternalNetworkPolicy("anp1", 2, nodes, newAntreaNetworkPolicyReference("ns1", "anp1"))
+	anp1.GenerationTimestamp = generationTimestamp
+	statusController, _, _, networkPolicyStore, networkPolicyControl := newTestStatusController()
+	networkPolicyStore.Create(anp1)
+
+	newRealizedStatus := func(nodeName string, generation int64, latency time.Duration) *controlplane.NetworkPolicyStatus {
+		status := newNetworkPolicyStatus("anp1", nodeName, generation)
+		status.Nodes[0].RealizationTime = v1.NewTime(generationTimestamp.Add(latency))
+		return status
+	}
+	// node1's clock is behind, its latency is considered to be 0.
+	statusController.UpdateStatus(newRealizedStatus("node1", 2, -time.Second))
+	statusController.UpdateStatus(newRealizedStatus("node2", 2, 100*time.Millisecond))
+	statusController.UpdateStatus(newRealizedStatus("node3", 2, 300*time.Millisecond))
+	// node4 hasn't realized the current generation, it's not taken into account.
+	statusController.UpdateStatus(newRealizedStatus("node4", 1, 10*time.Second))
+
+	require.NoError(t, statusController.syncHandler("anp1"))
+	assert.Equal(t, &crdv1beta1.NetworkPolicyStatus{
+		Phase:                crdv1beta1.NetworkPolicyRealizing,
+		ObservedGeneration:   2,
+		CurrentNodesRealized: 3,
+		DesiredNodesRealized: 4,
+		RealizationLatency:   &crdv1beta1.RealizationLatency{P50Milliseconds: 100, P99Milliseconds: 300},
+	}, networkPolicyControl.getAntreaNetworkPolicyStatus())
+}
+
+func TestNetworkPolicyRealizationFailure(t *testing.T) {
+	nodes := []string{"node1", "node2", "node3", "node4", "node5"}
+	anp1 := newInternalNetworkPolicy("anp1", 1, nodes, newAntreaNetworkPolicyReference("ns1", "anp1"))
+	statusController, _, _, networkPolicyStore, networkPolicyControl := newTestStatusController()
+	networkPolicyStore.Create(anp1)
+
+	newFailedStatus := func(nodeName string) *controlplane.NetworkPolicyStatus {
+		status := newNetworkPolicyStatus("anp1", nodeName, 1)
+		status.Nodes[0].RealizationError = "failed to realize 1 rule(s)"
+		return status
+	}
+	statusController.UpdateStatus(newNetworkPolicyStatus("anp1", "node1", 1))
+	for _, node := range []string{"node5", "node4", "node3", "node2"} {
+		statusController.UpdateStatus(newFailedStatus(node))
+	}
+
+	// Only the errors of the first Nodes are reported.
+	require.NoError(t, statusController.syncHandler("anp1"))
+	assert.Equal(t, &crdv1beta1.NetworkPolicyStatus{
+		Phase:                crdv1beta1.NetworkPolicyRealizing,
+		ObservedGeneration:   1,
+		CurrentNodesRealized: 1,
+		DesiredNodesRealized: 5,
+		Conditions: []crdv1beta1.NetworkPolicyCondition{
+			{
+				Type:    crdv1beta1.NetworkPolicyRealizationFailure,
+				Status:  corev1.ConditionTrue,
+				Reason:  "RuleRealizationFailed",
+				Message: "Failed to realize on 4 Node(s): node2: failed to realize 1 rule(s); node3: failed to realize 1 rule(s); node4: failed to realize 1 rule(s); and 1 more",
+			},
+		},
+	}, networkPolicyControl.getAntreaNetworkPolicyStatus())
+
+	// The condition is removed once all Nodes have realized the NetworkPolicy.
+	for _, node := range []string{"node2", "node3", "node4", "node5"} {
+		statusController.UpdateStatus(newNetworkPolicyStatus("anp1", node, 1))
+	}
+	require.NoError(t, statusController.syncHandler("anp1"))
+	assert.Equal(t, &crdv1beta1.NetworkPolicyStatus{
+		Phase:                crdv1beta1.NetworkPolicyRealized,
+		ObservedGeneration:   1,
+		CurrentNodesRealized: 5,
+		DesiredNodesRealized: 5,
+	}, networkPolicyControl.getAntreaNetworkPolicyStatus())
+}
+
+func TestLatencyPercentile(t *testing.T) {
+	latencies := make([]time.Duration, 0, 200)
+	for i := 1; i <= 200; i++ {
+		latencies = append(latencies, time.Duration(i)*time.Millisecond)
+	}
+	tests := []struct {
+		name       string
+		latencies  []time.Duration
+		percentile int
+		expected   time.Duration
+	}{
+		{"empty", nil, 50, 0},
+		{"single", []time.Duration{time.Second}, 99, time.Second},
+		{"p50", latencies, 50, 100 * time.Millisecond},
+		{"p99", latencies, 99, 198 * time.Millisecond},
+		{"p100", latencies, 100, 200 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, latencyPercentile(tt.latencies, tt.percentile))
+		})
+	}
+}
+
 // BenchmarkSyncHandler benchmarks syncHandler when the policy spans 1000 Nodes. Its current result is:
 // 70024 ns/op            8338 B/op          8 allocs/op
 func BenchmarkSyncHandler(b *testing.B) {
@@ -0,0 +1,136 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package networkpolicy
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	apiextensionclientset "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+
+	crdv1beta1 "antrea.io/antrea/pkg/apis/crd/v1beta1"
+	antreaclientset "antrea.io/antrea/pkg/client/clientset/versioned"
+)
+
+const (
+	storageVersionMigratorName = "StorageVersionMigrator"
+	// migrationRetryInterval is the interval between two migration attempts. The first attempts
+	// may fail as the conversion webhooks are served by antrea-controller itself.
+	migrationRetryInterval = 10 * time.Second
+)
+
+var (
+	anpCRDName = "networkpolicies." + crdv1beta1.SchemeGroupVersion.Group
+	cnpCRDName = "clusternetworkpolicies." + crdv1beta1.SchemeGroupVersion.Group
+)
+
+// StorageVersionMigrator migrates the Antrea NetworkPolicies and ClusterNetworkPolicies persisted
+// as v1alpha1 objects to the storage version of their CRDs, i.e. v1beta1. Once all objects of a CRD
+// have been rewritten, v1alpha1 is removed from the stored versions of the CRD, so that it can be
+// dropped from the CRD in a future release.
+type StorageVersionMigrator struct {
+	antreaClient       antreaclientset.Interface
+	apiExtensionClient apiextensionclientset.Interface
+}
+
+func NewStorageVersionMigrator(antreaClient antreaclientset.Interface, apiExtensionClient apiextensionclientset.Interface) *StorageVersionMigrator {
+	return &StorageVersionMigrator{
+		antreaClient:       antreaClient,
+		apiExtensionClient: apiExtensionClient,
+	}
+}
+
+// Run migrates the stored objects, retrying until it succeeds or stopCh is closed.
+func (m *StorageVersionMigrator) Run(stopCh <-chan struct{}) {
+	klog.Infof("Starting %s", storageVersionMigratorName)
+	defer klog.Infof("Shutting down %s", storageVersionMigratorName)
+
+	wait.PollImmediateUntil(migrationRetryInterval, func() (bool, error) {
+		if err := m.migrate(cnpCRDName, m.rewriteClusterNetworkPolicies); err != nil {
+			klog.Errorf("Failed to migrate Antrea ClusterNetworkPolicies to %s: %v", crdv1beta1.SchemeGroupVersion.Version, err)
+			return false, nil
+		}
+		if err := m.migrate(anpCRDName, m.rewriteNetworkPolicies); err != nil {
+			klog.Errorf("Failed to migrate Antrea NetworkPolicies to %s: %v", crdv1beta1.SchemeGroupVersion.Version, err)
+			return false, nil
+		}
+		return true, nil
+	}, stopCh)
+}
+
+// migrate rewrites all objects of the provided CRD if they may be stored in another version than
+// the storage version, then records the storage version as the only stored version of the CRD.
+func (m *StorageVersionMigrator) migrate(crdName string, rewrite func() error) error {
+	storageVersion := crdv1beta1.SchemeGroupVersion.Version
+	crd, err := m.apiExtensionClient.ApiextensionsV1().CustomResourceDefinitions().Get(context.TODO(), crdName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("error getting CRD %s: %v", crdName, err)
+	}
+	storedVersions := crd.Status.StoredVersions
+	if len(storedVersions) == 1 && storedVersions[0] == storageVersion {
+		klog.V(2).Infof("All objects of CRD %s are stored as %s", crdName, storageVersion)
+		return nil
+	}
+	if err := rewrite(); err != nil {
+		return err
+	}
+	crd.Status.StoredVersions = []string{storageVersion}
+	if _, err := m.apiExtensionClient.ApiextensionsV1().CustomResourceDefinitions().UpdateStatus(context.TODO(), crd, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("error updating stored versions of CRD %s: %v", crdName, err)
+	}
+	klog.Infof("Migrated all objects of CRD %s to %s", crdName, storageVersion)
+	return nil
+}
+
+// rewriteClusterNetworkPolicies persists all Antrea ClusterNetworkPolicies again without change,
+// which makes kube-apiserver store them in the storage version.
+func (m *StorageVersionMigrator) rewriteClusterNetworkPolicies() error {
+	cnps, err := m.antreaClient.CrdV1beta1().ClusterNetworkPolicies().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("error listing Antrea ClusterNetworkPolicies: %v", err)
+	}
+	for i := range cnps.Items {
+		cnp := &cnps.Items[i]
+		_, err := m.antreaClient.CrdV1beta1().ClusterNetworkPolicies().Update(context.TODO(), cnp, metav1.UpdateOptions{})
+		// A conflict means that the object has been updated since it was listed, which stored
+		// it in the storage version already.
+		if err != nil && !errors.IsNotFound(err) && !errors.IsConflict(err) {
+			return fmt.Errorf("error rewriting Antrea ClusterNetworkPolicy %s: %v", cnp.Name, err)
+		}
+	}
+	return nil
+}
+
+// rewriteNetworkPolicies persists all Antrea NetworkPolicies again without change, which makes
+// kube-apiserver store them in the storage version.
+func (m *StorageVersionMigrator) rewriteNetworkPolicies() error {
+	anps, err := m.antreaClient.CrdV1beta1().NetworkPolicies("").List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("error listing Antrea NetworkPolicies: %v", err)
+	}
+	for i := range anps.Items {
+		anp := &anps.Items[i]
+		_, err := m.antreaClient.CrdV1beta1().NetworkPolicies(anp.Namespace).Update(context.TODO(), anp, metav1.UpdateOptions{})
+		if err != nil && !errors.IsNotFound(err) && !errors.IsConflict(err) {
+			return fmt.Errorf("error rewriting Antrea NetworkPolicy %s/%s: %v", anp.Namespace, anp.Name, err)
+		}
+	}
+	return nil
+}
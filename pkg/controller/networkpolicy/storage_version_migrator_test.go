@@ -0,0 +1,66 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package networkpolicy
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apiextensionv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	fakeapiextensionclientset "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stesting "k8s.io/client-go/testing"
+
+	crdv1beta1 "antrea.io/antrea/pkg/apis/crd/v1beta1"
+	fakeversioned "antrea.io/antrea/pkg/client/clientset/versioned/fake"
+)
+
+func newCRD(name string, storedVersions ...string) *apiextensionv1.CustomResourceDefinition {
+	return &apiextensionv1.CustomResourceDefinition{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status:     apiextensionv1.CustomResourceDefinitionStatus{StoredVersions: storedVersions},
+	}
+}
+
+func TestStorageVersionMigrator(t *testing.T) {
+	antreaClient := fakeversioned.NewSimpleClientset(
+		&crdv1beta1.ClusterNetworkPolicy{ObjectMeta: metav1.ObjectMeta{Name: "acnp"}},
+		&crdv1beta1.NetworkPolicy{ObjectMeta: metav1.ObjectMeta{Name: "anp", Namespace: "ns1"}},
+	)
+	apiExtensionClient := fakeapiextensionclientset.NewSimpleClientset(
+		newCRD(cnpCRDName, "v1beta1"),
+		newCRD(anpCRDName, "v1alpha1", "v1beta1"),
+	)
+	m := NewStorageVersionMigrator(antreaClient, apiExtensionClient)
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	m.Run(stopCh)
+
+	var updated []string
+	for _, action := range antreaClient.Actions() {
+		if action.GetVerb() == "update" {
+			updated = append(updated, action.(k8stesting.UpdateAction).GetResource().Resource)
+		}
+	}
+	// The ClusterNetworkPolicies are already stored in the storage version only.
+	assert.Equal(t, []string{"networkpolicies"}, updated)
+	for _, crdName := range []string{cnpCRDName, anpCRDName} {
+		crd, err := apiExtensionClient.ApiextensionsV1().CustomResourceDefinitions().Get(context.TODO(), crdName, metav1.GetOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"v1beta1"}, crd.Status.StoredVersions)
+	}
+}
@@ -117,17 +117,7 @@ func genAddressGroupEvent(key string, prevObj, currObj interface{}, rv uint64) (
 	// Calculate PatchObject in advance so that we don't need to do it for
 	// each watcher when generating *event.Event.
 	if event.PrevGroup != nil && event.CurrGroup != nil {
-		var addedMembers, removedMembers []controlplane.GroupMember
-		for memberHash, member := range event.CurrGroup.GroupMembers {
-			if _, exists := event.PrevGroup.GroupMembers[memberHash]; !exists {
-				addedMembers = append(addedMembers, *member)
-			}
-		}
-		for memberHash, member := range event.PrevGroup.GroupMembers {
-			if _, exists := event.CurrGroup.GroupMembers[memberHash]; !exists {
-				removedMembers = append(removedMembers, *member)
-			}
-		}
+		addedMembers, removedMembers := groupMemberPatch(event.PrevGroup.GroupMembers, event.CurrGroup.GroupMembers)
 		// PatchObject will not be generated when only span changes.
 		if len(addedMembers)+len(removedMembers) > 0 {
 			event.PatchObject = new(controlplane.AddressGroupPatch)
@@ -74,21 +74,10 @@ func (event *appliedToGroupEvent) ToWatchEvent(selectors *storage.Selectors, isI
 			currMembers = event.CurrGroup.GroupMemberByNode[nodeName]
 			prevMembers = event.PrevGroup.GroupMemberByNode[nodeName]
 		} else {
-			currMembers = controlplane.GroupMemberSet{}
-			for _, members := range event.CurrGroup.GroupMemberByNode {
-				currMembers = currMembers.Union(members)
-			}
-			prevMembers = controlplane.GroupMemberSet{}
-			for _, members := range event.PrevGroup.GroupMemberByNode {
-				prevMembers = prevMembers.Union(members)
-			}
-		}
-		for _, member := range currMembers.Difference(prevMembers) {
-			obj.AddedGroupMembers = append(obj.AddedGroupMembers, *member)
-		}
-		for _, member := range prevMembers.Difference(currMembers) {
-			obj.RemovedGroupMembers = append(obj.RemovedGroupMembers, *member)
+			currMembers = groupMembersOnAllNodes(event.CurrGroup.GroupMemberByNode)
+			prevMembers = groupMembersOnAllNodes(event.PrevGroup.GroupMemberByNode)
 		}
+		obj.AddedGroupMembers, obj.RemovedGroupMembers = groupMemberPatch(prevMembers, currMembers)
 
 		if len(obj.AddedGroupMembers)+len(obj.RemovedGroupMembers) == 0 {
 			// No change for the watcher.
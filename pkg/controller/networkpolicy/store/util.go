@@ -17,6 +17,7 @@ package store
 import (
 	"reflect"
 
+	"antrea.io/antrea/pkg/apis/controlplane"
 	"antrea.io/antrea/pkg/apiserver/storage"
 	"antrea.io/antrea/pkg/controller/types"
 )
@@ -46,3 +47,26 @@ func isSelected(key string, prevObj, currObj interface{}, selectors *storage.Sel
 	currObjSelected := !reflect.ValueOf(currObj).IsNil() && keyAndSpanSelectFunc(selectors, key, currObj)
 	return prevObjSelected, currObjSelected
 }
+
+// groupMemberPatch returns the GroupMembers which must be added to and removed from prevMembers to
+// get currMembers. Both sets must be the versions of the group swapped in the store, they are only
+// read, so that the patch can never re-add a member removed by a later version.
+func groupMemberPatch(prevMembers, currMembers controlplane.GroupMemberSet) (added, removed []controlplane.GroupMember) {
+	for _, member := range currMembers.Difference(prevMembers) {
+		added = append(added, *member)
+	}
+	for _, member := range prevMembers.Difference(currMembers) {
+		removed = append(removed, *member)
+	}
+	return added, removed
+}
+
+// groupMembersOnAllNodes returns a new set of the GroupMembers on all the Nodes. The sets of the
+// Nodes are not modified.
+func groupMembersOnAllNodes(memberByNode map[string]controlplane.GroupMemberSet) controlplane.GroupMemberSet {
+	members := controlplane.GroupMemberSet{}
+	for _, nodeMembers := range memberByNode {
+		members.Merge(nodeMembers, nil)
+	}
+	return members
+}
@@ -0,0 +1,186 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store
+
+import (
+	"fmt"
+	"math/rand"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/watch"
+
+	"antrea.io/antrea/pkg/apis/controlplane"
+	"antrea.io/antrea/pkg/apiserver/storage"
+	"antrea.io/antrea/pkg/controller/types"
+)
+
+const (
+	patchTestIterations = 1000
+	patchTestPoolSize   = 20
+)
+
+// newMemberPool returns size Pods, where every other Pod shares the name of the previous one with
+// a different IP, like a Pod recreated with a new IP.
+func newMemberPool(size int) []*controlplane.GroupMember {
+	pool := make([]*controlplane.GroupMember, 0, size)
+	for i := 0; i < size; i++ {
+		pool = append(pool, &controlplane.GroupMember{
+			Pod: &controlplane.PodReference{Namespace: "default", Name: fmt.Sprintf("pod%d", i/2)},
+			IPs: []controlplane.IPAddress{controlplane.IPAddress(net.ParseIP(fmt.Sprintf("10.0.0.%d", i)))},
+		})
+	}
+	return pool
+}
+
+// randomMemberSet returns a random subset of pool.
+func randomMemberSet(r *rand.Rand, pool []*controlplane.GroupMember) controlplane.GroupMemberSet {
+	members := controlplane.NewGroupMemberSet()
+	for _, member := range pool {
+		if r.Intn(2) == 0 {
+			members.Insert(member)
+		}
+	}
+	return members
+}
+
+// applyPatch returns a new set with the members of the patch applied to members.
+func applyPatch(members controlplane.GroupMemberSet, added, removed []controlplane.GroupMember) controlplane.GroupMemberSet {
+	result := controlplane.NewGroupMemberSet(members.Items()...)
+	for i := range removed {
+		result.Delete(&removed[i])
+	}
+	for i := range added {
+		result.Insert(&added[i])
+	}
+	return result
+}
+
+func TestGroupMemberPatch(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	pool := newMemberPool(patchTestPoolSize)
+	for i := 0; i < patchTestIterations; i++ {
+		prevMembers, currMembers := randomMemberSet(r, pool), randomMemberSet(r, pool)
+		prevCopy, currCopy := prevMembers.Union(nil), currMembers.Union(nil)
+		added, removed := groupMemberPatch(prevMembers, currMembers)
+		require.True(t, applyPatch(prevMembers, added, removed).Equal(currMembers), "patch(%v, %v) applied to the previous members is not equal to the current members", prevMembers.Items(), currMembers.Items())
+		// Computing the patch must not modify the sets.
+		require.Equal(t, prevCopy, prevMembers)
+		require.Equal(t, currCopy, currMembers)
+		// An empty patch is only computed for equal sets.
+		require.Equal(t, prevMembers.Equal(currMembers), len(added)+len(removed) == 0)
+	}
+}
+
+func TestGroupMembersOnAllNodes(t *testing.T) {
+	pool := newMemberPool(4)
+	memberByNode := map[string]controlplane.GroupMemberSet{
+		"node1": controlplane.NewGroupMemberSet(pool[0], pool[1]),
+		"node2": controlplane.NewGroupMemberSet(pool[2]),
+		"node3": controlplane.NewGroupMemberSet(),
+	}
+	members := groupMembersOnAllNodes(memberByNode)
+	assert.Equal(t, controlplane.NewGroupMemberSet(pool[0], pool[1], pool[2]), members)
+	// The sets of the Nodes must not be modified.
+	assert.Equal(t, controlplane.NewGroupMemberSet(pool[0], pool[1]), memberByNode["node1"])
+	assert.Equal(t, controlplane.NewGroupMemberSet(pool[2]), memberByNode["node2"])
+	assert.Empty(t, memberByNode["node3"])
+	assert.Empty(t, groupMembersOnAllNodes(nil))
+}
+
+// TestAddressGroupPatchProperty verifies that the patch of every update of an AddressGroup, applied
+// to its previous members, gives its current members.
+func TestAddressGroupPatchProperty(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	pool := newMemberPool(patchTestPoolSize)
+	selectors := &storage.Selectors{Field: fields.Everything()}
+	prevGroup := &types.AddressGroup{Name: "foo", GroupMembers: randomMemberSet(r, pool), SpanMeta: types.SpanMeta{NodeNames: sets.NewString("node1")}}
+	for i := 0; i < patchTestIterations; i++ {
+		currGroup := &types.AddressGroup{Name: "foo", GroupMembers: randomMemberSet(r, pool), SpanMeta: types.SpanMeta{NodeNames: sets.NewString("node1")}}
+		event, err := genAddressGroupEvent("foo", prevGroup, currGroup, uint64(i))
+		require.NoError(t, err)
+		if event == nil {
+			require.True(t, prevGroup.GroupMembers.Equal(currGroup.GroupMembers))
+			continue
+		}
+		watchEvent := event.ToWatchEvent(selectors, false)
+		if watchEvent == nil {
+			require.True(t, prevGroup.GroupMembers.Equal(currGroup.GroupMembers))
+		} else {
+			require.Equal(t, watch.Modified, watchEvent.Type)
+			patch := watchEvent.Object.(*controlplane.AddressGroupPatch)
+			require.True(t, applyPatch(prevGroup.GroupMembers, patch.AddedGroupMembers, patch.RemovedGroupMembers).Equal(currGroup.GroupMembers))
+		}
+		prevGroup = currGroup
+	}
+}
+
+// TestAppliedToGroupPatchProperty verifies that the patch of every update of an AppliedToGroup,
+// applied to its previous members, gives its current members, for the watchers of all the Nodes and
+// for the watchers of a single Node.
+func TestAppliedToGroupPatchProperty(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	pool := newMemberPool(patchTestPoolSize)
+	nodes := []string{"node1", "node2", "node3"}
+	randomAppliedToGroup := func() *types.AppliedToGroup {
+		group := &types.AppliedToGroup{
+			Name:              "foo",
+			GroupMemberByNode: map[string]controlplane.GroupMemberSet{},
+			SpanMeta:          types.SpanMeta{NodeNames: sets.NewString(nodes...)},
+		}
+		// A member is only on a single Node, but it can move to another Node between updates.
+		for _, member := range pool {
+			if r.Intn(2) == 0 {
+				continue
+			}
+			node := nodes[r.Intn(len(nodes))]
+			if group.GroupMemberByNode[node] == nil {
+				group.GroupMemberByNode[node] = controlplane.NewGroupMemberSet()
+			}
+			group.GroupMemberByNode[node].Insert(member)
+		}
+		return group
+	}
+	checkPatch := func(event *watch.Event, prevMembers, currMembers controlplane.GroupMemberSet) {
+		if event == nil {
+			require.True(t, prevMembers.Equal(currMembers))
+			return
+		}
+		require.Equal(t, watch.Modified, event.Type)
+		patch := event.Object.(*controlplane.AppliedToGroupPatch)
+		require.True(t, applyPatch(prevMembers, patch.AddedGroupMembers, patch.RemovedGroupMembers).Equal(currMembers))
+	}
+	prevGroup := randomAppliedToGroup()
+	for i := 0; i < patchTestIterations; i++ {
+		currGroup := randomAppliedToGroup()
+		event, err := genAppliedToGroupEvent("foo", prevGroup, currGroup, uint64(i))
+		require.NoError(t, err)
+		if event == nil {
+			prevGroup = currGroup
+			continue
+		}
+		checkPatch(event.ToWatchEvent(&storage.Selectors{Field: fields.Everything()}, false),
+			groupMembersOnAllNodes(prevGroup.GroupMemberByNode), groupMembersOnAllNodes(currGroup.GroupMemberByNode))
+		for _, node := range nodes {
+			selectors := &storage.Selectors{Field: fields.OneTermEqualSelector("nodeName", node)}
+			checkPatch(event.ToWatchEvent(selectors, false), prevGroup.GroupMemberByNode[node], currGroup.GroupMemberByNode[node])
+		}
+		prevGroup = currGroup
+	}
+}
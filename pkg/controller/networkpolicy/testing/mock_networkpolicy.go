@@ -48,6 +48,21 @@ func (m *MockEndpointQuerier) EXPECT() *MockEndpointQuerierMockRecorder {
 	return m.recorder
 }
 
+// QueryLargestGroups mocks base method
+func (m *MockEndpointQuerier) QueryLargestGroups(arg0 int) ([]networkpolicy.GroupSize, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "QueryLargestGroups", arg0)
+	ret0, _ := ret[0].([]networkpolicy.GroupSize)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QueryLargestGroups indicates an expected call of QueryLargestGroups
+func (mr *MockEndpointQuerierMockRecorder) QueryLargestGroups(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryLargestGroups", reflect.TypeOf((*MockEndpointQuerier)(nil).QueryLargestGroups), arg0)
+}
+
 // QueryNetworkPolicies mocks base method
 func (m *MockEndpointQuerier) QueryNetworkPolicies(arg0, arg1 string) (*networkpolicy.EndpointQueryResponse, error) {
 	m.ctrl.T.Helper()
@@ -62,3 +77,33 @@ func (mr *MockEndpointQuerierMockRecorder) QueryNetworkPolicies(arg0, arg1 inter
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryNetworkPolicies", reflect.TypeOf((*MockEndpointQuerier)(nil).QueryNetworkPolicies), arg0, arg1)
 }
+
+// QueryPolicyConflicts mocks base method
+func (m *MockEndpointQuerier) QueryPolicyConflicts(arg0, arg1 string) (*networkpolicy.PolicyConflictResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "QueryPolicyConflicts", arg0, arg1)
+	ret0, _ := ret[0].(*networkpolicy.PolicyConflictResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QueryPolicyConflicts indicates an expected call of QueryPolicyConflicts
+func (mr *MockEndpointQuerierMockRecorder) QueryPolicyConflicts(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryPolicyConflicts", reflect.TypeOf((*MockEndpointQuerier)(nil).QueryPolicyConflicts), arg0, arg1)
+}
+
+// QueryPolicyQuotas mocks base method
+func (m *MockEndpointQuerier) QueryPolicyQuotas(arg0 string) ([]networkpolicy.PolicyQuotaUsage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "QueryPolicyQuotas", arg0)
+	ret0, _ := ret[0].([]networkpolicy.PolicyQuotaUsage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QueryPolicyQuotas indicates an expected call of QueryPolicyQuotas
+func (mr *MockEndpointQuerierMockRecorder) QueryPolicyQuotas(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryPolicyQuotas", reflect.TypeOf((*MockEndpointQuerier)(nil).QueryPolicyQuotas), arg0)
+}
@@ -20,14 +20,29 @@ package networkpolicy
 
 import (
 	"context"
+	"reflect"
 	"time"
 
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/cache"
 	"k8s.io/klog/v2"
 
 	secv1alpha1 "antrea.io/antrea/pkg/apis/crd/v1alpha1"
+	crdv1beta1 "antrea.io/antrea/pkg/apis/crd/v1beta1"
+)
+
+const (
+	// tierDefaultPolicyPrefix prefixes the name of the policy generated for the default action of
+	// a Tier. ":" is not allowed in the name of a ClusterNetworkPolicy, hence the generated policies
+	// never conflict with the ones created by users.
+	tierDefaultPolicyPrefix = "tier-default:"
+	// tierDefaultPolicyPriority is the priority of the policy generated for the default action of a
+	// Tier. It is lower than the lowest priority of ClusterNetworkPolicies, so that the policy is
+	// enforced after all the policies of the Tier.
+	tierDefaultPolicyPriority = float64(10001)
 )
 
 var (
@@ -199,3 +214,87 @@ func (n *NetworkPolicyController) updateTier(t *secv1alpha1.Tier) {
 		return
 	}
 }
+
+// tierDefaultPolicy returns the ClusterNetworkPolicy implementing the default action of the Tier,
+// or nil if the Tier has no default action. The policy applies to all Pods and matches any traffic
+// in both directions. It is never created in the K8s apiserver and only exists as an internal
+// NetworkPolicy.
+func tierDefaultPolicy(tier *secv1alpha1.Tier) *crdv1beta1.ClusterNetworkPolicy {
+	if tier.Spec.DefaultAction == nil {
+		return nil
+	}
+	newRule := func(name string) crdv1beta1.Rule {
+		action := crdv1beta1.RuleAction(*tier.Spec.DefaultAction)
+		return crdv1beta1.Rule{
+			Action:        &action,
+			Name:          name,
+			EnableLogging: tier.Spec.EnableLogging,
+		}
+	}
+	return &crdv1beta1.ClusterNetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              tierDefaultPolicyPrefix + tier.Name,
+			UID:               types.UID(tierDefaultPolicyPrefix + string(tier.UID)),
+			Generation:        tier.Generation,
+			CreationTimestamp: tier.CreationTimestamp,
+			ManagedFields:     tier.ManagedFields,
+		},
+		Spec: crdv1beta1.ClusterNetworkPolicySpec{
+			Tier:      tier.Name,
+			Priority:  tierDefaultPolicyPriority,
+			AppliedTo: []crdv1beta1.NetworkPolicyPeer{matchAllPodsPeerCrd},
+			Ingress:   []crdv1beta1.Rule{newRule("default-ingress")},
+			Egress:    []crdv1beta1.Rule{newRule("default-egress")},
+		},
+	}
+}
+
+// addTierDefaultPolicy receives Tier ADD events and creates the policy implementing the default
+// action of the Tier if it has one.
+func (n *NetworkPolicyController) addTierDefaultPolicy(obj interface{}) {
+	tier := obj.(*secv1alpha1.Tier)
+	if cnp := tierDefaultPolicy(tier); cnp != nil {
+		klog.Infof("Processing default action %s of Tier %s", *tier.Spec.DefaultAction, tier.Name)
+		n.addCNP(cnp)
+	}
+}
+
+// updateTierDefaultPolicy receives Tier UPDATE events and creates, updates or deletes the policy
+// implementing the default action of the Tier accordingly. As the policy gets the priority of the
+// Tier when it is processed, a change of the Tier priority re-positions the policy.
+func (n *NetworkPolicyController) updateTierDefaultPolicy(old, cur interface{}) {
+	oldTier, curTier := old.(*secv1alpha1.Tier), cur.(*secv1alpha1.Tier)
+	if reflect.DeepEqual(oldTier.Spec, curTier.Spec) {
+		return
+	}
+	oldCNP, curCNP := tierDefaultPolicy(oldTier), tierDefaultPolicy(curTier)
+	switch {
+	case oldCNP == nil && curCNP != nil:
+		n.addCNP(curCNP)
+	case oldCNP != nil && curCNP == nil:
+		n.deleteCNP(oldCNP)
+	case oldCNP != nil && curCNP != nil:
+		n.updateCNP(oldCNP, curCNP)
+	}
+}
+
+// deleteTierDefaultPolicy receives Tier DELETE events and deletes the policy implementing the
+// default action of the Tier if it has one.
+func (n *NetworkPolicyController) deleteTierDefaultPolicy(old interface{}) {
+	tier, ok := old.(*secv1alpha1.Tier)
+	if !ok {
+		tombstone, ok := old.(cache.DeletedFinalStateUnknown)
+		if !ok {
+			klog.Errorf("Error decoding object when deleting Tier, invalid type: %v", old)
+			return
+		}
+		tier, ok = tombstone.Obj.(*secv1alpha1.Tier)
+		if !ok {
+			klog.Errorf("Error decoding object tombstone when deleting Tier, invalid type: %v", tombstone.Obj)
+			return
+		}
+	}
+	if cnp := tierDefaultPolicy(tier); cnp != nil {
+		n.deleteCNP(cnp)
+	}
+}
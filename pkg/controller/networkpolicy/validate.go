@@ -17,6 +17,7 @@ package networkpolicy
 import (
 	"encoding/json"
 	"fmt"
+	"net"
 	"strconv"
 
 	admv1 "k8s.io/api/admission/v1"
@@ -29,6 +30,7 @@ import (
 
 	crdv1alpha1 "antrea.io/antrea/pkg/apis/crd/v1alpha1"
 	crdv1alpha2 "antrea.io/antrea/pkg/apis/crd/v1alpha2"
+	crdv1beta1 "antrea.io/antrea/pkg/apis/crd/v1beta1"
 	"antrea.io/antrea/pkg/controller/networkpolicy/store"
 	"antrea.io/antrea/pkg/controller/types"
 	"antrea.io/antrea/pkg/util/env"
@@ -140,6 +142,7 @@ func NewNetworkPolicyValidator(networkPolicyController *NetworkPolicyController)
 func (v *NetworkPolicyValidator) Validate(ar *admv1.AdmissionReview) *admv1.AdmissionResponse {
 	var result *metav1.Status
 	var msg string
+	var warnings []string
 	allowed := false
 	op := ar.Request.Operation
 	ui := ar.Request.UserInfo
@@ -180,7 +183,7 @@ func (v *NetworkPolicyValidator) Validate(ar *admv1.AdmissionReview) *admv1.Admi
 		msg, allowed = v.validateAntreaGroup(&curCG, &oldCG, op, ui)
 	case "ClusterNetworkPolicy":
 		klog.V(2).Info("Validating Antrea ClusterNetworkPolicy CRD")
-		var curCNP, oldCNP crdv1alpha1.ClusterNetworkPolicy
+		var curCNP, oldCNP crdv1beta1.ClusterNetworkPolicy
 		if curRaw != nil {
 			if err := json.Unmarshal(curRaw, &curCNP); err != nil {
 				klog.Errorf("Error de-serializing current Antrea ClusterNetworkPolicy")
@@ -194,9 +197,12 @@ func (v *NetworkPolicyValidator) Validate(ar *admv1.AdmissionReview) *admv1.Admi
 			}
 		}
 		msg, allowed = v.validateAntreaPolicy(&curCNP, &oldCNP, op, ui)
+		if allowed && op != admv1.Delete {
+			warnings = getLayer2RuleWarnings(curCNP.Spec.Layer2)
+		}
 	case "NetworkPolicy":
 		klog.V(2).Info("Validating Antrea NetworkPolicy CRD")
-		var curANP, oldANP crdv1alpha1.NetworkPolicy
+		var curANP, oldANP crdv1beta1.NetworkPolicy
 		if curRaw != nil {
 			if err := json.Unmarshal(curRaw, &curANP); err != nil {
 				klog.Errorf("Error de-serializing current Antrea NetworkPolicy")
@@ -217,8 +223,9 @@ func (v *NetworkPolicyValidator) Validate(ar *admv1.AdmissionReview) *admv1.Admi
 		}
 	}
 	return &admv1.AdmissionResponse{
-		Allowed: allowed,
-		Result:  result,
+		Allowed:  allowed,
+		Result:   result,
+		Warnings: warnings,
 	}
 }
 
@@ -255,8 +262,8 @@ func (v *NetworkPolicyValidator) validateAntreaPolicy(curObj, oldObj interface{}
 }
 
 // validatePort validates if ports is valid
-func (a *antreaPolicyValidator) validatePort(ingress, egress []crdv1alpha1.Rule) error {
-	isValid := func(rules []crdv1alpha1.Rule) error {
+func (a *antreaPolicyValidator) validatePort(ingress, egress []crdv1beta1.Rule) error {
+	isValid := func(rules []crdv1beta1.Rule) error {
 		for _, rule := range rules {
 			for _, port := range rule.Ports {
 				if port.EndPort == nil {
@@ -382,18 +389,22 @@ func GetAdmissionResponseForErr(err error) *admv1.AdmissionResponse {
 
 // createValidate validates the CREATE events of Antrea-native policies,
 func (a *antreaPolicyValidator) createValidate(curObj interface{}, userInfo authenticationv1.UserInfo) (string, bool) {
-	var tier string
-	var ingress, egress []crdv1alpha1.Rule
-	var specAppliedTo []crdv1alpha1.NetworkPolicyPeer
+	var tier, namespace string
+	var ingress, egress []crdv1beta1.Rule
+	var layer2 []crdv1beta1.Layer2Rule
+	var specAppliedTo []crdv1beta1.NetworkPolicyPeer
 	switch curObj.(type) {
-	case *crdv1alpha1.ClusterNetworkPolicy:
-		curCNP := curObj.(*crdv1alpha1.ClusterNetworkPolicy)
+	case *crdv1beta1.ClusterNetworkPolicy:
+		curCNP := curObj.(*crdv1beta1.ClusterNetworkPolicy)
 		tier = curCNP.Spec.Tier
 		ingress = curCNP.Spec.Ingress
-		egress = curCNP.Spec.Egress
+		// The layer 2 rules are validated as egress rules whose peers are the target addresses.
+		egress = append(toRulesForLayer2(curCNP.Spec.Layer2), curCNP.Spec.Egress...)
+		layer2 = curCNP.Spec.Layer2
 		specAppliedTo = curCNP.Spec.AppliedTo
-	case *crdv1alpha1.NetworkPolicy:
-		curANP := curObj.(*crdv1alpha1.NetworkPolicy)
+	case *crdv1beta1.NetworkPolicy:
+		curANP := curObj.(*crdv1beta1.NetworkPolicy)
+		namespace = curANP.Namespace
 		tier = curANP.Spec.Tier
 		ingress = curANP.Spec.Ingress
 		egress = curANP.Spec.Egress
@@ -406,11 +417,19 @@ func (a *antreaPolicyValidator) createValidate(curObj interface{}, userInfo auth
 	if ruleNameUnique := a.validateRuleName(ingress, egress); !ruleNameUnique {
 		return fmt.Sprint("rules names must be unique within the policy"), false
 	}
-	reason, allowed = a.validateAppliedTo(ingress, egress, specAppliedTo)
+	reason, allowed = validateRuleActions(tier, ingress, egress)
+	if !allowed {
+		return reason, allowed
+	}
+	reason, allowed = a.validateAppliedTo(namespace, ingress, egress, specAppliedTo)
 	if !allowed {
 		return reason, allowed
 	}
-	reason, allowed = a.validatePeers(ingress, egress)
+	reason, allowed = a.validatePeers(namespace, ingress, egress)
+	if !allowed {
+		return reason, allowed
+	}
+	reason, allowed = validateLayer2Rules(layer2)
 	if !allowed {
 		return reason, allowed
 	}
@@ -421,9 +440,9 @@ func (a *antreaPolicyValidator) createValidate(curObj interface{}, userInfo auth
 }
 
 // validateRuleName validates if the name of each rule is unique within a policy
-func (v *antreaPolicyValidator) validateRuleName(ingress, egress []crdv1alpha1.Rule) bool {
+func (v *antreaPolicyValidator) validateRuleName(ingress, egress []crdv1beta1.Rule) bool {
 	uniqueRuleName := sets.NewString()
-	isUnique := func(rules []crdv1alpha1.Rule) bool {
+	isUnique := func(rules []crdv1beta1.Rule) bool {
 		for _, rule := range rules {
 			if uniqueRuleName.Has(rule.Name) {
 				return false
@@ -435,9 +454,28 @@ func (v *antreaPolicyValidator) validateRuleName(ingress, egress []crdv1alpha1.R
 	return isUnique(ingress) && isUnique(egress)
 }
 
-func (a *antreaPolicyValidator) validateAppliedTo(ingress, egress []crdv1alpha1.Rule, specAppliedTo []crdv1alpha1.NetworkPolicyPeer) (string, bool) {
+// validateRuleActions ensures that the Pass action is not used in the baseline Tier, as the
+// baseline Tier is the last stage of policy evaluation and there is nothing to defer the traffic
+// to.
+func validateRuleActions(tier string, ingress, egress []crdv1beta1.Rule) (string, bool) {
+	if tier != baselineTierName {
+		return "", true
+	}
+	for _, rules := range [][]crdv1beta1.Rule{ingress, egress} {
+		for _, rule := range rules {
+			if rule.Action != nil && *rule.Action == crdv1beta1.RuleActionPass {
+				return fmt.Sprintf("rule %s cannot use the Pass action in the %s Tier", rule.Name, baselineTierName), false
+			}
+		}
+	}
+	return "", true
+}
+
+// validateAppliedTo validates the appliedTo set in the spec or in the rules of an Antrea-native policy.
+// namespace is the Namespace of the policy, which is empty for ClusterNetworkPolicies.
+func (a *antreaPolicyValidator) validateAppliedTo(namespace string, ingress, egress []crdv1beta1.Rule, specAppliedTo []crdv1beta1.NetworkPolicyPeer) (string, bool) {
 	appliedToInSpec := len(specAppliedTo) != 0
-	countAppliedToInRules := func(rules []crdv1alpha1.Rule) int {
+	countAppliedToInRules := func(rules []crdv1beta1.Rule) int {
 		num := 0
 		for _, rule := range rules {
 			if len(rule.AppliedTo) != 0 {
@@ -458,8 +496,36 @@ func (a *antreaPolicyValidator) validateAppliedTo(ingress, egress []crdv1alpha1.
 	if numAppliedToInRules > 0 && (numAppliedToInRules != len(ingress)+len(egress)) {
 		return "appliedTo field should either be set in all rules or in none of them", false
 	}
+	// Ensure that a ServiceAccount is set alone and, for Antrea NetworkPolicies, in the policy's Namespace.
+	checkServiceAccounts := func(appTos []crdv1beta1.NetworkPolicyPeer) (string, bool) {
+		for _, appTo := range appTos {
+			if appTo.NodeSelector != nil {
+				return "nodeSelector cannot be set in appliedTo", false
+			}
+			if reason, allowed := validateServiceAccountPeer(appTo); !allowed {
+				return reason, false
+			}
+			if appTo.ServiceAccount != nil && namespace != "" && appTo.ServiceAccount.Namespace != namespace {
+				return fmt.Sprintf("serviceAccount in appliedTo must be in the Namespace %s of the policy", namespace), false
+			}
+		}
+		return "", true
+	}
+	if reason, allowed := checkServiceAccounts(specAppliedTo); !allowed {
+		return reason, false
+	}
+	for _, rule := range ingress {
+		if reason, allowed := checkServiceAccounts(rule.AppliedTo); !allowed {
+			return reason, false
+		}
+	}
+	for _, rule := range egress {
+		if reason, allowed := checkServiceAccounts(rule.AppliedTo); !allowed {
+			return reason, false
+		}
+	}
 	// Ensure CG exists
-	checkAppTo := func(appTos []crdv1alpha1.NetworkPolicyPeer) bool {
+	checkAppTo := func(appTos []crdv1beta1.NetworkPolicyPeer) bool {
 		for _, appTo := range specAppliedTo {
 			if appTo.Group != "" {
 				// Ensure that group exists
@@ -490,17 +556,34 @@ func (a *antreaPolicyValidator) validateAppliedTo(ingress, egress []crdv1alpha1.
 }
 
 // validatePeers ensures that the NetworkPolicyPeer object set in rules are valid, i.e.
-// currently it ensures that a Group cannot be set with other stand-alone selectors or IPBlock.
-func (a *antreaPolicyValidator) validatePeers(ingress, egress []crdv1alpha1.Rule) (string, bool) {
-	checkPeers := func(peers []crdv1alpha1.NetworkPolicyPeer) (string, bool) {
+// currently it ensures that a Group cannot be set with other stand-alone selectors or IPBlock,
+// that Namespaces is only set with the selectors it applies to, and that a nodeSelector is
+// only set alone in the egress rules of ClusterNetworkPolicies.
+func (a *antreaPolicyValidator) validatePeers(namespace string, ingress, egress []crdv1beta1.Rule) (string, bool) {
+	checkPeers := func(peers []crdv1beta1.NetworkPolicyPeer, nodeSelectorAllowed bool) (string, bool) {
 		for _, peer := range peers {
+			if peer.NodeSelector != nil {
+				if !nodeSelectorAllowed {
+					return "nodeSelector can only be set in the egress rules of ClusterNetworkPolicies", false
+				}
+				if peer.IPBlock != nil || peer.PodSelector != nil || peer.NamespaceSelector != nil || peer.Namespaces != nil ||
+					peer.ExternalEntitySelector != nil || peer.Group != "" || peer.ServiceAccount != nil {
+					return "nodeSelector cannot be set with other selectors for a single NetworkPolicyPeer", false
+				}
+			}
 			if peer.NamespaceSelector != nil && peer.Namespaces != nil {
 				return "namespaces and namespaceSelector cannot be set at the same time for a single NetworkPolicyPeer", false
 			}
+			if peer.IPBlock != nil && peer.Namespaces != nil {
+				return "namespaces and ipBlock cannot be set at the same time for a single NetworkPolicyPeer", false
+			}
+			if reason, allowed := validateServiceAccountPeer(peer); !allowed {
+				return reason, false
+			}
 			if peer.Group == "" {
 				continue
 			}
-			if peer.PodSelector != nil || peer.IPBlock != nil || peer.NamespaceSelector != nil {
+			if peer.PodSelector != nil || peer.IPBlock != nil || peer.NamespaceSelector != nil || peer.Namespaces != nil {
 				return "group cannot be set with other peers in rules", false
 			}
 			// Ensure that group exists
@@ -511,13 +594,13 @@ func (a *antreaPolicyValidator) validatePeers(ingress, egress []crdv1alpha1.Rule
 		return "", true
 	}
 	for _, rule := range ingress {
-		msg, isValid := checkPeers(rule.From)
+		msg, isValid := checkPeers(rule.From, false)
 		if !isValid {
 			return msg, false
 		}
 	}
 	for _, rule := range egress {
-		msg, isValid := checkPeers(rule.To)
+		msg, isValid := checkPeers(rule.To, namespace == "")
 		if !isValid {
 			return msg, false
 		}
@@ -525,6 +608,114 @@ func (a *antreaPolicyValidator) validatePeers(ingress, egress []crdv1alpha1.Rule
 	return "", true
 }
 
+// toRulesForLayer2 returns the rules with the target addresses, name, action and appliedTo of the
+// layer 2 rules of a ClusterNetworkPolicy.
+func toRulesForLayer2(layer2 []crdv1beta1.Layer2Rule) []crdv1beta1.Rule {
+	var rules []crdv1beta1.Rule
+	for _, rule := range layer2 {
+		rules = append(rules, crdv1beta1.Rule{
+			Action:    rule.Action,
+			To:        rule.To,
+			Name:      rule.Name,
+			AppliedTo: rule.AppliedTo,
+		})
+	}
+	return rules
+}
+
+// validateLayer2Rules ensures that the layer 2 rules of a ClusterNetworkPolicy only use the Allow
+// and Drop actions, that the Neighbor Discovery message type is only set for the ICMPv6ND protocol,
+// and that the Router Solicitation and Router Advertisement messages, which have no target address,
+// are not matched by target addresses.
+func validateLayer2Rules(layer2 []crdv1beta1.Layer2Rule) (string, bool) {
+	for _, rule := range layer2 {
+		if rule.Action == nil || (*rule.Action != crdv1beta1.RuleActionAllow && *rule.Action != crdv1beta1.RuleActionDrop) {
+			return fmt.Sprintf("layer2 rule %s must use the Allow or Drop action", rule.Name), false
+		}
+		switch rule.Protocol {
+		case crdv1beta1.Layer2ProtocolARP:
+			if rule.NDType != nil {
+				return fmt.Sprintf("ndType cannot be set in layer2 rule %s with protocol %s", rule.Name, rule.Protocol), false
+			}
+			// The target protocol address of ARP messages can only be matched exactly.
+			for _, peer := range rule.To {
+				if peer.IPBlock == nil {
+					continue
+				}
+				_, ipNet, err := net.ParseCIDR(peer.IPBlock.CIDR)
+				if err != nil {
+					return fmt.Sprintf("invalid ipBlock %s in layer2 rule %s: %v", peer.IPBlock.CIDR, rule.Name, err), false
+				}
+				if ones, bits := ipNet.Mask.Size(); bits != 32 || (ones != 0 && ones != 32) || len(peer.IPBlock.Except) > 0 {
+					return fmt.Sprintf("ipBlock %s in layer2 rule %s with protocol %s must be a single IPv4 address or 0.0.0.0/0 without except", peer.IPBlock.CIDR, rule.Name, rule.Protocol), false
+				}
+			}
+		case crdv1beta1.Layer2ProtocolICMPv6ND:
+			if rule.NDType == nil {
+				break
+			}
+			if _, ok := ndTypes[*rule.NDType]; !ok {
+				return fmt.Sprintf("invalid ndType %s in layer2 rule %s", *rule.NDType, rule.Name), false
+			}
+			if (*rule.NDType == crdv1beta1.NDTypeRouterSolicitation || *rule.NDType == crdv1beta1.NDTypeRouterAdvertisement) && len(rule.To) > 0 {
+				return fmt.Sprintf("to cannot be set in layer2 rule %s as %s messages have no target address", rule.Name, *rule.NDType), false
+			}
+		default:
+			return fmt.Sprintf("invalid protocol %s in layer2 rule %s", rule.Protocol, rule.Name), false
+		}
+		for _, peer := range rule.To {
+			if peer.Namespaces != nil {
+				return fmt.Sprintf("namespaces cannot be set in the peers of layer2 rule %s", rule.Name), false
+			}
+		}
+	}
+	return "", true
+}
+
+// getLayer2RuleWarnings returns a warning for each layer 2 rule of a ClusterNetworkPolicy which
+// may drop the ARP or Neighbor Solicitation and Advertisement messages the Pods need to resolve
+// the MAC address of their gateway, i.e. a Drop rule which matches all target addresses or
+// IPBlocks, which may include the gateway addresses.
+func getLayer2RuleWarnings(layer2 []crdv1beta1.Layer2Rule) []string {
+	var warnings []string
+	for _, rule := range layer2 {
+		if rule.Action == nil || *rule.Action != crdv1beta1.RuleActionDrop {
+			continue
+		}
+		if rule.NDType != nil && *rule.NDType != crdv1beta1.NDTypeNeighborSolicitation && *rule.NDType != crdv1beta1.NDTypeNeighborAdvertisement {
+			continue
+		}
+		mayMatchGateway := len(rule.To) == 0
+		for _, peer := range rule.To {
+			if peer.IPBlock != nil {
+				mayMatchGateway = true
+			}
+		}
+		if mayMatchGateway {
+			warnings = append(warnings, fmt.Sprintf("layer2 rule %s may drop the %s messages the Pods it applies to need to resolve their gateway, which would cut off their connectivity", rule.Name, rule.Protocol))
+		}
+	}
+	return warnings
+}
+
+// validateServiceAccountPeer ensures that a ServiceAccount set in a NetworkPolicyPeer is referred to by both
+// its Namespace and name, and is not set with any other selector.
+func validateServiceAccountPeer(peer crdv1beta1.NetworkPolicyPeer) (string, bool) {
+	if peer.ServiceAccount == nil {
+		return "", true
+	}
+	if peer.ServiceAccount.Name == "" || peer.ServiceAccount.Namespace == "" {
+		return "serviceAccount must have both name and namespace set", false
+	}
+	if peer.PodSelector != nil {
+		return "serviceAccount and podSelector cannot be set at the same time for a single NetworkPolicyPeer", false
+	}
+	if peer.IPBlock != nil || peer.NamespaceSelector != nil || peer.Namespaces != nil || peer.ExternalEntitySelector != nil || peer.Group != "" {
+		return "serviceAccount cannot be set with other selectors for a single NetworkPolicyPeer", false
+	}
+	return "", true
+}
+
 // validateTierForPolicy validates whether a referenced Tier exists.
 func (v *antreaPolicyValidator) validateTierForPolicy(tier string) (string, bool) {
 	// "tier" must exist before referencing
@@ -541,31 +732,43 @@ func (v *antreaPolicyValidator) validateTierForPolicy(tier string) (string, bool
 
 // updateValidate validates the UPDATE events of Antrea-native policies.
 func (a *antreaPolicyValidator) updateValidate(curObj, oldObj interface{}, userInfo authenticationv1.UserInfo) (string, bool) {
-	var tier string
-	var ingress, egress []crdv1alpha1.Rule
-	var specAppliedTo []crdv1alpha1.NetworkPolicyPeer
+	var tier, namespace string
+	var ingress, egress []crdv1beta1.Rule
+	var layer2 []crdv1beta1.Layer2Rule
+	var specAppliedTo []crdv1beta1.NetworkPolicyPeer
 	switch curObj.(type) {
-	case *crdv1alpha1.ClusterNetworkPolicy:
-		curCNP := curObj.(*crdv1alpha1.ClusterNetworkPolicy)
+	case *crdv1beta1.ClusterNetworkPolicy:
+		curCNP := curObj.(*crdv1beta1.ClusterNetworkPolicy)
 		tier = curCNP.Spec.Tier
 		ingress = curCNP.Spec.Ingress
-		egress = curCNP.Spec.Egress
+		// The layer 2 rules are validated as egress rules whose peers are the target addresses.
+		egress = append(toRulesForLayer2(curCNP.Spec.Layer2), curCNP.Spec.Egress...)
+		layer2 = curCNP.Spec.Layer2
 		specAppliedTo = curCNP.Spec.AppliedTo
-	case *crdv1alpha1.NetworkPolicy:
-		curANP := curObj.(*crdv1alpha1.NetworkPolicy)
+	case *crdv1beta1.NetworkPolicy:
+		curANP := curObj.(*crdv1beta1.NetworkPolicy)
+		namespace = curANP.Namespace
 		tier = curANP.Spec.Tier
 		ingress = curANP.Spec.Ingress
 		egress = curANP.Spec.Egress
 		specAppliedTo = curANP.Spec.AppliedTo
 	}
-	reason, allowed := a.validateAppliedTo(ingress, egress, specAppliedTo)
+	reason, allowed := a.validateAppliedTo(namespace, ingress, egress, specAppliedTo)
 	if !allowed {
 		return reason, allowed
 	}
 	if ruleNameUnique := a.validateRuleName(ingress, egress); !ruleNameUnique {
 		return fmt.Sprint("rules names must be unique within the policy"), false
 	}
-	reason, allowed = a.validatePeers(ingress, egress)
+	reason, allowed = validateRuleActions(tier, ingress, egress)
+	if !allowed {
+		return reason, allowed
+	}
+	reason, allowed = a.validatePeers(namespace, ingress, egress)
+	if !allowed {
+		return reason, allowed
+	}
+	reason, allowed = validateLayer2Rules(layer2)
 	if !allowed {
 		return reason, allowed
 	}
@@ -586,6 +789,9 @@ func (t *tierValidator) createValidate(curObj interface{}, userInfo authenticati
 		return fmt.Sprintf("maximum number of Tiers supported: %d", maxSupportedTiers), false
 	}
 	curTier := curObj.(*crdv1alpha1.Tier)
+	if reason, allowed := validateTierDefaultAction(curTier); !allowed {
+		return reason, allowed
+	}
 	// Tier priority must not overlap reserved tier's priority.
 	if reservedTierPriorities.Has(curTier.Spec.Priority) {
 		return fmt.Sprintf("tier %s priority %d is reserved", curTier.Name, curTier.Spec.Priority), false
@@ -604,6 +810,9 @@ func (t *tierValidator) updateValidate(curObj, oldObj interface{}, userInfo auth
 	reason := ""
 	curTier := curObj.(*crdv1alpha1.Tier)
 	oldTier := oldObj.(*crdv1alpha1.Tier)
+	if reason, allowed := validateTierDefaultAction(curTier); !allowed {
+		return reason, allowed
+	}
 	// Retrieve antrea-controller's Namespace
 	namespace := env.GetAntreaNamespace()
 	// Allow exception of Tier Priority updates performed by the antrea-controller
@@ -617,6 +826,24 @@ func (t *tierValidator) updateValidate(curObj, oldObj interface{}, userInfo auth
 	return reason, allowed
 }
 
+// validateTierDefaultAction ensures that the default action of a Tier is supported and that it is
+// not set for the application and baseline Tiers. The default action of the application Tier
+// would prevent K8s NetworkPolicies, which are enforced right after it, from being enforced, and
+// the one of the baseline Tier would override the isolation semantics of K8s NetworkPolicies.
+func validateTierDefaultAction(tier *crdv1alpha1.Tier) (string, bool) {
+	if tier.Spec.DefaultAction == nil {
+		return "", true
+	}
+	if tier.Name == defaultTierName || tier.Name == baselineTierName {
+		return fmt.Sprintf("defaultAction cannot be set for the %s Tier", tier.Name), false
+	}
+	switch *tier.Spec.DefaultAction {
+	case crdv1alpha1.RuleActionAllow, crdv1alpha1.RuleActionDrop, crdv1alpha1.RuleActionPass:
+		return "", true
+	}
+	return fmt.Sprintf("defaultAction %s is not supported, it must be one of Allow, Drop or Pass", *tier.Spec.DefaultAction), false
+}
+
 // deleteValidate validates the DELETE events of Tier resources.
 func (t *tierValidator) deleteValidate(oldObj interface{}, userInfo authenticationv1.UserInfo) (string, bool) {
 	oldTier := oldObj.(*crdv1alpha1.Tier)
@@ -721,7 +948,7 @@ func (g *groupValidator) deleteValidate(oldObj interface{}, userInfo authenticat
 	if len(cnps) > 0 {
 		cnpNameList := make([]string, len(cnps))
 		for i := range cnps {
-			cnpObj := cnps[i].(*crdv1alpha1.ClusterNetworkPolicy)
+			cnpObj := cnps[i].(*crdv1beta1.ClusterNetworkPolicy)
 			cnpNameList[i] = cnpObj.Name
 		}
 		return fmt.Sprintf("ClusterGroup %s is referenced by %d Antrea ClusterNetworkPolicies: %v", oldCG.Name, len(cnps), cnpNameList), false
@@ -32,16 +32,27 @@ type ControllerQuerier interface {
 	GetControllerInfo(controllerInfo *v1beta1.AntreaControllerInfo, partial bool)
 }
 
+// AgentVersionQuerier provides the versions of the antrea-agents connected to the antrea-controller.
+type AgentVersionQuerier interface {
+	GetAgentVersions() map[string]string
+}
+
 type controllerQuerier struct {
 	networkPolicyInfoQuerier querier.ControllerNetworkPolicyInfoQuerier
+	agentVersionQuerier      AgentVersionQuerier
 	apiPort                  int
+	apiAggregationAvailable  bool
 }
 
 func NewControllerQuerier(networkPolicyInfoQuerier querier.ControllerNetworkPolicyInfoQuerier,
-	apiPort int) *controllerQuerier {
+	agentVersionQuerier AgentVersionQuerier,
+	apiPort int,
+	apiAggregationAvailable bool) *controllerQuerier {
 	return &controllerQuerier{
 		networkPolicyInfoQuerier: networkPolicyInfoQuerier,
+		agentVersionQuerier:      agentVersionQuerier,
 		apiPort:                  apiPort,
+		apiAggregationAvailable:  apiAggregationAvailable,
 	}
 }
 
@@ -66,13 +77,25 @@ func (cq controllerQuerier) getNetworkPolicyControllerInfo() v1beta1.NetworkPoli
 }
 
 func (cq controllerQuerier) getControllerConditions() []v1beta1.ControllerCondition {
-	return []v1beta1.ControllerCondition{
+	now := metav1.Now()
+	conditions := []v1beta1.ControllerCondition{
 		{
 			Type:              v1beta1.ControllerHealthy,
 			Status:            v1.ConditionTrue,
-			LastHeartbeatTime: metav1.Now(),
+			LastHeartbeatTime: now,
 		},
 	}
+	aggregationCondition := v1beta1.ControllerCondition{
+		Type:              v1beta1.ControllerAPIAggregationAvailable,
+		Status:            v1.ConditionTrue,
+		LastHeartbeatTime: now,
+	}
+	if !cq.apiAggregationAvailable {
+		aggregationCondition.Status = v1.ConditionFalse
+		aggregationCondition.Reason = "APIServiceRegistrationUnavailable"
+		aggregationCondition.Message = "The APIServices backed by antrea-controller cannot be registered: the controlplane and system APIs are only served on the antrea-controller endpoint, and the stats API is disabled"
+	}
+	return append(conditions, aggregationCondition)
 }
 
 // GetControllerInfo gets current info of controller.
@@ -80,6 +103,7 @@ func (cq controllerQuerier) GetControllerInfo(controllerInfo *v1beta1.AntreaCont
 	controllerInfo.NetworkPolicyControllerInfo = cq.getNetworkPolicyControllerInfo()
 	controllerInfo.ConnectedAgentNum = int32(cq.getNetworkPolicyInfoQuerier().GetConnectedAgentNum())
 	controllerInfo.ControllerConditions = cq.getControllerConditions()
+	controllerInfo.AgentVersions = cq.agentVersionQuerier.GetAgentVersions()
 
 	if !partial {
 		controllerInfo.Version = querier.GetVersion()
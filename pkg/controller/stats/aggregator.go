@@ -16,25 +16,32 @@ package stats
 
 import (
 	"fmt"
+	"sync"
 	"time"
 
 	networkingv1 "k8s.io/api/networking/v1"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
 	networkinginformers "k8s.io/client-go/informers/networking/v1"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/klog/v2"
 
 	"antrea.io/antrea/pkg/apis/controlplane"
-	crdv1alpha1 "antrea.io/antrea/pkg/apis/crd/v1alpha1"
+	crdv1beta1 "antrea.io/antrea/pkg/apis/crd/v1beta1"
 	statsv1alpha1 "antrea.io/antrea/pkg/apis/stats/v1alpha1"
-	crdvinformers "antrea.io/antrea/pkg/client/informers/externalversions/crd/v1alpha1"
+	crdvinformers "antrea.io/antrea/pkg/client/informers/externalversions/crd/v1beta1"
 	"antrea.io/antrea/pkg/features"
 	"antrea.io/antrea/pkg/util/k8s"
 )
 
 const (
 	uidIndex = "uid"
+	// watchQueueLength is the number of events which can be queued for a watcher of the stats. The events are dropped
+	// for a watcher whose queue is full, so that a slow watcher doesn't block the aggregation.
+	watchQueueLength = 1000
 )
 
 // Aggregator collects the stats from the antrea-agents, aggregates them, caches the result, and provides interfaces
@@ -50,6 +57,11 @@ type Aggregator struct {
 	antreaClusterNetworkPolicyStats cache.Indexer
 	// antreaNetworkPolicyStats caches the statistics of Antrea NetworkPolicies collected from the antrea-agents.
 	antreaNetworkPolicyStats cache.Indexer
+	// antreaNetworkPolicyStatsBroadcaster broadcasts the changes of antreaNetworkPolicyStats to the watchers.
+	antreaNetworkPolicyStatsBroadcaster *watch.Broadcaster
+	// antreaNetworkPolicyStatsMutex serializes the changes of antreaNetworkPolicyStats with the creation of watchers,
+	// so that a watcher receives all the changes made after the stats it initially receives.
+	antreaNetworkPolicyStatsMutex sync.Mutex
 	// dataCh is the channel that buffers the NodeSummaries sent by antrea-agents.
 	dataCh chan *controlplane.NodeStatsSummary
 	// npListerSynced is a function which returns true if the K8s NetworkPolicy shared informer has been synced at least once.
@@ -102,6 +114,7 @@ func NewAggregator(networkPolicyInformer networkinginformers.NetworkPolicyInform
 		)
 
 		aggregator.antreaNetworkPolicyStats = cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc, uidIndex: uidIndexFunc})
+		aggregator.antreaNetworkPolicyStatsBroadcaster = watch.NewLongQueueBroadcaster(watchQueueLength, watch.DropIfChannelFull)
 		aggregator.anpListerSynced = anpInformer.Informer().HasSynced
 		anpInformer.Informer().AddEventHandlerWithResyncPeriod(
 			cache.ResourceEventHandlerFuncs{
@@ -158,7 +171,7 @@ func (a *Aggregator) deleteNetworkPolicy(obj interface{}) {
 
 // addCNP handles ClusterNetworkPolicy ADD events and creates corresponding ClusterNetworkPolicyStats objects.
 func (a *Aggregator) addCNP(obj interface{}) {
-	cnp := obj.(*crdv1alpha1.ClusterNetworkPolicy)
+	cnp := obj.(*crdv1beta1.ClusterNetworkPolicy)
 	stats := &statsv1alpha1.AntreaClusterNetworkPolicyStats{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: cnp.Name,
@@ -173,14 +186,14 @@ func (a *Aggregator) addCNP(obj interface{}) {
 
 // deleteCNP handles ClusterNetworkPolicy DELETE events and deletes corresponding ClusterNetworkPolicyStats objects.
 func (a *Aggregator) deleteCNP(obj interface{}) {
-	cnp, ok := obj.(*crdv1alpha1.ClusterNetworkPolicy)
+	cnp, ok := obj.(*crdv1beta1.ClusterNetworkPolicy)
 	if !ok {
 		tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
 		if !ok {
 			klog.Errorf("Error decoding object when deleting Antrea ClusterNetworkPolicy, invalid type: %v", obj)
 			return
 		}
-		cnp, ok = tombstone.Obj.(*crdv1alpha1.ClusterNetworkPolicy)
+		cnp, ok = tombstone.Obj.(*crdv1beta1.ClusterNetworkPolicy)
 		if !ok {
 			klog.Errorf("Error decoding object tombstone when deleting Antrea ClusterNetworkPolicy, invalid type: %v", tombstone.Obj)
 			return
@@ -197,7 +210,7 @@ func (a *Aggregator) deleteCNP(obj interface{}) {
 
 // addANP handles Antrea NetworkPolicy ADD events and creates corresponding AntreaNetworkPolicyStats objects.
 func (a *Aggregator) addANP(obj interface{}) {
-	anp := obj.(*crdv1alpha1.NetworkPolicy)
+	anp := obj.(*crdv1beta1.NetworkPolicy)
 	stats := &statsv1alpha1.AntreaNetworkPolicyStats{
 		ObjectMeta: metav1.ObjectMeta{
 			Namespace: anp.Namespace,
@@ -208,32 +221,37 @@ func (a *Aggregator) addANP(obj interface{}) {
 			CreationTimestamp: metav1.Time{Time: time.Now()},
 		},
 	}
+	a.antreaNetworkPolicyStatsMutex.Lock()
+	defer a.antreaNetworkPolicyStatsMutex.Unlock()
 	a.antreaNetworkPolicyStats.Add(stats)
+	a.antreaNetworkPolicyStatsBroadcaster.Action(watch.Added, stats)
 }
 
 // deleteANP handles Antrea NetworkPolicy DELETE events and deletes corresponding AntreaNetworkPolicyStats objects.
 func (a *Aggregator) deleteANP(obj interface{}) {
-	anp, ok := obj.(*crdv1alpha1.NetworkPolicy)
+	anp, ok := obj.(*crdv1beta1.NetworkPolicy)
 	if !ok {
 		tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
 		if !ok {
 			klog.Errorf("Error decoding object when deleting Antrea NetworkPolicy, invalid type: %v", obj)
 			return
 		}
-		anp, ok = tombstone.Obj.(*crdv1alpha1.NetworkPolicy)
+		anp, ok = tombstone.Obj.(*crdv1beta1.NetworkPolicy)
 		if !ok {
 			klog.Errorf("Error decoding object tombstone when deleting Antrea NetworkPolicy, invalid type: %v", tombstone.Obj)
 			return
 		}
 	}
-	stats := &statsv1alpha1.AntreaNetworkPolicyStats{
-		ObjectMeta: metav1.ObjectMeta{
-			Namespace: anp.Namespace,
-			Name:      anp.Name,
-			UID:       anp.UID,
-		},
+	a.antreaNetworkPolicyStatsMutex.Lock()
+	defer a.antreaNetworkPolicyStatsMutex.Unlock()
+	obj, exists, _ := a.antreaNetworkPolicyStats.GetByKey(k8s.NamespacedName(anp.Namespace, anp.Name))
+	if !exists {
+		return
 	}
+	// The watchers receive the last stats of the policy.
+	stats := obj.(*statsv1alpha1.AntreaNetworkPolicyStats)
 	a.antreaNetworkPolicyStats.Delete(stats)
+	a.antreaNetworkPolicyStatsBroadcaster.Action(watch.Deleted, stats)
 }
 
 func (a *Aggregator) ListAntreaClusterNetworkPolicyStats() []statsv1alpha1.AntreaClusterNetworkPolicyStats {
@@ -276,6 +294,33 @@ func (a *Aggregator) GetAntreaNetworkPolicyStats(namespace, name string) (*stats
 	return obj.(*statsv1alpha1.AntreaNetworkPolicyStats), true
 }
 
+// WatchAntreaNetworkPolicyStats returns a watcher of the stats of the Antrea NetworkPolicies in the Namespace, or in
+// all Namespaces if it's empty, whose labels match the selector. The current stats are sent as ADDED events first.
+func (a *Aggregator) WatchAntreaNetworkPolicyStats(namespace string, labelSelector labels.Selector) watch.Interface {
+	matches := func(obj runtime.Object) bool {
+		stats := obj.(*statsv1alpha1.AntreaNetworkPolicyStats)
+		return (namespace == "" || stats.Namespace == namespace) && labelSelector.Matches(labels.Set(stats.Labels))
+	}
+	a.antreaNetworkPolicyStatsMutex.Lock()
+	defer a.antreaNetworkPolicyStatsMutex.Unlock()
+	var objs []interface{}
+	if namespace == "" {
+		objs = a.antreaNetworkPolicyStats.List()
+	} else {
+		objs, _ = a.antreaNetworkPolicyStats.ByIndex(cache.NamespaceIndex, namespace)
+	}
+	var initEvents []watch.Event
+	for _, obj := range objs {
+		if stats := obj.(*statsv1alpha1.AntreaNetworkPolicyStats); matches(stats) {
+			initEvents = append(initEvents, watch.Event{Type: watch.Added, Object: stats})
+		}
+	}
+	w := a.antreaNetworkPolicyStatsBroadcaster.WatchWithPrefix(initEvents)
+	return watch.Filter(w, func(e watch.Event) (watch.Event, bool) {
+		return e, matches(e.Object)
+	})
+}
+
 func (a *Aggregator) ListNetworkPolicyStats(namespace string) []statsv1alpha1.NetworkPolicyStats {
 	var objs []interface{}
 	if namespace == "" {
@@ -306,7 +351,8 @@ func (a *Aggregator) Collect(summary *controlplane.NodeStatsSummary) {
 }
 
 // Run runs a loop that keeps taking stats summary from the data channel and actually collecting them until the
-// provided stop channel is closed.
+// provided stop channel is closed. The summaries buffered in the data channel are collected before it returns, so that
+// the stats reported by antrea-agents before shutdown are reflected in the metrics.
 func (a *Aggregator) Run(stopCh <-chan struct{}) {
 	klog.Info("Starting stats aggregator")
 	defer klog.Info("Shutting down stats aggregator")
@@ -324,6 +370,19 @@ func (a *Aggregator) Run(stopCh <-chan struct{}) {
 		case summary := <-a.dataCh:
 			a.doCollect(summary)
 		case <-stopCh:
+			a.flush()
+			return
+		}
+	}
+}
+
+// flush collects the summaries that are buffered in the data channel without blocking.
+func (a *Aggregator) flush() {
+	for {
+		select {
+		case summary := <-a.dataCh:
+			a.doCollect(summary)
+		default:
 			return
 		}
 	}
@@ -357,6 +416,10 @@ func (a *Aggregator) doCollect(summary *controlplane.NodeStatsSummary) {
 			}
 		}
 
+		// The stats are looked up and updated while holding the lock, so that the stats of a policy removed in the
+		// meantime are not added back.
+		a.antreaNetworkPolicyStatsMutex.Lock()
+		defer a.antreaNetworkPolicyStatsMutex.Unlock()
 		for _, stats := range summary.AntreaNetworkPolicies {
 			// The policy have might been removed, skip processing it if missing.
 			objs, _ := a.antreaNetworkPolicyStats.ByIndex(uidIndex, string(stats.NetworkPolicy.UID))
@@ -371,6 +434,7 @@ func (a *Aggregator) doCollect(summary *controlplane.NodeStatsSummary) {
 					addRulesUp(&curStats.RuleTrafficStats, &curStats.TrafficStats, stats.RuleTrafficStats)
 				}
 				a.antreaNetworkPolicyStats.Update(curStats)
+				a.antreaNetworkPolicyStatsBroadcaster.Action(watch.Modified, curStats)
 			}
 		}
 	}
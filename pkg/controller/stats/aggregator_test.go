@@ -16,6 +16,7 @@ package stats
 
 import (
 	"context"
+	"fmt"
 	"testing"
 	"time"
 
@@ -23,14 +24,16 @@ import (
 	"github.com/stretchr/testify/require"
 	networkingv1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes/fake"
 	featuregatetesting "k8s.io/component-base/featuregate/testing"
 
 	"antrea.io/antrea/pkg/apis/controlplane"
-	crdv1alpha1 "antrea.io/antrea/pkg/apis/crd/v1alpha1"
+	crdv1beta1 "antrea.io/antrea/pkg/apis/crd/v1beta1"
 	statsv1alpha1 "antrea.io/antrea/pkg/apis/stats/v1alpha1"
 	fakeversioned "antrea.io/antrea/pkg/client/clientset/versioned/fake"
 	crdinformers "antrea.io/antrea/pkg/client/informers/externalversions"
@@ -44,16 +47,16 @@ var (
 	np2 = &networkingv1.NetworkPolicy{
 		ObjectMeta: metav1.ObjectMeta{Namespace: "foo", Name: "baz", UID: "uid2"},
 	}
-	cnp1 = &crdv1alpha1.ClusterNetworkPolicy{
+	cnp1 = &crdv1beta1.ClusterNetworkPolicy{
 		ObjectMeta: metav1.ObjectMeta{Namespace: "", Name: "bar", UID: "uid3"},
 	}
-	cnp2 = &crdv1alpha1.ClusterNetworkPolicy{
+	cnp2 = &crdv1beta1.ClusterNetworkPolicy{
 		ObjectMeta: metav1.ObjectMeta{Namespace: "", Name: "baz", UID: "uid4"},
 	}
-	anp1 = &crdv1alpha1.NetworkPolicy{
+	anp1 = &crdv1beta1.NetworkPolicy{
 		ObjectMeta: metav1.ObjectMeta{Namespace: "foo", Name: "bar", UID: "uid5"},
 	}
-	anp2 = &crdv1alpha1.NetworkPolicy{
+	anp2 = &crdv1beta1.NetworkPolicy{
 		ObjectMeta: metav1.ObjectMeta{Namespace: "foo", Name: "baz", UID: "uid6"},
 	}
 )
@@ -491,7 +494,7 @@ func TestAggregatorCollectListGet(t *testing.T) {
 			informerFactory := informers.NewSharedInformerFactory(client, 12*time.Hour)
 			crdClient := fakeversioned.NewSimpleClientset(append(tt.existingAntreaClusterNetworkPolicies, tt.existingAntreaNetworkPolicies...)...)
 			crdInformerFactory := crdinformers.NewSharedInformerFactory(crdClient, 12*time.Hour)
-			a := NewAggregator(informerFactory.Networking().V1().NetworkPolicies(), crdInformerFactory.Crd().V1alpha1().ClusterNetworkPolicies(), crdInformerFactory.Crd().V1alpha1().NetworkPolicies())
+			a := NewAggregator(informerFactory.Networking().V1().NetworkPolicies(), crdInformerFactory.Crd().V1beta1().ClusterNetworkPolicies(), crdInformerFactory.Crd().V1beta1().NetworkPolicies())
 			informerFactory.Start(stopCh)
 			crdInformerFactory.Start(stopCh)
 			go a.Run(stopCh)
@@ -537,7 +540,7 @@ func TestDeleteNetworkPolicy(t *testing.T) {
 	informerFactory := informers.NewSharedInformerFactory(client, 12*time.Hour)
 	crdClient := fakeversioned.NewSimpleClientset(cnp1, anp1)
 	crdInformerFactory := crdinformers.NewSharedInformerFactory(crdClient, 12*time.Hour)
-	a := NewAggregator(informerFactory.Networking().V1().NetworkPolicies(), crdInformerFactory.Crd().V1alpha1().ClusterNetworkPolicies(), crdInformerFactory.Crd().V1alpha1().NetworkPolicies())
+	a := NewAggregator(informerFactory.Networking().V1().NetworkPolicies(), crdInformerFactory.Crd().V1beta1().ClusterNetworkPolicies(), crdInformerFactory.Crd().V1beta1().NetworkPolicies())
 	informerFactory.Start(stopCh)
 	crdInformerFactory.Start(stopCh)
 	go a.Run(stopCh)
@@ -597,11 +600,128 @@ func TestDeleteNetworkPolicy(t *testing.T) {
 	require.Equal(t, 1, len(a.ListAntreaNetworkPolicyStats("")))
 
 	client.NetworkingV1().NetworkPolicies(np1.Namespace).Delete(context.TODO(), np1.Name, metav1.DeleteOptions{})
-	crdClient.CrdV1alpha1().ClusterNetworkPolicies().Delete(context.TODO(), cnp1.Name, metav1.DeleteOptions{})
-	crdClient.CrdV1alpha1().NetworkPolicies(anp1.Namespace).Delete(context.TODO(), anp1.Name, metav1.DeleteOptions{})
+	crdClient.CrdV1beta1().ClusterNetworkPolicies().Delete(context.TODO(), cnp1.Name, metav1.DeleteOptions{})
+	crdClient.CrdV1beta1().NetworkPolicies(anp1.Namespace).Delete(context.TODO(), anp1.Name, metav1.DeleteOptions{})
 	// Event handlers are asynchronous, it's supposed to finish very soon.
 	err = wait.PollImmediate(100*time.Millisecond, time.Second, func() (done bool, err error) {
 		return len(a.ListNetworkPolicyStats("")) == 0 && len(a.ListAntreaClusterNetworkPolicyStats()) == 0 && len(a.ListAntreaNetworkPolicyStats("")) == 0, nil
 	})
 	assert.NoError(t, err)
 }
+
+func TestAggregatorFlushOnStop(t *testing.T) {
+	informerStopCh := make(chan struct{})
+	defer close(informerStopCh)
+	client := fake.NewSimpleClientset(np1)
+	informerFactory := informers.NewSharedInformerFactory(client, 12*time.Hour)
+	crdClient := fakeversioned.NewSimpleClientset()
+	crdInformerFactory := crdinformers.NewSharedInformerFactory(crdClient, 12*time.Hour)
+	a := NewAggregator(informerFactory.Networking().V1().NetworkPolicies(), crdInformerFactory.Crd().V1beta1().ClusterNetworkPolicies(), crdInformerFactory.Crd().V1beta1().NetworkPolicies())
+	informerFactory.Start(informerStopCh)
+	crdInformerFactory.Start(informerStopCh)
+	informerFactory.WaitForCacheSync(informerStopCh)
+	crdInformerFactory.WaitForCacheSync(informerStopCh)
+	err := wait.PollImmediate(100*time.Millisecond, time.Second, func() (done bool, err error) {
+		return len(a.ListNetworkPolicyStats("")) == 1, nil
+	})
+	require.NoError(t, err)
+
+	for i := 0; i < 10; i++ {
+		a.Collect(&controlplane.NodeStatsSummary{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: fmt.Sprintf("node-%d", i),
+			},
+			NetworkPolicies: []controlplane.NetworkPolicyStats{
+				{
+					NetworkPolicy: controlplane.NetworkPolicyReference{UID: np1.UID},
+					TrafficStats: statsv1alpha1.TrafficStats{
+						Bytes:    10,
+						Packets:  1,
+						Sessions: 1,
+					},
+				},
+			},
+		})
+	}
+	// Stop the aggregator right after the summaries are collected, they should all be reflected in the stats once
+	// Run returns.
+	stopCh := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		a.Run(stopCh)
+		close(done)
+	}()
+	close(stopCh)
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Aggregator didn't stop in time")
+	}
+
+	assert.Equal(t, 0, len(a.dataCh))
+	stats, exists := a.GetNetworkPolicyStats(np1.Namespace, np1.Name)
+	require.True(t, exists)
+	assert.Equal(t, statsv1alpha1.TrafficStats{Bytes: 100, Packets: 10, Sessions: 10}, stats.TrafficStats)
+}
+
+func TestWatchAntreaNetworkPolicyStats(t *testing.T) {
+	defer featuregatetesting.SetFeatureGateDuringTest(t, features.DefaultFeatureGate, features.AntreaPolicy, true)()
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	// Two policies with the same name in different Namespaces.
+	anpFoo := &crdv1beta1.NetworkPolicy{ObjectMeta: metav1.ObjectMeta{Namespace: "foo", Name: "np", UID: "uid-foo"}}
+	anpBar := &crdv1beta1.NetworkPolicy{ObjectMeta: metav1.ObjectMeta{Namespace: "bar", Name: "np", UID: "uid-bar"}}
+	client := fake.NewSimpleClientset()
+	informerFactory := informers.NewSharedInformerFactory(client, 12*time.Hour)
+	crdClient := fakeversioned.NewSimpleClientset(anpFoo, anpBar)
+	crdInformerFactory := crdinformers.NewSharedInformerFactory(crdClient, 12*time.Hour)
+	a := NewAggregator(informerFactory.Networking().V1().NetworkPolicies(), crdInformerFactory.Crd().V1beta1().ClusterNetworkPolicies(), crdInformerFactory.Crd().V1beta1().NetworkPolicies())
+	informerFactory.Start(stopCh)
+	crdInformerFactory.Start(stopCh)
+	go a.Run(stopCh)
+	err := wait.PollImmediate(100*time.Millisecond, time.Second, func() (done bool, err error) {
+		return len(a.ListAntreaNetworkPolicyStats("")) == 2, nil
+	})
+	require.NoError(t, err)
+
+	w := a.WatchAntreaNetworkPolicyStats("foo", labels.Everything())
+	defer w.Stop()
+	nextEvent := func() watch.Event {
+		select {
+		case e := <-w.ResultChan():
+			return e
+		case <-time.After(time.Second):
+			t.Fatal("Timed out waiting for an event")
+		}
+		return watch.Event{}
+	}
+	e := nextEvent()
+	assert.Equal(t, watch.Added, e.Type)
+	assert.Equal(t, anpFoo.UID, e.Object.(*statsv1alpha1.AntreaNetworkPolicyStats).UID)
+
+	// The stats of the policy of the other Namespace are not sent.
+	ruleStats := []statsv1alpha1.RuleTrafficStats{{Name: "rule1", TrafficStats: statsv1alpha1.TrafficStats{Bytes: 10, Packets: 1, Sessions: 1}}}
+	a.Collect(&controlplane.NodeStatsSummary{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+		AntreaNetworkPolicies: []controlplane.NetworkPolicyStats{
+			{NetworkPolicy: controlplane.NetworkPolicyReference{UID: anpBar.UID}, RuleTrafficStats: ruleStats},
+			{NetworkPolicy: controlplane.NetworkPolicyReference{UID: anpFoo.UID}, RuleTrafficStats: ruleStats},
+		},
+	})
+	e = nextEvent()
+	assert.Equal(t, watch.Modified, e.Type)
+	stats := e.Object.(*statsv1alpha1.AntreaNetworkPolicyStats)
+	assert.Equal(t, "foo", stats.Namespace)
+	assert.Equal(t, statsv1alpha1.TrafficStats{Bytes: 10, Packets: 1, Sessions: 1}, stats.TrafficStats)
+	// The stats of the same-named policies are kept apart.
+	barStats, exists := a.GetAntreaNetworkPolicyStats("bar", "np")
+	require.True(t, exists)
+	assert.Equal(t, anpBar.UID, barStats.UID)
+
+	crdClient.CrdV1beta1().NetworkPolicies(anpBar.Namespace).Delete(context.TODO(), anpBar.Name, metav1.DeleteOptions{})
+	crdClient.CrdV1beta1().NetworkPolicies(anpFoo.Namespace).Delete(context.TODO(), anpFoo.Name, metav1.DeleteOptions{})
+	e = nextEvent()
+	assert.Equal(t, watch.Deleted, e.Type)
+	assert.Equal(t, anpFoo.UID, e.Object.(*statsv1alpha1.AntreaNetworkPolicyStats).UID)
+}
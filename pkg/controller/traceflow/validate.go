@@ -0,0 +1,161 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package traceflow
+
+import (
+	"encoding/json"
+	"net"
+	"reflect"
+
+	admv1 "k8s.io/api/admission/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/klog/v2"
+
+	crdv1alpha1 "antrea.io/antrea/pkg/apis/crd/v1alpha1"
+)
+
+const (
+	maxUint16Value = 65535
+	maxUint8Value  = 255
+)
+
+// ValidateTraceflow validates the CREATE and UPDATE requests for Traceflows. An invalid Traceflow
+// is rejected with an Invalid status, i.e. with code 422 and the paths of the invalid fields, so
+// that it does not fail later in the agents.
+func ValidateTraceflow(review *admv1.AdmissionReview) *admv1.AdmissionResponse {
+	klog.V(2).Info("Validating Traceflow", "request", review.Request)
+	var newObj, oldObj crdv1alpha1.Traceflow
+	if review.Request.Object.Raw != nil {
+		if err := json.Unmarshal(review.Request.Object.Raw, &newObj); err != nil {
+			klog.ErrorS(err, "Error de-serializing current Traceflow")
+			return newAdmissionResponseForErr(err)
+		}
+	}
+	if review.Request.OldObject.Raw != nil {
+		if err := json.Unmarshal(review.Request.OldObject.Raw, &oldObj); err != nil {
+			klog.ErrorS(err, "Error de-serializing old Traceflow")
+			return newAdmissionResponseForErr(err)
+		}
+	}
+
+	var allErrs field.ErrorList
+	switch review.Request.Operation {
+	case admv1.Create:
+		klog.V(2).Info("Validating CREATE request for Traceflow")
+		allErrs = validateTraceflowSpec(&newObj.Spec, field.NewPath("spec"))
+	case admv1.Update:
+		klog.V(2).Info("Validating UPDATE request for Traceflow")
+		// Only validate the spec when it changes, so that the status of a Traceflow created
+		// before the validation was introduced can still be updated.
+		if !reflect.DeepEqual(oldObj.Spec, newObj.Spec) {
+			allErrs = validateTraceflowSpec(&newObj.Spec, field.NewPath("spec"))
+		}
+	case admv1.Delete:
+		// This shouldn't happen with the webhook configuration we include in the Antrea YAML manifests.
+		klog.V(2).Info("Validating DELETE request for Traceflow")
+		// Always allow DELETE request.
+	}
+
+	if len(allErrs) == 0 {
+		return &admv1.AdmissionResponse{Allowed: true}
+	}
+	status := apierrors.NewInvalid(crdv1alpha1.Kind("Traceflow"), review.Request.Name, allErrs).ErrStatus
+	return &admv1.AdmissionResponse{
+		Allowed: false,
+		Result:  &status,
+	}
+}
+
+// validateTraceflowSpec returns the errors of the fields of a TraceflowSpec which cannot be
+// handled by the agents: invalid IP addresses and out-of-range header values.
+func validateTraceflowSpec(spec *crdv1alpha1.TraceflowSpec, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	allErrs = append(allErrs, validateIP(spec.Source.IP, false, false, fldPath.Child("source", "ip"))...)
+	allErrs = append(allErrs, validateIP(spec.Destination.IP, false, false, fldPath.Child("destination", "ip"))...)
+	if spec.Destination.Pod != "" && spec.Destination.Service != "" {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("destination", "service"), "may not be specified with destination pod"))
+	}
+
+	packetPath := fldPath.Child("packet")
+	ipHeaderPath := packetPath.Child("ipHeader")
+	allErrs = append(allErrs, validateIP(spec.Packet.IPHeader.SrcIP, true, false, ipHeaderPath.Child("srcIP"))...)
+	allErrs = append(allErrs, validateRange(spec.Packet.IPHeader.Protocol, maxUint8Value, ipHeaderPath.Child("protocol"))...)
+	allErrs = append(allErrs, validateRange(spec.Packet.IPHeader.TTL, maxUint8Value, ipHeaderPath.Child("ttl"))...)
+	if ipv6Header := spec.Packet.IPv6Header; ipv6Header != nil {
+		ipv6HeaderPath := packetPath.Child("ipv6Header")
+		allErrs = append(allErrs, validateIP(ipv6Header.SrcIP, false, true, ipv6HeaderPath.Child("srcIP"))...)
+		if ipv6Header.NextHeader != nil {
+			allErrs = append(allErrs, validateRange(*ipv6Header.NextHeader, maxUint8Value, ipv6HeaderPath.Child("nextHeader"))...)
+		}
+		allErrs = append(allErrs, validateRange(ipv6Header.HopLimit, maxUint8Value, ipv6HeaderPath.Child("hopLimit"))...)
+	}
+
+	transportHeaderPath := packetPath.Child("transportHeader")
+	if icmp := spec.Packet.TransportHeader.ICMP; icmp != nil {
+		icmpPath := transportHeaderPath.Child("icmp")
+		allErrs = append(allErrs, validateRange(icmp.ID, maxUint16Value, icmpPath.Child("id"))...)
+		allErrs = append(allErrs, validateRange(icmp.Sequence, maxUint16Value, icmpPath.Child("sequence"))...)
+	}
+	if udp := spec.Packet.TransportHeader.UDP; udp != nil {
+		udpPath := transportHeaderPath.Child("udp")
+		allErrs = append(allErrs, validateRange(udp.SrcPort, maxUint16Value, udpPath.Child("srcPort"))...)
+		allErrs = append(allErrs, validateRange(udp.DstPort, maxUint16Value, udpPath.Child("dstPort"))...)
+	}
+	if tcp := spec.Packet.TransportHeader.TCP; tcp != nil {
+		tcpPath := transportHeaderPath.Child("tcp")
+		allErrs = append(allErrs, validateRange(tcp.SrcPort, maxUint16Value, tcpPath.Child("srcPort"))...)
+		allErrs = append(allErrs, validateRange(tcp.DstPort, maxUint16Value, tcpPath.Child("dstPort"))...)
+		allErrs = append(allErrs, validateRange(tcp.Flags, maxUint8Value, tcpPath.Child("flags"))...)
+	}
+	return allErrs
+}
+
+// validateIP validates an optional IP address, which must be an IPv4 address if ipv4Only is true,
+// and an IPv6 address if ipv6Only is true.
+func validateIP(ip string, ipv4Only, ipv6Only bool, fldPath *field.Path) field.ErrorList {
+	if ip == "" {
+		return nil
+	}
+	parsedIP := net.ParseIP(ip)
+	switch {
+	case parsedIP == nil:
+		return field.ErrorList{field.Invalid(fldPath, ip, "must be a valid IP address")}
+	case ipv4Only && parsedIP.To4() == nil:
+		return field.ErrorList{field.Invalid(fldPath, ip, "must be a valid IPv4 address")}
+	case ipv6Only && parsedIP.To4() != nil:
+		return field.ErrorList{field.Invalid(fldPath, ip, "must be a valid IPv6 address")}
+	}
+	return nil
+}
+
+// validateRange validates that an optional header value is between 0 and max, inclusive.
+func validateRange(value int32, max int, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	for _, msg := range validation.IsInRange(int(value), 0, max) {
+		allErrs = append(allErrs, field.Invalid(fldPath, value, msg))
+	}
+	return allErrs
+}
+
+func newAdmissionResponseForErr(err error) *admv1.AdmissionResponse {
+	return &admv1.AdmissionResponse{
+		Result: &metav1.Status{
+			Message: err.Error(),
+		},
+	}
+}
@@ -0,0 +1,176 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package traceflow
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	crdv1alpha1 "antrea.io/antrea/pkg/apis/crd/v1alpha1"
+)
+
+func marshal(object runtime.Object) []byte {
+	raw, _ := json.Marshal(object)
+	return raw
+}
+
+func newTraceflowWithSpec(spec crdv1alpha1.TraceflowSpec) *crdv1alpha1.Traceflow {
+	return &crdv1alpha1.Traceflow{
+		ObjectMeta: metav1.ObjectMeta{Name: "tf"},
+		Spec:       spec,
+	}
+}
+
+func TestValidateTraceflow(t *testing.T) {
+	nextHeader := int32(256)
+	validSpec := crdv1alpha1.TraceflowSpec{
+		Source:      crdv1alpha1.Source{Namespace: "ns", Pod: "pod1"},
+		Destination: crdv1alpha1.Destination{Namespace: "ns", Pod: "pod2"},
+		Packet: crdv1alpha1.Packet{
+			IPHeader: crdv1alpha1.IPHeader{Protocol: 6, TTL: 64},
+			TransportHeader: crdv1alpha1.TransportHeader{
+				TCP: &crdv1alpha1.TCPHeader{SrcPort: 10000, DstPort: 80, Flags: 2},
+			},
+		},
+	}
+	tests := []struct {
+		name            string
+		operation       admv1.Operation
+		oldSpec         *crdv1alpha1.TraceflowSpec
+		spec            crdv1alpha1.TraceflowSpec
+		expectedAllowed bool
+		expectedFields  []string
+	}{
+		{
+			name:            "valid Traceflow",
+			operation:       admv1.Create,
+			spec:            validSpec,
+			expectedAllowed: true,
+		},
+		{
+			name:      "invalid IPs",
+			operation: admv1.Create,
+			spec: crdv1alpha1.TraceflowSpec{
+				Source:      crdv1alpha1.Source{IP: "10.0.0.256"},
+				Destination: crdv1alpha1.Destination{IP: "foo"},
+				LiveTraffic: true,
+				Packet: crdv1alpha1.Packet{
+					IPHeader:   crdv1alpha1.IPHeader{SrcIP: "fd00::1"},
+					IPv6Header: &crdv1alpha1.IPv6Header{SrcIP: "10.0.0.1"},
+				},
+			},
+			expectedFields: []string{
+				"spec.source.ip",
+				"spec.destination.ip",
+				"spec.packet.ipHeader.srcIP",
+				"spec.packet.ipv6Header.srcIP",
+			},
+		},
+		{
+			name:      "out-of-range header values",
+			operation: admv1.Create,
+			spec: crdv1alpha1.TraceflowSpec{
+				Source:      crdv1alpha1.Source{Namespace: "ns", Pod: "pod1"},
+				Destination: crdv1alpha1.Destination{Namespace: "ns", Pod: "pod2"},
+				Packet: crdv1alpha1.Packet{
+					IPHeader:   crdv1alpha1.IPHeader{Protocol: -1, TTL: 300},
+					IPv6Header: &crdv1alpha1.IPv6Header{NextHeader: &nextHeader, HopLimit: -1},
+					TransportHeader: crdv1alpha1.TransportHeader{
+						ICMP: &crdv1alpha1.ICMPEchoRequestHeader{ID: 65536, Sequence: -1},
+						UDP:  &crdv1alpha1.UDPHeader{SrcPort: -80, DstPort: 65536},
+						TCP:  &crdv1alpha1.TCPHeader{SrcPort: 70000, DstPort: -1, Flags: 256},
+					},
+				},
+			},
+			expectedFields: []string{
+				"spec.packet.ipHeader.protocol",
+				"spec.packet.ipHeader.ttl",
+				"spec.packet.ipv6Header.nextHeader",
+				"spec.packet.ipv6Header.hopLimit",
+				"spec.packet.transportHeader.icmp.id",
+				"spec.packet.transportHeader.icmp.sequence",
+				"spec.packet.transportHeader.udp.srcPort",
+				"spec.packet.transportHeader.udp.dstPort",
+				"spec.packet.transportHeader.tcp.srcPort",
+				"spec.packet.transportHeader.tcp.dstPort",
+				"spec.packet.transportHeader.tcp.flags",
+			},
+		},
+		{
+			name:      "destination Pod and Service",
+			operation: admv1.Create,
+			spec: crdv1alpha1.TraceflowSpec{
+				Source:      crdv1alpha1.Source{Namespace: "ns", Pod: "pod1"},
+				Destination: crdv1alpha1.Destination{Namespace: "ns", Pod: "pod2", Service: "svc"},
+			},
+			expectedFields: []string{"spec.destination.service"},
+		},
+		{
+			name:      "invalid spec update",
+			operation: admv1.Update,
+			oldSpec:   &validSpec,
+			spec: crdv1alpha1.TraceflowSpec{
+				Source:      crdv1alpha1.Source{Namespace: "ns", Pod: "pod1"},
+				Destination: crdv1alpha1.Destination{IP: "10.0.0.1.1"},
+			},
+			expectedFields: []string{"spec.destination.ip"},
+		},
+		{
+			name:      "status update of an invalid Traceflow",
+			operation: admv1.Update,
+			oldSpec: &crdv1alpha1.TraceflowSpec{
+				Destination: crdv1alpha1.Destination{IP: "10.0.0.1.1"},
+			},
+			spec: crdv1alpha1.TraceflowSpec{
+				Destination: crdv1alpha1.Destination{IP: "10.0.0.1.1"},
+			},
+			expectedAllowed: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := &admv1.AdmissionRequest{
+				Name:      "tf",
+				Operation: tt.operation,
+				Object:    runtime.RawExtension{Raw: marshal(newTraceflowWithSpec(tt.spec))},
+			}
+			if tt.oldSpec != nil {
+				request.OldObject = runtime.RawExtension{Raw: marshal(newTraceflowWithSpec(*tt.oldSpec))}
+			}
+			response := ValidateTraceflow(&admv1.AdmissionReview{Request: request})
+			assert.Equal(t, tt.expectedAllowed, response.Allowed)
+			if tt.expectedAllowed {
+				assert.Nil(t, response.Result)
+				return
+			}
+			require.NotNil(t, response.Result)
+			assert.Equal(t, int32(http.StatusUnprocessableEntity), response.Result.Code)
+			assert.Equal(t, metav1.StatusReasonInvalid, response.Result.Reason)
+			require.NotNil(t, response.Result.Details)
+			var fields []string
+			for _, cause := range response.Result.Details.Causes {
+				fields = append(fields, cause.Field)
+			}
+			assert.Equal(t, tt.expectedFields, fields)
+		})
+	}
+}
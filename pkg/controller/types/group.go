@@ -48,6 +48,9 @@ type GroupSelector struct {
 	// If Namespace and NamespaceSelector both are unset, it selects the ExternalEntities in all the Namespaces.
 	// TODO: Add validation in API to not allow externalEntitySelector and podSelector in the same group.
 	ExternalEntitySelector labels.Selector
+	// This is a label selector which selects Nodes. If this field is set, none of the other selectors can
+	// be set, and the GroupMembers are the addresses of the selected Nodes.
+	NodeSelector labels.Selector
 }
 
 func NewGroupSelector(namespace string, podSelector, nsSelector, extEntitySelector *metav1.LabelSelector) *GroupSelector {
@@ -15,6 +15,8 @@
 package types
 
 import (
+	"time"
+
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/sets"
@@ -82,6 +84,9 @@ type NetworkPolicy struct {
 	Name string
 	// Generation of the internal Network Policy. It's inherited from the original Network Policy.
 	Generation int64
+	// GenerationTimestamp is the time at which the Generation of the original Network Policy was
+	// created in the kube-apiserver. It's used to measure the realization latency of the policy.
+	GenerationTimestamp time.Time
 	// Reference to the original Network Policy.
 	SourceRef *controlplane.NetworkPolicyReference
 	// Priority represents the relative priority of this NetworkPolicy as compared to
@@ -103,4 +108,7 @@ type NetworkPolicy struct {
 	// to re-calculate affected Namespaces.
 	// It is set only for AntreaClusterNetworkPolicies with per-namespace rules.
 	PerNamespaceSelectors []labels.Selector
+	// QuotaExceeded is true when the estimated number of OpenFlow flows of the NetworkPolicy
+	// exceeds the flow quota left in its Namespace, in which case it spans no Node.
+	QuotaExceeded bool
 }
@@ -67,6 +67,18 @@ const (
 	// alpha: v1.0
 	// Enable controlling SNAT IPs of Pod egress traffic.
 	Egress featuregate.Feature = "Egress"
+
+	// alpha: v1.2
+	// Enable capturing the live traffic of Pods to pcap files.
+	PacketCapture featuregate.Feature = "PacketCapture"
+
+	// alpha: v1.2
+	// Enable measuring the latency between the Node and its peer Nodes.
+	NodeLatencyMonitor featuregate.Feature = "NodeLatencyMonitor"
+
+	// alpha: v1.2
+	// Enable exporting the audit logs and the flow records to an OpenTelemetry collector.
+	OTelExporter featuregate.Feature = "OTelExporter"
 )
 
 var (
@@ -88,7 +100,10 @@ var (
 		Traceflow:          {Default: true, PreRelease: featuregate.Beta},
 		FlowExporter:       {Default: false, PreRelease: featuregate.Alpha},
 		NetworkPolicyStats: {Default: true, PreRelease: featuregate.Beta},
+		NodeLatencyMonitor: {Default: false, PreRelease: featuregate.Alpha},
 		NodePortLocal:      {Default: false, PreRelease: featuregate.Alpha},
+		OTelExporter:       {Default: false, PreRelease: featuregate.Alpha},
+		PacketCapture:      {Default: false, PreRelease: featuregate.Alpha},
 	}
 
 	// UnsupportedFeaturesOnWindows records the features not supported on
@@ -102,8 +117,9 @@ var (
 	// can have different FeatureSpecs between Linux and Windows, we should
 	// still define a separate defaultAntreaFeatureGates map for Windows.
 	unsupportedFeaturesOnWindows = map[featuregate.Feature]struct{}{
-		NodePortLocal: {},
-		Egress:        {},
+		NodePortLocal:      {},
+		Egress:             {},
+		NodeLatencyMonitor: {},
 	}
 )
 
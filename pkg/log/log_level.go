@@ -16,20 +16,79 @@ package log
 
 import (
 	"flag"
+	"sync"
+	"time"
 
 	"k8s.io/klog/v2"
 )
 
 const logVerbosityFlag = "v"
 
+var (
+	// levelMutex protects the log verbosity level and the pending revert.
+	levelMutex sync.Mutex
+	// revertTimer restores revertLevel when the TTL of the current log verbosity level
+	// expires. It's nil if the current level has no TTL.
+	revertTimer *time.Timer
+	revertLevel string
+)
+
 // GetCurrentLogLevel returns the current log verbosity level.
 func GetCurrentLogLevel() string {
-	return flag.Lookup(logVerbosityFlag).Value.String()
+	f := flag.Lookup(logVerbosityFlag)
+	if f == nil {
+		// The klog flags are not registered, e.g. in unit tests of other packages.
+		return "0"
+	}
+	return f.Value.String()
 }
 
 // SetLogLevel sets the log verbosity level. level must be a string
-// representation of a decimal integer.
+// representation of a decimal integer. A pending revert scheduled by
+// SetLogLevelWithTTL is cancelled.
 func SetLogLevel(level string) error {
+	return SetLogLevelWithTTL(level, 0)
+}
+
+// SetLogLevelWithTTL sets the log verbosity level like SetLogLevel. If ttl is
+// positive, the level is reverted after ttl, unless it is set again in the
+// meantime. The level is reverted to the one which was set without TTL, so that
+// successive temporary changes don't become permanent.
+func SetLogLevelWithTTL(level string, ttl time.Duration) error {
+	levelMutex.Lock()
+	defer levelMutex.Unlock()
+	oldLevel := GetCurrentLogLevel()
+	if err := setLogLevel(level); err != nil {
+		return err
+	}
+	if revertTimer != nil {
+		revertTimer.Stop()
+		revertTimer = nil
+		oldLevel = revertLevel
+	}
+	if ttl <= 0 {
+		return nil
+	}
+	revertLevel = oldLevel
+	var timer *time.Timer
+	timer = time.AfterFunc(ttl, func() {
+		levelMutex.Lock()
+		defer levelMutex.Unlock()
+		// The level has been set again after the timer fired.
+		if revertTimer != timer {
+			return
+		}
+		revertTimer = nil
+		if err := setLogLevel(revertLevel); err != nil {
+			klog.Errorf("Failed to revert log level to %s: %v", revertLevel, err)
+		}
+	})
+	revertTimer = timer
+	klog.Infof("Log level will be reverted to %s in %v", revertLevel, ttl)
+	return nil
+}
+
+func setLogLevel(level string) error {
 	oldLevel := GetCurrentLogLevel()
 	if oldLevel == level {
 		return nil
@@ -42,5 +101,4 @@ func SetLogLevel(level string) error {
 	}
 	klog.Infof("Changed log level from %s to %s", oldLevel, level)
 	return nil
-
 }
@@ -0,0 +1,52 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetLogLevelWithTTL(t *testing.T) {
+	require.NoError(t, SetLogLevel("0"))
+	defer SetLogLevel("0")
+
+	require.NoError(t, SetLogLevelWithTTL("4", 100*time.Millisecond))
+	assert.Equal(t, "4", GetCurrentLogLevel())
+	assert.Eventually(t, func() bool {
+		return GetCurrentLogLevel() == "0"
+	}, time.Second, 10*time.Millisecond)
+
+	// Successive temporary changes revert to the level set without TTL.
+	require.NoError(t, SetLogLevel("2"))
+	require.NoError(t, SetLogLevelWithTTL("4", time.Hour))
+	require.NoError(t, SetLogLevelWithTTL("6", 100*time.Millisecond))
+	assert.Equal(t, "6", GetCurrentLogLevel())
+	assert.Eventually(t, func() bool {
+		return GetCurrentLogLevel() == "2"
+	}, time.Second, 10*time.Millisecond)
+
+	// Setting the level without TTL cancels the pending revert.
+	require.NoError(t, SetLogLevelWithTTL("4", 100*time.Millisecond))
+	require.NoError(t, SetLogLevel("3"))
+	time.Sleep(200 * time.Millisecond)
+	assert.Equal(t, "3", GetCurrentLogLevel())
+
+	assert.Error(t, SetLogLevelWithTTL("foo", time.Minute))
+	assert.Equal(t, "3", GetCurrentLogLevel())
+}
@@ -0,0 +1,130 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package condition maintains the conditions of the agent reported in its AntreaAgentInfo. The
+// modules of the agent set their conditions when their state changes, and the agent monitor
+// publishes them on every heartbeat.
+package condition
+
+import (
+	"sort"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+
+	"antrea.io/antrea/pkg/agent/metrics"
+	"antrea.io/antrea/pkg/apis/crd/v1beta1"
+)
+
+// conditionOrder is the order in which the conditions are listed. The conditions of other types are
+// listed after them, sorted by type.
+var conditionOrder = []v1beta1.AgentConditionType{
+	v1beta1.AgentHealthy,
+	v1beta1.ControllerConnectionUp,
+	v1beta1.OVSDBConnectionUp,
+	v1beta1.OpenflowConnectionUp,
+	v1beta1.PeerNodesReachable,
+	v1beta1.ControlplaneSynced,
+	v1beta1.ProxyHealthy,
+	v1beta1.FlowExporterConnected,
+	v1beta1.AuditLoggingReady,
+}
+
+// agentConditions is the Manager shared by the modules of the agent.
+var agentConditions = NewManager()
+
+// Manager maintains a set of conditions, one per type. It keeps track of the last time the status of
+// each condition changed, and mirrors the statuses with the antrea_agent_condition_status metric.
+type Manager struct {
+	mutex      sync.RWMutex
+	conditions map[v1beta1.AgentConditionType]*v1beta1.AgentCondition
+}
+
+func NewManager() *Manager {
+	return &Manager{conditions: map[v1beta1.AgentConditionType]*v1beta1.AgentCondition{}}
+}
+
+// DefaultManager returns the Manager shared by the modules of the agent.
+func DefaultManager() *Manager {
+	return agentConditions
+}
+
+// Set sets a condition in the Manager shared by the modules of the agent.
+func Set(conditionType v1beta1.AgentConditionType, status corev1.ConditionStatus, reason, message string) {
+	agentConditions.Set(conditionType, status, reason, message)
+}
+
+// Set sets the status, reason and message of the condition. The last transition time of the
+// condition is only updated when its status changes.
+func (m *Manager) Set(conditionType v1beta1.AgentConditionType, status corev1.ConditionStatus, reason, message string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	condition, exists := m.conditions[conditionType]
+	if !exists || condition.Status != status {
+		if exists {
+			klog.Infof("Agent condition %s changed from %s to %s: %s", conditionType, condition.Status, status, reason)
+		}
+		condition = &v1beta1.AgentCondition{
+			Type:               conditionType,
+			Status:             status,
+			LastTransitionTime: metav1.Now(),
+		}
+		m.conditions[conditionType] = condition
+		metrics.AgentConditionStatus.WithLabelValues(string(conditionType)).Set(statusToMetricValue(status))
+	}
+	condition.Reason = reason
+	condition.Message = message
+}
+
+// List returns a copy of the conditions, with their last heartbeat time set to heartbeatTime.
+func (m *Manager) List(heartbeatTime metav1.Time) []v1beta1.AgentCondition {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	conditions := make([]v1beta1.AgentCondition, 0, len(m.conditions))
+	for _, condition := range m.conditions {
+		c := *condition
+		c.LastHeartbeatTime = heartbeatTime
+		conditions = append(conditions, c)
+	}
+	sort.Slice(conditions, func(i, j int) bool {
+		ri, rj := conditionRank(conditions[i].Type), conditionRank(conditions[j].Type)
+		if ri != rj {
+			return ri < rj
+		}
+		return conditions[i].Type < conditions[j].Type
+	})
+	return conditions
+}
+
+func conditionRank(conditionType v1beta1.AgentConditionType) int {
+	for i, t := range conditionOrder {
+		if t == conditionType {
+			return i
+		}
+	}
+	return len(conditionOrder)
+}
+
+func statusToMetricValue(status corev1.ConditionStatus) float64 {
+	switch status {
+	case corev1.ConditionTrue:
+		return 1
+	case corev1.ConditionFalse:
+		return 0
+	default:
+		return -1
+	}
+}
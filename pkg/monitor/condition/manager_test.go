@@ -0,0 +1,71 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package condition
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"antrea.io/antrea/pkg/apis/crd/v1beta1"
+)
+
+func TestManager(t *testing.T) {
+	m := NewManager()
+	m.Set("CustomCondition", corev1.ConditionUnknown, "", "")
+	m.Set(v1beta1.AuditLoggingReady, corev1.ConditionTrue, "", "")
+	m.Set(v1beta1.ProxyHealthy, corev1.ConditionFalse, "SyncFailed", "1 Service or Endpoint operation(s) failed in the last sync")
+	m.Set(v1beta1.AgentHealthy, corev1.ConditionTrue, "", "")
+
+	heartbeatTime := metav1.NewTime(time.Date(2021, 6, 1, 10, 0, 0, 0, time.UTC))
+	conditions := m.List(heartbeatTime)
+	require.Len(t, conditions, 4)
+	// The known conditions are listed first, in a fixed order.
+	assert.Equal(t, []v1beta1.AgentConditionType{v1beta1.AgentHealthy, v1beta1.ProxyHealthy, v1beta1.AuditLoggingReady, "CustomCondition"},
+		[]v1beta1.AgentConditionType{conditions[0].Type, conditions[1].Type, conditions[2].Type, conditions[3].Type})
+	for _, c := range conditions {
+		assert.Equal(t, heartbeatTime, c.LastHeartbeatTime)
+		assert.False(t, c.LastTransitionTime.IsZero())
+	}
+	assert.Equal(t, "SyncFailed", conditions[1].Reason)
+
+	// The transition time is only updated when the status changes.
+	transitionTime := metav1.NewTime(time.Date(2021, 6, 1, 9, 0, 0, 0, time.UTC))
+	m.conditions[v1beta1.ProxyHealthy].LastTransitionTime = transitionTime
+	m.Set(v1beta1.ProxyHealthy, corev1.ConditionFalse, "SyncFailed", "2 Service or Endpoint operation(s) failed in the last sync")
+	proxyHealthy := m.List(heartbeatTime)[1]
+	assert.Equal(t, transitionTime, proxyHealthy.LastTransitionTime)
+	assert.Equal(t, "2 Service or Endpoint operation(s) failed in the last sync", proxyHealthy.Message)
+	m.Set(v1beta1.ProxyHealthy, corev1.ConditionTrue, "", "")
+	proxyHealthy = m.List(heartbeatTime)[1]
+	assert.NotEqual(t, transitionTime, proxyHealthy.LastTransitionTime)
+	assert.Equal(t, corev1.ConditionTrue, proxyHealthy.Status)
+	assert.Empty(t, proxyHealthy.Reason)
+	assert.Empty(t, proxyHealthy.Message)
+
+	// The conditions returned are copies.
+	conditions[0].Status = corev1.ConditionFalse
+	assert.Equal(t, corev1.ConditionTrue, m.List(heartbeatTime)[0].Status)
+}
+
+func TestStatusToMetricValue(t *testing.T) {
+	assert.Equal(t, float64(1), statusToMetricValue(corev1.ConditionTrue))
+	assert.Equal(t, float64(0), statusToMetricValue(corev1.ConditionFalse))
+	assert.Equal(t, float64(-1), statusToMetricValue(corev1.ConditionUnknown))
+}
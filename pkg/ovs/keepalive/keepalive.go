@@ -0,0 +1,118 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package keepalive detects the connections to Open vSwitch which are dead without being closed,
+// e.g. because the peer is hung, with application-level probes. The connections can then be
+// re-established immediately, instead of after the inactivity or TCP timeouts, during which the
+// requests sent on them are lost.
+package keepalive
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"antrea.io/antrea/pkg/agent/metrics"
+)
+
+// Monitor probes a connection periodically, and considers it dead when no reply has been received
+// for failureThreshold probe intervals.
+type Monitor struct {
+	// name identifies the connection in logs and metrics.
+	name             string
+	interval         time.Duration
+	failureThreshold int
+	// probe sends a probe on the connection. The owner of the connection must call ReplyReceived
+	// when the reply is received. probe is never called again before it returns, so it can block
+	// until the reply is received.
+	probe func() error
+	// onFailure is called when the connection is considered dead. It should close the connection
+	// so that it is re-established.
+	onFailure func()
+
+	// mutex protects lastReply and probing.
+	mutex     sync.Mutex
+	lastReply time.Time
+	probing   bool
+}
+
+// NewMonitor creates a Monitor for a connection. The connection is considered dead after
+// failureThreshold intervals without reply, hence the failure of a connection is detected at most
+// (failureThreshold + 1) intervals after its last reply.
+func NewMonitor(name string, interval time.Duration, failureThreshold int, probe func() error, onFailure func()) *Monitor {
+	return &Monitor{
+		name:             name,
+		interval:         interval,
+		failureThreshold: failureThreshold,
+		probe:            probe,
+		onFailure:        onFailure,
+	}
+}
+
+// ReplyReceived records that a reply was received on the connection. It can be called for any
+// message proving that the connection is alive, not only for the replies to the probes.
+func (m *Monitor) ReplyReceived() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.lastReply = time.Now()
+}
+
+// Run probes the connection every interval until stopCh is closed.
+func (m *Monitor) Run(stopCh <-chan struct{}) {
+	klog.Infof("Starting keepalive probes of the %s connection every %v", m.name, m.interval)
+	m.ReplyReceived()
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-ticker.C:
+			m.check()
+		}
+	}
+}
+
+func (m *Monitor) check() {
+	now := time.Now()
+	m.mutex.Lock()
+	silence := now.Sub(m.lastReply)
+	if silence >= time.Duration(m.failureThreshold)*m.interval {
+		// Give the connection which replaces the dead one a full window to reply.
+		m.lastReply = now
+		m.mutex.Unlock()
+		klog.Warningf("No reply received on the %s connection for %v, re-establishing it", m.name, silence)
+		metrics.OVSConnectionFailureCount.WithLabelValues(m.name).Inc()
+		metrics.OVSConnectionFailureDetectionLatency.WithLabelValues(m.name).Observe(silence.Seconds())
+		m.onFailure()
+		return
+	}
+	// The previous probe is still blocked, e.g. because the connection is hung.
+	if m.probing {
+		m.mutex.Unlock()
+		return
+	}
+	m.probing = true
+	m.mutex.Unlock()
+	go func() {
+		err := m.probe()
+		m.mutex.Lock()
+		m.probing = false
+		m.mutex.Unlock()
+		if err != nil {
+			klog.V(2).Infof("Failed to probe the %s connection: %v", m.name, err)
+		}
+	}()
+}
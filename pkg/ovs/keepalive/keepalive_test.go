@@ -0,0 +1,127 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keepalive
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testInterval         = 50 * time.Millisecond
+	testFailureThreshold = 3
+	// testSlack accounts for the scheduling delays of the test goroutines.
+	testSlack = 100 * time.Millisecond
+)
+
+// fakeConnection replies to the probes until it is hung, after which the probes block until the
+// connection is closed, like a connection whose peer stopped reading.
+type fakeConnection struct {
+	mutex     sync.Mutex
+	monitor   *Monitor
+	hung      bool
+	closeCh   chan struct{}
+	lastReply time.Time
+	probes    int
+}
+
+func newFakeConnection() *fakeConnection {
+	return &fakeConnection{closeCh: make(chan struct{})}
+}
+
+func (c *fakeConnection) probe() error {
+	c.mutex.Lock()
+	c.probes++
+	hung := c.hung
+	closeCh := c.closeCh
+	c.mutex.Unlock()
+	if hung {
+		<-closeCh
+		return nil
+	}
+	c.mutex.Lock()
+	c.lastReply = time.Now()
+	c.mutex.Unlock()
+	c.monitor.ReplyReceived()
+	return nil
+}
+
+func (c *fakeConnection) hang() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.hung = true
+}
+
+// reconnect closes the hung connection and replaces it with a healthy one.
+func (c *fakeConnection) reconnect() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	close(c.closeCh)
+	c.closeCh = make(chan struct{})
+	c.hung = false
+}
+
+func TestMonitorHealthyConnection(t *testing.T) {
+	conn := newFakeConnection()
+	failureCh := make(chan time.Time, 10)
+	conn.monitor = NewMonitor("test", testInterval, testFailureThreshold, conn.probe, func() { failureCh <- time.Now() })
+	stopCh := make(chan struct{})
+	go conn.monitor.Run(stopCh)
+	time.Sleep(10 * testInterval)
+	close(stopCh)
+	assert.Empty(t, failureCh)
+	conn.mutex.Lock()
+	defer conn.mutex.Unlock()
+	assert.GreaterOrEqual(t, conn.probes, 5)
+}
+
+func TestMonitorHungConnection(t *testing.T) {
+	conn := newFakeConnection()
+	failureCh := make(chan time.Time, 10)
+	conn.monitor = NewMonitor("test", testInterval, testFailureThreshold, conn.probe, func() {
+		failureCh <- time.Now()
+		conn.reconnect()
+	})
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go conn.monitor.Run(stopCh)
+
+	// Let the connection reply to a few probes, then hang it.
+	time.Sleep(3 * testInterval)
+	conn.hang()
+	conn.mutex.Lock()
+	lastReply := conn.lastReply
+	conn.mutex.Unlock()
+	require.False(t, lastReply.IsZero())
+
+	maxDetectionLatency := time.Duration(testFailureThreshold+1)*testInterval + testSlack
+	select {
+	case detectionTime := <-failureCh:
+		latency := detectionTime.Sub(lastReply)
+		assert.GreaterOrEqual(t, int64(latency), int64(testFailureThreshold*testInterval))
+		assert.LessOrEqual(t, int64(latency), int64(maxDetectionLatency))
+	case <-time.After(2 * maxDetectionLatency):
+		t.Fatalf("Hung connection was not detected within %v", maxDetectionLatency)
+	}
+
+	// The blocked probe is not sent again while the connection is hung, and the re-established
+	// connection is not considered dead.
+	time.Sleep(2 * maxDetectionLatency)
+	assert.Empty(t, failureCh)
+}
@@ -0,0 +1,116 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openflow
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
+)
+
+var (
+	broadcastMAC = net.HardwareAddr{0xff, 0xff, 0xff, 0xff, 0xff, 0xff}
+	// allNodesMAC is the Ethernet multicast address of the IPv6 all-nodes address ff02::1.
+	allNodesMAC = net.HardwareAddr{0x33, 0x33, 0x00, 0x00, 0x00, 0x01}
+	allNodesIP  = net.ParseIP("ff02::1")
+)
+
+const (
+	etherTypeARP  = 0x0806
+	etherTypeIPv4 = 0x0800
+	etherTypeIPv6 = 0x86dd
+
+	icmpv6ProtocolNumber        = 58
+	icmpv6NeighborAdvertisement = 136
+	// ndpTargetLinkLayerAddress is the type of the NDP option carrying the link-layer address
+	// of the target.
+	ndpTargetLinkLayerAddress = 2
+	// naFlagOverride asks the receivers to update their existing neighbor cache entry.
+	naFlagOverride = 0x20000000
+)
+
+// GratuitousARP returns the Ethernet frame of a gratuitous ARP request announcing that ip is at
+// mac. As recommended by RFC 5227, the sender and target protocol addresses are both set to the
+// announced IP, and the target hardware address is zero.
+func GratuitousARP(mac net.HardwareAddr, ip net.IP) ([]byte, error) {
+	ipv4 := ip.To4()
+	if ipv4 == nil {
+		return nil, fmt.Errorf("%s is not an IPv4 address", ip)
+	}
+	frame := bytes.NewBuffer(nil)
+	// Ethernet header.
+	frame.Write(broadcastMAC)
+	frame.Write(mac)
+	binary.Write(frame, binary.BigEndian, uint16(etherTypeARP))
+	// ARP message.
+	binary.Write(frame, binary.BigEndian, uint16(1))             // Hardware type, Ethernet is 1.
+	binary.Write(frame, binary.BigEndian, uint16(etherTypeIPv4)) // Protocol type.
+	frame.WriteByte(6)                                           // Hardware address length.
+	frame.WriteByte(4)                                           // Protocol address length.
+	binary.Write(frame, binary.BigEndian, uint16(1))             // Operation, request is 1.
+	frame.Write(mac)                                             // Sender hardware address.
+	frame.Write(ipv4)                                            // Sender protocol address.
+	frame.Write(make([]byte, 6))                                 // Target hardware address.
+	frame.Write(ipv4)                                            // Target protocol address.
+	return frame.Bytes(), nil
+}
+
+// UnsolicitedNeighborAdvertisement returns the Ethernet frame of an unsolicited Neighbor
+// Advertisement sent to all nodes, announcing that ip is at mac. The Override flag is set so that
+// the receivers update their existing neighbor cache entry (RFC 4861 section 7.2.6).
+func UnsolicitedNeighborAdvertisement(mac net.HardwareAddr, ip net.IP) ([]byte, error) {
+	if ip.To4() != nil || ip.To16() == nil {
+		return nil, fmt.Errorf("%s is not an IPv6 address", ip)
+	}
+	ipv6 := ip.To16()
+	icmp := bytes.NewBuffer(nil)
+	icmp.WriteByte(icmpv6NeighborAdvertisement)                  // Type.
+	icmp.WriteByte(0)                                            // Code.
+	binary.Write(icmp, binary.BigEndian, uint16(0))              // Checksum, computed below.
+	binary.Write(icmp, binary.BigEndian, uint32(naFlagOverride)) // Flags and reserved bits.
+	icmp.Write(ipv6)                                             // Target address.
+	icmp.WriteByte(ndpTargetLinkLayerAddress)                    // Option type.
+	icmp.WriteByte(1)                                            // Option length, in units of 8 bytes.
+	icmp.Write(mac)                                              // Target link-layer address.
+	message := icmp.Bytes()
+	binary.BigEndian.PutUint16(message[2:4], icmpv6Checksum(ipv6, allNodesIP, message))
+
+	frame := bytes.NewBuffer(nil)
+	// Ethernet header.
+	frame.Write(allNodesMAC)
+	frame.Write(mac)
+	binary.Write(frame, binary.BigEndian, uint16(etherTypeIPv6))
+	// IPv6 header.
+	binary.Write(frame, binary.BigEndian, uint32(0x60000000))   // Version, traffic class and flow label.
+	binary.Write(frame, binary.BigEndian, uint16(len(message))) // Payload length.
+	frame.WriteByte(icmpv6ProtocolNumber)                       // Next header.
+	frame.WriteByte(255)                                        // Hop limit, must be 255 for NDP.
+	frame.Write(ipv6)
+	frame.Write(allNodesIP)
+	frame.Write(message)
+	return frame.Bytes(), nil
+}
+
+// icmpv6Checksum computes the checksum of an ICMPv6 message, including the IPv6 pseudo-header.
+func icmpv6Checksum(src, dst net.IP, message []byte) uint16 {
+	pseudoHeader := bytes.NewBuffer(nil)
+	pseudoHeader.Write(src.To16())
+	pseudoHeader.Write(dst.To16())
+	binary.Write(pseudoHeader, binary.BigEndian, uint32(len(message)))
+	pseudoHeader.Write([]byte{0, 0, 0, icmpv6ProtocolNumber})
+	pseudoHeader.Write(message)
+	return checksum(pseudoHeader.Bytes())
+}
@@ -0,0 +1,62 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openflow
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var announcedMAC = net.HardwareAddr{0x42, 0xaf, 0xb8, 0x14, 0xcb, 0x4e}
+
+func TestGratuitousARP(t *testing.T) {
+	frame, err := GratuitousARP(announcedMAC, net.ParseIP("192.168.10.5"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte{
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x42, 0xaf,
+		0xb8, 0x14, 0xcb, 0x4e, 0x08, 0x06, 0x00, 0x01,
+		0x08, 0x00, 0x06, 0x04, 0x00, 0x01, 0x42, 0xaf,
+		0xb8, 0x14, 0xcb, 0x4e, 0xc0, 0xa8, 0x0a, 0x05,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xc0, 0xa8,
+		0x0a, 0x05,
+	}, frame)
+
+	_, err = GratuitousARP(announcedMAC, net.ParseIP("fd00::10"))
+	assert.Error(t, err)
+}
+
+func TestUnsolicitedNeighborAdvertisement(t *testing.T) {
+	frame, err := UnsolicitedNeighborAdvertisement(announcedMAC, net.ParseIP("fd00::10"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte{
+		0x33, 0x33, 0x00, 0x00, 0x00, 0x01, 0x42, 0xaf,
+		0xb8, 0x14, 0xcb, 0x4e, 0x86, 0xdd, 0x60, 0x00,
+		0x00, 0x00, 0x00, 0x20, 0x3a, 0xff, 0xfd, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x10, 0xff, 0x02,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x01, 0x88, 0x00,
+		0x96, 0x6b, 0x20, 0x00, 0x00, 0x00, 0xfd, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x10, 0x02, 0x01,
+		0x42, 0xaf, 0xb8, 0x14, 0xcb, 0x4e,
+	}, frame)
+
+	_, err = UnsolicitedNeighborAdvertisement(announcedMAC, net.ParseIP("192.168.10.5"))
+	assert.Error(t, err)
+}
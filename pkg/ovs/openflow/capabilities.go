@@ -0,0 +1,56 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openflow
+
+// OpenFlow protocol versions, named as in the "protocols" column of the OVSDB Bridge table.
+const (
+	OpenFlow10 = "OpenFlow10"
+	OpenFlow13 = "OpenFlow13"
+	OpenFlow15 = "OpenFlow15"
+)
+
+// Capability is an optional OpenFlow or OVS feature which some flows depend on.
+type Capability string
+
+const (
+	// CapabilityMeter indicates that OpenFlow meters are supported by the OVS datapath.
+	CapabilityMeter Capability = "Meter"
+	// CapabilityLearnDeleteLearned indicates that the learn action supports the delete_learned
+	// flag, which removes the learned flows when the learning flow is removed.
+	CapabilityLearnDeleteLearned Capability = "LearnDeleteLearned"
+	// CapabilityOpenFlow15 indicates that OpenFlow 1.5 is negotiated with the switch, which is
+	// required by actions like copy_field and by the options of group commands.
+	CapabilityOpenFlow15 Capability = "OpenFlow15"
+)
+
+// Capabilities describes the OpenFlow version negotiated with the switch, the OVS version and
+// the optional capabilities which are available.
+type Capabilities struct {
+	OpenFlowVersion string
+	// OVSVersion is empty if the OVS version could not be detected.
+	OVSVersion string
+	// Available is sorted by name.
+	Available []Capability
+}
+
+// Has returns whether the provided capability is available.
+func (c Capabilities) Has(capability Capability) bool {
+	for _, available := range c.Available {
+		if available == capability {
+			return true
+		}
+	}
+	return false
+}
@@ -56,6 +56,21 @@ const (
 	TableMissActionNone
 )
 
+func (a MissActionType) String() string {
+	switch a {
+	case TableMissActionDrop:
+		return "drop"
+	case TableMissActionNormal:
+		return "normal"
+	case TableMissActionNext:
+		return "next"
+	case TableMissActionNone:
+		return "none"
+	default:
+		return "unknown"
+	}
+}
+
 const (
 	NxmFieldSrcMAC      = "NXM_OF_ETH_SRC"
 	NxmFieldDstMAC      = "NXM_OF_ETH_DST"
@@ -111,6 +126,8 @@ type Bridge interface {
 	Disconnect() error
 	// IsConnected returns the OFSwitch's connection status. The result is true if the OFSwitch is connected.
 	IsConnected() bool
+	// OpenFlowVersion returns the OpenFlow version negotiated with the OFSwitch.
+	OpenFlowVersion() string
 	// SubscribePacketIn registers a consumer to listen to PacketIn messages matching the provided reason. When the
 	// Bridge receives a PacketIn message with the specified reason, it sends the message to the consumer using the
 	// provided channel.
@@ -123,6 +140,13 @@ type Bridge interface {
 	SendPacketOut(packetOut *ofctrl.PacketOut) error
 	// BuildPacketOut returns a new PacketOutBuilder.
 	BuildPacketOut() PacketOutBuilder
+	// SendRawPacketOut sends an Ethernet frame as a packetOut message to the OVS Bridge, with
+	// inPort as the input port and outPort as the only output port.
+	SendRawPacketOut(inPort, outPort uint32, frame []byte) error
+	// SetConnectionProbe sets the interval between the keepalive probes of the connection to the OFSwitch, and the
+	// number of intervals without reply after which the connection is closed and re-established. It must be called
+	// before Connect. An interval of 0 disables the probes.
+	SetConnectionProbe(interval time.Duration, failureThreshold int)
 }
 
 // TableStatus represents the status of a specific flow table. The status is useful for debugging.
@@ -169,6 +193,10 @@ type Flow interface {
 	FlowPriority() uint16
 	FlowProtocol() Protocol
 	MatchString() string
+	// GetCookieID returns the cookie ID of the flow.
+	GetCookieID() uint64
+	// GetTableID returns the ID of the table the flow is installed in.
+	GetTableID() TableIDType
 	// CopyToBuilder returns a new FlowBuilder that copies the matches of the Flow.
 	// It copies the original actions of the Flow only if copyActions is set to true, and
 	// resets the priority in the new FlowBuilder if the provided priority is not 0.
@@ -208,8 +236,10 @@ type Action interface {
 	Learn(id TableIDType, priority uint16, idleTimeout, hardTimeout uint16, cookieID uint64) LearnAction
 	GotoTable(table TableIDType) FlowBuilder
 	SendToController(reason uint8) FlowBuilder
+	SendToControllerWithMaxLen(reason uint8, maxLen uint16) FlowBuilder
 	Note(notes string) FlowBuilder
 	Meter(meterId uint32) FlowBuilder
+	CheckPktLarger(pktLen uint16, regID int, bit uint32) FlowBuilder
 }
 
 type FlowBuilder interface {
@@ -248,6 +278,9 @@ type FlowBuilder interface {
 	MatchSrcPort(port uint16, portMask *uint16) FlowBuilder
 	MatchICMPv6Type(icmp6Type byte) FlowBuilder
 	MatchICMPv6Code(icmp6Code byte) FlowBuilder
+	// MatchNDTarget matches the target address of ICMPv6 Neighbor Solicitation and Neighbor Advertisement
+	// messages with IP masking.
+	MatchNDTarget(target net.IPNet) FlowBuilder
 	MatchTunnelDst(dstIP net.IP) FlowBuilder
 	MatchTunMetadata(index int, data uint32) FlowBuilder
 	// MatchCTSrcIP matches the source IPv4 address of the connection tracker original direction tuple.
@@ -330,11 +330,37 @@ func (a *ofFlowAction) SendToController(reason uint8) FlowBuilder {
 	return a.builder
 }
 
+// SendToControllerWithMaxLen is like SendToController, but the packet-in includes up to maxLen bytes of the packet,
+// instead of the first 128 bytes set by ofctrl.
+func (a *ofFlowAction) SendToControllerWithMaxLen(reason uint8, maxLen uint16) FlowBuilder {
+	if a.builder.ofFlow.Table != nil && a.builder.ofFlow.Table.Switch != nil {
+		controllerAct := &nxControllerWithMaxLen{
+			NXController: ofctrl.NXController{
+				ControllerID: a.builder.ofFlow.Table.Switch.GetControllerID(),
+				Reason:       reason,
+			},
+			MaxLen: maxLen,
+		}
+		a.builder.ApplyAction(controllerAct)
+	}
+	return a.builder
+}
+
 func (a *ofFlowAction) Meter(meterId uint32) FlowBuilder {
 	a.builder.ofFlow.Meter(meterId)
 	return a.builder
 }
 
+// CheckPktLarger is an action to check whether the packet, including its L2 header, is larger than pktLen. The result
+// is written to the specified bit of the register: 1 if the packet is larger, 0 otherwise. It requires OVS 2.12 or
+// later.
+func (a *ofFlowAction) CheckPktLarger(pktLen uint16, regID int, bit uint32) FlowBuilder {
+	name := fmt.Sprintf("%s%d", NxmFieldReg, regID)
+	field, _ := openflow13.FindFieldHeaderByName(name, false)
+	a.builder.ApplyAction(&nxActionCheckPktLarger{PktLen: pktLen, Dst: field, Offset: uint16(bit)})
+	return a.builder
+}
+
 //  Learn is an action which adds or modifies a flow in an OpenFlow table.
 func (a *ofFlowAction) Learn(id TableIDType, priority uint16, idleTimeout, hardTimeout uint16, cookieID uint64) LearnAction {
 	la := &ofLearnAction{
@@ -549,3 +575,80 @@ func (a *ofFlowAction) GotoTable(table TableIDType) FlowBuilder {
 	a.builder.ofFlow.Goto(uint8(table))
 	return a.builder
 }
+
+const (
+	// nxExperimenterID is the OpenFlow experimenter ID of the Nicira extensions.
+	nxExperimenterID uint32 = 0x00002320
+	// nxActionCheckPktLargerSubtype is the subtype of the Nicira check_pkt_larger action.
+	nxActionCheckPktLargerSubtype uint16 = 49
+	nxActionCheckPktLargerLen     uint16 = 24
+)
+
+// nxActionCheckPktLarger implements the Nicira check_pkt_larger action, which is not provided by ofctrl. It is encoded
+// as: experimenter action header (10 bytes), pkt_len (2 bytes), offset of the destination bit (2 bytes), NXM header
+// of the destination field (4 bytes), and padding to 24 bytes.
+type nxActionCheckPktLarger struct {
+	PktLen uint16
+	Dst    *openflow13.MatchField
+	Offset uint16
+}
+
+func (a *nxActionCheckPktLarger) Header() *openflow13.ActionHeader {
+	return &openflow13.ActionHeader{Type: openflow13.ActionType_Experimenter, Length: nxActionCheckPktLargerLen}
+}
+
+func (a *nxActionCheckPktLarger) Len() uint16 {
+	return nxActionCheckPktLargerLen
+}
+
+func (a *nxActionCheckPktLarger) MarshalBinary() ([]byte, error) {
+	data := make([]byte, nxActionCheckPktLargerLen)
+	binary.BigEndian.PutUint16(data[0:], openflow13.ActionType_Experimenter)
+	binary.BigEndian.PutUint16(data[2:], nxActionCheckPktLargerLen)
+	binary.BigEndian.PutUint32(data[4:], nxExperimenterID)
+	binary.BigEndian.PutUint16(data[8:], nxActionCheckPktLargerSubtype)
+	binary.BigEndian.PutUint16(data[10:], a.PktLen)
+	binary.BigEndian.PutUint16(data[12:], a.Offset)
+	binary.BigEndian.PutUint32(data[14:], a.Dst.MarshalHeader())
+	return data, nil
+}
+
+func (a *nxActionCheckPktLarger) UnmarshalBinary(data []byte) error {
+	if len(data) < int(nxActionCheckPktLargerLen) {
+		return fmt.Errorf("check_pkt_larger action is too short: %d bytes", len(data))
+	}
+	if subtype := binary.BigEndian.Uint16(data[8:]); subtype != nxActionCheckPktLargerSubtype {
+		return fmt.Errorf("unexpected subtype %d for check_pkt_larger action", subtype)
+	}
+	a.PktLen = binary.BigEndian.Uint16(data[10:])
+	a.Offset = binary.BigEndian.Uint16(data[12:])
+	a.Dst = &openflow13.MatchField{
+		Class:   binary.BigEndian.Uint16(data[14:]),
+		Field:   data[16] >> 1,
+		HasMask: data[16]&1 == 1,
+		Length:  data[17],
+	}
+	return nil
+}
+
+// GetActionMessage and GetActionType implement ofctrl.OFAction.
+func (a *nxActionCheckPktLarger) GetActionMessage() openflow13.Action {
+	return a
+}
+
+func (a *nxActionCheckPktLarger) GetActionType() string {
+	return "check_pkt_larger"
+}
+
+// nxControllerWithMaxLen is the Nicira controller action of ofctrl, with a configurable maximum number of bytes of the
+// packet sent to the controller.
+type nxControllerWithMaxLen struct {
+	ofctrl.NXController
+	MaxLen uint16
+}
+
+func (a *nxControllerWithMaxLen) GetActionMessage() openflow13.Action {
+	action := a.NXController.GetActionMessage().(*openflow13.NXActionController)
+	action.MaxLen = a.MaxLen
+	return action
+}
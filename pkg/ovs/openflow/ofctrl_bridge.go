@@ -5,14 +5,20 @@ import (
 	"fmt"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/contiv/libOpenflow/openflow13"
+	"github.com/contiv/libOpenflow/util"
 	"github.com/contiv/ofnet/ofctrl"
 	"golang.org/x/time/rate"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/klog/v2"
 
 	"antrea.io/antrea/pkg/agent/metrics"
+	"antrea.io/antrea/pkg/apis/crd/v1beta1"
+	"antrea.io/antrea/pkg/monitor/condition"
+	"antrea.io/antrea/pkg/ovs/keepalive"
 )
 
 const (
@@ -146,6 +152,44 @@ type OFBridge struct {
 	connected chan bool
 	// pktConsumers is a map from PacketIn reason to the channel that is used to publish the PacketIn message.
 	pktConsumers sync.Map
+
+	// probeInterval is the interval between the keepalive probes of the connection to the OFSwitch, 0 disables
+	// the probes.
+	probeInterval time.Duration
+	// probeFailureThreshold is the number of probe intervals without reply after which the connection is
+	// considered dead.
+	probeFailureThreshold int
+	// keepaliveMutex protects keepalive and keepaliveStopCh, which are replaced for each connection.
+	keepaliveMutex  sync.Mutex
+	keepalive       *keepalive.Monitor
+	keepaliveStopCh chan struct{}
+}
+
+// emptyMessage is the body of the multipart requests which have none, e.g. OFPMP_DESC.
+type emptyMessage struct{}
+
+func (m emptyMessage) Len() uint16 {
+	return 0
+}
+
+func (m emptyMessage) MarshalBinary() ([]byte, error) {
+	return []byte{}, nil
+}
+
+func (m emptyMessage) UnmarshalBinary(data []byte) error {
+	return nil
+}
+
+// newDescStatsRequest returns an OFPMP_DESC multipart request, which is used as keepalive probe since the replies
+// to the echo requests are not visible outside ofctrl.OFSwitch.
+func newDescStatsRequest() *openflow13.MultipartRequest {
+	req := &openflow13.MultipartRequest{
+		Header: openflow13.NewOfp13Header(),
+		Type:   openflow13.MultipartType_Desc,
+		Body:   emptyMessage{},
+	}
+	req.Header.Type = openflow13.Type_MultiPartRequest
+	return req
 }
 
 func (b *OFBridge) CreateGroup(id GroupIDType) Group {
@@ -253,10 +297,12 @@ func (b *OFBridge) PacketRcvd(sw *ofctrl.OFSwitch, packet *ofctrl.PacketIn) {
 // SwitchConnected is a callback when the remote OFSwitch is connected.
 func (b *OFBridge) SwitchConnected(sw *ofctrl.OFSwitch) {
 	klog.Infof("OFSwitch is connected: %v", sw.DPID())
+	condition.Set(v1beta1.OpenflowConnectionUp, corev1.ConditionTrue, "", "")
 	// initialize tables.
 	b.ofSwitch = sw
 	b.ofSwitch.EnableMonitor()
 	b.initialize()
+	b.startKeepalive(sw)
 	go func() {
 		// b.connected is nil if it is an automatic reconnection but not triggered by OFSwitch.Connect.
 		if b.connected != nil {
@@ -269,10 +315,47 @@ func (b *OFBridge) SwitchConnected(sw *ofctrl.OFSwitch) {
 // MultipartReply is a callback when multipartReply message is received on ofctrl.OFSwitch is connected.
 // Client uses this method to handle the reply message if it has customized MultipartRequest message.
 func (b *OFBridge) MultipartReply(sw *ofctrl.OFSwitch, rep *openflow13.MultipartReply) {
+	b.keepaliveMutex.Lock()
+	defer b.keepaliveMutex.Unlock()
+	// Any reply proves that the connection is alive, not only the replies to the keepalive probes.
+	if b.keepalive != nil {
+		b.keepalive.ReplyReceived()
+	}
 }
 
 func (b *OFBridge) SwitchDisconnected(sw *ofctrl.OFSwitch) {
 	klog.Infof("OFSwitch is disconnected: %v", sw.DPID())
+	condition.Set(v1beta1.OpenflowConnectionUp, corev1.ConditionFalse, "Disconnected", fmt.Sprintf("OFSwitch %v is disconnected", sw.DPID()))
+	b.stopKeepalive()
+}
+
+// startKeepalive starts probing the connection to the OFSwitch, if the probes are enabled. When the connection is
+// considered dead, it is closed, and ofctrl.Controller reconnects to the OFSwitch, after which the flows are
+// replayed by the Client.
+func (b *OFBridge) startKeepalive(sw *ofctrl.OFSwitch) {
+	if b.probeInterval <= 0 {
+		return
+	}
+	b.keepaliveMutex.Lock()
+	defer b.keepaliveMutex.Unlock()
+	if b.keepaliveStopCh != nil {
+		close(b.keepaliveStopCh)
+	}
+	b.keepalive = keepalive.NewMonitor("openflow", b.probeInterval, b.probeFailureThreshold, func() error {
+		return sw.Send(newDescStatsRequest())
+	}, sw.Disconnect)
+	b.keepaliveStopCh = make(chan struct{})
+	go b.keepalive.Run(b.keepaliveStopCh)
+}
+
+func (b *OFBridge) stopKeepalive() {
+	b.keepaliveMutex.Lock()
+	defer b.keepaliveMutex.Unlock()
+	if b.keepaliveStopCh != nil {
+		close(b.keepaliveStopCh)
+	}
+	b.keepalive = nil
+	b.keepaliveStopCh = nil
 }
 
 // initialize creates ofctrl.Table for each table in the tableCache.
@@ -325,10 +408,19 @@ func (b *OFBridge) Connect(maxRetrySec int, connectionCh chan struct{}) error {
 
 // Disconnect stops connection to the OFSwitch.
 func (b *OFBridge) Disconnect() error {
+	b.stopKeepalive()
 	b.controller.Delete()
 	return nil
 }
 
+// SetConnectionProbe sets the interval between the keepalive probes of the connection to the OFSwitch, and the
+// number of intervals without reply after which the connection is considered dead. It must be called before
+// Connect. An interval of 0 disables the probes.
+func (b *OFBridge) SetConnectionProbe(interval time.Duration, failureThreshold int) {
+	b.probeInterval = interval
+	b.probeFailureThreshold = failureThreshold
+}
+
 // DumpFlows queries the Openflow entries from OFSwitch, the filter of the query is Openflow cookieID. The result is
 // a map from flow cookieID to FlowStates.
 func (b *OFBridge) DumpFlows(cookieID, cookieMask uint64) (map[uint64]*FlowStates, error) {
@@ -368,6 +460,13 @@ func (b *OFBridge) IsConnected() bool {
 	return b.ofSwitch.IsReady()
 }
 
+// OpenFlowVersion returns the OpenFlow version negotiated with the OFSwitch. libOpenflow only
+// implements OpenFlow 1.3, which is the version it announces in its Hello message, and all the
+// OVS versions supported by Antrea accept it.
+func (b *OFBridge) OpenFlowVersion() string {
+	return OpenFlow13
+}
+
 func (b *OFBridge) AddFlowsInBundle(addflows []Flow, modFlows []Flow, delFlows []Flow) error {
 	// If no Openflow entries are requested to be added or modified or deleted on the OVS bridge, return immediately.
 	if len(addflows) == 0 && len(modFlows) == 0 && len(delFlows) == 0 {
@@ -557,13 +656,19 @@ func (b *OFBridge) AddOFEntriesInBundle(addEntries []OFEntry, modEntries []OFEnt
 type PacketInQueue struct {
 	rateLimiter *rate.Limiter
 	packetsCh   chan *ofctrl.PacketIn
+	// limit is the maximum number of packets in the queue. It is the size of the queue unless the
+	// queue is shrunk with SetLimit. It is accessed atomically.
+	limit int32
 }
 
 func NewPacketInQueue(size int, r rate.Limit) *PacketInQueue {
-	return &PacketInQueue{rateLimiter: rate.NewLimiter(r, 1), packetsCh: make(chan *ofctrl.PacketIn, size)}
+	return &PacketInQueue{rateLimiter: rate.NewLimiter(r, 1), packetsCh: make(chan *ofctrl.PacketIn, size), limit: int32(size)}
 }
 
 func (q *PacketInQueue) AddOrDrop(packet *ofctrl.PacketIn) bool {
+	if len(q.packetsCh) >= int(atomic.LoadInt32(&q.limit)) {
+		return false
+	}
 	select {
 	case q.packetsCh <- packet:
 		return true
@@ -573,6 +678,25 @@ func (q *PacketInQueue) AddOrDrop(packet *ofctrl.PacketIn) bool {
 	}
 }
 
+// SetLimit sets the maximum number of packets in the queue, which cannot exceed the size of the
+// queue. The queued packets in excess are dropped, and their number is returned.
+func (q *PacketInQueue) SetLimit(limit int) int {
+	if limit > cap(q.packetsCh) {
+		limit = cap(q.packetsCh)
+	}
+	atomic.StoreInt32(&q.limit, int32(limit))
+	dropped := 0
+	for len(q.packetsCh) > limit {
+		select {
+		case <-q.packetsCh:
+			dropped++
+		default:
+			return dropped
+		}
+	}
+	return dropped
+}
+
 func (q *PacketInQueue) GetRateLimited(stopCh <-chan struct{}) *ofctrl.PacketIn {
 	when := q.rateLimiter.Reserve().Delay()
 	t := time.NewTimer(when)
@@ -612,6 +736,14 @@ func (b *OFBridge) SendPacketOut(packetOut *ofctrl.PacketOut) error {
 	return b.ofSwitch.Send(packetOut.GetMessage())
 }
 
+func (b *OFBridge) SendRawPacketOut(inPort, outPort uint32, frame []byte) error {
+	pktOut := openflow13.NewPacketOut()
+	pktOut.InPort = inPort
+	pktOut.Data = util.NewBuffer(frame)
+	pktOut.AddAction(openflow13.NewActionOutput(outPort))
+	return b.ofSwitch.Send(pktOut)
+}
+
 func (b *OFBridge) BuildPacketOut() PacketOutBuilder {
 	return &ofPacketOutBuilder{
 		pktOut: new(ofctrl.PacketOut),
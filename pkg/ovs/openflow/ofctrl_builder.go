@@ -262,33 +262,36 @@ func (b *ofFlowBuilder) MatchTunnelDst(dstIP net.IP) FlowBuilder {
 	return b
 }
 
+// ctLabelRange adds the match of the rng range of ct_label to match. The ranges matched by previous calls are kept, so
+// that a flow can match multiple ranges of ct_label.
 func ctLabelRange(high, low uint64, rng Range, match *ofctrl.FlowMatch) {
 	// [127..64] [63..0]
 	//   high     low
-	match.CtLabelHi = high
-	match.CtLabelLo = low
-	match.CtLabelHiMask = 0xffff_ffff_ffff_ffff
-	match.CtLabelLoMask = 0xffff_ffff_ffff_ffff
+	var hiMask, loMask uint64 = 0xffff_ffff_ffff_ffff, 0xffff_ffff_ffff_ffff
 	if rng[0] == rng[1] {
 		if rng[0] < 64 {
-			match.CtLabelLoMask = 1 << rng[0]
-			match.CtLabelHiMask = 0
+			loMask = 1 << rng[0]
+			hiMask = 0
 		} else {
-			match.CtLabelHiMask = 1 << (rng[0] - 64)
-			match.CtLabelLoMask = 0
+			hiMask = 1 << (rng[0] - 64)
+			loMask = 0
 		}
 	} else if rng[0] < 64 && rng[1] >= 64 {
-		match.CtLabelLoMask <<= rng[0]
-		match.CtLabelHiMask >>= 127 - rng[1]
+		loMask <<= rng[0]
+		hiMask >>= 127 - rng[1]
 	} else if rng[1] < 64 {
-		match.CtLabelLoMask &= 0xffff_ffff_ffff_ffff << rng[0]
-		match.CtLabelLoMask &= 0xffff_ffff_ffff_ffff >> (63 - rng[1])
-		match.CtLabelHiMask = 0
+		loMask &= 0xffff_ffff_ffff_ffff << rng[0]
+		loMask &= 0xffff_ffff_ffff_ffff >> (63 - rng[1])
+		hiMask = 0
 	} else if rng[0] >= 64 {
-		match.CtLabelHiMask &= 0xffff_ffff_ffff_ffff << (rng[0] - 64)
-		match.CtLabelHiMask &= 0xffff_ffff_ffff_ffff >> (127 - rng[1])
-		match.CtLabelLoMask = 0
+		hiMask &= 0xffff_ffff_ffff_ffff << (rng[0] - 64)
+		hiMask &= 0xffff_ffff_ffff_ffff >> (127 - rng[1])
+		loMask = 0
 	}
+	match.CtLabelHi = match.CtLabelHi&^hiMask | high&hiMask
+	match.CtLabelLo = match.CtLabelLo&^loMask | low&loMask
+	match.CtLabelHiMask |= hiMask
+	match.CtLabelLoMask |= loMask
 }
 
 func (b *ofFlowBuilder) MatchCTLabelRange(high, low uint64, bitRange Range) FlowBuilder {
@@ -339,6 +342,14 @@ func (b *ofFlowBuilder) MatchICMPv6Code(icmp6Code byte) FlowBuilder {
 	return b
 }
 
+// MatchNDTarget adds match condition for matching the target address of ICMPv6 Neighbor Discovery messages.
+func (b *ofFlowBuilder) MatchNDTarget(target net.IPNet) FlowBuilder {
+	b.matchers = append(b.matchers, fmt.Sprintf("nd_target=%s", target.String()))
+	b.Match.NdTarget = &target.IP
+	b.Match.NdTargetMask = maskToIP(target.Mask)
+	return b
+}
+
 func maskToIP(mask net.IPMask) *net.IP {
 	ip := net.IP(mask)
 	return &ip
@@ -569,14 +580,16 @@ func (b *ofFlowBuilder) MatchCTDstPort(port uint16) FlowBuilder {
 // "+new", "+est", "+rel" and "+trk-inv".
 func (b *ofFlowBuilder) MatchCTProtocol(proto Protocol) FlowBuilder {
 	switch proto {
-	case ProtocolTCP:
+	case ProtocolTCP, ProtocolTCPv6:
 		b.Match.CtIpProto = 6
-	case ProtocolUDP:
+	case ProtocolUDP, ProtocolUDPv6:
 		b.Match.CtIpProto = 17
-	case ProtocolSCTP:
+	case ProtocolSCTP, ProtocolSCTPv6:
 		b.Match.CtIpProto = 132
 	case ProtocolICMP:
 		b.Match.CtIpProto = 1
+	case ProtocolICMPv6:
+		b.Match.CtIpProto = 58
 	}
 	b.matchers = append(b.matchers, fmt.Sprintf("ct_nw_proto=%d", b.Match.CtIpProto))
 	return b
@@ -42,3 +42,31 @@ func TestMatchCTLabelRange(t *testing.T) {
 		require.Equal(t, tc.expectedLowMask, match.CtLabelLoMask, fmt.Sprintf("Expected low mask is equal, test case: %+v", tc))
 	}
 }
+
+func TestMatchMultipleCTLabelRanges(t *testing.T) {
+	match := new(ofctrl.FlowMatch)
+	ctLabelRange(0, 0x5_0000_0000, Range{32, 63}, match)
+	ctLabelRange(0b10, 0, Range{65, 65}, match)
+	require.Equal(t, uint64(0b10), match.CtLabelHi)
+	require.Equal(t, uint64(0x5_0000_0000), match.CtLabelLo)
+	require.Equal(t, uint64(0b10), match.CtLabelHiMask)
+	require.Equal(t, uint64(0xffff_ffff_0000_0000), match.CtLabelLoMask)
+}
+
+func TestMatchCTProtocol(t *testing.T) {
+	for _, tc := range []struct {
+		protocol          Protocol
+		expectedCtIpProto uint8
+	}{
+		{protocol: ProtocolTCP, expectedCtIpProto: 6},
+		{protocol: ProtocolTCPv6, expectedCtIpProto: 6},
+		{protocol: ProtocolUDPv6, expectedCtIpProto: 17},
+		{protocol: ProtocolSCTP, expectedCtIpProto: 132},
+		{protocol: ProtocolSCTPv6, expectedCtIpProto: 132},
+		{protocol: ProtocolICMPv6, expectedCtIpProto: 58},
+	} {
+		b := &ofFlowBuilder{ofFlow: ofFlow{Flow: &ofctrl.Flow{}}}
+		b.MatchCTProtocol(tc.protocol)
+		require.Equal(t, tc.expectedCtIpProto, b.Match.CtIpProto, fmt.Sprintf("Expected ct_nw_proto is equal, protocol: %s", tc.protocol))
+	}
+}
@@ -116,6 +116,14 @@ func (f *ofFlow) GetBundleMessage(entryOper OFOperation) (ofctrl.OpenFlowModMess
 	return message, nil
 }
 
+func (f *ofFlow) GetCookieID() uint64 {
+	return f.Flow.CookieID
+}
+
+func (f *ofFlow) GetTableID() TableIDType {
+	return f.table.GetID()
+}
+
 // CopyToBuilder returns a new FlowBuilder that copies the table, protocols,
 // matches, and CookieID of the Flow, but does not copy private status fields
 // of the ofctrl.Flow, e.g. "realized" and "isInstalled". It copies the
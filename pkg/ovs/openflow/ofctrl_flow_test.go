@@ -1,8 +1,10 @@
 package openflow
 
 import (
+	"fmt"
 	"testing"
 
+	"github.com/contiv/ofnet/ofctrl"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -50,3 +52,44 @@ func TestCopyToBuilder_Drop(t *testing.T) {
 	assert.Equal(t, newPriority, newFlow2.Done().(*ofFlow).Match.Priority)
 	assert.Equal(t, true, newFlow2.Done().IsDropFlow())
 }
+
+func TestCheckPktLargerAction(t *testing.T) {
+	field, _, err := getFieldRange(fmt.Sprintf("%s%d", NxmFieldReg, 0))
+	assert.NoError(t, err)
+	action := &nxActionCheckPktLarger{PktLen: 1464, Dst: field, Offset: 28}
+	assert.Equal(t, nxActionCheckPktLargerLen, action.Header().Length)
+	data, err := action.GetActionMessage().MarshalBinary()
+	assert.NoError(t, err)
+	// Experimenter header with the Nicira experimenter ID and subtype 49, pkt_len 1464, offset 28, NXM_NX_REG0
+	// header and padding.
+	expected := []byte{
+		0xff, 0xff, 0x00, 0x18, 0x00, 0x00, 0x23, 0x20, 0x00, 0x31, 0x05, 0xb8,
+		0x00, 0x1c, 0x00, 0x01, 0x00, 0x04, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	}
+	assert.Equal(t, expected, data)
+
+	decoded := new(nxActionCheckPktLarger)
+	assert.NoError(t, decoded.UnmarshalBinary(data))
+	assert.Equal(t, uint16(1464), decoded.PktLen)
+	assert.Equal(t, uint16(28), decoded.Offset)
+	assert.Equal(t, field.Class, decoded.Dst.Class)
+	assert.Equal(t, field.Field, decoded.Dst.Field)
+	assert.Equal(t, field.Length, decoded.Dst.Length)
+}
+
+func TestControllerWithMaxLenAction(t *testing.T) {
+	action := &nxControllerWithMaxLen{
+		NXController: ofctrl.NXController{ControllerID: 0x1234, Reason: 1},
+		MaxLen:       512,
+	}
+	data, err := action.GetActionMessage().MarshalBinary()
+	assert.NoError(t, err)
+	// Experimenter header with the Nicira experimenter ID and subtype 20, max_len 512, controller ID 0x1234, reason 1
+	// and padding.
+	expected := []byte{
+		0xff, 0xff, 0x00, 0x10, 0x00, 0x00, 0x23, 0x20, 0x00, 0x14, 0x02, 0x00,
+		0x12, 0x34, 0x01, 0x00,
+	}
+	assert.Equal(t, expected, data)
+	assert.Equal(t, ofctrl.ActTypeController, action.GetActionType())
+}
@@ -26,6 +26,9 @@ import (
 const (
 	icmpEchoRequestType  uint8 = 8
 	icmp6EchoRequestType uint8 = 128
+	// sctpCommonHeaderLen is the length of the SCTP common header, which starts with the source
+	// and destination ports.
+	sctpCommonHeaderLen = 12
 )
 
 // GetTCPHeaderData gets TCP header data from IP packet.
@@ -62,6 +65,25 @@ func getUDPHeaderData(ipPkt util.Message) (udpSrcPort, udpDstPort uint16, err er
 	return udpIn.PortSrc, udpIn.PortDst, nil
 }
 
+// getSCTPHeaderData gets the ports from the SCTP common header. libOpenflow doesn't parse SCTP,
+// hence the SCTP packet is the payload of the IP packet.
+func getSCTPHeaderData(ipPkt util.Message) (sctpSrcPort, sctpDstPort uint16, err error) {
+	var sctpBytes []byte
+	switch typedIPPkt := ipPkt.(type) {
+	case *protocol.IPv4:
+		sctpBytes, err = typedIPPkt.Data.(*util.Buffer).MarshalBinary()
+	case *protocol.IPv6:
+		sctpBytes, err = typedIPPkt.Data.(*util.Buffer).MarshalBinary()
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+	if len(sctpBytes) < sctpCommonHeaderLen {
+		return 0, 0, errors.New("SCTP packet is too short to unmarshal the common header")
+	}
+	return binary.BigEndian.Uint16(sctpBytes[:2]), binary.BigEndian.Uint16(sctpBytes[2:4]), nil
+}
+
 func getICMPHeaderData(ipPkt util.Message) (icmpType, icmpCode uint8, icmpEchoID, icmpEchoSeq uint16, err error) {
 	var icmpIn *protocol.ICMP
 	switch typedIPPkt := ipPkt.(type) {
@@ -114,6 +136,8 @@ func ParsePacketIn(pktIn *ofctrl.PacketIn) (*Packet, error) {
 		packet.SourcePort, packet.DestinationPort, _, _, packet.TCPFlags, err = GetTCPHeaderData(pktIn.Data.Data)
 	} else if packet.IPProto == protocol.Type_UDP {
 		packet.SourcePort, packet.DestinationPort, err = getUDPHeaderData(pktIn.Data.Data)
+	} else if packet.IPProto == ofctrl.IP_PROTO_SCTP {
+		packet.SourcePort, packet.DestinationPort, err = getSCTPHeaderData(pktIn.Data.Data)
 	} else if packet.IPProto == protocol.Type_ICMP || packet.IPProto == protocol.Type_IPv6ICMP {
 		_, _, packet.ICMPEchoID, packet.ICMPEchoSeq, err = getICMPHeaderData(pktIn.Data.Data)
 	}
@@ -19,8 +19,10 @@ import (
 
 	"github.com/contiv/libOpenflow/protocol"
 	"github.com/contiv/libOpenflow/util"
+	"github.com/contiv/ofnet/ofctrl"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
 )
 
 func TestGetTCPHeaderData(t *testing.T) {
@@ -73,3 +75,39 @@ func TestGetTCPHeaderData(t *testing.T) {
 		})
 	}
 }
+
+func TestGetSCTPHeaderData(t *testing.T) {
+	// Source port 5000, destination port 36412, verification tag and checksum.
+	sctpHeader := []byte{0x13, 0x88, 0x8e, 0x3c, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00}
+	pktIn := new(protocol.IPv4)
+	bf := new(util.Buffer)
+	bf.UnmarshalBinary(sctpHeader)
+	pktIn.Data = bf
+
+	sctpSrcPort, sctpDstPort, err := getSCTPHeaderData(pktIn)
+	require.NoError(t, err, "getSCTPHeaderData() returned an error")
+	assert.Equal(t, uint16(5000), sctpSrcPort)
+	assert.Equal(t, uint16(36412), sctpDstPort)
+
+	bf = new(util.Buffer)
+	bf.UnmarshalBinary(sctpHeader[:4])
+	pktIn.Data = bf
+	_, _, err = getSCTPHeaderData(pktIn)
+	assert.Error(t, err, "getSCTPHeaderData() should fail with a truncated header")
+}
+
+func TestPacketInQueueSetLimit(t *testing.T) {
+	q := NewPacketInQueue(10, rate.Inf)
+	for i := 0; i < 8; i++ {
+		assert.True(t, q.AddOrDrop(&ofctrl.PacketIn{}))
+	}
+	// Shrinking the queue drops the queued packets in excess.
+	assert.Equal(t, 6, q.SetLimit(2))
+	assert.False(t, q.AddOrDrop(&ofctrl.PacketIn{}))
+	// The limit cannot exceed the size of the queue.
+	assert.Equal(t, 0, q.SetLimit(20))
+	for i := 0; i < 8; i++ {
+		assert.True(t, q.AddOrDrop(&ofctrl.PacketIn{}))
+	}
+	assert.False(t, q.AddOrDrop(&ofctrl.PacketIn{}))
+}
@@ -25,6 +25,7 @@ import (
 	gomock "github.com/golang/mock/gomock"
 	net "net"
 	reflect "reflect"
+	time "time"
 )
 
 // MockBridge is a mock of Bridge interface
@@ -289,6 +290,20 @@ func (mr *MockBridgeMockRecorder) IsConnected() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsConnected", reflect.TypeOf((*MockBridge)(nil).IsConnected))
 }
 
+// OpenFlowVersion mocks base method
+func (m *MockBridge) OpenFlowVersion() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OpenFlowVersion")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// OpenFlowVersion indicates an expected call of OpenFlowVersion
+func (mr *MockBridgeMockRecorder) OpenFlowVersion() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OpenFlowVersion", reflect.TypeOf((*MockBridge)(nil).OpenFlowVersion))
+}
+
 // SendPacketOut mocks base method
 func (m *MockBridge) SendPacketOut(arg0 *ofctrl.PacketOut) error {
 	m.ctrl.T.Helper()
@@ -303,6 +318,32 @@ func (mr *MockBridgeMockRecorder) SendPacketOut(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendPacketOut", reflect.TypeOf((*MockBridge)(nil).SendPacketOut), arg0)
 }
 
+// SendRawPacketOut mocks base method
+func (m *MockBridge) SendRawPacketOut(arg0, arg1 uint32, arg2 []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendRawPacketOut", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendRawPacketOut indicates an expected call of SendRawPacketOut
+func (mr *MockBridgeMockRecorder) SendRawPacketOut(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendRawPacketOut", reflect.TypeOf((*MockBridge)(nil).SendRawPacketOut), arg0, arg1, arg2)
+}
+
+// SetConnectionProbe mocks base method
+func (m *MockBridge) SetConnectionProbe(arg0 time.Duration, arg1 int) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetConnectionProbe", arg0, arg1)
+}
+
+// SetConnectionProbe indicates an expected call of SetConnectionProbe
+func (mr *MockBridgeMockRecorder) SetConnectionProbe(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetConnectionProbe", reflect.TypeOf((*MockBridge)(nil).SetConnectionProbe), arg0, arg1)
+}
+
 // SubscribePacketIn mocks base method
 func (m *MockBridge) SubscribePacketIn(arg0 byte, arg1 *openflow.PacketInQueue) error {
 	m.ctrl.T.Helper()
@@ -518,6 +559,34 @@ func (mr *MockFlowMockRecorder) GetBundleMessage(arg0 interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBundleMessage", reflect.TypeOf((*MockFlow)(nil).GetBundleMessage), arg0)
 }
 
+// GetCookieID mocks base method
+func (m *MockFlow) GetCookieID() uint64 {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCookieID")
+	ret0, _ := ret[0].(uint64)
+	return ret0
+}
+
+// GetCookieID indicates an expected call of GetCookieID
+func (mr *MockFlowMockRecorder) GetCookieID() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCookieID", reflect.TypeOf((*MockFlow)(nil).GetCookieID))
+}
+
+// GetTableID mocks base method
+func (m *MockFlow) GetTableID() openflow.TableIDType {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTableID")
+	ret0, _ := ret[0].(openflow.TableIDType)
+	return ret0
+}
+
+// GetTableID indicates an expected call of GetTableID
+func (mr *MockFlowMockRecorder) GetTableID() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTableID", reflect.TypeOf((*MockFlow)(nil).GetTableID))
+}
+
 // IsDropFlow mocks base method
 func (m *MockFlow) IsDropFlow() bool {
 	m.ctrl.T.Helper()
@@ -637,6 +706,20 @@ func (mr *MockActionMockRecorder) CT(arg0, arg1, arg2 interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CT", reflect.TypeOf((*MockAction)(nil).CT), arg0, arg1, arg2)
 }
 
+// CheckPktLarger mocks base method
+func (m *MockAction) CheckPktLarger(arg0 uint16, arg1 int, arg2 uint32) openflow.FlowBuilder {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckPktLarger", arg0, arg1, arg2)
+	ret0, _ := ret[0].(openflow.FlowBuilder)
+	return ret0
+}
+
+// CheckPktLarger indicates an expected call of CheckPktLarger
+func (mr *MockActionMockRecorder) CheckPktLarger(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckPktLarger", reflect.TypeOf((*MockAction)(nil).CheckPktLarger), arg0, arg1, arg2)
+}
+
 // Conjunction mocks base method
 func (m *MockAction) Conjunction(arg0 uint32, arg1, arg2 byte) openflow.FlowBuilder {
 	m.ctrl.T.Helper()
@@ -959,6 +1042,20 @@ func (mr *MockActionMockRecorder) SendToController(arg0 interface{}) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendToController", reflect.TypeOf((*MockAction)(nil).SendToController), arg0)
 }
 
+// SendToControllerWithMaxLen mocks base method
+func (m *MockAction) SendToControllerWithMaxLen(arg0 byte, arg1 uint16) openflow.FlowBuilder {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendToControllerWithMaxLen", arg0, arg1)
+	ret0, _ := ret[0].(openflow.FlowBuilder)
+	return ret0
+}
+
+// SendToControllerWithMaxLen indicates an expected call of SendToControllerWithMaxLen
+func (mr *MockActionMockRecorder) SendToControllerWithMaxLen(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendToControllerWithMaxLen", reflect.TypeOf((*MockAction)(nil).SendToControllerWithMaxLen), arg0, arg1)
+}
+
 // SetARPSha mocks base method
 func (m *MockAction) SetARPSha(arg0 net.HardwareAddr) openflow.FlowBuilder {
 	m.ctrl.T.Helper()
@@ -1719,6 +1816,20 @@ func (mr *MockFlowBuilderMockRecorder) MatchInPort(arg0 interface{}) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MatchInPort", reflect.TypeOf((*MockFlowBuilder)(nil).MatchInPort), arg0)
 }
 
+// MatchNDTarget mocks base method
+func (m *MockFlowBuilder) MatchNDTarget(arg0 net.IPNet) openflow.FlowBuilder {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MatchNDTarget", arg0)
+	ret0, _ := ret[0].(openflow.FlowBuilder)
+	return ret0
+}
+
+// MatchNDTarget indicates an expected call of MatchNDTarget
+func (mr *MockFlowBuilderMockRecorder) MatchNDTarget(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MatchNDTarget", reflect.TypeOf((*MockFlowBuilder)(nil).MatchNDTarget), arg0)
+}
+
 // MatchPktMark mocks base method
 func (m *MockFlowBuilder) MatchPktMark(arg0 uint32, arg1 *uint32) openflow.FlowBuilder {
 	m.ctrl.T.Helper()
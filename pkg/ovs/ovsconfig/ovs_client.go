@@ -24,6 +24,8 @@ import (
 	"github.com/TomCodeLV/OVSDB-golang-lib/pkg/helpers"
 	"github.com/TomCodeLV/OVSDB-golang-lib/pkg/ovsdb"
 	"k8s.io/klog/v2"
+
+	"antrea.io/antrea/pkg/ovs/keepalive"
 )
 
 const defaultOVSDBFile = "db.sock"
@@ -93,6 +95,25 @@ func NewOVSDBConnectionUDS(address string) (*ovsdb.OVSDB, Error) {
 	return db, nil
 }
 
+// NewOVSDBKeepalive returns a keepalive.Monitor which probes the OVSDB connection with echo
+// requests every interval, and closes it after failureThreshold intervals without reply, after
+// which the OVSDB client re-establishes it.
+func NewOVSDBKeepalive(db *ovsdb.OVSDB, interval time.Duration, failureThreshold int) *keepalive.Monitor {
+	var monitor *keepalive.Monitor
+	probe := func() error {
+		// Call blocks until the reply is received or the connection is closed.
+		if _, err := db.Call("echo", []interface{}{}, nil); err != nil {
+			return err
+		}
+		monitor.ReplyReceived()
+		return nil
+	}
+	monitor = keepalive.NewMonitor("ovsdb", interval, failureThreshold, probe, func() {
+		db.Close()
+	})
+	return monitor
+}
+
 // NewOVSBridge creates and returns a new OVSBridge struct.
 func NewOVSBridge(bridgeName string, ovsDatapathType OVSDatapathType, ovsdb *ovsdb.OVSDB) *OVSBridge {
 	return &OVSBridge{ovsdb, bridgeName, ovsDatapathType, "", false}
@@ -197,7 +218,18 @@ func (br *OVSBridge) create() Error {
 	return nil
 }
 
+// Delete deletes the bridge and all its ports. It does nothing if the bridge
+// does not exist.
 func (br *OVSBridge) Delete() Error {
+	if br.uuid == "" {
+		exists, err := br.lookupByName()
+		if err != nil {
+			return err
+		} else if !exists {
+			klog.Infof("Bridge %s does not exist", br.name)
+			return nil
+		}
+	}
 	tx := br.ovsdb.Transaction(openvSwitchSchema)
 	mutateSet := helpers.MakeOVSDBSet(map[string]interface{}{
 		"uuid": []string{br.uuid},
@@ -372,12 +404,17 @@ func (br *OVSBridge) CreateInternalPort(name string, ofPortRequest int32, extern
 // the bridge.
 // If ofPortRequest is not zero, it will be passed to the OVS port creation.
 func (br *OVSBridge) CreateTunnelPort(name string, tunnelType TunnelType, ofPortRequest int32) (string, Error) {
-	return br.createTunnelPort(name, tunnelType, ofPortRequest, false, "", "", "", nil)
+	return br.createTunnelPort(name, tunnelType, ofPortRequest, false, 0, "", "", "", "", nil)
 }
 
 // CreateTunnelPortExt creates a tunnel port with the specified name and type
 // on the bridge.
 // If ofPortRequest is not zero, it will be passed to the OVS port creation.
+// If dstPort is not zero, it will be set as the destination port of the tunnel
+// traffic; otherwise the default port of the tunnel type is used.
+// If tos is not empty, it will be set as the TOS of the outer IP header of the
+// tunnel traffic. It is a decimal number, or "inherit" to copy the TOS of the
+// encapsulated packets.
 // If remoteIP is not empty, it will be set to the tunnel port interface
 // options; otherwise flow based tunneling will be configured.
 // psk is for the pre-shared key of IPSec ESP tunnel. If it is not empty, it
@@ -390,6 +427,8 @@ func (br *OVSBridge) CreateTunnelPortExt(
 	tunnelType TunnelType,
 	ofPortRequest int32,
 	csum bool,
+	dstPort int32,
+	tos string,
 	localIP string,
 	remoteIP string,
 	psk string,
@@ -397,7 +436,7 @@ func (br *OVSBridge) CreateTunnelPortExt(
 	if psk != "" && remoteIP == "" {
 		return "", newInvalidArgumentsError("IPSec tunnel can not be flow based. remoteIP must be set")
 	}
-	return br.createTunnelPort(name, tunnelType, ofPortRequest, csum, localIP, remoteIP, psk, externalIDs)
+	return br.createTunnelPort(name, tunnelType, ofPortRequest, csum, dstPort, tos, localIP, remoteIP, psk, externalIDs)
 }
 
 func (br *OVSBridge) createTunnelPort(
@@ -405,6 +444,8 @@ func (br *OVSBridge) createTunnelPort(
 	tunnelType TunnelType,
 	ofPortRequest int32,
 	csum bool,
+	dstPort int32,
+	tos string,
 	localIP string,
 	remoteIP string,
 	psk string,
@@ -435,6 +476,12 @@ func (br *OVSBridge) createTunnelPort(
 	if csum {
 		options["csum"] = "true"
 	}
+	if dstPort != 0 {
+		options["dst_port"] = strconv.Itoa(int(dstPort))
+	}
+	if tos != "" {
+		options["tos"] = tos
+	}
 
 	return br.createPort(name, name, string(tunnelType), ofPortRequest, externalIDs, options)
 }
@@ -478,17 +525,39 @@ func (br *OVSBridge) SetInterfaceOptions(name string, options map[string]interfa
 	return nil
 }
 
-// ParseTunnelInterfaceOptions reads remote IP, local IP, IPSec PSK, and csum
-// from the tunnel interface options and returns them.
-func ParseTunnelInterfaceOptions(portData *OVSPortData) (net.IP, net.IP, string, bool) {
+// SetInterfaceMAC sets the MAC address of the provided interface. It only takes effect for
+// internal interfaces.
+func (br *OVSBridge) SetInterfaceMAC(name string, mac net.HardwareAddr) Error {
+	tx := br.ovsdb.Transaction(openvSwitchSchema)
+
+	tx.Update(dbtransaction.Update{
+		Table: "Interface",
+		Where: [][]interface{}{{"name", "==", name}},
+		Row: map[string]interface{}{
+			"mac": mac.String(),
+		},
+	})
+
+	_, err, temporary := tx.Commit()
+	if err != nil {
+		klog.Error("Transaction failed: ", err)
+		return NewTransactionError(err, temporary)
+	}
+	return nil
+}
+
+// ParseTunnelInterfaceOptions reads remote IP, local IP, IPSec PSK, csum,
+// destination port and TOS from the tunnel interface options and returns them.
+func ParseTunnelInterfaceOptions(portData *OVSPortData) (net.IP, net.IP, string, bool, int32, string) {
 	if portData.Options == nil {
-		return nil, nil, "", false
+		return nil, nil, "", false, 0, ""
 	}
 
 	var ok bool
 	var remoteIPStr, localIPStr, psk string
 	var remoteIP, localIP net.IP
 	var csum bool
+	var dstPort int32
 
 	if remoteIPStr, ok = portData.Options["remote_ip"]; ok {
 		if remoteIPStr != "flow" {
@@ -503,7 +572,12 @@ func ParseTunnelInterfaceOptions(portData *OVSPortData) (net.IP, net.IP, string,
 	if csumStr, ok := portData.Options["csum"]; ok {
 		csum, _ = strconv.ParseBool(csumStr)
 	}
-	return remoteIP, localIP, psk, csum
+	if dstPortStr, ok := portData.Options["dst_port"]; ok {
+		if port, err := strconv.ParseInt(dstPortStr, 10, 32); err == nil {
+			dstPort = int32(port)
+		}
+	}
+	return remoteIP, localIP, psk, csum, dstPort, portData.Options["tos"]
 }
 
 // CreateUplinkPort creates uplink port.
@@ -741,7 +815,18 @@ func (br *OVSBridge) GetPortList() ([]OVSPortData, Error) {
 		port := portMap[uuid]
 		ifUUIDList := helpers.GetIdListFromOVSDBSet(port["interfaces"].([]interface{}))
 		// Port should have one interface
-		intf := ifMap[ifUUIDList[0]]
+		intf, ok := map[string]interface{}(nil), false
+		if len(ifUUIDList) > 0 {
+			intf, ok = ifMap[ifUUIDList[0]]
+		}
+		if !ok {
+			// Keep the port in the list with its external IDs, so that the caller can decide what
+			// to do with it, instead of failing to list all the ports.
+			klog.Warningf("Could not find the interface of OVS port %s", port["name"])
+			portList[i].Name = port["name"].(string)
+			portList[i].ExternalIDs = buildMapFromOVSDBMap(port["external_ids"].([]interface{}))
+			continue
+		}
 		portList[i].IFName = intf["name"].(string)
 		buildPortDataCommon(port, intf, &portList[i])
 	}
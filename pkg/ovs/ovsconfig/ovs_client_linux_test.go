@@ -0,0 +1,84 @@
+// +build linux
+
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovsconfig
+
+import (
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOVSDBKeepaliveHungServer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ovsdb")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	address := filepath.Join(dir, defaultOVSDBFile)
+	listener, err := net.Listen(defaultConnNetwork, address)
+	require.NoError(t, err)
+	defer listener.Close()
+
+	// The server accepts the connections and reads the requests, but never replies, like a hung
+	// ovsdb-server.
+	connCh := make(chan net.Conn, 10)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go io.Copy(ioutil.Discard, conn)
+			connCh <- conn
+		}
+	}()
+
+	db, ovsErr := NewOVSDBConnectionUDS(address)
+	require.Nil(t, ovsErr)
+	defer db.Close()
+	select {
+	case conn := <-connCh:
+		defer conn.Close()
+	case <-time.After(5 * time.Second):
+		t.Fatal("OVSDB client did not connect")
+	}
+
+	interval := 100 * time.Millisecond
+	failureThreshold := 3
+	monitor := NewOVSDBKeepalive(db, interval, failureThreshold)
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	start := time.Now()
+	go monitor.Run(stopCh)
+
+	// The hung connection is closed, after which the client connects again.
+	maxDetectionLatency := time.Duration(failureThreshold+1)*interval + 200*time.Millisecond
+	select {
+	case conn := <-connCh:
+		defer conn.Close()
+		latency := time.Since(start)
+		assert.GreaterOrEqual(t, int64(latency), int64(time.Duration(failureThreshold)*interval))
+		assert.LessOrEqual(t, int64(latency), int64(maxDetectionLatency))
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Hung OVSDB connection was not re-established within %v", maxDetectionLatency)
+	}
+}
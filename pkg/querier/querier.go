@@ -15,14 +15,24 @@
 package querier
 
 import (
+	"context"
+	"net"
+	"time"
+
 	v1 "k8s.io/api/core/v1"
 
 	"antrea.io/antrea/pkg/agent/types"
 	cpv1beta "antrea.io/antrea/pkg/apis/controlplane/v1beta2"
+	agentconfig "antrea.io/antrea/pkg/config/agent"
 	"antrea.io/antrea/pkg/util/env"
 	"antrea.io/antrea/pkg/version"
 )
 
+// LastUpdateTimeAnnotation is set by the Agent on the NetworkPolicies, AddressGroups and
+// AppliedToGroups it returns, to the RFC3339 time the object was last received from the Antrea
+// Controller.
+const LastUpdateTimeAnnotation = "agent.antrea.io/last-update-time"
+
 type NetworkPolicyInfoQuerier interface {
 	GetNetworkPolicyNum() int
 	GetAddressGroupNum() int
@@ -40,6 +50,153 @@ type AgentNetworkPolicyInfoQuerier interface {
 	GetRuleByFlowID(ruleFlowID uint32) *types.PolicyRule
 }
 
+// NetworkPolicyResyncSummary describes the corrections made to the NetworkPolicies,
+// AddressGroups and AppliedToGroups cached by the Agent when they were relisted from the Antrea
+// Controller. The changes received from the Controller during the resync are included.
+type NetworkPolicyResyncSummary struct {
+	PoliciesAdded          int `json:"policiesAdded"`
+	PoliciesRemoved        int `json:"policiesRemoved"`
+	PoliciesUpdated        int `json:"policiesUpdated"`
+	AddressGroupsAdded     int `json:"addressGroupsAdded"`
+	AddressGroupsRemoved   int `json:"addressGroupsRemoved"`
+	AddressGroupsUpdated   int `json:"addressGroupsUpdated"`
+	AppliedToGroupsAdded   int `json:"appliedToGroupsAdded"`
+	AppliedToGroupsRemoved int `json:"appliedToGroupsRemoved"`
+	AppliedToGroupsUpdated int `json:"appliedToGroupsUpdated"`
+	// GroupMembersAdded and GroupMembersRemoved are the numbers of members added to and removed
+	// from the existing AddressGroups and AppliedToGroups.
+	GroupMembersAdded   int `json:"groupMembersAdded"`
+	GroupMembersRemoved int `json:"groupMembersRemoved"`
+}
+
+// AgentNetworkPolicyResyncer resyncs the NetworkPolicy state of the Agent with the Antrea
+// Controller on demand.
+type AgentNetworkPolicyResyncer interface {
+	// ResyncNetworkPolicies restarts the watches of NetworkPolicies, AddressGroups and
+	// AppliedToGroups to relist them, and waits for the rules which changed to be reconciled.
+	// Concurrent calls share the same resync.
+	ResyncNetworkPolicies(ctx context.Context) (*NetworkPolicyResyncSummary, error)
+}
+
+// NetworkPolicyRealizationError describes a NetworkPolicy rule which the Agent failed to realize.
+// It's retried until it's realized or removed from the Node.
+type NetworkPolicyRealizationError struct {
+	PolicyRef *cpv1beta.NetworkPolicyReference `json:"policyRef,omitempty"`
+	RuleID    string                           `json:"ruleID"`
+	RuleName  string                           `json:"ruleName,omitempty"`
+	// ErrorClass is the stage of the realization which failed, e.g. FlowInstall.
+	ErrorClass string `json:"errorClass"`
+	Error      string `json:"error"`
+	// RetryCount is the number of times the realization failed again after the first failure.
+	RetryCount  int       `json:"retryCount"`
+	LastAttempt time.Time `json:"lastAttempt"`
+}
+
+// AgentNetworkPolicyRealizationErrorQuerier looks up the NetworkPolicy rules which the Agent
+// failed to realize.
+type AgentNetworkPolicyRealizationErrorQuerier interface {
+	// GetNetworkPolicyRealizationErrors returns the rules which failed to be realized, sorted by
+	// NetworkPolicy and rule ID.
+	GetNetworkPolicyRealizationErrors() []NetworkPolicyRealizationError
+}
+
+// AgentPacketCaptureQuerier looks up the pcap files of the PacketCaptures run on the Node.
+type AgentPacketCaptureQuerier interface {
+	// GetPcapFile returns the path of the pcap file of the PacketCapture, and false if the
+	// PacketCapture has not been completed on the Node.
+	GetPcapFile(name string) (string, bool)
+}
+
+// PeerNodeLatency is the latency measured from the Node to the gateway of a peer Node.
+type PeerNodeLatency struct {
+	NodeName  string
+	GatewayIP net.IP
+	// LastRTT is the round-trip time of the last successful probe, 0 if no probe has succeeded.
+	LastRTT time.Duration
+	// LastProbeTime is the time of the last probe, zero if the peer Node has not been probed yet.
+	LastProbeTime time.Time
+	// PacketLoss is the ratio of failed probes among the most recent probes.
+	PacketLoss          float64
+	ConsecutiveFailures int
+	Reachable           bool
+}
+
+// AgentNodeLatencyQuerier looks up the latency measured from the Node to its peer Nodes.
+type AgentNodeLatencyQuerier interface {
+	// GetPeerNodeLatencies returns the latency of all the peer Nodes, sorted by Node name.
+	GetPeerNodeLatencies() []PeerNodeLatency
+	// GetUnreachablePeerNodes returns the names of the peer Nodes which failed too many
+	// consecutive probes, sorted.
+	GetUnreachablePeerNodes() []string
+}
+
+// FlowCollectorStatus is the status of the export of the flow records to an IPFIX collector.
+type FlowCollectorStatus struct {
+	Name     string
+	Address  string
+	Protocol string
+	// Connected is true if the connection to the collector is established and no send has failed
+	// since.
+	Connected bool
+	// RecordsSent is the number of flow records sent to the collector.
+	RecordsSent uint64
+	// RecordsFiltered is the number of flow records not sent because of the record filter of the
+	// collector.
+	RecordsFiltered uint64
+	// Failures is the number of failed connection attempts and sends.
+	Failures uint64
+	// LastError is the error of the last failure, empty if the collector is connected.
+	LastError string
+	// LastExportTime is the time the last flow record was sent, zero if none has been sent.
+	LastExportTime time.Time
+}
+
+// AgentFlowExporterQuerier looks up the status of the flow exporter.
+type AgentFlowExporterQuerier interface {
+	// GetFlowCollectorStatuses returns the status of the export to each IPFIX collector, in the
+	// order of the configuration.
+	GetFlowCollectorStatuses() []FlowCollectorStatus
+}
+
+// AuditLogEntry is an audit log entry written by the Agent for the packets matching a NetworkPolicy
+// rule with logging enabled, or dropped by K8s NetworkPolicy isolation.
+type AuditLogEntry struct {
+	Timestamp   time.Time
+	Table       string
+	Policy      string
+	Rule        string
+	Disposition string
+	Priority    string
+	SrcIP       string
+	DestIP      string
+	// SrcPort and DestPort are 0 for ICMP, or if the transport header of the packet is unknown.
+	SrcPort  uint16
+	DestPort uint16
+	// ICMPType and ICMPCode are only set for ICMP and ICMPv6, if the transport header of the
+	// packet is known.
+	ICMPType *uint8
+	ICMPCode *uint8
+	Length   uint16
+	Protocol string
+	// Packets is the number of identical packets aggregated in the entry, 0 or 1 for a single
+	// packet.
+	Packets int
+}
+
+// AgentAuditLogQuerier looks up the audit log entries recently written by the Agent.
+type AgentAuditLogQuerier interface {
+	// GetRecentAuditLogs returns the most recent audit log entries kept in memory by the Agent,
+	// from the oldest to the most recent, and false if audit logging is not enabled.
+	GetRecentAuditLogs() ([]AuditLogEntry, bool)
+}
+
+// AgentConfigQuerier looks up the live configuration of the Agent.
+type AgentConfigQuerier interface {
+	// GetAgentConfig returns the configuration the Agent was started with, including the changes
+	// of the options which were applied without restarting the Agent. It must not be modified.
+	GetAgentConfig() *agentconfig.AgentConfig
+}
+
 type ControllerNetworkPolicyInfoQuerier interface {
 	NetworkPolicyInfoQuerier
 	GetConnectedAgentNum() int
@@ -27,6 +27,7 @@ import (
 	"github.com/spf13/afero"
 	"k8s.io/utils/exec"
 
+	"antrea.io/antrea/pkg/agent/crashjournal"
 	agentquerier "antrea.io/antrea/pkg/agent/querier"
 	clusterinformationv1beta1 "antrea.io/antrea/pkg/apis/crd/v1beta1"
 	controllerquerier "antrea.io/antrea/pkg/controller/querier"
@@ -59,6 +60,9 @@ type AgentDumper interface {
 
 	// DumpOVSPorts should create file that contains OF port descriptions under the basedir.
 	DumpOVSPorts(basedir string) error
+	// DumpCrashJournal should create a file that contains the panics and fatal errors recorded
+	// by the previous runs of the agent under the basedir.
+	DumpCrashJournal(basedir string) error
 }
 
 // ControllerDumper is the interface for dumping runtime information of the
@@ -243,6 +247,18 @@ func (d *agentDumper) DumpOVSPorts(basedir string) error {
 	return writeFile(d.fs, filepath.Join(basedir, "ovsports"), "ports", []byte(strings.Join(portData, "\n")))
 }
 
+func (d *agentDumper) DumpCrashJournal(basedir string) error {
+	data, err := afero.ReadFile(d.fs, crashjournal.DefaultPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			// The agent has never crashed.
+			return nil
+		}
+		return fmt.Errorf("error when reading crash journal: %w", err)
+	}
+	return writeFile(d.fs, filepath.Join(basedir, "crashjournal"), "crash journal", data)
+}
+
 func NewAgentDumper(fs afero.Fs, executor exec.Interface, ovsCtlClient ovsctl.OVSCtlClient, aq agentquerier.AgentQuerier, npq querier.AgentNetworkPolicyInfoQuerier) AgentDumper {
 	return &agentDumper{
 		fs:           fs,
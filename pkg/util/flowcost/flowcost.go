@@ -0,0 +1,103 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package flowcost estimates the number of OpenFlow flows installed by the antrea-agent to realize
+// a NetworkPolicy rule on a Node. It must be kept consistent with the way pkg/agent/openflow
+// generates the flows of a rule.
+package flowcost
+
+import (
+	"net"
+
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	crdv1alpha1 "antrea.io/antrea/pkg/apis/crd/v1alpha1"
+	"antrea.io/antrea/pkg/util/ip"
+	thirdpartynp "antrea.io/antrea/third_party/networkpolicy"
+)
+
+// Rule describes a NetworkPolicy rule as realized on a single Node.
+type Rule struct {
+	// IsK8sNetworkPolicy is true for the rules of K8s NetworkPolicies, which isolate the workloads
+	// they are applied to with additional drop flows.
+	IsK8sNetworkPolicy bool
+	// Action of the rule. nil is equivalent to Allow.
+	Action *crdv1alpha1.RuleAction
+	// AppliedToAddresses is the number of addresses of the workloads the rule is applied to on the
+	// Node: one OVS port per workload for ingress rules, one per IP for egress rules.
+	AppliedToAddresses int
+	// PeerAddresses is the number of addresses of the peers of the rule: the IPs of the peer
+	// workloads, and the CIDRs of the IPBlocks once the excepted CIDRs are removed.
+	PeerAddresses int
+	// ServiceMatches is the number of matches of the services of the rule, as returned by
+	// ServiceMatches. 0 means that the rule matches all ports.
+	ServiceMatches int
+	// IPFamilies is the number of IP families enabled on the Node.
+	IPFamilies int
+}
+
+// Flows returns the estimated number of flows of the rule. The conjunctive match flows shared with
+// other rules are counted for each rule, hence the estimation is an upper bound.
+func (r *Rule) Flows() int {
+	// One conjunctive match flow per address and per port match.
+	flows := r.AppliedToAddresses + r.PeerAddresses + r.ServiceMatches
+	action := crdv1alpha1.RuleActionAllow
+	if r.Action != nil {
+		action = *r.Action
+	}
+	switch action {
+	case crdv1alpha1.RuleActionDrop, crdv1alpha1.RuleActionReject:
+		// One action flow and one metric flow.
+		flows += 2
+	case crdv1alpha1.RuleActionPass:
+		// One action flow, the connections are not committed hence not counted.
+		flows += 1
+	default:
+		// One action flow and three metric flows per IP family.
+		flows += 4 * r.IPFamilies
+	}
+	if r.IsK8sNetworkPolicy {
+		// One drop flow per address of the workloads the rule is applied to.
+		flows += r.AppliedToAddresses
+	}
+	return flows
+}
+
+// ServiceMatches returns the number of matches of a service of a rule, which is the number of
+// bitwise matches covering its port range, for each IP family.
+func ServiceMatches(port *intstr.IntOrString, endPort *int32, ipFamilies int) int {
+	if port != nil && port.Type == intstr.Int && endPort != nil && *endPort > port.IntVal {
+		portRange := thirdpartynp.PortRange{Start: uint16(port.IntVal), End: uint16(*endPort)}
+		bitRanges, err := portRange.BitwiseMatch()
+		if err != nil {
+			return 0
+		}
+		return len(bitRanges) * ipFamilies
+	}
+	// A single port, a named port or all ports.
+	return ipFamilies
+}
+
+// IPBlockAddresses returns the number of CIDRs matched by the rules for an IPBlock, which is the
+// number of CIDRs left once the excepted CIDRs are removed from the CIDR of the IPBlock.
+func IPBlockAddresses(cidr *net.IPNet, except []*net.IPNet) int {
+	if len(except) == 0 {
+		return 1
+	}
+	diffCIDRs, err := ip.DiffFromCIDRs(cidr, except)
+	if err != nil {
+		return 0
+	}
+	return len(diffCIDRs)
+}
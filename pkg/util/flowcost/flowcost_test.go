@@ -0,0 +1,89 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flowcost
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	crdv1alpha1 "antrea.io/antrea/pkg/apis/crd/v1alpha1"
+)
+
+func TestRuleFlows(t *testing.T) {
+	allow := crdv1alpha1.RuleActionAllow
+	drop := crdv1alpha1.RuleActionDrop
+	pass := crdv1alpha1.RuleActionPass
+	tests := []struct {
+		name          string
+		rule          Rule
+		expectedFlows int
+	}{
+		{
+			name:          "K8s NetworkPolicy rule",
+			rule:          Rule{IsK8sNetworkPolicy: true, Action: &allow, AppliedToAddresses: 2, PeerAddresses: 3, ServiceMatches: 1, IPFamilies: 1},
+			expectedFlows: 2 + 3 + 1 + 4 + 2,
+		},
+		{
+			name:          "dual-stack allow rule",
+			rule:          Rule{AppliedToAddresses: 2, PeerAddresses: 3, IPFamilies: 2},
+			expectedFlows: 2 + 3 + 8,
+		},
+		{
+			name:          "drop rule",
+			rule:          Rule{Action: &drop, AppliedToAddresses: 1, PeerAddresses: 1, ServiceMatches: 2, IPFamilies: 2},
+			expectedFlows: 1 + 1 + 2 + 2,
+		},
+		{
+			name:          "pass rule",
+			rule:          Rule{Action: &pass, AppliedToAddresses: 1, PeerAddresses: 1, IPFamilies: 1},
+			expectedFlows: 1 + 1 + 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expectedFlows, tt.rule.Flows())
+		})
+	}
+}
+
+func TestServiceMatches(t *testing.T) {
+	port80 := intstr.FromInt(80)
+	namedPort := intstr.FromString("http")
+	endPort80 := int32(80)
+	endPort87 := int32(87)
+	endPort88 := int32(88)
+	assert.Equal(t, 1, ServiceMatches(nil, nil, 1))
+	assert.Equal(t, 2, ServiceMatches(&port80, nil, 2))
+	assert.Equal(t, 1, ServiceMatches(&namedPort, nil, 1))
+	assert.Equal(t, 1, ServiceMatches(&port80, &endPort80, 1))
+	// 80-87 is covered by a single bitwise match.
+	assert.Equal(t, 1, ServiceMatches(&port80, &endPort87, 1))
+	// 80-88 is covered by 80/0xfff8 and 88/0xffff.
+	assert.Equal(t, 4, ServiceMatches(&port80, &endPort88, 2))
+}
+
+func TestIPBlockAddresses(t *testing.T) {
+	_, cidr, _ := net.ParseCIDR("10.0.0.0/16")
+	_, except1, _ := net.ParseCIDR("10.0.0.0/24")
+	_, except2, _ := net.ParseCIDR("10.0.1.0/24")
+	assert.Equal(t, 1, IPBlockAddresses(cidr, nil))
+	// 10.0.0.0/16 minus 10.0.0.0/24 is 10.0.128.0/17, 10.0.64.0/18, ..., 10.0.1.0/24.
+	assert.Equal(t, 8, IPBlockAddresses(cidr, []*net.IPNet{except1}))
+	// 10.0.0.0/16 minus 10.0.0.0/23 is 10.0.128.0/17, ..., 10.0.2.0/23.
+	assert.Equal(t, 7, IPBlockAddresses(cidr, []*net.IPNet{except1, except2}))
+}
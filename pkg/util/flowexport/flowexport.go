@@ -20,6 +20,8 @@ import (
 	"strings"
 	"time"
 
+	ipfixregistry "github.com/vmware/go-ipfix/pkg/registry"
+
 	"antrea.io/antrea/pkg/flowaggregator"
 )
 
@@ -75,6 +77,23 @@ func ParseFlowIntervalString(intervalString string) (time.Duration, error) {
 	return flowInterval, nil
 }
 
+var flowTypes = map[string]uint8{
+	"IntraNode":    ipfixregistry.FlowTypeIntraNode,
+	"InterNode":    ipfixregistry.FlowTypeInterNode,
+	"ToExternal":   ipfixregistry.FlowTypeToExternal,
+	"FromExternal": ipfixregistry.FlowTypeFromExternal,
+}
+
+// ParseFlowType parses the name of a flow type used in the record filters of the flow exporter
+// into the value of the flowType information element.
+func ParseFlowType(flowType string) (uint8, error) {
+	value, ok := flowTypes[flowType]
+	if !ok {
+		return 0, fmt.Errorf("flow type %s is not supported", flowType)
+	}
+	return value, nil
+}
+
 // ParseTransportProtocol parses the transport protocol input for the flow aggregator
 func ParseTransportProtocol(trasnportProtocolInput flowaggregator.AggregatorTransportProtocol) (flowaggregator.AggregatorTransportProtocol, error) {
 	upperProtocolInput := flowaggregator.AggregatorTransportProtocol(strings.ToUpper(string(trasnportProtocolInput)))
@@ -20,6 +20,7 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	ipfixregistry "github.com/vmware/go-ipfix/pkg/registry"
 
 	"antrea.io/antrea/pkg/flowaggregator"
 )
@@ -130,6 +131,17 @@ func TestParseFlowIntervalString(t *testing.T) {
 	}
 }
 
+func TestParseFlowType(t *testing.T) {
+	flowType, err := ParseFlowType("InterNode")
+	assert.NoError(t, err)
+	assert.Equal(t, ipfixregistry.FlowTypeInterNode, flowType)
+	flowType, err = ParseFlowType("ToExternal")
+	assert.NoError(t, err)
+	assert.Equal(t, ipfixregistry.FlowTypeToExternal, flowType)
+	_, err = ParseFlowType("interNode")
+	assert.EqualError(t, err, "flow type interNode is not supported")
+}
+
 func TestParseTransportProtocol(t *testing.T) {
 	testcases := []struct {
 		// input
@@ -30,7 +30,7 @@ import (
 // CreateClients creates kube clients from the given config.
 func CreateClients(config componentbaseconfig.ClientConnectionConfiguration, kubeAPIServerOverride string) (
 	clientset.Interface, aggregatorclientset.Interface, crdclientset.Interface, apiextensionclientset.Interface, error) {
-	kubeConfig, err := createRestConfig(config, kubeAPIServerOverride)
+	kubeConfig, err := CreateRestConfig(config, kubeAPIServerOverride)
 	if err != nil {
 		return nil, nil, nil, nil, err
 	}
@@ -59,7 +59,7 @@ func CreateClients(config componentbaseconfig.ClientConnectionConfiguration, kub
 
 // CreateLegacyCRDClient creates legacyCRD client from the given config.
 func CreateLegacyCRDClient(config componentbaseconfig.ClientConnectionConfiguration, kubeAPIServerOverride string) (legacycrdclientset.Interface, error) {
-	kubeConfig, err := createRestConfig(config, kubeAPIServerOverride)
+	kubeConfig, err := CreateRestConfig(config, kubeAPIServerOverride)
 	if err != nil {
 		return nil, err
 	}
@@ -71,7 +71,9 @@ func CreateLegacyCRDClient(config componentbaseconfig.ClientConnectionConfigurat
 	return legacyCrdClient, nil
 }
 
-func createRestConfig(config componentbaseconfig.ClientConnectionConfiguration, kubeAPIServerOverride string) (*rest.Config, error) {
+// CreateRestConfig returns the config of the clients created by CreateClients, which can also be
+// used to authenticate to the API of the Antrea components with the same credentials.
+func CreateRestConfig(config componentbaseconfig.ClientConnectionConfiguration, kubeAPIServerOverride string) (*rest.Config, error) {
 	var kubeConfig *rest.Config
 	var err error
 
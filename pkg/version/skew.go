@@ -0,0 +1,76 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package version
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/blang/semver"
+)
+
+const (
+	// MaxMinorVersionSkew is the maximum number of minor versions by which the antrea-agent and the
+	// antrea-controller may differ. Larger skews are not tested and may lead to NetworkPolicies
+	// being enforced incorrectly.
+	MaxMinorVersionSkew = 1
+
+	// AgentVersionHeader is the header carrying the version of the antrea-agent in its requests to
+	// the antrea-controller.
+	AgentVersionHeader = "Antrea-Agent-Version"
+	// AgentNodeNameHeader is the header carrying the name of the Node of the antrea-agent in its
+	// requests to the antrea-controller.
+	AgentNodeNameHeader = "Antrea-Agent-Node-Name"
+	// ControllerVersionHeader is the header carrying the version of the antrea-controller in its
+	// responses to the antrea-agent.
+	ControllerVersionHeader = "Antrea-Controller-Version"
+)
+
+// ParseVersion parses a version as reported by an Antrea component, e.g. "v1.2.0" or
+// "v1.2.0-c5b5aa4.dirty". The pre-release and build information is ignored as it does not affect
+// compatibility, and a git SHA is not always a valid pre-release identifier.
+func ParseVersion(v string) (semver.Version, error) {
+	s := strings.TrimSpace(v)
+	if i := strings.IndexAny(s, "-+"); i >= 0 {
+		s = s[:i]
+	}
+	parsed, err := semver.ParseTolerant(s)
+	if err != nil {
+		return semver.Version{}, fmt.Errorf("invalid version %q: %v", v, err)
+	}
+	return parsed, nil
+}
+
+// IsSupportedSkew returns whether the version skew between the antrea-controller and an
+// antrea-agent is supported, i.e. whether they have the same major version and their minor versions
+// differ by at most MaxMinorVersionSkew. An error is returned if a version cannot be parsed.
+func IsSupportedSkew(controllerVersion, agentVersion string) (bool, error) {
+	cv, err := ParseVersion(controllerVersion)
+	if err != nil {
+		return false, err
+	}
+	av, err := ParseVersion(agentVersion)
+	if err != nil {
+		return false, err
+	}
+	if cv.Major != av.Major {
+		return false, nil
+	}
+	skew := int64(cv.Minor) - int64(av.Minor)
+	if skew < 0 {
+		skew = -skew
+	}
+	return skew <= MaxMinorVersionSkew, nil
+}
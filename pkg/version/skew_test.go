@@ -0,0 +1,131 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package version
+
+import (
+	"testing"
+
+	"github.com/blang/semver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		version         string
+		expectedVersion semver.Version
+		expectedErr     bool
+	}{
+		{version: "v1.2.0", expectedVersion: semver.Version{Major: 1, Minor: 2}},
+		{version: "1.2.3", expectedVersion: semver.Version{Major: 1, Minor: 2, Patch: 3}},
+		{version: " v1.2.0\n", expectedVersion: semver.Version{Major: 1, Minor: 2}},
+		{version: "v1.2", expectedVersion: semver.Version{Major: 1, Minor: 2}},
+		{version: "v1.2.0-c5b5aa4.dirty", expectedVersion: semver.Version{Major: 1, Minor: 2}},
+		// A git SHA made of digits with a leading zero is not a valid pre-release identifier.
+		{version: "v1.2.0-0123456", expectedVersion: semver.Version{Major: 1, Minor: 2}},
+		{version: "v1.2.0-unknown", expectedVersion: semver.Version{Major: 1, Minor: 2}},
+		{version: "v1.2.0+build.1", expectedVersion: semver.Version{Major: 1, Minor: 2}},
+		{version: "", expectedErr: true},
+		{version: "UNKNOWN", expectedErr: true},
+		{version: "v1.x.0", expectedErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			v, err := ParseVersion(tt.version)
+			if tt.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedVersion, v)
+		})
+	}
+}
+
+func TestIsSupportedSkew(t *testing.T) {
+	tests := []struct {
+		name              string
+		controllerVersion string
+		agentVersion      string
+		expectedSupported bool
+		expectedErr       bool
+	}{
+		{
+			name:              "same version",
+			controllerVersion: "v1.2.0",
+			agentVersion:      "v1.2.0",
+			expectedSupported: true,
+		},
+		{
+			name:              "different patch versions",
+			controllerVersion: "v1.2.3",
+			agentVersion:      "v1.2.0-c5b5aa4.dirty",
+			expectedSupported: true,
+		},
+		{
+			name:              "agent one minor version newer",
+			controllerVersion: "v1.2.0",
+			agentVersion:      "v1.3.0",
+			expectedSupported: true,
+		},
+		{
+			name:              "agent one minor version older",
+			controllerVersion: "v1.2.0",
+			agentVersion:      "v1.1.5",
+			expectedSupported: true,
+		},
+		{
+			name:              "agent two minor versions newer",
+			controllerVersion: "v1.2.0",
+			agentVersion:      "v1.4.0",
+			expectedSupported: false,
+		},
+		{
+			name:              "agent two minor versions older",
+			controllerVersion: "v1.2.0-unknown",
+			agentVersion:      "v1.0.0",
+			expectedSupported: false,
+		},
+		{
+			name:              "different major versions",
+			controllerVersion: "v2.0.0",
+			agentVersion:      "v1.9.0",
+			expectedSupported: false,
+		},
+		{
+			name:              "unknown agent version",
+			controllerVersion: "v1.2.0",
+			agentVersion:      "UNKNOWN",
+			expectedErr:       true,
+		},
+		{
+			name:              "empty controller version",
+			controllerVersion: "",
+			agentVersion:      "v1.2.0",
+			expectedErr:       true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			supported, err := IsSupportedSkew(tt.controllerVersion, tt.agentVersion)
+			if tt.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedSupported, supported)
+		})
+	}
+}
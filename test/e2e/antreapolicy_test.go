@@ -507,6 +507,23 @@ func testInvalidACNPIngressPeerNamespacesSetWithNSSelector(t *testing.T) {
 	}
 }
 
+func testInvalidACNPIngressPeerNamespacesSetWithIPBlock(t *testing.T) {
+	invalidNpErr := fmt.Errorf("invalid Antrea ClusterNetworkPolicy with namespaces and ipBlock in NetworkPolicyPeer set")
+	cidr := "10.0.0.10/32"
+	builder := &ClusterNetworkPolicySpecBuilder{}
+	builder = builder.SetName("acnp-ingress-namespaces-ipblock-set").
+		SetPriority(1.0).
+		SetAppliedToGroup([]ACNPAppliedToSpec{{PodSelector: map[string]string{"pod": "b"}}})
+	builder = builder.AddIngress(v1.ProtocolTCP, &p80, nil, nil, &cidr, nil, nil,
+		nil, nil, true, nil, crdv1alpha1.RuleActionAllow, "", "")
+	acnp := builder.Get()
+	log.Debugf("creating ACNP %v", acnp.Name)
+	if _, err := k8sUtils.CreateOrUpdateACNP(acnp); err == nil {
+		// Above creation of ACNP must fail as it is an invalid spec.
+		failOnError(invalidNpErr, t)
+	}
+}
+
 func testInvalidANPNoPriority(t *testing.T) {
 	invalidNpErr := fmt.Errorf("invalid Antrea NetworkPolicy without a priority accepted")
 	builder := &AntreaNetworkPolicySpecBuilder{}
@@ -1580,6 +1597,81 @@ func testACNPTierOverride(t *testing.T) {
 	executeTests(t, testCase)
 }
 
+// testACNPPassOverridesDrop tests that a Pass rule defers the traffic it matches to K8s
+// NetworkPolicies, skipping a broad Drop rule in a lower priority Tier.
+func testACNPPassOverridesDrop(t *testing.T) {
+	builder1 := &ClusterNetworkPolicySpecBuilder{}
+	builder1 = builder1.SetName("acnp-tier-securityops-pass").
+		SetTier("securityops").
+		SetPriority(10).
+		SetAppliedToGroup([]ACNPAppliedToSpec{{NSSelector: map[string]string{"ns": "x"}}})
+	// Passes traffic from z to x to K8s NetworkPolicies.
+	builder1.AddIngress(v1.ProtocolTCP, &p80, nil, nil, nil, nil, map[string]string{"ns": "z"},
+		nil, nil, false, nil, crdv1alpha1.RuleActionPass, "", "")
+
+	builder2 := &ClusterNetworkPolicySpecBuilder{}
+	builder2 = builder2.SetName("acnp-tier-application-drop").
+		SetTier("application").
+		SetPriority(1).
+		SetAppliedToGroup([]ACNPAppliedToSpec{{NSSelector: map[string]string{"ns": "x"}}})
+	// Drops traffic from all Namespaces to x.
+	builder2.AddIngress(v1.ProtocolTCP, &p80, nil, nil, nil, nil, map[string]string{},
+		nil, nil, false, nil, crdv1alpha1.RuleActionDrop, "", "")
+
+	builder3 := &NetworkPolicySpecBuilder{}
+	builder3 = builder3.SetName("x", "np-allow-from-zb").
+		SetPodSelector(map[string]string{}).
+		SetTypeIngress()
+	// Isolates the Pods of x and allows traffic from z/b only.
+	builder3.AddIngress(v1.ProtocolTCP, &p80, nil, nil, nil, map[string]string{"pod": "b"}, map[string]string{"ns": "z"}, nil, nil)
+
+	reachabilityACNPs := NewReachability(allPods, Connected)
+	for _, pod := range []Pod{"x/a", "x/b", "x/c"} {
+		reachabilityACNPs.ExpectIngressFromNamespace(pod, "x", Dropped)
+		reachabilityACNPs.ExpectIngressFromNamespace(pod, "y", Dropped)
+	}
+	reachabilityACNPs.ExpectSelf(allPods, Connected)
+
+	reachabilityWithK8sNP := NewReachability(allPods, Connected)
+	for _, pod := range []Pod{"x/a", "x/b", "x/c"} {
+		reachabilityWithK8sNP.ExpectIngressFromNamespace(pod, "x", Dropped)
+		reachabilityWithK8sNP.ExpectIngressFromNamespace(pod, "y", Dropped)
+		reachabilityWithK8sNP.Expect(Pod("z/a"), pod, Dropped)
+		reachabilityWithK8sNP.Expect(Pod("z/c"), pod, Dropped)
+	}
+	reachabilityWithK8sNP.ExpectSelf(allPods, Connected)
+
+	testStepACNPs := []*TestStep{
+		{
+			"Pass rule overriding a Drop rule in a lower Tier",
+			reachabilityACNPs,
+			[]metav1.Object{builder2.Get(), builder1.Get()},
+			nil,
+			[]int32{80},
+			v1.ProtocolTCP,
+			0,
+			nil,
+		},
+	}
+	testStepWithK8sNP := []*TestStep{
+		{
+			"Pass rule deferring to a K8s NetworkPolicy",
+			reachabilityWithK8sNP,
+			[]metav1.Object{builder2.Get(), builder1.Get(), builder3.Get()},
+			nil,
+			[]int32{80},
+			v1.ProtocolTCP,
+			0,
+			nil,
+		},
+	}
+	testCase := []*TestCase{
+		{"ACNP Pass overrides Drop", testStepACNPs},
+		{"ACNP Pass defers to K8s NetworkPolicy", testStepWithK8sNP},
+	}
+	executeTests(t, testCase)
+}
+
 // testACNPTierOverride tests tier priority overriding in three Policies with custom created tiers.
 // Each ACNP controls a smaller set of traffic patterns as tier priority increases.
 func testACNPCustomTiers(t *testing.T) {
@@ -2648,6 +2740,7 @@ func TestAntreaPolicy(t *testing.T) {
 		t.Run("Case=ACNPIngressPeerCGSetWithPodSelector", func(t *testing.T) { testInvalidACNPIngressPeerCGSetWithPodSelector(t) })
 		t.Run("Case=ACNPIngressPeerCGSetWithNSSelector", func(t *testing.T) { testInvalidACNPIngressPeerCGSetWithNSSelector(t) })
 		t.Run("Case=ACNPIngressPeerNamespaceSetWithNSSelector", func(t *testing.T) { testInvalidACNPIngressPeerNamespacesSetWithNSSelector(t) })
+		t.Run("Case=ACNPIngressPeerNamespaceSetWithIPBlock", func(t *testing.T) { testInvalidACNPIngressPeerNamespacesSetWithIPBlock(t) })
 		t.Run("Case=ACNPCGDoesNotExist", func(t *testing.T) { testInvalidACNPCGDoesNotExist(t) })
 		t.Run("Case=ACNPAppliedToCGDoesNotExist", func(t *testing.T) { testInvalidACNPAppliedToCGDoesNotExist(t) })
 		t.Run("Case=ACNPSpecAppliedToRuleAppliedToSet", func(t *testing.T) { testInvalidACNPSpecAppliedToRuleAppliedToSet(t) })
@@ -2702,6 +2795,7 @@ func TestAntreaPolicy(t *testing.T) {
 		t.Run("Case=ACNPPriorityOverride", func(t *testing.T) { testACNPPriorityOverride(t) })
 		t.Run("Case=ACNPTierOverride", func(t *testing.T) { testACNPTierOverride(t) })
 		t.Run("Case=ACNPCustomTiers", func(t *testing.T) { testACNPCustomTiers(t) })
+		t.Run("Case=ACNPPassOverridesDrop", func(t *testing.T) { testACNPPassOverridesDrop(t) })
 		t.Run("Case=ACNPPriorityConflictingRule", func(t *testing.T) { testACNPPriorityConflictingRule(t) })
 		t.Run("Case=ACNPRulePriority", func(t *testing.T) { testACNPRulePriority(t) })
 		t.Run("Case=ANPPortRange", func(t *testing.T) { testANPPortRange(t) })
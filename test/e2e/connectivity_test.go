@@ -18,6 +18,7 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -296,6 +297,51 @@ func TestPodConnectivityAfterAntreaRestart(t *testing.T) {
 	data.runPingMesh(t, podInfos[:numPods], agnhostContainerName)
 }
 
+// TestPodConnectivityAfterTrafficEncapModeChange checks that changing the traffic encap mode and
+// restarting antrea-agent does not create connectivity issues between Pods, i.e. that the agent
+// migrates the Node network configuration from the previous mode without the Nodes being recreated.
+// It requires all Nodes to be in the same subnet, so that noEncap mode can be used.
+func TestPodConnectivityAfterTrafficEncapModeChange(t *testing.T) {
+	skipIfNumNodesLessThan(t, 2)
+	skipIfHasWindowsNodes(t)
+
+	data, err := setupTest(t)
+	if err != nil {
+		t.Fatalf("Error when setting up test: %v", err)
+	}
+	defer teardownTest(t, data)
+	skipIfEncapModeIsNot(t, data, config.TrafficEncapModeEncap)
+
+	numPods := 2 // can be increased
+	podInfos, deletePods := createPodsOnDifferentNodes(t, data)
+	defer deletePods()
+
+	data.runPingMesh(t, podInfos[:numPods], agnhostContainerName)
+
+	currentMode := config.TrafficEncapModeEncap
+	setEncapMode := func(mode config.TrafficEncapModeType) {
+		t.Logf("Changing traffic encap mode to %s", mode)
+		ac := []configChange{
+			{"trafficEncapMode", mode.String(), false},
+		}
+		if err := data.mutateAntreaConfigMap(nil, ac, false, true); err != nil {
+			t.Fatalf("Failed to change traffic encap mode to %s: %v", mode, err)
+		}
+		currentMode = mode
+	}
+	defer func() {
+		if currentMode != config.TrafficEncapModeEncap {
+			setEncapMode(config.TrafficEncapModeEncap)
+		}
+	}()
+
+	setEncapMode(config.TrafficEncapModeNoEncap)
+	data.runPingMesh(t, podInfos[:numPods], agnhostContainerName)
+
+	setEncapMode(config.TrafficEncapModeEncap)
+	data.runPingMesh(t, podInfos[:numPods], agnhostContainerName)
+}
+
 // TestOVSRestartSameNode verifies that datapath flows are not removed when the Antrea Agent Pod is
 // stopped gracefully (e.g. as part of a RollingUpdate). The test sends ARP requests every 1s and
 // checks that there is no packet loss during the restart. This test does not apply to the userspace
@@ -477,3 +523,172 @@ func TestPingLargeMTU(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+// TestTCPMSSClamping verifies that a large payload can be sent from a Pod to the external network
+// through a path whose MTU is lower than the Pod MTU, while the ICMP "Fragmentation Needed" messages
+// are dropped, i.e. when Path MTU Discovery cannot work. The path is emulated by a route with a
+// reduced MTU on the client Node, towards the IP of another Node running a hostNetwork server.
+func TestTCPMSSClamping(t *testing.T) {
+	skipIfNumNodesLessThan(t, 2)
+	skipIfHasWindowsNodes(t)
+	skipIfNotIPv4Cluster(t)
+
+	data, err := setupTest(t)
+	if err != nil {
+		t.Fatalf("Error when setting up test: %v", err)
+	}
+	defer teardownTest(t, data)
+
+	const (
+		pathMTU    = 1000
+		mss        = 900
+		serverPort = 8765
+	)
+	clientNode, serverNode := nodeName(0), nodeName(1)
+	serverIP := nodeIP(1)
+
+	t.Logf("Enabling TCP MSS clamping with MSS %d", mss)
+	ac := []configChange{
+		{"tcpMSSClamping", "true", false},
+		{"tcpMSS", fmt.Sprintf("%d", mss), false},
+	}
+	if err := data.mutateAntreaConfigMap(nil, ac, false, true); err != nil {
+		t.Fatalf("Failed to enable TCP MSS clamping: %v", err)
+	}
+	defer func() {
+		ac := []configChange{
+			{"tcpMSSClamping", "false", false},
+			{"tcpMSS", "0", false},
+		}
+		if err := data.mutateAntreaConfigMap(nil, ac, false, true); err != nil {
+			t.Errorf("Failed to disable TCP MSS clamping: %v", err)
+		}
+	}()
+
+	serverPodName := randName("tcp-mss-server-")
+	serverCmd := fmt.Sprintf("while true; do nc -l -p %d > /dev/null; done", serverPort)
+	if err := data.createPodOnNode(serverPodName, serverNode, busyboxImage, []string{"sh", "-c", serverCmd}, nil, nil, nil, true, nil); err != nil {
+		t.Fatalf("Error when creating server Pod: %v", err)
+	}
+	defer deletePodWrapper(t, data, serverPodName)
+	clientPodName := randName("tcp-mss-client-")
+	if err := data.createBusyboxPodOnNode(clientPodName, clientNode); err != nil {
+		t.Fatalf("Error when creating client Pod: %v", err)
+	}
+	defer deletePodWrapper(t, data, clientPodName)
+	for _, podName := range []string{serverPodName, clientPodName} {
+		if err := data.podWaitForRunning(defaultTimeout, podName, testNamespace); err != nil {
+			t.Fatalf("Error when waiting for Pod '%s' to be running: %v", podName, err)
+		}
+	}
+
+	// Reduce the MTU of the path to the server, and block Path MTU Discovery on the client Node.
+	setupCmds := []string{
+		fmt.Sprintf("ip route replace $(ip route get %[1]s | head -n 1 | sed 's/ uid.*//') mtu lock %[2]d", serverIP, pathMTU),
+		"iptables -I OUTPUT -p icmp --icmp-type fragmentation-needed -j DROP",
+	}
+	cleanupCmds := []string{
+		fmt.Sprintf("ip route del %s/32", serverIP),
+		"iptables -D OUTPUT -p icmp --icmp-type fragmentation-needed -j DROP",
+	}
+	defer func() {
+		for _, cmd := range cleanupCmds {
+			if rc, _, stderr, err := RunCommandOnNode(clientNode, cmd); err != nil || rc != 0 {
+				t.Errorf("Error when running '%s' on Node '%s': rc=%d, stderr=%s, err=%v", cmd, clientNode, rc, stderr, err)
+			}
+		}
+	}()
+	for _, cmd := range setupCmds {
+		if rc, _, stderr, err := RunCommandOnNode(clientNode, cmd); err != nil || rc != 0 {
+			t.Fatalf("Error when running '%s' on Node '%s': rc=%d, stderr=%s, err=%v", cmd, clientNode, rc, stderr, err)
+		}
+	}
+
+	t.Logf("Sending a large payload from Pod '%s' to %s:%d", clientPodName, serverIP, serverPort)
+	cmd := []string{"sh", "-c", fmt.Sprintf("head -c 1000000 /dev/zero | timeout 30 nc -w 10 %s %d", serverIP, serverPort)}
+	if stdout, stderr, err := data.runCommandFromPod(testNamespace, clientPodName, busyboxContainerName, cmd); err != nil {
+		t.Errorf("Error when sending a large payload to %s:%d: %v, stdout: %s, stderr: %s", serverIP, serverPort, err, stdout, stderr)
+	}
+}
+
+// TestTunnelPortAndDSCP checks that the tunnel traffic between Pods is sent to the configured tunnel
+// port, with the configured DSCP value in the outer IP header. The tunnel packets are counted by an
+// iptables rule on each Node, matching both the port and the DSCP value.
+func TestTunnelPortAndDSCP(t *testing.T) {
+	skipIfNumNodesLessThan(t, 2)
+	skipIfHasWindowsNodes(t)
+	skipIfNotIPv4Cluster(t)
+
+	data, err := setupTest(t)
+	if err != nil {
+		t.Fatalf("Error when setting up test: %v", err)
+	}
+	defer teardownTest(t, data)
+	skipIfEncapModeIsNot(t, data, config.TrafficEncapModeEncap)
+	configMap, err := data.GetAntreaConfigMap(antreaNamespace)
+	if err != nil {
+		t.Fatalf("Failed to get Antrea ConfigMap: %v", err)
+	}
+	for _, line := range strings.Split(configMap.Data["antrea-agent.conf"], "\n") {
+		if strings.HasPrefix(line, "tunnelType:") && strings.TrimSpace(strings.TrimPrefix(line, "tunnelType:")) != "geneve" &&
+			strings.TrimSpace(strings.TrimPrefix(line, "tunnelType:")) != "vxlan" {
+			t.Skipf("Skipping test as it requires a UDP tunnel, got '%s'", line)
+		}
+	}
+
+	const (
+		tunnelPort = 6082
+		tunnelDSCP = 46
+	)
+	t.Logf("Setting the tunnel port to %d and the tunnel DSCP to %d", tunnelPort, tunnelDSCP)
+	ac := []configChange{
+		{"tunnelPort", fmt.Sprintf("%d", tunnelPort), false},
+		{"tunnelDSCP", fmt.Sprintf(`"%d"`, tunnelDSCP), false},
+	}
+	if err := data.mutateAntreaConfigMap(nil, ac, false, true); err != nil {
+		t.Fatalf("Failed to set the tunnel options: %v", err)
+	}
+	defer func() {
+		ac := []configChange{
+			{"tunnelPort", "0", false},
+			{"tunnelDSCP", `""`, false},
+		}
+		if err := data.mutateAntreaConfigMap(nil, ac, false, true); err != nil {
+			t.Errorf("Failed to reset the tunnel options: %v", err)
+		}
+	}()
+
+	rule := fmt.Sprintf("INPUT -p udp --dport %d -m dscp --dscp %d -j ACCEPT", tunnelPort, tunnelDSCP)
+	for idx := 0; idx < clusterInfo.numNodes; idx++ {
+		node := nodeName(idx)
+		if rc, _, stderr, err := RunCommandOnNode(node, "iptables -I "+rule); err != nil || rc != 0 {
+			t.Fatalf("Error when adding iptables rule on Node '%s': rc=%d, stderr=%s, err=%v", node, rc, stderr, err)
+		}
+		defer func() {
+			if rc, _, stderr, err := RunCommandOnNode(node, "iptables -D "+rule); err != nil || rc != 0 {
+				t.Errorf("Error when deleting iptables rule on Node '%s': rc=%d, stderr=%s, err=%v", node, rc, stderr, err)
+			}
+		}()
+	}
+
+	podInfos, deletePods := createPodsOnDifferentNodes(t, data)
+	defer deletePods()
+	data.runPingMesh(t, podInfos, agnhostContainerName)
+
+	// The first column of the rule is the number of packets it matched.
+	var tunnelPackets int
+	for idx := 0; idx < clusterInfo.numNodes; idx++ {
+		node := nodeName(idx)
+		cmd := fmt.Sprintf("iptables -nvx -L INPUT | grep 'udp dpt:%d' | grep 'DSCP match 0x%02x'", tunnelPort, tunnelDSCP)
+		rc, stdout, stderr, err := RunCommandOnNode(node, cmd)
+		if err != nil || rc != 0 {
+			t.Fatalf("Error when getting iptables counters on Node '%s': rc=%d, stderr=%s, err=%v", node, rc, stderr, err)
+		}
+		packets, err := strconv.Atoi(strings.Fields(stdout)[0])
+		if err != nil {
+			t.Fatalf("Failed to parse iptables counters on Node '%s': %s", node, stdout)
+		}
+		tunnelPackets += packets
+	}
+	assert.Greater(t, tunnelPackets, 0, "No tunnel packet with destination port %d and DSCP %d was received", tunnelPort, tunnelDSCP)
+}
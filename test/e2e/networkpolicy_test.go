@@ -30,6 +30,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/wait"
 
 	"antrea.io/antrea/pkg/agent/apiserver/handlers/agentinfo"
+	"antrea.io/antrea/pkg/agent/controller/networkpolicy"
 	"antrea.io/antrea/pkg/apis/crd/v1beta1"
 	"antrea.io/antrea/pkg/apis/stats/v1alpha1"
 	"antrea.io/antrea/pkg/features"
@@ -658,20 +659,9 @@ func TestNetworkPolicyResyncAfterRestart(t *testing.T) {
 		preCheckFunc(server0IPs.ipv6.String(), server1IPs.ipv6.String())
 	}
 
-	scaleFunc := func(replicas int32) {
-		scale, err := data.clientset.AppsV1().Deployments(antreaNamespace).GetScale(context.TODO(), antreaDeployment, metav1.GetOptions{})
-		if err != nil {
-			t.Fatalf("error when getting scale of Antrea Deployment: %v", err)
-		}
-		scale.Spec.Replicas = replicas
-		if _, err := data.clientset.AppsV1().Deployments(antreaNamespace).UpdateScale(context.TODO(), antreaDeployment, scale, metav1.UpdateOptions{}); err != nil {
-			t.Fatalf("error when scaling Antrea Deployment to %d: %v", replicas, err)
-		}
-	}
-
 	// Scale antrea-controller to 0 so antrea-agent will lose connection with antrea-controller.
-	scaleFunc(0)
-	defer scaleFunc(1)
+	scaleAntreaController(t, data, 0)
+	defer scaleAntreaController(t, data, 1)
 	// Make sure antrea-agent disconnects from antrea-controller.
 	waitForAgentCondition(t, data, antreaPod, v1beta1.ControllerConnectionUp, corev1.ConditionFalse)
 
@@ -695,7 +685,7 @@ func TestNetworkPolicyResyncAfterRestart(t *testing.T) {
 	}()
 
 	// Scale antrea-controller to 1 so antrea-agent will connect to antrea-controller.
-	scaleFunc(1)
+	scaleAntreaController(t, data, 1)
 	// Make sure antrea-agent connects to antrea-controller.
 	waitForAgentCondition(t, data, antreaPod, v1beta1.ControllerConnectionUp, corev1.ConditionTrue)
 
@@ -716,6 +706,181 @@ func TestNetworkPolicyResyncAfterRestart(t *testing.T) {
 	}
 }
 
+// TestNetworkPolicyBootstrapMode verifies that, when antrea-agent starts without being able to connect to
+// antrea-controller, new connections of the Pods on its Node are allowed in the failOpen policy bootstrap mode and
+// dropped in the failClosed mode, until antrea-agent connects to antrea-controller.
+func TestNetworkPolicyBootstrapMode(t *testing.T) {
+	skipIfHasWindowsNodes(t)
+
+	data, err := setupTest(t)
+	if err != nil {
+		t.Fatalf("Error when setting up test: %v", err)
+	}
+	defer teardownTest(t, data)
+
+	setBootstrapMode := func(mode string) {
+		ac := []configChange{
+			{"policyBootstrapMode", mode, false},
+		}
+		if err := data.mutateAntreaConfigMap(nil, ac, false, true); err != nil {
+			t.Fatalf("Failed to change policy bootstrap mode to %s: %v", mode, err)
+		}
+	}
+	defer setBootstrapMode("failOpen")
+
+	workerNode := workerNodeName(1)
+	serverName, serverIPs, cleanupFunc := createAndWaitForPod(t, data, data.createNginxPodOnNode, "test-server-", workerNode)
+	defer cleanupFunc()
+	clientName, _, cleanupFunc := createAndWaitForPod(t, data, data.createBusyboxPodOnNode, "test-client-", workerNode)
+	defer cleanupFunc()
+	var serverIPStrs []string
+	if clusterInfo.podV4NetworkCIDR != "" {
+		serverIPStrs = append(serverIPStrs, serverIPs.ipv4.String())
+	}
+	if clusterInfo.podV6NetworkCIDR != "" {
+		serverIPStrs = append(serverIPStrs, serverIPs.ipv6.String())
+	}
+	checkConnectivity := func(expectConnected bool) {
+		for _, serverIP := range serverIPStrs {
+			// Poll as antrea-agent may still be installing its flows.
+			if err := wait.Poll(defaultInterval, defaultTimeout, func() (bool, error) {
+				err := data.runNetcatCommandFromTestPod(clientName, serverIP, 80)
+				return (err == nil) == expectConnected, nil
+			}); err != nil {
+				t.Fatalf("Pod %s should be able to connect %s (%s): %t, but got the opposite result", clientName, serverName, serverIP, expectConnected)
+			}
+		}
+	}
+
+	for _, tc := range []struct {
+		mode string
+		// Whether the Pods can connect to each other while antrea-controller is unreachable.
+		expectConnected bool
+	}{
+		{mode: "failOpen", expectConnected: true},
+		{mode: "failClosed", expectConnected: false},
+	} {
+		t.Run(tc.mode, func(t *testing.T) {
+			setBootstrapMode(tc.mode)
+			checkConnectivity(true)
+
+			// Scale antrea-controller to 0 and restart antrea-agent, so that antrea-agent starts without being
+			// able to connect to antrea-controller.
+			scaleAntreaController(t, data, 0)
+			defer scaleAntreaController(t, data, 1)
+			if _, err := data.deleteAntreaAgentOnNode(workerNode, 30, defaultTimeout); err != nil {
+				t.Fatalf("Error when restarting antrea-agent on Node %s: %v", workerNode, err)
+			}
+			antreaPod, err := data.getAntreaPodOnNode(workerNode)
+			if err != nil {
+				t.Fatalf("Error when getting antrea-agent pod name: %v", err)
+			}
+			waitForAgentCondition(t, data, antreaPod, v1beta1.ControllerConnectionUp, corev1.ConditionFalse)
+			checkConnectivity(tc.expectConnected)
+
+			// The NetworkPolicies are enforced once antrea-agent connects to antrea-controller. As no
+			// NetworkPolicy applies to the Pods, they can connect to each other in both modes.
+			scaleAntreaController(t, data, 1)
+			waitForAgentCondition(t, data, antreaPod, v1beta1.ControllerConnectionUp, corev1.ConditionTrue)
+			checkConnectivity(true)
+		})
+	}
+}
+
+// TestNetworkPolicyReadinessGate verifies that a Pod declaring the antrea.io/network-policy-realized readiness gate
+// stays NotReady while a NetworkPolicy applied to it fails to be realized, and becomes Ready once it is realized.
+func TestNetworkPolicyReadinessGate(t *testing.T) {
+	skipIfHasWindowsNodes(t)
+
+	data, err := setupTest(t)
+	if err != nil {
+		t.Fatalf("Error when setting up test: %v", err)
+	}
+	defer teardownTest(t, data)
+
+	workerNode := workerNodeName(1)
+	antreaPod, err := data.getAntreaPodOnNode(workerNode)
+	if err != nil {
+		t.Fatalf("Error when getting antrea-agent pod name: %v", err)
+	}
+	// Inject an error in the installation of the flows of the NetworkPolicy: OVS refuses to add flows to the
+	// IngressRule table (90), where the flows of the K8s NetworkPolicy ingress rules are installed, as soon as it
+	// holds a flow.
+	limitTableCmd := []string{"ovs-vsctl", "--", "--id=@ft", "create", "Flow_Table", "flow_limit=1", "overflow_policy=refuse",
+		"--", "set", "Bridge", defaultBridgeName, "flow_tables:90=@ft"}
+	unlimitTableCmd := []string{"ovs-vsctl", "clear", "Bridge", defaultBridgeName, "flow_tables"}
+	if _, stderr, err := data.runCommandFromPod(antreaNamespace, antreaPod, ovsContainerName, limitTableCmd); err != nil {
+		t.Fatalf("Error when limiting the number of flows of the IngressRule table: %v, stderr: %s", err, stderr)
+	}
+	defer data.runCommandFromPod(antreaNamespace, antreaPod, ovsContainerName, unlimitTableCmd)
+
+	clientName, _, cleanupFunc := createAndWaitForPod(t, data, data.createBusyboxPodOnNode, "test-client-", workerNode)
+	defer cleanupFunc()
+	createServerPod := func(name string, nodeName string) error {
+		return data.createPodOnNode(name, nodeName, nginxImage, []string{}, nil, nil, nil, false, func(pod *corev1.Pod) {
+			pod.Spec.ReadinessGates = []corev1.PodReadinessGate{{ConditionType: networkpolicy.PodReadinessGatePolicyRealized}}
+		})
+	}
+	serverName, _, cleanupFunc := createAndWaitForPod(t, data, createServerPod, "test-server-", workerNode)
+	defer cleanupFunc()
+
+	netpol, err := data.createNetworkPolicy("test-readiness-gate", &networkingv1.NetworkPolicySpec{
+		PodSelector: metav1.LabelSelector{
+			MatchLabels: map[string]string{
+				"antrea-e2e": serverName,
+			},
+		},
+		Ingress: []networkingv1.NetworkPolicyIngressRule{{
+			From: []networkingv1.NetworkPolicyPeer{{
+				PodSelector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{
+						"antrea-e2e": clientName,
+					},
+				},
+			}},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("Error when creating network policy: %v", err)
+	}
+	defer func() {
+		if err = data.deleteNetworkpolicy(netpol); err != nil {
+			t.Fatalf("Error when deleting network policy: %v", err)
+		}
+	}()
+
+	getCondition := func(pod *corev1.Pod, conditionType corev1.PodConditionType) corev1.ConditionStatus {
+		for _, condition := range pod.Status.Conditions {
+			if condition.Type == conditionType {
+				return condition.Status
+			}
+		}
+		return corev1.ConditionUnknown
+	}
+	if _, err := data.podWaitFor(defaultTimeout, serverName, testNamespace, func(pod *corev1.Pod) (bool, error) {
+		return getCondition(pod, networkpolicy.PodReadinessGatePolicyRealized) == corev1.ConditionFalse, nil
+	}); err != nil {
+		t.Fatalf("Error when waiting for the readiness gate of Pod %s to be False: %v", serverName, err)
+	}
+	// The Pod must stay NotReady while the NetworkPolicy fails to be realized.
+	if _, err := data.podWaitFor(10*time.Second, serverName, testNamespace, func(pod *corev1.Pod) (bool, error) {
+		return getCondition(pod, corev1.PodReady) == corev1.ConditionTrue, nil
+	}); err != wait.ErrWaitTimeout {
+		t.Fatalf("Pod %s should stay NotReady while the NetworkPolicy fails to be realized, err: %v", serverName, err)
+	}
+
+	// The NetworkPolicy is realized when antrea-agent retries it after the error stops.
+	if _, stderr, err := data.runCommandFromPod(antreaNamespace, antreaPod, ovsContainerName, unlimitTableCmd); err != nil {
+		t.Fatalf("Error when removing the limit of the number of flows of the IngressRule table: %v, stderr: %s", err, stderr)
+	}
+	if _, err := data.podWaitFor(defaultTimeout, serverName, testNamespace, func(pod *corev1.Pod) (bool, error) {
+		return getCondition(pod, networkpolicy.PodReadinessGatePolicyRealized) == corev1.ConditionTrue &&
+			getCondition(pod, corev1.PodReady) == corev1.ConditionTrue, nil
+	}); err != nil {
+		t.Fatalf("Error when waiting for Pod %s to be Ready: %v", serverName, err)
+	}
+}
+
 func TestIngressPolicyWithoutPortNumber(t *testing.T) {
 	skipIfHasWindowsNodes(t)
 
@@ -997,6 +1162,17 @@ func createAndWaitForPodWithLabels(t *testing.T, data *TestData, createFunc func
 	return name, podIP, cleanupFunc
 }
 
+func scaleAntreaController(t *testing.T, data *TestData, replicas int32) {
+	scale, err := data.clientset.AppsV1().Deployments(antreaNamespace).GetScale(context.TODO(), antreaDeployment, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("error when getting scale of Antrea Deployment: %v", err)
+	}
+	scale.Spec.Replicas = replicas
+	if _, err := data.clientset.AppsV1().Deployments(antreaNamespace).UpdateScale(context.TODO(), antreaDeployment, scale, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("error when scaling Antrea Deployment to %d: %v", replicas, err)
+	}
+}
+
 func waitForAgentCondition(t *testing.T, data *TestData, podName string, conditionType v1beta1.AgentConditionType, expectedStatus corev1.ConditionStatus) {
 	if err := wait.Poll(defaultInterval, defaultTimeout, func() (bool, error) {
 		cmds := []string{"antctl", "get", "agentinfo", "-o", "json"}
@@ -0,0 +1,152 @@
+// Copyright 2021 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package e2e
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+	restclient "k8s.io/client-go/rest"
+
+	crdv1alpha1 "antrea.io/antrea/pkg/apis/crd/v1alpha1"
+	statsv1alpha1 "antrea.io/antrea/pkg/apis/stats/v1alpha1"
+	crdclientset "antrea.io/antrea/pkg/client/clientset/versioned"
+)
+
+// TestAntreaNetworkPolicyStatsAuthorization tests that a user only allowed to read the stats of the Antrea
+// NetworkPolicies of a Namespace can neither get, list nor watch the stats of the policies of other Namespaces.
+func TestAntreaNetworkPolicyStatsAuthorization(t *testing.T) {
+	skipIfHasWindowsNodes(t)
+
+	data, err := setupTest(t)
+	if err != nil {
+		t.Fatalf("Error when setting up test: %v", err)
+	}
+	defer teardownTest(t, data)
+	skipIfNetworkPolicyStatsDisabled(t, data)
+	skipIfAntreaPolicyDisabled(t, data)
+
+	const (
+		otherNamespace = "antrea-test-stats-other"
+		policyName     = "np-stats"
+		userName       = "antrea-e2e-stats-reader"
+	)
+	require.NoError(t, data.createNamespace(otherNamespace))
+	defer data.deleteNamespace(otherNamespace, defaultTimeout)
+
+	// The same-named policies of both Namespaces must have distinct stats.
+	p10 := float64(10)
+	for _, ns := range []string{testNamespace, otherNamespace} {
+		anp := &crdv1alpha1.NetworkPolicy{
+			ObjectMeta: metav1.ObjectMeta{Namespace: ns, Name: policyName},
+			Spec: crdv1alpha1.NetworkPolicySpec{
+				AppliedTo: []crdv1alpha1.NetworkPolicyPeer{{PodSelector: &metav1.LabelSelector{}}},
+				Priority:  p10,
+			},
+		}
+		_, err := data.crdClient.CrdV1alpha1().NetworkPolicies(ns).Create(context.TODO(), anp, metav1.CreateOptions{})
+		require.NoError(t, err)
+		defer data.crdClient.CrdV1alpha1().NetworkPolicies(ns).Delete(context.TODO(), policyName, metav1.DeleteOptions{})
+	}
+
+	role := &rbacv1.Role{
+		ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: userName},
+		Rules: []rbacv1.PolicyRule{{
+			APIGroups: []string{statsv1alpha1.SchemeGroupVersion.Group},
+			Resources: []string{"antreanetworkpolicystats"},
+			Verbs:     []string{"get", "list", "watch"},
+		}},
+	}
+	_, err = data.clientset.RbacV1().Roles(testNamespace).Create(context.TODO(), role, metav1.CreateOptions{})
+	require.NoError(t, err)
+	roleBinding := &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: userName},
+		Subjects:   []rbacv1.Subject{{Kind: rbacv1.UserKind, APIGroup: rbacv1.GroupName, Name: userName}},
+		RoleRef:    rbacv1.RoleRef{Kind: "Role", APIGroup: rbacv1.GroupName, Name: userName},
+	}
+	_, err = data.clientset.RbacV1().RoleBindings(testNamespace).Create(context.TODO(), roleBinding, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	restrictedConfig := restclient.CopyConfig(data.kubeConfig)
+	restrictedConfig.Impersonate = restclient.ImpersonationConfig{UserName: userName}
+	restrictedClient, err := crdclientset.NewForConfig(restrictedConfig)
+	require.NoError(t, err)
+	stats := restrictedClient.StatsV1alpha1()
+
+	// Wait for the stats of the policy to be created and for the permissions to be effective.
+	var ownStats *statsv1alpha1.AntreaNetworkPolicyStats
+	err = wait.Poll(time.Second, defaultTimeout, func() (bool, error) {
+		ownStats, err = stats.AntreaNetworkPolicyStats(testNamespace).Get(context.TODO(), policyName, metav1.GetOptions{})
+		if err != nil {
+			t.Logf("Cannot get AntreaNetworkPolicyStats %s/%s yet: %v", testNamespace, policyName, err)
+			return false, nil
+		}
+		return true, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, testNamespace, ownStats.Namespace)
+
+	list, err := stats.AntreaNetworkPolicyStats(testNamespace).List(context.TODO(), metav1.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, list.Items, 1)
+	assert.Equal(t, testNamespace, list.Items[0].Namespace)
+	assert.Equal(t, ownStats.UID, list.Items[0].UID)
+
+	w, err := stats.AntreaNetworkPolicyStats(testNamespace).Watch(context.TODO(), metav1.ListOptions{})
+	require.NoError(t, err)
+	defer w.Stop()
+	select {
+	case e := <-w.ResultChan():
+		require.Equal(t, watch.Added, e.Type)
+		assert.Equal(t, ownStats.UID, e.Object.(*statsv1alpha1.AntreaNetworkPolicyStats).UID)
+	case <-time.After(10 * time.Second):
+		t.Fatal("Timed out waiting for the stats to be watched")
+	}
+
+	// The stats of the other Namespace, and the stats of all Namespaces, are not visible.
+	for name, fn := range map[string]func() error{
+		"get other Namespace": func() error {
+			_, err := stats.AntreaNetworkPolicyStats(otherNamespace).Get(context.TODO(), policyName, metav1.GetOptions{})
+			return err
+		},
+		"list other Namespace": func() error {
+			_, err := stats.AntreaNetworkPolicyStats(otherNamespace).List(context.TODO(), metav1.ListOptions{})
+			return err
+		},
+		"list all Namespaces": func() error {
+			_, err := stats.AntreaNetworkPolicyStats(metav1.NamespaceAll).List(context.TODO(), metav1.ListOptions{})
+			return err
+		},
+		"watch other Namespace": func() error {
+			w, err := stats.AntreaNetworkPolicyStats(otherNamespace).Watch(context.TODO(), metav1.ListOptions{})
+			if err == nil {
+				w.Stop()
+			}
+			return err
+		},
+	} {
+		err := fn()
+		assert.True(t, errors.IsForbidden(err), fmt.Sprintf("%s: expected Forbidden error, got %v", name, err))
+	}
+}
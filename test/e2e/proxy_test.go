@@ -15,6 +15,7 @@
 package e2e
 
 import (
+	"context"
 	"encoding/hex"
 	"fmt"
 	"net"
@@ -23,8 +24,13 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/wait"
 
 	"antrea.io/antrea/pkg/features"
 )
@@ -148,6 +154,175 @@ func testProxyHairpin(ipFamily *corev1.IPFamily, data *TestData, t *testing.T) {
 	require.NoError(t, err, fmt.Sprintf("ipFamily: %v\nstdout: %s\nstderr: %s\n", *ipFamily, stdout, stderr))
 }
 
+// TestProxySCTPService tests that an SCTP Service is load-balanced to its backend, and that the
+// traffic is still allowed when a NetworkPolicy selecting the backend only allows the SCTP port.
+func TestProxySCTPService(t *testing.T) {
+	skipIfHasWindowsNodes(t)
+	skipIfNumNodesLessThan(t, 2)
+
+	data, err := setupTest(t)
+	if err != nil {
+		t.Fatalf("Error when setting up test: %v", err)
+	}
+	defer teardownTest(t, data)
+
+	skipIfProxyDisabled(t, data)
+
+	if len(clusterInfo.podV4NetworkCIDR) != 0 {
+		ipFamily := corev1.IPv4Protocol
+		testProxySCTPService(&ipFamily, data, t)
+	}
+	if len(clusterInfo.podV6NetworkCIDR) != 0 {
+		ipFamily := corev1.IPv6Protocol
+		testProxySCTPService(&ipFamily, data, t)
+	}
+}
+
+func testProxySCTPService(ipFamily *corev1.IPFamily, data *TestData, t *testing.T) {
+	const sctpPort = 5000
+	server := randName("sctp-server-")
+	client := randName("sctp-client-")
+	// agnhost netexec echoes the hostname of the Pod on the SCTP port.
+	cmd := []string{"/agnhost", "netexec", "--http-port=8080", fmt.Sprintf("--sctp-port=%d", sctpPort)}
+	err := data.createPodOnNode(server, nodeName(1), agnhostImage, cmd, nil, nil, []corev1.ContainerPort{{ContainerPort: sctpPort, Protocol: corev1.ProtocolSCTP}}, false, nil)
+	defer data.deletePodAndWait(defaultTimeout, server)
+	require.NoError(t, err)
+	require.NoError(t, data.podWaitForRunning(defaultTimeout, server, testNamespace))
+	require.NoError(t, data.createAgnhostPodOnNode(client, nodeName(0)))
+	defer data.deletePodAndWait(defaultTimeout, client)
+	require.NoError(t, data.podWaitForRunning(defaultTimeout, client, testNamespace))
+
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      server,
+			Namespace: testNamespace,
+			Labels:    map[string]string{"antrea-e2e": server},
+		},
+		Spec: corev1.ServiceSpec{
+			Ports: []corev1.ServicePort{{
+				Port:       80,
+				TargetPort: intstr.FromInt(sctpPort),
+				Protocol:   corev1.ProtocolSCTP,
+			}},
+			Type:       corev1.ServiceTypeClusterIP,
+			Selector:   map[string]string{"antrea-e2e": server},
+			IPFamilies: []corev1.IPFamily{*ipFamily},
+		},
+	}
+	svc, err := data.clientset.CoreV1().Services(testNamespace).Create(context.TODO(), service, metav1.CreateOptions{})
+	defer data.deleteServiceAndWait(defaultTimeout, server)
+	require.NoError(t, err)
+
+	// Hold on to make sure that the Service is realized.
+	time.Sleep(3 * time.Second)
+
+	connect := func() {
+		connectCmd := []string{"/agnhost", "connect", net.JoinHostPort(svc.Spec.ClusterIP, "80"), "--protocol=sctp", "--timeout=5s"}
+		stdout, stderr, err := data.runCommandFromPod(testNamespace, client, agnhostContainerName, connectCmd)
+		require.NoError(t, err, fmt.Sprintf("ipFamily: %v\nstdout: %s\nstderr: %s\n", *ipFamily, stdout, stderr))
+	}
+	connect()
+
+	sctpProtocol := corev1.ProtocolSCTP
+	port := intstr.FromInt(sctpPort)
+	np, err := data.createNetworkPolicy(randName("allow-sctp-"), &networkingv1.NetworkPolicySpec{
+		PodSelector: metav1.LabelSelector{MatchLabels: map[string]string{"antrea-e2e": server}},
+		PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+		Ingress: []networkingv1.NetworkPolicyIngressRule{{
+			Ports: []networkingv1.NetworkPolicyPort{{Protocol: &sctpProtocol, Port: &port}},
+		}},
+	})
+	require.NoError(t, err)
+	defer data.deleteNetworkpolicy(np)
+	// Hold on to make sure that the NetworkPolicy is realized.
+	time.Sleep(3 * time.Second)
+	connect()
+}
+
+// TestProxyHairpinDeployment tests that the single Pod of a Deployment can access its own Service,
+// in which case the traffic is load-balanced back to the Pod.
+func TestProxyHairpinDeployment(t *testing.T) {
+	skipIfHasWindowsNodes(t)
+
+	data, err := setupTest(t)
+	if err != nil {
+		t.Fatalf("Error when setting up test: %v", err)
+	}
+	defer teardownTest(t, data)
+
+	skipIfProxyDisabled(t, data)
+
+	if len(clusterInfo.podV4NetworkCIDR) != 0 {
+		ipFamily := corev1.IPv4Protocol
+		testProxyHairpinDeployment(&ipFamily, data, t)
+	}
+	if len(clusterInfo.podV6NetworkCIDR) != 0 {
+		ipFamily := corev1.IPv6Protocol
+		testProxyHairpinDeployment(&ipFamily, data, t)
+	}
+}
+
+func testProxyHairpinDeployment(ipFamily *corev1.IPFamily, data *TestData, t *testing.T) {
+	name := fmt.Sprintf("hairpin-nginx-%s", strings.ToLower(string(*ipFamily)))
+	replicas := int32(1)
+	podLabels := map[string]string{"antrea-e2e": name, "app": name}
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: testNamespace,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: podLabels},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: podLabels},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{
+						Name:            nginxContainerName,
+						Image:           nginxImage,
+						ImagePullPolicy: corev1.PullIfNotPresent,
+						Ports:           []corev1.ContainerPort{{ContainerPort: 80, Protocol: corev1.ProtocolTCP}},
+					}},
+					NodeSelector: map[string]string{"kubernetes.io/hostname": nodeName(1)},
+				},
+			},
+		},
+	}
+	_, err := data.clientset.AppsV1().Deployments(testNamespace).Create(context.TODO(), deployment, metav1.CreateOptions{})
+	require.NoError(t, err)
+	defer data.clientset.AppsV1().Deployments(testNamespace).Delete(context.TODO(), name, metav1.DeleteOptions{})
+
+	var podName string
+	err = wait.Poll(defaultInterval, defaultTimeout, func() (bool, error) {
+		pods, err := data.clientset.CoreV1().Pods(testNamespace).List(context.TODO(), metav1.ListOptions{
+			LabelSelector: labels.SelectorFromSet(podLabels).String(),
+		})
+		if err != nil {
+			return false, err
+		}
+		for _, pod := range pods.Items {
+			if pod.Status.Phase == corev1.PodRunning && pod.DeletionTimestamp == nil {
+				podName = pod.Name
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+	require.NoError(t, err, "Deployment Pod didn't become Running")
+
+	svc, err := data.createService(name, 80, 80, podLabels, false, corev1.ServiceTypeClusterIP, ipFamily)
+	defer data.deleteServiceAndWait(defaultTimeout, name)
+	require.NoError(t, err)
+
+	// Hold on to make sure that the Service is realized.
+	time.Sleep(3 * time.Second)
+
+	url := fmt.Sprintf("http://%s", net.JoinHostPort(svc.Spec.ClusterIP, "80"))
+	stdout, stderr, err := data.runCommandFromPod(testNamespace, podName, nginxContainerName, []string{"curl", "--connect-timeout", "1", "--retry", "3", "-s", "-o", "/dev/null", "-w", "%{http_code}", url})
+	require.NoError(t, err, fmt.Sprintf("ipFamily: %v\nstdout: %s\nstderr: %s\n", *ipFamily, stdout, stderr))
+	require.Equal(t, "200", stdout, "Pod should be able to access its own Service")
+}
+
 func TestProxyEndpointLifeCycle(t *testing.T) {
 	skipIfHasWindowsNodes(t)
 
@@ -317,3 +492,80 @@ func testProxyServiceLifeCycle(ipFamily *corev1.IPFamily, ingressIPs []string, d
 		}
 	}
 }
+
+func TestProxyLoadBalancerSourceRanges(t *testing.T) {
+	skipIfHasWindowsNodes(t)
+
+	data, err := setupTest(t)
+	if err != nil {
+		t.Fatalf("Error when setting up test: %v", err)
+	}
+	defer teardownTest(t, data)
+
+	skipIfProxyDisabled(t, data)
+
+	if len(clusterInfo.podV4NetworkCIDR) != 0 {
+		ipFamily := corev1.IPv4Protocol
+		testProxyLoadBalancerSourceRanges(&ipFamily, "169.254.169.253", data, t)
+	}
+	if len(clusterInfo.podV6NetworkCIDR) != 0 {
+		ipFamily := corev1.IPv6Protocol
+		testProxyLoadBalancerSourceRanges(&ipFamily, "fd75::aabb:ccdd:ef00", data, t)
+	}
+}
+
+func testProxyLoadBalancerSourceRanges(ipFamily *corev1.IPFamily, ingressIP string, data *TestData, t *testing.T) {
+	nodeName := nodeName(0)
+	nginx := "nginx"
+	require.NoError(t, data.createNginxPodOnNode(nginx, nodeName))
+	defer data.deletePodAndWait(defaultTimeout, nginx)
+	require.NoError(t, data.podWaitForRunning(defaultTimeout, nginx, testNamespace))
+	svc, err := data.createNginxLoadBalancerService(false, []string{ingressIP}, ipFamily)
+	defer data.deleteServiceAndWait(defaultTimeout, nginxLBService)
+	require.NoError(t, err)
+
+	clientIPs := map[string]string{}
+	for _, client := range []string{"busybox-in-range", "busybox-out-of-range"} {
+		require.NoError(t, data.createBusyboxPodOnNode(client, nodeName))
+		defer data.deletePodAndWait(defaultTimeout, client)
+		podIPs, err := data.podWaitForIPs(defaultTimeout, client, testNamespace)
+		require.NoError(t, err)
+		if *ipFamily == corev1.IPv6Protocol {
+			clientIPs[client] = podIPs.ipv6.String() + "/128"
+		} else {
+			clientIPs[client] = podIPs.ipv4.String() + "/32"
+		}
+	}
+
+	canConnect := func(client string) bool {
+		_, _, err := data.runCommandFromPod(testNamespace, client, busyboxContainerName, []string{"wget", "-O", "-", net.JoinHostPort(ingressIP, "80"), "-T", "1"})
+		return err == nil
+	}
+	setSourceRanges := func(sourceRanges ...string) {
+		svc, err = data.clientset.CoreV1().Services(testNamespace).Get(context.TODO(), svc.Name, metav1.GetOptions{})
+		require.NoError(t, err)
+		svc.Spec.LoadBalancerSourceRanges = sourceRanges
+		svc, err = data.clientset.CoreV1().Services(testNamespace).Update(context.TODO(), svc, metav1.UpdateOptions{})
+		require.NoError(t, err)
+	}
+	waitForConnectivity := func(client string, expected bool) {
+		err := wait.PollImmediate(time.Second, defaultTimeout, func() (bool, error) {
+			return canConnect(client) == expected, nil
+		})
+		require.NoError(t, err, "Connectivity of client %s to LoadBalancer IP %s did not become %t", client, ingressIP, expected)
+	}
+
+	// Without source ranges, all the clients can connect.
+	waitForConnectivity("busybox-in-range", true)
+	waitForConnectivity("busybox-out-of-range", true)
+
+	// The out-of-range client is blocked once the source ranges are realized.
+	setSourceRanges(clientIPs["busybox-in-range"])
+	waitForConnectivity("busybox-out-of-range", false)
+	require.True(t, canConnect("busybox-in-range"), "Client in the source ranges cannot connect to LoadBalancer IP %s", ingressIP)
+
+	// The out-of-range client can connect again once its IP is added to the source ranges.
+	setSourceRanges(clientIPs["busybox-in-range"], clientIPs["busybox-out-of-range"])
+	waitForConnectivity("busybox-out-of-range", true)
+	require.True(t, canConnect("busybox-in-range"), "Client in the source ranges cannot connect to LoadBalancer IP %s", ingressIP)
+}
@@ -15,14 +15,20 @@
 package e2e
 
 import (
+	"context"
 	"fmt"
 	"net"
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	agenttypes "antrea.io/antrea/pkg/agent/types"
 )
 
 // TestClusterIP tests traffic from Nodes and Pods to ClusterIP Service.
@@ -170,3 +176,56 @@ func (data *TestData) createAgnhostServiceAndBackendPods(t *testing.T, name stri
 
 	return svc, cleanup
 }
+
+// TestLoadBalancerExternalSNATDisabledWindows tests that the client IP is preserved for the traffic from outside the
+// cluster to a LoadBalancer Service on a Windows Node, when the Service disables the SNAT of external traffic with the
+// "service.antrea.io/disable-external-snat" annotation.
+func TestLoadBalancerExternalSNATDisabledWindows(t *testing.T) {
+	skipIfNoWindowsNodes(t)
+
+	data, err := setupTest(t)
+	if err != nil {
+		t.Fatalf("Error when setting up test: %v", err)
+	}
+	defer teardownTest(t, data)
+
+	svcName := "agnhost"
+	svcNode := nodeName(clusterInfo.windowsNodes[0])
+	svc, cleanup := data.createAgnhostServiceAndBackendPods(t, svcName, svcNode, corev1.ServiceTypeLoadBalancer)
+	defer cleanup()
+
+	// Use the IP of the Windows Node as the ingress IP of the Service, so that the requests from the Linux Node are
+	// received from the uplink of the Windows Node, like the requests from an external load balancer.
+	ingressIP := clusterInfo.nodes[clusterInfo.windowsNodes[0]].ip
+	updatedSvc := svc.DeepCopy()
+	updatedSvc.Annotations = map[string]string{agenttypes.ServiceExternalSNATDisabledAnnotationKey: "true"}
+	updatedSvc.Spec.ExternalTrafficPolicy = corev1.ServiceExternalTrafficPolicyTypeCluster
+	svc, err = data.clientset.CoreV1().Services(testNamespace).Update(context.TODO(), updatedSvc, metav1.UpdateOptions{})
+	require.NoError(t, err)
+	updatedSvc = svc.DeepCopy()
+	updatedSvc.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{IP: ingressIP}}
+	_, err = data.clientset.CoreV1().Services(testNamespace).UpdateStatus(context.TODO(), updatedSvc, metav1.UpdateOptions{})
+	require.NoError(t, err)
+	t.Logf("%s Service is ready", svcName)
+
+	clientNode := nodeName(0)
+	clientIP := clusterInfo.nodes[0].ip
+	cmd := fmt.Sprintf("curl --connect-timeout 1 --retry 5 --retry-connrefused http://%s/clientip", net.JoinHostPort(ingressIP, "80"))
+	var stdout, stderr string
+	var cmdErr error
+	if err := wait.Poll(time.Second, 10*time.Second, func() (bool, error) {
+		var rc int
+		rc, stdout, stderr, cmdErr = RunCommandOnNode(clientNode, cmd)
+		if rc != 0 || cmdErr != nil {
+			return false, nil
+		}
+		// The stdout is in this format: x.x.x.x:port
+		host, _, err := net.SplitHostPort(strings.TrimSpace(stdout))
+		if err != nil {
+			return false, nil
+		}
+		return host == clientIP, nil
+	}); err != nil {
+		t.Errorf("Endpoint of Service %s did not see the client IP %s, stdout: %s, stderr: %s, err: %v", svcName, clientIP, stdout, stderr, cmdErr)
+	}
+}
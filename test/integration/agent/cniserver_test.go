@@ -572,7 +572,9 @@ func newTester() *cmdAddDelTester {
 		testNodeConfig,
 		k8sFake.NewSimpleClientset(),
 		false,
+		cniserver.InterfaceDiscovery{},
 		nil,
+		0,
 		tester.networkReadyCh)
 	tester.server.Initialize(ovsServiceMock, ofServiceMock, ifaceStore, make(chan antreatypes.EntityReference, 100))
 	ctx := context.Background()
@@ -732,7 +734,9 @@ func setupChainTest(
 			testNodeConfig,
 			k8sFake.NewSimpleClientset(),
 			true,
+			cniserver.InterfaceDiscovery{},
 			routeMock,
+			0,
 			networkReadyCh)
 	} else {
 		server = inServer
@@ -107,6 +107,7 @@ func TestConnectionStoreAndFlowRecords(t *testing.T) {
 	connDumperMock := connectionstest.NewMockConnTrackDumper(ctrl)
 	ifStoreMock := interfacestoretest.NewMockInterfaceStore(ctrl)
 	npQuerier := queriertest.NewMockAgentNetworkPolicyInfoQuerier(ctrl)
+	ifStoreMock.EXPECT().AddDeleteHandler(mock.Any())
 	// TODO: Enhance the integration test by testing service.
 	conntrackConnStore := connections.NewConntrackConnectionStore(connDumperMock, flowrecords.NewFlowRecords(), ifStoreMock, true, false, nil, npQuerier, testPollInterval)
 	// Expect calls for connStore.poll and other callees
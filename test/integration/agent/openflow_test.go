@@ -605,7 +605,7 @@ func expectedProxyServiceGroupAndFlows(gid uint32, svc svcConfig, endpointList [
 	if stickyAge != 0 {
 		serviceLearnReg = 3
 	}
-	cookieAllocator := cookie.NewAllocator(roundInfo.RoundNum)
+	cookieAllocator := cookie.NewAllocator(roundInfo.RoundNum, roundInfo.Generation)
 	svcFlows := expectTableFlows{tableID: 41, flows: []*ofTestUtils.ExpectFlow{
 		{
 			MatchStr: fmt.Sprintf("priority=200,%s,reg4=0x10000/0x70000,nw_dst=%s,tp_dst=%d", string(svc.protocol), svc.ip.String(), svc.port),
@@ -941,7 +941,7 @@ func preparePodFlows(podIPs []net.IP, podMAC net.HardwareAddr, podOFPort uint32,
 			[]*ofTestUtils.ExpectFlow{
 				{
 					MatchStr: fmt.Sprintf("priority=190,in_port=%d", podOFPort),
-					ActStr:   "load:0x2->NXM_NX_REG0[0..15],goto_table:10",
+					ActStr:   "load:0x2->NXM_NX_REG0[0..15],goto_table:8",
 				},
 			},
 		},
@@ -1012,7 +1012,7 @@ func prepareGatewayFlows(gwIPs []net.IP, gwMAC net.HardwareAddr, vMAC net.Hardwa
 			[]*ofTestUtils.ExpectFlow{
 				{
 					MatchStr: fmt.Sprintf("priority=200,in_port=%d", config1.HostGatewayOFPort),
-					ActStr:   "load:0x1->NXM_NX_REG0[0..15],goto_table:10",
+					ActStr:   "load:0x1->NXM_NX_REG0[0..15],goto_table:8",
 				},
 			},
 		},
@@ -1200,6 +1200,10 @@ func prepareDefaultFlows(config *testConfig) []expectTableFlows {
 			uint8(0),
 			[]*ofTestUtils.ExpectFlow{{MatchStr: "priority=0", ActStr: "drop"}},
 		},
+		{
+			uint8(8),
+			[]*ofTestUtils.ExpectFlow{{MatchStr: "priority=0", ActStr: "goto_table:10"}},
+		},
 		{
 			uint8(10),
 			[]*ofTestUtils.ExpectFlow{{MatchStr: "priority=0", ActStr: "drop"}},
@@ -589,6 +589,76 @@ func TestRouteTablePolicyOnly(t *testing.T) {
 	_ = netlink.LinkDel(gwLink)
 }
 
+func TestAdvertiseServiceCIDR(t *testing.T) {
+	skipIfNotInContainer(t)
+
+	gwLink := createDummyGW(t)
+	defer netlink.LinkDel(gwLink)
+
+	routeClient, err := route.NewClient(serviceCIDR, &config.NetworkConfig{TrafficEncapMode: config.TrafficEncapModeNoEncap, AdvertiseServiceCIDR: true}, false)
+	require.NoError(t, err)
+	inited := make(chan struct{})
+	require.NoError(t, routeClient.Initialize(nodeConfig, func() {
+		close(inited)
+	}))
+	select {
+	case <-time.After(3 * time.Second):
+		t.Fatalf("Initialize didn't finish in time")
+	case <-inited:
+	}
+
+	expRoute := strings.Join(strings.Fields(
+		fmt.Sprintf("%s via %s dev %s onlink", serviceCIDR, config.VirtualServiceIPv4, gwName)), "")
+	output, err := ExecOutputTrim(fmt.Sprintf("ip route show table 0 exact %s", serviceCIDR))
+	assert.NoError(t, err)
+	assert.Equal(t, expRoute, output)
+
+	// The route of the Service CIDR must not be removed as an unknown route on the gateway.
+	require.NoError(t, routeClient.Reconcile([]string{podCIDR.String()}))
+	output, err = ExecOutputTrim(fmt.Sprintf("ip route show table 0 exact %s", serviceCIDR))
+	assert.NoError(t, err)
+	assert.Equal(t, expRoute, output)
+
+	// The traffic from the Node network to the Service CIDR must be masqueraded, so that the reply
+	// traffic from an Endpoint running on another Node goes back through this Node.
+	expectedRule := fmt.Sprintf(`-A ANTREA-POSTROUTING -d %s -o %s -m comment --comment "Antrea: masquerade external to ClusterIP packets" -m addrtype ! --src-type LOCAL -j MASQUERADE
+`, serviceCIDR, gwName)
+	// #nosec G204: ignore in test code
+	actualRule, err := exec.Command("bash", "-c", "iptables-save -t nat | grep -i ClusterIP").Output()
+	assert.NoError(t, err, "error executing iptables-save")
+	assert.Equal(t, expectedRule, string(actualRule))
+}
+
+func TestTCPMSSClamping(t *testing.T) {
+	skipIfNotInContainer(t)
+
+	gwLink := createDummyGW(t)
+	defer netlink.LinkDel(gwLink)
+
+	routeClient, err := route.NewClient(serviceCIDR, &config.NetworkConfig{TrafficEncapMode: config.TrafficEncapModeEncap, EnableTCPMSSClamping: true, TCPMSS: 1360}, false)
+	require.NoError(t, err)
+	inited := make(chan struct{})
+	require.NoError(t, routeClient.Initialize(nodeConfig, func() {
+		close(inited)
+	}))
+	select {
+	case <-time.After(3 * time.Second):
+		t.Fatalf("Initialize didn't finish in time")
+	case <-inited:
+	}
+
+	// The MSS of the SYN packets must be clamped before they are accepted by the forwarding rules.
+	expectedRules := fmt.Sprintf(`-A ANTREA-FORWARD -i %[1]s ! -o %[1]s -p tcp -m comment --comment "Antrea: clamp TCP MSS of Pod to external packets" -m tcp --tcp-flags SYN,RST SYN -j TCPMSS --set-mss 1360
+-A ANTREA-FORWARD ! -i %[1]s -o %[1]s -p tcp -m comment --comment "Antrea: clamp TCP MSS of external to Pod packets" -m tcp --tcp-flags SYN,RST SYN -j TCPMSS --set-mss 1360
+-A ANTREA-FORWARD -i %[1]s -m comment --comment "Antrea: accept packets from local Pods" -j ACCEPT
+-A ANTREA-FORWARD -o %[1]s -m comment --comment "Antrea: accept packets to local Pods" -j ACCEPT
+`, gwName)
+	// #nosec G204: ignore in test code
+	actualRules, err := exec.Command("bash", "-c", "iptables-save -t filter | grep -e '-A ANTREA-FORWARD'").Output()
+	assert.NoError(t, err, "error executing iptables-save")
+	assert.Equal(t, expectedRules, string(actualRules))
+}
+
 func TestIPv6RoutesAndNeighbors(t *testing.T) {
 	skipIfNotInContainer(t)
 	if !nettest.SupportsIPv6() {
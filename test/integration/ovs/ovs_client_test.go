@@ -238,7 +238,7 @@ func TestTunnelOptionCsum(t *testing.T) {
 			defer data.teardown(t)
 
 			name := "vxlan0"
-			_, err := data.br.CreateTunnelPortExt(name, ovsconfig.VXLANTunnel, ofPortRequest, testCase.initialCsum, "", "", "", nil)
+			_, err := data.br.CreateTunnelPortExt(name, ovsconfig.VXLANTunnel, ofPortRequest, testCase.initialCsum, 0, "", "", "", "", nil)
 			require.Nil(t, err, "Error when creating tunnel port")
 			options, err := data.br.GetInterfaceOptions(name)
 			require.Nil(t, err, "Error when getting interface options")
@@ -260,6 +260,24 @@ func TestTunnelOptionCsum(t *testing.T) {
 	}
 }
 
+func TestTunnelOptionDstPortAndTOS(t *testing.T) {
+	data := &testData{}
+	data.setup(t)
+	defer data.teardown(t)
+
+	name := "genev0"
+	_, err := data.br.CreateTunnelPortExt(name, ovsconfig.GeneveTunnel, ofPortRequest, false, 6082, "inherit", "", "", "", nil)
+	require.Nil(t, err, "Error when creating tunnel port")
+	options, err := data.br.GetInterfaceOptions(name)
+	require.Nil(t, err, "Error when getting interface options")
+	assert.Equal(t, "6082", options["dst_port"])
+	assert.Equal(t, "inherit", options["tos"])
+
+	_, _, _, _, dstPort, tos := ovsconfig.ParseTunnelInterfaceOptions(&ovsconfig.OVSPortData{Options: options})
+	assert.Equal(t, int32(6082), dstPort)
+	assert.Equal(t, "inherit", tos)
+}
+
 func deleteAllPorts(t *testing.T, br *ovsconfig.OVSBridge) {
 	portList, err := br.GetPortUUIDList()
 	require.Nil(t, err, "Error when retrieving port list")